@@ -10,8 +10,32 @@ import (
 	"go.signoz.io/signoz/ee/query-service/license"
 	"go.signoz.io/signoz/ee/query-service/usage"
 	baseapp "go.signoz.io/signoz/pkg/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/datadeletion"
+	"go.signoz.io/signoz/pkg/query-service/app/deliveryprofiles"
+	"go.signoz.io/signoz/pkg/query-service/app/filtersnippets"
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/app/ingestionkeys"
+	"go.signoz.io/signoz/pkg/query-service/app/insertsettings"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/kafkareceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/keyusage"
+	"go.signoz.io/signoz/pkg/query-service/app/loadtests"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logreceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/lookuptables"
+	"go.signoz.io/signoz/pkg/query-service/app/metricexports"
+	"go.signoz.io/signoz/pkg/query-service/app/metricowners"
+	"go.signoz.io/signoz/pkg/query-service/app/obfuscation"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/app/quotas"
+	"go.signoz.io/signoz/pkg/query-service/app/savedqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/scheduledqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/app/slackapp"
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/app/tracereceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
 	"go.signoz.io/signoz/pkg/query-service/cache"
 	baseint "go.signoz.io/signoz/pkg/query-service/interfaces"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
@@ -34,6 +58,30 @@ type APIHandlerOptions struct {
 	LicenseManager                *license.Manager
 	IntegrationsController        *integrations.Controller
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
+	LookupTablesController        *lookuptables.Controller
+	MetricOwnersController        *metricowners.Controller
+	LogExportsController          *logexports.Controller
+	KafkaReceiversController      *kafkareceivers.Controller
+	TraceReceiversController      *tracereceivers.Controller
+	LogReceiversController        *logreceivers.Controller
+	DeliveryProfilesController    *deliveryprofiles.Controller
+	InsertSettingsController      *insertsettings.Controller
+	KeyUsageController            *keyusage.Controller
+	IngestionKeysController       *ingestionkeys.Controller
+	FilterSnippetsController      *filtersnippets.Controller
+	QuotasController              *quotas.Controller
+	QueryLimitsController         *querylimits.Controller
+	ObfuscationController         *obfuscation.Controller
+	IncidentsController           *incidents.Controller
+	SlackAppController            *slackapp.Controller
+	ScheduledQueriesController    *scheduledqueries.Controller
+	SavedQueriesController        *savedqueries.Controller
+	DataDeletionController        *datadeletion.Controller
+	LoadTestsController           *loadtests.Controller
+	MetricExportsController       *metricexports.Controller
+	Trash                         *trash.Trash
+	Tagging                       *tagging.Tagging
+	Search                        *search.Search
 	Cache                         cache.Cache
 	// Querier Influx Interval
 	FluxInterval time.Duration
@@ -60,6 +108,30 @@ func NewAPIHandler(opts APIHandlerOptions) (*APIHandler, error) {
 		FeatureFlags:                  opts.FeatureFlags,
 		IntegrationsController:        opts.IntegrationsController,
 		LogsParsingPipelineController: opts.LogsParsingPipelineController,
+		LookupTablesController:        opts.LookupTablesController,
+		MetricOwnersController:        opts.MetricOwnersController,
+		LogExportsController:          opts.LogExportsController,
+		KafkaReceiversController:      opts.KafkaReceiversController,
+		TraceReceiversController:      opts.TraceReceiversController,
+		LogReceiversController:        opts.LogReceiversController,
+		DeliveryProfilesController:    opts.DeliveryProfilesController,
+		InsertSettingsController:      opts.InsertSettingsController,
+		KeyUsageController:            opts.KeyUsageController,
+		IngestionKeysController:       opts.IngestionKeysController,
+		FilterSnippetsController:      opts.FilterSnippetsController,
+		QuotasController:              opts.QuotasController,
+		QueryLimitsController:         opts.QueryLimitsController,
+		ObfuscationController:         opts.ObfuscationController,
+		IncidentsController:           opts.IncidentsController,
+		SlackAppController:            opts.SlackAppController,
+		ScheduledQueriesController:    opts.ScheduledQueriesController,
+		SavedQueriesController:        opts.SavedQueriesController,
+		DataDeletionController:        opts.DataDeletionController,
+		LoadTestsController:           opts.LoadTestsController,
+		MetricExportsController:       opts.MetricExportsController,
+		Trash:                         opts.Trash,
+		Tagging:                       opts.Tagging,
+		Search:                        opts.Search,
 		Cache:                         opts.Cache,
 		FluxInterval:                  opts.FluxInterval,
 	})
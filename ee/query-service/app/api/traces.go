@@ -24,6 +24,11 @@ func (ah *APIHandler) searchTraces(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
 		return
 	}
+	selection, err := baseapp.ParseSpanFieldSelection(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
+		return
+	}
 	spanLimit, err := strconv.Atoi(constants.SpanLimitStr)
 	if err != nil {
 		zap.S().Error("Error during strconv.Atoi() on SPAN_LIMIT env variable: ", err)
@@ -33,6 +38,9 @@ func (ah *APIHandler) searchTraces(w http.ResponseWriter, r *http.Request) {
 	if ah.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	for i := range *result {
+		(*result)[i].Project(selection)
+	}
 
 	ah.WriteJSON(w, r, result)
 
@@ -34,14 +34,41 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	baseapp "go.signoz.io/signoz/pkg/query-service/app"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/datadeletion"
+	"go.signoz.io/signoz/pkg/query-service/app/deliveryprofiles"
 	baseexplorer "go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/filtersnippets"
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/app/ingestionkeys"
+	"go.signoz.io/signoz/pkg/query-service/app/insertsettings"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/kafkareceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/keyusage"
+	"go.signoz.io/signoz/pkg/query-service/app/loadtests"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logreceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/lookuptables"
+	"go.signoz.io/signoz/pkg/query-service/app/metricexports"
+	"go.signoz.io/signoz/pkg/query-service/app/metricowners"
+	"go.signoz.io/signoz/pkg/query-service/app/obfuscation"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/app/querier"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/app/quotas"
+	"go.signoz.io/signoz/pkg/query-service/app/savedqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/scheduledqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/app/slackapp"
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/app/tracereceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
 	"go.signoz.io/signoz/pkg/query-service/cache"
 	baseconst "go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/healthcheck"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	basealm "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	baseint "go.signoz.io/signoz/pkg/query-service/interfaces"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
@@ -95,6 +122,15 @@ type Server struct {
 
 	opampServer *opamp.Server
 
+	pipelineWatchdog *logparsingpipeline.Watchdog
+	staleAgents      *opAmpModel.StaleAgentWatcher
+	configDrift      *opAmpModel.ConfigDriftWatcher
+	rollouts         *agentConf.RolloutWatcher
+
+	scheduledQueries *scheduledqueries.Controller
+	metricExports    *metricexports.Controller
+	trash            *trash.Trash
+
 	unavailableChannel chan healthcheck.Status
 }
 
@@ -111,6 +147,10 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	if err := baseauth.InitSigningKeys(context.Background()); err != nil {
+		return nil, err
+	}
+
 	baseexplorer.InitWithDSN(baseconst.RELATIONAL_DATASOURCE_PATH)
 
 	localDB, err := dashboards.InitDB(baseconst.RELATIONAL_DATASOURCE_PATH)
@@ -158,6 +198,35 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		}
 	}
 
+	// named filters builder queries of panels and alerts can reference
+	filterSnippetsController, err := filtersnippets.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create filter snippets controller: %w", err,
+		)
+	}
+
+	quotasController, err := quotas.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create org quotas controller: %w", err,
+		)
+	}
+
+	queryLimitsController, err := querylimits.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create query limits controller: %w", err,
+		)
+	}
+
+	obfuscationController, err := obfuscation.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create obfuscation profiles controller: %w", err,
+		)
+	}
+
 	<-readerReady
 	rm, err := makeRulesManager(serverOptions.PromConfigPath,
 		baseconst.GetAlertManagerApiPrefix(),
@@ -165,7 +234,8 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		localDB,
 		reader,
 		serverOptions.DisableRules,
-		lm)
+		lm,
+		filterSnippetsController)
 
 	if err != nil {
 		return nil, err
@@ -190,11 +260,117 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	// lookup tables for ingest time enrichment
+	lookupTablesController, err := lookuptables.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create lookup tables controller: %w", err,
+		)
+	}
+
+	metricOwnersController, err := metricowners.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create metric owners controller: %w", err,
+		)
+	}
+
+	// secondary log export destinations, e.g. SIEMs
+	logExportsController, err := logexports.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create log exports controller: %w", err,
+		)
+	}
+
+	// kafka topics to ingest telemetry from
+	kafkaReceiversController, err := kafkareceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create kafka receivers controller: %w", err,
+		)
+	}
+
+	// jaeger and zipkin receivers for apps not sending OTLP
+	traceReceiversController, err := tracereceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create trace receivers controller: %w", err,
+		)
+	}
+
+	// files, syslog listeners and journald units agents collect logs from
+	logReceiversController, err := logreceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create log receivers controller: %w", err,
+		)
+	}
+
+	// sending queue and retry settings of the agents' exporters
+	deliveryProfilesController, err := deliveryprofiles.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create delivery profiles controller: %w", err,
+		)
+	}
+
+	// async insert and batch settings the agents write to clickhouse with
+	insertSettingsController, err := insertsettings.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create clickhouse insert settings controller: %w", err,
+		)
+	}
+
+	// usage of attribute keys in queries, ranking autocomplete suggestions
+	keyUsageController, err := keyusage.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create attribute key usage controller: %w", err,
+		)
+	}
+
+	// keys shippers send telemetry with
+	ingestionKeysController, err := ingestionkeys.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create ingestion keys controller: %w", err,
+		)
+	}
+
+	// incidents grouping related alerts
+	incidentsController, err := incidents.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create incidents controller: %w", err,
+		)
+	}
+	rm.AddAlertListener(incidentsController.OnAlerts)
+
+	slackAppController, err := slackapp.NewController(incidentsController)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create slack app controller: %w", err,
+		)
+	}
+	rm.AddAlertListener(slackAppController.OnAlerts)
+
 	// initiate agent config handler
 	agentConfMgr, err := agentConf.Initiate(&agentConf.ManagerOptions{
-		DB:            localDB,
-		DBEngine:      AppDbEngine,
-		AgentFeatures: []agentConf.AgentFeature{logParsingPipelineController},
+		DB:       localDB,
+		DBEngine: AppDbEngine,
+		AgentFeatures: []agentConf.AgentFeature{
+			logParsingPipelineController,
+			lookupTablesController,
+			metricOwnersController,
+			logExportsController,
+			kafkaReceiversController,
+			traceReceiversController,
+			logReceiversController,
+			deliveryProfilesController,
+			insertSettingsController,
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -228,6 +404,75 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	// queries run on a schedule with their results persisted
+	scheduledQueriesController, err := scheduledqueries.NewController(
+		localDB,
+		reader,
+		querier.NewQuerier(querier.QuerierOptions{
+			Reader:        reader,
+			Cache:         c,
+			KeyGenerator:  queryBuilder.NewKeyGenerator(),
+			FluxInterval:  fluxInterval,
+			FeatureLookup: lm,
+		}),
+		baseapp.PrepareQueryRangeParams,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create scheduled queries controller: %w", err,
+		)
+	}
+
+	savedQueriesController, err := savedqueries.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create saved queries controller: %w", err,
+		)
+	}
+
+	dataDeletionController, err := datadeletion.NewController(localDB, reader, keyUsageController)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create data deletion controller: %w", err,
+		)
+	}
+
+	loadTestsController, err := loadtests.NewController(localDB, reader)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create load tests controller: %w", err,
+		)
+	}
+
+	metricExportsController, err := metricexports.NewController(localDB, reader)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create metric exports controller: %w", err,
+		)
+	}
+
+	// deleted resources are kept restorable until purged
+	trashController := trash.NewTrash(map[trash.ResourceType]trash.Store{
+		trash.ResourceDashboards: &dashboards.TrashStore{FeatureFlags: lm},
+		trash.ResourceRules:      rm,
+		trash.ResourcePipelines:  logParsingPipelineController,
+	})
+
+	taggingController := tagging.NewTagging(map[tagging.ResourceType]tagging.Store{
+		tagging.ResourceDashboards: &dashboards.TagStore{FeatureFlags: lm},
+		tagging.ResourceRules:      rm,
+		tagging.ResourceSavedViews: &baseexplorer.TagStore{},
+	})
+
+	searchController := search.NewSearch(map[search.ResourceType]search.Source{
+		search.ResourceDashboards: &dashboards.SearchSource{},
+		search.ResourceRules:      rm,
+		search.ResourceSavedViews: &baseexplorer.SearchSource{},
+		search.ResourcePipelines:  logParsingPipelineController,
+		search.ResourceServices:   &search.ServicesSource{Reader: reader},
+		search.ResourceChannels:   &search.ChannelsSource{Reader: reader},
+	})
+
 	apiOpts := api.APIHandlerOptions{
 		DataConnector:                 reader,
 		SkipConfig:                    skipConfig,
@@ -243,6 +488,30 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		LicenseManager:                lm,
 		IntegrationsController:        integrationsController,
 		LogsParsingPipelineController: logParsingPipelineController,
+		LookupTablesController:        lookupTablesController,
+		MetricOwnersController:        metricOwnersController,
+		LogExportsController:          logExportsController,
+		KafkaReceiversController:      kafkaReceiversController,
+		TraceReceiversController:      traceReceiversController,
+		LogReceiversController:        logReceiversController,
+		DeliveryProfilesController:    deliveryProfilesController,
+		InsertSettingsController:      insertSettingsController,
+		KeyUsageController:            keyUsageController,
+		IngestionKeysController:       ingestionKeysController,
+		FilterSnippetsController:      filterSnippetsController,
+		QuotasController:              quotasController,
+		QueryLimitsController:         queryLimitsController,
+		ObfuscationController:         obfuscationController,
+		IncidentsController:           incidentsController,
+		SlackAppController:            slackAppController,
+		ScheduledQueriesController:    scheduledQueriesController,
+		SavedQueriesController:        savedQueriesController,
+		DataDeletionController:        dataDeletionController,
+		LoadTestsController:           loadTestsController,
+		MetricExportsController:       metricExportsController,
+		Trash:                         trashController,
+		Tagging:                       taggingController,
+		Search:                        searchController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 	}
@@ -256,6 +525,9 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		// logger: logger,
 		// tracer: tracer,
 		ruleManager:        rm,
+		scheduledQueries:   scheduledQueriesController,
+		metricExports:      metricExportsController,
+		trash:              trashController,
 		serverOptions:      serverOptions,
 		unavailableChannel: make(chan healthcheck.Status),
 		usageManager:       usageManager,
@@ -276,10 +548,33 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 
 	s.privateHTTP = privateServer
 
+	opampAuth, err := opamp.AuthSettingsFromEnv()
+	if err != nil {
+		return nil, err
+	}
 	s.opampServer = opamp.InitializeServer(
-		&opAmpModel.AllAgents, agentConfMgr,
+		&opAmpModel.AllAgents, agentConfMgr, opampAuth,
 	)
 
+	// pauses log pipelines that lose logs after being deployed
+	s.pipelineWatchdog = baseapp.NewLogPipelineWatchdog(
+		logParsingPipelineController,
+		querier.NewQuerier(querier.QuerierOptions{
+			Reader:        reader,
+			Cache:         c,
+			KeyGenerator:  queryBuilder.NewKeyGenerator(),
+			FluxInterval:  fluxInterval,
+			FeatureLookup: lm,
+		}),
+		rm,
+	)
+	// alerts on the agents that stopped reporting
+	s.staleAgents = baseapp.NewStaleAgentWatcher(rm)
+	// alerts on the agents running another config than the recommended one
+	s.configDrift = baseapp.NewConfigDriftWatcher(rm)
+	// notifies about the rollouts of agent config versions
+	s.rollouts = baseapp.NewRolloutWatcher(rm)
+
 	return s, nil
 }
 
@@ -287,6 +582,7 @@ func (s *Server) createPrivateServer(apiHandler *api.APIHandler) (*http.Server,
 
 	r := mux.NewRouter()
 
+	r.Use(instrumentation.Middleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddlewarePrivate)
@@ -319,16 +615,40 @@ func (s *Server) createPublicServer(apiHandler *api.APIHandler) (*http.Server, e
 	}
 	am := baseapp.NewAuthMiddleware(getUserFromRequest)
 
+	r.Use(instrumentation.Middleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddleware)
+	r.Use(apiHandler.LookbackLimitMiddleware(am))
 
 	apiHandler.RegisterRoutes(r, am)
 	apiHandler.RegisterMetricsRoutes(r, am)
 	apiHandler.RegisterLogsRoutes(r, am)
 	apiHandler.RegisterIntegrationRoutes(r, am)
+	apiHandler.RegisterLookupTableRoutes(r, am)
+	apiHandler.RegisterMetricOwnerRoutes(r, am)
+	apiHandler.RegisterLogExportRoutes(r, am)
+	apiHandler.RegisterKafkaRoutes(r, am)
+	apiHandler.RegisterTraceReceiversRoutes(r, am)
+	apiHandler.RegisterLogReceiversRoutes(r, am)
+	apiHandler.RegisterIngestionKeyRoutes(r, am)
+	apiHandler.RegisterFilterSnippetRoutes(r, am)
+	apiHandler.RegisterQuotaRoutes(r, am)
+	apiHandler.RegisterQueryLimitRoutes(r, am)
+	apiHandler.RegisterObfuscationProfileRoutes(r, am)
+	apiHandler.RegisterScheduledQueryRoutes(r, am)
+	apiHandler.RegisterSavedQueryRoutes(r, am)
+	apiHandler.RegisterDataDeletionRoutes(r, am)
+	apiHandler.RegisterLoadTestRoutes(r, am)
+	apiHandler.RegisterMetricExportRoutes(r, am)
+	apiHandler.RegisterTrashRoutes(r, am)
+	apiHandler.RegisterTagRoutes(r, am)
+	apiHandler.RegisterSearchRoutes(r, am)
+	apiHandler.RegisterAgentConfigRoutes(r, am)
+	apiHandler.RegisterIncidentRoutes(r, am)
 	apiHandler.RegisterQueryRangeV3Routes(r, am)
 	apiHandler.RegisterQueryRangeV4Routes(r, am)
+	apiHandler.RegisterCapabilityRoutes(r, am)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
@@ -597,6 +917,14 @@ func (s *Server) Start() error {
 
 	}()
 
+	s.pipelineWatchdog.Start()
+	s.staleAgents.Start()
+	s.configDrift.Start()
+	s.rollouts.Start()
+	s.scheduledQueries.Start()
+	s.metricExports.Start()
+	s.trash.Start()
+
 	go func() {
 		zap.S().Info("Starting OpAmp Websocket server", zap.String("addr", baseconst.OpAmpWsEndpoint))
 		err := s.opampServer.Start(baseconst.OpAmpWsEndpoint)
@@ -624,6 +952,34 @@ func (s *Server) Stop() error {
 
 	s.opampServer.Stop()
 
+	if s.pipelineWatchdog != nil {
+		s.pipelineWatchdog.Stop()
+	}
+
+	if s.staleAgents != nil {
+		s.staleAgents.Stop()
+	}
+
+	if s.configDrift != nil {
+		s.configDrift.Stop()
+	}
+
+	if s.rollouts != nil {
+		s.rollouts.Stop()
+	}
+
+	if s.scheduledQueries != nil {
+		s.scheduledQueries.Stop()
+	}
+
+	if s.metricExports != nil {
+		s.metricExports.Stop()
+	}
+
+	if s.trash != nil {
+		s.trash.Stop()
+	}
+
 	if s.ruleManager != nil {
 		s.ruleManager.Stop()
 	}
@@ -641,7 +997,8 @@ func makeRulesManager(
 	db *sqlx.DB,
 	ch baseint.Reader,
 	disableRules bool,
-	fm baseInterface.FeatureLookup) (*rules.Manager, error) {
+	fm baseInterface.FeatureLookup,
+	filterSnippets *filtersnippets.Controller) (*rules.Manager, error) {
 
 	// create engine
 	pqle, err := pqle.FromConfigPath(promConfigPath)
@@ -669,6 +1026,8 @@ func makeRulesManager(
 		Logger:       nil,
 		DisableRules: disableRules,
 		FeatureFlags: fm,
+
+		ExpandFilterSnippets: filterSnippets.ExpandFilterSnippets,
 	}
 
 	// create Manager
@@ -1,6 +1,11 @@
 package agentConf
 
-import "go.signoz.io/signoz/pkg/query-service/model"
+import (
+	"context"
+
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
 
 // Interface for features implemented via agent config.
 // Eg: ingestion side signal pre-processing features like log processing pipelines etc
@@ -23,3 +28,29 @@ type AgentFeature interface {
 		apiErr *model.ApiError,
 	)
 }
+
+// AgentScopedFeature is implemented by features whose settings can target a
+// subset of agents, so that agents get recommended different config for them
+type AgentScopedFeature interface {
+	AgentFeature
+
+	// Recommend config for the `agent` based on its `currentConfYaml` and
+	// `configVersion` for the feature's settings
+	RecommendAgentConfigForAgent(
+		agent opampModel.AgentInfo,
+		currentConfYaml []byte,
+		configVersion *ConfigVersion,
+	) (
+		recommendedConfYaml []byte,
+		serializedSettingsUsed string,
+		apiErr *model.ApiError,
+	)
+}
+
+// AgentGroupReferrer is implemented by features whose settings can target
+// agent groups defined on the server, so that targeted groups aren't deleted
+type AgentGroupReferrer interface {
+	// AgentGroupReferences returns the names of the settings targeting the
+	// agent group with the given name
+	AgentGroupReferences(ctx context.Context, group string) ([]string, *model.ApiError)
+}
@@ -51,7 +51,8 @@ func (r *Repo) GetConfigHistory(
 		deploy_status, 
 		deploy_result,
 		coalesce(last_hash, '') as last_hash,
-		coalesce(last_config, '{}') as last_config
+		coalesce(last_config, '{}') as last_config,
+		coalesce(change_note, '') as change_note
 		FROM agent_config_versions AS v
 		WHERE element_type = $1
 		ORDER BY created_at desc, version desc
@@ -69,6 +70,10 @@ func (r *Repo) GetConfigHistory(
 		}
 	}
 
+	if apiErr := r.withDeployReports(ctx, typ, c); apiErr != nil {
+		return nil, apiErr
+	}
+
 	return c, nil
 }
 
@@ -90,7 +95,8 @@ func (r *Repo) GetConfigVersion(
 		deploy_status, 
 		deploy_result,
 		coalesce(last_hash, '') as last_hash,
-		coalesce(last_config, '{}') as last_config
+		coalesce(last_config, '{}') as last_config,
+		coalesce(change_note, '') as change_note
 		FROM agent_config_versions v 
 		WHERE element_type = $1 
 		AND version = $2`, typ, v)
@@ -121,7 +127,8 @@ func (r *Repo) GetLatestVersion(
 		is_valid, 
 		disabled, 
 		deploy_status, 
-		deploy_result 
+		deploy_result,
+		coalesce(change_note, '') as change_note
 		FROM agent_config_versions AS v
 		WHERE element_type = $1 
 		AND version = ( 
@@ -139,8 +146,11 @@ func (r *Repo) GetLatestVersion(
 	return &c, nil
 }
 
+// insertConfig inserts a new config version with its elements, beforeCommit
+// if set writes what goes along with the version in the same transaction
 func (r *Repo) insertConfig(
 	ctx context.Context, userId string, c *ConfigVersion, elements []string,
+	beforeCommit func(tx *sqlx.Tx) *model.ApiError,
 ) (fnerr *model.ApiError) {
 
 	if string(c.ElementType) == "" {
@@ -149,8 +159,7 @@ func (r *Repo) insertConfig(
 		))
 	}
 
-	// allowing empty elements for logs - use case is deleting all pipelines
-	if len(elements) == 0 && c.ElementType != ElementTypeLogPipelines {
+	if len(elements) == 0 && !allowsNoElements(c.ElementType) {
 		zap.S().Error("insert config called with no elements ", c.ElementType)
 		return model.BadRequest(fmt.Errorf("config must have atleast one element"))
 	}
@@ -178,11 +187,15 @@ func (r *Repo) insertConfig(
 		c.Version = 1
 	}
 
+	// the version and its elements are written in one transaction so that
+	// a version is never left half written
+	tx, dbErr := r.db.BeginTxx(ctx, nil)
+	if dbErr != nil {
+		return model.InternalError(errors.Wrap(dbErr, "failed to start transaction"))
+	}
 	defer func() {
 		if fnerr != nil {
-			// remove all the damage (invalid rows from db)
-			r.db.Exec("DELETE FROM agent_config_versions WHERE id = $1", c.ID)
-			r.db.Exec("DELETE FROM agent_config_elements WHERE version_id=$1", c.ID)
+			tx.Rollback()
 		}
 	}()
 
@@ -196,10 +209,11 @@ func (r *Repo) insertConfig(
 		is_valid, 
 		disabled,
 		deploy_status, 
-		deploy_result) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		deploy_result,
+		change_note) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, dbErr := r.db.ExecContext(ctx,
+	_, dbErr = tx.ExecContext(ctx,
 		configQuery,
 		c.ID,
 		c.Version,
@@ -209,7 +223,8 @@ func (r *Repo) insertConfig(
 		false,
 		false,
 		c.DeployStatus,
-		c.DeployResult)
+		c.DeployResult,
+		c.ChangeNote)
 
 	if dbErr != nil {
 		zap.S().Error("error in inserting config version: ", zap.Error(dbErr))
@@ -224,7 +239,7 @@ func (r *Repo) insertConfig(
 	VALUES ($1, $2, $3, $4)`
 
 	for _, e := range elements {
-		_, dbErr = r.db.ExecContext(
+		_, dbErr = tx.ExecContext(
 			ctx,
 			elementsQuery,
 			uuid.NewString(),
@@ -237,6 +252,78 @@ func (r *Repo) insertConfig(
 		}
 	}
 
+	if beforeCommit != nil {
+		if apiErr := beforeCommit(tx); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	if dbErr = tx.Commit(); dbErr != nil {
+		zap.S().Error("error in committing config version: ", zap.Error(dbErr))
+		return model.InternalError(errors.Wrap(dbErr, "failed to commit config version"))
+	}
+	return nil
+}
+
+// allowsNoElements tells if versions of an element type can have no
+// elements, the use case being deleting all of them
+func allowsNoElements(typ ElementTypeDef) bool {
+	switch typ {
+	case ElementTypeLogPipelines, ElementTypeLookupTables, ElementTypeLogExports,
+		ElementTypeKafkaReceivers, ElementTypeTraceReceivers, ElementTypeMetricOwners,
+		ElementTypeDeliveryProfiles, ElementTypeLogReceivers:
+		return true
+	}
+	return false
+}
+
+// repairConfigVersions removes what versions written before they were
+// written in a transaction may have left half written: elements of missing
+// versions, and versions without elements of element types which can't
+// have none
+func (r *Repo) repairConfigVersions(ctx context.Context) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM agent_config_elements
+		WHERE version_id NOT IN (SELECT id FROM agent_config_versions)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove elements of missing config versions")
+	}
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		zap.L().Warn("removed elements of missing agent config versions", zap.Int64("elements", removed))
+	}
+
+	empty := []struct {
+		Id          string         `db:"id"`
+		ElementType ElementTypeDef `db:"element_type"`
+		Version     int            `db:"version"`
+	}{}
+	err = tx.SelectContext(ctx, &empty, `SELECT id, element_type, version FROM agent_config_versions
+		WHERE id NOT IN (SELECT version_id FROM agent_config_elements)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config versions without elements")
+	}
+	for _, v := range empty {
+		if allowsNoElements(v.ElementType) {
+			continue
+		}
+		zap.L().Warn(
+			"removing half written agent config version",
+			zap.String("elementType", string(v.ElementType)),
+			zap.Int("version", v.Version),
+		)
+		if _, err := tx.ExecContext(ctx, "DELETE FROM agent_config_versions WHERE id = $1", v.Id); err != nil {
+			return errors.Wrap(err, "failed to remove half written config version")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit config version repair")
+	}
 	return nil
 }
 
@@ -272,7 +359,7 @@ func (r *Repo) updateDeployStatusByHash(
 	updateQuery := `UPDATE agent_config_versions
 	set deploy_status = $1, 
 	deploy_result = $2
-	WHERE last_hash=$4`
+	WHERE last_hash=$3`
 
 	_, err := r.db.ExecContext(ctx, updateQuery, status, result, confighash)
 	if err != nil {
@@ -282,3 +369,148 @@ func (r *Repo) updateDeployStatusByHash(
 
 	return nil
 }
+
+func (r *Repo) getAgentGroupTemplates(ctx context.Context) ([]AgentGroupTemplate, *model.ApiError) {
+	groupTemplates := []AgentGroupTemplate{}
+	err := r.db.SelectContext(ctx, &groupTemplates, `SELECT
+		agent_group,
+		template_name,
+		COALESCE(updated_by, '') as updated_by,
+		updated_at
+		FROM agent_group_config_templates
+		ORDER BY agent_group`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent group templates"))
+	}
+	return groupTemplates, nil
+}
+
+func (r *Repo) upsertAgentGroupTemplate(ctx context.Context, groupTemplate AgentGroupTemplate) *model.ApiError {
+	_, err := r.db.NamedExecContext(ctx, `INSERT INTO agent_group_config_templates (
+		agent_group,
+		template_name,
+		updated_by,
+		updated_at
+	) VALUES (
+		:agent_group,
+		:template_name,
+		:updated_by,
+		:updated_at
+	) ON CONFLICT(agent_group) DO UPDATE SET
+		template_name = excluded.template_name,
+		updated_by = excluded.updated_by,
+		updated_at = excluded.updated_at`, groupTemplate)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to save agent group template"))
+	}
+	return nil
+}
+
+func (r *Repo) deleteAgentGroupTemplate(ctx context.Context, agentGroup string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM agent_group_config_templates WHERE agent_group = $1`, agentGroup)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to delete agent group template"))
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return model.NotFoundError(fmt.Errorf("no template is assigned to agent group %s", agentGroup))
+	}
+	return nil
+}
+
+func (r *Repo) getAgentConfigOverrides(ctx context.Context) ([]AgentConfigOverride, *model.ApiError) {
+	overrides := []AgentConfigOverride{}
+	err := r.db.SelectContext(ctx, &overrides, `SELECT
+		id,
+		name,
+		match_json,
+		agent_groups_json,
+		priority,
+		config,
+		COALESCE(updated_by, '') as updated_by,
+		updated_at
+		FROM agent_config_overrides
+		ORDER BY priority, name`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent config overrides"))
+	}
+	return overrides, nil
+}
+
+func (r *Repo) getAgentConfigOverride(ctx context.Context, id string) (*AgentConfigOverride, *model.ApiError) {
+	var override AgentConfigOverride
+	err := r.db.GetContext(ctx, &override, `SELECT
+		id,
+		name,
+		match_json,
+		agent_groups_json,
+		priority,
+		config,
+		COALESCE(updated_by, '') as updated_by,
+		updated_at
+		FROM agent_config_overrides
+		WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("agent config override %s not found", id))
+	}
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent config override"))
+	}
+	return &override, nil
+}
+
+func (r *Repo) upsertAgentConfigOverride(ctx context.Context, override *AgentConfigOverride) *model.ApiError {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT count(*) FROM agent_config_overrides
+		WHERE name = $1 AND id != $2`, override.Name, override.Id)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to check agent config override names"))
+	}
+	if count > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("an agent config override named %s already exists", override.Name),
+		}
+	}
+
+	_, err = r.db.NamedExecContext(ctx, `INSERT INTO agent_config_overrides (
+		id,
+		name,
+		match_json,
+		agent_groups_json,
+		priority,
+		config,
+		updated_by,
+		updated_at
+	) VALUES (
+		:id,
+		:name,
+		:match_json,
+		:agent_groups_json,
+		:priority,
+		:config,
+		:updated_by,
+		:updated_at
+	) ON CONFLICT(id) DO UPDATE SET
+		name = excluded.name,
+		match_json = excluded.match_json,
+		agent_groups_json = excluded.agent_groups_json,
+		priority = excluded.priority,
+		config = excluded.config,
+		updated_by = excluded.updated_by,
+		updated_at = excluded.updated_at`, override)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to save agent config override"))
+	}
+	return nil
+}
+
+func (r *Repo) deleteAgentConfigOverride(ctx context.Context, id string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM agent_config_overrides WHERE id = $1`, id)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to delete agent config override"))
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return model.NotFoundError(fmt.Errorf("agent config override %s not found", id))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf/sqlite"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestInsertConfigIsAtomic(t *testing.T) {
+	require := require.New(t)
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	failing := func(tx *sqlx.Tx) *model.ApiError {
+		return model.InternalError(fmt.Errorf("failed"))
+	}
+	apiErr := repo.insertConfig(ctx, "user", NewConfigversion(ElementTypeLogPipelines), []string{"p1", "p2"}, failing)
+	require.NotNil(apiErr)
+
+	var versions, elements int
+	require.Nil(repo.db.Get(&versions, "SELECT count(*) FROM agent_config_versions"))
+	require.Nil(repo.db.Get(&elements, "SELECT count(*) FROM agent_config_elements"))
+	require.Zero(versions, "a failed version is rolled back")
+	require.Zero(elements)
+
+	require.Nil(repo.insertConfig(ctx, "user", NewConfigversion(ElementTypeLogPipelines), []string{"p1"}, nil))
+	latest, apiErr := repo.GetLatestVersion(ctx, ElementTypeLogPipelines)
+	require.Nil(apiErr)
+	require.Equal(1, latest.Version)
+}
+
+func TestRepairConfigVersions(t *testing.T) {
+	require := require.New(t)
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	require.Nil(repo.insertConfig(ctx, "user", NewConfigversion(ElementTypeSamplingRules), []string{"rule"}, nil))
+	require.Nil(repo.insertConfig(ctx, "user", NewConfigversion(ElementTypeLogPipelines), []string{}, nil))
+
+	// versions half written before they were written in a transaction
+	for _, q := range []string{
+		`INSERT INTO agent_config_versions(id, version, created_by, element_type, active, is_valid, disabled, deploy_status, deploy_result)
+		VALUES ('half', 2, 'user', 'sampling_rules', false, false, false, 'DIRTY', '')`,
+		`INSERT INTO agent_config_elements(id, version_id, element_type, element_id)
+		VALUES ('orphan', 'missing', 'sampling_rules', 'rule')`,
+	} {
+		_, err := repo.db.Exec(q)
+		require.Nil(err)
+	}
+	latest, apiErr := repo.GetLatestVersion(ctx, ElementTypeSamplingRules)
+	require.Nil(apiErr)
+	require.Equal(2, latest.Version)
+
+	require.Nil(repo.repairConfigVersions(ctx))
+
+	latest, apiErr = repo.GetLatestVersion(ctx, ElementTypeSamplingRules)
+	require.Nil(apiErr)
+	require.Equal(1, latest.Version, "the half written version is removed")
+	latest, apiErr = repo.GetLatestVersion(ctx, ElementTypeLogPipelines)
+	require.Nil(apiErr)
+	require.Equal(1, latest.Version, "versions which can have no elements are kept")
+	var orphans int
+	require.Nil(repo.db.Get(&orphans, "SELECT count(*) FROM agent_config_elements WHERE version_id = 'missing'"))
+	require.Zero(orphans)
+}
+
+func newTestRepo(t *testing.T) *Repo {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(t, err)
+	require.Nil(t, sqlite.InitDB(db))
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (id TEXT PRIMARY KEY, name TEXT, email TEXT)`)
+	require.Nil(t, err)
+	return &Repo{db}
+}
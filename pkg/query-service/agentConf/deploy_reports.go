@@ -0,0 +1,171 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// deployReportVersions is for how many of the latest versions of a feature
+// the reports of the agents are kept
+const deployReportVersions = 50
+
+// AgentDeployReport is what an agent reported about applying a config
+// version, with the error message of the agent if it failed to
+type AgentDeployReport struct {
+	ElementType  ElementTypeDef `json:"elementType" db:"element_type"`
+	Version      int            `json:"version" db:"version"`
+	AgentId      string         `json:"agentId" db:"agent_id"`
+	Status       DeployStatus   `json:"status" db:"status"`
+	ErrorMessage string         `json:"errorMessage,omitempty" db:"error_message"`
+	ReportedAt   time.Time      `json:"reportedAt" db:"reported_at"`
+}
+
+// parseFeatureConfId parses the element type and version of a feature
+// config id, telling if it is one. Config ids also include the templates
+// and overrides used.
+func (m *Manager) parseFeatureConfId(featureConfId string) (ElementTypeDef, int, bool) {
+	idx := strings.LastIndex(featureConfId, ":")
+	if idx < 0 {
+		return "", 0, false
+	}
+	elementType := ElementTypeDef(featureConfId[:idx])
+	version, err := strconv.Atoi(featureConfId[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	for _, feature := range m.agentFeatures {
+		if ElementTypeDef(feature.AgentFeatureType()) == elementType {
+			return elementType, version, true
+		}
+	}
+	return "", 0, false
+}
+
+// saveDeployReport persists what an agent reported about a feature config
+// id, replacing what it reported before about the same version
+func (m *Manager) saveDeployReport(ctx context.Context, featureConfId string, agentId string, err error) {
+	elementType, version, ok := m.parseFeatureConfId(featureConfId)
+	if !ok {
+		return
+	}
+
+	report := AgentDeployReport{
+		ElementType: elementType,
+		Version:     version,
+		AgentId:     agentId,
+		Status:      Deployed,
+		ReportedAt:  time.Now(),
+	}
+	if err != nil {
+		report.Status = DeployFailed
+		report.ErrorMessage = err.Error()
+	}
+	if apiErr := m.upsertDeployReport(ctx, &report); apiErr != nil {
+		zap.L().Error("could not save agent config deploy report", zap.Error(apiErr.ToError()))
+	}
+}
+
+func (r *Repo) upsertDeployReport(ctx context.Context, report *AgentDeployReport) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO agent_config_deploy_reports (
+			element_type, version, agent_id, status, error_message, reported_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(element_type, version, agent_id) DO UPDATE SET
+			status = excluded.status,
+			error_message = excluded.error_message,
+			reported_at = excluded.reported_at
+	`,
+		report.ElementType, report.Version, report.AgentId,
+		report.Status, report.ErrorMessage, report.ReportedAt,
+	)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to save agent config deploy report"))
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM agent_config_deploy_reports
+		WHERE element_type = $1 AND version <= $2
+	`, report.ElementType, report.Version-deployReportVersions)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to prune agent config deploy reports"))
+	}
+	return nil
+}
+
+// getDeployReports returns the reports of the agents about the versions of
+// a feature, by version
+func (r *Repo) getDeployReports(
+	ctx context.Context, elementType ElementTypeDef, minVersion int,
+) (map[int][]AgentDeployReport, *model.ApiError) {
+	reports := []AgentDeployReport{}
+	err := r.db.SelectContext(ctx, &reports, `
+		SELECT element_type, version, agent_id, status, error_message, reported_at
+		FROM agent_config_deploy_reports
+		WHERE element_type = $1 AND version >= $2
+		ORDER BY version DESC, agent_id
+	`, elementType, minVersion)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent config deploy reports"))
+	}
+
+	byVersion := map[int][]AgentDeployReport{}
+	for _, report := range reports {
+		byVersion[report.Version] = append(byVersion[report.Version], report)
+	}
+	return byVersion, nil
+}
+
+// withDeployReports adds what the agents reported to the versions of a
+// feature
+func (r *Repo) withDeployReports(
+	ctx context.Context, elementType ElementTypeDef, versions []ConfigVersion,
+) *model.ApiError {
+	if len(versions) == 0 {
+		return nil
+	}
+	minVersion := versions[0].Version
+	for _, v := range versions {
+		if v.Version < minVersion {
+			minVersion = v.Version
+		}
+	}
+
+	reports, apiErr := r.getDeployReports(ctx, elementType, minVersion)
+	if apiErr != nil {
+		return apiErr
+	}
+	for i := range versions {
+		versions[i].AgentReports = reports[versions[i].Version]
+		if versions[i].AgentReports == nil {
+			versions[i].AgentReports = []AgentDeployReport{}
+		}
+	}
+	return nil
+}
+
+// GetAgentDeployReports returns what an agent reported about the config
+// versions it was recommended, the latest first
+func GetAgentDeployReports(
+	ctx context.Context, agentId string, limit int,
+) ([]AgentDeployReport, *model.ApiError) {
+	reports := []AgentDeployReport{}
+	err := m.db.SelectContext(ctx, &reports, fmt.Sprintf(`
+		SELECT element_type, version, agent_id, status, error_message, reported_at
+		FROM agent_config_deploy_reports
+		WHERE agent_id = $1
+		ORDER BY reported_at DESC
+		LIMIT %d
+	`, limit), agentId)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent config deploy reports"))
+	}
+	return reports, nil
+}
@@ -0,0 +1,70 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf/sqlite"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type testFeature struct{}
+
+func (testFeature) AgentFeatureType() AgentFeatureType {
+	return AgentFeatureType(ElementTypeLogPipelines)
+}
+
+func (testFeature) RecommendAgentConfig(
+	currentConfYaml []byte, configVersion *ConfigVersion,
+) ([]byte, string, *model.ApiError) {
+	return currentConfYaml, "", nil
+}
+
+func TestPersistedDeployReports(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	require.Nil(sqlite.InitDB(db))
+
+	mgr := &Manager{Repo: Repo{db}, agentFeatures: []AgentFeature{testFeature{}}}
+	ctx := context.Background()
+
+	mgr.saveDeployReport(ctx, "log_pipelines:2", "agent-1", nil)
+	mgr.saveDeployReport(ctx, "log_pipelines:2", "agent-2", fmt.Errorf("unknown processor"))
+	mgr.saveDeployReport(ctx, "log_pipelines:1", "agent-2", nil)
+	mgr.saveDeployReport(ctx, "template:default", "agent-1", nil)
+	mgr.saveDeployReport(ctx, "override:a0c1:1700000000", "agent-1", nil)
+
+	versions := []ConfigVersion{{Version: 2}, {Version: 1}}
+	require.Nil(mgr.withDeployReports(ctx, ElementTypeLogPipelines, versions))
+	require.Len(versions[0].AgentReports, 2)
+	require.Equal("agent-1", versions[0].AgentReports[0].AgentId)
+	require.Equal(Deployed, versions[0].AgentReports[0].Status)
+	require.Equal(DeployFailed, versions[0].AgentReports[1].Status)
+	require.Equal("unknown processor", versions[0].AgentReports[1].ErrorMessage)
+	require.Len(versions[1].AgentReports, 1)
+
+	// a later report of the agent about the same version replaces the
+	// earlier one
+	mgr.saveDeployReport(ctx, "log_pipelines:2", "agent-2", nil)
+	require.Nil(mgr.withDeployReports(ctx, ElementTypeLogPipelines, versions))
+	require.Equal(Deployed, versions[0].AgentReports[1].Status)
+	require.Empty(versions[0].AgentReports[1].ErrorMessage)
+
+	// the reports of versions long superseded are dropped
+	mgr.saveDeployReport(ctx, fmt.Sprintf("log_pipelines:%d", 1+deployReportVersions), "agent-1", nil)
+	reports, apiErr := mgr.getDeployReports(ctx, ElementTypeLogPipelines, 0)
+	require.Nil(apiErr)
+	require.Empty(reports[1])
+	require.Len(reports[2], 2)
+}
@@ -0,0 +1,406 @@
+package agentConf
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	tsp "go.signoz.io/signoz/pkg/query-service/app/opamp/otelconfig/tailsampler"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+var agentGroupNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// AgentGroup is a set of agents defined on the server, either statically by
+// their instance ids or by a selector on the attributes they report over
+// OpAMP. Config overrides and pipelines target groups by name, so groups
+// can't be renamed.
+type AgentGroup struct {
+	Id          string        `json:"id" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Description string        `json:"description" db:"description"`
+	Members     StringList    `json:"members" db:"members_json"`
+	Selector    OverrideMatch `json:"selector" db:"selector_json"`
+	UpdatedBy   string        `json:"updatedBy" db:"updated_by"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// contains tells if the agent is a member of the group
+func (g *AgentGroup) contains(agent opampModel.AgentInfo) bool {
+	if len(g.Members) > 0 {
+		return slices.Contains(g.Members, agent.ID)
+	}
+	return len(g.Selector) > 0 && g.Selector.matches(agent)
+}
+
+// StringList is a list of strings stored as JSON
+type StringList []string
+
+func (l *StringList) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	serialized, err := json.Marshal(l)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize string list to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableAgentGroup struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Members     StringList    `json:"members"`
+	Selector    OverrideMatch `json:"selector"`
+}
+
+func (p *PostableAgentGroup) IsValid() error {
+	if !agentGroupNameRegex.MatchString(p.Name) {
+		return fmt.Errorf(
+			"agent group name must start with a letter or digit and only contain letters, digits, '_', '.' and '-'",
+		)
+	}
+	if (len(p.Members) == 0) == (len(p.Selector) == 0) {
+		return fmt.Errorf("agent group must have either members or a selector")
+	}
+	for _, member := range p.Members {
+		if strings.TrimSpace(member) == "" {
+			return fmt.Errorf("agent group members can't be empty")
+		}
+	}
+	for key := range p.Selector {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("selected agent attributes can't be empty")
+		}
+	}
+	return nil
+}
+
+func ListAgentGroups(ctx context.Context) ([]AgentGroup, *model.ApiError) {
+	return m.getAgentGroups(ctx)
+}
+
+func GetAgentGroup(ctx context.Context, id string) (*AgentGroup, *model.ApiError) {
+	return m.getAgentGroup(ctx, id)
+}
+
+// CreateAgentGroup stores a group and rolls out the config of the agents
+// targeted through it
+func CreateAgentGroup(
+	ctx context.Context, postable *PostableAgentGroup,
+) (*AgentGroup, *model.ApiError) {
+	group := &AgentGroup{Id: uuid.NewString()}
+	return saveAgentGroup(ctx, group, postable)
+}
+
+func UpdateAgentGroup(
+	ctx context.Context, id string, postable *PostableAgentGroup,
+) (*AgentGroup, *model.ApiError) {
+	group, apiErr := m.getAgentGroup(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if postable.Name != group.Name {
+		return nil, model.BadRequest(fmt.Errorf(
+			"agent groups can't be renamed as they are targeted by name",
+		))
+	}
+	return saveAgentGroup(ctx, group, postable)
+}
+
+func saveAgentGroup(
+	ctx context.Context, group *AgentGroup, postable *PostableAgentGroup,
+) (*AgentGroup, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	group.Name = postable.Name
+	group.Description = postable.Description
+	group.Members = postable.Members
+	group.Selector = postable.Selector
+	if group.Members == nil {
+		group.Members = StringList{}
+	}
+	if group.Selector == nil {
+		group.Selector = OverrideMatch{}
+	}
+	group.UpdatedBy = ""
+	if user := common.GetUserFromContext(ctx); user != nil {
+		group.UpdatedBy = user.Email
+	}
+	group.UpdatedAt = time.Now()
+
+	if apiErr := m.upsertAgentGroup(ctx, group); apiErr != nil {
+		return nil, apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return group, nil
+}
+
+// DeleteAgentGroup deletes a group no config override, rollout, template,
+// sampling policy or agent feature targets
+func DeleteAgentGroup(ctx context.Context, id string) *model.ApiError {
+	group, apiErr := m.getAgentGroup(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	referrer, apiErr := m.agentGroupReferrer(ctx, group.Name)
+	if apiErr != nil {
+		return apiErr
+	}
+	if referrer != "" {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("agent group %s is targeted by %s", group.Name, referrer),
+		}
+	}
+
+	if apiErr := m.deleteAgentGroup(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// agentGroupReferrer describes the first setting found targeting the agent
+// group with the given name, it is empty if none does
+func (m *Manager) agentGroupReferrer(ctx context.Context, name string) (string, *model.ApiError) {
+	serverGroup := opampModel.ServerAgentGroupPrefix + name
+
+	overrides, apiErr := m.getAgentConfigOverrides(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	for _, o := range overrides {
+		if slices.Contains(o.AgentGroups, name) {
+			return fmt.Sprintf("the config override %s", o.Name), nil
+		}
+	}
+
+	strategy, err := getRolloutStrategy(ctx, m.db)
+	if err != nil {
+		return "", model.InternalError(err)
+	}
+	if strategy.CanaryGroup == serverGroup {
+		return "the rollout strategy", nil
+	}
+
+	groupTemplates, apiErr := m.getAgentGroupTemplates(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	for _, t := range groupTemplates {
+		if t.AgentGroup == serverGroup {
+			return fmt.Sprintf("the config template %s", t.TemplateName), nil
+		}
+	}
+
+	samplingConfig, apiErr := m.getLatestSamplingConfig(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if samplingConfig != nil {
+		for _, policy := range samplingConfig.PolicyCfgs {
+			if slices.Contains(policy.AgentGroups, name) {
+				return fmt.Sprintf("the sampling policy %s", policy.Name), nil
+			}
+		}
+	}
+
+	for _, feature := range m.agentFeatures {
+		referrer, ok := feature.(AgentGroupReferrer)
+		if !ok {
+			continue
+		}
+		names, apiErr := referrer.AgentGroupReferences(ctx, name)
+		if apiErr != nil {
+			return "", apiErr
+		}
+		if len(names) > 0 {
+			return fmt.Sprintf("%s of %s", names[0], feature.AgentFeatureType()), nil
+		}
+	}
+	return "", nil
+}
+
+// getLatestSamplingConfig returns the config of the latest sampling rules
+// version, it is nil if sampling rules have never been deployed
+func (m *Manager) getLatestSamplingConfig(ctx context.Context) (*tsp.Config, *model.ApiError) {
+	latest, apiErr := m.GetLatestVersion(ctx, ElementTypeSamplingRules)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return nil, nil
+		}
+		return nil, apiErr
+	}
+	// the latest version is read without its config
+	latest, apiErr = m.GetConfigVersion(ctx, ElementTypeSamplingRules, latest.Version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if latest.LastConf == "" {
+		return nil, nil
+	}
+
+	var config *tsp.Config
+	if err := yaml.Unmarshal([]byte(latest.LastConf), &config); err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to read the sampling rules config"))
+	}
+	return config, nil
+}
+
+// ensureAgentGroupExists fails if no agent group has the given name
+func (m *Manager) ensureAgentGroupExists(ctx context.Context, name string) *model.ApiError {
+	groups, apiErr := m.getAgentGroups(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return nil
+		}
+	}
+	return model.BadRequest(fmt.Errorf("unknown agent group %q", name))
+}
+
+// GetAgentGroupAgents returns the ids of the connected agents in a group
+func GetAgentGroupAgents(ctx context.Context, id string) ([]string, *model.ApiError) {
+	group, apiErr := m.getAgentGroup(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	agentIds := []string{}
+	for _, agent := range opampModel.AllAgents.GetAllAgentInfos() {
+		if group.contains(agent) {
+			agentIds = append(agentIds, agent.ID)
+		}
+	}
+	sort.Strings(agentIds)
+	return agentIds, nil
+}
+
+// agentGroupsOf returns the names of the groups the agent is a member of
+func (m *Manager) agentGroupsOf(
+	ctx context.Context, agent opampModel.AgentInfo,
+) ([]string, *model.ApiError) {
+	groups, apiErr := m.getAgentGroups(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	names := []string{}
+	for i := range groups {
+		if groups[i].contains(agent) {
+			names = append(names, groups[i].Name)
+		}
+	}
+	return names, nil
+}
+
+const agentGroupColumns = `id, name, description, members_json, selector_json,
+	COALESCE(updated_by, '') as updated_by, updated_at`
+
+func (r *Repo) getAgentGroups(ctx context.Context) ([]AgentGroup, *model.ApiError) {
+	groups := []AgentGroup{}
+	err := r.db.SelectContext(ctx, &groups, `SELECT `+agentGroupColumns+`
+		FROM agent_groups
+		ORDER BY name`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent groups"))
+	}
+	return groups, nil
+}
+
+func (r *Repo) getAgentGroup(ctx context.Context, id string) (*AgentGroup, *model.ApiError) {
+	var group AgentGroup
+	err := r.db.GetContext(ctx, &group, `SELECT `+agentGroupColumns+`
+		FROM agent_groups
+		WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("agent group %s not found", id))
+	}
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent group"))
+	}
+	return &group, nil
+}
+
+func (r *Repo) upsertAgentGroup(ctx context.Context, group *AgentGroup) *model.ApiError {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT count(*) FROM agent_groups
+		WHERE name = $1 AND id != $2`, group.Name, group.Id)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to check agent group names"))
+	}
+	if count > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("an agent group named %s already exists", group.Name),
+		}
+	}
+
+	_, err = r.db.NamedExecContext(ctx, `INSERT INTO agent_groups (
+		id,
+		name,
+		description,
+		members_json,
+		selector_json,
+		updated_by,
+		updated_at
+	) VALUES (
+		:id,
+		:name,
+		:description,
+		:members_json,
+		:selector_json,
+		:updated_by,
+		:updated_at
+	) ON CONFLICT(id) DO UPDATE SET
+		description = excluded.description,
+		members_json = excluded.members_json,
+		selector_json = excluded.selector_json,
+		updated_by = excluded.updated_by,
+		updated_at = excluded.updated_at`, group)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to save agent group"))
+	}
+	return nil
+}
+
+func (r *Repo) deleteAgentGroup(ctx context.Context, id string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM agent_groups WHERE id = $1`, id)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to delete agent group"))
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return model.NotFoundError(fmt.Errorf("agent group %s not found", id))
+	}
+	return nil
+}
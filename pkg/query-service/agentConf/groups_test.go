@@ -0,0 +1,144 @@
+package agentConf
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf/sqlite"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestAgentGroups(t *testing.T) {
+	require := require.New(t)
+
+	require.NotNil((&PostableAgentGroup{Name: "prod"}).IsValid(), "members or a selector is required")
+	require.NotNil((&PostableAgentGroup{
+		Name: "prod", Members: StringList{"agent-1"}, Selector: OverrideMatch{"env": "prod"},
+	}).IsValid(), "only one of members and selector can be set")
+	require.NotNil((&PostableAgentGroup{Name: "prod east", Members: StringList{"agent-1"}}).IsValid())
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	require.Nil(sqlite.InitDB(db))
+
+	mgr := &Manager{Repo: Repo{db}}
+	ctx := context.Background()
+
+	for _, group := range []AgentGroup{
+		{Id: "1", Name: "edge", Members: StringList{"agent-1", "agent-3"}, Selector: OverrideMatch{}},
+		{Id: "2", Name: "prod", Members: StringList{}, Selector: OverrideMatch{"deployment.environment": "prod"}},
+	} {
+		group := group
+		require.Nil(mgr.upsertAgentGroup(ctx, &group))
+	}
+	duplicate := AgentGroup{Id: "3", Name: "prod", Members: StringList{"agent-2"}}
+	require.NotNil(mgr.upsertAgentGroup(ctx, &duplicate))
+
+	agent := opampModel.AgentInfo{
+		ID:         "agent-1",
+		Attributes: map[string]string{"deployment.environment": "prod"},
+	}
+	groups, apiErr := mgr.agentGroupsOf(ctx, agent)
+	require.Nil(apiErr)
+	require.Equal([]string{"edge", "prod"}, groups)
+
+	groups, apiErr = mgr.agentGroupsOf(ctx, opampModel.AgentInfo{ID: "agent-2"})
+	require.Nil(apiErr)
+	require.Empty(groups)
+
+	agent.Groups = []string{"edge", "prod"}
+	override := AgentConfigOverride{AgentGroups: StringList{"staging", "edge"}}
+	require.True(override.matches(agent))
+	override.Match = OverrideMatch{"host.name": "edge-1"}
+	require.False(override.matches(agent), "the agent attributes must match too")
+	override = AgentConfigOverride{AgentGroups: StringList{"staging"}}
+	require.False(override.matches(agent))
+}
+
+type fakeGroupReferrer struct {
+	AgentFeature
+	references map[string][]string
+}
+
+func (f fakeGroupReferrer) AgentFeatureType() AgentFeatureType {
+	return "log_pipelines"
+}
+
+func (f fakeGroupReferrer) AgentGroupReferences(ctx context.Context, group string) ([]string, *model.ApiError) {
+	return f.references[group], nil
+}
+
+func TestAgentGroupReferrer(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	require.Nil(sqlite.InitDB(db))
+	// the versions are read along with the names of their creators
+	_, err = db.Exec(`CREATE TABLE users (id TEXT PRIMARY KEY, name TEXT)`)
+	require.Nil(err)
+
+	mgr := &Manager{Repo: Repo{db}, agentFeatures: []AgentFeature{
+		fakeGroupReferrer{references: map[string][]string{"pipelines": {"nginx"}}},
+	}}
+	m = mgr
+	ctx := context.Background()
+
+	referrer, apiErr := mgr.agentGroupReferrer(ctx, "edge")
+	require.Nil(apiErr)
+	require.Empty(referrer)
+
+	require.Nil(mgr.upsertAgentGroupTemplate(ctx, AgentGroupTemplate{
+		AgentGroup: "server:edge", TemplateName: "gateway", UpdatedAt: time.Now(),
+	}))
+	require.Nil(mgr.upsertAgentGroupTemplate(ctx, AgentGroupTemplate{
+		AgentGroup: "edge", TemplateName: "vm", UpdatedAt: time.Now(),
+	}))
+	referrer, apiErr = mgr.agentGroupReferrer(ctx, "edge")
+	require.Nil(apiErr)
+	require.Equal("the config template gateway", referrer)
+
+	version := NewConfigversion(ElementTypeSamplingRules)
+	require.Nil(mgr.insertConfig(ctx, "", version, []string{"sampling"}, nil))
+	require.Nil(mgr.updateDeployStatus(
+		ctx, ElementTypeSamplingRules, version.Version, string(DeployInitiated), "", "",
+		"policies:\n  - name: checkout\n    agent_groups: [prod]\n",
+	))
+	referrer, apiErr = mgr.agentGroupReferrer(ctx, "prod")
+	require.Nil(apiErr)
+	require.Equal("the sampling policy checkout", referrer)
+
+	referrer, apiErr = mgr.agentGroupReferrer(ctx, "pipelines")
+	require.Nil(apiErr)
+	require.Equal("nginx of log_pipelines", referrer)
+
+	require.Nil(SaveRolloutStrategy(ctx, "user", &RolloutStrategy{CanaryGroup: "server:canary"}))
+	referrer, apiErr = mgr.agentGroupReferrer(ctx, "canary")
+	require.Nil(apiErr)
+	require.Equal("the rollout strategy", referrer)
+
+	// the template of the group the agent reports wins over the ones of
+	// the agent groups defined on the server
+	template, apiErr := mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{Groups: []string{"edge"}})
+	require.Nil(apiErr)
+	require.Equal("gateway", template.Name)
+	template, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{Group: "edge", Groups: []string{"edge"}})
+	require.Nil(apiErr)
+	require.Equal("vm", template.Name)
+	template, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{Group: "prod"})
+	require.Nil(apiErr)
+	require.Nil(template)
+}
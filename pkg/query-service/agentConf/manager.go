@@ -12,6 +12,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
 	filterprocessor "go.signoz.io/signoz/pkg/query-service/app/opamp/otelconfig/filterprocessor"
 	tsp "go.signoz.io/signoz/pkg/query-service/app/opamp/otelconfig/tailsampler"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -70,6 +71,11 @@ func Initiate(options *ManagerOptions) (*Manager, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "could not init agentConf db")
 	}
+
+	err = m.repairConfigVersions(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not recover agent config versions")
+	}
 	return m, nil
 }
 
@@ -95,7 +101,7 @@ func (m *Manager) notifyConfigUpdateSubscribers() {
 }
 
 // Implements opamp.AgentConfigProvider
-func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
+func (m *Manager) RecommendAgentConfig(agent opampModel.AgentInfo, currentConfYaml []byte) (
 	recommendedConfYaml []byte,
 	// Opaque id of the recommended config, used for reporting deployment status updates
 	configId string,
@@ -104,6 +110,21 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 	recommendation := currentConfYaml
 	settingVersionsUsed := []string{}
 
+	groups, apiErr := m.agentGroupsOf(context.Background(), agent)
+	if apiErr != nil {
+		return nil, "", errors.Wrap(apiErr.ToError(), "failed to get the agent groups of the agent")
+	}
+	agent.Groups = groups
+
+	template, apiErr := m.baseConfigForAgent(context.Background(), agent)
+	if apiErr != nil {
+		return nil, "", errors.Wrap(apiErr.ToError(), "failed to get config template for agent group")
+	}
+	if template != nil {
+		recommendation = []byte(template.Config)
+		settingVersionsUsed = append(settingVersionsUsed, fmt.Sprintf("template:%s", template.Name))
+	}
+
 	for _, feature := range m.agentFeatures {
 		featureType := ElementTypeDef(feature.AgentFeatureType())
 		latestConfig, apiErr := GetLatestVersion(context.Background(), featureType)
@@ -115,18 +136,40 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 			continue
 		}
 
-		updatedConf, serializedSettingsUsed, apiErr := feature.RecommendAgentConfig(
-			recommendation, latestConfig,
-		)
+		configVersion, isCanary, apiErr := m.versionForAgent(context.Background(), agent, latestConfig)
+		if apiErr != nil {
+			return nil, "", errors.Wrap(apiErr.ToError(), "failed to get the config version to roll out")
+		}
+
+		var updatedConf []byte
+		var serializedSettingsUsed string
+		if scoped, ok := feature.(AgentScopedFeature); ok {
+			updatedConf, serializedSettingsUsed, apiErr = scoped.RecommendAgentConfigForAgent(
+				agent, recommendation, configVersion,
+			)
+		} else {
+			updatedConf, serializedSettingsUsed, apiErr = feature.RecommendAgentConfig(
+				recommendation, configVersion,
+			)
+		}
 		if apiErr != nil {
 			return nil, "", errors.Wrap(apiErr.ToError(), fmt.Sprintf(
 				"failed to generate agent config recommendation for %s", featureType,
 			))
 		}
 		recommendation = updatedConf
-		configId := fmt.Sprintf("%s:%d", featureType, latestConfig.Version)
+		configId := fmt.Sprintf("%s:%d", featureType, configVersion.Version)
 		settingVersionsUsed = append(settingVersionsUsed, configId)
 
+		// the deploy status is of the latest version, agents kept on the
+		// stable one during a staged rollout do not change it
+		if configVersion != latestConfig {
+			continue
+		}
+		if isCanary {
+			m.recordCanaryAgent(context.Background(), featureType, configVersion.Version, agent.ID)
+		}
+
 		m.updateDeployStatus(
 			context.Background(),
 			featureType,
@@ -139,6 +182,12 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 
 	}
 
+	recommendation, overridesUsed, apiErr := m.applyOverrides(context.Background(), agent, recommendation)
+	if apiErr != nil {
+		return nil, "", errors.Wrap(apiErr.ToError(), "failed to apply agent config overrides")
+	}
+	settingVersionsUsed = append(settingVersionsUsed, overridesUsed...)
+
 	if len(settingVersionsUsed) > 0 {
 		configId = strings.Join(settingVersionsUsed, ",")
 
@@ -160,6 +209,8 @@ func (m *Manager) ReportConfigDeploymentStatus(
 ) {
 	featureConfigIds := strings.Split(configId, ",")
 	for _, featureConfId := range featureConfigIds {
+		m.saveDeployReport(context.Background(), featureConfId, agentId, err)
+
 		newStatus := string(Deployed)
 		message := "Deployment was successful"
 		if err != nil {
@@ -194,12 +245,24 @@ func GetConfigHistory(
 func StartNewVersion(
 	ctx context.Context, userId string, eleType ElementTypeDef, elementIds []string,
 ) (*ConfigVersion, *model.ApiError) {
+	return StartNewVersionWithNote(ctx, userId, eleType, elementIds, "")
+}
+
+// StartNewVersionWithNote starts a new version recording why it was made
+func StartNewVersionWithNote(
+	ctx context.Context, userId string, eleType ElementTypeDef, elementIds []string, changeNote string,
+) (*ConfigVersion, *model.ApiError) {
 
 	// create a new version
 	cfg := NewConfigversion(eleType)
-
-	// insert new config and elements into database
-	err := m.insertConfig(ctx, userId, cfg, elementIds)
+	cfg.ChangeNote = changeNote
+
+	// insert new config and elements into database, along with its staged
+	// rollout so that a version is never recommended to all agents because
+	// its rollout could not be started
+	err := m.insertConfig(ctx, userId, cfg, elementIds, func(tx *sqlx.Tx) *model.ApiError {
+		return m.startStagedRollout(ctx, tx, userId, cfg)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -209,6 +272,23 @@ func StartNewVersion(
 	return cfg, nil
 }
 
+// StartUnchangedVersionWithNote records a new version which doesn't change
+// the config the agents run, e.g. one only renaming elements. The version is
+// not pushed to the agents and is deployed as soon as it is created.
+func StartUnchangedVersionWithNote(
+	ctx context.Context, userId string, eleType ElementTypeDef, elementIds []string, changeNote string,
+) (*ConfigVersion, *model.ApiError) {
+	cfg := NewConfigversion(eleType)
+	cfg.ChangeNote = changeNote
+	cfg.DeployStatus = Deployed
+	cfg.DeployResult = "The config of the agents is unchanged"
+
+	if err := m.insertConfig(ctx, userId, cfg, elementIds, nil); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func Redeploy(ctx context.Context, typ ElementTypeDef, version int) *model.ApiError {
 
 	configVersion, err := GetConfigVersion(ctx, typ, version)
@@ -229,13 +309,8 @@ func Redeploy(ctx context.Context, typ ElementTypeDef, version int) *model.ApiEr
 			return model.BadRequest(fmt.Errorf("failed to read the stored config correctly"))
 		}
 
-		// merge current config with new filter params
-		processorConf := map[string]interface{}{
-			"signoz_tail_sampling": config,
-		}
-
 		opamp.AddToTracePipelineSpec("signoz_tail_sampling")
-		configHash, err := opamp.UpsertControlProcessors(ctx, "traces", processorConf, m.OnConfigUpdate)
+		configHash, err := opamp.UpsertControlProcessorsForAgents(ctx, "traces", m.samplingProcessorsFor(config), m.OnConfigUpdate)
 		if err != nil {
 			zap.S().Error("failed to call agent config update for trace processor:", err)
 			return model.InternalError(fmt.Errorf("failed to deploy the config"))
@@ -324,13 +399,8 @@ func UpsertSamplingProcessor(ctx context.Context, version int, config *tsp.Confi
 	}
 	defer atomic.StoreUint32(&m.lock, 0)
 
-	// merge current config with new filter params
-	processorConf := map[string]interface{}{
-		"signoz_tail_sampling": config,
-	}
-
 	opamp.AddToTracePipelineSpec("signoz_tail_sampling")
-	configHash, err := opamp.UpsertControlProcessors(ctx, "traces", processorConf, m.OnConfigUpdate)
+	configHash, err := opamp.UpsertControlProcessorsForAgents(ctx, "traces", m.samplingProcessorsFor(config), m.OnConfigUpdate)
 	if err != nil {
 		zap.S().Error("failed to call agent config update for trace processor:", err)
 		return err
@@ -344,3 +414,17 @@ func UpsertSamplingProcessor(ctx context.Context, version int, config *tsp.Confi
 	m.updateDeployStatus(ctx, ElementTypeSamplingRules, version, string(DeployInitiated), "Deployment started", configHash, string(processorConfYaml))
 	return nil
 }
+
+// samplingProcessorsFor returns the sampling processor config of each agent,
+// with the policies targeting agent groups it isn't a member of left out
+func (m *Manager) samplingProcessorsFor(config *tsp.Config) func(opampModel.AgentInfo) (map[string]interface{}, error) {
+	return func(agent opampModel.AgentInfo) (map[string]interface{}, error) {
+		groups, apiErr := m.agentGroupsOf(context.Background(), agent)
+		if apiErr != nil {
+			return nil, apiErr.ToError()
+		}
+		return map[string]interface{}{
+			"signoz_tail_sampling": config.ForAgentGroups(groups),
+		}, nil
+	}
+}
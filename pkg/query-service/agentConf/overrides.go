@@ -0,0 +1,261 @@
+package agentConf
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// AgentConfigOverride is a collector config fragment merged into the config
+// recommended to the agents whose attributes have all the values in Match
+// and that are members of one of AgentGroups if any, after the agent
+// features. Maps are merged key by key while other values,
+// lists included, replace the recommended ones. Overrides are merged by
+// ascending priority so that the highest priority wins.
+type AgentConfigOverride struct {
+	Id          string        `json:"id" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Match       OverrideMatch `json:"match" db:"match_json"`
+	AgentGroups StringList    `json:"agentGroups" db:"agent_groups_json"`
+	Priority    int           `json:"priority" db:"priority"`
+	Config      string        `json:"config" db:"config"`
+	UpdatedBy   string        `json:"updatedBy" db:"updated_by"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+func (o *AgentConfigOverride) matches(agent opampModel.AgentInfo) bool {
+	if !o.Match.matches(agent) {
+		return false
+	}
+	if len(o.AgentGroups) == 0 {
+		return true
+	}
+	for _, group := range o.AgentGroups {
+		if slices.Contains(agent.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// OverrideMatch are the agent attributes an override or agent group
+// applies to, like host.name or service.instance.id for a single agent
+type OverrideMatch map[string]string
+
+func (m OverrideMatch) matches(agent opampModel.AgentInfo) bool {
+	for key, value := range m {
+		if agent.Attributes[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// For serializing from db
+func (m *OverrideMatch) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	}
+	return nil
+}
+
+// For serializing to db
+func (m OverrideMatch) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize override match to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableAgentConfigOverride struct {
+	Name        string        `json:"name"`
+	Match       OverrideMatch `json:"match"`
+	AgentGroups StringList    `json:"agentGroups"`
+	Priority    int           `json:"priority"`
+	Config      string        `json:"config"`
+}
+
+func (p *PostableAgentConfigOverride) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("override name is required")
+	}
+	if len(p.Match) == 0 && len(p.AgentGroups) == 0 {
+		return fmt.Errorf("override must match at least one agent attribute or agent group")
+	}
+	for key := range p.Match {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("matched agent attributes can't be empty")
+		}
+	}
+	for _, group := range p.AgentGroups {
+		if strings.TrimSpace(group) == "" {
+			return fmt.Errorf("matched agent groups can't be empty")
+		}
+	}
+	if _, err := parseConfigFragment(p.Config); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseConfigFragment(config string) (map[string]interface{}, error) {
+	var fragment map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &fragment); err != nil {
+		return nil, fmt.Errorf("override config is not valid yaml: %w", err)
+	}
+	if len(fragment) == 0 {
+		return nil, fmt.Errorf("override config can't be empty")
+	}
+	return fragment, nil
+}
+
+func ListAgentConfigOverrides(ctx context.Context) ([]AgentConfigOverride, *model.ApiError) {
+	return m.getAgentConfigOverrides(ctx)
+}
+
+// CreateAgentConfigOverride stores an override and rolls out the resulting
+// config to the connected agents
+func CreateAgentConfigOverride(
+	ctx context.Context, postable *PostableAgentConfigOverride,
+) (*AgentConfigOverride, *model.ApiError) {
+	override := &AgentConfigOverride{Id: uuid.NewString()}
+	return saveAgentConfigOverride(ctx, override, postable)
+}
+
+func UpdateAgentConfigOverride(
+	ctx context.Context, id string, postable *PostableAgentConfigOverride,
+) (*AgentConfigOverride, *model.ApiError) {
+	override, apiErr := m.getAgentConfigOverride(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return saveAgentConfigOverride(ctx, override, postable)
+}
+
+func saveAgentConfigOverride(
+	ctx context.Context, override *AgentConfigOverride, postable *PostableAgentConfigOverride,
+) (*AgentConfigOverride, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	override.Name = postable.Name
+	override.Match = postable.Match
+	override.AgentGroups = postable.AgentGroups
+	if override.Match == nil {
+		override.Match = OverrideMatch{}
+	}
+	if override.AgentGroups == nil {
+		override.AgentGroups = StringList{}
+	}
+	override.Priority = postable.Priority
+	override.Config = postable.Config
+	override.UpdatedBy = ""
+	if user := common.GetUserFromContext(ctx); user != nil {
+		override.UpdatedBy = user.Email
+	}
+	override.UpdatedAt = time.Now()
+
+	if apiErr := m.upsertAgentConfigOverride(ctx, override); apiErr != nil {
+		return nil, apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return override, nil
+}
+
+func DeleteAgentConfigOverride(ctx context.Context, id string) *model.ApiError {
+	if apiErr := m.deleteAgentConfigOverride(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// applyOverrides merges the overrides matching the agent into the config,
+// returning the ids of the settings used
+func (m *Manager) applyOverrides(
+	ctx context.Context, agent opampModel.AgentInfo, config []byte,
+) ([]byte, []string, *model.ApiError) {
+	overrides, apiErr := m.getAgentConfigOverrides(ctx)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return mergeOverrides(agent, config, overrides)
+}
+
+func mergeOverrides(
+	agent opampModel.AgentInfo, config []byte, overrides []AgentConfigOverride,
+) ([]byte, []string, *model.ApiError) {
+	matching := []AgentConfigOverride{}
+	for _, o := range overrides {
+		if o.matches(agent) {
+			matching = append(matching, o)
+		}
+	}
+	if len(matching) == 0 {
+		return config, nil, nil
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Priority < matching[j].Priority
+	})
+
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, nil, model.BadRequest(errors.Wrap(err, "could not parse recommended config"))
+	}
+	if c == nil {
+		c = map[string]interface{}{}
+	}
+
+	settingsUsed := []string{}
+	for _, o := range matching {
+		fragment, err := parseConfigFragment(o.Config)
+		if err != nil {
+			return nil, nil, model.InternalError(fmt.Errorf("override %s: %w", o.Name, err))
+		}
+		mergeConfigMaps(c, fragment)
+		// the update time is part of the id so that changed overrides are
+		// deployed again
+		settingsUsed = append(settingsUsed, fmt.Sprintf("override:%s:%d", o.Id, o.UpdatedAt.Unix()))
+	}
+
+	merged, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, nil, model.InternalError(errors.Wrap(err, "could not marshal overridden config"))
+	}
+	return merged, settingsUsed, nil
+}
+
+func mergeConfigMaps(base map[string]interface{}, override map[string]interface{}) {
+	for key, value := range override {
+		overrideMap, ok := value.(map[string]interface{})
+		if !ok {
+			base[key] = value
+			continue
+		}
+		baseMap, ok := base[key].(map[string]interface{})
+		if !ok {
+			base[key] = overrideMap
+			continue
+		}
+		mergeConfigMaps(baseMap, overrideMap)
+	}
+}
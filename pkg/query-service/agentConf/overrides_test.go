@@ -0,0 +1,105 @@
+package agentConf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const testRecommendedConf = `
+processors:
+  batch:
+    send_batch_size: 10000
+    timeout: 1s
+exporters:
+  clickhousetraces:
+    datasource: tcp://localhost:9000/signoz_traces
+service:
+  pipelines:
+    traces:
+      processors: [batch]
+      exporters: [clickhousetraces]
+`
+
+func TestMergeOverrides(t *testing.T) {
+	require := require.New(t)
+
+	agent := opampModel.AgentInfo{
+		ID:         "agent-1",
+		Attributes: map[string]string{"host.name": "edge-1", "deployment.environment": "prod"},
+	}
+	updatedAt := time.Unix(1700000000, 0)
+	overrides := []AgentConfigOverride{
+		{
+			Id:        "high",
+			Name:      "edge batch",
+			Match:     OverrideMatch{"host.name": "edge-1"},
+			Priority:  10,
+			Config:    "processors:\n  batch:\n    send_batch_size: 500\n",
+			UpdatedAt: updatedAt,
+		},
+		{
+			Id:        "low",
+			Name:      "prod batch",
+			Match:     OverrideMatch{"deployment.environment": "prod"},
+			Priority:  1,
+			Config:    "processors:\n  batch:\n    send_batch_size: 2000\nservice:\n  pipelines:\n    traces:\n      exporters: [otlp]\n",
+			UpdatedAt: updatedAt,
+		},
+		{
+			Id:        "other",
+			Name:      "other host",
+			Match:     OverrideMatch{"host.name": "edge-2"},
+			Config:    "processors:\n  batch:\n    timeout: 5s\n",
+			UpdatedAt: updatedAt,
+		},
+	}
+
+	merged, settingsUsed, apiErr := mergeOverrides(agent, []byte(testRecommendedConf), overrides)
+	require.Nil(apiErr)
+	require.Equal([]string{"override:low:1700000000", "override:high:1700000000"}, settingsUsed)
+
+	var c map[string]interface{}
+	require.Nil(yaml.Unmarshal(merged, &c))
+
+	batch := c["processors"].(map[string]interface{})["batch"].(map[string]interface{})
+	require.Equal(500, batch["send_batch_size"])
+	require.Equal("1s", batch["timeout"])
+
+	traces := c["service"].(map[string]interface{})["pipelines"].(map[string]interface{})["traces"].(map[string]interface{})
+	require.Equal([]interface{}{"otlp"}, traces["exporters"])
+	require.Equal([]interface{}{"batch"}, traces["processors"])
+
+	unmatched, settingsUsed, apiErr := mergeOverrides(
+		opampModel.AgentInfo{ID: "agent-2"}, []byte(testRecommendedConf), overrides,
+	)
+	require.Nil(apiErr)
+	require.Empty(settingsUsed)
+	require.Equal(testRecommendedConf, string(unmatched))
+}
+
+func TestPostableAgentConfigOverrideIsValid(t *testing.T) {
+	require := require.New(t)
+
+	valid := PostableAgentConfigOverride{
+		Name:   "edge batch",
+		Match:  OverrideMatch{"host.name": "edge-1"},
+		Config: "processors:\n  batch:\n    send_batch_size: 500\n",
+	}
+	require.Nil(valid.IsValid())
+
+	noMatch := valid
+	noMatch.Match = nil
+	require.NotNil(noMatch.IsValid())
+
+	badConfig := valid
+	badConfig.Config = "processors: [batch"
+	require.NotNil(badConfig.IsValid())
+
+	emptyConfig := valid
+	emptyConfig.Config = ""
+	require.NotNil(emptyConfig.IsValid())
+}
@@ -0,0 +1,296 @@
+package agentConf
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const rolloutCheckInterval = time.Minute
+
+// DeployTimedOut is only reported in notifications, the version stays in
+// progress until agents report back. Staged rollouts are rolled back
+// instead.
+const DeployTimedOut DeployStatus = "TIMED_OUT"
+
+// RolloutNotificationSettings configure notifying alert channels when the
+// latest config version of an agent feature is deployed, fails to deploy
+// or stays in progress for too long
+type RolloutNotificationSettings struct {
+	Enabled bool `json:"enabled"`
+	// TimeoutMinutes is for how long a rollout may stay in progress before
+	// it is notified as timed out. Staged rollouts whose canary agents
+	// don't all apply the version in that time are rolled back, whether
+	// notifications are enabled or not.
+	TimeoutMinutes int `json:"timeoutMinutes"`
+	// NotifyOnSuccess also notifies about successful rollouts, failed and
+	// timed out ones are always notified
+	NotifyOnSuccess bool `json:"notifyOnSuccess"`
+	// Channels the notifications are posted to, all the channels if empty
+	Channels []string `json:"channels"`
+	// Severity of the notifications, the most severe level if empty
+	Severity string `json:"severity,omitempty"`
+}
+
+var defaultRolloutNotificationSettings = RolloutNotificationSettings{
+	Enabled:        false,
+	TimeoutMinutes: 15,
+	Channels:       []string{},
+}
+
+func (s *RolloutNotificationSettings) IsValid() error {
+	if s.TimeoutMinutes < 1 || s.TimeoutMinutes > 24*60 {
+		return fmt.Errorf("timeoutMinutes must be between 1 and 1440")
+	}
+	if s.Channels == nil {
+		s.Channels = []string{}
+	}
+	return nil
+}
+
+// GetRolloutNotificationSettings returns the rollout notification
+// settings, the defaults if they were never saved
+func GetRolloutNotificationSettings(ctx context.Context) (RolloutNotificationSettings, error) {
+	var settingsJSON string
+	err := m.db.GetContext(ctx, &settingsJSON, `
+		SELECT settings_json FROM agent_config_rollout_notification_settings WHERE id = 1
+	`)
+	if err == sql.ErrNoRows {
+		return defaultRolloutNotificationSettings, nil
+	}
+	if err != nil {
+		return RolloutNotificationSettings{}, errors.Wrap(err, "failed to get rollout notification settings")
+	}
+
+	settings := defaultRolloutNotificationSettings
+	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+		return RolloutNotificationSettings{}, errors.Wrap(err, "invalid rollout notification settings")
+	}
+	return settings, nil
+}
+
+func SaveRolloutNotificationSettings(
+	ctx context.Context, userId string, settings *RolloutNotificationSettings,
+) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize rollout notification settings")
+	}
+	_, err = m.db.ExecContext(ctx, `
+		INSERT INTO agent_config_rollout_notification_settings (id, settings_json, updated_by, updated_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET
+			settings_json = excluded.settings_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, string(settingsJSON), userId, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to save rollout notification settings")
+	}
+	return nil
+}
+
+// RolloutNotification describes the outcome of the rollout of a config
+// version to the agents
+type RolloutNotification struct {
+	ElementType  ElementTypeDef `json:"elementType"`
+	Version      int            `json:"version"`
+	Status       DeployStatus   `json:"status"`
+	DeployResult string         `json:"deployResult"`
+	ChangeNote   string         `json:"changeNote"`
+	// InProgressSince is set for timed out rollouts
+	InProgressSince *time.Time `json:"inProgressSince,omitempty"`
+
+	// Agents that reported applying the version, and the ones that failed
+	// to with the error they reported
+	DeployedAgents []string          `json:"deployedAgents"`
+	FailedAgents   map[string]string `json:"failedAgents"`
+}
+
+// deployedAndFailedAgents returns the agents that reported applying a
+// version, and the ones that failed to with the error they reported
+func (m *Manager) deployedAndFailedAgents(
+	ctx context.Context, elementType ElementTypeDef, version int,
+) ([]string, map[string]string, *model.ApiError) {
+	reports := []AgentDeployReport{}
+	err := m.db.SelectContext(ctx, &reports, `
+		SELECT element_type, version, agent_id, status, error_message, reported_at
+		FROM agent_config_deploy_reports
+		WHERE element_type = $1 AND version = $2
+		ORDER BY agent_id
+	`, elementType, version)
+	if err != nil {
+		return nil, nil, model.InternalError(errors.Wrap(err, "failed to get agent config deploy reports"))
+	}
+
+	deployed, failed := []string{}, map[string]string{}
+	for _, report := range reports {
+		if report.Status == DeployFailed {
+			failed[report.AgentId] = report.ErrorMessage
+		} else {
+			deployed = append(deployed, report.AgentId)
+		}
+	}
+	return deployed, failed, nil
+}
+
+// RolloutWatcher notifies when the latest config version of an agent
+// feature is deployed, fails to deploy or stays in progress for longer than
+// the configured timeout. Rollouts are tracked in memory, versions whose
+// rollout finished before the watcher started are not notified. It also
+// promotes or rolls back the staged rollouts in their canary stage, which
+// are notified once finished: a rolled back version failed to deploy.
+type RolloutWatcher struct {
+	onNotify func(ctx context.Context, notification RolloutNotification, settings RolloutNotificationSettings)
+
+	// the last status notified, or observed while notifications were
+	// disabled, by version id
+	notified        map[string]DeployStatus
+	inProgressSince map[string]time.Time
+	seeded          bool
+	done            chan struct{}
+}
+
+func NewRolloutWatcher(
+	onNotify func(ctx context.Context, notification RolloutNotification, settings RolloutNotificationSettings),
+) *RolloutWatcher {
+	return &RolloutWatcher{
+		onNotify:        onNotify,
+		notified:        map[string]DeployStatus{},
+		inProgressSince: map[string]time.Time{},
+		done:            make(chan struct{}),
+	}
+}
+
+func (w *RolloutWatcher) Start() {
+	go func() {
+		tick := time.NewTicker(rolloutCheckInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case now := <-tick.C:
+				if err := w.check(context.Background(), now); err != nil {
+					zap.L().Error("agent config rollout check failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (w *RolloutWatcher) Stop() {
+	close(w.done)
+}
+
+func (w *RolloutWatcher) check(ctx context.Context, now time.Time) error {
+	settings, err := GetRolloutNotificationSettings(ctx)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(settings.TimeoutMinutes) * time.Minute
+
+	if apiErr := m.advanceStagedRollouts(ctx, now, timeout); apiErr != nil {
+		return apiErr.ToError()
+	}
+
+	latestIds := map[string]bool{}
+	for _, feature := range m.agentFeatures {
+		elementType := ElementTypeDef(feature.AgentFeatureType())
+		latest, apiErr := m.GetLatestVersion(ctx, elementType)
+		if apiErr != nil {
+			if apiErr.Type() == model.ErrorNotFound {
+				continue
+			}
+			return apiErr.ToError()
+		}
+		latestIds[latest.ID] = true
+
+		// rollouts in their canary stage don't time out, they are rolled
+		// back when their canary agents don't all apply the version in time
+		rollout, apiErr := m.getStagedRollout(ctx, elementType, latest.Version)
+		if apiErr != nil {
+			return apiErr.ToError()
+		}
+		if rollout != nil && rollout.Stage == StageCanary {
+			continue
+		}
+		status, notify := w.transition(latest, timeout, now)
+		if !notify || !w.seeded || !settings.Enabled {
+			continue
+		}
+		if status == Deployed && !settings.NotifyOnSuccess {
+			continue
+		}
+
+		notification := RolloutNotification{
+			ElementType:  elementType,
+			Version:      latest.Version,
+			Status:       status,
+			DeployResult: latest.DeployResult,
+			ChangeNote:   latest.ChangeNote,
+		}
+		if status == DeployTimedOut {
+			inProgressSince := w.inProgressSince[latest.ID]
+			notification.InProgressSince = &inProgressSince
+		}
+		notification.DeployedAgents, notification.FailedAgents, apiErr = m.deployedAndFailedAgents(
+			ctx, elementType, latest.Version,
+		)
+		if apiErr != nil {
+			return apiErr.ToError()
+		}
+		w.onNotify(ctx, notification, settings)
+	}
+	w.seeded = true
+
+	for id := range w.notified {
+		if !latestIds[id] {
+			delete(w.notified, id)
+		}
+	}
+	for id := range w.inProgressSince {
+		if !latestIds[id] {
+			delete(w.inProgressSince, id)
+		}
+	}
+	return nil
+}
+
+// transition records the deploy status of a version and tells if it is to
+// be notified, with the status to notify
+func (w *RolloutWatcher) transition(
+	version *ConfigVersion, timeout time.Duration, now time.Time,
+) (DeployStatus, bool) {
+	switch version.DeployStatus {
+	case Deployed, DeployFailed:
+		delete(w.inProgressSince, version.ID)
+		if w.notified[version.ID] == version.DeployStatus {
+			return "", false
+		}
+		w.notified[version.ID] = version.DeployStatus
+		return version.DeployStatus, true
+
+	case DeployInitiated:
+		since, ok := w.inProgressSince[version.ID]
+		if !ok {
+			since = now
+			w.inProgressSince[version.ID] = now
+		}
+		if now.Sub(since) < timeout || w.notified[version.ID] == DeployTimedOut {
+			return "", false
+		}
+		w.notified[version.ID] = DeployTimedOut
+		return DeployTimedOut, true
+
+	default:
+		delete(w.inProgressSince, version.ID)
+		return "", false
+	}
+}
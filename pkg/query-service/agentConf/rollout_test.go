@@ -0,0 +1,107 @@
+package agentConf
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf/sqlite"
+)
+
+func TestRolloutWatcherTransitions(t *testing.T) {
+	require := require.New(t)
+
+	w := NewRolloutWatcher(nil)
+	timeout := 15 * time.Minute
+	start := time.Now()
+	version := &ConfigVersion{ID: "v1", Version: 1, DeployStatus: DeployInitiated}
+
+	_, notify := w.transition(version, timeout, start)
+	require.False(notify)
+	_, notify = w.transition(version, timeout, start.Add(10*time.Minute))
+	require.False(notify)
+
+	status, notify := w.transition(version, timeout, start.Add(16*time.Minute))
+	require.True(notify)
+	require.Equal(DeployTimedOut, status)
+	_, notify = w.transition(version, timeout, start.Add(17*time.Minute))
+	require.False(notify, "a timed out rollout should be notified once")
+
+	version.DeployStatus = Deployed
+	status, notify = w.transition(version, timeout, start.Add(20*time.Minute))
+	require.True(notify)
+	require.Equal(Deployed, status)
+	_, notify = w.transition(version, timeout, start.Add(21*time.Minute))
+	require.False(notify)
+
+	// a new agent connecting starts deploying the version again
+	version.DeployStatus = DeployInitiated
+	_, notify = w.transition(version, timeout, start.Add(22*time.Minute))
+	require.False(notify)
+	version.DeployStatus = Deployed
+	_, notify = w.transition(version, timeout, start.Add(23*time.Minute))
+	require.False(notify, "the version was already notified as deployed")
+
+	version.DeployStatus = DeployFailed
+	status, notify = w.transition(version, timeout, start.Add(24*time.Minute))
+	require.True(notify)
+	require.Equal(DeployFailed, status)
+}
+
+func TestRolloutWatcherCanaryTimeout(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	require.Nil(sqlite.InitDB(db))
+	_, err = db.Exec(`CREATE TABLE users (id TEXT PRIMARY KEY, name TEXT)`)
+	require.Nil(err)
+
+	m = &Manager{Repo: Repo{db}, agentFeatures: []AgentFeature{testFeature{}}}
+	ctx := context.Background()
+	require.Nil(SaveRolloutStrategy(ctx, "user", &RolloutStrategy{
+		Staged: true, CanaryPercent: 50, ObservationMinutes: 10, AutoPromote: true,
+	}))
+	require.Nil(SaveRolloutNotificationSettings(ctx, "user", &RolloutNotificationSettings{
+		Enabled: true, TimeoutMinutes: 15, Channels: []string{},
+	}))
+	for i := 0; i < 2; i++ {
+		_, apiErr := StartNewVersion(ctx, "user", ElementTypeLogPipelines, []string{})
+		require.Nil(apiErr)
+	}
+	// the canary agent never reports back
+	m.recordCanaryAgent(ctx, ElementTypeLogPipelines, 2, "agent-1")
+
+	notifications := []RolloutNotification{}
+	w := NewRolloutWatcher(func(ctx context.Context, notification RolloutNotification, settings RolloutNotificationSettings) {
+		notifications = append(notifications, notification)
+	})
+	start := time.Now()
+	require.Nil(w.check(ctx, start))
+	require.Nil(w.check(ctx, start.Add(10*time.Minute)))
+	rollout, apiErr := GetStagedRollout(ctx, ElementTypeLogPipelines, 2)
+	require.Nil(apiErr)
+	require.Equal(StageCanary, rollout.Stage)
+	require.Empty(notifications)
+
+	require.Nil(w.check(ctx, start.Add(16*time.Minute)))
+	rollout, apiErr = GetStagedRollout(ctx, ElementTypeLogPipelines, 2)
+	require.Nil(apiErr)
+	require.Equal(StageRolledBack, rollout.Stage, "the rollout is not left in its canary stage")
+	version, apiErr := GetConfigVersion(ctx, ElementTypeLogPipelines, 2)
+	require.Nil(apiErr)
+	require.Equal(DeployFailed, version.DeployStatus)
+	require.Len(notifications, 1)
+	require.Equal(DeployFailed, notifications[0].Status)
+	require.Contains(notifications[0].DeployResult, "0 of 1 canary agents applied the version within 15 minutes")
+
+	require.Nil(w.check(ctx, start.Add(17*time.Minute)))
+	require.Len(notifications, 1, "the rolled back version is notified once")
+}
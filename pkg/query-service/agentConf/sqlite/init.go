@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -55,11 +56,100 @@ func InitDB(db *sqlx.DB) error {
 	CREATE UNIQUE INDEX IF NOT EXISTS agent_config_elements_u1 
 	ON agent_config_elements(version_id, element_id, element_type);
 
+	DROP TABLE IF EXISTS agent_config_version_journal;
+
+	CREATE TABLE IF NOT EXISTS agent_group_config_templates(
+		agent_group TEXT PRIMARY KEY,
+		template_name TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_overrides(
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		match_json TEXT NOT NULL,
+		priority INTEGER NOT NULL DEFAULT 0,
+		config TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_rollout_notification_settings(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		settings_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_rollout_strategy(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		strategy_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_staged_rollouts(
+		element_type TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		stable_version INTEGER NOT NULL,
+		strategy_json TEXT NOT NULL,
+		stage TEXT NOT NULL,
+		stage_reason TEXT NOT NULL DEFAULT '',
+		acknowledged_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (element_type, version)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_deploy_reports(
+		element_type TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		agent_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error_message TEXT NOT NULL DEFAULT '',
+		reported_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (element_type, version, agent_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS agent_config_deploy_reports_agent_idx
+	ON agent_config_deploy_reports(agent_id, reported_at);
+
+	CREATE TABLE IF NOT EXISTS agent_config_canary_agents(
+		element_type TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		agent_id TEXT NOT NULL,
+		recommended_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (element_type, version, agent_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_groups(
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		members_json TEXT NOT NULL,
+		selector_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
 	`
 
 	_, err = db.Exec(table_schema)
 	if err != nil {
 		return errors.Wrap(err, "Error in creating agent config tables")
 	}
+
+	// sqlite does not support "IF NOT EXISTS"
+	_, err = db.Exec(`ALTER TABLE agent_config_versions ADD COLUMN change_note TEXT;`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return errors.Wrap(err, "Error in adding column change_note to agent config versions table")
+	}
+
+	_, err = db.Exec(`ALTER TABLE agent_config_overrides ADD COLUMN agent_groups_json TEXT NOT NULL DEFAULT '[]';`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return errors.Wrap(err, "Error in adding column agent_groups_json to agent config overrides table")
+	}
 	return nil
 }
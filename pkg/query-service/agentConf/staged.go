@@ -0,0 +1,564 @@
+package agentConf
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// RolloutStrategy configures how new config versions are rolled out to the
+// agents. Staged rollouts deploy a new version to canary agents first, the
+// other agents keep the stable version until it is promoted.
+type RolloutStrategy struct {
+	Staged bool `json:"staged"`
+
+	// The canary agents are either a percentage of the agents, picked
+	// anew for each version, or the agents of a group, the one they report
+	// or an agent group defined on the server named with
+	// opampModel.ServerAgentGroupPrefix
+	CanaryPercent int    `json:"canaryPercent,omitempty"`
+	CanaryGroup   string `json:"canaryGroup,omitempty"`
+
+	// ObservationMinutes is for how long the canary agents must run the new
+	// version once they all applied it before it is promoted
+	ObservationMinutes int `json:"observationMinutes"`
+	// AutoPromote promotes the version once observed, it is left to be
+	// promoted manually otherwise
+	AutoPromote bool `json:"autoPromote"`
+	// AutoRollback rolls back the version as soon as a canary agent fails
+	// to apply it
+	AutoRollback bool `json:"autoRollback"`
+}
+
+var defaultRolloutStrategy = RolloutStrategy{
+	Staged:             false,
+	CanaryPercent:      10,
+	ObservationMinutes: 10,
+	AutoPromote:        true,
+	AutoRollback:       true,
+}
+
+func (s *RolloutStrategy) IsValid() error {
+	if !s.Staged {
+		return nil
+	}
+	if (s.CanaryPercent == 0) == (s.CanaryGroup == "") {
+		return fmt.Errorf("one of canaryPercent and canaryGroup is required for staged rollouts")
+	}
+	if s.CanaryPercent < 0 || s.CanaryPercent > 99 {
+		return fmt.Errorf("canaryPercent must be between 1 and 99")
+	}
+	if s.ObservationMinutes < 0 || s.ObservationMinutes > 24*60 {
+		return fmt.Errorf("observationMinutes must be between 0 and 1440")
+	}
+	return nil
+}
+
+// For serializing from db
+func (s *RolloutStrategy) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s RolloutStrategy) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize rollout strategy to JSON")
+	}
+	return serialized, nil
+}
+
+// isCanary tells if the agent is one of the canaries of a version. The
+// agents picked by percentage differ from version to version so that the
+// same agents do not always take the risk.
+func (s *RolloutStrategy) isCanary(agent opampModel.AgentInfo, versionId string) bool {
+	if s.CanaryGroup != "" {
+		return agent.InGroup(s.CanaryGroup)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(versionId + "/" + agent.ID))
+	return int(h.Sum32()%100) < s.CanaryPercent
+}
+
+func GetRolloutStrategy(ctx context.Context) (RolloutStrategy, error) {
+	return getRolloutStrategy(ctx, m.db)
+}
+
+func getRolloutStrategy(ctx context.Context, q sqlx.QueryerContext) (RolloutStrategy, error) {
+	var strategyJSON string
+	err := sqlx.GetContext(ctx, q, &strategyJSON, `
+		SELECT strategy_json FROM agent_config_rollout_strategy WHERE id = 1
+	`)
+	if err == sql.ErrNoRows {
+		return defaultRolloutStrategy, nil
+	}
+	if err != nil {
+		return RolloutStrategy{}, errors.Wrap(err, "failed to get rollout strategy")
+	}
+
+	strategy := defaultRolloutStrategy
+	if err := json.Unmarshal([]byte(strategyJSON), &strategy); err != nil {
+		return RolloutStrategy{}, errors.Wrap(err, "invalid rollout strategy")
+	}
+	return strategy, nil
+}
+
+// SaveRolloutStrategy saves the strategy of the versions created from now
+// on, the rollouts in progress keep the strategy they were started with
+func SaveRolloutStrategy(ctx context.Context, userId string, strategy *RolloutStrategy) error {
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO agent_config_rollout_strategy (id, strategy_json, updated_by, updated_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET
+			strategy_json = excluded.strategy_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, strategy, userId, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to save rollout strategy")
+	}
+	return nil
+}
+
+type RolloutStage string
+
+const (
+	StageCanary     RolloutStage = "CANARY"
+	StagePromoted   RolloutStage = "PROMOTED"
+	StageRolledBack RolloutStage = "ROLLED_BACK"
+)
+
+// StagedRollout is the rollout of a config version to canary agents, while
+// the other agents keep running the stable version
+type StagedRollout struct {
+	ElementType   ElementTypeDef  `json:"elementType" db:"element_type"`
+	Version       int             `json:"version" db:"version"`
+	StableVersion int             `json:"stableVersion" db:"stable_version"`
+	Strategy      RolloutStrategy `json:"strategy" db:"strategy_json"`
+	Stage         RolloutStage    `json:"stage" db:"stage"`
+	StageReason   string          `json:"stageReason" db:"stage_reason"`
+	// AcknowledgedAt is when all the canary agents had applied the version,
+	// the observation window starts then
+	AcknowledgedAt *time.Time `json:"acknowledgedAt" db:"acknowledged_at"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedBy      string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt      time.Time  `json:"updatedAt" db:"updated_at"`
+
+	// Agents the version was recommended to and what they reported, while
+	// the rollout is in its canary stage
+	CanaryAgents   []string          `json:"canaryAgents,omitempty" db:"-"`
+	DeployedAgents []string          `json:"deployedAgents,omitempty" db:"-"`
+	FailedAgents   map[string]string `json:"failedAgents,omitempty" db:"-"`
+}
+
+// canaryAgentsWait is for how long a rollout may have no canary agent
+// before it is rolled back, e.g. when no connected agent is in the canary
+// group or the fleet is too small for the canary percentage
+const canaryAgentsWait = 5 * time.Minute
+
+// advance promotes or rolls back a rollout in its canary stage as per its
+// strategy, given what the canary agents reported and whether any connected
+// agent is a canary. Rollouts whose canary agents don't all apply the
+// version within applyTimeout are rolled back. It tells if the rollout
+// changed.
+func (r *StagedRollout) advance(now time.Time, hasConnectedCanaries bool, applyTimeout time.Duration) bool {
+	if r.Stage != StageCanary {
+		return false
+	}
+
+	if len(r.CanaryAgents) == 0 && !hasConnectedCanaries {
+		if now.Sub(r.CreatedAt) < canaryAgentsWait {
+			return false
+		}
+		r.Stage = StageRolledBack
+		r.StageReason = fmt.Sprintf(
+			"no agent was a canary of the version for %d minutes", int(canaryAgentsWait.Minutes()),
+		)
+		return true
+	}
+
+	if len(r.FailedAgents) > 0 {
+		if !r.Strategy.AutoRollback {
+			return false
+		}
+		agentIds := make([]string, 0, len(r.FailedAgents))
+		for agentId := range r.FailedAgents {
+			agentIds = append(agentIds, agentId)
+		}
+		sort.Strings(agentIds)
+		r.Stage = StageRolledBack
+		r.StageReason = fmt.Sprintf(
+			"agent %s failed to apply the version: %s", agentIds[0], r.FailedAgents[agentIds[0]],
+		)
+		return true
+	}
+
+	if len(r.CanaryAgents) == 0 || len(r.DeployedAgents) < len(r.CanaryAgents) {
+		if now.Sub(r.CreatedAt) < applyTimeout {
+			return false
+		}
+		r.Stage = StageRolledBack
+		r.StageReason = fmt.Sprintf(
+			"%d of %d canary agents applied the version within %d minutes",
+			len(r.DeployedAgents), len(r.CanaryAgents), int(applyTimeout.Minutes()),
+		)
+		return true
+	}
+	if r.AcknowledgedAt == nil {
+		r.AcknowledgedAt = &now
+		return true
+	}
+
+	observation := time.Duration(r.Strategy.ObservationMinutes) * time.Minute
+	if !r.Strategy.AutoPromote || now.Sub(*r.AcknowledgedAt) < observation {
+		return false
+	}
+	r.Stage = StagePromoted
+	r.StageReason = fmt.Sprintf(
+		"%d canary agents ran the version for %d minutes without failures",
+		len(r.CanaryAgents), r.Strategy.ObservationMinutes,
+	)
+	return true
+}
+
+// startStagedRollout starts rolling out a new version to canary agents if
+// the rollout strategy is staged and there is a stable version to keep the
+// other agents on
+func (m *Manager) startStagedRollout(
+	ctx context.Context, tx *sqlx.Tx, userId string, version *ConfigVersion,
+) *model.ApiError {
+	strategy, err := getRolloutStrategy(ctx, tx)
+	if err != nil {
+		return model.InternalError(err)
+	}
+	if !strategy.Staged {
+		return nil
+	}
+
+	stableVersion := version.Version - 1
+	previous, apiErr := selectStagedRollout(ctx, tx, version.ElementType, stableVersion)
+	if apiErr != nil {
+		return apiErr
+	}
+	// the previous version never made it to all agents
+	if previous != nil && previous.Stage != StagePromoted {
+		stableVersion = previous.StableVersion
+	}
+	if stableVersion < 1 {
+		return nil
+	}
+
+	now := time.Now()
+	_, dbErr := tx.ExecContext(ctx, `
+		INSERT INTO agent_config_staged_rollouts (
+			element_type, version, stable_version, strategy_json, stage, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, version.ElementType, version.Version, stableVersion, strategy, StageCanary, now, userId, now)
+	if dbErr != nil {
+		return model.InternalError(errors.Wrap(dbErr, "failed to start staged rollout"))
+	}
+	return nil
+}
+
+func (m *Manager) getStagedRollout(
+	ctx context.Context, elementType ElementTypeDef, version int,
+) (*StagedRollout, *model.ApiError) {
+	return selectStagedRollout(ctx, m.db, elementType, version)
+}
+
+func selectStagedRollout(
+	ctx context.Context, q sqlx.QueryerContext, elementType ElementTypeDef, version int,
+) (*StagedRollout, *model.ApiError) {
+	rollouts := []StagedRollout{}
+	err := sqlx.SelectContext(ctx, q, &rollouts, `
+		SELECT
+			element_type, version, stable_version, strategy_json, stage, stage_reason,
+			acknowledged_at, created_at, COALESCE(updated_by, '') as updated_by, updated_at
+		FROM agent_config_staged_rollouts
+		WHERE element_type = $1 AND version = $2
+	`, elementType, version)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get staged rollout"))
+	}
+	if len(rollouts) == 0 {
+		return nil, nil
+	}
+	return &rollouts[0], nil
+}
+
+func (m *Manager) updateStagedRollout(ctx context.Context, rollout *StagedRollout) *model.ApiError {
+	rollout.UpdatedAt = time.Now()
+	_, err := m.db.ExecContext(ctx, `
+		UPDATE agent_config_staged_rollouts
+		SET stage = $1, stage_reason = $2, acknowledged_at = $3, updated_by = $4, updated_at = $5
+		WHERE element_type = $6 AND version = $7
+	`,
+		rollout.Stage, rollout.StageReason, rollout.AcknowledgedAt,
+		rollout.UpdatedBy, rollout.UpdatedAt, rollout.ElementType, rollout.Version,
+	)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to update staged rollout"))
+	}
+	return nil
+}
+
+// versionForAgent returns the version of a feature's config to recommend
+// to the agent: the latest one, unless it is being rolled out to canary
+// agents the agent is not one of or it was rolled back, in which case the
+// stable one. It also tells if the agent is a canary of the latest version.
+func (m *Manager) versionForAgent(
+	ctx context.Context, agent opampModel.AgentInfo, latest *ConfigVersion,
+) (*ConfigVersion, bool, *model.ApiError) {
+	rollout, apiErr := m.getStagedRollout(ctx, latest.ElementType, latest.Version)
+	if apiErr != nil {
+		return nil, false, apiErr
+	}
+	if rollout == nil || rollout.Stage == StagePromoted {
+		return latest, false, nil
+	}
+	if rollout.Stage == StageCanary && rollout.Strategy.isCanary(agent, latest.ID) {
+		return latest, true, nil
+	}
+
+	stable, apiErr := m.GetConfigVersion(ctx, latest.ElementType, rollout.StableVersion)
+	if apiErr != nil {
+		return nil, false, model.WrapApiError(apiErr, "failed to get the stable config version")
+	}
+	return stable, false, nil
+}
+
+// recordCanaryAgent persists that a version was recommended to an agent
+// as a canary, along with the canaries of the other recent versions
+func (m *Manager) recordCanaryAgent(
+	ctx context.Context, elementType ElementTypeDef, version int, agentId string,
+) {
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO agent_config_canary_agents (element_type, version, agent_id, recommended_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT(element_type, version, agent_id) DO NOTHING
+	`, elementType, version, agentId, time.Now())
+	if err == nil {
+		_, err = m.db.ExecContext(ctx, `
+			DELETE FROM agent_config_canary_agents
+			WHERE element_type = $1 AND version <= $2
+		`, elementType, version-deployReportVersions)
+	}
+	if err != nil {
+		zap.L().Error("could not save canary agent of config version", zap.Error(err))
+	}
+}
+
+func (m *Manager) canaryAgentsOf(
+	ctx context.Context, elementType ElementTypeDef, version int,
+) ([]string, *model.ApiError) {
+	agentIds := []string{}
+	err := m.db.SelectContext(ctx, &agentIds, `
+		SELECT agent_id FROM agent_config_canary_agents
+		WHERE element_type = $1 AND version = $2
+		ORDER BY agent_id
+	`, elementType, version)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get canary agents"))
+	}
+	return agentIds, nil
+}
+
+// withCanaryReports adds the canary agents of a rollout and what they
+// reported
+func (m *Manager) withCanaryReports(ctx context.Context, rollout *StagedRollout) *model.ApiError {
+	canaryAgents, apiErr := m.canaryAgentsOf(ctx, rollout.ElementType, rollout.Version)
+	if apiErr != nil {
+		return apiErr
+	}
+	deployed, failed, apiErr := m.deployedAndFailedAgents(ctx, rollout.ElementType, rollout.Version)
+	if apiErr != nil {
+		return apiErr
+	}
+	rollout.CanaryAgents, rollout.DeployedAgents, rollout.FailedAgents = canaryAgents, deployed, failed
+	return nil
+}
+
+// hasConnectedCanaries tells if any connected agent is a canary of the
+// version of a rollout
+func (m *Manager) hasConnectedCanaries(
+	ctx context.Context, rollout *StagedRollout, version *ConfigVersion,
+) (bool, *model.ApiError) {
+	for _, agent := range opampModel.AllAgents.GetAllAgentInfos() {
+		groups, apiErr := m.agentGroupsOf(ctx, agent)
+		if apiErr != nil {
+			return false, apiErr
+		}
+		agent.Groups = groups
+		if rollout.Strategy.isCanary(agent, version.ID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// finishStagedRollout records the promotion or rollback of a rollout and
+// recommends the resulting config to the agents. A rolled back version is
+// marked as failed to deploy.
+func (m *Manager) finishStagedRollout(ctx context.Context, rollout *StagedRollout) *model.ApiError {
+	if apiErr := m.updateStagedRollout(ctx, rollout); apiErr != nil {
+		return apiErr
+	}
+
+	if rollout.Stage == StageRolledBack {
+		version, apiErr := m.GetConfigVersion(ctx, rollout.ElementType, rollout.Version)
+		if apiErr != nil {
+			return apiErr
+		}
+		apiErr = m.updateDeployStatus(
+			ctx, rollout.ElementType, rollout.Version, string(DeployFailed),
+			fmt.Sprintf("Rolled back to version %d: %s", rollout.StableVersion, rollout.StageReason),
+			version.LastHash, version.LastConf,
+		)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
+	zap.L().Info(
+		"staged rollout of agent config version finished",
+		zap.String("elementType", string(rollout.ElementType)),
+		zap.Int("version", rollout.Version),
+		zap.String("stage", string(rollout.Stage)),
+		zap.String("reason", rollout.StageReason),
+	)
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// advanceStagedRollouts promotes or rolls back the rollouts of the latest
+// versions which are in their canary stage, as per their strategy and the
+// time their canary agents have to apply the versions
+func (m *Manager) advanceStagedRollouts(
+	ctx context.Context, now time.Time, applyTimeout time.Duration,
+) *model.ApiError {
+	for _, feature := range m.agentFeatures {
+		elementType := ElementTypeDef(feature.AgentFeatureType())
+		latest, apiErr := m.GetLatestVersion(ctx, elementType)
+		if apiErr != nil {
+			if apiErr.Type() == model.ErrorNotFound {
+				continue
+			}
+			return apiErr
+		}
+		rollout, apiErr := m.getStagedRollout(ctx, elementType, latest.Version)
+		if apiErr != nil {
+			return apiErr
+		}
+		if rollout == nil || rollout.Stage != StageCanary {
+			continue
+		}
+
+		if apiErr := m.withCanaryReports(ctx, rollout); apiErr != nil {
+			return apiErr
+		}
+		hasConnectedCanaries, apiErr := m.hasConnectedCanaries(ctx, rollout, latest)
+		if apiErr != nil {
+			return apiErr
+		}
+		if !rollout.advance(now, hasConnectedCanaries, applyTimeout) {
+			continue
+		}
+		rollout.UpdatedBy = ""
+		if rollout.Stage == StageCanary {
+			apiErr = m.updateStagedRollout(ctx, rollout)
+		} else {
+			apiErr = m.finishStagedRollout(ctx, rollout)
+		}
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// GetStagedRollout returns the staged rollout of a version with what its
+// canary agents reported
+func GetStagedRollout(
+	ctx context.Context, elementType ElementTypeDef, version int,
+) (*StagedRollout, *model.ApiError) {
+	rollout, apiErr := m.getStagedRollout(ctx, elementType, version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if rollout == nil {
+		return nil, model.NotFoundError(fmt.Errorf(
+			"version %d of %s was not rolled out in stages", version, elementType,
+		))
+	}
+	if apiErr := m.withCanaryReports(ctx, rollout); apiErr != nil {
+		return nil, apiErr
+	}
+	return rollout, nil
+}
+
+// PromoteStagedRollout deploys a version in its canary stage to all agents
+func PromoteStagedRollout(
+	ctx context.Context, userId string, elementType ElementTypeDef, version int,
+) (*StagedRollout, *model.ApiError) {
+	return finishStagedRollout(ctx, userId, elementType, version, StagePromoted, "promoted manually")
+}
+
+// RollbackStagedRollout reverts the canary agents of a version in its
+// canary stage to the stable version
+func RollbackStagedRollout(
+	ctx context.Context, userId string, elementType ElementTypeDef, version int,
+) (*StagedRollout, *model.ApiError) {
+	return finishStagedRollout(ctx, userId, elementType, version, StageRolledBack, "rolled back manually")
+}
+
+func finishStagedRollout(
+	ctx context.Context, userId string, elementType ElementTypeDef, version int,
+	stage RolloutStage, reason string,
+) (*StagedRollout, *model.ApiError) {
+	rollout, apiErr := GetStagedRollout(ctx, elementType, version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if rollout.Stage != StageCanary {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("the rollout of version %d of %s is already %s", version, elementType, rollout.Stage),
+		}
+	}
+	latest, apiErr := m.GetLatestVersion(ctx, elementType)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if latest.Version != version {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("version %d of %s was superseded by version %d", version, elementType, latest.Version),
+		}
+	}
+
+	rollout.Stage = stage
+	rollout.StageReason = reason
+	rollout.UpdatedBy = userId
+	if apiErr := m.finishStagedRollout(ctx, rollout); apiErr != nil {
+		return nil, apiErr
+	}
+	return rollout, nil
+}
@@ -0,0 +1,181 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf/sqlite"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+)
+
+func TestStagedRolloutAdvance(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	timeout := 15 * time.Minute
+	rollout := &StagedRollout{
+		ElementType: ElementTypeLogPipelines, Version: 3, StableVersion: 2, Stage: StageCanary, CreatedAt: now,
+		Strategy: RolloutStrategy{
+			Staged: true, CanaryPercent: 10, ObservationMinutes: 10, AutoPromote: true, AutoRollback: true,
+		},
+		CanaryAgents:   []string{"agent-1", "agent-2"},
+		DeployedAgents: []string{"agent-1"},
+	}
+	require.False(rollout.advance(now, true, timeout), "waiting for the canary agents to apply the version")
+
+	rollout.DeployedAgents = []string{"agent-1", "agent-2"}
+	require.True(rollout.advance(now, true, timeout))
+	require.Equal(StageCanary, rollout.Stage)
+	require.Equal(now, *rollout.AcknowledgedAt)
+
+	require.False(rollout.advance(now.Add(5*time.Minute), true, timeout), "the version is being observed")
+	require.True(rollout.advance(now.Add(10*time.Minute), true, timeout))
+	require.Equal(StagePromoted, rollout.Stage)
+	require.False(rollout.advance(now.Add(11*time.Minute), true, timeout))
+
+	rollout.Stage, rollout.AcknowledgedAt = StageCanary, nil
+	rollout.FailedAgents = map[string]string{"agent-3": "unknown processor"}
+	require.True(rollout.advance(now, true, timeout))
+	require.Equal(StageRolledBack, rollout.Stage)
+	require.Contains(rollout.StageReason, "agent-3")
+
+	rollout.Stage = StageCanary
+	rollout.Strategy.AutoRollback = false
+	require.False(rollout.advance(now, true, timeout), "left to be rolled back manually")
+
+	// rollouts without canary agents are rolled back instead of being
+	// left in their canary stage
+	rollout = &StagedRollout{
+		Stage: StageCanary, CreatedAt: now,
+		Strategy: RolloutStrategy{Staged: true, CanaryGroup: "staging", AutoPromote: true},
+	}
+	require.False(rollout.advance(now.Add(time.Minute), true, timeout), "a connected canary is yet to be recommended the version")
+	require.False(rollout.advance(now.Add(time.Minute), false, timeout), "waiting for canary agents to connect")
+	require.True(rollout.advance(now.Add(canaryAgentsWait), false, timeout))
+	require.Equal(StageRolledBack, rollout.Stage)
+	require.Contains(rollout.StageReason, "no agent was a canary")
+
+	// rollouts whose canary agents never apply the version are rolled back
+	// once the time to apply it is over
+	rollout = &StagedRollout{
+		Stage: StageCanary, CreatedAt: now,
+		Strategy:       RolloutStrategy{Staged: true, CanaryPercent: 10, ObservationMinutes: 10},
+		CanaryAgents:   []string{"agent-1", "agent-2"},
+		DeployedAgents: []string{"agent-1"},
+	}
+	require.False(rollout.advance(now.Add(14*time.Minute), true, timeout))
+	require.True(rollout.advance(now.Add(timeout), true, timeout))
+	require.Equal(StageRolledBack, rollout.Stage)
+	require.Equal("1 of 2 canary agents applied the version within 15 minutes", rollout.StageReason)
+
+	rollout = &StagedRollout{
+		Stage: StageCanary, CreatedAt: now,
+		Strategy: RolloutStrategy{Staged: true, CanaryGroup: "staging"},
+	}
+	require.True(rollout.advance(now.Add(timeout), true, timeout), "a connected canary never got the version")
+	require.Equal(StageRolledBack, rollout.Stage)
+
+	rollout = &StagedRollout{
+		Stage: StageCanary, CreatedAt: now,
+		Strategy:       RolloutStrategy{Staged: true, CanaryPercent: 10, ObservationMinutes: 30},
+		CanaryAgents:   []string{"agent-1"},
+		DeployedAgents: []string{"agent-1"},
+	}
+	require.True(rollout.advance(now.Add(timeout), true, timeout))
+	require.Equal(StageCanary, rollout.Stage, "canaries applying the version late are observed")
+	require.False(rollout.advance(now.Add(2*timeout), true, timeout), "the observation is not timed out")
+	require.Equal(StageCanary, rollout.Stage)
+}
+
+func TestStagedRolloutPersistence(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	require.Nil(sqlite.InitDB(db))
+	// the versions are read along with the names of their creators
+	_, err = db.Exec(`CREATE TABLE users (id TEXT PRIMARY KEY, name TEXT)`)
+	require.Nil(err)
+
+	mgr := &Manager{Repo: Repo{db}, agentFeatures: []AgentFeature{testFeature{}}}
+	m = mgr
+	ctx := context.Background()
+	require.Nil(SaveRolloutStrategy(ctx, "user", &RolloutStrategy{
+		Staged: true, CanaryPercent: 50, ObservationMinutes: 10, AutoPromote: true,
+	}))
+
+	for i := 0; i < 2; i++ {
+		_, apiErr := StartNewVersion(ctx, "user", ElementTypeLogPipelines, []string{})
+		require.Nil(apiErr)
+	}
+	rollout, apiErr := GetStagedRollout(ctx, ElementTypeLogPipelines, 2)
+	require.Nil(apiErr)
+	require.Equal(1, rollout.StableVersion)
+	require.Equal(StageCanary, rollout.Stage)
+
+	// what the canary agents were recommended and reported survives
+	// restarts as it is read back from the db
+	mgr.recordCanaryAgent(ctx, ElementTypeLogPipelines, 2, "agent-2")
+	mgr.recordCanaryAgent(ctx, ElementTypeLogPipelines, 2, "agent-1")
+	mgr.recordCanaryAgent(ctx, ElementTypeLogPipelines, 2, "agent-1")
+	mgr.saveDeployReport(ctx, "log_pipelines:2", "agent-1", nil)
+	mgr.saveDeployReport(ctx, "log_pipelines:2", "agent-2", fmt.Errorf("unknown processor"))
+	mgr.saveDeployReport(ctx, "log_pipelines:1", "agent-3", nil)
+
+	rollout, apiErr = GetStagedRollout(ctx, ElementTypeLogPipelines, 2)
+	require.Nil(apiErr)
+	require.Equal([]string{"agent-1", "agent-2"}, rollout.CanaryAgents)
+	require.Equal([]string{"agent-1"}, rollout.DeployedAgents)
+	require.Equal(map[string]string{"agent-2": "unknown processor"}, rollout.FailedAgents)
+
+	// a version whose staged rollout can't be started is not created
+	_, err = db.Exec(`DROP TABLE agent_config_staged_rollouts`)
+	require.Nil(err)
+	_, apiErr = StartNewVersion(ctx, "user", ElementTypeLogPipelines, []string{})
+	require.NotNil(apiErr)
+	latest, apiErr := GetLatestVersion(ctx, ElementTypeLogPipelines)
+	require.Nil(apiErr)
+	require.Equal(2, latest.Version)
+}
+
+func TestRolloutStrategyCanaries(t *testing.T) {
+	require := require.New(t)
+
+	strategy := RolloutStrategy{Staged: true, CanaryPercent: 20}
+	require.Nil(strategy.IsValid())
+
+	canaries := 0
+	for i := 0; i < 1000; i++ {
+		agent := opampModel.AgentInfo{ID: fmt.Sprintf("agent-%d", i)}
+		isCanary := strategy.isCanary(agent, "version-1")
+		require.Equal(isCanary, strategy.isCanary(agent, "version-1"), "canaries should be stable")
+		if isCanary {
+			canaries++
+		}
+	}
+	require.InDelta(200, canaries, 50)
+
+	strategy = RolloutStrategy{Staged: true, CanaryGroup: "staging"}
+	require.Nil(strategy.IsValid())
+	require.True(strategy.isCanary(opampModel.AgentInfo{ID: "a", Group: "staging"}, "version-1"))
+	require.False(strategy.isCanary(opampModel.AgentInfo{ID: "b", Group: "prod"}, "version-1"))
+	require.False(strategy.isCanary(opampModel.AgentInfo{ID: "c", Groups: []string{"staging"}}, "version-1"),
+		"agent groups defined on the server are named with a prefix",
+	)
+
+	strategy.CanaryGroup = opampModel.ServerAgentGroupPrefix + "staging"
+	require.True(strategy.isCanary(opampModel.AgentInfo{ID: "c", Groups: []string{"staging"}}, "version-1"))
+	require.False(strategy.isCanary(opampModel.AgentInfo{ID: "a", Group: "staging"}, "version-1"))
+
+	strategy.CanaryPercent = 10
+	require.NotNil(strategy.IsValid(), "only one of percent and group can be set")
+}
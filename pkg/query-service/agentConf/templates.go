@@ -0,0 +1,284 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+// ConfigTemplate is a base collector config for a deployment topology. Agents
+// of a group assigned to a template are recommended the template with the
+// agent features layered on top instead of their current effective config.
+type ConfigTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Config      string `json:"config"`
+}
+
+// AgentGroupTemplate assigns a config template to a group of agents, either
+// the group they report or an agent group defined on the server named with
+// opampModel.ServerAgentGroupPrefix
+type AgentGroupTemplate struct {
+	AgentGroup   string    `json:"agentGroup" db:"agent_group"`
+	TemplateName string    `json:"templateName" db:"template_name"`
+	UpdatedBy    string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// exporters are configured through env vars so that the same template can be
+// used across installations
+var configTemplates = []ConfigTemplate{
+	{
+		Name:        "k8s-daemonset",
+		Description: "Collector running as a daemonset on every kubernetes node, collecting container logs and node level metrics",
+		Config: `receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+  filelog/k8s:
+    include:
+      - /var/log/pods/*/*/*.log
+    start_at: end
+    include_file_path: true
+    include_file_name: false
+    operators:
+      - type: container
+        id: container-parser
+  kubeletstats:
+    collection_interval: 30s
+    auth_type: serviceAccount
+    endpoint: ${env:K8S_NODE_NAME}:10250
+    insecure_skip_verify: true
+processors:
+  k8sattributes:
+    passthrough: false
+    filter:
+      node_from_env_var: K8S_NODE_NAME
+  resourcedetection:
+    detectors: [env, system]
+    timeout: 2s
+  batch:
+    send_batch_size: 10000
+    timeout: 1s
+exporters:
+  otlp:
+    endpoint: ${env:SIGNOZ_COLLECTOR_ENDPOINT}
+    tls:
+      insecure: ${env:SIGNOZ_COLLECTOR_INSECURE}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [k8sattributes, resourcedetection, batch]
+      exporters: [otlp]
+    metrics:
+      receivers: [otlp, kubeletstats]
+      processors: [k8sattributes, resourcedetection, batch]
+      exporters: [otlp]
+    logs:
+      receivers: [otlp, filelog/k8s]
+      processors: [k8sattributes, resourcedetection, batch]
+      exporters: [otlp]
+`,
+	},
+	{
+		Name:        "vm",
+		Description: "Collector running on a virtual machine or bare metal host, collecting host metrics and system logs",
+		Config: `receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+  hostmetrics:
+    collection_interval: 30s
+    scrapers:
+      cpu: {}
+      load: {}
+      memory: {}
+      disk: {}
+      filesystem: {}
+      network: {}
+  filelog/syslog:
+    include:
+      - /var/log/syslog
+      - /var/log/messages
+    start_at: end
+processors:
+  resourcedetection:
+    detectors: [env, system]
+    timeout: 2s
+  batch:
+    send_batch_size: 10000
+    timeout: 1s
+exporters:
+  otlp:
+    endpoint: ${env:SIGNOZ_COLLECTOR_ENDPOINT}
+    tls:
+      insecure: ${env:SIGNOZ_COLLECTOR_INSECURE}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [resourcedetection, batch]
+      exporters: [otlp]
+    metrics:
+      receivers: [otlp, hostmetrics]
+      processors: [resourcedetection, batch]
+      exporters: [otlp]
+    logs:
+      receivers: [otlp, filelog/syslog]
+      processors: [resourcedetection, batch]
+      exporters: [otlp]
+`,
+	},
+	{
+		Name:        "gateway",
+		Description: "Standalone collector receiving OTLP from other agents and forwarding it to SigNoz",
+		Config: `receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+        max_recv_msg_size_mib: 16
+      http:
+        endpoint: 0.0.0.0:4318
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_percentage: 80
+    spike_limit_percentage: 25
+  batch:
+    send_batch_size: 50000
+    timeout: 2s
+exporters:
+  otlp:
+    endpoint: ${env:SIGNOZ_COLLECTOR_ENDPOINT}
+    tls:
+      insecure: ${env:SIGNOZ_COLLECTOR_INSECURE}
+    sending_queue:
+      enabled: true
+      queue_size: 5000
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [memory_limiter, batch]
+      exporters: [otlp]
+    metrics:
+      receivers: [otlp]
+      processors: [memory_limiter, batch]
+      exporters: [otlp]
+    logs:
+      receivers: [otlp]
+      processors: [memory_limiter, batch]
+      exporters: [otlp]
+`,
+	},
+}
+
+func ListConfigTemplates() []ConfigTemplate {
+	return slices.Clone(configTemplates)
+}
+
+func getConfigTemplate(name string) *ConfigTemplate {
+	for _, t := range configTemplates {
+		if t.Name == name {
+			template := t
+			return &template
+		}
+	}
+	return nil
+}
+
+func ListAgentGroupTemplates(ctx context.Context) ([]AgentGroupTemplate, *model.ApiError) {
+	return m.getAgentGroupTemplates(ctx)
+}
+
+// SetAgentGroupTemplate assigns a template to an agent group and rolls out
+// the resulting config to the connected agents
+func SetAgentGroupTemplate(
+	ctx context.Context, agentGroup string, templateName string,
+) (*AgentGroupTemplate, *model.ApiError) {
+	if agentGroup == "" {
+		return nil, model.BadRequest(fmt.Errorf("agent group is required"))
+	}
+	if getConfigTemplate(templateName) == nil {
+		return nil, model.BadRequest(fmt.Errorf("unknown config template %q", templateName))
+	}
+	if name, ok := strings.CutPrefix(agentGroup, opampModel.ServerAgentGroupPrefix); ok {
+		if apiErr := m.ensureAgentGroupExists(ctx, name); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	updatedBy := ""
+	if user := common.GetUserFromContext(ctx); user != nil {
+		updatedBy = user.Email
+	}
+
+	groupTemplate := AgentGroupTemplate{
+		AgentGroup:   agentGroup,
+		TemplateName: templateName,
+		UpdatedBy:    updatedBy,
+		UpdatedAt:    time.Now(),
+	}
+	if apiErr := m.upsertAgentGroupTemplate(ctx, groupTemplate); apiErr != nil {
+		return nil, apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return &groupTemplate, nil
+}
+
+// RemoveAgentGroupTemplate makes the agents of the group fall back to their
+// own effective config as the base config
+func RemoveAgentGroupTemplate(ctx context.Context, agentGroup string) *model.ApiError {
+	if apiErr := m.deleteAgentGroupTemplate(ctx, agentGroup); apiErr != nil {
+		return apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// baseConfigForAgent returns the template assigned to a group of the agent,
+// if any. The template of the group the agent reports wins over the ones of
+// the agent groups defined on the server, which are tried by name.
+func (m *Manager) baseConfigForAgent(ctx context.Context, agent opampModel.AgentInfo) (*ConfigTemplate, *model.ApiError) {
+	groupTemplates, apiErr := m.getAgentGroupTemplates(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var groupTemplate *AgentGroupTemplate
+	for i := range groupTemplates {
+		if !agent.InGroup(groupTemplates[i].AgentGroup) {
+			continue
+		}
+		if groupTemplate == nil || groupTemplates[i].AgentGroup == agent.Group {
+			groupTemplate = &groupTemplates[i]
+		}
+	}
+	if groupTemplate == nil {
+		return nil, nil
+	}
+
+	template := getConfigTemplate(groupTemplate.TemplateName)
+	if template == nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"agent group %s is assigned to unknown template %s", groupTemplate.AgentGroup, groupTemplate.TemplateName,
+		))
+	}
+	return template, nil
+}
@@ -0,0 +1,142 @@
+package agentConf
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigTemplates(t *testing.T) {
+	require := require.New(t)
+
+	templates := ListConfigTemplates()
+	require.NotEmpty(templates)
+	for _, template := range templates {
+		var config struct {
+			Receivers  map[string]interface{} `yaml:"receivers"`
+			Processors map[string]interface{} `yaml:"processors"`
+			Exporters  map[string]interface{} `yaml:"exporters"`
+			Service    struct {
+				Pipelines map[string]struct {
+					Receivers  []string `yaml:"receivers"`
+					Processors []string `yaml:"processors"`
+					Exporters  []string `yaml:"exporters"`
+				} `yaml:"pipelines"`
+			} `yaml:"service"`
+		}
+		require.Nil(yaml.Unmarshal([]byte(template.Config), &config), template.Name)
+		require.NotEmpty(config.Service.Pipelines, template.Name)
+
+		// every component a pipeline uses must be defined
+		for name, pipeline := range config.Service.Pipelines {
+			require.NotEmpty(pipeline.Receivers, "%s %s", template.Name, name)
+			require.NotEmpty(pipeline.Exporters, "%s %s", template.Name, name)
+			for _, receiver := range pipeline.Receivers {
+				require.Contains(config.Receivers, receiver, "%s %s", template.Name, name)
+			}
+			for _, processor := range pipeline.Processors {
+				require.Contains(config.Processors, processor, "%s %s", template.Name, name)
+			}
+			for _, exporter := range pipeline.Exporters {
+				require.Contains(config.Exporters, exporter, "%s %s", template.Name, name)
+			}
+		}
+
+		found := getConfigTemplate(template.Name)
+		require.NotNil(found)
+		require.Equal(template, *found)
+	}
+	require.Nil(getConfigTemplate("unknown"))
+
+	templates[0].Config = "changed"
+	require.NotEqual("changed", ListConfigTemplates()[0].Config, "the templates can't be changed by callers")
+	getConfigTemplate(templates[1].Name).Config = "changed"
+	require.NotEqual("changed", getConfigTemplate(templates[1].Name).Config)
+}
+
+func TestAgentGroupTemplates(t *testing.T) {
+	require := require.New(t)
+	repo := newTestRepo(t)
+	notified := 0
+	mgr := &Manager{Repo: *repo, configSubscribers: map[string]func(){
+		"test": func() { notified++ },
+	}}
+	m = mgr
+	ctx := context.Background()
+
+	_, apiErr := SetAgentGroupTemplate(ctx, "", "vm")
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Type())
+	_, apiErr = SetAgentGroupTemplate(ctx, "edge", "unknown")
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Type())
+	_, apiErr = SetAgentGroupTemplate(ctx, opampModel.ServerAgentGroupPrefix+"edge", "vm")
+	require.NotNil(apiErr, "agent groups defined on the server must exist")
+	require.Equal(model.ErrorBadData, apiErr.Type())
+	require.Zero(notified)
+
+	require.Nil(mgr.upsertAgentGroup(ctx, &AgentGroup{
+		Id: "1", Name: "edge", Members: StringList{"agent-1"}, Selector: OverrideMatch{},
+	}))
+	groupTemplate, apiErr := SetAgentGroupTemplate(ctx, opampModel.ServerAgentGroupPrefix+"edge", "gateway")
+	require.Nil(apiErr)
+	require.Equal("gateway", groupTemplate.TemplateName)
+	_, apiErr = SetAgentGroupTemplate(ctx, "k8s", "vm")
+	require.Nil(apiErr)
+	_, apiErr = SetAgentGroupTemplate(ctx, "k8s", "k8s-daemonset")
+	require.Nil(apiErr, "the template of a group can be changed")
+	require.Equal(3, notified)
+
+	groupTemplates, apiErr := ListAgentGroupTemplates(ctx)
+	require.Nil(apiErr)
+	assigned := map[string]string{}
+	for _, gt := range groupTemplates {
+		assigned[gt.AgentGroup] = gt.TemplateName
+	}
+	require.Equal(map[string]string{"server:edge": "gateway", "k8s": "k8s-daemonset"}, assigned)
+
+	template, apiErr := mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{ID: "agent-1", Group: "k8s"})
+	require.Nil(apiErr)
+	require.Equal("k8s-daemonset", template.Name)
+	template, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{ID: "agent-1", Groups: []string{"edge"}})
+	require.Nil(apiErr)
+	require.Equal("gateway", template.Name)
+	template, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{ID: "agent-2", Group: "edge"})
+	require.Nil(apiErr)
+	require.Nil(template, "a reported group doesn't match the server group of the same name")
+
+	// the template is the base config of the agents of the group
+	recommended, configId, err := mgr.RecommendAgentConfig(
+		opampModel.AgentInfo{ID: "agent-2", Group: "k8s"}, []byte("receivers: {}\n"),
+	)
+	require.Nil(err)
+	require.Equal(getConfigTemplate("k8s-daemonset").Config, string(recommended))
+	require.Equal("template:k8s-daemonset", configId)
+	recommended, _, err = mgr.RecommendAgentConfig(opampModel.AgentInfo{ID: "agent-3"}, []byte("receivers: {}\n"))
+	require.Nil(err)
+	require.Equal("receivers: {}\n", string(recommended), "agents without a template keep their config")
+
+	require.Nil(RemoveAgentGroupTemplate(ctx, "k8s"))
+	require.Equal(4, notified)
+	template, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{ID: "agent-2", Group: "k8s"})
+	require.Nil(apiErr)
+	require.Nil(template)
+
+	// templates removed from the server fail the agents assigned to them
+	// instead of silently falling back to their own config
+	require.Nil(mgr.upsertAgentGroupTemplate(ctx, AgentGroupTemplate{
+		AgentGroup: "legacy", TemplateName: "removed", UpdatedAt: time.Now(),
+	}))
+	_, apiErr = mgr.baseConfigForAgent(ctx, opampModel.AgentInfo{ID: "agent-2", Group: "legacy"})
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorInternal, apiErr.Type())
+	require.True(strings.Contains(apiErr.Error(), "removed"))
+	_, _, err = mgr.RecommendAgentConfig(opampModel.AgentInfo{ID: "agent-2", Group: "legacy"}, nil)
+	require.NotNil(err)
+}
@@ -9,10 +9,18 @@ import (
 type ElementTypeDef string
 
 const (
-	ElementTypeSamplingRules ElementTypeDef = "sampling_rules"
-	ElementTypeDropRules     ElementTypeDef = "drop_rules"
-	ElementTypeLogPipelines  ElementTypeDef = "log_pipelines"
-	ElementTypeLbExporter    ElementTypeDef = "lb_exporter"
+	ElementTypeSamplingRules    ElementTypeDef = "sampling_rules"
+	ElementTypeDropRules        ElementTypeDef = "drop_rules"
+	ElementTypeLogPipelines     ElementTypeDef = "log_pipelines"
+	ElementTypeLbExporter       ElementTypeDef = "lb_exporter"
+	ElementTypeLookupTables     ElementTypeDef = "lookup_tables"
+	ElementTypeLogExports       ElementTypeDef = "log_exports"
+	ElementTypeKafkaReceivers   ElementTypeDef = "kafka_receivers"
+	ElementTypeTraceReceivers   ElementTypeDef = "trace_receivers"
+	ElementTypeMetricOwners     ElementTypeDef = "metric_owners"
+	ElementTypeDeliveryProfiles ElementTypeDef = "delivery_profiles"
+	ElementTypeInsertSettings   ElementTypeDef = "clickhouse_insert_settings"
+	ElementTypeLogReceivers     ElementTypeDef = "log_receivers"
 )
 
 type DeployStatus string
@@ -43,6 +51,12 @@ type ConfigVersion struct {
 	CreatedBy     string    `json:"createdBy" db:"created_by"`
 	CreatedByName string    `json:"createdByName" db:"created_by_name"`
 	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+
+	// why the version was created, as described by its creator
+	ChangeNote string `json:"changeNote" db:"change_note"`
+
+	// what each agent reported about applying the version, in the history
+	AgentReports []AgentDeployReport `json:"agentReports,omitempty" db:"-"`
 }
 
 func NewConfigversion(typeDef ElementTypeDef) *ConfigVersion {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"reflect"
+	"sync"
 
 	"github.com/gorilla/mux"
 	"go.signoz.io/signoz/pkg/query-service/auth"
@@ -13,22 +15,53 @@ import (
 
 type AuthMiddleware struct {
 	GetUserFromRequest func(r *http.Request) (*model.UserPayload, error)
+
+	// the access levels of the handlers the middleware returned, recorded
+	// as routes are registered so that they can be listed to the users
+	accessMtx sync.RWMutex
+	access    map[uintptr]Access
 }
 
 func NewAuthMiddleware(f func(r *http.Request) (*model.UserPayload, error)) *AuthMiddleware {
 	return &AuthMiddleware{
 		GetUserFromRequest: f,
+		access:             map[uintptr]Access{},
+	}
+}
+
+// withAccess records the access level of a handler. Handlers are told apart
+// by their code, which is the same for all the handlers a method returns.
+func (am *AuthMiddleware) withAccess(access Access, h http.HandlerFunc) http.HandlerFunc {
+	am.accessMtx.Lock()
+	defer am.accessMtx.Unlock()
+	if am.access == nil {
+		am.access = map[uintptr]Access{}
+	}
+	am.access[reflect.ValueOf(h).Pointer()] = access
+	return h
+}
+
+// AccessOf tells the access level a route handler was registered with,
+// handlers registered without the middleware are not known
+func (am *AuthMiddleware) AccessOf(h http.Handler) (Access, bool) {
+	handlerFunc, ok := h.(http.HandlerFunc)
+	if !ok {
+		return "", false
 	}
+	am.accessMtx.RLock()
+	defer am.accessMtx.RUnlock()
+	access, ok := am.access[reflect.ValueOf(handlerFunc).Pointer()]
+	return access, ok
 }
 
 func (am *AuthMiddleware) OpenAccess(f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return am.withAccess(AccessOpen, func(w http.ResponseWriter, r *http.Request) {
 		f(w, r)
-	}
+	})
 }
 
 func (am *AuthMiddleware) ViewAccess(f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return am.withAccess(AccessView, func(w http.ResponseWriter, r *http.Request) {
 		user, err := am.GetUserFromRequest(r)
 		if err != nil {
 			RespondError(w, &model.ApiError{
@@ -48,11 +81,11 @@ func (am *AuthMiddleware) ViewAccess(f func(http.ResponseWriter, *http.Request))
 		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
 		r = r.WithContext(ctx)
 		f(w, r)
-	}
+	})
 }
 
 func (am *AuthMiddleware) EditAccess(f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return am.withAccess(AccessEdit, func(w http.ResponseWriter, r *http.Request) {
 		user, err := am.GetUserFromRequest(r)
 		if err != nil {
 			RespondError(w, &model.ApiError{
@@ -71,11 +104,11 @@ func (am *AuthMiddleware) EditAccess(f func(http.ResponseWriter, *http.Request))
 		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
 		r = r.WithContext(ctx)
 		f(w, r)
-	}
+	})
 }
 
 func (am *AuthMiddleware) SelfAccess(f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return am.withAccess(AccessSelf, func(w http.ResponseWriter, r *http.Request) {
 		user, err := am.GetUserFromRequest(r)
 		if err != nil {
 			RespondError(w, &model.ApiError{
@@ -95,11 +128,11 @@ func (am *AuthMiddleware) SelfAccess(f func(http.ResponseWriter, *http.Request))
 		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
 		r = r.WithContext(ctx)
 		f(w, r)
-	}
+	})
 }
 
 func (am *AuthMiddleware) AdminAccess(f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return am.withAccess(AccessAdmin, func(w http.ResponseWriter, r *http.Request) {
 		user, err := am.GetUserFromRequest(r)
 		if err != nil {
 			RespondError(w, &model.ApiError{
@@ -118,5 +151,5 @@ func (am *AuthMiddleware) AdminAccess(f func(http.ResponseWriter, *http.Request)
 		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
 		r = r.WithContext(ctx)
 		f(w, r)
-	}
+	})
 }
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/app/quotas"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Access is the access level an API is registered with
+type Access string
+
+const (
+	AccessOpen  Access = "open"
+	AccessView  Access = "view"
+	AccessEdit  Access = "edit"
+	AccessSelf  Access = "self"
+	AccessAdmin Access = "admin"
+)
+
+// Permissions are what the role of a user allows them to do
+type Permissions struct {
+	View  bool `json:"view"`
+	Edit  bool `json:"edit"`
+	Admin bool `json:"admin"`
+}
+
+func permissionsOf(user *model.UserPayload) Permissions {
+	return Permissions{
+		View:  auth.IsViewer(user) || auth.IsEditor(user) || auth.IsAdmin(user),
+		Edit:  auth.IsEditor(user) || auth.IsAdmin(user),
+		Admin: auth.IsAdmin(user),
+	}
+}
+
+func (p Permissions) allows(access Access) bool {
+	switch access {
+	case AccessOpen, AccessSelf:
+		return true
+	case AccessView:
+		return p.View
+	case AccessEdit:
+		return p.Edit
+	case AccessAdmin:
+		return p.Admin
+	}
+	return false
+}
+
+// CapabilityEndpoint is an API the user can call. Self access APIs can
+// only be called about the user themselves unless they are an admin.
+type CapabilityEndpoint struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Access Access `json:"access"`
+}
+
+// CapabilityModule groups the endpoints of the user under the same API
+// path, e.g. dashboards for /api/v1/dashboards/{uuid}
+type CapabilityModule struct {
+	Name  string `json:"name"`
+	Read  bool   `json:"read"`
+	Write bool   `json:"write"`
+}
+
+type CapabilityUser struct {
+	Id    string `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type CapabilityLimits struct {
+	// MaxLookbackHours is how far back the role of the user can query, nil
+	// if not limited
+	MaxLookbackHours *int                `json:"maxLookbackHours"`
+	Quotas           []quotas.QuotaUsage `json:"quotas"`
+}
+
+// Capabilities let clients adapt to what is enabled and what the calling
+// user is allowed to do without probing the APIs
+type Capabilities struct {
+	User         CapabilityUser       `json:"user"`
+	Permissions  Permissions          `json:"permissions"`
+	FeatureFlags model.FeatureSet     `json:"featureFlags"`
+	Modules      []CapabilityModule   `json:"modules"`
+	Limits       CapabilityLimits     `json:"limits"`
+	Endpoints    []CapabilityEndpoint `json:"endpoints"`
+}
+
+// moduleOfPath returns the module of an API path, its first segment after
+// the API version
+func moduleOfPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 3 || segments[0] != "api" {
+		return ""
+	}
+	return segments[2]
+}
+
+// endpointsOf lists the endpoints registered on a router with the auth
+// middleware that the given permissions allow calling, with the modules
+// they belong to
+func endpointsOf(
+	router *mux.Router, am *AuthMiddleware, permissions Permissions,
+) ([]CapabilityEndpoint, []CapabilityModule, error) {
+	endpoints := []CapabilityEndpoint{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		access, ok := am.AccessOf(route.GetHandler())
+		if !ok || !permissions.allows(access) {
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+		for _, method := range methods {
+			endpoints = append(endpoints, CapabilityEndpoint{
+				Method: method, Path: path, Access: access,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	modules := []CapabilityModule{}
+	moduleIdx := map[string]int{}
+	for _, endpoint := range endpoints {
+		name := moduleOfPath(endpoint.Path)
+		if name == "" || endpoint.Access == AccessOpen {
+			continue
+		}
+		idx, ok := moduleIdx[name]
+		if !ok {
+			idx = len(modules)
+			moduleIdx[name] = idx
+			modules = append(modules, CapabilityModule{Name: name})
+		}
+		switch endpoint.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			modules[idx].Read = true
+		default:
+			modules[idx].Write = true
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return endpoints, modules, nil
+}
+
+// Capability discovery, to be registered after all the other routes
+func (aH *APIHandler) RegisterCapabilityRoutes(router *mux.Router, am *AuthMiddleware) {
+	router.HandleFunc("/api/v1/capabilities", am.ViewAccess(func(w http.ResponseWriter, r *http.Request) {
+		aH.getCapabilities(w, r, router, am)
+	})).Methods(http.MethodGet)
+}
+
+func (aH *APIHandler) getCapabilities(w http.ResponseWriter, r *http.Request, router *mux.Router, am *AuthMiddleware) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, model.UnauthorizedError(fmt.Errorf("failed to get user from context")), nil)
+		return
+	}
+
+	capabilities := Capabilities{
+		User: CapabilityUser{
+			Id: user.Id, Email: user.Email, Role: querylimits.RoleOf(user),
+		},
+		Permissions: permissionsOf(user),
+		Limits:      CapabilityLimits{Quotas: []quotas.QuotaUsage{}},
+	}
+
+	featureSet, err := aH.FF().GetFeatureFlags()
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	if aH.preferSpanMetrics {
+		for idx := range featureSet {
+			if featureSet[idx].Name == model.UseSpanMetrics {
+				featureSet[idx].Active = true
+			}
+		}
+	}
+	capabilities.FeatureFlags = featureSet
+
+	capabilities.Endpoints, capabilities.Modules, err = endpointsOf(router, am, capabilities.Permissions)
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+
+	if aH.QueryLimitsController != nil {
+		limit, apiErr := aH.QueryLimitsController.GetLimitOfUser(r.Context(), user)
+		if apiErr != nil {
+			RespondError(w, apiErr, "Failed to get the query lookback limit")
+			return
+		}
+		if limit != nil {
+			capabilities.Limits.MaxLookbackHours = &limit.MaxLookbackHours
+		}
+	}
+	if aH.QuotasController != nil {
+		usage, apiErr := aH.QuotasController.GetUsage(r.Context())
+		if apiErr != nil {
+			RespondError(w, apiErr, "Failed to get quota usage")
+			return
+		}
+		capabilities.Limits.Quotas = usage
+	}
+
+	aH.Respond(w, capabilities)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsOf(t *testing.T) {
+	require := require.New(t)
+
+	am := NewAuthMiddleware(nil)
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/version", am.OpenAccess(noop)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(noop)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards", am.EditAccess(noop)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/user/{id}", am.SelfAccess(noop)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/pipelines", am.AdminAccess(noop)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/unguarded", noop).Methods(http.MethodGet)
+	subRouter := router.PathPrefix("/api/v1/channels").Subrouter()
+	subRouter.HandleFunc("/{id}", am.AdminAccess(noop)).Methods(http.MethodDelete, http.MethodPut)
+
+	endpoints, modules, err := endpointsOf(router, am, Permissions{View: true})
+	require.Nil(err)
+	require.Equal([]CapabilityEndpoint{
+		{Method: http.MethodGet, Path: "/api/v1/dashboards", Access: AccessView},
+		{Method: http.MethodGet, Path: "/api/v1/user/{id}", Access: AccessSelf},
+		{Method: http.MethodGet, Path: "/api/v1/version", Access: AccessOpen},
+	}, endpoints)
+	require.Equal([]CapabilityModule{
+		{Name: "dashboards", Read: true},
+		{Name: "user", Read: true},
+	}, modules)
+
+	endpoints, modules, err = endpointsOf(router, am, Permissions{View: true, Edit: true, Admin: true})
+	require.Nil(err)
+	require.Len(endpoints, 7)
+	require.Equal(CapabilityEndpoint{
+		Method: http.MethodDelete, Path: "/api/v1/channels/{id}", Access: AccessAdmin,
+	}, endpoints[0])
+	require.Equal([]CapabilityModule{
+		{Name: "channels", Write: true},
+		{Name: "dashboards", Read: true, Write: true},
+		{Name: "pipelines", Write: true},
+		{Name: "user", Read: true},
+	}, modules)
+}
@@ -0,0 +1,195 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// attributeRefresh tracks the last attribute metadata rebuild, only one
+// rebuild can run at a time
+type attributeRefresh struct {
+	mu     sync.Mutex
+	status *model.AttributeRefreshStatus
+}
+
+type attributeRefreshStep struct {
+	name  string
+	query string
+}
+
+// attributeRefreshSteps returns the statements which re-insert the attribute
+// keys seen in the last lookbackHours into the metadata tables. The tables
+// deduplicate on merge so keys which are already present are not duplicated.
+func (r *ClickHouseReader) attributeRefreshSteps(lookbackHours int) []attributeRefreshStep {
+	logsSince := fmt.Sprintf("timestamp >= toInt64(toUnixTimestamp(now() - INTERVAL %d HOUR)*1000000000)", lookbackHours)
+	tracesSince := fmt.Sprintf("timestamp >= now() - INTERVAL %d HOUR", lookbackHours)
+
+	steps := []attributeRefreshStep{}
+
+	for _, dataType := range []string{"string", "int64", "float64", "bool"} {
+		steps = append(steps, attributeRefreshStep{
+			name: fmt.Sprintf("logs %s attribute keys", dataType),
+			query: fmt.Sprintf(
+				"INSERT INTO %s.%s (name, datatype) SELECT DISTINCT arrayJoin(attributes_%s_key), '%s' FROM %s.%s WHERE %s",
+				r.logsDB, r.logsAttributeKeys, dataType, logsKeysDataType(dataType), r.logsDB, r.logsTable, logsSince,
+			),
+		})
+	}
+	steps = append(steps, attributeRefreshStep{
+		name: "logs resource keys",
+		query: fmt.Sprintf(
+			"INSERT INTO %s.%s (name, datatype) SELECT DISTINCT arrayJoin(resources_string_key), 'String' FROM %s.%s WHERE %s",
+			r.logsDB, r.logsResourceKeys, r.logsDB, r.logsTable, logsSince,
+		),
+	})
+
+	// the tag attributes table backs the v3 autocomplete for logs
+	logsTagAttributes := []struct {
+		tagType, dataType, valueColumn, keys, values string
+	}{
+		{"tag", "string", "stringTagValue", "attributes_string_key", "attributes_string_value"},
+		{"tag", "int64", "int64TagValue", "attributes_int64_key", "attributes_int64_value"},
+		{"tag", "float64", "float64TagValue", "attributes_float64_key", "attributes_float64_value"},
+		{"resource", "string", "stringTagValue", "resources_string_key", "resources_string_value"},
+	}
+	for _, attr := range logsTagAttributes {
+		steps = append(steps, attributeRefreshStep{
+			name: fmt.Sprintf("logs %s %s tag attributes", attr.dataType, attr.tagType),
+			query: fmt.Sprintf(
+				"INSERT INTO %s.%s (timestamp, tagKey, tagType, tagDataType, %s) "+
+					"SELECT DISTINCT toStartOfHour(now()), kv.1, '%s', '%s', kv.2 FROM %s.%s "+
+					"ARRAY JOIN arrayZip(%s, %s) AS kv WHERE %s",
+				r.logsDB, r.logsTagAttributeTable, attr.valueColumn,
+				attr.tagType, attr.dataType, r.logsDB, r.logsTable,
+				attr.keys, attr.values, logsSince,
+			),
+		})
+	}
+
+	// span attributes back the v3 autocomplete for traces
+	spanAttributes := []struct {
+		tagType, dataType, column, values string
+	}{
+		{"tag", "string", "stringTagMap", "kv.2, NULL"},
+		{"tag", "float64", "numberTagMap", "'', kv.2"},
+		{"tag", "bool", "boolTagMap", "'', NULL"},
+		{"resource", "string", "resourceTagsMap", "kv.2, NULL"},
+	}
+	for _, attr := range spanAttributes {
+		steps = append(steps, attributeRefreshStep{
+			name: fmt.Sprintf("traces %s %s attributes", attr.dataType, attr.tagType),
+			query: fmt.Sprintf(
+				"INSERT INTO %s.%s (timestamp, tagKey, tagType, dataType, stringTagValue, float64TagValue, isColumn) "+
+					"SELECT DISTINCT toStartOfHour(now()), kv.1, '%s', '%s', %s, false FROM %s.%s "+
+					"ARRAY JOIN arrayZip(mapKeys(%s), mapValues(%s)) AS kv WHERE %s",
+				r.TraceDB, r.spanAttributeTable,
+				attr.tagType, attr.dataType, attr.values, r.TraceDB, r.indexTable,
+				attr.column, attr.column, tracesSince,
+			),
+		})
+		steps = append(steps, attributeRefreshStep{
+			name: fmt.Sprintf("traces %s %s attribute keys", attr.dataType, attr.tagType),
+			query: fmt.Sprintf(
+				"INSERT INTO %s.%s (tagKey, tagType, dataType, isColumn) "+
+					"SELECT DISTINCT arrayJoin(mapKeys(%s)), '%s', '%s', false FROM %s.%s WHERE %s",
+				r.TraceDB, r.spanAttributesKeysTable,
+				attr.column, attr.tagType, attr.dataType, r.TraceDB, r.indexTable, tracesSince,
+			),
+		})
+	}
+
+	return steps
+}
+
+// the logs keys tables store the data type in the collector's notation
+func logsKeysDataType(dataType string) string {
+	switch dataType {
+	case "int64":
+		return "Int64"
+	case "float64":
+		return "Float64"
+	case "bool":
+		return "Bool"
+	default:
+		return "String"
+	}
+}
+
+// RefreshAttributeMetadata starts rebuilding the attribute key metadata used for
+// autocomplete in the background, the progress can be followed with
+// GetAttributeMetadataRefreshStatus
+func (r *ClickHouseReader) RefreshAttributeMetadata(params *model.RefreshAttributesParams) (*model.AttributeRefreshStatus, *model.ApiError) {
+	r.attributeRefresh.mu.Lock()
+	defer r.attributeRefresh.mu.Unlock()
+
+	if r.attributeRefresh.status != nil && r.attributeRefresh.status.Status == constants.StatusPending {
+		return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("an attribute refresh is already running")}
+	}
+
+	steps := r.attributeRefreshSteps(params.LookbackHours)
+	r.attributeRefresh.status = &model.AttributeRefreshStatus{
+		Status:        constants.StatusPending,
+		LookbackHours: params.LookbackHours,
+		StartedAt:     time.Now(),
+		TotalSteps:    len(steps),
+	}
+	status := *r.attributeRefresh.status
+
+	// the rebuild outlives the request which started it
+	go r.runAttributeRefresh(context.Background(), steps)
+
+	return &status, nil
+}
+
+func (r *ClickHouseReader) runAttributeRefresh(ctx context.Context, steps []attributeRefreshStep) {
+	update := func(fn func(status *model.AttributeRefreshStatus)) {
+		r.attributeRefresh.mu.Lock()
+		defer r.attributeRefresh.mu.Unlock()
+		fn(r.attributeRefresh.status)
+	}
+
+	for _, step := range steps {
+		update(func(status *model.AttributeRefreshStatus) {
+			status.CurrentStep = step.name
+		})
+
+		if err := r.db.Exec(ctx, step.query); err != nil {
+			zap.S().Error("attribute refresh failed", zap.String("step", step.name), zap.Error(err))
+			update(func(status *model.AttributeRefreshStatus) {
+				finishedAt := time.Now()
+				status.Status = constants.StatusFailed
+				status.FinishedAt = &finishedAt
+				status.Error = fmt.Sprintf("%s: %s", step.name, err.Error())
+			})
+			return
+		}
+
+		update(func(status *model.AttributeRefreshStatus) {
+			status.CompletedSteps++
+		})
+	}
+
+	update(func(status *model.AttributeRefreshStatus) {
+		finishedAt := time.Now()
+		status.Status = constants.StatusSuccess
+		status.FinishedAt = &finishedAt
+		status.CurrentStep = ""
+	})
+}
+
+func (r *ClickHouseReader) GetAttributeMetadataRefreshStatus() *model.AttributeRefreshStatus {
+	r.attributeRefresh.mu.Lock()
+	defer r.attributeRefresh.mu.Unlock()
+
+	if r.attributeRefresh.status == nil {
+		return nil
+	}
+	status := *r.attributeRefresh.status
+	return &status
+}
@@ -0,0 +1,94 @@
+package clickhouseReader
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func newAttributeRefreshReader(t *testing.T) (*ClickHouseReader, cmock.ClickConnMockCommon) {
+	mock, err := cmock.NewClickHouseNative(nil)
+	require.Nil(t, err)
+	return &ClickHouseReader{
+		db:                      mock,
+		logsDB:                  "signoz_logs",
+		logsTable:               "logs",
+		logsAttributeKeys:       "logs_attribute_keys",
+		logsResourceKeys:        "logs_resource_keys",
+		logsTagAttributeTable:   "tag_attributes",
+		TraceDB:                 "signoz_traces",
+		indexTable:              "signoz_index_v2",
+		spanAttributeTable:      "span_attributes",
+		spanAttributesKeysTable: "span_attributes_keys",
+	}, mock
+}
+
+func TestAttributeRefreshSteps(t *testing.T) {
+	require := require.New(t)
+
+	reader, _ := newAttributeRefreshReader(t)
+	steps := reader.attributeRefreshSteps(6)
+	require.Len(steps, 17)
+	require.Equal("logs int64 attribute keys", steps[1].name)
+	require.Equal(
+		"INSERT INTO signoz_logs.logs_attribute_keys (name, datatype) SELECT DISTINCT arrayJoin(attributes_int64_key), 'Int64' "+
+			"FROM signoz_logs.logs WHERE timestamp >= toInt64(toUnixTimestamp(now() - INTERVAL 6 HOUR)*1000000000)",
+		steps[1].query,
+	)
+	for _, step := range steps {
+		if strings.HasPrefix(step.name, "traces") {
+			require.Contains(step.query, "FROM signoz_traces.signoz_index_v2")
+			require.Contains(step.query, "WHERE timestamp >= now() - INTERVAL 6 HOUR")
+		}
+	}
+}
+
+func TestRefreshAttributeMetadata(t *testing.T) {
+	require := require.New(t)
+
+	reader, mock := newAttributeRefreshReader(t)
+	require.Nil(reader.GetAttributeMetadataRefreshStatus())
+
+	steps := reader.attributeRefreshSteps(24)
+	mock.ExpectExec(steps[0].query).WillDelayFor(100 * time.Millisecond)
+	for _, step := range steps[1:] {
+		mock.ExpectExec(step.query)
+	}
+
+	status, apiErr := reader.RefreshAttributeMetadata(&model.RefreshAttributesParams{LookbackHours: 24})
+	require.Nil(apiErr)
+	require.Equal(constants.StatusPending, status.Status)
+	require.Equal(len(steps), status.TotalSteps)
+
+	_, apiErr = reader.RefreshAttributeMetadata(&model.RefreshAttributesParams{LookbackHours: 24})
+	require.NotNil(apiErr, "only one refresh runs at a time")
+	require.Equal(model.ErrorConflict, apiErr.Typ)
+
+	require.Eventually(func() bool {
+		return reader.GetAttributeMetadataRefreshStatus().Status == constants.StatusSuccess
+	}, 5*time.Second, 10*time.Millisecond)
+	status = reader.GetAttributeMetadataRefreshStatus()
+	require.Equal(len(steps), status.CompletedSteps)
+	require.NotNil(status.FinishedAt)
+	require.Empty(status.CurrentStep)
+	require.Nil(mock.ExpectationsWereMet())
+
+	// a failed step stops the refresh, which can then be run again
+	mock.ExpectExec(steps[0].query)
+	mock.ExpectExec(steps[1].query).WillReturnError(fmt.Errorf("table is read only"))
+	_, apiErr = reader.RefreshAttributeMetadata(&model.RefreshAttributesParams{LookbackHours: 24})
+	require.Nil(apiErr)
+	require.Eventually(func() bool {
+		return reader.GetAttributeMetadataRefreshStatus().Status == constants.StatusFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	status = reader.GetAttributeMetadataRefreshStatus()
+	require.Equal(1, status.CompletedSteps)
+	require.Equal("logs int64 attribute keys: table is read only", status.Error)
+	require.Nil(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,176 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.uber.org/zap"
+)
+
+// maxDeletedTraces bounds the traces a single data deletion can remove, as
+// their ids are part of the mutations
+const maxDeletedTraces = 10000
+
+// dataDeletionMarker is part of the condition of every mutation of a data
+// deletion. ignore() is always 0, so it doesn't change what is deleted, but
+// it lets the mutations be found in system.mutations.
+func dataDeletionMarker(id string) string {
+	return fmt.Sprintf("data_deletion_%s", strings.ReplaceAll(id, "-", ""))
+}
+
+// deletionMutation deletes the rows matching the condition from a local table
+type deletionMutation struct {
+	table     string
+	condition string
+}
+
+func (r *ClickHouseReader) logsAttributeExpression(key string) string {
+	return fmt.Sprintf(
+		"if(indexOf(attributes_string_key, %[1]s) != 0, attributes_string_value[indexOf(attributes_string_key, %[1]s)], "+
+			"resources_string_value[indexOf(resources_string_key, %[1]s)])",
+		utils.ClickHouseFormattedValue(key),
+	)
+}
+
+func (r *ClickHouseReader) spansAttributeExpression(key string) string {
+	return fmt.Sprintf(
+		"if(stringTagMap[%[1]s] != '', stringTagMap[%[1]s], resourceTagsMap[%[1]s])",
+		utils.ClickHouseFormattedValue(key),
+	)
+}
+
+// autocompleteDeletion deletes the value of the attribute from a table of
+// the values suggested in autocomplete
+func (r *ClickHouseReader) autocompleteDeletion(table string, params *model.DataDeletionParams) deletionMutation {
+	return deletionMutation{
+		table: table,
+		condition: fmt.Sprintf(
+			"tagKey = %s AND stringTagValue = %s",
+			utils.ClickHouseFormattedValue(params.AttributeKey), utils.ClickHouseFormattedValue(params.AttributeValue),
+		),
+	}
+}
+
+// StartDataDeletion submits the mutations deleting the logs, and the traces
+// with a span, having the attribute value in the time range, along with the
+// value from the autocomplete suggestions. Mutations run asynchronously on
+// every shard, their progress is returned by GetDataDeletionProgress.
+func (r *ClickHouseReader) StartDataDeletion(
+	ctx context.Context, params *model.DataDeletionParams,
+) *model.ApiError {
+	marker := utils.ClickHouseFormattedValue(dataDeletionMarker(params.Id))
+	mutations := []deletionMutation{}
+
+	for _, signal := range params.Signals {
+		switch signal {
+		case string(v3.DataSourceLogs):
+			mutations = append(mutations, deletionMutation{
+				table: fmt.Sprintf("%s.%s", r.logsDB, r.logsLocalTable),
+				condition: fmt.Sprintf(
+					"timestamp >= %d AND timestamp <= %d AND %s = %s",
+					params.Start.UnixNano(), params.End.UnixNano(),
+					r.logsAttributeExpression(params.AttributeKey), utils.ClickHouseFormattedValue(params.AttributeValue),
+				),
+			}, r.autocompleteDeletion(getLocalTableName(r.logsDB+"."+r.logsTagAttributeTable), params))
+
+		case string(v3.DataSourceTraces):
+			// the value is suggested in autocomplete whether or not spans
+			// having it are left in the range
+			mutations = append(mutations,
+				r.autocompleteDeletion(getLocalTableName(r.TraceDB+"."+r.spanAttributeTable), params),
+			)
+
+			traceIds, apiErr := r.getDeletedTraceIds(ctx, params)
+			if apiErr != nil {
+				return apiErr
+			}
+			if len(traceIds) == 0 {
+				continue
+			}
+			quoted := make([]string, 0, len(traceIds))
+			for _, traceId := range traceIds {
+				quoted = append(quoted, utils.ClickHouseFormattedValue(traceId))
+			}
+			// the spans of a trace can start before the range, the trace
+			// ids keep the mutation to the traces of the user. Mutations can't be
+			// parameterized so the values are quoted into them.
+			condition := fmt.Sprintf("traceID IN (%s)", strings.Join(quoted, ", "))
+			for _, table := range []string{r.indexTable, r.SpansTable, r.errorTable} {
+				mutations = append(mutations, deletionMutation{
+					table:     getLocalTableName(r.TraceDB + "." + table),
+					condition: condition,
+				})
+			}
+		}
+	}
+
+	for _, m := range mutations {
+		query := fmt.Sprintf(
+			"ALTER TABLE %s ON CLUSTER %s DELETE WHERE %s AND NOT ignore(%s) SETTINGS mutations_sync = 0",
+			m.table, r.cluster, m.condition, marker,
+		)
+		if err := r.db.Exec(ctx, query); err != nil {
+			zap.L().Error("could not submit data deletion mutation",
+				zap.String("id", params.Id), zap.String("table", m.table), zap.Error(err))
+			return model.InternalError(fmt.Errorf("could not delete data from %s: %w", m.table, err))
+		}
+	}
+	return nil
+}
+
+func (r *ClickHouseReader) getDeletedTraceIds(
+	ctx context.Context, params *model.DataDeletionParams,
+) ([]string, *model.ApiError) {
+	query := fmt.Sprintf(
+		"SELECT DISTINCT traceID FROM %s.%s WHERE timestamp >= @start AND timestamp <= @end AND %s = @value LIMIT @limit",
+		r.TraceDB, r.indexTable, r.spansAttributeExpression(params.AttributeKey),
+	)
+	traceIds := []string{}
+	err := r.db.Select(ctx, &traceIds, query,
+		clickhouse.Named("start", strconv.FormatInt(params.Start.UnixNano(), 10)),
+		clickhouse.Named("end", strconv.FormatInt(params.End.UnixNano(), 10)),
+		clickhouse.Named("value", params.AttributeValue),
+		clickhouse.Named("limit", maxDeletedTraces+1),
+	)
+	if err != nil {
+		zap.L().Error("could not query traces to delete", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query traces to delete: %w", err))
+	}
+	if len(traceIds) > maxDeletedTraces {
+		return nil, model.BadRequest(fmt.Errorf(
+			"more than %d traces match, delete them over smaller time ranges", maxDeletedTraces,
+		))
+	}
+	return traceIds, nil
+}
+
+// GetDataDeletionProgress returns the progress of the mutations of a data
+// deletion on each table, across all the replicas of the cluster
+func (r *ClickHouseReader) GetDataDeletionProgress(
+	ctx context.Context, id string,
+) ([]model.DataDeletionMutation, *model.ApiError) {
+	query := fmt.Sprintf(`
+		SELECT database, table, count() as mutations, countIf(is_done = 1) as done,
+			sum(parts_to_do) as parts_to_do, max(latest_fail_reason) as fail_reason
+		FROM clusterAllReplicas('%s', system.mutations)
+		WHERE position(command, @marker) > 0
+		GROUP BY database, table
+		ORDER BY database, table`,
+		r.cluster,
+	)
+	progress := []model.DataDeletionMutation{}
+	err := r.db.Select(ctx, &progress, query,
+		clickhouse.Named("marker", dataDeletionMarker(id)),
+	)
+	if err != nil {
+		zap.L().Error("could not query data deletion mutations", zap.String("id", id), zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query data deletion mutations: %w", err))
+	}
+	return progress, nil
+}
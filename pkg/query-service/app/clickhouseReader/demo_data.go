@@ -0,0 +1,242 @@
+package clickhouseReader
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// demoSpanModel is the span json stored in the spans table, in the format
+// the SigNoz collector writes
+type demoSpanModel struct {
+	TraceId           string              `json:"traceId,omitempty"`
+	SpanId            string              `json:"spanId,omitempty"`
+	Name              string              `json:"name,omitempty"`
+	DurationNano      uint64              `json:"durationNano,omitempty"`
+	StartTimeUnixNano uint64              `json:"startTimeUnixNano,omitempty"`
+	ServiceName       string              `json:"serviceName,omitempty"`
+	Kind              int8                `json:"kind,omitempty"`
+	References        []model.OtelSpanRef `json:"references,omitempty"`
+	StatusCode        int16               `json:"statusCode,omitempty"`
+	TagMap            map[string]string   `json:"tagMap,omitempty"`
+	StringTagMap      map[string]string   `json:"stringTagMap,omitempty"`
+	NumberTagMap      map[string]float64  `json:"numberTagMap,omitempty"`
+	Events            []string            `json:"event,omitempty"`
+	HasError          bool                `json:"hasError,omitempty"`
+}
+
+func demoSpanTagMap(span model.DemoSpan) map[string]string {
+	tagMap := map[string]string{}
+	for k, v := range span.StringTagMap {
+		tagMap[k] = v
+	}
+	for k, v := range span.NumberTagMap {
+		tagMap[k] = fmt.Sprintf("%v", v)
+	}
+	return tagMap
+}
+
+func demoSpanEvents(span model.DemoSpan) ([]string, error) {
+	if span.Exception == nil {
+		return []string{}, nil
+	}
+	event, err := json.Marshal(model.Event{
+		Name:         "exception",
+		TimeUnixNano: uint64(span.Timestamp.UnixNano()) + span.DurationNano,
+		AttributeMap: map[string]interface{}{
+			"exception.type":       span.Exception.Type,
+			"exception.message":    span.Exception.Message,
+			"exception.stacktrace": span.Exception.Stacktrace,
+			"exception.escaped":    "false",
+		},
+		IsError: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(event)}, nil
+}
+
+func demoSpanModelJSON(span model.DemoSpan, events []string) (string, error) {
+	references := []model.OtelSpanRef{}
+	if span.ParentSpanId != "" {
+		references = append(references, model.OtelSpanRef{
+			TraceId: span.TraceId, SpanId: span.ParentSpanId, RefType: "CHILD_OF",
+		})
+	}
+	serialized, err := json.Marshal(demoSpanModel{
+		TraceId:           span.TraceId,
+		SpanId:            span.SpanId,
+		Name:              span.Name,
+		DurationNano:      span.DurationNano,
+		StartTimeUnixNano: uint64(span.Timestamp.UnixNano()),
+		ServiceName:       span.ServiceName,
+		Kind:              span.Kind,
+		References:        references,
+		StatusCode:        span.StatusCode,
+		TagMap:            demoSpanTagMap(span),
+		StringTagMap:      span.StringTagMap,
+		NumberTagMap:      span.NumberTagMap,
+		Events:            events,
+		HasError:          span.HasError,
+	})
+	return string(serialized), err
+}
+
+// sortedKeysAndValues returns the keys and values of a map as the parallel
+// arrays used by the logs table
+func sortedKeysAndValues(m map[string]string) ([]string, []string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return keys, values
+}
+
+// WriteDemoData writes generated sample telemetry to the traces, logs and
+// metrics tables the same way the SigNoz collector would
+func (r *ClickHouseReader) WriteDemoData(ctx context.Context, data *model.DemoData) *model.ApiError {
+	writes := []struct {
+		db      string
+		table   string
+		columns string
+		skip    bool
+		append  func(batch driver.Batch) error
+	}{
+		{
+			db:    r.TraceDB,
+			table: r.indexTable,
+			columns: "timestamp, traceID, spanID, parentSpanID, serviceName, name, kind, durationNano, statusCode, " +
+				"httpMethod, httpRoute, httpCode, responseStatusCode, hasError, events, tagMap, stringTagMap, " +
+				"numberTagMap, boolTagMap, resourceTagsMap",
+			skip: len(data.Spans) == 0,
+			append: func(batch driver.Batch) error {
+				for _, span := range data.Spans {
+					events, err := demoSpanEvents(span)
+					if err != nil {
+						return err
+					}
+					if err := batch.Append(
+						span.Timestamp, span.TraceId, span.SpanId, span.ParentSpanId, span.ServiceName,
+						span.Name, span.Kind, span.DurationNano, span.StatusCode, span.HttpMethod,
+						span.HttpRoute, span.HttpCode, span.HttpCode, span.HasError, events,
+						demoSpanTagMap(span), span.StringTagMap, span.NumberTagMap, map[string]bool{},
+						span.ResourceTagsMap,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			db:      r.TraceDB,
+			table:   r.SpansTable,
+			columns: "timestamp, traceID, model",
+			skip:    len(data.Spans) == 0,
+			append: func(batch driver.Batch) error {
+				for _, span := range data.Spans {
+					events, err := demoSpanEvents(span)
+					if err != nil {
+						return err
+					}
+					serialized, err := demoSpanModelJSON(span, events)
+					if err != nil {
+						return err
+					}
+					if err := batch.Append(span.Timestamp, span.TraceId, serialized); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			db:    r.TraceDB,
+			table: r.errorTable,
+			columns: "timestamp, errorID, groupID, traceID, spanID, serviceName, exceptionType, " +
+				"exceptionMessage, exceptionStacktrace, exceptionEscaped, resourceTagsMap",
+			skip: len(data.Spans) == 0,
+			append: func(batch driver.Batch) error {
+				for _, span := range data.Spans {
+					if span.Exception == nil {
+						continue
+					}
+					// errors are grouped the way the collector groups them by default
+					groupId := fmt.Sprintf("%x", md5.Sum([]byte(
+						span.ServiceName+span.Exception.Type+span.Exception.Message,
+					)))
+					errorId := strings.ReplaceAll(uuid.NewString(), "-", "")
+					if err := batch.Append(
+						span.Timestamp, errorId, groupId, span.TraceId, span.SpanId,
+						span.ServiceName, span.Exception.Type, span.Exception.Message,
+						span.Exception.Stacktrace, false, span.ResourceTagsMap,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			db:    r.logsDB,
+			table: r.logsTable,
+			columns: "timestamp, observed_timestamp, id, trace_id, span_id, severity_text, severity_number, body, " +
+				"resources_string_key, resources_string_value, attributes_string_key, attributes_string_value",
+			skip: len(data.Logs) == 0,
+			append: func(batch driver.Batch) error {
+				for _, log := range data.Logs {
+					resourceKeys, resourceValues := sortedKeysAndValues(log.Resources)
+					attributeKeys, attributeValues := sortedKeysAndValues(log.Attributes)
+					if err := batch.Append(
+						uint64(log.Timestamp.UnixNano()), uint64(log.Timestamp.UnixNano()), log.Id,
+						log.TraceId, log.SpanId, log.SeverityText, log.SeverityNumber, log.Body,
+						resourceKeys, resourceValues, attributeKeys, attributeValues,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, write := range writes {
+		if write.skip {
+			continue
+		}
+		batch, err := r.db.PrepareBatch(ctx, fmt.Sprintf(
+			"INSERT INTO %s.%s (%s)", write.db, write.table, write.columns,
+		))
+		if err != nil {
+			zap.L().Error("could not prepare demo data batch", zap.String("table", write.table), zap.Error(err))
+			return model.InternalError(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+		if err := write.append(batch); err != nil {
+			batch.Abort()
+			return model.InternalError(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+		if err := batch.Send(); err != nil {
+			zap.L().Error("could not send demo data batch", zap.String("table", write.table), zap.Error(err))
+			return model.InternalError(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+	}
+
+	if data.Metrics != nil && len(data.Metrics.Timeseries) > 0 {
+		return r.WriteRemoteWriteRequest(ctx, data.Metrics)
+	}
+	return nil
+}
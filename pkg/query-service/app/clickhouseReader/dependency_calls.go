@@ -0,0 +1,134 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// spans of outgoing calls to databases and external services have the client kind
+const spanKindClient = 3
+
+// dependencyCallsQuery builds the query aggregating the client spans matching
+// where by groupBy, the most time consuming groups come first
+func (r *ClickHouseReader) dependencyCallsQuery(
+	ctx context.Context, queryParams *model.GetDependencyCallsParams, selectColumns string, where string, groupBy string,
+) (string, []interface{}, *model.ApiError) {
+	args := []interface{}{
+		clickhouse.Named("start", strconv.FormatInt(queryParams.Start.UnixNano(), 10)),
+		clickhouse.Named("end", strconv.FormatInt(queryParams.End.UnixNano(), 10)),
+		clickhouse.Named("kind", spanKindClient),
+		clickhouse.Named("limit", queryParams.Limit),
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s,
+			quantile(0.5)(durationNano) as p50,
+			quantile(0.95)(durationNano) as p95,
+			quantile(0.99)(durationNano) as p99,
+			COUNT(*) as numCalls,
+			countIf(statusCode=2) as errorCount,
+			toFloat64(sum(durationNano)) as totalDuration
+		FROM %s.%s
+		WHERE timestamp >= @start AND timestamp <= @end AND kind = @kind AND %s`,
+		selectColumns, r.TraceDB, r.indexTable, where,
+	)
+	if queryParams.ServiceName != "" {
+		query += " AND serviceName = @serviceName"
+		args = append(args, clickhouse.Named("serviceName", queryParams.ServiceName))
+	}
+
+	tags := createTagQueryFromTagQueryParams(queryParams.Tags)
+	subQuery, argsSubQuery, errStatus := buildQueryWithTagParams(ctx, tags)
+	if errStatus != nil {
+		return "", nil, errStatus
+	}
+	query += subQuery
+	args = append(args, argsSubQuery...)
+
+	query += fmt.Sprintf(" GROUP BY %s ORDER BY totalDuration DESC LIMIT @limit", groupBy)
+	return query, args, nil
+}
+
+// GetDatabaseCalls aggregates database client spans by db system and
+// statement fingerprint
+func (r *ClickHouseReader) GetDatabaseCalls(
+	ctx context.Context, queryParams *model.GetDependencyCallsParams,
+) (*[]model.DatabaseCallsItem, *model.ApiError) {
+	fingerprint, _ := tracesV3.DerivedAttributeExpression(constants.DBStatementFingerprint)
+	statement := fmt.Sprintf("if(stringTagMap['db.statement'] != '', %s, dbOperation)", fingerprint)
+
+	query, args, apiErr := r.dependencyCallsQuery(
+		ctx, queryParams,
+		fmt.Sprintf("dbSystem, %s as statement", statement),
+		"dbSystem != ''",
+		"dbSystem, statement",
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []model.DatabaseCallsItem{}
+	err := r.db.Select(ctx, &items, query, args...)
+	zap.S().Debug(query)
+	if err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query")}
+	}
+
+	seconds := queryParams.End.Sub(*queryParams.Start).Seconds()
+	for i := range items {
+		items[i].CallRate, items[i].ErrorRate = callRates(items[i].NumCalls, items[i].ErrorCount, seconds)
+	}
+	return &items, nil
+}
+
+// GetExternalCalls aggregates client spans of http calls by the host called
+func (r *ClickHouseReader) GetExternalCalls(
+	ctx context.Context, queryParams *model.GetDependencyCallsParams,
+) (*[]model.ExternalCallsItem, *model.ApiError) {
+	host := "if(domain(externalHttpUrl) != '', domain(externalHttpUrl), externalHttpUrl)"
+
+	query, args, apiErr := r.dependencyCallsQuery(
+		ctx, queryParams,
+		fmt.Sprintf("%s as host", host),
+		"externalHttpUrl != ''",
+		"host",
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []model.ExternalCallsItem{}
+	err := r.db.Select(ctx, &items, query, args...)
+	zap.S().Debug(query)
+	if err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query")}
+	}
+
+	seconds := queryParams.End.Sub(*queryParams.Start).Seconds()
+	for i := range items {
+		items[i].CallRate, items[i].ErrorRate = callRates(items[i].NumCalls, items[i].ErrorCount, seconds)
+	}
+	return &items, nil
+}
+
+// callRates returns the calls per second and the percentage of failed calls
+func callRates(numCalls uint64, errorCount uint64, seconds float64) (float64, float64) {
+	callRate, errorRate := 0.0, 0.0
+	if seconds > 0 {
+		callRate = float64(numCalls) / seconds
+	}
+	if numCalls > 0 {
+		errorRate = float64(errorCount) * 100 / float64(numCalls)
+	}
+	return callRate, errorRate
+}
@@ -0,0 +1,112 @@
+package clickhouseReader
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// argsRecordingConn records the named arguments of the selects
+type argsRecordingConn struct {
+	driver.Conn
+	args map[string]interface{}
+}
+
+func (c *argsRecordingConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	for _, arg := range args {
+		if named, ok := arg.(driver.NamedValue); ok {
+			c.args[named.Name] = named.Value
+		}
+	}
+	return c.Conn.Select(ctx, dest, query, args...)
+}
+
+func TestDependencyCallsQueries(t *testing.T) {
+	start := time.Unix(0, 1700000000000000000)
+	end := start.Add(time.Hour)
+	params := &model.GetDependencyCallsParams{
+		Start: &start, End: &end, ServiceName: "checkout", Limit: 10,
+		Tags: []model.TagQueryParam{{
+			Key: "http.route", TagType: model.SpanAttributeTagType, StringValues: []string{"/cart"}, Operator: model.EqualOperator,
+		}},
+	}
+	where := func(dependency string) string {
+		return regexp.QuoteMeta("FROM signoz_traces.signoz_index_v2 "+
+			"WHERE timestamp >= @start AND timestamp <= @end AND kind = @kind AND "+dependency+" AND serviceName = @serviceName") +
+			` AND \(stringTagMap\[@arithmeticTagKey\w+\] = @arithmeticTagValue\w+\)`
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		get   func(r *ClickHouseReader) *model.ApiError
+	}{
+		{
+			name: "database calls",
+			query: `SELECT\s+dbSystem, if\(stringTagMap\['db.statement'\] != '', .+, dbOperation\) as statement,.+` +
+				where("dbSystem != ''") + regexp.QuoteMeta(" GROUP BY dbSystem, statement ORDER BY totalDuration DESC LIMIT @limit"),
+			get: func(r *ClickHouseReader) *model.ApiError {
+				_, apiErr := r.GetDatabaseCalls(context.Background(), params)
+				return apiErr
+			},
+		},
+		{
+			name: "external calls",
+			query: regexp.QuoteMeta("if(domain(externalHttpUrl) != '', domain(externalHttpUrl), externalHttpUrl) as host,") + ".+" +
+				where("externalHttpUrl != ''") + regexp.QuoteMeta(" GROUP BY host ORDER BY totalDuration DESC LIMIT @limit"),
+			get: func(r *ClickHouseReader) *model.ApiError {
+				_, apiErr := r.GetExternalCalls(context.Background(), params)
+				return apiErr
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			mock, err := cmock.NewClickHouseWithQueryMatcher(nil, sqlmock.QueryMatcherRegexp)
+			require.Nil(err)
+			mock.ExpectSelect("(?s)" + test.query)
+			conn := &argsRecordingConn{Conn: mock, args: map[string]interface{}{}}
+			reader := &ClickHouseReader{db: conn, TraceDB: "signoz_traces", indexTable: "signoz_index_v2"}
+
+			require.Nil(test.get(reader))
+			require.Nil(mock.ExpectationsWereMet())
+			require.Equal(spanKindClient, conn.args["kind"], "only the client spans are calls to dependencies")
+			require.Equal("1700000000000000000", conn.args["start"])
+			require.Equal("1700003600000000000", conn.args["end"])
+			require.Equal("checkout", conn.args["serviceName"])
+			require.Equal(10, conn.args["limit"])
+		})
+	}
+}
+
+func TestCallRates(t *testing.T) {
+	tests := []struct {
+		name       string
+		numCalls   uint64
+		errorCount uint64
+		seconds    float64
+		callRate   float64
+		errorRate  float64
+	}{
+		{name: "calls", numCalls: 120, errorCount: 30, seconds: 60, callRate: 2, errorRate: 25},
+		{name: "no errors", numCalls: 10, seconds: 10, callRate: 1},
+		{name: "zero seconds", numCalls: 10, errorCount: 1, seconds: 0, callRate: 0, errorRate: 10},
+		{name: "negative seconds", numCalls: 10, seconds: -5},
+		{name: "zero calls", numCalls: 0, seconds: 60},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callRate, errorRate := callRates(test.numCalls, test.errorCount, test.seconds)
+			require.Equal(t, test.callRate, callRate)
+			require.Equal(t, test.errorRate, errorRate)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// GetK8sPodEvents returns the kubernetes events of pods collected by the k8s
+// events receiver in the given time range, oldest first
+func (r *ClickHouseReader) GetK8sPodEvents(
+	ctx context.Context, params *model.K8sPodTimelineParams,
+) ([]model.K8sEvent, *model.ApiError) {
+	resource := func(key string) string {
+		return fmt.Sprintf("resources_string_value[indexOf(resources_string_key, '%s')]", key)
+	}
+	attribute := func(key string) string {
+		return fmt.Sprintf("attributes_string_value[indexOf(attributes_string_key, '%s')]", key)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			timestamp,
+			%s as namespace,
+			%s as object_kind,
+			%s as object_name,
+			%s as field_path,
+			%s as reason,
+			%s as count,
+			severity_text as severity,
+			body as message
+		FROM %s.%s
+		WHERE timestamp >= @start AND timestamp <= @end
+		AND object_kind = 'Pod' AND reason != ''`,
+		resource("k8s.namespace.name"), resource("k8s.object.kind"), resource("k8s.object.name"),
+		resource("k8s.object.fieldpath"), attribute("k8s.event.reason"), attribute("k8s.event.count"),
+		r.logsDB, r.logsTable,
+	)
+	args := []interface{}{
+		clickhouse.Named("start", uint64(params.Start.UnixNano())),
+		clickhouse.Named("end", uint64(params.End.UnixNano())),
+		clickhouse.Named("limit", params.Limit),
+	}
+	if params.Namespace != "" {
+		query += " AND namespace = @namespace"
+		args = append(args, clickhouse.Named("namespace", params.Namespace))
+	}
+	if params.Pod != "" {
+		query += " AND object_name = @pod"
+		args = append(args, clickhouse.Named("pod", params.Pod))
+	}
+	query += " ORDER BY timestamp ASC LIMIT @limit"
+
+	events := []model.K8sEvent{}
+	if err := r.db.Select(ctx, &events, query, args...); err != nil {
+		zap.L().Error("could not query k8s events", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query k8s events: %w", err))
+	}
+	return events, nil
+}
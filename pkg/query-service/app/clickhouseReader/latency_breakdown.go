@@ -0,0 +1,77 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// GetLatencyBreakdown aggregates the spans of an operation by the value of
+// an attribute, the values contributing the most time first
+func (r *ClickHouseReader) GetLatencyBreakdown(
+	ctx context.Context, queryParams *model.GetLatencyBreakdownParams,
+) (*[]model.LatencyBreakdownItem, *model.ApiError) {
+	keys, err := r.GetSpanAttributeKeys(ctx)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	// only known attributes are queried as the key is part of the query
+	_, isDerived := tracesV3.DerivedAttributeExpression(queryParams.Attribute)
+	if _, ok := keys[queryParams.Attribute]; !ok && !isDerived {
+		return nil, model.BadRequest(fmt.Errorf("unknown span attribute %s", queryParams.Attribute))
+	}
+	attribute := tracesV3.AttributeExpression(v3.AttributeKey{Key: queryParams.Attribute}, keys)
+
+	args := []interface{}{
+		clickhouse.Named("start", strconv.FormatInt(queryParams.Start.UnixNano(), 10)),
+		clickhouse.Named("end", strconv.FormatInt(queryParams.End.UnixNano(), 10)),
+		clickhouse.Named("serviceName", queryParams.ServiceName),
+		clickhouse.Named("operation", queryParams.Operation),
+		clickhouse.Named("limit", queryParams.Limit),
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			toString(%s) as value,
+			quantile(0.5)(durationNano) as p50,
+			quantile(0.90)(durationNano) as p90,
+			quantile(0.95)(durationNano) as p95,
+			quantile(0.99)(durationNano) as p99,
+			COUNT(*) as numCalls,
+			countIf(statusCode=2) as errorCount,
+			toFloat64(sum(durationNano)) as totalDuration
+		FROM %s.%s
+		WHERE serviceName = @serviceName AND name = @operation AND timestamp >= @start AND timestamp <= @end`,
+		attribute, r.TraceDB, r.indexTable,
+	)
+
+	tags := createTagQueryFromTagQueryParams(queryParams.Tags)
+	subQuery, argsSubQuery, errStatus := buildQueryWithTagParams(ctx, tags)
+	if errStatus != nil {
+		return nil, errStatus
+	}
+	query += subQuery
+	args = append(args, argsSubQuery...)
+
+	query += " GROUP BY value ORDER BY totalDuration DESC LIMIT @limit"
+
+	items := []model.LatencyBreakdownItem{}
+	err = r.db.Select(ctx, &items, query, args...)
+	zap.S().Debug(query)
+	if err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query")}
+	}
+
+	seconds := queryParams.End.Sub(*queryParams.Start).Seconds()
+	for i := range items {
+		items[i].CallRate, items[i].ErrorRate = callRates(items[i].NumCalls, items[i].ErrorCount, seconds)
+	}
+	return &items, nil
+}
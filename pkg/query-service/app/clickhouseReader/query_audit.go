@@ -0,0 +1,313 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	queryAuditDB         = "signoz_analytics"
+	queryAuditLocalTable = "query_audit"
+	queryAuditTable      = "distributed_query_audit"
+	queryAuditTTLDays    = 30
+
+	queryAuditBufferSize    = 10000
+	queryAuditBatchSize     = 1000
+	queryAuditFlushInterval = 10 * time.Second
+)
+
+type queryAuditRecord struct {
+	timestamp  time.Time
+	user       string
+	sourceType string
+	sourceId   string
+	query      string
+	readRows   uint64
+	readBytes  uint64
+	durationMs uint64
+	failed     bool
+}
+
+// auditedConn records a sample of the queries run through it in the query
+// audit table, which backs the query cost attribution report
+type auditedConn struct {
+	clickhouse.Conn
+
+	sampleRate float64
+	records    chan queryAuditRecord
+}
+
+func newAuditedConn(db clickhouse.Conn, cluster string, sampleRate float64) *auditedConn {
+	c := &auditedConn{
+		Conn:       db,
+		sampleRate: sampleRate,
+		records:    make(chan queryAuditRecord, queryAuditBufferSize),
+	}
+	go c.run(cluster)
+	return c
+}
+
+// queryAudit tracks the progress of a single sampled query
+type queryAudit struct {
+	conn    *auditedConn
+	started time.Time
+	record  queryAuditRecord
+
+	mu   sync.Mutex
+	once sync.Once
+}
+
+func (c *auditedConn) sample(ctx context.Context, query string) (context.Context, *queryAudit) {
+	if rand.Float64() >= c.sampleRate {
+		return ctx, nil
+	}
+
+	audit := &queryAudit{
+		conn:    c,
+		started: time.Now(),
+		record: queryAuditRecord{
+			query: query,
+		},
+	}
+	if user := common.GetUserFromContext(ctx); user != nil {
+		audit.record.user = user.Email
+	}
+	if source := common.GetQuerySourceFromContext(ctx); source != nil {
+		audit.record.sourceType = source.Type
+		audit.record.sourceId = source.Id
+	}
+
+	ctx = clickhouse.Context(ctx, clickhouse.WithProgress(audit.progress))
+	return ctx, audit
+}
+
+// progress adds up the rows and bytes read, the server reports them in
+// increments while the query runs
+func (a *queryAudit) progress(p *clickhouse.Progress) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.record.readRows += p.Rows
+	a.record.readBytes += p.Bytes
+}
+
+func (a *queryAudit) done(err error) {
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {
+		a.mu.Lock()
+		record := a.record
+		a.mu.Unlock()
+
+		record.timestamp = a.started
+		record.durationMs = uint64(time.Since(a.started).Milliseconds())
+		record.failed = err != nil
+
+		select {
+		case a.conn.records <- record:
+		default:
+			// audit records are best effort, drop them rather than slow down queries
+		}
+	})
+}
+
+func (c *auditedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, audit := c.sample(ctx, query)
+	err := c.Conn.Select(ctx, dest, query, args...)
+	audit.done(err)
+	return err
+}
+
+func (c *auditedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	ctx, audit := c.sample(ctx, query)
+	rows, err := c.Conn.Query(ctx, query, args...)
+	if err != nil || audit == nil {
+		audit.done(err)
+		return rows, err
+	}
+	return &auditedRows{Rows: rows, audit: audit}, nil
+}
+
+func (c *auditedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	ctx, audit := c.sample(ctx, query)
+	row := c.Conn.QueryRow(ctx, query, args...)
+	audit.done(row.Err())
+	return row
+}
+
+// auditedRows records the query once all its rows have been read
+type auditedRows struct {
+	driver.Rows
+	audit *queryAudit
+}
+
+func (r *auditedRows) Close() error {
+	err := r.Rows.Close()
+	if rowsErr := r.Rows.Err(); rowsErr != nil {
+		err = rowsErr
+	}
+	r.audit.done(err)
+	return err
+}
+
+func (c *auditedConn) run(cluster string) {
+	if err := c.ensureTables(cluster); err != nil {
+		zap.L().Error("could not create query audit tables, queries will not be audited", zap.Error(err))
+		for range c.records {
+			// keep draining so that queries never block on a full buffer
+		}
+		return
+	}
+
+	ticker := time.NewTicker(queryAuditFlushInterval)
+	defer ticker.Stop()
+
+	batch := []queryAuditRecord{}
+	for {
+		select {
+		case record := <-c.records:
+			batch = append(batch, record)
+			if len(batch) < queryAuditBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := c.write(batch); err != nil {
+			zap.L().Error("could not write query audit records", zap.Int("count", len(batch)), zap.Error(err))
+		}
+		batch = []queryAuditRecord{}
+	}
+}
+
+func (c *auditedConn) ensureTables(cluster string) error {
+	ctx := context.Background()
+	statements := []string{
+		fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s ON CLUSTER %s", queryAuditDB, cluster),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s (
+			timestamp DateTime64(3) CODEC(DoubleDelta, ZSTD(1)),
+			user LowCardinality(String) CODEC(ZSTD(1)),
+			source_type LowCardinality(String) CODEC(ZSTD(1)),
+			source_id String CODEC(ZSTD(1)),
+			query String CODEC(ZSTD(1)),
+			read_rows UInt64 CODEC(ZSTD(1)),
+			read_bytes UInt64 CODEC(ZSTD(1)),
+			duration_ms UInt64 CODEC(ZSTD(1)),
+			failed Bool CODEC(ZSTD(1))
+		) ENGINE = MergeTree
+		ORDER BY (timestamp, user)
+		TTL toDateTime(timestamp) + INTERVAL %d DAY DELETE`,
+			queryAuditDB, queryAuditLocalTable, cluster, queryAuditTTLDays),
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s AS %s.%s ENGINE = Distributed(%s, %s, %s, rand())",
+			queryAuditDB, queryAuditTable, cluster, queryAuditDB, queryAuditLocalTable,
+			cluster, queryAuditDB, queryAuditLocalTable,
+		),
+	}
+	for _, statement := range statements {
+		if err := c.Conn.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *auditedConn) write(records []queryAuditRecord) error {
+	// inserts go through the embedded conn so that they are not audited themselves
+	batch, err := c.Conn.PrepareBatch(context.Background(), fmt.Sprintf(
+		"INSERT INTO %s.%s (timestamp, user, source_type, source_id, query, read_rows, read_bytes, duration_ms, failed)",
+		queryAuditDB, queryAuditTable,
+	))
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		err := batch.Append(
+			r.timestamp, r.user, r.sourceType, r.sourceId, r.query,
+			r.readRows, r.readBytes, r.durationMs, r.failed,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+var queryAuditGroupByColumns = map[string]string{
+	model.QueryAuditGroupByUser:      "user",
+	model.QueryAuditGroupByDashboard: "source_id",
+	model.QueryAuditGroupByAlert:     "source_id",
+}
+
+var queryAuditGroupBySourceTypes = map[string]string{
+	model.QueryAuditGroupByDashboard: common.QuerySourceDashboard,
+	model.QueryAuditGroupByAlert:     common.QuerySourceAlert,
+}
+
+// GetQueryAuditReport returns the users, dashboards or alerts which scanned
+// the most data in the given time range according to the sampled queries
+func (r *ClickHouseReader) GetQueryAuditReport(
+	ctx context.Context, params *model.QueryAuditReportParams,
+) (*model.QueryAuditReport, *model.ApiError) {
+	sampleRate := 0.0
+	if conn, ok := r.db.(*auditedConn); ok {
+		sampleRate = conn.sampleRate
+	}
+	report := &model.QueryAuditReport{
+		SampleRate: sampleRate,
+		GroupBy:    params.GroupBy,
+		Items:      []model.QueryAuditReportItem{},
+	}
+	if sampleRate == 0 {
+		return report, nil
+	}
+
+	column := queryAuditGroupByColumns[params.GroupBy]
+	filter := "user != ''"
+	args := []interface{}{
+		clickhouse.Named("start", params.Start), clickhouse.Named("end", params.End),
+		clickhouse.Named("limit", params.Limit),
+	}
+	if sourceType, ok := queryAuditGroupBySourceTypes[params.GroupBy]; ok {
+		filter = "source_type = @sourceType"
+		args = append(args, clickhouse.Named("sourceType", sourceType))
+	}
+
+	query := fmt.Sprintf(`SELECT %s AS key, count() AS queries, sum(read_rows) AS read_rows,
+		sum(read_bytes) AS read_bytes, sum(duration_ms) AS duration_ms
+		FROM %s.%s
+		WHERE timestamp >= fromUnixTimestamp64Milli(@start) AND timestamp <= fromUnixTimestamp64Milli(@end) AND %s
+		GROUP BY key
+		ORDER BY read_bytes DESC
+		LIMIT @limit`, column, queryAuditDB, queryAuditTable, filter)
+
+	rows := []struct {
+		Key        string `ch:"key"`
+		Queries    uint64 `ch:"queries"`
+		ReadRows   uint64 `ch:"read_rows"`
+		ReadBytes  uint64 `ch:"read_bytes"`
+		DurationMs uint64 `ch:"duration_ms"`
+	}{}
+	if err := r.db.Select(ctx, &rows, query, args...); err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not query the query audit table: %w", err))
+	}
+
+	for _, row := range rows {
+		report.Items = append(report.Items, model.NewQueryAuditReportItem(
+			row.Key, row.Queries, row.ReadRows, row.ReadBytes, row.DurationMs, sampleRate,
+		))
+	}
+	return report, nil
+}
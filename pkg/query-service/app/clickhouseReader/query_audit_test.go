@@ -0,0 +1,205 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// fakeConn records the statements run on it and fails the ones in failures
+type fakeConn struct {
+	clickhouse.Conn
+	statements []string
+	failures   map[string]error
+}
+
+func (c *fakeConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	c.statements = append(c.statements, query)
+	return c.failures[query]
+}
+
+func (c *fakeConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	c.statements = append(c.statements, query)
+	if err := c.failures[query]; err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	c.statements = append(c.statements, query)
+	return &fakeRow{err: c.failures[query]}
+}
+
+func (c *fakeConn) Exec(ctx context.Context, query string, args ...any) error {
+	c.statements = append(c.statements, query)
+	return c.failures[query]
+}
+
+type fakeRows struct {
+	driver.Rows
+	err    error
+	closed int
+}
+
+func (r *fakeRows) Close() error {
+	r.closed++
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+type fakeRow struct {
+	driver.Row
+	err error
+}
+
+func (r *fakeRow) Err() error {
+	return r.err
+}
+
+func newTestAuditedConn(sampleRate float64, buffer int) (*auditedConn, *fakeConn) {
+	db := &fakeConn{failures: map[string]error{}}
+	// the records are read by the test instead of being written by run
+	return &auditedConn{Conn: db, sampleRate: sampleRate, records: make(chan queryAuditRecord, buffer)}, db
+}
+
+func TestQueryAuditSampling(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	conn, _ := newTestAuditedConn(0, 10)
+	for i := 0; i < 100; i++ {
+		sampledCtx, audit := conn.sample(ctx, "SELECT 1")
+		assert.Nil(audit)
+		assert.Equal(ctx, sampledCtx, "queries which aren't sampled are run as is")
+	}
+
+	conn, _ = newTestAuditedConn(1, 10)
+	for i := 0; i < 100; i++ {
+		_, audit := conn.sample(ctx, "SELECT 1")
+		assert.NotNil(audit)
+	}
+
+	conn, _ = newTestAuditedConn(0.25, 10)
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		if _, audit := conn.sample(ctx, "SELECT 1"); audit != nil {
+			sampled++
+		}
+	}
+	assert.InDelta(2500, sampled, 300)
+
+	// the user and the dashboard or alert of the query are recorded
+	conn, _ = newTestAuditedConn(1, 10)
+	ctx = context.WithValue(ctx, constants.ContextUserKey, &model.UserPayload{
+		User: model.User{Email: "alice@signoz.io"},
+	})
+	ctx = common.ContextWithQuerySource(ctx, common.QuerySource{Type: common.QuerySourceDashboard, Id: "dashboard-1"})
+	_, audit := conn.sample(ctx, "SELECT 1")
+	audit.progress(&clickhouse.Progress{Rows: 10, Bytes: 100})
+	audit.progress(&clickhouse.Progress{Rows: 5, Bytes: 50})
+	audit.done(nil)
+	audit.done(fmt.Errorf("failed"))
+
+	assert.Len(conn.records, 1, "a query is recorded once")
+	record := <-conn.records
+	assert.Equal("alice@signoz.io", record.user)
+	assert.Equal(common.QuerySourceDashboard, record.sourceType)
+	assert.Equal("dashboard-1", record.sourceId)
+	assert.Equal("SELECT 1", record.query)
+	assert.Equal(uint64(15), record.readRows)
+	assert.Equal(uint64(150), record.readBytes)
+	assert.False(record.failed)
+	assert.False(record.timestamp.IsZero())
+
+	// records are dropped rather than blocking queries on a full buffer
+	conn, _ = newTestAuditedConn(1, 1)
+	for i := 0; i < 3; i++ {
+		_, audit := conn.sample(context.Background(), "SELECT 1")
+		audit.done(nil)
+	}
+	assert.Len(conn.records, 1)
+
+	var noAudit *queryAudit
+	noAudit.done(nil)
+}
+
+func TestAuditedConn(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	failure := fmt.Errorf("failed")
+
+	conn, db := newTestAuditedConn(1, 10)
+	db.failures["SELECT failing"] = failure
+
+	assert.Nil(conn.Select(ctx, nil, "SELECT 1"))
+	assert.Equal(failure, conn.Select(ctx, nil, "SELECT failing"))
+	assert.Nil(conn.QueryRow(ctx, "SELECT 2").Err())
+	assert.Equal(failure, conn.QueryRow(ctx, "SELECT failing").Err())
+	_, err := conn.Query(ctx, "SELECT failing")
+	assert.Equal(failure, err)
+
+	records := readAuditRecords(conn)
+	assert.Equal([]string{"SELECT 1", "SELECT failing", "SELECT 2", "SELECT failing", "SELECT failing"}, auditedQueries(records))
+	assert.Equal([]bool{false, true, false, true, true}, failedQueries(records))
+
+	// queries returning rows are recorded once the rows are closed, with
+	// the error hit while reading them
+	rows, err := conn.Query(ctx, "SELECT 3")
+	assert.Nil(err)
+	assert.Empty(conn.records)
+	rows.(*auditedRows).Rows.(*fakeRows).err = failure
+	assert.Equal(failure, rows.Close())
+	assert.Equal(failure, rows.Close())
+	assert.Equal(2, rows.(*auditedRows).Rows.(*fakeRows).closed)
+	records = readAuditRecords(conn)
+	assert.Equal([]string{"SELECT 3"}, auditedQueries(records))
+	assert.Equal([]bool{true}, failedQueries(records))
+
+	// execs are not audited
+	assert.Nil(conn.Exec(ctx, "ALTER TABLE logs"))
+	assert.Empty(conn.records)
+
+	// rows of queries which aren't sampled are not wrapped
+	conn, db = newTestAuditedConn(0, 10)
+	rows, err = conn.Query(ctx, "SELECT 4")
+	assert.Nil(err)
+	assert.IsType(&fakeRows{}, rows)
+	assert.Nil(conn.Select(ctx, nil, "SELECT 5"))
+	assert.Empty(conn.records)
+	assert.Equal([]string{"SELECT 4", "SELECT 5"}, db.statements)
+}
+
+func readAuditRecords(conn *auditedConn) []queryAuditRecord {
+	records := []queryAuditRecord{}
+	for len(conn.records) > 0 {
+		records = append(records, <-conn.records)
+	}
+	return records
+}
+
+func auditedQueries(records []queryAuditRecord) []string {
+	queries := []string{}
+	for _, r := range records {
+		queries = append(queries, r.query)
+	}
+	return queries
+}
+
+func failedQueries(records []queryAuditRecord) []bool {
+	failed := []bool{}
+	for _, r := range records {
+		failed = append(failed, r.failed)
+	}
+	return failed
+}
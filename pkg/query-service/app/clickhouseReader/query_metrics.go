@@ -0,0 +1,66 @@
+package clickhouseReader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
+)
+
+// instrumentedConn records the duration of every query run through it in
+// the query service metrics
+type instrumentedConn struct {
+	clickhouse.Conn
+}
+
+func (c *instrumentedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	start := time.Now()
+	err := c.Conn.Select(ctx, dest, query, args...)
+	instrumentation.ObserveClickhouseQuery("select", start, err)
+	return err
+}
+
+func (c *instrumentedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.Conn.Query(ctx, query, args...)
+	if err != nil {
+		instrumentation.ObserveClickhouseQuery("query", start, err)
+		return rows, err
+	}
+	return &instrumentedRows{Rows: rows, start: start}, nil
+}
+
+func (c *instrumentedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	start := time.Now()
+	row := c.Conn.QueryRow(ctx, query, args...)
+	instrumentation.ObserveClickhouseQuery("query_row", start, row.Err())
+	return row
+}
+
+func (c *instrumentedConn) Exec(ctx context.Context, query string, args ...any) error {
+	start := time.Now()
+	err := c.Conn.Exec(ctx, query, args...)
+	instrumentation.ObserveClickhouseQuery("exec", start, err)
+	return err
+}
+
+// instrumentedRows records the query once all its rows have been read
+type instrumentedRows struct {
+	driver.Rows
+	start time.Time
+	once  sync.Once
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	if rowsErr := r.Rows.Err(); rowsErr != nil {
+		err = rowsErr
+	}
+	r.once.Do(func() {
+		instrumentation.ObserveClickhouseQuery("query", r.start, err)
+	})
+	return err
+}
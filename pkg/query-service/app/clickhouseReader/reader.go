@@ -46,6 +46,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
 	"go.signoz.io/signoz/pkg/query-service/app/logs"
 	"go.signoz.io/signoz/pkg/query-service/app/services"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
@@ -121,6 +122,9 @@ type ClickHouseReader struct {
 
 	liveTailRefreshSeconds int
 	cluster                string
+
+	attributeRefresh attributeRefresh
+	schemaCheck      schemaCheck
 }
 
 // NewTraceReader returns a TraceReader for the database
@@ -161,6 +165,11 @@ func NewReaderFromClickhouseConnection(
 		os.Exit(1)
 	}
 
+	db = &instrumentedConn{Conn: db}
+	if constants.QueryAuditSampleRate > 0 {
+		db = newAuditedConn(db, cluster, constants.QueryAuditSampleRate)
+	}
+
 	return &ClickHouseReader{
 		db:                      db,
 		localDB:                 localDB,
@@ -369,6 +378,7 @@ func (r *ClickHouseReader) Start(readerReady chan bool) {
 	r.queryEngine = queryEngine
 	r.remoteStorage = remoteStorage
 	r.fanoutStorage = &fanoutStorage
+	r.logSchemaStatus(context.Background())
 	readerReady <- true
 
 	if err := g.Run(); err != nil {
@@ -1970,6 +1980,91 @@ func (r *ClickHouseReader) GetUsage(ctx context.Context, queryParams *model.GetU
 	return &usageItems, nil
 }
 
+// GetExistingTraceIDs returns the ids among traceIDs having spans stored
+// between start and end (ns). The time range bounds the parts scanned, so
+// it is required.
+func (r *ClickHouseReader) GetExistingTraceIDs(
+	ctx context.Context, traceIDs []string, start, end int64,
+) (map[string]struct{}, *model.ApiError) {
+	existing := map[string]struct{}{}
+	if len(traceIDs) == 0 {
+		return existing, nil
+	}
+	if start <= 0 || end < start {
+		return nil, model.BadRequest(fmt.Errorf("a valid time range is required to look for traces"))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT DISTINCT traceID FROM %s.%s WHERE traceID IN @traceIDs AND timestamp >= @timestampL AND timestamp <= @timestampU",
+		r.TraceDB, r.indexTable,
+	)
+	rows, err := r.db.Query(ctx, query,
+		clickhouse.Named("traceIDs", traceIDs),
+		clickhouse.Named("timestampL", strconv.FormatInt(start, 10)),
+		clickhouse.Named("timestampU", strconv.FormatInt(end, 10)),
+	)
+	if err != nil {
+		zap.L().Error("Error while querying existing trace ids", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var traceID string
+		if err := rows.Scan(&traceID); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+		existing[traceID] = struct{}{}
+	}
+	return existing, nil
+}
+
+// maxTraceSpanAttributes caps the spans whose attributes are read for a
+// trace, traces are usually far smaller
+const maxTraceSpanAttributes = 10000
+
+// GetTraceSpanAttributes returns the resource and span attributes of each
+// span of a trace, keyed by attribute name
+func (r *ClickHouseReader) GetTraceSpanAttributes(
+	ctx context.Context, traceID string,
+) ([]map[string]interface{}, *model.ApiError) {
+	query := fmt.Sprintf(`SELECT resourceTagsMap, stringTagMap, numberTagMap, boolTagMap
+		FROM %s.%s WHERE traceID = @traceID LIMIT %d`, r.TraceDB, r.indexTable, maxTraceSpanAttributes)
+
+	rows, err := r.db.Query(ctx, query, clickhouse.Named("traceID", traceID))
+	if err != nil {
+		zap.L().Error("Error while querying the span attributes of a trace", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+
+	spans := []map[string]interface{}{}
+	for rows.Next() {
+		var resourceTags, stringTags map[string]string
+		var numberTags map[string]float64
+		var boolTags map[string]bool
+		if err := rows.Scan(&resourceTags, &stringTags, &numberTags, &boolTags); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+
+		span := map[string]interface{}{}
+		for key, value := range resourceTags {
+			span[key] = value
+		}
+		for key, value := range stringTags {
+			span[key] = value
+		}
+		for key, value := range numberTags {
+			span[key] = value
+		}
+		for key, value := range boolTags {
+			span[key] = value
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
 func (r *ClickHouseReader) SearchTraces(ctx context.Context, traceId string, spanId string, levelUp int, levelDown int, spanLimit int, smartTraceAlgorithm func(payload []model.SearchSpanResponseItem, targetSpanId string, levelUp int, levelDown int, spanLimit int) ([]model.SearchSpansResult, error)) (*[]model.SearchSpansResult, error) {
 
 	var searchScanResponses []model.SearchSpanDBResponseItem
@@ -2025,6 +2120,65 @@ func (r *ClickHouseReader) SearchTraces(ctx context.Context, traceId string, spa
 	return &searchSpansResult, nil
 }
 
+func (r *ClickHouseReader) SearchTraceSpans(ctx context.Context, params *model.SearchTraceSpansParams) (*model.SearchTraceSpansResult, *model.ApiError) {
+
+	// only the tree structure is read for the whole trace, the span
+	// models are fetched for the selected window alone
+	var nodes []spanTreeNode
+	query := fmt.Sprintf("SELECT timestamp, spanID, parentSpanID FROM %s.%s WHERE traceID=$1", r.TraceDB, r.indexTable)
+	if err := r.db.Select(ctx, &nodes, query, params.TraceID); err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query: %w", err)}
+	}
+	if len(nodes) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("trace %s not found", params.TraceID))
+	}
+
+	window := selectSpanWindow(nodes, params.ParentSpanID, params.Depth, params.Limit, params.Offset)
+
+	result := model.SearchTraceSpansResult{
+		SearchSpansResult: model.SearchSpansResult{
+			Columns: []string{"__time", "SpanId", "TraceId", "ServiceName", "Name", "Kind", "DurationNano", "TagsKeys", "TagsValues", "References", "Events", "HasError"},
+			Events:  [][]interface{}{},
+		},
+		ChildCount: window.childCount,
+		TotalSpans: len(nodes),
+		HasMore:    window.hasMore,
+		NextOffset: params.Offset + window.consumed,
+	}
+	if len(window.spanIds) == 0 {
+		return &result, nil
+	}
+
+	var searchScanResponses []model.SearchSpanDBResponseItem
+	query = fmt.Sprintf("SELECT timestamp, traceID, model FROM %s.%s WHERE traceID=@traceID AND JSONExtractString(model, 'spanId') IN @spanIds", r.TraceDB, r.SpansTable)
+	err := r.db.Select(ctx, &searchScanResponses, query,
+		clickhouse.Named("traceID", params.TraceID),
+		clickhouse.Named("spanIds", window.spanIds),
+	)
+	if err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query: %w", err)}
+	}
+
+	spans := make(map[string]model.SearchSpanResponseItem, len(searchScanResponses))
+	for _, item := range searchScanResponses {
+		var jsonItem model.SearchSpanResponseItem
+		easyjson.Unmarshal([]byte(item.Model), &jsonItem)
+		jsonItem.TimeUnixNano = uint64(item.Timestamp.UnixNano() / 1000000)
+		spans[jsonItem.SpanID] = jsonItem
+	}
+
+	// keep the waterfall order of the window
+	for _, spanId := range window.spanIds {
+		if span, ok := spans[spanId]; ok {
+			result.Events = append(result.Events, span.GetValues())
+		}
+	}
+
+	return &result, nil
+}
+
 func (r *ClickHouseReader) GetDependencyGraph(ctx context.Context, queryParams *model.GetServicesParams) (*[]model.ServiceMapDependencyResponseItem, error) {
 
 	response := []model.ServiceMapDependencyResponseItem{}
@@ -3363,6 +3517,57 @@ func (r *ClickHouseReader) GetTimeSeriesInfo(ctx context.Context) (map[string]in
 	return timeSeriesData, nil
 }
 
+// GetMetricSeriesCounts counts the series of every metric active between
+// start and end (ms), grouped by the values of the given labels
+func (r *ClickHouseReader) GetMetricSeriesCounts(
+	ctx context.Context, start, end int64, labelKeys []string,
+) ([]model.MetricSeriesCount, *model.ApiError) {
+	labelValues := "[]"
+	if len(labelKeys) > 0 {
+		labelValues = "arrayMap(k -> JSONExtractString(labels, k), $3)"
+	}
+	query := fmt.Sprintf(`SELECT metric_name, %s AS label_values, uniq(fingerprint) AS series
+		FROM %s.%s
+		WHERE metric_name NOT LIKE 'signoz_%%' AND unix_milli >= $1 AND unix_milli < $2
+		GROUP BY metric_name, label_values
+		ORDER BY series DESC`, labelValues, signozMetricDBName, signozTSTableNameV41Day)
+
+	// the 1 day table is bucketed by day
+	args := []interface{}{start - start%(24*time.Hour).Milliseconds(), end}
+	if len(labelKeys) > 0 {
+		args = append(args, labelKeys)
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		zap.S().Error("Error while querying metric series counts: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+
+	counts := []model.MetricSeriesCount{}
+	for rows.Next() {
+		var metricName string
+		var values []string
+		var series uint64
+		if err := rows.Scan(&metricName, &values, &series); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+		labels := map[string]string{}
+		for i, key := range labelKeys {
+			if i < len(values) && values[i] != "" {
+				labels[key] = values[i]
+			}
+		}
+		counts = append(counts, model.MetricSeriesCount{
+			MetricName: metricName, Labels: labels, Series: series,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return counts, nil
+}
+
 func (r *ClickHouseReader) GetSamplesInfoInLastHeartBeatInterval(ctx context.Context, interval time.Duration) (uint64, error) {
 
 	var totalSamples uint64
@@ -3473,7 +3678,7 @@ func removeUnderscoreDuplicateFields(fields []model.LogField) []model.LogField {
 func (r *ClickHouseReader) GetDashboardsInfo(ctx context.Context) (*model.DashboardsInfo, error) {
 	dashboardsInfo := model.DashboardsInfo{}
 	// fetch dashboards from dashboard db
-	query := "SELECT data FROM dashboards"
+	query := "SELECT data FROM dashboards WHERE deleted_at IS NULL"
 	var dashboardsData []dashboards.Dashboard
 	err := r.localDB.Select(&dashboardsData, query)
 	if err != nil {
@@ -3536,7 +3741,7 @@ func countPanelsInDashboard(data map[string]interface{}) model.DashboardsInfo {
 func (r *ClickHouseReader) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 	alertsInfo := model.AlertsInfo{}
 	// fetch alerts from rules db
-	query := "SELECT data FROM rules"
+	query := "SELECT data FROM rules WHERE deleted = 0"
 	var alertsData []string
 	err := r.localDB.Select(&alertsData, query)
 	if err != nil {
@@ -4862,6 +5067,7 @@ func (r *ClickHouseReader) GetTraceAttributeKeys(ctx context.Context, req *v3.Fi
 		}
 		response.AttributeKeys = append(response.AttributeKeys, key)
 	}
+	response.AttributeKeys = append(response.AttributeKeys, tracesV3.DerivedAttributeKeys(req.SearchText)...)
 	return &response, nil
 }
 
@@ -0,0 +1,277 @@
+package clickhouseReader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/SigNoz/signoz-otel-collector/exporter/clickhousemetricsexporter/utils/timeseries"
+	"github.com/prometheus/prometheus/prompb"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	signozTSTableNameV4       = "distributed_time_series_v4"
+	signozSampleTableNameV4   = "distributed_samples_v4"
+	remoteWriteNameLabel      = "__name__"
+	remoteWriteTemporality    = "__temporality__"
+	remoteWriteDefaultEnv     = "default"
+	remoteWriteEnvLabel       = "deployment_environment"
+	remoteWriteEnvLabelDotted = "deployment.environment"
+)
+
+// remoteWriteMeta is what the metrics schema records about a metric, derived
+// from the prometheus metric type
+type remoteWriteMeta struct {
+	Temporality string
+	Type        string
+	Description string
+	Unit        string
+	IsMonotonic bool
+}
+
+type remoteWriteSeries struct {
+	Fingerprint uint64
+	MetricName  string
+	Env         string
+	Labels      string
+	Meta        remoteWriteMeta
+	Samples     []prompb.Sample
+}
+
+func remoteWriteMetaFromType(typ prompb.MetricMetadata_MetricType) remoteWriteMeta {
+	switch typ {
+	case prompb.MetricMetadata_COUNTER:
+		return remoteWriteMeta{Temporality: "Cumulative", Type: "Sum", IsMonotonic: true}
+	case prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM:
+		return remoteWriteMeta{Temporality: "Cumulative", Type: "Histogram"}
+	case prompb.MetricMetadata_SUMMARY:
+		return remoteWriteMeta{Temporality: "Cumulative", Type: "Summary"}
+	default:
+		return remoteWriteMeta{Temporality: "Unspecified", Type: "Gauge"}
+	}
+}
+
+// guessRemoteWriteMeta is used for metrics prometheus sent no metadata for,
+// going by the prometheus naming conventions
+func guessRemoteWriteMeta(metricName string) remoteWriteMeta {
+	switch {
+	case strings.HasSuffix(metricName, "_total"):
+		return remoteWriteMetaFromType(prompb.MetricMetadata_COUNTER)
+	case strings.HasSuffix(metricName, "_bucket"):
+		return remoteWriteMetaFromType(prompb.MetricMetadata_HISTOGRAM)
+	default:
+		return remoteWriteMetaFromType(prompb.MetricMetadata_GAUGE)
+	}
+}
+
+// metricFamilyName strips the suffixes prometheus adds to the series of
+// histograms and summaries
+func metricFamilyName(metricName string) string {
+	for _, suffix := range []string{"_bucket", "_count", "_sum"} {
+		if strings.HasSuffix(metricName, suffix) {
+			return strings.TrimSuffix(metricName, suffix)
+		}
+	}
+	return metricName
+}
+
+// remoteWriteSeriesFromRequest converts the series of a remote write request
+// to the metrics schema, fingerprinting them the way the SigNoz collector
+// does so that series pushed here and through the collector are the same
+func remoteWriteSeriesFromRequest(req *prompb.WriteRequest) ([]remoteWriteSeries, error) {
+	metadata := map[string]remoteWriteMeta{}
+	for _, m := range req.Metadata {
+		meta := remoteWriteMetaFromType(m.Type)
+		meta.Description = m.Help
+		meta.Unit = m.Unit
+		metadata[m.MetricFamilyName] = meta
+	}
+
+	result := make([]remoteWriteSeries, 0, len(req.Timeseries))
+	for _, ts := range req.Timeseries {
+		// native histograms have no place in the metrics schema
+		if len(ts.Histograms) > 0 {
+			return nil, fmt.Errorf("native histograms are not supported")
+		}
+		series := remoteWriteSeries{Env: remoteWriteDefaultEnv, Samples: ts.Samples}
+
+		labels := []*prompb.Label{}
+		seen := map[string]bool{}
+		for i := range ts.Labels {
+			label := ts.Labels[i]
+			if seen[label.Name] || label.Name == remoteWriteTemporality {
+				continue
+			}
+			seen[label.Name] = true
+			labels = append(labels, &label)
+
+			switch label.Name {
+			case remoteWriteNameLabel:
+				series.MetricName = label.Value
+			case remoteWriteEnvLabel, remoteWriteEnvLabelDotted:
+				series.Env = label.Value
+			}
+		}
+		if series.MetricName == "" {
+			return nil, fmt.Errorf("series without a %s label", remoteWriteNameLabel)
+		}
+
+		meta, ok := metadata[series.MetricName]
+		if !ok {
+			meta, ok = metadata[metricFamilyName(series.MetricName)]
+		}
+		if !ok {
+			meta = guessRemoteWriteMeta(series.MetricName)
+		}
+		series.Meta = meta
+
+		labels = append(labels, &prompb.Label{Name: remoteWriteTemporality, Value: meta.Temporality})
+		timeseries.SortLabels(labels)
+		series.Fingerprint = timeseries.Fingerprint(labels)
+
+		labelsMap := make(map[string]string, len(labels))
+		for _, l := range labels {
+			labelsMap[l.Name] = l.Value
+		}
+		encoded, err := json.Marshal(labelsMap)
+		if err != nil {
+			return nil, err
+		}
+		series.Labels = string(encoded)
+
+		result = append(result, series)
+	}
+	return result, nil
+}
+
+// remoteWriteDeduplicationToken identifies the rows written for a remote
+// write request. Prometheus retries the requests it failed to write as they
+// were, and clickhouse drops the blocks inserted again with the same token.
+func remoteWriteDeduplicationToken(req *prompb.WriteRequest) (string, error) {
+	encoded, err := req.Marshal()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// WriteRemoteWriteRequest writes the series of a prometheus remote write
+// request to the metrics tables. The tables are written one after another,
+// so a failure can leave some of them written; the inserts are deduplicated
+// for the retry of the request not to write them twice.
+func (r *ClickHouseReader) WriteRemoteWriteRequest(ctx context.Context, req *prompb.WriteRequest) *model.ApiError {
+	series, err := remoteWriteSeriesFromRequest(req)
+	if err != nil {
+		return model.BadRequest(err)
+	}
+	if len(series) == 0 {
+		return nil
+	}
+	token, err := remoteWriteDeduplicationToken(req)
+	if err != nil {
+		return model.InternalError(err)
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"insert_deduplicate":         1,
+		"insert_deduplication_token": token,
+	}))
+
+	now := time.Now().UnixMilli()
+	// rows of time series tables are per hour in v4
+	hour := now / 3600000 * 3600000
+
+	writes := []struct {
+		table   string
+		columns string
+		append  func(batch driver.Batch) error
+	}{
+		{
+			table:   signozTSTableName,
+			columns: "metric_name, temporality, timestamp_ms, fingerprint, labels, description, unit, type, is_monotonic",
+			append: func(batch driver.Batch) error {
+				for _, s := range series {
+					if err := batch.Append(
+						s.MetricName, s.Meta.Temporality, now, s.Fingerprint, s.Labels,
+						s.Meta.Description, s.Meta.Unit, s.Meta.Type, s.Meta.IsMonotonic,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			table:   signozTSTableNameV4,
+			columns: "env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels",
+			append: func(batch driver.Batch) error {
+				for _, s := range series {
+					if err := batch.Append(
+						s.Env, s.Meta.Temporality, s.MetricName, s.Meta.Description, s.Meta.Unit,
+						s.Meta.Type, s.Meta.IsMonotonic, s.Fingerprint, hour, s.Labels,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			table:   signozSampleTableName,
+			columns: "metric_name, fingerprint, timestamp_ms, value",
+			append: func(batch driver.Batch) error {
+				for _, s := range series {
+					for _, sample := range s.Samples {
+						if err := batch.Append(s.MetricName, s.Fingerprint, sample.Timestamp, sample.Value); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			table:   signozSampleTableNameV4,
+			columns: "env, temporality, metric_name, fingerprint, unix_milli, value",
+			append: func(batch driver.Batch) error {
+				for _, s := range series {
+					for _, sample := range s.Samples {
+						if err := batch.Append(
+							s.Env, s.Meta.Temporality, s.MetricName, s.Fingerprint, sample.Timestamp, sample.Value,
+						); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, write := range writes {
+		batch, err := r.db.PrepareBatch(ctx, fmt.Sprintf(
+			"INSERT INTO %s.%s (%s)", signozMetricDBName, write.table, write.columns,
+		))
+		if err != nil {
+			zap.L().Error("could not prepare remote write batch", zap.String("table", write.table), zap.Error(err))
+			return model.InternalError(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+		if err := write.append(batch); err != nil {
+			batch.Abort()
+			return model.BadRequest(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+		if err := batch.Send(); err != nil {
+			zap.L().Error("could not send remote write batch", zap.String("table", write.table), zap.Error(err))
+			return model.InternalError(fmt.Errorf("could not write to %s: %w", write.table, err))
+		}
+	}
+	return nil
+}
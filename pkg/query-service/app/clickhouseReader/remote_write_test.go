@@ -0,0 +1,157 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/SigNoz/signoz-otel-collector/exporter/clickhousemetricsexporter/utils/timeseries"
+	"github.com/prometheus/prometheus/prompb"
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestRemoteWriteSeriesFromRequest(t *testing.T) {
+	require := require.New(t)
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "job", Value: "api"},
+					{Name: "__name__", Value: "http_requests_total"},
+					{Name: "deployment_environment", Value: "prod"},
+				},
+				Samples: []prompb.Sample{{Value: 10, Timestamp: 1000}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "http_duration_seconds_bucket"},
+					{Name: "le", Value: "0.5"},
+				},
+			},
+			{
+				Labels: []prompb.Label{{Name: "__name__", Value: "queue_size"}},
+			},
+		},
+		Metadata: []prompb.MetricMetadata{
+			{MetricFamilyName: "http_duration_seconds", Type: prompb.MetricMetadata_HISTOGRAM, Help: "duration", Unit: "seconds"},
+		},
+	}
+
+	series, err := remoteWriteSeriesFromRequest(req)
+	require.Nil(err)
+	require.Equal(3, len(series))
+
+	counter := series[0]
+	require.Equal("http_requests_total", counter.MetricName)
+	require.Equal("prod", counter.Env)
+	require.Equal(remoteWriteMeta{Temporality: "Cumulative", Type: "Sum", IsMonotonic: true}, counter.Meta)
+	require.Equal(
+		`{"__name__":"http_requests_total","__temporality__":"Cumulative","deployment_environment":"prod","job":"api"}`,
+		counter.Labels,
+	)
+	require.Equal(timeseries.Fingerprint([]*prompb.Label{
+		{Name: "__name__", Value: "http_requests_total"},
+		{Name: "__temporality__", Value: "Cumulative"},
+		{Name: "deployment_environment", Value: "prod"},
+		{Name: "job", Value: "api"},
+	}), counter.Fingerprint)
+
+	histogram := series[1]
+	require.Equal("default", histogram.Env)
+	require.Equal(remoteWriteMeta{
+		Temporality: "Cumulative", Type: "Histogram", Description: "duration", Unit: "seconds",
+	}, histogram.Meta)
+
+	require.Equal(remoteWriteMeta{Temporality: "Unspecified", Type: "Gauge"}, series[2].Meta)
+
+	_, err = remoteWriteSeriesFromRequest(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "job", Value: "api"}}}},
+	})
+	require.NotNil(err, "series without a metric name should be rejected")
+
+	_, err = remoteWriteSeriesFromRequest(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:     []prompb.Label{{Name: "__name__", Value: "http_duration_seconds"}},
+			Histograms: []prompb.Histogram{{Schema: 3, Timestamp: 1000}},
+		}},
+	})
+	require.NotNil(err, "native histograms should be rejected")
+}
+
+func TestRemoteWriteDeduplicationToken(t *testing.T) {
+	require := require.New(t)
+
+	request := func(value float64) *prompb.WriteRequest {
+		return &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "queue_size"}},
+			Samples: []prompb.Sample{{Value: value, Timestamp: 1000}},
+		}}}
+	}
+	token, err := remoteWriteDeduplicationToken(request(1))
+	require.Nil(err)
+	retried, err := remoteWriteDeduplicationToken(request(1))
+	require.Nil(err)
+	require.Equal(token, retried, "retries of a request are deduplicated")
+	other, err := remoteWriteDeduplicationToken(request(2))
+	require.Nil(err)
+	require.NotEqual(token, other)
+}
+
+func TestWriteRemoteWriteRequest(t *testing.T) {
+	req := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "queue_size"}},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}, {Value: 2, Timestamp: 2000}},
+	}}}
+	inserts := []string{
+		"INSERT INTO signoz_metrics.distributed_time_series_v2 (metric_name, temporality, timestamp_ms, fingerprint, labels, description, unit, type, is_monotonic)",
+		"INSERT INTO signoz_metrics.distributed_time_series_v4 (env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels)",
+		"INSERT INTO signoz_metrics.distributed_samples_v2 (metric_name, fingerprint, timestamp_ms, value)",
+		"INSERT INTO signoz_metrics.distributed_samples_v4 (env, temporality, metric_name, fingerprint, unix_milli, value)",
+	}
+
+	t.Run("written", func(t *testing.T) {
+		mock, err := cmock.NewClickHouseNative(nil)
+		require.Nil(t, err)
+		for _, insert := range inserts {
+			mock.ExpectPrepareBatch(insert).ExpectSend()
+		}
+		reader := &ClickHouseReader{db: mock}
+		require.Nil(t, reader.WriteRemoteWriteRequest(context.Background(), req))
+		require.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("partially written", func(t *testing.T) {
+		mock, err := cmock.NewClickHouseNative(nil)
+		require.Nil(t, err)
+		for _, insert := range inserts[:3] {
+			mock.ExpectPrepareBatch(insert).ExpectSend()
+		}
+		mock.ExpectPrepareBatch(inserts[3]).ExpectSend().WillReturnError(fmt.Errorf("timeout"))
+		reader := &ClickHouseReader{db: mock}
+
+		// the failure is retried by prometheus, the tables already written
+		// drop the inserts of the retry by their deduplication token
+		apiErr := reader.WriteRemoteWriteRequest(context.Background(), req)
+		require.NotNil(t, apiErr)
+		require.Equal(t, model.ErrorInternal, apiErr.Typ)
+		require.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("native histograms", func(t *testing.T) {
+		mock, err := cmock.NewClickHouseNative(nil)
+		require.Nil(t, err)
+		reader := &ClickHouseReader{db: mock}
+		apiErr := reader.WriteRemoteWriteRequest(context.Background(), &prompb.WriteRequest{
+			Timeseries: []prompb.TimeSeries{{
+				Labels:     []prompb.Label{{Name: "__name__", Value: "http_duration_seconds"}},
+				Histograms: []prompb.Histogram{{Schema: 3, Timestamp: 1000}},
+			}},
+		})
+		require.NotNil(t, apiErr)
+		require.Equal(t, model.ErrorBadData, apiErr.Typ, "prometheus doesn't retry rejected requests")
+		require.Nil(t, mock.ExpectationsWereMet(), "nothing is written")
+	})
+}
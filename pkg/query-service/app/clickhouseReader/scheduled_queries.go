@@ -0,0 +1,116 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	scheduledQueryResultsLocalTable = "scheduled_query_results"
+	scheduledQueryResultsTable      = "distributed_scheduled_query_results"
+)
+
+// CreateScheduledQueryResultsTable creates the tables the results of
+// scheduled queries are persisted in. Rows expire after the retention of
+// their scheduled query, independent of the TTL of the other tables.
+func (r *ClickHouseReader) CreateScheduledQueryResultsTable(ctx context.Context) error {
+	queries := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s (
+			scheduled_query_id LowCardinality(String) CODEC(ZSTD(1)),
+			query_name LowCardinality(String) CODEC(ZSTD(1)),
+			labels String CODEC(ZSTD(1)),
+			unix_milli Int64 CODEC(DoubleDelta, ZSTD(1)),
+			run_at Int64 CODEC(DoubleDelta, ZSTD(1)),
+			value Float64 CODEC(Gorilla, ZSTD(1)),
+			retention_days UInt16
+		) ENGINE = MergeTree
+		PARTITION BY toDate(intDiv(unix_milli, 1000))
+		ORDER BY (scheduled_query_id, query_name, labels, unix_milli)
+		TTL toDateTime(intDiv(unix_milli, 1000)) + toIntervalDay(retention_days)`,
+			signozMetricDBName, scheduledQueryResultsLocalTable, r.cluster,
+		),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s
+		AS %s.%s
+		ENGINE = Distributed(%s, %s, %s, cityHash64(scheduled_query_id))`,
+			signozMetricDBName, scheduledQueryResultsTable, r.cluster,
+			signozMetricDBName, scheduledQueryResultsLocalTable,
+			r.cluster, signozMetricDBName, scheduledQueryResultsLocalTable,
+		),
+	}
+	for _, query := range queries {
+		if err := r.db.Exec(ctx, query); err != nil {
+			return fmt.Errorf("could not create scheduled query results table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *ClickHouseReader) WriteScheduledQueryResults(
+	ctx context.Context, results []model.ScheduledQueryResult,
+) *model.ApiError {
+	if len(results) == 0 {
+		return nil
+	}
+
+	batch, err := r.db.PrepareBatch(ctx, fmt.Sprintf(
+		"INSERT INTO %s.%s (scheduled_query_id, query_name, labels, unix_milli, run_at, value, retention_days)",
+		signozMetricDBName, scheduledQueryResultsTable,
+	))
+	if err != nil {
+		zap.L().Error("could not prepare scheduled query results batch", zap.Error(err))
+		return model.InternalError(fmt.Errorf("could not write scheduled query results: %w", err))
+	}
+	for _, res := range results {
+		err := batch.Append(
+			res.ScheduledQueryId, res.QueryName, res.Labels, res.UnixMilli, res.RunAt, res.Value, res.RetentionDays,
+		)
+		if err != nil {
+			batch.Abort()
+			return model.InternalError(fmt.Errorf("could not write scheduled query results: %w", err))
+		}
+	}
+	if err := batch.Send(); err != nil {
+		zap.L().Error("could not send scheduled query results batch", zap.Error(err))
+		return model.InternalError(fmt.Errorf("could not write scheduled query results: %w", err))
+	}
+	return nil
+}
+
+// GetScheduledQueryResults returns the persisted points of a scheduled query
+// in the given range in milliseconds. Points computed by several runs, when
+// their ranges overlap, are taken from the latest run.
+func (r *ClickHouseReader) GetScheduledQueryResults(
+	ctx context.Context, scheduledQueryId string, start, end int64,
+) ([]model.ScheduledQueryResult, *model.ApiError) {
+	query := fmt.Sprintf(`
+		SELECT
+			scheduled_query_id,
+			query_name,
+			labels,
+			unix_milli,
+			max(run_at) as run_at,
+			argMax(value, run_at) as value,
+			argMax(retention_days, run_at) as retention_days
+		FROM %s.%s
+		WHERE scheduled_query_id = @id AND unix_milli >= @start AND unix_milli <= @end
+		GROUP BY scheduled_query_id, query_name, labels, unix_milli
+		ORDER BY query_name, labels, unix_milli`,
+		signozMetricDBName, scheduledQueryResultsTable,
+	)
+
+	results := []model.ScheduledQueryResult{}
+	err := r.db.Select(ctx, &results, query,
+		clickhouse.Named("id", scheduledQueryId),
+		clickhouse.Named("start", start),
+		clickhouse.Named("end", end),
+	)
+	if err != nil {
+		zap.L().Error("could not query scheduled query results", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query scheduled query results: %w", err))
+	}
+	return results, nil
+}
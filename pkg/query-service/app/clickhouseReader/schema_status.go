@@ -0,0 +1,342 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// the detected schema is reused for this long before being checked
+	// again, so that queries start working again soon after the migrator
+	// is run
+	schemaStatusTTL = 5 * time.Minute
+	// failures to detect the schema are reused for this long, so that
+	// clickhouse isn't queried again on every query while it is down
+	schemaFailureTTL = 30 * time.Second
+
+	schemaDetectTimeout = 30 * time.Second
+)
+
+// schemaCheck caches the detected schema status. Only one detection runs
+// at a time, callers needing a new status wait for the running one.
+type schemaCheck struct {
+	mu        sync.Mutex
+	status    *model.SchemaStatus
+	err       error
+	checkedAt time.Time
+	// closed once the running detection is done, nil when none runs
+	detecting chan struct{}
+}
+
+func (c *schemaCheck) fresh() bool {
+	switch {
+	case c.err != nil:
+		return time.Since(c.checkedAt) < schemaFailureTTL
+	case c.status != nil:
+		return time.Since(c.checkedAt) < schemaStatusTTL
+	}
+	return false
+}
+
+func (c *schemaCheck) result() (*model.SchemaStatus, *model.ApiError) {
+	if c.err != nil {
+		return nil, model.UnavailableError(fmt.Errorf("could not detect the clickhouse schema: %w", c.err))
+	}
+	return c.status, nil
+}
+
+// get returns the cached status, detecting it again if refresh is set or
+// the cached one is old. The lock isn't held while detecting.
+func (c *schemaCheck) get(
+	ctx context.Context, refresh bool, detect func(ctx context.Context) (*model.SchemaStatus, error),
+) (*model.SchemaStatus, *model.ApiError) {
+	c.mu.Lock()
+	if !refresh && c.fresh() {
+		defer c.mu.Unlock()
+		return c.result()
+	}
+
+	detecting := c.detecting
+	if detecting == nil {
+		detecting = make(chan struct{})
+		c.detecting = detecting
+		c.mu.Unlock()
+
+		// the result is shared, it mustn't fail with the context of the
+		// caller which happened to start the detection
+		detectCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), schemaDetectTimeout)
+		status, err := detect(detectCtx)
+		cancel()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.status, c.err, c.checkedAt = status, err, time.Now()
+		c.detecting = nil
+		close(detecting)
+		return c.result()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-detecting:
+	case <-ctx.Done():
+		return nil, model.UnavailableError(ctx.Err())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result()
+}
+
+type schemaRequirement struct {
+	feature  string
+	database string
+	table    string
+	columns  []string
+}
+
+// schemaRequirements lists the tables and columns the query builders of
+// each feature read, which were added over time by schema migrations
+func (r *ClickHouseReader) schemaRequirements() []schemaRequirement {
+	metricsV4Columns := []string{"env", "temporality", "metric_name", "fingerprint", "unix_milli", "labels"}
+	return []schemaRequirement{
+		{
+			feature:  model.SchemaFeatureTraces,
+			database: r.TraceDB,
+			table:    r.indexTable,
+			columns: []string{
+				"stringTagMap", "numberTagMap", "boolTagMap", "resourceTagsMap", "httpRoute", "responseStatusCode",
+			},
+		},
+		{
+			feature:  model.SchemaFeatureTraces,
+			database: r.TraceDB,
+			table:    r.spanAttributesKeysTable,
+			columns:  []string{"tagKey", "tagType", "dataType", "isColumn"},
+		},
+		{
+			feature:  model.SchemaFeatureLogs,
+			database: r.logsDB,
+			table:    r.logsTable,
+			columns: []string{
+				"resources_string_key", "resources_string_value",
+				"attributes_int64_key", "attributes_int64_value",
+				"attributes_float64_key", "attributes_float64_value",
+				"attributes_bool_key", "attributes_bool_value",
+			},
+		},
+		{
+			feature:  model.SchemaFeatureMetrics,
+			database: signozMetricDBName,
+			table:    signozTSTableName,
+			columns:  []string{"metric_name", "temporality", "fingerprint", "labels"},
+		},
+		{
+			feature:  model.SchemaFeatureMetrics,
+			database: signozMetricDBName,
+			table:    signozSampleTableName,
+			columns:  []string{"metric_name", "fingerprint", "timestamp_ms", "value"},
+		},
+		{
+			feature:  model.SchemaFeatureMetricsV4,
+			database: signozMetricDBName,
+			table:    constants.SIGNOZ_TIMESERIES_v4_LOCAL_TABLENAME,
+			columns:  metricsV4Columns,
+		},
+		{
+			feature:  model.SchemaFeatureMetricsV4,
+			database: signozMetricDBName,
+			table:    constants.SIGNOZ_TIMESERIES_v4_6HRS_LOCAL_TABLENAME,
+			columns:  metricsV4Columns,
+		},
+		{
+			feature:  model.SchemaFeatureMetricsV4,
+			database: signozMetricDBName,
+			table:    constants.SIGNOZ_TIMESERIES_v4_1DAY_LOCAL_TABLENAME,
+			columns:  metricsV4Columns,
+		},
+		{
+			feature:  model.SchemaFeatureMetricsV4,
+			database: signozMetricDBName,
+			table:    signozSampleTableNameV4,
+			columns:  []string{"env", "temporality", "metric_name", "fingerprint", "unix_milli", "value"},
+		},
+	}
+}
+
+// missingSchemaRequirements compares the requirements with the columns of
+// each table keyed by database.table
+func missingSchemaRequirements(
+	requirements []schemaRequirement, tableColumns map[string]map[string]bool,
+) []model.SchemaRequirement {
+	missing := []model.SchemaRequirement{}
+	for _, req := range requirements {
+		columns, ok := tableColumns[req.database+"."+req.table]
+		if !ok {
+			missing = append(missing, model.SchemaRequirement{
+				Feature: req.feature, Database: req.database, Table: req.table, MissingTable: true,
+			})
+			continue
+		}
+		missingColumns := []string{}
+		for _, c := range req.columns {
+			if !columns[c] {
+				missingColumns = append(missingColumns, c)
+			}
+		}
+		if len(missingColumns) > 0 {
+			missing = append(missing, model.SchemaRequirement{
+				Feature: req.feature, Database: req.database, Table: req.table, MissingColumns: missingColumns,
+			})
+		}
+	}
+	return missing
+}
+
+func (r *ClickHouseReader) detectSchemaStatus(ctx context.Context) (*model.SchemaStatus, error) {
+	requirements := r.schemaRequirements()
+	databases := []string{}
+	seen := map[string]bool{}
+	for _, req := range requirements {
+		if !seen[req.database] {
+			seen[req.database] = true
+			databases = append(databases, req.database)
+		}
+	}
+	sort.Strings(databases)
+
+	rows, err := r.db.Query(ctx,
+		"SELECT database, table, name FROM system.columns WHERE database IN @databases",
+		clickhouse.Named("databases", databases),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not read clickhouse columns: %w", err)
+	}
+	defer rows.Close()
+
+	tableColumns := map[string]map[string]bool{}
+	for rows.Next() {
+		var database, table, column string
+		if err := rows.Scan(&database, &table, &column); err != nil {
+			return nil, fmt.Errorf("could not read clickhouse columns: %w", err)
+		}
+		key := database + "." + table
+		if tableColumns[key] == nil {
+			tableColumns[key] = map[string]bool{}
+		}
+		tableColumns[key][column] = true
+	}
+
+	status := &model.SchemaStatus{
+		CheckedAt: time.Now(),
+		Missing:   missingSchemaRequirements(requirements, tableColumns),
+	}
+	status.Compatible = len(status.Missing) == 0
+
+	for _, database := range databases {
+		migration := model.SchemaMigrationVersion{Database: database}
+		if _, ok := tableColumns[database+".schema_migrations"]; ok {
+			var version int64
+			var dirty uint8
+			err := r.db.QueryRow(ctx, fmt.Sprintf(
+				"SELECT version, dirty FROM %s.schema_migrations ORDER BY sequence DESC LIMIT 1", database,
+			)).Scan(&version, &dirty)
+			if err == nil {
+				migration.Found = true
+				migration.Version = version
+				migration.Dirty = dirty != 0
+			} else {
+				zap.L().Debug("could not read schema migrations", zap.String("database", database), zap.Error(err))
+			}
+		}
+		status.Migrations = append(status.Migrations, migration)
+	}
+	return status, nil
+}
+
+// GetSchemaStatus returns the clickhouse tables and columns missing for
+// queries, detecting them again if refresh is set or the last check is old
+func (r *ClickHouseReader) GetSchemaStatus(ctx context.Context, refresh bool) (*model.SchemaStatus, *model.ApiError) {
+	return r.schemaCheck.get(ctx, refresh, r.detectSchemaStatus)
+}
+
+// schemaIncompatibilityError explains the missing schema migrations for
+// queries of the features
+func schemaIncompatibilityError(status *model.SchemaStatus, features []string) *model.ApiError {
+	problems := []string{}
+	missingFeatures := []string{}
+	for _, f := range features {
+		isMissing := false
+		for _, req := range status.Missing {
+			if req.Feature == f {
+				problems = append(problems, req.String())
+				isMissing = true
+			}
+		}
+		if isMissing {
+			missingFeatures = append(missingFeatures, f)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	versions := []string{}
+	for _, m := range status.Migrations {
+		switch {
+		case !m.Found:
+			versions = append(versions, fmt.Sprintf("%s has no migrations", m.Database))
+		case m.Dirty:
+			versions = append(versions, fmt.Sprintf("%s is at migration %d which failed", m.Database, m.Version))
+		default:
+			versions = append(versions, fmt.Sprintf("%s is at migration %d", m.Database, m.Version))
+		}
+	}
+
+	return model.UnavailableError(fmt.Errorf(
+		"the clickhouse schema is too old for %s queries: %s. Run the schema migrator of this SigNoz version to apply the missing migrations (%s)",
+		strings.Join(missingFeatures, ", "), strings.Join(problems, "; "), strings.Join(versions, ", "),
+	))
+}
+
+// CheckSchemaCompatibility returns an error listing the missing migrations if
+// the clickhouse schema lacks tables or columns needed for queries of the
+// features. Queries are let through if the schema can't be detected.
+func (r *ClickHouseReader) CheckSchemaCompatibility(ctx context.Context, features []string) *model.ApiError {
+	if len(features) == 0 {
+		return nil
+	}
+	status, apiErr := r.GetSchemaStatus(ctx, false)
+	if apiErr != nil {
+		zap.L().Warn("could not detect clickhouse schema", zap.Error(apiErr.Err))
+		return nil
+	}
+	return schemaIncompatibilityError(status, features)
+}
+
+func (r *ClickHouseReader) logSchemaStatus(ctx context.Context) {
+	status, apiErr := r.GetSchemaStatus(ctx, true)
+	if apiErr != nil {
+		zap.L().Warn("could not detect clickhouse schema", zap.Error(apiErr.Err))
+		return
+	}
+	for _, m := range status.Migrations {
+		zap.L().Info("clickhouse schema migrations",
+			zap.String("database", m.Database), zap.Bool("found", m.Found),
+			zap.Int64("version", m.Version), zap.Bool("dirty", m.Dirty),
+		)
+	}
+	for _, req := range status.Missing {
+		zap.L().Error("clickhouse schema is missing migrations, queries of the feature will be refused",
+			zap.String("feature", req.Feature), zap.String("problem", req.String()),
+		)
+	}
+}
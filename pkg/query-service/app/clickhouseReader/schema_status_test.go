@@ -0,0 +1,135 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestSchemaIncompatibility(t *testing.T) {
+	assert := assert.New(t)
+
+	requirements := []schemaRequirement{
+		{feature: model.SchemaFeatureLogs, database: "signoz_logs", table: "logs", columns: []string{"body", "attributes_bool_key"}},
+		{feature: model.SchemaFeatureTraces, database: "signoz_traces", table: "signoz_index_v2", columns: []string{"httpRoute"}},
+		{feature: model.SchemaFeatureMetrics, database: "signoz_metrics", table: "samples_v2", columns: []string{"value"}},
+	}
+	missing := missingSchemaRequirements(requirements, map[string]map[string]bool{
+		"signoz_logs.logs":          {"body": true},
+		"signoz_metrics.samples_v2": {"value": true},
+	})
+	assert.Equal([]model.SchemaRequirement{
+		{Feature: model.SchemaFeatureLogs, Database: "signoz_logs", Table: "logs", MissingColumns: []string{"attributes_bool_key"}},
+		{Feature: model.SchemaFeatureTraces, Database: "signoz_traces", Table: "signoz_index_v2", MissingTable: true},
+	}, missing)
+
+	status := &model.SchemaStatus{
+		Missing: missing,
+		Migrations: []model.SchemaMigrationVersion{
+			{Database: "signoz_logs", Found: true, Version: 12, Dirty: true},
+			{Database: "signoz_traces"},
+		},
+	}
+	assert.Nil(schemaIncompatibilityError(status, []string{model.SchemaFeatureMetrics}))
+
+	apiErr := schemaIncompatibilityError(status, []string{model.SchemaFeatureLogs, model.SchemaFeatureMetrics})
+	assert.NotNil(apiErr)
+	assert.Equal(model.ErrorUnavailable, apiErr.Typ)
+	assert.Contains(apiErr.Err.Error(), "too old for logs queries")
+	assert.Contains(apiErr.Err.Error(), "table signoz_logs.logs is missing columns attributes_bool_key")
+	assert.Contains(apiErr.Err.Error(), "signoz_logs is at migration 12 which failed")
+	assert.Contains(apiErr.Err.Error(), "signoz_traces has no migrations")
+}
+
+func TestSchemaCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	check := &schemaCheck{}
+	detections := 0
+	var failure error
+	detect := func(ctx context.Context) (*model.SchemaStatus, error) {
+		detections++
+		if failure != nil {
+			return nil, failure
+		}
+		return &model.SchemaStatus{CheckedAt: time.Now(), Compatible: true}, nil
+	}
+
+	status, apiErr := check.get(ctx, false, detect)
+	assert.Nil(apiErr)
+	assert.True(status.Compatible)
+	_, apiErr = check.get(ctx, false, detect)
+	assert.Nil(apiErr)
+	assert.Equal(1, detections, "the status is cached")
+	_, apiErr = check.get(ctx, true, detect)
+	assert.Nil(apiErr)
+	assert.Equal(2, detections)
+
+	// failures are cached too, for a shorter time
+	failure = fmt.Errorf("connection refused")
+	check.checkedAt = time.Now().Add(-schemaStatusTTL)
+	_, apiErr = check.get(ctx, false, detect)
+	assert.NotNil(apiErr)
+	assert.Equal(model.ErrorUnavailable, apiErr.Typ)
+	_, apiErr = check.get(ctx, false, detect)
+	assert.NotNil(apiErr)
+	assert.Equal(3, detections)
+	failure = nil
+	check.checkedAt = time.Now().Add(-schemaFailureTTL)
+	_, apiErr = check.get(ctx, false, detect)
+	assert.Nil(apiErr)
+	assert.Equal(4, detections)
+
+	// callers don't wait for a detection when the cached status is fresh
+	started, unblock := make(chan struct{}), make(chan struct{})
+	slowDetections := 0
+	slow := func(ctx context.Context) (*model.SchemaStatus, error) {
+		slowDetections++
+		started <- struct{}{}
+		<-unblock
+		return &model.SchemaStatus{CheckedAt: time.Now()}, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		check.get(ctx, true, slow)
+	}()
+	<-started
+	status, apiErr = check.get(ctx, false, slow)
+	assert.Nil(apiErr)
+	assert.True(status.Compatible, "the cached status is returned during a detection")
+	unblock <- struct{}{}
+	<-done
+
+	// the callers needing a new status share the running detection
+	check.checkedAt = time.Now().Add(-schemaStatusTTL)
+	var wg sync.WaitGroup
+	results := make(chan *model.SchemaStatus, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _ := check.get(ctx, false, slow)
+			results <- status
+		}()
+	}
+	<-started
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, apiErr = check.get(cancelled, false, slow)
+	assert.NotNil(apiErr, "waiting callers give up with their context")
+
+	close(unblock)
+	wg.Wait()
+	close(results)
+	for status := range results {
+		assert.NotNil(status)
+	}
+	assert.Equal(2, slowDetections)
+}
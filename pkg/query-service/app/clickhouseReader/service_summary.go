@@ -0,0 +1,58 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// GetServiceSummary aggregates the top level operations of a service over
+// the whole time range, unlike GetServiceOverview which returns a point
+// per step
+func (r *ClickHouseReader) GetServiceSummary(
+	ctx context.Context, serviceName string, start, end time.Time, skipConfig *model.SkipConfig,
+) (*model.ServiceOverviewItem, *model.ApiError) {
+	topLevelOps, apiErr := r.GetTopLevelOperations(ctx, skipConfig)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	ops, ok := (*topLevelOps)[serviceName]
+	if !ok {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("Service not found")}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			quantile(0.99)(durationNano) as p99,
+			quantile(0.95)(durationNano) as p95,
+			quantile(0.50)(durationNano) as p50,
+			count(*) as numCalls,
+			countIf(statusCode=2) as numErrors
+		FROM %s.%s
+		WHERE serviceName = @serviceName AND name In @names AND timestamp>= @start AND timestamp<= @end`,
+		r.TraceDB, r.indexTable,
+	)
+
+	summary := model.ServiceOverviewItem{}
+	err := r.db.QueryRow(ctx, query,
+		clickhouse.Named("start", strconv.FormatInt(start.UnixNano(), 10)),
+		clickhouse.Named("end", strconv.FormatInt(end.UnixNano(), 10)),
+		clickhouse.Named("serviceName", serviceName),
+		clickhouse.Named("names", ops),
+	).ScanStruct(&summary)
+	zap.S().Debug(query)
+	if err != nil {
+		zap.S().Error("Error in processing sql query: ", err)
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query")}
+	}
+
+	summary.Time = start
+	summary.Timestamp = start.UnixNano()
+	summary.CallRate, summary.ErrorRate = callRates(summary.NumCalls, summary.NumErrors, end.Sub(start).Seconds())
+	return &summary, nil
+}
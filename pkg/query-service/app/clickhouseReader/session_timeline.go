@@ -0,0 +1,227 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	sessionIdAttribute = "session.id"
+	// sessionIdColumn is materialized in the logs and spans tables from the
+	// session id attribute, or resource attribute, when sessions are indexed
+	sessionIdColumn = "session_id"
+)
+
+func (r *ClickHouseReader) logsSessionIdExpression() string {
+	return fmt.Sprintf(
+		"if(indexOf(attributes_string_key, '%[1]s') != 0, attributes_string_value[indexOf(attributes_string_key, '%[1]s')], "+
+			"resources_string_value[indexOf(resources_string_key, '%[1]s')])",
+		sessionIdAttribute,
+	)
+}
+
+func (r *ClickHouseReader) spansSessionIdExpression() string {
+	return fmt.Sprintf(
+		"if(stringTagMap['%[1]s'] != '', stringTagMap['%[1]s'], resourceTagsMap['%[1]s'])",
+		sessionIdAttribute,
+	)
+}
+
+// IndexSessionIds materializes the session id of logs and spans into a
+// column with a bloom filter index so that the telemetry of a session can be
+// found without reading the attributes of every log and span. Only data
+// ingested afterwards is indexed.
+func (r *ClickHouseReader) IndexSessionIds(ctx context.Context) *model.ApiError {
+	tables := []struct {
+		database    string
+		local       string
+		distributed string
+		expression  string
+	}{
+		{r.logsDB, r.logsLocalTable, r.logsTable, r.logsSessionIdExpression()},
+		{r.TraceDB, signozTraceLocalTableName, r.indexTable, r.spansSessionIdExpression()},
+	}
+
+	for _, t := range tables {
+		for _, table := range []string{t.local, t.distributed} {
+			query := fmt.Sprintf(
+				"ALTER TABLE %s.%s ON CLUSTER %s ADD COLUMN IF NOT EXISTS %s String DEFAULT %s CODEC(ZSTD(1))",
+				t.database, table, r.cluster, sessionIdColumn, t.expression,
+			)
+			if err := r.db.Exec(ctx, query); err != nil {
+				return model.InternalError(fmt.Errorf("could not add session id column to %s.%s: %w", t.database, table, err))
+			}
+		}
+
+		query := fmt.Sprintf(
+			"ALTER TABLE %s.%s ON CLUSTER %s ADD INDEX IF NOT EXISTS %s_idx (%s) TYPE %s GRANULARITY %d",
+			t.database, t.local, r.cluster, sessionIdColumn, sessionIdColumn,
+			constants.DefaultLogSkipIndexType, constants.DefaultLogSkipIndexGranularity,
+		)
+		if err := r.db.Exec(ctx, query); err != nil {
+			return model.InternalError(fmt.Errorf("could not add session id index to %s.%s: %w", t.database, t.local, err))
+		}
+	}
+	return nil
+}
+
+// sessionIdExpressions returns the materialized session id column of the logs
+// and spans tables when they are indexed and the expressions reading the
+// session id from attributes otherwise
+func (r *ClickHouseReader) sessionIdExpressions(ctx context.Context) (string, string, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT database, table FROM system.columns WHERE name = @column AND ((database = @logsDB AND table = @logsTable) OR (database = @traceDB AND table = @spansTable))",
+		clickhouse.Named("column", sessionIdColumn),
+		clickhouse.Named("logsDB", r.logsDB),
+		clickhouse.Named("logsTable", r.logsTable),
+		clickhouse.Named("traceDB", r.TraceDB),
+		clickhouse.Named("spansTable", r.indexTable),
+	)
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	logsExpr, spansExpr := r.logsSessionIdExpression(), r.spansSessionIdExpression()
+	for rows.Next() {
+		var database, table string
+		if err := rows.Scan(&database, &table); err != nil {
+			return "", "", err
+		}
+		if database == r.logsDB && table == r.logsTable {
+			logsExpr = sessionIdColumn
+		} else {
+			spansExpr = sessionIdColumn
+		}
+	}
+	return logsExpr, spansExpr, nil
+}
+
+// GetSessionTimeline returns the logs and spans of a session in the time
+// range merged into a single timeline, oldest first
+func (r *ClickHouseReader) GetSessionTimeline(
+	ctx context.Context, params *model.SessionTimelineParams,
+) (*model.SessionTimeline, *model.ApiError) {
+	logsExpr, spansExpr, err := r.sessionIdExpressions(ctx)
+	if err != nil {
+		zap.L().Error("could not find session id columns", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not find session id columns: %w", err))
+	}
+
+	logsQuery := fmt.Sprintf(`
+		SELECT timestamp, id, trace_id, span_id, severity_text, body,
+			resources_string_value[indexOf(resources_string_key, 'service.name')] as service_name
+		FROM %s.%s
+		WHERE timestamp >= @start AND timestamp <= @end AND %s = @sessionId
+		ORDER BY timestamp ASC LIMIT @limit`,
+		r.logsDB, r.logsTable, logsExpr,
+	)
+	logs := []model.SessionLog{}
+	err = r.db.Select(ctx, &logs, logsQuery,
+		clickhouse.Named("start", uint64(params.Start.UnixNano())),
+		clickhouse.Named("end", uint64(params.End.UnixNano())),
+		clickhouse.Named("sessionId", params.SessionId),
+		clickhouse.Named("limit", params.Limit+1),
+	)
+	if err != nil {
+		zap.L().Error("could not query logs of session", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query logs of session: %w", err))
+	}
+
+	spansQuery := fmt.Sprintf(`
+		SELECT timestamp, traceID, spanID, parentSpanID, serviceName, name, durationNano, hasError
+		FROM %s.%s
+		WHERE timestamp >= @start AND timestamp <= @end AND %s = @sessionId
+		ORDER BY timestamp ASC LIMIT @limit`,
+		r.TraceDB, r.indexTable, spansExpr,
+	)
+	spans := []model.SessionSpan{}
+	err = r.db.Select(ctx, &spans, spansQuery,
+		clickhouse.Named("start", strconv.FormatInt(params.Start.UnixNano(), 10)),
+		clickhouse.Named("end", strconv.FormatInt(params.End.UnixNano(), 10)),
+		clickhouse.Named("sessionId", params.SessionId),
+		clickhouse.Named("limit", params.Limit+1),
+	)
+	if err != nil {
+		zap.L().Error("could not query spans of session", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("could not query spans of session: %w", err))
+	}
+
+	return buildSessionTimeline(params.SessionId, logs, spans, params.Limit), nil
+}
+
+// buildSessionTimeline merges the logs and spans of a session, each sorted
+// by time, keeping the oldest limit items
+func buildSessionTimeline(
+	sessionId string, logs []model.SessionLog, spans []model.SessionSpan, limit int,
+) *model.SessionTimeline {
+	timeline := &model.SessionTimeline{
+		SessionId: sessionId,
+		Services:  []string{},
+		TraceIds:  []string{},
+		Items:     []model.SessionTimelineItem{},
+	}
+
+	for _, l := range logs {
+		timeline.Items = append(timeline.Items, model.SessionTimelineItem{
+			Timestamp:    int64(l.Timestamp),
+			Signal:       "logs",
+			ServiceName:  l.ServiceName,
+			TraceId:      l.TraceId,
+			SpanId:       l.SpanId,
+			LogId:        l.Id,
+			SeverityText: l.SeverityText,
+			Body:         l.Body,
+		})
+	}
+	for _, s := range spans {
+		timeline.Items = append(timeline.Items, model.SessionTimelineItem{
+			Timestamp:    s.Timestamp.UnixNano(),
+			Signal:       "traces",
+			ServiceName:  s.ServiceName,
+			TraceId:      s.TraceId,
+			SpanId:       s.SpanId,
+			ParentSpanId: s.ParentSpanId,
+			Name:         s.Name,
+			DurationNano: s.DurationNano,
+			HasError:     s.HasError,
+		})
+	}
+	sort.SliceStable(timeline.Items, func(i, j int) bool {
+		return timeline.Items[i].Timestamp < timeline.Items[j].Timestamp
+	})
+	if len(timeline.Items) > limit {
+		timeline.Items = timeline.Items[:limit]
+		timeline.Truncated = true
+	}
+
+	services := map[string]bool{}
+	traces := map[string]bool{}
+	for _, item := range timeline.Items {
+		if item.Signal == "logs" {
+			timeline.Logs++
+		} else {
+			timeline.Spans++
+		}
+		if item.ServiceName != "" && !services[item.ServiceName] {
+			services[item.ServiceName] = true
+			timeline.Services = append(timeline.Services, item.ServiceName)
+		}
+		if item.TraceId != "" && !traces[item.TraceId] {
+			traces[item.TraceId] = true
+			timeline.TraceIds = append(timeline.TraceIds, item.TraceId)
+		}
+	}
+	if len(timeline.Items) > 0 {
+		timeline.Start = timeline.Items[0].Timestamp
+		timeline.End = timeline.Items[len(timeline.Items)-1].Timestamp
+	}
+	return timeline
+}
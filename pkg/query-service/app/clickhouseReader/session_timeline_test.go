@@ -0,0 +1,55 @@
+package clickhouseReader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestBuildSessionTimeline(t *testing.T) {
+	require := require.New(t)
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	logs := []model.SessionLog{
+		{Timestamp: uint64(t0.UnixNano()), Id: "log1", TraceId: "trace1", ServiceName: "frontend", Body: "clicked"},
+		{Timestamp: uint64(t0.Add(3 * time.Second).UnixNano()), Id: "log2", ServiceName: "frontend", Body: "rendered"},
+	}
+	spans := []model.SessionSpan{
+		{Timestamp: t0.Add(time.Second), TraceId: "trace1", SpanId: "span1", ServiceName: "checkout", Name: "POST /cart"},
+		{Timestamp: t0.Add(2 * time.Second), TraceId: "trace2", SpanId: "span2", ServiceName: "checkout", HasError: true},
+	}
+
+	timeline := buildSessionTimeline("session1", logs, spans, 10)
+	require.Equal("session1", timeline.SessionId)
+	ids := []string{}
+	for _, item := range timeline.Items {
+		ids = append(ids, item.LogId+item.SpanId)
+	}
+	require.Equal([]string{"log1", "span1", "span2", "log2"}, ids, "logs and spans are merged by time")
+	require.Equal("logs", timeline.Items[0].Signal)
+	require.Equal("traces", timeline.Items[1].Signal)
+	require.True(timeline.Items[2].HasError)
+	require.Equal(2, timeline.Logs)
+	require.Equal(2, timeline.Spans)
+	require.Equal([]string{"frontend", "checkout"}, timeline.Services)
+	require.Equal([]string{"trace1", "trace2"}, timeline.TraceIds)
+	require.Equal(t0.UnixNano(), timeline.Start)
+	require.Equal(t0.Add(3*time.Second).UnixNano(), timeline.End)
+	require.False(timeline.Truncated)
+
+	// the oldest items are kept
+	timeline = buildSessionTimeline("session1", logs, spans, 2)
+	require.True(timeline.Truncated)
+	require.Len(timeline.Items, 2)
+	require.Equal(1, timeline.Logs)
+	require.Equal(1, timeline.Spans)
+	require.Equal([]string{"trace1"}, timeline.TraceIds)
+	require.Equal(t0.Add(time.Second).UnixNano(), timeline.End)
+
+	timeline = buildSessionTimeline("session1", nil, nil, 10)
+	require.NotNil(timeline.Items)
+	require.Empty(timeline.Items)
+	require.Zero(timeline.Start)
+}
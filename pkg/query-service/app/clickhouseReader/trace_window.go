@@ -0,0 +1,81 @@
+package clickhouseReader
+
+import (
+	"sort"
+	"time"
+)
+
+type spanTreeNode struct {
+	Timestamp    time.Time `ch:"timestamp"`
+	SpanID       string    `ch:"spanID"`
+	ParentSpanID string    `ch:"parentSpanID"`
+}
+
+type spanWindow struct {
+	spanIds    []string
+	childCount map[string]int
+	// number of direct children of the parent included in the window
+	consumed int
+	hasMore  bool
+}
+
+// selectSpanWindow picks the descendants of parentSpanId, or of the trace roots
+// when empty, up to depth levels in waterfall (depth first) order. Pagination
+// applies to the direct children, a window ends once limit spans are selected.
+func selectSpanWindow(nodes []spanTreeNode, parentSpanId string, depth, limit, offset int) spanWindow {
+	present := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		present[n.SpanID] = struct{}{}
+	}
+
+	children := map[string][]spanTreeNode{}
+	for _, n := range nodes {
+		parent := n.ParentSpanID
+		if _, ok := present[parent]; !ok {
+			// spans with missing parents are shown as roots
+			parent = ""
+		}
+		children[parent] = append(children[parent], n)
+	}
+	for parent := range children {
+		siblings := children[parent]
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].Timestamp.Before(siblings[j].Timestamp)
+		})
+	}
+
+	window := spanWindow{
+		spanIds:    []string{},
+		childCount: map[string]int{},
+	}
+
+	var visit func(n spanTreeNode, level int)
+	visit = func(n spanTreeNode, level int) {
+		if len(window.spanIds) >= limit {
+			return
+		}
+		window.spanIds = append(window.spanIds, n.SpanID)
+		window.childCount[n.SpanID] = len(children[n.SpanID])
+		if level >= depth {
+			return
+		}
+		for _, child := range children[n.SpanID] {
+			visit(child, level+1)
+		}
+	}
+
+	top := children[parentSpanId]
+	if offset > len(top) {
+		offset = len(top)
+	}
+	for _, n := range top[offset:] {
+		if len(window.spanIds) >= limit {
+			break
+		}
+		visit(n, 1)
+		window.consumed++
+	}
+	window.hasMore = offset+window.consumed < len(top)
+
+	return window
+}
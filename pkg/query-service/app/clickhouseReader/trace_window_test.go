@@ -0,0 +1,53 @@
+package clickhouseReader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectSpanWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := time.Now()
+	at := func(offset int) time.Time { return ts.Add(time.Duration(offset) * time.Millisecond) }
+	// root
+	//  ├── a
+	//  │   └── a1
+	//  ├── b
+	//  └── c
+	// orphan (parent missing from the trace)
+	nodes := []spanTreeNode{
+		{SpanID: "c", ParentSpanID: "root", Timestamp: at(3)},
+		{SpanID: "a1", ParentSpanID: "a", Timestamp: at(2)},
+		{SpanID: "root", ParentSpanID: "", Timestamp: at(0)},
+		{SpanID: "a", ParentSpanID: "root", Timestamp: at(1)},
+		{SpanID: "b", ParentSpanID: "root", Timestamp: at(2)},
+		{SpanID: "orphan", ParentSpanID: "missing", Timestamp: at(5)},
+	}
+
+	window := selectSpanWindow(nodes, "", 1, 100, 0)
+	assert.Equal([]string{"root", "orphan"}, window.spanIds)
+	assert.Equal(3, window.childCount["root"])
+	assert.False(window.hasMore)
+
+	window = selectSpanWindow(nodes, "", 3, 100, 0)
+	assert.Equal([]string{"root", "a", "a1", "b", "c", "orphan"}, window.spanIds)
+
+	window = selectSpanWindow(nodes, "root", 1, 2, 0)
+	assert.Equal([]string{"a", "b"}, window.spanIds)
+	assert.Equal(1, window.childCount["a"])
+	assert.Equal(2, window.consumed)
+	assert.True(window.hasMore)
+
+	window = selectSpanWindow(nodes, "root", 1, 2, 2)
+	assert.Equal([]string{"c"}, window.spanIds)
+	assert.False(window.hasMore)
+
+	// the window ends mid subtree once the limit is reached
+	window = selectSpanWindow(nodes, "root", 2, 2, 0)
+	assert.Equal([]string{"a", "a1"}, window.spanIds)
+	assert.Equal(1, window.consumed)
+	assert.True(window.hasMore)
+}
@@ -0,0 +1,48 @@
+package dashboards
+
+import (
+	"sync"
+	"time"
+)
+
+// dashboardsCacheTTL bounds how long cached dashboards can be stale. View
+// statistics and dashboards written by another query service sharing the
+// db don't drop the cache.
+const dashboardsCacheTTL = 30 * time.Second
+
+// dashboardsCache keeps all dashboards in memory so that polling them
+// doesn't query the db every time. It is dropped on every write.
+var dashboardsCache = struct {
+	sync.Mutex
+	dashboards []Dashboard
+	expiresAt  time.Time
+	generation uint64
+}{}
+
+func invalidateDashboardsCache() {
+	dashboardsCache.Lock()
+	defer dashboardsCache.Unlock()
+	dashboardsCache.dashboards = nil
+	dashboardsCache.generation++
+}
+
+// cachedDashboards returns a copy of the cached dashboards, or nil along
+// with the current generation when they have to be fetched again
+func cachedDashboards() ([]Dashboard, uint64) {
+	dashboardsCache.Lock()
+	defer dashboardsCache.Unlock()
+	if dashboardsCache.dashboards == nil || time.Now().After(dashboardsCache.expiresAt) {
+		return nil, dashboardsCache.generation
+	}
+	return append([]Dashboard{}, dashboardsCache.dashboards...), dashboardsCache.generation
+}
+
+func cacheDashboards(dashboards []Dashboard, generation uint64) {
+	dashboardsCache.Lock()
+	defer dashboardsCache.Unlock()
+	// dashboards written while fetching them are not cached
+	if generation == dashboardsCache.generation {
+		dashboardsCache.dashboards = append([]Dashboard{}, dashboards...)
+		dashboardsCache.expiresAt = time.Now().Add(dashboardsCacheTTL)
+	}
+}
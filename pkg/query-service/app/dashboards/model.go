@@ -134,6 +134,42 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in adding column locked to dashboards table: %s", err.Error())
 	}
 
+	viewCount := `ALTER TABLE dashboards ADD COLUMN view_count INTEGER DEFAULT 0;`
+	_, err = db.Exec(viewCount)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column view_count to dashboards table: %s", err.Error())
+	}
+
+	lastAccessedAt := `ALTER TABLE dashboards ADD COLUMN last_accessed_at datetime;`
+	_, err = db.Exec(lastAccessedAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column last_accessed_at to dashboards table: %s", err.Error())
+	}
+
+	deletedAt := `ALTER TABLE dashboards ADD COLUMN deleted_at datetime;`
+	_, err = db.Exec(deletedAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column deleted_at to dashboards table: %s", err.Error())
+	}
+
+	deletedBy := `ALTER TABLE dashboards ADD COLUMN deleted_by TEXT;`
+	_, err = db.Exec(deletedBy)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column deleted_by to dashboards table: %s", err.Error())
+	}
+
+	deletedAt = `ALTER TABLE rules ADD COLUMN deleted_at datetime;`
+	_, err = db.Exec(deletedAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column deleted_at to rules table: %s", err.Error())
+	}
+
+	deletedBy = `ALTER TABLE rules ADD COLUMN deleted_by TEXT;`
+	_, err = db.Exec(deletedBy)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column deleted_by to rules table: %s", err.Error())
+	}
+
 	return db, nil
 }
 
@@ -148,6 +184,14 @@ type Dashboard struct {
 	Title     string    `json:"-" db:"-"`
 	Data      Data      `json:"data" db:"data"`
 	Locked    *int      `json:"isLocked" db:"locked"`
+
+	// access stats, bumped when the dashboard is opened
+	ViewCount      int64      `json:"viewCount,omitempty" db:"view_count"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty" db:"last_accessed_at"`
+
+	// deleted dashboards stay in the trash until purged
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	DeletedBy *string    `json:"deletedBy,omitempty" db:"deleted_by"`
 }
 
 type Data map[string]interface{}
@@ -205,6 +249,7 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 
 	result, err := db.Exec("INSERT INTO dashboards (uuid, created_at, created_by, updated_at, updated_by, data) VALUES ($1, $2, $3, $4, $5, $6)",
 		dash.Uuid, dash.CreatedAt, userEmail, dash.UpdatedAt, userEmail, mapData)
+	invalidateDashboardsCache()
 
 	if err != nil {
 		zap.S().Errorf("Error in inserting dashboard data: ", dash, err)
@@ -226,15 +271,20 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 }
 
 func GetDashboards(ctx context.Context) ([]Dashboard, *model.ApiError) {
+	cached, generation := cachedDashboards()
+	if cached != nil {
+		return cached, nil
+	}
 
 	dashboards := []Dashboard{}
-	query := `SELECT * FROM dashboards`
+	query := `SELECT * FROM dashboards WHERE deleted_at IS NULL`
 
 	err := db.Select(&dashboards, query)
 	if err != nil {
 		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
 	}
 
+	cacheDashboards(dashboards, generation)
 	return dashboards, nil
 }
 
@@ -252,9 +302,16 @@ func DeleteDashboard(ctx context.Context, uuid string, fm interfaces.FeatureLook
 		}
 	}
 
-	query := `DELETE FROM dashboards WHERE uuid=?`
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+
+	// dashboards are moved to the trash, they are purged after the retention
+	query := `UPDATE dashboards SET deleted_at=$1, deleted_by=$2 WHERE uuid=$3 AND deleted_at IS NULL`
 
-	result, err := db.Exec(query, uuid)
+	result, err := db.Exec(query, time.Now(), userEmail, uuid)
+	invalidateDashboardsCache()
 
 	if err != nil {
 		return &model.ApiError{Typ: model.ErrorExec, Err: err}
@@ -277,9 +334,16 @@ func DeleteDashboard(ctx context.Context, uuid string, fm interfaces.FeatureLook
 }
 
 func GetDashboard(ctx context.Context, uuid string) (*Dashboard, *model.ApiError) {
+	if cached, _ := cachedDashboards(); cached != nil {
+		for _, d := range cached {
+			if d.Uuid == uuid {
+				return &d, nil
+			}
+		}
+	}
 
 	dashboard := Dashboard{}
-	query := `SELECT * FROM dashboards WHERE uuid=?`
+	query := `SELECT * FROM dashboards WHERE uuid=? AND deleted_at IS NULL`
 
 	err := db.Get(&dashboard, query, uuid)
 	if err != nil {
@@ -289,6 +353,48 @@ func GetDashboard(ctx context.Context, uuid string) (*Dashboard, *model.ApiError
 	return &dashboard, nil
 }
 
+// RecordDashboardView bumps the view count and last accessed time of a dashboard
+func RecordDashboardView(ctx context.Context, uuid string) *model.ApiError {
+	_, err := db.ExecContext(ctx,
+		`UPDATE dashboards SET view_count = COALESCE(view_count, 0) + 1, last_accessed_at = $1 WHERE uuid = $2`,
+		time.Now(), uuid,
+	)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// WithoutAccessStats returns copies of the dashboards without their access
+// stats, which change whenever a dashboard is opened, so that responses
+// only change with the dashboards
+func WithoutAccessStats(dashboards []Dashboard) []Dashboard {
+	stripped := make([]Dashboard, len(dashboards))
+	for i, dashboard := range dashboards {
+		dashboard.ViewCount = 0
+		dashboard.LastAccessedAt = nil
+		stripped[i] = dashboard
+	}
+	return stripped
+}
+
+// GetStaleDashboards returns the dashboards that have not been viewed since
+// the given time, dashboards created after it are not considered stale yet
+func GetStaleDashboards(ctx context.Context, since time.Time) ([]Dashboard, *model.ApiError) {
+	dashboards := []Dashboard{}
+	query := `SELECT * FROM dashboards
+		WHERE deleted_at IS NULL
+		AND ((last_accessed_at IS NULL AND created_at < $1) OR last_accessed_at < $1)
+		ORDER BY COALESCE(last_accessed_at, created_at)`
+
+	err := db.SelectContext(ctx, &dashboards, query, since)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	return dashboards, nil
+}
+
 func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface{}, fm interfaces.FeatureLookup) (*Dashboard, *model.ApiError) {
 
 	mapData, err := json.Marshal(data)
@@ -332,6 +438,7 @@ func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface
 
 	_, err = db.Exec("UPDATE dashboards SET updated_at=$1, updated_by=$2, data=$3 WHERE uuid=$4;",
 		dashboard.UpdatedAt, userEmail, mapData, dashboard.Uuid)
+	invalidateDashboardsCache()
 
 	if err != nil {
 		zap.S().Errorf("Error in inserting dashboard data: ", data, err)
@@ -353,6 +460,7 @@ func LockUnlockDashboard(ctx context.Context, uuid string, lock bool) *model.Api
 	}
 
 	_, err := db.Exec(query, uuid)
+	invalidateDashboardsCache()
 
 	if err != nil {
 		zap.S().Errorf("Error in updating dashboard: ", uuid, err)
@@ -0,0 +1,38 @@
+package dashboards
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// SearchSource lets dashboards be found by title, description and tags
+type SearchSource struct{}
+
+func (s *SearchSource) ListSearchable(ctx context.Context) ([]search.Item, *model.ApiError) {
+	dashboards, apiErr := GetDashboards(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []search.Item{}
+	for _, d := range dashboards {
+		item := search.Item{Id: d.Uuid, Labels: []string{}}
+		if title, ok := d.Data["title"].(string); ok {
+			item.Name = title
+		}
+		if description, ok := d.Data["description"].(string); ok {
+			item.Description = description
+		}
+		if tags, ok := d.Data["tags"].([]interface{}); ok {
+			for _, t := range tags {
+				if tag, ok := t.(string); ok {
+					item.Labels = append(item.Labels, tag)
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
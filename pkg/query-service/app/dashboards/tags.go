@@ -0,0 +1,59 @@
+package dashboards
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// TagStore lets the tags of dashboards be changed in bulk. Locked dashboards
+// can't be retagged, like any other edit of them.
+type TagStore struct {
+	FeatureFlags interfaces.FeatureLookup
+}
+
+func (s *TagStore) ListTagged(ctx context.Context) ([]tagging.Item, *model.ApiError) {
+	dashboards, apiErr := GetDashboards(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []tagging.Item{}
+	for _, d := range dashboards {
+		item := tagging.Item{Id: d.Uuid, Tags: []string{}}
+		if title, ok := d.Data["title"].(string); ok {
+			item.Name = title
+		}
+		if tags, ok := d.Data["tags"].([]interface{}); ok {
+			for _, t := range tags {
+				if tag, ok := t.(string); ok {
+					item.Tags = append(item.Tags, tag)
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *TagStore) SetTags(ctx context.Context, id string, tags []string) *model.ApiError {
+	dashboard, apiErr := GetDashboard(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range dashboard.Data {
+		data[k] = v
+	}
+	dashboardTags := []interface{}{}
+	for _, tag := range tags {
+		dashboardTags = append(dashboardTags, tag)
+	}
+	data["tags"] = dashboardTags
+
+	_, apiErr = UpdateDashboard(ctx, id, data, s.FeatureFlags)
+	return apiErr
+}
@@ -0,0 +1,94 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// TrashStore lets deleted dashboards be restored from the trash
+type TrashStore struct {
+	FeatureFlags interfaces.FeatureLookup
+}
+
+func getDeletedDashboard(ctx context.Context, uuid string) (*Dashboard, *model.ApiError) {
+	dashboard := Dashboard{}
+	query := `SELECT * FROM dashboards WHERE uuid=$1 AND deleted_at IS NOT NULL`
+	if err := db.GetContext(ctx, &dashboard, query, uuid); err != nil {
+		return nil, model.NotFoundError(fmt.Errorf("no deleted dashboard found with uuid: %s", uuid))
+	}
+	return &dashboard, nil
+}
+
+func (s *TrashStore) ListDeleted(ctx context.Context) ([]trash.Item, *model.ApiError) {
+	deleted := []Dashboard{}
+	query := `SELECT * FROM dashboards WHERE deleted_at IS NOT NULL`
+	if err := db.SelectContext(ctx, &deleted, query); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	items := []trash.Item{}
+	for _, d := range deleted {
+		item := trash.Item{Id: d.Uuid, DeletedAt: *d.DeletedAt}
+		if title, ok := d.Data["title"].(string); ok {
+			item.Name = title
+		}
+		if d.DeletedBy != nil {
+			item.DeletedBy = *d.DeletedBy
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *TrashStore) RestoreDeleted(ctx context.Context, uuid string) *model.ApiError {
+	dashboard, apiErr := getDeletedDashboard(ctx, uuid)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	// panels of restored dashboards count towards the feature usage again
+	traceAndLogsPanelUsage, _ := countTraceAndLogsPanel(dashboard.Data)
+	if traceAndLogsPanelUsage > 0 {
+		if apiErr := checkFeatureUsage(s.FeatureFlags, traceAndLogsPanelUsage); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	_, err := db.ExecContext(ctx,
+		`UPDATE dashboards SET deleted_at=NULL, deleted_by=NULL WHERE uuid=$1`, uuid,
+	)
+	invalidateDashboardsCache()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	if traceAndLogsPanelUsage > 0 {
+		updateFeatureUsage(s.FeatureFlags, traceAndLogsPanelUsage)
+	}
+	return nil
+}
+
+func (s *TrashStore) PurgeDeleted(ctx context.Context, uuid string) *model.ApiError {
+	if _, apiErr := getDeletedDashboard(ctx, uuid); apiErr != nil {
+		return apiErr
+	}
+
+	_, err := db.ExecContext(ctx, `DELETE FROM dashboards WHERE uuid=$1 AND deleted_at IS NOT NULL`, uuid)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+func (s *TrashStore) PurgeExpired(ctx context.Context, deletedBefore time.Time) *model.ApiError {
+	_, err := db.ExecContext(ctx, `DELETE FROM dashboards WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package datadeletion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/app/keyusage"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Controller deletes telemetry matching a selector with clickhouse
+// mutations and tracks their progress, keeping an audit record of every
+// deletion.
+type Controller struct {
+	repo     *Repo
+	reader   interfaces.Reader
+	keyUsage *keyusage.Controller
+}
+
+// NewController creates a controller deleting the telemetry with reader,
+// and the usage of the deleted values with keyUsage
+func NewController(
+	db *sqlx.DB, reader interfaces.Reader, keyUsage *keyusage.Controller,
+) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create data deletions repo: %w", err)
+	}
+
+	return &Controller{
+		repo:     repo,
+		reader:   reader,
+		keyUsage: keyUsage,
+	}, nil
+}
+
+func (c *Controller) ListDataDeletions(ctx context.Context) ([]DataDeletion, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+// GetDataDeletion returns a deletion with the progress of its mutations
+// while it is running, marking it as succeeded once they are all done or as
+// failed once one of them fails
+func (c *Controller) GetDataDeletion(ctx context.Context, id string) (*DataDeletion, *model.ApiError) {
+	deletion, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if deletion.Status != StatusRunning {
+		return deletion, nil
+	}
+
+	mutations, apiErr := c.reader.GetDataDeletionProgress(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	deletion.Progress = newProgress(mutations)
+
+	if deletion.Progress.FailReason != "" {
+		completedAt := time.Now()
+		if apiErr := c.repo.updateStatus(ctx, id, StatusFailed, deletion.Progress.FailReason, &completedAt); apiErr != nil {
+			return nil, apiErr
+		}
+		deletion.Status, deletion.Error, deletion.CompletedAt = StatusFailed, deletion.Progress.FailReason, &completedAt
+		zap.L().Error("data deletion failed", zap.String("id", id), zap.String("reason", deletion.Progress.FailReason))
+	} else if deletion.Progress.Done {
+		completedAt := time.Now()
+		if apiErr := c.repo.updateStatus(ctx, id, StatusSucceeded, "", &completedAt); apiErr != nil {
+			return nil, apiErr
+		}
+		deletion.Status, deletion.CompletedAt = StatusSucceeded, &completedAt
+		zap.L().Info("data deletion completed", zap.String("id", id))
+	}
+	return deletion, nil
+}
+
+// CreateDataDeletion records the deletion and submits its mutations, which
+// run in the background
+func (c *Controller) CreateDataDeletion(
+	ctx context.Context, postable *PostableDataDeletion,
+) (*DataDeletion, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	deletion := &DataDeletion{
+		Id:          uuid.NewString(),
+		Selector:    postable.Selector,
+		Reason:      postable.Reason,
+		Status:      StatusRunning,
+		RequestedBy: userId,
+		RequestedAt: time.Now(),
+	}
+	// the deletion is recorded before anything is deleted so that the audit
+	// record exists even if submitting the mutations fails half way
+	if apiErr := c.repo.insert(ctx, deletion); apiErr != nil {
+		return nil, apiErr
+	}
+	zap.L().Info("data deletion requested",
+		zap.String("id", deletion.Id), zap.String("requestedBy", userId),
+		zap.String("attributeKey", deletion.Selector.AttributeKey), zap.String("reason", deletion.Reason))
+
+	apiErr := c.keyUsage.ForgetValue(
+		ctx, deletion.Selector.Signals, deletion.Selector.AttributeKey, deletion.Selector.AttributeValue,
+	)
+	if apiErr == nil {
+		apiErr = c.reader.StartDataDeletion(ctx, deletion.Selector.params(deletion.Id))
+	}
+	if apiErr != nil {
+		completedAt := time.Now()
+		if err := c.repo.updateStatus(ctx, deletion.Id, StatusFailed, apiErr.Error(), &completedAt); err != nil {
+			zap.L().Error("could not record data deletion failure", zap.String("id", deletion.Id), zap.Error(err.ToError()))
+		}
+		return nil, apiErr
+	}
+	return deletion, nil
+}
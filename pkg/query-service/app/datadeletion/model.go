@@ -0,0 +1,141 @@
+package datadeletion
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// DataDeletion is a request to delete the telemetry of a subject, e.g. for
+// a right to be forgotten request. Deletions are kept as the audit record
+// of who deleted what and when.
+type DataDeletion struct {
+	Id          string     `json:"id" db:"id"`
+	Selector    Selector   `json:"selector" db:"selector_json"`
+	Reason      string     `json:"reason" db:"reason"`
+	Status      Status     `json:"status" db:"status"`
+	Error       string     `json:"error" db:"error"`
+	RequestedBy string     `json:"requestedBy" db:"requested_by"`
+	RequestedAt time.Time  `json:"requestedAt" db:"requested_at"`
+	CompletedAt *time.Time `json:"completedAt" db:"completed_at"`
+	Progress    *Progress  `json:"progress,omitempty" db:"-"`
+}
+
+// Selector is the telemetry to delete, the logs and the traces with a span
+// having the attribute, or resource attribute, value in the time range
+type Selector struct {
+	Signals        []v3.DataSource `json:"signals"`
+	AttributeKey   string          `json:"attributeKey"`
+	AttributeValue string          `json:"attributeValue"`
+	// Start and End are in milliseconds
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// For serializing from db
+func (s *Selector) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s Selector) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize data deletion selector to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *Selector) IsValid() error {
+	if len(s.Signals) == 0 {
+		return fmt.Errorf("at least one signal is required")
+	}
+	for _, signal := range s.Signals {
+		if signal != v3.DataSourceLogs && signal != v3.DataSourceTraces {
+			return fmt.Errorf("data can only be deleted from %s and %s", v3.DataSourceLogs, v3.DataSourceTraces)
+		}
+	}
+	if strings.TrimSpace(s.AttributeKey) == "" {
+		return fmt.Errorf("attributeKey is required")
+	}
+	// an empty value would match every log and span without the attribute
+	if s.AttributeValue == "" {
+		return fmt.Errorf("attributeValue is required")
+	}
+	if s.Start <= 0 || s.End <= s.Start {
+		return fmt.Errorf("start and end must be timestamps in milliseconds with start before end")
+	}
+	return nil
+}
+
+func (s *Selector) params(id string) *model.DataDeletionParams {
+	signals := make([]string, 0, len(s.Signals))
+	for _, signal := range s.Signals {
+		signals = append(signals, string(signal))
+	}
+	return &model.DataDeletionParams{
+		Id:             id,
+		Signals:        signals,
+		AttributeKey:   s.AttributeKey,
+		AttributeValue: s.AttributeValue,
+		Start:          time.UnixMilli(s.Start),
+		End:            time.UnixMilli(s.End),
+	}
+}
+
+type PostableDataDeletion struct {
+	Selector Selector `json:"selector"`
+	Reason   string   `json:"reason"`
+}
+
+func (p *PostableDataDeletion) IsValid() error {
+	if strings.TrimSpace(p.Reason) == "" {
+		return fmt.Errorf("reason is required for the audit record")
+	}
+	return p.Selector.IsValid()
+}
+
+// Progress of the mutations of a running deletion
+type Progress struct {
+	Mutations []model.DataDeletionMutation `json:"mutations"`
+	PartsToDo int64                        `json:"partsToDo"`
+	Done      bool                         `json:"done"`
+	// FailReason tells why the mutations which aren't done failed, if any
+	FailReason string `json:"failReason,omitempty"`
+}
+
+func newProgress(mutations []model.DataDeletionMutation) *Progress {
+	progress := &Progress{Mutations: mutations, Done: true}
+	failReasons := []string{}
+	for _, m := range mutations {
+		progress.PartsToDo += m.PartsToDo
+		if m.Done < m.Mutations {
+			progress.Done = false
+			if m.FailReason != "" {
+				failReasons = append(failReasons, fmt.Sprintf("%s.%s: %s", m.Database, m.Table, m.FailReason))
+			}
+		}
+	}
+	progress.FailReason = strings.Join(failReasons, "; ")
+	return progress
+}
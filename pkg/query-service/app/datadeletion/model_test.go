@@ -0,0 +1,65 @@
+package datadeletion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestSelectorIsValid(t *testing.T) {
+	valid := Selector{
+		Signals:        []v3.DataSource{v3.DataSourceLogs, v3.DataSourceTraces},
+		AttributeKey:   "user.id",
+		AttributeValue: "42",
+		Start:          1700000000000,
+		End:            1700003600000,
+	}
+	require.NoError(t, valid.IsValid())
+
+	tests := []struct {
+		name   string
+		modify func(s *Selector)
+	}{
+		{"no signals", func(s *Selector) { s.Signals = nil }},
+		{"metrics", func(s *Selector) { s.Signals = []v3.DataSource{v3.DataSourceMetrics} }},
+		{"no attribute key", func(s *Selector) { s.AttributeKey = " " }},
+		{"no attribute value", func(s *Selector) { s.AttributeValue = "" }},
+		{"end before start", func(s *Selector) { s.End = s.Start - 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			require.Error(t, s.IsValid())
+		})
+	}
+
+	params := valid.params("id")
+	require.Equal(t, []string{"logs", "traces"}, params.Signals)
+	require.Equal(t, valid.Start, params.Start.UnixMilli())
+	require.Equal(t, valid.End, params.End.UnixMilli())
+}
+
+func TestNewProgress(t *testing.T) {
+	progress := newProgress([]model.DataDeletionMutation{
+		{Database: "signoz_logs", Table: "logs", Mutations: 2, Done: 2},
+		{Database: "signoz_traces", Table: "signoz_index_v2", Mutations: 2, Done: 1, PartsToDo: 7},
+	})
+	require.False(t, progress.Done)
+	require.Equal(t, int64(7), progress.PartsToDo)
+
+	progress = newProgress([]model.DataDeletionMutation{
+		{Database: "signoz_logs", Table: "logs", Mutations: 2, Done: 2},
+	})
+	require.True(t, progress.Done)
+	require.Empty(t, progress.FailReason)
+
+	progress = newProgress([]model.DataDeletionMutation{
+		{Database: "signoz_logs", Table: "logs", Mutations: 1, Done: 1, FailReason: "retried"},
+		{Database: "signoz_traces", Table: "signoz_index_v2", Mutations: 1, FailReason: "Memory limit exceeded"},
+	})
+	require.False(t, progress.Done)
+	require.Equal(t, "signoz_traces.signoz_index_v2: Memory limit exceeded", progress.FailReason)
+}
@@ -0,0 +1,122 @@
+package datadeletion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS data_deletions(
+			id TEXT PRIMARY KEY,
+			selector_json TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			requested_by TEXT NOT NULL,
+			requested_at TIMESTAMP NOT NULL,
+			completed_at TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure data deletions schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for data deletions: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]DataDeletion, *model.ApiError) {
+	deletions := []DataDeletion{}
+
+	err := r.db.SelectContext(ctx, &deletions, `
+		SELECT id, selector_json, reason, status, error, requested_by, requested_at, completed_at
+		FROM data_deletions
+		ORDER BY requested_at DESC
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query data deletions: %w", err,
+		))
+	}
+	return deletions, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*DataDeletion, *model.ApiError) {
+	deletions := []DataDeletion{}
+
+	err := r.db.SelectContext(ctx, &deletions, `
+		SELECT id, selector_json, reason, status, error, requested_by, requested_at, completed_at
+		FROM data_deletions
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query data deletion %s: %w", id, err,
+		))
+	}
+
+	if len(deletions) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("data deletion %s not found", id))
+	}
+	return &deletions[0], nil
+}
+
+func (r *Repo) insert(ctx context.Context, deletion *DataDeletion) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO data_deletions (
+			id, selector_json, reason, status, error, requested_by, requested_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		deletion.Id, deletion.Selector, deletion.Reason, deletion.Status,
+		deletion.Error, deletion.RequestedBy, deletion.RequestedAt,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not insert data deletion: %w", err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) updateStatus(
+	ctx context.Context, id string, status Status, errMsg string, completedAt *time.Time,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_deletions SET status = $1, error = $2, completed_at = $3 WHERE id = $4
+	`, status, errMsg, completedAt, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update status of data deletion %s: %w", id, err,
+		))
+	}
+	return nil
+}
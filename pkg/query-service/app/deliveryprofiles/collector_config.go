@@ -0,0 +1,183 @@
+package deliveryprofiles
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// storage extension of the persistent sending queues
+const queueStorageExtension = "file_storage/signoz_delivery"
+
+// exporter types supporting the sending_queue and retry_on_failure settings
+var queueingExporterTypes = []string{
+	"otlp",
+	"otlphttp",
+	"kafka",
+	"clickhousetraces",
+	"clickhouselogsexporter",
+	"clickhousemetricswrite",
+}
+
+// GenerateCollectorConfigWithDeliveryProfile sets the sending queue and
+// retry settings of the profile on the exporters of the pipelines, keeping
+// the exporters' other settings. Without a profile the exporters keep their
+// settings, except the persistent queue storage which is removed.
+func GenerateCollectorConfigWithDeliveryProfile(
+	config []byte, profile *DeliveryProfile,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+	exporters, ok := c["exporters"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("exporters not found in OTEL config"))
+	}
+
+	persistent := profile != nil && profile.Spec.SendingQueue != nil &&
+		profile.Spec.SendingQueue.Enabled && profile.Spec.SendingQueue.Persistent
+
+	for _, name := range pipelineExporters(pipelines) {
+		exporter, ok := exporters[name].(map[string]interface{})
+		if !ok {
+			if exporters[name] != nil {
+				continue
+			}
+			// exporters configured with their defaults
+			exporter = map[string]interface{}{}
+		}
+
+		if queue, ok := exporter["sending_queue"].(map[string]interface{}); ok &&
+			queue["storage"] == queueStorageExtension && !persistent {
+			delete(queue, "storage")
+		}
+
+		if profile == nil || !profile.appliesTo(name) {
+			if len(exporter) > 0 {
+				exporters[name] = exporter
+			}
+			continue
+		}
+
+		if q := profile.Spec.SendingQueue; q != nil {
+			queue, _ := exporter["sending_queue"].(map[string]interface{})
+			if queue == nil {
+				queue = map[string]interface{}{}
+			}
+			queue["enabled"] = q.Enabled
+			if q.NumConsumers > 0 {
+				queue["num_consumers"] = q.NumConsumers
+			}
+			if q.QueueSize > 0 {
+				queue["queue_size"] = q.QueueSize
+			}
+			if persistent {
+				queue["storage"] = queueStorageExtension
+			}
+			exporter["sending_queue"] = queue
+		}
+
+		if r := profile.Spec.RetryOnFailure; r != nil {
+			retry, _ := exporter["retry_on_failure"].(map[string]interface{})
+			if retry == nil {
+				retry = map[string]interface{}{}
+			}
+			retry["enabled"] = r.Enabled
+			for key, value := range map[string]string{
+				"initial_interval": r.InitialInterval,
+				"max_interval":     r.MaxInterval,
+				"max_elapsed_time": r.MaxElapsedTime,
+			} {
+				if value != "" {
+					retry[key] = value
+				}
+			}
+			exporter["retry_on_failure"] = retry
+		}
+		exporters[name] = exporter
+	}
+
+	extensions, _ := c["extensions"].(map[string]interface{})
+	serviceExtensions, _ := service["extensions"].([]interface{})
+	updatedServiceExtensions := []interface{}{}
+	for _, e := range serviceExtensions {
+		if e != queueStorageExtension {
+			updatedServiceExtensions = append(updatedServiceExtensions, e)
+		}
+	}
+	delete(extensions, queueStorageExtension)
+
+	if persistent {
+		if extensions == nil {
+			extensions = map[string]interface{}{}
+		}
+		extensions[queueStorageExtension] = map[string]interface{}{
+			"directory": profile.Spec.SendingQueue.Directory,
+		}
+		updatedServiceExtensions = append(updatedServiceExtensions, queueStorageExtension)
+	}
+
+	if len(extensions) > 0 {
+		c["extensions"] = extensions
+	} else {
+		delete(c, "extensions")
+	}
+	if len(updatedServiceExtensions) > 0 {
+		service["extensions"] = updatedServiceExtensions
+	} else {
+		delete(service, "extensions")
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+// appliesTo checks whether the profile manages the exporter, by name or by
+// type when the profile doesn't list exporters
+func (p *DeliveryProfile) appliesTo(exporter string) bool {
+	if len(p.Spec.Exporters) > 0 {
+		return slices.Contains(p.Spec.Exporters, exporter)
+	}
+	exporterType, _, _ := strings.Cut(exporter, "/")
+	return slices.Contains(queueingExporterTypes, exporterType)
+}
+
+// pipelineExporters returns the exporters used by the pipelines, sorted
+func pipelineExporters(pipelines map[string]interface{}) []string {
+	names := []string{}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		exporters, _ := pipeline["exporters"].([]interface{})
+		for _, e := range exporters {
+			if name, ok := e.(string); ok && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,114 @@
+package deliveryprofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhousetraces:
+    datasource: tcp://localhost:9000
+  otlp/upstream:
+    endpoint: signoz:4317
+    sending_queue:
+      queue_size: 500
+  debug: {}
+extensions:
+  health_check: {}
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces, debug]
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlp/upstream]
+`
+
+type testConf struct {
+	Exporters  map[string]map[string]interface{} `yaml:"exporters"`
+	Extensions map[string]map[string]interface{} `yaml:"extensions"`
+	Service    struct {
+		Extensions []string `yaml:"extensions"`
+	} `yaml:"service"`
+}
+
+func TestGenerateCollectorConfigWithDeliveryProfile(t *testing.T) {
+	require := require.New(t)
+
+	deliveryProfiles := []DeliveryProfile{
+		{
+			Id: "default", Name: "default",
+			Spec: DeliveryProfileSpec{
+				RetryOnFailure: &RetryOnFailureConfig{Enabled: true, MaxElapsedTime: "10m"},
+			},
+		},
+		{
+			Id: "edge", Name: "edge", AgentGroup: "edge",
+			Spec: DeliveryProfileSpec{
+				SendingQueue: &SendingQueueConfig{
+					Enabled: true, NumConsumers: 4, Persistent: true, Directory: "/var/lib/signoz/queue",
+				},
+			},
+		},
+	}
+	for _, p := range deliveryProfiles {
+		require.Nil(p.Spec.IsValid())
+	}
+	require.Equal("edge", profileForGroup(deliveryProfiles, "edge").Id)
+	require.Equal("default", profileForGroup(deliveryProfiles, "core").Id)
+	require.Nil(profileForGroup(deliveryProfiles[1:], "core"))
+
+	updated, apiErr := GenerateCollectorConfigWithDeliveryProfile(
+		[]byte(testCollectorConf), &deliveryProfiles[1],
+	)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(map[string]interface{}{
+		"enabled": true, "num_consumers": 4, "storage": queueStorageExtension,
+	}, conf.Exporters["clickhousetraces"]["sending_queue"])
+	require.Equal(map[string]interface{}{
+		"enabled": true, "num_consumers": 4, "queue_size": 500, "storage": queueStorageExtension,
+	}, conf.Exporters["otlp/upstream"]["sending_queue"], "unset sizes should keep the exporter's settings")
+	require.Empty(conf.Exporters["debug"], "exporters without queues should not be changed")
+	require.Equal("/var/lib/signoz/queue", conf.Extensions[queueStorageExtension]["directory"])
+	require.Equal([]string{"health_check", queueStorageExtension}, conf.Service.Extensions)
+
+	// switching to a profile without persistent queues removes the storage
+	updated, apiErr = GenerateCollectorConfigWithDeliveryProfile(updated, &deliveryProfiles[0])
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(map[string]interface{}{
+		"enabled": true, "num_consumers": 4,
+	}, conf.Exporters["clickhousetraces"]["sending_queue"])
+	require.Equal(map[string]interface{}{
+		"enabled": true, "max_elapsed_time": "10m",
+	}, conf.Exporters["otlp/upstream"]["retry_on_failure"])
+	require.Nil(conf.Exporters["debug"]["retry_on_failure"])
+	require.Equal(1, len(conf.Extensions))
+	require.Equal([]string{"health_check"}, conf.Service.Extensions)
+
+	invalid := []DeliveryProfileSpec{
+		{},
+		{SendingQueue: &SendingQueueConfig{Enabled: true, Persistent: true}},
+		{SendingQueue: &SendingQueueConfig{Enabled: true, QueueSize: -1}},
+		{RetryOnFailure: &RetryOnFailureConfig{Enabled: true, MaxInterval: "often"}},
+	}
+	for _, spec := range invalid {
+		require.NotNil(spec.IsValid(), spec)
+	}
+}
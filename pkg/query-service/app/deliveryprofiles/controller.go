@@ -0,0 +1,236 @@
+package deliveryprofiles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const DeliveryProfilesFeatureType agentConf.AgentFeatureType = "delivery_profiles"
+
+// Controller manages the queueing and retry settings of the agents'
+// exporters per agent group and deploys them via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create delivery profiles repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type DeliveryProfilesResponse struct {
+	*agentConf.ConfigVersion
+
+	DeliveryProfiles []DeliveryProfile `json:"deliveryProfiles"`
+}
+
+func (c *Controller) ListDeliveryProfiles(ctx context.Context) (
+	*DeliveryProfilesResponse, *model.ApiError,
+) {
+	deliveryProfiles, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeDeliveryProfiles)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest delivery profiles config version")
+	}
+
+	return &DeliveryProfilesResponse{
+		ConfigVersion:    latest,
+		DeliveryProfiles: deliveryProfiles,
+	}, nil
+}
+
+func (c *Controller) GetDeliveryProfile(ctx context.Context, id string) (
+	*DeliveryProfile, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateDeliveryProfile stores a new delivery profile and starts deploying
+// it to the agents of its group
+func (c *Controller) CreateDeliveryProfile(
+	ctx context.Context, postable *PostableDeliveryProfile,
+) (*DeliveryProfile, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	deliveryProfile, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, deliveryProfile.Id)
+		return nil, apiErr
+	}
+
+	return deliveryProfile, nil
+}
+
+// UpdateDeliveryProfile replaces a delivery profile and starts deploying
+// the updated settings
+func (c *Controller) UpdateDeliveryProfile(
+	ctx context.Context, id string, postable *PostableDeliveryProfile,
+) (*DeliveryProfile, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.AgentGroup = postable.AgentGroup
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.update(ctx, existing.UpdatedBy, existing)
+		return nil, apiErr
+	}
+
+	return &updated, nil
+}
+
+// DeleteDeliveryProfile removes a delivery profile. Agents of its group
+// fall back to the default profile, if any.
+func (c *Controller) DeleteDeliveryProfile(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	deliveryProfiles, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(deliveryProfiles))
+	for i, d := range deliveryProfiles {
+		elements[i] = d.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeDeliveryProfiles, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new delivery profiles config version")
+	}
+	return nil
+}
+
+// profileForGroup returns the profile of the agent group, or the default
+// profile if the group has none
+func profileForGroup(deliveryProfiles []DeliveryProfile, group string) *DeliveryProfile {
+	var fallback *DeliveryProfile
+	for i := range deliveryProfiles {
+		switch deliveryProfiles[i].AgentGroup {
+		case group:
+			return &deliveryProfiles[i]
+		case "":
+			fallback = &deliveryProfiles[i]
+		}
+	}
+	return fallback
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return DeliveryProfilesFeatureType
+}
+
+// Implements agentConf.AgentFeature interface. Agents outside of any group
+// get the default profile.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	return c.RecommendAgentConfigForAgent(opampModel.AgentInfo{}, currentConfYaml, configVersion)
+}
+
+// Implements agentConf.AgentScopedFeature interface. Agents get the profile
+// of their group.
+func (c *Controller) RecommendAgentConfigForAgent(
+	agent opampModel.AgentInfo,
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	deliveryProfiles, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithDeliveryProfile(
+		currentConfYaml, profileForGroup(deliveryProfiles, agent.Group),
+	)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for delivery profiles")
+	}
+
+	// the settings used are all the profiles of the version, so that
+	// deployment status is tracked for the version as a whole
+	rawDeliveryProfiles, err := json.Marshal(deliveryProfiles)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize delivery profiles to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawDeliveryProfiles), nil
+}
@@ -0,0 +1,128 @@
+package deliveryprofiles
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DeliveryProfile configures how the exporters of the agents of a group
+// queue and retry the data they send. The profile without an agent group
+// is the default for agents not matching any other profile.
+type DeliveryProfile struct {
+	Id         string              `json:"id" db:"id"`
+	Name       string              `json:"name" db:"name"`
+	AgentGroup string              `json:"agentGroup" db:"agent_group"`
+	Spec       DeliveryProfileSpec `json:"spec" db:"spec_json"`
+	CreatedBy  string              `json:"createdBy" db:"created_by"`
+	CreatedAt  time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedBy  string              `json:"updatedBy" db:"updated_by"`
+	UpdatedAt  time.Time           `json:"updatedAt" db:"updated_at"`
+}
+
+type DeliveryProfileSpec struct {
+	SendingQueue   *SendingQueueConfig   `json:"sendingQueue,omitempty"`
+	RetryOnFailure *RetryOnFailureConfig `json:"retryOnFailure,omitempty"`
+
+	// Exporters the profile applies to, all the queueing exporters of the
+	// agent's pipelines if empty
+	Exporters []string `json:"exporters,omitempty"`
+}
+
+// SendingQueueConfig maps to the exporters' sending_queue settings.
+// Zero sizes keep the collector's defaults.
+type SendingQueueConfig struct {
+	Enabled      bool `json:"enabled"`
+	NumConsumers int  `json:"numConsumers,omitempty"`
+	QueueSize    int  `json:"queueSize,omitempty"`
+
+	// Persistent queues are stored in Directory on the agents' hosts so
+	// that queued data survives restarts
+	Persistent bool   `json:"persistent,omitempty"`
+	Directory  string `json:"directory,omitempty"`
+}
+
+// RetryOnFailureConfig maps to the exporters' retry_on_failure settings.
+// Intervals are durations like 5s, empty ones keep the collector's defaults.
+type RetryOnFailureConfig struct {
+	Enabled         bool   `json:"enabled"`
+	InitialInterval string `json:"initialInterval,omitempty"`
+	MaxInterval     string `json:"maxInterval,omitempty"`
+	MaxElapsedTime  string `json:"maxElapsedTime,omitempty"`
+}
+
+// For serializing from db
+func (s *DeliveryProfileSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s DeliveryProfileSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize delivery profile spec to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableDeliveryProfile struct {
+	Name       string              `json:"name"`
+	AgentGroup string              `json:"agentGroup"`
+	Spec       DeliveryProfileSpec `json:"spec"`
+}
+
+func (p *PostableDeliveryProfile) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("delivery profile name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *DeliveryProfileSpec) IsValid() error {
+	if s.SendingQueue == nil && s.RetryOnFailure == nil {
+		return fmt.Errorf("sendingQueue or retryOnFailure is required")
+	}
+
+	if q := s.SendingQueue; q != nil {
+		if q.NumConsumers < 0 || q.QueueSize < 0 {
+			return fmt.Errorf("numConsumers and queueSize can't be negative")
+		}
+		if q.Persistent && !filepath.IsAbs(q.Directory) {
+			return fmt.Errorf("an absolute directory is required for persistent queues")
+		}
+	}
+
+	if r := s.RetryOnFailure; r != nil {
+		durations := map[string]string{
+			"initialInterval": r.InitialInterval,
+			"maxInterval":     r.MaxInterval,
+			"maxElapsedTime":  r.MaxElapsedTime,
+		}
+		for field, value := range durations {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return fmt.Errorf("%s must be a duration like 5s, got %s", field, value)
+			}
+		}
+	}
+
+	for _, exporter := range s.Exporters {
+		if strings.TrimSpace(exporter) == "" {
+			return fmt.Errorf("exporter names can't be empty")
+		}
+	}
+	return nil
+}
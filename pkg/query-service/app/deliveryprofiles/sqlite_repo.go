@@ -0,0 +1,228 @@
+package deliveryprofiles
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS delivery_profiles(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			agent_group TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure delivery profiles schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for delivery profiles: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]DeliveryProfile, *model.ApiError) {
+	deliveryProfiles := []DeliveryProfile{}
+
+	err := r.db.SelectContext(ctx, &deliveryProfiles, `
+		SELECT id, name, agent_group, spec_json, created_by, created_at, updated_by, updated_at
+		FROM delivery_profiles
+		ORDER BY agent_group
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query delivery profiles: %w", err,
+		))
+	}
+	return deliveryProfiles, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*DeliveryProfile, *model.ApiError) {
+	deliveryProfiles := []DeliveryProfile{}
+
+	err := r.db.SelectContext(ctx, &deliveryProfiles, `
+		SELECT id, name, agent_group, spec_json, created_by, created_at, updated_by, updated_at
+		FROM delivery_profiles
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query delivery profile %s: %w", id, err,
+		))
+	}
+
+	if len(deliveryProfiles) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("delivery profile %s not found", id))
+	}
+	return &deliveryProfiles[0], nil
+}
+
+// getByVersion returns delivery profiles associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]DeliveryProfile, *model.ApiError) {
+	deliveryProfiles := []DeliveryProfile{}
+
+	err := r.db.SelectContext(ctx, &deliveryProfiles, `
+		SELECT d.id, d.name, d.agent_group, d.spec_json, d.created_by, d.created_at, d.updated_by, d.updated_at
+		FROM delivery_profiles d,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE d.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY d.agent_group
+	`, agentConf.ElementTypeDeliveryProfiles, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query delivery profiles for version %d: %w", version, err,
+		))
+	}
+	return deliveryProfiles, nil
+}
+
+// ensureIsUnique rejects a second profile with the same name or agent group
+func (r *Repo) ensureIsUnique(ctx context.Context, profile *DeliveryProfile) *model.ApiError {
+	existing := []DeliveryProfile{}
+	err := r.db.SelectContext(ctx, &existing, `
+		SELECT id, name, agent_group, spec_json, created_by, created_at, updated_by, updated_at
+		FROM delivery_profiles
+		WHERE (name = $1 OR agent_group = $2) AND id != $3
+	`, profile.Name, profile.AgentGroup, profile.Id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query delivery profiles: %w", err,
+		))
+	}
+
+	for _, e := range existing {
+		if e.Name == profile.Name {
+			return &model.ApiError{
+				Typ: model.ErrorConflict,
+				Err: fmt.Errorf("a delivery profile named %s already exists", profile.Name),
+			}
+		}
+		if profile.AgentGroup == "" {
+			return &model.ApiError{
+				Typ: model.ErrorConflict,
+				Err: fmt.Errorf("the default delivery profile already exists: %s", e.Name),
+			}
+		}
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf(
+				"agent group %s already has the delivery profile %s", profile.AgentGroup, e.Name,
+			),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableDeliveryProfile,
+) (*DeliveryProfile, *model.ApiError) {
+	now := time.Now()
+	deliveryProfile := &DeliveryProfile{
+		Id:         uuid.NewString(),
+		Name:       postable.Name,
+		AgentGroup: postable.AgentGroup,
+		Spec:       postable.Spec,
+		CreatedBy:  userId,
+		CreatedAt:  now,
+		UpdatedBy:  userId,
+		UpdatedAt:  now,
+	}
+
+	if apiErr := r.ensureIsUnique(ctx, deliveryProfile); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO delivery_profiles (
+			id, name, agent_group, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		deliveryProfile.Id, deliveryProfile.Name, deliveryProfile.AgentGroup,
+		deliveryProfile.Spec, deliveryProfile.CreatedBy, deliveryProfile.CreatedAt,
+		deliveryProfile.UpdatedBy, deliveryProfile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert delivery profile: %w", err,
+		))
+	}
+
+	return deliveryProfile, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, deliveryProfile *DeliveryProfile,
+) *model.ApiError {
+	if apiErr := r.ensureIsUnique(ctx, deliveryProfile); apiErr != nil {
+		return apiErr
+	}
+
+	deliveryProfile.UpdatedBy = userId
+	deliveryProfile.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_profiles
+		SET name = $1, agent_group = $2, spec_json = $3, updated_by = $4, updated_at = $5
+		WHERE id = $6
+	`,
+		deliveryProfile.Name, deliveryProfile.AgentGroup, deliveryProfile.Spec,
+		deliveryProfile.UpdatedBy, deliveryProfile.UpdatedAt, deliveryProfile.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update delivery profile %s: %w", deliveryProfile.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM delivery_profiles WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete delivery profile %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,544 @@
+package demodata
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/prompb"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	SignalTraces  = "traces"
+	SignalLogs    = "logs"
+	SignalMetrics = "metrics"
+
+	maxDurationMinutes = 24 * 60
+	maxTraces          = 100000
+	// a trace has a span for every call in the call graph of its entry
+	// service, which grows quickly when services fan out
+	maxSpansPerTrace = 1000
+	maxSpans         = 1000000
+
+	// every generated span, log and series carries this attribute so demo
+	// data can be told apart from real telemetry
+	demoAttribute = "signoz.demo"
+
+	requestsMetric = "demo_requests_total"
+	durationMetric = "demo_request_duration_milliseconds"
+)
+
+// ServiceSpec describes a service to generate telemetry for
+type ServiceSpec struct {
+	Name       string   `json:"name"`
+	Operations []string `json:"operations"`
+	// Calls are the names of the services called by every operation
+	Calls []string `json:"calls,omitempty"`
+	// ErrorRate is the fraction of operations that fail, between 0 and 1
+	ErrorRate      float64 `json:"errorRate"`
+	MeanDurationMs float64 `json:"meanDurationMs"`
+}
+
+var defaultServices = []ServiceSpec{
+	{
+		Name:           "frontend",
+		Operations:     []string{"GET /", "GET /cart", "POST /checkout"},
+		Calls:          []string{"cart", "checkout"},
+		ErrorRate:      0.01,
+		MeanDurationMs: 15,
+	},
+	{
+		Name:           "cart",
+		Operations:     []string{"GetCart", "AddItem"},
+		Calls:          []string{"redis"},
+		ErrorRate:      0.005,
+		MeanDurationMs: 5,
+	},
+	{
+		Name:           "checkout",
+		Operations:     []string{"PlaceOrder"},
+		Calls:          []string{"payment"},
+		ErrorRate:      0.02,
+		MeanDurationMs: 40,
+	},
+	{
+		Name:           "payment",
+		Operations:     []string{"Charge"},
+		ErrorRate:      0.05,
+		MeanDurationMs: 120,
+	},
+	{
+		Name:           "redis",
+		Operations:     []string{"GET", "SET"},
+		ErrorRate:      0.001,
+		MeanDurationMs: 1,
+	},
+}
+
+// GenerateRequest configures the sample telemetry to generate
+type GenerateRequest struct {
+	Services        []ServiceSpec `json:"services,omitempty"`
+	Signals         []string      `json:"signals,omitempty"`
+	Environment     string        `json:"environment,omitempty"`
+	DurationMinutes int           `json:"durationMinutes,omitempty"`
+	TracesPerMinute int           `json:"tracesPerMinute,omitempty"`
+	// Seed makes the generated telemetry reproducible
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+func (req *GenerateRequest) setDefaults() {
+	if len(req.Services) == 0 {
+		req.Services = defaultServices
+	}
+	if len(req.Signals) == 0 {
+		req.Signals = []string{SignalTraces, SignalLogs, SignalMetrics}
+	}
+	if req.Environment == "" {
+		req.Environment = "demo"
+	}
+	if req.DurationMinutes == 0 {
+		req.DurationMinutes = 60
+	}
+	if req.TracesPerMinute == 0 {
+		req.TracesPerMinute = 10
+	}
+}
+
+func (req *GenerateRequest) IsValid() error {
+	req.setDefaults()
+
+	for _, signal := range req.Signals {
+		if signal != SignalTraces && signal != SignalLogs && signal != SignalMetrics {
+			return fmt.Errorf("invalid signal %s, use one of (traces, logs, metrics)", signal)
+		}
+	}
+	if req.DurationMinutes < 0 || req.DurationMinutes > maxDurationMinutes {
+		return fmt.Errorf("durationMinutes must be between 1 and %d", maxDurationMinutes)
+	}
+	if req.TracesPerMinute < 0 {
+		return fmt.Errorf("tracesPerMinute can not be negative")
+	}
+	if req.DurationMinutes*req.TracesPerMinute > maxTraces {
+		return fmt.Errorf("at most %d traces can be generated at once", maxTraces)
+	}
+
+	names := map[string]bool{}
+	for _, s := range req.Services {
+		if s.Name == "" {
+			return fmt.Errorf("service name is required")
+		}
+		if names[s.Name] {
+			return fmt.Errorf("duplicate service %s", s.Name)
+		}
+		names[s.Name] = true
+		if len(s.Operations) == 0 {
+			return fmt.Errorf("service %s must have at least one operation", s.Name)
+		}
+		if s.ErrorRate < 0 || s.ErrorRate > 1 {
+			return fmt.Errorf("errorRate of service %s must be between 0 and 1", s.Name)
+		}
+		if s.MeanDurationMs <= 0 {
+			return fmt.Errorf("meanDurationMs of service %s must be positive", s.Name)
+		}
+	}
+	for _, s := range req.Services {
+		for _, called := range s.Calls {
+			if !names[called] {
+				return fmt.Errorf("service %s calls unknown service %s", s.Name, called)
+			}
+		}
+	}
+
+	spans, err := spansPerTrace(req.Services)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entryServices(req.Services) {
+		if spans[entry.Name] > maxSpansPerTrace {
+			return fmt.Errorf(
+				"traces starting at service %s would have more than %d spans", entry.Name, maxSpansPerTrace,
+			)
+		}
+		if spans[entry.Name]*req.DurationMinutes*req.TracesPerMinute > maxSpans {
+			return fmt.Errorf("at most %d spans can be generated at once", maxSpans)
+		}
+	}
+	return nil
+}
+
+// spansPerTrace counts the spans of a trace starting at each service, the
+// span of the service and those of the services it calls. Services calling
+// each other in a cycle are rejected as their traces would never end.
+func spansPerTrace(services []ServiceSpec) (map[string]int, error) {
+	byName := map[string]ServiceSpec{}
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	spans := map[string]int{}
+	visiting := map[string]bool{}
+	var count func(name string, path []string) error
+	count = func(name string, path []string) error {
+		if _, ok := spans[name]; ok {
+			return nil
+		}
+		path = append(path, name)
+		if visiting[name] {
+			return fmt.Errorf("services call each other in a cycle: %s", strings.Join(path, " -> "))
+		}
+		visiting[name] = true
+		total := 1
+		for _, called := range byName[name].Calls {
+			if err := count(called, path); err != nil {
+				return err
+			}
+			// saturate to not overflow with deep fan outs
+			total = min(total+spans[called], maxSpans+1)
+		}
+		visiting[name] = false
+		spans[name] = total
+		return nil
+	}
+
+	for _, s := range services {
+		if err := count(s.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return spans, nil
+}
+
+func (req *GenerateRequest) hasSignal(signal string) bool {
+	for _, s := range req.Signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// entryServices are the services not called by any other service, traces
+// start at them
+func entryServices(services []ServiceSpec) []ServiceSpec {
+	called := map[string]bool{}
+	for _, s := range services {
+		for _, c := range s.Calls {
+			called[c] = true
+		}
+	}
+	entries := []ServiceSpec{}
+	for _, s := range services {
+		if !called[s.Name] {
+			entries = append(entries, s)
+		}
+	}
+	if len(entries) == 0 {
+		entries = append(entries, services[0])
+	}
+	return entries
+}
+
+// operationStats aggregates the spans of an operation per minute for metrics
+type operationStats struct {
+	service   string
+	operation string
+	status    string
+	minute    int64
+	count     float64
+	totalMs   float64
+}
+
+type generator struct {
+	req      *GenerateRequest
+	rng      *rand.Rand
+	services map[string]ServiceSpec
+	data     *model.DemoData
+	stats    map[string]*operationStats
+}
+
+func (g *generator) hexId(bytes int) string {
+	b := make([]byte, bytes)
+	g.rng.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ksuid generates a time ordered id in the format used for log ids
+func (g *generator) ksuid(ts time.Time) string {
+	// ksuid timestamps are seconds since 2014-05-13
+	const ksuidEpoch = 1400000000
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint32(b, uint32(ts.Unix()-ksuidEpoch))
+	g.rng.Read(b[4:])
+	id := new(big.Int).SetBytes(b).Text(62)
+	// big.Int uses lower case letters for the digits after 9, ksuid upper case
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return r
+	}, id)
+	return strings.Repeat("0", 27-len(mapped)) + mapped
+}
+
+// duration samples a log-normal distribution around the mean duration,
+// giving the long tail latencies usually have
+func (g *generator) duration(meanMs float64) time.Duration {
+	const sigma = 0.6
+	mu := math.Log(meanMs) - sigma*sigma/2
+	ms := math.Exp(mu + sigma*g.rng.NormFloat64())
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func (g *generator) resource(service string) map[string]string {
+	return map[string]string{
+		"service.name":           service,
+		"deployment.environment": g.req.Environment,
+		demoAttribute:            "true",
+	}
+}
+
+// generateSpan generates the span of an operation of service along with the
+// spans of the services it calls and returns its duration and error status
+func (g *generator) generateSpan(
+	service ServiceSpec, traceId string, parentSpanId string, start time.Time,
+) (time.Duration, bool) {
+	spanId := g.hexId(8)
+	operation := service.Operations[g.rng.Intn(len(service.Operations))]
+
+	// downstream calls are made sequentially after some time of processing
+	elapsed := g.duration(service.MeanDurationMs) / 2
+	hasError := false
+	for _, name := range service.Calls {
+		childDuration, childError := g.generateSpan(g.services[name], traceId, spanId, start.Add(elapsed))
+		elapsed += childDuration
+		hasError = hasError || childError
+	}
+	elapsed += g.duration(service.MeanDurationMs) / 2
+	hasError = hasError || g.rng.Float64() < service.ErrorRate
+
+	span := model.DemoSpan{
+		Timestamp:       start,
+		TraceId:         traceId,
+		SpanId:          spanId,
+		ParentSpanId:    parentSpanId,
+		ServiceName:     service.Name,
+		Name:            operation,
+		Kind:            2,
+		DurationNano:    uint64(elapsed.Nanoseconds()),
+		StatusCode:      1,
+		HasError:        hasError,
+		StringTagMap:    map[string]string{demoAttribute: "true"},
+		NumberTagMap:    map[string]float64{},
+		ResourceTagsMap: g.resource(service.Name),
+	}
+
+	statusCode := 200
+	if hasError {
+		span.StatusCode = 2
+		statusCode = 500
+		span.Exception = &model.DemoException{
+			Type:       "DemoError",
+			Message:    fmt.Sprintf("%s failed", operation),
+			Stacktrace: fmt.Sprintf("DemoError: %s failed\n    at %s.handle", operation, service.Name),
+		}
+	}
+	// operations named like http routes get http attributes
+	if method, route, ok := strings.Cut(operation, " "); ok && strings.HasPrefix(route, "/") {
+		span.HttpMethod = method
+		span.HttpRoute = route
+		span.HttpCode = fmt.Sprintf("%d", statusCode)
+		span.StringTagMap["http.method"] = method
+		span.StringTagMap["http.route"] = route
+		span.NumberTagMap["http.status_code"] = float64(statusCode)
+	}
+
+	if g.req.hasSignal(SignalTraces) {
+		g.data.Spans = append(g.data.Spans, span)
+	}
+	if g.req.hasSignal(SignalLogs) {
+		g.addLog(span, elapsed)
+	}
+	if g.req.hasSignal(SignalMetrics) {
+		g.addStats(span, elapsed)
+	}
+	return elapsed, hasError
+}
+
+func (g *generator) addLog(span model.DemoSpan, elapsed time.Duration) {
+	ts := span.Timestamp.Add(elapsed)
+	log := model.DemoLog{
+		Timestamp:      ts,
+		Id:             g.ksuid(ts),
+		TraceId:        span.TraceId,
+		SpanId:         span.SpanId,
+		SeverityText:   "INFO",
+		SeverityNumber: 9,
+		Body:           fmt.Sprintf("handled %s in %dms", span.Name, elapsed.Milliseconds()),
+		Resources:      span.ResourceTagsMap,
+		Attributes: map[string]string{
+			"operation":   span.Name,
+			demoAttribute: "true",
+		},
+	}
+	if span.Exception != nil {
+		log.SeverityText = "ERROR"
+		log.SeverityNumber = 17
+		log.Body = fmt.Sprintf("%s: %s", span.Exception.Type, span.Exception.Message)
+	}
+	g.data.Logs = append(g.data.Logs, log)
+}
+
+func (g *generator) addStats(span model.DemoSpan, elapsed time.Duration) {
+	status := "ok"
+	if span.HasError {
+		status = "error"
+	}
+	minute := span.Timestamp.Truncate(time.Minute).UnixMilli()
+	key := fmt.Sprintf("%s/%s/%s/%d", span.ServiceName, span.Name, status, minute)
+	s, ok := g.stats[key]
+	if !ok {
+		s = &operationStats{
+			service: span.ServiceName, operation: span.Name, status: status, minute: minute,
+		}
+		g.stats[key] = s
+	}
+	s.count++
+	s.totalMs += float64(elapsed.Microseconds()) / 1000
+}
+
+// metrics converts the per minute operation stats to a cumulative request
+// counter and an average duration gauge per operation
+func (g *generator) metrics() *prompb.WriteRequest {
+	stats := make([]*operationStats, 0, len(g.stats))
+	for _, s := range g.stats {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].minute != stats[j].minute {
+			return stats[i].minute < stats[j].minute
+		}
+		return fmt.Sprintf("%s/%s/%s", stats[i].service, stats[i].operation, stats[i].status) <
+			fmt.Sprintf("%s/%s/%s", stats[j].service, stats[j].operation, stats[j].status)
+	})
+
+	series := map[string]*prompb.TimeSeries{}
+	order := []string{}
+	getSeries := func(metric string, s *operationStats) *prompb.TimeSeries {
+		key := fmt.Sprintf("%s/%s/%s/%s", metric, s.service, s.operation, s.status)
+		ts, ok := series[key]
+		if !ok {
+			ts = &prompb.TimeSeries{Labels: []prompb.Label{
+				{Name: "__name__", Value: metric},
+				{Name: "service_name", Value: s.service},
+				{Name: "operation", Value: s.operation},
+				{Name: "status", Value: s.status},
+				{Name: "deployment_environment", Value: g.req.Environment},
+				{Name: "signoz_demo", Value: "true"},
+			}}
+			series[key] = ts
+			order = append(order, key)
+		}
+		return ts
+	}
+
+	for _, s := range stats {
+		requests := getSeries(requestsMetric, s)
+		total := s.count
+		if n := len(requests.Samples); n > 0 {
+			total += requests.Samples[n-1].Value
+		}
+		requests.Samples = append(requests.Samples, prompb.Sample{Timestamp: s.minute, Value: total})
+
+		duration := getSeries(durationMetric, s)
+		duration.Samples = append(duration.Samples, prompb.Sample{
+			Timestamp: s.minute, Value: s.totalMs / s.count,
+		})
+	}
+
+	req := &prompb.WriteRequest{
+		Metadata: []prompb.MetricMetadata{
+			{
+				Type:             prompb.MetricMetadata_COUNTER,
+				MetricFamilyName: requestsMetric,
+				Help:             "Requests handled by demo services",
+			},
+			{
+				Type:             prompb.MetricMetadata_GAUGE,
+				MetricFamilyName: durationMetric,
+				Help:             "Average duration of requests handled by demo services",
+				Unit:             "ms",
+			},
+		},
+	}
+	for _, key := range order {
+		req.Timeseries = append(req.Timeseries, *series[key])
+	}
+	return req
+}
+
+// Generate generates traces, logs and metrics for the requested services
+// spread evenly over the minutes before now
+func Generate(req *GenerateRequest, now time.Time) (*model.DemoData, *model.DemoDataSummary, error) {
+	if err := req.IsValid(); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid demo data request")
+	}
+
+	seed := now.UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	g := &generator{
+		req:      req,
+		rng:      rand.New(rand.NewSource(seed)),
+		services: map[string]ServiceSpec{},
+		data:     &model.DemoData{},
+		stats:    map[string]*operationStats{},
+	}
+	for _, s := range req.Services {
+		g.services[s.Name] = s
+	}
+	entries := entryServices(req.Services)
+
+	end := now.Truncate(time.Minute)
+	start := end.Add(-time.Duration(req.DurationMinutes) * time.Minute)
+	traces := 0
+	for minute := 0; minute < req.DurationMinutes; minute++ {
+		minuteStart := start.Add(time.Duration(minute) * time.Minute)
+		for i := 0; i < req.TracesPerMinute; i++ {
+			offset := time.Duration(g.rng.Int63n(int64(time.Minute)))
+			entry := entries[g.rng.Intn(len(entries))]
+			g.generateSpan(entry, g.hexId(16), "", minuteStart.Add(offset))
+			traces++
+		}
+	}
+
+	summary := &model.DemoDataSummary{
+		Traces:         traces,
+		Spans:          len(g.data.Spans),
+		Logs:           len(g.data.Logs),
+		StartTimestamp: start.UnixMilli(),
+		EndTimestamp:   end.UnixMilli(),
+	}
+	if req.hasSignal(SignalMetrics) {
+		g.data.Metrics = g.metrics()
+		summary.MetricSeries = len(g.data.Metrics.Timeseries)
+		for _, ts := range g.data.Metrics.Timeseries {
+			summary.MetricSamples += len(ts.Samples)
+		}
+	}
+	if !req.hasSignal(SignalTraces) {
+		summary.Traces = 0
+	}
+	return g.data, summary, nil
+}
@@ -0,0 +1,167 @@
+package demodata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestGenerate(t *testing.T) {
+	seed := int64(42)
+	now := time.Date(2024, 3, 1, 10, 30, 15, 0, time.UTC)
+	req := &GenerateRequest{
+		Services: []ServiceSpec{
+			{Name: "api", Operations: []string{"GET /orders"}, Calls: []string{"db"}, MeanDurationMs: 20},
+			{Name: "db", Operations: []string{"SELECT"}, ErrorRate: 1, MeanDurationMs: 5},
+		},
+		DurationMinutes: 5,
+		TracesPerMinute: 3,
+		Seed:            &seed,
+	}
+
+	data, summary, err := Generate(req, now)
+	require.NoError(t, err)
+	require.Equal(t, 15, summary.Traces)
+	require.Equal(t, 30, summary.Spans)
+	require.Equal(t, 30, summary.Logs)
+	require.Equal(t, time.Date(2024, 3, 1, 10, 25, 0, 0, time.UTC).UnixMilli(), summary.StartTimestamp)
+
+	spans := map[string]model.DemoSpan{}
+	for _, span := range data.Spans {
+		spans[span.SpanId] = span
+	}
+	for _, span := range data.Spans {
+		// errors of db propagate to the api spans calling it
+		require.True(t, span.HasError)
+		require.NotNil(t, span.Exception)
+		require.Equal(t, "true", span.ResourceTagsMap[demoAttribute])
+
+		if span.ServiceName == "api" {
+			require.Empty(t, span.ParentSpanId)
+			require.Equal(t, "GET", span.HttpMethod)
+			require.Equal(t, "/orders", span.HttpRoute)
+			require.Equal(t, "500", span.HttpCode)
+			continue
+		}
+		parent, ok := spans[span.ParentSpanId]
+		require.True(t, ok)
+		require.Equal(t, parent.TraceId, span.TraceId)
+		require.False(t, span.Timestamp.Before(parent.Timestamp))
+		require.LessOrEqual(t,
+			span.Timestamp.UnixNano()+int64(span.DurationNano),
+			parent.Timestamp.UnixNano()+int64(parent.DurationNano),
+		)
+	}
+
+	for _, log := range data.Logs {
+		require.Equal(t, "ERROR", log.SeverityText)
+		require.Len(t, log.Id, 27)
+	}
+
+	// requests counter and duration gauge for each service
+	require.Equal(t, 4, summary.MetricSeries)
+	for _, ts := range data.Metrics.Timeseries {
+		for i := 1; i < len(ts.Samples); i++ {
+			require.Greater(t, ts.Samples[i].Timestamp, ts.Samples[i-1].Timestamp)
+			if ts.Labels[0].Value == requestsMetric {
+				require.GreaterOrEqual(t, ts.Samples[i].Value, ts.Samples[i-1].Value)
+			}
+		}
+	}
+
+	// the same seed generates the same data
+	again, _, err := Generate(req, now)
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+}
+
+func TestGenerateRequestValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		req  GenerateRequest
+		err  string
+	}{
+		{
+			name: "unknown signal",
+			req:  GenerateRequest{Signals: []string{"profiles"}},
+			err:  "invalid signal profiles",
+		},
+		{
+			name: "too many traces",
+			req:  GenerateRequest{DurationMinutes: 1440, TracesPerMinute: 1000},
+			err:  "at most 100000 traces",
+		},
+		{
+			name: "unknown downstream service",
+			req: GenerateRequest{Services: []ServiceSpec{
+				{Name: "api", Operations: []string{"GET /"}, Calls: []string{"db"}, MeanDurationMs: 10},
+			}},
+			err: "calls unknown service db",
+		},
+		{
+			name: "services calling each other",
+			req: GenerateRequest{Services: []ServiceSpec{
+				{Name: "web", Operations: []string{"GET /"}, MeanDurationMs: 10},
+				{Name: "api", Operations: []string{"GET /"}, Calls: []string{"db"}, MeanDurationMs: 10},
+				{Name: "db", Operations: []string{"SELECT"}, Calls: []string{"api"}, MeanDurationMs: 10},
+			}},
+			err: "cycle: api -> db -> api",
+		},
+		{
+			name: "service calling itself",
+			req: GenerateRequest{Services: []ServiceSpec{
+				{Name: "api", Operations: []string{"GET /"}, Calls: []string{"api"}, MeanDurationMs: 10},
+			}},
+			err: "cycle: api -> api",
+		},
+		{
+			name: "too many spans per trace",
+			req: GenerateRequest{Services: []ServiceSpec{
+				{Name: "a", Operations: []string{"op"}, Calls: []string{"b", "b", "b", "b"}, MeanDurationMs: 10},
+				{Name: "b", Operations: []string{"op"}, Calls: []string{"c", "c", "c", "c"}, MeanDurationMs: 10},
+				{Name: "c", Operations: []string{"op"}, Calls: []string{"d", "d", "d", "d"}, MeanDurationMs: 10},
+				{Name: "d", Operations: []string{"op"}, Calls: []string{"e", "e", "e", "e"}, MeanDurationMs: 10},
+				{Name: "e", Operations: []string{"op"}, Calls: []string{"f", "f", "f", "f"}, MeanDurationMs: 10},
+				{Name: "f", Operations: []string{"op"}, MeanDurationMs: 10},
+			}},
+			err: "traces starting at service a would have more than 1000 spans",
+		},
+		{
+			name: "too many spans",
+			req: GenerateRequest{
+				Services: []ServiceSpec{
+					{Name: "api", Operations: []string{"GET /"}, Calls: repeat("db", 19), MeanDurationMs: 10},
+					{Name: "db", Operations: []string{"SELECT"}, MeanDurationMs: 1},
+				},
+				DurationMinutes: 1440,
+				TracesPerMinute: 69,
+			},
+			err: "at most 1000000 spans",
+		},
+		{
+			name: "invalid error rate",
+			req: GenerateRequest{Services: []ServiceSpec{
+				{Name: "api", Operations: []string{"GET /"}, ErrorRate: 2, MeanDurationMs: 10},
+			}},
+			err: "errorRate of service api",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.req.IsValid()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func repeat(s string, n int) []string {
+	repeated := make([]string, n)
+	for i := range repeated {
+		repeated[i] = s
+	}
+	return repeated
+}
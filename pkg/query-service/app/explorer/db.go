@@ -27,6 +27,33 @@ type SavedView struct {
 	Tags       string    `json:"tags" db:"tags"`
 	Data       string    `json:"data" db:"data"`
 	ExtraData  string    `json:"extra_data" db:"extra_data"`
+
+	ViewCount      int64      `json:"view_count" db:"view_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at" db:"last_accessed_at"`
+}
+
+// toSavedView converts a saved view row into its API model
+func (view SavedView) toSavedView() (*v3.SavedView, error) {
+	var compositeQuery v3.CompositeQuery
+	err := json.Unmarshal([]byte(view.Data), &compositeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+	}
+	return &v3.SavedView{
+		UUID:           view.UUID,
+		Name:           view.Name,
+		Category:       view.Category,
+		CreatedAt:      view.CreatedAt,
+		CreatedBy:      view.CreatedBy,
+		UpdatedAt:      view.UpdatedAt,
+		UpdatedBy:      view.UpdatedBy,
+		SourcePage:     view.SourcePage,
+		Tags:           strings.Split(view.Tags, ","),
+		CompositeQuery: &compositeQuery,
+		ExtraData:      view.ExtraData,
+		ViewCount:      view.ViewCount,
+		LastAccessedAt: view.LastAccessedAt,
+	}, nil
 }
 
 // InitWithDSN sets up setting up the connection pool global variable.
@@ -57,6 +84,19 @@ func InitWithDSN(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating saved views table: %s", err.Error())
 	}
 
+	// sqlite does not support "IF NOT EXISTS"
+	viewCount := `ALTER TABLE saved_views ADD COLUMN view_count INTEGER DEFAULT 0;`
+	_, err = db.Exec(viewCount)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column view_count to saved views table: %s", err.Error())
+	}
+
+	lastAccessedAt := `ALTER TABLE saved_views ADD COLUMN last_accessed_at datetime;`
+	_, err = db.Exec(lastAccessedAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column last_accessed_at to saved views table: %s", err.Error())
+	}
+
 	return db, nil
 }
 
@@ -73,24 +113,11 @@ func GetViews() ([]*v3.SavedView, error) {
 
 	var savedViews []*v3.SavedView
 	for _, view := range views {
-		var compositeQuery v3.CompositeQuery
-		err = json.Unmarshal([]byte(view.Data), &compositeQuery)
+		savedView, err := view.toSavedView()
 		if err != nil {
-			return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+			return nil, err
 		}
-		savedViews = append(savedViews, &v3.SavedView{
-			UUID:           view.UUID,
-			Name:           view.Name,
-			Category:       view.Category,
-			CreatedAt:      view.CreatedAt,
-			CreatedBy:      view.CreatedBy,
-			UpdatedAt:      view.UpdatedAt,
-			UpdatedBy:      view.UpdatedBy,
-			Tags:           strings.Split(view.Tags, ","),
-			SourcePage:     view.SourcePage,
-			CompositeQuery: &compositeQuery,
-			ExtraData:      view.ExtraData,
-		})
+		savedViews = append(savedViews, savedView)
 	}
 	return savedViews, nil
 }
@@ -109,23 +136,11 @@ func GetViewsForFilters(sourcePage string, name string, category string) ([]*v3.
 
 	var savedViews []*v3.SavedView
 	for _, view := range views {
-		var compositeQuery v3.CompositeQuery
-		err = json.Unmarshal([]byte(view.Data), &compositeQuery)
+		savedView, err := view.toSavedView()
 		if err != nil {
-			return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+			return nil, err
 		}
-		savedViews = append(savedViews, &v3.SavedView{
-			UUID:           view.UUID,
-			Name:           view.Name,
-			CreatedAt:      view.CreatedAt,
-			CreatedBy:      view.CreatedBy,
-			UpdatedAt:      view.UpdatedAt,
-			UpdatedBy:      view.UpdatedBy,
-			Tags:           strings.Split(view.Tags, ","),
-			SourcePage:     view.SourcePage,
-			CompositeQuery: &compositeQuery,
-			ExtraData:      view.ExtraData,
-		})
+		savedViews = append(savedViews, savedView)
 	}
 	return savedViews, nil
 }
@@ -179,24 +194,7 @@ func GetView(uuid_ string) (*v3.SavedView, error) {
 		return nil, fmt.Errorf("error in getting saved view: %s", err.Error())
 	}
 
-	var compositeQuery v3.CompositeQuery
-	err = json.Unmarshal([]byte(view.Data), &compositeQuery)
-	if err != nil {
-		return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
-	}
-	return &v3.SavedView{
-		UUID:           view.UUID,
-		Name:           view.Name,
-		Category:       view.Category,
-		CreatedAt:      view.CreatedAt,
-		CreatedBy:      view.CreatedBy,
-		UpdatedAt:      view.UpdatedAt,
-		UpdatedBy:      view.UpdatedBy,
-		SourcePage:     view.SourcePage,
-		Tags:           strings.Split(view.Tags, ","),
-		CompositeQuery: &compositeQuery,
-		ExtraData:      view.ExtraData,
-	}, nil
+	return view.toSavedView()
 }
 
 func UpdateView(ctx context.Context, uuid_ string, view v3.SavedView) error {
@@ -228,3 +226,35 @@ func DeleteView(uuid_ string) error {
 	}
 	return nil
 }
+
+// RecordViewAccess bumps the view count and last accessed time of a saved view
+func RecordViewAccess(uuid_ string) error {
+	_, err := db.Exec("UPDATE saved_views SET view_count = COALESCE(view_count, 0) + 1, last_accessed_at = ? WHERE uuid = ?", time.Now(), uuid_)
+	if err != nil {
+		return fmt.Errorf("error in recording saved view access: %s", err.Error())
+	}
+	return nil
+}
+
+// GetStaleViews returns the saved views that have not been opened since the
+// given time, views created after it are not considered stale yet
+func GetStaleViews(since time.Time) ([]*v3.SavedView, error) {
+	var views []SavedView
+	err := db.Select(&views,
+		"SELECT * FROM saved_views WHERE (last_accessed_at IS NULL AND created_at < ?) OR last_accessed_at < ? ORDER BY COALESCE(last_accessed_at, created_at)",
+		since, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error in getting stale saved views: %s", err.Error())
+	}
+
+	savedViews := []*v3.SavedView{}
+	for _, view := range views {
+		savedView, err := view.toSavedView()
+		if err != nil {
+			return nil, err
+		}
+		savedViews = append(savedViews, savedView)
+	}
+	return savedViews, nil
+}
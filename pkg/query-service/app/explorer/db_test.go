@@ -0,0 +1,103 @@
+package explorer
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestStaleViews(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	_, err = InitWithDSN(testDBFile.Name())
+	require.Nil(err)
+
+	userJwt, err := auth.GenerateJWTForUser(&model.User{Id: "user1", Email: "user1@signoz.io"})
+	require.Nil(err)
+	req := httptest.NewRequest("POST", "/api/v1/explorer/views", nil)
+	req.Header.Add("Authorization", "Bearer "+userJwt.AccessJwt)
+	ctx := auth.AttachJwtToContext(context.Background(), req)
+
+	createView := func(name string, createdAt time.Time) string {
+		id, err := CreateView(ctx, v3.SavedView{
+			Name:           name,
+			Category:       "errors",
+			SourcePage:     "logs",
+			Tags:           []string{"team-a"},
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder, PanelType: v3.PanelTypeList},
+		})
+		require.Nil(err)
+		_, err = db.Exec("UPDATE saved_views SET created_at = ? WHERE uuid = ?", createdAt, id)
+		require.Nil(err)
+		return id
+	}
+	now := time.Now()
+	since := now.AddDate(0, 0, -90)
+	unused := createView("unused", now.AddDate(0, 0, -200))
+	opened := createView("opened", now.AddDate(0, 0, -200))
+	recent := createView("recent", now.AddDate(0, 0, -10))
+
+	// reading views doesn't count as opening them
+	view, err := GetView(opened)
+	require.Nil(err)
+	require.Equal("errors", view.Category)
+	require.Equal([]string{"team-a"}, view.Tags)
+	require.Equal(v3.PanelTypeList, view.CompositeQuery.PanelType)
+	views, err := GetViewsForFilters("logs", "", "")
+	require.Nil(err)
+	require.Len(views, 3)
+	for _, v := range views {
+		require.Equal("errors", v.Category)
+		require.Zero(v.ViewCount)
+		require.Nil(v.LastAccessedAt)
+	}
+
+	stale, err := GetStaleViews(since)
+	require.Nil(err)
+	require.Equal([]string{unused, opened}, savedViewIds(stale), "recent views aren't stale yet")
+
+	require.Nil(RecordViewAccess(opened))
+	require.Nil(RecordViewAccess(opened))
+	view, err = GetView(opened)
+	require.Nil(err)
+	require.Equal(int64(2), view.ViewCount)
+	require.NotNil(view.LastAccessedAt)
+
+	stale, err = GetStaleViews(since)
+	require.Nil(err)
+	require.Equal([]string{unused}, savedViewIds(stale))
+
+	// views opened long ago are stale again, the least recently used first
+	_, err = db.Exec("UPDATE saved_views SET last_accessed_at = ? WHERE uuid = ?", now.AddDate(0, 0, -100), opened)
+	require.Nil(err)
+	_, err = db.Exec("UPDATE saved_views SET last_accessed_at = ? WHERE uuid = ?", now.AddDate(0, 0, -300), recent)
+	require.Nil(err)
+	stale, err = GetStaleViews(since)
+	require.Nil(err)
+	require.Equal([]string{recent, unused, opened}, savedViewIds(stale))
+
+	stale, err = GetStaleViews(now.AddDate(0, 0, -1000))
+	require.Nil(err)
+	require.NotNil(stale)
+	require.Empty(stale)
+}
+
+func savedViewIds(views []*v3.SavedView) []string {
+	ids := []string{}
+	for _, view := range views {
+		ids = append(ids, view.UUID)
+	}
+	return ids
+}
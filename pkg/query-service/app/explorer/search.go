@@ -0,0 +1,36 @@
+package explorer
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// SearchSource lets saved views be found by name and tags
+type SearchSource struct{}
+
+func (s *SearchSource) ListSearchable(ctx context.Context) ([]search.Item, *model.ApiError) {
+	views, err := GetViews()
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []search.Item{}
+	for _, view := range views {
+		labels := []string{}
+		for _, tag := range view.Tags {
+			// views without tags have a single empty one
+			if tag != "" {
+				labels = append(labels, tag)
+			}
+		}
+		items = append(items, search.Item{
+			Id:          view.UUID,
+			Name:        view.Name,
+			Description: view.SourcePage,
+			Labels:      labels,
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,43 @@
+package explorer
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// TagStore lets the tags of saved views be changed in bulk
+type TagStore struct{}
+
+func (s *TagStore) ListTagged(ctx context.Context) ([]tagging.Item, *model.ApiError) {
+	views, err := GetViews()
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []tagging.Item{}
+	for _, view := range views {
+		tags := []string{}
+		for _, tag := range view.Tags {
+			// views without tags have a single empty one
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		items = append(items, tagging.Item{Id: view.UUID, Name: view.Name, Tags: tags})
+	}
+	return items, nil
+}
+
+func (s *TagStore) SetTags(ctx context.Context, id string, tags []string) *model.ApiError {
+	view, err := GetView(id)
+	if err != nil {
+		return model.NotFoundError(err)
+	}
+	view.Tags = tags
+	if err := UpdateView(ctx, id, *view); err != nil {
+		return model.InternalError(err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package filtersnippets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// Controller manages the filter snippets of the org and expands them in the
+// builder queries referencing them
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create filter snippets repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+func (c *Controller) ListFilterSnippets(ctx context.Context) ([]FilterSnippet, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+func (c *Controller) GetFilterSnippet(ctx context.Context, name string) (*FilterSnippet, *model.ApiError) {
+	return c.repo.get(ctx, name)
+}
+
+func (c *Controller) CreateFilterSnippet(
+	ctx context.Context, postable *PostableFilterSnippet,
+) (*FilterSnippet, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	return c.repo.insert(ctx, userId, postable)
+}
+
+// UpdatedFilterSnippet is the response to an update, listing the dashboards
+// and alerts whose queries pick up the change
+type UpdatedFilterSnippet struct {
+	*FilterSnippet
+
+	References []Reference `json:"references"`
+}
+
+func (c *Controller) UpdateFilterSnippet(
+	ctx context.Context, name string, postable *PostableFilterSnippet,
+) (*UpdatedFilterSnippet, *model.ApiError) {
+	if postable.Name == "" {
+		postable.Name = name
+	}
+	if postable.Name != name {
+		return nil, model.BadRequest(fmt.Errorf("filter snippets can not be renamed"))
+	}
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.update(ctx, userId, postable); apiErr != nil {
+		return nil, apiErr
+	}
+	snippet, apiErr := c.repo.get(ctx, name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	references, apiErr := c.GetReferences(ctx, name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &UpdatedFilterSnippet{FilterSnippet: snippet, References: references}, nil
+}
+
+// DeleteFilterSnippet deletes a filter snippet which is not used by any
+// dashboard or alert
+func (c *Controller) DeleteFilterSnippet(ctx context.Context, name string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, name); apiErr != nil {
+		return apiErr
+	}
+
+	references, apiErr := c.GetReferences(ctx, name)
+	if apiErr != nil {
+		return apiErr
+	}
+	if len(references) > 0 {
+		titles := []string{}
+		for _, r := range references {
+			titles = append(titles, fmt.Sprintf("%s %s", r.Type, r.Title))
+		}
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf(
+				"filter snippet %s is in use by %s", name, strings.Join(titles, ", "),
+			),
+		}
+	}
+	return c.repo.delete(ctx, name)
+}
+
+// GetReferences returns the dashboards and alerts with queries using the
+// snippet
+func (c *Controller) GetReferences(ctx context.Context, name string) ([]Reference, *model.ApiError) {
+	references := []Reference{}
+
+	dashboards, apiErr := c.repo.listDashboards(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, d := range dashboards {
+		data := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(d.Data), &data); err != nil {
+			zap.L().Warn("could not parse stored dashboard", zap.String("id", d.Id), zap.Error(err))
+			continue
+		}
+		if slices.Contains(referencedInJSON(data), name) {
+			title, _ := data["title"].(string)
+			references = append(references, Reference{
+				Type: ReferenceTypeDashboard, Id: d.Id, Title: title,
+			})
+		}
+	}
+
+	rules, apiErr := c.repo.listRules(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, r := range rules {
+		data := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(r.Data), &data); err != nil {
+			zap.L().Warn("could not parse stored rule", zap.String("id", r.Id), zap.Error(err))
+			continue
+		}
+		if slices.Contains(referencedInJSON(data), name) {
+			title, _ := data["alert"].(string)
+			references = append(references, Reference{
+				Type: ReferenceTypeAlert, Id: r.Id, Title: title,
+			})
+		}
+	}
+
+	return references, nil
+}
+
+// ExpandFilterSnippets returns the params with the filters of the snippets
+// used by its builder queries added to their own filters
+func (c *Controller) ExpandFilterSnippets(
+	ctx context.Context, params *v3.QueryRangeParamsV3,
+) (*v3.QueryRangeParamsV3, *model.ApiError) {
+	names := referencedInQuery(params)
+	if len(names) == 0 {
+		return params, nil
+	}
+
+	snippets := map[string]*v3.FilterSet{}
+	for _, name := range names {
+		snippet, apiErr := c.repo.get(ctx, name)
+		if apiErr != nil {
+			if apiErr.Type() == model.ErrorNotFound {
+				return nil, model.BadRequest(fmt.Errorf("unknown filter snippet %s", name))
+			}
+			return nil, apiErr
+		}
+		snippets[name] = snippet.Filters
+	}
+
+	expanded, err := expand(params, snippets)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return expanded, nil
+}
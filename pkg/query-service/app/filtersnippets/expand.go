@@ -0,0 +1,109 @@
+package filtersnippets
+
+import (
+	"fmt"
+	"sort"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// referencedInQuery returns the names of the snippets used by the builder
+// queries of params
+func referencedInQuery(params *v3.QueryRangeParamsV3) []string {
+	if params == nil || params.CompositeQuery == nil {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, query := range params.CompositeQuery.BuilderQueries {
+		for _, name := range query.FilterSnippets {
+			names[name] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+// expand returns params with the filters of the snippets referenced by its
+// builder queries ANDed to their own. params is left as is since the
+// composite query of alerts is reused on every evaluation.
+func expand(
+	params *v3.QueryRangeParamsV3, snippets map[string]*v3.FilterSet,
+) (*v3.QueryRangeParamsV3, error) {
+	if len(referencedInQuery(params)) == 0 {
+		return params, nil
+	}
+
+	expandedQuery := *params.CompositeQuery
+	expandedQuery.BuilderQueries = map[string]*v3.BuilderQuery{}
+	for name, query := range params.CompositeQuery.BuilderQueries {
+		if len(query.FilterSnippets) == 0 {
+			expandedQuery.BuilderQueries[name] = query
+			continue
+		}
+
+		items := []v3.FilterItem{}
+		if query.Filters != nil {
+			if query.Filters.Operator == "OR" && len(query.Filters.Items) > 1 {
+				return nil, fmt.Errorf(
+					"query %s uses filter snippets, its filters must be combined with AND", name,
+				)
+			}
+			items = append(items, query.Filters.Items...)
+		}
+		for _, snippetName := range query.FilterSnippets {
+			snippet, ok := snippets[snippetName]
+			if !ok {
+				return nil, fmt.Errorf("query %s uses unknown filter snippet %s", name, snippetName)
+			}
+			items = append(items, snippet.Items...)
+		}
+
+		expanded := *query
+		expanded.Filters = &v3.FilterSet{Operator: "AND", Items: items}
+		expanded.FilterSnippets = nil
+		expandedQuery.BuilderQueries[name] = &expanded
+	}
+
+	expandedParams := *params
+	expandedParams.CompositeQuery = &expandedQuery
+	return &expandedParams, nil
+}
+
+// referencedInJSON returns the names of the snippets used by the builder
+// queries found anywhere in the decoded JSON of a dashboard or alert
+func referencedInJSON(data interface{}) []string {
+	names := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for key, child := range val {
+				if key == "filterSnippets" {
+					if list, ok := child.([]interface{}); ok {
+						for _, item := range list {
+							if name, ok := item.(string); ok {
+								names[name] = true
+							}
+						}
+						continue
+					}
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(data)
+	return sortedKeys(names)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package filtersnippets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestExpandFilterSnippets(t *testing.T) {
+	require := require.New(t)
+
+	envItem := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "deployment.environment", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+		Operator: v3.FilterOperatorEqual,
+		Value:    "prod",
+	}
+	serviceItem := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+		Operator: v3.FilterOperatorIn,
+		Value:    []interface{}{"checkout", "cart"},
+	}
+	routeItem := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "http.route", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+		Operator: v3.FilterOperatorNotEqual,
+		Value:    "/health",
+	}
+	snippets := map[string]*v3.FilterSet{
+		"prod-traffic":      {Operator: "AND", Items: []v3.FilterItem{envItem}},
+		"checkout-services": {Operator: "AND", Items: []v3.FilterItem{serviceItem}},
+	}
+
+	withSnippets := &v3.BuilderQuery{
+		QueryName:      "A",
+		Expression:     "A",
+		DataSource:     v3.DataSourceTraces,
+		Filters:        &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{routeItem}},
+		FilterSnippets: []string{"prod-traffic", "checkout-services"},
+	}
+	withoutSnippets := &v3.BuilderQuery{
+		QueryName:  "B",
+		Expression: "B",
+		DataSource: v3.DataSourceTraces,
+	}
+	params := &v3.QueryRangeParamsV3{
+		CompositeQuery: &v3.CompositeQuery{
+			BuilderQueries: map[string]*v3.BuilderQuery{"A": withSnippets, "B": withoutSnippets},
+		},
+	}
+	require.Equal([]string{"checkout-services", "prod-traffic"}, referencedInQuery(params))
+
+	expanded, err := expand(params, snippets)
+	require.Nil(err)
+	require.Equal(
+		[]v3.FilterItem{routeItem, envItem, serviceItem},
+		expanded.CompositeQuery.BuilderQueries["A"].Filters.Items,
+	)
+	require.Empty(expanded.CompositeQuery.BuilderQueries["A"].FilterSnippets)
+	require.Same(withoutSnippets, expanded.CompositeQuery.BuilderQueries["B"])
+
+	// the params are reused by alerts on every evaluation and must be left as is
+	require.Same(withSnippets, params.CompositeQuery.BuilderQueries["A"])
+	require.Equal([]v3.FilterItem{routeItem}, withSnippets.Filters.Items)
+	require.Len(withSnippets.FilterSnippets, 2)
+
+	withSnippets.FilterSnippets = []string{"unknown"}
+	_, err = expand(params, snippets)
+	require.NotNil(err)
+
+	withSnippets.FilterSnippets = []string{"prod-traffic"}
+	withSnippets.Filters = &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{routeItem, serviceItem}}
+	_, err = expand(params, snippets)
+	require.NotNil(err)
+}
+
+func TestReferencedInJSON(t *testing.T) {
+	dashboard := `{
+		"title": "Checkout",
+		"widgets": [
+			{"query": {"builder": {"queryData": [{"queryName": "A", "filterSnippets": ["prod-traffic"]}]}}},
+			{"query": {"builder": {"queryData": [{"queryName": "A", "filterSnippets": ["checkout-services", "prod-traffic"]}]}}},
+			{"query": {"builder": {"queryData": [{"queryName": "A"}]}}}
+		]
+	}`
+	data := map[string]interface{}{}
+	require.Nil(t, json.Unmarshal([]byte(dashboard), &data))
+	require.Equal(t, []string{"checkout-services", "prod-traffic"}, referencedInJSON(data))
+}
@@ -0,0 +1,62 @@
+package filtersnippets
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// FilterSnippet is a named filter that builder queries can reference with
+// their filterSnippets, its items are ANDed with the filters of the query
+// when the query runs so that changes to a snippet apply to all the panels
+// and alerts using it.
+// Eg: "prod-traffic" -> deployment.environment = prod AND http.route != /health
+type FilterSnippet struct {
+	Name        string        `json:"name" db:"name"`
+	Description string        `json:"description" db:"description"`
+	Filters     *v3.FilterSet `json:"filters" db:"filters_json"`
+	CreatedBy   string        `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedBy   string        `json:"updatedBy" db:"updated_by"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+type PostableFilterSnippet struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Filters     *v3.FilterSet `json:"filters"`
+}
+
+var snippetNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func (p *PostableFilterSnippet) IsValid() error {
+	if !snippetNameRegex.MatchString(p.Name) {
+		return fmt.Errorf(
+			"snippet name must be lowercase letters, digits, - or _ and at most 64 characters",
+		)
+	}
+	if p.Filters == nil || len(p.Filters.Items) == 0 {
+		return fmt.Errorf("snippet must have at least one filter")
+	}
+	if p.Filters.Operator != "" && p.Filters.Operator != "AND" {
+		return fmt.Errorf("snippet filters must be combined with AND")
+	}
+	if err := p.Filters.Validate(); err != nil {
+		return fmt.Errorf("snippet filters are invalid: %w", err)
+	}
+	return nil
+}
+
+const (
+	ReferenceTypeDashboard = "dashboard"
+	ReferenceTypeAlert     = "alert"
+)
+
+// Reference is a dashboard or alert with a query using a snippet
+type Reference struct {
+	Type  string `json:"type"`
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
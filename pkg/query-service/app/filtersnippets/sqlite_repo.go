@@ -0,0 +1,194 @@
+package filtersnippets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS filter_snippets(
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			filters_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure filter snippets schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for filter snippets: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]FilterSnippet, *model.ApiError) {
+	snippets := []FilterSnippet{}
+
+	err := r.db.SelectContext(ctx, &snippets, `
+		SELECT name, description, filters_json, created_by, created_at, updated_by, updated_at
+		FROM filter_snippets
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query filter snippets: %w", err,
+		))
+	}
+	return snippets, nil
+}
+
+func (r *Repo) get(ctx context.Context, name string) (*FilterSnippet, *model.ApiError) {
+	snippets := []FilterSnippet{}
+
+	err := r.db.SelectContext(ctx, &snippets, `
+		SELECT name, description, filters_json, created_by, created_at, updated_by, updated_at
+		FROM filter_snippets
+		WHERE name = $1
+	`, name)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query filter snippet %s: %w", name, err,
+		))
+	}
+	if len(snippets) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf(
+			"filter snippet %s not found", name,
+		))
+	}
+	return &snippets[0], nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableFilterSnippet,
+) (*FilterSnippet, *model.ApiError) {
+	now := time.Now()
+	snippet := &FilterSnippet{
+		Name:        postable.Name,
+		Description: postable.Description,
+		Filters:     postable.Filters,
+		CreatedBy:   userId,
+		CreatedAt:   now,
+		UpdatedBy:   userId,
+		UpdatedAt:   now,
+	}
+
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM filter_snippets WHERE name = $1
+	`, snippet.Name)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query filter snippets: %w", err,
+		))
+	}
+	if existing > 0 {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a filter snippet named %s already exists", snippet.Name),
+		}
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO filter_snippets (
+			name, description, filters_json, created_by, created_at, updated_by, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, snippet.Name, snippet.Description, snippet.Filters,
+		snippet.CreatedBy, snippet.CreatedAt, snippet.UpdatedBy, snippet.UpdatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert filter snippet: %w", err,
+		))
+	}
+
+	return snippet, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, postable *PostableFilterSnippet,
+) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE filter_snippets
+		SET description = $1, filters_json = $2, updated_by = $3, updated_at = $4
+		WHERE name = $5
+	`, postable.Description, postable.Filters, userId, time.Now(), postable.Name)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update filter snippet %s: %w", postable.Name, err,
+		))
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return model.NotFoundError(fmt.Errorf(
+			"filter snippet %s not found", postable.Name,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, name string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM filter_snippets WHERE name = $1
+	`, name)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete filter snippet %s: %w", name, err,
+		))
+	}
+	return nil
+}
+
+type storedSource struct {
+	Id   string `db:"id"`
+	Data string `db:"data"`
+}
+
+// listDashboards and listRules return the stored JSON of the dashboards and
+// alerts, to look for the snippets they use
+func (r *Repo) listDashboards(ctx context.Context) ([]storedSource, *model.ApiError) {
+	dashboards := []storedSource{}
+	err := r.db.SelectContext(ctx, &dashboards, `SELECT uuid AS id, data FROM dashboards WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not query dashboards: %w", err))
+	}
+	return dashboards, nil
+}
+
+func (r *Repo) listRules(ctx context.Context) ([]storedSource, *model.ApiError) {
+	rules := []storedSource{}
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT CAST(id AS TEXT) AS id, data FROM rules WHERE deleted = 0
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not query alerts: %w", err))
+	}
+	return rules, nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,26 +16,41 @@ import (
 	"time"
 
 	"github.com/SigNoz/govaluate"
+	"github.com/golang/snappy"
 	"github.com/gorilla/mux"
 	jsoniter "github.com/json-iterator/go"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/promql"
 
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/datadeletion"
+	"go.signoz.io/signoz/pkg/query-service/app/demodata"
 	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/filtersnippets"
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/app/insertsettings"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/k8stimeline"
 	"go.signoz.io/signoz/pkg/query-service/app/logs"
 	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
 	"go.signoz.io/signoz/pkg/query-service/app/metrics"
 	metricsv3 "go.signoz.io/signoz/pkg/query-service/app/metrics/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/obfuscation"
+	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
 	"go.signoz.io/signoz/pkg/query-service/app/parser"
 	"go.signoz.io/signoz/pkg/query-service/app/querier"
 	querierV2 "go.signoz.io/signoz/pkg/query-service/app/querier/v2"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/app/quotas"
+	"go.signoz.io/signoz/pkg/query-service/app/rangecompare"
+	"go.signoz.io/signoz/pkg/query-service/app/slo"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/cache"
+	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	querytemplate "go.signoz.io/signoz/pkg/query-service/utils/queryTemplate"
@@ -42,8 +58,26 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
+	"go.signoz.io/signoz/pkg/query-service/app/deliveryprofiles"
+	"go.signoz.io/signoz/pkg/query-service/app/ingestionkeys"
+	"go.signoz.io/signoz/pkg/query-service/app/kafkareceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/keyusage"
+	"go.signoz.io/signoz/pkg/query-service/app/loadtests"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logreceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/lookuptables"
+	"go.signoz.io/signoz/pkg/query-service/app/metricexports"
+	"go.signoz.io/signoz/pkg/query-service/app/metricowners"
+	"go.signoz.io/signoz/pkg/query-service/app/savedqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/scheduledqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/app/slackapp"
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/app/tracereceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
 	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	signozio "go.signoz.io/signoz/pkg/query-service/integrations/signozio"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
@@ -99,6 +133,59 @@ type APIHandler struct {
 
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	LookupTablesController *lookuptables.Controller
+
+	MetricOwnersController *metricowners.Controller
+
+	LogExportsController *logexports.Controller
+
+	KafkaReceiversController *kafkareceivers.Controller
+
+	TraceReceiversController *tracereceivers.Controller
+
+	LogReceiversController *logreceivers.Controller
+
+	DeliveryProfilesController *deliveryprofiles.Controller
+
+	InsertSettingsController *insertsettings.Controller
+
+	KeyUsageController *keyusage.Controller
+
+	IngestionKeysController *ingestionkeys.Controller
+
+	ScheduledQueriesController *scheduledqueries.Controller
+
+	SavedQueriesController *savedqueries.Controller
+
+	DataDeletionController *datadeletion.Controller
+
+	LoadTestsController *loadtests.Controller
+
+	MetricExportsController *metricexports.Controller
+
+	// Deleted dashboards, rules and pipelines which can be restored
+	Trash *trash.Trash
+
+	// Bulk changes of the tags of dashboards, rules and saved views
+	Tagging *tagging.Tagging
+
+	// Search across dashboards, rules, saved views, pipelines, services
+	// and channels
+	Search *search.Search
+
+	FilterSnippetsController *filtersnippets.Controller
+
+	IncidentsController *incidents.Controller
+
+	QuotasController *quotas.Controller
+
+	// Limits on how far back the users of each role can query
+	QueryLimitsController *querylimits.Controller
+
+	ObfuscationController *obfuscation.Controller
+
+	SlackAppController *slackapp.Controller
+
 	// SetupCompleted indicates if SigNoz is ready for general use.
 	// at the moment, we mark the app ready when the first user
 	// is registers.
@@ -134,6 +221,79 @@ type APIHandlerOpts struct {
 	// Log parsing pipelines
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	// Lookup tables for ingest time enrichment
+	LookupTablesController *lookuptables.Controller
+
+	// Teams owning metrics, labeled at write time
+	MetricOwnersController *metricowners.Controller
+
+	// Secondary log export destinations, e.g. SIEMs
+	LogExportsController *logexports.Controller
+
+	// Kafka topics to ingest telemetry from
+	KafkaReceiversController *kafkareceivers.Controller
+
+	// Jaeger and zipkin receivers for apps not sending OTLP
+	TraceReceiversController *tracereceivers.Controller
+
+	// Files, syslog listeners and journald units agents collect logs from
+	LogReceiversController *logreceivers.Controller
+
+	// Sending queue and retry settings of the agents' exporters
+	DeliveryProfilesController *deliveryprofiles.Controller
+
+	// Async insert and batch settings the agents write to ClickHouse with
+	InsertSettingsController *insertsettings.Controller
+
+	// Usage of attribute keys in queries, ranking autocomplete suggestions
+	KeyUsageController *keyusage.Controller
+
+	// Keys shippers send telemetry with, with per key quotas
+	IngestionKeysController *ingestionkeys.Controller
+
+	// Named filters builder queries can reference
+	FilterSnippetsController *filtersnippets.Controller
+
+	// Per org caps on the number of dashboards, rules, pipelines and views
+	QuotasController *quotas.Controller
+
+	// Limits on how far back the users of each role can query
+	QueryLimitsController *querylimits.Controller
+
+	// Profiles obfuscating the query results shared externally
+	ObfuscationController *obfuscation.Controller
+
+	// Incidents grouping related alerts
+	IncidentsController *incidents.Controller
+
+	// Slack app posting alerts with actions
+	SlackAppController *slackapp.Controller
+
+	// Queries run on a schedule with their results persisted
+	ScheduledQueriesController *scheduledqueries.Controller
+
+	// Parameterized queries run on demand by scripts and the explorer
+	SavedQueriesController *savedqueries.Controller
+
+	// Deletion of the telemetry of a subject, e.g. for GDPR requests
+	DataDeletionController *datadeletion.Controller
+
+	// Load test runs registered by k6, vegeta and other tools
+	LoadTestsController *loadtests.Controller
+
+	// Downsampled metrics forwarded to prometheus remote_write endpoints
+	MetricExportsController *metricexports.Controller
+
+	// Deleted dashboards, rules and pipelines which can be restored
+	Trash *trash.Trash
+
+	// Bulk changes of the tags of dashboards, rules and saved views
+	Tagging *tagging.Tagging
+
+	// Search across dashboards, rules, saved views, pipelines, services
+	// and channels
+	Search *search.Search
+
 	// cache
 	Cache cache.Cache
 
@@ -183,6 +343,30 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		featureFlags:                  opts.FeatureFlags,
 		IntegrationsController:        opts.IntegrationsController,
 		LogsParsingPipelineController: opts.LogsParsingPipelineController,
+		LookupTablesController:        opts.LookupTablesController,
+		MetricOwnersController:        opts.MetricOwnersController,
+		LogExportsController:          opts.LogExportsController,
+		KafkaReceiversController:      opts.KafkaReceiversController,
+		TraceReceiversController:      opts.TraceReceiversController,
+		LogReceiversController:        opts.LogReceiversController,
+		DeliveryProfilesController:    opts.DeliveryProfilesController,
+		InsertSettingsController:      opts.InsertSettingsController,
+		KeyUsageController:            opts.KeyUsageController,
+		ScheduledQueriesController:    opts.ScheduledQueriesController,
+		SavedQueriesController:        opts.SavedQueriesController,
+		DataDeletionController:        opts.DataDeletionController,
+		LoadTestsController:           opts.LoadTestsController,
+		MetricExportsController:       opts.MetricExportsController,
+		Trash:                         opts.Trash,
+		Tagging:                       opts.Tagging,
+		Search:                        opts.Search,
+		IngestionKeysController:       opts.IngestionKeysController,
+		FilterSnippetsController:      opts.FilterSnippetsController,
+		IncidentsController:           opts.IncidentsController,
+		QuotasController:              opts.QuotasController,
+		QueryLimitsController:         opts.QueryLimitsController,
+		ObfuscationController:         opts.ObfuscationController,
+		SlackAppController:            opts.SlackAppController,
 		querier:                       querier,
 		querierV2:                     querierv2,
 	}
@@ -282,7 +466,7 @@ func RespondError(w http.ResponseWriter, apiErr model.BaseApiError, data interfa
 		code = http.StatusBadRequest
 	case model.ErrorExec:
 		code = 422
-	case model.ErrorCanceled, model.ErrorTimeout:
+	case model.ErrorCanceled, model.ErrorTimeout, model.ErrorUnavailable:
 		code = http.StatusServiceUnavailable
 	case model.ErrorInternal:
 		code = http.StatusInternalServerError
@@ -340,8 +524,11 @@ func (aH *APIHandler) RegisterQueryRangeV3Routes(router *mux.Router, am *AuthMid
 		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteAttributeKeys))).Methods(http.MethodGet)
 	subRouter.HandleFunc("/autocomplete/attribute_values", am.ViewAccess(
 		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteAttributeValues))).Methods(http.MethodGet)
+	subRouter.HandleFunc("/autocomplete/usage", am.ViewAccess(aH.autocompleteKeyUsage)).Methods(http.MethodGet)
 	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.QueryRangeV3)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/query_range/format", am.ViewAccess(aH.QueryRangeV3Format)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/query_range/compare", am.ViewAccess(aH.QueryRangeV3Compare)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/slo/budget_forecast", am.ViewAccess(aH.SLOBudgetForecast)).Methods(http.MethodPost)
 
 	// live logs
 	subRouter.HandleFunc("/logs/livetail", am.ViewAccess(aH.liveTailLogs)).Methods(http.MethodGet)
@@ -360,6 +547,9 @@ func (aH *APIHandler) Respond(w http.ResponseWriter, data interface{}) {
 // RegisterPrivateRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterPrivateRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/channels", aH.listChannels).Methods(http.MethodGet)
+
+	// metrics of query service itself, for operators' prometheus scrapers
+	router.Handle("/metrics", instrumentation.Handler()).Methods(http.MethodGet)
 }
 
 // RegisterRoutes registers routes for this handler on the given router
@@ -375,20 +565,23 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 
 	router.HandleFunc("/api/v1/alerts", am.ViewAccess(aH.getAlerts)).Methods(http.MethodGet)
 
-	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(aH.getRule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules", am.ViewAccess(withETag(aH.listRules))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(withETag(aH.getRule))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/explain", am.ViewAccess(aH.explainRule)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules", am.EditAccess(aH.createRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.editRule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.deleteRule)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.patchRule)).Methods(http.MethodPatch)
 	router.HandleFunc("/api/v1/testRule", am.EditAccess(aH.testRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/convert", am.EditAccess(aH.convertRule)).Methods(http.MethodPost)
 
-	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(aH.getDashboards)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(withETag(aH.getDashboards))).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dashboards", am.EditAccess(aH.createDashboards)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/dashboards/grafana", am.EditAccess(aH.createDashboardsTransform)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/dashboards/{uuid}", am.ViewAccess(aH.getDashboard)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}", am.ViewAccess(withETag(aH.getDashboard))).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dashboards/{uuid}", am.EditAccess(aH.updateDashboard)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/dashboards/{uuid}", am.EditAccess(aH.deleteDashboard)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/views", am.ViewAccess(aH.recordDashboardView)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/variables/query", am.ViewAccess(aH.queryDashboardVars)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v2/variables/query", am.ViewAccess(aH.queryDashboardVarsV2)).Methods(http.MethodPost)
 
@@ -397,20 +590,44 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.ViewAccess(aH.getSavedView)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.EditAccess(aH.updateSavedView)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.EditAccess(aH.deleteSavedView)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/explorer/views/{viewId}/access", am.ViewAccess(aH.recordSavedViewAccess)).Methods(http.MethodPost)
 
 	router.HandleFunc("/api/v1/feedback", am.OpenAccess(aH.submitFeedback)).Methods(http.MethodPost)
+	// called by slack, requests are authenticated by their signature
+	router.HandleFunc("/api/v1/slack/interactions", am.OpenAccess(aH.handleSlackInteraction)).Methods(http.MethodPost)
 	// router.HandleFunc("/api/v1/get_percentiles", aH.getApplicationPercentiles).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/services", am.ViewAccess(aH.getServices)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/services/list", am.ViewAccess(aH.getServicesList)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/service/overview", am.ViewAccess(aH.getServiceOverview)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/service/top_operations", am.ViewAccess(aH.getTopOperations)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/service/top_level_operations", am.ViewAccess(aH.getServicesTopLevelOps)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/service/database_calls", am.ViewAccess(aH.getDatabaseCalls)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/service/external_calls", am.ViewAccess(aH.getExternalCalls)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/service/latency_breakdown", am.ViewAccess(aH.getLatencyBreakdown)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/traces/exists", am.ViewAccess(aH.checkTracesExist)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/traces/sampling/explain", am.ViewAccess(aH.explainTraceSampling)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/traces/{traceId}", am.ViewAccess(aH.SearchTraces)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/prometheus/write", am.EditAccess(aH.prometheusRemoteWrite)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/k8s/pods/timeline", am.ViewAccess(aH.getK8sPodTimelines)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/sessions/index", am.AdminAccess(aH.indexSessionIds)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/sessions/{id}/timeline", am.ViewAccess(aH.getSessionTimeline)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/traces/{traceId}/spans", am.ViewAccess(aH.SearchTraceSpans)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/usage", am.ViewAccess(aH.getUsage)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dependency_graph", am.ViewAccess(aH.dependencyGraph)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/settings/ttl", am.AdminAccess(aH.setTTL)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/settings/ttl", am.ViewAccess(aH.getTTL)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/admin/attributes/refresh", am.AdminAccess(aH.refreshAttributes)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/admin/attributes/refresh", am.AdminAccess(aH.getAttributesRefreshStatus)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/admin/stale_resources", am.AdminAccess(aH.getStaleResources)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/admin/demo_data", am.AdminAccess(aH.generateDemoData)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/admin/schema_status", am.AdminAccess(aH.getSchemaStatus)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/admin/query_audit/report", am.AdminAccess(aH.getQueryAuditReport)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/settings/apdex", am.AdminAccess(aH.setApdexSettings)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/alert_severities", am.ViewAccess(aH.getAlertSeverityLevels)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/alert_severities", am.AdminAccess(aH.setAlertSeverityLevels)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/settings/alert_digests", am.ViewAccess(aH.listChannelDigests)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/alert_digests/{channel}", am.AdminAccess(aH.setChannelDigest)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/settings/alert_digests/{channel}", am.AdminAccess(aH.removeChannelDigest)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/settings/apdex", am.ViewAccess(aH.getApdexSettings)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/settings/ingestion_key", am.AdminAccess(aH.insertIngestionKey)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/settings/ingestion_key", am.ViewAccess(aH.getIngestionKeys)).Methods(http.MethodGet)
@@ -452,6 +669,8 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/user/{id}", am.AdminAccess(aH.deleteUser)).Methods(http.MethodDelete)
 
 	router.HandleFunc("/api/v1/user/{id}/flags", am.SelfAccess(aH.patchUserFlag)).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v1/user/{id}/preferences", am.SelfAccess(aH.getUserPreferences)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/user/{id}/preferences", am.SelfAccess(aH.patchUserPreferences)).Methods(http.MethodPatch)
 
 	router.HandleFunc("/api/v1/rbac/role/{id}", am.SelfAccess(aH.getRole)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rbac/role/{id}", am.AdminAccess(aH.editRole)).Methods(http.MethodPut)
@@ -464,6 +683,10 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/getResetPasswordToken/{id}", am.AdminAccess(aH.getResetPasswordToken)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/resetPassword", am.OpenAccess(aH.resetPassword)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/changePassword/{id}", am.SelfAccess(aH.changePassword)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/auth/signing_keys", am.AdminAccess(aH.listSigningKeys)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/auth/signing_keys/rotate", am.AdminAccess(aH.rotateSigningKey)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/auth/signing_keys/{id}", am.AdminAccess(aH.revokeSigningKey)).Methods(http.MethodDelete)
 }
 
 func Intersection(a, b []int) (c []int) {
@@ -491,6 +714,29 @@ func (aH *APIHandler) getRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, ruleResponse)
 }
 
+// explainRule evaluates a rule at the timestamp ts, in nanoseconds and now
+// by default, returning the series compared with the threshold
+func (aH *APIHandler) explainRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	ts := time.Now()
+	if r.URL.Query().Get("ts") != "" {
+		parsed, err := parseTime("ts", r)
+		if err != nil {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		ts = *parsed
+	}
+
+	explanation, apiErr := aH.ruleManager.ExplainRule(r.Context(), id, ts)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, explanation)
+}
+
 func (aH *APIHandler) metricAutocompleteMetricName(w http.ResponseWriter, r *http.Request) {
 	matchText := r.URL.Query().Get("match")
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -871,6 +1117,7 @@ func (aH *APIHandler) getDashboards(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, err, nil)
 		return
 	}
+	allDashboards = dashboards.WithoutAccessStats(allDashboards)
 	tagsFromReq, ok := r.URL.Query()["tags"]
 	if !ok || len(tagsFromReq) == 0 || tagsFromReq[0] == "" {
 		aH.Respond(w, allDashboards)
@@ -1043,10 +1290,27 @@ func (aH *APIHandler) getDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	aH.Respond(w, dashboard)
+	aH.Respond(w, dashboards.WithoutAccessStats([]dashboards.Dashboard{*dashboard})[0])
 
 }
 
+// recordDashboardView records that a dashboard was opened, the dashboard
+// GETs don't as they are also polled
+func (aH *APIHandler) recordDashboardView(w http.ResponseWriter, r *http.Request) {
+	uuid := mux.Vars(r)["uuid"]
+
+	if _, apiErr := dashboards.GetDashboard(r.Context(), uuid); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := dashboards.RecordDashboardView(r.Context(), uuid); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) saveAndReturn(w http.ResponseWriter, r *http.Request, signozDashboard model.DashboardData) {
 	toSave := make(map[string]interface{})
 	toSave["title"] = signozDashboard.Title
@@ -1056,6 +1320,11 @@ func (aH *APIHandler) saveAndReturn(w http.ResponseWriter, r *http.Request, sign
 	toSave["widgets"] = signozDashboard.Widgets
 	toSave["variables"] = signozDashboard.Variables
 
+	if apiErr := aH.ensureCanCreate(r.Context(), quotas.ResourceDashboards); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
 	dashboard, apiError := dashboards.CreateDashboard(r.Context(), toSave, aH.featureFlags)
 	if apiError != nil {
 		RespondError(w, apiError, nil)
@@ -1097,6 +1366,11 @@ func (aH *APIHandler) createDashboards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := aH.ensureCanCreate(r.Context(), quotas.ResourceDashboards); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
 	dash, apiErr := dashboards.CreateDashboard(r.Context(), postData, aH.featureFlags)
 
 	if apiErr != nil {
@@ -1134,6 +1408,38 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, response)
 }
 
+// convertRule converts the queries of the rule in the body to the query type
+// given in the `to` query param, the converted rule is returned and not saved
+func (aH *APIHandler) convertRule(w http.ResponseWriter, r *http.Request) {
+
+	to := v3.QueryType(r.URL.Query().Get("to"))
+	if to != v3.QueryTypeBuilder && to != v3.QueryTypeClickHouseSQL {
+		RespondError(w, model.BadRequest(fmt.Errorf("to must be one of %s, %s", v3.QueryTypeBuilder, v3.QueryTypeClickHouseSQL)), nil)
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rule, errs := rules.ParsePostableRule(body)
+	if len(errs) > 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: errs[0]}, nil)
+		return
+	}
+
+	converted, err := rules.ConvertRule(rule, to, aH.featureFlags)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, converted)
+}
+
 func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
@@ -1193,6 +1499,59 @@ func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func (aH *APIHandler) getAlertSeverityLevels(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, aH.ruleManager.GetSeverityLevels())
+}
+
+func (aH *APIHandler) setAlertSeverityLevels(w http.ResponseWriter, r *http.Request) {
+	req := rules.PostableSeverityLevels{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	levels, apiErr := aH.ruleManager.SetSeverityLevels(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, levels)
+}
+
+func (aH *APIHandler) listChannelDigests(w http.ResponseWriter, r *http.Request) {
+	digests, apiErr := aH.ruleManager.GetChannelDigests()
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, digests)
+}
+
+func (aH *APIHandler) setChannelDigest(w http.ResponseWriter, r *http.Request) {
+	req := rules.ChannelDigest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	req.Channel = mux.Vars(r)["channel"]
+
+	digest, apiErr := aH.ruleManager.SetChannelDigest(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, digest)
+}
+
+func (aH *APIHandler) removeChannelDigest(w http.ResponseWriter, r *http.Request) {
+	apiErr := aH.ruleManager.RemoveChannelDigest(r.Context(), mux.Vars(r)["channel"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) getChannel(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	channel, apiErrorObj := aH.reader.GetChannel(id)
@@ -1335,6 +1694,11 @@ func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := aH.ensureCanCreate(r.Context(), quotas.ResourceRules); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
 	rule, err := aH.ruleManager.CreateRule(r.Context(), string(body))
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
@@ -1457,6 +1821,23 @@ func (aH *APIHandler) queryMetrics(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// handleSlackInteraction handles the clicks on the buttons of alert messages
+// posted by the slack app
+func (aH *APIHandler) handleSlackInteraction(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, slackapp.MaxInteractionSize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	if apiErr := aH.SlackAppController.HandleInteraction(r.Context(), r.Header, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) submitFeedback(w http.ResponseWriter, r *http.Request) {
 
 	var postData map[string]interface{}
@@ -1506,6 +1887,53 @@ func (aH *APIHandler) getTopOperations(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func (aH *APIHandler) getDatabaseCalls(w http.ResponseWriter, r *http.Request) {
+	query, err := parseGetDependencyCallsRequest(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	result, apiErr := aH.reader.GetDatabaseCalls(r.Context(), query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
+func (aH *APIHandler) getExternalCalls(w http.ResponseWriter, r *http.Request) {
+	query, err := parseGetDependencyCallsRequest(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	result, apiErr := aH.reader.GetExternalCalls(r.Context(), query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
+// getLatencyBreakdown breaks down the duration of the spans of an operation
+// by the values of an attribute, e.g. to explain its latency by db.system
+func (aH *APIHandler) getLatencyBreakdown(w http.ResponseWriter, r *http.Request) {
+	query, err := parseGetLatencyBreakdownRequest(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	result, apiErr := aH.reader.GetLatencyBreakdown(r.Context(), query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
 func (aH *APIHandler) getUsage(w http.ResponseWriter, r *http.Request) {
 
 	query, err := parseGetUsageRequest(r)
@@ -1609,16 +2037,84 @@ func (aH *APIHandler) SearchTraces(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
 		return
 	}
+	selection, err := ParseSpanFieldSelection(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
+		return
+	}
 
 	result, err := aH.reader.SearchTraces(r.Context(), traceId, spanId, levelUpInt, levelDownInt, 0, nil)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	for i := range *result {
+		(*result)[i].Project(selection)
+	}
 
 	aH.WriteJSON(w, r, result)
 
 }
 
+func (aH *APIHandler) checkTracesExist(w http.ResponseWriter, r *http.Request) {
+	req := model.TraceExistenceParams{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if len(req.TraceIDs) > maxTraceExistenceBatch {
+		RespondError(w, model.BadRequest(fmt.Errorf(
+			"at most %d trace ids can be checked at once", maxTraceExistenceBatch,
+		)), nil)
+		return
+	}
+
+	// start and end are optional and in ms, the traces are looked for in
+	// the last traceExistenceLookback by default
+	now := time.Now()
+	start, end := now.Add(-traceExistenceLookback).UnixNano(), now.UnixNano()
+	if req.Start > 0 {
+		start = req.Start * int64(time.Millisecond)
+	}
+	if req.End > 0 {
+		end = req.End * int64(time.Millisecond)
+	}
+
+	existing, apiErr := aH.reader.GetExistingTraceIDs(r.Context(), req.TraceIDs, start, end)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	exists := map[string]bool{}
+	for _, traceID := range req.TraceIDs {
+		_, exists[traceID] = existing[traceID]
+	}
+	aH.Respond(w, exists)
+}
+
+func (aH *APIHandler) SearchTraceSpans(w http.ResponseWriter, r *http.Request) {
+
+	params, err := ParseSearchTraceSpansParams(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
+		return
+	}
+	selection, err := ParseSpanFieldSelection(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading params")
+		return
+	}
+
+	result, apiErr := aH.reader.SearchTraceSpans(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	result.Project(selection)
+
+	aH.WriteJSON(w, r, result)
+}
+
 func (aH *APIHandler) listErrors(w http.ResponseWriter, r *http.Request) {
 
 	query, err := parseListErrorsRequest(r)
@@ -1794,28 +2290,250 @@ func (aH *APIHandler) setTTL(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (aH *APIHandler) getTTL(w http.ResponseWriter, r *http.Request) {
-	ttlParams, err := parseGetTTL(r)
-	if aH.HandleError(w, err, http.StatusBadRequest) {
+// refreshAttributes rescans recent logs and traces to rebuild the attribute
+// key metadata, e.g. after bulk backfills which skipped the metadata tables
+func (aH *APIHandler) refreshAttributes(w http.ResponseWriter, r *http.Request) {
+	params, err := parseRefreshAttributesParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
 
-	result, apiErr := aH.reader.GetTTL(r.Context(), ttlParams)
-	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
+	status, apiErr := aH.reader.RefreshAttributeMetadata(params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
 
-	aH.WriteJSON(w, r, result)
+	aH.Respond(w, status)
 }
 
-func (aH *APIHandler) getDisks(w http.ResponseWriter, r *http.Request) {
-	result, apiErr := aH.reader.GetDisks(context.Background())
-	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
+func (aH *APIHandler) generateDemoData(w http.ResponseWriter, r *http.Request) {
+	req := demodata.GenerateRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("failed to decode request body: %w", err)), nil)
+			return
+		}
+	}
+
+	data, summary, err := demodata.Generate(&req, time.Now())
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
 
-	aH.WriteJSON(w, r, result)
-}
+	if apiErr := aH.reader.WriteDemoData(r.Context(), data); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, summary)
+}
+
+func (aH *APIHandler) getSchemaStatus(w http.ResponseWriter, r *http.Request) {
+	refresh := r.URL.Query().Get("refresh") == "true"
+	status, apiErr := aH.reader.GetSchemaStatus(r.Context(), refresh)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, status)
+}
+
+// schemaFeaturesOfQuery returns the schema features needed by the builder
+// queries of a composite query
+func schemaFeaturesOfQuery(queryRangeParams *v3.QueryRangeParamsV3, metricsV4 bool) []string {
+	if queryRangeParams.CompositeQuery.QueryType != v3.QueryTypeBuilder {
+		return nil
+	}
+
+	features := []string{}
+	seen := map[string]bool{}
+	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+		var feature string
+		switch query.DataSource {
+		case v3.DataSourceTraces:
+			feature = model.SchemaFeatureTraces
+		case v3.DataSourceLogs:
+			feature = model.SchemaFeatureLogs
+		case v3.DataSourceMetrics:
+			feature = model.SchemaFeatureMetrics
+			if metricsV4 {
+				feature = model.SchemaFeatureMetricsV4
+			}
+		}
+		if feature != "" && !seen[feature] {
+			seen[feature] = true
+			features = append(features, feature)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+func (aH *APIHandler) getAttributesRefreshStatus(w http.ResponseWriter, r *http.Request) {
+	status := aH.reader.GetAttributeMetadataRefreshStatus()
+	if status == nil {
+		RespondError(w, model.NotFoundError(fmt.Errorf("no attribute refresh has been run")), nil)
+		return
+	}
+
+	aH.Respond(w, status)
+}
+
+// getStaleResources lists the dashboards and saved views nobody has opened
+// in a while so that admins can prune them
+func (aH *APIHandler) getStaleResources(w http.ResponseWriter, r *http.Request) {
+	unusedForDays, err := parseStaleResourcesParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	since := time.Now().AddDate(0, 0, -unusedForDays)
+
+	staleDashboards, apiErr := dashboards.GetStaleDashboards(r.Context(), since)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	staleViews, err := explorer.GetStaleViews(since)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, map[string]interface{}{
+		"unusedForDays": unusedForDays,
+		"dashboards":    staleDashboards,
+		"savedViews":    staleViews,
+	})
+}
+
+// getQueryAuditReport attributes the cost of ClickHouse queries to the
+// users, dashboards or alerts which ran them, based on the sampled queries
+func (aH *APIHandler) getQueryAuditReport(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryAuditReportParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	report, apiErr := aH.reader.GetQueryAuditReport(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+// prometheusRemoteWrite accepts the snappy compressed protobuf payloads sent
+// by prometheus remote_write and writes them to the metrics tables
+func (aH *APIHandler) prometheusRemoteWrite(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(constants.RemoteWriteMaxBytes))
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	// the decoded size is read from the payload header, so that payloads
+	// decompressing to more than the limit are rejected before allocating
+	decodedLen, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("could not decompress request: %w", err)), nil)
+		return
+	}
+	if decodedLen > constants.RemoteWriteMaxBytes {
+		RespondError(w, model.BadRequest(fmt.Errorf(
+			"decompressed request of %d bytes exceeds the limit of %d bytes", decodedLen, constants.RemoteWriteMaxBytes,
+		)), nil)
+		return
+	}
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("could not decompress request: %w", err)), nil)
+		return
+	}
+	req := prompb.WriteRequest{}
+	if err := req.Unmarshal(body); err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("could not decode write request: %w", err)), nil)
+		return
+	}
+
+	if apiErr := aH.reader.WriteRemoteWriteRequest(r.Context(), &req); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getK8sPodTimelines returns the lifecycle of pods and their containers as
+// recorded by k8s events, for overlaying on service charts
+func (aH *APIHandler) getK8sPodTimelines(w http.ResponseWriter, r *http.Request) {
+	params, err := parseK8sPodTimelineParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	events, apiErr := aH.reader.GetK8sPodEvents(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, k8stimeline.BuildPodTimelines(events, params.End.UnixMilli()))
+}
+
+// getSessionTimeline returns the logs and spans carrying the session.id of a
+// frontend session, ordered on a single timeline
+func (aH *APIHandler) getSessionTimeline(w http.ResponseWriter, r *http.Request) {
+	params, err := parseSessionTimelineParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	timeline, apiErr := aH.reader.GetSessionTimeline(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, timeline)
+}
+
+func (aH *APIHandler) indexSessionIds(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.reader.IndexSessionIds(r.Context()); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) getTTL(w http.ResponseWriter, r *http.Request) {
+	ttlParams, err := parseGetTTL(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	result, apiErr := aH.reader.GetTTL(r.Context(), ttlParams)
+	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
+func (aH *APIHandler) getDisks(w http.ResponseWriter, r *http.Request) {
+	result, apiErr := aH.reader.GetDisks(context.Background())
+	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
 
 func (aH *APIHandler) getVersion(w http.ResponseWriter, r *http.Request) {
 	version := version.GetVersion()
@@ -2179,6 +2897,37 @@ func (aH *APIHandler) patchUserFlag(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, newflags)
 }
 
+func (aH *APIHandler) getUserPreferences(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["id"]
+
+	preferences, apiErr := dao.DB().GetUserPreferences(r.Context(), userId)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch user preferences")
+		return
+	}
+
+	aH.Respond(w, preferences)
+}
+
+// patchUserPreferences updates only the preferences present in the request
+func (aH *APIHandler) patchUserPreferences(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["id"]
+
+	patch := model.UserPreferencesPatch{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		RespondError(w, model.BadRequestStr("received user preferences in invalid format"), nil)
+		return
+	}
+
+	preferences, apiErr := dao.DB().UpdateUserPreferences(r.Context(), userId, &patch)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, preferences)
+}
+
 func (aH *APIHandler) getRole(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 
@@ -2322,6 +3071,39 @@ func (aH *APIHandler) getResetPasswordToken(w http.ResponseWriter, r *http.Reque
 	aH.WriteJSON(w, r, resp)
 }
 
+func (aH *APIHandler) listSigningKeys(w http.ResponseWriter, r *http.Request) {
+	keys, apiErr := auth.ListSigningKeys(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch jwt signing keys")
+		return
+	}
+	aH.Respond(w, keys)
+}
+
+func (aH *APIHandler) rotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	gracePeriod, err := parseRotateSigningKeyRequest(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	key, apiErr := auth.RotateSigningKey(r.Context(), gracePeriod)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to rotate jwt signing key")
+		return
+	}
+	aH.Respond(w, key)
+}
+
+func (aH *APIHandler) revokeSigningKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if apiErr := auth.RevokeSigningKey(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, "Failed to revoke jwt signing key")
+		return
+	}
+	aH.Respond(w, map[string]string{"data": "jwt signing key revoked successfully"})
+}
+
 func (aH *APIHandler) resetPassword(w http.ResponseWriter, r *http.Request) {
 	req, err := parseResetPasswordRequest(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
@@ -2594,77 +3376,2823 @@ func (ah *APIHandler) UninstallIntegration(
 	ah.Respond(w, map[string]interface{}{})
 }
 
-// logs
-func (aH *APIHandler) RegisterLogsRoutes(router *mux.Router, am *AuthMiddleware) {
-	subRouter := router.PathPrefix("/api/v1/logs").Subrouter()
-	subRouter.HandleFunc("", am.ViewAccess(aH.getLogs)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/tail", am.ViewAccess(aH.tailLogs)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/fields", am.ViewAccess(aH.logFields)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/fields", am.EditAccess(aH.logFieldUpdate)).Methods(http.MethodPost)
-	subRouter.HandleFunc("/aggregate", am.ViewAccess(aH.logAggregate)).Methods(http.MethodGet)
+// Incidents
+func (ah *APIHandler) RegisterIncidentRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/incidents").Subrouter()
 
-	// log pipelines
-	subRouter.HandleFunc("/pipelines/preview", am.ViewAccess(aH.PreviewLogsPipelinesHandler)).Methods(http.MethodPost)
-	subRouter.HandleFunc("/pipelines/{version}", am.ViewAccess(aH.ListLogsPipelinesHandler)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.CreateLogsPipeline)).Methods(http.MethodPost)
+	subRouter.HandleFunc(
+		"/{id}/alerts/{fingerprint}", am.EditAccess(ah.UnlinkIncidentAlert),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/{id}/alerts", am.EditAccess(ah.LinkIncidentAlert),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}/annotations", am.EditAccess(ah.AddIncidentAnnotation),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetIncident),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.PatchIncident),
+	).Methods(http.MethodPatch)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteIncident),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListIncidents),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateIncident),
+	).Methods(http.MethodPost)
 }
 
-func (aH *APIHandler) logFields(w http.ResponseWriter, r *http.Request) {
-	fields, apiErr := aH.reader.GetLogFields(r.Context())
+func (ah *APIHandler) ListIncidents(
+	w http.ResponseWriter, r *http.Request,
+) {
+	status := incidents.IncidentStatus(r.URL.Query().Get("status"))
+	resp, apiErr := ah.IncidentsController.ListIncidents(r.Context(), status)
 	if apiErr != nil {
-		RespondError(w, apiErr, "Failed to fetch fields from the DB")
+		RespondError(w, apiErr, nil)
 		return
 	}
-	aH.WriteJSON(w, r, fields)
+	ah.Respond(w, resp)
 }
 
-func (aH *APIHandler) logFieldUpdate(w http.ResponseWriter, r *http.Request) {
-	field := model.UpdateField{}
-	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
-		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErr, "Failed to decode payload")
+func (ah *APIHandler) GetIncident(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	resp, apiErr := ah.IncidentsController.GetIncident(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
+	ah.Respond(w, resp)
+}
 
-	err := logs.ValidateUpdateFieldPayload(&field)
-	if err != nil {
-		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErr, "Incorrect payload")
+func (ah *APIHandler) CreateIncident(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := incidents.PostableIncident{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
 
-	apiErr := aH.reader.UpdateLogField(r.Context(), &field)
+	resp, apiErr := ah.IncidentsController.CreateIncident(r.Context(), &req)
 	if apiErr != nil {
-		RespondError(w, apiErr, "Failed to update filed in the DB")
+		RespondError(w, apiErr, nil)
 		return
 	}
-	aH.WriteJSON(w, r, field)
+	ah.Respond(w, resp)
 }
 
-func (aH *APIHandler) getLogs(w http.ResponseWriter, r *http.Request) {
-	params, err := logs.ParseLogFilterParams(r)
-	if err != nil {
-		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErr, "Incorrect params")
+func (ah *APIHandler) PatchIncident(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	req := incidents.IncidentPatch{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
-	res, apiErr := aH.reader.GetLogs(r.Context(), params)
+
+	resp, apiErr := ah.IncidentsController.PatchIncident(r.Context(), id, &req)
 	if apiErr != nil {
-		RespondError(w, apiErr, "Failed to fetch logs from the DB")
+		RespondError(w, apiErr, nil)
 		return
 	}
-	aH.WriteJSON(w, r, map[string]interface{}{"results": res})
+	ah.Respond(w, resp)
 }
 
-func (aH *APIHandler) tailLogs(w http.ResponseWriter, r *http.Request) {
-	params, err := logs.ParseLogFilterParams(r)
-	if err != nil {
-		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErr, "Incorrect params")
-		return
-	}
-
-	// create the client
+func (ah *APIHandler) DeleteIncident(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	apiErr := ah.IncidentsController.DeleteIncident(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+func (ah *APIHandler) LinkIncidentAlert(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	req := incidents.PostableIncidentAlert{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	resp, apiErr := ah.IncidentsController.LinkAlert(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) UnlinkIncidentAlert(
+	w http.ResponseWriter, r *http.Request,
+) {
+	vars := mux.Vars(r)
+	resp, apiErr := ah.IncidentsController.UnlinkAlert(r.Context(), vars["id"], vars["fingerprint"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) AddIncidentAnnotation(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	req := incidents.PostableAnnotation{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	resp, apiErr := ah.IncidentsController.AddAnnotation(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+// Lookup tables
+const maxLookupTableUploadSize = 10 << 20 // 10 MB
+
+func (ah *APIHandler) RegisterLookupTableRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/lookup_tables").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetLookupTable),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteLookupTable),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListLookupTables),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateLookupTable),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListLookupTables(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.LookupTablesController.ListLookupTables(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch lookup tables")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetLookupTable(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	table, apiErr := ah.LookupTablesController.GetLookupTable(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch lookup table")
+		return
+	}
+	ah.Respond(w, table)
+}
+
+// CreateLookupTable expects a multipart form with the table name
+// in the `name` field and the CSV contents in the `file` field
+func (ah *APIHandler) CreateLookupTable(
+	w http.ResponseWriter, r *http.Request,
+) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLookupTableUploadSize)
+	if err := r.ParseMultipartForm(maxLookupTableUploadSize); err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("failed to parse form: %w", err)), nil)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("csv file is required: %w", err)), nil)
+		return
+	}
+	defer file.Close()
+
+	entries, err := lookuptables.ParseCSV(file)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	table, apiErr := ah.LookupTablesController.CreateLookupTable(
+		r.Context(), &lookuptables.PostableLookupTable{
+			Name:    r.FormValue("name"),
+			Entries: *entries,
+		},
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, table)
+}
+
+func (ah *APIHandler) DeleteLookupTable(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	apiErr := ah.LookupTablesController.DeleteLookupTable(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// Org quotas
+func (ah *APIHandler) RegisterQuotaRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/quotas").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{resource}", am.AdminAccess(ah.DeleteQuota),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.GetQuotaUsage),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.SetQuota),
+	).Methods(http.MethodPut)
+}
+
+// GetQuotaUsage lists the quotas of the org along with their usage
+func (ah *APIHandler) GetQuotaUsage(
+	w http.ResponseWriter, r *http.Request,
+) {
+	usage, apiErr := ah.QuotasController.GetUsage(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch quota usage")
+		return
+	}
+	ah.Respond(w, usage)
+}
+
+func (ah *APIHandler) SetQuota(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := quotas.PostableQuota{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	quota, apiErr := ah.QuotasController.SetQuota(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, quota)
+}
+
+func (ah *APIHandler) DeleteQuota(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resource := quotas.Resource(mux.Vars(r)["resource"])
+	if apiErr := ah.QuotasController.DeleteQuota(r.Context(), resource); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// ensureCanCreate checks the org quota of a resource before creating one more
+func (ah *APIHandler) ensureCanCreate(ctx context.Context, resource quotas.Resource) *model.ApiError {
+	if ah.QuotasController == nil {
+		return nil
+	}
+	return ah.QuotasController.EnsureCanCreate(ctx, resource, 1)
+}
+
+// Query lookback limits per role
+func (ah *APIHandler) RegisterQueryLimitRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/query_limits").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{role}", am.AdminAccess(ah.DeleteQueryLimit),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.GetQueryLimits),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.SetQueryLimit),
+	).Methods(http.MethodPut)
+}
+
+// GetQueryLimits lists the lookback limits of the roles of the org, so that
+// users can see how far back they can query
+func (ah *APIHandler) GetQueryLimits(
+	w http.ResponseWriter, r *http.Request,
+) {
+	limits, apiErr := ah.QueryLimitsController.GetLimits(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch query limits")
+		return
+	}
+	ah.Respond(w, limits)
+}
+
+func (ah *APIHandler) SetQueryLimit(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := querylimits.PostableLookbackLimit{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	limit, apiErr := ah.QueryLimitsController.SetLimit(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, limit)
+}
+
+func (ah *APIHandler) DeleteQueryLimit(
+	w http.ResponseWriter, r *http.Request,
+) {
+	role := mux.Vars(r)["role"]
+	if apiErr := ah.QueryLimitsController.DeleteLimit(r.Context(), role); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// Obfuscation profiles applied to the query results shared externally
+func (ah *APIHandler) RegisterObfuscationProfileRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/obfuscation_profiles").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetObfuscationProfile),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateObfuscationProfile),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.DeleteObfuscationProfile),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListObfuscationProfiles),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateObfuscationProfile),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListObfuscationProfiles(
+	w http.ResponseWriter, r *http.Request,
+) {
+	profiles, apiErr := ah.ObfuscationController.ListObfuscationProfiles(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch obfuscation profiles")
+		return
+	}
+	ah.Respond(w, profiles)
+}
+
+func (ah *APIHandler) GetObfuscationProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	profile, apiErr := ah.ObfuscationController.GetObfuscationProfile(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, profile)
+}
+
+func (ah *APIHandler) CreateObfuscationProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := obfuscation.PostableObfuscationProfile{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	profile, apiErr := ah.ObfuscationController.CreateObfuscationProfile(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, profile)
+}
+
+func (ah *APIHandler) UpdateObfuscationProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	req := obfuscation.PostableObfuscationProfile{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	profile, apiErr := ah.ObfuscationController.UpdateObfuscationProfile(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, profile)
+}
+
+func (ah *APIHandler) DeleteObfuscationProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.ObfuscationController.DeleteObfuscationProfile(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// obfuscateResults applies the obfuscation profile requested for query
+// results shared externally, if any, and the profiles enforced for the role
+// of the user
+func (ah *APIHandler) obfuscateResults(
+	ctx context.Context, params *v3.QueryRangeParamsV3, results []*v3.Result,
+) ([]*v3.Result, *model.ApiError) {
+	if ah.ObfuscationController == nil {
+		if params.ObfuscationProfile != "" {
+			return nil, model.BadRequest(fmt.Errorf("obfuscation profiles are not supported"))
+		}
+		return results, nil
+	}
+	return ah.ObfuscationController.ObfuscateResults(ctx, params.ObfuscationProfile, results)
+}
+
+// Metric owners
+func (ah *APIHandler) RegisterMetricOwnerRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/metric_owners").Subrouter()
+
+	subRouter.HandleFunc(
+		"/cardinality", am.ViewAccess(ah.GetMetricOwnersCardinality),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteMetricOwner),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListMetricOwners),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateMetricOwner),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListMetricOwners(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.MetricOwnersController.ListOwnershipRules(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch metric owners")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) CreateMetricOwner(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := metricowners.PostableOwnershipRule{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	rule, apiErr := ah.MetricOwnersController.CreateOwnershipRule(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, rule)
+}
+
+func (ah *APIHandler) DeleteMetricOwner(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	apiErr := ah.MetricOwnersController.DeleteOwnershipRule(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// GetMetricOwnersCardinality reports the series count of every team for
+// the `start` and `end` query params in ms, defaulting to the last hour
+func (ah *APIHandler) GetMetricOwnersCardinality(
+	w http.ResponseWriter, r *http.Request,
+) {
+	end := time.Now().UnixMilli()
+	start := end - time.Hour.Milliseconds()
+	for param, value := range map[string]*int64{"start": &start, "end": &end} {
+		if s := r.URL.Query().Get(param); s != "" {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				RespondError(w, model.BadRequest(fmt.Errorf("%s is not a valid timestamp: %w", param, err)), nil)
+				return
+			}
+			*value = v
+		}
+	}
+	if start >= end {
+		RespondError(w, model.BadRequestStr("start must be before end"), nil)
+		return
+	}
+
+	report, apiErr := ah.MetricOwnersController.GetCardinalityReport(r.Context(), ah.reader, start, end)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, report)
+}
+
+// Log exports
+func (ah *APIHandler) RegisterLogExportRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/log_exports").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetLogExportDestination),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateLogExportDestination),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.DeleteLogExportDestination),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListLogExportDestinations),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateLogExportDestination),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListLogExportDestinations(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.LogExportsController.ListDestinations(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch log export destinations")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetLogExportDestination(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	destination, apiErr := ah.LogExportsController.GetDestination(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch log export destination")
+		return
+	}
+	ah.Respond(w, destination)
+}
+
+func (ah *APIHandler) CreateLogExportDestination(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := logexports.PostableLogExportDestination{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	destination, apiErr := ah.LogExportsController.CreateDestination(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, destination)
+}
+
+func (ah *APIHandler) UpdateLogExportDestination(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := logexports.PostableLogExportDestination{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	destination, apiErr := ah.LogExportsController.UpdateDestination(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, destination)
+}
+
+func (ah *APIHandler) DeleteLogExportDestination(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.LogExportsController.DeleteDestination(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// Kafka ingestion
+func (ah *APIHandler) RegisterKafkaRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/kafka").Subrouter()
+
+	subRouter.HandleFunc(
+		"/consumer_lag", am.ViewAccess(ah.GetKafkaConsumerLag),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/receivers/{id}", am.AdminAccess(ah.GetKafkaReceiver),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/receivers/{id}", am.AdminAccess(ah.UpdateKafkaReceiver),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/receivers/{id}", am.AdminAccess(ah.DeleteKafkaReceiver),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/receivers", am.AdminAccess(ah.ListKafkaReceivers),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/receivers", am.AdminAccess(ah.CreateKafkaReceiver),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListKafkaReceivers(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.KafkaReceiversController.ListKafkaReceivers(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch kafka receivers")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetKafkaReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	kafkaReceiver, apiErr := ah.KafkaReceiversController.GetKafkaReceiver(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch kafka receiver")
+		return
+	}
+	ah.Respond(w, kafkaReceiver)
+}
+
+func (ah *APIHandler) CreateKafkaReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := kafkareceivers.PostableKafkaReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	kafkaReceiver, apiErr := ah.KafkaReceiversController.CreateKafkaReceiver(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, kafkaReceiver)
+}
+
+func (ah *APIHandler) UpdateKafkaReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := kafkareceivers.PostableKafkaReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	kafkaReceiver, apiErr := ah.KafkaReceiversController.UpdateKafkaReceiver(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, kafkaReceiver)
+}
+
+func (ah *APIHandler) DeleteKafkaReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.KafkaReceiversController.DeleteKafkaReceiver(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// Jaeger and zipkin trace ingestion
+func (ah *APIHandler) RegisterTraceReceiversRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/traces/receivers").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetTraceReceiver),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateTraceReceiver),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.DeleteTraceReceiver),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListTraceReceivers),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateTraceReceiver),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListTraceReceivers(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.TraceReceiversController.ListTraceReceivers(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch trace receivers")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetTraceReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	traceReceiver, apiErr := ah.TraceReceiversController.GetTraceReceiver(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch trace receiver")
+		return
+	}
+	ah.Respond(w, traceReceiver)
+}
+
+func (ah *APIHandler) CreateTraceReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := tracereceivers.PostableTraceReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	traceReceiver, apiErr := ah.TraceReceiversController.CreateTraceReceiver(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, traceReceiver)
+}
+
+func (ah *APIHandler) UpdateTraceReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := tracereceivers.PostableTraceReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	traceReceiver, apiErr := ah.TraceReceiversController.UpdateTraceReceiver(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, traceReceiver)
+}
+
+func (ah *APIHandler) DeleteTraceReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.TraceReceiversController.DeleteTraceReceiver(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// Files, syslog and journald logs collected by the agents
+func (ah *APIHandler) RegisterLogReceiversRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/logs/receivers").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetLogReceiver),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateLogReceiver),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.DeleteLogReceiver),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListLogReceivers),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateLogReceiver),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListLogReceivers(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.LogReceiversController.ListLogReceivers(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch log receivers")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetLogReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	logReceiver, apiErr := ah.LogReceiversController.GetLogReceiver(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch log receiver")
+		return
+	}
+	ah.Respond(w, logReceiver)
+}
+
+func (ah *APIHandler) CreateLogReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := logreceivers.PostableLogReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	logReceiver, apiErr := ah.LogReceiversController.CreateLogReceiver(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, logReceiver)
+}
+
+func (ah *APIHandler) UpdateLogReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := logreceivers.PostableLogReceiver{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	logReceiver, apiErr := ah.LogReceiversController.UpdateLogReceiver(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, logReceiver)
+}
+
+func (ah *APIHandler) DeleteLogReceiver(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.LogReceiversController.DeleteLogReceiver(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// GetKafkaConsumerLag returns the latest consumer lag per consumer group,
+// topic and partition as reported by the collectors' kafkametrics receivers
+func (ah *APIHandler) GetKafkaConsumerLag(
+	w http.ResponseWriter, r *http.Request,
+) {
+	lookbackSecondsStr := r.URL.Query().Get("lookback_seconds")
+	lookbackSeconds, err := strconv.ParseInt(lookbackSecondsStr, 10, 64)
+	if err != nil || lookbackSeconds <= 0 {
+		lookbackSeconds = 5 * 60
+	}
+
+	end := time.Now().UnixMilli()
+	qrParams := kafkareceivers.ConsumerLagQueryRangeParams(end-(lookbackSeconds*1000), end)
+	queryRes, err, _ := ah.querier.QueryRange(
+		r.Context(), qrParams, map[string]v3.AttributeKey{},
+	)
+	if err != nil {
+		RespondError(w, model.InternalError(fmt.Errorf(
+			"could not query kafka consumer lag: %w", err,
+		)), nil)
+		return
+	}
+
+	ah.Respond(w, kafkareceivers.ConsumerLagFromResults(queryRes))
+}
+
+// Ingestion keys
+func (ah *APIHandler) RegisterIngestionKeyRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/ingestion_keys").Subrouter()
+
+	subRouter.HandleFunc(
+		"/check", am.EditAccess(ah.CheckIngestionKey),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}/revoke", am.AdminAccess(ah.RevokeIngestionKey),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetIngestionKey),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateIngestionKey),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListIngestionKeys),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateIngestionKey),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListIngestionKeys(
+	w http.ResponseWriter, r *http.Request,
+) {
+	keys, apiErr := ah.IngestionKeysController.ListIngestionKeys(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch ingestion keys")
+		return
+	}
+	ah.Respond(w, keys)
+}
+
+func (ah *APIHandler) GetIngestionKey(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	key, apiErr := ah.IngestionKeysController.GetIngestionKey(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch ingestion key")
+		return
+	}
+	ah.Respond(w, key)
+}
+
+func (ah *APIHandler) CreateIngestionKey(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := ingestionkeys.PostableIngestionKey{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	key, apiErr := ah.IngestionKeysController.CreateIngestionKey(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, key)
+}
+
+func (ah *APIHandler) UpdateIngestionKey(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := ingestionkeys.PostableIngestionKey{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	key, apiErr := ah.IngestionKeysController.UpdateIngestionKey(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, key)
+}
+
+func (ah *APIHandler) RevokeIngestionKey(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	key, apiErr := ah.IngestionKeysController.RevokeIngestionKey(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, key)
+}
+
+// CheckIngestionKey is called by gateways in front of the collectors for each
+// batch of telemetry. The key can be sent in the body or, as shippers send
+// it, in the signoz-ingestion-key header.
+func (ah *APIHandler) CheckIngestionKey(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := ingestionkeys.CheckRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.Key == "" {
+		req.Key = r.Header.Get("signoz-ingestion-key")
+	}
+
+	result, apiErr := ah.IngestionKeysController.Check(&req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, result)
+}
+
+// Filter snippets
+func (ah *APIHandler) RegisterFilterSnippetRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/filter_snippets").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{name}/references", am.ViewAccess(ah.GetFilterSnippetReferences),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{name}", am.ViewAccess(ah.GetFilterSnippet),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{name}", am.EditAccess(ah.UpdateFilterSnippet),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{name}", am.EditAccess(ah.DeleteFilterSnippet),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListFilterSnippets),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateFilterSnippet),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListFilterSnippets(
+	w http.ResponseWriter, r *http.Request,
+) {
+	snippets, apiErr := ah.FilterSnippetsController.ListFilterSnippets(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch filter snippets")
+		return
+	}
+	ah.Respond(w, snippets)
+}
+
+func (ah *APIHandler) GetFilterSnippet(
+	w http.ResponseWriter, r *http.Request,
+) {
+	name := mux.Vars(r)["name"]
+	snippet, apiErr := ah.FilterSnippetsController.GetFilterSnippet(r.Context(), name)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch filter snippet")
+		return
+	}
+	ah.Respond(w, snippet)
+}
+
+func (ah *APIHandler) CreateFilterSnippet(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := filtersnippets.PostableFilterSnippet{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	snippet, apiErr := ah.FilterSnippetsController.CreateFilterSnippet(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, snippet)
+}
+
+func (ah *APIHandler) UpdateFilterSnippet(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := filtersnippets.PostableFilterSnippet{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	snippet, apiErr := ah.FilterSnippetsController.UpdateFilterSnippet(r.Context(), name, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, snippet)
+}
+
+func (ah *APIHandler) DeleteFilterSnippet(
+	w http.ResponseWriter, r *http.Request,
+) {
+	name := mux.Vars(r)["name"]
+	if apiErr := ah.FilterSnippetsController.DeleteFilterSnippet(r.Context(), name); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// GetFilterSnippetReferences lists the dashboards and alerts with queries
+// using the snippet, which changes to it apply to
+func (ah *APIHandler) GetFilterSnippetReferences(
+	w http.ResponseWriter, r *http.Request,
+) {
+	name := mux.Vars(r)["name"]
+	references, apiErr := ah.FilterSnippetsController.GetReferences(r.Context(), name)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, references)
+}
+
+// Trash of deleted dashboards, rules and pipelines
+func (ah *APIHandler) RegisterTrashRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/trash").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{type}/{id}/restore", am.EditAccess(ah.RestoreFromTrash),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{type}/{id}", am.AdminAccess(ah.PurgeFromTrash),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListTrash),
+	).Methods(http.MethodGet)
+}
+
+func (ah *APIHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	items, apiErr := ah.Trash.List(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch trash")
+		return
+	}
+	ah.Respond(w, items)
+}
+
+func (ah *APIHandler) RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	resourceType := trash.ResourceType(mux.Vars(r)["type"])
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.Trash.Restore(r.Context(), resourceType, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, nil)
+}
+
+func (ah *APIHandler) PurgeFromTrash(w http.ResponseWriter, r *http.Request) {
+	resourceType := trash.ResourceType(mux.Vars(r)["type"])
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.Trash.Purge(r.Context(), resourceType, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, nil)
+}
+
+// Tags of dashboards, rules and saved views
+func (ah *APIHandler) RegisterTagRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/tags").Subrouter()
+
+	subRouter.HandleFunc(
+		"/bulk", am.EditAccess(ah.BulkChangeTags),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListTags),
+	).Methods(http.MethodGet)
+}
+
+func (ah *APIHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, apiErr := ah.Tagging.ListTags(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch tags")
+		return
+	}
+	ah.Respond(w, tags)
+}
+
+// BulkChangeTags adds, removes or renames a tag across resources. With
+// dryRun set it only reports the resources which would change.
+func (ah *APIHandler) BulkChangeTags(w http.ResponseWriter, r *http.Request) {
+	req := tagging.PostableTagChange{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	result, apiErr := ah.Tagging.Apply(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, result)
+}
+
+// Search across resources, e.g. for a command palette
+func (ah *APIHandler) RegisterSearchRoutes(router *mux.Router, am *AuthMiddleware) {
+	router.HandleFunc("/api/v1/search", am.ViewAccess(ah.GlobalSearch)).Methods(http.MethodGet)
+}
+
+// GlobalSearch finds resources of any type by name, description and
+// labels, the best matches first
+func (ah *APIHandler) GlobalSearch(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	results, apiErr := ah.Search.Search(r.Context(), *query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, results)
+}
+
+// Scheduled queries
+func (ah *APIHandler) RegisterScheduledQueryRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/scheduled_queries").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}/results", am.ViewAccess(ah.GetScheduledQueryResults),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}/run", am.EditAccess(ah.RunScheduledQuery),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetScheduledQuery),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.UpdateScheduledQuery),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteScheduledQuery),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListScheduledQueries),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateScheduledQuery),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListScheduledQueries(
+	w http.ResponseWriter, r *http.Request,
+) {
+	scheduledQueries, apiErr := ah.ScheduledQueriesController.ListScheduledQueries(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch scheduled queries")
+		return
+	}
+	ah.Respond(w, scheduledQueries)
+}
+
+func (ah *APIHandler) GetScheduledQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	scheduledQuery, apiErr := ah.ScheduledQueriesController.GetScheduledQuery(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch scheduled query")
+		return
+	}
+	ah.Respond(w, scheduledQuery)
+}
+
+// parsePostableScheduledQuery parses the request body and resolves the
+// temporality of the metrics of its query once, rather than on every run
+func (ah *APIHandler) parsePostableScheduledQuery(r *http.Request) (
+	*scheduledqueries.PostableScheduledQuery, *model.ApiError,
+) {
+	req := scheduledqueries.PostableScheduledQuery{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if req.Spec.CompositeQuery != nil {
+		err := ah.addTemporality(r.Context(), &v3.QueryRangeParamsV3{CompositeQuery: req.Spec.CompositeQuery})
+		if err != nil {
+			return nil, model.InternalError(err)
+		}
+	}
+	return &req, nil
+}
+
+func (ah *APIHandler) CreateScheduledQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req, apiErr := ah.parsePostableScheduledQuery(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	scheduledQuery, apiErr := ah.ScheduledQueriesController.CreateScheduledQuery(r.Context(), req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, scheduledQuery)
+}
+
+func (ah *APIHandler) UpdateScheduledQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req, apiErr := ah.parsePostableScheduledQuery(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	scheduledQuery, apiErr := ah.ScheduledQueriesController.UpdateScheduledQuery(r.Context(), id, req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, scheduledQuery)
+}
+
+func (ah *APIHandler) DeleteScheduledQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.ScheduledQueriesController.DeleteScheduledQuery(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// RunScheduledQuery runs a scheduled query now, e.g. to backfill its results
+// after creating it
+func (ah *APIHandler) RunScheduledQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	scheduledQuery, apiErr := ah.ScheduledQueriesController.RunScheduledQuery(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, scheduledQuery)
+}
+
+// GetScheduledQueryResults returns the persisted results of a scheduled
+// query between start and end in milliseconds, in the query range format
+func (ah *APIHandler) GetScheduledQueryResults(
+	w http.ResponseWriter, r *http.Request,
+) {
+	start, err := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	if err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("start must be a timestamp in milliseconds")), nil)
+		return
+	}
+	end, err := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+	if err != nil || end < start {
+		RespondError(w, model.BadRequest(fmt.Errorf("end must be a timestamp in milliseconds after start")), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	results, apiErr := ah.ScheduledQueriesController.GetScheduledQueryResults(r.Context(), id, start, end)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, &v3.QueryRangeResponse{Result: results})
+}
+
+// Saved queries
+func (ah *APIHandler) RegisterSavedQueryRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/saved_queries").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}/run", am.ViewAccess(ah.RunSavedQuery),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetSavedQuery),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.UpdateSavedQuery),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteSavedQuery),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListSavedQueries),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateSavedQuery),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListSavedQueries(
+	w http.ResponseWriter, r *http.Request,
+) {
+	savedQueries, apiErr := ah.SavedQueriesController.ListSavedQueries(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch saved queries")
+		return
+	}
+	ah.Respond(w, savedQueries)
+}
+
+func (ah *APIHandler) GetSavedQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	savedQuery, apiErr := ah.SavedQueriesController.GetSavedQuery(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch saved query")
+		return
+	}
+	ah.Respond(w, savedQuery)
+}
+
+func (ah *APIHandler) CreateSavedQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := savedqueries.PostableSavedQuery{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	savedQuery, apiErr := ah.SavedQueriesController.CreateSavedQuery(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, savedQuery)
+}
+
+func (ah *APIHandler) UpdateSavedQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := savedqueries.PostableSavedQuery{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	savedQuery, apiErr := ah.SavedQueriesController.UpdateSavedQuery(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, savedQuery)
+}
+
+func (ah *APIHandler) DeleteSavedQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.SavedQueriesController.DeleteSavedQuery(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// RunSavedQuery runs a saved query with the parameter values of the request
+// and responds like the query range API
+func (ah *APIHandler) RunSavedQuery(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := savedqueries.RunSavedQueryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	params, apiErr := ah.SavedQueriesController.QueryRangeParams(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// the params go through the query range parsing so that the parameters
+	// are substituted in the queries the same way as dashboard variables
+	body, err := json.Marshal(params)
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	queryRangeParams, apiErr := ParseQueryRangeParams(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if err := ah.addTemporality(r.Context(), queryRangeParams); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+
+	ah.queryRangeV3(queryContext(r), queryRangeParams, w, r)
+}
+
+// Deletion of telemetry matching a selector
+func (ah *APIHandler) RegisterDataDeletionRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/data_deletions").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetDataDeletion),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListDataDeletions),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateDataDeletion),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListDataDeletions(
+	w http.ResponseWriter, r *http.Request,
+) {
+	deletions, apiErr := ah.DataDeletionController.ListDataDeletions(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch data deletions")
+		return
+	}
+	ah.Respond(w, deletions)
+}
+
+// GetDataDeletion returns a data deletion with the progress of its
+// mutations while it is running
+func (ah *APIHandler) GetDataDeletion(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	deletion, apiErr := ah.DataDeletionController.GetDataDeletion(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch data deletion")
+		return
+	}
+	ah.Respond(w, deletion)
+}
+
+func (ah *APIHandler) CreateDataDeletion(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := datadeletion.PostableDataDeletion{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	deletion, apiErr := ah.DataDeletionController.CreateDataDeletion(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, deletion)
+}
+
+// Load test runs, shown as annotations and compared with the service's
+// metrics before them
+func (ah *APIHandler) RegisterLoadTestRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/loadtests").Subrouter()
+
+	subRouter.HandleFunc(
+		"/annotations", am.ViewAccess(ah.ListLoadTestAnnotations),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}/report", am.ViewAccess(ah.GetLoadTestReport),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}/finish", am.EditAccess(ah.FinishLoadTestRun),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.ViewAccess(ah.GetLoadTestRun),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.EditAccess(ah.DeleteLoadTestRun),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.ViewAccess(ah.ListLoadTestRuns),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.EditAccess(ah.CreateLoadTestRun),
+	).Methods(http.MethodPost)
+}
+
+// parseLoadTestsListParams parses the service and the start and end in
+// nanoseconds of the runs to list
+func parseLoadTestsListParams(r *http.Request) (string, *time.Time, *time.Time, error) {
+	start, err := parseTime("start", r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	end, err := parseTime("end", r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if end.Before(*start) {
+		return "", nil, nil, fmt.Errorf("end must not be before start")
+	}
+	return r.URL.Query().Get("service"), start, end, nil
+}
+
+func (ah *APIHandler) ListLoadTestRuns(
+	w http.ResponseWriter, r *http.Request,
+) {
+	service, start, end, err := parseLoadTestsListParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	runs, apiErr := ah.LoadTestsController.ListRuns(r.Context(), service, *start, *end)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch load test runs")
+		return
+	}
+	ah.Respond(w, runs)
+}
+
+func (ah *APIHandler) ListLoadTestAnnotations(
+	w http.ResponseWriter, r *http.Request,
+) {
+	service, start, end, err := parseLoadTestsListParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	annotations, apiErr := ah.LoadTestsController.ListAnnotations(r.Context(), service, *start, *end)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch load test annotations")
+		return
+	}
+	ah.Respond(w, annotations)
+}
+
+func (ah *APIHandler) GetLoadTestRun(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	run, apiErr := ah.LoadTestsController.GetRun(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch load test run")
+		return
+	}
+	ah.Respond(w, run)
+}
+
+func (ah *APIHandler) CreateLoadTestRun(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := loadtests.PostableRun{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	run, apiErr := ah.LoadTestsController.CreateRun(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, run)
+}
+
+func (ah *APIHandler) FinishLoadTestRun(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := loadtests.PostableRunEnd{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	run, apiErr := ah.LoadTestsController.FinishRun(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, run)
+}
+
+func (ah *APIHandler) DeleteLoadTestRun(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.LoadTestsController.DeleteRun(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// GetLoadTestReport compares the service metrics before, during and after
+// a finished load test run
+func (ah *APIHandler) GetLoadTestReport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	report, apiErr := ah.LoadTestsController.GetReport(r.Context(), id, ah.skipConfig)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to get load test report")
+		return
+	}
+	ah.Respond(w, report)
+}
+
+// Metric exports to prometheus remote_write endpoints
+func (ah *APIHandler) RegisterMetricExportRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/metric_exports").Subrouter()
+
+	subRouter.HandleFunc(
+		"/{id}/run", am.AdminAccess(ah.RunMetricExport),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.GetMetricExport),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.UpdateMetricExport),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/{id}", am.AdminAccess(ah.DeleteMetricExport),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.ListMetricExports),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"", am.AdminAccess(ah.CreateMetricExport),
+	).Methods(http.MethodPost)
+}
+
+func (ah *APIHandler) ListMetricExports(
+	w http.ResponseWriter, r *http.Request,
+) {
+	metricExports, apiErr := ah.MetricExportsController.ListMetricExports(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch metric exports")
+		return
+	}
+	ah.Respond(w, metricExports)
+}
+
+func (ah *APIHandler) GetMetricExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	metricExport, apiErr := ah.MetricExportsController.GetMetricExport(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch metric export")
+		return
+	}
+	ah.Respond(w, metricExport)
+}
+
+func (ah *APIHandler) CreateMetricExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := metricexports.PostableMetricExport{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	metricExport, apiErr := ah.MetricExportsController.CreateMetricExport(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, metricExport)
+}
+
+func (ah *APIHandler) UpdateMetricExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := metricexports.PostableMetricExport{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	metricExport, apiErr := ah.MetricExportsController.UpdateMetricExport(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, metricExport)
+}
+
+func (ah *APIHandler) DeleteMetricExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.MetricExportsController.DeleteMetricExport(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// RunMetricExport exports the last interval now, e.g. to check the
+// endpoint and credentials of a new export
+func (ah *APIHandler) RunMetricExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	metricExport, apiErr := ah.MetricExportsController.RunMetricExport(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, metricExport)
+}
+
+// Agent config templates and the agents they are deployed to
+func (ah *APIHandler) RegisterAgentConfigRoutes(router *mux.Router, am *AuthMiddleware) {
+	agentsRouter := router.PathPrefix("/api/v1/agents").Subrouter()
+
+	agentsRouter.HandleFunc("", am.ViewAccess(ah.ListAgents)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/stale_alert", am.ViewAccess(ah.GetStaleAgentAlertSettings)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/stale_alert", am.AdminAccess(ah.SetStaleAgentAlertSettings)).Methods(http.MethodPut)
+	agentsRouter.HandleFunc("/drift_alert", am.ViewAccess(ah.GetConfigDriftAlertSettings)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/drift_alert", am.AdminAccess(ah.SetConfigDriftAlertSettings)).Methods(http.MethodPut)
+	agentsRouter.HandleFunc("/packages", am.ViewAccess(ah.ListPackageOffers)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/packages", am.AdminAccess(ah.SetPackageOffer)).Methods(http.MethodPut)
+	agentsRouter.HandleFunc("/packages", am.AdminAccess(ah.RemovePackageOffer)).Methods(http.MethodDelete)
+	agentsRouter.HandleFunc("/packages/status", am.ViewAccess(ah.ListPackageStatuses)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/drift", am.ViewAccess(ah.ListAgentConfigDrifts)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/{id}", am.ViewAccess(ah.GetAgent)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/{id}/drift", am.ViewAccess(ah.GetAgentConfigDrift)).Methods(http.MethodGet)
+	agentsRouter.HandleFunc("/{id}/deploy_reports", am.ViewAccess(ah.ListAgentDeployReports)).Methods(http.MethodGet)
+
+	subRouter := router.PathPrefix("/api/v1/agentConfig").Subrouter()
+
+	subRouter.HandleFunc(
+		"/templates", am.ViewAccess(ah.ListAgentConfigTemplates),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/rollout_notifications", am.ViewAccess(ah.GetRolloutNotificationSettings),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/rollout_notifications", am.AdminAccess(ah.SetRolloutNotificationSettings),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/rollout_strategy", am.ViewAccess(ah.GetRolloutStrategy),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/rollout_strategy", am.AdminAccess(ah.SetRolloutStrategy),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/staged_rollouts/{elementType}/{version}", am.ViewAccess(ah.GetStagedRollout),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/staged_rollouts/{elementType}/{version}/promote", am.AdminAccess(ah.PromoteStagedRollout),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/staged_rollouts/{elementType}/{version}/rollback", am.AdminAccess(ah.RollbackStagedRollout),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/groups", am.ViewAccess(ah.ListAgentGroupTemplates),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/groups/{group}/template", am.AdminAccess(ah.SetAgentGroupTemplate),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/groups/{group}/template", am.AdminAccess(ah.RemoveAgentGroupTemplate),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/agent_groups", am.ViewAccess(ah.ListAgentGroups),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/agent_groups", am.AdminAccess(ah.CreateAgentGroup),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/agent_groups/{id}", am.ViewAccess(ah.GetAgentGroup),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/agent_groups/{id}", am.AdminAccess(ah.UpdateAgentGroup),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/agent_groups/{id}", am.AdminAccess(ah.DeleteAgentGroup),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/agent_groups/{id}/agents", am.ViewAccess(ah.ListAgentGroupAgents),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/overrides", am.ViewAccess(ah.ListAgentConfigOverrides),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/overrides", am.AdminAccess(ah.CreateAgentConfigOverride),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/overrides/{id}", am.AdminAccess(ah.UpdateAgentConfigOverride),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/overrides/{id}", am.AdminAccess(ah.DeleteAgentConfigOverride),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/delivery_profiles/{id}", am.AdminAccess(ah.GetDeliveryProfile),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/delivery_profiles/{id}", am.AdminAccess(ah.UpdateDeliveryProfile),
+	).Methods(http.MethodPut)
+
+	subRouter.HandleFunc(
+		"/delivery_profiles/{id}", am.AdminAccess(ah.DeleteDeliveryProfile),
+	).Methods(http.MethodDelete)
+
+	subRouter.HandleFunc(
+		"/delivery_profiles", am.AdminAccess(ah.ListDeliveryProfiles),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/delivery_profiles", am.AdminAccess(ah.CreateDeliveryProfile),
+	).Methods(http.MethodPost)
+
+	subRouter.HandleFunc(
+		"/clickhouse_insert_settings", am.AdminAccess(ah.GetInsertSettings),
+	).Methods(http.MethodGet)
+
+	subRouter.HandleFunc(
+		"/clickhouse_insert_settings", am.AdminAccess(ah.SetInsertSettings),
+	).Methods(http.MethodPut)
+}
+
+// ListAgents returns the connected agents and those seen within
+// lookback_seconds, a day by default, optionally filtered by status
+func (ah *APIHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
+	lookbackSeconds := int64(24 * 60 * 60)
+	if lookbackStr := r.URL.Query().Get("lookback_seconds"); lookbackStr != "" {
+		var err error
+		lookbackSeconds, err = strconv.ParseInt(lookbackStr, 10, 64)
+		if err != nil || lookbackSeconds < 0 {
+			RespondError(w, model.BadRequestStr("lookback_seconds must be a non negative number of seconds"), nil)
+			return
+		}
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != opAmpModel.AgentStatusConnected.String() &&
+		status != opAmpModel.AgentStatusDisconnected.String() {
+		RespondError(w, model.BadRequestStr("status must be one of connected, disconnected"), nil)
+		return
+	}
+
+	seenSince := time.Now().Add(-time.Duration(lookbackSeconds) * time.Second)
+	agents, err := opAmpModel.AllAgents.ListAgents(seenSince)
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+
+	if status != "" {
+		filtered := []opAmpModel.AgentView{}
+		for _, agent := range agents {
+			if agent.Status == status {
+				filtered = append(filtered, agent)
+			}
+		}
+		agents = filtered
+	}
+	ah.Respond(w, agents)
+}
+
+func (ah *APIHandler) GetAgent(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	agent, err := opAmpModel.AllAgents.GetAgentView(id)
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	if agent == nil {
+		RespondError(w, model.NotFoundError(fmt.Errorf("agent %s not found", id)), nil)
+		return
+	}
+	ah.Respond(w, agent)
+}
+
+// ListAgentConfigDrifts returns the difference between the recommended and
+// the effective config of every connected agent
+func (ah *APIHandler) ListAgentConfigDrifts(w http.ResponseWriter, r *http.Request) {
+	ah.Respond(w, opAmpModel.AllAgents.ConfigDrifts())
+}
+
+func (ah *APIHandler) GetAgentConfigDrift(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	drift := opAmpModel.AllAgents.ConfigDrift(id)
+	if drift == nil {
+		RespondError(w, model.NotFoundError(fmt.Errorf("agent %s is not connected", id)), nil)
+		return
+	}
+	ah.Respond(w, drift)
+}
+
+// ListAgentDeployReports returns what an agent reported about applying the
+// config versions it was sent, with the error messages of the failed ones
+func (ah *APIHandler) ListAgentDeployReports(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			RespondError(w, model.BadRequest(fmt.Errorf("limit must be between 1 and 1000")), nil)
+			return
+		}
+		limit = parsed
+	}
+
+	id := mux.Vars(r)["id"]
+	reports, apiErr := agentConf.GetAgentDeployReports(r.Context(), id, limit)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, reports)
+}
+
+func (ah *APIHandler) GetStaleAgentAlertSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := opAmpModel.GetStaleAgentAlertSettings(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) SetStaleAgentAlertSettings(w http.ResponseWriter, r *http.Request) {
+	req := opAmpModel.StaleAgentAlertSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.Severity != "" && !ah.isKnownSeverity(req.Severity) {
+		RespondError(w, model.BadRequest(fmt.Errorf("unknown severity %q", req.Severity)), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	if err := opAmpModel.SaveStaleAgentAlertSettings(r.Context(), userId, &req); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, req)
+}
+
+func (ah *APIHandler) GetConfigDriftAlertSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := opAmpModel.GetConfigDriftAlertSettings(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) SetConfigDriftAlertSettings(w http.ResponseWriter, r *http.Request) {
+	req := opAmpModel.ConfigDriftAlertSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.Severity != "" && !ah.isKnownSeverity(req.Severity) {
+		RespondError(w, model.BadRequest(fmt.Errorf("unknown severity %q", req.Severity)), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	if err := opAmpModel.SaveConfigDriftAlertSettings(r.Context(), userId, &req); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, req)
+}
+
+func (ah *APIHandler) GetRolloutNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := agentConf.GetRolloutNotificationSettings(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) SetRolloutNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	req := agentConf.RolloutNotificationSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.Severity != "" && !ah.isKnownSeverity(req.Severity) {
+		RespondError(w, model.BadRequest(fmt.Errorf("unknown severity %q", req.Severity)), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	if err := agentConf.SaveRolloutNotificationSettings(r.Context(), userId, &req); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, req)
+}
+
+func (ah *APIHandler) GetRolloutStrategy(w http.ResponseWriter, r *http.Request) {
+	strategy, err := agentConf.GetRolloutStrategy(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, strategy)
+}
+
+func (ah *APIHandler) SetRolloutStrategy(w http.ResponseWriter, r *http.Request) {
+	req := agentConf.RolloutStrategy{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	if err := agentConf.SaveRolloutStrategy(r.Context(), userId, &req); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, req)
+}
+
+// parseStagedRolloutParams parses the element type and version of the
+// staged rollout in the path
+func parseStagedRolloutParams(r *http.Request) (agentConf.ElementTypeDef, int, error) {
+	vars := mux.Vars(r)
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("version must be a positive integer")
+	}
+	return agentConf.ElementTypeDef(vars["elementType"]), version, nil
+}
+
+// GetStagedRollout returns the staged rollout of a config version with the
+// canary agents it was recommended to and what they reported
+func (ah *APIHandler) GetStagedRollout(w http.ResponseWriter, r *http.Request) {
+	elementType, version, err := parseStagedRolloutParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	rollout, apiErr := agentConf.GetStagedRollout(r.Context(), elementType, version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, rollout)
+}
+
+func (ah *APIHandler) PromoteStagedRollout(w http.ResponseWriter, r *http.Request) {
+	ah.finishStagedRollout(w, r, agentConf.PromoteStagedRollout)
+}
+
+func (ah *APIHandler) RollbackStagedRollout(w http.ResponseWriter, r *http.Request) {
+	ah.finishStagedRollout(w, r, agentConf.RollbackStagedRollout)
+}
+
+func (ah *APIHandler) finishStagedRollout(
+	w http.ResponseWriter, r *http.Request,
+	finish func(context.Context, string, agentConf.ElementTypeDef, int) (*agentConf.StagedRollout, *model.ApiError),
+) {
+	elementType, version, err := parseStagedRolloutParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	rollout, apiErr := finish(r.Context(), userId, elementType, version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, rollout)
+}
+
+func (ah *APIHandler) isKnownSeverity(severity string) bool {
+	for _, level := range ah.ruleManager.GetSeverityLevels() {
+		if level.Name == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func (ah *APIHandler) ListPackageOffers(w http.ResponseWriter, r *http.Request) {
+	offers, err := opAmpModel.GetPackageOffers(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, offers)
+}
+
+// SetPackageOffer offers a package, the collector itself if its name is
+// empty, to the agents of a group or all agents and sends it to the
+// connected ones accepting packages
+func (ah *APIHandler) SetPackageOffer(w http.ResponseWriter, r *http.Request) {
+	req := opAmpModel.PackageOffer{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+	if err := opAmpModel.SavePackageOffer(r.Context(), userId, &req); err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	opAmpModel.AllAgents.OfferPackagesToAll()
+	ah.Respond(w, req)
+}
+
+// RemovePackageOffer stops offering the package with the name and group in
+// the query, agents of the group fall back to the offer for all agents
+func (ah *APIHandler) RemovePackageOffer(w http.ResponseWriter, r *http.Request) {
+	name, group := r.URL.Query().Get("name"), r.URL.Query().Get("group")
+	found, err := opAmpModel.DeletePackageOffer(r.Context(), name, group)
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	if !found {
+		RespondError(w, model.NotFoundError(fmt.Errorf("package %q is not offered to group %q", name, group)), nil)
+		return
+	}
+	opAmpModel.AllAgents.OfferPackagesToAll()
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// ListPackageStatuses returns the package statuses reported by the agents,
+// filtered by the name and agentId in the query
+func (ah *APIHandler) ListPackageStatuses(w http.ResponseWriter, r *http.Request) {
+	var name *string
+	if r.URL.Query().Has("name") {
+		n := r.URL.Query().Get("name")
+		name = &n
+	}
+	statuses, err := opAmpModel.ListPackageStatuses(r.Context(), name, r.URL.Query().Get("agentId"))
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	ah.Respond(w, statuses)
+}
+
+func (ah *APIHandler) ListAgentConfigTemplates(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ah.Respond(w, agentConf.ListConfigTemplates())
+}
+
+func (ah *APIHandler) ListAgentGroupTemplates(
+	w http.ResponseWriter, r *http.Request,
+) {
+	groupTemplates, apiErr := agentConf.ListAgentGroupTemplates(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch agent group templates")
+		return
+	}
+	ah.Respond(w, groupTemplates)
+}
+
+func (ah *APIHandler) SetAgentGroupTemplate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := struct {
+		TemplateName string `json:"templateName"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	group := mux.Vars(r)["group"]
+	groupTemplate, apiErr := agentConf.SetAgentGroupTemplate(r.Context(), group, req.TemplateName)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, groupTemplate)
+}
+
+func (ah *APIHandler) RemoveAgentGroupTemplate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	group := mux.Vars(r)["group"]
+	if apiErr := agentConf.RemoveAgentGroupTemplate(r.Context(), group); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+func (ah *APIHandler) ListAgentConfigOverrides(
+	w http.ResponseWriter, r *http.Request,
+) {
+	overrides, apiErr := agentConf.ListAgentConfigOverrides(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch agent config overrides")
+		return
+	}
+	ah.Respond(w, overrides)
+}
+
+func (ah *APIHandler) CreateAgentConfigOverride(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := agentConf.PostableAgentConfigOverride{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	override, apiErr := agentConf.CreateAgentConfigOverride(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, override)
+}
+
+func (ah *APIHandler) UpdateAgentConfigOverride(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := agentConf.PostableAgentConfigOverride{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	override, apiErr := agentConf.UpdateAgentConfigOverride(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, override)
+}
+
+func (ah *APIHandler) DeleteAgentConfigOverride(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := agentConf.DeleteAgentConfigOverride(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+func (ah *APIHandler) ListAgentGroups(
+	w http.ResponseWriter, r *http.Request,
+) {
+	groups, apiErr := agentConf.ListAgentGroups(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, groups)
+}
+
+func (ah *APIHandler) GetAgentGroup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	group, apiErr := agentConf.GetAgentGroup(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, group)
+}
+
+func (ah *APIHandler) CreateAgentGroup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := agentConf.PostableAgentGroup{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	group, apiErr := agentConf.CreateAgentGroup(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, group)
+}
+
+func (ah *APIHandler) UpdateAgentGroup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := agentConf.PostableAgentGroup{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	group, apiErr := agentConf.UpdateAgentGroup(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, group)
+}
+
+func (ah *APIHandler) DeleteAgentGroup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := agentConf.DeleteAgentGroup(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// ListAgentGroupAgents returns the ids of the connected agents in a group
+func (ah *APIHandler) ListAgentGroupAgents(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	agentIds, apiErr := agentConf.GetAgentGroupAgents(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, agentIds)
+}
+
+func (ah *APIHandler) ListDeliveryProfiles(
+	w http.ResponseWriter, r *http.Request,
+) {
+	resp, apiErr := ah.DeliveryProfilesController.ListDeliveryProfiles(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch delivery profiles")
+		return
+	}
+	ah.Respond(w, resp)
+}
+
+func (ah *APIHandler) GetDeliveryProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	deliveryProfile, apiErr := ah.DeliveryProfilesController.GetDeliveryProfile(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch delivery profile")
+		return
+	}
+	ah.Respond(w, deliveryProfile)
+}
+
+func (ah *APIHandler) GetInsertSettings(
+	w http.ResponseWriter, r *http.Request,
+) {
+	settings, apiErr := ah.InsertSettingsController.GetInsertSettings(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) SetInsertSettings(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := insertsettings.PostableInsertSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	settings, apiErr := ah.InsertSettingsController.SetInsertSettings(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) CreateDeliveryProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := deliveryprofiles.PostableDeliveryProfile{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	deliveryProfile, apiErr := ah.DeliveryProfilesController.CreateDeliveryProfile(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, deliveryProfile)
+}
+
+func (ah *APIHandler) UpdateDeliveryProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	req := deliveryprofiles.PostableDeliveryProfile{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	deliveryProfile, apiErr := ah.DeliveryProfilesController.UpdateDeliveryProfile(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, deliveryProfile)
+}
+
+func (ah *APIHandler) DeleteDeliveryProfile(
+	w http.ResponseWriter, r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+	if apiErr := ah.DeliveryProfilesController.DeleteDeliveryProfile(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, map[string]interface{}{})
+}
+
+// logs
+func (aH *APIHandler) RegisterLogsRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/v1/logs").Subrouter()
+	subRouter.HandleFunc("", am.ViewAccess(aH.getLogs)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/tail", am.ViewAccess(aH.tailLogs)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/fields", am.ViewAccess(aH.logFields)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/fields", am.EditAccess(aH.logFieldUpdate)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/aggregate", am.ViewAccess(aH.logAggregate)).Methods(http.MethodGet)
+
+	// log pipelines
+	subRouter.HandleFunc("/pipelines/preview", am.ViewAccess(aH.PreviewLogsPipelinesHandler)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/estimate", am.ViewAccess(aH.estimateLogsPipelinesCost)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/timestamp_layouts", am.ViewAccess(aH.suggestTimestampLayouts)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/rollback/{version}", am.EditAccess(aH.rollbackLogsPipelines)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/diff", am.ViewAccess(aH.diffLogsPipelines)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/{version}", am.ViewAccess(withETag(aH.ListLogsPipelinesHandler))).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/{id}/clone", am.EditAccess(aH.cloneLogsPipeline)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.CreateLogsPipeline)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.patchLogsPipelines)).Methods(http.MethodPatch)
+	subRouter.HandleFunc("/pipeline_variables", am.ViewAccess(aH.listPipelineVariables)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipeline_variables", am.EditAccess(aH.setPipelineVariable)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipeline_variables/{name}", am.EditAccess(aH.deletePipelineVariable)).Methods(http.MethodDelete)
+	subRouter.HandleFunc("/json_schemas", am.ViewAccess(aH.listLogsJSONSchemas)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/json_schemas", am.EditAccess(aH.setLogsJSONSchema)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/json_schemas/{name}", am.EditAccess(aH.deleteLogsJSONSchema)).Methods(http.MethodDelete)
+	subRouter.HandleFunc("/pipeline_watchdog", am.ViewAccess(aH.getPipelineWatchdogSettings)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipeline_watchdog", am.AdminAccess(aH.setPipelineWatchdogSettings)).Methods(http.MethodPut)
+	subRouter.HandleFunc("/pipeline_watchdog/events", am.ViewAccess(aH.listPipelineWatchdogEvents)).Methods(http.MethodGet)
+}
+
+func (aH *APIHandler) logFields(w http.ResponseWriter, r *http.Request) {
+	fields, apiErr := aH.reader.GetLogFields(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch fields from the DB")
+		return
+	}
+	aH.WriteJSON(w, r, fields)
+}
+
+func (aH *APIHandler) logFieldUpdate(w http.ResponseWriter, r *http.Request) {
+	field := model.UpdateField{}
+	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		RespondError(w, apiErr, "Failed to decode payload")
+		return
+	}
+
+	err := logs.ValidateUpdateFieldPayload(&field)
+	if err != nil {
+		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		RespondError(w, apiErr, "Incorrect payload")
+		return
+	}
+
+	apiErr := aH.reader.UpdateLogField(r.Context(), &field)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to update filed in the DB")
+		return
+	}
+	aH.WriteJSON(w, r, field)
+}
+
+func (aH *APIHandler) getLogs(w http.ResponseWriter, r *http.Request) {
+	params, err := logs.ParseLogFilterParams(r)
+	if err != nil {
+		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		RespondError(w, apiErr, "Incorrect params")
+		return
+	}
+	res, apiErr := aH.reader.GetLogs(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch logs from the DB")
+		return
+	}
+	aH.WriteJSON(w, r, map[string]interface{}{"results": res})
+}
+
+func (aH *APIHandler) tailLogs(w http.ResponseWriter, r *http.Request) {
+	params, err := logs.ParseLogFilterParams(r)
+	if err != nil {
+		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		RespondError(w, apiErr, "Incorrect params")
+		return
+	}
+
+	// create the client
 	client := &model.LogsTailClient{Name: r.RemoteAddr, Logs: make(chan *model.SignozLog, 1000), Done: make(chan *bool), Error: make(chan error), Filter: *params}
 	go aH.reader.TailLogs(r.Context(), client)
 
@@ -2758,6 +6286,42 @@ func (ah *APIHandler) PreviewLogsPipelinesHandler(w http.ResponseWriter, r *http
 	ah.Respond(w, resultLogs)
 }
 
+// suggestTimestampLayouts suggests time parser layouts for sample timestamps
+func (ah *APIHandler) suggestTimestampLayouts(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.TimestampLayoutSuggestionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	suggestions, err := logparsingpipeline.SuggestTimestampLayouts(req.Samples)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	ah.Respond(w, suggestions)
+}
+
+// estimateLogsPipelinesCost projects the collector cpu used by pipelines
+// before they are deployed
+func (ah *APIHandler) estimateLogsPipelinesCost(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.PipelinesCostEstimateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	estimate, apiErr := ah.LogsParsingPipelineController.EstimatePipelinesCost(
+		r.Context(), ah.querier, req.Pipelines,
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, estimate)
+}
+
 func (ah *APIHandler) ListLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
 
 	version, err := parseAgentConfigVersion(r)
@@ -2782,6 +6346,87 @@ func (ah *APIHandler) ListLogsPipelinesHandler(w http.ResponseWriter, r *http.Re
 	ah.Respond(w, payload)
 }
 
+// rollbackLogsPipelines deploys the pipelines of a previous config version
+// as a new version
+func (ah *APIHandler) rollbackLogsPipelines(w http.ResponseWriter, r *http.Request) {
+	version, err := parseAgentConfigVersion(r)
+	if err != nil {
+		RespondError(w, model.WrapApiError(err, "Failed to parse agent config version"), nil)
+		return
+	}
+	if version == -1 {
+		RespondError(w, model.BadRequestStr("a version number is required to roll back to"), nil)
+		return
+	}
+
+	res, apiErr := ah.LogsParsingPipelineController.RollbackPipelines(r.Context(), version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, res)
+}
+
+// diffLogsPipelines responds with the changes in pipelines between the config
+// versions in the from and to query params
+func (ah *APIHandler) diffLogsPipelines(w http.ResponseWriter, r *http.Request) {
+	versions := map[string]int{}
+	for _, param := range []string{"from", "to"} {
+		version, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf(
+				"%s must be a config version number: %w", param, err,
+			)), nil)
+			return
+		}
+		versions[param] = version
+	}
+
+	res, apiErr := ah.LogsParsingPipelineController.DiffPipelines(
+		r.Context(), versions["from"], versions["to"],
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, res)
+}
+
+// patchLogsPipelines enables, disables or reorders pipelines in a single new
+// config version, without posting all the pipelines
+func (ah *APIHandler) patchLogsPipelines(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.PipelineChanges{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	res, apiErr := ah.LogsParsingPipelineController.PatchPipelines(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, res)
+}
+
+// cloneLogsPipeline copies a pipeline under a new name and alias and deploys
+// it along with the others as a new config version
+func (ah *APIHandler) cloneLogsPipeline(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.PipelineClone{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	res, apiErr := ah.LogsParsingPipelineController.ClonePipeline(r.Context(), id, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, res)
+}
+
 // listLogsPipelines lists logs piplines for latest version
 func (ah *APIHandler) listLogsPipelines(ctx context.Context) (
 	*logparsingpipeline.PipelinesResponse, *model.ApiError,
@@ -2808,6 +6453,17 @@ func (ah *APIHandler) listLogsPipelines(ctx context.Context) (
 		return nil, model.WrapApiError(err, "failed to get config history")
 	}
 	payload.History = history
+
+	// metrics are read from the telemetry store
+	if ah.reader == nil {
+		return payload, nil
+	}
+	metrics, err := ah.LogsParsingPipelineController.GetPipelinesMetrics(ctx, ah.querier, payload.Pipelines)
+	if err != nil {
+		zap.L().Warn("could not get log pipeline metrics", zap.Error(err.ToError()))
+	} else {
+		payload.Metrics = metrics
+	}
 	return payload, nil
 }
 
@@ -2826,44 +6482,180 @@ func (ah *APIHandler) listLogsPipelinesByVersion(ctx context.Context, version in
 	if err != nil {
 		return nil, model.WrapApiError(err, "failed to retrieve agent config history")
 	}
-
-	payload.History = history
-	return payload, nil
+
+	payload.History = history
+	return payload, nil
+}
+
+func (ah *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request) {
+
+	req := logparsingpipeline.PostablePipelines{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	createPipeline := func(
+		ctx context.Context,
+		postable []logparsingpipeline.PostablePipeline,
+	) (*logparsingpipeline.PipelinesResponse, *model.ApiError) {
+		if len(postable) == 0 {
+			zap.S().Warnf("found no pipelines in the http request, this will delete all the pipelines")
+		}
+
+		for _, p := range postable {
+			if err := p.IsValid(); err != nil {
+				return nil, model.BadRequestStr(err.Error())
+			}
+		}
+
+		if ah.QuotasController != nil {
+			apiErr := ah.QuotasController.EnsureWithinQuota(ctx, quotas.ResourcePipelines, len(postable))
+			if apiErr != nil {
+				return nil, apiErr
+			}
+		}
+
+		return ah.LogsParsingPipelineController.ApplyPipelines(ctx, postable, req.ChangeNote)
+	}
+
+	// a dry run returns the collector config that would be deployed, so
+	// that pipeline changes can be validated before reaching any agent
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun")); dryRun {
+		res, apiErr := ah.LogsParsingPipelineController.DryRunPipelines(r.Context(), req.Pipelines)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		ah.Respond(w, res)
+		return
+	}
+
+	res, err := createPipeline(r.Context(), req.Pipelines)
+	if err != nil {
+		RespondError(w, err, nil)
+		return
+	}
+
+	// the pipelines are deployed regardless of the estimate, going over the
+	// budget is only surfaced as a warning
+	if ah.reader != nil {
+		estimate, apiErr := ah.LogsParsingPipelineController.EstimatePipelinesCost(
+			r.Context(), ah.querier, res.Pipelines,
+		)
+		if apiErr != nil {
+			zap.L().Warn("could not estimate cost of log pipelines", zap.Error(apiErr.Err))
+		} else {
+			if estimate.ExceedsBudget {
+				zap.L().Warn("log pipelines are projected to exceed the collector cpu budget",
+					zap.Float64("cores", estimate.TotalCores), zap.Float64("budget", estimate.BudgetCores),
+				)
+			}
+			res.CostEstimate = estimate
+		}
+	}
+
+	ah.Respond(w, res)
+}
+
+func (ah *APIHandler) listPipelineVariables(w http.ResponseWriter, r *http.Request) {
+	variables, apiErr := ah.LogsParsingPipelineController.ListPipelineVariables(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, variables)
+}
+
+func (ah *APIHandler) setPipelineVariable(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.PostablePipelineVariable{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	variable, apiErr := ah.LogsParsingPipelineController.SetPipelineVariable(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, variable)
+}
+
+func (ah *APIHandler) deletePipelineVariable(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if apiErr := ah.LogsParsingPipelineController.DeletePipelineVariable(r.Context(), name); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, nil)
+}
+
+func (ah *APIHandler) getPipelineWatchdogSettings(w http.ResponseWriter, r *http.Request) {
+	settings, apiErr := ah.LogsParsingPipelineController.GetWatchdogSettings(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, settings)
+}
+
+func (ah *APIHandler) setPipelineWatchdogSettings(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.WatchdogSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	settings, apiErr := ah.LogsParsingPipelineController.SetWatchdogSettings(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, settings)
 }
 
-func (ah *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request) {
+func (ah *APIHandler) listPipelineWatchdogEvents(w http.ResponseWriter, r *http.Request) {
+	events, apiErr := ah.LogsParsingPipelineController.ListWatchdogEvents(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, events)
+}
 
-	req := logparsingpipeline.PostablePipelines{}
+func (ah *APIHandler) listLogsJSONSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas, apiErr := ah.LogsParsingPipelineController.ListJSONSchemas(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, schemas)
+}
 
+func (ah *APIHandler) setLogsJSONSchema(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.PostableJSONSchema{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
 
-	createPipeline := func(
-		ctx context.Context,
-		postable []logparsingpipeline.PostablePipeline,
-	) (*logparsingpipeline.PipelinesResponse, *model.ApiError) {
-		if len(postable) == 0 {
-			zap.S().Warnf("found no pipelines in the http request, this will delete all the pipelines")
-		}
-
-		for _, p := range postable {
-			if err := p.IsValid(); err != nil {
-				return nil, model.BadRequestStr(err.Error())
-			}
-		}
-
-		return ah.LogsParsingPipelineController.ApplyPipelines(ctx, postable)
+	schema, apiErr := ah.LogsParsingPipelineController.SetJSONSchema(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
 	}
+	ah.Respond(w, schema)
+}
 
-	res, err := createPipeline(r.Context(), req.Pipelines)
-	if err != nil {
-		RespondError(w, err, nil)
+func (ah *APIHandler) deleteLogsJSONSchema(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if apiErr := ah.LogsParsingPipelineController.DeleteJSONSchema(r.Context(), name); apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
-
-	ah.Respond(w, res)
+	ah.Respond(w, nil)
 }
 
 func (aH *APIHandler) getSavedViews(w http.ResponseWriter, r *http.Request) {
@@ -2892,6 +6684,10 @@ func (aH *APIHandler) createSavedViews(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
+	if apiErr := aH.ensureCanCreate(r.Context(), quotas.ResourceSavedViews); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	uuid, err := explorer.CreateView(r.Context(), view)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
@@ -2912,6 +6708,23 @@ func (aH *APIHandler) getSavedView(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, view)
 }
 
+// recordSavedViewAccess records that a saved view was opened, the saved
+// view GETs don't so that reading a view doesn't write
+func (aH *APIHandler) recordSavedViewAccess(w http.ResponseWriter, r *http.Request) {
+	viewID := mux.Vars(r)["viewId"]
+
+	if _, err := explorer.GetView(viewID); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if err := explorer.RecordViewAccess(viewID); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) updateSavedView(w http.ResponseWriter, r *http.Request) {
 	viewID := mux.Vars(r)["viewId"]
 	var view v3.SavedView
@@ -3002,6 +6815,10 @@ func (aH *APIHandler) autoCompleteAttributeKeys(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if aH.KeyUsageController != nil && response != nil {
+		response.AttributeKeys = aH.KeyUsageController.RankKeys(r.Context(), req, response.AttributeKeys)
+	}
+
 	aH.Respond(w, response)
 }
 
@@ -3031,9 +6848,54 @@ func (aH *APIHandler) autoCompleteAttributeValues(w http.ResponseWriter, r *http
 		return
 	}
 
+	if aH.KeyUsageController != nil && response != nil {
+		response.StringAttributeValues = aH.KeyUsageController.RankValues(r.Context(), req, response.StringAttributeValues)
+	}
+
 	aH.Respond(w, response)
 }
 
+// autocompleteKeyUsage lists the attribute keys of a data source most used
+// in the queries of the org, or the most used values of a key
+func (aH *APIHandler) autocompleteKeyUsage(w http.ResponseWriter, r *http.Request) {
+	dataSource := v3.DataSource(r.URL.Query().Get("dataSource"))
+	if err := dataSource.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	limit := 50
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 {
+			RespondError(w, model.BadRequestStr("limit must be a positive number"), nil)
+			return
+		}
+	}
+
+	if aH.KeyUsageController == nil {
+		aH.Respond(w, []keyusage.KeyUsage{})
+		return
+	}
+	usages, apiErr := aH.KeyUsageController.GetUsage(
+		r.Context(), dataSource, r.URL.Query().Get("key"), limit,
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, usages)
+}
+
+// recordKeyUsage counts the attribute keys and values used by a query, to
+// rank autocomplete suggestions
+func (aH *APIHandler) recordKeyUsage(ctx context.Context, params *v3.QueryRangeParamsV3) {
+	if aH.KeyUsageController != nil {
+		aH.KeyUsageController.RecordQuery(ctx, params)
+	}
+}
+
 func (aH *APIHandler) execClickHouseGraphQueries(ctx context.Context, queries map[string]string) ([]*v3.Result, error, map[string]string) {
 	type channelResult struct {
 		Series []*v3.Series
@@ -3293,40 +7155,54 @@ func (aH *APIHandler) QueryRangeV3Format(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, queryRangeParams)
 }
 
-func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
-
+// execQueryRangeV3 runs the queries of a composite query, the errors of the
+// failed queries are returned along with the results of the others when
+// partial results are allowed
+func (aH *APIHandler) execQueryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) (
+	[]*v3.Result, []v3.QueryError, map[string]string, *model.ApiError,
+) {
 	var result []*v3.Result
 	var err error
 	var errQuriesByName map[string]string
 	var spanKeys map[string]v3.AttributeKey
+	aH.recordKeyUsage(ctx, queryRangeParams)
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		var apiErr *model.ApiError
+		features := schemaFeaturesOfQuery(queryRangeParams, false)
+		if apiErr = aH.reader.CheckSchemaCompatibility(ctx, features); apiErr != nil {
+			return nil, nil, errQuriesByName, apiErr
+		}
+
+		queryRangeParams, apiErr = aH.FilterSnippetsController.ExpandFilterSnippets(ctx, queryRangeParams)
+		if apiErr != nil {
+			return nil, nil, errQuriesByName, apiErr
+		}
+
 		// check if any enrichment is required for logs if yes then enrich them
 		if logsv3.EnrichmentRequired(queryRangeParams) {
 			// get the fields if any logs query is present
 			var fields map[string]v3.AttributeKey
 			fields, err = aH.getLogFieldsV3(ctx, queryRangeParams)
 			if err != nil {
-				apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-				RespondError(w, apiErrObj, errQuriesByName)
-				return
+				return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 			}
 			logsv3.Enrich(queryRangeParams, fields)
 		}
 
 		spanKeys, err = aH.getSpanKeysV3(ctx, queryRangeParams)
 		if err != nil {
-			apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-			RespondError(w, apiErrObj, errQuriesByName)
-			return
+			return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 		}
 	}
 
 	result, err, errQuriesByName = aH.querier.QueryRange(ctx, queryRangeParams, spanKeys)
 
+	var queryErrors []v3.QueryError
 	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErrObj, errQuriesByName)
-		return
+		if !queryRangeParams.AllowPartial || len(result) == 0 || len(errQuriesByName) == 0 {
+			return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		queryErrors = partialQueryErrors(errQuriesByName)
 	}
 
 	applyMetricLimit(result, queryRangeParams)
@@ -3337,8 +7213,59 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 		applyFunctions(result, queryRangeParams)
 	}
 
+	return result, queryErrors, errQuriesByName, nil
+}
+
+// execAnnotatedQueryRangeV3 runs the queries of a composite query and
+// annotates their results with the metric owners and trace links
+func (aH *APIHandler) execAnnotatedQueryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) (
+	[]*v3.Result, []v3.QueryError, map[string]string, *model.ApiError,
+) {
+	result, queryErrors, errQuriesByName, apiErr := aH.execQueryRangeV3(ctx, queryRangeParams)
+	if apiErr != nil {
+		return nil, nil, errQuriesByName, apiErr
+	}
+
+	if aH.MetricOwnersController != nil {
+		aH.MetricOwnersController.AnnotateResults(ctx, queryRangeParams, result)
+	}
+
+	// the links are marked after obfuscating so that they only point to the
+	// trace ids left in the results
+	result, apiErr = aH.obfuscateResults(ctx, queryRangeParams, result)
+	if apiErr != nil {
+		return nil, nil, errQuriesByName, apiErr
+	}
+	aH.markLogTraceLinks(ctx, queryRangeParams, result)
+	return result, queryErrors, errQuriesByName, nil
+}
+
+func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
+
+	if queryRangeParams.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(queryRangeParams.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	result, queryErrors, errQuriesByName, apiErr := aH.execAnnotatedQueryRangeV3(ctx, queryRangeParams)
+	if apiErr != nil {
+		RespondError(w, apiErr, errQuriesByName)
+		return
+	}
+
 	resp := v3.QueryRangeResponse{
 		Result: result,
+		Errors: queryErrors,
+	}
+
+	if len(queryRangeParams.CompositeQuery.CalculatedColumns) > 0 {
+		table, err := buildTable(result, queryRangeParams.CompositeQuery)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+			return
+		}
+		resp.Table = table
 	}
 
 	// This checks if the time for context to complete has exceeded.
@@ -3354,6 +7281,23 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	aH.Respond(w, resp)
 }
 
+// partialQueryErrors lists the errors of the failed queries for a response
+// which carries the results of the successful ones
+func partialQueryErrors(errQueriesByName map[string]string) []v3.QueryError {
+	queryErrors := make([]v3.QueryError, 0, len(errQueriesByName))
+	for name, errMsg := range errQueriesByName {
+		queryErrors = append(queryErrors, v3.QueryError{
+			QueryName: name,
+			Error:     errMsg,
+			TimedOut:  strings.Contains(errMsg, context.DeadlineExceeded.Error()),
+		})
+	}
+	sort.Slice(queryErrors, func(i, j int) bool {
+		return queryErrors[i].QueryName < queryErrors[j].QueryName
+	})
+	return queryErrors
+}
+
 func (aH *APIHandler) QueryRangeV3(w http.ResponseWriter, r *http.Request) {
 	queryRangeParams, apiErrorObj := ParseQueryRangeParams(r)
 
@@ -3372,7 +7316,171 @@ func (aH *APIHandler) QueryRangeV3(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	aH.queryRangeV3(r.Context(), queryRangeParams, w, r)
+	if wantsQueryRangeStream(r) {
+		aH.streamQueryRange(queryContext(r), queryRangeParams, w, aH.execAnnotatedQueryRangeV3)
+		return
+	}
+	if wantsQueryRangeCSV(r) {
+		aH.exportQueryRangeCSV(queryContext(r), queryRangeParams, w, aH.execAnnotatedQueryRangeV3)
+		return
+	}
+
+	aH.queryRangeV3(queryContext(r), queryRangeParams, w, r)
+}
+
+// parseComparedQueryRangeParams parses the query range request body with its
+// range replaced by the given one, so that the variables of the range in
+// clickhouse queries are for that range too
+func parseComparedQueryRangeParams(r *http.Request, body []byte, compared rangecompare.Range) (
+	*v3.QueryRangeParamsV3, *model.ApiError,
+) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, model.BadRequest(fmt.Errorf("cannot parse the request body: %v", err))
+	}
+	raw["start"], raw["end"] = compared.Start, compared.End
+	comparedBody, err := json.Marshal(raw)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(comparedBody))
+	return ParseQueryRangeParams(r)
+}
+
+// QueryRangeV3Compare runs a composite query over its range and over the
+// comparison range in the request, e.g. before and after a deploy, and
+// returns the aligned series of both with their changes
+func (aH *APIHandler) QueryRangeV3Compare(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	req := struct {
+		Start        int64 `json:"start"`
+		End          int64 `json:"end"`
+		CompareStart int64 `json:"compareStart"`
+		CompareEnd   int64 `json:"compareEnd"`
+	}{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("cannot parse the request body: %v", err)), nil)
+		return
+	}
+	ranges := []rangecompare.Range{
+		{Start: req.Start, End: req.End},
+		{Start: req.CompareStart, End: req.CompareEnd},
+	}
+	if err := rangecompare.ValidateRanges(ranges[0], ranges[1]); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	// the steps are the same for both ranges as they are of the same length
+	steps := map[string]int64{}
+	results := make([][]*v3.Result, len(ranges))
+	queryErrors := make([][]v3.QueryError, len(ranges))
+	for i, queryRange := range ranges {
+		queryRangeParams, apiErr := parseComparedQueryRangeParams(r, body, queryRange)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if err := aH.addTemporality(r.Context(), queryRangeParams); err != nil {
+			zap.L().Error("error while adding temporality for metrics", zap.Error(err))
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+			return
+		}
+		for name, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+			steps[name] = query.StepInterval * 1000
+		}
+		for name := range queryRangeParams.CompositeQuery.ClickHouseQueries {
+			steps[name] = queryRangeParams.Step * 1000
+		}
+		for name := range queryRangeParams.CompositeQuery.PromQueries {
+			steps[name] = queryRangeParams.Step * 1000
+		}
+
+		ctx := queryContext(r)
+		if queryRangeParams.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(queryRangeParams.Timeout)*time.Second)
+			defer cancel()
+		}
+
+		var errQueriesByName map[string]string
+		results[i], queryErrors[i], errQueriesByName, apiErr = aH.execAnnotatedQueryRangeV3(ctx, queryRangeParams)
+		if apiErr != nil {
+			RespondError(w, apiErr, errQueriesByName)
+			return
+		}
+	}
+
+	aH.Respond(w, map[string]interface{}{
+		"baseline":         ranges[0],
+		"comparison":       ranges[1],
+		"result":           rangecompare.Compare(results[0], ranges[0], results[1], ranges[1], steps),
+		"baselineErrors":   queryErrors[0],
+		"comparisonErrors": queryErrors[1],
+	})
+}
+
+// SLOBudgetForecast runs the good and total queries of an SLO over its
+// window up to now and returns its error budget burn chart, projected to the
+// end of the window, with when the budget runs out
+func (aH *APIHandler) SLOBudgetForecast(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	req := struct {
+		SLO slo.BudgetForecastRequest `json:"slo"`
+	}{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		RespondError(w, model.BadRequest(fmt.Errorf("cannot parse the request body: %v", err)), nil)
+		return
+	}
+	if err := req.SLO.IsValid(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if now <= req.SLO.WindowStart {
+		RespondError(w, model.BadRequest(fmt.Errorf("the window of the SLO has not started yet")), nil)
+		return
+	}
+	queryRange := rangecompare.Range{Start: req.SLO.WindowStart, End: req.SLO.WindowEnd}
+	if queryRange.End > now {
+		queryRange.End = now
+	}
+	queryRangeParams, apiErr := parseComparedQueryRangeParams(r, body, queryRange)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	queryRangeParams.Step = slo.Step
+	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+		query.StepInterval = slo.Step
+	}
+	if err := aH.addTemporality(r.Context(), queryRangeParams); err != nil {
+		zap.L().Error("error while adding temporality for metrics", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	result, _, errQueriesByName, apiErr := aH.execAnnotatedQueryRangeV3(queryContext(r), queryRangeParams)
+	if apiErr != nil {
+		RespondError(w, apiErr, errQueriesByName)
+		return
+	}
+
+	forecast, err := slo.Forecast(req.SLO, result, now)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	aH.Respond(w, forecast)
 }
 
 func (aH *APIHandler) liveTailLogs(w http.ResponseWriter, r *http.Request) {
@@ -3392,6 +7500,13 @@ func (aH *APIHandler) liveTailLogs(w http.ResponseWriter, r *http.Request) {
 	var queryString string
 	switch queryRangeParams.CompositeQuery.QueryType {
 	case v3.QueryTypeBuilder:
+		var apiErr *model.ApiError
+		queryRangeParams, apiErr = aH.FilterSnippetsController.ExpandFilterSnippets(r.Context(), queryRangeParams)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+
 		// check if any enrichment is required for logs if yes then enrich them
 		if logsv3.EnrichmentRequired(queryRangeParams) {
 			// get the fields if any logs query is present
@@ -3467,54 +7582,97 @@ func (aH *APIHandler) getMetricMetadata(w http.ResponseWriter, r *http.Request)
 	aH.WriteJSON(w, r, metricMetadata)
 }
 
-func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
-
+// execQueryRangeV4 runs and post processes the queries of a composite
+// query, the errors of the failed queries are returned along with the
+// results of the others when partial results are allowed
+func (aH *APIHandler) execQueryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) (
+	[]*v3.Result, []v3.QueryError, map[string]string, *model.ApiError,
+) {
 	var result []*v3.Result
 	var err error
 	var errQuriesByName map[string]string
 	var spanKeys map[string]v3.AttributeKey
+	aH.recordKeyUsage(ctx, queryRangeParams)
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		var apiErr *model.ApiError
+		features := schemaFeaturesOfQuery(queryRangeParams, true)
+		if apiErr = aH.reader.CheckSchemaCompatibility(ctx, features); apiErr != nil {
+			return nil, nil, errQuriesByName, apiErr
+		}
+
+		queryRangeParams, apiErr = aH.FilterSnippetsController.ExpandFilterSnippets(ctx, queryRangeParams)
+		if apiErr != nil {
+			return nil, nil, errQuriesByName, apiErr
+		}
+
 		// check if any enrichment is required for logs if yes then enrich them
 		if logsv3.EnrichmentRequired(queryRangeParams) {
 			// get the fields if any logs query is present
 			var fields map[string]v3.AttributeKey
 			fields, err = aH.getLogFieldsV3(ctx, queryRangeParams)
 			if err != nil {
-				apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-				RespondError(w, apiErrObj, errQuriesByName)
-				return
+				return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 			}
 			logsv3.Enrich(queryRangeParams, fields)
 		}
 
 		spanKeys, err = aH.getSpanKeysV3(ctx, queryRangeParams)
 		if err != nil {
-			apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-			RespondError(w, apiErrObj, errQuriesByName)
-			return
+			return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 		}
 	}
 
 	result, err, errQuriesByName = aH.querierV2.QueryRange(ctx, queryRangeParams, spanKeys)
 
+	var queryErrors []v3.QueryError
 	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErrObj, errQuriesByName)
-		return
+		if !queryRangeParams.AllowPartial || len(result) == 0 || len(errQuriesByName) == 0 {
+			return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		queryErrors = partialQueryErrors(errQuriesByName)
 	}
 
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 		result, err = postProcessResult(result, queryRangeParams)
+		if err != nil {
+			return nil, nil, errQuriesByName, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
 	}
 
-	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErrObj, errQuriesByName)
+	result, apiErr := aH.obfuscateResults(ctx, queryRangeParams, result)
+	if apiErr != nil {
+		return nil, nil, errQuriesByName, apiErr
+	}
+	aH.markLogTraceLinks(ctx, queryRangeParams, result)
+	return result, queryErrors, errQuriesByName, nil
+}
+
+func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
+
+	if queryRangeParams.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(queryRangeParams.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	result, queryErrors, errQuriesByName, apiErr := aH.execQueryRangeV4(ctx, queryRangeParams)
+	if apiErr != nil {
+		RespondError(w, apiErr, errQuriesByName)
 		return
 	}
 
 	resp := v3.QueryRangeResponse{
 		Result: result,
+		Errors: queryErrors,
+	}
+
+	if len(queryRangeParams.CompositeQuery.CalculatedColumns) > 0 {
+		table, err := buildTable(result, queryRangeParams.CompositeQuery)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+			return
+		}
+		resp.Table = table
 	}
 
 	aH.Respond(w, resp)
@@ -3537,7 +7695,32 @@ func (aH *APIHandler) QueryRangeV4(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	aH.queryRangeV4(r.Context(), queryRangeParams, w, r)
+	if wantsQueryRangeStream(r) {
+		aH.streamQueryRange(queryContext(r), queryRangeParams, w, aH.execQueryRangeV4)
+		return
+	}
+	if wantsQueryRangeCSV(r) {
+		aH.exportQueryRangeCSV(queryContext(r), queryRangeParams, w, aH.execQueryRangeV4)
+		return
+	}
+
+	aH.queryRangeV4(queryContext(r), queryRangeParams, w, r)
+}
+
+// dashboardIdHeader is set by the frontend on queries of dashboard panels
+const dashboardIdHeader = "X-SIGNOZ-DASHBOARD-ID"
+
+// queryContext attributes the queries of a request to the dashboard which
+// made them, if any
+func queryContext(r *http.Request) context.Context {
+	dashboardId := r.Header.Get(dashboardIdHeader)
+	if dashboardId == "" {
+		return r.Context()
+	}
+	return common.ContextWithQuerySource(r.Context(), common.QuerySource{
+		Type: common.QuerySourceDashboard,
+		Id:   dashboardId,
+	})
 }
 
 // postProcessResult applies having clause, metric limit, reduce function to the result
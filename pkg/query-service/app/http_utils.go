@@ -1,8 +1,12 @@
 package app
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -20,3 +24,57 @@ func withCacheControl(maxAge time.Duration, h http.HandlerFunc) http.HandlerFunc
 		h(w, r)
 	}
 }
+
+// bufferedResponseWriter holds back the response of a handler so that
+// headers depending on the body can be set before it is written
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(code int) {
+	b.statusCode = code
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// withETag sets an ETag computed from the body of successful responses and
+// responds with 304 Not Modified when the client already has it, so that
+// the UI polling metadata doesn't download it again when it hasn't changed
+func withETag(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buffered := &bufferedResponseWriter{header: w.Header(), statusCode: http.StatusOK}
+		h(buffered, r)
+
+		body := buffered.body.Bytes()
+		if buffered.statusCode == http.StatusOK {
+			sum := sha256.Sum256(body)
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "no-cache")
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.WriteHeader(buffered.statusCode)
+		w.Write(body)
+	}
+}
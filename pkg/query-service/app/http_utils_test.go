@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+)
+
+func TestWithETag(t *testing.T) {
+	body := `{"status":"success","data":[]}`
+	handler := withETag(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dashboards", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, body, rec.Body.String())
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/dashboards", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	require.Equal(t, http.StatusNotModified, rec.Code)
+	require.Empty(t, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/dashboards?fail=true", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Empty(t, rec.Header().Get("ETag"))
+}
+
+func TestDashboardETagIgnoresViews(t *testing.T) {
+	require := require.New(t)
+
+	dbFile, err := os.CreateTemp("", "dashboards-*.db")
+	require.Nil(err)
+	defer os.Remove(dbFile.Name())
+	_, err = dashboards.InitDB(dbFile.Name())
+	require.Nil(err)
+
+	dashboard, apiErr := dashboards.CreateDashboard(
+		context.Background(), map[string]interface{}{"title": "checkout"}, featureManager.StartManager(),
+	)
+	require.Nil(apiErr)
+
+	aH := &APIHandler{}
+	request := func(method string, handler http.HandlerFunc, etag string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(method, "/api/v1/dashboards/"+dashboard.Uuid, nil),
+			map[string]string{"uuid": dashboard.Uuid})
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := request(http.MethodGet, withETag(aH.getDashboard), "")
+	require.Equal(http.StatusOK, rec.Code)
+	require.NotContains(rec.Body.String(), "viewCount")
+	etag := rec.Header().Get("ETag")
+
+	stale, apiErr := dashboards.GetStaleDashboards(context.Background(), time.Now().Add(time.Hour))
+	require.Nil(apiErr)
+	require.Len(stale, 1)
+	require.Zero(stale[0].ViewCount, "getting a dashboard isn't opening it")
+
+	require.Equal(http.StatusOK, request(http.MethodPost, aH.recordDashboardView, "").Code)
+	stale, apiErr = dashboards.GetStaleDashboards(context.Background(), time.Now().Add(time.Hour))
+	require.Nil(apiErr)
+	require.Equal(int64(1), stale[0].ViewCount)
+
+	rec = request(http.MethodGet, withETag(aH.getDashboard), etag)
+	require.Equal(http.StatusNotModified, rec.Code, "opening a dashboard doesn't change its ETag")
+}
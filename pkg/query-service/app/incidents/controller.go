@@ -0,0 +1,405 @@
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	alertStateFiring   = "firing"
+	alertStateResolved = "resolved"
+)
+
+// Controller manages incidents, which group related alerts for on-call
+// coordination. Alerts get grouped either manually or by matching the
+// match labels of an unresolved incident.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create incidents repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+func authorFromContext(ctx context.Context) string {
+	if user := common.GetUserFromContext(ctx); user != nil {
+		return user.Email
+	}
+	return ""
+}
+
+func (c *Controller) ListIncidents(
+	ctx context.Context, status IncidentStatus,
+) ([]Incident, *model.ApiError) {
+	if status != "" && !status.IsValid() {
+		return nil, model.BadRequest(fmt.Errorf("invalid incident status %q", status))
+	}
+	return c.repo.list(ctx, status)
+}
+
+func (c *Controller) GetIncident(
+	ctx context.Context, id string,
+) (*GettableIncident, *model.ApiError) {
+	incident, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	alerts, apiErr := c.repo.listAlerts(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	timeline, apiErr := c.repo.listTimeline(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &GettableIncident{
+		Incident: *incident,
+		Alerts:   alerts,
+		Timeline: timeline,
+	}, nil
+}
+
+func (c *Controller) CreateIncident(
+	ctx context.Context, postable *PostableIncident,
+) (*GettableIncident, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	now := time.Now()
+	author := authorFromContext(ctx)
+	incident := &Incident{
+		Id:          uuid.NewString(),
+		Title:       postable.Title,
+		Description: postable.Description,
+		Status:      IncidentStatusOpen,
+		Severity:    postable.Severity,
+		Assignee:    postable.Assignee,
+		MatchLabels: postable.MatchLabels,
+		CreatedBy:   author,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if apiErr := c.repo.insert(ctx, incident); apiErr != nil {
+		return nil, apiErr
+	}
+
+	c.recordEvent(ctx, incident.Id, TimelineEventCreated, "Incident created", author)
+	if incident.Assignee != "" {
+		c.recordEvent(ctx, incident.Id, TimelineEventAssigned, fmt.Sprintf(
+			"Assigned to %s", incident.Assignee,
+		), author)
+	}
+
+	return c.GetIncident(ctx, incident.Id)
+}
+
+func (c *Controller) PatchIncident(
+	ctx context.Context, id string, patch *IncidentPatch,
+) (*GettableIncident, *model.ApiError) {
+	if err := patch.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	incident, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	author := authorFromContext(ctx)
+	events := []TimelineEvent{}
+	addEvent := func(typ TimelineEventType, message string) {
+		events = append(events, TimelineEvent{Type: typ, Message: message})
+	}
+
+	if patch.Title != nil {
+		incident.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		incident.Description = *patch.Description
+	}
+	if patch.MatchLabels != nil {
+		incident.MatchLabels = *patch.MatchLabels
+	}
+	if patch.Status != nil && *patch.Status != incident.Status {
+		addEvent(TimelineEventStatusChanged, fmt.Sprintf(
+			"Status changed from %s to %s", incident.Status, *patch.Status,
+		))
+		incident.Status = *patch.Status
+		if incident.Status == IncidentStatusResolved {
+			resolvedAt := time.Now()
+			incident.ResolvedAt = &resolvedAt
+		} else {
+			incident.ResolvedAt = nil
+		}
+	}
+	if patch.Severity != nil && *patch.Severity != incident.Severity {
+		addEvent(TimelineEventSeverityChanged, fmt.Sprintf(
+			"Severity changed from %q to %q", incident.Severity, *patch.Severity,
+		))
+		incident.Severity = *patch.Severity
+	}
+	if patch.Assignee != nil && *patch.Assignee != incident.Assignee {
+		if *patch.Assignee == "" {
+			addEvent(TimelineEventAssigned, "Unassigned")
+		} else {
+			addEvent(TimelineEventAssigned, fmt.Sprintf("Assigned to %s", *patch.Assignee))
+		}
+		incident.Assignee = *patch.Assignee
+	}
+
+	incident.UpdatedAt = time.Now()
+	if apiErr := c.repo.update(ctx, incident); apiErr != nil {
+		return nil, apiErr
+	}
+
+	for _, e := range events {
+		c.recordEvent(ctx, incident.Id, e.Type, e.Message, author)
+	}
+
+	return c.GetIncident(ctx, incident.Id)
+}
+
+func (c *Controller) DeleteIncident(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return c.repo.delete(ctx, id)
+}
+
+// LinkAlert manually groups an alert, identified by its labels, into an incident
+func (c *Controller) LinkAlert(
+	ctx context.Context, id string, postable *PostableIncidentAlert,
+) (*GettableIncident, *model.ApiError) {
+	if len(postable.Labels) == 0 {
+		return nil, model.BadRequest(fmt.Errorf("alert labels are required"))
+	}
+
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	fingerprint := postable.Labels.Fingerprint()
+	existing, apiErr := c.repo.getAlert(ctx, id, fingerprint)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if existing != nil {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("alert %s is already linked to the incident", fingerprint),
+		}
+	}
+
+	now := time.Now()
+	alert := &IncidentAlert{
+		IncidentId:  id,
+		Fingerprint: fingerprint,
+		RuleId:      postable.Labels[labels.AlertRuleIdLabel],
+		AlertName:   postable.Labels[labels.AlertNameLabel],
+		Labels:      postable.Labels,
+		State:       alertStateFiring,
+		Manual:      true,
+		LinkedAt:    now,
+		UpdatedAt:   now,
+	}
+	if apiErr := c.repo.upsertAlert(ctx, alert); apiErr != nil {
+		return nil, apiErr
+	}
+
+	c.recordEvent(ctx, id, TimelineEventAlertLinked, fmt.Sprintf(
+		"Alert %s linked", alertDisplayName(alert),
+	), authorFromContext(ctx))
+
+	return c.GetIncident(ctx, id)
+}
+
+func (c *Controller) UnlinkAlert(
+	ctx context.Context, id string, fingerprint string,
+) (*GettableIncident, *model.ApiError) {
+	alert, apiErr := c.repo.getAlert(ctx, id, fingerprint)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if alert == nil {
+		return nil, model.NotFoundError(fmt.Errorf(
+			"alert %s is not linked to incident %s", fingerprint, id,
+		))
+	}
+
+	if apiErr := c.repo.deleteAlert(ctx, id, fingerprint); apiErr != nil {
+		return nil, apiErr
+	}
+
+	c.recordEvent(ctx, id, TimelineEventAlertUnlinked, fmt.Sprintf(
+		"Alert %s unlinked", alertDisplayName(alert),
+	), authorFromContext(ctx))
+
+	return c.GetIncident(ctx, id)
+}
+
+func (c *Controller) AddAnnotation(
+	ctx context.Context, id string, postable *PostableAnnotation,
+) (*GettableIncident, *model.ApiError) {
+	if strings.TrimSpace(postable.Message) == "" {
+		return nil, model.BadRequest(fmt.Errorf("annotation message is required"))
+	}
+
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	apiErr := c.repo.addTimelineEvent(
+		ctx, id, TimelineEventAnnotation, postable.Message, authorFromContext(ctx),
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return c.GetIncident(ctx, id)
+}
+
+// AcknowledgeAlert acknowledges the open incidents an alert is linked to,
+// on behalf of author, and returns them
+func (c *Controller) AcknowledgeAlert(
+	ctx context.Context, fingerprint string, author string,
+) ([]Incident, *model.ApiError) {
+	incidents, apiErr := c.repo.listOpenWithAlert(ctx, fingerprint)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for i := range incidents {
+		incident := &incidents[i]
+		incident.Status = IncidentStatusAcknowledged
+		incident.UpdatedAt = time.Now()
+		if apiErr := c.repo.update(ctx, incident); apiErr != nil {
+			return nil, apiErr
+		}
+		c.recordEvent(ctx, incident.Id, TimelineEventStatusChanged, fmt.Sprintf(
+			"Status changed from %s to %s", IncidentStatusOpen, IncidentStatusAcknowledged,
+		), author)
+	}
+	return incidents, nil
+}
+
+// OnAlerts implements rules.AlertListener. Firing alerts are linked to the
+// unresolved incidents whose match labels they carry, and state changes of
+// linked alerts are recorded on the incident timelines.
+func (c *Controller) OnAlerts(ctx context.Context, alerts ...*rules.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	incidents, apiErr := c.repo.listUnresolved(ctx)
+	if apiErr != nil {
+		zap.S().Errorf("failed to list unresolved incidents: %v", apiErr.Err)
+		return
+	}
+
+	for _, a := range alerts {
+		alertLabels := LabelSet(a.Labels.Map())
+		alertName := alertLabels[labels.AlertNameLabel]
+		if alertLabels[labels.AlertRuleIdLabel] == "" || strings.HasSuffix(alertName, rules.TestAlertPostFix) {
+			// test notifications are not grouped into incidents
+			continue
+		}
+
+		state := alertStateFiring
+		if !a.ResolvedAt.IsZero() {
+			state = alertStateResolved
+		}
+
+		for _, incident := range incidents {
+			if err := c.processAlert(ctx, &incident, alertLabels, state); err != nil {
+				zap.S().Errorf("failed to process alert for incident %s: %v", incident.Id, err.Err)
+			}
+		}
+	}
+}
+
+func (c *Controller) processAlert(
+	ctx context.Context, incident *Incident, alertLabels LabelSet, state string,
+) *model.ApiError {
+	fingerprint := alertLabels.Fingerprint()
+	existing, apiErr := c.repo.getAlert(ctx, incident.Id, fingerprint)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now()
+	if existing != nil {
+		if existing.State == state {
+			return nil
+		}
+		existing.State = state
+		existing.UpdatedAt = now
+		if apiErr := c.repo.upsertAlert(ctx, existing); apiErr != nil {
+			return apiErr
+		}
+
+		eventType, message := TimelineEventAlertFiring, "Alert %s started firing"
+		if state == alertStateResolved {
+			eventType, message = TimelineEventAlertResolved, "Alert %s resolved"
+		}
+		return c.repo.addTimelineEvent(
+			ctx, incident.Id, eventType, fmt.Sprintf(message, alertDisplayName(existing)), "",
+		)
+	}
+
+	if state != alertStateFiring || !alertLabels.Matches(incident.MatchLabels) {
+		return nil
+	}
+
+	alert := &IncidentAlert{
+		IncidentId:  incident.Id,
+		Fingerprint: fingerprint,
+		RuleId:      alertLabels[labels.AlertRuleIdLabel],
+		AlertName:   alertLabels[labels.AlertNameLabel],
+		Labels:      alertLabels,
+		State:       state,
+		LinkedAt:    now,
+		UpdatedAt:   now,
+	}
+	if apiErr := c.repo.upsertAlert(ctx, alert); apiErr != nil {
+		return apiErr
+	}
+	return c.repo.addTimelineEvent(ctx, incident.Id, TimelineEventAlertLinked, fmt.Sprintf(
+		"Firing alert %s linked by matching labels", alertDisplayName(alert),
+	), "")
+}
+
+func (c *Controller) recordEvent(
+	ctx context.Context, incidentId string, typ TimelineEventType, message string, author string,
+) {
+	if apiErr := c.repo.addTimelineEvent(ctx, incidentId, typ, message, author); apiErr != nil {
+		zap.S().Errorf("failed to record %s event for incident %s: %v", typ, incidentId, apiErr.Err)
+	}
+}
+
+func alertDisplayName(alert *IncidentAlert) string {
+	if alert.AlertName != "" {
+		return alert.AlertName
+	}
+	return alert.Fingerprint
+}
@@ -0,0 +1,108 @@
+package incidents
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func newTestController(t *testing.T) *Controller {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	if err != nil {
+		t.Fatalf("could not create temp file for test db: %v", err)
+	}
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+
+	testDB, err := sqlx.Open("sqlite3", testDBFilePath)
+	if err != nil {
+		t.Fatalf("could not open test db sqlite file: %v", err)
+	}
+
+	controller, err := NewController(testDB)
+	if err != nil {
+		t.Fatalf("could not create incidents controller: %v", err)
+	}
+	return controller
+}
+
+func testAlert(resolved bool, ls ...string) *rules.Alert {
+	alert := &rules.Alert{
+		State:  rules.StateFiring,
+		Labels: labels.FromStrings(ls...),
+	}
+	if resolved {
+		alert.State = rules.StateInactive
+		alert.ResolvedAt = time.Now()
+	}
+	return alert
+}
+
+func TestIncidentAlertGrouping(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	controller := newTestController(t)
+
+	incident, apiErr := controller.CreateIncident(ctx, &PostableIncident{
+		Title:       "checkout is down",
+		Assignee:    "oncall@example.com",
+		MatchLabels: LabelSet{"service_name": "checkout"},
+	})
+	require.Nil(apiErr)
+	require.Equal(IncidentStatusOpen, incident.Status)
+	require.Equal(2, len(incident.Timeline))
+
+	checkoutAlert := []string{
+		labels.AlertNameLabel, "high latency", labels.AlertRuleIdLabel, "1", "service_name", "checkout",
+	}
+	controller.OnAlerts(ctx,
+		testAlert(false, checkoutAlert...),
+		testAlert(false, labels.AlertNameLabel, "high latency", labels.AlertRuleIdLabel, "1", "service_name", "frontend"),
+	)
+	// resending an alert in the same state should not add to the timeline
+	controller.OnAlerts(ctx, testAlert(false, checkoutAlert...))
+
+	incident, apiErr = controller.GetIncident(ctx, incident.Id)
+	require.Nil(apiErr)
+	require.Equal(1, len(incident.Alerts))
+	require.Equal("checkout", incident.Alerts[0].Labels["service_name"])
+	require.False(incident.Alerts[0].Manual)
+	require.Equal(3, len(incident.Timeline))
+
+	controller.OnAlerts(ctx, testAlert(true, checkoutAlert...))
+	incident, apiErr = controller.GetIncident(ctx, incident.Id)
+	require.Nil(apiErr)
+	require.Equal(alertStateResolved, incident.Alerts[0].State)
+	require.Equal(TimelineEventAlertResolved, incident.Timeline[3].Type)
+
+	// manual linking
+	incident, apiErr = controller.LinkAlert(ctx, incident.Id, &PostableIncidentAlert{
+		Labels: LabelSet{labels.AlertNameLabel: "errors", labels.AlertRuleIdLabel: "2"},
+	})
+	require.Nil(apiErr)
+	require.Equal(2, len(incident.Alerts))
+	_, apiErr = controller.LinkAlert(ctx, incident.Id, &PostableIncidentAlert{
+		Labels: LabelSet{labels.AlertNameLabel: "errors", labels.AlertRuleIdLabel: "2"},
+	})
+	require.NotNil(apiErr, "linking an alert twice should fail")
+
+	// resolved incidents do not group alerts anymore
+	resolved := IncidentStatusResolved
+	incident, apiErr = controller.PatchIncident(ctx, incident.Id, &IncidentPatch{Status: &resolved})
+	require.Nil(apiErr)
+	require.NotNil(incident.ResolvedAt)
+
+	timelineLen := len(incident.Timeline)
+	controller.OnAlerts(ctx, testAlert(false, checkoutAlert...))
+	incident, apiErr = controller.GetIncident(ctx, incident.Id)
+	require.Nil(apiErr)
+	require.Equal(timelineLen, len(incident.Timeline))
+}
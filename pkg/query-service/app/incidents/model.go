@@ -0,0 +1,180 @@
+package incidents
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen         IncidentStatus = "open"
+	IncidentStatusAcknowledged IncidentStatus = "acknowledged"
+	IncidentStatusResolved     IncidentStatus = "resolved"
+)
+
+func (s IncidentStatus) IsValid() bool {
+	switch s {
+	case IncidentStatusOpen, IncidentStatusAcknowledged, IncidentStatusResolved:
+		return true
+	}
+	return false
+}
+
+type TimelineEventType string
+
+const (
+	TimelineEventCreated         TimelineEventType = "created"
+	TimelineEventStatusChanged   TimelineEventType = "status_changed"
+	TimelineEventAssigned        TimelineEventType = "assigned"
+	TimelineEventAnnotation      TimelineEventType = "annotation"
+	TimelineEventAlertLinked     TimelineEventType = "alert_linked"
+	TimelineEventAlertUnlinked   TimelineEventType = "alert_unlinked"
+	TimelineEventAlertFiring     TimelineEventType = "alert_firing"
+	TimelineEventAlertResolved   TimelineEventType = "alert_resolved"
+	TimelineEventSeverityChanged TimelineEventType = "severity_changed"
+)
+
+// LabelSet is a set of alert labels stored as JSON
+type LabelSet map[string]string
+
+// For serializing from db
+func (l *LabelSet) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+// For serializing to db
+func (l LabelSet) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+	serialized, err := json.Marshal(l)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize labels to JSON: %w", err)
+	}
+	return string(serialized), nil
+}
+
+// Matches reports if the labels contain all the given matchers. An empty
+// matcher set never matches so that incidents without match labels only
+// group manually linked alerts.
+func (l LabelSet) Matches(matchers LabelSet) bool {
+	if len(matchers) == 0 {
+		return false
+	}
+	for name, value := range matchers {
+		if l[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Fingerprint identifies an alert the same way the alert manager does
+func (l LabelSet) Fingerprint() string {
+	ls := pmodel.LabelSet{}
+	for name, value := range l {
+		ls[pmodel.LabelName(name)] = pmodel.LabelValue(value)
+	}
+	return ls.Fingerprint().String()
+}
+
+type Incident struct {
+	Id          string         `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title"`
+	Description string         `json:"description" db:"description"`
+	Status      IncidentStatus `json:"status" db:"status"`
+	Severity    string         `json:"severity" db:"severity"`
+	Assignee    string         `json:"assignee" db:"assignee"`
+	// firing alerts carrying all of these labels are linked to the incident
+	MatchLabels LabelSet   `json:"matchLabels" db:"match_labels"`
+	CreatedBy   string     `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+type IncidentAlert struct {
+	IncidentId  string    `json:"incidentId" db:"incident_id"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+	RuleId      string    `json:"ruleId" db:"rule_id"`
+	AlertName   string    `json:"alertName" db:"alert_name"`
+	Labels      LabelSet  `json:"labels" db:"labels"`
+	State       string    `json:"state" db:"state"`
+	Manual      bool      `json:"manual" db:"manual"`
+	LinkedAt    time.Time `json:"linkedAt" db:"linked_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type TimelineEvent struct {
+	Id         int64             `json:"id" db:"id"`
+	IncidentId string            `json:"incidentId" db:"incident_id"`
+	Type       TimelineEventType `json:"type" db:"type"`
+	Message    string            `json:"message" db:"message"`
+	Author     string            `json:"author,omitempty" db:"author"`
+	CreatedAt  time.Time         `json:"createdAt" db:"created_at"`
+}
+
+// GettableIncident is an incident along with its alerts and timeline
+type GettableIncident struct {
+	Incident
+	Alerts   []IncidentAlert `json:"alerts"`
+	Timeline []TimelineEvent `json:"timeline"`
+}
+
+type PostableIncident struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Assignee    string   `json:"assignee"`
+	MatchLabels LabelSet `json:"matchLabels"`
+}
+
+func (p *PostableIncident) Validate() error {
+	if strings.TrimSpace(p.Title) == "" {
+		return fmt.Errorf("incident title is required")
+	}
+	return nil
+}
+
+// IncidentPatch captures a partial update of an incident, nil fields are left untouched
+type IncidentPatch struct {
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	Status      *IncidentStatus `json:"status"`
+	Severity    *string         `json:"severity"`
+	Assignee    *string         `json:"assignee"`
+	MatchLabels *LabelSet       `json:"matchLabels"`
+}
+
+func (p *IncidentPatch) Validate() error {
+	if p.Title != nil && strings.TrimSpace(*p.Title) == "" {
+		return fmt.Errorf("incident title can not be empty")
+	}
+	if p.Status != nil && !p.Status.IsValid() {
+		return fmt.Errorf(
+			"invalid incident status %q, use one of %s, %s or %s", *p.Status,
+			IncidentStatusOpen, IncidentStatusAcknowledged, IncidentStatusResolved,
+		)
+	}
+	return nil
+}
+
+type PostableIncidentAlert struct {
+	Labels LabelSet `json:"labels"`
+}
+
+type PostableAnnotation struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,331 @@
+package incidents
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS incidents(
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			severity TEXT NOT NULL DEFAULT '',
+			assignee TEXT NOT NULL DEFAULT '',
+			match_labels TEXT NOT NULL DEFAULT '{}',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			resolved_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS incident_alerts(
+			incident_id TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			rule_id TEXT NOT NULL DEFAULT '',
+			alert_name TEXT NOT NULL DEFAULT '',
+			labels TEXT NOT NULL,
+			state TEXT NOT NULL,
+			manual BOOLEAN NOT NULL DEFAULT FALSE,
+			linked_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (incident_id, fingerprint),
+			FOREIGN KEY(incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS incident_timeline(
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			incident_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			message TEXT NOT NULL,
+			author TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			FOREIGN KEY(incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS incident_timeline_incident_id
+		ON incident_timeline(incident_id);
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure incidents schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for incidents: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+const incidentColumns = `id, title, description, status, severity, assignee,
+	match_labels, created_by, created_at, updated_at, resolved_at`
+
+func (r *Repo) list(
+	ctx context.Context, status IncidentStatus,
+) ([]Incident, *model.ApiError) {
+	incidents := []Incident{}
+
+	query := fmt.Sprintf(`SELECT %s FROM incidents`, incidentColumns)
+	args := []interface{}{}
+	if status != "" {
+		query += ` WHERE status = $1`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &incidents, query, args...)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query incidents: %w", err,
+		))
+	}
+	return incidents, nil
+}
+
+// listUnresolved returns the incidents that alerts can still be grouped into
+func (r *Repo) listUnresolved(ctx context.Context) ([]Incident, *model.ApiError) {
+	incidents := []Incident{}
+
+	err := r.db.SelectContext(ctx, &incidents, fmt.Sprintf(
+		`SELECT %s FROM incidents WHERE status != $1`, incidentColumns,
+	), IncidentStatusResolved)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query unresolved incidents: %w", err,
+		))
+	}
+	return incidents, nil
+}
+
+// listOpenWithAlert returns the open incidents the alert is linked to
+func (r *Repo) listOpenWithAlert(
+	ctx context.Context, fingerprint string,
+) ([]Incident, *model.ApiError) {
+	incidents := []Incident{}
+
+	err := r.db.SelectContext(ctx, &incidents, fmt.Sprintf(`
+		SELECT %s FROM incidents
+		WHERE status = $1 AND id IN (
+			SELECT incident_id FROM incident_alerts WHERE fingerprint = $2
+		)`, incidentColumns,
+	), IncidentStatusOpen, fingerprint)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query incidents of alert %s: %w", fingerprint, err,
+		))
+	}
+	return incidents, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*Incident, *model.ApiError) {
+	incident := Incident{}
+
+	err := r.db.GetContext(ctx, &incident, fmt.Sprintf(
+		`SELECT %s FROM incidents WHERE id = $1`, incidentColumns,
+	), id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("incident %s not found", id))
+	}
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query incident %s: %w", id, err,
+		))
+	}
+	return &incident, nil
+}
+
+func (r *Repo) insert(ctx context.Context, incident *Incident) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO incidents (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		incidentColumns,
+	),
+		incident.Id, incident.Title, incident.Description, incident.Status,
+		incident.Severity, incident.Assignee, incident.MatchLabels,
+		incident.CreatedBy, incident.CreatedAt, incident.UpdatedAt, incident.ResolvedAt,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not insert incident: %w", err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) update(ctx context.Context, incident *Incident) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE incidents SET
+			title = $1, description = $2, status = $3, severity = $4,
+			assignee = $5, match_labels = $6, updated_at = $7, resolved_at = $8
+		WHERE id = $9`,
+		incident.Title, incident.Description, incident.Status, incident.Severity,
+		incident.Assignee, incident.MatchLabels, incident.UpdatedAt, incident.ResolvedAt,
+		incident.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update incident %s: %w", incident.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	// sqlite foreign keys are not enforced by default, clean up dependents explicitly
+	for _, table := range []string{"incident_alerts", "incident_timeline"} {
+		_, err := r.db.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE incident_id = $1`, table,
+		), id)
+		if err != nil {
+			return model.InternalError(fmt.Errorf(
+				"could not delete %s for incident %s: %w", table, id, err,
+			))
+		}
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM incidents WHERE id = $1`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete incident %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) listAlerts(
+	ctx context.Context, incidentId string,
+) ([]IncidentAlert, *model.ApiError) {
+	alerts := []IncidentAlert{}
+
+	err := r.db.SelectContext(ctx, &alerts, `
+		SELECT incident_id, fingerprint, rule_id, alert_name, labels,
+			state, manual, linked_at, updated_at
+		FROM incident_alerts
+		WHERE incident_id = $1
+		ORDER BY linked_at`, incidentId,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query alerts of incident %s: %w", incidentId, err,
+		))
+	}
+	return alerts, nil
+}
+
+func (r *Repo) getAlert(
+	ctx context.Context, incidentId string, fingerprint string,
+) (*IncidentAlert, *model.ApiError) {
+	alert := IncidentAlert{}
+
+	err := r.db.GetContext(ctx, &alert, `
+		SELECT incident_id, fingerprint, rule_id, alert_name, labels,
+			state, manual, linked_at, updated_at
+		FROM incident_alerts
+		WHERE incident_id = $1 AND fingerprint = $2`, incidentId, fingerprint,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query alert %s of incident %s: %w", fingerprint, incidentId, err,
+		))
+	}
+	return &alert, nil
+}
+
+func (r *Repo) upsertAlert(ctx context.Context, alert *IncidentAlert) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO incident_alerts (
+			incident_id, fingerprint, rule_id, alert_name, labels,
+			state, manual, linked_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT(incident_id, fingerprint) DO UPDATE SET
+			state = excluded.state,
+			updated_at = excluded.updated_at`,
+		alert.IncidentId, alert.Fingerprint, alert.RuleId, alert.AlertName,
+		alert.Labels, alert.State, alert.Manual, alert.LinkedAt, alert.UpdatedAt,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not store alert for incident %s: %w", alert.IncidentId, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) deleteAlert(
+	ctx context.Context, incidentId string, fingerprint string,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM incident_alerts WHERE incident_id = $1 AND fingerprint = $2`,
+		incidentId, fingerprint,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not unlink alert %s from incident %s: %w", fingerprint, incidentId, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) listTimeline(
+	ctx context.Context, incidentId string,
+) ([]TimelineEvent, *model.ApiError) {
+	events := []TimelineEvent{}
+
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, incident_id, type, message, author, created_at
+		FROM incident_timeline
+		WHERE incident_id = $1
+		ORDER BY created_at, id`, incidentId,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query timeline of incident %s: %w", incidentId, err,
+		))
+	}
+	return events, nil
+}
+
+func (r *Repo) addTimelineEvent(
+	ctx context.Context, incidentId string, typ TimelineEventType, message string, author string,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO incident_timeline (incident_id, type, message, author, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		incidentId, typ, message, author, time.Now(),
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not add timeline event to incident %s: %w", incidentId, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package ingestionkeys
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	KeyIdResourceAttribute   = "signoz.ingestion_key.id"
+	KeyNameResourceAttribute = "signoz.ingestion_key.name"
+)
+
+// Controller manages ingestion keys and checks the telemetry gateways receive
+// against the keys' signals and quotas.
+type Controller struct {
+	repo   *Repo
+	quotas *quotaTracker
+
+	// keys by their value, checks are too frequent to hit the db for each
+	keysMtx sync.RWMutex
+	keys    map[string]IngestionKey
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create ingestion keys repo: %w", err)
+	}
+
+	c := &Controller{
+		repo:   repo,
+		quotas: newQuotaTracker(),
+	}
+	if apiErr := c.reloadKeys(context.Background()); apiErr != nil {
+		return nil, fmt.Errorf("couldn't load ingestion keys: %w", apiErr.ToError())
+	}
+	return c, nil
+}
+
+func (c *Controller) reloadKeys(ctx context.Context) *model.ApiError {
+	keys, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	byValue := make(map[string]IngestionKey, len(keys))
+	for _, k := range keys {
+		byValue[k.Key] = k
+	}
+
+	c.keysMtx.Lock()
+	defer c.keysMtx.Unlock()
+	c.keys = byValue
+	return nil
+}
+
+func (c *Controller) ListIngestionKeys(ctx context.Context) ([]IngestionKey, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+func (c *Controller) GetIngestionKey(ctx context.Context, id string) (*IngestionKey, *model.ApiError) {
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) CreateIngestionKey(
+	ctx context.Context, postable *PostableIngestionKey,
+) (*IngestionKey, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	ingestionKey, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return ingestionKey, c.reloadKeys(ctx)
+}
+
+// UpdateIngestionKey replaces the name, signals and quotas of a key
+func (c *Controller) UpdateIngestionKey(
+	ctx context.Context, id string, postable *PostableIngestionKey,
+) (*IngestionKey, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	ingestionKey, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	ingestionKey.Name = postable.Name
+	ingestionKey.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, ingestionKey); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return ingestionKey, c.reloadKeys(ctx)
+}
+
+// RevokeIngestionKey cuts off a key, gateways reject the telemetry sent with
+// it from then on. Revoked keys are kept so that usage stays attributable.
+func (c *Controller) RevokeIngestionKey(ctx context.Context, id string) (*IngestionKey, *model.ApiError) {
+	ingestionKey, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if ingestionKey.IsRevoked() {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("ingestion key %s is already revoked", id),
+		}
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	now := time.Now()
+	ingestionKey.RevokedAt = &now
+	ingestionKey.RevokedBy = &userId
+	if apiErr := c.repo.update(ctx, userId, ingestionKey); apiErr != nil {
+		return nil, apiErr
+	}
+	c.quotas.forget(ingestionKey.Id)
+
+	return ingestionKey, c.reloadKeys(ctx)
+}
+
+// Check decides whether a gateway should accept a batch of telemetry sent
+// with a key, counting accepted batches against the key's quota
+func (c *Controller) Check(req *CheckRequest) (*CheckResult, *model.ApiError) {
+	if !slices.Contains(supportedSignals, req.Signal) {
+		return nil, model.BadRequest(fmt.Errorf("signal must be one of logs, traces or metrics"))
+	}
+	if req.Records < 0 || req.Bytes < 0 {
+		return nil, model.BadRequest(fmt.Errorf("records and bytes can not be negative"))
+	}
+
+	c.keysMtx.RLock()
+	ingestionKey, ok := c.keys[req.Key]
+	c.keysMtx.RUnlock()
+
+	if !ok {
+		return &CheckResult{Reason: ReasonUnknownKey}, nil
+	}
+	if ingestionKey.IsRevoked() {
+		return &CheckResult{Reason: ReasonRevoked}, nil
+	}
+	if !slices.Contains(ingestionKey.Spec.Signals, req.Signal) {
+		return &CheckResult{Reason: ReasonSignalNotAllowed}, nil
+	}
+
+	reason, retryAfter := c.quotas.consume(ingestionKey.Id, ingestionKey.Spec.Quota, req.Records, req.Bytes)
+	if reason != "" {
+		return &CheckResult{Reason: reason, RetryAfterSeconds: retryAfter}, nil
+	}
+
+	return &CheckResult{
+		Allowed: true,
+		ResourceAttributes: map[string]string{
+			KeyIdResourceAttribute:   ingestionKey.Id,
+			KeyNameResourceAttribute: ingestionKey.Name,
+		},
+	}, nil
+}
@@ -0,0 +1,122 @@
+package ingestionkeys
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+var supportedSignals = []string{"logs", "traces", "metrics"}
+
+// IngestionKey authenticates a shipper sending telemetry to SigNoz. Gateways
+// in front of the collectors check the keys they receive along with the
+// amount of data sent, so that keys can be throttled or cut off individually.
+type IngestionKey struct {
+	Id        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Key       string     `json:"key" db:"key"`
+	Spec      KeySpec    `json:"spec" db:"spec_json"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	RevokedBy *string    `json:"revokedBy,omitempty" db:"revoked_by"`
+	CreatedBy string     `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedBy string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+func (k *IngestionKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+type KeySpec struct {
+	// logs, traces and/or metrics, the signals that can be sent with the key
+	Signals []string `json:"signals"`
+
+	Quota Quota `json:"quota"`
+}
+
+// Quota limits the data sent with a key, zero values mean no limit
+type Quota struct {
+	RecordsPerSecond int64 `json:"recordsPerSecond,omitempty"`
+	BytesPerDay      int64 `json:"bytesPerDay,omitempty"`
+}
+
+// For serializing from db
+func (s *KeySpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s KeySpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize ingestion key spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *KeySpec) IsValid() error {
+	if len(s.Signals) == 0 {
+		return fmt.Errorf("at least one signal is required")
+	}
+	for _, signal := range s.Signals {
+		if !slices.Contains(supportedSignals, signal) {
+			return fmt.Errorf("signals must be one of %s", strings.Join(supportedSignals, ", "))
+		}
+	}
+	if s.Quota.RecordsPerSecond < 0 || s.Quota.BytesPerDay < 0 {
+		return fmt.Errorf("quotas can not be negative")
+	}
+	return nil
+}
+
+type PostableIngestionKey struct {
+	Name string  `json:"name"`
+	Spec KeySpec `json:"spec"`
+}
+
+func (p *PostableIngestionKey) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("ingestion key name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+// CheckRequest is sent by gateways for each batch of telemetry received
+type CheckRequest struct {
+	Key     string `json:"key"`
+	Signal  string `json:"signal"`
+	Records int64  `json:"records"`
+	Bytes   int64  `json:"bytes"`
+}
+
+type CheckResult struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+
+	// set when the batch was throttled, seconds after which it can be retried
+	RetryAfterSeconds int64 `json:"retryAfterSeconds,omitempty"`
+
+	// resource attributes gateways should add to the accepted telemetry so
+	// that usage can be attributed to the key
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty"`
+}
+
+const (
+	ReasonUnknownKey       = "unknown_key"
+	ReasonRevoked          = "revoked"
+	ReasonSignalNotAllowed = "signal_not_allowed"
+	ReasonRateLimited      = "rate_limited"
+	ReasonDailyQuota       = "daily_quota_exceeded"
+)
@@ -0,0 +1,79 @@
+package ingestionkeys
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type keyUsage struct {
+	// token bucket for the records per second quota, holding at most a
+	// second's worth of records
+	tokens     float64
+	refilledAt time.Time
+
+	day   time.Time
+	bytes int64
+}
+
+// quotaTracker keeps the usage of keys in memory, usage is lost on restarts
+// which only means a key can send up to a day's quota more on those days
+type quotaTracker struct {
+	mtx   sync.Mutex
+	usage map[string]*keyUsage
+	now   func() time.Time
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{
+		usage: map[string]*keyUsage{},
+		now:   time.Now,
+	}
+}
+
+// consume records the batch against the quota of the key if the quota allows
+// it. The reason and the seconds after which to retry are returned otherwise.
+func (t *quotaTracker) consume(keyId string, quota Quota, records int64, bytes int64) (string, int64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	now := t.now()
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	u, ok := t.usage[keyId]
+	if !ok {
+		u = &keyUsage{tokens: float64(quota.RecordsPerSecond), refilledAt: now, day: day}
+		t.usage[keyId] = u
+	}
+	if !u.day.Equal(day) {
+		u.day = day
+		u.bytes = 0
+	}
+
+	if quota.BytesPerDay > 0 && u.bytes+bytes > quota.BytesPerDay {
+		return ReasonDailyQuota, int64(math.Ceil(day.Add(24 * time.Hour).Sub(now).Seconds()))
+	}
+
+	if quota.RecordsPerSecond > 0 {
+		rate := float64(quota.RecordsPerSecond)
+		u.tokens = math.Min(rate, u.tokens+now.Sub(u.refilledAt).Seconds()*rate)
+		u.refilledAt = now
+
+		// batches bigger than the bucket go through when it is full, leaving
+		// the bucket in debt
+		needed := math.Min(float64(records), rate)
+		if u.tokens < needed {
+			return ReasonRateLimited, int64(math.Ceil((needed - u.tokens) / rate))
+		}
+		u.tokens -= float64(records)
+	}
+
+	u.bytes += bytes
+	return "", 0
+}
+
+func (t *quotaTracker) forget(keyId string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.usage, keyId)
+}
@@ -0,0 +1,51 @@
+package ingestionkeys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	tracker := newQuotaTracker()
+	tracker.now = func() time.Time { return now }
+
+	quota := Quota{RecordsPerSecond: 100, BytesPerDay: 1000}
+
+	reason, _ := tracker.consume("k1", quota, 60, 100)
+	require.Equal("", reason)
+
+	reason, retryAfter := tracker.consume("k1", quota, 60, 100)
+	require.Equal(ReasonRateLimited, reason)
+	require.Equal(int64(1), retryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	reason, _ = tracker.consume("k1", quota, 60, 100)
+	require.Equal("", reason, "the bucket should have refilled")
+
+	// batches bigger than the rate go through on a full bucket
+	now = now.Add(2 * time.Second)
+	reason, _ = tracker.consume("k1", quota, 250, 100)
+	require.Equal("", reason)
+	now = now.Add(time.Second)
+	reason, retryAfter = tracker.consume("k1", quota, 10, 100)
+	require.Equal(ReasonRateLimited, reason)
+	require.Equal(int64(1), retryAfter)
+
+	now = now.Add(2 * time.Second)
+	reason, retryAfter = tracker.consume("k1", quota, 1, 800)
+	require.Equal(ReasonDailyQuota, reason)
+	require.Equal(int64(55), retryAfter)
+
+	// the daily quota resets at midnight UTC
+	now = now.Add(time.Minute)
+	reason, _ = tracker.consume("k1", quota, 1, 800)
+	require.Equal("", reason)
+
+	reason, _ = tracker.consume("k2", Quota{}, 1000000, 1000000)
+	require.Equal("", reason, "keys without quotas should not be limited")
+}
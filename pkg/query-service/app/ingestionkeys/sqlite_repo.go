@@ -0,0 +1,167 @@
+package ingestionkeys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS signal_ingestion_keys(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			key TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			revoked_at TIMESTAMP,
+			revoked_by TEXT,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure ingestion keys schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for ingestion keys: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+const ingestionKeyColumns = `id, name, key, spec_json, revoked_at, revoked_by,
+	created_by, created_at, updated_by, updated_at`
+
+func (r *Repo) list(ctx context.Context) ([]IngestionKey, *model.ApiError) {
+	keys := []IngestionKey{}
+
+	err := r.db.SelectContext(ctx, &keys, fmt.Sprintf(`
+		SELECT %s FROM signal_ingestion_keys ORDER BY created_at
+	`, ingestionKeyColumns))
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query ingestion keys: %w", err,
+		))
+	}
+	return keys, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*IngestionKey, *model.ApiError) {
+	keys := []IngestionKey{}
+
+	err := r.db.SelectContext(ctx, &keys, fmt.Sprintf(`
+		SELECT %s FROM signal_ingestion_keys WHERE id = $1
+	`, ingestionKeyColumns), id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query ingestion key %s: %w", id, err,
+		))
+	}
+
+	if len(keys) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("ingestion key %s not found", id))
+	}
+	return &keys[0], nil
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableIngestionKey,
+) (*IngestionKey, *model.ApiError) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not generate ingestion key: %w", err,
+		))
+	}
+
+	now := time.Now()
+	ingestionKey := &IngestionKey{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Key:       key,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO signal_ingestion_keys (
+			id, name, key, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		ingestionKey.Id, ingestionKey.Name, ingestionKey.Key, ingestionKey.Spec,
+		ingestionKey.CreatedBy, ingestionKey.CreatedAt,
+		ingestionKey.UpdatedBy, ingestionKey.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert ingestion key: %w", err,
+		))
+	}
+
+	return ingestionKey, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, ingestionKey *IngestionKey,
+) *model.ApiError {
+	ingestionKey.UpdatedBy = userId
+	ingestionKey.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE signal_ingestion_keys
+		SET name = $1, spec_json = $2, revoked_at = $3, revoked_by = $4,
+			updated_by = $5, updated_at = $6
+		WHERE id = $7
+	`,
+		ingestionKey.Name, ingestionKey.Spec,
+		ingestionKey.RevokedAt, ingestionKey.RevokedBy,
+		ingestionKey.UpdatedBy, ingestionKey.UpdatedAt, ingestionKey.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update ingestion key %s: %w", ingestionKey.Id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package insertsettings
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// ClickHouse exporters of the collectors and the setting holding the
+// address of the ClickHouse they write to
+var clickHouseExporterDSNKeys = map[string]string{
+	"clickhousetraces":       "datasource",
+	"clickhouselogsexporter": "dsn",
+	"clickhousemetricswrite": "endpoint",
+}
+
+const batchProcessorType = "batch"
+
+// ExporterInsertSettings are the ClickHouse settings an exporter connects
+// with, empty if not set
+type ExporterInsertSettings struct {
+	Exporter           string `json:"exporter"`
+	AsyncInsert        string `json:"asyncInsert,omitempty"`
+	WaitForAsyncInsert string `json:"waitForAsyncInsert,omitempty"`
+}
+
+// BatchProcessorSettings are the settings of a batch processor of a pipeline
+// exporting to ClickHouse, zero if not set
+type BatchProcessorSettings struct {
+	Processor        string `json:"processor"`
+	SendBatchSize    int    `json:"sendBatchSize,omitempty"`
+	SendBatchMaxSize int    `json:"sendBatchMaxSize,omitempty"`
+	Timeout          string `json:"timeout,omitempty"`
+}
+
+// CollectorInsertSettings are the insert settings found in a collector config
+type CollectorInsertSettings struct {
+	Exporters       []ExporterInsertSettings `json:"exporters"`
+	BatchProcessors []BatchProcessorSettings `json:"batchProcessors"`
+}
+
+type collectorConfig struct {
+	conf       map[string]interface{}
+	pipelines  map[string]interface{}
+	exporters  map[string]interface{}
+	processors map[string]interface{}
+}
+
+func parseCollectorConfig(config []byte) (*collectorConfig, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+	exporters, _ := c["exporters"].(map[string]interface{})
+	processors, _ := c["processors"].(map[string]interface{})
+	return &collectorConfig{
+		conf: c, pipelines: pipelines, exporters: exporters, processors: processors,
+	}, nil
+}
+
+// clickHouseComponents returns the ClickHouse exporters used by the
+// pipelines and the batch processors of the pipelines using them, sorted
+func (c *collectorConfig) clickHouseComponents() (exporters []string, batchProcessors []string) {
+	exporters, batchProcessors = []string{}, []string{}
+	for _, p := range c.pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		usesClickHouse := false
+		pipelineExporters, _ := pipeline["exporters"].([]interface{})
+		for _, e := range pipelineExporters {
+			name, ok := e.(string)
+			if !ok || !isClickHouseExporter(name) {
+				continue
+			}
+			usesClickHouse = true
+			if !slices.Contains(exporters, name) {
+				exporters = append(exporters, name)
+			}
+		}
+		if !usesClickHouse {
+			continue
+		}
+
+		processors, _ := pipeline["processors"].([]interface{})
+		for _, p := range processors {
+			name, ok := p.(string)
+			if !ok || componentType(name) != batchProcessorType {
+				continue
+			}
+			if !slices.Contains(batchProcessors, name) {
+				batchProcessors = append(batchProcessors, name)
+			}
+		}
+	}
+	slices.Sort(exporters)
+	slices.Sort(batchProcessors)
+	return exporters, batchProcessors
+}
+
+// GenerateCollectorConfigWithInsertSettings sets the insert settings on the
+// ClickHouse exporters and the batch processors of their pipelines,
+// keeping their other settings. Exporters whose address isn't a URL, like
+// one read from the environment, keep their connection settings.
+func GenerateCollectorConfigWithInsertSettings(
+	config []byte, spec *InsertSettingsSpec,
+) ([]byte, *model.ApiError) {
+	c, apiErr := parseCollectorConfig(config)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	exporterNames, batchProcessorNames := c.clickHouseComponents()
+
+	if spec.AsyncInsert != nil {
+		for _, name := range exporterNames {
+			exporter, ok := c.exporters[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			dsnKey := clickHouseExporterDSNKeys[componentType(name)]
+			dsn, _ := exporter[dsnKey].(string)
+			updated, ok := dsnWithInsertSettings(dsn, spec)
+			if ok {
+				exporter[dsnKey] = updated
+			}
+		}
+	}
+
+	if b := spec.Batch; b != nil {
+		if len(batchProcessorNames) > 0 && c.processors == nil {
+			c.processors = map[string]interface{}{}
+			c.conf["processors"] = c.processors
+		}
+		for _, name := range batchProcessorNames {
+			processor, _ := c.processors[name].(map[string]interface{})
+			if processor == nil {
+				processor = map[string]interface{}{}
+			}
+			if b.SendBatchSize > 0 {
+				processor["send_batch_size"] = b.SendBatchSize
+			}
+			if b.SendBatchMaxSize > 0 {
+				processor["send_batch_max_size"] = b.SendBatchMaxSize
+			}
+			if b.Timeout != "" {
+				processor["timeout"] = b.Timeout
+			}
+			c.processors[name] = processor
+		}
+	}
+
+	updatedConf, err := yaml.Marshal(c.conf)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+// dsnWithInsertSettings sets the async insert settings as parameters of the
+// ClickHouse address, wait_for_async_insert is dropped when async inserts
+// are disabled as ClickHouse ignores it then
+func dsnWithInsertSettings(dsn string, spec *InsertSettingsSpec) (string, bool) {
+	if dsn == "" || strings.Contains(dsn, "${") {
+		return "", false
+	}
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return "", false
+	}
+
+	query := u.Query()
+	query.Set("async_insert", boolSetting(*spec.AsyncInsert))
+	if *spec.AsyncInsert && spec.WaitForAsyncInsert != nil {
+		query.Set("wait_for_async_insert", boolSetting(*spec.WaitForAsyncInsert))
+	}
+	if !*spec.AsyncInsert {
+		query.Del("wait_for_async_insert")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), true
+}
+
+// CurrentInsertSettings returns the insert settings of the ClickHouse
+// exporters and the batch processors of their pipelines in a collector
+// config
+func CurrentInsertSettings(config []byte) (*CollectorInsertSettings, *model.ApiError) {
+	c, apiErr := parseCollectorConfig(config)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	exporterNames, batchProcessorNames := c.clickHouseComponents()
+
+	current := &CollectorInsertSettings{
+		Exporters:       []ExporterInsertSettings{},
+		BatchProcessors: []BatchProcessorSettings{},
+	}
+	for _, name := range exporterNames {
+		settings := ExporterInsertSettings{Exporter: name}
+		exporter, _ := c.exporters[name].(map[string]interface{})
+		dsn, _ := exporter[clickHouseExporterDSNKeys[componentType(name)]].(string)
+		if u, err := url.Parse(dsn); err == nil {
+			settings.AsyncInsert = u.Query().Get("async_insert")
+			settings.WaitForAsyncInsert = u.Query().Get("wait_for_async_insert")
+		}
+		current.Exporters = append(current.Exporters, settings)
+	}
+	for _, name := range batchProcessorNames {
+		settings := BatchProcessorSettings{Processor: name}
+		processor, _ := c.processors[name].(map[string]interface{})
+		settings.SendBatchSize, _ = processor["send_batch_size"].(int)
+		settings.SendBatchMaxSize, _ = processor["send_batch_max_size"].(int)
+		settings.Timeout, _ = processor["timeout"].(string)
+		current.BatchProcessors = append(current.BatchProcessors, settings)
+	}
+	return current, nil
+}
+
+func isClickHouseExporter(name string) bool {
+	_, ok := clickHouseExporterDSNKeys[componentType(name)]
+	return ok
+}
+
+// componentType returns the type of a component named like type/name
+func componentType(name string) string {
+	componentType, _, _ := strings.Cut(name, "/")
+	return componentType
+}
+
+func boolSetting(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,97 @@
+package insertsettings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch:
+    send_batch_size: 10000
+  batch/logs: {}
+  batch/upstream: {}
+exporters:
+  clickhousetraces:
+    datasource: tcp://localhost:9000/signoz_traces?wait_for_async_insert=1
+  clickhouselogsexporter:
+    dsn: ${env:CLICKHOUSE_DSN}
+  otlp/upstream:
+    endpoint: signoz:4317
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces]
+    logs:
+      receivers: [otlp]
+      processors: [batch/logs]
+      exporters: [clickhouselogsexporter]
+    metrics:
+      receivers: [otlp]
+      processors: [batch/upstream]
+      exporters: [otlp/upstream]
+`
+
+func TestGenerateCollectorConfigWithInsertSettings(t *testing.T) {
+	require := require.New(t)
+
+	async, wait := true, false
+	spec := InsertSettingsSpec{
+		AsyncInsert:        &async,
+		WaitForAsyncInsert: &wait,
+		Batch:              &BatchConfig{SendBatchSize: 50000, Timeout: "2s"},
+	}
+	require.Nil(spec.IsValid())
+	require.Equal(1, len(spec.Warnings()))
+
+	updated, apiErr := GenerateCollectorConfigWithInsertSettings([]byte(testCollectorConf), &spec)
+	require.Nil(apiErr)
+
+	current, apiErr := CurrentInsertSettings(updated)
+	require.Nil(apiErr)
+	require.Equal([]ExporterInsertSettings{
+		// addresses read from the environment are left alone
+		{Exporter: "clickhouselogsexporter"},
+		{Exporter: "clickhousetraces", AsyncInsert: "1", WaitForAsyncInsert: "0"},
+	}, current.Exporters)
+	require.Equal([]BatchProcessorSettings{
+		{Processor: "batch", SendBatchSize: 50000, Timeout: "2s"},
+		{Processor: "batch/logs", SendBatchSize: 50000, Timeout: "2s"},
+	}, current.BatchProcessors, "only the batch processors of clickhouse pipelines are tuned")
+
+	// disabling async inserts drops the setting depending on them
+	async = false
+	updated, apiErr = GenerateCollectorConfigWithInsertSettings(updated, &InsertSettingsSpec{AsyncInsert: &async})
+	require.Nil(apiErr)
+	current, apiErr = CurrentInsertSettings(updated)
+	require.Nil(apiErr)
+	require.Equal(ExporterInsertSettings{Exporter: "clickhousetraces", AsyncInsert: "0"}, current.Exporters[1])
+	require.Equal(50000, current.BatchProcessors[0].SendBatchSize)
+}
+
+func TestInsertSettingsValidation(t *testing.T) {
+	require := require.New(t)
+
+	enabled, disabled := true, false
+	for _, invalid := range []InsertSettingsSpec{
+		{},
+		{WaitForAsyncInsert: &enabled},
+		{AsyncInsert: &disabled, WaitForAsyncInsert: &enabled},
+		{Batch: &BatchConfig{}},
+		{Batch: &BatchConfig{SendBatchSize: 10}},
+		{Batch: &BatchConfig{SendBatchSize: 20000, SendBatchMaxSize: 10000}},
+		{Batch: &BatchConfig{Timeout: "10ms"}},
+		{Batch: &BatchConfig{Timeout: "soon"}},
+	} {
+		require.NotNil(invalid.IsValid(), invalid)
+	}
+
+	small := InsertSettingsSpec{Batch: &BatchConfig{SendBatchSize: 500}}
+	require.Nil(small.IsValid())
+	require.Equal(1, len(small.Warnings()))
+}
@@ -0,0 +1,181 @@
+package insertsettings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const InsertSettingsFeatureType agentConf.AgentFeatureType = "clickhouse_insert_settings"
+
+// Controller manages the settings the agents write to ClickHouse with and
+// deploys them via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create clickhouse insert settings repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+// AgentInsertSettings are the insert settings in the effective config of a
+// connected agent
+type AgentInsertSettings struct {
+	AgentId string `json:"agentId"`
+	*CollectorInsertSettings
+	Error string `json:"error,omitempty"`
+}
+
+type InsertSettingsResponse struct {
+	*agentConf.ConfigVersion
+
+	// Settings of the latest version, nil if they were never set
+	Settings *InsertSettings `json:"settings"`
+	Warnings []string        `json:"warnings"`
+
+	// Current are the settings the connected agents report using
+	Current []AgentInsertSettings `json:"current"`
+}
+
+func (c *Controller) GetInsertSettings(ctx context.Context) (
+	*InsertSettingsResponse, *model.ApiError,
+) {
+	response := &InsertSettingsResponse{Warnings: []string{}}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeInsertSettings)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest clickhouse insert settings config version")
+	}
+	if latest != nil {
+		response.ConfigVersion = latest
+		response.Settings, apiErr = c.repo.getByVersion(ctx, latest.Version)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	if response.Settings != nil {
+		response.Warnings = response.Settings.Spec.Warnings()
+	}
+
+	current, err := currentAgentInsertSettings()
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not get the insert settings of the agents: %w", err,
+		))
+	}
+	response.Current = current
+	return response, nil
+}
+
+func currentAgentInsertSettings() ([]AgentInsertSettings, error) {
+	agents, err := opampModel.AllAgents.ListAgents(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	current := []AgentInsertSettings{}
+	for _, agent := range agents {
+		if agent.Status != opampModel.AgentStatusConnected.String() {
+			continue
+		}
+		view, err := opampModel.AllAgents.GetAgentView(agent.InstanceUID)
+		if err != nil {
+			return nil, err
+		}
+		if view == nil || view.EffectiveConfig == "" {
+			continue
+		}
+
+		settings := AgentInsertSettings{AgentId: agent.InstanceUID}
+		collectorSettings, apiErr := CurrentInsertSettings([]byte(view.EffectiveConfig))
+		if apiErr != nil {
+			settings.Error = apiErr.Error()
+		} else {
+			settings.CollectorInsertSettings = collectorSettings
+		}
+		current = append(current, settings)
+	}
+	return current, nil
+}
+
+// SetInsertSettings stores new insert settings and starts deploying them to
+// the agents
+func (c *Controller) SetInsertSettings(
+	ctx context.Context, postable *PostableInsertSettings,
+) (*InsertSettingsResponse, *model.ApiError) {
+	if err := postable.Spec.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	settings, apiErr := c.repo.insert(ctx, userId, postable.Spec)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, apiErr = agentConf.StartNewVersion(
+		ctx, userId, agentConf.ElementTypeInsertSettings, []string{settings.Id},
+	)
+	if apiErr != nil {
+		c.repo.delete(ctx, settings.Id)
+		return nil, model.WrapApiError(apiErr, "failed to start new clickhouse insert settings config version")
+	}
+
+	return c.GetInsertSettings(ctx)
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return InsertSettingsFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	settings, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+	if settings == nil {
+		return currentConfYaml, "", nil
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithInsertSettings(currentConfYaml, &settings.Spec)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for clickhouse insert settings")
+	}
+
+	rawSpec, err := json.Marshal(settings.Spec)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize clickhouse insert settings to JSON: %w", err,
+		))
+	}
+	return updatedConf, string(rawSpec), nil
+}
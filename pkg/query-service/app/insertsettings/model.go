@@ -0,0 +1,129 @@
+package insertsettings
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// guardrails for the batch processors, ClickHouse creates a part per
+// insert so small batches without async inserts overload its merges
+const (
+	minBatchSize    = 100
+	maxBatchSize    = 200000
+	smallBatchSize  = 10000
+	minBatchTimeout = 200 * time.Millisecond
+	maxBatchTimeout = time.Minute
+)
+
+// InsertSettings are the settings the managed collectors write to
+// ClickHouse with. Every change is stored as a new revision deployed via
+// agentConf.
+type InsertSettings struct {
+	Id        string             `json:"id" db:"id"`
+	Spec      InsertSettingsSpec `json:"spec" db:"spec_json"`
+	CreatedBy string             `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time          `json:"createdAt" db:"created_at"`
+}
+
+// InsertSettingsSpec configures the ClickHouse exporters of the agents and
+// the batch processors of their pipelines exporting to ClickHouse. Unset
+// fields keep the collectors' values.
+type InsertSettingsSpec struct {
+	// AsyncInsert and WaitForAsyncInsert are passed to ClickHouse as settings
+	// of the exporters' connections
+	AsyncInsert        *bool `json:"asyncInsert,omitempty"`
+	WaitForAsyncInsert *bool `json:"waitForAsyncInsert,omitempty"`
+
+	Batch *BatchConfig `json:"batch,omitempty"`
+}
+
+// BatchConfig maps to the batch processor settings, the timeout is a
+// duration like 5s
+type BatchConfig struct {
+	SendBatchSize    int    `json:"sendBatchSize,omitempty"`
+	SendBatchMaxSize int    `json:"sendBatchMaxSize,omitempty"`
+	Timeout          string `json:"timeout,omitempty"`
+}
+
+// For serializing from db
+func (s *InsertSettingsSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s InsertSettingsSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize insert settings spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *InsertSettingsSpec) IsValid() error {
+	if s.AsyncInsert == nil && s.WaitForAsyncInsert == nil && s.Batch == nil {
+		return fmt.Errorf("asyncInsert, waitForAsyncInsert or batch is required")
+	}
+
+	if s.WaitForAsyncInsert != nil && (s.AsyncInsert == nil || !*s.AsyncInsert) {
+		return fmt.Errorf("waitForAsyncInsert can only be set along with asyncInsert enabled")
+	}
+
+	if b := s.Batch; b != nil {
+		if b.SendBatchSize == 0 && b.SendBatchMaxSize == 0 && b.Timeout == "" {
+			return fmt.Errorf("batch requires sendBatchSize, sendBatchMaxSize or timeout")
+		}
+		for field, size := range map[string]int{
+			"sendBatchSize":    b.SendBatchSize,
+			"sendBatchMaxSize": b.SendBatchMaxSize,
+		} {
+			if size != 0 && (size < minBatchSize || size > maxBatchSize) {
+				return fmt.Errorf("%s must be between %d and %d", field, minBatchSize, maxBatchSize)
+			}
+		}
+		if b.SendBatchMaxSize != 0 && b.SendBatchMaxSize < b.SendBatchSize {
+			return fmt.Errorf("sendBatchMaxSize can't be less than sendBatchSize")
+		}
+		if b.Timeout != "" {
+			timeout, err := time.ParseDuration(b.Timeout)
+			if err != nil {
+				return fmt.Errorf("timeout must be a duration like 5s, got %s", b.Timeout)
+			}
+			if timeout < minBatchTimeout || timeout > maxBatchTimeout {
+				return fmt.Errorf("timeout must be between %s and %s", minBatchTimeout, maxBatchTimeout)
+			}
+		}
+	}
+	return nil
+}
+
+// Warnings describe the settings that are valid but likely to hurt
+// ingestion
+func (s *InsertSettingsSpec) Warnings() []string {
+	warnings := []string{}
+	async := s.AsyncInsert != nil && *s.AsyncInsert
+	if async && s.WaitForAsyncInsert != nil && !*s.WaitForAsyncInsert {
+		warnings = append(warnings,
+			"inserts are acknowledged before being written, data buffered by ClickHouse is lost if it restarts",
+		)
+	}
+	if !async && s.Batch != nil && s.Batch.SendBatchSize != 0 && s.Batch.SendBatchSize < smallBatchSize {
+		warnings = append(warnings, fmt.Sprintf(
+			"batches smaller than %d without async inserts create many parts in ClickHouse", smallBatchSize,
+		))
+	}
+	return warnings
+}
+
+type PostableInsertSettings struct {
+	Spec InsertSettingsSpec `json:"spec"`
+}
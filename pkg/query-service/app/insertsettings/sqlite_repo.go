@@ -0,0 +1,114 @@
+package insertsettings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS clickhouse_insert_settings(
+			id TEXT PRIMARY KEY,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure clickhouse insert settings schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for clickhouse insert settings: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+// getByVersion returns the insert settings of a given agent config version,
+// nil if the version has none
+func (r *Repo) getByVersion(ctx context.Context, version int) (*InsertSettings, *model.ApiError) {
+	settings := []InsertSettings{}
+
+	err := r.db.SelectContext(ctx, &settings, `
+		SELECT s.id, s.spec_json, s.created_by, s.created_at
+		FROM clickhouse_insert_settings s,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE s.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+	`, agentConf.ElementTypeInsertSettings, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query clickhouse insert settings for version %d: %w", version, err,
+		))
+	}
+
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return &settings[0], nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, spec InsertSettingsSpec,
+) (*InsertSettings, *model.ApiError) {
+	settings := &InsertSettings{
+		Id:        uuid.NewString(),
+		Spec:      spec,
+		CreatedBy: userId,
+		CreatedAt: time.Now(),
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO clickhouse_insert_settings (id, spec_json, created_by, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, settings.Id, settings.Spec, settings.CreatedBy, settings.CreatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert clickhouse insert settings: %w", err,
+		))
+	}
+
+	return settings, nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM clickhouse_insert_settings WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete clickhouse insert settings %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package k8stimeline
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	CategoryScheduled        = "scheduled"
+	CategoryFailedScheduling = "failed_scheduling"
+	CategoryImage            = "image"
+	CategoryCreated          = "created"
+	CategoryStarted          = "started"
+	CategoryKilled           = "killed"
+	CategoryOOMKilled        = "oom_killed"
+	CategoryBackOff          = "back_off"
+	CategoryUnhealthy        = "unhealthy"
+	CategoryEvicted          = "evicted"
+	CategoryOther            = "other"
+)
+
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+)
+
+var reasonCategories = map[string]string{
+	"Scheduled":        CategoryScheduled,
+	"FailedScheduling": CategoryFailedScheduling,
+	"Pulling":          CategoryImage,
+	"Pulled":           CategoryImage,
+	"Failed":           CategoryImage,
+	"ErrImagePull":     CategoryImage,
+	"Created":          CategoryCreated,
+	"Started":          CategoryStarted,
+	"Killing":          CategoryKilled,
+	"BackOff":          CategoryBackOff,
+	"Unhealthy":        CategoryUnhealthy,
+	"Evicted":          CategoryEvicted,
+}
+
+type Event struct {
+	Timestamp int64  `json:"timestamp"`
+	Reason    string `json:"reason"`
+	Category  string `json:"category"`
+	Container string `json:"container,omitempty"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// Phase is a bar in the timeline of a pod, container is empty for the
+// phases of the pod itself
+type Phase struct {
+	State     string `json:"state"`
+	Container string `json:"container,omitempty"`
+	Start     int64  `json:"start"`
+	End       int64  `json:"end"`
+}
+
+type PodTimeline struct {
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Restarts  int     `json:"restarts"`
+	OOMKills  int     `json:"oomKills"`
+	Phases    []Phase `json:"phases"`
+	Events    []Event `json:"events"`
+}
+
+var containerFieldPathRegex = regexp.MustCompile(`^spec\.(?:init|ephemeral)?[cC]ontainers\{(.+)\}$`)
+
+func category(reason string) string {
+	if c, ok := reasonCategories[reason]; ok {
+		return c
+	}
+	if strings.Contains(strings.ToUpper(reason), "OOM") {
+		return CategoryOOMKilled
+	}
+	return CategoryOther
+}
+
+func containerName(fieldPath string) string {
+	if m := containerFieldPathRegex.FindStringSubmatch(fieldPath); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// BuildPodTimelines groups k8s events by pod and derives the phases of the
+// pods and their containers. Events are expected oldest first, timestamps in
+// the timelines are in milliseconds and phases still in progress end at end.
+func BuildPodTimelines(events []model.K8sEvent, end int64) []PodTimeline {
+	timelines := map[string]*PodTimeline{}
+	keys := []string{}
+
+	for _, e := range events {
+		key := e.Namespace + "/" + e.ObjectName
+		timeline, ok := timelines[key]
+		if !ok {
+			timeline = &PodTimeline{
+				Namespace: e.Namespace,
+				Pod:       e.ObjectName,
+				Phases:    []Phase{},
+				Events:    []Event{},
+			}
+			timelines[key] = timeline
+			keys = append(keys, key)
+		}
+		timeline.Events = append(timeline.Events, Event{
+			Timestamp: int64(e.Timestamp / 1000000),
+			Reason:    e.Reason,
+			Category:  category(e.Reason),
+			Container: containerName(e.FieldPath),
+			Severity:  e.Severity,
+			Message:   e.Message,
+		})
+	}
+
+	sort.Strings(keys)
+	result := []PodTimeline{}
+	for _, key := range keys {
+		timeline := timelines[key]
+		buildPhases(timeline, end)
+		result = append(result, *timeline)
+	}
+	return result
+}
+
+func buildPhases(timeline *PodTimeline, end int64) {
+	// the pod is pending from when it gets scheduled till a container starts
+	pendingSince := int64(-1)
+	podStarted := false
+
+	runningSince := map[string]int64{}
+	containers := []string{}
+	started := map[string]bool{}
+
+	endRunning := func(container string, ts int64) {
+		if since, ok := runningSince[container]; ok {
+			timeline.Phases = append(timeline.Phases, Phase{
+				State: StateRunning, Container: container, Start: since, End: ts,
+			})
+			delete(runningSince, container)
+		}
+	}
+
+	for _, e := range timeline.Events {
+		switch e.Category {
+		case CategoryScheduled, CategoryFailedScheduling:
+			if pendingSince < 0 && !podStarted {
+				pendingSince = e.Timestamp
+			}
+
+		case CategoryStarted:
+			if pendingSince >= 0 {
+				timeline.Phases = append(timeline.Phases, Phase{
+					State: StatePending, Start: pendingSince, End: e.Timestamp,
+				})
+				pendingSince = -1
+			}
+			podStarted = true
+
+			if started[e.Container] {
+				timeline.Restarts++
+			} else {
+				started[e.Container] = true
+				containers = append(containers, e.Container)
+			}
+			endRunning(e.Container, e.Timestamp)
+			runningSince[e.Container] = e.Timestamp
+
+		case CategoryKilled, CategoryBackOff, CategoryEvicted, CategoryOOMKilled:
+			if e.Category == CategoryOOMKilled {
+				timeline.OOMKills++
+			}
+			if e.Container != "" {
+				endRunning(e.Container, e.Timestamp)
+			} else {
+				for _, c := range containers {
+					endRunning(c, e.Timestamp)
+				}
+			}
+		}
+	}
+
+	if pendingSince >= 0 {
+		timeline.Phases = append(timeline.Phases, Phase{
+			State: StatePending, Start: pendingSince, End: end,
+		})
+	}
+	for _, c := range containers {
+		endRunning(c, end)
+	}
+
+	sort.SliceStable(timeline.Phases, func(i, j int) bool {
+		return timeline.Phases[i].Start < timeline.Phases[j].Start
+	})
+}
@@ -0,0 +1,53 @@
+package k8stimeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestBuildPodTimelines(t *testing.T) {
+	require := require.New(t)
+
+	event := func(ms uint64, pod string, reason string, fieldPath string) model.K8sEvent {
+		return model.K8sEvent{
+			Timestamp:  ms * 1000000,
+			Namespace:  "default",
+			ObjectKind: "Pod",
+			ObjectName: pod,
+			FieldPath:  fieldPath,
+			Reason:     reason,
+		}
+	}
+	app := "spec.containers{app}"
+
+	timelines := BuildPodTimelines([]model.K8sEvent{
+		event(100, "web-1", "Scheduled", ""),
+		event(110, "web-1", "Pulled", app),
+		event(120, "web-1", "Created", app),
+		event(130, "web-1", "Started", app),
+		event(200, "web-1", "OOMKilled", app),
+		event(210, "web-1", "BackOff", app),
+		event(250, "web-1", "Started", app),
+		event(150, "api-1", "FailedScheduling", ""),
+	}, 300)
+
+	require.Equal(2, len(timelines))
+	require.Equal("api-1", timelines[0].Pod)
+	require.Equal([]Phase{
+		{State: StatePending, Start: 150, End: 300},
+	}, timelines[0].Phases)
+
+	web := timelines[1]
+	require.Equal("web-1", web.Pod)
+	require.Equal(1, web.Restarts)
+	require.Equal(1, web.OOMKills)
+	require.Equal([]Phase{
+		{State: StatePending, Start: 100, End: 130},
+		{State: StateRunning, Container: "app", Start: 130, End: 200},
+		{State: StateRunning, Container: "app", Start: 250, End: 300},
+	}, web.Phases)
+	require.Equal(CategoryOOMKilled, web.Events[4].Category)
+	require.Equal("app", web.Events[4].Container)
+}
@@ -0,0 +1,137 @@
+package kafkareceivers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	componentNamePrefix = "signoz_kafka"
+
+	lagCollectionInterval = "30s"
+)
+
+// GenerateCollectorConfigWithKafkaReceivers adds a kafka receiver for each
+// enabled kafka receiver to the pipeline of its signal. A kafkametrics
+// receiver scraping the consumer lag of the receiver's group and topic is
+// added to the metrics pipeline along with it, so that ingestion backpressure
+// shows up as the kafka_consumer_group_lag metric. Receivers are removed
+// when there are no enabled kafka receivers.
+func GenerateCollectorConfigWithKafkaReceivers(
+	config []byte, kafkaReceivers []KafkaReceiver,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	receivers, ok := c["receivers"].(map[string]interface{})
+	if !ok || receivers == nil {
+		receivers = map[string]interface{}{}
+	}
+	for name := range receivers {
+		if isKafkaReceiverComponent(name) {
+			delete(receivers, name)
+		}
+	}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		current, _ := pipeline["receivers"].([]interface{})
+		updated := []interface{}{}
+		for _, r := range current {
+			if name, ok := r.(string); !ok || !isKafkaReceiverComponent(name) {
+				updated = append(updated, r)
+			}
+		}
+		pipeline["receivers"] = updated
+	}
+
+	for _, kr := range kafkaReceivers {
+		if !kr.Spec.Enabled {
+			continue
+		}
+		spec := kr.Spec
+		spec.setDefaults()
+
+		pipeline, ok := pipelines[spec.Signal].(map[string]interface{})
+		if !ok {
+			// the agent doesn't process this signal
+			continue
+		}
+
+		brokers := []interface{}{}
+		for _, b := range spec.Brokers {
+			brokers = append(brokers, b)
+		}
+
+		name := componentNamePrefix + "_" + kr.Id
+		receiverName := "kafka/" + name
+		receivers[receiverName] = map[string]interface{}{
+			"brokers":          brokers,
+			"topic":            spec.Topic,
+			"group_id":         spec.GroupId,
+			"encoding":         spec.Encoding,
+			"initial_offset":   spec.InitialOffset,
+			"protocol_version": spec.ProtocolVersion,
+		}
+		pipeline["receivers"] = append(pipeline["receivers"].([]interface{}), receiverName)
+
+		metricsPipeline, ok := pipelines["metrics"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		lagReceiverName := "kafkametrics/" + name
+		receivers[lagReceiverName] = map[string]interface{}{
+			"brokers":             brokers,
+			"protocol_version":    spec.ProtocolVersion,
+			"scrapers":            []interface{}{"consumers"},
+			"group_match":         exactMatch(spec.GroupId),
+			"topic_match":         exactMatch(spec.Topic),
+			"collection_interval": lagCollectionInterval,
+		}
+		metricsPipeline["receivers"] = append(metricsPipeline["receivers"].([]interface{}), lagReceiverName)
+	}
+
+	if len(receivers) > 0 {
+		c["receivers"] = receivers
+	} else {
+		delete(c, "receivers")
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+func isKafkaReceiverComponent(name string) bool {
+	_, componentName, _ := strings.Cut(name, "/")
+	return strings.HasPrefix(componentName, componentNamePrefix)
+}
+
+func exactMatch(s string) string {
+	// escape `$`s so that they do not get treated as env vars when loading collector config
+	return strings.ReplaceAll("^"+regexp.QuoteMeta(s)+"$", "$", "$$")
+}
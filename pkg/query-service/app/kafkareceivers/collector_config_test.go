@@ -0,0 +1,85 @@
+package kafkareceivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhouselogsexporter: {}
+  clickhousemetricswrite: {}
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhouselogsexporter]
+    metrics:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousemetricswrite]
+`
+
+type testConf struct {
+	Receivers map[string]map[string]interface{} `yaml:"receivers"`
+	Service   struct {
+		Pipelines map[string]struct {
+			Receivers []string `yaml:"receivers"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func TestGenerateCollectorConfigWithKafkaReceivers(t *testing.T) {
+	require := require.New(t)
+
+	kafkaReceivers := []KafkaReceiver{
+		{
+			Id: "logs", Name: "logs",
+			Spec: KafkaReceiverSpec{
+				Enabled: true, Signal: "logs", Brokers: []string{"kafka:9092"}, Topic: "otlp_logs",
+			},
+		},
+		{
+			Id: "traces", Name: "traces",
+			Spec: KafkaReceiverSpec{
+				Enabled: true, Signal: "traces", Brokers: []string{"kafka:9092"}, Topic: "otlp_spans",
+			},
+		},
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithKafkaReceivers([]byte(testCollectorConf), kafkaReceivers)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+
+	require.Equal([]string{"otlp", "kafka/signoz_kafka_logs"}, conf.Service.Pipelines["logs"].Receivers)
+	require.Equal(
+		[]string{"otlp", "kafkametrics/signoz_kafka_logs"}, conf.Service.Pipelines["metrics"].Receivers,
+		"lag should be monitored for kafka receivers",
+	)
+	require.Equal("signoz", conf.Receivers["kafka/signoz_kafka_logs"]["group_id"])
+	require.Equal("^signoz$$", conf.Receivers["kafkametrics/signoz_kafka_logs"]["group_match"])
+	require.Equal("^otlp_logs$$", conf.Receivers["kafkametrics/signoz_kafka_logs"]["topic_match"])
+
+	_, exists := conf.Receivers["kafka/signoz_kafka_traces"]
+	require.False(exists, "receivers should not be added for signals without a pipeline")
+
+	// kafka receivers should get cleaned up when disabled
+	kafkaReceivers[0].Spec.Enabled = false
+	updated, apiErr = GenerateCollectorConfigWithKafkaReceivers(updated, kafkaReceivers)
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(1, len(conf.Receivers))
+	require.Equal([]string{"otlp"}, conf.Service.Pipelines["logs"].Receivers)
+	require.Equal([]string{"otlp"}, conf.Service.Pipelines["metrics"].Receivers)
+}
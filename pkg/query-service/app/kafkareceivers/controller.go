@@ -0,0 +1,202 @@
+package kafkareceivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const KafkaReceiversFeatureType agentConf.AgentFeatureType = "kafka_receivers"
+
+// Controller manages the kafka topics agents consume telemetry from and
+// deploys the receiver config derived from them to agents via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create kafka receivers repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type KafkaReceiversResponse struct {
+	*agentConf.ConfigVersion
+
+	KafkaReceivers []KafkaReceiver `json:"kafkaReceivers"`
+}
+
+func (c *Controller) ListKafkaReceivers(ctx context.Context) (
+	*KafkaReceiversResponse, *model.ApiError,
+) {
+	kafkaReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeKafkaReceivers)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest kafka receivers config version")
+	}
+
+	return &KafkaReceiversResponse{
+		ConfigVersion:  latest,
+		KafkaReceivers: kafkaReceivers,
+	}, nil
+}
+
+func (c *Controller) GetKafkaReceiver(ctx context.Context, id string) (
+	*KafkaReceiver, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateKafkaReceiver stores a new kafka receiver and starts deploying
+// an agent config that consumes from its topic
+func (c *Controller) CreateKafkaReceiver(
+	ctx context.Context, postable *PostableKafkaReceiver,
+) (*KafkaReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	kafkaReceiver, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, kafkaReceiver.Id)
+		return nil, apiErr
+	}
+
+	return kafkaReceiver, nil
+}
+
+// UpdateKafkaReceiver replaces the name and spec of a kafka receiver and
+// starts deploying the updated agent config
+func (c *Controller) UpdateKafkaReceiver(
+	ctx context.Context, id string, postable *PostableKafkaReceiver,
+) (*KafkaReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.update(ctx, existing.UpdatedBy, existing)
+		return nil, apiErr
+	}
+
+	return &updated, nil
+}
+
+// DeleteKafkaReceiver removes a kafka receiver and starts deploying
+// an agent config without it
+func (c *Controller) DeleteKafkaReceiver(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	kafkaReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(kafkaReceivers))
+	for i, d := range kafkaReceivers {
+		elements[i] = d.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeKafkaReceivers, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new kafka receivers config version")
+	}
+	return nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return KafkaReceiversFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	kafkaReceivers, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithKafkaReceivers(currentConfYaml, kafkaReceivers)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for kafka receivers")
+	}
+
+	rawKafkaReceivers, err := json.Marshal(kafkaReceivers)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize kafka receivers to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawKafkaReceivers), nil
+}
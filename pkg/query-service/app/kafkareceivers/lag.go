@@ -0,0 +1,94 @@
+package kafkareceivers
+
+import (
+	"sort"
+	"strconv"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// metric reported by the kafkametrics receivers added along with kafka receivers
+const consumerLagMetric = "kafka_consumer_group_lag"
+
+type ConsumerLag struct {
+	Group     string  `json:"group"`
+	Topic     string  `json:"topic"`
+	Partition string  `json:"partition"`
+	Lag       float64 `json:"lag"`
+}
+
+// ConsumerLagQueryRangeParams queries the latest consumer lag reported
+// in the given time range per consumer group, topic and partition
+func ConsumerLagQueryRangeParams(start, end int64) *v3.QueryRangeParamsV3 {
+	groupBy := []v3.AttributeKey{}
+	for _, key := range []string{"group", "topic", "partition"} {
+		groupBy = append(groupBy, v3.AttributeKey{
+			Key:      key,
+			DataType: v3.AttributeKeyDataTypeString,
+			Type:     v3.AttributeKeyTypeTag,
+		})
+	}
+
+	return &v3.QueryRangeParamsV3{
+		Start: start,
+		End:   end,
+		Step:  60,
+		CompositeQuery: &v3.CompositeQuery{
+			PanelType: v3.PanelTypeValue,
+			QueryType: v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:    "A",
+					Expression:   "A",
+					DataSource:   v3.DataSourceMetrics,
+					StepInterval: 60,
+					AggregateAttribute: v3.AttributeKey{
+						Key:      consumerLagMetric,
+						DataType: v3.AttributeKeyDataTypeFloat64,
+						Type:     v3.AttributeKeyType("Gauge"),
+						IsColumn: true,
+					},
+					AggregateOperator: v3.AggregateOperatorMax,
+					GroupBy:           groupBy,
+					ReduceTo:          v3.ReduceToOperatorLast,
+					Filters:           &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{}},
+				},
+			},
+		},
+	}
+}
+
+// ConsumerLagFromResults reads the results of the consumer lag query,
+// the most lagging partitions come first
+func ConsumerLagFromResults(results []*v3.Result) []ConsumerLag {
+	lags := []ConsumerLag{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			if len(series.Points) == 0 {
+				continue
+			}
+			lags = append(lags, ConsumerLag{
+				Group:     series.Labels["group"],
+				Topic:     series.Labels["topic"],
+				Partition: series.Labels["partition"],
+				Lag:       series.Points[len(series.Points)-1].Value,
+			})
+		}
+	}
+
+	sort.SliceStable(lags, func(i, j int) bool {
+		if lags[i].Lag != lags[j].Lag {
+			return lags[i].Lag > lags[j].Lag
+		}
+		if lags[i].Group != lags[j].Group {
+			return lags[i].Group < lags[j].Group
+		}
+		if lags[i].Topic != lags[j].Topic {
+			return lags[i].Topic < lags[j].Topic
+		}
+		pi, _ := strconv.Atoi(lags[i].Partition)
+		pj, _ := strconv.Atoi(lags[j].Partition)
+		return pi < pj
+	})
+	return lags
+}
@@ -0,0 +1,114 @@
+package kafkareceivers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	defaultConsumerGroup   = "signoz"
+	defaultEncoding        = "otlp_proto"
+	defaultInitialOffset   = "latest"
+	defaultProtocolVersion = "2.0.0"
+)
+
+var supportedSignals = []string{"logs", "traces", "metrics"}
+
+// KafkaReceiver makes agents consume telemetry of a signal from a kafka topic
+type KafkaReceiver struct {
+	Id        string            `json:"id" db:"id"`
+	Name      string            `json:"name" db:"name"`
+	Spec      KafkaReceiverSpec `json:"spec" db:"spec_json"`
+	CreatedBy string            `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedBy string            `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time         `json:"updatedAt" db:"updated_at"`
+}
+
+type KafkaReceiverSpec struct {
+	Enabled bool `json:"enabled"`
+
+	// logs, traces or metrics
+	Signal  string   `json:"signal"`
+	Brokers []string `json:"brokers"`
+	Topic   string   `json:"topic"`
+
+	GroupId         string `json:"groupId,omitempty"`
+	Encoding        string `json:"encoding,omitempty"`
+	InitialOffset   string `json:"initialOffset,omitempty"`
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+}
+
+// For serializing from db
+func (s *KafkaReceiverSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s KafkaReceiverSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize kafka receiver spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *KafkaReceiverSpec) setDefaults() {
+	if s.GroupId == "" {
+		s.GroupId = defaultConsumerGroup
+	}
+	if s.Encoding == "" {
+		s.Encoding = defaultEncoding
+	}
+	if s.InitialOffset == "" {
+		s.InitialOffset = defaultInitialOffset
+	}
+	if s.ProtocolVersion == "" {
+		s.ProtocolVersion = defaultProtocolVersion
+	}
+}
+
+type PostableKafkaReceiver struct {
+	Name string            `json:"name"`
+	Spec KafkaReceiverSpec `json:"spec"`
+}
+
+func (p *PostableKafkaReceiver) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("kafka receiver name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *KafkaReceiverSpec) IsValid() error {
+	if !slices.Contains(supportedSignals, s.Signal) {
+		return fmt.Errorf("signal must be one of %s", strings.Join(supportedSignals, ", "))
+	}
+	if len(s.Brokers) == 0 {
+		return fmt.Errorf("at least one broker is required")
+	}
+	for _, broker := range s.Brokers {
+		if strings.TrimSpace(broker) == "" {
+			return fmt.Errorf("broker addresses can not be empty")
+		}
+	}
+	if strings.TrimSpace(s.Topic) == "" {
+		return fmt.Errorf("topic is required")
+	}
+	if s.InitialOffset != "" && s.InitialOffset != "latest" && s.InitialOffset != "earliest" {
+		return fmt.Errorf("initialOffset must be either latest or earliest")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package kafkareceivers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS kafka_receivers(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure kafka receivers schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for kafka receivers: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]KafkaReceiver, *model.ApiError) {
+	kafkaReceivers := []KafkaReceiver{}
+
+	err := r.db.SelectContext(ctx, &kafkaReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM kafka_receivers
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query kafka receivers: %w", err,
+		))
+	}
+	return kafkaReceivers, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*KafkaReceiver, *model.ApiError) {
+	kafkaReceivers := []KafkaReceiver{}
+
+	err := r.db.SelectContext(ctx, &kafkaReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM kafka_receivers
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query kafka receiver %s: %w", id, err,
+		))
+	}
+
+	if len(kafkaReceivers) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("kafka receiver %s not found", id))
+	}
+	return &kafkaReceivers[0], nil
+}
+
+// getByVersion returns kafka receivers associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]KafkaReceiver, *model.ApiError) {
+	kafkaReceivers := []KafkaReceiver{}
+
+	err := r.db.SelectContext(ctx, &kafkaReceivers, `
+		SELECT k.id, k.name, k.spec_json, k.created_by, k.created_at, k.updated_by, k.updated_at
+		FROM kafka_receivers k,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE k.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY k.name
+	`, agentConf.ElementTypeKafkaReceivers, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query kafka receivers for version %d: %w", version, err,
+		))
+	}
+	return kafkaReceivers, nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM kafka_receivers WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query kafka receivers: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a kafka receiver named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableKafkaReceiver,
+) (*KafkaReceiver, *model.ApiError) {
+	now := time.Now()
+	kafkaReceiver := &KafkaReceiver{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, kafkaReceiver.Name, kafkaReceiver.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO kafka_receivers (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		kafkaReceiver.Id, kafkaReceiver.Name, kafkaReceiver.Spec,
+		kafkaReceiver.CreatedBy, kafkaReceiver.CreatedAt,
+		kafkaReceiver.UpdatedBy, kafkaReceiver.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert kafka receiver: %w", err,
+		))
+	}
+
+	return kafkaReceiver, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, kafkaReceiver *KafkaReceiver,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, kafkaReceiver.Name, kafkaReceiver.Id); apiErr != nil {
+		return apiErr
+	}
+
+	kafkaReceiver.UpdatedBy = userId
+	kafkaReceiver.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE kafka_receivers
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		kafkaReceiver.Name, kafkaReceiver.Spec,
+		kafkaReceiver.UpdatedBy, kafkaReceiver.UpdatedAt, kafkaReceiver.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update kafka receiver %s: %w", kafkaReceiver.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM kafka_receivers WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete kafka receiver %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package keyusage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	usageBufferSize    = 10000
+	usageFlushInterval = 30 * time.Second
+
+	// uses older than this weigh less than a thousandth of a recent one in
+	// ranking, they are deleted
+	usageRetention     = 90 * 24 * time.Hour
+	usagePruneInterval = time.Hour
+
+	// longer values are rarely typed again, they are not worth tracking
+	maxTrackedValueLength = 256
+)
+
+// operators whose values are the ones users pick in autocomplete
+var valueOperators = []v3.FilterOperator{
+	v3.FilterOperatorEqual, v3.FilterOperatorNotEqual, v3.FilterOperatorIn, v3.FilterOperatorNotIn,
+}
+
+// Controller tracks which attribute keys and values each org uses in its
+// queries, and ranks autocomplete suggestions by that usage. Uses are
+// counted in memory and stored periodically so that queries don't wait
+// on the db.
+type Controller struct {
+	repo    *Repo
+	uses    chan keyUse
+	forgets chan forgetRequest
+}
+
+// forgetRequest deletes the usage of a value, pending uses included
+type forgetRequest struct {
+	dataSources []v3.DataSource
+	key         string
+	value       string
+	done        chan error
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create attribute key usage repo: %w", err)
+	}
+
+	c := &Controller{
+		repo:    repo,
+		uses:    make(chan keyUse, usageBufferSize),
+		forgets: make(chan forgetRequest),
+	}
+	go c.run()
+	return c, nil
+}
+
+func (c *Controller) run() {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(usagePruneInterval)
+	defer pruneTicker.Stop()
+
+	pending := map[usageId]usageCount{}
+	for {
+		select {
+		case use := <-c.uses:
+			count := pending[use.id]
+			count.uses++
+			count.lastUsedAt = time.Now().UTC()
+			count.attribute = use.attribute
+			pending[use.id] = count
+		case req := <-c.forgets:
+			for id := range pending {
+				if id.key == req.key && id.value == req.value && slices.Contains(req.dataSources, id.dataSource) {
+					delete(pending, id)
+				}
+			}
+			req.done <- c.repo.deleteValue(context.Background(), req.dataSources, req.key, req.value)
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+			if err := c.repo.add(context.Background(), pending); err != nil {
+				zap.L().Warn("could not store attribute key usage", zap.Error(err))
+			}
+			pending = map[usageId]usageCount{}
+		case <-pruneTicker.C:
+			if err := c.repo.prune(context.Background(), time.Now().UTC().Add(-usageRetention)); err != nil {
+				zap.L().Warn("could not prune attribute key usage", zap.Error(err))
+			}
+		}
+	}
+}
+
+// RecordQuery counts the keys filtered and grouped by in the builder
+// queries of a request, along with the values they are compared to. Only
+// queries made by users count, e.g. not the ones of alert rules.
+func (c *Controller) RecordQuery(ctx context.Context, params *v3.QueryRangeParamsV3) {
+	user := common.GetUserFromContext(ctx)
+	if user == nil || params.CompositeQuery == nil {
+		return
+	}
+	for _, use := range usedKeys(user.OrgId, params.CompositeQuery.BuilderQueries) {
+		select {
+		case c.uses <- use:
+		default:
+			// usage is best effort, drop it rather than slow down queries
+		}
+	}
+}
+
+func usedKeys(orgId string, queries map[string]*v3.BuilderQuery) []keyUse {
+	uses := []keyUse{}
+	add := func(dataSource v3.DataSource, key v3.AttributeKey, value string) {
+		if key.Key != "" && len(value) <= maxTrackedValueLength {
+			uses = append(uses, keyUse{
+				id:        usageId{orgId: orgId, dataSource: dataSource, key: key.Key, value: value},
+				attribute: key,
+			})
+		}
+	}
+
+	for _, q := range queries {
+		if q == nil {
+			continue
+		}
+		for _, groupBy := range q.GroupBy {
+			add(q.DataSource, groupBy, "")
+		}
+		if q.Filters == nil {
+			continue
+		}
+		for _, item := range q.Filters.Items {
+			add(q.DataSource, item.Key, "")
+			if !slices.Contains(valueOperators, item.Operator) {
+				continue
+			}
+			switch value := item.Value.(type) {
+			case string:
+				add(q.DataSource, item.Key, value)
+			case []interface{}:
+				for _, v := range value {
+					if s, ok := v.(string); ok {
+						add(q.DataSource, item.Key, s)
+					}
+				}
+			}
+		}
+	}
+	return uses
+}
+
+// ForgetValue deletes the usage of a value of a key in every org, e.g. once
+// the telemetry having the value is deleted
+func (c *Controller) ForgetValue(
+	ctx context.Context, dataSources []v3.DataSource, key string, value string,
+) *model.ApiError {
+	req := forgetRequest{dataSources: dataSources, key: key, value: value, done: make(chan error, 1)}
+	select {
+	case c.forgets <- req:
+	case <-ctx.Done():
+		return model.InternalError(ctx.Err())
+	}
+	select {
+	case err := <-req.done:
+		if err != nil {
+			return model.InternalError(err)
+		}
+		return nil
+	case <-ctx.Done():
+		return model.InternalError(ctx.Err())
+	}
+}
+
+// GetUsage lists the most used keys of a data source in the org of the
+// user in ctx, or the most used values of key if set
+func (c *Controller) GetUsage(
+	ctx context.Context, dataSource v3.DataSource, key string, limit int,
+) ([]KeyUsage, *model.ApiError) {
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return nil, model.UnauthorizedError(fmt.Errorf("failed to get user from context"))
+	}
+
+	var usages []KeyUsage
+	var apiErr *model.ApiError
+	if key == "" {
+		usages, apiErr = c.repo.listKeys(ctx, user.OrgId, dataSource)
+	} else {
+		usages, apiErr = c.repo.listValues(ctx, user.OrgId, dataSource, key)
+	}
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	usages = rankUsages(usages, time.Now())
+	if limit > 0 && len(usages) > limit {
+		usages = usages[:limit]
+	}
+	return usages, nil
+}
+
+// RankKeys orders the keys autocompleted for a request by their usage in
+// the org of the user in ctx, keeping unused keys in their order after the
+// used ones. The used keys matching the search text are added as the keys
+// listed are only the first ones found, except for metrics whose keys
+// depend on the metric.
+func (c *Controller) RankKeys(
+	ctx context.Context, req *v3.FilterAttributeKeyRequest, keys []v3.AttributeKey,
+) []v3.AttributeKey {
+	usages, apiErr := c.GetUsage(ctx, req.DataSource, "", 0)
+	if apiErr != nil {
+		zap.L().Warn("could not rank attribute keys by usage", zap.Error(apiErr.Err))
+		return keys
+	}
+
+	ranked := append([]v3.AttributeKey{}, keys...)
+	if req.DataSource != v3.DataSourceMetrics {
+		listed := map[string]bool{}
+		for _, key := range keys {
+			listed[key.Key] = true
+		}
+		for _, usage := range usages {
+			// keys used before their type was tracked can't be listed
+			if !listed[usage.Key] && usage.KeyDataType != "" && matchesSearch(usage.Key, req.SearchText) {
+				ranked = append(ranked, usage.attributeKey())
+			}
+		}
+	}
+
+	ranked = rankBy(ranked, scores(usages, func(u KeyUsage) string { return u.Key }), func(k v3.AttributeKey) string {
+		return k.Key
+	})
+	if req.Limit > 0 && len(ranked) > req.Limit {
+		ranked = ranked[:req.Limit]
+	}
+	return ranked
+}
+
+// RankValues orders the string values autocompleted for a request by their
+// usage in the org of the user in ctx. The used values matching the search
+// text are added like in RankKeys.
+func (c *Controller) RankValues(
+	ctx context.Context, req *v3.FilterAttributeValueRequest, values []string,
+) []string {
+	usages, apiErr := c.GetUsage(ctx, req.DataSource, req.FilterAttributeKey, 0)
+	if apiErr != nil {
+		zap.L().Warn("could not rank attribute values by usage", zap.Error(apiErr.Err))
+		return values
+	}
+
+	ranked := append([]string{}, values...)
+	if req.DataSource != v3.DataSourceMetrics && req.FilterAttributeKeyDataType == v3.AttributeKeyDataTypeString {
+		for _, usage := range usages {
+			if !slices.Contains(values, usage.Value) && matchesSearch(usage.Value, req.SearchText) {
+				ranked = append(ranked, usage.Value)
+			}
+		}
+	}
+
+	ranked = rankBy(ranked, scores(usages, func(u KeyUsage) string { return u.Value }), func(v string) string {
+		return v
+	})
+	if req.Limit > 0 && len(ranked) > req.Limit {
+		ranked = ranked[:req.Limit]
+	}
+	return ranked
+}
+
+// matchesSearch matches like the case insensitive search of autocomplete
+func matchesSearch(name string, searchText string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(searchText))
+}
+
+func rankUsages(usages []KeyUsage, now time.Time) []KeyUsage {
+	for i := range usages {
+		usages[i].Score = usages[i].score(now)
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Score != usages[j].Score {
+			return usages[i].Score > usages[j].Score
+		}
+		return usages[i].Key+usages[i].Value < usages[j].Key+usages[j].Value
+	})
+	return usages
+}
+
+func scores(usages []KeyUsage, name func(KeyUsage) string) map[string]float64 {
+	scores := map[string]float64{}
+	for _, u := range usages {
+		scores[name(u)] = u.Score
+	}
+	return scores
+}
+
+func rankBy[T any](items []T, scores map[string]float64, name func(T) string) []T {
+	ranked := append([]T{}, items...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[name(ranked[i])] > scores[name(ranked[j])]
+	})
+	return ranked
+}
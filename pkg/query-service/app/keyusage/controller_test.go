@@ -0,0 +1,181 @@
+package keyusage
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func newTestController(t *testing.T) *Controller {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	if err != nil {
+		t.Fatalf("could not create temp file for test db: %v", err)
+	}
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+
+	testDB, err := sqlx.Open("sqlite3", testDBFilePath)
+	if err != nil {
+		t.Fatalf("could not open test db sqlite file: %v", err)
+	}
+
+	controller, err := NewController(testDB)
+	if err != nil {
+		t.Fatalf("could not create attribute key usage controller: %v", err)
+	}
+	return controller
+}
+
+func TestKeyUsageRanking(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+
+	ctx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "user1", OrgId: "org1"},
+		},
+	)
+
+	queries := map[string]*v3.BuilderQuery{
+		"A": {
+			DataSource: v3.DataSourceLogs,
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "service.name"}, Operator: v3.FilterOperatorIn, Value: []interface{}{"checkout", "cart"}},
+				{Key: v3.AttributeKey{Key: "duration"}, Operator: v3.FilterOperatorGreaterThan, Value: "100"},
+			}},
+			GroupBy: []v3.AttributeKey{{Key: "k8s.pod.name"}},
+		},
+	}
+	ids := []usageId{}
+	for _, use := range usedKeys("org1", queries) {
+		ids = append(ids, use.id)
+	}
+	require.ElementsMatch([]usageId{
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "service.name"},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "service.name", value: "checkout"},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "service.name", value: "cart"},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "duration"},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "k8s.pod.name"},
+	}, ids, "values should only be tracked for equality filters")
+
+	now := time.Now().UTC()
+	monthAgo := now.Add(-30 * 24 * time.Hour)
+	logsKey := func(key string, value string) usageId {
+		return usageId{orgId: "org1", dataSource: v3.DataSourceLogs, key: key, value: value}
+	}
+	require.Nil(controller.repo.add(ctx, map[usageId]usageCount{
+		logsKey("service.name", ""): {uses: 10, lastUsedAt: now, attribute: v3.AttributeKey{
+			Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource,
+		}},
+		logsKey("k8s.pod.name", ""):                                      {uses: 40, lastUsedAt: monthAgo},
+		logsKey("duration", ""):                                          {uses: 3, lastUsedAt: now},
+		logsKey("service.name", "checkout"):                              {uses: 2, lastUsedAt: now},
+		{orgId: "org2", dataSource: v3.DataSourceLogs, key: "host.name"}: {uses: 100, lastUsedAt: now},
+	}))
+	// uses add up
+	require.Nil(controller.repo.add(ctx, map[usageId]usageCount{
+		logsKey("duration", ""): {uses: 2, lastUsedAt: now},
+	}))
+
+	usages, apiErr := controller.GetUsage(ctx, v3.DataSourceLogs, "", 0)
+	require.Nil(apiErr)
+	require.Len(usages, 3, "usage of other orgs should not be listed")
+	require.Equal("service.name", usages[0].Key)
+	require.Equal("duration", usages[1].Key)
+	require.Equal(int64(5), usages[1].Uses)
+	require.Equal("k8s.pod.name", usages[2].Key, "old uses should weigh less than recent ones")
+
+	keysReq := &v3.FilterAttributeKeyRequest{DataSource: v3.DataSourceLogs}
+	keys := controller.RankKeys(ctx, keysReq, []v3.AttributeKey{
+		{Key: "body"}, {Key: "duration"}, {Key: "host.name"}, {Key: "service.name"},
+	})
+	require.Equal([]v3.AttributeKey{
+		{Key: "service.name"}, {Key: "duration"}, {Key: "body"}, {Key: "host.name"},
+	}, keys, "unused keys should keep their order after the used ones")
+
+	// used keys which weren't listed are added if they match the search
+	keysReq.SearchText = "NAME"
+	keysReq.Limit = 2
+	keys = controller.RankKeys(ctx, keysReq, []v3.AttributeKey{{Key: "host.name"}, {Key: "k8s.node.name"}})
+	require.Equal([]v3.AttributeKey{
+		{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+		{Key: "host.name"},
+	}, keys, "keys used before their type was stored aren't added")
+	keys = controller.RankKeys(ctx, &v3.FilterAttributeKeyRequest{DataSource: v3.DataSourceLogs, SearchText: "pod"}, nil)
+	require.Empty(keys)
+
+	valuesReq := &v3.FilterAttributeValueRequest{
+		DataSource: v3.DataSourceLogs, FilterAttributeKey: "service.name",
+		FilterAttributeKeyDataType: v3.AttributeKeyDataTypeString,
+	}
+	values := controller.RankValues(ctx, valuesReq, []string{"cart", "checkout", "search"})
+	require.Equal([]string{"checkout", "cart", "search"}, values)
+	values = controller.RankValues(ctx, valuesReq, []string{"cart"})
+	require.Equal([]string{"checkout", "cart"}, values)
+	valuesReq.SearchText = "car"
+	values = controller.RankValues(ctx, valuesReq, []string{"cart"})
+	require.Equal([]string{"cart"}, values)
+}
+
+func TestPruneUsage(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "user1", OrgId: "org1"},
+		},
+	)
+
+	now := time.Now().UTC()
+	require.Nil(controller.repo.add(ctx, map[usageId]usageCount{
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "recent"}: {uses: 1, lastUsedAt: now},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "old"}:    {uses: 100, lastUsedAt: now.Add(-usageRetention - time.Hour)},
+	}))
+	require.Nil(controller.repo.prune(ctx, now.Add(-usageRetention)))
+
+	usages, apiErr := controller.GetUsage(ctx, v3.DataSourceLogs, "", 0)
+	require.Nil(apiErr)
+	require.Len(usages, 1)
+	require.Equal("recent", usages[0].Key)
+}
+
+func TestForgetValue(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "user1", OrgId: "org1"},
+		},
+	)
+
+	now := time.Now().UTC()
+	require.Nil(controller.repo.add(ctx, map[usageId]usageCount{
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "user.id", value: "42"}:   {uses: 1, lastUsedAt: now},
+		{orgId: "org2", dataSource: v3.DataSourceLogs, key: "user.id", value: "42"}:   {uses: 1, lastUsedAt: now},
+		{orgId: "org1", dataSource: v3.DataSourceTraces, key: "user.id", value: "42"}: {uses: 1, lastUsedAt: now},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "user.id", value: "7"}:    {uses: 1, lastUsedAt: now},
+		{orgId: "org1", dataSource: v3.DataSourceLogs, key: "user.id"}:                {uses: 1, lastUsedAt: now},
+	}))
+
+	require.Nil(controller.ForgetValue(ctx, []v3.DataSource{v3.DataSourceLogs}, "user.id", "42"))
+
+	usages, apiErr := controller.GetUsage(ctx, v3.DataSourceLogs, "user.id", 0)
+	require.Nil(apiErr)
+	require.Len(usages, 1)
+	require.Equal("7", usages[0].Value)
+	keys, apiErr := controller.GetUsage(ctx, v3.DataSourceLogs, "", 0)
+	require.Nil(apiErr)
+	require.Len(keys, 1, "the usage of the key is kept")
+	usages, apiErr = controller.GetUsage(ctx, v3.DataSourceTraces, "user.id", 0)
+	require.Nil(apiErr)
+	require.Len(usages, 1, "the usage in other data sources is kept")
+}
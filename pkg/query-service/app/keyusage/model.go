@@ -0,0 +1,63 @@
+package keyusage
+
+import (
+	"math"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// uses lose half their weight in ranking every usageHalfLife
+const usageHalfLife = 7 * 24 * time.Hour
+
+// KeyUsage is how much an attribute key, or a value of it if Value is set,
+// was used in the queries of an org
+type KeyUsage struct {
+	DataSource v3.DataSource `json:"dataSource" db:"data_source"`
+	Key        string        `json:"key" db:"key"`
+	Value      string        `json:"value,omitempty" db:"value"`
+
+	// the type of the key when it was last used
+	KeyType     v3.AttributeKeyType     `json:"keyType,omitempty" db:"key_type"`
+	KeyDataType v3.AttributeKeyDataType `json:"keyDataType,omitempty" db:"key_data_type"`
+	IsColumn    bool                    `json:"isColumn,omitempty" db:"is_column"`
+
+	Uses       int64     `json:"uses" db:"uses"`
+	LastUsedAt time.Time `json:"lastUsedAt" db:"last_used_at"`
+
+	// Score ranks usages by frequency with recent uses weighing more
+	Score float64 `json:"score" db:"-"`
+}
+
+func (u *KeyUsage) score(now time.Time) float64 {
+	age := now.Sub(u.LastUsedAt)
+	if age < 0 {
+		age = 0
+	}
+	return float64(u.Uses) * math.Pow(0.5, float64(age)/float64(usageHalfLife))
+}
+
+// usageId identifies the counters of a key or value in an org
+type usageId struct {
+	orgId      string
+	dataSource v3.DataSource
+	key        string
+	value      string
+}
+
+type usageCount struct {
+	uses       int64
+	lastUsedAt time.Time
+	attribute  v3.AttributeKey
+}
+
+// keyUse is a use of a key, or of a value of it, in a query
+type keyUse struct {
+	id        usageId
+	attribute v3.AttributeKey
+}
+
+// attributeKey returns the key of a key usage as autocomplete lists it
+func (u *KeyUsage) attributeKey() v3.AttributeKey {
+	return v3.AttributeKey{Key: u.Key, DataType: u.KeyDataType, Type: u.KeyType, IsColumn: u.IsColumn}
+}
@@ -0,0 +1,172 @@
+package keyusage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS attribute_key_usage(
+			org_id TEXT NOT NULL,
+			data_source TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL DEFAULT '',
+			key_type TEXT NOT NULL DEFAULT '',
+			key_data_type TEXT NOT NULL DEFAULT '',
+			is_column INTEGER NOT NULL DEFAULT 0,
+			uses INTEGER NOT NULL DEFAULT 0,
+			last_used_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (org_id, data_source, key, value)
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure attribute key usage schema in sqlite DB: %w", err,
+		)
+	}
+
+	for _, column := range []string{
+		`key_type TEXT NOT NULL DEFAULT ''`,
+		`key_data_type TEXT NOT NULL DEFAULT ''`,
+		`is_column INTEGER NOT NULL DEFAULT 0`,
+	} {
+		_, err = db.Exec(`ALTER TABLE attribute_key_usage ADD COLUMN ` + column)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("could not add column to attribute key usage table: %w", err)
+		}
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS attribute_key_usage_last_used_at ON attribute_key_usage(last_used_at)`)
+	if err != nil {
+		return fmt.Errorf("could not index attribute key usage: %w", err)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for attribute key usage: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+// listKeys returns the usage of the keys of a data source in an org
+func (r *Repo) listKeys(
+	ctx context.Context, orgId string, dataSource v3.DataSource,
+) ([]KeyUsage, *model.ApiError) {
+	usages := []KeyUsage{}
+
+	err := r.db.SelectContext(ctx, &usages, `
+		SELECT data_source, key, value, key_type, key_data_type, is_column, uses, last_used_at
+		FROM attribute_key_usage
+		WHERE org_id = $1 AND data_source = $2 AND value = ''
+	`, orgId, dataSource)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query attribute key usage: %w", err,
+		))
+	}
+	return usages, nil
+}
+
+// listValues returns the usage of the values of a key in an org
+func (r *Repo) listValues(
+	ctx context.Context, orgId string, dataSource v3.DataSource, key string,
+) ([]KeyUsage, *model.ApiError) {
+	usages := []KeyUsage{}
+
+	err := r.db.SelectContext(ctx, &usages, `
+		SELECT data_source, key, value, key_type, key_data_type, is_column, uses, last_used_at
+		FROM attribute_key_usage
+		WHERE org_id = $1 AND data_source = $2 AND key = $3 AND value != ''
+	`, orgId, dataSource, key)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query usage of the values of %s: %w", key, err,
+		))
+	}
+	return usages, nil
+}
+
+// add adds the counted uses to the stored ones
+func (r *Repo) add(ctx context.Context, counts map[usageId]usageCount) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for id, count := range counts {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO attribute_key_usage (
+				org_id, data_source, key, value, key_type, key_data_type, is_column, uses, last_used_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT(org_id, data_source, key, value) DO UPDATE SET
+				key_type = excluded.key_type,
+				key_data_type = excluded.key_data_type,
+				is_column = excluded.is_column,
+				uses = uses + excluded.uses,
+				last_used_at = max(last_used_at, excluded.last_used_at)
+		`, id.orgId, id.dataSource, id.key, id.value, count.attribute.Type, count.attribute.DataType,
+			count.attribute.IsColumn, count.uses, count.lastUsedAt)
+		if err != nil {
+			return fmt.Errorf("could not store usage of %s: %w", id.key, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteValue deletes the usage of a value of a key in every org
+func (r *Repo) deleteValue(
+	ctx context.Context, dataSources []v3.DataSource, key string, value string,
+) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, dataSource := range dataSources {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM attribute_key_usage
+			WHERE data_source = $1 AND key = $2 AND value = $3
+		`, dataSource, key, value)
+		if err != nil {
+			return fmt.Errorf("could not delete usage of the values of %s: %w", key, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// prune deletes the usage of the keys and values not used since before
+func (r *Repo) prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attribute_key_usage WHERE last_used_at < $1`, before)
+	if err != nil {
+		return fmt.Errorf("could not prune attribute key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package loadtests
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Controller keeps the load test runs registered by k6, vegeta and other
+// load testing tools, and reports on how the tested services behaved
+// during and after them.
+type Controller struct {
+	repo   *Repo
+	reader interfaces.Reader
+}
+
+func NewController(db *sqlx.DB, reader interfaces.Reader) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create load test runs repo: %w", err)
+	}
+
+	return &Controller{
+		repo:   repo,
+		reader: reader,
+	}, nil
+}
+
+// ListRuns returns the runs overlapping the time range, of a service if
+// serviceName is set
+func (c *Controller) ListRuns(
+	ctx context.Context, serviceName string, start, end time.Time,
+) ([]Run, *model.ApiError) {
+	return c.repo.list(ctx, serviceName, start, end)
+}
+
+// ListAnnotations returns the runs overlapping the time range as
+// annotations for the charts of the range
+func (c *Controller) ListAnnotations(
+	ctx context.Context, serviceName string, start, end time.Time,
+) ([]Annotation, *model.ApiError) {
+	runs, apiErr := c.repo.list(ctx, serviceName, start, end)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	annotations := make([]Annotation, len(runs))
+	for i := range runs {
+		annotations[i] = runs[i].annotation()
+	}
+	return annotations, nil
+}
+
+func (c *Controller) GetRun(ctx context.Context, id string) (*Run, *model.ApiError) {
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) CreateRun(
+	ctx context.Context, postable *PostableRun,
+) (*Run, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.setDefaults()
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	return c.repo.insert(ctx, userId, postable)
+}
+
+// FinishRun sets the end of a run registered while it was in progress
+func (c *Controller) FinishRun(
+	ctx context.Context, id string, postable *PostableRunEnd,
+) (*Run, *model.ApiError) {
+	run, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if run.End != nil {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("load test run %s already finished", id),
+		}
+	}
+	if !postable.End.After(run.Start) {
+		return nil, model.BadRequest(fmt.Errorf("end must be after start"))
+	}
+
+	if apiErr := c.repo.setEnd(ctx, id, postable.End); apiErr != nil {
+		return nil, apiErr
+	}
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) DeleteRun(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return c.repo.delete(ctx, id)
+}
+
+// GetReport compares the metrics of the run's service during and after
+// the run with the ones before it. The window after the run is left out
+// until it is over.
+func (c *Controller) GetReport(
+	ctx context.Context, id string, skipConfig *model.SkipConfig,
+) (*Report, *model.ApiError) {
+	run, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if run.End == nil {
+		return nil, model.BadRequest(fmt.Errorf(
+			"load test run %s is in progress, it can be reported on once finished", id,
+		))
+	}
+
+	before, during, after := reportWindows(run, time.Now())
+	for _, w := range []*Window{&before, &during, &after} {
+		if w.Pending {
+			continue
+		}
+		start, end := w.times()
+		summary, apiErr := c.reader.GetServiceSummary(ctx, run.ServiceName, start, end, skipConfig)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "failed to get service metrics of the load test run")
+		}
+		w.Summary = summary
+	}
+
+	return newReport(*run, before, during, after), nil
+}
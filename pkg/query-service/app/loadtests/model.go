@@ -0,0 +1,140 @@
+package loadtests
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// Tools the load tests are run with, other tools can register their runs
+// as well
+const (
+	ToolK6     = "k6"
+	ToolVegeta = "vegeta"
+	ToolOther  = "other"
+)
+
+var supportedTools = []string{ToolK6, ToolVegeta, ToolOther}
+
+// Service metrics the reports compare
+const (
+	MetricP50       = "p50"
+	MetricP95       = "p95"
+	MetricP99       = "p99"
+	MetricCallRate  = "callRate"
+	MetricErrorRate = "errorRate"
+)
+
+var supportedMetrics = []string{MetricP50, MetricP95, MetricP99, MetricCallRate, MetricErrorRate}
+
+// Run is a load test run against a service. Runs are shown as annotations
+// and reported on by comparing the service's metrics during and after the
+// run with the ones before it.
+type Run struct {
+	Id          string     `json:"id" db:"id"`
+	Tool        string     `json:"tool" db:"tool"`
+	Scenario    string     `json:"scenario" db:"scenario"`
+	ServiceName string     `json:"serviceName" db:"service_name"`
+	Metrics     Metrics    `json:"metrics" db:"metrics_json"`
+	Start       time.Time  `json:"start" db:"start_time"`
+	End         *time.Time `json:"end" db:"end_time"`
+	CreatedBy   string     `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Metrics the report of a run compares
+type Metrics []string
+
+// For serializing from db
+func (m *Metrics) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	}
+	return nil
+}
+
+// For serializing to db
+func (m Metrics) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize load test metrics to JSON")
+	}
+	return serialized, nil
+}
+
+// PostableRun registers a run, the end can be left out while the run is in
+// progress and set by finishing it
+type PostableRun struct {
+	Tool        string     `json:"tool"`
+	Scenario    string     `json:"scenario"`
+	ServiceName string     `json:"serviceName"`
+	Metrics     Metrics    `json:"metrics"`
+	Start       time.Time  `json:"start"`
+	End         *time.Time `json:"end"`
+}
+
+func (p *PostableRun) IsValid() error {
+	if !slices.Contains(supportedTools, p.Tool) {
+		return fmt.Errorf("tool must be one of %s", strings.Join(supportedTools, ", "))
+	}
+	if strings.TrimSpace(p.Scenario) == "" {
+		return fmt.Errorf("scenario is required")
+	}
+	if strings.TrimSpace(p.ServiceName) == "" {
+		return fmt.Errorf("serviceName is required")
+	}
+	for _, m := range p.Metrics {
+		if !slices.Contains(supportedMetrics, m) {
+			return fmt.Errorf("metrics must be some of %s", strings.Join(supportedMetrics, ", "))
+		}
+	}
+	if p.Start.IsZero() {
+		return fmt.Errorf("start is required")
+	}
+	if p.End != nil && !p.End.After(p.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+func (p *PostableRun) setDefaults() {
+	if len(p.Metrics) == 0 {
+		p.Metrics = append(Metrics{}, supportedMetrics...)
+	}
+}
+
+type PostableRunEnd struct {
+	End time.Time `json:"end"`
+}
+
+// Annotation is a run as shown on the time axis of the service's charts
+type Annotation struct {
+	RunId       string `json:"runId"`
+	Title       string `json:"title"`
+	ServiceName string `json:"serviceName"`
+	// Start and End are in milliseconds, End is 0 while the run is in
+	// progress
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+func (r *Run) annotation() Annotation {
+	a := Annotation{
+		RunId:       r.Id,
+		Title:       fmt.Sprintf("%s: %s", r.Tool, r.Scenario),
+		ServiceName: r.ServiceName,
+		Start:       r.Start.UnixMilli(),
+	}
+	if r.End != nil {
+		a.End = r.End.UnixMilli()
+	}
+	return a
+}
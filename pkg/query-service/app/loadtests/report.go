@@ -0,0 +1,114 @@
+package loadtests
+
+import (
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Report compares the metrics of the service during and after a run with
+// the ones of the same duration before it
+type Report struct {
+	Run     Run                `json:"run"`
+	Before  Window             `json:"before"`
+	During  Window             `json:"during"`
+	After   Window             `json:"after"`
+	Metrics []MetricComparison `json:"metrics"`
+}
+
+// Window is a time range of a report, in milliseconds. The window after a
+// run is pending until it is over.
+type Window struct {
+	Start   int64                      `json:"start"`
+	End     int64                      `json:"end"`
+	Pending bool                       `json:"pending"`
+	Summary *model.ServiceOverviewItem `json:"summary"`
+}
+
+// MetricComparison has the value of a metric in each window and its change
+// in percent from before the run. Values are nil when the service had no
+// calls in a window and changes are nil without both values or when the
+// value before the run is 0.
+type MetricComparison struct {
+	Metric       string   `json:"metric"`
+	Before       *float64 `json:"before"`
+	During       *float64 `json:"during"`
+	After        *float64 `json:"after"`
+	DuringChange *float64 `json:"duringChange"`
+	AfterChange  *float64 `json:"afterChange"`
+}
+
+// reportWindows returns the windows before, during and after a finished run
+func reportWindows(run *Run, now time.Time) (before, during, after Window) {
+	duration := run.End.Sub(run.Start)
+	before = Window{
+		Start: run.Start.Add(-duration).UnixMilli(),
+		End:   run.Start.UnixMilli(),
+	}
+	during = Window{
+		Start: run.Start.UnixMilli(),
+		End:   run.End.UnixMilli(),
+	}
+	after = Window{
+		Start:   run.End.UnixMilli(),
+		End:     run.End.Add(duration).UnixMilli(),
+		Pending: now.Before(run.End.Add(duration)),
+	}
+	return before, during, after
+}
+
+func (w *Window) times() (time.Time, time.Time) {
+	return time.UnixMilli(w.Start), time.UnixMilli(w.End)
+}
+
+func newReport(run Run, before, during, after Window) *Report {
+	report := &Report{
+		Run:     run,
+		Before:  before,
+		During:  during,
+		After:   after,
+		Metrics: []MetricComparison{},
+	}
+	for _, metric := range run.Metrics {
+		c := MetricComparison{
+			Metric: metric,
+			Before: metricValue(before.Summary, metric),
+			During: metricValue(during.Summary, metric),
+			After:  metricValue(after.Summary, metric),
+		}
+		c.DuringChange = percentChange(c.Before, c.During)
+		c.AfterChange = percentChange(c.Before, c.After)
+		report.Metrics = append(report.Metrics, c)
+	}
+	return report
+}
+
+func metricValue(summary *model.ServiceOverviewItem, metric string) *float64 {
+	if summary == nil || summary.NumCalls == 0 {
+		return nil
+	}
+	var value float64
+	switch metric {
+	case MetricP50:
+		value = summary.Percentile50
+	case MetricP95:
+		value = summary.Percentile95
+	case MetricP99:
+		value = summary.Percentile99
+	case MetricCallRate:
+		value = summary.CallRate
+	case MetricErrorRate:
+		value = summary.ErrorRate
+	default:
+		return nil
+	}
+	return &value
+}
+
+func percentChange(baseline, value *float64) *float64 {
+	if baseline == nil || value == nil || *baseline == 0 {
+		return nil
+	}
+	change := (*value - *baseline) / *baseline * 100
+	return &change
+}
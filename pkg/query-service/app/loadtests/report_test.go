@@ -0,0 +1,80 @@
+package loadtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestReport(t *testing.T) {
+	require := require.New(t)
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	run := Run{
+		Id: "run-1", Tool: ToolK6, Scenario: "checkout spike", ServiceName: "checkout",
+		Metrics: Metrics{MetricP99, MetricErrorRate, MetricCallRate},
+		Start:   start, End: &end,
+	}
+
+	before, during, after := reportWindows(&run, end.Add(5*time.Minute))
+	require.Equal(start.Add(-10*time.Minute).UnixMilli(), before.Start)
+	require.Equal(start.UnixMilli(), before.End)
+	require.Equal(end.UnixMilli(), during.End)
+	require.Equal(end.Add(10*time.Minute).UnixMilli(), after.End)
+	require.False(before.Pending)
+	require.True(after.Pending, "the window after the run should be pending until it is over")
+
+	_, _, after = reportWindows(&run, end.Add(10*time.Minute))
+	require.False(after.Pending)
+
+	before.Summary = &model.ServiceOverviewItem{
+		Percentile99: 200, NumCalls: 600, CallRate: 1, ErrorRate: 0,
+	}
+	during.Summary = &model.ServiceOverviewItem{
+		Percentile99: 500, NumCalls: 60000, CallRate: 100, ErrorRate: 2,
+	}
+	after.Summary = &model.ServiceOverviewItem{}
+
+	report := newReport(run, before, during, after)
+	require.Len(report.Metrics, 3)
+
+	p99 := report.Metrics[0]
+	require.Equal(MetricP99, p99.Metric)
+	require.Equal(200.0, *p99.Before)
+	require.Equal(500.0, *p99.During)
+	require.Equal(150.0, *p99.DuringChange)
+	require.Nil(p99.After, "no calls after the run")
+	require.Nil(p99.AfterChange)
+
+	errorRate := report.Metrics[1]
+	require.Equal(2.0, *errorRate.During)
+	require.Nil(errorRate.DuringChange, "no change from a zero baseline")
+
+	callRate := report.Metrics[2]
+	require.Equal(9900.0, *callRate.DuringChange)
+}
+
+func TestPostableRunIsValid(t *testing.T) {
+	require := require.New(t)
+
+	start := time.Now()
+	end := start.Add(time.Minute)
+	run := PostableRun{Tool: ToolVegeta, Scenario: "steady", ServiceName: "cart", Start: start, End: &end}
+	require.Nil(run.IsValid())
+	run.setDefaults()
+	require.Equal(Metrics(supportedMetrics), run.Metrics)
+
+	run.Metrics = Metrics{"apdex"}
+	require.NotNil(run.IsValid())
+
+	run.Metrics = nil
+	run.End = &start
+	require.NotNil(run.IsValid(), "end should be after start")
+
+	run.End = nil
+	run.Tool = "jmeter"
+	require.NotNil(run.IsValid())
+}
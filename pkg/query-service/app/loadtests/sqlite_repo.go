@@ -0,0 +1,167 @@
+package loadtests
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS load_test_runs(
+			id TEXT PRIMARY KEY,
+			tool TEXT NOT NULL,
+			scenario TEXT NOT NULL,
+			service_name TEXT NOT NULL,
+			metrics_json TEXT NOT NULL,
+			start_time TIMESTAMP NOT NULL,
+			end_time TIMESTAMP,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure load test runs schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for load test runs: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+// list returns the runs of a service, or of all services if serviceName is
+// empty, overlapping the time range, the latest first
+func (r *Repo) list(
+	ctx context.Context, serviceName string, start, end time.Time,
+) ([]Run, *model.ApiError) {
+	runs := []Run{}
+
+	query := `
+		SELECT id, tool, scenario, service_name, metrics_json, start_time, end_time, created_by, created_at
+		FROM load_test_runs
+		WHERE start_time <= $1
+		AND (end_time IS NULL OR end_time >= $2)
+	`
+	args := []interface{}{end.UTC(), start.UTC()}
+	if serviceName != "" {
+		query += " AND service_name = $3"
+		args = append(args, serviceName)
+	}
+	query += " ORDER BY start_time DESC"
+
+	err := r.db.SelectContext(ctx, &runs, query, args...)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query load test runs: %w", err,
+		))
+	}
+	return runs, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*Run, *model.ApiError) {
+	runs := []Run{}
+
+	err := r.db.SelectContext(ctx, &runs, `
+		SELECT id, tool, scenario, service_name, metrics_json, start_time, end_time, created_by, created_at
+		FROM load_test_runs
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query load test run %s: %w", id, err,
+		))
+	}
+
+	if len(runs) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("load test run %s not found", id))
+	}
+	return &runs[0], nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableRun,
+) (*Run, *model.ApiError) {
+	run := &Run{
+		Id:          uuid.NewString(),
+		Tool:        postable.Tool,
+		Scenario:    postable.Scenario,
+		ServiceName: postable.ServiceName,
+		Metrics:     postable.Metrics,
+		Start:       postable.Start.UTC(),
+		End:         postable.End,
+		CreatedBy:   userId,
+		CreatedAt:   time.Now(),
+	}
+	// times are stored in UTC for comparing them as text
+	if run.End != nil {
+		end := run.End.UTC()
+		run.End = &end
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO load_test_runs (
+			id, tool, scenario, service_name, metrics_json, start_time, end_time, created_by, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`,
+		run.Id, run.Tool, run.Scenario, run.ServiceName, run.Metrics,
+		run.Start, run.End, run.CreatedBy, run.CreatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert load test run: %w", err,
+		))
+	}
+
+	return run, nil
+}
+
+func (r *Repo) setEnd(ctx context.Context, id string, end time.Time) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE load_test_runs SET end_time = $1 WHERE id = $2
+	`, end.UTC(), id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update load test run %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM load_test_runs WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete load test run %s: %w", id, err,
+		))
+	}
+	return nil
+}
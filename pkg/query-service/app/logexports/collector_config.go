@@ -0,0 +1,346 @@
+package logexports
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	componentNamePrefix = "signoz_log_export"
+
+	// processed logs are forwarded from the logs pipeline
+	// to a separate pipeline per destination
+	forwardConnectorName = "forward/signoz_log_exports"
+)
+
+// GenerateCollectorConfigWithLogExports adds an exporter and a logs pipeline
+// for each enabled destination to the collector config. The logs pipeline
+// forwards logs to the destination pipelines after all of its processing
+// (e.g. logs pipelines) is done, where logs not matching the destination's
+// filter get dropped. Export components are removed when there are no
+// enabled destinations.
+//
+// Config values are passed through as is so that secrets like tokens
+// can be referenced as env vars available to the collector, e.g. ${env:SPLUNK_TOKEN}
+func GenerateCollectorConfigWithLogExports(
+	config []byte, destinations []LogExportDestination,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	connectors := componentMap(c, "connectors")
+	processors := componentMap(c, "processors")
+	exporters := componentMap(c, "exporters")
+	for _, components := range []map[string]interface{}{connectors, processors, exporters, pipelines} {
+		for name := range components {
+			if isLogExportComponent(name) {
+				delete(components, name)
+			}
+		}
+	}
+
+	enabled := []LogExportDestination{}
+	for _, d := range destinations {
+		if d.Spec.Enabled {
+			enabled = append(enabled, d)
+		}
+	}
+
+	logsPipeline, hasLogsPipeline := pipelines["logs"].(map[string]interface{})
+	if hasLogsPipeline {
+		current, _ := logsPipeline["exporters"].([]interface{})
+		updated := []interface{}{}
+		for _, e := range current {
+			if e != forwardConnectorName {
+				updated = append(updated, e)
+			}
+		}
+		if len(enabled) > 0 {
+			updated = append(updated, forwardConnectorName)
+		}
+		logsPipeline["exporters"] = updated
+	}
+
+	if hasLogsPipeline && len(enabled) > 0 {
+		connectors[forwardConnectorName] = map[string]interface{}{}
+
+		for _, d := range enabled {
+			name := componentNamePrefix + "_" + d.Id
+			exporterName := fmt.Sprintf("%s/%s", d.Spec.Type, name)
+			exporters[exporterName] = exporterConfig(d.Spec)
+
+			pipeline := map[string]interface{}{
+				"receivers":  []interface{}{forwardConnectorName},
+				"processors": []interface{}{},
+				"exporters":  []interface{}{exporterName},
+			}
+
+			condition, err := filterCondition(d.Spec.Filter)
+			if err != nil {
+				return nil, model.BadRequest(fmt.Errorf(
+					"invalid filter for log export destination %s: %w", d.Name, err,
+				))
+			}
+			if condition != "" {
+				processorName := "filter/" + name
+				processors[processorName] = map[string]interface{}{
+					"error_mode": "ignore",
+					"logs": map[string]interface{}{
+						"log_record": []interface{}{fmt.Sprintf("not (%s)", condition)},
+					},
+				}
+				pipeline["processors"] = []interface{}{processorName}
+			}
+
+			pipelines["logs/"+name] = pipeline
+		}
+	}
+
+	for key, components := range map[string]map[string]interface{}{
+		"connectors": connectors, "processors": processors, "exporters": exporters,
+	} {
+		if len(components) > 0 {
+			c[key] = components
+		} else {
+			delete(c, key)
+		}
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+func componentMap(c map[string]interface{}, key string) map[string]interface{} {
+	components, ok := c[key].(map[string]interface{})
+	if !ok || components == nil {
+		return map[string]interface{}{}
+	}
+	return components
+}
+
+func isLogExportComponent(name string) bool {
+	_, componentName, _ := strings.Cut(name, "/")
+	return strings.HasPrefix(componentName, componentNamePrefix)
+}
+
+func exporterConfig(spec DestinationSpec) map[string]interface{} {
+	c := spec.Config
+	conf := map[string]interface{}{}
+	setIfNotEmpty := func(key string, value string) {
+		if value != "" {
+			conf[key] = value
+		}
+	}
+
+	switch spec.Type {
+	case DestinationSplunkHEC:
+		conf["endpoint"] = c.Endpoint
+		conf["token"] = c.Token
+		setIfNotEmpty("index", c.Index)
+		setIfNotEmpty("source", c.Source)
+		setIfNotEmpty("sourcetype", c.SourceType)
+		if c.InsecureSkipVerify {
+			conf["tls"] = map[string]interface{}{"insecure_skip_verify": true}
+		}
+	case DestinationElasticsearch:
+		conf["endpoints"] = []interface{}{c.Endpoint}
+		setIfNotEmpty("logs_index", c.Index)
+		setIfNotEmpty("user", c.Username)
+		setIfNotEmpty("password", c.Password)
+		if c.InsecureSkipVerify {
+			conf["tls"] = map[string]interface{}{"insecure_skip_verify": true}
+		}
+	case DestinationKafka:
+		brokers := []interface{}{}
+		for _, b := range c.Brokers {
+			brokers = append(brokers, b)
+		}
+		conf["brokers"] = brokers
+		conf["topic"] = c.Topic
+		conf["protocol_version"] = "2.0.0"
+		conf["encoding"] = "otlp_json"
+		if c.InsecureSkipVerify {
+			conf["auth"] = map[string]interface{}{
+				"tls": map[string]interface{}{"insecure_skip_verify": true},
+			}
+		}
+	}
+	return conf
+}
+
+// filterCondition translates a filter set to an OTTL condition
+// in the log context. Returns an empty condition for empty filters.
+func filterCondition(filter *v3.FilterSet) (string, error) {
+	if filter == nil || len(filter.Items) == 0 {
+		return "", nil
+	}
+
+	conditions := []string{}
+	for _, item := range filter.Items {
+		condition, err := filterItemCondition(item)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	joiner := " and "
+	if strings.ToUpper(filter.Operator) == "OR" {
+		joiner = " or "
+	}
+	return strings.Join(conditions, joiner), nil
+}
+
+func filterItemCondition(item v3.FilterItem) (string, error) {
+	target, err := ottlTarget(item.Key)
+	if err != nil {
+		return "", err
+	}
+
+	switch op := v3.FilterOperator(strings.ToLower(string(item.Operator))); op {
+	case v3.FilterOperatorExists:
+		return fmt.Sprintf("%s != nil", target), nil
+	case v3.FilterOperatorNotExists:
+		return fmt.Sprintf("%s == nil", target), nil
+
+	case v3.FilterOperatorEqual, v3.FilterOperatorNotEqual,
+		v3.FilterOperatorGreaterThan, v3.FilterOperatorGreaterThanOrEq,
+		v3.FilterOperatorLessThan, v3.FilterOperatorLessThanOrEq:
+		value, err := ottlValue(item.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", target, op, value), nil
+
+	case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
+		values, ok := item.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("%s operator requires a non empty list of values", op)
+		}
+		comparison, joiner := "==", " or "
+		if op == v3.FilterOperatorNotIn {
+			comparison, joiner = "!=", " and "
+		}
+		conditions := []string{}
+		for _, v := range values {
+			value, err := ottlValue(v)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", target, comparison, value))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(conditions, joiner)), nil
+
+	case v3.FilterOperatorContains, v3.FilterOperatorNotContains,
+		v3.FilterOperatorRegex, v3.FilterOperatorNotRegex,
+		v3.FilterOperatorLike, v3.FilterOperatorNotLike:
+		value, ok := item.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("%s operator requires a string value", op)
+		}
+
+		pattern := value
+		switch op {
+		case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
+			pattern = regexp.QuoteMeta(value)
+		case v3.FilterOperatorLike, v3.FilterOperatorNotLike:
+			pattern = likeToRegex(value)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid regex %q: %w", value, err)
+		}
+
+		condition := fmt.Sprintf("IsMatch(%s, %s)", target, ottlString(pattern))
+		if op == v3.FilterOperatorNotContains || op == v3.FilterOperatorNotRegex || op == v3.FilterOperatorNotLike {
+			condition = "not " + condition
+		}
+		return condition, nil
+	}
+
+	return "", fmt.Errorf("unsupported filter operator %q", item.Operator)
+}
+
+func ottlTarget(key v3.AttributeKey) (string, error) {
+	if key.Key == "" {
+		return "", fmt.Errorf("filter key is required")
+	}
+
+	switch key.Type {
+	case v3.AttributeKeyTypeTag:
+		return fmt.Sprintf("attributes[%s]", ottlString(key.Key)), nil
+	case v3.AttributeKeyTypeResource:
+		return fmt.Sprintf("resource.attributes[%s]", ottlString(key.Key)), nil
+	}
+
+	switch key.Key {
+	case "body", "severity_text", "severity_number":
+		return key.Key, nil
+	}
+	return "", fmt.Errorf("unsupported filter key %q, must be an attribute, a resource attribute, body, severity_text or severity_number", key.Key)
+}
+
+func ottlValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return ottlString(v), nil
+	case bool:
+		return fmt.Sprintf("%t", v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v)), nil
+		}
+		return fmt.Sprintf("%v", v), nil
+	case int, int64:
+		return fmt.Sprintf("%d", v), nil
+	}
+	return "", fmt.Errorf("unsupported filter value %v", value)
+}
+
+func likeToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func ottlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	// escape `$`s so that they do not get treated as env vars when loading collector config
+	s = strings.ReplaceAll(s, "$", "$$")
+	return fmt.Sprintf(`"%s"`, s)
+}
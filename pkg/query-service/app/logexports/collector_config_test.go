@@ -0,0 +1,155 @@
+package logexports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhouselogsexporter: {}
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhouselogsexporter]
+`
+
+type testConf struct {
+	Connectors map[string]interface{} `yaml:"connectors"`
+	Processors map[string]struct {
+		Logs struct {
+			LogRecord []string `yaml:"log_record"`
+		} `yaml:"logs"`
+	} `yaml:"processors"`
+	Exporters map[string]map[string]interface{} `yaml:"exporters"`
+	Service   struct {
+		Pipelines map[string]struct {
+			Receivers  []string `yaml:"receivers"`
+			Processors []string `yaml:"processors"`
+			Exporters  []string `yaml:"exporters"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func TestGenerateCollectorConfigWithLogExports(t *testing.T) {
+	require := require.New(t)
+
+	destinations := []LogExportDestination{
+		{
+			Id:   "splunk",
+			Name: "splunk",
+			Spec: DestinationSpec{
+				Type:    DestinationSplunkHEC,
+				Enabled: true,
+				Filter: &v3.FilterSet{
+					Operator: "AND",
+					Items: []v3.FilterItem{
+						{
+							Key:      v3.AttributeKey{Key: "k8s.namespace.name", Type: v3.AttributeKeyTypeResource},
+							Operator: v3.FilterOperatorIn,
+							Value:    []interface{}{"auth", "payments"},
+						},
+						{
+							Key:      v3.AttributeKey{Key: "severity_text"},
+							Operator: v3.FilterOperatorNotEqual,
+							Value:    "DEBUG",
+						},
+					},
+				},
+				Config: DestinationConfig{
+					Endpoint: "https://splunk:8088/services/collector",
+					Token:    "${env:SPLUNK_TOKEN}",
+				},
+			},
+		},
+		{
+			Id:   "kafka",
+			Name: "kafka",
+			Spec: DestinationSpec{
+				Type:    DestinationKafka,
+				Enabled: false,
+				Config:  DestinationConfig{Brokers: []string{"kafka:9092"}, Topic: "logs"},
+			},
+		},
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithLogExports([]byte(testCollectorConf), destinations)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+
+	require.Contains(conf.Connectors, forwardConnectorName)
+	require.Equal(
+		[]string{"clickhouselogsexporter", forwardConnectorName},
+		conf.Service.Pipelines["logs"].Exporters,
+	)
+
+	exportPipeline, ok := conf.Service.Pipelines["logs/signoz_log_export_splunk"]
+	require.True(ok)
+	require.Equal([]string{forwardConnectorName}, exportPipeline.Receivers)
+	require.Equal([]string{"filter/signoz_log_export_splunk"}, exportPipeline.Processors)
+	require.Equal([]string{"splunk_hec/signoz_log_export_splunk"}, exportPipeline.Exporters)
+
+	require.Equal(
+		[]string{`not ((resource.attributes["k8s.namespace.name"] == "auth" or resource.attributes["k8s.namespace.name"] == "payments") and severity_text != "DEBUG")`},
+		conf.Processors["filter/signoz_log_export_splunk"].Logs.LogRecord,
+	)
+	require.Equal("${env:SPLUNK_TOKEN}", conf.Exporters["splunk_hec/signoz_log_export_splunk"]["token"])
+
+	_, ok = conf.Service.Pipelines["logs/signoz_log_export_kafka"]
+	require.False(ok, "disabled destinations should not be exported to")
+
+	// export components should get cleaned up once there are no enabled destinations
+	updated, apiErr = GenerateCollectorConfigWithLogExports(updated, []LogExportDestination{})
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(0, len(conf.Connectors))
+	require.Equal(1, len(conf.Processors))
+	require.Equal(1, len(conf.Exporters))
+	require.Equal(1, len(conf.Service.Pipelines))
+	require.Equal([]string{"clickhouselogsexporter"}, conf.Service.Pipelines["logs"].Exporters)
+}
+
+func TestFilterCondition(t *testing.T) {
+	require := require.New(t)
+
+	condition, err := filterCondition(&v3.FilterSet{
+		Operator: "OR",
+		Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body"}, Operator: v3.FilterOperatorContains, Value: "failed login"},
+			{Key: v3.AttributeKey{Key: "user.id", Type: v3.AttributeKeyTypeTag}, Operator: v3.FilterOperatorExists},
+			{Key: v3.AttributeKey{Key: "severity_number"}, Operator: v3.FilterOperatorGreaterThanOrEq, Value: float64(17)},
+		},
+	})
+	require.Nil(err)
+	require.Equal(
+		`IsMatch(body, "failed login") or attributes["user.id"] != nil or severity_number >= 17`,
+		condition,
+	)
+
+	_, err = filterCondition(&v3.FilterSet{
+		Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "trace_id"}, Operator: v3.FilterOperatorEqual, Value: "abc"},
+		},
+	})
+	require.NotNil(err, "unsupported top level fields should be rejected")
+
+	_, err = filterCondition(&v3.FilterSet{
+		Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "user.id", Type: v3.AttributeKeyTypeTag}, Operator: v3.FilterOperatorRegex, Value: "("},
+		},
+	})
+	require.NotNil(err, "invalid regexes should be rejected")
+}
@@ -0,0 +1,200 @@
+package logexports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const LogExportsFeatureType agentConf.AgentFeatureType = "log_exports"
+
+// Controller manages secondary log export destinations and deploys the
+// exporter config derived from them to agents via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log exports repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type LogExportDestinationsResponse struct {
+	*agentConf.ConfigVersion
+
+	Destinations []LogExportDestination `json:"destinations"`
+}
+
+func (c *Controller) ListDestinations(ctx context.Context) (
+	*LogExportDestinationsResponse, *model.ApiError,
+) {
+	destinations, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogExports)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest log exports config version")
+	}
+
+	return &LogExportDestinationsResponse{
+		ConfigVersion: latest,
+		Destinations:  destinations,
+	}, nil
+}
+
+func (c *Controller) GetDestination(ctx context.Context, id string) (
+	*LogExportDestination, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateDestination stores a new destination and starts deploying
+// an agent config that exports to it
+func (c *Controller) CreateDestination(
+	ctx context.Context, postable *PostableLogExportDestination,
+) (*LogExportDestination, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	destination, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, destination.Id)
+		return nil, apiErr
+	}
+
+	return destination, nil
+}
+
+// UpdateDestination replaces the name and spec of a destination and
+// starts deploying the updated agent config
+func (c *Controller) UpdateDestination(
+	ctx context.Context, id string, postable *PostableLogExportDestination,
+) (*LogExportDestination, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.update(ctx, existing.UpdatedBy, existing)
+		return nil, apiErr
+	}
+
+	return &updated, nil
+}
+
+// DeleteDestination removes a destination and starts deploying
+// an agent config without it
+func (c *Controller) DeleteDestination(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	destinations, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(destinations))
+	for i, d := range destinations {
+		elements[i] = d.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogExports, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new log exports config version")
+	}
+	return nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return LogExportsFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	destinations, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithLogExports(currentConfYaml, destinations)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for log exports")
+	}
+
+	rawDestinations, err := json.Marshal(destinations)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize log export destinations to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawDestinations), nil
+}
@@ -0,0 +1,155 @@
+package logexports
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type DestinationType string
+
+const (
+	DestinationSplunkHEC     DestinationType = "splunk_hec"
+	DestinationElasticsearch DestinationType = "elasticsearch"
+	DestinationKafka         DestinationType = "kafka"
+)
+
+// LogExportDestination is a secondary destination, e.g. a SIEM, that
+// receives a copy of the logs matching its filter in addition to SigNoz
+type LogExportDestination struct {
+	Id        string          `json:"id" db:"id"`
+	Name      string          `json:"name" db:"name"`
+	Spec      DestinationSpec `json:"spec" db:"spec_json"`
+	CreatedBy string          `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedBy string          `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+type DestinationSpec struct {
+	Type    DestinationType `json:"type"`
+	Enabled bool            `json:"enabled"`
+
+	// logs not matching the filter are not exported, all logs
+	// are exported when the filter is empty
+	Filter *v3.FilterSet `json:"filter,omitempty"`
+
+	Config DestinationConfig `json:"config"`
+}
+
+// DestinationConfig holds the connection settings of a destination,
+// only the fields relevant to the destination type are used.
+type DestinationConfig struct {
+	// splunk_hec and elasticsearch
+	Endpoint string `json:"endpoint,omitempty"`
+	Index    string `json:"index,omitempty"`
+
+	// splunk_hec
+	Token      string `json:"token,omitempty"`
+	Source     string `json:"source,omitempty"`
+	SourceType string `json:"sourceType,omitempty"`
+
+	// elasticsearch
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// kafka
+	Brokers []string `json:"brokers,omitempty"`
+	Topic   string   `json:"topic,omitempty"`
+
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// For serializing from db
+func (s *DestinationSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s DestinationSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize log export destination spec to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableLogExportDestination struct {
+	Name string          `json:"name"`
+	Spec DestinationSpec `json:"spec"`
+}
+
+func (p *PostableLogExportDestination) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("destination name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *DestinationSpec) IsValid() error {
+	if err := s.Filter.Validate(); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	if _, err := filterCondition(s.Filter); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+
+	c := s.Config
+	switch s.Type {
+	case DestinationSplunkHEC:
+		if err := validateEndpoint(c.Endpoint); err != nil {
+			return err
+		}
+		if c.Token == "" {
+			return fmt.Errorf("token is required for splunk HEC destinations")
+		}
+	case DestinationElasticsearch:
+		if err := validateEndpoint(c.Endpoint); err != nil {
+			return err
+		}
+		if (c.Username == "") != (c.Password == "") {
+			return fmt.Errorf("both username and password are required for elasticsearch basic auth")
+		}
+	case DestinationKafka:
+		if len(c.Brokers) == 0 {
+			return fmt.Errorf("at least one broker is required for kafka destinations")
+		}
+		for _, broker := range c.Brokers {
+			if strings.TrimSpace(broker) == "" {
+				return fmt.Errorf("kafka broker addresses can not be empty")
+			}
+		}
+		if c.Topic == "" {
+			return fmt.Errorf("topic is required for kafka destinations")
+		}
+	default:
+		return fmt.Errorf(
+			"unsupported destination type %q, must be one of %s, %s or %s",
+			s.Type, DestinationSplunkHEC, DestinationElasticsearch, DestinationKafka,
+		)
+	}
+	return nil
+}
+
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("endpoint must be a valid http(s) url")
+	}
+	return nil
+}
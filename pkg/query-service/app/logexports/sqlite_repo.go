@@ -0,0 +1,208 @@
+package logexports
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS log_export_destinations(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure log export destinations schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for log export destinations: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]LogExportDestination, *model.ApiError) {
+	destinations := []LogExportDestination{}
+
+	err := r.db.SelectContext(ctx, &destinations, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM log_export_destinations
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log export destinations: %w", err,
+		))
+	}
+	return destinations, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*LogExportDestination, *model.ApiError) {
+	destinations := []LogExportDestination{}
+
+	err := r.db.SelectContext(ctx, &destinations, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM log_export_destinations
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log export destination %s: %w", id, err,
+		))
+	}
+
+	if len(destinations) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("log export destination %s not found", id))
+	}
+	return &destinations[0], nil
+}
+
+// getByVersion returns log export destinations associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]LogExportDestination, *model.ApiError) {
+	destinations := []LogExportDestination{}
+
+	err := r.db.SelectContext(ctx, &destinations, `
+		SELECT d.id, d.name, d.spec_json, d.created_by, d.created_at, d.updated_by, d.updated_at
+		FROM log_export_destinations d,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE d.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY d.name
+	`, agentConf.ElementTypeLogExports, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log export destinations for version %d: %w", version, err,
+		))
+	}
+	return destinations, nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM log_export_destinations WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query log export destinations: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a log export destination named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableLogExportDestination,
+) (*LogExportDestination, *model.ApiError) {
+	now := time.Now()
+	destination := &LogExportDestination{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, destination.Name, destination.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO log_export_destinations (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		destination.Id, destination.Name, destination.Spec,
+		destination.CreatedBy, destination.CreatedAt,
+		destination.UpdatedBy, destination.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert log export destination: %w", err,
+		))
+	}
+
+	return destination, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, destination *LogExportDestination,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, destination.Name, destination.Id); apiErr != nil {
+		return apiErr
+	}
+
+	destination.UpdatedBy = userId
+	destination.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE log_export_destinations
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		destination.Name, destination.Spec,
+		destination.UpdatedBy, destination.UpdatedAt, destination.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update log export destination %s: %w", destination.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM log_export_destinations WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete log export destination %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package logparsingpipeline
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"golang.org/x/exp/slices"
+)
+
+// AgentSelector limits a pipeline to the agents matching it, by the group
+// and attributes they report over OpAMP. An agent matches if it is in one of
+// the groups, when any are specified, and has all the labels as attributes.
+// Groups are either the group reported by the agent or agent groups defined
+// on the server, named with opampModel.ServerAgentGroupPrefix.
+type AgentSelector struct {
+	Groups []string          `json:"groups,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (s *AgentSelector) IsValid() error {
+	for _, g := range s.Groups {
+		if strings.TrimSpace(g) == "" {
+			return fmt.Errorf("agent groups of a selector cannot be empty")
+		}
+	}
+	for k := range s.Labels {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("agent label keys of a selector cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Matches tells if the agent is selected, a nil selector selects all agents
+func (s *AgentSelector) Matches(agent opampModel.AgentInfo) bool {
+	if s == nil {
+		return true
+	}
+	if len(s.Groups) > 0 && !s.selectsGroupOf(agent) {
+		return false
+	}
+	for k, v := range s.Labels {
+		if value, ok := agent.Attributes[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *AgentSelector) selectsGroupOf(agent opampModel.AgentInfo) bool {
+	for _, group := range s.Groups {
+		if agent.InGroup(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectsAgentGroup tells if the selector selects the agents of the agent
+// group defined on the server with the given name
+func (s *AgentSelector) SelectsAgentGroup(name string) bool {
+	return s != nil && slices.Contains(s.Groups, opampModel.ServerAgentGroupPrefix+name)
+}
+
+func (s *AgentSelector) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, &s)
+	case string:
+		return json.Unmarshal([]byte(data), &s)
+	}
+	return nil
+}
+
+func (s *AgentSelector) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	selectorJson, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize AgentSelector to JSON")
+	}
+	return selectorJson, nil
+}
+
+// pipelinesForAgent returns the pipelines to be deployed to an agent
+func pipelinesForAgent(pipelines []Pipeline, agent opampModel.AgentInfo) []Pipeline {
+	selected := []Pipeline{}
+	for _, p := range pipelines {
+		if p.AgentSelector.Matches(agent) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
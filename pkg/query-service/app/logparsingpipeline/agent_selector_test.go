@@ -0,0 +1,112 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPipelinesScopedToAgents(t *testing.T) {
+	require := require.New(t)
+
+	makePipeline := func(alias string, selector *AgentSelector) Pipeline {
+		return Pipeline{
+			OrderId: 1,
+			Name:    alias,
+			Alias:   alias,
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "method",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "GET",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add",
+					Field: "attributes.test", Value: "val",
+				},
+			},
+			AgentSelector: selector,
+		}
+	}
+	pipelines := []Pipeline{
+		makePipeline("everywhere", nil),
+		makePipeline("prodclusters", &AgentSelector{Groups: []string{"prod-us", "prod-eu"}}),
+		makePipeline("produs", &AgentSelector{
+			Groups: []string{"prod-us"},
+			Labels: map[string]string{"k8s.cluster.name": "us-1"},
+		}),
+	}
+
+	baseConf := []byte(`
+receivers:
+  otlp:
+processors:
+  batch:
+exporters:
+  otlp:
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlp]
+`)
+
+	processorsForAgent := func(agent opampModel.AgentInfo) []interface{} {
+		conf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, pipelinesForAgent(pipelines, agent))
+		require.Nil(apiErr)
+
+		var c map[string]interface{}
+		require.Nil(yaml.Unmarshal(conf, &c))
+		logs := c["service"].(map[string]interface{})["pipelines"].(map[string]interface{})["logs"]
+		return logs.(map[string]interface{})["processors"].([]interface{})
+	}
+
+	require.Equal([]interface{}{
+		"logstransform/pipeline_everywhere", "batch",
+	}, processorsForAgent(opampModel.AgentInfo{ID: "dev"}))
+
+	require.Equal([]interface{}{
+		"logstransform/pipeline_everywhere", "logstransform/pipeline_prodclusters", "batch",
+	}, processorsForAgent(opampModel.AgentInfo{
+		ID: "eu", Group: "prod-eu", Attributes: map[string]string{"k8s.cluster.name": "eu-1"},
+	}))
+
+	require.Equal([]interface{}{
+		"logstransform/pipeline_everywhere", "logstransform/pipeline_prodclusters",
+		"logstransform/pipeline_produs", "batch",
+	}, processorsForAgent(opampModel.AgentInfo{
+		ID: "us", Group: "prod-us", Attributes: map[string]string{"k8s.cluster.name": "us-1"},
+	}))
+
+	require.Equal([]interface{}{
+		"logstransform/pipeline_everywhere", "logstransform/pipeline_prodclusters", "batch",
+	}, processorsForAgent(opampModel.AgentInfo{
+		ID: "us2", Group: "prod-us", Attributes: map[string]string{"k8s.cluster.name": "us-2"},
+	}))
+
+	// agent groups defined on the server are told apart from the reported
+	// ones by their prefix
+	serverGroup := &AgentSelector{Groups: []string{opampModel.ServerAgentGroupPrefix + "prod-us"}}
+	require.True(serverGroup.Matches(opampModel.AgentInfo{ID: "us", Groups: []string{"prod-us"}}))
+	require.False(serverGroup.Matches(opampModel.AgentInfo{ID: "us", Group: "prod-us"}))
+	require.True(serverGroup.SelectsAgentGroup("prod-us"))
+	require.False(pipelines[1].AgentSelector.SelectsAgentGroup("prod-us"))
+	require.False(pipelines[0].AgentSelector.SelectsAgentGroup("prod-us"))
+
+	require.NotNil((&AgentSelector{Groups: []string{""}}).IsValid())
+	require.NotNil((&AgentSelector{Labels: map[string]string{" ": "v"}}).IsValid())
+}
@@ -0,0 +1,96 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PipelineChanges are changes to the enabled state and order of some of the
+// deployed pipelines, applied together as a single new config version
+type PipelineChanges struct {
+	Pipelines []PipelineChange `json:"pipelines"`
+
+	// why the pipelines are changed, recorded on the deployed version
+	ChangeNote string `json:"changeNote,omitempty"`
+}
+
+// PipelineChange updates the fields of a deployed pipeline which are set
+type PipelineChange struct {
+	Id      string `json:"id"`
+	Enabled *bool  `json:"enabled,omitempty"`
+	OrderId *int   `json:"orderId,omitempty"`
+}
+
+func (c *PipelineChanges) IsValid() error {
+	if len(c.Pipelines) == 0 {
+		return fmt.Errorf("at least one pipeline change is required")
+	}
+	if err := validateChangeNote(c.ChangeNote); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, change := range c.Pipelines {
+		if change.Id == "" {
+			return fmt.Errorf("id of the changed pipeline is required")
+		}
+		if seen[change.Id] {
+			return fmt.Errorf("pipeline %s is changed more than once", change.Id)
+		}
+		seen[change.Id] = true
+
+		if change.Enabled == nil && change.OrderId == nil {
+			return fmt.Errorf("enabled or orderId is required to change pipeline %s", change.Id)
+		}
+		if change.OrderId != nil && *change.OrderId < 1 {
+			return fmt.Errorf("orderId of pipeline %s must be greater than 0", change.Id)
+		}
+	}
+	return nil
+}
+
+// applyPipelineChanges returns the pipelines with the changes applied, ordered
+// by their orderId, along with the ids of the pipelines which did change
+func applyPipelineChanges(
+	pipelines []Pipeline, changes []PipelineChange,
+) ([]Pipeline, map[string]bool, error) {
+	updated := make([]Pipeline, len(pipelines))
+	copy(updated, pipelines)
+
+	indexById := map[string]int{}
+	for i, p := range updated {
+		indexById[p.Id] = i
+	}
+
+	changed := map[string]bool{}
+	for _, change := range changes {
+		i, ok := indexById[change.Id]
+		if !ok {
+			return nil, nil, fmt.Errorf("pipeline %s is not in the latest pipelines version", change.Id)
+		}
+		p := &updated[i]
+		if change.Enabled != nil && *change.Enabled != p.Enabled {
+			p.Enabled = *change.Enabled
+			changed[p.Id] = true
+		}
+		if change.OrderId != nil && *change.OrderId != p.OrderId {
+			p.OrderId = *change.OrderId
+			changed[p.Id] = true
+		}
+	}
+
+	orderIds := map[int]string{}
+	for _, p := range updated {
+		if other, ok := orderIds[p.OrderId]; ok {
+			return nil, nil, fmt.Errorf(
+				"pipelines %s and %s would have the same orderId %d", other, p.Name, p.OrderId,
+			)
+		}
+		orderIds[p.OrderId] = p.Name
+	}
+
+	sort.SliceStable(updated, func(i, j int) bool {
+		return updated[i].OrderId < updated[j].OrderId
+	})
+	return updated, changed, nil
+}
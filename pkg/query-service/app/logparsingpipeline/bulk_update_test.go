@@ -0,0 +1,84 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPipelineChanges(t *testing.T) {
+	require := require.New(t)
+
+	enabled, disabled := true, false
+	orderId := func(o int) *int { return &o }
+
+	pipelines := []Pipeline{
+		{Id: "p1", Name: "one", OrderId: 1, Enabled: true},
+		{Id: "p2", Name: "two", OrderId: 2, Enabled: true},
+		{Id: "p3", Name: "three", OrderId: 3, Enabled: false},
+	}
+
+	updated, changed, err := applyPipelineChanges(pipelines, []PipelineChange{
+		{Id: "p1", OrderId: orderId(3)},
+		{Id: "p3", OrderId: orderId(1), Enabled: &enabled},
+		{Id: "p2", Enabled: &enabled},
+	})
+	require.Nil(err)
+	require.Equal(map[string]bool{"p1": true, "p3": true}, changed)
+	require.Equal([]string{"p3", "p2", "p1"}, []string{updated[0].Id, updated[1].Id, updated[2].Id})
+	require.True(updated[0].Enabled)
+
+	// the given pipelines are left untouched
+	require.Equal(1, pipelines[0].OrderId)
+	require.False(pipelines[2].Enabled)
+
+	_, _, err = applyPipelineChanges(pipelines, []PipelineChange{{Id: "p4", Enabled: &disabled}})
+	require.NotNil(err, "unknown pipelines can't be changed")
+
+	_, _, err = applyPipelineChanges(pipelines, []PipelineChange{{Id: "p1", OrderId: orderId(2)}})
+	require.NotNil(err, "pipelines can't end up with the same orderId")
+
+	testCases := []struct {
+		Name    string
+		Changes PipelineChanges
+		IsValid bool
+	}{
+		{
+			Name:    "no changes",
+			Changes: PipelineChanges{},
+			IsValid: false,
+		}, {
+			Name:    "missing id",
+			Changes: PipelineChanges{Pipelines: []PipelineChange{{Enabled: &enabled}}},
+			IsValid: false,
+		}, {
+			Name:    "nothing to change",
+			Changes: PipelineChanges{Pipelines: []PipelineChange{{Id: "p1"}}},
+			IsValid: false,
+		}, {
+			Name: "duplicate id",
+			Changes: PipelineChanges{Pipelines: []PipelineChange{
+				{Id: "p1", Enabled: &enabled}, {Id: "p1", OrderId: orderId(2)},
+			}},
+			IsValid: false,
+		}, {
+			Name:    "invalid orderId",
+			Changes: PipelineChanges{Pipelines: []PipelineChange{{Id: "p1", OrderId: orderId(0)}}},
+			IsValid: false,
+		}, {
+			Name: "valid changes",
+			Changes: PipelineChanges{Pipelines: []PipelineChange{
+				{Id: "p1", Enabled: &disabled}, {Id: "p2", OrderId: orderId(5)},
+			}},
+			IsValid: true,
+		},
+	}
+	for _, tc := range testCases {
+		err := tc.Changes.IsValid()
+		if tc.IsValid {
+			require.Nil(err, tc.Name)
+		} else {
+			require.NotNil(err, tc.Name)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// PipelineClone names the copy of a pipeline and where it goes. The copy
+// is inserted at OrderId, moving the pipelines from there down by one, or
+// right after the copied pipeline if OrderId isn't set.
+type PipelineClone struct {
+	Name        string  `json:"name"`
+	Alias       string  `json:"alias"`
+	Description *string `json:"description,omitempty"`
+	OrderId     int     `json:"orderId,omitempty"`
+}
+
+func (c *PipelineClone) IsValid() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("name of the pipeline copy is required")
+	}
+	if strings.TrimSpace(c.Alias) == "" {
+		return fmt.Errorf("alias of the pipeline copy is required")
+	}
+	if c.OrderId < 0 {
+		return fmt.Errorf("orderId of the pipeline copy can't be negative")
+	}
+	return nil
+}
+
+// ClonePipeline copies the filter and operators of a pipeline of the latest
+// version into a new pipeline and deploys it along with the others as a new
+// config version
+func (ic *LogParsingPipelineController) ClonePipeline(
+	ctx context.Context, id string, clone *PipelineClone,
+) (*PipelinesResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if err := clone.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	_, latest, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	copied, updated, shifted, err := clonePipeline(latest, id, clone)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	// pipelines of a version are immutable, shifted ones are stored anew
+	for i, p := range updated {
+		if p.Id != "" && !shifted[p.Id] {
+			continue
+		}
+		postable := toPostablePipeline(p)
+		postable.Id = ""
+		inserted, apiErr := ic.insertPipeline(ctx, postable)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf("failed to store pipeline %s", p.Name))
+		}
+		updated[i] = *inserted
+	}
+
+	zap.L().Info("cloning log pipeline",
+		zap.String("pipeline", id), zap.String("alias", copied.Alias), zap.Int("orderId", copied.OrderId),
+	)
+	changeNote := fmt.Sprintf("clone pipeline as %s", copied.Alias)
+	return ic.deployPipelines(ctx, userId, updated, changeNote)
+}
+
+// clonePipeline returns the copy of the pipeline with the given id, the
+// pipelines including the copy ordered by orderId, and the ids of the
+// pipelines whose orderId moved to make room for it. The copy has no id
+// as it isn't stored yet.
+func clonePipeline(
+	pipelines []Pipeline, id string, clone *PipelineClone,
+) (*Pipeline, []Pipeline, map[string]bool, error) {
+	var source *Pipeline
+	for i := range pipelines {
+		if pipelines[i].Id == id {
+			source = &pipelines[i]
+		}
+		if pipelines[i].Alias == clone.Alias {
+			return nil, nil, nil, fmt.Errorf(
+				"pipeline %s already has the alias %s", pipelines[i].Name, clone.Alias,
+			)
+		}
+	}
+	if source == nil {
+		return nil, nil, nil, fmt.Errorf("pipeline %s is not in the latest pipelines version", id)
+	}
+
+	orderId := clone.OrderId
+	if orderId == 0 {
+		orderId = source.OrderId + 1
+	}
+
+	copied := *source
+	copied.Id = ""
+	copied.Name = clone.Name
+	copied.Alias = clone.Alias
+	copied.OrderId = orderId
+	if clone.Description != nil {
+		copied.Description = clone.Description
+	}
+	copied.Config = append([]PipelineOperator{}, source.Config...)
+	copied.Creator = Creator{}
+	copied.Updater = Updater{}
+	copied.ChangeNote = ""
+
+	// only the pipelines in the way move, up to the first gap in orderIds
+	updated := sortedByOrder(pipelines)
+	shifted := map[string]bool{}
+	next := orderId
+	for i := range updated {
+		if updated[i].OrderId == next {
+			updated[i].OrderId++
+			shifted[updated[i].Id] = true
+			next++
+		}
+	}
+	updated = append(updated, copied)
+
+	sort.SliceStable(updated, func(i, j int) bool {
+		return updated[i].OrderId < updated[j].OrderId
+	})
+	return &copied, updated, shifted, nil
+}
@@ -0,0 +1,51 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClonePipeline(t *testing.T) {
+	require := require.New(t)
+
+	parse := PipelineOperator{
+		ID: "parse", Type: "json_parser", Enabled: true, Name: "parse body",
+		ParseFrom: "body", ParseTo: "attributes",
+	}
+	pipelines := []Pipeline{
+		{Id: "1", OrderId: 1, Name: "checkout", Alias: "checkout", Enabled: true, Config: []PipelineOperator{parse}},
+		{Id: "2", OrderId: 2, Name: "cart", Alias: "cart", Enabled: true},
+		{Id: "3", OrderId: 3, Name: "search", Alias: "search", Enabled: false},
+		{Id: "4", OrderId: 5, Name: "infra", Alias: "infra", Enabled: true},
+	}
+
+	copied, updated, shifted, err := clonePipeline(pipelines, "1", &PipelineClone{
+		Name: "payments", Alias: "payments", OrderId: 2,
+	})
+	require.Nil(err)
+	require.Equal("", copied.Id, "the copy should be stored as a new pipeline")
+	require.Equal([]PipelineOperator{parse}, copied.Config)
+	require.Equal(map[string]bool{"2": true, "3": true}, shifted, "only pipelines in the way should move")
+
+	order := map[string]int{}
+	for _, p := range updated {
+		order[p.Alias] = p.OrderId
+	}
+	require.Equal(map[string]int{
+		"checkout": 1, "payments": 2, "cart": 3, "search": 4, "infra": 5,
+	}, order)
+	require.Equal(2, pipelines[1].OrderId, "the latest pipelines should not change")
+
+	// the copy goes right after the copied pipeline by default
+	copied, _, shifted, err = clonePipeline(pipelines, "4", &PipelineClone{Name: "infra eu", Alias: "infra-eu"})
+	require.Nil(err)
+	require.Equal(6, copied.OrderId)
+	require.Empty(shifted)
+
+	_, _, _, err = clonePipeline(pipelines, "1", &PipelineClone{Name: "cart", Alias: "cart"})
+	require.ErrorContains(err, "already has the alias")
+	_, _, _, err = clonePipeline(pipelines, "7", &PipelineClone{Name: "new", Alias: "new"})
+	require.NotNil(err)
+	require.NotNil((&PipelineClone{Name: "new"}).IsValid())
+}
@@ -9,7 +9,6 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/pkg/errors"
-	"go.signoz.io/signoz/pkg/query-service/constants"
 	coreModel "go.signoz.io/signoz/pkg/query-service/model"
 	"go.uber.org/zap"
 )
@@ -32,7 +31,7 @@ func buildLogParsingProcessors(agentConf, parsingProcessors map[string]interface
 	}
 	// remove the old unwanted processors
 	for k := range agentProcessors {
-		if _, ok := exists[k]; !ok && strings.HasPrefix(k, constants.LogsPPLPfx) {
+		if _, ok := exists[k]; !ok && isPipelineProcessor(k) {
 			delete(agentProcessors, k)
 		}
 	}
@@ -78,7 +77,7 @@ func buildLogsProcessors(current []string, logsParserPipeline []string) ([]strin
 	var pipeline []string
 	for _, v := range current {
 		k := v
-		if _, ok := exists[k]; ok || !strings.HasPrefix(k, constants.LogsPPLPfx) {
+		if _, ok := exists[k]; ok || !isPipelineProcessor(k) {
 			pipeline = append(pipeline, v)
 		}
 	}
@@ -110,7 +109,7 @@ func buildLogsProcessors(current []string, logsParserPipeline []string) ([]strin
 		m := logsParserPipeline[i]
 		if loc, ok := specVsExistingMap[i]; ok {
 			for j := lastMatched; j < loc; j++ {
-				if strings.HasPrefix(pipeline[j], constants.LogsPPLPfx) {
+				if isPipelineProcessor(pipeline[j]) {
 					delete(specVsExistingMap, existingVsSpec[j])
 				} else {
 					newPipeline = append(newPipeline, pipeline[j])
@@ -150,30 +149,14 @@ func checkDuplicateString(pipeline []string) bool {
 	return false
 }
 
-func GenerateCollectorConfigWithPipelines(
-	config []byte,
-	pipelines []Pipeline,
-) ([]byte, *coreModel.ApiError) {
-	var c map[string]interface{}
-	err := yaml.Unmarshal([]byte(config), &c)
-	if err != nil {
-		return nil, coreModel.BadRequest(err)
-	}
-
-	processors, procNames, err := PreparePipelineProcessor(pipelines)
-	if err != nil {
-		return nil, coreModel.BadRequest(errors.Wrap(
-			err, "could not prepare otel collector processors for log pipelines",
-		))
-	}
-
-	// Escape any `$`s as `$$` in config generated for pipelines, to ensure any occurrences
-	// like $data do not end up being treated as env vars when loading collector config.
+// escapeProcessorsConf escapes any `$`s as `$$` in config generated for pipelines, to ensure
+// any occurrences like $data do not end up being treated as env vars when loading collector config.
+func escapeProcessorsConf(processors map[string]interface{}, procNames []string) *coreModel.ApiError {
 	for _, procName := range procNames {
 		procConf := processors[procName]
 		serializedProcConf, err := yaml.Marshal(procConf)
 		if err != nil {
-			return nil, coreModel.InternalError(fmt.Errorf(
+			return coreModel.InternalError(fmt.Errorf(
 				"could not marshal processor config for %s: %w", procName, err,
 			))
 		}
@@ -184,16 +167,61 @@ func GenerateCollectorConfigWithPipelines(
 		var escapedConf map[string]interface{}
 		err = yaml.Unmarshal([]byte(escapedSerializedConf), &escapedConf)
 		if err != nil {
-			return nil, coreModel.InternalError(fmt.Errorf(
+			return coreModel.InternalError(fmt.Errorf(
 				"could not unmarshal dollar escaped processor config for %s: %w", procName, err,
 			))
 		}
 
 		processors[procName] = escapedConf
 	}
+	return nil
+}
+
+// enableProcessorTelemetry makes sure collectors report the metrics of their
+// processors, which the records received, dropped and refused by each
+// pipeline are read from. Only a disabled telemetry level is changed.
+func enableProcessorTelemetry(agentConf map[string]interface{}) {
+	service, ok := agentConf["service"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	telemetry, ok := service["telemetry"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	metrics, ok := telemetry["metrics"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if metrics["level"] == "none" {
+		metrics["level"] = "basic"
+	}
+}
+
+func GenerateCollectorConfigWithPipelines(
+	config []byte,
+	pipelines []Pipeline,
+) ([]byte, *coreModel.ApiError) {
+	var c map[string]interface{}
+	err := yaml.Unmarshal([]byte(config), &c)
+	if err != nil {
+		return nil, coreModel.BadRequest(err)
+	}
+
+	processors, procNames, err := PreparePipelineProcessor(pipelines)
+	if err != nil {
+		return nil, coreModel.BadRequest(errors.Wrap(
+			err, "could not prepare otel collector processors for log pipelines",
+		))
+	}
+
+	if apiErr := escapeProcessorsConf(processors, procNames); apiErr != nil {
+		return nil, apiErr
+	}
 
 	// Add processors to unmarshaled collector config `c`
 	buildLogParsingProcessors(c, processors)
+	enableProcessorTelemetry(c)
 
 	// build the new processor list in service.pipelines.logs
 	p, err := getOtelPipelineFromConfig(c)
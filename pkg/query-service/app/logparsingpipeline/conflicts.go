@@ -0,0 +1,289 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type PipelineConflictType string
+
+const (
+	// two pipelines set the same field to different values
+	PipelineConflictTypeWrite PipelineConflictType = "conflicting_write"
+	// a pipeline removes a field that a later pipeline reads
+	PipelineConflictTypeRemovedField PipelineConflictType = "removed_dependency"
+)
+
+// PipelineConflict describes interference between two pipelines whose
+// filters can match the same log. Conflicts are reported as warnings and
+// do not prevent pipelines from being saved.
+type PipelineConflict struct {
+	Type      PipelineConflictType `json:"type"`
+	Field     string               `json:"field"`
+	Pipelines []string             `json:"pipelines"`
+	Message   string               `json:"message"`
+}
+
+// fieldWrite is a field set by an operator, value is a description of what
+// is written to it and is compared across pipelines
+type fieldWrite struct {
+	field      string
+	value      string
+	operatorId string
+}
+
+// fieldAccess is a field removed or read by an operator
+type fieldAccess struct {
+	field      string
+	operatorId string
+}
+
+type pipelineFieldUsage struct {
+	writes  []fieldWrite
+	removes []fieldAccess
+	reads   []fieldAccess
+}
+
+var bracketFieldRe = regexp.MustCompile(`\[\s*"([^"]*)"\s*\]`)
+
+// normalizeFieldPath converts bracket accessors to dot notation so that
+// attributes["a"] and attributes.a are treated as the same field
+func normalizeFieldPath(field string) string {
+	return bracketFieldRe.ReplaceAllString(strings.TrimSpace(field), ".$1")
+}
+
+// filterKeyField returns the operator field path for a pipeline filter key
+func filterKeyField(key v3.AttributeKey) string {
+	switch key.Type {
+	case v3.AttributeKeyTypeTag:
+		return "attributes." + key.Key
+	case v3.AttributeKeyTypeResource:
+		return "resource." + key.Key
+	}
+	return key.Key
+}
+
+// isFieldWithin returns true if field is path or nested under it,
+// removing attributes.http removes attributes.http.method as well
+func isFieldWithin(field string, path string) bool {
+	return field == path || strings.HasPrefix(field, path+".")
+}
+
+func getPipelineFieldUsage(p Pipeline) pipelineFieldUsage {
+	usage := pipelineFieldUsage{}
+	if p.Filter != nil {
+		for _, item := range p.Filter.Items {
+			usage.reads = append(usage.reads, fieldAccess{field: filterKeyField(item.Key)})
+		}
+	}
+
+	read := func(field string, op PipelineOperator) {
+		if field != "" {
+			usage.reads = append(usage.reads, fieldAccess{
+				field: normalizeFieldPath(field), operatorId: op.ID,
+			})
+		}
+	}
+
+	for _, op := range p.Config {
+		if !op.Enabled {
+			continue
+		}
+		switch op.Type {
+		case "add":
+			usage.writes = append(usage.writes, fieldWrite{
+				field: normalizeFieldPath(op.Field), value: op.Value, operatorId: op.ID,
+			})
+		case "copy", "move":
+			read(op.From, op)
+			usage.writes = append(usage.writes, fieldWrite{
+				field:      normalizeFieldPath(op.To),
+				value:      fmt.Sprintf("value of %s", normalizeFieldPath(op.From)),
+				operatorId: op.ID,
+			})
+			if op.Type == "move" {
+				usage.removes = append(usage.removes, fieldAccess{
+					field: normalizeFieldPath(op.From), operatorId: op.ID,
+				})
+			}
+		case "remove":
+			usage.removes = append(usage.removes, fieldAccess{
+				field: normalizeFieldPath(op.Field), operatorId: op.ID,
+			})
+		case "trace_parser":
+			if op.Regex != "" {
+				parseFrom := op.ParseFrom
+				if parseFrom == "" {
+					parseFrom = defaultTraceParserParseFrom
+				}
+				read(parseFrom, op)
+			} else if op.TraceParser != nil {
+				for _, pf := range []*ParseFrom{
+					op.TraceParser.TraceId, op.TraceParser.SpanId, op.TraceParser.TraceFlags,
+				} {
+					if pf != nil {
+						read(pf.ParseFrom, op)
+					}
+				}
+			}
+		default:
+			read(op.ParseFrom, op)
+		}
+	}
+	return usage
+}
+
+// filterValues returns the values a filter item compares against
+func filterValues(item v3.FilterItem) []string {
+	if values, ok := item.Value.([]interface{}); ok {
+		result := []string{}
+		for _, v := range values {
+			result = append(result, fmt.Sprintf("%v", v))
+		}
+		return result
+	}
+	return []string{fmt.Sprintf("%v", item.Value)}
+}
+
+// areFilterItemsDisjoint returns true if no log can match both items
+func areFilterItemsDisjoint(a v3.FilterItem, b v3.FilterItem) bool {
+	if filterKeyField(a.Key) != filterKeyField(b.Key) {
+		return false
+	}
+
+	isMatch := func(op v3.FilterOperator) bool {
+		return op == v3.FilterOperatorEqual || op == v3.FilterOperatorIn
+	}
+	isNotMatch := func(op v3.FilterOperator) bool {
+		return op == v3.FilterOperatorNotEqual || op == v3.FilterOperatorNotIn
+	}
+	intersects := func(x []string, y []string) bool {
+		for _, v := range x {
+			for _, w := range y {
+				if v == w {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	containsAll := func(x []string, y []string) bool {
+		for _, v := range y {
+			if !intersects([]string{v}, x) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case isMatch(a.Operator) && isMatch(b.Operator):
+		return !intersects(filterValues(a), filterValues(b))
+	case isMatch(a.Operator) && isNotMatch(b.Operator):
+		return containsAll(filterValues(b), filterValues(a))
+	case isNotMatch(a.Operator) && isMatch(b.Operator):
+		return containsAll(filterValues(a), filterValues(b))
+	case a.Operator == v3.FilterOperatorNotExists:
+		return b.Operator != v3.FilterOperatorNotExists && !isNotMatch(b.Operator)
+	case b.Operator == v3.FilterOperatorNotExists:
+		return !isNotMatch(a.Operator)
+	}
+	return false
+}
+
+// canFiltersOverlap returns false only when the filters provably can't
+// match the same log, filters using OR are always assumed to overlap
+func canFiltersOverlap(a *v3.FilterSet, b *v3.FilterSet) bool {
+	isAnd := func(f *v3.FilterSet) bool {
+		return f != nil && (f.Operator == "" || strings.EqualFold(f.Operator, "AND"))
+	}
+	if !isAnd(a) || !isAnd(b) {
+		return true
+	}
+	for _, x := range a.Items {
+		for _, y := range b.Items {
+			if areFilterItemsDisjoint(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// detectPipelineConflicts finds operations of enabled pipelines that
+// interfere with operations of other pipelines matching the same logs.
+func detectPipelineConflicts(pipelines []Pipeline) []PipelineConflict {
+	enabled := []Pipeline{}
+	for _, p := range pipelines {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool {
+		return enabled[i].OrderId < enabled[j].OrderId
+	})
+
+	usages := make([]pipelineFieldUsage, len(enabled))
+	for i, p := range enabled {
+		usages[i] = getPipelineFieldUsage(p)
+	}
+
+	conflicts := []PipelineConflict{}
+	for i, earlier := range enabled {
+		for j := i + 1; j < len(enabled); j++ {
+			later := enabled[j]
+			if !canFiltersOverlap(earlier.Filter, later.Filter) {
+				continue
+			}
+			names := []string{earlier.Name, later.Name}
+
+			reported := map[string]bool{}
+			for _, w1 := range usages[i].writes {
+				for _, w2 := range usages[j].writes {
+					if w1.field != w2.field || w1.value == w2.value || reported[w1.field] {
+						continue
+					}
+					reported[w1.field] = true
+					conflicts = append(conflicts, PipelineConflict{
+						Type:      PipelineConflictTypeWrite,
+						Field:     w1.field,
+						Pipelines: names,
+						Message: fmt.Sprintf(
+							"%s is set to %q by operator %s of pipeline %s and overwritten with %q by operator %s of pipeline %s",
+							w1.field, w1.value, w1.operatorId, earlier.Name, w2.value, w2.operatorId, later.Name,
+						),
+					})
+				}
+			}
+
+			reported = map[string]bool{}
+			for _, removed := range usages[i].removes {
+				for _, read := range usages[j].reads {
+					if !isFieldWithin(read.field, removed.field) || reported[read.field] {
+						continue
+					}
+					reported[read.field] = true
+
+					usedBy := "the filter"
+					if read.operatorId != "" {
+						usedBy = fmt.Sprintf("operator %s", read.operatorId)
+					}
+					conflicts = append(conflicts, PipelineConflict{
+						Type:      PipelineConflictTypeRemovedField,
+						Field:     read.field,
+						Pipelines: names,
+						Message: fmt.Sprintf(
+							"%s is removed by operator %s of pipeline %s before it is used by %s of pipeline %s",
+							read.field, removed.operatorId, earlier.Name, usedBy, later.Name,
+						),
+					})
+				}
+			}
+		}
+	}
+	return conflicts
+}
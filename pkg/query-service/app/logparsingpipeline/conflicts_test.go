@@ -0,0 +1,182 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func serviceFilter(op v3.FilterOperator, value interface{}) *v3.FilterSet {
+	return &v3.FilterSet{
+		Operator: "AND",
+		Items: []v3.FilterItem{
+			{
+				Key: v3.AttributeKey{
+					Key:      "service",
+					DataType: v3.AttributeKeyDataTypeString,
+					Type:     v3.AttributeKeyTypeResource,
+				},
+				Operator: op,
+				Value:    value,
+			},
+		},
+	}
+}
+
+func TestDetectPipelineConflicts(t *testing.T) {
+	addTeam := func(id string, value string) PipelineOperator {
+		return PipelineOperator{
+			ID: id, Type: "add", Enabled: true, Field: "attributes.team", Value: value,
+		}
+	}
+
+	testCases := []struct {
+		Name          string
+		Pipelines     []Pipeline
+		ExpectedTypes []PipelineConflictType
+	}{
+		{
+			Name: "different values written for overlapping filters",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add1", "payments")},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorIn, []interface{}{"api", "web"}),
+					Config: []PipelineOperator{addTeam("add2", "frontend")},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{PipelineConflictTypeWrite},
+		},
+		{
+			Name: "same value written",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add1", "payments")},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorExists, ""),
+					Config: []PipelineOperator{addTeam("add2", "payments")},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{},
+		},
+		{
+			Name: "disjoint filters",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add1", "payments")},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorNotEqual, "api"),
+					Config: []PipelineOperator{addTeam("add2", "frontend")},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{},
+		},
+		{
+			Name: "disabled pipelines are ignored",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add1", "payments")},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: false,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add2", "frontend")},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{},
+		},
+		{
+			Name: "field removed before a later pipeline parses it",
+			Pipelines: []Pipeline{
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{
+						{
+							ID: "parse", Type: "json_parser", Enabled: true,
+							ParseFrom: `attributes["http.request"]`, ParseTo: "attributes",
+						},
+					},
+				},
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{
+						{ID: "remove", Type: "remove", Enabled: true, Field: "attributes.http"},
+					},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{PipelineConflictTypeRemovedField},
+		},
+		{
+			Name: "field removed after it is used",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{
+						{
+							ID: "copy", Type: "copy", Enabled: true,
+							From: "attributes.user", To: "attributes.user_id",
+						},
+					},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{
+						{ID: "remove", Type: "remove", Enabled: true, Field: "attributes.user"},
+					},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{},
+		},
+		{
+			Name: "field used in a later pipeline filter is moved",
+			Pipelines: []Pipeline{
+				{
+					Name: "p1", OrderId: 1, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorExists, ""),
+					Config: []PipelineOperator{
+						{
+							ID: "move", Type: "move", Enabled: true,
+							From: "resource.service", To: "attributes.service",
+						},
+					},
+				},
+				{
+					Name: "p2", OrderId: 2, Enabled: true,
+					Filter: serviceFilter(v3.FilterOperatorEqual, "api"),
+					Config: []PipelineOperator{addTeam("add", "payments")},
+				},
+			},
+			ExpectedTypes: []PipelineConflictType{PipelineConflictTypeRemovedField},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			conflicts := detectPipelineConflicts(testCase.Pipelines)
+			types := []PipelineConflictType{}
+			for _, c := range conflicts {
+				types = append(types, c.Type)
+			}
+			require.Equal(t, testCase.ExpectedTypes, types)
+		})
+	}
+}
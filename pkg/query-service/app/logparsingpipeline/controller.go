@@ -8,15 +8,19 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 )
 
 // Controller takes care of deployment cycle of log parsing pipelines.
 type LogParsingPipelineController struct {
 	Repo
+
+	metricsCache pipelinesMetricsCache
 }
 
 func NewLogParsingPipelinesController(db *sqlx.DB, engine string) (*LogParsingPipelineController, error) {
@@ -31,12 +35,28 @@ type PipelinesResponse struct {
 
 	Pipelines []Pipeline                `json:"pipelines"`
 	History   []agentConf.ConfigVersion `json:"history"`
+
+	// Conflicts between the saved pipelines, only populated when applying pipelines
+	Conflicts []PipelineConflict `json:"conflicts,omitempty"`
+
+	// Warnings about slow regexes of the saved pipelines, only populated when applying pipelines
+	RegexWarnings []string `json:"regexWarnings,omitempty"`
+
+	// Projected collector cpu cost of the saved pipelines, only populated when applying pipelines
+	CostEstimate *PipelinesCostEstimate `json:"costEstimate,omitempty"`
+
+	// Recent throughput of the deployed pipelines, only populated for the latest version
+	Metrics *PipelinesMetrics `json:"metrics,omitempty"`
+
+	// Processors changed by the version compared to the one deployed before it
+	ProcessorChanges *ProcessorChanges `json:"processorChanges,omitempty"`
 }
 
 // ApplyPipelines stores new or changed pipelines and initiates a new config update
 func (ic *LogParsingPipelineController) ApplyPipelines(
 	ctx context.Context,
 	postable []PostablePipeline,
+	changeNote string,
 ) (*PipelinesResponse, *model.ApiError) {
 	// get user id from context
 	userId, authErr := auth.ExtractUserIdFromContext(ctx)
@@ -44,6 +64,10 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
 	}
 
+	if err := validateChangeNote(changeNote); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
 	var pipelines []Pipeline
 
 	// scan through postable pipelines, to select the existing pipelines or insert missing ones
@@ -73,25 +97,50 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 
 	}
 
-	// prepare config elements
-	elements := make([]string, len(pipelines))
-	for i, p := range pipelines {
-		elements[i] = p.Id
+	return ic.deployPipelines(ctx, userId, pipelines, changeNote)
+}
+
+// deployPipelines starts a new config version made of the stored pipelines
+// which gets rolled out to agents
+func (ic *LogParsingPipelineController) deployPipelines(
+	ctx context.Context, userId string, pipelines []Pipeline, changeNote string,
+) (*PipelinesResponse, *model.ApiError) {
+	resolved, apiErr := ic.resolvePipelines(ctx, pipelines)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	conflicts := detectPipelineConflicts(resolved)
+	for _, c := range conflicts {
+		zap.S().Warnf("conflict between log pipelines %v: %s", c.Pipelines, c.Message)
+	}
+
+	previousVersion, previous, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	// prepare config by calling gen func
-	cfg, err := agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements)
+	cfg, processorChanges, err := ic.startPipelinesVersion(
+		ctx, userId, previousVersion, previous, pipelines, changeNote,
+	)
 	if err != nil || cfg == nil {
 		return nil, err
 	}
 
+	if apiErr := ic.recordDeletedPipelines(ctx, userId, previous, pipelines); apiErr != nil {
+		zap.L().Error("could not move deleted pipelines to trash", zap.Error(apiErr.ToError()))
+	}
+
 	history, _ := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeLogPipelines, 10)
 	insertedCfg, _ := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, cfg.Version)
 
 	response := &PipelinesResponse{
-		ConfigVersion: insertedCfg,
-		Pipelines:     pipelines,
-		History:       history,
+		ConfigVersion:    insertedCfg,
+		Pipelines:        pipelines,
+		History:          history,
+		Conflicts:        conflicts,
+		RegexWarnings:    detectRegexWarnings(resolved),
+		ProcessorChanges: processorChanges,
 	}
 
 	if err != nil {
@@ -100,6 +149,80 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 	return response, nil
 }
 
+// PatchPipelines enables, disables or reorders pipelines of the latest
+// version. The changed pipelines are stored as new pipelines and all the
+// changes are deployed together as one new config version.
+func (ic *LogParsingPipelineController) PatchPipelines(
+	ctx context.Context, changes *PipelineChanges,
+) (*PipelinesResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if err := changes.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	latestVersion, latest, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	updated, changed, err := applyPipelineChanges(latest, changes.Pipelines)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if len(changed) == 0 {
+		// nothing to deploy, avoid adding a version identical to the latest
+		return ic.GetPipelinesByVersion(ctx, latestVersion.Version)
+	}
+
+	// pipelines of a version are immutable, changed ones are stored anew
+	for i, p := range updated {
+		if !changed[p.Id] {
+			continue
+		}
+		postable := toPostablePipeline(p)
+		postable.Id = ""
+		postable.ChangeNote = changes.ChangeNote
+		inserted, apiErr := ic.insertPipeline(ctx, postable)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf("failed to store changed pipeline %s", p.Name))
+		}
+		updated[i] = *inserted
+	}
+
+	zap.L().Info("patching log pipelines",
+		zap.Int("version", latestVersion.Version), zap.Int("changed", len(changed)),
+	)
+	return ic.deployPipelines(ctx, userId, updated, changes.ChangeNote)
+}
+
+// RollbackPipelines deploys the pipelines of a previous config version again
+// as a new version
+func (ic *LogParsingPipelineController) RollbackPipelines(
+	ctx context.Context, version int,
+) (*PipelinesResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if _, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, version); apiErr != nil {
+		return nil, model.WrapApiError(apiErr, fmt.Sprintf("failed to get pipelines version %d", version))
+	}
+	pipelines, errs := ic.getPipelinesByVersion(ctx, version)
+	if len(errs) > 0 {
+		return nil, model.InternalError(multierr.Combine(errs...))
+	}
+
+	zap.L().Info("rolling back log pipelines",
+		zap.Int("version", version), zap.Int("pipelines", len(pipelines)),
+	)
+	return ic.deployPipelines(ctx, userId, pipelines, fmt.Sprintf("rollback to version %d", version))
+}
+
 // GetPipelinesByVersion responds with version info and associated pipelines
 func (ic *LogParsingPipelineController) GetPipelinesByVersion(
 	ctx context.Context, version int,
@@ -115,9 +238,15 @@ func (ic *LogParsingPipelineController) GetPipelinesByVersion(
 		return nil, model.WrapApiError(err, "failed to get config for given version")
 	}
 
+	processorChanges, apiErr := ic.getProcessorChanges(ctx, version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	return &PipelinesResponse{
-		ConfigVersion: configVersion,
-		Pipelines:     pipelines,
+		ConfigVersion:    configVersion,
+		Pipelines:        pipelines,
+		ProcessorChanges: processorChanges,
 	}, nil
 }
 
@@ -126,6 +255,10 @@ type PipelinesPreviewRequest struct {
 	Logs      []model.SignozLog `json:"logs"`
 }
 
+type PipelinesCostEstimateRequest struct {
+	Pipelines []Pipeline `json:"pipelines"`
+}
+
 type PipelinesPreviewResponse struct {
 	OutputLogs    []model.SignozLog `json:"logs"`
 	CollectorLogs []string          `json:"collectorLogs"`
@@ -135,8 +268,21 @@ func (ic *LogParsingPipelineController) PreviewLogsPipelines(
 	ctx context.Context,
 	request *PipelinesPreviewRequest,
 ) (*PipelinesPreviewResponse, *model.ApiError) {
+	pipelines, apiErr := ic.resolvePipelines(ctx, request.Pipelines)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// report mistakes in the pipelines being edited the same way saving
+	// them would, instead of as failures of the simulated collector
+	for _, p := range pipelines {
+		if err := toPostablePipeline(p).IsValid(); err != nil {
+			return nil, model.BadRequest(fmt.Errorf("invalid pipeline %s: %w", p.Name, err))
+		}
+	}
+
 	result, collectorLogs, err := SimulatePipelinesProcessing(
-		ctx, request.Pipelines, request.Logs,
+		ctx, pipelines, request.Logs,
 	)
 
 	if err != nil {
@@ -163,6 +309,50 @@ func (pc *LogParsingPipelineController) RecommendAgentConfig(
 	serializedSettingsUsed string,
 	apiErr *model.ApiError,
 ) {
+	return pc.recommendAgentConfig(currentConfYaml, configVersion, nil)
+}
+
+// Implements agentConf.AgentScopedFeature interface. Agents only get the
+// pipelines whose agent selector matches them.
+func (pc *LogParsingPipelineController) RecommendAgentConfigForAgent(
+	agent opampModel.AgentInfo,
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	return pc.recommendAgentConfig(currentConfYaml, configVersion, &agent)
+}
+
+// Implements agentConf.AgentGroupReferrer interface.
+func (pc *LogParsingPipelineController) AgentGroupReferences(
+	ctx context.Context, group string,
+) ([]string, *model.ApiError) {
+	_, pipelines, apiErr := pc.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	names := []string{}
+	for _, p := range pipelines {
+		if p.AgentSelector.SelectsAgentGroup(group) {
+			names = append(names, p.Name)
+		}
+	}
+	return names, nil
+}
+
+func (pc *LogParsingPipelineController) recommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+	agent *opampModel.AgentInfo,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
 
 	pipelines, errs := pc.getPipelinesByVersion(
 		context.Background(), configVersion.Version,
@@ -171,8 +361,20 @@ func (pc *LogParsingPipelineController) RecommendAgentConfig(
 		return nil, "", model.InternalError(multierr.Combine(errs...))
 	}
 
+	pipelines, apiErr = pc.resolvePipelines(context.Background(), pipelines)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	// the settings used are all the pipelines of the version, so that
+	// deployment status is tracked for the version as a whole
+	deployed := pipelines
+	if agent != nil {
+		deployed = pipelinesForAgent(pipelines, *agent)
+	}
+
 	updatedConf, apiErr := GenerateCollectorConfigWithPipelines(
-		currentConfYaml, pipelines,
+		currentConfYaml, deployed,
 	)
 	if apiErr != nil {
 		return nil, "", model.WrapApiError(apiErr, "could not marshal yaml for updated conf")
@@ -186,3 +388,181 @@ func (pc *LogParsingPipelineController) RecommendAgentConfig(
 	return updatedConf, string(rawPipelineData), nil
 
 }
+
+// resolvePipelines replaces references to pipeline variables and json
+// schemas in pipelines with their current values
+func (ic *LogParsingPipelineController) resolvePipelines(
+	ctx context.Context, pipelines []Pipeline,
+) ([]Pipeline, *model.ApiError) {
+	variables, apiErr := ic.getPipelineVariables(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	resolved, err := resolvePipelineVariables(pipelines, variables)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	schemas, apiErr := ic.getJSONSchemas(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := resolveJSONSchemas(resolved, schemas); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return resolved, nil
+}
+
+func (ic *LogParsingPipelineController) ListPipelineVariables(
+	ctx context.Context,
+) ([]PipelineVariable, *model.ApiError) {
+	return ic.getPipelineVariables(ctx)
+}
+
+// SetPipelineVariable creates or updates a pipeline variable and redeploys
+// the latest pipelines if they reference it
+func (ic *LogParsingPipelineController) SetPipelineVariable(
+	ctx context.Context, postable *PostablePipelineVariable,
+) (*PipelineVariable, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	variable, apiErr := ic.upsertPipelineVariable(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latestVersion, pipelines, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	referenced, err := referencedVariables(pipelines)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	if latestVersion == nil || !slices.Contains(referenced, variable.Name) {
+		return variable, nil
+	}
+
+	changeNote := fmt.Sprintf("update pipeline variable %s", variable.Name)
+	if apiErr := ic.redeployPipelines(ctx, userId, latestVersion, pipelines, changeNote); apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "could not deploy pipelines with updated variable")
+	}
+	return variable, nil
+}
+
+// DeletePipelineVariable deletes a pipeline variable which is not
+// referenced by the latest pipelines
+func (ic *LogParsingPipelineController) DeletePipelineVariable(
+	ctx context.Context, name string,
+) *model.ApiError {
+	_, pipelines, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	referenced, err := referencedVariables(pipelines)
+	if err != nil {
+		return model.InternalError(err)
+	}
+	if slices.Contains(referenced, name) {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("pipeline variable %s is in use by pipelines", name),
+		}
+	}
+	return ic.deletePipelineVariable(ctx, name)
+}
+
+func (ic *LogParsingPipelineController) ListJSONSchemas(
+	ctx context.Context,
+) ([]RegisteredJSONSchema, *model.ApiError) {
+	return ic.getJSONSchemas(ctx)
+}
+
+// SetJSONSchema registers or updates a json schema and redeploys the latest
+// pipelines if they validate logs against it
+func (ic *LogParsingPipelineController) SetJSONSchema(
+	ctx context.Context, postable *PostableJSONSchema,
+) (*RegisteredJSONSchema, *model.ApiError) {
+	schema, err := postable.parse()
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	registered, apiErr := ic.upsertJSONSchema(ctx, userId, postable.Name, schema)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latestVersion, pipelines, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if latestVersion == nil || !slices.Contains(referencedJSONSchemas(pipelines), registered.Name) {
+		return registered, nil
+	}
+
+	changeNote := fmt.Sprintf("update json schema %s", registered.Name)
+	if apiErr := ic.redeployPipelines(ctx, userId, latestVersion, pipelines, changeNote); apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "could not deploy pipelines with updated json schema")
+	}
+	return registered, nil
+}
+
+// DeleteJSONSchema deletes a json schema which is not used by the latest pipelines
+func (ic *LogParsingPipelineController) DeleteJSONSchema(
+	ctx context.Context, name string,
+) *model.ApiError {
+	_, pipelines, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	if slices.Contains(referencedJSONSchemas(pipelines), name) {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("json schema %s is in use by pipelines", name),
+		}
+	}
+	return ic.deleteJSONSchema(ctx, name)
+}
+
+// getLatestPipelines returns the latest pipelines config version and its
+// pipelines, the version is nil if pipelines have never been saved
+func (ic *LogParsingPipelineController) getLatestPipelines(
+	ctx context.Context,
+) (*agentConf.ConfigVersion, []Pipeline, *model.ApiError) {
+	latestVersion, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogPipelines)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return nil, []Pipeline{}, nil
+		}
+		return nil, nil, model.WrapApiError(apiErr, "could not get latest pipelines version")
+	}
+
+	pipelines, errs := ic.getPipelinesByVersion(ctx, latestVersion.Version)
+	if len(errs) > 0 {
+		return nil, nil, model.InternalError(multierr.Combine(errs...))
+	}
+	return latestVersion, pipelines, nil
+}
+
+// redeployPipelines starts a new config version with the same pipelines so
+// that changes to the variables or schemas they use get rolled out to agents
+func (ic *LogParsingPipelineController) redeployPipelines(
+	ctx context.Context, userId string, latestVersion *agentConf.ConfigVersion, pipelines []Pipeline,
+	changeNote string,
+) *model.ApiError {
+	_, _, apiErr := ic.startPipelinesVersion(ctx, userId, latestVersion, pipelines, pipelines, changeNote)
+	return apiErr
+}
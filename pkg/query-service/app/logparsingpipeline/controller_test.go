@@ -0,0 +1,271 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func newTestController(t *testing.T) *LogParsingPipelineController {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	require.Nil(t, dao.InitDao("sqlite", testDBFile.Name()))
+	testDB, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(t, err)
+	controller, err := NewLogParsingPipelinesController(testDB, "sqlite")
+	require.Nil(t, err)
+	_, err = agentConf.Initiate(&agentConf.ManagerOptions{DB: testDB, DBEngine: "sqlite"})
+	require.Nil(t, err)
+	return controller
+}
+
+func userContext(t *testing.T) context.Context {
+	userJwt, err := auth.GenerateJWTForUser(&model.User{Id: "user1", Email: "user1@signoz.io"})
+	require.Nil(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/pipelines", nil)
+	req.Header.Add("Authorization", "Bearer "+userJwt.AccessJwt)
+	return auth.AttachJwtToContext(context.Background(), req)
+}
+
+func TestPreviewValidatesPipelines(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+
+	pipeline := Pipeline{
+		OrderId: 1, Name: "checkout", Alias: "checkout", Enabled: true,
+		Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+			Key:      v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+			Operator: "=",
+			Value:    "GET",
+		}}},
+		Config: []PipelineOperator{{
+			OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add",
+			Field: "attributes.test", Value: "val",
+		}},
+	}
+	logs := []model.SignozLog{makeTestSignozLog("test log", map[string]interface{}{"method": "GET"})}
+
+	resp, apiErr := controller.PreviewLogsPipelines(context.Background(), &PipelinesPreviewRequest{
+		Pipelines: []Pipeline{pipeline}, Logs: logs,
+	})
+	require.Nil(apiErr)
+	require.Len(resp.OutputLogs, 1)
+	require.Equal("val", resp.OutputLogs[0].Attributes_string["test"])
+
+	invalid := []func(p *Pipeline){
+		func(p *Pipeline) { p.Alias = "" },
+		func(p *Pipeline) { p.Config[0].ID = "" },
+		func(p *Pipeline) { p.Filter.Items[0].Operator = "unknown" },
+	}
+	for _, invalidate := range invalid {
+		p := pipeline
+		p.Filter = &v3.FilterSet{Operator: "AND", Items: append([]v3.FilterItem{}, pipeline.Filter.Items...)}
+		p.Config = append([]PipelineOperator{}, pipeline.Config...)
+		invalidate(&p)
+
+		_, apiErr := controller.PreviewLogsPipelines(context.Background(), &PipelinesPreviewRequest{
+			Pipelines: []Pipeline{p}, Logs: logs,
+		})
+		require.NotNil(apiErr)
+		require.Equal(model.ErrorBadData, apiErr.Typ)
+		require.Contains(apiErr.Err.Error(), "invalid pipeline checkout")
+	}
+}
+
+func TestRollbackPipelines(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := userContext(t)
+
+	postable := func(name string) PostablePipeline {
+		return PostablePipeline{
+			OrderId: 1, Name: name, Alias: name, Enabled: true,
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+				Key:      v3.AttributeKey{Key: "service", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+				Operator: "=",
+				Value:    name,
+			}}},
+			Config: []PipelineOperator{{
+				OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add",
+				Field: "attributes.pipeline", Value: name,
+			}},
+		}
+	}
+
+	first, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("checkout")}, "")
+	require.Nil(apiErr)
+	second, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("cart")}, "")
+	require.Nil(apiErr)
+	require.Equal(first.Version+1, second.Version)
+
+	rolledBack, apiErr := controller.RollbackPipelines(ctx, first.Version)
+	require.Nil(apiErr)
+	require.Equal(second.Version+1, rolledBack.Version, "a rollback is deployed as a new version")
+	require.Equal(fmt.Sprintf("rollback to version %d", first.Version), rolledBack.ChangeNote)
+	require.Len(rolledBack.Pipelines, 1)
+	require.Equal(first.Pipelines[0].Id, rolledBack.Pipelines[0].Id, "the pipelines of the version are deployed again")
+
+	latest, apiErr := controller.GetPipelinesByVersion(ctx, rolledBack.Version)
+	require.Nil(apiErr)
+	require.Len(latest.Pipelines, 1)
+	require.Equal("checkout", latest.Pipelines[0].Name)
+
+	_, apiErr = controller.RollbackPipelines(ctx, 42)
+	require.NotNil(apiErr, "unknown versions can't be rolled back to")
+	_, apiErr = controller.RollbackPipelines(context.Background(), first.Version)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorUnauthorized, apiErr.Typ)
+}
+
+func TestUnchangedPipelinesAreNotPushed(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := userContext(t)
+	mgr, err := agentConf.Initiate(&agentConf.ManagerOptions{
+		DB: controller.db, DBEngine: "sqlite", AgentFeatures: []agentConf.AgentFeature{controller},
+	})
+	require.Nil(err)
+	pushes := 0
+	mgr.SubscribeToConfigUpdates(func() { pushes++ })
+
+	postable := func(name string, alias string, value string) PostablePipeline {
+		return PostablePipeline{
+			OrderId: 1, Name: name, Alias: alias, Enabled: true,
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+				Key:      v3.AttributeKey{Key: "service", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+				Operator: "=",
+				Value:    alias,
+			}}},
+			Config: []PipelineOperator{{
+				OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add",
+				Field: "attributes.team", Value: value,
+			}},
+		}
+	}
+	deploy := func() {
+		_, configId, err := mgr.RecommendAgentConfig(opampModel.AgentInfo{ID: "agent-1"}, []byte(`
+receivers:
+  otlp:
+exporters:
+  otlp:
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      exporters: [otlp]
+`))
+		require.Nil(err)
+		mgr.ReportConfigDeploymentStatus("agent-1", configId, nil)
+	}
+
+	first, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("checkout", "checkout", "shop")}, "")
+	require.Nil(apiErr)
+	require.Equal(1, pushes)
+
+	// the previous version hasn't reached the agents yet
+	renamed, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("Checkout", "checkout", "shop")}, "rename")
+	require.Nil(apiErr)
+	require.Equal(2, pushes)
+	require.NotEqual(agentConf.Deployed, renamed.DeployStatus)
+	deploy()
+
+	renamed, apiErr = controller.ApplyPipelines(ctx, []PostablePipeline{postable("Checkout service", "checkout", "shop")}, "rename")
+	require.Nil(apiErr)
+	require.Equal(2, pushes, "versions which don't change the processors are not pushed to the agents")
+	require.Equal(first.Version+2, renamed.Version)
+	require.Equal(agentConf.Deployed, renamed.DeployStatus)
+	require.Equal("rename", renamed.ChangeNote)
+	require.Equal([]string{"logstransform/pipeline_checkout"}, renamed.ProcessorChanges.Unchanged)
+
+	changed, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("Checkout service", "checkout", "payments")}, "")
+	require.Nil(apiErr)
+	require.Equal(3, pushes)
+	require.NotEqual(agentConf.Deployed, changed.DeployStatus)
+	require.Equal([]string{"logstransform/pipeline_checkout"}, changed.ProcessorChanges.Modified)
+	deploy()
+
+	// reordering pipelines changes the config of the agents
+	cart := postable("cart", "cart", "shop")
+	cart.OrderId = 2
+	_, apiErr = controller.ApplyPipelines(ctx, []PostablePipeline{postable("Checkout service", "checkout", "payments"), cart}, "")
+	require.Nil(apiErr)
+	require.Equal(4, pushes)
+	deploy()
+	cart.OrderId = 1
+	checkout := postable("Checkout service", "checkout", "payments")
+	checkout.OrderId = 2
+	reordered, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{cart, checkout}, "")
+	require.Nil(apiErr)
+	require.Equal(5, pushes)
+	require.Empty(reordered.ProcessorChanges.Modified)
+	require.Equal([]string{"logstransform/pipeline_cart", "logstransform/pipeline_checkout"}, reordered.ProcessorChanges.Order)
+}
+
+func TestDryRunPipelines(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := userContext(t)
+
+	postable := func(name string, orderId int) PostablePipeline {
+		return PostablePipeline{
+			OrderId: orderId, Name: name, Alias: name, Enabled: true,
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+				Key:      v3.AttributeKey{Key: "service", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+				Operator: "=",
+				Value:    name,
+			}}},
+			Config: []PipelineOperator{{
+				OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add",
+				Field: "attributes.cost", Value: "$5",
+			}},
+		}
+	}
+
+	applied, apiErr := controller.ApplyPipelines(ctx, []PostablePipeline{postable("checkout", 1)}, "")
+	require.Nil(apiErr)
+	stored := postable("checkout", 1)
+	stored.Id = applied.Pipelines[0].Id
+
+	resp, apiErr := controller.DryRunPipelines(ctx, []PostablePipeline{stored, postable("cart", 2)})
+	require.Nil(apiErr)
+	require.Len(resp.Pipelines, 2)
+	require.Equal(stored.Id, resp.Pipelines[0].Id)
+	require.Equal("", resp.Pipelines[1].Id, "dry runs don't store pipelines")
+	require.Equal([]string{"logstransform/pipeline_checkout", "logstransform/pipeline_cart"}, resp.ProcessorNames)
+	require.Len(resp.Routes, 2)
+	require.Equal("cart", resp.Routes[1].Alias)
+	require.Equal("logstransform/pipeline_cart", resp.Routes[1].Processor)
+	require.Contains(resp.Routes[1].Expr, `attributes["service"] == "cart"`)
+	require.Contains(resp.ProcessorsYaml, "logstransform/pipeline_cart:")
+	require.Contains(resp.ProcessorsYaml, "$$5", "the config is escaped the way it is deployed")
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogPipelines)
+	require.Nil(apiErr)
+	require.Equal(applied.Version, latest.Version, "dry runs don't deploy pipelines")
+
+	invalid := postable("search", 3)
+	invalid.Alias = ""
+	_, apiErr = controller.DryRunPipelines(ctx, []PostablePipeline{invalid})
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Typ)
+
+	unknown := postable("search", 3)
+	unknown.Id = "unknown"
+	_, apiErr = controller.DryRunPipelines(ctx, []PostablePipeline{unknown})
+	require.NotNil(apiErr)
+}
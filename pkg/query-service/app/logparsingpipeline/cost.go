@@ -0,0 +1,338 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+
+	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// throughput of the logs matching pipeline filters is averaged over this window
+	costLookbackMinutes = 60
+
+	// rough cpu cost in microseconds of processing a record, these are
+	// relative estimates of collector operators and not measurements
+	filterCostMicros          = 2.0
+	fieldOperatorCostMicros   = 1.0
+	parserCostMicros          = 3.0
+	jsonParserCostMicros      = 10.0
+	regexBaseCostMicros       = 4.0
+	grokPatternCostMicros     = 6.0
+	schemaValidatorCostMicros = 15.0
+)
+
+var grokPatternRef = regexp.MustCompile(`%\{[^}]+\}`)
+
+// PipelineOperatorCost is the estimated cost of an operator per record
+type PipelineOperatorCost struct {
+	Id          string  `json:"id"`
+	Type        string  `json:"type"`
+	CostMicros  float64 `json:"costMicros"`
+	Explanation string  `json:"explanation,omitempty"`
+}
+
+type PipelineCostEstimate struct {
+	Id    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Alias string `json:"alias"`
+	// CostMicros is the estimated cpu time spent on a log matching the filter
+	CostMicros float64 `json:"costMicros"`
+	// RecordsPerSecond is the recent rate of logs matching the filter
+	RecordsPerSecond float64                `json:"recordsPerSecond"`
+	Cores            float64                `json:"cores"`
+	Operators        []PipelineOperatorCost `json:"operators"`
+}
+
+// PipelinesCostEstimate projects the collector cpu used by a set of pipelines
+// from the recent throughput of the logs they process
+type PipelinesCostEstimate struct {
+	BudgetCores      float64                `json:"budgetCores"`
+	TotalCores       float64                `json:"totalCores"`
+	ExceedsBudget    bool                   `json:"exceedsBudget"`
+	LookbackMinutes  int                    `json:"lookbackMinutes"`
+	RecordsPerSecond float64                `json:"recordsPerSecond"`
+	Pipelines        []PipelineCostEstimate `json:"pipelines"`
+	Warnings         []string               `json:"warnings,omitempty"`
+}
+
+// regexComplexity scores how expensive a regex is to match, growing with
+// its size and with quantifiers nested in other quantifiers which make the
+// regex engine do more work per byte
+func regexComplexity(expr string) (float64, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return 0, err
+	}
+	re = re.Simplify()
+
+	nodes := 0
+	maxNesting := 0
+	var walk func(r *syntax.Regexp, nesting int)
+	walk = func(r *syntax.Regexp, nesting int) {
+		nodes++
+		switch r.Op {
+		case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+			nesting++
+		}
+		if nesting > maxNesting {
+			maxNesting = nesting
+		}
+		for _, sub := range r.Sub {
+			walk(sub, nesting)
+		}
+	}
+	walk(re, 0)
+
+	nestingFactor := 1.0
+	if maxNesting > 1 {
+		nestingFactor = float64(maxNesting * maxNesting)
+	}
+	return (1 + float64(nodes)/20) * nestingFactor, nil
+}
+
+func estimateOperatorCost(op PipelineOperator) PipelineOperatorCost {
+	cost := PipelineOperatorCost{Id: op.ID, Type: op.Type}
+	switch op.Type {
+	case "add", "copy", "move", "remove", "retain", samplerOperator:
+		cost.CostMicros = fieldOperatorCostMicros
+	case "json_parser", jsonFlattenOperator:
+		cost.CostMicros = jsonParserCostMicros
+	case "regex_parser":
+		complexity, err := regexComplexity(op.Regex)
+		if err != nil {
+			complexity = 1
+		}
+		cost.CostMicros = regexBaseCostMicros * complexity
+		cost.Explanation = fmt.Sprintf("regex complexity %.1f", complexity)
+	case "grok_parser":
+		refs := len(grokPatternRef.FindAllString(op.Pattern, -1))
+		cost.CostMicros = regexBaseCostMicros + grokPatternCostMicros*float64(refs)
+		cost.Explanation = fmt.Sprintf("%d grok patterns", refs)
+	case jsonSchemaValidatorOperator:
+		cost.CostMicros = schemaValidatorCostMicros
+	case maskOperator:
+		if op.Regex == "" {
+			cost.CostMicros = fieldOperatorCostMicros * float64(len(op.Fields))
+			break
+		}
+		complexity, err := regexComplexity(op.Regex)
+		if err != nil {
+			complexity = 1
+		}
+		cost.CostMicros = regexBaseCostMicros * complexity
+		cost.Explanation = fmt.Sprintf("regex complexity %.1f", complexity)
+	default:
+		cost.CostMicros = parserCostMicros
+	}
+	return cost
+}
+
+// estimatePipelineCost returns the cost per record of the operators of a
+// pipeline, filter evaluation is accounted for separately as it applies to
+// every log and not only to the matching ones
+func estimatePipelineCost(p Pipeline) PipelineCostEstimate {
+	estimate := PipelineCostEstimate{
+		Id: p.Id, Name: p.Name, Alias: p.Alias, Operators: []PipelineOperatorCost{},
+	}
+	for _, op := range p.Config {
+		if !op.Enabled {
+			continue
+		}
+		opCost := estimateOperatorCost(op)
+		estimate.CostMicros += opCost.CostMicros
+		estimate.Operators = append(estimate.Operators, opCost)
+	}
+	return estimate
+}
+
+func pipelineCostQueryName(i int) string {
+	return fmt.Sprintf("P%d", i)
+}
+
+const (
+	totalLogsCostQueryName = "TOTAL"
+
+	// pipelineThroughputQueryName is the query counting the logs, its series
+	// are labeled with pipelineThroughputLabel by the count they hold
+	pipelineThroughputQueryName = "THROUGHPUT"
+	pipelineThroughputLabel     = "count_name"
+)
+
+// pipelineThroughputParams queries the count of all logs and of the logs
+// matching the filter of each pipeline over the lookback window, in a single
+// scan of the logs. The pipelines whose filter can't be translated are left
+// out of the counts.
+func pipelineThroughputParams(pipelines []Pipeline, start, end int64) *v3.QueryRangeParamsV3 {
+	names := []string{fmt.Sprintf("'%s'", totalLogsCostQueryName)}
+	counts := []string{"count()"}
+	for i, p := range pipelines {
+		filter, err := logsv3.BuildLogsFilterQuery(p.Filter)
+		if err != nil {
+			zap.L().Warn("could not count the logs matching a log pipeline",
+				zap.String("pipeline", p.Name), zap.Error(err),
+			)
+			continue
+		}
+		if filter == "" {
+			filter = "true"
+		}
+		names = append(names, fmt.Sprintf("'%s'", pipelineCostQueryName(i)))
+		counts = append(counts, fmt.Sprintf("countIf(%s)", filter))
+	}
+
+	query := fmt.Sprintf(`SELECT %s, value FROM (
+	SELECT [%s] AS counts
+	FROM signoz_logs.distributed_logs
+	WHERE timestamp >= %d AND timestamp <= %d
+) ARRAY JOIN counts AS value, [%s] AS %s`,
+		pipelineThroughputLabel, strings.Join(counts, ", "),
+		start*int64(time.Millisecond), end*int64(time.Millisecond),
+		strings.Join(names, ", "), pipelineThroughputLabel,
+	)
+
+	return &v3.QueryRangeParamsV3{
+		Start: start,
+		End:   end,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeClickHouseSQL,
+			PanelType: v3.PanelTypeTable,
+			ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+				pipelineThroughputQueryName: {Query: query},
+			},
+		},
+	}
+}
+
+// throughputFromResults reads the results of the throughput query into the
+// count of all logs and of the logs matching each pipeline, keyed by the
+// index of the pipeline. ok is false when the logs couldn't be counted.
+func throughputFromResults(results []*v3.Result) (total float64, matching map[int]float64, ok bool) {
+	matching = map[int]float64{}
+	for _, result := range results {
+		if result == nil || result.QueryName != pipelineThroughputQueryName {
+			continue
+		}
+		for _, series := range result.Series {
+			count := 0.0
+			for _, p := range series.Points {
+				count += p.Value
+			}
+			name := series.Labels[pipelineThroughputLabel]
+			if name == totalLogsCostQueryName {
+				total, ok = count, true
+				continue
+			}
+			var i int
+			if _, err := fmt.Sscanf(name, "P%d", &i); err == nil {
+				matching[i] = count
+			}
+		}
+	}
+	return total, matching, ok
+}
+
+// projectPipelinesCost combines the cost estimates of the pipelines with the
+// rate of logs matching them. Every log goes through the filter of every
+// pipeline while only the matching ones go through its operators.
+func projectPipelinesCost(
+	pipelines []Pipeline,
+	totalRate float64,
+	matchingRates map[int]float64,
+	budgetCores float64,
+) *PipelinesCostEstimate {
+	estimate := &PipelinesCostEstimate{
+		BudgetCores:      budgetCores,
+		LookbackMinutes:  costLookbackMinutes,
+		RecordsPerSecond: totalRate,
+		Pipelines:        []PipelineCostEstimate{},
+	}
+
+	for i, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+		pipelineEstimate := estimatePipelineCost(p)
+		rate, ok := matchingRates[i]
+		if !ok {
+			// the share of logs matching couldn't be found out, assume all do
+			rate = totalRate
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf(
+				"could not find the logs matching the filter of pipeline %s, assuming it processes all logs", p.Name,
+			))
+		}
+		pipelineEstimate.RecordsPerSecond = rate
+		pipelineEstimate.Cores = (totalRate*filterCostMicros + rate*pipelineEstimate.CostMicros) / 1e6
+		estimate.TotalCores += pipelineEstimate.Cores
+		estimate.Pipelines = append(estimate.Pipelines, pipelineEstimate)
+
+		for _, op := range pipelineEstimate.Operators {
+			if op.Type == "regex_parser" && op.CostMicros > 10*regexBaseCostMicros {
+				estimate.Warnings = append(estimate.Warnings, fmt.Sprintf(
+					"regex of operator %s in pipeline %s is expensive to match (%s)", op.Id, p.Name, op.Explanation,
+				))
+			}
+		}
+	}
+
+	sort.SliceStable(estimate.Pipelines, func(i, j int) bool {
+		return estimate.Pipelines[i].Cores > estimate.Pipelines[j].Cores
+	})
+
+	if estimate.TotalCores > budgetCores {
+		estimate.ExceedsBudget = true
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf(
+			"pipelines are projected to use %.2f collector cpu cores, more than the budget of %.2f cores",
+			estimate.TotalCores, budgetCores,
+		))
+	}
+	return estimate
+}
+
+// EstimatePipelinesCost projects the collector cpu the pipelines would use
+// given the logs received over the last hour
+func (ic *LogParsingPipelineController) EstimatePipelinesCost(
+	ctx context.Context, querier interfaces.Querier, pipelines []Pipeline,
+) (*PipelinesCostEstimate, *model.ApiError) {
+	resolved, apiErr := ic.resolvePipelines(ctx, pipelines)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	end := time.Now()
+	start := end.Add(-costLookbackMinutes * time.Minute)
+	params := pipelineThroughputParams(resolved, start.UnixMilli(), end.UnixMilli())
+	results, err, errQueriesByName := querier.QueryRange(ctx, params, map[string]v3.AttributeKey{})
+	if err != nil {
+		zap.L().Warn("could not query throughput of log pipelines",
+			zap.Error(err), zap.Any("errors", errQueriesByName),
+		)
+	}
+
+	total, matching, ok := throughputFromResults(results)
+	if !ok {
+		if err == nil {
+			err = fmt.Errorf("no count of logs was returned")
+		}
+		return nil, model.InternalError(fmt.Errorf("could not query the recent throughput of logs: %w", err))
+	}
+
+	seconds := float64(costLookbackMinutes * 60)
+	totalRate := total / seconds
+	matchingRates := map[int]float64{}
+	for i, count := range matching {
+		matchingRates[i] = count / seconds
+	}
+
+	return projectPipelinesCost(resolved, totalRate, matchingRates, constants.LogPipelinesCPUBudgetCores), nil
+}
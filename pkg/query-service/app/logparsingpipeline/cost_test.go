@@ -0,0 +1,97 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestRegexComplexity(t *testing.T) {
+	simple, err := regexComplexity(`^(?P<level>\w+) (?P<msg>.*)$`)
+	require.NoError(t, err)
+
+	nested, err := regexComplexity(`^((?P<word>\w+\s?)+)*$`)
+	require.NoError(t, err)
+	require.Greater(t, nested, 2*simple)
+
+	_, err = regexComplexity(`(unclosed`)
+	require.Error(t, err)
+}
+
+func TestProjectPipelinesCost(t *testing.T) {
+	pipelines := []Pipeline{
+		{
+			Name: "parse", Alias: "parse", Enabled: true,
+			Filter: serviceFilter("=", "api"),
+			Config: []PipelineOperator{
+				{ID: "json", Type: "json_parser", Enabled: true},
+				{ID: "add", Type: "add", Enabled: true},
+				{ID: "disabled", Type: "json_parser", Enabled: false},
+			},
+		},
+		{
+			Name: "expensive", Alias: "expensive", Enabled: true,
+			Filter: serviceFilter("=", "db"),
+			Config: []PipelineOperator{
+				{ID: "regex", Type: "regex_parser", Enabled: true, Regex: `^((?P<word>\w+\s?)+)*$`},
+			},
+		},
+		{
+			Name: "off", Alias: "off", Enabled: false,
+			Config: []PipelineOperator{{ID: "json", Type: "json_parser", Enabled: true}},
+		},
+	}
+
+	estimate := projectPipelinesCost(pipelines, 10000, map[int]float64{0: 1000}, 0.05)
+	require.Len(t, estimate.Pipelines, 2)
+
+	// the filter applies to all logs and the operators only to matching ones
+	parse := estimate.Pipelines[len(estimate.Pipelines)-1]
+	require.Equal(t, "parse", parse.Name)
+	require.Equal(t, jsonParserCostMicros+fieldOperatorCostMicros, parse.CostMicros)
+	require.InDelta(t, (10000*filterCostMicros+1000*parse.CostMicros)/1e6, parse.Cores, 1e-9)
+
+	// the rate of the second pipeline is unknown so all logs are assumed to match
+	expensive := estimate.Pipelines[0]
+	require.Equal(t, "expensive", expensive.Name)
+	require.Equal(t, float64(10000), expensive.RecordsPerSecond)
+
+	require.True(t, estimate.ExceedsBudget)
+	require.InDelta(t, parse.Cores+expensive.Cores, estimate.TotalCores, 1e-9)
+	require.Len(t, estimate.Warnings, 3)
+	require.Contains(t, estimate.Warnings[0], "could not find the logs matching the filter of pipeline expensive")
+	require.Contains(t, estimate.Warnings[1], "regex of operator regex")
+	require.Contains(t, estimate.Warnings[2], "more than the budget")
+}
+
+func TestPipelineThroughput(t *testing.T) {
+	require := require.New(t)
+
+	pipelines := []Pipeline{
+		{Name: "api", Filter: serviceFilter("=", "api")},
+		{Name: "invalid", Filter: serviceFilter("unknown", "api")},
+		{Name: "all"},
+	}
+	params := pipelineThroughputParams(pipelines, 1000, 2000)
+	require.Equal(v3.QueryTypeClickHouseSQL, params.CompositeQuery.QueryType)
+	require.Len(params.CompositeQuery.ClickHouseQueries, 1, "the logs are counted in one query")
+	query := params.CompositeQuery.ClickHouseQueries[pipelineThroughputQueryName].Query
+	require.Contains(query, "[count(), countIf(resources_string_value[indexOf(resources_string_key, 'service')] = 'api'), countIf(true)]")
+	require.Contains(query, "['TOTAL', 'P0', 'P2']", "pipelines with invalid filters are left out")
+	require.Contains(query, "timestamp >= 1000000000 AND timestamp <= 2000000000")
+
+	series := func(name string, value float64) *v3.Series {
+		return &v3.Series{Labels: map[string]string{pipelineThroughputLabel: name}, Points: []v3.Point{{Value: value}}}
+	}
+	total, matching, ok := throughputFromResults([]*v3.Result{{
+		QueryName: pipelineThroughputQueryName,
+		Series:    []*v3.Series{series("TOTAL", 100), series("P0", 40), series("P2", 100)},
+	}})
+	require.True(ok)
+	require.Equal(100.0, total)
+	require.Equal(map[int]float64{0: 40, 2: 100}, matching)
+
+	_, _, ok = throughputFromResults(nil)
+	require.False(ok, "missing counts must be told apart from no logs")
+}
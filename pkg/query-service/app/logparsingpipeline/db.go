@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,11 @@ import (
 // Repo handles DDL and DML ops on ingestion pipeline
 type Repo struct {
 	db *sqlx.DB
+
+	// the pipelines of a config version never change once it is created,
+	// they are kept in memory for the versions polled repeatedly
+	versionsMtx *sync.RWMutex
+	versions    map[int][]Pipeline
 }
 
 const logPipelines = "log_pipelines"
@@ -25,8 +31,41 @@ const logPipelines = "log_pipelines"
 // NewRepo initiates a new ingestion repo
 func NewRepo(db *sqlx.DB) Repo {
 	return Repo{
-		db: db,
+		db:          db,
+		versionsMtx: &sync.RWMutex{},
+		versions:    map[int][]Pipeline{},
+	}
+}
+
+func (r *Repo) cachedPipelinesByVersion(version int) ([]Pipeline, bool) {
+	if r.versionsMtx == nil {
+		return nil, false
+	}
+	r.versionsMtx.RLock()
+	defer r.versionsMtx.RUnlock()
+	cached, ok := r.versions[version]
+	if !ok {
+		return nil, false
+	}
+	return copyPipelines(cached), true
+}
+
+func copyPipelines(pipelines []Pipeline) []Pipeline {
+	copied := make([]Pipeline, len(pipelines))
+	for i, p := range pipelines {
+		copied[i] = p
+		copied[i].Config = append([]PipelineOperator{}, p.Config...)
+	}
+	return copied
+}
+
+func (r *Repo) cachePipelinesByVersion(version int, pipelines []Pipeline) {
+	if r.versionsMtx == nil {
+		return
 	}
+	r.versionsMtx.Lock()
+	defer r.versionsMtx.Unlock()
+	r.versions[version] = copyPipelines(pipelines)
 }
 
 func (r *Repo) InitDB(engine string) error {
@@ -41,6 +80,24 @@ func (r *Repo) InitDB(engine string) error {
 // insertPipeline stores a given postable pipeline to database
 func (r *Repo) insertPipeline(
 	ctx context.Context, postable *PostablePipeline,
+) (*Pipeline, *model.ApiError) {
+	jwt, ok := auth.ExtractJwtFromContext(ctx)
+	if !ok {
+		return nil, model.UnauthorizedError(fmt.Errorf("no jwt in context"))
+	}
+
+	claims, err := auth.ParseJWT(jwt)
+	if err != nil {
+		return nil, model.UnauthorizedError(err)
+	}
+
+	return r.insertPipelineAs(ctx, postable, claims["email"].(string))
+}
+
+// insertPipelineAs stores a given postable pipeline to database as created
+// by createdBy, for pipelines that are not stored on behalf of a user
+func (r *Repo) insertPipelineAs(
+	ctx context.Context, postable *PostablePipeline, createdBy string,
 ) (*Pipeline, *model.ApiError) {
 	if err := postable.IsValid(); err != nil {
 		return nil, model.BadRequest(errors.Wrap(err,
@@ -55,16 +112,6 @@ func (r *Repo) insertPipeline(
 		))
 	}
 
-	jwt, ok := auth.ExtractJwtFromContext(ctx)
-	if !ok {
-		return nil, model.UnauthorizedError(err)
-	}
-
-	claims, err := auth.ParseJWT(jwt)
-	if err != nil {
-		return nil, model.UnauthorizedError(err)
-	}
-
 	insertRow := &Pipeline{
 		Id:          uuid.New().String(),
 		OrderId:     postable.OrderId,
@@ -75,15 +122,33 @@ func (r *Repo) insertPipeline(
 		Filter:      postable.Filter,
 		Config:      postable.Config,
 		RawConfig:   string(rawConfig),
+
+		AgentSelector: postable.AgentSelector,
+		Tags:          postable.Tags,
+		ChangeNote:    postable.ChangeNote,
 		Creator: Creator{
-			CreatedBy: claims["email"].(string),
+			CreatedBy: createdBy,
 			CreatedAt: time.Now(),
 		},
 	}
+	insertRow.Updater = Updater{
+		UpdatedBy: insertRow.CreatedBy,
+		UpdatedAt: insertRow.CreatedAt,
+	}
+
+	// changed pipelines keep the creator of their first revision
+	creator, apiErr := r.pipelineCreator(ctx, postable.Alias)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if creator != nil {
+		insertRow.Creator = *creator
+	}
 
 	insertQuery := `INSERT INTO pipelines 
-	(id, order_id, enabled, created_by, created_at, name, alias, description, filter, config_json) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	(id, order_id, enabled, created_by, created_at, name, alias, description, filter, config_json, agent_selector,
+	tags, change_note, updated_by, updated_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
 	_, err = r.db.ExecContext(ctx,
 		insertQuery,
@@ -96,7 +161,12 @@ func (r *Repo) insertPipeline(
 		insertRow.Alias,
 		insertRow.Description,
 		insertRow.Filter,
-		insertRow.RawConfig)
+		insertRow.RawConfig,
+		insertRow.AgentSelector,
+		insertRow.Tags,
+		insertRow.ChangeNote,
+		insertRow.UpdatedBy,
+		insertRow.UpdatedAt)
 
 	if err != nil {
 		zap.S().Errorf("error in inserting pipeline data: ", zap.Error(err))
@@ -110,6 +180,10 @@ func (r *Repo) insertPipeline(
 func (r *Repo) getPipelinesByVersion(
 	ctx context.Context, version int,
 ) ([]Pipeline, []error) {
+	if pipelines, ok := r.cachedPipelinesByVersion(version); ok {
+		return pipelines, nil
+	}
+
 	var errors []error
 	pipelines := []Pipeline{}
 
@@ -119,9 +193,14 @@ func (r *Repo) getPipelinesByVersion(
 		r.alias,
 		r.description,
 		r.filter,
+		r.agent_selector,
 		r.order_id,
 		r.created_by,
 		r.created_at,
+		COALESCE(r.updated_by, '') as updated_by,
+		r.updated_at,
+		COALESCE(r.tags, '[]') as tags,
+		COALESCE(r.change_note, '') as change_note,
 		r.enabled
 		FROM pipelines r,
 			 agent_config_elements e,
@@ -147,6 +226,9 @@ func (r *Repo) getPipelinesByVersion(
 		}
 	}
 
+	if len(errors) == 0 {
+		r.cachePipelinesByVersion(version, pipelines)
+	}
 	return pipelines, errors
 }
 
@@ -162,9 +244,14 @@ func (r *Repo) GetPipeline(
 		alias,
 		description,
 		filter,
+		agent_selector,
 		order_id,
 		created_by,
 		created_at,
+		COALESCE(updated_by, '') as updated_by,
+		updated_at,
+		COALESCE(tags, '[]') as tags,
+		COALESCE(change_note, '') as change_note,
 		enabled
 		FROM pipelines 
 		WHERE id = $1`
@@ -0,0 +1,89 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	csvParserOperator      = "csv_parser"
+	keyValueParserOperator = "key_value_parser"
+
+	defaultCSVDelimiter      = ","
+	defaultKeyValueDelimiter = "="
+)
+
+func validateCSVParser(op PipelineOperator) error {
+	if op.ParseFrom == "" {
+		return fmt.Errorf("parse from of csv parser %s cannot be empty", op.ID)
+	}
+	if len(op.HeaderFields) == 0 {
+		return fmt.Errorf("header fields of csv parser %s cannot be empty", op.ID)
+	}
+	delimiter := op.Delimiter
+	if delimiter == "" {
+		delimiter = defaultCSVDelimiter
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return fmt.Errorf("delimiter of csv parser %s must be a single character", op.ID)
+	}
+
+	seen := map[string]bool{}
+	for _, field := range op.HeaderFields {
+		if strings.TrimSpace(field) == "" {
+			return fmt.Errorf("header fields of csv parser %s cannot be empty", op.ID)
+		}
+		if strings.Contains(field, delimiter) {
+			return fmt.Errorf("header field %s of csv parser %s contains the delimiter", field, op.ID)
+		}
+		if seen[field] {
+			return fmt.Errorf("header field %s of csv parser %s is repeated", field, op.ID)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+func validateKeyValueParser(op PipelineOperator) error {
+	if op.ParseFrom == "" {
+		return fmt.Errorf("parse from of key value parser %s cannot be empty", op.ID)
+	}
+	delimiter := op.Delimiter
+	if delimiter == "" {
+		delimiter = defaultKeyValueDelimiter
+	}
+	// pairs are delimited by whitespace by default
+	pairDelimiter := op.PairDelimiter
+	if pairDelimiter == "" {
+		pairDelimiter = " "
+	}
+	if strings.Contains(pairDelimiter, delimiter) || strings.Contains(delimiter, pairDelimiter) {
+		return fmt.Errorf(
+			"delimiter and pair delimiter of key value parser %s must not overlap", op.ID,
+		)
+	}
+	return nil
+}
+
+// prepareDelimitedParser sets the stanza config of csv and key value parsers
+// and makes them skip logs where parseFrom isn't a string
+func prepareDelimitedParser(operator *PipelineOperator) error {
+	parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+	if err != nil {
+		return fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+	operator.If = fmt.Sprintf(
+		`%s && type(%s) == "string"`, parseFromNotNilCheck, operator.ParseFrom,
+	)
+
+	operator.ParserDelimiter = operator.Delimiter
+	if operator.Type == csvParserOperator {
+		delimiter := operator.Delimiter
+		if delimiter == "" {
+			delimiter = defaultCSVDelimiter
+		}
+		operator.Header = strings.Join(operator.HeaderFields, delimiter)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestDelimitedParsers(t *testing.T) {
+	require := require.New(t)
+
+	csvParser := PipelineOperator{
+		OrderId: 1, ID: "csv", Type: csvParserOperator, Enabled: true, Name: "parse haproxy",
+		ParseFrom: "body", ParseTo: "attributes", Delimiter: "|",
+		HeaderFields: []string{"client", "backend", "status"}, Output: "kv",
+	}
+	kvParser := PipelineOperator{
+		OrderId: 2, ID: "kv", Type: keyValueParserOperator, Enabled: true, Name: "parse audit",
+		ParseFrom: "attributes.audit", ParseTo: "attributes", PairDelimiter: ";",
+	}
+	require.Nil(isValidOperator(csvParser))
+	require.Nil(isValidOperator(kvParser))
+
+	invalid := []PipelineOperator{
+		{ID: "csv", Type: csvParserOperator, ParseFrom: "body"},
+		{ID: "csv", Type: csvParserOperator, ParseFrom: "body", HeaderFields: []string{"a", "a"}},
+		{ID: "csv", Type: csvParserOperator, ParseFrom: "body", HeaderFields: []string{"a"}, Delimiter: "||"},
+		{ID: "csv", Type: csvParserOperator, ParseFrom: "body", HeaderFields: []string{"a,b"}},
+		{ID: "kv", Type: keyValueParserOperator},
+		{ID: "kv", Type: keyValueParserOperator, ParseFrom: "body", Delimiter: " "},
+		{ID: "kv", Type: keyValueParserOperator, ParseFrom: "body", Delimiter: ":", PairDelimiter: ":"},
+	}
+	for _, op := range invalid {
+		require.NotNil(isValidOperator(op), op)
+	}
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "haproxy",
+					},
+				},
+			},
+			Config: []PipelineOperator{csvParser, kvParser},
+		},
+	}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, []model.SignozLog{
+			makeTestSignozLog("10.0.0.1|web|200", map[string]interface{}{
+				"service": "haproxy", "audit": "user=alice;action=login",
+			}),
+			makeTestSignozLog("10.0.0.2|api|503", map[string]interface{}{"service": "haproxy"}),
+		},
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(2, len(result))
+
+	require.Equal(map[string]string{
+		"service": "haproxy", "audit": "user=alice;action=login",
+		"client": "10.0.0.1", "backend": "web", "status": "200",
+		"user": "alice", "action": "login",
+	}, result[0].Attributes_string)
+	require.Equal(map[string]string{
+		"service": "haproxy", "client": "10.0.0.2", "backend": "api", "status": "503",
+	}, result[1].Attributes_string, "logs without the parsed field should be skipped")
+}
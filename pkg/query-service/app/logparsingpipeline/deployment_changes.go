@@ -0,0 +1,241 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Agents get the whole collector config file over opamp on each deployment.
+// A config can't be sent to them in parts: the opamp remote config carries
+// whole files, and the collector replaces its config with the file it gets.
+// So deployments don't send a diff of the config. Each deployment records a
+// hash of every processor generated from the pipelines instead. The hashes
+// tell which processors a version changed, apart from the ones that were
+// only generated again. Versions which change no processor, e.g. ones only
+// renaming pipelines, are not pushed to the agents at all: the agents
+// already run their config.
+
+// ProcessorChanges lists the collector processors of a pipelines version by
+// how they changed since the version deployed before it
+type ProcessorChanges struct {
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+
+	// hashes of the processors of the version by processor name, processors
+	// with the same hash in two versions have the same config
+	Hashes map[string]string `json:"hashes"`
+
+	// names of the processors in the order logs go through them
+	Order []string `json:"order"`
+}
+
+// leaveConfigUnchanged tells whether the version generates the same
+// collector config as the previous one, whose processors were in
+// previousOrder
+func (c *ProcessorChanges) leaveConfigUnchanged(previousOrder []string) bool {
+	if len(c.Added)+len(c.Modified)+len(c.Removed) > 0 {
+		return false
+	}
+	return slices.Equal(c.Order, previousOrder)
+}
+
+// processorHashes hashes each collector processor generated for pipelines
+// and returns the order of the processors
+func processorHashes(pipelines []Pipeline) (map[string]string, []string, error) {
+	processors, order, err := PreparePipelineProcessor(pipelines)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to prepare pipeline processors")
+	}
+
+	hashes := map[string]string{}
+	for name, processor := range processors {
+		serialized, err := yaml.Marshal(processor)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to serialize processor %s", name)
+		}
+		sum := sha256.Sum256(serialized)
+		hashes[name] = hex.EncodeToString(sum[:])
+	}
+	return hashes, order, nil
+}
+
+func diffProcessorHashes(previous map[string]string, current map[string]string) *ProcessorChanges {
+	changes := &ProcessorChanges{
+		Added:     []string{},
+		Modified:  []string{},
+		Removed:   []string{},
+		Unchanged: []string{},
+		Hashes:    current,
+	}
+	for name, hash := range current {
+		previousHash, ok := previous[name]
+		if !ok {
+			changes.Added = append(changes.Added, name)
+		} else if previousHash != hash {
+			changes.Modified = append(changes.Modified, name)
+		} else {
+			changes.Unchanged = append(changes.Unchanged, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+
+	for _, names := range [][]string{changes.Added, changes.Modified, changes.Removed, changes.Unchanged} {
+		sort.Strings(names)
+	}
+	return changes
+}
+
+func (r *Repo) insertProcessorChanges(
+	ctx context.Context, version int, changes *ProcessorChanges,
+) *model.ApiError {
+	changesJson, err := json.Marshal(changes)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to serialize processor changes"))
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO pipeline_deployments (version, changes_json)
+		VALUES ($1, $2)
+		ON CONFLICT(version) DO UPDATE SET changes_json = excluded.changes_json
+	`, version, string(changesJson))
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to record processor changes"))
+	}
+	return nil
+}
+
+// getProcessorChanges returns the processor changes recorded for a version,
+// nil for versions deployed before changes were recorded
+func (r *Repo) getProcessorChanges(
+	ctx context.Context, version int,
+) (*ProcessorChanges, *model.ApiError) {
+	var changesJson string
+	err := r.db.GetContext(ctx, &changesJson, `
+		SELECT changes_json FROM pipeline_deployments WHERE version = $1
+	`, version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get processor changes"))
+	}
+
+	var changes ProcessorChanges
+	if err := json.Unmarshal([]byte(changesJson), &changes); err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to parse processor changes"))
+	}
+	return &changes, nil
+}
+
+// processorChanges compares the processors generated for pipelines with the
+// ones of the version that is latest before them. It returns nil changes if
+// the processors can't be generated, and whether the collector config stays
+// the same.
+func (ic *LogParsingPipelineController) processorChanges(
+	ctx context.Context, previousVersion *agentConf.ConfigVersion, previous []Pipeline,
+	pipelines []Pipeline,
+) (changes *ProcessorChanges, unchanged bool) {
+	current, order, err := ic.resolvedProcessorHashes(ctx, pipelines)
+	if err != nil {
+		zap.L().Error("could not hash log pipeline processors", zap.Error(err))
+		return nil, false
+	}
+
+	previousHashes := map[string]string{}
+	var previousOrder []string
+	known := false
+	if previousVersion != nil {
+		recorded, apiErr := ic.getProcessorChanges(ctx, previousVersion.Version)
+		if apiErr != nil {
+			zap.L().Warn("could not get processor changes of previous pipelines version", zap.Error(apiErr.ToError()))
+		}
+		if recorded != nil {
+			previousHashes, previousOrder = recorded.Hashes, recorded.Order
+			// orders weren't recorded for older versions
+			known = recorded.Order != nil
+		} else if hashes, order, err := ic.resolvedProcessorHashes(ctx, previous); err == nil {
+			// versions deployed before hashes were recorded are hashed with
+			// the current variables and schemas
+			previousHashes, previousOrder = hashes, order
+			known = true
+		}
+	}
+
+	changes = diffProcessorHashes(previousHashes, current)
+	changes.Order = order
+	return changes, known && changes.leaveConfigUnchanged(previousOrder)
+}
+
+// recordProcessorChanges records the processor changes of a newly deployed
+// version. Failures are only logged as the version is already deployed.
+func (ic *LogParsingPipelineController) recordProcessorChanges(
+	ctx context.Context, version int, changes *ProcessorChanges,
+) {
+	if changes == nil {
+		return
+	}
+	if apiErr := ic.insertProcessorChanges(ctx, version, changes); apiErr != nil {
+		zap.L().Error("could not record log pipeline processor changes", zap.Int("version", version), zap.Error(apiErr.ToError()))
+	}
+	zap.L().Info(
+		"deployed log pipelines",
+		zap.Int("version", version),
+		zap.Strings("added", changes.Added),
+		zap.Strings("modified", changes.Modified),
+		zap.Strings("removed", changes.Removed),
+		zap.Int("unchanged", len(changes.Unchanged)),
+	)
+}
+
+// startPipelinesVersion starts a new config version made of pipelines. The
+// version is only pushed to the agents if it changes their collector config
+// and the previous version already reached them.
+func (ic *LogParsingPipelineController) startPipelinesVersion(
+	ctx context.Context, userId string, previousVersion *agentConf.ConfigVersion, previous []Pipeline,
+	pipelines []Pipeline, changeNote string,
+) (*agentConf.ConfigVersion, *ProcessorChanges, *model.ApiError) {
+	elements := make([]string, len(pipelines))
+	for i, p := range pipelines {
+		elements[i] = p.Id
+	}
+
+	changes, unchanged := ic.processorChanges(ctx, previousVersion, previous, pipelines)
+	startVersion := agentConf.StartNewVersionWithNote
+	if unchanged && previousVersion.DeployStatus == agentConf.Deployed {
+		startVersion = agentConf.StartUnchangedVersionWithNote
+	}
+	cfg, apiErr := startVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements, changeNote)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	ic.recordProcessorChanges(ctx, cfg.Version, changes)
+	return cfg, changes, nil
+}
+
+func (ic *LogParsingPipelineController) resolvedProcessorHashes(
+	ctx context.Context, pipelines []Pipeline,
+) (map[string]string, []string, error) {
+	resolved, apiErr := ic.resolvePipelines(ctx, pipelines)
+	if apiErr != nil {
+		return nil, nil, fmt.Errorf("could not resolve pipelines: %w", apiErr.ToError())
+	}
+	return processorHashes(resolved)
+}
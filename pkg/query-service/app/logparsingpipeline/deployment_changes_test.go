@@ -0,0 +1,62 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestProcessorChanges(t *testing.T) {
+	require := require.New(t)
+
+	filter := func(service string) *v3.FilterSet {
+		return &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "service",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    service,
+				},
+			},
+		}
+	}
+	parse := PipelineOperator{
+		OrderId: 1, ID: "parse", Type: "json_parser", Enabled: true, Name: "parse body",
+		ParseFrom: "body", ParseTo: "attributes",
+	}
+	pipeline := func(alias string) Pipeline {
+		return Pipeline{
+			OrderId: 1, Name: alias, Alias: alias, Enabled: true,
+			Filter: filter(alias), Config: []PipelineOperator{parse},
+		}
+	}
+
+	previous, _, err := processorHashes([]Pipeline{pipeline("checkout"), pipeline("cart"), pipeline("search")})
+	require.Nil(err)
+	again, _, err := processorHashes([]Pipeline{pipeline("checkout"), pipeline("cart"), pipeline("search")})
+	require.Nil(err)
+	require.Equal(previous, again, "hashes should not change when pipelines don't")
+
+	cart := pipeline("cart")
+	cart.Filter = filter("basket")
+	current, _, err := processorHashes([]Pipeline{pipeline("checkout"), cart, pipeline("payments")})
+	require.Nil(err)
+
+	changes := diffProcessorHashes(previous, current)
+	require.Equal([]string{CollectorConfProcessorName(pipeline("payments"))}, changes.Added)
+	require.Equal([]string{CollectorConfProcessorName(cart)}, changes.Modified)
+	require.Equal([]string{CollectorConfProcessorName(pipeline("search"))}, changes.Removed)
+	require.Equal([]string{CollectorConfProcessorName(pipeline("checkout"))}, changes.Unchanged)
+	require.Equal(current, changes.Hashes)
+
+	// the first deployment adds every processor
+	changes = diffProcessorHashes(map[string]string{}, current)
+	require.Len(changes.Added, 3)
+	require.Empty(changes.Removed)
+}
@@ -0,0 +1,222 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/multierr"
+)
+
+// PipelinesDiff is what changed in the pipelines from one config version to
+// another. Pipelines are matched across versions by alias, as editing a
+// pipeline stores it again with a new id, and operators by id.
+type PipelinesDiff struct {
+	FromVersion int            `json:"fromVersion"`
+	ToVersion   int            `json:"toVersion"`
+	Added       []Pipeline     `json:"added"`
+	Removed     []Pipeline     `json:"removed"`
+	Modified    []PipelineDiff `json:"modified"`
+}
+
+type PipelineDiff struct {
+	Alias   string        `json:"alias"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+
+	AddedOperators    []PipelineOperator `json:"addedOperators"`
+	RemovedOperators  []PipelineOperator `json:"removedOperators"`
+	ModifiedOperators []OperatorDiff     `json:"modifiedOperators"`
+}
+
+type OperatorDiff struct {
+	Id      string        `json:"id"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is the change of a field, keyed by its json name
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+func (d *PipelineDiff) isEmpty() bool {
+	return len(d.Changes) == 0 && len(d.AddedOperators) == 0 &&
+		len(d.RemovedOperators) == 0 && len(d.ModifiedOperators) == 0
+}
+
+// DiffPipelines compares the pipelines of two config versions
+func (ic *LogParsingPipelineController) DiffPipelines(
+	ctx context.Context, fromVersion int, toVersion int,
+) (*PipelinesDiff, *model.ApiError) {
+	versions := map[int][]Pipeline{}
+	for _, version := range []int{fromVersion, toVersion} {
+		if _, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, version); apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf("failed to get pipelines version %d", version))
+		}
+		pipelines, errs := ic.getPipelinesByVersion(ctx, version)
+		if len(errs) > 0 {
+			return nil, model.InternalError(multierr.Combine(errs...))
+		}
+		versions[version] = pipelines
+	}
+
+	diff, err := diffPipelines(versions[fromVersion], versions[toVersion])
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("couldn't compare pipelines: %w", err))
+	}
+	diff.FromVersion = fromVersion
+	diff.ToVersion = toVersion
+	return diff, nil
+}
+
+func diffPipelines(from []Pipeline, to []Pipeline) (*PipelinesDiff, error) {
+	diff := &PipelinesDiff{
+		Added:    []Pipeline{},
+		Removed:  []Pipeline{},
+		Modified: []PipelineDiff{},
+	}
+
+	fromByAlias := map[string]Pipeline{}
+	for _, p := range from {
+		fromByAlias[p.Alias] = p
+	}
+	toAliases := map[string]bool{}
+
+	to = sortedByOrder(to)
+	for _, p := range to {
+		toAliases[p.Alias] = true
+		previous, ok := fromByAlias[p.Alias]
+		if !ok {
+			diff.Added = append(diff.Added, p)
+			continue
+		}
+
+		pd, err := diffPipeline(previous, p)
+		if err != nil {
+			return nil, err
+		}
+		if !pd.isEmpty() {
+			diff.Modified = append(diff.Modified, *pd)
+		}
+	}
+
+	for _, p := range sortedByOrder(from) {
+		if !toAliases[p.Alias] {
+			diff.Removed = append(diff.Removed, p)
+		}
+	}
+	return diff, nil
+}
+
+func sortedByOrder(pipelines []Pipeline) []Pipeline {
+	sorted := append([]Pipeline{}, pipelines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OrderId < sorted[j].OrderId
+	})
+	return sorted
+}
+
+func diffPipeline(from Pipeline, to Pipeline) (*PipelineDiff, error) {
+	pd := &PipelineDiff{
+		Alias:             to.Alias,
+		Name:              to.Name,
+		AddedOperators:    []PipelineOperator{},
+		RemovedOperators:  []PipelineOperator{},
+		ModifiedOperators: []OperatorDiff{},
+	}
+
+	// the id and creator change with every edit, and operators are
+	// compared separately
+	changes, err := fieldChanges(from, to, "id", "config", "createdBy", "createdAt")
+	if err != nil {
+		return nil, err
+	}
+	pd.Changes = changes
+
+	fromOps := map[string]PipelineOperator{}
+	for _, op := range from.Config {
+		fromOps[op.ID] = op
+	}
+	toOps := map[string]bool{}
+	for _, op := range to.Config {
+		toOps[op.ID] = true
+		previous, ok := fromOps[op.ID]
+		if !ok {
+			pd.AddedOperators = append(pd.AddedOperators, op)
+			continue
+		}
+
+		changes, err := fieldChanges(previous, op)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			pd.ModifiedOperators = append(pd.ModifiedOperators, OperatorDiff{
+				Id: op.ID, Name: op.Name, Changes: changes,
+			})
+		}
+	}
+	for _, op := range from.Config {
+		if !toOps[op.ID] {
+			pd.RemovedOperators = append(pd.RemovedOperators, op)
+		}
+	}
+	return pd, nil
+}
+
+// fieldChanges compares the json fields of two values, except the ignored
+// ones, sorted by field name
+func fieldChanges(from interface{}, to interface{}, ignored ...string) ([]FieldChange, error) {
+	fromFields, err := jsonFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := jsonFields(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range ignored {
+		delete(fromFields, field)
+		delete(toFields, field)
+	}
+
+	names := []string{}
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		if !reflect.DeepEqual(fromFields[name], toFields[name]) {
+			changes = append(changes, FieldChange{
+				Field: name, From: fromFields[name], To: toFields[name],
+			})
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	serialized, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(serialized, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,65 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	require := require.New(t)
+
+	parse := PipelineOperator{
+		ID: "parse", Type: "json_parser", Enabled: true, Name: "parse body",
+		ParseFrom: "body", ParseTo: "attributes", Output: "severity",
+	}
+	severity := PipelineOperator{
+		ID: "severity", Type: "severity_parser", Enabled: true, Name: "severity",
+		ParseFrom: "attributes.level",
+	}
+	from := []Pipeline{
+		{Id: "1", OrderId: 1, Name: "nginx", Alias: "nginx", Enabled: true, Config: []PipelineOperator{parse, severity}},
+		{Id: "2", OrderId: 2, Name: "redis", Alias: "redis", Enabled: true, Config: []PipelineOperator{parse}},
+		{Id: "3", OrderId: 3, Name: "old", Alias: "old", Enabled: true},
+	}
+
+	editedParse := parse
+	editedParse.ParseTo = "attributes.parsed"
+	editedParse.Output = ""
+	to := []Pipeline{
+		{Id: "4", OrderId: 1, Name: "redis", Alias: "redis", Enabled: true, Config: []PipelineOperator{parse}},
+		{Id: "5", OrderId: 2, Name: "nginx logs", Alias: "nginx", Enabled: false, Config: []PipelineOperator{editedParse}},
+		{Id: "6", OrderId: 3, Name: "new", Alias: "new", Enabled: true},
+	}
+	to[1].CreatedBy = "someone"
+
+	diff, err := diffPipelines(from, to)
+	require.Nil(err)
+
+	require.Len(diff.Added, 1)
+	require.Equal("new", diff.Added[0].Alias)
+	require.Len(diff.Removed, 1)
+	require.Equal("old", diff.Removed[0].Alias)
+
+	require.Len(diff.Modified, 2)
+	redis := diff.Modified[0]
+	require.Equal("redis", redis.Alias)
+	require.Equal([]FieldChange{{Field: "orderId", From: float64(2), To: float64(1)}}, redis.Changes)
+	require.Empty(redis.ModifiedOperators, "reordering alone doesn't change operators")
+
+	nginx := diff.Modified[1]
+	require.Equal("nginx", nginx.Alias)
+	require.Equal([]FieldChange{
+		{Field: "enabled", From: true, To: false},
+		{Field: "name", From: "nginx", To: "nginx logs"},
+		{Field: "orderId", From: float64(1), To: float64(2)},
+	}, nginx.Changes, "ids and creators of stored pipelines change with every edit")
+	require.Empty(nginx.AddedOperators)
+	require.Equal([]PipelineOperator{severity}, nginx.RemovedOperators)
+	require.Equal([]OperatorDiff{{
+		Id: "parse", Name: "parse body", Changes: []FieldChange{
+			{Field: "output", From: "severity", To: nil},
+			{Field: "parse_to", From: "attributes", To: "attributes.parsed"},
+		},
+	}}, nginx.ModifiedOperators)
+}
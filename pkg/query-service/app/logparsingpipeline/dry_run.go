@@ -0,0 +1,113 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+// PipelineRoute is the router expression selecting the logs a pipeline processes
+type PipelineRoute struct {
+	Name      string `json:"name"`
+	Alias     string `json:"alias"`
+	Processor string `json:"processor"`
+	Expr      string `json:"expr"`
+}
+
+// PipelinesDryRunResponse is the collector config that would be deployed
+// for a set of pipelines
+type PipelinesDryRunResponse struct {
+	Pipelines []Pipeline `json:"pipelines"`
+	// ProcessorNames are the processors in the order they are added to the
+	// logs pipeline of the collector
+	ProcessorNames []string           `json:"processorNames"`
+	ProcessorsYaml string             `json:"processorsYaml"`
+	Routes         []PipelineRoute    `json:"routes"`
+	Conflicts      []PipelineConflict `json:"conflicts,omitempty"`
+}
+
+// toPipeline is the pipeline a postable pipeline would be stored as
+func toPipeline(postable *PostablePipeline) Pipeline {
+	return Pipeline{
+		OrderId:     postable.OrderId,
+		Enabled:     postable.Enabled,
+		Name:        postable.Name,
+		Alias:       postable.Alias,
+		Description: &postable.Description,
+		Filter:      postable.Filter,
+		Config:      postable.Config,
+	}
+}
+
+func pipelineRoutes(pipelines []Pipeline, processors map[string]interface{}) []PipelineRoute {
+	routes := []PipelineRoute{}
+	for _, p := range pipelines {
+		name := CollectorConfProcessorName(p)
+		processor, ok := processors[name].(Processor)
+		if !ok || len(processor.Operators) == 0 || processor.Operators[0].Routes == nil {
+			continue
+		}
+		for _, route := range *processor.Operators[0].Routes {
+			routes = append(routes, PipelineRoute{
+				Name: p.Name, Alias: p.Alias, Processor: name, Expr: route.Expr,
+			})
+		}
+	}
+	return routes
+}
+
+// DryRunPipelines generates the collector processors config for pipelines
+// the way applying them would, without storing or deploying anything
+func (ic *LogParsingPipelineController) DryRunPipelines(
+	ctx context.Context, postable []PostablePipeline,
+) (*PipelinesDryRunResponse, *model.ApiError) {
+	pipelines := []Pipeline{}
+	for i := range postable {
+		r := &postable[i]
+		if err := r.IsValid(); err != nil {
+			return nil, model.BadRequest(errors.Wrap(err, "pipeline is not valid"))
+		}
+		if r.Id == "" {
+			pipelines = append(pipelines, toPipeline(r))
+			continue
+		}
+
+		selected, apiErr := ic.GetPipeline(ctx, r.Id)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "failed to find edited pipeline")
+		}
+		pipelines = append(pipelines, *selected)
+	}
+
+	resolved, apiErr := ic.resolvePipelines(ctx, pipelines)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	processors, names, err := PreparePipelineProcessor(resolved)
+	if err != nil {
+		return nil, model.BadRequest(errors.Wrap(
+			err, "could not prepare otel collector processors for log pipelines",
+		))
+	}
+	routes := pipelineRoutes(resolved, processors)
+
+	if apiErr := escapeProcessorsConf(processors, names); apiErr != nil {
+		return nil, apiErr
+	}
+	processorsYaml, err := yaml.Marshal(map[string]interface{}{"processors": processors})
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not marshal processors config: %w", err))
+	}
+
+	return &PipelinesDryRunResponse{
+		Pipelines:      pipelines,
+		ProcessorNames: names,
+		ProcessorsYaml: string(processorsYaml),
+		Routes:         routes,
+		Conflicts:      detectPipelineConflicts(resolved),
+	}, nil
+}
@@ -0,0 +1,163 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonFlattenOperator = "json_flatten"
+
+	defaultJSONFlattenMaxDepth  = 5
+	maxJSONFlattenMaxDepth      = 10
+	defaultJSONFlattenDelimiter = "."
+	defaultJSONFlattenParseTo   = "attributes"
+
+	// the flattened map is built in this field before being lifted into
+	// the target, as stanza can't set the attributes root
+	jsonFlattenTempField = "signoz_json_flattened"
+
+	// separators used for building the flattened keys inside the generated
+	// expression, chosen so that they don't clash with real keys
+	jsonFlattenPathSeparator = `"\x1f"`
+	jsonFlattenListSeparator = `"\x1e"`
+)
+
+func validateJSONFlatten(op PipelineOperator) error {
+	if op.ParseFrom == "" {
+		return fmt.Errorf("parse from of json flatten operator %s cannot be empty", op.ID)
+	}
+	if op.MaxDepth < 0 || op.MaxDepth > maxJSONFlattenMaxDepth {
+		return fmt.Errorf(
+			"max depth of json flatten operator %s must be between 1 and %d", op.ID, maxJSONFlattenMaxDepth,
+		)
+	}
+	for _, key := range append(append([]string{}, op.IncludeKeys...), op.ExcludeKeys...) {
+		if key == "" {
+			return fmt.Errorf("include and exclude keys of json flatten operator %s cannot be empty", op.ID)
+		}
+	}
+	return nil
+}
+
+// prepareJSONFlatten turns a json_flatten operator into an add operator
+// setting a map of the leaves of the map at parseFrom, followed by a flatten
+// operator lifting its keys into the map at parseTo. Stanza's flatten only
+// lifts a single level and keeps the keys as they are, so the nested keys
+// are walked with an expression unrolled up to the max depth. Maps nested
+// deeper than that are set as they are.
+//
+// The operator is updated to the add operator and the flatten operator to
+// be chained after it is returned.
+func prepareJSONFlatten(operator *PipelineOperator) (*PipelineOperator, error) {
+	parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+
+	maxDepth := operator.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = defaultJSONFlattenMaxDepth
+	}
+	delimiter := operator.Delimiter
+	if delimiter == "" {
+		delimiter = defaultJSONFlattenDelimiter
+	}
+
+	parseTo := operator.ParseTo
+	if parseTo == "" {
+		parseTo = defaultJSONFlattenParseTo
+	}
+
+	operator.Type = "add"
+	operator.Field = fmt.Sprintf("%s.%s", parseTo, jsonFlattenTempField)
+	operator.Value = fmt.Sprintf("EXPR(%s)", jsonFlattenExpr(
+		operator.ParseFrom, maxDepth, delimiter, operator.KeyPrefix,
+		operator.IncludeKeys, operator.ExcludeKeys,
+	))
+	operator.If = fmt.Sprintf(
+		`%s && type(%s) == "map" && len(%s) > 0`,
+		parseFromNotNilCheck, operator.ParseFrom, operator.ParseFrom,
+	)
+	operator.ParseFrom = ""
+	operator.ParseTo = ""
+
+	lift := &PipelineOperator{
+		Type:    "flatten",
+		ID:      fmt.Sprintf("%s_lift", operator.ID),
+		OrderId: operator.OrderId,
+		Enabled: operator.Enabled,
+		Name:    operator.Name,
+		Field:   operator.Field,
+		If:      operator.If,
+	}
+	operator.Output = lift.ID
+	return lift, nil
+}
+
+// jsonFlattenExpr generates an expression evaluating to the flattened map.
+// Nested keys are tracked as paths of key segments. Every level expands
+// the paths pointing to non empty maps into the paths of their children.
+func jsonFlattenExpr(
+	from string, maxDepth int, delimiter string, keyPrefix string,
+	includeKeys []string, excludeKeys []string,
+) string {
+	paths := fmt.Sprintf("keys(%s)", from)
+	for depth := 1; depth < maxDepth; depth++ {
+		paths = fmt.Sprintf(
+			`split(join(map(%s, let p = #; let v = %s; type(v) == "map" && len(v) > 0 ? join(map(keys(v), p + %s + #), %s) : p), %s), %s)`,
+			paths, jsonFlattenLookup(from, "p", depth), jsonFlattenPathSeparator,
+			jsonFlattenListSeparator, jsonFlattenListSeparator, jsonFlattenListSeparator,
+		)
+	}
+
+	key := fmt.Sprintf("join(split(#, %s), %s)", jsonFlattenPathSeparator, strconv.Quote(delimiter))
+	filters := []string{}
+	keyMatches := func(keys []string) string {
+		return fmt.Sprintf(
+			"any(%s, k == # || hasPrefix(k, # + %s))", exprStringList(keys), strconv.Quote(delimiter),
+		)
+	}
+	if len(includeKeys) > 0 {
+		filters = append(filters, keyMatches(includeKeys))
+	}
+	if len(excludeKeys) > 0 {
+		filters = append(filters, fmt.Sprintf("!%s", keyMatches(excludeKeys)))
+	}
+	if len(filters) > 0 {
+		paths = fmt.Sprintf(
+			"filter(%s, let k = %s; %s)", paths, key, strings.Join(filters, " && "),
+		)
+	}
+
+	// maps built by fromPairs have keys of type any which stanza doesn't
+	// treat as maps, so the map is built from json instead
+	entry := fmt.Sprintf(
+		`toJSON(%s + %s) + ":" + toJSON(%s)`,
+		strconv.Quote(keyPrefix), key, jsonFlattenLookup(from, "#", maxDepth),
+	)
+	return fmt.Sprintf(`fromJSON("{" + join(map(%s, %s), ",") + "}")`, paths, entry)
+}
+
+// jsonFlattenLookup generates an expression for the value at a path with
+// up to maxDepth segments
+func jsonFlattenLookup(from string, path string, maxDepth int) string {
+	lookup := "nil"
+	for depth := maxDepth; depth >= 1; depth-- {
+		value := from
+		for i := 0; i < depth; i++ {
+			value = fmt.Sprintf("%s[s[%d]]", value, i)
+		}
+		lookup = fmt.Sprintf("len(s) == %d ? %s : %s", depth, value, lookup)
+	}
+	return fmt.Sprintf("(let s = split(%s, %s); %s)", path, jsonFlattenPathSeparator, lookup)
+}
+
+func exprStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return fmt.Sprintf("[%s]", strings.Join(quoted, ", "))
+}
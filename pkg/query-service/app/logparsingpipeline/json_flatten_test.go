@@ -0,0 +1,97 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestJSONFlatten(t *testing.T) {
+	require := require.New(t)
+
+	flatten := PipelineOperator{
+		OrderId:     2,
+		ID:          "flatten",
+		Type:        jsonFlattenOperator,
+		Enabled:     true,
+		Name:        "flatten payload",
+		ParseFrom:   "attributes.payload",
+		MaxDepth:    2,
+		Delimiter:   "_",
+		KeyPrefix:   "app.",
+		ExcludeKeys: []string{"user_address", "request_headers"},
+	}
+	require.Nil(isValidOperator(flatten))
+
+	invalid := flatten
+	invalid.MaxDepth = maxJSONFlattenMaxDepth + 1
+	require.NotNil(isValidOperator(invalid), "max depth should be bounded")
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "checkout",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					OrderId:   1,
+					ID:        "parse",
+					Type:      "json_parser",
+					Enabled:   true,
+					Name:      "parse body",
+					ParseFrom: "body",
+					ParseTo:   "attributes.payload",
+				},
+				flatten,
+				{
+					OrderId: 3,
+					ID:      "cleanup",
+					Type:    "remove",
+					Enabled: true,
+					Name:    "remove payload",
+					Field:   "attributes.payload",
+				},
+			},
+		},
+	}
+
+	testLogs := []model.SignozLog{
+		makeTestSignozLog(
+			`{"level": "info", "status": 200, "user": {"id": "u1", "address": {"city": "Pune"}}, "request": {"path": "/cart", "headers": {"x": "y"}}}`,
+			map[string]interface{}{"service": "checkout"},
+		),
+	}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, testLogs,
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(1, len(result))
+
+	require.Equal(map[string]string{
+		"service":          "checkout",
+		"app.level":        "info",
+		"app.user_id":      "u1",
+		"app.request_path": "/cart",
+	}, result[0].Attributes_string)
+	require.Equal(float64(200), result[0].Attributes_float64["app.status"])
+}
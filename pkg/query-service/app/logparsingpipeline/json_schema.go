@@ -0,0 +1,387 @@
+package logparsingpipeline
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	jsonSchemaValidatorOperator = "json_schema_validator"
+
+	defaultSchemaValidationErrorField = "attributes.schema_validation_error"
+)
+
+// JSONSchema is the subset of JSON schema which can be enforced by
+// json_schema_validator operators. Schemas using other keywords are
+// rejected when they are registered.
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type       string                 `json:"type,omitempty"`
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Enum       []interface{}          `json:"enum,omitempty"`
+	Minimum    *float64               `json:"minimum,omitempty"`
+	Maximum    *float64               `json:"maximum,omitempty"`
+	MinLength  *int                   `json:"minLength,omitempty"`
+	MaxLength  *int                   `json:"maxLength,omitempty"`
+	Pattern    string                 `json:"pattern,omitempty"`
+}
+
+var jsonSchemaTypes = []string{"string", "number", "integer", "boolean", "object", "array", "null"}
+
+func (s *JSONSchema) IsValid() error {
+	if s.Type != "" && !slices.Contains(jsonSchemaTypes, s.Type) {
+		return fmt.Errorf("unsupported type %s, use one of %s", s.Type, strings.Join(jsonSchemaTypes, ", "))
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", s.Pattern, err)
+		}
+	}
+	for _, v := range s.Enum {
+		switch v.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("enum values must be strings, numbers or booleans")
+		}
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("schema of property %s can not be empty", name)
+		}
+		if err := property.IsValid(); err != nil {
+			return fmt.Errorf("invalid schema for property %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// For serializing from db
+func (s *JSONSchema) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s JSONSchema) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize json schema to JSON")
+	}
+	return serialized, nil
+}
+
+// RegisteredJSONSchema is a JSON schema which json_schema_validator
+// operators can refer to by name
+type RegisteredJSONSchema struct {
+	Name      string     `json:"name" db:"name"`
+	Schema    JSONSchema `json:"schema" db:"schema_json"`
+	UpdatedBy string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+type PostableJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+func (p *PostableJSONSchema) parse() (*JSONSchema, error) {
+	if strings.TrimSpace(p.Name) == "" {
+		return nil, fmt.Errorf("schema name is required")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(p.Schema))
+	decoder.DisallowUnknownFields()
+	schema := &JSONSchema{}
+	if err := decoder.Decode(schema); err != nil {
+		return nil, fmt.Errorf("schema is not valid or uses unsupported keywords: %w", err)
+	}
+	if err := schema.IsValid(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// referencedJSONSchemas returns the names of the schemas used by pipelines
+func referencedJSONSchemas(pipelines []Pipeline) []string {
+	names := []string{}
+	for _, p := range pipelines {
+		for _, op := range p.Config {
+			if op.Type == jsonSchemaValidatorOperator && !slices.Contains(names, op.SchemaName) {
+				names = append(names, op.SchemaName)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveJSONSchemas sets the definitions of the schemas used by the
+// json_schema_validator operators in pipelines
+func resolveJSONSchemas(pipelines []Pipeline, schemas []RegisteredJSONSchema) error {
+	for _, p := range pipelines {
+		for i := range p.Config {
+			op := &p.Config[i]
+			if op.Type != jsonSchemaValidatorOperator {
+				continue
+			}
+			idx := slices.IndexFunc(schemas, func(s RegisteredJSONSchema) bool {
+				return s.Name == op.SchemaName
+			})
+			if idx < 0 {
+				return fmt.Errorf("json schema %s used in pipeline %s is not registered", op.SchemaName, p.Name)
+			}
+			schema := schemas[idx].Schema
+			op.JSONSchema = &schema
+		}
+	}
+	return nil
+}
+
+// schemaCheck is a condition a valid log must satisfy along with the
+// validation error to be reported when it doesn't
+type schemaCheck struct {
+	condition string
+	message   string
+}
+
+// prepareJSONSchemaValidator turns a json_schema_validator operator into an
+// add operator which sets the validation error field of logs not conforming
+// to the schema. Pipelines can then quarantine or drop invalid logs by
+// filtering on the validation error field.
+func prepareJSONSchemaValidator(operator *PipelineOperator) error {
+	if operator.JSONSchema == nil {
+		return fmt.Errorf("json schema %s not found", operator.SchemaName)
+	}
+
+	parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+	if err != nil {
+		return fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+
+	checks := jsonSchemaChecks(operator.ParseFrom, operator.ParseFrom, operator.JSONSchema)
+	conditions := []string{}
+	for _, c := range checks {
+		conditions = append(conditions, fmt.Sprintf("(%s)", c.condition))
+	}
+
+	// report the first failed check
+	message := strconv.Quote(fmt.Sprintf("does not conform to schema %s", operator.SchemaName))
+	for i := len(checks) - 1; i >= 0; i-- {
+		message = fmt.Sprintf(
+			"(!(%s) ? %s : %s)", checks[i].condition, strconv.Quote(checks[i].message), message,
+		)
+	}
+
+	operator.Type = "add"
+	if operator.Field == "" {
+		operator.Field = defaultSchemaValidationErrorField
+	}
+	operator.Value = fmt.Sprintf("EXPR(%s)", message)
+	operator.If = "false"
+	if len(conditions) > 0 {
+		operator.If = fmt.Sprintf(
+			"%s && !(%s)", parseFromNotNilCheck, strings.Join(conditions, " && "),
+		)
+	}
+	operator.ParseFrom = ""
+	return nil
+}
+
+// jsonSchemaChecks returns the checks for the value at path conforming to
+// schema. Checks are ordered so that each check can assume the ones before
+// it passed, eg: properties are only accessed after the type check for their
+// object has passed.
+func jsonSchemaChecks(path string, displayPath string, schema *JSONSchema) []schemaCheck {
+	checks := []schemaCheck{}
+
+	schemaType := schema.Type
+	if schemaType == "" && (len(schema.Properties) > 0 || len(schema.Required) > 0) {
+		schemaType = "object"
+	}
+	if schemaType != "" {
+		checks = append(checks, schemaCheck{
+			condition: jsonTypeCondition(path, schemaType),
+			message:   fmt.Sprintf("%s must be of type %s", displayPath, schemaType),
+		})
+	}
+
+	if len(schema.Enum) > 0 {
+		values := []string{}
+		for _, v := range schema.Enum {
+			values = append(values, exprLiteral(v))
+		}
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("%s in [%s]", path, strings.Join(values, ", ")),
+			message:   fmt.Sprintf("%s must be one of the allowed values", displayPath),
+		})
+	}
+
+	isString := fmt.Sprintf(`type(%s) == "string"`, path)
+	if schema.MinLength != nil {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("!(%s) || len(%s) >= %d", isString, path, *schema.MinLength),
+			message:   fmt.Sprintf("%s must be at least %d characters long", displayPath, *schema.MinLength),
+		})
+	}
+	if schema.MaxLength != nil {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("!(%s) || len(%s) <= %d", isString, path, *schema.MaxLength),
+			message:   fmt.Sprintf("%s must be at most %d characters long", displayPath, *schema.MaxLength),
+		})
+	}
+	if schema.Pattern != "" {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf(`!(%s) || %s matches "%s"`, isString, path, strings.ReplaceAll(
+				strings.ReplaceAll(schema.Pattern, `\`, `\\`), `"`, `\"`,
+			)),
+			message: fmt.Sprintf("%s must match the pattern %s", displayPath, schema.Pattern),
+		})
+	}
+
+	isNumber := fmt.Sprintf(`type(%s) in ["int", "uint", "float"]`, path)
+	if schema.Minimum != nil {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("!(%s) || %s >= %s", isNumber, path, exprLiteral(*schema.Minimum)),
+			message:   fmt.Sprintf("%s must be at least %v", displayPath, *schema.Minimum),
+		})
+	}
+	if schema.Maximum != nil {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("!(%s) || %s <= %s", isNumber, path, exprLiteral(*schema.Maximum)),
+			message:   fmt.Sprintf("%s must be at most %v", displayPath, *schema.Maximum),
+		})
+	}
+
+	for _, name := range schema.Required {
+		checks = append(checks, schemaCheck{
+			condition: fmt.Sprintf("%s[%s] != nil", path, strconv.Quote(name)),
+			message:   fmt.Sprintf("%s.%s is required", displayPath, name),
+		})
+	}
+
+	propertyNames := []string{}
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	for _, name := range propertyNames {
+		propertyPath := fmt.Sprintf("%s[%s]", path, strconv.Quote(name))
+		propertyChecks := jsonSchemaChecks(
+			propertyPath, displayPath+"."+name, schema.Properties[name],
+		)
+		for _, c := range propertyChecks {
+			if !slices.Contains(schema.Required, name) {
+				// optional properties only need to be valid when present
+				c.condition = fmt.Sprintf("%s == nil || (%s)", propertyPath, c.condition)
+			}
+			checks = append(checks, c)
+		}
+	}
+
+	return checks
+}
+
+func jsonTypeCondition(path string, schemaType string) string {
+	switch schemaType {
+	case "string":
+		return fmt.Sprintf(`type(%s) == "string"`, path)
+	case "number":
+		return fmt.Sprintf(`type(%s) in ["int", "uint", "float"]`, path)
+	case "integer":
+		return fmt.Sprintf(
+			`type(%s) in ["int", "uint"] || (type(%s) == "float" && %s == float(int(%s)))`,
+			path, path, path, path,
+		)
+	case "boolean":
+		return fmt.Sprintf(`type(%s) == "bool"`, path)
+	case "object":
+		return fmt.Sprintf(`type(%s) == "map"`, path)
+	case "array":
+		return fmt.Sprintf(`type(%s) == "array"`, path)
+	}
+	return fmt.Sprintf("%s == nil", path)
+}
+
+func exprLiteral(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return "nil"
+}
+
+func (r *Repo) getJSONSchemas(ctx context.Context) ([]RegisteredJSONSchema, *model.ApiError) {
+	schemas := []RegisteredJSONSchema{}
+	err := r.db.SelectContext(ctx, &schemas, `
+		SELECT name, schema_json, updated_by, updated_at
+		FROM log_json_schemas
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get json schemas"))
+	}
+	return schemas, nil
+}
+
+func (r *Repo) upsertJSONSchema(
+	ctx context.Context, userId string, name string, schema *JSONSchema,
+) (*RegisteredJSONSchema, *model.ApiError) {
+	registered := &RegisteredJSONSchema{
+		Name:      name,
+		Schema:    *schema,
+		UpdatedBy: userId,
+		UpdatedAt: time.Now(),
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO log_json_schemas (name, schema_json, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT(name) DO UPDATE SET
+			schema_json = excluded.schema_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, registered.Name, registered.Schema, registered.UpdatedBy, registered.UpdatedAt)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to save json schema"))
+	}
+	return registered, nil
+}
+
+func (r *Repo) deleteJSONSchema(ctx context.Context, name string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM log_json_schemas WHERE name = $1
+	`, name)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to delete json schema"))
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return model.NotFoundError(fmt.Errorf("json schema %s not found", name))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestJSONSchemaValidator(t *testing.T) {
+	require := require.New(t)
+
+	postableSchema := PostableJSONSchema{
+		Name: "orders",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"required": ["order_id"],
+			"properties": {
+				"order_id": {"type": "string", "pattern": "^ord-"},
+				"amount": {"type": "number", "minimum": 0},
+				"status": {"enum": ["created", "paid"]}
+			}
+		}`),
+	}
+	schema, err := postableSchema.parse()
+	require.Nil(err)
+
+	_, err = (&PostableJSONSchema{
+		Name: "unsupported", Schema: json.RawMessage(`{"type": "object", "oneOf": []}`),
+	}).parse()
+	require.NotNil(err, "schemas with unsupported keywords should be rejected")
+
+	validator := PipelineOperator{
+		OrderId:    1,
+		ID:         "validate",
+		Type:       jsonSchemaValidatorOperator,
+		Enabled:    true,
+		Name:       "validate orders",
+		ParseFrom:  "attributes",
+		SchemaName: "orders",
+	}
+	require.Nil(isValidOperator(validator))
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "orders",
+					},
+				},
+			},
+			Config: []PipelineOperator{validator},
+		},
+	}
+	require.Nil(resolveJSONSchemas(pipelines, []RegisteredJSONSchema{
+		{Name: "orders", Schema: *schema},
+	}))
+
+	testLogs := []model.SignozLog{
+		makeTestSignozLog("valid", map[string]interface{}{
+			"service": "orders", "order_id": "ord-1", "amount": 10.5, "status": "paid",
+		}),
+		makeTestSignozLog("missing order id", map[string]interface{}{
+			"service": "orders", "amount": 10.5,
+		}),
+		makeTestSignozLog("negative amount", map[string]interface{}{
+			"service": "orders", "order_id": "ord-2", "amount": -1.0,
+		}),
+		makeTestSignozLog("bad order id", map[string]interface{}{
+			"service": "orders", "order_id": "2",
+		}),
+		makeTestSignozLog("unknown status", map[string]interface{}{
+			"service": "orders", "order_id": "ord-3", "status": "lost",
+		}),
+	}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, testLogs,
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(len(testLogs), len(result))
+
+	validationErrors := []string{}
+	for _, l := range result {
+		validationErrors = append(validationErrors, l.Attributes_string["schema_validation_error"])
+	}
+	require.Equal([]string{
+		"",
+		"attributes.order_id is required",
+		"attributes.amount must be at least 0",
+		"attributes.order_id must match the pattern ^ord-",
+		"attributes.status must be one of the allowed values",
+	}, validationErrors)
+}
@@ -0,0 +1,142 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	maskOperator = "mask"
+
+	maskTypeRedact = "redact"
+	maskTypeHash   = "hash"
+	maskTypeLast4  = "last4"
+
+	maskPlaceholder = "****"
+)
+
+var maskTypes = []string{maskTypeRedact, maskTypeHash, maskTypeLast4}
+
+func validateMaskOperator(op PipelineOperator) error {
+	if len(op.Fields) == 0 && op.Regex == "" {
+		return fmt.Errorf("fields or regex of mask operator %s must be present", op.ID)
+	}
+	if op.MaskType != "" && !slices.Contains(maskTypes, op.MaskType) {
+		return fmt.Errorf(
+			"invalid mask type %s of mask operator %s, must be one of %v", op.MaskType, op.ID, maskTypes,
+		)
+	}
+	if op.Condition != "" || (op.Filter != nil && len(op.Filter.Items) > 0) {
+		return fmt.Errorf("mask operator %s can't have a filter or condition", op.ID)
+	}
+
+	if op.Regex != "" {
+		if _, err := regexp.Compile(op.Regex); err != nil {
+			return fmt.Errorf("error compiling regex expression of mask operator %s: %w", op.ID, err)
+		}
+		if op.MaskType == maskTypeLast4 {
+			return fmt.Errorf(
+				"mask operator %s can only keep the last 4 characters of whole fields, not of regex matches", op.ID,
+			)
+		}
+	}
+	for _, field := range op.Fields {
+		if _, err := ottlFieldPath(field); err != nil {
+			return fmt.Errorf("invalid field of mask operator %s: %w", op.ID, err)
+		}
+	}
+	return nil
+}
+
+// ottlFieldPath translates the path of a log field as used by the other
+// operators, e.g. attributes.user_email, into its ottl path
+func ottlFieldPath(field string) (string, error) {
+	if field == "body" {
+		return field, nil
+	}
+	for prefix, ottlPrefix := range map[string]string{
+		"attributes.": "attributes",
+		"resource.":   "resource.attributes",
+	} {
+		if key := strings.TrimPrefix(field, prefix); key != field && key != "" {
+			return fmt.Sprintf(`%s[%s]`, ottlPrefix, ottlQuote(key)), nil
+		}
+	}
+	return "", fmt.Errorf("%s must be body or an attribute or resource field", field)
+}
+
+func ottlQuote(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`))
+}
+
+// prepareMaskOperator turns a mask operator into the ottl statements masking
+// its fields, or the matches of its regex in them. The body and the values
+// of all attributes are masked when a regex is given without fields.
+//
+// The $ of replacements is escaped with the rest of the generated config,
+// see escapeProcessorsConf.
+func prepareMaskOperator(operator *PipelineOperator) error {
+	if err := validateMaskOperator(*operator); err != nil {
+		return err
+	}
+	maskType := operator.MaskType
+	if maskType == "" {
+		maskType = maskTypeRedact
+	}
+
+	replacement := ottlQuote(maskPlaceholder)
+	if maskType == maskTypeHash {
+		replacement = `"$0", SHA256`
+	}
+
+	statements := []string{}
+	if operator.Regex != "" && len(operator.Fields) == 0 {
+		regex := ottlQuote(operator.Regex)
+		statements = append(statements,
+			fmt.Sprintf(`replace_pattern(body, %s, %s) where IsString(body)`, regex, replacement),
+			fmt.Sprintf(`replace_all_patterns(attributes, "value", %s, %s)`, regex, replacement),
+		)
+	}
+
+	for _, field := range operator.Fields {
+		path, err := ottlFieldPath(field)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case operator.Regex != "":
+			statements = append(statements, fmt.Sprintf(
+				`replace_pattern(%s, %s, %s) where IsString(%s)`,
+				path, ottlQuote(operator.Regex), replacement, path,
+			))
+		case maskType == maskTypeHash:
+			statements = append(statements, fmt.Sprintf(
+				`set(%s, SHA256(%s)) where IsString(%s)`, path, path, path,
+			))
+		case maskType == maskTypeLast4:
+			// values too short to keep a part of are redacted fully
+			statements = append(statements,
+				fmt.Sprintf(
+					`set(%s, %s) where IsString(%s) and Len(%s) <= 4`,
+					path, ottlQuote(maskPlaceholder), path, path,
+				),
+				fmt.Sprintf(
+					`replace_pattern(%s, "^.+(.{4})$", %s) where IsString(%s)`,
+					path, ottlQuote(maskPlaceholder+"$1"), path,
+				),
+			)
+		default:
+			statements = append(statements, fmt.Sprintf(
+				`set(%s, %s) where %s != nil`, path, ottlQuote(maskPlaceholder), path,
+			))
+		}
+	}
+
+	operator.Type = ottlOperator
+	operator.Statements = statements
+	return nil
+}
@@ -0,0 +1,157 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestMaskOperator(t *testing.T) {
+	require := require.New(t)
+
+	invalid := []PipelineOperator{
+		{ID: "mask", Type: maskOperator},
+		{ID: "mask", Type: maskOperator, Fields: []string{"attributes.email"}, MaskType: "scramble"},
+		{ID: "mask", Type: maskOperator, Fields: []string{"severity_text"}},
+		{ID: "mask", Type: maskOperator, Regex: `\d{16}`, MaskType: maskTypeLast4},
+		{ID: "mask", Type: maskOperator, Regex: `(\d{16}`},
+		{ID: "mask", Type: maskOperator, Regex: `\d{16}`, Condition: `attributes.service == "checkout"`},
+	}
+	for _, op := range invalid {
+		require.NotNil(isValidOperator(op), op)
+	}
+
+	pipeline := Pipeline{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "service",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "checkout",
+				},
+			},
+		},
+		Config: []PipelineOperator{
+			{
+				OrderId: 1, ID: "emails", Type: maskOperator, Enabled: true, Name: "hash emails",
+				Fields: []string{"attributes.user.email"}, MaskType: maskTypeHash, Output: "cards",
+			},
+			{
+				OrderId: 2, ID: "cards", Type: maskOperator, Enabled: true, Name: "mask cards",
+				Fields: []string{"attributes.card"}, MaskType: maskTypeLast4, Output: "ssn",
+			},
+			{
+				OrderId: 3, ID: "ssn", Type: maskOperator, Enabled: true, Name: "redact ssn",
+				Regex: `\d{3}-\d{2}-\d{4}`, Output: "tokens",
+			},
+			{
+				OrderId: 4, ID: "tokens", Type: maskOperator, Enabled: true, Name: "hash tokens",
+				Fields: []string{"resource.token"}, Regex: `tok_[a-z0-9]+`, MaskType: maskTypeHash,
+			},
+		},
+	}
+	for _, op := range pipeline.Config {
+		require.Nil(isValidOperator(op))
+	}
+
+	processors, names, err := PreparePipelineProcessor([]Pipeline{pipeline})
+	require.Nil(err)
+	require.Equal([]string{
+		"logstransform/pipeline_pipeline1", "transform/pipeline_pipeline1_1",
+	}, names)
+
+	statements := processors[names[1]].(TransformProcessor).LogStatements[0].Statements
+	marker := `attributes["__signoz_pipeline_match__"] == "true"`
+	require.Equal([]string{
+		`set(attributes["user.email"], SHA256(attributes["user.email"])) where ` + marker + ` and (IsString(attributes["user.email"]))`,
+		`set(attributes["card"], "****") where ` + marker + ` and (IsString(attributes["card"]) and Len(attributes["card"]) <= 4)`,
+		`replace_pattern(attributes["card"], "^.+(.{4})$", "****$1") where ` + marker + ` and (IsString(attributes["card"]))`,
+		`replace_pattern(body, "\\d{3}-\\d{2}-\\d{4}", "****") where ` + marker + ` and (IsString(body))`,
+		`replace_all_patterns(attributes, "value", "\\d{3}-\\d{2}-\\d{4}", "****") where ` + marker,
+		`replace_pattern(resource.attributes["token"], "tok_[a-z0-9]+", "$0", SHA256) where ` + marker + ` and (IsString(resource.attributes["token"]))`,
+		`delete_key(attributes, "__signoz_pipeline_match__")`,
+	}, statements)
+
+	require.Nil(validateOTTLOperator(PipelineOperator{ID: "ottl", Statements: statements}))
+}
+
+func TestMaskOperatorPreview(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := Pipeline{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "service",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "checkout",
+				},
+			},
+		},
+		Config: []PipelineOperator{
+			{
+				OrderId: 1, ID: "emails", Type: maskOperator, Enabled: true, Name: "hash emails",
+				Fields: []string{"attributes.email"}, MaskType: maskTypeHash, Output: "cards",
+			},
+			{
+				OrderId: 2, ID: "cards", Type: maskOperator, Enabled: true, Name: "mask cards",
+				Fields: []string{"attributes.card", "attributes.pin"}, MaskType: maskTypeLast4, Output: "ssn",
+			},
+			{
+				OrderId: 3, ID: "ssn", Type: maskOperator, Enabled: true, Name: "redact ssn",
+				Regex: `\d{3}-\d{2}-\d{4}`, Output: "tokens",
+			},
+			{
+				OrderId: 4, ID: "tokens", Type: maskOperator, Enabled: true, Name: "hash tokens",
+				Fields: []string{"resource.token"}, Regex: `tok_[a-z0-9]+`, MaskType: maskTypeHash,
+			},
+		},
+	}
+
+	log := makeTestSignozLog("ssn 123-45-6789", map[string]interface{}{
+		"service": "checkout",
+		"email":   "alice@signoz.io",
+		"card":    "4111111111111111",
+		"pin":     "1234",
+		"note":    "ssn of bob is 987-65-4321",
+	})
+	log.Resources_string["token"] = "auth tok_abc"
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, []model.SignozLog{log},
+	)
+	require.Nil(apiErr)
+	require.Empty(collectorWarnAndErrorLogs)
+	require.Len(result, 1)
+
+	require.Equal("ssn ****", result[0].Body)
+	require.Equal(map[string]string{
+		"service": "checkout",
+		"email":   ottlHash("SHA256", "alice@signoz.io"),
+		"card":    "****1111",
+		"pin":     "****",
+		"note":    "ssn of bob is ****",
+	}, result[0].Attributes_string)
+	require.Equal(map[string]string{"token": "auth " + ottlHash("SHA256", "tok_abc")}, result[0].Resources_string)
+}
@@ -18,13 +18,25 @@ type Pipeline struct {
 	Enabled     bool          `json:"enabled" db:"enabled"`
 	Filter      *v3.FilterSet `json:"filter" db:"filter"`
 
+	// agents the pipeline is deployed to, all agents if nil
+	AgentSelector *AgentSelector `json:"agentSelector,omitempty" db:"agent_selector"`
+
+	// free form tags, e.g. the team owning the pipeline
+	Tags PipelineTags `json:"tags" db:"tags"`
+
+	// why this revision of the pipeline was saved
+	ChangeNote string `json:"changeNote,omitempty" db:"change_note"`
+
 	// configuration for pipeline
 	RawConfig string `db:"config_json" json:"-"`
 
 	Config []PipelineOperator `json:"config"`
 
-	// Updater not required as any change will result in new version
+	// any change to a pipeline stores it again with a new id, the creator
+	// of the first revision is kept and the author of the change recorded
+	// as the updater
 	Creator
+	Updater
 }
 
 type Creator struct {
@@ -32,6 +44,11 @@ type Creator struct {
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
+type Updater struct {
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
 type Processor struct {
 	Operators []PipelineOperator `json:"operators" yaml:"operators"`
 }
@@ -48,6 +65,11 @@ type PipelineOperator struct {
 	Enabled bool   `json:"enabled" yaml:"-"`
 	Name    string `json:"name,omitempty" yaml:"-"`
 
+	// condition for running the operator, specified either as a filter or
+	// as an expr expression. Logs not matching it skip the operator.
+	Filter    *v3.FilterSet `json:"filter,omitempty" yaml:"-"`
+	Condition string        `json:"condition,omitempty" yaml:"-"`
+
 	// optional keys depending on the type
 	ParseTo      string `json:"parse_to,omitempty" yaml:"parse_to,omitempty"`
 	Pattern      string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
@@ -70,6 +92,41 @@ type PipelineOperator struct {
 	// severity parser fields
 	SeverityMapping       map[string][]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 	OverwriteSeverityText bool                `json:"overwrite_text,omitempty" yaml:"overwrite_text,omitempty"`
+	// name of a predefined mapping, merged into the mapping when generating
+	// collector config
+	SeverityDictionary string `json:"dictionary,omitempty" yaml:"-"`
+
+	// json schema validator fields, the schema definition is looked up by
+	// name when generating collector config
+	SchemaName string      `json:"schema,omitempty" yaml:"-"`
+	JSONSchema *JSONSchema `json:"-" yaml:"-"`
+
+	// json flatten fields, the operator is translated to an add operator
+	MaxDepth    int      `json:"max_depth,omitempty" yaml:"-"`
+	Delimiter   string   `json:"delimiter,omitempty" yaml:"-"`
+	KeyPrefix   string   `json:"key_prefix,omitempty" yaml:"-"`
+	IncludeKeys []string `json:"include_keys,omitempty" yaml:"-"`
+	ExcludeKeys []string `json:"exclude_keys,omitempty" yaml:"-"`
+
+	// ottl operator statements, run by a transform processor
+	Statements []string `json:"statements,omitempty" yaml:"-"`
+
+	// mask operator fields, the operator is translated to ottl statements
+	// masking the fields given or the regex matches in them
+	MaskType string `json:"mask_type,omitempty" yaml:"-"`
+
+	// csv and key value parser fields, the delimiter is shared with json
+	// flatten and emitted for the parsers as ParserDelimiter
+	HeaderFields    []string `json:"header_fields,omitempty" yaml:"-"`
+	Header          string   `json:"-" yaml:"header,omitempty"`
+	ParserDelimiter string   `json:"-" yaml:"delimiter,omitempty"`
+	PairDelimiter   string   `json:"pair_delimiter,omitempty" yaml:"pair_delimiter,omitempty"`
+	LazyQuotes      bool     `json:"lazy_quotes,omitempty" yaml:"lazy_quotes,omitempty"`
+
+	// sampler fields, the operator is translated to a filter operator
+	// dropping the given percentage of the logs reaching it
+	DropPercent float64 `json:"drop_percent,omitempty" yaml:"-"`
+	DropRatio   float64 `json:"-" yaml:"drop_ratio,omitempty"`
 }
 
 type TimestampParser struct {
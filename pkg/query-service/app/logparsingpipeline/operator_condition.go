@@ -0,0 +1,48 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+
+	"github.com/antonmedv/expr/parser"
+	"go.signoz.io/signoz/pkg/query-service/queryBuilderToExpr"
+)
+
+// operatorCondition returns the expression an operator has to match for
+// being run, or an empty string if the operator runs on every log reaching
+// it. Conditions can be specified either as a filter, the same way pipeline
+// filters are, or as a raw expr expression.
+func operatorCondition(op PipelineOperator) (string, error) {
+	hasFilter := op.Filter != nil && len(op.Filter.Items) > 0
+	if hasFilter && op.Condition != "" {
+		return "", fmt.Errorf(
+			"only one of filter or condition can be specified for operator %s", op.ID,
+		)
+	}
+
+	if hasFilter {
+		condition, err := queryBuilderToExpr.Parse(op.Filter)
+		if err != nil {
+			return "", fmt.Errorf("invalid filter of operator %s: %w", op.ID, err)
+		}
+		return condition, nil
+	}
+
+	if op.Condition != "" {
+		if _, err := parser.Parse(op.Condition); err != nil {
+			return "", fmt.Errorf("invalid condition of operator %s: %w", op.ID, err)
+		}
+	}
+	return op.Condition, nil
+}
+
+// withCondition combines the `if` expression generated for an operator with
+// its condition
+func withCondition(ifExpr string, condition string) string {
+	if condition == "" {
+		return ifExpr
+	}
+	if ifExpr == "" {
+		return condition
+	}
+	return fmt.Sprintf("(%s) && (%s)", condition, ifExpr)
+}
@@ -0,0 +1,345 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ottlOperator = "ottl"
+
+	// processors a pipeline gets split into around its ottl operators
+	pipelineSegmentPfx   = "logstransform/pipelinepart_"
+	pipelineTransformPfx = "transform/pipeline_"
+
+	// matching a pipeline filter is recorded in this attribute by the first
+	// processor of a split pipeline, for the following ones to only process
+	// the logs that matched it
+	pipelineMatchMarker = "__signoz_pipeline_match__"
+)
+
+// TransformProcessor is the config of a transform processor running the
+// statements of ottl operators. It is also the config of the transform
+// processor simulated for previews, see ottl_preview.go.
+type TransformProcessor struct {
+	ErrorMode     string                  `json:"error_mode" yaml:"error_mode" mapstructure:"error_mode"`
+	LogStatements []OTTLContextStatements `json:"log_statements" yaml:"log_statements" mapstructure:"log_statements"`
+}
+
+type OTTLContextStatements struct {
+	Context    string   `json:"context" yaml:"context" mapstructure:"context"`
+	Statements []string `json:"statements" yaml:"statements" mapstructure:"statements"`
+}
+
+// editors of the transform processor usable with logs. Converters aren't
+// listed, any function starting with an uppercase letter is left for the
+// collector to check as their set grows with every release.
+var (
+	ottlEditors = []string{
+		"append", "delete_key", "delete_matching_keys", "flatten", "keep_keys", "limit",
+		"merge_maps", "replace_all_matches", "replace_all_patterns", "replace_match",
+		"replace_pattern", "set", "truncate_all",
+	}
+	ottlLogPaths = []string{
+		"attributes", "body", "cache", "dropped_attributes_count", "flags",
+		"instrumentation_scope", "observed_time", "observed_time_unix_nano", "resource",
+		"severity_number", "severity_text", "span_id", "time", "time_unix_nano", "trace_id",
+	}
+	ottlKeywords = []string{"where", "and", "or", "not", "true", "false", "nil"}
+)
+
+type ottlTokenKind int
+
+const (
+	ottlIdent ottlTokenKind = iota
+	ottlString
+	ottlNumber
+	ottlPunct
+)
+
+type ottlToken struct {
+	kind ottlTokenKind
+	text string
+	pos  int
+}
+
+func lexOTTL(statement string) ([]ottlToken, error) {
+	tokens := []ottlToken{}
+	runes := []rune(statement)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, ottlToken{ottlString, string(runes[start:i]), start})
+
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, ottlToken{ottlNumber, string(runes[start:i]), start})
+
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, ottlToken{ottlIdent, string(runes[start:i]), start})
+
+		case strings.ContainsRune("=!<>", r) && i+1 < len(runes) && runes[i+1] == '=':
+			i += 2
+			tokens = append(tokens, ottlToken{ottlPunct, string(runes[start:i]), start})
+
+		case strings.ContainsRune("()[]{},.:=<>+-*/", r):
+			i++
+			tokens = append(tokens, ottlToken{ottlPunct, string(r), start})
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", r, start)
+		}
+	}
+	return tokens, nil
+}
+
+// parseOTTLStatement checks the structure of a statement, an editor
+// invocation optionally followed by a where clause, and returns the index
+// of the where token or -1 when there is none
+func parseOTTLStatement(tokens []ottlToken) (int, error) {
+	if len(tokens) < 3 || tokens[0].kind != ottlIdent || tokens[1].text != "(" {
+		return -1, fmt.Errorf("statement must start with an editor invocation like set(...)")
+	}
+	if !slices.Contains(ottlEditors, tokens[0].text) {
+		return -1, fmt.Errorf("unknown editor %s", tokens[0].text)
+	}
+
+	where := -1
+	stack := []string{}
+	for i, t := range tokens {
+		if t.kind != ottlPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			stack = append(stack, t.text)
+		case ")", "]", "}":
+			open := map[string]string{")": "(", "]": "[", "}": "{"}[t.text]
+			if len(stack) == 0 || stack[len(stack)-1] != open {
+				return -1, fmt.Errorf("unbalanced %s at %d", t.text, t.pos)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 && where == -1 && i < len(tokens)-1 {
+				// the editor invocation is over
+				if tokens[i+1].text != "where" {
+					return -1, fmt.Errorf("unexpected %s after editor invocation", tokens[i+1].text)
+				}
+				if i+2 >= len(tokens) {
+					return -1, fmt.Errorf("where clause can't be empty")
+				}
+				where = i + 1
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return -1, fmt.Errorf("unclosed %s", stack[len(stack)-1])
+	}
+
+	for i, t := range tokens {
+		if t.kind != ottlIdent || i == 0 {
+			continue
+		}
+		isCall := i+1 < len(tokens) && tokens[i+1].text == "("
+		isPathSegment := tokens[i-1].text == "."
+		isArgName := i+1 < len(tokens) && tokens[i+1].text == "="
+		switch {
+		case t.text == "where":
+			if i != where {
+				return -1, fmt.Errorf("unexpected where at %d", t.pos)
+			}
+		case isPathSegment || isArgName || slices.Contains(ottlKeywords, t.text):
+		case unicode.IsUpper([]rune(t.text)[0]):
+			// converters, which can also be passed to editors to hash
+			// replacements, and enums like SEVERITY_NUMBER_WARN
+		case isCall:
+			return -1, fmt.Errorf("unknown converter %s, editors can only start a statement", t.text)
+		case !slices.Contains(ottlLogPaths, t.text):
+			return -1, fmt.Errorf("unknown path %s, must start with one of %v", t.text, ottlLogPaths)
+		}
+	}
+	return where, nil
+}
+
+func validateOTTLOperator(op PipelineOperator) error {
+	if len(op.Statements) == 0 {
+		return fmt.Errorf("statements of ottl operator %s cannot be empty", op.ID)
+	}
+	if op.Condition != "" || (op.Filter != nil && len(op.Filter.Items) > 0) {
+		return fmt.Errorf(
+			"ottl operator %s can't have a filter or condition, use where clauses in its statements instead", op.ID,
+		)
+	}
+	for _, s := range op.Statements {
+		tokens, err := lexOTTL(s)
+		if err == nil {
+			_, err = parseOTTLStatement(tokens)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid statement %q of ottl operator %s: %w", s, op.ID, err)
+		}
+	}
+	return nil
+}
+
+// withOTTLCondition adds a condition to the where clause of a statement
+func withOTTLCondition(statement string, condition string) (string, error) {
+	tokens, err := lexOTTL(statement)
+	if err != nil {
+		return "", err
+	}
+	where, err := parseOTTLStatement(tokens)
+	if err != nil {
+		return "", err
+	}
+	if where == -1 {
+		return fmt.Sprintf("%s where %s", strings.TrimSpace(statement), condition), nil
+	}
+
+	invocation := strings.TrimSpace(string([]rune(statement)[:tokens[where].pos]))
+	existing := strings.TrimSpace(string([]rune(statement)[tokens[where].pos+len("where"):]))
+	return fmt.Sprintf("%s where %s and (%s)", invocation, condition, existing), nil
+}
+
+func hasOTTLOperators(operators []PipelineOperator) bool {
+	return slices.ContainsFunc(operators, func(op PipelineOperator) bool {
+		return op.Type == ottlOperator
+	})
+}
+
+func isPipelineProcessor(name string) bool {
+	for _, prefix := range []string{constants.LogsPPLPfx, pipelineSegmentPfx, pipelineTransformPfx} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stanzaProcessorCount is the number of logstransform processors generated
+// for the operators of a pipeline, see splitPipelineProcessors
+func stanzaProcessorCount(operators []PipelineOperator) int {
+	count := 1
+	for i, op := range operators {
+		if op.Type != ottlOperator && i > 0 && operators[i-1].Type == ottlOperator {
+			count++
+		}
+	}
+	return count
+}
+
+// splitPipelineProcessors generates the processors for a pipeline with ottl
+// operators. Consecutive ottl operators run in a transform processor, and
+// the other operators in logstransform processors between them. The first
+// processor evaluates the pipeline filter and marks the matching logs, the
+// following ones only process marked logs and the last one drops the mark.
+func splitPipelineProcessors(
+	p Pipeline, operators []PipelineOperator, filterExpr string,
+) (map[string]interface{}, []string, error) {
+	segments := [][]PipelineOperator{}
+	for _, op := range operators {
+		if len(segments) > 0 {
+			last := segments[len(segments)-1]
+			if (last[0].Type == ottlOperator) == (op.Type == ottlOperator) {
+				segments[len(segments)-1] = append(last, op)
+				continue
+			}
+		}
+		segments = append(segments, []PipelineOperator{op})
+	}
+
+	markerField := fmt.Sprintf("attributes.%s", pipelineMatchMarker)
+	markerCheck := fmt.Sprintf(`attributes["%s"] == "true"`, pipelineMatchMarker)
+	noop := PipelineOperator{ID: NOOP, Type: NOOP}
+
+	// stanzaProcessor routes logs matching expr through the operators
+	stanzaProcessor := func(expr string, ops []PipelineOperator, cleanup bool) Processor {
+		ops = append([]PipelineOperator{}, ops...)
+		ops[len(ops)-1].Output = ""
+		if cleanup {
+			ops = append(ops, PipelineOperator{
+				ID:    "signoz_pipeline_match_cleanup",
+				Type:  "remove",
+				Field: markerField,
+				If:    fmt.Sprintf(`attributes["%s"] != nil`, pipelineMatchMarker),
+			})
+		}
+		router := PipelineOperator{
+			ID:      "router_signoz",
+			Type:    "router",
+			Routes:  &[]Route{{Output: ops[0].ID, Expr: expr}},
+			Default: NOOP,
+		}
+		return Processor{Operators: append(append([]PipelineOperator{router}, ops...), noop)}
+	}
+
+	processors := map[string]interface{}{}
+	names := []string{}
+
+	mark := PipelineOperator{ID: "signoz_pipeline_match", Type: "add", Field: markerField, Value: "true"}
+	first := []PipelineOperator{mark}
+	if segments[0][0].Type != ottlOperator {
+		first = append(first, segments[0]...)
+		segments = segments[1:]
+	}
+	name := CollectorConfProcessorName(p)
+	processors[name] = stanzaProcessor(filterExpr, first, false)
+	names = append(names, name)
+
+	for i, segment := range segments {
+		isLast := i == len(segments)-1
+		if segment[0].Type != ottlOperator {
+			name := fmt.Sprintf("%s%s_%d", pipelineSegmentPfx, p.Alias, i+1)
+			processors[name] = stanzaProcessor(markerCheck, segment, isLast)
+			names = append(names, name)
+			continue
+		}
+
+		statements := []string{}
+		for _, op := range segment {
+			for _, s := range op.Statements {
+				statement, err := withOTTLCondition(s, markerCheck)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid statement %q of ottl operator %s: %w", s, op.ID, err)
+				}
+				statements = append(statements, statement)
+			}
+		}
+		if isLast {
+			statements = append(statements, fmt.Sprintf(`delete_key(attributes, "%s")`, pipelineMatchMarker))
+		}
+
+		name := fmt.Sprintf("%s%s_%d", pipelineTransformPfx, p.Alias, i+1)
+		processors[name] = TransformProcessor{
+			ErrorMode:     "ignore",
+			LogStatements: []OTTLContextStatements{{Context: "log", Statements: statements}},
+		}
+		names = append(names, name)
+	}
+	return processors, names, nil
+}
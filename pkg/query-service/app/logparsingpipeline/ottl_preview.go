@@ -0,0 +1,1464 @@
+package logparsingpipeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// The transform processor running ottl and mask operators isn't part of the
+// query service, so previews simulate it with an interpreter for the ottl
+// pipelines use: the editors, converters and paths below. Statements using
+// anything else can't be previewed.
+
+const transformProcessorType = "transform"
+
+func newTransformPreviewFactory() processor.Factory {
+	return processor.NewFactory(
+		transformProcessorType,
+		func() component.Config { return &TransformProcessor{} },
+		processor.WithLogs(createTransformPreviewProcessor, component.StabilityLevelDevelopment),
+	)
+}
+
+func createTransformPreviewProcessor(
+	ctx context.Context, set processor.CreateSettings, cfg component.Config, next consumer.Logs,
+) (processor.Logs, error) {
+	config := cfg.(*TransformProcessor)
+	statements, err := compileTransformProcessor(*config)
+	if err != nil {
+		return nil, err
+	}
+
+	return processorhelper.NewLogsProcessor(ctx, set, cfg, next, func(
+		ctx context.Context, ld plog.Logs,
+	) (plog.Logs, error) {
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			rl := ld.ResourceLogs().At(i)
+			for j := 0; j < rl.ScopeLogs().Len(); j++ {
+				sl := rl.ScopeLogs().At(j)
+				for k := 0; k < sl.LogRecords().Len(); k++ {
+					tctx := &ottlLogContext{
+						record:   sl.LogRecords().At(k),
+						scope:    sl.Scope(),
+						resource: rl.Resource(),
+						cache:    pcommon.NewMap(),
+					}
+					for _, s := range statements {
+						err := s.execute(tctx)
+						if err == nil {
+							continue
+						}
+						if config.ErrorMode != "ignore" {
+							return ld, err
+						}
+						set.Logger.Warn(
+							"failed to execute statement", zap.Error(err), zap.String("statement", s.text),
+						)
+					}
+				}
+			}
+		}
+		return ld, nil
+	})
+}
+
+func compileTransformProcessor(config TransformProcessor) ([]*ottlPreviewStatement, error) {
+	statements := []*ottlPreviewStatement{}
+	for _, cs := range config.LogStatements {
+		if cs.Context != "log" {
+			return nil, fmt.Errorf("statements of the %s context can't be previewed", cs.Context)
+		}
+		for _, s := range cs.Statements {
+			statement, err := compileOTTLStatement(s)
+			if err != nil {
+				return nil, fmt.Errorf("statement %q can't be previewed: %w", s, err)
+			}
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+}
+
+// checkPipelinesPreview reports the ottl and mask operators of pipelines
+// which can't be previewed
+func checkPipelinesPreview(pipelines []Pipeline) error {
+	for _, p := range pipelines {
+		processors, _, err := PreparePipelineProcessor([]Pipeline{p})
+		if err != nil {
+			// invalid pipelines are reported while generating the config
+			continue
+		}
+		for _, processor := range processors {
+			if tp, ok := processor.(TransformProcessor); ok {
+				if _, err := compileTransformProcessor(tp); err != nil {
+					return fmt.Errorf("pipeline %s can't be previewed: %w", p.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type ottlLogContext struct {
+	record   plog.LogRecord
+	scope    pcommon.InstrumentationScope
+	resource pcommon.Resource
+	cache    pcommon.Map
+}
+
+type ottlPreviewStatement struct {
+	text      string
+	editor    func(*ottlLogContext) error
+	condition *ottlExpr
+}
+
+func (s *ottlPreviewStatement) execute(tctx *ottlLogContext) error {
+	if s.condition != nil {
+		matched, err := s.condition.evalBool(tctx)
+		if err != nil || !matched {
+			return err
+		}
+	}
+	return s.editor(tctx)
+}
+
+// ottlExpr is a compiled expression. Its values are nil, string, int64,
+// float64, bool, []byte, []any for lists and the pcommon.Map and
+// pcommon.Slice of the log.
+type ottlExpr struct {
+	eval func(*ottlLogContext) (any, error)
+	// path is set for expressions which are a path, e.g. attributes["a"]
+	path *ottlPath
+	// function is set for converters passed by name, e.g. SHA256
+	function string
+}
+
+func (e *ottlExpr) evalBool(tctx *ottlLogContext) (bool, error) {
+	v, err := e.eval(tctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %v", v)
+	}
+	return b, nil
+}
+
+func (e *ottlExpr) evalString(tctx *ottlLogContext) (string, error) {
+	v, err := e.eval(tctx)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string, got %v", v)
+	}
+	return s, nil
+}
+
+func (e *ottlExpr) evalInt(tctx *ottlLogContext) (int64, error) {
+	v, err := e.eval(tctx)
+	if err != nil {
+		return 0, err
+	}
+	i, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("expected an int, got %v", v)
+	}
+	return i, nil
+}
+
+func (e *ottlExpr) evalMap(tctx *ottlLogContext) (pcommon.Map, error) {
+	v, err := e.eval(tctx)
+	if err != nil {
+		return pcommon.Map{}, err
+	}
+	m, ok := v.(pcommon.Map)
+	if !ok {
+		return pcommon.Map{}, fmt.Errorf("expected a map, got %v", v)
+	}
+	return m, nil
+}
+
+func (e *ottlExpr) evalRegexp(tctx *ottlLogContext) (*regexp.Regexp, error) {
+	pattern, err := e.evalString(tctx)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(pattern)
+}
+
+func compileOTTLStatement(statement string) (*ottlPreviewStatement, error) {
+	tokens, err := lexOTTL(statement)
+	if err != nil {
+		return nil, err
+	}
+	where, err := parseOTTLStatement(tokens)
+	if err != nil {
+		return nil, err
+	}
+	invocation := tokens
+	if where != -1 {
+		invocation = tokens[:where]
+	}
+
+	p := &ottlParser{tokens: invocation}
+	name := p.next().text
+	args, err := p.parseArgs("(", ")")
+	if err != nil {
+		return nil, err
+	}
+	editor, ok := ottlPreviewEditors[name]
+	if !ok {
+		return nil, fmt.Errorf("editor %s isn't supported", name)
+	}
+	result := &ottlPreviewStatement{text: statement}
+	if result.editor, err = editor(args); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if where != -1 {
+		p = &ottlParser{tokens: tokens[where+1:]}
+		if result.condition, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if !p.done() {
+			return nil, fmt.Errorf("unexpected %s at %d", p.peek(), p.tokens[p.pos].pos)
+		}
+	}
+	return result, nil
+}
+
+type ottlParser struct {
+	tokens []ottlToken
+	pos    int
+}
+
+func (p *ottlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ottlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *ottlParser) next() ottlToken {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *ottlParser) expect(text string) error {
+	if p.peek() != text {
+		return fmt.Errorf("expected %s", text)
+	}
+	p.pos++
+	return nil
+}
+
+// parseArgs parses the comma separated expressions between open and close
+func (p *ottlParser) parseArgs(open string, close string) ([]*ottlExpr, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+	args := []*ottlExpr{}
+	for p.peek() != close {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "=" {
+			return nil, fmt.Errorf("named arguments aren't supported")
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, p.expect(close)
+}
+
+func (p *ottlParser) parseExpr() (*ottlExpr, error) {
+	return p.parseBinary(0)
+}
+
+// binary operators by increasing precedence
+var ottlBinaryOperators = [][]string{
+	{"or"},
+	{"and"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *ottlParser) parseBinary(level int) (*ottlExpr, error) {
+	if level == len(ottlBinaryOperators) {
+		return p.parseUnary()
+	}
+	// not binds tighter than and but looser than comparisons
+	if ottlBinaryOperators[level][0] == "==" && p.peek() == "not" {
+		p.pos++
+		operand, err := p.parseBinary(level)
+		if err != nil {
+			return nil, err
+		}
+		return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+			b, err := operand.evalBool(tctx)
+			return !b, err
+		}}, nil
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for slices.Contains(ottlBinaryOperators[level], p.peek()) {
+		op := p.next().text
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = ottlBinaryExpr(op, left, right)
+		if level == 2 && slices.Contains(ottlBinaryOperators[level], p.peek()) {
+			return nil, fmt.Errorf("comparisons can't be chained")
+		}
+	}
+	return left, nil
+}
+
+func ottlBinaryExpr(op string, left *ottlExpr, right *ottlExpr) *ottlExpr {
+	switch op {
+	case "and", "or":
+		return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+			l, err := left.evalBool(tctx)
+			if err != nil || l == (op == "or") {
+				return l, err
+			}
+			return right.evalBool(tctx)
+		}}
+	}
+	return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+		l, err := left.eval(tctx)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right.eval(tctx)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "+", "-", "*", "/":
+			return ottlMath(op, l, r)
+		}
+		return ottlCompare(op, l, r), nil
+	}}
+}
+
+func (p *ottlParser) parseUnary() (*ottlExpr, error) {
+	if p.peek() != "-" {
+		return p.parsePostfix()
+	}
+	p.pos++
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+		v, err := operand.eval(tctx)
+		if err != nil {
+			return nil, err
+		}
+		return ottlMath("-", int64(0), v)
+	}}, nil
+}
+
+// parsePostfix parses a primary expression followed by keys, e.g.
+// ParseJSON(body)["a"]
+func (p *ottlParser) parsePostfix() (*ottlExpr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil || p.peek() != "[" || expr.path != nil {
+		return expr, err
+	}
+	keys, err := p.parseKeys()
+	if err != nil {
+		return nil, err
+	}
+	return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+		v, err := expr.eval(tctx)
+		if err != nil {
+			return nil, err
+		}
+		return ottlIndex(tctx, v, keys)
+	}}, nil
+}
+
+func (p *ottlParser) parseKeys() ([]*ottlExpr, error) {
+	keys := []*ottlExpr{}
+	for p.peek() == "[" {
+		p.pos++
+		key, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (p *ottlParser) parsePrimary() (*ottlExpr, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of statement")
+	}
+	t := p.tokens[p.pos]
+	switch {
+	case t.text == "(":
+		p.pos++
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &ottlExpr{eval: expr.eval}, p.expect(")")
+
+	case t.text == "[":
+		items, err := p.parseArgs("[", "]")
+		if err != nil {
+			return nil, err
+		}
+		return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+			list := []any{}
+			for _, item := range items {
+				v, err := item.eval(tctx)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, nil
+		}}, nil
+
+	case t.text == "{":
+		return p.parseMap()
+
+	case t.kind == ottlString:
+		p.pos++
+		s, err := strconv.Unquote(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", t.text)
+		}
+		return ottlLiteral(s), nil
+
+	case t.kind == ottlNumber:
+		p.pos++
+		if strings.HasPrefix(t.text, "0x") {
+			b, err := hex.DecodeString(t.text[2:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid bytes %s", t.text)
+			}
+			return ottlLiteral(b), nil
+		}
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return ottlLiteral(i), nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t.text)
+		}
+		return ottlLiteral(f), nil
+
+	case t.kind == ottlIdent:
+		switch t.text {
+		case "true", "false":
+			p.pos++
+			return ottlLiteral(t.text == "true"), nil
+		case "nil":
+			p.pos++
+			return ottlLiteral(nil), nil
+		}
+		if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+			return p.parseConverter()
+		}
+		if severity, ok := ottlSeverityNumbers[t.text]; ok {
+			p.pos++
+			return ottlLiteral(severity), nil
+		}
+		if slices.Contains(ottlHashFunctions, t.text) {
+			p.pos++
+			return &ottlExpr{function: t.text, eval: func(*ottlLogContext) (any, error) {
+				return nil, fmt.Errorf("%s must be called", t.text)
+			}}, nil
+		}
+		return p.parsePath()
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", t.text, t.pos)
+}
+
+func (p *ottlParser) parseMap() (*ottlExpr, error) {
+	p.pos++
+	keys := []string{}
+	values := []*ottlExpr{}
+	for p.peek() != "}" {
+		if len(keys) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if p.done() || p.tokens[p.pos].kind != ottlString {
+			return nil, fmt.Errorf("map keys must be strings")
+		}
+		key, err := strconv.Unquote(p.next().text)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	p.pos++
+	return &ottlExpr{eval: func(tctx *ottlLogContext) (any, error) {
+		m := pcommon.NewMap()
+		for i, key := range keys {
+			v, err := values[i].eval(tctx)
+			if err != nil {
+				return nil, err
+			}
+			ottlSetValue(m.PutEmpty(key), v)
+		}
+		return m, nil
+	}}, nil
+}
+
+func (p *ottlParser) parseConverter() (*ottlExpr, error) {
+	name := p.next().text
+	args, err := p.parseArgs("(", ")")
+	if err != nil {
+		return nil, err
+	}
+	converter, ok := ottlPreviewConverters[name]
+	if !ok {
+		return nil, fmt.Errorf("converter %s isn't supported", name)
+	}
+	eval, err := converter(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &ottlExpr{eval: eval}, nil
+}
+
+func ottlLiteral(v any) *ottlExpr {
+	return &ottlExpr{eval: func(*ottlLogContext) (any, error) { return v, nil }}
+}
+
+var ottlSeverityNumbers = func() map[string]int64 {
+	numbers := map[string]int64{"SEVERITY_NUMBER_UNSPECIFIED": 0}
+	for i, level := range []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"} {
+		for j, suffix := range []string{"", "2", "3", "4"} {
+			numbers["SEVERITY_NUMBER_"+level+suffix] = int64(4*i + j + 1)
+		}
+	}
+	return numbers
+}()
+
+// ottlPath is a path of the log, with the keys indexing into it
+type ottlPath struct {
+	name string
+	keys []*ottlExpr
+}
+
+var ottlPreviewPaths = []string{
+	"attributes", "body", "cache", "dropped_attributes_count", "flags",
+	"instrumentation_scope.attributes", "instrumentation_scope.name",
+	"instrumentation_scope.version", "observed_time_unix_nano", "resource.attributes",
+	"severity_number", "severity_text", "span_id.string", "time_unix_nano",
+	"trace_id.string",
+}
+
+func (p *ottlParser) parsePath() (*ottlExpr, error) {
+	segments := []string{p.next().text}
+	for p.peek() == "." {
+		p.pos++
+		if p.done() || p.tokens[p.pos].kind != ottlIdent {
+			return nil, fmt.Errorf("invalid path %s", strings.Join(segments, "."))
+		}
+		segments = append(segments, p.next().text)
+	}
+	path := &ottlPath{name: strings.Join(segments, ".")}
+	if !slices.Contains(ottlPreviewPaths, path.name) {
+		return nil, fmt.Errorf("path %s isn't supported", path.name)
+	}
+	keys, err := p.parseKeys()
+	if err != nil {
+		return nil, err
+	}
+	path.keys = keys
+	return &ottlExpr{path: path, eval: path.get}, nil
+}
+
+func (path *ottlPath) get(tctx *ottlLogContext) (any, error) {
+	var v any
+	r := tctx.record
+	switch path.name {
+	case "attributes":
+		v = r.Attributes()
+	case "body":
+		v = ottlValue(r.Body())
+	case "cache":
+		v = tctx.cache
+	case "dropped_attributes_count":
+		v = int64(r.DroppedAttributesCount())
+	case "flags":
+		v = int64(r.Flags())
+	case "instrumentation_scope.attributes":
+		v = tctx.scope.Attributes()
+	case "instrumentation_scope.name":
+		v = tctx.scope.Name()
+	case "instrumentation_scope.version":
+		v = tctx.scope.Version()
+	case "observed_time_unix_nano":
+		v = int64(r.ObservedTimestamp())
+	case "resource.attributes":
+		v = tctx.resource.Attributes()
+	case "severity_number":
+		v = int64(r.SeverityNumber())
+	case "severity_text":
+		v = r.SeverityText()
+	case "span_id.string":
+		id := r.SpanID()
+		v = hex.EncodeToString(id[:])
+	case "time_unix_nano":
+		v = int64(r.Timestamp())
+	case "trace_id.string":
+		id := r.TraceID()
+		v = hex.EncodeToString(id[:])
+	}
+	return ottlIndex(tctx, v, path.keys)
+}
+
+// set sets the value of the path. Values of the wrong type are ignored, as
+// by the transform processor.
+func (path *ottlPath) set(tctx *ottlLogContext, v any) error {
+	if len(path.keys) > 0 {
+		parent, err := (&ottlPath{name: path.name, keys: path.keys[:len(path.keys)-1]}).get(tctx)
+		if err != nil {
+			return err
+		}
+		key, err := path.keys[len(path.keys)-1].eval(tctx)
+		if err != nil {
+			return err
+		}
+		switch container := parent.(type) {
+		case pcommon.Map:
+			k, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("maps must be indexed with strings, got %v", key)
+			}
+			ottlSetValue(container.PutEmpty(k), v)
+		case pcommon.Slice:
+			i, ok := key.(int64)
+			if !ok || i < 0 || int(i) >= container.Len() {
+				return fmt.Errorf("invalid index %v of a slice of length %d", key, container.Len())
+			}
+			ottlSetValue(container.At(int(i)), v)
+		default:
+			return fmt.Errorf("can't index into %v", parent)
+		}
+		return nil
+	}
+
+	r := tctx.record
+	s, isString := v.(string)
+	i, isInt := v.(int64)
+	m, isMap := v.(pcommon.Map)
+	switch path.name {
+	case "attributes", "cache", "instrumentation_scope.attributes", "resource.attributes":
+		if isMap {
+			target, _ := path.get(tctx)
+			m.CopyTo(target.(pcommon.Map))
+		}
+	case "body":
+		ottlSetValue(r.Body(), v)
+	case "dropped_attributes_count":
+		if isInt {
+			r.SetDroppedAttributesCount(uint32(i))
+		}
+	case "flags":
+		if isInt {
+			r.SetFlags(plog.LogRecordFlags(i))
+		}
+	case "instrumentation_scope.name":
+		if isString {
+			tctx.scope.SetName(s)
+		}
+	case "instrumentation_scope.version":
+		if isString {
+			tctx.scope.SetVersion(s)
+		}
+	case "observed_time_unix_nano":
+		if isInt {
+			r.SetObservedTimestamp(pcommon.Timestamp(i))
+		}
+	case "severity_number":
+		if isInt {
+			r.SetSeverityNumber(plog.SeverityNumber(i))
+		}
+	case "severity_text":
+		if isString {
+			r.SetSeverityText(s)
+		}
+	case "span_id.string":
+		var id pcommon.SpanID
+		if b, err := hex.DecodeString(s); isString && err == nil && len(b) == len(id) {
+			copy(id[:], b)
+			r.SetSpanID(id)
+		}
+	case "time_unix_nano":
+		if isInt {
+			r.SetTimestamp(pcommon.Timestamp(i))
+		}
+	case "trace_id.string":
+		var id pcommon.TraceID
+		if b, err := hex.DecodeString(s); isString && err == nil && len(b) == len(id) {
+			copy(id[:], b)
+			r.SetTraceID(id)
+		}
+	}
+	return nil
+}
+
+func ottlIndex(tctx *ottlLogContext, v any, keys []*ottlExpr) (any, error) {
+	for _, keyExpr := range keys {
+		key, err := keyExpr.eval(tctx)
+		if err != nil {
+			return nil, err
+		}
+		switch container := v.(type) {
+		case pcommon.Map:
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("maps must be indexed with strings, got %v", key)
+			}
+			value, ok := container.Get(k)
+			if !ok {
+				return nil, nil
+			}
+			v = ottlValue(value)
+		case pcommon.Slice, []any:
+			items := ottlList(container)
+			i, ok := key.(int64)
+			if !ok || i < 0 || int(i) >= len(items) {
+				return nil, fmt.Errorf("invalid index %v of a slice of length %d", key, len(items))
+			}
+			v = items[i]
+		case nil:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("can't index into %v", v)
+		}
+	}
+	return v, nil
+}
+
+func ottlList(v any) []any {
+	switch list := v.(type) {
+	case []any:
+		return list
+	case pcommon.Slice:
+		items := []any{}
+		for i := 0; i < list.Len(); i++ {
+			items = append(items, ottlValue(list.At(i)))
+		}
+		return items
+	}
+	return nil
+}
+
+// ottlValue returns the value of a pcommon.Value, maps and slices being
+// returned as is for editors to change them
+func ottlValue(v pcommon.Value) any {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return v.Str()
+	case pcommon.ValueTypeInt:
+		return v.Int()
+	case pcommon.ValueTypeDouble:
+		return v.Double()
+	case pcommon.ValueTypeBool:
+		return v.Bool()
+	case pcommon.ValueTypeBytes:
+		return v.Bytes().AsRaw()
+	case pcommon.ValueTypeMap:
+		return v.Map()
+	case pcommon.ValueTypeSlice:
+		return v.Slice()
+	}
+	return nil
+}
+
+func ottlSetValue(dst pcommon.Value, v any) {
+	switch value := v.(type) {
+	case string:
+		dst.SetStr(value)
+	case int64:
+		dst.SetInt(value)
+	case float64:
+		dst.SetDouble(value)
+	case bool:
+		dst.SetBool(value)
+	case []byte:
+		dst.SetEmptyBytes().FromRaw(value)
+	case pcommon.Map:
+		m := pcommon.NewMap()
+		value.CopyTo(m)
+		m.CopyTo(dst.SetEmptyMap())
+	case pcommon.Slice:
+		s := pcommon.NewSlice()
+		value.CopyTo(s)
+		s.CopyTo(dst.SetEmptySlice())
+	case []any:
+		s := dst.SetEmptySlice()
+		for _, item := range value {
+			ottlSetValue(s.AppendEmpty(), item)
+		}
+	}
+}
+
+// ottlCompare compares values as ottl does, values of different types
+// being neither equal nor ordered
+func ottlCompare(op string, l any, r any) bool {
+	if lf, rf, ok := ottlNumbers(l, r); ok {
+		return ottlOrdered(op, compareNumbers(lf, rf, l, r))
+	}
+	equal := false
+	switch lv := l.(type) {
+	case nil:
+		equal = r == nil
+	case string:
+		if rv, ok := r.(string); ok {
+			return ottlOrdered(op, strings.Compare(lv, rv))
+		}
+	case []byte:
+		if rv, ok := r.([]byte); ok {
+			return ottlOrdered(op, bytes.Compare(lv, rv))
+		}
+	case bool:
+		rv, ok := r.(bool)
+		equal = ok && lv == rv
+	case pcommon.Map:
+		rv, ok := r.(pcommon.Map)
+		equal = ok && reflect.DeepEqual(lv.AsRaw(), rv.AsRaw())
+	case pcommon.Slice:
+		rv, ok := r.(pcommon.Slice)
+		equal = ok && reflect.DeepEqual(lv.AsRaw(), rv.AsRaw())
+	}
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	}
+	return false
+}
+
+// compareNumbers compares ints exactly and other numbers as floats
+func compareNumbers(lf float64, rf float64, l any, r any) int {
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	switch {
+	case lok && rok && li < ri, !(lok && rok) && lf < rf:
+		return -1
+	case lok && rok && li > ri, !(lok && rok) && lf > rf:
+		return 1
+	}
+	return 0
+}
+
+func ottlOrdered(op string, cmp int) bool {
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func ottlNumbers(l any, r any) (float64, float64, bool) {
+	toFloat := func(v any) (float64, bool) {
+		switch n := v.(type) {
+		case int64:
+			return float64(n), true
+		case float64:
+			return n, true
+		}
+		return 0, false
+	}
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	return lf, rf, lok && rok
+}
+
+func ottlMath(op string, l any, r any) (any, error) {
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	if lok && rok {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		}
+		if ri == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return li / ri, nil
+	}
+
+	lf, rf, ok := ottlNumbers(l, r)
+	if !ok {
+		return nil, fmt.Errorf("can't compute %v %s %v", l, op, r)
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	return lf / rf, nil
+}
+
+func ottlArgs(args []*ottlExpr, min int, max int) error {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return fmt.Errorf("expected %d arguments, got %d", min, len(args))
+		}
+		return fmt.Errorf("expected %d to %d arguments, got %d", min, max, len(args))
+	}
+	return nil
+}
+
+func ottlTarget(arg *ottlExpr) (*ottlPath, error) {
+	if arg.path == nil {
+		return nil, fmt.Errorf("the target must be a path")
+	}
+	return arg.path, nil
+}
+
+var ottlHashFunctions = []string{"SHA1", "SHA256"}
+
+func ottlHash(function string, s string) string {
+	if function == "SHA1" {
+		sum := sha1.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// ottlReplacementFunction returns the hash function optionally passed to
+// the replacing editors
+func ottlReplacementFunction(args []*ottlExpr, i int) (string, error) {
+	if len(args) <= i {
+		return "", nil
+	}
+	if args[i].function == "" {
+		return "", fmt.Errorf("the replacement function must be one of %v", ottlHashFunctions)
+	}
+	return args[i].function, nil
+}
+
+// ottlReplacePattern replaces the matches of regex in s, hashing every
+// expanded replacement with function when set
+func ottlReplacePattern(s string, regex *regexp.Regexp, replacement string, function string) string {
+	if function == "" {
+		return regex.ReplaceAllString(s, replacement)
+	}
+	result := []byte{}
+	last := 0
+	for _, match := range regex.FindAllStringSubmatchIndex(s, -1) {
+		expanded := regex.ExpandString(nil, replacement, s, match)
+		result = append(result, s[last:match[0]]...)
+		result = append(result, ottlHash(function, string(expanded))...)
+		last = match[1]
+	}
+	return string(append(result, s[last:]...))
+}
+
+type ottlEditor func(args []*ottlExpr) (func(*ottlLogContext) error, error)
+
+var ottlPreviewEditors = map[string]ottlEditor{
+	"set": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		target, err := ottlTarget(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			v, err := args[1].eval(tctx)
+			if err != nil || v == nil {
+				return err
+			}
+			return target.set(tctx, v)
+		}, nil
+	},
+
+	"delete_key": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			m, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			key, err := args[1].evalString(tctx)
+			if err != nil {
+				return err
+			}
+			m.Remove(key)
+			return nil
+		}, nil
+	},
+
+	"delete_matching_keys": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			m, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			regex, err := args[1].evalRegexp(tctx)
+			if err != nil {
+				return err
+			}
+			m.RemoveIf(func(key string, _ pcommon.Value) bool {
+				return regex.MatchString(key)
+			})
+			return nil
+		}, nil
+	},
+
+	"keep_keys": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			m, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			keys, err := args[1].eval(tctx)
+			if err != nil {
+				return err
+			}
+			keep := ottlList(keys)
+			m.RemoveIf(func(key string, _ pcommon.Value) bool {
+				return !slices.Contains(keep, any(key))
+			})
+			return nil
+		}, nil
+	},
+
+	"merge_maps": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 3, 3); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			target, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			strategy, err := args[2].evalString(tctx)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains([]string{"insert", "update", "upsert"}, strategy) {
+				return fmt.Errorf("invalid merge strategy %s", strategy)
+			}
+			v, err := args[1].eval(tctx)
+			source, ok := v.(pcommon.Map)
+			if err != nil || !ok {
+				return err
+			}
+			source.Range(func(key string, value pcommon.Value) bool {
+				_, exists := target.Get(key)
+				if (strategy == "insert" && !exists) || (strategy == "update" && exists) || strategy == "upsert" {
+					value.CopyTo(target.PutEmpty(key))
+				}
+				return true
+			})
+			return nil
+		}, nil
+	},
+
+	"replace_pattern": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 3, 4); err != nil {
+			return nil, err
+		}
+		target, err := ottlTarget(args[0])
+		if err != nil {
+			return nil, err
+		}
+		function, err := ottlReplacementFunction(args, 3)
+		if err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			v, err := target.get(tctx)
+			s, ok := v.(string)
+			if err != nil || !ok {
+				return err
+			}
+			regex, err := args[1].evalRegexp(tctx)
+			if err != nil {
+				return err
+			}
+			replacement, err := args[2].evalString(tctx)
+			if err != nil {
+				return err
+			}
+			return target.set(tctx, ottlReplacePattern(s, regex, replacement, function))
+		}, nil
+	},
+
+	"replace_all_patterns": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 4, 5); err != nil {
+			return nil, err
+		}
+		function, err := ottlReplacementFunction(args, 4)
+		if err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			m, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			mode, err := args[1].evalString(tctx)
+			if err != nil {
+				return err
+			}
+			regex, err := args[2].evalRegexp(tctx)
+			if err != nil {
+				return err
+			}
+			replacement, err := args[3].evalString(tctx)
+			if err != nil {
+				return err
+			}
+
+			switch mode {
+			case "value":
+				m.Range(func(_ string, value pcommon.Value) bool {
+					if value.Type() == pcommon.ValueTypeStr {
+						value.SetStr(ottlReplacePattern(value.Str(), regex, replacement, function))
+					}
+					return true
+				})
+			case "key":
+				replaced := pcommon.NewMap()
+				m.Range(func(key string, value pcommon.Value) bool {
+					value.CopyTo(replaced.PutEmpty(ottlReplacePattern(key, regex, replacement, function)))
+					return true
+				})
+				replaced.CopyTo(m)
+			default:
+				return fmt.Errorf("invalid mode %s, must be key or value", mode)
+			}
+			return nil
+		}, nil
+	},
+
+	"truncate_all": func(args []*ottlExpr) (func(*ottlLogContext) error, error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) error {
+			m, err := args[0].evalMap(tctx)
+			if err != nil {
+				return err
+			}
+			limit, err := args[1].evalInt(tctx)
+			if err != nil {
+				return err
+			}
+			if limit < 0 {
+				return fmt.Errorf("invalid limit %d", limit)
+			}
+			m.Range(func(_ string, value pcommon.Value) bool {
+				if value.Type() == pcommon.ValueTypeStr && int64(len(value.Str())) > limit {
+					value.SetStr(value.Str()[:limit])
+				}
+				return true
+			})
+			return nil
+		}, nil
+	},
+}
+
+type ottlConverter func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error)
+
+// ottlUnaryConverter builds a converter of a single argument
+func ottlUnaryConverter(convert func(v any) (any, error)) ottlConverter {
+	return func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			v, err := args[0].eval(tctx)
+			if err != nil {
+				return nil, err
+			}
+			return convert(v)
+		}, nil
+	}
+}
+
+func ottlHashConverter(function string) ottlConverter {
+	return ottlUnaryConverter(func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %v", v)
+		}
+		return ottlHash(function, s), nil
+	})
+}
+
+var ottlPreviewConverters = map[string]ottlConverter{
+	"IsString": ottlUnaryConverter(func(v any) (any, error) {
+		_, ok := v.(string)
+		return ok, nil
+	}),
+
+	"IsMap": ottlUnaryConverter(func(v any) (any, error) {
+		_, ok := v.(pcommon.Map)
+		return ok, nil
+	}),
+
+	"Len": ottlUnaryConverter(func(v any) (any, error) {
+		switch value := v.(type) {
+		case string:
+			return int64(len(value)), nil
+		case pcommon.Map:
+			return int64(value.Len()), nil
+		case pcommon.Slice:
+			return int64(value.Len()), nil
+		case []any:
+			return int64(len(value)), nil
+		}
+		return nil, fmt.Errorf("can't compute the length of %v", v)
+	}),
+
+	"SHA1":   ottlHashConverter("SHA1"),
+	"SHA256": ottlHashConverter("SHA256"),
+
+	"Int": ottlUnaryConverter(func(v any) (any, error) {
+		switch value := v.(type) {
+		case int64:
+			return value, nil
+		case float64:
+			return int64(value), nil
+		case bool:
+			if value {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+		return nil, nil
+	}),
+
+	"Double": ottlUnaryConverter(func(v any) (any, error) {
+		switch value := v.(type) {
+		case int64:
+			return float64(value), nil
+		case float64:
+			return value, nil
+		case bool:
+			if value {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		case string:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, nil
+	}),
+
+	"ParseJSON": ottlUnaryConverter(func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %v", v)
+		}
+		parsed := map[string]any{}
+		if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+			return nil, err
+		}
+		m := pcommon.NewMap()
+		return m, m.FromRaw(parsed)
+	}),
+
+	"IsMatch": func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			v, err := args[0].eval(tctx)
+			if err != nil {
+				return nil, err
+			}
+			regex, err := args[1].evalRegexp(tctx)
+			if err != nil {
+				return nil, err
+			}
+			switch value := v.(type) {
+			case string:
+				return regex.MatchString(value), nil
+			case int64, float64, bool:
+				return regex.MatchString(fmt.Sprint(value)), nil
+			}
+			return false, nil
+		}, nil
+	},
+
+	"Concat": func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			values, err := args[0].eval(tctx)
+			if err != nil {
+				return nil, err
+			}
+			delimiter, err := args[1].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			parts := []string{}
+			for _, v := range ottlList(values) {
+				parts = append(parts, fmt.Sprint(v))
+			}
+			return strings.Join(parts, delimiter), nil
+		}, nil
+	},
+
+	"ConvertCase": func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			s, err := args[0].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			toCase, err := args[1].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			switch toCase {
+			case "lower":
+				return strings.ToLower(s), nil
+			case "upper":
+				return strings.ToUpper(s), nil
+			}
+			return nil, fmt.Errorf("case %s isn't supported", toCase)
+		}, nil
+	},
+
+	"Split": func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			s, err := args[0].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			delimiter, err := args[1].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			parts := []any{}
+			for _, part := range strings.Split(s, delimiter) {
+				parts = append(parts, part)
+			}
+			return parts, nil
+		}, nil
+	},
+
+	"Substring": func(args []*ottlExpr) (func(*ottlLogContext) (any, error), error) {
+		if err := ottlArgs(args, 3, 3); err != nil {
+			return nil, err
+		}
+		return func(tctx *ottlLogContext) (any, error) {
+			s, err := args[0].evalString(tctx)
+			if err != nil {
+				return nil, err
+			}
+			start, err := args[1].evalInt(tctx)
+			if err != nil {
+				return nil, err
+			}
+			length, err := args[2].evalInt(tctx)
+			if err != nil {
+				return nil, err
+			}
+			if start < 0 || length <= 0 || start+length > int64(len(s)) {
+				return nil, fmt.Errorf("invalid range %d+%d of a string of length %d", start, length, len(s))
+			}
+			return s[start : start+length], nil
+		}, nil
+	},
+}
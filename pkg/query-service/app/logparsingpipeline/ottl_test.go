@@ -0,0 +1,280 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestOTTLStatementValidation(t *testing.T) {
+	require := require.New(t)
+
+	valid := []string{
+		`set(attributes["env"], "prod")`,
+		`set(severity_number, SEVERITY_NUMBER_WARN) where IsMatch(body, "(?i)warn") and severity_number < 13`,
+		`replace_pattern(attributes["url"], "token=[^&]+", "token=***")`,
+		`delete_key(resource.attributes, "host.ip") where attributes["k8s.namespace"] != nil`,
+		`merge_maps(attributes, ParseJSON(body), "upsert") where IsString(body)`,
+		`merge_maps(attributes, ParseKeyValue(body), "upsert") where IsString(body)`,
+		`set(attributes["tags"], {"team": "checkout", "ids": [1, 2]})`,
+		`replace_pattern(body, "token=[^ ]+", "token=$1", function = SHA256)`,
+		`set(attributes["level"], ConvertCase(severity_text, "lower")) where severity_number >= SEVERITY_NUMBER_INFO`,
+	}
+	for _, s := range valid {
+		require.Nil(validateOTTLOperator(PipelineOperator{ID: "ottl", Statements: []string{s}}), s)
+	}
+
+	invalid := []string{
+		`set(attributes["env"], "prod"`,
+		`set(attributes["env"], "prod") attributes`,
+		`set(attributes["env"], "prod") where`,
+		`drop()`,
+		`set(attributes["env"], upper(body))`,
+		`set(attributes["tags"], {"team": "checkout")`,
+		`set(attributes["env"], set(body, "x"))`,
+		`set(span.name, "x")`,
+		`set(attributes["env"], "prod) where true`,
+		`set(attributes["env"], "prod") where true where false`,
+	}
+	for _, s := range invalid {
+		require.NotNil(validateOTTLOperator(PipelineOperator{ID: "ottl", Statements: []string{s}}), s)
+	}
+
+	require.NotNil(validateOTTLOperator(PipelineOperator{ID: "ottl"}), "statements should be required")
+}
+
+func TestOTTLOperatorsSplitPipelineProcessors(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := Pipeline{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "service",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "checkout",
+				},
+			},
+		},
+		Config: []PipelineOperator{
+			{
+				OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add env",
+				Field: "attributes.env", Value: "prod", Output: "mask",
+			},
+			{
+				OrderId: 2, ID: "mask", Type: ottlOperator, Enabled: true, Name: "mask tokens",
+				Statements: []string{
+					`replace_pattern(body, "token=[^ ]+", "token=***")`,
+					`set(attributes["masked"], true) where IsMatch(body, "token=")`,
+				},
+				Output: "remove",
+			},
+			{
+				OrderId: 3, ID: "remove", Type: "remove", Enabled: true, Name: "remove env",
+				Field: "attributes.env",
+			},
+		},
+	}
+
+	processors, names, err := PreparePipelineProcessor([]Pipeline{pipeline})
+	require.Nil(err)
+	require.Equal([]string{
+		"logstransform/pipeline_pipeline1",
+		"transform/pipeline_pipeline1_1",
+		"logstransform/pipelinepart_pipeline1_2",
+	}, names)
+
+	first := processors[names[0]].(Processor)
+	opIds := []string{}
+	for _, op := range first.Operators {
+		opIds = append(opIds, op.ID)
+	}
+	require.Equal([]string{"router_signoz", "signoz_pipeline_match", "add", NOOP}, opIds)
+	require.Equal("signoz_pipeline_match", (*first.Operators[0].Routes)[0].Output)
+	require.Equal("", first.Operators[2].Output, "the last operator should lead to the noop")
+
+	transform := processors[names[1]].(TransformProcessor)
+	require.Equal([]string{
+		`replace_pattern(body, "token=[^ ]+", "token=***") where attributes["__signoz_pipeline_match__"] == "true"`,
+		`set(attributes["masked"], true) where attributes["__signoz_pipeline_match__"] == "true" and (IsMatch(body, "token="))`,
+	}, transform.LogStatements[0].Statements)
+
+	last := processors[names[2]].(Processor)
+	opIds = []string{}
+	for _, op := range last.Operators {
+		opIds = append(opIds, op.ID)
+	}
+	require.Equal([]string{"router_signoz", "remove", "signoz_pipeline_match_cleanup", NOOP}, opIds)
+	require.Equal(`attributes["__signoz_pipeline_match__"] == "true"`, (*last.Operators[0].Routes)[0].Expr)
+
+	require.True(isPipelineProcessor(names[1]))
+	require.True(isPipelineProcessor(names[2]))
+	require.False(isPipelineProcessor("transform/signoz_lookup_tables"))
+}
+
+func TestOTTLPreviewStatements(t *testing.T) {
+	require := require.New(t)
+
+	newLog := func() *ottlLogContext {
+		ld := plog.NewLogs()
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("host.name", "db-1")
+		sl := rl.ScopeLogs().AppendEmpty()
+		record := sl.LogRecords().AppendEmpty()
+		record.Body().SetStr(`{"user": "alice", "token": "tok_1 tok_2"}`)
+		record.SetSeverityNumber(plog.SeverityNumberWarn)
+		record.Attributes().PutStr("http.method", "GET")
+		record.Attributes().PutInt("http.status", 503)
+		record.Attributes().PutStr("tmp_id", "42")
+		return &ottlLogContext{record: record, scope: sl.Scope(), resource: rl.Resource(), cache: pcommon.NewMap()}
+	}
+	run := func(tctx *ottlLogContext, statements ...string) {
+		for _, s := range statements {
+			statement, err := compileOTTLStatement(s)
+			require.Nil(err, s)
+			require.Nil(statement.execute(tctx), s)
+		}
+	}
+
+	tctx := newLog()
+	run(tctx,
+		`merge_maps(cache, ParseJSON(body), "upsert") where IsString(body)`,
+		`set(attributes["user"], cache["user"])`,
+		`set(attributes["error"], true) where attributes["http.status"] >= 500 and not attributes["http.method"] == "POST"`,
+		`set(attributes["warn"], true) where severity_number == SEVERITY_NUMBER_WARN or 1 / 0 == 0`,
+		`set(attributes["bucket"], attributes["http.status"] / 100 * 100 - 1)`,
+		`set(attributes["tokens"], Len(Split(cache["token"], " ")))`,
+		`set(attributes["route"], Concat([ConvertCase(attributes["http.method"], "lower"), "users"], "/"))`,
+		`set(attributes["missing"], attributes["nothing"])`,
+		`delete_matching_keys(attributes, "^tmp_")`,
+		`replace_pattern(body, "tok_([0-9])", "$1", SHA256)`,
+		`set(resource.attributes["host.name"], "****") where resource.attributes["host.name"] != nil`,
+	)
+	attributes := tctx.record.Attributes().AsRaw()
+	require.Equal(map[string]any{
+		"http.method": "GET",
+		"http.status": int64(503),
+		"user":        "alice",
+		"error":       true,
+		"warn":        true,
+		"bucket":      int64(499),
+		"tokens":      int64(2),
+		"route":       "get/users",
+	}, attributes, "statements setting nil leave the log as is")
+	require.Equal(
+		fmt.Sprintf(`{"user": "alice", "token": "%s %s"}`, ottlHash("SHA256", "1"), ottlHash("SHA256", "2")),
+		tctx.record.Body().Str(), "every expanded replacement is hashed",
+	)
+	require.Equal("****", tctx.resource.Attributes().AsRaw()["host.name"])
+
+	tctx = newLog()
+	run(tctx,
+		`keep_keys(attributes, ["http.method", "tmp_id"])`,
+		`truncate_all(attributes, 2)`,
+		`replace_all_patterns(attributes, "key", "^http\\.", "")`,
+		`set(severity_text, "warning") where severity_number > 12 and "b" > "a" and attributes["method"] != 1`,
+	)
+	require.Equal(map[string]any{"method": "GE", "tmp_id": "42"}, tctx.record.Attributes().AsRaw())
+	require.Equal("warning", tctx.record.SeverityText())
+
+	// statements failing on a log are reported
+	statement, err := compileOTTLStatement(`set(attributes["x"], 1) where attributes["http.method"]`)
+	require.Nil(err)
+	require.NotNil(statement.execute(newLog()), "where clauses must be boolean")
+
+	unsupported := []string{
+		`limit(attributes, 10, [])`,
+		`set(attributes["id"], UUID())`,
+		`set(attributes["name"], span.name)`,
+		`replace_pattern(body, "a", "b", function = SHA256)`,
+		`replace_pattern(body, "a", "b", FNV)`,
+		`set("body", "x")`,
+	}
+	for _, s := range unsupported {
+		_, err := compileOTTLStatement(s)
+		require.NotNil(err, s)
+	}
+}
+
+func TestOTTLOperatorsPreview(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := Pipeline{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "service",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "checkout",
+				},
+			},
+		},
+		Config: []PipelineOperator{
+			{
+				OrderId: 1, ID: "add", Type: "add", Enabled: true, Name: "add env",
+				Field: "attributes.env", Value: "prod", Output: "mask",
+			},
+			{
+				OrderId: 2, ID: "mask", Type: ottlOperator, Enabled: true, Name: "mask tokens",
+				Statements: []string{
+					`replace_pattern(body, "token=[^ ]+", "token=***")`,
+					`set(attributes["masked"], true) where IsMatch(body, "token=") and attributes["env"] == "prod"`,
+				},
+				Output: "remove",
+			},
+			{
+				OrderId: 3, ID: "remove", Type: "remove", Enabled: true, Name: "remove env",
+				Field: "attributes.env",
+			},
+		},
+	}
+
+	require.Equal(2, stanzaProcessorCount(pipeline.Config))
+	require.Equal(1, stanzaProcessorCount(pipeline.Config[:2]))
+
+	matchingLog := makeTestSignozLog("login token=abc", map[string]interface{}{"service": "checkout"})
+	nonMatchingLog := makeTestSignozLog("login token=abc", map[string]interface{}{"service": "cart"})
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, []model.SignozLog{matchingLog, nonMatchingLog},
+	)
+	require.Nil(apiErr)
+	require.Empty(collectorWarnAndErrorLogs)
+	require.Len(result, 2)
+
+	require.Equal("login token=***", result[0].Body)
+	require.Equal(map[string]string{"service": "checkout", "masked": "true"}, result[0].Attributes_string)
+	require.Equal("login token=abc", result[1].Body)
+	require.Equal(map[string]string{"service": "cart"}, result[1].Attributes_string)
+
+	pipeline.Config[1].Statements = []string{`set(attributes["id"], UUID())`}
+	_, _, apiErr = SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, []model.SignozLog{matchingLog},
+	)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Type())
+	require.Contains(apiErr.Error(), "UUID")
+}
@@ -0,0 +1,89 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	maxPipelineTags      = 20
+	maxPipelineTagLength = 64
+	maxChangeNoteLength  = 1000
+)
+
+// PipelineTags are free form labels of a pipeline, e.g. the team owning it
+type PipelineTags []string
+
+func (t PipelineTags) IsValid() error {
+	if len(t) > maxPipelineTags {
+		return fmt.Errorf("a pipeline can have at most %d tags", maxPipelineTags)
+	}
+	seen := map[string]bool{}
+	for _, tag := range t {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("pipeline tags cannot be empty")
+		}
+		if len(tag) > maxPipelineTagLength {
+			return fmt.Errorf("pipeline tag %s is longer than %d characters", tag, maxPipelineTagLength)
+		}
+		if seen[tag] {
+			return fmt.Errorf("pipeline tag %s is repeated", tag)
+		}
+		seen[tag] = true
+	}
+	return nil
+}
+
+func (t *PipelineTags) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, &t)
+	case string:
+		return json.Unmarshal([]byte(data), &t)
+	}
+	return nil
+}
+
+func (t PipelineTags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	tagsJson, err := json.Marshal(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize PipelineTags to JSON")
+	}
+	return string(tagsJson), nil
+}
+
+func validateChangeNote(note string) error {
+	if len(note) > maxChangeNoteLength {
+		return fmt.Errorf("change note cannot be longer than %d characters", maxChangeNoteLength)
+	}
+	return nil
+}
+
+// pipelineCreator returns the creator of the pipeline with the given alias,
+// nil if no revision of it has been stored yet
+func (r *Repo) pipelineCreator(ctx context.Context, alias string) (*Creator, *model.ApiError) {
+	var creator Creator
+	err := r.db.GetContext(ctx, &creator, `
+		SELECT created_by, created_at FROM pipelines
+		WHERE alias = $1
+		ORDER BY created_at ASC
+		LIMIT 1
+	`, alias)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get pipeline creator"))
+	}
+	return &creator, nil
+}
@@ -0,0 +1,65 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPipelineOwnership(t *testing.T) {
+	require := require.New(t)
+
+	require.Nil(PipelineTags{"team:payments", "noisy"}.IsValid())
+	require.NotNil(PipelineTags{"team:payments", "team:payments"}.IsValid())
+	require.NotNil(PipelineTags{" "}.IsValid())
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	testDB, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	repo := NewRepo(testDB)
+	require.Nil(repo.InitDB("sqlite"))
+
+	ctx := context.Background()
+	postable := &PostablePipeline{
+		OrderId: 1, Name: "checkout", Alias: "checkout", Enabled: true,
+		Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+			Key:      v3.AttributeKey{Key: "service", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+			Operator: "=",
+			Value:    "checkout",
+		}}},
+		Config: []PipelineOperator{{
+			OrderId: 1, ID: "parse", Type: "json_parser", Enabled: true, Name: "parse body",
+			ParseFrom: "body", ParseTo: "attributes",
+		}},
+		Tags:       PipelineTags{"team:payments"},
+		ChangeNote: "parse checkout logs",
+	}
+	created, apiErr := repo.insertPipelineAs(ctx, postable, "alice")
+	require.Nil(apiErr)
+
+	postable.Enabled = false
+	postable.ChangeNote = "too noisy"
+	changed, apiErr := repo.insertPipelineAs(ctx, postable, "bob")
+	require.Nil(apiErr)
+
+	stored, apiErr := repo.GetPipeline(ctx, changed.Id)
+	require.Nil(apiErr)
+	require.Equal("alice", stored.CreatedBy, "changed pipelines should keep their creator")
+	require.Equal(created.CreatedAt.Unix(), stored.CreatedAt.Unix())
+	require.Equal("bob", stored.UpdatedBy)
+	require.Equal(PipelineTags{"team:payments"}, stored.Tags)
+	require.Equal("too noisy", stored.ChangeNote)
+
+	stored, apiErr = repo.GetPipeline(ctx, created.Id)
+	require.Nil(apiErr)
+	require.Equal("alice", stored.UpdatedBy)
+	require.Equal("parse checkout logs", stored.ChangeNote)
+}
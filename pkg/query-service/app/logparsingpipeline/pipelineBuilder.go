@@ -43,6 +43,18 @@ func PreparePipelineProcessor(pipelines []Pipeline) (map[string]interface{}, []s
 			return nil, nil, errors.Wrap(err, "failed to parse pipeline filter")
 		}
 
+		if hasOTTLOperators(operators) {
+			splitProcessors, splitNames, err := splitPipelineProcessors(v, operators, filterExpr)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to prepare ottl operators")
+			}
+			for name, processor := range splitProcessors {
+				processors[name] = processor
+			}
+			names = append(names, splitNames...)
+			continue
+		}
+
 		router := []PipelineOperator{
 			{
 				ID:   "router_signoz",
@@ -84,6 +96,11 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 				filteredOp[len(filteredOp)-1].Output = operator.ID
 			}
 
+			condition, err := operatorCondition(operator)
+			if err != nil {
+				return nil, err
+			}
+
 			if operator.Type == "regex_parser" {
 				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
 				if err != nil {
@@ -155,7 +172,20 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 				operator.If = fieldNotNilCheck
 
 			} else if operator.Type == "trace_parser" {
-				cleanTraceParser(&operator)
+				if operator.Regex != "" {
+					trace, cleanup, err := prepareTraceParserRegex(&operator)
+					if err != nil {
+						return nil, fmt.Errorf(
+							"couldn't prepare trace parser %s: %w", operator.Name, err,
+						)
+					}
+					operator.If = withCondition(operator.If, condition)
+					trace.If = withCondition(trace.If, condition)
+					filteredOp = append(filteredOp, operator, *trace)
+					operator = *cleanup
+				} else {
+					cleanTraceParser(&operator)
+				}
 
 			} else if operator.Type == "time_parser" {
 				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
@@ -187,23 +217,73 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 						`%s && string(%s) matches "%s"`, operator.If, operator.ParseFrom, valueRegex,
 					)
 
+				} else if operator.LayoutType == "gotime" {
+					regex, err := RegexForGotimeLayout(operator.Layout)
+					if err != nil {
+						return nil, fmt.Errorf(
+							"couldn't generate layout regex for time_parser %s: %w", operator.Name, err,
+						)
+					}
+
+					operator.If = fmt.Sprintf(
+						`%s && %s matches "%s"`, operator.If, operator.ParseFrom, regex,
+					)
 				}
-				// TODO(Raj): Maybe add support for gotime too eventually
 
 			} else if operator.Type == "severity_parser" {
-				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+				prepareSeverityParser(&operator)
+
+				severity, cleanup, err := prepareSeverityParserInput(&operator)
 				if err != nil {
 					return nil, fmt.Errorf(
-						"couldn't generate nil check for parseFrom of severity parser %s: %w", operator.Name, err,
+						"couldn't prepare severity parser %s: %w", operator.Name, err,
 					)
 				}
-				operator.If = fmt.Sprintf(
-					`%s && ( type(%s) == "string" || ( type(%s) in ["int", "float"] && %s == float(int(%s)) ) )`,
-					parseFromNotNilCheck, operator.ParseFrom, operator.ParseFrom, operator.ParseFrom, operator.ParseFrom,
-				)
+				operator.If = withCondition(operator.If, condition)
+				severity.If = withCondition(severity.If, condition)
+				filteredOp = append(filteredOp, operator, *severity)
+				operator = *cleanup
+
+			} else if operator.Type == jsonSchemaValidatorOperator {
+				if err := prepareJSONSchemaValidator(&operator); err != nil {
+					return nil, fmt.Errorf(
+						"couldn't prepare json schema validator %s: %w", operator.Name, err,
+					)
+				}
+
+			} else if operator.Type == csvParserOperator || operator.Type == keyValueParserOperator {
+				if err := prepareDelimitedParser(&operator); err != nil {
+					return nil, fmt.Errorf(
+						"couldn't prepare %s %s: %w", operator.Type, operator.Name, err,
+					)
+				}
+
+			} else if operator.Type == maskOperator {
+				if err := prepareMaskOperator(&operator); err != nil {
+					return nil, fmt.Errorf(
+						"couldn't prepare mask operator %s: %w", operator.Name, err,
+					)
+				}
+
+			} else if operator.Type == samplerOperator {
+				prepareSampler(&operator, condition)
+				// the condition selects the logs to drop, it is part of expr
+				condition = ""
+
+			} else if operator.Type == jsonFlattenOperator {
+				lift, err := prepareJSONFlatten(&operator)
+				if err != nil {
+					return nil, fmt.Errorf(
+						"couldn't prepare json flatten operator %s: %w", operator.Name, err,
+					)
+				}
+				operator.If = withCondition(operator.If, condition)
+				filteredOp = append(filteredOp, operator)
+				operator = *lift
 
 			}
 
+			operator.If = withCondition(operator.If, condition)
 			filteredOp = append(filteredOp, operator)
 		} else if i == len(ops)-1 && len(filteredOp) != 0 {
 			filteredOp[len(filteredOp)-1].Output = ""
@@ -876,3 +876,89 @@ func TestTemporaryWorkaroundForSupportingAttribsContainingDots(t *testing.T) {
 		require.Equal(processedLog.Attributes_string["test"], "test-value")
 	}
 }
+
+func TestOperatorConditions(t *testing.T) {
+	require := require.New(t)
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "checkout",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					OrderId: 1,
+					ID:      "add_team",
+					Type:    "add",
+					Enabled: true,
+					Name:    "add team for eu",
+					Field:   "attributes.team",
+					Value:   "eu-checkout",
+					Filter: &v3.FilterSet{
+						Operator: "AND",
+						Items: []v3.FilterItem{
+							{
+								Key: v3.AttributeKey{
+									Key:      "region",
+									DataType: v3.AttributeKeyDataTypeString,
+									Type:     v3.AttributeKeyTypeResource,
+								},
+								Operator: "=",
+								Value:    "eu",
+							},
+						},
+					},
+				},
+				{
+					OrderId:   2,
+					ID:        "add_tier",
+					Type:      "add",
+					Enabled:   true,
+					Name:      "add tier for errors",
+					Field:     "attributes.tier",
+					Value:     "critical",
+					Condition: `body contains "error"`,
+				},
+			},
+		},
+	}
+
+	testLogs := []model.SignozLog{
+		makeTestSignozLog("error in eu", map[string]interface{}{"service": "checkout"}),
+		makeTestSignozLog("ok in us", map[string]interface{}{"service": "checkout"}),
+	}
+	testLogs[0].Resources_string = map[string]string{"region": "eu"}
+	testLogs[1].Resources_string = map[string]string{"region": "us"}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, testLogs,
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(2, len(result))
+
+	require.Equal("eu-checkout", result[0].Attributes_string["team"])
+	require.Equal("critical", result[0].Attributes_string["tier"])
+	require.NotContains(result[1].Attributes_string, "team")
+	require.NotContains(result[1].Attributes_string, "tier")
+
+	invalid := pipelines[0].Config[1]
+	invalid.Filter = pipelines[0].Config[0].Filter
+	_, err := operatorCondition(invalid)
+	require.NotNil(err, "only one of filter or condition should be allowed")
+}
@@ -0,0 +1,141 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	pipelineMetricsLookbackMinutes = 15
+
+	// pipelinesMetricsCacheTTL bounds how stale the reported metrics can be,
+	// the collectors report their processor metrics about once a minute
+	pipelinesMetricsCacheTTL = time.Minute
+)
+
+// pipelinesMetricsCache keeps the last metrics computed so that listing the
+// pipelines doesn't query the telemetry store every time. The metrics are
+// kept for the pipelines they were computed for.
+type pipelinesMetricsCache struct {
+	sync.Mutex
+	key       string
+	metrics   *PipelinesMetrics
+	expiresAt time.Time
+}
+
+func pipelinesMetricsCacheKey(pipelines []Pipeline) string {
+	ids := []string{}
+	for _, p := range pipelines {
+		ids = append(ids, p.Id)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (c *pipelinesMetricsCache) get(pipelines []Pipeline) *PipelinesMetrics {
+	c.Lock()
+	defer c.Unlock()
+	if c.metrics == nil || c.key != pipelinesMetricsCacheKey(pipelines) || time.Now().After(c.expiresAt) {
+		return nil
+	}
+	return c.metrics
+}
+
+func (c *pipelinesMetricsCache) set(pipelines []Pipeline, metrics *PipelinesMetrics) {
+	c.Lock()
+	defer c.Unlock()
+	c.key = pipelinesMetricsCacheKey(pipelines)
+	c.metrics = metrics
+	c.expiresAt = time.Now().Add(pipelinesMetricsCacheTTL)
+}
+
+// PipelineMetrics are the per second rates of logs handled by a pipeline
+type PipelineMetrics struct {
+	// ReceivedRate is the rate of logs going through the pipeline
+	// processor, whether they match its filter or not
+	ReceivedRate float64 `json:"receivedRate"`
+	// MatchRate is the rate of stored logs matching the pipeline filter.
+	// It is computed from the stored logs, so pipelines changing the fields
+	// their filter is on are not accounted for correctly.
+	MatchRate float64 `json:"matchRate"`
+	DropRate  float64 `json:"dropRate"`
+	// ErrorRate is the rate of logs refused by the pipeline processor
+	ErrorRate float64 `json:"errorRate"`
+}
+
+type PipelinesMetrics struct {
+	LookbackMinutes int `json:"lookbackMinutes"`
+	// Pipelines are keyed by pipeline id
+	Pipelines map[string]*PipelineMetrics `json:"pipelines"`
+}
+
+// pipelinesMetricsFromResults combines the processor counts reported by the
+// collectors with the logs matching each pipeline
+func pipelinesMetricsFromResults(
+	pipelines []Pipeline, processorResults []*v3.Result, matchResults []*v3.Result, seconds float64,
+) map[string]*PipelineMetrics {
+	counts := processorCountsFromResults(processorResults)
+	_, matching, _ := throughputFromResults(matchResults)
+
+	metrics := map[string]*PipelineMetrics{}
+	for i, p := range pipelines {
+		m := &PipelineMetrics{}
+		if c, ok := counts[p.Alias]; ok {
+			m.ReceivedRate = (c.Accepted + c.Refused + c.Dropped) / seconds
+			m.DropRate = c.Dropped / seconds
+			m.ErrorRate = c.Refused / seconds
+		}
+		m.MatchRate = matching[i] / seconds
+		metrics[p.Id] = m
+	}
+	return metrics
+}
+
+// GetPipelinesMetrics reports how many logs the deployed pipelines received,
+// matched, dropped and errored on recently. The metrics are cached for
+// pipelinesMetricsCacheTTL, failures are not.
+func (ic *LogParsingPipelineController) GetPipelinesMetrics(
+	ctx context.Context, querier interfaces.Querier, pipelines []Pipeline,
+) (*PipelinesMetrics, *model.ApiError) {
+	if metrics := ic.metricsCache.get(pipelines); metrics != nil {
+		return metrics, nil
+	}
+
+	end := time.Now()
+	start := end.Add(-pipelineMetricsLookbackMinutes * time.Minute)
+
+	processorResults, err, _ := querier.QueryRange(
+		ctx, watchdogQueryRangeParams(start.UnixMilli(), end.UnixMilli()), map[string]v3.AttributeKey{},
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log pipeline processor metrics: %w", err,
+		))
+	}
+
+	matchResults, err, errQueriesByName := querier.QueryRange(
+		ctx, pipelineThroughputParams(pipelines, start.UnixMilli(), end.UnixMilli()), map[string]v3.AttributeKey{},
+	)
+	if err != nil {
+		// processor metrics are still useful without the match rates
+		zap.L().Warn("could not query logs matching log pipelines",
+			zap.Error(err), zap.Any("errors", errQueriesByName),
+		)
+	}
+
+	metrics := &PipelinesMetrics{
+		LookbackMinutes: pipelineMetricsLookbackMinutes,
+		Pipelines: pipelinesMetricsFromResults(
+			pipelines, processorResults, matchResults, float64(pipelineMetricsLookbackMinutes*60),
+		),
+	}
+	ic.metricsCache.set(pipelines, metrics)
+	return metrics, nil
+}
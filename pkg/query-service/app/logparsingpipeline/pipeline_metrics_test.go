@@ -0,0 +1,101 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// metricsQuerier answers the processor metrics and logs queries of the
+// pipeline metrics, and counts the queries run
+type metricsQuerier struct {
+	processorResults []*v3.Result
+	matchResults     []*v3.Result
+	err              error
+	queries          int
+}
+
+func (q *metricsQuerier) QueryRange(
+	ctx context.Context, params *v3.QueryRangeParamsV3, keys map[string]v3.AttributeKey,
+) ([]*v3.Result, error, map[string]string) {
+	q.queries++
+	if q.err != nil {
+		return nil, q.err, nil
+	}
+	if _, ok := params.CompositeQuery.ClickHouseQueries[pipelineThroughputQueryName]; ok {
+		return q.matchResults, nil, nil
+	}
+	return q.processorResults, nil, nil
+}
+
+func (q *metricsQuerier) QueriesExecuted() []string {
+	return nil
+}
+
+func TestGetPipelinesMetrics(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	series := func(processor string, rates ...float64) *v3.Series {
+		points := []v3.Point{}
+		for i, rate := range rates {
+			points = append(points, v3.Point{Timestamp: int64(i) * 60000, Value: rate})
+		}
+		return &v3.Series{Labels: map[string]string{"processor": processor}, Points: points}
+	}
+	querier := &metricsQuerier{
+		processorResults: []*v3.Result{
+			{QueryName: "A", Series: []*v3.Series{series("logstransform/pipeline_nginx", 10, 5)}},
+			{QueryName: "B", Series: []*v3.Series{series("logstransform/pipeline_nginx", 1)}},
+			{QueryName: "C", Series: []*v3.Series{series("logstransform/pipeline_nginx", 2)}},
+		},
+		matchResults: []*v3.Result{{QueryName: pipelineThroughputQueryName, Series: []*v3.Series{
+			{Labels: map[string]string{pipelineThroughputLabel: pipelineCostQueryName(0)}, Points: []v3.Point{{Value: 450}}},
+			{Labels: map[string]string{pipelineThroughputLabel: totalLogsCostQueryName}, Points: []v3.Point{{Value: 900}}},
+		}}},
+	}
+	pipelines := []Pipeline{{Id: "1", Alias: "nginx"}, {Id: "2", Alias: "unreported"}}
+
+	controller := &LogParsingPipelineController{}
+	metrics, apiErr := controller.GetPipelinesMetrics(ctx, querier, pipelines)
+	require.Nil(apiErr)
+	require.Equal(2, querier.queries)
+	require.Equal(pipelineMetricsLookbackMinutes, metrics.LookbackMinutes)
+	seconds := float64(pipelineMetricsLookbackMinutes * 60)
+	require.Equal(&PipelineMetrics{
+		ReceivedRate: 18 * 60 / seconds,
+		MatchRate:    450 / seconds,
+		DropRate:     2 * 60 / seconds,
+		ErrorRate:    1 * 60 / seconds,
+	}, metrics.Pipelines["1"])
+	require.Equal(&PipelineMetrics{}, metrics.Pipelines["2"])
+
+	// the metrics are cached for the same pipelines for a while
+	cached, apiErr := controller.GetPipelinesMetrics(ctx, querier, pipelines)
+	require.Nil(apiErr)
+	require.Equal(metrics, cached)
+	require.Equal(2, querier.queries)
+
+	_, apiErr = controller.GetPipelinesMetrics(ctx, querier, pipelines[:1])
+	require.Nil(apiErr)
+	require.Equal(4, querier.queries, "changed pipelines are queried again")
+
+	controller.metricsCache.expiresAt = time.Now().Add(-time.Second)
+	_, apiErr = controller.GetPipelinesMetrics(ctx, querier, pipelines[:1])
+	require.Nil(apiErr)
+	require.Equal(6, querier.queries, "expired metrics are queried again")
+
+	// failures are not cached
+	controller = &LogParsingPipelineController{}
+	querier.err = fmt.Errorf("failed")
+	_, apiErr = controller.GetPipelinesMetrics(ctx, querier, pipelines)
+	require.NotNil(apiErr)
+	querier.err = nil
+	_, apiErr = controller.GetPipelinesMetrics(ctx, querier, pipelines)
+	require.Nil(apiErr)
+	require.Equal(9, querier.queries)
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/vjeantet/grok"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/queryBuilderToExpr"
 	"golang.org/x/exp/slices"
@@ -14,6 +15,9 @@ import (
 // PostablePipelines are a list of user defined pielines
 type PostablePipelines struct {
 	Pipelines []PostablePipeline `json:"pipelines"`
+
+	// why the pipelines are changed, recorded on the deployed version
+	ChangeNote string `json:"changeNote,omitempty"`
 }
 
 // PostablePipeline captures user inputs in setting the pipeline
@@ -27,6 +31,30 @@ type PostablePipeline struct {
 	Enabled     bool               `json:"enabled"`
 	Filter      *v3.FilterSet      `json:"filter"`
 	Config      []PipelineOperator `json:"config"`
+
+	AgentSelector *AgentSelector `json:"agentSelector,omitempty"`
+
+	Tags       PipelineTags `json:"tags,omitempty"`
+	ChangeNote string       `json:"changeNote,omitempty"`
+}
+
+func toPostablePipeline(p Pipeline) *PostablePipeline {
+	postable := &PostablePipeline{
+		Id:      p.Id,
+		OrderId: p.OrderId,
+		Name:    p.Name,
+		Alias:   p.Alias,
+		Enabled: p.Enabled,
+		Filter:  p.Filter,
+		Config:  p.Config,
+
+		AgentSelector: p.AgentSelector,
+		Tags:          p.Tags,
+	}
+	if p.Description != nil {
+		postable.Description = *p.Description
+	}
+	return postable
 }
 
 // IsValid checks if postable pipeline has all the required params
@@ -48,6 +76,19 @@ func (p *PostablePipeline) IsValid() error {
 		return fmt.Errorf(fmt.Sprintf("filter for pipeline %v is not correct: %v", p.Name, err.Error()))
 	}
 
+	if p.AgentSelector != nil {
+		if err := p.AgentSelector.IsValid(); err != nil {
+			return fmt.Errorf("agent selector for pipeline %v is not correct: %w", p.Name, err)
+		}
+	}
+
+	if err := p.Tags.IsValid(); err != nil {
+		return fmt.Errorf("tags for pipeline %v are not correct: %w", p.Name, err)
+	}
+	if err := validateChangeNote(p.ChangeNote); err != nil {
+		return err
+	}
+
 	idUnique := map[string]struct{}{}
 	outputUnique := map[string]struct{}{}
 
@@ -85,6 +126,10 @@ func (p *PostablePipeline) IsValid() error {
 			return err
 		}
 
+		if _, err := operatorCondition(op); err != nil {
+			return err
+		}
+
 		idUnique[op.ID] = struct{}{}
 		outputUnique[op.Output] = struct{}{}
 	}
@@ -105,6 +150,9 @@ func isValidOperator(op PipelineOperator) error {
 		if op.Pattern == "" {
 			return fmt.Errorf(fmt.Sprintf("pattern of %s grok operator cannot be empty", op.ID))
 		}
+		if err := validateGrokPattern(op.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern of %s grok operator: %w", op.ID, err)
+		}
 	case "regex_parser":
 		if op.Regex == "" {
 			return fmt.Errorf(fmt.Sprintf("regex of %s regex operator cannot be empty", op.ID))
@@ -139,6 +187,9 @@ func isValidOperator(op PipelineOperator) error {
 			return fmt.Errorf(fmt.Sprintf("field of %s remove operator cannot be empty", op.ID))
 		}
 	case "trace_parser":
+		if op.Regex != "" {
+			return validateTraceParserRegex(op)
+		}
 		if op.TraceParser == nil {
 			return fmt.Errorf(fmt.Sprintf("field of %s remove operator cannot be empty", op.ID))
 		}
@@ -170,8 +221,7 @@ func isValidOperator(op PipelineOperator) error {
 		if op.ParseFrom == "" {
 			return fmt.Errorf("parse from of time parsing processor %s cannot be empty", op.ID)
 		}
-		if op.LayoutType != "epoch" && op.LayoutType != "strptime" {
-			// TODO(Raj): Maybe add support for gotime format
+		if !slices.Contains([]string{"epoch", "strptime", "gotime"}, op.LayoutType) {
 			return fmt.Errorf(
 				"invalid format type '%s' of time parsing processor %s", op.LayoutType, op.ID,
 			)
@@ -198,20 +248,59 @@ func isValidOperator(op PipelineOperator) error {
 			}
 		}
 
+		if op.LayoutType == "gotime" {
+			if err := validateGotimeLayout(op.Layout); err != nil {
+				return fmt.Errorf(
+					"invalid gotime format '%s' of time parsing processor %s: %w", op.Layout, op.ID, err,
+				)
+			}
+		}
+
 	case "severity_parser":
+		if err := validateSeverityParser(op); err != nil {
+			return err
+		}
+
+	case jsonSchemaValidatorOperator:
 		if op.ParseFrom == "" {
-			return fmt.Errorf("parse from of severity parsing processor %s cannot be empty", op.ID)
+			return fmt.Errorf("parse from of json schema validator %s cannot be empty", op.ID)
+		}
+		if op.SchemaName == "" {
+			return fmt.Errorf("schema of json schema validator %s cannot be empty", op.ID)
 		}
 
-		validMappingLevels := []string{"trace", "debug", "info", "warn", "error", "fatal"}
-		for k, _ := range op.SeverityMapping {
-			if !slices.Contains(validMappingLevels, strings.ToLower(k)) {
-				return fmt.Errorf("%s is not a valid severity in processor %s", k, op.ID)
-			}
+	case jsonFlattenOperator:
+		if err := validateJSONFlatten(op); err != nil {
+			return err
+		}
+
+	case csvParserOperator:
+		if err := validateCSVParser(op); err != nil {
+			return err
+		}
+
+	case keyValueParserOperator:
+		if err := validateKeyValueParser(op); err != nil {
+			return err
+		}
+
+	case maskOperator:
+		if err := validateMaskOperator(op); err != nil {
+			return err
+		}
+
+	case ottlOperator:
+		if err := validateOTTLOperator(op); err != nil {
+			return err
+		}
+
+	case samplerOperator:
+		if err := validateSampler(op); err != nil {
+			return err
 		}
 
 	default:
-		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, copy, move, add, remove, trace_parser, retain)", op.Type, op.ID))
+		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, csv_parser, key_value_parser, copy, move, add, remove, trace_parser, retain, json_schema_validator, json_flatten, mask, ottl, sampler)", op.Type, op.ID))
 	}
 
 	if !isValidOtelValue(op.ParseFrom) ||
@@ -225,6 +314,18 @@ func isValidOperator(op PipelineOperator) error {
 	return nil
 }
 
+// validateGrokPattern compiles the pattern the same way the collector's grok
+// parser does, so that unknown patterns or invalid regexes are reported
+// when saving pipelines instead of failing the collector config
+func validateGrokPattern(pattern string) error {
+	g, err := grok.NewWithConfig(&grok.Config{NamedCapturesOnly: true})
+	if err != nil {
+		return err
+	}
+	_, err = g.Match(pattern, "")
+	return err
+}
+
 func isValidOtelValue(val string) bool {
 	if val == "" {
 		return true
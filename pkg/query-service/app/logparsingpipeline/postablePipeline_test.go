@@ -231,6 +231,26 @@ var operatorTest = []struct {
 		},
 		IsValid: false,
 	},
+	{
+		Name: "Grok - unknown pattern",
+		Operator: PipelineOperator{
+			ID:      "grok",
+			Type:    "grok_parser",
+			Pattern: "%{NOTAPATTERN:field} %{GREEDYDATA:message}",
+			ParseTo: "attributes",
+		},
+		IsValid: false,
+	},
+	{
+		Name: "Grok - invalid regex",
+		Operator: PipelineOperator{
+			ID:      "grok",
+			Type:    "grok_parser",
+			Pattern: "%{IP:client} (unclosed",
+			ParseTo: "attributes",
+		},
+		IsValid: false,
+	},
 	{
 		Name: "Regex - valid",
 		Operator: PipelineOperator{
@@ -296,15 +316,34 @@ var operatorTest = []struct {
 		},
 		IsValid: false,
 	}, {
-		Name: "Timestamp Parser - unsupported layout_type",
+		Name: "Timestamp Parser - gotime layout",
 		Operator: PipelineOperator{
-			ID:        "time",
-			Type:      "time_parser",
-			ParseFrom: "attributes.test_timestamp",
-			// TODO(Raj): Maybe add support for gotime format
+			ID:         "time",
+			Type:       "time_parser",
+			ParseFrom:  "attributes.test_timestamp",
 			LayoutType: "gotime",
 			Layout:     "Mon Jan 2 15:04:05 -0700 MST 2006",
 		},
+		IsValid: true,
+	}, {
+		Name: "Timestamp Parser - gotime layout without reference time elements",
+		Operator: PipelineOperator{
+			ID:         "time",
+			Type:       "time_parser",
+			ParseFrom:  "attributes.test_timestamp",
+			LayoutType: "gotime",
+			Layout:     "%Y-%m-%d",
+		},
+		IsValid: false,
+	}, {
+		Name: "Timestamp Parser - unsupported layout_type",
+		Operator: PipelineOperator{
+			ID:         "time",
+			Type:       "time_parser",
+			ParseFrom:  "attributes.test_timestamp",
+			LayoutType: "native",
+			Layout:     "2006-01-02",
+		},
 		IsValid: false,
 	}, {
 		Name: "Timestamp Parser - invalid epoch layout",
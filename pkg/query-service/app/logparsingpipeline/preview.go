@@ -28,6 +28,10 @@ func SimulatePipelinesProcessing(
 		return logs, nil, nil
 	}
 
+	if err := checkPipelinesPreview(pipelines); err != nil {
+		return nil, nil, model.BadRequest(err)
+	}
+
 	// Collector simulation does not guarantee that logs will come
 	// out in the same order as in the input.
 	//
@@ -43,6 +47,7 @@ func SimulatePipelinesProcessing(
 
 	processorFactories, err := processor.MakeFactoryMap(
 		logstransformprocessor.NewFactory(),
+		newTransformPreviewFactory(),
 	)
 	if err != nil {
 		return nil, nil, model.InternalError(errors.Wrap(
@@ -56,7 +61,12 @@ func SimulatePipelinesProcessing(
 	// the number of logtransformprocessors involved.
 	// See defaultFlushInterval at https://github.com/open-telemetry/opentelemetry-collector-contrib/blob/main/pkg/stanza/adapter/emitter.go
 	// TODO(Raj): Remove this after flushInterval is exposed in logtransformprocessor config
-	timeout := time.Millisecond * time.Duration(len(pipelines)*100+100)
+	// Pipelines with ottl operators translate to several logtransformprocessors.
+	stanzaProcessors := 0
+	for _, p := range pipelines {
+		stanzaProcessors += stanzaProcessorCount(p.Config)
+	}
+	timeout := time.Millisecond * time.Duration(stanzaProcessors*100+100)
 
 	configGenerator := func(baseConf []byte) ([]byte, error) {
 		updatedConf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, pipelines)
@@ -0,0 +1,219 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp/syntax"
+)
+
+// programs larger than this take too long to compile and match on every
+// collector, they usually come from nested counted repetitions
+const maxRegexProgramSize = 5000
+
+// checkRegexBacktracking finds regexes whose worst case is exponential in
+// backtracking regex engines, i.e. quantified expressions whose body can
+// match the same text in more than one way like (a+)+ or (\w+\s?)*, and
+// regexes compiling to very large programs. The collectors use RE2 and
+// match in linear time, so those regexes are only reported as warnings,
+// rejecting them would also block saving pipelines stored before the check.
+func checkRegexBacktracking(expr string) error {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return err
+	}
+
+	if nested := findAmbiguousLoop(re); nested != nil {
+		return fmt.Errorf(
+			"%s repeats an expression that can itself repeat, which has exponential worst case backtracking",
+			nested,
+		)
+	}
+
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return err
+	}
+	if len(prog.Inst) > maxRegexProgramSize {
+		return fmt.Errorf(
+			"regex compiles to %d instructions, more than the limit of %d", len(prog.Inst), maxRegexProgramSize,
+		)
+	}
+	return nil
+}
+
+// checkGrokBacktracking checks the regex written around the grok pattern
+// references, each reference standing for a non empty wildcard
+func checkGrokBacktracking(pattern string) error {
+	return checkRegexBacktracking(grokPatternRef.ReplaceAllLiteralString(pattern, "(?:.+)"))
+}
+
+// regexWarnings reports the parts of a regex which are slow to match
+func regexWarnings(expr string) []string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	warnings := []string{}
+	if err := checkRegexBacktracking(expr); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	walkRegex(re, func(r *syntax.Regexp) {
+		if r.Op != syntax.OpConcat {
+			return
+		}
+		// wildcards only separated by optional parts can split the text
+		// between them in many ways
+		var previous *syntax.Regexp
+		for _, sub := range r.Sub {
+			if isUnboundedWildcard(sub) {
+				if previous != nil {
+					warnings = append(warnings, fmt.Sprintf(
+						"wildcards %s and %s are adjacent, which makes matching slow on long logs", previous, sub,
+					))
+				}
+				previous = sub
+			} else if !canMatchEmpty(sub) {
+				previous = nil
+			}
+		}
+	})
+	return warnings
+}
+
+// detectRegexWarnings collects the warnings about the regexes of the
+// enabled operators of enabled pipelines
+func detectRegexWarnings(pipelines []Pipeline) []string {
+	warnings := []string{}
+	for _, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+		for _, op := range p.Config {
+			if !op.Enabled {
+				continue
+			}
+			if op.Regex != "" {
+				for _, w := range regexWarnings(op.Regex) {
+					warnings = append(warnings, fmt.Sprintf(
+						"regex of operator %s in pipeline %s: %s", op.Name, p.Name, w,
+					))
+				}
+			}
+			if op.Type == "grok_parser" && op.Pattern != "" {
+				if err := checkGrokBacktracking(op.Pattern); err != nil {
+					warnings = append(warnings, fmt.Sprintf(
+						"pattern of operator %s in pipeline %s: %s", op.Name, p.Name, err,
+					))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+func walkRegex(r *syntax.Regexp, visit func(*syntax.Regexp)) {
+	visit(r)
+	for _, sub := range r.Sub {
+		walkRegex(sub, visit)
+	}
+}
+
+// findAmbiguousLoop returns the first unbounded repetition whose body is
+// itself an unbounded repetition, once the parts of the body which can
+// match nothing are left out
+func findAmbiguousLoop(re *syntax.Regexp) *syntax.Regexp {
+	var found *syntax.Regexp
+	walkRegex(re, func(r *syntax.Regexp) {
+		if found == nil && isUnbounded(r) && repeatsUnbounded(r.Sub[0]) {
+			found = r
+		}
+	})
+	return found
+}
+
+func repeatsUnbounded(r *syntax.Regexp) bool {
+	r = uncaptured(r)
+	switch r.Op {
+	case syntax.OpConcat:
+		var required []*syntax.Regexp
+		for _, sub := range r.Sub {
+			if !canMatchEmpty(sub) {
+				required = append(required, sub)
+			}
+		}
+		if len(required) == 0 {
+			// every part is optional, any repeating one is ambiguous
+			for _, sub := range r.Sub {
+				if repeatsUnbounded(sub) {
+					return true
+				}
+			}
+			return false
+		}
+		return len(required) == 1 && repeatsUnbounded(required[0])
+	case syntax.OpAlternate:
+		for _, sub := range r.Sub {
+			if repeatsUnbounded(sub) {
+				return true
+			}
+		}
+		return false
+	case syntax.OpQuest:
+		return repeatsUnbounded(r.Sub[0])
+	}
+	return isUnbounded(r)
+}
+
+func isUnbounded(r *syntax.Regexp) bool {
+	switch r.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return r.Max == -1
+	}
+	return false
+}
+
+func isUnboundedWildcard(r *syntax.Regexp) bool {
+	r = uncaptured(r)
+	if !isUnbounded(r) {
+		return false
+	}
+	sub := uncaptured(r.Sub[0])
+	return sub.Op == syntax.OpAnyChar || sub.Op == syntax.OpAnyCharNotNL
+}
+
+func canMatchEmpty(r *syntax.Regexp) bool {
+	switch r.Op {
+	case syntax.OpEmptyMatch, syntax.OpStar, syntax.OpQuest,
+		syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	case syntax.OpRepeat:
+		return r.Min == 0 || canMatchEmpty(r.Sub[0])
+	case syntax.OpCapture, syntax.OpPlus:
+		return canMatchEmpty(r.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range r.Sub {
+			if !canMatchEmpty(sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		for _, sub := range r.Sub {
+			if canMatchEmpty(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func uncaptured(r *syntax.Regexp) *syntax.Regexp {
+	for r.Op == syntax.OpCapture {
+		r = r.Sub[0]
+	}
+	return r
+}
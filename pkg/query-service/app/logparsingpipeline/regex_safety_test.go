@@ -0,0 +1,56 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexBacktrackingCheck(t *testing.T) {
+	require := require.New(t)
+
+	unsafe := []string{
+		`^(a+)+$`,
+		`(\w+\s?)*$`,
+		`^(.*)*x`,
+		`(?P<words>(\w*)+)`,
+		`(x|(\d+))+`,
+		`((a{1,100}){1,100}){1,10}`,
+	}
+	for _, expr := range unsafe {
+		require.NotNil(checkRegexBacktracking(expr), expr)
+	}
+
+	safe := []string{
+		`^(?P<ip>(\d+\.)+\d+) (?P<method>\w+)`,
+		`(?P<ts>\d{4}-\d{2}-\d{2}) (?P<msg>.*)`,
+		`(?P<kv>(\w+=\w+;)*)`,
+	}
+	for _, expr := range safe {
+		require.Nil(checkRegexBacktracking(expr), expr)
+	}
+
+	require.NotNil(checkGrokBacktracking(`(%{WORD} ?)+`))
+	require.Nil(checkGrokBacktracking(`%{IP:ip} (%{WORD:word} )+%{GREEDYDATA:rest}`))
+
+	// RE2 matches in linear time, unsafe regexes are only warned about so
+	// that pipelines saved before the check can still be saved
+	op := PipelineOperator{
+		ID: "regex", Type: "regex_parser", Regex: `^(?P<words>(\w+\s?)+)$`, ParseFrom: "body",
+		Enabled: true, Name: "words",
+	}
+	require.Nil(isValidOperator(op))
+	grok := PipelineOperator{
+		ID: "grok", Type: "grok_parser", Pattern: `(%{WORD} ?)+`, ParseFrom: "body",
+		Enabled: true, Name: "grok",
+	}
+	require.Nil(isValidOperator(grok))
+	warnings := detectRegexWarnings([]Pipeline{{Name: "legacy", Enabled: true, Config: []PipelineOperator{op, grok}}})
+	require.Len(warnings, 2)
+	require.Contains(warnings[0], "regex of operator words in pipeline legacy")
+	require.Contains(warnings[0], "exponential")
+	require.Contains(warnings[1], "pattern of operator grok in pipeline legacy")
+
+	require.Len(regexWarnings(`(?P<a>.*) ?.*`), 1)
+	require.Empty(regexWarnings(`(?P<a>.*) (?P<b>.*)`))
+}
@@ -0,0 +1,34 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	samplerOperator = "sampler"
+
+	// stanza drops logs in steps of a thousandth
+	minDropPercent = 0.1
+)
+
+func validateSampler(op PipelineOperator) error {
+	if op.DropPercent < minDropPercent || op.DropPercent > 100 {
+		return fmt.Errorf(
+			"drop percent of sampler %s must be between %v and 100", op.ID, minDropPercent,
+		)
+	}
+	return nil
+}
+
+// prepareSampler translates a sampler to a stanza filter operator dropping
+// the given percentage of the logs matching condition. Filter operators
+// don't support `if`, the logs to sample are selected by their expr.
+func prepareSampler(operator *PipelineOperator, condition string) {
+	operator.Type = "filter"
+	operator.Expr = condition
+	if operator.Expr == "" {
+		operator.Expr = "true"
+	}
+	operator.DropRatio = math.Round(operator.DropPercent*10) / 1000
+}
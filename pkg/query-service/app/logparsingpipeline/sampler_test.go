@@ -0,0 +1,77 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestSampler(t *testing.T) {
+	require := require.New(t)
+
+	sampler := PipelineOperator{
+		OrderId: 1, ID: "sample", Type: samplerOperator, Enabled: true, Name: "drop debug logs",
+		Condition: `attributes.level == "debug"`, DropPercent: 100,
+	}
+	require.Nil(isValidOperator(sampler))
+	for _, percent := range []float64{0, 0.05, 101} {
+		invalid := sampler
+		invalid.DropPercent = percent
+		require.NotNil(isValidOperator(invalid), percent)
+	}
+
+	sampled := sampler
+	sampled.DropPercent = 25
+	operators, err := getOperators([]PipelineOperator{sampled})
+	require.Nil(err)
+	require.Equal("filter", operators[0].Type)
+	require.Equal(`attributes.level == "debug"`, operators[0].Expr)
+	require.Equal(0.25, operators[0].DropRatio)
+	require.Empty(operators[0].If, "filter operators select the logs to drop with expr")
+
+	unconditional := sampled
+	unconditional.Condition = ""
+	operators, err = getOperators([]PipelineOperator{unconditional})
+	require.Nil(err)
+	require.Equal("true", operators[0].Expr)
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "checkout",
+					},
+				},
+			},
+			Config: []PipelineOperator{sampler},
+		},
+	}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, []model.SignozLog{
+			makeTestSignozLog("cart loaded", map[string]interface{}{"service": "checkout", "level": "debug"}),
+			makeTestSignozLog("payment failed", map[string]interface{}{"service": "checkout", "level": "error"}),
+			makeTestSignozLog("query plan", map[string]interface{}{"service": "search", "level": "debug"}),
+		},
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(2, len(result), "only debug logs of the pipeline should be dropped")
+	require.Equal("payment failed", result[0].Body)
+	require.Equal("query plan", result[1].Body)
+}
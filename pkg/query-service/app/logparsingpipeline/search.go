@@ -0,0 +1,27 @@
+package logparsingpipeline
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ListSearchable returns the pipelines of the latest version. Implements
+// search.Source
+func (ic *LogParsingPipelineController) ListSearchable(ctx context.Context) ([]search.Item, *model.ApiError) {
+	_, pipelines, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []search.Item{}
+	for _, p := range pipelines {
+		item := search.Item{Id: p.Id, Name: p.Name, Labels: append([]string{p.Alias}, p.Tags...)}
+		if p.Description != nil {
+			item.Description = *p.Description
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,173 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// severityParserTempField holds the value parsed by severity parsers
+const severityParserTempField = "attributes.signoz_severity"
+
+// severityLevels are the OTel severity levels values can be mapped to,
+// from trace (1) to fatal4 (24)
+var severityLevels = func() []string {
+	levels := []string{}
+	for _, name := range []string{"trace", "debug", "info", "warn", "error", "fatal"} {
+		levels = append(levels, name, name+"2", name+"3", name+"4")
+	}
+	return levels
+}()
+
+// severityDictionaries are mappings for common level conventions which can
+// be used by severity parsers instead of listing every value. Mappings of
+// the operator take precedence over the dictionary.
+var severityDictionaries = map[string]map[string][]string{
+	// https://opentelemetry.io/docs/specs/otel/logs/data-model-appendix/#appendix-b-severitynumber-example-mappings
+	"syslog": {
+		"debug":  {"7", "debug"},
+		"info":   {"6", "informational"},
+		"info2":  {"5", "notice"},
+		"warn":   {"4", "warning"},
+		"error":  {"3", "err", "error"},
+		"error2": {"2", "crit", "critical"},
+		"error3": {"1", "alert"},
+		"fatal":  {"0", "emerg", "emergency", "panic"},
+	},
+	"python": {
+		"debug": {"10", "debug"},
+		"info":  {"20", "info"},
+		"warn":  {"30", "warning", "warn"},
+		"error": {"40", "error", "exception"},
+		"fatal": {"50", "critical", "fatal"},
+	},
+	"windows": {
+		"debug": {"5", "verbose"},
+		"info":  {"4", "information"},
+		"warn":  {"3", "warning"},
+		"error": {"2", "error"},
+		"fatal": {"1", "critical"},
+	},
+}
+
+func validateSeverityParser(op PipelineOperator) error {
+	if op.ParseFrom == "" {
+		return fmt.Errorf("parse from of severity parsing processor %s cannot be empty", op.ID)
+	}
+
+	if op.SeverityDictionary != "" {
+		if _, ok := severityDictionaries[op.SeverityDictionary]; !ok {
+			names := []string{}
+			for name := range severityDictionaries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf(
+				"unknown severity dictionary %s in processor %s, must be one of %v",
+				op.SeverityDictionary, op.ID, names,
+			)
+		}
+	}
+
+	// values are matched case insensitively, so a value can't be listed
+	// for two levels even with different cases
+	levelOfValue := map[string]string{}
+	for level, values := range op.SeverityMapping {
+		if !slices.Contains(severityLevels, strings.ToLower(level)) {
+			return fmt.Errorf("%s is not a valid severity in processor %s", level, op.ID)
+		}
+		for _, v := range values {
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("empty value mapped to %s in processor %s", level, op.ID)
+			}
+			key := strings.ToLower(v)
+			if other, ok := levelOfValue[key]; ok && other != strings.ToLower(level) {
+				return fmt.Errorf(
+					"%s is mapped to both %s and %s in processor %s", v, other, level, op.ID,
+				)
+			}
+			levelOfValue[key] = strings.ToLower(level)
+		}
+	}
+	return nil
+}
+
+// prepareSeverityParser merges the dictionary of a severity parser into its
+// mapping, leaving out the dictionary values the mapping overrides
+func prepareSeverityParser(operator *PipelineOperator) {
+	dictionary, ok := severityDictionaries[operator.SeverityDictionary]
+	operator.SeverityDictionary = ""
+	if !ok {
+		return
+	}
+
+	overridden := map[string]struct{}{}
+	for _, values := range operator.SeverityMapping {
+		for _, v := range values {
+			overridden[strings.ToLower(v)] = struct{}{}
+		}
+	}
+
+	// the mapping is shared with the stored pipeline, so a new one is built
+	mapping := map[string][]string{}
+	for level, values := range dictionary {
+		for _, v := range values {
+			if _, ok := overridden[v]; !ok {
+				mapping[level] = append(mapping[level], v)
+			}
+		}
+	}
+	for level, values := range operator.SeverityMapping {
+		level = strings.ToLower(level)
+		mapping[level] = append(mapping[level], values...)
+	}
+	operator.SeverityMapping = mapping
+}
+
+// prepareSeverityParserInput copies the value to parse into a temp field the
+// severity parser reads from. stanza can't parse severities from int64
+// values, which is what int attributes come in as, so ints are copied as
+// strings. Values which aren't strings or whole numbers are left alone.
+// The operator becomes the copy, the returned operators parse the severity
+// and remove the temp field.
+func prepareSeverityParserInput(operator *PipelineOperator) (*PipelineOperator, *PipelineOperator, error) {
+	parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+	tempFieldNotNilCheck, err := fieldNotNilCheck(severityParserTempField)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	severity := *operator
+	severity.ID = fmt.Sprintf("%s_severity", operator.ID)
+	severity.ParseFrom = severityParserTempField
+	severity.If = tempFieldNotNilCheck
+	cleanup := &PipelineOperator{
+		Type:    "remove",
+		ID:      fmt.Sprintf("%s_cleanup", operator.ID),
+		OrderId: operator.OrderId,
+		Enabled: operator.Enabled,
+		Name:    operator.Name,
+		Field:   severityParserTempField,
+		If:      tempFieldNotNilCheck,
+	}
+
+	parseFrom := operator.ParseFrom
+	operator.Type = "add"
+	operator.Field = severityParserTempField
+	operator.Value = fmt.Sprintf(`EXPR(type(%s) == "int" ? string(%s) : %s)`, parseFrom, parseFrom, parseFrom)
+	operator.If = fmt.Sprintf(
+		`%s && ( type(%s) == "string" || type(%s) == "int" || ( type(%s) == "float" && %s == float(int(%s)) ) )`,
+		parseFromNotNilCheck, parseFrom, parseFrom, parseFrom, parseFrom, parseFrom,
+	)
+	operator.ParseFrom = ""
+	operator.SeverityMapping = nil
+	operator.OverwriteSeverityText = false
+	operator.Output = severity.ID
+	severity.Output = cleanup.ID
+	return &severity, cleanup, nil
+}
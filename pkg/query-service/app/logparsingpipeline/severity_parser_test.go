@@ -219,3 +219,100 @@ func TestNoCollectorErrorsFromSeverityParserForMismatchedLogs(t *testing.T) {
 		require.Equal(1, len(result))
 	}
 }
+
+func TestSeverityParserDictionaries(t *testing.T) {
+	require := require.New(t)
+
+	severityParserOp := PipelineOperator{
+		OrderId:            1,
+		ID:                 "severity",
+		Type:               "severity_parser",
+		Enabled:            true,
+		Name:               "syslog severity",
+		ParseFrom:          "attributes.level",
+		SeverityDictionary: "syslog",
+		SeverityMapping: map[string][]string{
+			"warn2": {"notice"},
+		},
+		OverwriteSeverityText: true,
+	}
+	require.Nil(isValidOperator(severityParserOp))
+
+	invalid := severityParserOp
+	invalid.SeverityDictionary = "cobol"
+	require.NotNil(isValidOperator(invalid), "unknown dictionaries should be rejected")
+
+	invalid = severityParserOp
+	invalid.SeverityMapping = map[string][]string{
+		"warn":  {"WARNING"},
+		"error": {"warning"},
+	}
+	require.NotNil(isValidOperator(invalid), "values mapped to two levels should be rejected")
+
+	testPipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "method",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "GET",
+					},
+				},
+			},
+			Config: []PipelineOperator{severityParserOp},
+		},
+	}
+
+	testCases := []struct {
+		level                  interface{}
+		expectedSeverityText   string
+		expectedSeverityNumber uint8
+	}{
+		{"crit", "ERROR2", 18},
+		{"2", "ERROR2", 18},
+		{2, "ERROR2", 18},
+		{7, "DEBUG", 5},
+		// values which aren't whole numbers are left alone, keeping the
+		// severity logs come with
+		{4.5, "INFO", 9},
+		{"Emergency", "FATAL", 21},
+		{4.0, "WARN", 13},
+		{"notice", "WARN2", 14},
+	}
+
+	for _, testCase := range testCases {
+		result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+			context.Background(),
+			testPipelines,
+			[]model.SignozLog{makeTestSignozLog("test log", map[string]interface{}{
+				"method": "GET",
+				"level":  testCase.level,
+			})},
+		)
+		require.Nil(err)
+		require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+		require.Equal(1, len(result))
+		require.Equal(testCase.expectedSeverityNumber, result[0].SeverityNumber, testCase.level)
+		require.Equal(testCase.expectedSeverityText, result[0].SeverityText, testCase.level)
+		require.NotContains(result[0].Attributes_string, "signoz_severity", "the temp field should be removed")
+		if level, ok := testCase.level.(int); ok {
+			require.Equal(int64(level), result[0].Attributes_int64["level"], "the parsed value should be kept")
+		}
+	}
+
+	require.Equal("syslog", testPipelines[0].Config[0].SeverityDictionary)
+	require.Equal(
+		map[string][]string{"warn2": {"notice"}}, testPipelines[0].Config[0].SeverityMapping,
+		"preparing collector config should not change the pipeline",
+	)
+}
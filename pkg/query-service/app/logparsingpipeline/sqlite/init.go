@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -31,5 +32,94 @@ func InitDB(db *sqlx.DB) error {
 	if err != nil {
 		return errors.Wrap(err, "Error in creating pipelines table")
 	}
+
+	// sqlite does not support "IF NOT EXISTS"
+	_, err = db.Exec(`ALTER TABLE pipelines ADD COLUMN agent_selector TEXT;`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return errors.Wrap(err, "Error in adding column agent_selector to pipelines table")
+	}
+
+	for _, alter := range []string{
+		`ALTER TABLE pipelines ADD COLUMN tags TEXT;`,
+		`ALTER TABLE pipelines ADD COLUMN change_note TEXT;`,
+		`ALTER TABLE pipelines ADD COLUMN updated_by TEXT;`,
+		`ALTER TABLE pipelines ADD COLUMN updated_at TIMESTAMP;`,
+	} {
+		_, err = db.Exec(alter)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return errors.Wrap(err, "Error in adding ownership columns to pipelines table")
+		}
+	}
+	// pipelines stored before they had updaters were last updated when created
+	_, err = db.Exec(`UPDATE pipelines SET updated_by = created_by, updated_at = created_at
+		WHERE updated_at IS NULL;`)
+	if err != nil {
+		return errors.Wrap(err, "Error in setting updaters of pipelines")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS deleted_pipelines(
+		pipeline_id TEXT PRIMARY KEY,
+		alias TEXT NOT NULL,
+		deleted_by TEXT,
+		deleted_at TIMESTAMP NOT NULL
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating deleted pipelines table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pipeline_variables(
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating pipeline variables table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS log_json_schemas(
+		name TEXT PRIMARY KEY,
+		schema_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating log json schemas table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pipeline_watchdog_settings(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		settings_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating pipeline watchdog settings table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pipeline_watchdog_events(
+		id TEXT PRIMARY KEY,
+		pipeline_id TEXT NOT NULL,
+		pipeline_name TEXT NOT NULL,
+		pipeline_alias TEXT NOT NULL,
+		paused_pipeline_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		drop_rate_percent REAL NOT NULL,
+		dropped REAL NOT NULL,
+		received REAL NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating pipeline watchdog events table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pipeline_deployments(
+		version INTEGER PRIMARY KEY,
+		changes_json TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return errors.Wrap(err, "Error in creating pipeline deployments table")
+	}
 	return nil
 }
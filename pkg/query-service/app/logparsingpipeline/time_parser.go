@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Regex for strptime format placeholders supported by the time parser.
@@ -118,3 +121,113 @@ func RegexForStrptimeLayout(layout string) (string, error) {
 
 	return layoutRegex, nil
 }
+
+// Regex for elements of golang reference time layouts, checked in order at
+// each position of a layout like time.Parse does, so longer elements come
+// before the ones they start with.
+var gotimeRegex = []struct {
+	element string
+	regex   string
+}{
+	{"January", "[a-zA-Z]+"},
+	{"Monday", "[a-zA-Z]+"},
+	{"Jan", "[a-zA-Z]{3}"},
+	{"Mon", "[a-zA-Z]{3}"},
+	{"MST", "[a-zA-Z]+"},
+	{"2006", "[0-9]{4}"},
+	{"Z07:00:00", "(Z|[-+][0-9]{2}:[0-9]{2}:[0-9]{2})"},
+	{"Z070000", "(Z|[-+][0-9]{6})"},
+	{"Z07:00", "(Z|[-+][0-9]{2}:[0-9]{2})"},
+	{"Z0700", "(Z|[-+][0-9]{4})"},
+	{"Z07", "(Z|[-+][0-9]{2})"},
+	{"-07:00:00", "[-+][0-9]{2}:[0-9]{2}:[0-9]{2}"},
+	{"-070000", "[-+][0-9]{6}"},
+	{"-07:00", "[-+][0-9]{2}:[0-9]{2}"},
+	{"-0700", "[-+][0-9]{4}"},
+	{"-07", "[-+][0-9]{2}"},
+	{"__2", "[ 0-9]{2}[0-9]"},
+	{"002", "[0-9]{3}"},
+	{"_2", "[ 0-9][0-9]"},
+	{"01", "[0-9]{2}"},
+	{"02", "[0-9]{2}"},
+	{"03", "[0-9]{2}"},
+	{"04", "[0-9]{2}"},
+	{"05", "[0-9]{2}"},
+	{"06", "[0-9]{2}"},
+	{"15", "[0-9]{2}"},
+	{"PM", "(AM|PM)"},
+	{"pm", "(am|pm)"},
+	{"1", "[0-9]{1,2}"},
+	{"2", "[0-9]{1,2}"},
+	{"3", "[0-9]{1,2}"},
+	{"4", "[0-9]{1,2}"},
+	{"5", "[0-9]{1,2}"},
+}
+
+// gotimeFractionalSecond matches the fractional seconds elements of golang
+// layouts, like .000 or ,999
+var gotimeFractionalSecond = regexp.MustCompile(`^[.,](0+|9+)`)
+
+func RegexForGotimeLayout(layout string) (string, error) {
+	var layoutRegex strings.Builder
+	elementCount := 0
+
+	for i := 0; i < len(layout); {
+		if frac := gotimeFractionalSecond.FindString(layout[i:]); frac != "" &&
+			(i+len(frac) == len(layout) || !unicode.IsDigit(rune(layout[i+len(frac)]))) {
+			if frac[1] == '0' {
+				layoutRegex.WriteString(fmt.Sprintf("[.,][0-9]{%d}", len(frac)-1))
+			} else {
+				layoutRegex.WriteString("([.,][0-9]+)?")
+			}
+			i += len(frac)
+			elementCount++
+			continue
+		}
+
+		matched := false
+		for _, e := range gotimeRegex {
+			if !strings.HasPrefix(layout[i:], e.element) {
+				continue
+			}
+			layoutRegex.WriteString(e.regex)
+			i += len(e.element)
+			elementCount++
+			matched = true
+
+			// golang accepts fractional seconds following seconds even if the
+			// layout doesn't have them
+			if (e.element == "05" || e.element == "5") && gotimeFractionalSecond.FindString(layout[i:]) == "" {
+				layoutRegex.WriteString("([.,][0-9]+)?")
+			}
+			break
+		}
+		if !matched {
+			r, size := utf8.DecodeRuneInString(layout[i:])
+			// escaped like strptime layout regex, for use in expr string literals
+			layoutRegex.WriteString(strings.ReplaceAll(regexp.QuoteMeta(string(r)), `\`, `\\`))
+			i += size
+		}
+	}
+
+	if elementCount == 0 {
+		return "", fmt.Errorf(
+			"layout %s has no elements of the reference time Mon Jan 2 15:04:05 MST 2006", layout,
+		)
+	}
+	return layoutRegex.String(), nil
+}
+
+// validateGotimeLayout ensures values formatted with a golang layout can be
+// parsed back with it
+func validateGotimeLayout(layout string) error {
+	if _, err := RegexForGotimeLayout(layout); err != nil {
+		return err
+	}
+
+	reference := time.Date(2006, time.January, 2, 15, 4, 5, 123456789, time.FixedZone("MST", -7*3600))
+	if _, err := time.Parse(layout, reference.Format(layout)); err != nil {
+		return fmt.Errorf("couldn't parse timestamps formatted with layout %s: %w", layout, err)
+	}
+	return nil
+}
@@ -134,3 +134,129 @@ func TestTimestampParsingProcessor(t *testing.T) {
 	require.Equal(uint64(expectedTimestamp.UnixNano()), processed.Timestamp)
 
 }
+
+func TestGotimeTimestampParsing(t *testing.T) {
+	require := require.New(t)
+
+	timestampParserOp := PipelineOperator{
+		OrderId:    1,
+		Enabled:    true,
+		Type:       "time_parser",
+		Name:       "Test gotime timestamp parser",
+		ID:         "test-gotime-timestamp-parser",
+		ParseFrom:  "attributes.test_timestamp",
+		LayoutType: "gotime",
+		Layout:     "Jan _2 2006 15:04:05.000 -0700",
+	}
+	testPipelines := []Pipeline{{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "method",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "GET",
+				},
+			},
+		},
+		Config: []PipelineOperator{timestampParserOp},
+	}}
+
+	testTimestampStr := "Nov  7 2023 12:03:28.239 +0530"
+	testLogs := []model.SignozLog{
+		makeTestSignozLog("test log", map[string]interface{}{
+			"method":         "GET",
+			"test_timestamp": testTimestampStr,
+		}),
+		makeTestSignozLog("test log with mismatching timestamp", map[string]interface{}{
+			"method":         "GET",
+			"test_timestamp": "2023-11-07 12:03:28",
+		}),
+	}
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), testPipelines, testLogs,
+	)
+	require.Nil(apiErr)
+	require.Equal(2, len(result))
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+
+	expectedTimestamp, err := time.Parse("Jan _2 2006 15:04:05.000 -0700", testTimestampStr)
+	require.Nil(err)
+	require.Equal(uint64(expectedTimestamp.UnixNano()), result[0].Timestamp)
+	require.Equal(testLogs[1].Timestamp, result[1].Timestamp)
+}
+
+func TestSuggestTimestampLayouts(t *testing.T) {
+	require := require.New(t)
+
+	var testCases = []struct {
+		samples            []string
+		expectedLayoutType string
+		expectedLayout     string
+	}{
+		{
+			samples:            []string{"2023-11-27T12:03:28.239907Z", "2023-11-27T12:03:29.1Z"},
+			expectedLayoutType: "strptime",
+			expectedLayout:     "%Y-%m-%dT%H:%M:%S.%fZ",
+		}, {
+			samples:            []string{"2023-11-27T12:03:28+05:30"},
+			expectedLayoutType: "strptime",
+			expectedLayout:     "%Y-%m-%dT%H:%M:%S%j",
+		}, {
+			samples:            []string{"2023-11-27 12:03:28,239"},
+			expectedLayoutType: "strptime",
+			expectedLayout:     "%Y-%m-%d %H:%M:%S,%L",
+		}, {
+			samples:            []string{"27/Nov/2023:12:03:28 +0000"},
+			expectedLayoutType: "strptime",
+			expectedLayout:     "%d/%b/%Y:%H:%M:%S %z",
+		}, {
+			samples:            []string{"Nov  7 12:03:28", "Nov 17 12:03:28"},
+			expectedLayoutType: "gotime",
+			expectedLayout:     "Jan _2 15:04:05",
+		}, {
+			samples:            []string{"1700000000123"},
+			expectedLayoutType: "epoch",
+			expectedLayout:     "ms",
+		}, {
+			samples:            []string{"1700000000.123456"},
+			expectedLayoutType: "epoch",
+			expectedLayout:     "s.us",
+		}, {
+			// the layout parsing most of the samples comes first
+			samples:            []string{"2023-11-27 12:03:28", "2023-11-27 12:03:29", "1700000000"},
+			expectedLayoutType: "strptime",
+			expectedLayout:     "%Y-%m-%d %H:%M:%S",
+		},
+	}
+
+	for _, test := range testCases {
+		suggestions, err := SuggestTimestampLayouts(test.samples)
+		require.Nil(err)
+		require.NotEmpty(suggestions, test.samples)
+		require.Equal(test.expectedLayoutType, suggestions[0].LayoutType, test.samples)
+		require.Equal(test.expectedLayout, suggestions[0].Layout, test.samples)
+	}
+
+	suggestions, err := SuggestTimestampLayouts([]string{"1700000000", "not a timestamp"})
+	require.Nil(err)
+	require.Equal(1, suggestions[0].Matched)
+	require.Equal(int64(1700000000), suggestions[0].Parsed[0].Unix())
+	require.Nil(suggestions[0].Parsed[1])
+
+	suggestions, err = SuggestTimestampLayouts([]string{"not a timestamp"})
+	require.Nil(err)
+	require.Empty(suggestions)
+
+	_, err = SuggestTimestampLayouts([]string{})
+	require.NotNil(err)
+}
@@ -0,0 +1,172 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/entry"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/helper"
+)
+
+const maxTimestampSamples = 100
+
+// timestampLayoutCandidates are the layouts suggested for sample timestamps,
+// more specific layouts first so they rank higher among equally good ones.
+// gotime is only used where strptime can't express the layout.
+var timestampLayoutCandidates = []struct {
+	layoutType string
+	layout     string
+}{
+	// ISO 8601 and RFC 3339
+	{"strptime", "%Y-%m-%dT%H:%M:%S.%fZ"},
+	{"strptime", "%Y-%m-%dT%H:%M:%SZ"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S.%f%j"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S%j"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S.%f%z"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S%z"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S.%f"},
+	{"strptime", "%Y-%m-%dT%H:%M:%S"},
+	{"strptime", "%Y-%m-%d %H:%M:%S.%f%z"},
+	{"strptime", "%Y-%m-%d %H:%M:%S,%L"},
+	{"strptime", "%Y-%m-%d %H:%M:%S.%f"},
+	{"strptime", "%Y-%m-%d %H:%M:%S %z"},
+	{"strptime", "%Y-%m-%d %H:%M:%S %Z"},
+	{"strptime", "%Y-%m-%d %H:%M:%S"},
+	{"strptime", "%Y/%m/%d %H:%M:%S"},
+
+	// common log format of web servers
+	{"strptime", "%d/%b/%Y:%H:%M:%S %z"},
+
+	// RFC 1123 and ctime
+	{"strptime", "%a, %d %b %Y %H:%M:%S %z"},
+	{"strptime", "%a, %d %b %Y %H:%M:%S %Z"},
+	{"strptime", "%a %b %d %H:%M:%S %Y"},
+	{"gotime", "Mon Jan _2 15:04:05 2006"},
+
+	// syslog, which pads days with spaces
+	{"gotime", "Jan _2 15:04:05.000"},
+	{"gotime", "Jan _2 15:04:05"},
+
+	{"strptime", "%d/%m/%Y %H:%M:%S"},
+	{"strptime", "%m/%d/%Y %H:%M:%S"},
+	{"strptime", "%d-%m-%Y %H:%M:%S"},
+	{"strptime", "%Y-%m-%d"},
+
+	{"epoch", "s"},
+	{"epoch", "ms"},
+	{"epoch", "us"},
+	{"epoch", "ns"},
+	{"epoch", "s.ms"},
+	{"epoch", "s.us"},
+	{"epoch", "s.ns"},
+}
+
+type TimestampLayoutSuggestionRequest struct {
+	Samples []string `json:"samples"`
+}
+
+type TimestampLayoutSuggestion struct {
+	LayoutType string `json:"layout_type"`
+	Layout     string `json:"layout"`
+
+	// Matched is the count of samples parsed with the layout, and Parsed
+	// has the timestamp parsed from each sample, nil for unparsable ones
+	Matched int          `json:"matched"`
+	Parsed  []*time.Time `json:"parsed"`
+}
+
+// SuggestTimestampLayouts returns the layouts time parsers could use for the
+// sample timestamps, the ones parsing the most samples first. Samples are
+// parsed the way the collector would parse them, and layouts giving
+// implausible timestamps, like epochs with the wrong unit, are left out.
+func SuggestTimestampLayouts(samples []string) ([]TimestampLayoutSuggestion, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one sample timestamp is required")
+	}
+	if len(samples) > maxTimestampSamples {
+		return nil, fmt.Errorf("at most %d sample timestamps can be used", maxTimestampSamples)
+	}
+
+	now := time.Now()
+	earliest := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	latest := now.Add(366 * 24 * time.Hour)
+
+	suggestions := []TimestampLayoutSuggestion{}
+	for _, candidate := range timestampLayoutCandidates {
+		suggestion := TimestampLayoutSuggestion{
+			LayoutType: candidate.layoutType,
+			Layout:     candidate.layout,
+			Parsed:     make([]*time.Time, len(samples)),
+		}
+		for i, sample := range samples {
+			sample = strings.TrimSpace(sample)
+			ts, err := parseTimestampSample(candidate.layoutType, candidate.layout, sample)
+			if err != nil || ts.Before(earliest) || ts.After(latest) {
+				continue
+			}
+			suggestion.Parsed[i] = &ts
+			suggestion.Matched++
+		}
+		if suggestion.Matched > 0 {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Matched > suggestions[j].Matched
+	})
+	return suggestions, nil
+}
+
+var epochFractionDigits = map[string]int{"s.ms": 3, "s.us": 6, "s.ns": 9}
+
+// parseTimestampSample parses a sample like a time parser using the layout
+// would in the collector, including the condition it gets for skipping
+// values that don't look like the layout
+func parseTimestampSample(layoutType string, layout string, sample string) (time.Time, error) {
+	var layoutRegex string
+	var err error
+	switch layoutType {
+	case "strptime":
+		layoutRegex, err = RegexForStrptimeLayout(layout)
+	case "gotime":
+		layoutRegex, err = RegexForGotimeLayout(layout)
+	case "epoch":
+		layoutRegex = `^[0-9]+$`
+		if digits, ok := epochFractionDigits[layout]; ok {
+			// fractions get scaled by the unit of the layout, so only the
+			// layout with as many fraction digits gives the right timestamp
+			layoutRegex = fmt.Sprintf(`^[0-9]+\.[0-9]{%d}$`, digits)
+		}
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if layoutType != "epoch" {
+		// layout regex is escaped for use in expr string literals
+		layoutRegex = strings.ReplaceAll(layoutRegex, `\\`, `\`)
+	}
+	if matched, err := regexp.MatchString(layoutRegex, sample); err != nil || !matched {
+		return time.Time{}, fmt.Errorf("sample doesn't match layout %s", layout)
+	}
+
+	bodyField := entry.NewBodyField()
+	parser := helper.NewTimeParser()
+	parser.ParseFrom = &bodyField
+	parser.LayoutType = layoutType
+	parser.Layout = layout
+	parser.Location = "UTC"
+	if err := parser.Validate(); err != nil {
+		return time.Time{}, err
+	}
+
+	e := entry.New()
+	e.Body = sample
+	if err := parser.Parse(e); err != nil {
+		return time.Time{}, err
+	}
+	return e.Timestamp, nil
+}
@@ -0,0 +1,114 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// named groups of a trace parser regex the trace context is taken from
+	traceIdGroup    = "trace_id"
+	spanIdGroup     = "span_id"
+	traceFlagsGroup = "trace_flags"
+
+	defaultTraceParserParseFrom = "body"
+
+	// the groups matched by a trace parser regex are parsed into this field
+	// for the trace operator to read them, and removed after
+	traceParserTempField = "attributes.signoz_trace_context"
+)
+
+// validateTraceParserRegex checks that the regex of a trace parser captures
+// at least one of the trace context fields
+func validateTraceParserRegex(op PipelineOperator) error {
+	r, err := regexp.Compile(op.Regex)
+	if err != nil {
+		return fmt.Errorf("error compiling regex expression of %s trace parser: %w", op.ID, err)
+	}
+	for _, groupName := range r.SubexpNames() {
+		if groupName == traceIdGroup || groupName == spanIdGroup || groupName == traceFlagsGroup {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"regex of %s trace parser must have one of the named groups %s, %s, %s",
+		op.ID, traceIdGroup, spanIdGroup, traceFlagsGroup,
+	)
+}
+
+// prepareTraceParserRegex turns a trace parser extracting the trace context
+// with regex groups into a regex parser parsing the groups into a temporary
+// field, followed by the trace parser reading the ids from there and a
+// remove operator dropping the temporary field. Stanza's trace parser can
+// only read ids from fields of the log.
+//
+// The operator is updated to the regex parser, the trace parser and the
+// remove operator to be chained after it are returned.
+func prepareTraceParserRegex(operator *PipelineOperator) (*PipelineOperator, *PipelineOperator, error) {
+	parseFrom := operator.ParseFrom
+	if parseFrom == "" {
+		parseFrom = defaultTraceParserParseFrom
+	}
+	parseFromNotNilCheck, err := fieldNotNilCheck(parseFrom)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+	tempFieldNotNilCheck, err := fieldNotNilCheck(traceParserTempField)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := regexp.Compile(operator.Regex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	traceParser := &TraceParser{}
+	for _, groupName := range r.SubexpNames() {
+		groupField := &ParseFrom{ParseFrom: fmt.Sprintf("%s.%s", traceParserTempField, groupName)}
+		switch groupName {
+		case traceIdGroup:
+			traceParser.TraceId = groupField
+		case spanIdGroup:
+			traceParser.SpanId = groupField
+		case traceFlagsGroup:
+			traceParser.TraceFlags = groupField
+		}
+	}
+
+	trace := &PipelineOperator{
+		Type:        "trace_parser",
+		ID:          fmt.Sprintf("%s_trace", operator.ID),
+		OrderId:     operator.OrderId,
+		Enabled:     operator.Enabled,
+		Name:        operator.Name,
+		TraceParser: traceParser,
+		If:          tempFieldNotNilCheck,
+	}
+	cleanup := &PipelineOperator{
+		Type:    "remove",
+		ID:      fmt.Sprintf("%s_cleanup", operator.ID),
+		OrderId: operator.OrderId,
+		Enabled: operator.Enabled,
+		Name:    operator.Name,
+		Field:   traceParserTempField,
+		If:      tempFieldNotNilCheck,
+	}
+
+	operator.Type = "regex_parser"
+	operator.TraceParser = nil
+	operator.ParseFrom = parseFrom
+	operator.ParseTo = traceParserTempField
+	operator.If = fmt.Sprintf(
+		`%s && %s matches "%s"`,
+		parseFromNotNilCheck,
+		parseFrom,
+		strings.ReplaceAll(
+			strings.ReplaceAll(operator.Regex, `\`, `\\`),
+			`"`, `\"`,
+		),
+	)
+	operator.Output = trace.ID
+	trace.Output = cleanup.ID
+	return trace, cleanup, nil
+}
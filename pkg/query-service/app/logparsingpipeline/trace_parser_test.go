@@ -0,0 +1,76 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestTraceParserRegex(t *testing.T) {
+	require := require.New(t)
+
+	traceParser := PipelineOperator{
+		OrderId: 1,
+		ID:      "trace",
+		Type:    "trace_parser",
+		Enabled: true,
+		Name:    "trace context from body",
+		Regex:   `trace_id=(?P<trace_id>[0-9a-f]{32}) span_id=(?P<span_id>[0-9a-f]{16})`,
+	}
+	require.Nil(isValidOperator(traceParser))
+
+	invalid := traceParser
+	invalid.Regex = `request_id=(?P<request_id>\w+)`
+	require.NotNil(isValidOperator(invalid), "regex should capture trace context")
+
+	pipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "service",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "checkout",
+					},
+				},
+			},
+			Config: []PipelineOperator{traceParser},
+		},
+	}
+
+	withContext := makeTestSignozLog(
+		"payment failed trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7",
+		map[string]interface{}{"service": "checkout"},
+	)
+	withContext.TraceID = ""
+	withContext.SpanID = ""
+	withoutContext := makeTestSignozLog("payment failed", map[string]interface{}{"service": "checkout"})
+	withoutContext.TraceID = ""
+	withoutContext.SpanID = ""
+
+	result, collectorWarnAndErrorLogs, apiErr := SimulatePipelinesProcessing(
+		context.Background(), pipelines, []model.SignozLog{withContext, withoutContext},
+	)
+	require.Nil(apiErr)
+	require.Equal(0, len(collectorWarnAndErrorLogs), collectorWarnAndErrorLogs)
+	require.Equal(2, len(result))
+
+	require.Equal("4bf92f3577b34da6a3ce929d0e0e4736", result[0].TraceID)
+	require.Equal("00f067aa0ba902b7", result[0].SpanID)
+	require.Equal(map[string]string{"service": "checkout"}, result[0].Attributes_string)
+
+	require.Equal("", result[1].TraceID)
+	require.Equal(map[string]string{"service": "checkout"}, result[1].Attributes_string)
+}
@@ -0,0 +1,171 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Pipelines are deleted by deploying a version without them. The stored
+// pipelines are kept as the config history refers to them, so the trash only
+// records which pipelines were left out of the latest version and when.
+
+type deletedPipeline struct {
+	PipelineId string    `db:"pipeline_id"`
+	Name       string    `db:"name"`
+	DeletedBy  *string   `db:"deleted_by"`
+	DeletedAt  time.Time `db:"deleted_at"`
+}
+
+// recordDeletedPipelines moves the pipelines of the previous version which
+// are not in the deployed one to the trash. Pipelines are identified by
+// alias, as editing a pipeline stores it again with a new id.
+func (r *Repo) recordDeletedPipelines(
+	ctx context.Context, userId string, previous []Pipeline, deployed []Pipeline,
+) *model.ApiError {
+	deployedAliases := map[string]bool{}
+	for _, p := range deployed {
+		deployedAliases[p.Alias] = true
+
+		// pipelines deployed again are out of the trash
+		if _, err := r.db.ExecContext(ctx,
+			`DELETE FROM deleted_pipelines WHERE alias = $1`, p.Alias,
+		); err != nil {
+			return model.InternalError(errors.Wrap(err, "failed to update deleted pipelines"))
+		}
+	}
+
+	for _, p := range previous {
+		if deployedAliases[p.Alias] {
+			continue
+		}
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO deleted_pipelines (pipeline_id, alias, deleted_by, deleted_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT(pipeline_id) DO NOTHING
+		`, p.Id, p.Alias, userId, time.Now())
+		if err != nil {
+			return model.InternalError(errors.Wrap(err, "failed to record deleted pipeline"))
+		}
+	}
+	return nil
+}
+
+func (r *Repo) getDeletedPipelines(ctx context.Context) ([]deletedPipeline, *model.ApiError) {
+	deleted := []deletedPipeline{}
+	err := r.db.SelectContext(ctx, &deleted, `
+		SELECT d.pipeline_id, p.name, d.deleted_by, d.deleted_at
+		FROM deleted_pipelines d
+		JOIN pipelines p ON p.id = d.pipeline_id
+	`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get deleted pipelines"))
+	}
+	return deleted, nil
+}
+
+func (r *Repo) isDeletedPipeline(ctx context.Context, id string) (bool, *model.ApiError) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT count(*) FROM deleted_pipelines WHERE pipeline_id = $1`, id,
+	)
+	if err != nil {
+		return false, model.InternalError(errors.Wrap(err, "failed to get deleted pipeline"))
+	}
+	return count > 0, nil
+}
+
+// ListDeleted implements trash.Store
+func (ic *LogParsingPipelineController) ListDeleted(ctx context.Context) ([]trash.Item, *model.ApiError) {
+	deleted, apiErr := ic.getDeletedPipelines(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []trash.Item{}
+	for _, d := range deleted {
+		item := trash.Item{Id: d.PipelineId, Name: d.Name, DeletedAt: d.DeletedAt}
+		if d.DeletedBy != nil {
+			item.DeletedBy = *d.DeletedBy
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// RestoreDeleted deploys a new version with the deleted pipeline added back
+// after the others. Implements trash.Store
+func (ic *LogParsingPipelineController) RestoreDeleted(ctx context.Context, id string) *model.ApiError {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if deleted, apiErr := ic.isDeletedPipeline(ctx, id); apiErr != nil {
+		return apiErr
+	} else if !deleted {
+		return model.NotFoundError(fmt.Errorf("no deleted pipeline found with id %s", id))
+	}
+	restored, apiErr := ic.GetPipeline(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	_, latest, apiErr := ic.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	orderId := 0
+	for _, p := range latest {
+		if p.Alias == restored.Alias {
+			return &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf(
+				"pipeline %s can't be restored, another pipeline has its alias %s", restored.Name, restored.Alias,
+			)}
+		}
+		if p.OrderId > orderId {
+			orderId = p.OrderId
+		}
+	}
+
+	if restored.OrderId != orderId+1 {
+		// stored pipelines are immutable, a copy is stored to put it last
+		postable := toPostablePipeline(*restored)
+		postable.Id = ""
+		postable.OrderId = orderId + 1
+		if restored, apiErr = ic.insertPipeline(ctx, postable); apiErr != nil {
+			return model.WrapApiError(apiErr, "failed to store restored pipeline")
+		}
+	}
+
+	changeNote := fmt.Sprintf("restore deleted pipeline %s", restored.Alias)
+	_, apiErr = ic.deployPipelines(ctx, userId, append(latest, *restored), changeNote)
+	return apiErr
+}
+
+// PurgeDeleted removes a pipeline from the trash, so it can only be brought
+// back by rolling back to a version having it. Implements trash.Store
+func (ic *LogParsingPipelineController) PurgeDeleted(ctx context.Context, id string) *model.ApiError {
+	result, err := ic.db.ExecContext(ctx, `DELETE FROM deleted_pipelines WHERE pipeline_id = $1`, id)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to purge deleted pipeline"))
+	}
+	if purged, _ := result.RowsAffected(); purged == 0 {
+		return model.NotFoundError(fmt.Errorf("no deleted pipeline found with id %s", id))
+	}
+	return nil
+}
+
+// PurgeExpired implements trash.Store
+func (ic *LogParsingPipelineController) PurgeExpired(ctx context.Context, deletedBefore time.Time) *model.ApiError {
+	_, err := ic.db.ExecContext(ctx, `DELETE FROM deleted_pipelines WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to purge deleted pipelines"))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// PipelineVariable is an org level constant which can be referenced as
+// {{NAME}} in pipeline operator values and filters. References are resolved
+// when generating collector config, so the same pipelines can be used across
+// environments by just changing the variables.
+type PipelineVariable struct {
+	Name      string    `json:"name" db:"name"`
+	Value     string    `json:"value" db:"value"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type PostablePipelineVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var (
+	pipelineVariableNameRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	pipelineVariableRefRegex  = regexp.MustCompile(`\{\{\s*([A-Z][A-Z0-9_]*)\s*\}\}`)
+)
+
+func (p *PostablePipelineVariable) IsValid() error {
+	if !pipelineVariableNameRegex.MatchString(p.Name) {
+		return fmt.Errorf(
+			"variable name must start with an uppercase letter and contain only uppercase letters, digits and underscores",
+		)
+	}
+	return nil
+}
+
+// referencedVariables returns the names of the variables used in the
+// operators and filters of pipelines
+func referencedVariables(pipelines []Pipeline) ([]string, error) {
+	names := map[string]struct{}{}
+	for _, p := range pipelines {
+		serialized, err := json.Marshal([]interface{}{p.Config, p.Filter})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not serialize pipeline %s", p.Name)
+		}
+		for _, match := range pipelineVariableRefRegex.FindAllStringSubmatch(string(serialized), -1) {
+			names[match[1]] = struct{}{}
+		}
+	}
+
+	result := []string{}
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// resolvePipelineVariables returns copies of pipelines with all variable
+// references replaced by their values
+func resolvePipelineVariables(
+	pipelines []Pipeline, variables []PipelineVariable,
+) ([]Pipeline, error) {
+	values := map[string]string{}
+	for _, v := range variables {
+		values[v.Name] = v.Value
+	}
+
+	referenced, err := referencedVariables(pipelines)
+	if err != nil {
+		return nil, err
+	}
+	undefined := []string{}
+	for _, name := range referenced {
+		if _, ok := values[name]; !ok {
+			undefined = append(undefined, name)
+		}
+	}
+	if len(undefined) > 0 {
+		return nil, fmt.Errorf("undefined pipeline variables: %s", strings.Join(undefined, ", "))
+	}
+
+	resolved := make([]Pipeline, len(pipelines))
+	for i, p := range pipelines {
+		resolved[i] = p
+
+		config := []PipelineOperator{}
+		if err := resolveVariablesInJSON(p.Config, &config, values); err != nil {
+			return nil, errors.Wrapf(err, "could not resolve variables in config of pipeline %s", p.Name)
+		}
+		resolved[i].Config = config
+
+		if p.Filter != nil {
+			filter := &v3.FilterSet{}
+			if err := resolveVariablesInJSON(p.Filter, filter, values); err != nil {
+				return nil, errors.Wrapf(err, "could not resolve variables in filter of pipeline %s", p.Name)
+			}
+			resolved[i].Filter = filter
+		}
+	}
+	return resolved, nil
+}
+
+// resolveVariablesInJSON replaces variable references in all string values
+// of src and unmarshals the result into dest. Replacing values in the decoded
+// JSON rather than in the serialized text ensures values with quotes or
+// backslashes can not break out of the strings they are used in.
+func resolveVariablesInJSON(src interface{}, dest interface{}, values map[string]string) error {
+	serialized, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(serialized, &decoded); err != nil {
+		return err
+	}
+
+	resolved, err := json.Marshal(replaceVariables(decoded, values))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resolved, dest)
+}
+
+func replaceVariables(v interface{}, values map[string]string) interface{} {
+	switch val := v.(type) {
+	case string:
+		return pipelineVariableRefRegex.ReplaceAllStringFunc(val, func(ref string) string {
+			name := pipelineVariableRefRegex.FindStringSubmatch(ref)[1]
+			return values[name]
+		})
+	case []interface{}:
+		for i := range val {
+			val[i] = replaceVariables(val[i], values)
+		}
+		return val
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = replaceVariables(val[k], values)
+		}
+		return val
+	}
+	return v
+}
+
+func (r *Repo) getPipelineVariables(ctx context.Context) ([]PipelineVariable, *model.ApiError) {
+	variables := []PipelineVariable{}
+	err := r.db.SelectContext(ctx, &variables, `
+		SELECT name, value, updated_by, updated_at
+		FROM pipeline_variables
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get pipeline variables"))
+	}
+	return variables, nil
+}
+
+func (r *Repo) upsertPipelineVariable(
+	ctx context.Context, userId string, postable *PostablePipelineVariable,
+) (*PipelineVariable, *model.ApiError) {
+	variable := &PipelineVariable{
+		Name:      postable.Name,
+		Value:     postable.Value,
+		UpdatedBy: userId,
+		UpdatedAt: time.Now(),
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO pipeline_variables (name, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT(name) DO UPDATE SET
+			value = excluded.value,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, variable.Name, variable.Value, variable.UpdatedBy, variable.UpdatedAt)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to save pipeline variable"))
+	}
+	return variable, nil
+}
+
+func (r *Repo) deletePipelineVariable(ctx context.Context, name string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM pipeline_variables WHERE name = $1
+	`, name)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to delete pipeline variable"))
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return model.NotFoundError(fmt.Errorf("pipeline variable %s not found", name))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestResolvePipelineVariables(t *testing.T) {
+	require := require.New(t)
+
+	pipelines := []Pipeline{
+		{
+			Name: "pipeline1",
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "deployment.environment",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeResource,
+						},
+						Operator: "=",
+						Value:    "{{ENVIRONMENT}}",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					ID:      "add",
+					Type:    "add",
+					Enabled: true,
+					Field:   "attributes.region",
+					Value:   "{{REGION}}-{{ ENVIRONMENT }}",
+				},
+			},
+		},
+	}
+
+	referenced, err := referencedVariables(pipelines)
+	require.Nil(err)
+	require.Equal([]string{"ENVIRONMENT", "REGION"}, referenced)
+
+	_, err = resolvePipelineVariables(pipelines, []PipelineVariable{
+		{Name: "ENVIRONMENT", Value: "production"},
+	})
+	require.NotNil(err, "undefined variables should not be allowed")
+
+	resolved, err := resolvePipelineVariables(pipelines, []PipelineVariable{
+		{Name: "ENVIRONMENT", Value: "production"},
+		{Name: "REGION", Value: `us-east-1"`},
+	})
+	require.Nil(err)
+	require.Equal("production", resolved[0].Filter.Items[0].Value)
+	require.Equal(`us-east-1"-production`, resolved[0].Config[0].Value)
+	require.True(resolved[0].Config[0].Enabled)
+
+	require.Equal(
+		"{{ENVIRONMENT}}", pipelines[0].Filter.Items[0].Value,
+		"resolving variables should not modify the pipelines being resolved",
+	)
+}
@@ -0,0 +1,483 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// the watchdog is recorded as the creator of the pipelines and config
+	// versions it deploys
+	watchdogUser = "pipeline-watchdog"
+
+	watchdogInterval = time.Minute
+	watchdogStep     = 60
+
+	// metrics the collectors report for each of their processors
+	processorAcceptedMetric = "otelcol_processor_accepted_log_records"
+	processorRefusedMetric  = "otelcol_processor_refused_log_records"
+	processorDroppedMetric  = "otelcol_processor_dropped_log_records"
+)
+
+// WatchdogSettings configure when the watchdog pauses pipelines losing logs
+type WatchdogSettings struct {
+	Enabled bool `json:"enabled"`
+	// MaxDropRatePercent is the share of the logs received by a pipeline it
+	// may drop or refuse before it gets paused
+	MaxDropRatePercent float64 `json:"maxDropRatePercent"`
+	// MinRecords is the number of logs a pipeline must have received before
+	// its drop rate is looked at
+	MinRecords float64 `json:"minRecords"`
+	// WindowMinutes is for how long after a deployment pipelines are watched
+	WindowMinutes int `json:"windowMinutes"`
+}
+
+// the watchdog is opt-in as it pauses pipelines on its own
+var defaultWatchdogSettings = WatchdogSettings{
+	Enabled:            false,
+	MaxDropRatePercent: 5,
+	MinRecords:         1000,
+	WindowMinutes:      30,
+}
+
+func (s *WatchdogSettings) IsValid() error {
+	if s.MaxDropRatePercent <= 0 || s.MaxDropRatePercent > 100 {
+		return fmt.Errorf("maxDropRatePercent must be greater than 0 and at most 100")
+	}
+	if s.MinRecords < 0 {
+		return fmt.Errorf("minRecords can not be negative")
+	}
+	if s.WindowMinutes < 1 || s.WindowMinutes > 24*60 {
+		return fmt.Errorf("windowMinutes must be between 1 and 1440")
+	}
+	return nil
+}
+
+// WatchdogEvent records a pipeline paused by the watchdog
+type WatchdogEvent struct {
+	Id            string `json:"id" db:"id"`
+	PipelineId    string `json:"pipelineId" db:"pipeline_id"`
+	PipelineName  string `json:"pipelineName" db:"pipeline_name"`
+	PipelineAlias string `json:"pipelineAlias" db:"pipeline_alias"`
+	// PausedPipelineId is the disabled copy of the pipeline deployed in its
+	// place
+	PausedPipelineId string `json:"pausedPipelineId" db:"paused_pipeline_id"`
+	// Version is the pipelines config version the pipeline was paused in
+	Version         int       `json:"version" db:"version"`
+	DropRatePercent float64   `json:"dropRatePercent" db:"drop_rate_percent"`
+	Dropped         float64   `json:"dropped" db:"dropped"`
+	Received        float64   `json:"received" db:"received"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
+}
+
+type pipelineDropStats struct {
+	Received float64
+	Dropped  float64
+}
+
+func (s *pipelineDropStats) dropRatePercent() float64 {
+	if s.Received == 0 {
+		return 0
+	}
+	return s.Dropped / s.Received * 100
+}
+
+// watchdogQueryRangeParams queries the logs accepted, refused and dropped by
+// each of the pipeline processors of the collectors
+func watchdogQueryRangeParams(start, end int64) *v3.QueryRangeParamsV3 {
+	queries := map[string]*v3.BuilderQuery{}
+	for name, metric := range map[string]string{
+		"A": processorAcceptedMetric,
+		"B": processorRefusedMetric,
+		"C": processorDroppedMetric,
+	} {
+		queries[name] = &v3.BuilderQuery{
+			QueryName:    name,
+			Expression:   name,
+			DataSource:   v3.DataSourceMetrics,
+			StepInterval: watchdogStep,
+			AggregateAttribute: v3.AttributeKey{
+				Key:      metric,
+				DataType: v3.AttributeKeyDataTypeFloat64,
+				Type:     v3.AttributeKeyType("Sum"),
+				IsColumn: true,
+			},
+			AggregateOperator: v3.AggregateOperatorSumRate,
+			GroupBy: []v3.AttributeKey{{
+				Key:      "processor",
+				DataType: v3.AttributeKeyDataTypeString,
+				Type:     v3.AttributeKeyTypeTag,
+			}},
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+				Key: v3.AttributeKey{
+					Key:      "processor",
+					DataType: v3.AttributeKeyDataTypeString,
+					Type:     v3.AttributeKeyTypeTag,
+				},
+				Operator: v3.FilterOperatorLike,
+				Value:    constants.LogsPPLPfx + "%",
+			}}},
+		}
+	}
+
+	return &v3.QueryRangeParamsV3{
+		Start: start,
+		End:   end,
+		Step:  watchdogStep,
+		CompositeQuery: &v3.CompositeQuery{
+			PanelType:      v3.PanelTypeGraph,
+			QueryType:      v3.QueryTypeBuilder,
+			BuilderQueries: queries,
+		},
+	}
+}
+
+// processorRecordCounts are the logs accepted, refused and dropped by a
+// pipeline processor
+type processorRecordCounts struct {
+	Accepted float64
+	Refused  float64
+	Dropped  float64
+}
+
+// processorCountsFromResults reads the results of the watchdog query into
+// the number of logs handled by each pipeline processor, keyed by alias
+func processorCountsFromResults(results []*v3.Result) map[string]*processorRecordCounts {
+	counts := map[string]*processorRecordCounts{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			alias, ok := strings.CutPrefix(series.Labels["processor"], constants.LogsPPLPfx)
+			if !ok {
+				continue
+			}
+			// points are per second rates over each step
+			count := 0.0
+			for _, point := range series.Points {
+				count += point.Value * watchdogStep
+			}
+
+			if counts[alias] == nil {
+				counts[alias] = &processorRecordCounts{}
+			}
+			switch result.QueryName {
+			case "A":
+				counts[alias].Accepted += count
+			case "B":
+				counts[alias].Refused += count
+			case "C":
+				counts[alias].Dropped += count
+			}
+		}
+	}
+	return counts
+}
+
+// dropStatsFromResults reads the results of the watchdog query into the
+// number of logs received and lost by each pipeline, keyed by alias
+func dropStatsFromResults(results []*v3.Result) map[string]*pipelineDropStats {
+	stats := map[string]*pipelineDropStats{}
+	for alias, c := range processorCountsFromResults(results) {
+		stats[alias] = &pipelineDropStats{
+			Received: c.Accepted + c.Refused + c.Dropped,
+			Dropped:  c.Refused + c.Dropped,
+		}
+	}
+	return stats
+}
+
+// pipelinesToPause returns the enabled pipelines whose drop rate exceeds the
+// one allowed by the settings, along with their drop stats
+func pipelinesToPause(
+	settings WatchdogSettings, pipelines []Pipeline, stats map[string]*pipelineDropStats,
+) map[string]*pipelineDropStats {
+	toPause := map[string]*pipelineDropStats{}
+	for _, p := range pipelines {
+		s := stats[p.Alias]
+		if !p.Enabled || s == nil || s.Received == 0 || s.Received < settings.MinRecords {
+			continue
+		}
+		if s.dropRatePercent() > settings.MaxDropRatePercent {
+			toPause[p.Id] = s
+		}
+	}
+	return toPause
+}
+
+// Watchdog pauses the pipelines that drop or refuse too many of the logs they
+// receive after being deployed
+type Watchdog struct {
+	controller *LogParsingPipelineController
+	querier    interfaces.Querier
+	onPause    func(ctx context.Context, event WatchdogEvent)
+	done       chan struct{}
+}
+
+// NewWatchdog creates a watchdog, onPause is called for each pipeline it
+// pauses so that the pause can be alerted on
+func NewWatchdog(
+	controller *LogParsingPipelineController,
+	querier interfaces.Querier,
+	onPause func(ctx context.Context, event WatchdogEvent),
+) *Watchdog {
+	return &Watchdog{
+		controller: controller,
+		querier:    querier,
+		onPause:    onPause,
+		done:       make(chan struct{}),
+	}
+}
+
+func (w *Watchdog) Start() {
+	go func() {
+		tick := time.NewTicker(watchdogInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-tick.C:
+				if err := w.check(context.Background()); err != nil {
+					zap.L().Error("pipeline watchdog check failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (w *Watchdog) Stop() {
+	close(w.done)
+}
+
+func (w *Watchdog) check(ctx context.Context) error {
+	settings, apiErr := w.controller.getWatchdogSettings(ctx)
+	if apiErr != nil {
+		return apiErr.ToError()
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	latestVersion, pipelines, apiErr := w.controller.getLatestPipelines(ctx)
+	if apiErr != nil {
+		return apiErr.ToError()
+	}
+	if latestVersion == nil {
+		return nil
+	}
+	sinceDeploy := time.Since(latestVersion.CreatedAt)
+	if sinceDeploy < watchdogStep*time.Second ||
+		sinceDeploy > time.Duration(settings.WindowMinutes)*time.Minute {
+		return nil
+	}
+
+	params := watchdogQueryRangeParams(latestVersion.CreatedAt.UnixMilli(), time.Now().UnixMilli())
+	results, err, _ := w.querier.QueryRange(ctx, params, map[string]v3.AttributeKey{})
+	if err != nil {
+		return fmt.Errorf("could not query pipeline drop rates: %w", err)
+	}
+
+	toPause := pipelinesToPause(settings, pipelines, dropStatsFromResults(results))
+	if len(toPause) == 0 {
+		return nil
+	}
+	events, apiErr := w.controller.pausePipelines(ctx, pipelines, toPause)
+	if apiErr != nil {
+		return apiErr.ToError()
+	}
+	for _, event := range events {
+		zap.L().Warn("pipeline watchdog paused pipeline",
+			zap.String("pipeline", event.PipelineName),
+			zap.Float64("dropRatePercent", event.DropRatePercent),
+		)
+		if w.onPause != nil {
+			w.onPause(ctx, event)
+		}
+	}
+	return nil
+}
+
+// pausePipelines deploys a new version of the pipelines in which the ones to
+// pause are replaced with disabled copies
+func (ic *LogParsingPipelineController) pausePipelines(
+	ctx context.Context, pipelines []Pipeline, toPause map[string]*pipelineDropStats,
+) ([]WatchdogEvent, *model.ApiError) {
+	updated := make([]Pipeline, len(pipelines))
+	events := []WatchdogEvent{}
+	for i, p := range pipelines {
+		updated[i] = p
+		stats, ok := toPause[p.Id]
+		if !ok {
+			continue
+		}
+
+		description := ""
+		if p.Description != nil {
+			description = *p.Description
+		}
+		paused, apiErr := ic.insertPipelineAs(ctx, &PostablePipeline{
+			OrderId:     p.OrderId,
+			Name:        p.Name,
+			Alias:       p.Alias,
+			Description: description,
+			Enabled:     false,
+			Filter:      p.Filter,
+			Config:      p.Config,
+
+			AgentSelector: p.AgentSelector,
+		}, watchdogUser)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "could not store paused pipeline")
+		}
+		updated[i] = *paused
+
+		events = append(events, WatchdogEvent{
+			Id:               uuid.NewString(),
+			PipelineId:       p.Id,
+			PipelineName:     p.Name,
+			PipelineAlias:    p.Alias,
+			PausedPipelineId: paused.Id,
+			DropRatePercent:  stats.dropRatePercent(),
+			Dropped:          stats.Dropped,
+			Received:         stats.Received,
+			CreatedAt:        time.Now(),
+		})
+	}
+
+	elements := make([]string, len(updated))
+	for i, p := range updated {
+		elements[i] = p.Id
+	}
+	version, apiErr := agentConf.StartNewVersion(
+		ctx, watchdogUser, agentConf.ElementTypeLogPipelines, elements,
+	)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "could not deploy paused pipelines")
+	}
+
+	for i := range events {
+		events[i].Version = version.Version
+		if apiErr := ic.insertWatchdogEvent(ctx, &events[i]); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return events, nil
+}
+
+// GetWatchdogSettings returns the watchdog settings, the defaults if they
+// were never changed
+func (ic *LogParsingPipelineController) GetWatchdogSettings(
+	ctx context.Context,
+) (*WatchdogSettings, *model.ApiError) {
+	settings, apiErr := ic.getWatchdogSettings(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &settings, nil
+}
+
+func (ic *LogParsingPipelineController) SetWatchdogSettings(
+	ctx context.Context, settings *WatchdogSettings,
+) (*WatchdogSettings, *model.ApiError) {
+	if err := settings.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := ic.saveWatchdogSettings(ctx, userId, settings); apiErr != nil {
+		return nil, apiErr
+	}
+	return settings, nil
+}
+
+// ListWatchdogEvents returns the pipelines paused by the watchdog, latest
+// first
+func (ic *LogParsingPipelineController) ListWatchdogEvents(
+	ctx context.Context,
+) ([]WatchdogEvent, *model.ApiError) {
+	events := []WatchdogEvent{}
+	err := ic.db.SelectContext(ctx, &events, `
+		SELECT id, pipeline_id, pipeline_name, pipeline_alias, paused_pipeline_id,
+			version, drop_rate_percent, dropped, received, created_at
+		FROM pipeline_watchdog_events
+		ORDER BY created_at DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get pipeline watchdog events"))
+	}
+	return events, nil
+}
+
+func (r *Repo) getWatchdogSettings(ctx context.Context) (WatchdogSettings, *model.ApiError) {
+	var settingsJSON string
+	err := r.db.GetContext(ctx, &settingsJSON, `
+		SELECT settings_json FROM pipeline_watchdog_settings WHERE id = 1
+	`)
+	if err == sql.ErrNoRows {
+		return defaultWatchdogSettings, nil
+	}
+	if err != nil {
+		return WatchdogSettings{}, model.InternalError(errors.Wrap(err, "failed to get pipeline watchdog settings"))
+	}
+
+	settings := defaultWatchdogSettings
+	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+		return WatchdogSettings{}, model.InternalError(errors.Wrap(err, "invalid pipeline watchdog settings"))
+	}
+	return settings, nil
+}
+
+func (r *Repo) saveWatchdogSettings(
+	ctx context.Context, userId string, settings *WatchdogSettings,
+) *model.ApiError {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "could not serialize pipeline watchdog settings"))
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO pipeline_watchdog_settings (id, settings_json, updated_by, updated_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET
+			settings_json = excluded.settings_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, string(settingsJSON), userId, time.Now())
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to save pipeline watchdog settings"))
+	}
+	return nil
+}
+
+func (r *Repo) insertWatchdogEvent(ctx context.Context, event *WatchdogEvent) *model.ApiError {
+	_, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO pipeline_watchdog_events (
+			id, pipeline_id, pipeline_name, pipeline_alias, paused_pipeline_id,
+			version, drop_rate_percent, dropped, received, created_at
+		) VALUES (
+			:id, :pipeline_id, :pipeline_name, :pipeline_alias, :paused_pipeline_id,
+			:version, :drop_rate_percent, :dropped, :received, :created_at
+		)
+	`, event)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to record pipeline watchdog event"))
+	}
+	return nil
+}
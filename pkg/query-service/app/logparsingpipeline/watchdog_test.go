@@ -0,0 +1,72 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestWatchdogPipelinesToPause(t *testing.T) {
+	require := require.New(t)
+
+	series := func(processor string, rates ...float64) *v3.Series {
+		points := []v3.Point{}
+		for i, rate := range rates {
+			points = append(points, v3.Point{Timestamp: int64(i) * 60000, Value: rate})
+		}
+		return &v3.Series{Labels: map[string]string{"processor": processor}, Points: points}
+	}
+
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				series("logstransform/pipeline_healthy", 10, 10),
+				series("logstransform/pipeline_lossy", 5, 5),
+				series("logstransform/pipeline_quiet", 0.1),
+				series("logstransform/pipeline_disabled", 1),
+				series("batch", 100),
+			},
+		},
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				series("logstransform/pipeline_lossy", 1),
+			},
+		},
+		{
+			QueryName: "C",
+			Series: []*v3.Series{
+				series("logstransform/pipeline_healthy", 0.1),
+				series("logstransform/pipeline_lossy", 4, 5),
+				series("logstransform/pipeline_quiet", 0.1),
+				series("logstransform/pipeline_disabled", 10, 10),
+			},
+		},
+	}
+
+	stats := dropStatsFromResults(results)
+	require.NotContains(stats, "batch")
+	require.Equal(pipelineDropStats{Received: 1206, Dropped: 6}, *stats["healthy"])
+	require.Equal(pipelineDropStats{Received: 1200, Dropped: 600}, *stats["lossy"])
+	require.InDelta(50, stats["lossy"].dropRatePercent(), 0.001)
+
+	pipelines := []Pipeline{
+		{Id: "1", Alias: "healthy", Enabled: true},
+		{Id: "2", Alias: "lossy", Enabled: true},
+		{Id: "3", Alias: "quiet", Enabled: true},
+		{Id: "4", Alias: "disabled", Enabled: false},
+		{Id: "5", Alias: "unreported", Enabled: true},
+	}
+	toPause := pipelinesToPause(defaultWatchdogSettings, pipelines, stats)
+	require.Len(toPause, 1)
+	require.Same(stats["lossy"], toPause["2"])
+
+	// pipelines receiving few logs are only judged with a lower minRecords
+	settings := defaultWatchdogSettings
+	settings.MinRecords = 10
+	toPause = pipelinesToPause(settings, pipelines, stats)
+	require.Len(toPause, 2)
+	require.Contains(toPause, "3")
+}
@@ -0,0 +1,143 @@
+package logreceivers
+
+import (
+	"fmt"
+	"strings"
+
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+const componentNamePrefix = "signoz_log_receiver"
+
+// GenerateCollectorConfigWithLogReceivers adds a filelog, syslog or journald
+// receiver for each enabled log receiver selecting the agent to the logs
+// pipeline. Receivers are removed when no enabled log receiver selects
+// the agent.
+func GenerateCollectorConfigWithLogReceivers(
+	config []byte, logReceivers []LogReceiver, agent opampModel.AgentInfo,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	receivers, ok := c["receivers"].(map[string]interface{})
+	if !ok || receivers == nil {
+		receivers = map[string]interface{}{}
+	}
+	for name := range receivers {
+		if isLogReceiverComponent(name) {
+			delete(receivers, name)
+		}
+	}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		current, _ := pipeline["receivers"].([]interface{})
+		updated := []interface{}{}
+		for _, r := range current {
+			if name, ok := r.(string); !ok || !isLogReceiverComponent(name) {
+				updated = append(updated, r)
+			}
+		}
+		pipeline["receivers"] = updated
+	}
+
+	logsPipeline, hasLogsPipeline := pipelines["logs"].(map[string]interface{})
+	for _, lr := range logReceivers {
+		if !lr.Spec.Enabled || !hasLogsPipeline || !lr.Spec.Selector.Matches(agent) {
+			// the agent doesn't process logs or isn't selected
+			continue
+		}
+		spec := lr.Spec
+		spec.setDefaults()
+
+		receiverName := spec.Type + "/" + componentNamePrefix + "_" + lr.Id
+		receivers[receiverName] = receiverConfig(spec)
+		logsPipeline["receivers"] = append(logsPipeline["receivers"].([]interface{}), receiverName)
+	}
+
+	if len(receivers) > 0 {
+		c["receivers"] = receivers
+	} else {
+		delete(c, "receivers")
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+func receiverConfig(spec LogReceiverSpec) map[string]interface{} {
+	switch spec.Type {
+	case TypeFilelog:
+		conf := map[string]interface{}{
+			"include":           toInterfaceSlice(spec.Filelog.Include),
+			"include_file_path": true,
+		}
+		if len(spec.Filelog.Exclude) > 0 {
+			conf["exclude"] = toInterfaceSlice(spec.Filelog.Exclude)
+		}
+		if spec.Filelog.StartAt != "" {
+			conf["start_at"] = spec.Filelog.StartAt
+		}
+		return conf
+
+	case TypeSyslog:
+		return map[string]interface{}{
+			"protocol": spec.Syslog.Protocol,
+			spec.Syslog.Transport: map[string]interface{}{
+				"listen_address": spec.Syslog.endpoint(),
+			},
+		}
+
+	default:
+		conf := map[string]interface{}{}
+		if spec.Journald == nil {
+			return conf
+		}
+		if len(spec.Journald.Units) > 0 {
+			conf["units"] = toInterfaceSlice(spec.Journald.Units)
+		}
+		if spec.Journald.Directory != "" {
+			conf["directory"] = spec.Journald.Directory
+		}
+		if spec.Journald.Priority != "" {
+			conf["priority"] = spec.Journald.Priority
+		}
+		return conf
+	}
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+func isLogReceiverComponent(name string) bool {
+	_, componentName, _ := strings.Cut(name, "/")
+	return strings.HasPrefix(componentName, componentNamePrefix)
+}
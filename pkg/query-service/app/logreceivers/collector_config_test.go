@@ -0,0 +1,127 @@
+package logreceivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhouselogsexporter: {}
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhouselogsexporter]
+`
+
+type testConf struct {
+	Receivers map[string]map[string]interface{} `yaml:"receivers"`
+	Service   struct {
+		Pipelines map[string]struct {
+			Receivers []string `yaml:"receivers"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func TestGenerateCollectorConfigWithLogReceivers(t *testing.T) {
+	require := require.New(t)
+
+	logReceivers := []LogReceiver{
+		{
+			Id: "nginx", Name: "nginx",
+			Spec: LogReceiverSpec{
+				Enabled: true, Type: TypeFilelog,
+				Selector: &logparsingpipeline.AgentSelector{Groups: []string{"edge"}},
+				Filelog: &FilelogConfig{
+					Include: []string{"/var/log/nginx/*.log"},
+					Exclude: []string{"/var/log/nginx/*.gz"},
+					StartAt: "beginning",
+				},
+			},
+		},
+		{
+			Id: "syslog", Name: "syslog",
+			Spec: LogReceiverSpec{
+				Enabled: true, Type: TypeSyslog,
+				Syslog: &SyslogConfig{Transport: "udp", Protocol: "rfc3164", Port: 5514},
+			},
+		},
+		{
+			Id: "sshd", Name: "sshd",
+			Spec: LogReceiverSpec{
+				Enabled: true, Type: TypeJournald,
+				Journald: &JournaldConfig{Units: []string{"sshd"}, Priority: "warning"},
+			},
+		},
+	}
+	for _, lr := range logReceivers {
+		require.Nil(lr.Spec.IsValid())
+	}
+
+	edgeAgent := opampModel.AgentInfo{ID: "edge-1", Group: "edge"}
+	updated, apiErr := GenerateCollectorConfigWithLogReceivers([]byte(testCollectorConf), logReceivers, edgeAgent)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(
+		[]string{
+			"otlp",
+			"filelog/signoz_log_receiver_nginx",
+			"syslog/signoz_log_receiver_syslog",
+			"journald/signoz_log_receiver_sshd",
+		},
+		conf.Service.Pipelines["logs"].Receivers,
+	)
+	require.Equal(map[string]interface{}{
+		"include":           []interface{}{"/var/log/nginx/*.log"},
+		"exclude":           []interface{}{"/var/log/nginx/*.gz"},
+		"start_at":          "beginning",
+		"include_file_path": true,
+	}, conf.Receivers["filelog/signoz_log_receiver_nginx"])
+	require.Equal(map[string]interface{}{
+		"protocol": "rfc3164",
+		"udp":      map[string]interface{}{"listen_address": "0.0.0.0:5514"},
+	}, conf.Receivers["syslog/signoz_log_receiver_syslog"])
+	require.Equal(map[string]interface{}{
+		"units":    []interface{}{"sshd"},
+		"priority": "warning",
+	}, conf.Receivers["journald/signoz_log_receiver_sshd"])
+
+	// agents not selected don't get the file receiver
+	updated, apiErr = GenerateCollectorConfigWithLogReceivers(
+		updated, logReceivers, opampModel.AgentInfo{ID: "k8s-1", Group: "k8s"},
+	)
+	require.Nil(apiErr)
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.NotContains(conf.Receivers, "filelog/signoz_log_receiver_nginx")
+	require.Equal(3, len(conf.Receivers))
+
+	// log receivers should get cleaned up when disabled
+	for i := range logReceivers {
+		logReceivers[i].Spec.Enabled = false
+	}
+	updated, apiErr = GenerateCollectorConfigWithLogReceivers(updated, logReceivers, edgeAgent)
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(1, len(conf.Receivers))
+	require.Equal([]string{"otlp"}, conf.Service.Pipelines["logs"].Receivers)
+
+	relative := LogReceiverSpec{
+		Type: TypeFilelog, Filelog: &FilelogConfig{Include: []string{"logs/*.log"}},
+	}
+	require.NotNil(relative.IsValid(), "file paths should be absolute")
+}
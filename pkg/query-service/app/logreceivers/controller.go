@@ -0,0 +1,284 @@
+package logreceivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+const LogReceiversFeatureType agentConf.AgentFeatureType = "log_receivers"
+
+// ports of the OTLP receiver of the agents
+var reservedPorts = []int{4317, 4318}
+
+// Controller manages the files, syslog listeners and journald units agents
+// collect logs from and deploys the receiver config derived from them via
+// agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log receivers repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type LogReceiversResponse struct {
+	*agentConf.ConfigVersion
+
+	LogReceivers []LogReceiver `json:"logReceivers"`
+}
+
+func (c *Controller) ListLogReceivers(ctx context.Context) (
+	*LogReceiversResponse, *model.ApiError,
+) {
+	logReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogReceivers)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest log receivers config version")
+	}
+
+	return &LogReceiversResponse{
+		ConfigVersion: latest,
+		LogReceivers:  logReceivers,
+	}, nil
+}
+
+func (c *Controller) GetLogReceiver(ctx context.Context, id string) (
+	*LogReceiver, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateLogReceiver stores a new log receiver and starts deploying an agent
+// config that collects its logs
+func (c *Controller) CreateLogReceiver(
+	ctx context.Context, postable *PostableLogReceiver,
+) (*LogReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+	if apiErr := c.ensurePortIsFree(ctx, "", postable.Spec); apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	logReceiver, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, logReceiver.Id)
+		return nil, apiErr
+	}
+
+	return logReceiver, nil
+}
+
+// UpdateLogReceiver replaces the name and spec of a log receiver and starts
+// deploying the updated agent config
+func (c *Controller) UpdateLogReceiver(
+	ctx context.Context, id string, postable *PostableLogReceiver,
+) (*LogReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := c.ensurePortIsFree(ctx, id, postable.Spec); apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.update(ctx, existing.UpdatedBy, existing)
+		return nil, apiErr
+	}
+
+	return &updated, nil
+}
+
+// DeleteLogReceiver removes a log receiver and starts deploying an agent
+// config without it
+func (c *Controller) DeleteLogReceiver(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+// ensurePortIsFree rejects enabling a syslog listener on a port the agents
+// already listen on, for OTLP or another enabled syslog receiver. Receivers
+// selecting different agents are still rejected, selectors may overlap.
+func (c *Controller) ensurePortIsFree(
+	ctx context.Context, id string, spec LogReceiverSpec,
+) *model.ApiError {
+	if !spec.Enabled || spec.Type != TypeSyslog {
+		return nil
+	}
+	if slices.Contains(reservedPorts, spec.Syslog.Port) {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("port %d is reserved for the OTLP receiver", spec.Syslog.Port),
+		}
+	}
+
+	logReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	for _, lr := range logReceivers {
+		if lr.Id == id || !lr.Spec.Enabled || lr.Spec.Type != TypeSyslog || lr.Spec.Syslog == nil {
+			continue
+		}
+		if lr.Spec.Syslog.Port == spec.Syslog.Port && lr.Spec.Syslog.Transport == spec.Syslog.Transport {
+			return &model.ApiError{
+				Typ: model.ErrorConflict,
+				Err: fmt.Errorf(
+					"%s port %d is used by the log receiver %s",
+					spec.Syslog.Transport, spec.Syslog.Port, lr.Name,
+				),
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	logReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(logReceivers))
+	for i, d := range logReceivers {
+		elements[i] = d.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogReceivers, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new log receivers config version")
+	}
+	return nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return LogReceiversFeatureType
+}
+
+// Implements agentConf.AgentGroupReferrer interface.
+func (c *Controller) AgentGroupReferences(ctx context.Context, group string) ([]string, *model.ApiError) {
+	logReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	names := []string{}
+	for _, lr := range logReceivers {
+		if lr.Spec.Selector.SelectsAgentGroup(group) {
+			names = append(names, lr.Name)
+		}
+	}
+	return names, nil
+}
+
+// Implements agentConf.AgentFeature interface. Only the log receivers
+// without a selector apply to agents recommended config for as a whole.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	return c.RecommendAgentConfigForAgent(opampModel.AgentInfo{}, currentConfYaml, configVersion)
+}
+
+// Implements agentConf.AgentScopedFeature interface. Agents get the log
+// receivers selecting them.
+func (c *Controller) RecommendAgentConfigForAgent(
+	agent opampModel.AgentInfo,
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	logReceivers, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithLogReceivers(currentConfYaml, logReceivers, agent)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for log receivers")
+	}
+
+	// the settings used are all the log receivers of the version, so that
+	// deployment status is tracked for the version as a whole
+	rawLogReceivers, err := json.Marshal(logReceivers)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize log receivers to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawLogReceivers), nil
+}
@@ -0,0 +1,211 @@
+package logreceivers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"golang.org/x/exp/slices"
+)
+
+const defaultListenAddress = "0.0.0.0"
+
+// Sources the agents can collect logs from
+const (
+	TypeFilelog  = "filelog"
+	TypeSyslog   = "syslog"
+	TypeJournald = "journald"
+)
+
+var supportedTypes = []string{TypeFilelog, TypeSyslog, TypeJournald}
+
+var supportedSyslogTransports = []string{"tcp", "udp"}
+
+var supportedSyslogProtocols = []string{"rfc5424", "rfc3164"}
+
+var supportedStartAt = []string{"beginning", "end"}
+
+var supportedJournaldPriorities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// LogReceiver makes agents collect logs from files, syslog or journald, so
+// that new log sources don't require editing the collector config on hosts
+type LogReceiver struct {
+	Id        string          `json:"id" db:"id"`
+	Name      string          `json:"name" db:"name"`
+	Spec      LogReceiverSpec `json:"spec" db:"spec_json"`
+	CreatedBy string          `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedBy string          `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+type LogReceiverSpec struct {
+	Enabled bool   `json:"enabled"`
+	Type    string `json:"type"`
+
+	// Selector limits the receiver to some agents, e.g. the hosts the
+	// files exist on. All agents get the receiver if empty.
+	Selector *logparsingpipeline.AgentSelector `json:"selector,omitempty"`
+
+	// Only the config of the receiver's type is used
+	Filelog  *FilelogConfig  `json:"filelog,omitempty"`
+	Syslog   *SyslogConfig   `json:"syslog,omitempty"`
+	Journald *JournaldConfig `json:"journald,omitempty"`
+}
+
+// FilelogConfig tails the files matching the include globs on the agents'
+// hosts
+type FilelogConfig struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude,omitempty"`
+	// StartAt is where new files are read from, the end if empty
+	StartAt string `json:"startAt,omitempty"`
+}
+
+// SyslogConfig listens for syslog messages
+type SyslogConfig struct {
+	Transport     string `json:"transport"`
+	Protocol      string `json:"protocol"`
+	ListenAddress string `json:"listenAddress,omitempty"`
+	Port          int    `json:"port"`
+}
+
+// JournaldConfig reads the systemd journal of the agents' hosts
+type JournaldConfig struct {
+	// Units to read the logs of, all units if empty
+	Units []string `json:"units,omitempty"`
+	// Directory of the journal, the default system journal if empty
+	Directory string `json:"directory,omitempty"`
+	// Priority is the lowest priority of the entries read, info if empty
+	Priority string `json:"priority,omitempty"`
+}
+
+// For serializing from db
+func (s *LogReceiverSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s LogReceiverSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize log receiver spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *LogReceiverSpec) setDefaults() {
+	if s.Syslog != nil && s.Syslog.ListenAddress == "" {
+		s.Syslog.ListenAddress = defaultListenAddress
+	}
+}
+
+func (s *SyslogConfig) endpoint() string {
+	return fmt.Sprintf("%s:%d", s.ListenAddress, s.Port)
+}
+
+type PostableLogReceiver struct {
+	Name string          `json:"name"`
+	Spec LogReceiverSpec `json:"spec"`
+}
+
+func (p *PostableLogReceiver) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("log receiver name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *LogReceiverSpec) IsValid() error {
+	if !slices.Contains(supportedTypes, s.Type) {
+		return fmt.Errorf("type must be one of %s", strings.Join(supportedTypes, ", "))
+	}
+	if s.Selector != nil {
+		if err := s.Selector.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case TypeFilelog:
+		if s.Filelog == nil {
+			return fmt.Errorf("filelog config is required for filelog receivers")
+		}
+		return s.Filelog.IsValid()
+	case TypeSyslog:
+		if s.Syslog == nil {
+			return fmt.Errorf("syslog config is required for syslog receivers")
+		}
+		return s.Syslog.IsValid()
+	default:
+		if s.Journald == nil {
+			// reading all units of the system journal
+			return nil
+		}
+		return s.Journald.IsValid()
+	}
+}
+
+func (c *FilelogConfig) IsValid() error {
+	if len(c.Include) == 0 {
+		return fmt.Errorf("filelog receivers must include at least one path")
+	}
+	for _, patterns := range [][]string{c.Include, c.Exclude} {
+		for _, p := range patterns {
+			if !filepath.IsAbs(p) {
+				return fmt.Errorf("path %q must be absolute", p)
+			}
+			if _, err := filepath.Match(p, ""); err != nil {
+				return fmt.Errorf("path %q is not a valid glob: %w", p, err)
+			}
+		}
+	}
+	if c.StartAt != "" && !slices.Contains(supportedStartAt, c.StartAt) {
+		return fmt.Errorf("startAt must be one of %s", strings.Join(supportedStartAt, ", "))
+	}
+	return nil
+}
+
+func (c *SyslogConfig) IsValid() error {
+	if !slices.Contains(supportedSyslogTransports, c.Transport) {
+		return fmt.Errorf("syslog transport must be one of %s", strings.Join(supportedSyslogTransports, ", "))
+	}
+	if !slices.Contains(supportedSyslogProtocols, c.Protocol) {
+		return fmt.Errorf("syslog protocol must be one of %s", strings.Join(supportedSyslogProtocols, ", "))
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if strings.ContainsAny(c.ListenAddress, ": ") {
+		return fmt.Errorf("listenAddress must be a host without a port")
+	}
+	return nil
+}
+
+func (c *JournaldConfig) IsValid() error {
+	for _, u := range c.Units {
+		if strings.TrimSpace(u) == "" {
+			return fmt.Errorf("journald units cannot be empty")
+		}
+	}
+	if c.Directory != "" && !filepath.IsAbs(c.Directory) {
+		return fmt.Errorf("journald directory must be absolute")
+	}
+	if c.Priority != "" && !slices.Contains(supportedJournaldPriorities, c.Priority) {
+		return fmt.Errorf("journald priority must be one of %s", strings.Join(supportedJournaldPriorities, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package logreceivers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS log_receivers(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure log receivers schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for log receivers: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]LogReceiver, *model.ApiError) {
+	logReceivers := []LogReceiver{}
+
+	err := r.db.SelectContext(ctx, &logReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM log_receivers
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log receivers: %w", err,
+		))
+	}
+	return logReceivers, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*LogReceiver, *model.ApiError) {
+	logReceivers := []LogReceiver{}
+
+	err := r.db.SelectContext(ctx, &logReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM log_receivers
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log receiver %s: %w", id, err,
+		))
+	}
+
+	if len(logReceivers) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("log receiver %s not found", id))
+	}
+	return &logReceivers[0], nil
+}
+
+// getByVersion returns log receivers associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]LogReceiver, *model.ApiError) {
+	logReceivers := []LogReceiver{}
+
+	err := r.db.SelectContext(ctx, &logReceivers, `
+		SELECT l.id, l.name, l.spec_json, l.created_by, l.created_at, l.updated_by, l.updated_at
+		FROM log_receivers l,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE l.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY l.name
+	`, agentConf.ElementTypeLogReceivers, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query log receivers for version %d: %w", version, err,
+		))
+	}
+	return logReceivers, nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM log_receivers WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query log receivers: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a log receiver named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableLogReceiver,
+) (*LogReceiver, *model.ApiError) {
+	now := time.Now()
+	logReceiver := &LogReceiver{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, logReceiver.Name, logReceiver.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO log_receivers (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		logReceiver.Id, logReceiver.Name, logReceiver.Spec,
+		logReceiver.CreatedBy, logReceiver.CreatedAt,
+		logReceiver.UpdatedBy, logReceiver.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert log receiver: %w", err,
+		))
+	}
+
+	return logReceiver, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, logReceiver *LogReceiver,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, logReceiver.Name, logReceiver.Id); apiErr != nil {
+		return apiErr
+	}
+
+	logReceiver.UpdatedBy = userId
+	logReceiver.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE log_receivers
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		logReceiver.Name, logReceiver.Spec,
+		logReceiver.UpdatedBy, logReceiver.UpdatedAt, logReceiver.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update log receiver %s: %w", logReceiver.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM log_receivers WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete log receiver %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -226,6 +226,12 @@ func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey,
 	return queryString, nil
 }
 
+// BuildLogsFilterQuery returns the where clause conditions selecting the
+// logs matching the filters, for queries not made by the query builder
+func BuildLogsFilterQuery(fs *v3.FilterSet) (string, error) {
+	return buildLogsTimeSeriesFilterQuery(fs, nil, v3.AttributeKey{})
+}
+
 func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.BuilderQuery, graphLimitQtype string, preferRPM bool) (string, error) {
 
 	filterSubQuery, err := buildLogsTimeSeriesFilterQuery(mq.Filters, mq.GroupBy, mq.AggregateAttribute)
@@ -237,9 +243,13 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	}
 
 	// timerange will be sent in epoch millisecond
-	timeFilter := fmt.Sprintf("(timestamp >= %d AND timestamp <= %d)", utils.GetEpochNanoSecs(start), utils.GetEpochNanoSecs(end))
+	timeFilter := fmt.Sprintf("(timestamp >= %s AND timestamp <= %s)", utils.ClickHouseFormattedTimestampNano(mq, start), utils.ClickHouseFormattedTimestampNano(mq, end))
 
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
+	rollup := panelType == v3.PanelTypeTable && mq.GroupByRollup && len(mq.GroupBy) > 0
+	if rollup {
+		selectLabels += utils.RollupLevelLabel(mq.GroupBy)
+	}
 
 	having := having(mq.Having)
 	if having != "" {
@@ -276,6 +286,9 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	if panelType != v3.PanelTypeList && groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if rollup {
+		groupBy = " group by ROLLUP(" + groupByAttributeKeyTags(panelType, graphLimitQtype, mq.GroupBy...) + ")"
+	}
 	orderBy := orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
 	if panelType != v3.PanelTypeList && orderBy != "" {
 		orderBy = " order by " + orderBy
@@ -287,7 +300,7 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 
 	aggregationKey := ""
 	if mq.AggregateAttribute.Key != "" {
-		aggregationKey = getClickhouseColumnName(mq.AggregateAttribute)
+		aggregationKey = castAggregationKey(getClickhouseColumnName(mq.AggregateAttribute), mq.CastAggregateAttribute)
 	}
 
 	switch mq.AggregateOperator {
@@ -347,6 +360,44 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	}
 }
 
+// castAggregationKey wraps the column in a cast to the given type, values
+// which can't be parsed become NULL and are skipped by the aggregate functions
+func castAggregationKey(column string, castTo v3.AttributeKeyDataType) string {
+	switch castTo {
+	case v3.AttributeKeyDataTypeFloat64:
+		return fmt.Sprintf("toFloat64OrNull(%s)", column)
+	case v3.AttributeKeyDataTypeInt64:
+		return fmt.Sprintf("toInt64OrNull(%s)", column)
+	default:
+		return column
+	}
+}
+
+// PrepareLogsCastFailuresQuery prepares a query counting the rows whose aggregate
+// attribute could not be cast, start and end are in epoch millisecond
+func PrepareLogsCastFailuresQuery(start, end int64, mq *v3.BuilderQuery) (string, error) {
+	if mq.CastAggregateAttribute == "" || mq.AggregateAttribute.Key == "" {
+		return "", fmt.Errorf("aggregate attribute is not cast")
+	}
+
+	filterSubQuery, err := buildLogsTimeSeriesFilterQuery(mq.Filters, mq.GroupBy, mq.AggregateAttribute)
+	if err != nil {
+		return "", err
+	}
+	if len(filterSubQuery) > 0 {
+		filterSubQuery = " AND " + filterSubQuery
+	}
+
+	timeFilter := fmt.Sprintf("(timestamp >= %s AND timestamp <= %s)", utils.ClickHouseFormattedTimestampNano(mq, start), utils.ClickHouseFormattedTimestampNano(mq, end))
+	castKey := castAggregationKey(getClickhouseColumnName(mq.AggregateAttribute), mq.CastAggregateAttribute)
+
+	query := fmt.Sprintf(
+		"SELECT now() as ts, toFloat64(countIf(isNull(%s))) as value from signoz_logs.distributed_logs where %s%s",
+		castKey, timeFilter, filterSubQuery,
+	)
+	return query, nil
+}
+
 func buildLogsLiveTailQuery(mq *v3.BuilderQuery) (string, error) {
 	filterSubQuery, err := buildLogsTimeSeriesFilterQuery(mq.Filters, mq.GroupBy, v3.AttributeKey{})
 	if err != nil {
@@ -901,6 +901,25 @@ var testBuildLogsQueryData = []struct {
 		TableName:     "logs",
 		ExpectedQuery: "SELECT now() as ts, attributes_string_value[indexOf(attributes_string_key, 'name')] as `name`, toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND has(attributes_string_key, 'name') group by `name` order by value DESC",
 	},
+	{
+		Name:      "TABLE: Test count with groupBy rollup",
+		PanelType: v3.PanelTypeTable,
+		Start:     1680066360726210000,
+		End:       1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:         "A",
+			StepInterval:      60,
+			AggregateOperator: v3.AggregateOperatorCount,
+			Expression:        "A",
+			GroupBy: []v3.AttributeKey{
+				{Key: "k8s_namespace", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+				{Key: "k8s_pod", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+			},
+			GroupByRollup: true,
+		},
+		TableName:     "logs",
+		ExpectedQuery: "SELECT now() as ts, resources_string_value[indexOf(resources_string_key, 'k8s_namespace')] as `k8s_namespace`, resources_string_value[indexOf(resources_string_key, 'k8s_pod')] as `k8s_pod`, toString(2 - bitCount(GROUPING(`k8s_namespace`, `k8s_pod`))) as `__rollup_level`, toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND has(resources_string_key, 'k8s_namespace') AND has(resources_string_key, 'k8s_pod') group by ROLLUP(`k8s_namespace`,`k8s_pod`) order by value DESC",
+	},
 	{
 		Name:      "TABLE: Test count with groupBy, orderBy",
 		PanelType: v3.PanelTypeTable,
@@ -989,6 +1008,38 @@ var testBuildLogsQueryData = []struct {
 		TableName:     "logs",
 		ExpectedQuery: "SELECT now() as ts, attributes_string_value[indexOf(attributes_string_key, 'name')] as `name`, toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND has(JSONExtract(JSON_QUERY(body, '$.\"requestor_list\"[*]'), 'Array(String)'), 'index_service') AND has(attributes_string_key, 'name') group by `name` order by `name` DESC",
 	},
+	{
+		Name:      "Test aggregate avg on a string attribute cast to float64",
+		PanelType: v3.PanelTypeGraph,
+		Start:     1680066360726210000,
+		End:       1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:              "A",
+			StepInterval:           60,
+			AggregateAttribute:     v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+			AggregateOperator:      v3.AggregateOperatorAvg,
+			CastAggregateAttribute: v3.AttributeKeyDataTypeFloat64,
+			Expression:             "A",
+		},
+		TableName:     "logs",
+		ExpectedQuery: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, avg(toFloat64OrNull(attributes_string_value[indexOf(attributes_string_key, 'bytes')])) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND has(attributes_string_key, 'bytes') group by ts order by value DESC",
+	},
+	{
+		Name:      "Test aggregate p99 on a string attribute cast to int64",
+		PanelType: v3.PanelTypeGraph,
+		Start:     1680066360726210000,
+		End:       1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:              "A",
+			StepInterval:           60,
+			AggregateAttribute:     v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+			AggregateOperator:      v3.AggregateOperatorP99,
+			CastAggregateAttribute: v3.AttributeKeyDataTypeInt64,
+			Expression:             "A",
+		},
+		TableName:     "logs",
+		ExpectedQuery: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, quantile(0.99)(toInt64OrNull(attributes_string_value[indexOf(attributes_string_key, 'bytes')])) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND has(attributes_string_key, 'bytes') group by ts order by value DESC",
+	},
 }
 
 func TestBuildLogsQuery(t *testing.T) {
@@ -1002,6 +1053,26 @@ func TestBuildLogsQuery(t *testing.T) {
 	}
 }
 
+func TestPrepareLogsCastFailuresQuery(t *testing.T) {
+	Convey("TestPrepareLogsCastFailuresQuery", t, func() {
+		mq := &v3.BuilderQuery{
+			QueryName:              "A",
+			StepInterval:           60,
+			AggregateAttribute:     v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+			AggregateOperator:      v3.AggregateOperatorSum,
+			CastAggregateAttribute: v3.AttributeKeyDataTypeFloat64,
+			Expression:             "A",
+		}
+		query, err := PrepareLogsCastFailuresQuery(1680066360726, 1680066458000, mq)
+		So(err, ShouldBeNil)
+		So(query, ShouldEqual, "SELECT now() as ts, toFloat64(countIf(isNull(toFloat64OrNull(attributes_string_value[indexOf(attributes_string_key, 'bytes')])))) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726000000 AND timestamp <= 1680066458000000000) AND has(attributes_string_key, 'bytes')")
+
+		mq.CastAggregateAttribute = ""
+		_, err = PrepareLogsCastFailuresQuery(1680066360726, 1680066458000, mq)
+		So(err, ShouldNotBeNil)
+	})
+}
+
 var testOrderBy = []struct {
 	Name      string
 	PanelType v3.PanelType
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/logs"
+	"go.signoz.io/signoz/pkg/query-service/app/parser"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// rangeStartOf reads the start of the range of data a request queries
+type rangeStartOf func(aH *APIHandler, r *http.Request) (time.Time, error)
+
+// lookbackLimitedRoutes are the routes querying data, by path template, and
+// how to read the start of the range they query. They are limited by the
+// lookback limits of the roles of the users.
+var lookbackLimitedRoutes = map[string]rangeStartOf{
+	"/api/v1/query_range": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, apiErr := parseQueryRangeRequest(r)
+		if apiErr != nil {
+			return time.Time{}, apiErr.ToError()
+		}
+		return params.Start, nil
+	},
+	"/api/v1/query": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, apiErr := parseInstantQueryMetricsRequest(r)
+		if apiErr != nil {
+			return time.Time{}, apiErr.ToError()
+		}
+		return params.Time, nil
+	},
+	"/api/v2/metrics/query_range": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, apiErr := parser.ParseMetricQueryRangeParams(r)
+		if apiErr != nil {
+			return time.Time{}, apiErr.ToError()
+		}
+		return time.UnixMilli(params.Start), nil
+	},
+	"/api/v3/query_range":         queryRangeStart,
+	"/api/v3/query_range/format":  queryRangeStart,
+	"/api/v3/query_range/compare": comparedQueryRangeStart,
+	"/api/v3/slo/budget_forecast": sloBudgetForecastStart,
+	"/api/v4/query_range":         queryRangeStart,
+	"/api/v1/logs": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := logs.ParseLogFilterParams(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(params.TimestampStart)), nil
+	},
+	"/api/v1/logs/aggregate": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := logs.ParseLogAggregateParams(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(params.TimestampStart)), nil
+	},
+	"/api/v1/getFilteredSpans": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseFilteredSpansRequest(r, aH)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/getFilteredSpans/aggregates": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseFilteredSpanAggregatesRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/getSpanFilters": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseSpanFilterRequestBody(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/getTagFilters": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseTagFilterRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/getTagValues": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseTagValueRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/listErrors": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseListErrorsRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/countErrors": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseCountErrorsRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/services":         servicesStart,
+	"/api/v1/dependency_graph": servicesStart,
+	"/api/v1/service/overview": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseGetServiceOverviewRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/service/top_operations": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseGetTopOperationsRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/service/database_calls": dependencyCallsStart,
+	"/api/v1/service/external_calls": dependencyCallsStart,
+	"/api/v1/service/latency_breakdown": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseGetLatencyBreakdownRequest(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return startOrEpoch(params.Start), nil
+	},
+	"/api/v1/k8s/pods/timeline": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseK8sPodTimelineParams(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return params.Start, nil
+	},
+	"/api/v1/sessions/{id}/timeline": func(aH *APIHandler, r *http.Request) (time.Time, error) {
+		params, err := parseSessionTimelineParams(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return params.Start, nil
+	},
+	"/api/v1/rules/{id}/explain": ruleExplanationStart,
+}
+
+func startOrEpoch(start *time.Time) time.Time {
+	if start == nil {
+		return time.Unix(0, 0)
+	}
+	return *start
+}
+
+func queryRangeStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	params := struct {
+		Start int64 `json:"start"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(params.Start), nil
+}
+
+// comparedQueryRangeStart is the earliest start of the compared ranges
+func comparedQueryRangeStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	params := struct {
+		Start        int64 `json:"start"`
+		CompareStart int64 `json:"compareStart"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(min(params.Start, params.CompareStart)), nil
+}
+
+func sloBudgetForecastStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	params := struct {
+		SLO struct {
+			WindowStart int64 `json:"windowStart"`
+		} `json:"slo"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(params.SLO.WindowStart), nil
+}
+
+func servicesStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	params, err := parseGetServicesRequest(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return startOrEpoch(params.Start), nil
+}
+
+func dependencyCallsStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	params, err := parseGetDependencyCallsRequest(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return startOrEpoch(params.Start), nil
+}
+
+// ruleExplanationStart is the start of the evaluation window of the rule at
+// the explained timestamp
+func ruleExplanationStart(aH *APIHandler, r *http.Request) (time.Time, error) {
+	ts := time.Now()
+	if r.URL.Query().Get("ts") != "" {
+		parsed, err := parseTime("ts", r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		ts = *parsed
+	}
+	if aH.ruleManager == nil {
+		return ts, nil
+	}
+	rule, err := aH.ruleManager.GetRule(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.Add(-time.Duration(rule.EvalWindow)), nil
+}
+
+// LookbackLimitMiddleware rejects the requests of users querying further
+// back than the lookback limit of their role. Requests which can't be read
+// are let through for their handlers to respond with the error.
+func (aH *APIHandler) LookbackLimitMiddleware(am *AuthMiddleware) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if aH.QueryLimitsController == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			path, _ := mux.CurrentRoute(r).GetPathTemplate()
+			startOf, ok := lookbackLimitedRoutes[path]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			user, err := am.GetUserFromRequest(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// the body is read again by the handler
+			var body []byte
+			if r.Body != nil {
+				body, err = io.ReadAll(r.Body)
+				if err != nil {
+					RespondError(w, model.BadRequest(err), nil)
+					return
+				}
+				r.Body.Close()
+			}
+			parsed := r.Clone(r.Context())
+			parsed.Body = io.NopCloser(bytes.NewReader(body))
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			start, err := startOf(aH, parsed)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
+			if apiErr := aH.QueryLimitsController.EnsureWithinLimit(ctx, start); apiErr != nil {
+				RespondError(w, apiErr, nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
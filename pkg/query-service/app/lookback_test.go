@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// lookbackReader answers the logs and spans queries reaching the reader
+type lookbackReader struct {
+	interfaces.Reader
+}
+
+func (r *lookbackReader) GetLogs(ctx context.Context, params *model.LogsFilterParams) (*[]model.SignozLog, *model.ApiError) {
+	return &[]model.SignozLog{}, nil
+}
+
+func (r *lookbackReader) GetFilteredSpans(ctx context.Context, query *model.GetFilteredSpansParams) (*model.GetFilterSpansResponse, *model.ApiError) {
+	return &model.GetFilterSpansResponse{}, nil
+}
+
+func newTestQueryLimitsController(t *testing.T) *querylimits.Controller {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+
+	testDB, err := sqlx.Open("sqlite3", testDBFilePath)
+	require.Nil(t, err)
+	controller, err := querylimits.NewController(testDB)
+	require.Nil(t, err)
+	return controller
+}
+
+// newLookbackLimitedRouter serves the APIs as the viewer or admin named in
+// the X-Test-User header, with the lookback of viewers limited to a week
+func newLookbackLimitedRouter(t *testing.T) (*mux.Router, *APIHandler, *AuthMiddleware) {
+	auth.AuthCacheObj.AdminGroupId = "admins"
+	auth.AuthCacheObj.ViewerGroupId = "viewers"
+	users := map[string]*model.UserPayload{
+		"viewer": {User: model.User{Id: "viewer1", OrgId: "org1", GroupId: "viewers"}},
+		"admin":  {User: model.User{Id: "admin1", OrgId: "org1", GroupId: "admins"}},
+	}
+
+	controller := newTestQueryLimitsController(t)
+	adminJwt, err := auth.GenerateJWTForUser(&model.User{Id: "admin1", Email: "admin1@signoz.io"})
+	require.Nil(t, err)
+	limitRequest := httptest.NewRequest(http.MethodPut, "/api/v1/query_limits", nil)
+	limitRequest.Header.Add("Authorization", "Bearer "+adminJwt.AccessJwt)
+	adminCtx := context.WithValue(
+		auth.AttachJwtToContext(context.Background(), limitRequest),
+		constants.ContextUserKey, users["admin"],
+	)
+	_, apiErr := controller.SetLimit(adminCtx, &querylimits.PostableLookbackLimit{
+		Role: constants.ViewerGroup, MaxLookbackHours: 7 * 24,
+	})
+	require.Nil(t, apiErr)
+
+	aH := &APIHandler{reader: &lookbackReader{}, QueryLimitsController: controller}
+	am := NewAuthMiddleware(func(r *http.Request) (*model.UserPayload, error) {
+		user, ok := users[r.Header.Get("X-Test-User")]
+		if !ok {
+			return nil, fmt.Errorf("unknown user")
+		}
+		return user, nil
+	})
+	router := mux.NewRouter()
+	router.Use(aH.LookbackLimitMiddleware(am))
+	aH.RegisterRoutes(router, am)
+	aH.RegisterLogsRoutes(router, am)
+	aH.RegisterQueryRangeV3Routes(router, am)
+	aH.RegisterQueryRangeV4Routes(router, am)
+	return router, aH, am
+}
+
+func TestLookbackLimitMiddleware(t *testing.T) {
+	router, aH, am := newLookbackLimitedRouter(t)
+	// the metrics query range v2 is served by the enterprise handler
+	metricsQueries := 0
+	router.HandleFunc("/api/v2/metrics/query_range", am.ViewAccess(func(w http.ResponseWriter, r *http.Request) {
+		metricsQueries++
+		aH.Respond(w, nil)
+	})).Methods(http.MethodPost)
+
+	monthAgo := time.Now().Add(-30 * 24 * time.Hour)
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	requests := map[string]func(start time.Time) *http.Request{
+		"v2 metrics": func(start time.Time) *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/api/v2/metrics/query_range", strings.NewReader(fmt.Sprintf(
+				`{"start": %d, "end": %d, "step": 60, "dataSource": 1, "compositeMetricQuery": {"queryType": 3, "panelType": 1, "promQueries": {"A": {"query": "up"}}}}`,
+				start.UnixMilli(), time.Now().UnixMilli(),
+			)))
+		},
+		"logs": func(start time.Time) *http.Request {
+			return httptest.NewRequest(http.MethodGet, fmt.Sprintf(
+				"/api/v1/logs?timestampStart=%d&timestampEnd=%d", start.UnixNano(), time.Now().UnixNano(),
+			), nil)
+		},
+		"spans": func(start time.Time) *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/api/v1/getFilteredSpans", strings.NewReader(fmt.Sprintf(
+				`{"start": "%d", "end": "%d"}`, start.UnixNano(), time.Now().UnixNano(),
+			)))
+		},
+	}
+
+	for api, newRequest := range requests {
+		t.Run(api, func(t *testing.T) {
+			serve := func(user string, start time.Time) *httptest.ResponseRecorder {
+				request := newRequest(start)
+				request.Header.Set("X-Test-User", user)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, request)
+				return w
+			}
+
+			w := serve("viewer", monthAgo)
+			require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+			require.Contains(t, w.Body.String(), "at most the last 168 hours")
+
+			w = serve("viewer", dayAgo)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			w = serve("admin", monthAgo)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		})
+	}
+	require.Equal(t, 2, metricsQueries, "only the metrics queries within the limit should reach the handler")
+}
+
+func TestLookbackLimitedRoutes(t *testing.T) {
+	router, _, _ := newLookbackLimitedRouter(t)
+
+	monthAgo := time.Now().Add(-30 * 24 * time.Hour)
+	now := time.Now()
+	ns := func(ts time.Time) string { return fmt.Sprint(ts.UnixNano()) }
+	ms := func(ts time.Time) int64 { return ts.UnixMilli() }
+
+	// the viewers are refused before reaching the handlers, which have no
+	// reader to query
+	requests := map[string]*http.Request{
+		"database calls": httptest.NewRequest(http.MethodPost, "/api/v1/service/database_calls", strings.NewReader(fmt.Sprintf(
+			`{"start": "%s", "end": "%s", "service": "frontend"}`, ns(monthAgo), ns(now),
+		))),
+		"external calls": httptest.NewRequest(http.MethodPost, "/api/v1/service/external_calls", strings.NewReader(fmt.Sprintf(
+			`{"start": "%s", "end": "%s", "service": "frontend"}`, ns(monthAgo), ns(now),
+		))),
+		"latency breakdown": httptest.NewRequest(http.MethodPost, "/api/v1/service/latency_breakdown", strings.NewReader(fmt.Sprintf(
+			`{"start": "%s", "end": "%s", "service": "frontend", "operation": "GET /", "attribute": "http.route"}`, ns(monthAgo), ns(now),
+		))),
+		"pod timeline": httptest.NewRequest(http.MethodGet, fmt.Sprintf(
+			"/api/v1/k8s/pods/timeline?namespace=default&pod=frontend&start=%d&end=%d", ms(monthAgo), ms(now),
+		), nil),
+		"session timeline": httptest.NewRequest(http.MethodGet, fmt.Sprintf(
+			"/api/v1/sessions/session1/timeline?start=%d&end=%d", ms(monthAgo), ms(now),
+		), nil),
+		"rule explanation": httptest.NewRequest(http.MethodGet, fmt.Sprintf(
+			"/api/v1/rules/1/explain?ts=%s", ns(monthAgo),
+		), nil),
+		"v3 query range": httptest.NewRequest(http.MethodPost, "/api/v3/query_range", strings.NewReader(fmt.Sprintf(
+			`{"start": %d, "end": %d}`, ms(monthAgo), ms(now),
+		))),
+		"v3 compared query range": httptest.NewRequest(http.MethodPost, "/api/v3/query_range/compare", strings.NewReader(fmt.Sprintf(
+			`{"start": %d, "end": %d, "compareStart": %d, "compareEnd": %d}`,
+			ms(now.Add(-time.Hour)), ms(now), ms(monthAgo), ms(monthAgo.Add(time.Hour)),
+		))),
+		"slo budget forecast": httptest.NewRequest(http.MethodPost, "/api/v3/slo/budget_forecast", strings.NewReader(fmt.Sprintf(
+			`{"slo": {"windowStart": %d}}`, ms(monthAgo),
+		))),
+		"v4 query range": httptest.NewRequest(http.MethodPost, "/api/v4/query_range", strings.NewReader(fmt.Sprintf(
+			`{"start": %d, "end": %d}`, ms(monthAgo), ms(now),
+		))),
+	}
+	for api, request := range requests {
+		t.Run(api, func(t *testing.T) {
+			request.Header.Set("X-Test-User", "viewer")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+			require.Contains(t, w.Body.String(), "at most the last 168 hours")
+		})
+	}
+}
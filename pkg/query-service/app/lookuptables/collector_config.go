@@ -0,0 +1,123 @@
+package lookuptables
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+const enrichmentProcessorName = "transform/signoz_lookup_tables"
+
+// signals enriched with lookup table attributes
+var enrichedPipelines = []string{"traces", "logs"}
+
+// GenerateCollectorConfigWithLookupTables adds a transform processor that
+// sets lookup table attributes on resources carrying a matching key to the
+// traces and logs pipelines of the collector config. The processor is
+// removed when there are no lookup tables.
+func GenerateCollectorConfigWithLookupTables(
+	config []byte, tables []LookupTable,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	processors, ok := c["processors"].(map[string]interface{})
+	if !ok || processors == nil {
+		processors = map[string]interface{}{}
+	}
+
+	statements := enrichmentStatements(tables)
+	if len(statements) > 0 {
+		processors[enrichmentProcessorName] = map[string]interface{}{
+			"error_mode": "ignore",
+			"trace_statements": []interface{}{
+				map[string]interface{}{"context": "resource", "statements": statements},
+			},
+			"log_statements": []interface{}{
+				map[string]interface{}{"context": "resource", "statements": statements},
+			},
+		}
+	} else {
+		delete(processors, enrichmentProcessorName)
+	}
+	c["processors"] = processors
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	for _, name := range enrichedPipelines {
+		pipeline, ok := pipelines[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		current, _ := pipeline["processors"].([]interface{})
+		updated := []interface{}{}
+		for _, p := range current {
+			if p != enrichmentProcessorName {
+				updated = append(updated, p)
+			}
+		}
+		if len(statements) > 0 {
+			// enrich before any other processing so that processors
+			// down the line can make use of the added attributes
+			updated = append([]interface{}{enrichmentProcessorName}, updated...)
+		}
+		pipeline["processors"] = updated
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+// enrichmentStatements translates lookup table rows to OTTL statements.
+// Attributes already present on the resource are never overwritten.
+func enrichmentStatements(tables []LookupTable) []interface{} {
+	statements := []interface{}{}
+	for _, t := range tables {
+		key := ottlAttribute(t.Entries.KeyAttribute)
+		for _, row := range t.Entries.Rows {
+			for i, attr := range t.Entries.Attributes {
+				if i+1 >= len(row) || row[i+1] == "" {
+					continue
+				}
+				target := ottlAttribute(attr)
+				statements = append(statements, fmt.Sprintf(
+					"set(%s, %s) where %s == %s and %s == nil",
+					target, ottlString(row[i+1]), key, ottlString(row[0]), target,
+				))
+			}
+		}
+	}
+	return statements
+}
+
+func ottlAttribute(name string) string {
+	return fmt.Sprintf("attributes[%s]", ottlString(name))
+}
+
+func ottlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	// escape `$`s so that they do not get treated as env vars when loading collector config
+	s = strings.ReplaceAll(s, "$", "$$")
+	return fmt.Sprintf(`"%s"`, s)
+}
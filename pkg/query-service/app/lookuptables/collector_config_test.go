@@ -0,0 +1,113 @@
+package lookuptables
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhousetraces: {}
+  clickhouselogsexporter: {}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces]
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhouselogsexporter]
+`
+
+type testConf struct {
+	Processors map[string]struct {
+		TraceStatements []struct {
+			Statements []string `yaml:"statements"`
+		} `yaml:"trace_statements"`
+	} `yaml:"processors"`
+	Service struct {
+		Pipelines map[string]struct {
+			Processors []string `yaml:"processors"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func TestParseCSV(t *testing.T) {
+	require := require.New(t)
+
+	entries, err := ParseCSV(strings.NewReader(
+		"service.name, team, owner\ncheckout,payments,alice\nfrontend,web,bob\n",
+	))
+	require.Nil(err)
+	require.Equal("service.name", entries.KeyAttribute)
+	require.Equal([]string{"team", "owner"}, entries.Attributes)
+	require.Equal(2, len(entries.Rows))
+
+	_, err = ParseCSV(strings.NewReader("service.name\ncheckout\n"))
+	require.NotNil(err, "a key column alone should be rejected")
+
+	_, err = ParseCSV(strings.NewReader("service.name,team\ncheckout,payments\ncheckout,web\n"))
+	require.NotNil(err, "duplicate keys should be rejected")
+}
+
+func TestGenerateCollectorConfigWithLookupTables(t *testing.T) {
+	require := require.New(t)
+
+	tables := []LookupTable{{
+		Id:   "1",
+		Name: "service owners",
+		Entries: LookupTableEntries{
+			KeyAttribute: "service.name",
+			Attributes:   []string{"team", "cost_center"},
+			Rows: [][]string{
+				{"checkout", `pay"ments`, "$100"},
+				{"frontend", "web", ""},
+			},
+		},
+	}}
+
+	updated, apiErr := GenerateCollectorConfigWithLookupTables([]byte(testCollectorConf), tables)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+
+	for _, pipeline := range []string{"traces", "logs"} {
+		require.Equal(
+			[]string{enrichmentProcessorName, "batch"},
+			conf.Service.Pipelines[pipeline].Processors,
+		)
+	}
+
+	processor, ok := conf.Processors[enrichmentProcessorName]
+	require.True(ok)
+	require.Equal([]string{
+		`set(attributes["team"], "pay\"ments") where attributes["service.name"] == "checkout" and attributes["team"] == nil`,
+		`set(attributes["cost_center"], "$$100") where attributes["service.name"] == "checkout" and attributes["cost_center"] == nil`,
+		`set(attributes["team"], "web") where attributes["service.name"] == "frontend" and attributes["team"] == nil`,
+	}, processor.TraceStatements[0].Statements)
+
+	// generating again should not add the processor twice and
+	// removing all tables should remove the processor
+	updated, apiErr = GenerateCollectorConfigWithLookupTables(updated, tables)
+	require.Nil(apiErr)
+	updated, apiErr = GenerateCollectorConfigWithLookupTables(updated, nil)
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	_, ok = conf.Processors[enrichmentProcessorName]
+	require.False(ok)
+	for _, pipeline := range []string{"traces", "logs"} {
+		require.Equal([]string{"batch"}, conf.Service.Pipelines[pipeline].Processors)
+	}
+}
@@ -0,0 +1,164 @@
+package lookuptables
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const LookupTablesFeatureType agentConf.AgentFeatureType = "lookup_tables"
+
+// Controller manages lookup tables and deploys the enrichment
+// config derived from them to agents via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create lookup tables repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type LookupTablesResponse struct {
+	*agentConf.ConfigVersion
+
+	LookupTables []LookupTable `json:"lookupTables"`
+}
+
+func (c *Controller) ListLookupTables(ctx context.Context) (
+	*LookupTablesResponse, *model.ApiError,
+) {
+	tables, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLookupTables)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest lookup tables config version")
+	}
+
+	return &LookupTablesResponse{
+		ConfigVersion: latest,
+		LookupTables:  tables,
+	}, nil
+}
+
+func (c *Controller) GetLookupTable(ctx context.Context, id string) (
+	*LookupTable, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateLookupTable stores a new lookup table and starts deploying
+// an agent config that includes it
+func (c *Controller) CreateLookupTable(
+	ctx context.Context, postable *PostableLookupTable,
+) (*LookupTable, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	table, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, table.Id)
+		return nil, apiErr
+	}
+
+	return table, nil
+}
+
+// DeleteLookupTable removes a lookup table and starts deploying
+// an agent config without it
+func (c *Controller) DeleteLookupTable(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	tables, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(tables))
+	for i, t := range tables {
+		elements[i] = t.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLookupTables, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new lookup tables config version")
+	}
+	return nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return LookupTablesFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	tables, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithLookupTables(currentConfYaml, tables)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for lookup tables")
+	}
+
+	rawTables, err := json.Marshal(tables)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize lookup tables to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawTables), nil
+}
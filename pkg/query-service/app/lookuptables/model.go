@@ -0,0 +1,154 @@
+package lookuptables
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// limits to keep the generated collector config to a reasonable size
+const (
+	maxLookupTableRows    = 5000
+	maxLookupTableColumns = 20
+)
+
+// LookupTable maps values of a key attribute to a set of attributes
+// that get added to spans and logs at ingest time.
+// Eg: service.name -> team, owner, tier
+type LookupTable struct {
+	Id        string             `json:"id" db:"id"`
+	Name      string             `json:"name" db:"name"`
+	Entries   LookupTableEntries `json:"entries" db:"entries_json"`
+	CreatedBy string             `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time          `json:"createdAt" db:"created_at"`
+}
+
+// LookupTableEntries holds the parsed contents of an uploaded CSV.
+// The first column is the key attribute, the rest are the attributes
+// to be set for telemetry carrying a matching key.
+type LookupTableEntries struct {
+	KeyAttribute string     `json:"keyAttribute"`
+	Attributes   []string   `json:"attributes"`
+	Rows         [][]string `json:"rows"`
+}
+
+// For serializing from db
+func (e *LookupTableEntries) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, e)
+	case string:
+		return json.Unmarshal([]byte(data), e)
+	}
+	return nil
+}
+
+// For serializing to db
+func (e LookupTableEntries) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize lookup table entries to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableLookupTable struct {
+	Name    string             `json:"name"`
+	Entries LookupTableEntries `json:"entries"`
+}
+
+func (p *PostableLookupTable) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("lookup table name is required")
+	}
+	return p.Entries.IsValid()
+}
+
+func (e *LookupTableEntries) IsValid() error {
+	if strings.TrimSpace(e.KeyAttribute) == "" {
+		return fmt.Errorf("key attribute is required")
+	}
+	if len(e.Attributes) == 0 {
+		return fmt.Errorf("at least one attribute is required in addition to the key attribute")
+	}
+	if len(e.Attributes) > maxLookupTableColumns {
+		return fmt.Errorf("lookup table can not have more than %d attributes", maxLookupTableColumns)
+	}
+	if len(e.Rows) == 0 {
+		return fmt.Errorf("lookup table has no rows")
+	}
+	if len(e.Rows) > maxLookupTableRows {
+		return fmt.Errorf("lookup table can not have more than %d rows", maxLookupTableRows)
+	}
+
+	seenAttributes := map[string]struct{}{e.KeyAttribute: {}}
+	for _, attr := range e.Attributes {
+		if strings.TrimSpace(attr) == "" {
+			return fmt.Errorf("attribute names can not be empty")
+		}
+		if _, ok := seenAttributes[attr]; ok {
+			return fmt.Errorf("attribute %s is specified more than once", attr)
+		}
+		seenAttributes[attr] = struct{}{}
+	}
+
+	seenKeys := map[string]struct{}{}
+	for i, row := range e.Rows {
+		if len(row) != len(e.Attributes)+1 {
+			return fmt.Errorf(
+				"row %d has %d values, expected %d", i+1, len(row), len(e.Attributes)+1,
+			)
+		}
+		if row[0] == "" {
+			return fmt.Errorf("row %d has an empty key", i+1)
+		}
+		if _, ok := seenKeys[row[0]]; ok {
+			return fmt.Errorf("key %s is specified more than once", row[0])
+		}
+		seenKeys[row[0]] = struct{}{}
+	}
+
+	return nil
+}
+
+// ParseCSV reads lookup table entries from a CSV with a header row.
+// Eg:
+//
+//	service.name,team,owner
+//	checkout,payments,alice@example.com
+func ParseCSV(r io.Reader) (*LookupTableEntries, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse csv")
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv is empty")
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if len(header) < 2 {
+		return nil, fmt.Errorf("csv header must have a key column followed by at least one attribute column")
+	}
+
+	entries := &LookupTableEntries{
+		KeyAttribute: header[0],
+		Attributes:   header[1:],
+		Rows:         records[1:],
+	}
+	if err := entries.IsValid(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,164 @@
+package lookuptables
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS lookup_tables(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			entries_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure lookup tables schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for lookup tables: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]LookupTable, *model.ApiError) {
+	tables := []LookupTable{}
+
+	err := r.db.SelectContext(ctx, &tables, `
+		SELECT id, name, entries_json, created_by, created_at
+		FROM lookup_tables
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookup tables: %w", err,
+		))
+	}
+	return tables, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*LookupTable, *model.ApiError) {
+	tables := []LookupTable{}
+
+	err := r.db.SelectContext(ctx, &tables, `
+		SELECT id, name, entries_json, created_by, created_at
+		FROM lookup_tables
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookup table %s: %w", id, err,
+		))
+	}
+
+	if len(tables) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("lookup table %s not found", id))
+	}
+	return &tables[0], nil
+}
+
+// getByVersion returns lookup tables associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]LookupTable, *model.ApiError) {
+	tables := []LookupTable{}
+
+	err := r.db.SelectContext(ctx, &tables, `
+		SELECT t.id, t.name, t.entries_json, t.created_by, t.created_at
+		FROM lookup_tables t,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE t.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY t.name
+	`, agentConf.ElementTypeLookupTables, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookup tables for version %d: %w", version, err,
+		))
+	}
+	return tables, nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableLookupTable,
+) (*LookupTable, *model.ApiError) {
+	table := &LookupTable{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Entries:   postable.Entries,
+		CreatedBy: userId,
+		CreatedAt: time.Now(),
+	}
+
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM lookup_tables WHERE name = $1
+	`, table.Name)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookup tables: %w", err,
+		))
+	}
+	if existing > 0 {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a lookup table named %s already exists", table.Name),
+		}
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO lookup_tables (id, name, entries_json, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, table.Id, table.Name, table.Entries, table.CreatedBy, table.CreatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert lookup table: %w", err,
+		))
+	}
+
+	return table, nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM lookup_tables WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete lookup table %s: %w", id, err,
+		))
+	}
+	return nil
+}
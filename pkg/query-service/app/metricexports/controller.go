@@ -0,0 +1,316 @@
+package metricexports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	runTimeout = 5 * time.Minute
+	// exportDelay is how long after the end of an interval it is exported,
+	// leaving time for its samples to be ingested
+	exportDelay = time.Minute
+
+	// failed intervals are retried by the next runs until they are older
+	// than maxRetryWindow, the oldest first and at most maxIntervalsPerRun
+	// of them per run
+	maxRetryWindow     = 24 * time.Hour
+	maxIntervalsPerRun = 12
+)
+
+// intervalSchedule runs an export once per interval, the intervals being
+// aligned to the unix epoch so that the downsampled samples are too
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return intervalEnd(t, s.interval).Add(s.interval + exportDelay)
+}
+
+// intervalEnd is the end of the last interval which can be exported at t
+func intervalEnd(t time.Time, interval time.Duration) time.Time {
+	return t.Add(-exportDelay).Truncate(interval)
+}
+
+// pendingIntervals returns the ends of the intervals to export at now, the
+// ones ending after the given time which are not too old to be retried,
+// the oldest first
+func pendingIntervals(after time.Time, now time.Time, interval time.Duration) []time.Time {
+	oldest := now.Add(-maxRetryWindow)
+	ends := []time.Time{}
+	for end := intervalEnd(now, interval); end.After(after) && end.After(oldest); end = end.Add(-interval) {
+		ends = append([]time.Time{end}, ends...)
+	}
+	if len(ends) > maxIntervalsPerRun {
+		ends = ends[:maxIntervalsPerRun]
+	}
+	return ends
+}
+
+// Controller manages metric exports and runs them once per interval,
+// querying the downsampled metrics via the reader and writing them to the
+// remote_write endpoints. Every replica schedules the exports, the runs
+// claim them in the db so that the replicas sharing it export each
+// interval once.
+type Controller struct {
+	repo      *Repo
+	reader    interfaces.Reader
+	client    *http.Client
+	replicaId string
+
+	cron       *cron.Cron
+	entries    map[string]cron.EntryID
+	entriesMtx sync.Mutex
+}
+
+func NewController(db *sqlx.DB, reader interfaces.Reader) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create metric exports repo: %w", err)
+	}
+
+	return &Controller{
+		repo:      repo,
+		reader:    reader,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		replicaId: uuid.NewString(),
+		cron:      cron.New(cron.WithLocation(time.UTC)),
+		entries:   map[string]cron.EntryID{},
+	}, nil
+}
+
+// Start schedules the enabled metric exports
+func (c *Controller) Start() {
+	metricExports, apiErr := c.repo.list(context.Background())
+	if apiErr != nil {
+		zap.L().Error("could not list metric exports", zap.Error(apiErr.ToError()))
+	}
+	for i := range metricExports {
+		c.schedule(&metricExports[i])
+	}
+	c.cron.Start()
+}
+
+// Stop waits for the running metric exports to finish
+func (c *Controller) Stop() {
+	<-c.cron.Stop().Done()
+}
+
+func (c *Controller) schedule(me *MetricExport) {
+	c.entriesMtx.Lock()
+	defer c.entriesMtx.Unlock()
+
+	if entryId, ok := c.entries[me.Id]; ok {
+		c.cron.Remove(entryId)
+		delete(c.entries, me.Id)
+	}
+	if !me.Spec.Enabled {
+		return
+	}
+
+	id := me.Id
+	c.entries[id] = c.cron.Schedule(intervalSchedule{interval: me.Spec.interval()}, cron.FuncJob(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+		defer cancel()
+		_, apiErr := c.RunMetricExport(ctx, id)
+		if apiErr != nil && apiErr.Type() != model.ErrorConflict {
+			zap.L().Error("metric export run failed", zap.String("id", id), zap.Error(apiErr.ToError()))
+		}
+	}))
+}
+
+func (c *Controller) unschedule(id string) {
+	c.entriesMtx.Lock()
+	defer c.entriesMtx.Unlock()
+
+	if entryId, ok := c.entries[id]; ok {
+		c.cron.Remove(entryId)
+		delete(c.entries, id)
+	}
+}
+
+// ListMetricExports lists the metric exports, without the credentials of
+// their endpoints
+func (c *Controller) ListMetricExports(ctx context.Context) ([]MetricExport, *model.ApiError) {
+	metricExports, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for i := range metricExports {
+		metricExports[i] = *metricExports[i].redacted()
+	}
+	return metricExports, nil
+}
+
+func (c *Controller) GetMetricExport(ctx context.Context, id string) (*MetricExport, *model.ApiError) {
+	metricExport, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return metricExport.redacted(), nil
+}
+
+func (c *Controller) CreateMetricExport(
+	ctx context.Context, postable *PostableMetricExport,
+) (*MetricExport, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	metricExport, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	c.schedule(metricExport)
+	return metricExport.redacted(), nil
+}
+
+func (c *Controller) UpdateMetricExport(
+	ctx context.Context, id string, postable *PostableMetricExport,
+) (*MetricExport, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	postable.Spec.keepSecrets(&existing.Spec)
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+	c.schedule(&updated)
+	return updated.redacted(), nil
+}
+
+func (c *Controller) DeleteMetricExport(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	c.unschedule(id)
+	return nil
+}
+
+// RunMetricExport exports the intervals which are over now and weren't
+// exported yet, the oldest first, stopping at the first failure so that the
+// failed interval is retried by the next run. The outcome is recorded as the
+// export's last run. It fails with a conflict if the export is being run.
+func (c *Controller) RunMetricExport(ctx context.Context, id string) (*MetricExport, *model.ApiError) {
+	runAt := time.Now()
+	claimed, apiErr := c.repo.claim(ctx, id, c.replicaId, runAt, runAt.Add(runTimeout))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !claimed {
+		if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("metric export %s is already running", id),
+		}
+	}
+	defer func() {
+		if apiErr := c.repo.release(context.WithoutCancel(ctx), id, c.replicaId); apiErr != nil {
+			zap.L().Error("could not release metric export", zap.String("id", id), zap.Error(apiErr.ToError()))
+		}
+	}()
+
+	me, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// exports which never ran start with the interval they were created in
+	after := me.CreatedAt.Truncate(me.Spec.interval())
+	if me.ExportedUntil != nil {
+		after = *me.ExportedUntil
+	}
+	pending := pendingIntervals(after, runAt, me.Spec.interval())
+	if len(pending) == 0 {
+		return me.redacted(), nil
+	}
+
+	for _, end := range pending {
+		if apiErr = c.run(ctx, me, end); apiErr != nil {
+			break
+		}
+		if apiErr = c.repo.updateExportedUntil(ctx, id, end); apiErr != nil {
+			break
+		}
+		exportedUntil := end
+		me.ExportedUntil = &exportedUntil
+	}
+
+	lastError := ""
+	if apiErr != nil {
+		lastError = apiErr.Error()
+	}
+	if err := c.repo.updateLastRun(ctx, id, runAt, lastError); err != nil {
+		zap.L().Error("could not record metric export run", zap.String("id", id), zap.Error(err.ToError()))
+	}
+	me.LastRunAt, me.LastError = &runAt, lastError
+	return me.redacted(), apiErr
+}
+
+func (c *Controller) run(ctx context.Context, me *MetricExport, end time.Time) *model.ApiError {
+	res, _, apiErr := c.reader.GetInstantQueryMetricsResult(ctx, &model.InstantQueryMetricsParams{
+		Time:  end,
+		Query: fmt.Sprintf("%s[%dm]", me.Spec.Selector, me.Spec.IntervalMinutes),
+	})
+	if apiErr != nil {
+		return apiErr
+	}
+	if res.Err != nil {
+		return model.InternalError(fmt.Errorf("could not query the metrics to export: %w", res.Err))
+	}
+	matrix, err := res.Matrix()
+	if err != nil {
+		return model.InternalError(fmt.Errorf("unexpected result of the metrics query: %w", err))
+	}
+
+	series := downsample(&me.Spec, matrix, end.UnixMilli())
+	if len(series) == 0 {
+		return nil
+	}
+	if err := write(ctx, c.client, &me.Spec, series); err != nil {
+		return &model.ApiError{Typ: model.ErrorUnavailable, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package metricexports
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestPendingIntervals(t *testing.T) {
+	require := require.New(t)
+
+	interval := 5 * time.Minute
+	now := time.Date(2024, 3, 1, 12, 6, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	require.Equal([]time.Time{at(12, 5)}, pendingIntervals(at(12, 0), now, interval))
+	require.Empty(pendingIntervals(at(12, 5), now, interval), "intervals are exported once")
+	require.Empty(pendingIntervals(at(12, 5), at(12, 10), interval), "intervals are exported after the delay")
+
+	// intervals which failed are retried by the next run, the oldest first
+	require.Equal(
+		[]time.Time{at(11, 55), at(12, 0), at(12, 5)},
+		pendingIntervals(at(11, 50), now, interval),
+	)
+
+	// intervals are retried for a while, a few of them per run
+	pending := pendingIntervals(at(0, 0), now, interval)
+	require.Len(pending, maxIntervalsPerRun)
+	require.Equal(at(0, 5), pending[0])
+	pending = pendingIntervals(time.Time{}, now, time.Hour)
+	require.Len(pending, maxIntervalsPerRun)
+	require.True(pending[0].After(now.Add(-maxRetryWindow)))
+}
+
+func TestMetricExportRuns(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+	ctx := context.Background()
+
+	me, apiErr := controller.repo.insert(ctx, "user1", &PostableMetricExport{
+		Name: "thanos", Spec: ExportSpec{Selector: "up", Endpoint: "https://thanos.example.com", IntervalMinutes: 5},
+	})
+	require.Nil(apiErr)
+
+	// a run holds the export until it is over or its lease expires
+	now := time.Now()
+	claimed, apiErr := controller.repo.claim(ctx, me.Id, "replica-1", now, now.Add(runTimeout))
+	require.Nil(apiErr)
+	require.True(claimed)
+	claimed, apiErr = controller.repo.claim(ctx, me.Id, "replica-2", now, now.Add(runTimeout))
+	require.Nil(apiErr)
+	require.False(claimed, "an export runs on one replica at a time")
+	_, apiErr = controller.RunMetricExport(ctx, me.Id)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorConflict, apiErr.Type())
+
+	require.Nil(controller.repo.release(ctx, me.Id, "replica-2"), "only the replica holding the export releases it")
+	claimed, apiErr = controller.repo.claim(ctx, me.Id, "replica-2", now, now.Add(runTimeout))
+	require.Nil(apiErr)
+	require.False(claimed)
+	claimed, apiErr = controller.repo.claim(ctx, me.Id, "replica-2", now.Add(runTimeout+time.Second), now.Add(2*runTimeout))
+	require.Nil(apiErr)
+	require.True(claimed, "expired leases can be claimed")
+	require.Nil(controller.repo.release(ctx, me.Id, "replica-2"))
+
+	// an export created in the current interval has nothing to export yet
+	ran, apiErr := controller.RunMetricExport(ctx, me.Id)
+	require.Nil(apiErr)
+	require.Nil(ran.ExportedUntil)
+	claimed, apiErr = controller.repo.claim(ctx, me.Id, "replica-2", now, now.Add(runTimeout))
+	require.Nil(apiErr)
+	require.True(claimed, "runs release the export")
+
+	end := time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC)
+	require.Nil(controller.repo.updateExportedUntil(ctx, me.Id, end))
+	me, apiErr = controller.repo.get(ctx, me.Id)
+	require.Nil(apiErr)
+	require.True(end.Equal(*me.ExportedUntil))
+
+	_, apiErr = controller.RunMetricExport(ctx, "missing")
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorNotFound, apiErr.Type())
+}
+
+func TestMetricExportSecrets(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+
+	userJwt, err := auth.GenerateJWTForUser(&model.User{Id: "user1", Email: "user1@signoz.io"})
+	require.Nil(err)
+	req := httptest.NewRequest("PUT", "/api/v1/metric_exports", nil)
+	req.Header.Add("Authorization", "Bearer "+userJwt.AccessJwt)
+	ctx := auth.AttachJwtToContext(context.Background(), req)
+
+	created, apiErr := controller.repo.insert(ctx, "user1", &PostableMetricExport{
+		Name: "thanos", Spec: ExportSpec{
+			Selector: "up", Endpoint: "https://thanos.example.com", Username: "signoz", Password: "password",
+		},
+	})
+	require.Nil(apiErr)
+	_, apiErr = controller.repo.insert(ctx, "user1", &PostableMetricExport{
+		Name: "mimir", Spec: ExportSpec{Selector: "up", Endpoint: "https://mimir.example.com", BearerToken: "token"},
+	})
+	require.Nil(apiErr)
+
+	me, apiErr := controller.GetMetricExport(ctx, created.Id)
+	require.Nil(apiErr)
+	require.Equal(redactedSecret, me.Spec.Password)
+	require.Equal("signoz", me.Spec.Username)
+	list, apiErr := controller.ListMetricExports(ctx)
+	require.Nil(apiErr)
+	require.Len(list, 2)
+	for _, me := range list {
+		require.NotContains([]string{"token", "password"}, me.Spec.BearerToken)
+		require.NotContains([]string{"token", "password"}, me.Spec.Password)
+	}
+
+	// the redacted credentials sent back are kept, the changed ones updated
+	spec := me.Spec
+	updated, apiErr := controller.UpdateMetricExport(ctx, created.Id, &PostableMetricExport{Name: "thanos", Spec: spec})
+	require.Nil(apiErr)
+	require.Equal(redactedSecret, updated.Spec.Password)
+	stored, apiErr := controller.repo.get(ctx, created.Id)
+	require.Nil(apiErr)
+	require.Equal("password", stored.Spec.Password)
+	require.Equal("password", created.Spec.Password, "the stored export is left as is")
+
+	spec.Password = "changed"
+	_, apiErr = controller.UpdateMetricExport(ctx, created.Id, &PostableMetricExport{Name: "thanos", Spec: spec})
+	require.Nil(apiErr)
+	stored, apiErr = controller.repo.get(ctx, created.Id)
+	require.Nil(apiErr)
+	require.Equal("changed", stored.Spec.Password)
+}
+
+func newTestController(t *testing.T) *Controller {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	controller, err := NewController(db, nil)
+	require.Nil(t, err)
+	return controller
+}
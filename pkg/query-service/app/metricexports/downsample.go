@@ -0,0 +1,131 @@
+package metricexports
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// reduce applies a function to values, for downsampling the samples of a
+// series and aggregating the downsampled series of a group
+func reduce(f Function, values []float64) float64 {
+	switch f {
+	case FunctionMin:
+		result := math.Inf(1)
+		for _, v := range values {
+			result = math.Min(result, v)
+		}
+		return result
+	case FunctionMax:
+		result := math.Inf(-1)
+		for _, v := range values {
+			result = math.Max(result, v)
+		}
+		return result
+	case FunctionSum, FunctionAvg:
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if f == FunctionAvg {
+			return sum / float64(len(values))
+		}
+		return sum
+	default:
+		return values[len(values)-1]
+	}
+}
+
+// exportedLabels are the labels of a series once exported. The labels
+// internal to signoz, prefixed with __, are dropped except for the metric
+// name, and only the groupBy labels are kept when grouping.
+func exportedLabels(spec *ExportSpec, metric labels.Labels) map[string]string {
+	exported := map[string]string{}
+	metric.Range(func(l labels.Label) {
+		if l.Name == labels.MetricName {
+			exported[l.Name] = l.Value
+			return
+		}
+		if strings.HasPrefix(l.Name, "__") {
+			return
+		}
+		if len(spec.GroupBy) > 0 && !contains(spec.GroupBy, l.Name) {
+			return
+		}
+		exported[l.Name] = l.Value
+	})
+	for name, value := range spec.ExternalLabels {
+		exported[name] = value
+	}
+	return exported
+}
+
+// downsample reduces the samples of each series in the interval ending at
+// timestamp to one with the spec's function, and aggregates the series by
+// the groupBy labels if set. The series are sorted by their labels.
+func downsample(spec *ExportSpec, matrix promql.Matrix, timestamp int64) []prompb.TimeSeries {
+	type group struct {
+		labels map[string]string
+		values []float64
+	}
+	groups := map[string]*group{}
+	keys := []string{}
+
+	for _, series := range matrix {
+		if len(series.Floats) == 0 {
+			continue
+		}
+		values := make([]float64, len(series.Floats))
+		for i, point := range series.Floats {
+			values[i] = point.F
+		}
+
+		exported := exportedLabels(spec, series.Metric)
+		key := labels.FromMap(exported).String()
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: exported}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.values = append(g.values, reduce(spec.Function, values))
+	}
+
+	sort.Strings(keys)
+	timeSeries := make([]prompb.TimeSeries, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		value := g.values[0]
+		if len(g.values) > 1 {
+			aggregation := spec.Aggregation
+			if aggregation == "" {
+				// series only collide when their labels differ in the
+				// dropped internal ones
+				aggregation = FunctionLast
+			}
+			value = reduce(aggregation, g.values)
+		}
+
+		ts := prompb.TimeSeries{
+			Samples: []prompb.Sample{{Value: value, Timestamp: timestamp}},
+		}
+		labels.FromMap(g.labels).Range(func(l labels.Label) {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		})
+		timeSeries = append(timeSeries, ts)
+	}
+	return timeSeries
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
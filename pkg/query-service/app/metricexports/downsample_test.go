@@ -0,0 +1,130 @@
+package metricexports
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsample(t *testing.T) {
+	require := require.New(t)
+
+	matrix := promql.Matrix{
+		{
+			Metric: labels.FromStrings("__name__", "http_requests", "__temporality__", "Delta", "service", "cart", "pod", "a"),
+			Floats: []promql.FPoint{{T: 1, F: 1}, {T: 2, F: 5}, {T: 3, F: 3}},
+		},
+		{
+			Metric: labels.FromStrings("__name__", "http_requests", "service", "cart", "pod", "b"),
+			Floats: []promql.FPoint{{T: 1, F: 2}, {T: 2, F: 2}},
+		},
+		{
+			Metric: labels.FromStrings("__name__", "http_requests", "service", "checkout", "pod", "c"),
+			Floats: []promql.FPoint{{T: 1, F: 10}},
+		},
+		{
+			Metric: labels.FromStrings("__name__", "http_requests", "service", "empty", "pod", "d"),
+		},
+	}
+
+	spec := &ExportSpec{Function: FunctionMax, ExternalLabels: map[string]string{"cluster": "eu"}}
+	series := downsample(spec, matrix, 1000)
+	require.Len(series, 3)
+	require.Equal([]prompb.Label{
+		{Name: "__name__", Value: "http_requests"}, {Name: "cluster", Value: "eu"},
+		{Name: "pod", Value: "a"}, {Name: "service", Value: "cart"},
+	}, series[0].Labels, "internal labels should be dropped")
+	require.Equal([]prompb.Sample{{Value: 5, Timestamp: 1000}}, series[0].Samples)
+
+	spec = &ExportSpec{Function: FunctionAvg, GroupBy: []string{"service"}, Aggregation: FunctionSum}
+	series = downsample(spec, matrix, 1000)
+	require.Len(series, 2)
+	require.Equal([]prompb.Label{
+		{Name: "__name__", Value: "http_requests"}, {Name: "service", Value: "cart"},
+	}, series[0].Labels)
+	require.Equal(5.0, series[0].Samples[0].Value, "avg of 3 plus avg of 2")
+	require.Equal(10.0, series[1].Samples[0].Value)
+}
+
+func TestIntervalSchedule(t *testing.T) {
+	require := require.New(t)
+
+	schedule := intervalSchedule{interval: 5 * time.Minute}
+	now := time.Date(2024, 3, 1, 12, 3, 20, 0, time.UTC)
+	next := schedule.Next(now)
+	require.Equal(time.Date(2024, 3, 1, 12, 6, 0, 0, time.UTC), next)
+	require.Equal(time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC), intervalEnd(next, schedule.interval))
+	require.Equal(time.Date(2024, 3, 1, 12, 11, 0, 0, time.UTC), schedule.Next(next))
+}
+
+func TestWrite(t *testing.T) {
+	require := require.New(t)
+
+	received := []prompb.WriteRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal("snappy", r.Header.Get("Content-Encoding"))
+		require.Equal("Bearer secret", r.Header.Get("Authorization"))
+		require.Equal("tenant-1", r.Header.Get("X-Scope-OrgID"))
+
+		compressed, err := io.ReadAll(r.Body)
+		require.Nil(err)
+		data, err := snappy.Decode(nil, compressed)
+		require.Nil(err)
+		req := prompb.WriteRequest{}
+		require.Nil(req.Unmarshal(data))
+		received = append(received, req)
+	}))
+	defer server.Close()
+
+	spec := &ExportSpec{
+		Endpoint:    server.URL,
+		BearerToken: "secret",
+		Headers:     map[string]string{"X-Scope-OrgID": "tenant-1"},
+	}
+	series := make([]prompb.TimeSeries, maxSeriesPerRequest+1)
+	for i := range series {
+		series[i] = prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+		}
+	}
+
+	require.Nil(write(context.Background(), server.Client(), spec, series))
+	require.Len(received, 2)
+	require.Len(received[0].Timeseries, maxSeriesPerRequest)
+	require.Len(received[1].Timeseries, 1)
+}
+
+func TestExportSpecIsValid(t *testing.T) {
+	require := require.New(t)
+
+	spec := ExportSpec{
+		Selector: `{__name__=~"http_.*", env="prod"}`,
+		Endpoint: "https://thanos.example.com/api/v1/receive",
+	}
+	require.Nil(spec.IsValid())
+	spec.setDefaults()
+	require.Equal(defaultIntervalMinutes, spec.IntervalMinutes)
+	require.Equal(FunctionAvg, spec.Function)
+
+	spec.Selector = "http_requests{"
+	require.NotNil(spec.IsValid())
+
+	spec.Selector = "http_requests"
+	spec.Aggregation = FunctionSum
+	require.NotNil(spec.IsValid(), "aggregation requires groupBy")
+	spec.GroupBy = []string{"service"}
+	require.Nil(spec.IsValid())
+
+	spec.Endpoint = "thanos:19291"
+	require.NotNil(spec.IsValid())
+}
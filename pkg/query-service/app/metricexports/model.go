@@ -0,0 +1,209 @@
+package metricexports
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Function downsamples the samples of a series in an interval to one
+type Function string
+
+const (
+	FunctionAvg  Function = "avg"
+	FunctionMin  Function = "min"
+	FunctionMax  Function = "max"
+	FunctionSum  Function = "sum"
+	FunctionLast Function = "last"
+)
+
+var supportedFunctions = []Function{
+	FunctionAvg, FunctionMin, FunctionMax, FunctionSum, FunctionLast,
+}
+
+const (
+	defaultIntervalMinutes = 5
+	maxIntervalMinutes     = 24 * 60
+
+	// redactedSecret replaces the credentials of the endpoints in responses,
+	// updates sending it back keep the stored credentials
+	redactedSecret = "********"
+)
+
+// MetricExport forwards the metrics matching a selector, downsampled, to a
+// prometheus remote_write endpoint, e.g. a central thanos receive kept
+// alongside signoz for long term storage
+type MetricExport struct {
+	Id        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Spec      ExportSpec `json:"spec" db:"spec_json"`
+	LastRunAt *time.Time `json:"lastRunAt" db:"last_run_at"`
+	LastError string     `json:"lastError" db:"last_error"`
+	// ExportedUntil is the end of the last interval exported, the intervals
+	// after it are exported or retried by the next runs
+	ExportedUntil *time.Time `json:"exportedUntil" db:"exported_until"`
+	CreatedBy     string     `json:"createdBy" db:"created_by"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedBy     string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+type ExportSpec struct {
+	Enabled bool `json:"enabled"`
+
+	// Selector is a promql series selector of the metrics to export, e.g.
+	// {__name__=~"http_server_.*", deployment_environment="prod"}
+	Selector string `json:"selector"`
+	// IntervalMinutes is how often the metrics are exported, each series is
+	// downsampled to one sample per interval
+	IntervalMinutes int `json:"intervalMinutes,omitempty"`
+	// Function downsamples the samples of a series in an interval
+	Function Function `json:"function,omitempty"`
+
+	// GroupBy aggregates the downsampled series of a metric by these labels
+	// with Aggregation, the series are exported as they are if empty
+	GroupBy     []string `json:"groupBy,omitempty"`
+	Aggregation Function `json:"aggregation,omitempty"`
+
+	Endpoint    string            `json:"endpoint"`
+	BearerToken string            `json:"bearerToken,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Password    string            `json:"password,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	// ExternalLabels are added to every exported series, e.g. to tell
+	// apart the signoz installations writing to the same endpoint
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+}
+
+// redacted returns a copy of the metric export without the credentials of
+// its endpoint
+func (me *MetricExport) redacted() *MetricExport {
+	redacted := *me
+	if redacted.Spec.BearerToken != "" {
+		redacted.Spec.BearerToken = redactedSecret
+	}
+	if redacted.Spec.Password != "" {
+		redacted.Spec.Password = redactedSecret
+	}
+	return &redacted
+}
+
+// keepSecrets restores the credentials of an existing spec which were sent
+// back redacted
+func (s *ExportSpec) keepSecrets(existing *ExportSpec) {
+	if s.BearerToken == redactedSecret {
+		s.BearerToken = existing.BearerToken
+	}
+	if s.Password == redactedSecret {
+		s.Password = existing.Password
+	}
+}
+
+// For serializing from db
+func (s *ExportSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s ExportSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize metric export spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *ExportSpec) setDefaults() {
+	if s.IntervalMinutes == 0 {
+		s.IntervalMinutes = defaultIntervalMinutes
+	}
+	if s.Function == "" {
+		s.Function = FunctionAvg
+	}
+	if len(s.GroupBy) > 0 && s.Aggregation == "" {
+		s.Aggregation = FunctionSum
+	}
+}
+
+func (s *ExportSpec) interval() time.Duration {
+	return time.Duration(s.IntervalMinutes) * time.Minute
+}
+
+type PostableMetricExport struct {
+	Name string     `json:"name"`
+	Spec ExportSpec `json:"spec"`
+}
+
+func (p *PostableMetricExport) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("metric export name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *ExportSpec) IsValid() error {
+	if _, err := parser.ParseMetricSelector(s.Selector); err != nil {
+		return fmt.Errorf("selector is not a valid promql series selector: %w", err)
+	}
+	if s.IntervalMinutes < 0 || s.IntervalMinutes > maxIntervalMinutes {
+		return fmt.Errorf("intervalMinutes must be between 1 and %d", maxIntervalMinutes)
+	}
+	if s.Function != "" && !isSupportedFunction(s.Function) {
+		return fmt.Errorf("unsupported function %q, must be one of %v", s.Function, supportedFunctions)
+	}
+	if s.Aggregation != "" {
+		if len(s.GroupBy) == 0 {
+			return fmt.Errorf("aggregation requires groupBy labels")
+		}
+		if !isSupportedFunction(s.Aggregation) || s.Aggregation == FunctionLast {
+			return fmt.Errorf("unsupported aggregation %q, must be one of avg, min, max or sum", s.Aggregation)
+		}
+	}
+	for _, label := range s.GroupBy {
+		if !model.LabelName(label).IsValid() || label == model.MetricNameLabel {
+			return fmt.Errorf("invalid groupBy label %q", label)
+		}
+	}
+	for name := range s.ExternalLabels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid external label %q", name)
+		}
+	}
+
+	if s.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("endpoint must be a valid http(s) url")
+	}
+	if s.BearerToken != "" && s.Username != "" {
+		return fmt.Errorf("only one of bearerToken and basic auth can be set")
+	}
+	if s.Password != "" && s.Username == "" {
+		return fmt.Errorf("username is required for basic auth")
+	}
+	return nil
+}
+
+func isSupportedFunction(f Function) bool {
+	for _, supported := range supportedFunctions {
+		if f == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package metricexports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// maxSeriesPerRequest keeps the write requests under the size limits of
+// the usual remote_write receivers
+const maxSeriesPerRequest = 2000
+
+// write sends the series to the spec's endpoint with the remote_write 1.0
+// protocol, in batches
+func write(
+	ctx context.Context, client *http.Client, spec *ExportSpec, series []prompb.TimeSeries,
+) error {
+	for start := 0; start < len(series); start += maxSeriesPerRequest {
+		end := start + maxSeriesPerRequest
+		if end > len(series) {
+			end = len(series)
+		}
+		if err := writeBatch(ctx, client, spec, series[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBatch(
+	ctx context.Context, client *http.Client, spec *ExportSpec, series []prompb.TimeSeries,
+) error {
+	writeRequest := prompb.WriteRequest{Timeseries: series}
+	data, err := writeRequest.Marshal()
+	if err != nil {
+		return fmt.Errorf("could not encode write request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, spec.Endpoint, bytes.NewReader(snappy.Encode(nil, data)),
+	)
+	if err != nil {
+		return err
+	}
+	for name, value := range spec.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if spec.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+spec.BearerToken)
+	} else if spec.Username != "" {
+		req.SetBasicAuth(spec.Username, spec.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote write request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write endpoint responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
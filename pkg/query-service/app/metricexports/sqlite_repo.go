@@ -0,0 +1,267 @@
+package metricexports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS metric_exports(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			last_run_at TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			exported_until TIMESTAMP,
+			claimed_by TEXT NOT NULL DEFAULT '',
+			claimed_until INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure metric exports schema in sqlite DB: %w", err,
+		)
+	}
+
+	// columns added after the table was first released
+	for _, column := range []string{
+		"exported_until TIMESTAMP",
+		"claimed_by TEXT NOT NULL DEFAULT ''",
+		"claimed_until INTEGER NOT NULL DEFAULT 0",
+	} {
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE metric_exports ADD COLUMN %s;", column))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("could not add column %s to metric exports: %w", column, err)
+		}
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for metric exports: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]MetricExport, *model.ApiError) {
+	metricExports := []MetricExport{}
+
+	err := r.db.SelectContext(ctx, &metricExports, `
+		SELECT id, name, spec_json, last_run_at, last_error, exported_until,
+			created_by, created_at, updated_by, updated_at
+		FROM metric_exports
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric exports: %w", err,
+		))
+	}
+	return metricExports, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*MetricExport, *model.ApiError) {
+	metricExports := []MetricExport{}
+
+	err := r.db.SelectContext(ctx, &metricExports, `
+		SELECT id, name, spec_json, last_run_at, last_error, exported_until,
+			created_by, created_at, updated_by, updated_at
+		FROM metric_exports
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric export %s: %w", id, err,
+		))
+	}
+
+	if len(metricExports) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("metric export %s not found", id))
+	}
+	return &metricExports[0], nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM metric_exports WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query metric exports: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a metric export named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableMetricExport,
+) (*MetricExport, *model.ApiError) {
+	now := time.Now()
+	metricExport := &MetricExport{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, metricExport.Name, metricExport.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO metric_exports (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		metricExport.Id, metricExport.Name, metricExport.Spec,
+		metricExport.CreatedBy, metricExport.CreatedAt,
+		metricExport.UpdatedBy, metricExport.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert metric export: %w", err,
+		))
+	}
+
+	return metricExport, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, metricExport *MetricExport,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, metricExport.Name, metricExport.Id); apiErr != nil {
+		return apiErr
+	}
+
+	metricExport.UpdatedBy = userId
+	metricExport.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE metric_exports
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		metricExport.Name, metricExport.Spec,
+		metricExport.UpdatedBy, metricExport.UpdatedAt, metricExport.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update metric export %s: %w", metricExport.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) updateLastRun(
+	ctx context.Context, id string, runAt time.Time, lastError string,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE metric_exports SET last_run_at = $1, last_error = $2 WHERE id = $3
+	`, runAt, lastError, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update last run of metric export %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) updateExportedUntil(ctx context.Context, id string, end time.Time) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE metric_exports SET exported_until = $1 WHERE id = $2
+	`, end, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update exported intervals of metric export %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+// claim takes the lease of running a metric export until the given time,
+// so that the replicas sharing the db don't run it at the same time. It
+// fails if a run, on any replica, holds an unexpired lease.
+func (r *Repo) claim(
+	ctx context.Context, id string, replicaId string, now time.Time, until time.Time,
+) (bool, *model.ApiError) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE metric_exports SET claimed_by = $1, claimed_until = $2
+		WHERE id = $3 AND claimed_until < $4
+	`, replicaId, until.Unix(), id, now.Unix())
+	if err != nil {
+		return false, model.InternalError(fmt.Errorf(
+			"could not claim metric export %s: %w", id, err,
+		))
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, model.InternalError(fmt.Errorf(
+			"could not claim metric export %s: %w", id, err,
+		))
+	}
+	return claimed > 0, nil
+}
+
+func (r *Repo) release(ctx context.Context, id string, replicaId string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE metric_exports SET claimed_by = '', claimed_until = 0
+		WHERE id = $1 AND claimed_by = $2
+	`, id, replicaId)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not release metric export %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM metric_exports WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete metric export %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package metricowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+const labelingProcessorName = "transform/signoz_metric_owners"
+
+// labeledPipelines are the collector pipelines whose datapoints get a team label
+var labeledPipelines = []string{"metrics", "metrics/generic"}
+
+// GenerateCollectorConfigWithMetricOwners adds a transform processor that
+// sets the team label on datapoints of owned metrics to the metrics
+// pipelines of the collector config. The processor is removed when there
+// are no ownership rules.
+func GenerateCollectorConfigWithMetricOwners(
+	config []byte, rules []OwnershipRule,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	processors, ok := c["processors"].(map[string]interface{})
+	if !ok || processors == nil {
+		processors = map[string]interface{}{}
+	}
+
+	statements := labelingStatements(rules)
+	if len(statements) > 0 {
+		processors[labelingProcessorName] = map[string]interface{}{
+			"error_mode": "ignore",
+			"metric_statements": []interface{}{
+				map[string]interface{}{"context": "datapoint", "statements": statements},
+			},
+		}
+	} else {
+		delete(processors, labelingProcessorName)
+	}
+	c["processors"] = processors
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	for _, name := range labeledPipelines {
+		pipeline, ok := pipelines[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		current, _ := pipeline["processors"].([]interface{})
+		updated := []interface{}{}
+		for _, p := range current {
+			if p != labelingProcessorName {
+				updated = append(updated, p)
+			}
+		}
+		if len(statements) > 0 {
+			updated = append([]interface{}{labelingProcessorName}, updated...)
+		}
+		pipeline["processors"] = updated
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+// labelingStatements translates ownership rules to OTTL statements in the
+// order they are resolved at query time, so that the first matching rule
+// sets the team. A team label sent by the application is never overwritten.
+func labelingStatements(rules []OwnershipRule) []interface{} {
+	target := ottlAttribute(TeamLabel)
+	statements := []interface{}{}
+	for _, r := range sortedRules(rules) {
+		var condition string
+		if r.MetricPrefix != "" {
+			condition = fmt.Sprintf(
+				"IsMatch(metric.name, %s)", ottlString("^"+regexp.QuoteMeta(r.MetricPrefix)),
+			)
+		} else {
+			condition = fmt.Sprintf(
+				"resource.%s == %s", ottlAttribute(r.ResourceAttribute), ottlString(r.ResourceValue),
+			)
+		}
+		statements = append(statements, fmt.Sprintf(
+			"set(%s, %s) where %s and %s == nil",
+			target, ottlString(r.Team), condition, target,
+		))
+	}
+	return statements
+}
+
+func ottlAttribute(name string) string {
+	return fmt.Sprintf("attributes[%s]", ottlString(name))
+}
+
+func ottlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	// escape `$`s so that they do not get treated as env vars when loading collector config
+	s = strings.ReplaceAll(s, "$", "$$")
+	return fmt.Sprintf(`"%s"`, s)
+}
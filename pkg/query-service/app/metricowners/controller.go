@@ -0,0 +1,302 @@
+package metricowners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const MetricOwnersFeatureType agentConf.AgentFeatureType = "metric_owners"
+
+// Controller manages the teams owning metrics. Ownership is applied as a
+// team label at write time by agents and resolved from the rules at query
+// time for series written before a rule existed.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create metric owners repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type OwnershipRulesResponse struct {
+	*agentConf.ConfigVersion
+
+	Rules []OwnershipRule `json:"rules"`
+}
+
+func (c *Controller) ListOwnershipRules(ctx context.Context) (
+	*OwnershipRulesResponse, *model.ApiError,
+) {
+	rules, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeMetricOwners)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest metric owners config version")
+	}
+
+	return &OwnershipRulesResponse{
+		ConfigVersion: latest,
+		Rules:         rules,
+	}, nil
+}
+
+// CreateOwnershipRule stores a new rule and starts deploying an agent
+// config labeling the metrics it matches
+func (c *Controller) CreateOwnershipRule(
+	ctx context.Context, postable *PostableOwnershipRule,
+) (*OwnershipRule, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	rule, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, rule.Id)
+		return nil, apiErr
+	}
+
+	return rule, nil
+}
+
+// DeleteOwnershipRule removes a rule and starts deploying an agent
+// config without it
+func (c *Controller) DeleteOwnershipRule(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	rules, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(rules))
+	for i, r := range rules {
+		elements[i] = r.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeMetricOwners, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new metric owners config version")
+	}
+	return nil
+}
+
+type MetricCardinality struct {
+	MetricName string `json:"metricName"`
+	Series     uint64 `json:"series"`
+}
+
+// TeamCardinality is the number of series owned by a team. Metrics
+// not matched by any rule are reported with an empty team.
+type TeamCardinality struct {
+	Team    string              `json:"team"`
+	Series  uint64              `json:"series"`
+	Metrics []MetricCardinality `json:"metrics"`
+}
+
+type CardinalityReport struct {
+	Start int64             `json:"start"`
+	End   int64             `json:"end"`
+	Teams []TeamCardinality `json:"teams"`
+}
+
+// GetCardinalityReport breaks down the series active between start and
+// end (ms) by owning team
+func (c *Controller) GetCardinalityReport(
+	ctx context.Context, reader interfaces.Reader, start, end int64,
+) (*CardinalityReport, *model.ApiError) {
+	rules, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	labelKeys := []string{TeamLabel}
+	for _, attr := range resourceAttributes(rules) {
+		labelKeys = append(labelKeys, attr)
+		if normalized := normalizedLabel(attr); normalized != attr {
+			labelKeys = append(labelKeys, normalized)
+		}
+	}
+
+	counts, apiErr := reader.GetMetricSeriesCounts(ctx, start, end, labelKeys)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to count metric series")
+	}
+
+	return &CardinalityReport{
+		Start: start,
+		End:   end,
+		Teams: cardinalityByTeam(rules, counts),
+	}, nil
+}
+
+func cardinalityByTeam(rules []OwnershipRule, counts []model.MetricSeriesCount) []TeamCardinality {
+	byTeam := map[string]map[string]uint64{}
+	for _, count := range counts {
+		team := ResolveOwner(rules, count.MetricName, count.Labels)
+		if byTeam[team] == nil {
+			byTeam[team] = map[string]uint64{}
+		}
+		byTeam[team][count.MetricName] += count.Series
+	}
+
+	teams := []TeamCardinality{}
+	for team, metrics := range byTeam {
+		t := TeamCardinality{Team: team, Metrics: []MetricCardinality{}}
+		for name, series := range metrics {
+			t.Series += series
+			t.Metrics = append(t.Metrics, MetricCardinality{MetricName: name, Series: series})
+		}
+		sort.Slice(t.Metrics, func(i, j int) bool {
+			if t.Metrics[i].Series != t.Metrics[j].Series {
+				return t.Metrics[i].Series > t.Metrics[j].Series
+			}
+			return t.Metrics[i].MetricName < t.Metrics[j].MetricName
+		})
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].Series != teams[j].Series {
+			return teams[i].Series > teams[j].Series
+		}
+		return teams[i].Team < teams[j].Team
+	})
+	return teams
+}
+
+// AnnotateResults sets the teams owning the series of metrics builder
+// queries on the query results
+func (c *Controller) AnnotateResults(
+	ctx context.Context, params *v3.QueryRangeParamsV3, results []*v3.Result,
+) {
+	if params.CompositeQuery == nil || params.CompositeQuery.QueryType != v3.QueryTypeBuilder {
+		return
+	}
+
+	metricNames := map[string]string{}
+	for name, q := range params.CompositeQuery.BuilderQueries {
+		if q.DataSource == v3.DataSourceMetrics && q.Expression == name {
+			metricNames[name] = q.AggregateAttribute.Key
+		}
+	}
+	if len(metricNames) == 0 {
+		return
+	}
+
+	rules, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		zap.L().Error("failed to list metric owners", zap.Error(apiErr.ToError()))
+		return
+	}
+
+	for _, result := range results {
+		metricName, ok := metricNames[result.QueryName]
+		if !ok {
+			continue
+		}
+
+		owners := map[string]struct{}{}
+		for _, series := range result.Series {
+			if team := ResolveOwner(rules, metricName, series.Labels); team != "" {
+				owners[team] = struct{}{}
+			}
+		}
+		if len(result.Series) == 0 {
+			if team := ResolveOwner(rules, metricName, nil); team != "" {
+				owners[team] = struct{}{}
+			}
+		}
+		if len(owners) == 0 {
+			continue
+		}
+
+		if result.Meta == nil {
+			result.Meta = &v3.ResultMeta{}
+		}
+		for team := range owners {
+			result.Meta.Owners = append(result.Meta.Owners, team)
+		}
+		sort.Strings(result.Meta.Owners)
+	}
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return MetricOwnersFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	rules, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithMetricOwners(currentConfYaml, rules)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for metric owners")
+	}
+
+	rawRules, err := json.Marshal(rules)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize metric owners to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawRules), nil
+}
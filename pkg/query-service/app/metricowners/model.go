@@ -0,0 +1,117 @@
+package metricowners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TeamLabel is the attribute set on metric datapoints owned by a team
+const TeamLabel = "team"
+
+// OwnershipRule assigns the metrics matching it to a team. A rule matches
+// either metric names starting with a prefix or metrics whose resource
+// carries an attribute with a given value.
+// Eg: prefix "payments_" -> team payments, k8s.namespace.name=checkout -> team web
+type OwnershipRule struct {
+	Id                string    `json:"id" db:"id"`
+	Team              string    `json:"team" db:"team"`
+	MetricPrefix      string    `json:"metricPrefix,omitempty" db:"metric_prefix"`
+	ResourceAttribute string    `json:"resourceAttribute,omitempty" db:"resource_attribute"`
+	ResourceValue     string    `json:"resourceValue,omitempty" db:"resource_value"`
+	CreatedBy         string    `json:"createdBy" db:"created_by"`
+	CreatedAt         time.Time `json:"createdAt" db:"created_at"`
+}
+
+type PostableOwnershipRule struct {
+	Team              string `json:"team"`
+	MetricPrefix      string `json:"metricPrefix"`
+	ResourceAttribute string `json:"resourceAttribute"`
+	ResourceValue     string `json:"resourceValue"`
+}
+
+func (p *PostableOwnershipRule) IsValid() error {
+	if strings.TrimSpace(p.Team) == "" {
+		return fmt.Errorf("team is required")
+	}
+
+	byPrefix := p.MetricPrefix != ""
+	byResource := p.ResourceAttribute != "" || p.ResourceValue != ""
+	if byPrefix == byResource {
+		return fmt.Errorf(
+			"exactly one of a metric prefix or a resource attribute and value is required",
+		)
+	}
+	if byResource && (p.ResourceAttribute == "" || p.ResourceValue == "") {
+		return fmt.Errorf("both resource attribute and value are required")
+	}
+	return nil
+}
+
+// sortedRules orders rules the way they get applied: prefix rules first,
+// longest prefix first, followed by resource attribute rules
+func sortedRules(rules []OwnershipRule) []OwnershipRule {
+	sorted := append([]OwnershipRule{}, rules...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := sorted[i].MetricPrefix, sorted[j].MetricPrefix
+		if (pi == "") != (pj == "") {
+			return pi != ""
+		}
+		return len(pi) > len(pj)
+	})
+	return sorted
+}
+
+// ResolveOwner returns the team owning a metric series, or an empty string
+// when no rule matches. A team label set at write time takes precedence.
+func ResolveOwner(rules []OwnershipRule, metricName string, labels map[string]string) string {
+	if team := labels[TeamLabel]; team != "" {
+		return team
+	}
+	for _, r := range sortedRules(rules) {
+		if r.MetricPrefix != "" {
+			if strings.HasPrefix(metricName, r.MetricPrefix) {
+				return r.Team
+			}
+			continue
+		}
+		v, ok := labels[r.ResourceAttribute]
+		if !ok {
+			v, ok = labels[normalizedLabel(r.ResourceAttribute)]
+		}
+		if ok && v == r.ResourceValue {
+			return r.Team
+		}
+	}
+	return ""
+}
+
+// resourceAttributes are the attributes referenced by resource attribute rules
+func resourceAttributes(rules []OwnershipRule) []string {
+	seen := map[string]struct{}{}
+	attributes := []string{}
+	for _, r := range rules {
+		if r.ResourceAttribute == "" {
+			continue
+		}
+		if _, ok := seen[r.ResourceAttribute]; ok {
+			continue
+		}
+		seen[r.ResourceAttribute] = struct{}{}
+		attributes = append(attributes, r.ResourceAttribute)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+// normalizedLabel is the name a resource attribute is stored with on metrics
+// Eg: k8s.namespace.name -> k8s_namespace_name
+func normalizedLabel(attr string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, attr)
+}
@@ -0,0 +1,33 @@
+package metricowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveOwner(t *testing.T) {
+	rules := []OwnershipRule{
+		{Team: "web", ResourceAttribute: "k8s.namespace.name", ResourceValue: "checkout"},
+		{Team: "payments", MetricPrefix: "payments_"},
+		{Team: "billing", MetricPrefix: "payments_invoice_"},
+	}
+
+	require.Equal(t, "billing", ResolveOwner(rules, "payments_invoice_total", nil))
+	require.Equal(t, "payments", ResolveOwner(rules, "payments_latency", nil))
+	require.Equal(t, "web", ResolveOwner(rules, "http_requests", map[string]string{
+		"k8s_namespace_name": "checkout",
+	}))
+	require.Equal(t, "", ResolveOwner(rules, "http_requests", map[string]string{
+		"k8s.namespace.name": "frontend",
+	}))
+	require.Equal(t, "infra", ResolveOwner(rules, "payments_latency", map[string]string{
+		TeamLabel: "infra",
+	}), "a team label set at write time should take precedence")
+
+	require.Equal(t, []interface{}{
+		`set(attributes["team"], "billing") where IsMatch(metric.name, "^payments_invoice_") and attributes["team"] == nil`,
+		`set(attributes["team"], "payments") where IsMatch(metric.name, "^payments_") and attributes["team"] == nil`,
+		`set(attributes["team"], "web") where resource.attributes["k8s.namespace.name"] == "checkout" and attributes["team"] == nil`,
+	}, labelingStatements(rules))
+}
@@ -0,0 +1,174 @@
+package metricowners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS metric_owners(
+			id TEXT PRIMARY KEY,
+			team TEXT NOT NULL,
+			metric_prefix TEXT NOT NULL DEFAULT '',
+			resource_attribute TEXT NOT NULL DEFAULT '',
+			resource_value TEXT NOT NULL DEFAULT '',
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(metric_prefix, resource_attribute, resource_value)
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure metric owners schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for metric owners: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]OwnershipRule, *model.ApiError) {
+	rules := []OwnershipRule{}
+
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT id, team, metric_prefix, resource_attribute, resource_value, created_by, created_at
+		FROM metric_owners
+		ORDER BY team, created_at
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric owners: %w", err,
+		))
+	}
+	return rules, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*OwnershipRule, *model.ApiError) {
+	rules := []OwnershipRule{}
+
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT id, team, metric_prefix, resource_attribute, resource_value, created_by, created_at
+		FROM metric_owners
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric owner %s: %w", id, err,
+		))
+	}
+
+	if len(rules) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("metric owner %s not found", id))
+	}
+	return &rules[0], nil
+}
+
+// getByVersion returns ownership rules associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]OwnershipRule, *model.ApiError) {
+	rules := []OwnershipRule{}
+
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT o.id, o.team, o.metric_prefix, o.resource_attribute, o.resource_value,
+			o.created_by, o.created_at
+		FROM metric_owners o,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE o.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY o.team, o.created_at
+	`, agentConf.ElementTypeMetricOwners, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric owners for version %d: %w", version, err,
+		))
+	}
+	return rules, nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableOwnershipRule,
+) (*OwnershipRule, *model.ApiError) {
+	rule := &OwnershipRule{
+		Id:                uuid.NewString(),
+		Team:              postable.Team,
+		MetricPrefix:      postable.MetricPrefix,
+		ResourceAttribute: postable.ResourceAttribute,
+		ResourceValue:     postable.ResourceValue,
+		CreatedBy:         userId,
+		CreatedAt:         time.Now(),
+	}
+
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM metric_owners
+		WHERE metric_prefix = $1 AND resource_attribute = $2 AND resource_value = $3
+	`, rule.MetricPrefix, rule.ResourceAttribute, rule.ResourceValue)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query metric owners: %w", err,
+		))
+	}
+	if existing > 0 {
+		return nil, &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("an owner is already assigned to the same metrics"),
+		}
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO metric_owners (
+			id, team, metric_prefix, resource_attribute, resource_value, created_by, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, rule.Id, rule.Team, rule.MetricPrefix, rule.ResourceAttribute, rule.ResourceValue,
+		rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert metric owner: %w", err,
+		))
+	}
+
+	return rule, nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM metric_owners WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete metric owner %s: %w", id, err,
+		))
+	}
+	return nil
+}
@@ -492,7 +492,7 @@ func PrepareBuilderMetricQueries(qp *model.QueryRangeParamsV2, tableName string)
 // PromFormattedValue formats the value to be used in promql
 func PromFormattedValue(v interface{}) string {
 	switch x := v.(type) {
-	case int:
+	case int, int64:
 		return fmt.Sprintf("%d", x)
 	case float32, float64:
 		return fmt.Sprintf("%f", x)
@@ -505,7 +505,7 @@ func PromFormattedValue(v interface{}) string {
 			return ""
 		}
 		switch x[0].(type) {
-		case string, int, float32, float64, bool:
+		case string, int, int64, float32, float64, bool:
 			// list of values joined by | for promql - a value can contain whitespace
 			var str []string
 			for _, sVal := range x {
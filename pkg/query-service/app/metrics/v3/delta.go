@@ -52,7 +52,7 @@ func buildDeltaMetricQuery(start, end, step int64, mq *v3.BuilderQuery, tableNam
 		return "", err
 	}
 
-	samplesTableTimeFilter := fmt.Sprintf("metric_name = %s AND timestamp_ms >= %d AND timestamp_ms <= %d", utils.ClickHouseFormattedValue(mq.AggregateAttribute.Key), start, end)
+	samplesTableTimeFilter := fmt.Sprintf("metric_name = %s AND timestamp_ms >= %s AND timestamp_ms <= %s", utils.ClickHouseFormattedValue(mq.AggregateAttribute.Key), utils.ClickHouseFormattedTimestampMs(mq, start), utils.ClickHouseFormattedTimestampMs(mq, end))
 
 	// Select the aggregate value for interval
 	queryTmpl :=
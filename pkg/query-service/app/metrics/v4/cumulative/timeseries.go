@@ -112,7 +112,7 @@ func prepareTimeAggregationSubQuery(start, end, step int64, mq *v3.BuilderQuery)
 		return "", err
 	}
 
-	samplesTableFilter := fmt.Sprintf("metric_name = %s AND unix_milli >= %d AND unix_milli < %d", utils.ClickHouseFormattedValue(mq.AggregateAttribute.Key), start, end)
+	samplesTableFilter := fmt.Sprintf("metric_name = %s AND unix_milli >= %s AND unix_milli < %s", utils.ClickHouseFormattedValue(mq.AggregateAttribute.Key), utils.ClickHouseFormattedTimestampMs(mq, start), utils.ClickHouseFormattedTimestampMs(mq, end))
 
 	// Select the aggregate value for interval
 	queryTmpl :=
@@ -49,7 +49,7 @@ func PrepareTimeseriesFilterQuery(start, end int64, mq *v3.BuilderQuery) (string
 
 	start, end, tableName := which(start, end)
 
-	conditions = append(conditions, fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end))
+	conditions = append(conditions, fmt.Sprintf("unix_milli >= %s AND unix_milli < %s", utils.ClickHouseFormattedTimestampMs(mq, start), utils.ClickHouseFormattedTimestampMs(mq, end)))
 
 	if fs != nil && len(fs.Items) != 0 {
 		for _, item := range fs.Items {
@@ -0,0 +1,157 @@
+package obfuscation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"golang.org/x/exp/slices"
+)
+
+// Controller manages the obfuscation profiles applied to the query results
+// shared outside of SigNoz
+type Controller struct {
+	repo *Repo
+
+	// results are obfuscated for each query, too often to hit the db
+	profilesMtx sync.RWMutex
+	profiles    []ObfuscationProfile
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create obfuscation profiles repo: %w", err)
+	}
+
+	c := &Controller{
+		repo: repo,
+	}
+	if apiErr := c.reloadProfiles(context.Background()); apiErr != nil {
+		return nil, fmt.Errorf("couldn't load obfuscation profiles: %w", apiErr.ToError())
+	}
+	return c, nil
+}
+
+func (c *Controller) reloadProfiles(ctx context.Context) *model.ApiError {
+	profiles, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	c.profilesMtx.Lock()
+	defer c.profilesMtx.Unlock()
+	c.profiles = profiles
+	return nil
+}
+
+func (c *Controller) ListObfuscationProfiles(ctx context.Context) ([]ObfuscationProfile, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+func (c *Controller) GetObfuscationProfile(ctx context.Context, id string) (*ObfuscationProfile, *model.ApiError) {
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) CreateObfuscationProfile(
+	ctx context.Context, postable *PostableObfuscationProfile,
+) (*ObfuscationProfile, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	profile, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return profile, c.reloadProfiles(ctx)
+}
+
+func (c *Controller) UpdateObfuscationProfile(
+	ctx context.Context, id string, postable *PostableObfuscationProfile,
+) (*ObfuscationProfile, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &updated, c.reloadProfiles(ctx)
+}
+
+func (c *Controller) DeleteObfuscationProfile(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.reloadProfiles(ctx)
+}
+
+// ObfuscateResults applies the requested obfuscation profile, if any, and
+// the profiles enforced for the role of the user in ctx to query results,
+// returning obfuscated copies of them. Results are left as is when no
+// profile applies.
+func (c *Controller) ObfuscateResults(
+	ctx context.Context, requested string, results []*v3.Result,
+) ([]*v3.Result, *model.ApiError) {
+	c.profilesMtx.RLock()
+	profiles := c.profiles
+	c.profilesMtx.RUnlock()
+
+	// queries made without a user, e.g. by rules, are not shared
+	role := ""
+	if user := common.GetUserFromContext(ctx); user != nil {
+		role = querylimits.RoleOf(user)
+	}
+
+	found := requested == ""
+	for i := range profiles {
+		profile := &profiles[i]
+		if profile.Id == requested {
+			found = true
+		} else if role == "" || !slices.Contains(profile.Spec.EnforcedRoles, role) {
+			continue
+		}
+		results = newObfuscator(profile).results(results)
+	}
+	if !found {
+		return nil, model.BadRequest(fmt.Errorf("obfuscation profile %s not found", requested))
+	}
+	return results, nil
+}
@@ -0,0 +1,115 @@
+package obfuscation
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"golang.org/x/exp/slices"
+)
+
+// ObfuscationProfile hides attributes of query results meant to be shared
+// externally, e.g. internal hostnames and user identifiers, while keeping
+// them distinguishable where needed
+type ObfuscationProfile struct {
+	Id        string      `json:"id" db:"id"`
+	Name      string      `json:"name" db:"name"`
+	Spec      ProfileSpec `json:"spec" db:"spec_json"`
+	CreatedBy string      `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedBy string      `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time   `json:"updatedAt" db:"updated_at"`
+
+	// Salt of the hashes, so that hashed values can't be looked up
+	Salt string `json:"-" db:"salt"`
+}
+
+type ProfileSpec struct {
+	// HashAttributes are replaced by a hash of their value, the same values
+	// get the same hash so that e.g. the rows of a user can still be told
+	// apart from the rows of others
+	HashAttributes []string `json:"hashAttributes"`
+	// MaskAttributes are replaced by a fixed mask
+	MaskAttributes []string `json:"maskAttributes"`
+	// StripBodies empties the bodies of logs
+	StripBodies bool `json:"stripBodies"`
+	// EnforcedRoles are the roles whose users always get results obfuscated
+	// with the profile, whether they ask for it or not
+	EnforcedRoles []string `json:"enforcedRoles"`
+}
+
+// For serializing from db
+func (s *ProfileSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s ProfileSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize obfuscation profile spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *ProfileSpec) setDefaults() {
+	if s.HashAttributes == nil {
+		s.HashAttributes = []string{}
+	}
+	if s.MaskAttributes == nil {
+		s.MaskAttributes = []string{}
+	}
+	if s.EnforcedRoles == nil {
+		s.EnforcedRoles = []string{}
+	}
+}
+
+type PostableObfuscationProfile struct {
+	Name string      `json:"name"`
+	Spec ProfileSpec `json:"spec"`
+}
+
+func (p *PostableObfuscationProfile) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("obfuscation profile name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *ProfileSpec) IsValid() error {
+	if len(s.HashAttributes) == 0 && len(s.MaskAttributes) == 0 && !s.StripBodies {
+		return fmt.Errorf("obfuscation profile must hash or mask attributes, or strip bodies")
+	}
+
+	hashed := map[string]bool{}
+	for _, attribute := range s.HashAttributes {
+		if strings.TrimSpace(attribute) == "" {
+			return fmt.Errorf("hashed attributes can't be empty")
+		}
+		hashed[attribute] = true
+	}
+	for _, attribute := range s.MaskAttributes {
+		if strings.TrimSpace(attribute) == "" {
+			return fmt.Errorf("masked attributes can't be empty")
+		}
+		if hashed[attribute] {
+			return fmt.Errorf("attribute %s can't be both hashed and masked", attribute)
+		}
+	}
+	for _, role := range s.EnforcedRoles {
+		if !slices.Contains(querylimits.Roles, role) {
+			return fmt.Errorf("unknown role %s, must be one of %v", role, querylimits.Roles)
+		}
+	}
+	return nil
+}
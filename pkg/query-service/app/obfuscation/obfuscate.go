@@ -0,0 +1,129 @@
+package obfuscation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	maskedValue = "****"
+	// hashes are truncated, long enough for the values of a result not to
+	// collide while keeping the shared data readable
+	hashLength = 16
+
+	bodyKey = "body"
+)
+
+type obfuscator struct {
+	profile *ObfuscationProfile
+	hashed  map[string]bool
+	masked  map[string]bool
+}
+
+func newObfuscator(profile *ObfuscationProfile) *obfuscator {
+	o := &obfuscator{profile: profile, hashed: map[string]bool{}, masked: map[string]bool{}}
+	for _, attribute := range profile.Spec.HashAttributes {
+		o.hashed[attribute] = true
+	}
+	for _, attribute := range profile.Spec.MaskAttributes {
+		o.masked[attribute] = true
+	}
+	return o
+}
+
+func (o *obfuscator) hash(value string) string {
+	mac := hmac.New(sha256.New, []byte(o.profile.Salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// value returns the obfuscated value of an attribute
+func (o *obfuscator) value(key string, value string) string {
+	switch {
+	case o.hashed[key]:
+		return o.hash(value)
+	case o.masked[key]:
+		return maskedValue
+	}
+	return value
+}
+
+func (o *obfuscator) labels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	obfuscated := make(map[string]string, len(labels))
+	for key, value := range labels {
+		obfuscated[key] = o.value(key, value)
+	}
+	return obfuscated
+}
+
+// rowValue obfuscates a value of a list row, the attributes of logs and
+// spans are nested in maps by type
+func (o *obfuscator) rowValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if key == bodyKey && o.profile.Spec.StripBodies {
+			return ""
+		}
+		return o.value(key, v)
+	case map[string]string:
+		return o.labels(v)
+	case map[string]interface{}:
+		obfuscated := make(map[string]interface{}, len(v))
+		for nestedKey, nestedValue := range v {
+			if s, ok := nestedValue.(string); ok {
+				obfuscated[nestedKey] = o.value(nestedKey, s)
+			} else {
+				obfuscated[nestedKey] = nestedValue
+			}
+		}
+		return obfuscated
+	}
+	return value
+}
+
+// results returns obfuscated copies of query results, the results may be
+// cached and are left as is
+func (o *obfuscator) results(results []*v3.Result) []*v3.Result {
+	obfuscated := make([]*v3.Result, 0, len(results))
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		copied := *result
+
+		if result.Series != nil {
+			copied.Series = make([]*v3.Series, 0, len(result.Series))
+			for _, series := range result.Series {
+				copiedSeries := *series
+				copiedSeries.Labels = o.labels(series.Labels)
+				if series.LabelsArray != nil {
+					copiedSeries.LabelsArray = make([]map[string]string, 0, len(series.LabelsArray))
+					for _, labels := range series.LabelsArray {
+						copiedSeries.LabelsArray = append(copiedSeries.LabelsArray, o.labels(labels))
+					}
+				}
+				copied.Series = append(copied.Series, &copiedSeries)
+			}
+		}
+
+		if result.List != nil {
+			copied.List = make([]*v3.Row, 0, len(result.List))
+			for _, row := range result.List {
+				copiedRow := *row
+				copiedRow.Data = make(map[string]interface{}, len(row.Data))
+				for key, value := range row.Data {
+					copiedRow.Data[key] = o.rowValue(key, value)
+				}
+				copied.List = append(copied.List, &copiedRow)
+			}
+		}
+		obfuscated = append(obfuscated, &copied)
+	}
+	return obfuscated
+}
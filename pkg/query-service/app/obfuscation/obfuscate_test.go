@@ -0,0 +1,192 @@
+package obfuscation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestObfuscateResults(t *testing.T) {
+	require := require.New(t)
+
+	require.NotNil((&PostableObfuscationProfile{Name: "support"}).IsValid(), "an action is required")
+	require.NotNil((&PostableObfuscationProfile{Name: "support", Spec: ProfileSpec{
+		HashAttributes: []string{"user.id"}, MaskAttributes: []string{"user.id"},
+	}}).IsValid(), "an attribute can't be both hashed and masked")
+
+	profile := &ObfuscationProfile{
+		Salt: "salt",
+		Spec: ProfileSpec{
+			HashAttributes: []string{"user.id"},
+			MaskAttributes: []string{"host.name"},
+			StripBodies:    true,
+		},
+	}
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"user.id": "alice", "host.name": "db-1", "service.name": "api"}},
+				{Labels: map[string]string{"user.id": "bob", "host.name": "db-1", "service.name": "api"}},
+			},
+		},
+		{
+			QueryName: "B",
+			List: []*v3.Row{
+				{Data: map[string]interface{}{
+					"body":              "login of alice",
+					"attributes_string": map[string]string{"user.id": "alice", "http.method": "GET"},
+					"resources_string":  map[string]interface{}{"host.name": "db-1", "pid": 42},
+				}},
+			},
+		},
+	}
+
+	obfuscated := newObfuscator(profile).results(results)
+	require.Len(obfuscated, 2)
+
+	alice := obfuscated[0].Series[0].Labels
+	bob := obfuscated[0].Series[1].Labels
+	require.Len(alice["user.id"], hashLength)
+	require.NotEqual("alice", alice["user.id"])
+	require.NotEqual(alice["user.id"], bob["user.id"])
+	require.Equal(maskedValue, alice["host.name"])
+	require.Equal("api", alice["service.name"])
+
+	row := obfuscated[1].List[0].Data
+	require.Equal("", row["body"])
+	require.Equal(
+		map[string]string{"user.id": alice["user.id"], "http.method": "GET"},
+		row["attributes_string"], "the same values get the same hash",
+	)
+	require.Equal(map[string]interface{}{"host.name": maskedValue, "pid": 42}, row["resources_string"])
+
+	require.Equal("alice", results[0].Series[0].Labels["user.id"], "the results are left as is")
+	require.Equal("login of alice", results[1].List[0].Data["body"])
+
+	salted := newObfuscator(&ObfuscationProfile{Salt: "other", Spec: profile.Spec}).results(results)
+	require.NotEqual(alice["user.id"], salted[0].Series[0].Labels["user.id"])
+}
+
+func TestEnforcedObfuscationProfiles(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	controller, err := NewController(db)
+	require.Nil(err)
+
+	require.NotNil((&PostableObfuscationProfile{Name: "support", Spec: ProfileSpec{
+		StripBodies: true, EnforcedRoles: []string{"GUEST"},
+	}}).IsValid(), "unknown roles should be rejected")
+
+	auth.AuthCacheObj.AdminGroupId = "admins"
+	auth.AuthCacheObj.ViewerGroupId = "viewers"
+	viewerCtx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "viewer1", GroupId: "viewers"},
+		},
+	)
+	adminJwt, err := auth.GenerateJWTForUser(&model.User{Id: "admin1", Email: "admin1@signoz.io"})
+	require.Nil(err)
+	adminRequest := httptest.NewRequest(http.MethodPost, "/api/v1/obfuscation_profiles", nil)
+	adminRequest.Header.Add("Authorization", "Bearer "+adminJwt.AccessJwt)
+	adminCtx := context.WithValue(
+		auth.AttachJwtToContext(context.Background(), adminRequest),
+		constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "admin1", GroupId: "admins"},
+		},
+	)
+
+	viewers, apiErr := controller.CreateObfuscationProfile(adminCtx, &PostableObfuscationProfile{
+		Name: "viewers", Spec: ProfileSpec{
+			MaskAttributes: []string{"host.name"}, EnforcedRoles: []string{constants.ViewerGroup},
+		},
+	})
+	require.Nil(apiErr)
+	support, apiErr := controller.CreateObfuscationProfile(adminCtx, &PostableObfuscationProfile{
+		Name: "support", Spec: ProfileSpec{StripBodies: true},
+	})
+	require.Nil(apiErr)
+
+	results := []*v3.Result{{
+		QueryName: "A",
+		List: []*v3.Row{{Data: map[string]interface{}{
+			"body":             "login of alice",
+			"resources_string": map[string]interface{}{"host.name": "db-1"},
+		}}},
+	}}
+	row := func(results []*v3.Result) map[string]interface{} {
+		return results[0].List[0].Data
+	}
+
+	// the profile enforced for viewers applies whether they ask for it or not
+	obfuscated, apiErr := controller.ObfuscateResults(viewerCtx, "", results)
+	require.Nil(apiErr)
+	require.Equal(map[string]interface{}{"host.name": maskedValue}, row(obfuscated)["resources_string"])
+	require.Equal("login of alice", row(obfuscated)["body"])
+
+	obfuscated, apiErr = controller.ObfuscateResults(viewerCtx, support.Id, results)
+	require.Nil(apiErr)
+	require.Equal(map[string]interface{}{"host.name": maskedValue}, row(obfuscated)["resources_string"])
+	require.Equal("", row(obfuscated)["body"], "the requested profile applies on top")
+
+	obfuscated, apiErr = controller.ObfuscateResults(adminCtx, "", results)
+	require.Nil(apiErr)
+	require.Equal(results, obfuscated, "no profile is enforced for admins")
+	obfuscated, apiErr = controller.ObfuscateResults(adminCtx, viewers.Id, results)
+	require.Nil(apiErr)
+	require.Equal(map[string]interface{}{"host.name": maskedValue}, row(obfuscated)["resources_string"])
+
+	obfuscated, apiErr = controller.ObfuscateResults(context.Background(), "", results)
+	require.Nil(apiErr)
+	require.Equal(results, obfuscated)
+
+	_, apiErr = controller.ObfuscateResults(viewerCtx, "missing", results)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Type())
+
+	// the profiles are cached, the writes through the controller reload them
+	_, apiErr = controller.repo.insert(adminCtx, "admin1", &PostableObfuscationProfile{
+		Name: "written elsewhere", Spec: ProfileSpec{
+			StripBodies: true, EnforcedRoles: []string{constants.ViewerGroup},
+		},
+	})
+	require.Nil(apiErr)
+	obfuscated, apiErr = controller.ObfuscateResults(viewerCtx, "", results)
+	require.Nil(apiErr)
+	require.Equal("login of alice", row(obfuscated)["body"], "the cached profiles are used")
+
+	_, apiErr = controller.UpdateObfuscationProfile(adminCtx, viewers.Id, &PostableObfuscationProfile{
+		Name: "viewers", Spec: ProfileSpec{
+			HashAttributes: []string{"host.name"}, EnforcedRoles: []string{constants.ViewerGroup},
+		},
+	})
+	require.Nil(apiErr)
+	obfuscated, apiErr = controller.ObfuscateResults(viewerCtx, "", results)
+	require.Nil(apiErr)
+	host := row(obfuscated)["resources_string"].(map[string]interface{})["host.name"]
+	require.Len(host, hashLength, "the updated profile applies")
+	require.Equal("", row(obfuscated)["body"], "the profiles are reloaded on writes")
+
+	require.Nil(controller.DeleteObfuscationProfile(adminCtx, viewers.Id))
+	obfuscated, apiErr = controller.ObfuscateResults(viewerCtx, "", results)
+	require.Nil(apiErr)
+	require.Equal(map[string]interface{}{"host.name": "db-1"}, row(obfuscated)["resources_string"])
+	_, apiErr = controller.ObfuscateResults(viewerCtx, viewers.Id, results)
+	require.NotNil(apiErr, "deleted profiles can't be requested")
+}
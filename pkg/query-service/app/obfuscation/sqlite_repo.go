@@ -0,0 +1,186 @@
+package obfuscation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS obfuscation_profiles(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			salt TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure obfuscation profiles schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for obfuscation profiles: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]ObfuscationProfile, *model.ApiError) {
+	profiles := []ObfuscationProfile{}
+
+	err := r.db.SelectContext(ctx, &profiles, `
+		SELECT id, name, spec_json, salt, created_by, created_at, updated_by, updated_at
+		FROM obfuscation_profiles
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query obfuscation profiles: %w", err,
+		))
+	}
+	return profiles, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*ObfuscationProfile, *model.ApiError) {
+	profiles := []ObfuscationProfile{}
+
+	err := r.db.SelectContext(ctx, &profiles, `
+		SELECT id, name, spec_json, salt, created_by, created_at, updated_by, updated_at
+		FROM obfuscation_profiles
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query obfuscation profile %s: %w", id, err,
+		))
+	}
+
+	if len(profiles) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("obfuscation profile %s not found", id))
+	}
+	return &profiles[0], nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM obfuscation_profiles WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query obfuscation profiles: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a obfuscation profile named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableObfuscationProfile,
+) (*ObfuscationProfile, *model.ApiError) {
+	now := time.Now()
+	profile := &ObfuscationProfile{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		Salt:      uuid.NewString(),
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, profile.Name, profile.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO obfuscation_profiles (
+			id, name, spec_json, salt, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		profile.Id, profile.Name, profile.Spec, profile.Salt,
+		profile.CreatedBy, profile.CreatedAt,
+		profile.UpdatedBy, profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert obfuscation profile: %w", err,
+		))
+	}
+
+	return profile, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, profile *ObfuscationProfile,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, profile.Name, profile.Id); apiErr != nil {
+		return apiErr
+	}
+
+	profile.UpdatedBy = userId
+	profile.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE obfuscation_profiles
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		profile.Name, profile.Spec,
+		profile.UpdatedBy, profile.UpdatedAt, profile.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update obfuscation profile %s: %w", profile.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM obfuscation_profiles WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete obfuscation profile %s: %w", id, err,
+		))
+	}
+	return nil
+}
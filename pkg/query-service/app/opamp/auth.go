@@ -0,0 +1,184 @@
+package opamp
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/open-telemetry/opamp-go/server/types"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.uber.org/zap"
+)
+
+// AuthSettings secure the connections of the agents, which receive the
+// full collector config including the credentials of the exporters.
+// Without any setting any process reaching the port can connect.
+type AuthSettings struct {
+	// TLS is enabled when both the certificate and its key are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile requires agents to present a client certificate signed
+	// by one of its CAs, agents are identified by the certificate's subject
+	ClientCAFile string
+	// Tokens maps the bearer tokens accepted from agents to the identity of
+	// the agents presenting them
+	Tokens map[string]string
+}
+
+// ParseAuthTokens parses tokens given as comma separated identity=token
+// pairs, e.g. edge=s3cr3t,k8s=t0k3n
+func ParseAuthTokens(tokens string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, pair := range strings.Split(tokens, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		identity, token, found := strings.Cut(pair, "=")
+		if !found || identity == "" || token == "" {
+			return nil, fmt.Errorf("opamp auth tokens must be identity=token pairs")
+		}
+		if _, ok := parsed[token]; ok {
+			return nil, fmt.Errorf("opamp auth token of %s is used by another identity", identity)
+		}
+		parsed[token] = identity
+	}
+	return parsed, nil
+}
+
+// AuthSettingsFromEnv returns the auth settings configured with the
+// OPAMP_TLS_* and OPAMP_AUTH_TOKENS environment variables
+func AuthSettingsFromEnv() (AuthSettings, error) {
+	tokens, err := ParseAuthTokens(constants.OpAmpAuthTokens)
+	if err != nil {
+		return AuthSettings{}, err
+	}
+	settings := AuthSettings{
+		TLSCertFile:  constants.OpAmpTLSCertFile,
+		TLSKeyFile:   constants.OpAmpTLSKeyFile,
+		ClientCAFile: constants.OpAmpTLSClientCAFile,
+		Tokens:       tokens,
+	}
+	if settings.ClientCAFile == "" && len(settings.Tokens) == 0 {
+		zap.L().Warn("opamp agents are not authenticated, any process reaching the opamp port can get the collector config")
+	}
+	return settings, nil
+}
+
+func (s *AuthSettings) tlsConfig() (*tls.Config, error) {
+	if s.TLSCertFile == "" && s.TLSKeyFile == "" {
+		if s.ClientCAFile != "" {
+			return nil, fmt.Errorf("client certificates can't be verified without TLS, the certificate and key of the opamp server are required")
+		}
+		return nil, nil
+	}
+	if s.TLSCertFile == "" || s.TLSKeyFile == "" {
+		return nil, fmt.Errorf("both the certificate and the key of the opamp server are required for TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.TLSCertFile, s.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load opamp server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if s.ClientCAFile != "" {
+		pem, err := os.ReadFile(s.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read opamp client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in opamp client CA %s", s.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// authenticate returns the identity of the agent making the request, empty
+// if no authentication is configured
+func (s *AuthSettings) authenticate(r *http.Request) (string, error) {
+	identities := []string{}
+
+	if s.ClientCAFile != "" {
+		// the certificate was verified during the handshake
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			return "", fmt.Errorf("no verified client certificate")
+		}
+		identities = append(identities, "cert:"+r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}
+
+	if len(s.Tokens) > 0 {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			return "", fmt.Errorf("no bearer token")
+		}
+		identity := ""
+		for t, i := range s.Tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				identity = i
+			}
+		}
+		if identity == "" {
+			return "", fmt.Errorf("invalid bearer token")
+		}
+		identities = append(identities, "token:"+identity)
+	}
+
+	return strings.Join(identities, ","), nil
+}
+
+// connectionIdentities keeps the identity agents authenticated as until
+// their connection is established. Connections are only known by their
+// remote address when authenticating.
+type connectionIdentities struct {
+	byRemoteAddr map[string]string
+	mux          sync.Mutex
+}
+
+func (c *connectionIdentities) set(addr string, identity string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.byRemoteAddr[addr] = identity
+}
+
+func (c *connectionIdentities) get(conn types.Connection) string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.byRemoteAddr[remoteAddr(conn)]
+}
+
+func (c *connectionIdentities) remove(conn types.Connection) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.byRemoteAddr, remoteAddr(conn))
+}
+
+func remoteAddr(conn types.Connection) string {
+	if conn == nil || conn.RemoteAddr() == nil {
+		return ""
+	}
+	return conn.RemoteAddr().String()
+}
+
+func (srv *Server) onConnecting(r *http.Request) types.ConnectionResponse {
+	identity, err := srv.auth.authenticate(r)
+	if err != nil {
+		zap.L().Warn("rejected opamp connection",
+			zap.String("remoteAddr", r.RemoteAddr), zap.Error(err),
+		)
+		return types.ConnectionResponse{Accept: false, HTTPStatusCode: http.StatusUnauthorized}
+	}
+	srv.identities.set(r.RemoteAddr, identity)
+	return types.ConnectionResponse{Accept: true}
+}
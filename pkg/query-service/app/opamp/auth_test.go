@@ -0,0 +1,110 @@
+package opamp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/open-telemetry/opamp-go/protobufs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthTokens(t *testing.T) {
+	tokens, err := ParseAuthTokens(" edge=s3cr3t, k8s=t0k3n=,")
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"s3cr3t": "edge", "t0k3n=": "k8s"}, tokens)
+
+	tokens, err = ParseAuthTokens("")
+	require.Nil(t, err)
+	require.Empty(t, tokens)
+
+	_, err = ParseAuthTokens("s3cr3t")
+	require.NotNil(t, err)
+	_, err = ParseAuthTokens("edge=s3cr3t,k8s=s3cr3t")
+	require.NotNil(t, err)
+}
+
+func TestAuthenticate(t *testing.T) {
+	require := require.New(t)
+
+	request := func(token string, cn string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/opamp", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		if cn != "" {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+				{Subject: pkix.Name{CommonName: cn}},
+			}}}
+		}
+		return r
+	}
+
+	noAuth := AuthSettings{}
+	identity, err := noAuth.authenticate(request("", ""))
+	require.Nil(err)
+	require.Equal("", identity)
+
+	tokenAuth := AuthSettings{Tokens: map[string]string{"s3cr3t": "edge"}}
+	identity, err = tokenAuth.authenticate(request("s3cr3t", ""))
+	require.Nil(err)
+	require.Equal("token:edge", identity)
+	_, err = tokenAuth.authenticate(request("wrong", ""))
+	require.NotNil(err)
+	_, err = tokenAuth.authenticate(request("", ""))
+	require.NotNil(err)
+
+	mtls := AuthSettings{ClientCAFile: "ca.pem", Tokens: map[string]string{"s3cr3t": "edge"}}
+	identity, err = mtls.authenticate(request("s3cr3t", "host-1"))
+	require.Nil(err)
+	require.Equal("cert:host-1,token:edge", identity)
+	_, err = mtls.authenticate(request("s3cr3t", ""))
+	require.NotNil(err)
+
+	_, err = (&AuthSettings{ClientCAFile: "ca.pem"}).tlsConfig()
+	require.NotNil(err, "client certificates require TLS")
+	_, err = (&AuthSettings{TLSCertFile: "cert.pem"}).tlsConfig()
+	require.NotNil(err, "TLS requires the key")
+}
+
+func TestAgentIdentity(t *testing.T) {
+	require := require.New(t)
+
+	tb := newTestbed(t)
+	tb.opampServer.auth = AuthSettings{Tokens: map[string]string{"s3cr3t": "edge", "t0k3n": "k8s"}}
+
+	connect := func(addr string, token string) *MockOpAmpConnection {
+		r := httptest.NewRequest(http.MethodGet, "/v1/opamp", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("Authorization", "Bearer "+token)
+		require.True(tb.opampServer.onConnecting(r).Accept)
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		require.Nil(err)
+		return &MockOpAmpConnection{Addr: tcpAddr}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/opamp", nil)
+	response := tb.opampServer.onConnecting(r)
+	require.False(response.Accept)
+	require.Equal(http.StatusUnauthorized, response.HTTPStatusCode)
+
+	edgeConn := connect("10.0.0.1:40000", "s3cr3t")
+	tb.opampServer.OnMessage(edgeConn, &protobufs.AgentToServer{InstanceUid: "agent-1"})
+	view, err := tb.opampServer.agents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.Equal("token:edge", view.Identity)
+
+	// agents can't take over the instance id of an agent connected with
+	// another identity
+	k8sConn := connect("10.0.0.2:40000", "t0k3n")
+	response2 := tb.opampServer.OnMessage(k8sConn, &protobufs.AgentToServer{InstanceUid: "agent-1"})
+	require.NotNil(response2.ErrorResponse)
+	require.Nil(response2.RemoteConfig)
+
+	tb.opampServer.onDisconnect(edgeConn)
+	require.Equal("", tb.opampServer.identities.get(edgeConn))
+}
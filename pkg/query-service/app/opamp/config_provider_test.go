@@ -180,7 +180,7 @@ func newTestbed(t *testing.T) *testbed {
 	}
 
 	testConfigProvider := NewMockAgentConfigProvider()
-	opampServer := InitializeServer(nil, testConfigProvider)
+	opampServer := InitializeServer(nil, testConfigProvider, AuthSettings{})
 
 	return &testbed{
 		testConfigProvider: testConfigProvider,
@@ -21,13 +21,27 @@ func UpsertControlProcessors(
 	signal string,
 	processors map[string]interface{},
 	callback model.OnChangeCallback,
+) (hash string, fnerr *coreModel.ApiError) {
+	return UpsertControlProcessorsForAgents(ctx, signal, func(model.AgentInfo) (map[string]interface{}, error) {
+		return processors, nil
+	}, callback)
+}
+
+// UpsertControlProcessorsForAgents is UpsertControlProcessors with the
+// processors of each agent given by processorsFor, e.g. for processors
+// targeting agent groups
+func UpsertControlProcessorsForAgents(
+	ctx context.Context,
+	signal string,
+	processorsFor func(agent model.AgentInfo) (map[string]interface{}, error),
+	callback model.OnChangeCallback,
 ) (hash string, fnerr *coreModel.ApiError) {
 	// note: only processors enabled through tracesPipelinePlan will be added
 	// to pipeline. To enable or disable processors from pipeline, call
 	// AddToTracePipeline() or RemoveFromTracesPipeline() prior to calling
 	// this method
 
-	zap.S().Debug("initiating ingestion rules deployment config", signal, processors)
+	zap.S().Debug("initiating ingestion rules deployment config", signal)
 
 	if signal != string(Metrics) && signal != string(Traces) {
 		zap.S().Error("received invalid signal int UpsertControlProcessors", signal)
@@ -57,6 +71,11 @@ func UpsertControlProcessors(
 	}
 
 	for _, agent := range agents {
+		processors, err := processorsFor(agent.Info())
+		if err != nil {
+			zap.S().Error("failed to prepare ingestion rules config for agent", agent.ID, err)
+			continue
+		}
 
 		agenthash, err := addIngestionControlToAgent(agent, signal, processors, false)
 		if err != nil {
@@ -11,10 +11,13 @@ import (
 	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/open-telemetry/opamp-go/protobufs"
 	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/opamp/model"
 )
 
 type MockOpAmpConnection struct {
 	ServerToAgentMsgs []*protobufs.ServerToAgent
+	// Addr is the remote address of the connection, nil if not set
+	Addr net.Addr
 }
 
 func (conn *MockOpAmpConnection) Send(ctx context.Context, msg *protobufs.ServerToAgent) error {
@@ -39,7 +42,7 @@ func (conn *MockOpAmpConnection) Disconnect() error {
 	return nil
 }
 func (conn *MockOpAmpConnection) RemoteAddr() net.Addr {
-	return nil
+	return conn.Addr
 }
 
 // Implements opamp.AgentConfigProvider
@@ -67,7 +70,7 @@ func (ta *MockAgentConfigProvider) HasRecommendations() bool {
 }
 
 // AgentConfigProvider interface
-func (ta *MockAgentConfigProvider) RecommendAgentConfig(baseConfYaml []byte) (
+func (ta *MockAgentConfigProvider) RecommendAgentConfig(agent model.AgentInfo, baseConfYaml []byte) (
 	[]byte, string, error,
 ) {
 	if len(ta.ZPagesEndpoint) < 1 {
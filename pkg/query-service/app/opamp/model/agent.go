@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,8 +33,28 @@ type Agent struct {
 	TerminatedAt    time.Time   `json:"terminatedAt" yaml:"terminatedAt" db:"terminated_at"`
 	EffectiveConfig string      `json:"effectiveConfig" yaml:"effectiveConfig" db:"effective_config"`
 	CurrentStatus   AgentStatus `json:"currentStatus" yaml:"currentStatus" db:"current_status"`
-	remoteConfig    *protobufs.AgentRemoteConfig
-	Status          *protobufs.AgentToServer
+	LastSeenAt      time.Time   `json:"lastSeenAt" yaml:"lastSeenAt" db:"last_seen_at"`
+	Hostname        string      `json:"hostname" yaml:"hostname" db:"hostname"`
+	Version         string      `json:"version" yaml:"version" db:"version"`
+	// Identity the agent authenticated as, e.g. token:edge or cert:host-1,
+	// empty if the opamp server doesn't authenticate agents
+	Identity string `json:"identity" yaml:"identity" db:"identity"`
+	// DisconnectedCleanly is set once the agent tells it is shutting down
+	DisconnectedCleanly bool `json:"disconnectedCleanly" yaml:"disconnectedCleanly" db:"disconnected_cleanly"`
+
+	// status of the last remote config the agent reported, e.g. applied
+	RemoteConfigStatus string `json:"remoteConfigStatus" yaml:"remoteConfigStatus" db:"remote_config_status"`
+	RemoteConfigError  string `json:"remoteConfigError" yaml:"remoteConfigError" db:"remote_config_error"`
+	remoteConfig       *protobufs.AgentRemoteConfig
+	Status             *protobufs.AgentToServer
+
+	// hash of the packages last offered over the agent's connection
+	offeredPackagesHash []byte
+
+	// since when the effective config differs from the config recommended
+	// with the id driftConfigId, nil if it doesn't
+	configDriftedSince *time.Time
+	driftConfigId      string
 
 	// can this agent be load balancer
 	CanLB bool
@@ -47,7 +68,8 @@ type Agent struct {
 }
 
 func New(ID string, conn types.Connection) *Agent {
-	return &Agent{ID: ID, StartedAt: time.Now(), CurrentStatus: AgentStatusConnected, conn: conn}
+	now := time.Now()
+	return &Agent{ID: ID, StartedAt: now, LastSeenAt: now, CurrentStatus: AgentStatusConnected, conn: conn}
 }
 
 // Upsert inserts or updates the agent in the database.
@@ -55,17 +77,40 @@ func (agent *Agent) Upsert() error {
 	agent.mux.Lock()
 	defer agent.mux.Unlock()
 
-	_, err := db.NamedExec(`INSERT OR REPLACE INTO agents (
+	// times are stored in UTC so that they compare as text
+	var terminatedAt *time.Time
+	if !agent.TerminatedAt.IsZero() {
+		t := agent.TerminatedAt.UTC()
+		terminatedAt = &t
+	}
+
+	_, err := db.Exec(`INSERT OR REPLACE INTO agents (
 		agent_id,
 		started_at,
+		terminated_at,
+		last_seen_at,
 		effective_config,
-		current_status
-	) VALUES (
-		:agent_id,
-		:started_at,
-		:effective_config,
-		:current_status
-	)`, agent)
+		current_status,
+		hostname,
+		version,
+		remote_config_status,
+		remote_config_error,
+		identity,
+		disconnected_cleanly
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		agent.ID,
+		agent.StartedAt.UTC(),
+		terminatedAt,
+		agent.LastSeenAt.UTC(),
+		agent.EffectiveConfig,
+		agent.CurrentStatus,
+		agent.Hostname,
+		agent.Version,
+		agent.RemoteConfigStatus,
+		agent.RemoteConfigError,
+		agent.Identity,
+		agent.DisconnectedCleanly,
+	)
 	if err != nil {
 		return err
 	}
@@ -73,6 +118,14 @@ func (agent *Agent) Upsert() error {
 	return nil
 }
 
+// SetIdentity records the identity the agent authenticated as
+func (agent *Agent) SetIdentity(identity string) error {
+	agent.mux.Lock()
+	agent.Identity = identity
+	agent.mux.Unlock()
+	return agent.Upsert()
+}
+
 // extracts lb exporter support flag from agent description. the flag
 // is used to decide if lb exporter can be enabled on the agent.
 func ExtractLbFlag(agentDescr *protobufs.AgentDescription) bool {
@@ -96,6 +149,38 @@ func ExtractLbFlag(agentDescr *protobufs.AgentDescription) bool {
 	return false
 }
 
+// info describes the agent to config providers. The caller must hold the agent lock.
+// Info describes the agent by what it reported
+func (agent *Agent) Info() AgentInfo {
+	agent.mux.RLock()
+	defer agent.mux.RUnlock()
+	return agent.info()
+}
+
+func (agent *Agent) info() AgentInfo {
+	info := AgentInfo{ID: agent.ID, Attributes: map[string]string{}}
+	if agent.Status == nil || agent.Status.AgentDescription == nil {
+		return info
+	}
+
+	descr := agent.Status.AgentDescription
+	for _, attributes := range [][]*protobufs.KeyValue{descr.IdentifyingAttributes, descr.NonIdentifyingAttributes} {
+		for _, kv := range attributes {
+			if kv.Value == nil {
+				continue
+			}
+			if _, ok := kv.Value.Value.(*protobufs.AnyValue_StringValue); !ok {
+				continue
+			}
+			info.Attributes[kv.Key] = kv.Value.GetStringValue()
+			if kv.Key == AgentGroupAttribute {
+				info.Group = kv.Value.GetStringValue()
+			}
+		}
+	}
+	return info
+}
+
 func (agent *Agent) updateAgentDescription(newStatus *protobufs.AgentToServer) (agentDescrChanged bool) {
 	prevStatus := agent.Status
 
@@ -146,6 +231,9 @@ func (agent *Agent) updateAgentDescription(newStatus *protobufs.AgentToServer) (
 
 	if agentDescrChanged {
 		agent.CanLB = ExtractLbFlag(newStatus.AgentDescription)
+		attributes := agent.info().Attributes
+		agent.Hostname = attributes[hostNameAttribute]
+		agent.Version = attributes[serviceVersionAttribute]
 	}
 
 	return agentDescrChanged
@@ -180,6 +268,14 @@ func (agent *Agent) updateStatusField(newStatus *protobufs.AgentToServer) (agent
 	agentDescrChanged = agent.updateAgentDescription(newStatus) || agentDescrChanged
 	agent.updateRemoteConfigStatus(newStatus)
 	agent.updateHealth(newStatus)
+	agent.updatePackageStatuses(newStatus)
+
+	if status := agent.Status.RemoteConfigStatus; status != nil {
+		agent.RemoteConfigStatus = strings.ToLower(
+			strings.TrimPrefix(status.Status.String(), "RemoteConfigStatuses_"),
+		)
+		agent.RemoteConfigError = status.ErrorMessage
+	}
 	return agentDescrChanged
 }
 
@@ -210,6 +306,10 @@ func (agent *Agent) UpdateStatus(
 ) {
 	agent.mux.Lock()
 	defer agent.mux.Unlock()
+	agent.LastSeenAt = time.Now()
+	if statusMsg.AgentDisconnect != nil {
+		agent.DisconnectedCleanly = true
+	}
 	agent.processStatusUpdate(statusMsg, response, configProvider)
 }
 
@@ -247,6 +347,9 @@ func (agent *Agent) processStatusUpdate(
 	// the latest value for agent.EffectiveConfig when generating a config recommendation
 	agent.updateEffectiveConfig(newStatus, response)
 
+	// Offer the packages of the agent's group, which can change with its description
+	agent.updatePackages(response)
+
 	configChanged := false
 	if agentDescrChanged {
 		// Agent description is changed.
@@ -274,7 +377,7 @@ func (agent *Agent) processStatusUpdate(
 }
 
 func (agent *Agent) updateRemoteConfig(configProvider AgentConfigProvider) bool {
-	recommendedConfig, confId, err := configProvider.RecommendAgentConfig([]byte(agent.EffectiveConfig))
+	recommendedConfig, confId, err := configProvider.RecommendAgentConfig(agent.info(), []byte(agent.EffectiveConfig))
 	if err != nil {
 		zap.S().Error("could not generate config recommendation for agent:", agent.ID, err)
 		return false
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -51,6 +52,38 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("Error in creating agents table: %s", err.Error())
 	}
 
+	// columns added after the table was first released
+	for _, column := range []string{
+		"last_seen_at datetime",
+		"hostname TEXT NOT NULL DEFAULT ''",
+		"version TEXT NOT NULL DEFAULT ''",
+		"remote_config_status TEXT NOT NULL DEFAULT ''",
+		"remote_config_error TEXT NOT NULL DEFAULT ''",
+		"identity TEXT NOT NULL DEFAULT ''",
+		"disconnected_cleanly BOOLEAN NOT NULL DEFAULT 0",
+	} {
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE agents ADD COLUMN %s;", column))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("Error in altering agents table: %s", err.Error())
+		}
+	}
+	_, err = db.Exec(`UPDATE agents SET last_seen_at = COALESCE(terminated_at, started_at) WHERE last_seen_at IS NULL;`)
+	if err != nil {
+		return nil, fmt.Errorf("Error in backfilling agents last seen time: %s", err.Error())
+	}
+
+	if err := initStaleAgentAlertSettings(); err != nil {
+		return nil, err
+	}
+
+	if err := initConfigDriftAlertSettings(); err != nil {
+		return nil, err
+	}
+
+	if err := initPackageTables(); err != nil {
+		return nil, err
+	}
+
 	AllAgents = Agents{
 		agentsById:  make(map[string]*Agent),
 		connections: make(map[types.Connection]map[string]bool),
@@ -67,8 +100,13 @@ func (agents *Agents) RemoveConnection(conn types.Connection) {
 
 	for instanceId := range agents.connections[conn] {
 		agent := agents.agentsById[instanceId]
+		// the agent is last seen when its connection closes, which
+		// stale agents are measured from
+		agent.mux.Lock()
 		agent.CurrentStatus = AgentStatusDisconnected
 		agent.TerminatedAt = time.Now()
+		agent.LastSeenAt = agent.TerminatedAt
+		agent.mux.Unlock()
 		agent.Upsert()
 		delete(agents.agentsById, instanceId)
 	}
@@ -119,14 +157,27 @@ func (agents *Agents) GetAllAgents() []*Agent {
 	return allAgents
 }
 
+// GetAllAgentInfos describes the connected agents by what they reported
+func (agents *Agents) GetAllAgentInfos() []AgentInfo {
+	infos := []AgentInfo{}
+	for _, agent := range agents.GetAllAgents() {
+		infos = append(infos, agent.Info())
+	}
+	return infos
+}
+
 // Recommend latest config to connected agents whose effective
 // config is not the same as the latest recommendation
 func (agents *Agents) RecommendLatestConfigToAll(
 	provider AgentConfigProvider,
 ) error {
 	for _, agent := range agents.GetAllAgents() {
+		agent.mux.RLock()
+		agentInfo := agent.info()
+		agent.mux.RUnlock()
+
 		newConfig, confId, err := provider.RecommendAgentConfig(
-			[]byte(agent.EffectiveConfig),
+			agentInfo, []byte(agent.EffectiveConfig),
 		)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf(
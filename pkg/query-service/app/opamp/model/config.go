@@ -1,10 +1,46 @@
 package model
 
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// ServerAgentGroupPrefix prefixes the names of the agent groups defined on
+// the server where agents are selected by group, e.g. "server:edge", so that
+// they are told apart from the groups reported by agents
+const ServerAgentGroupPrefix = "server:"
+
+// AgentInfo identifies the agent a config recommendation is generated for
+type AgentInfo struct {
+	ID string
+
+	// Group reported by the agent with the AgentGroupAttribute, empty if none
+	Group string
+
+	// Attributes are the string attributes of the agent description, both
+	// identifying and non identifying ones
+	Attributes map[string]string
+
+	// Groups are the names of the agent groups defined on the server the
+	// agent is a member of, resolved by the config provider
+	Groups []string
+}
+
+// InGroup tells if the agent reported the group, or is a member of the agent
+// group defined on the server when the group has the ServerAgentGroupPrefix
+func (a AgentInfo) InGroup(group string) bool {
+	if name, ok := strings.CutPrefix(group, ServerAgentGroupPrefix); ok {
+		return slices.Contains(a.Groups, name)
+	}
+	return a.Group == group
+}
+
 // Interface for source of otel collector config recommendations.
 type AgentConfigProvider interface {
 	// Generate recommended config for an agent based on its `currentConfYaml`
 	// and current state of user facing settings for agent based features.
-	RecommendAgentConfig(currentConfYaml []byte) (
+	RecommendAgentConfig(agent AgentInfo, currentConfYaml []byte) (
 		recommendedConfYaml []byte,
 		// Opaque id of the recommended config, used for reporting deployment status updates
 		configId string,
@@ -2,3 +2,12 @@ package model
 
 // Must match collectorConfigKey in https://github.com/SigNoz/signoz-otel-collector/blob/main/opamp/config_manager.go
 const CollectorConfigFilename = "collector.yaml"
+
+// Attribute in the agent description used for assigning agents to a group
+const AgentGroupAttribute = "signoz.agent.group"
+
+// Resource attributes in the agent description shown for the agents
+const (
+	hostNameAttribute       = "host.name"
+	serviceVersionAttribute = "service.version"
+)
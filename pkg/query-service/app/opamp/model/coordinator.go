@@ -9,9 +9,8 @@ import (
 var coordinator *Coordinator
 
 func init() {
-	subscribers := make(map[string][]OnChangeCallback, 0)
 	coordinator = &Coordinator{
-		subscribers: subscribers,
+		subscribers: map[string]map[string][]OnChangeCallback{},
 	}
 }
 
@@ -21,8 +20,9 @@ type OnChangeCallback func(agentId string, hash string, err error)
 type Coordinator struct {
 	mutex sync.Mutex
 
-	// hash wise list of subscribers
-	subscribers map[string][]OnChangeCallback
+	// subscribers by config hash and agent id, so that each agent the
+	// config was sent to reports its outcome
+	subscribers map[string]map[string][]OnChangeCallback
 }
 
 func onConfigSuccess(agentId string, hash string) {
@@ -30,26 +30,24 @@ func onConfigSuccess(agentId string, hash string) {
 }
 
 func onConfigFailure(agentId string, hash string, errorMessage string) {
-	notifySubscribers(agentId, hash, fmt.Errorf(errorMessage))
+	notifySubscribers(agentId, hash, fmt.Errorf("%s", errorMessage))
 }
 
-// OnSuccess listens to config changes and notifies subscribers
+// notifySubscribers notifies the subscribers to a config hash sent to an
+// agent, once: the first status the agent reports for the hash is the
+// outcome of applying it
 func notifySubscribers(agentId string, hash string, err error) {
-	// this method currently does not handle multi-agent scenario.
-	// as soon as a message is delivered, we release all the subscribers
-	// for a given hash
-	subs, ok := coordinator.subscribers[hash]
-	if !ok {
-		return
+	coordinator.mutex.Lock()
+	subs := coordinator.subscribers[hash][agentId]
+	delete(coordinator.subscribers[hash], agentId)
+	if len(coordinator.subscribers[hash]) == 0 {
+		delete(coordinator.subscribers, hash)
 	}
+	coordinator.mutex.Unlock()
 
 	for _, s := range subs {
 		s(agentId, hash, err)
 	}
-
-	// delete all subscribers for this hash, assume future
-	// notifies will be disabled. the first response is processed
-	delete(coordinator.subscribers, hash)
 }
 
 // callers subscribe to this function to listen on config change requests
@@ -57,10 +55,10 @@ func ListenToConfigUpdate(agentId string, hash string, ss OnChangeCallback) {
 	coordinator.mutex.Lock()
 	defer coordinator.mutex.Unlock()
 
-	if subs, ok := coordinator.subscribers[hash]; ok {
-		subs = append(subs, ss)
-		coordinator.subscribers[hash] = subs
-	} else {
-		coordinator.subscribers[hash] = []OnChangeCallback{ss}
+	agentSubs, ok := coordinator.subscribers[hash]
+	if !ok {
+		agentSubs = map[string][]OnChangeCallback{}
+		coordinator.subscribers[hash] = agentSubs
 	}
+	agentSubs[agentId] = append(agentSubs[agentId], ss)
 }
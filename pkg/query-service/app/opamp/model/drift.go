@@ -0,0 +1,183 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// sections are compared down to this depth, e.g. processors.batch or
+// service.pipelines.traces, so that the report points at the components
+// that differ rather than at their individual settings
+const configDriftDepth = 3
+
+// ConfigDrift is the difference between the config recommended to a
+// connected agent and the effective config it reports. Added sections are
+// only in the effective config, removed ones only in the recommended one.
+type ConfigDrift struct {
+	InstanceUID string `json:"instanceUid"`
+	Hostname    string `json:"hostname"`
+	Group       string `json:"group,omitempty"`
+	Drifted     bool   `json:"drifted"`
+	// DriftedSince is when the drift was first seen, the drift of agents
+	// is checked every minute
+	DriftedSince *time.Time `json:"driftedSince,omitempty"`
+	// RecommendedConfigId identifies the settings the recommended config is
+	// made of, empty if no config was recommended to the agent yet
+	RecommendedConfigId string `json:"recommendedConfigId"`
+	// the hashes are of the configs with keys sorted, so that formatting
+	// doesn't count as drift
+	RecommendedConfigHash string   `json:"recommendedConfigHash,omitempty"`
+	EffectiveConfigHash   string   `json:"effectiveConfigHash,omitempty"`
+	Added                 []string `json:"added"`
+	Removed               []string `json:"removed"`
+	Changed               []string `json:"changed"`
+	Error                 string   `json:"error,omitempty"`
+}
+
+// configDrift compares the recommended and effective config of the agent.
+// The caller must hold the agent lock.
+func (agent *Agent) configDrift() ConfigDrift {
+	drift := agent.diffConfig()
+	if drift.Drifted && drift.RecommendedConfigId == agent.driftConfigId {
+		drift.DriftedSince = agent.configDriftedSince
+	}
+	return drift
+}
+
+func (agent *Agent) diffConfig() ConfigDrift {
+	drift := ConfigDrift{
+		InstanceUID: agent.ID,
+		Hostname:    agent.Hostname,
+		Group:       agent.info().Group,
+		Added:       []string{},
+		Removed:     []string{},
+		Changed:     []string{},
+	}
+	if agent.remoteConfig == nil || agent.remoteConfig.Config == nil {
+		return drift
+	}
+	drift.RecommendedConfigId = string(agent.remoteConfig.ConfigHash)
+
+	recommendedFile := agent.remoteConfig.Config.ConfigMap[CollectorConfigFilename]
+	if recommendedFile == nil {
+		return drift
+	}
+
+	var recommended, effective map[string]interface{}
+	if err := yaml.Unmarshal(recommendedFile.Body, &recommended); err != nil {
+		drift.Error = fmt.Sprintf("could not parse recommended config: %v", err)
+		return drift
+	}
+	if err := yaml.Unmarshal([]byte(agent.EffectiveConfig), &effective); err != nil {
+		drift.Drifted = true
+		drift.Error = fmt.Sprintf("could not parse effective config: %v", err)
+		return drift
+	}
+
+	drift.RecommendedConfigHash = canonicalConfigHash(recommended)
+	drift.EffectiveConfigHash = canonicalConfigHash(effective)
+	drift.Drifted = drift.RecommendedConfigHash != drift.EffectiveConfigHash
+	if !drift.Drifted {
+		return drift
+	}
+
+	diffConfigSections(nil, recommended, effective, &drift)
+	sort.Strings(drift.Added)
+	sort.Strings(drift.Removed)
+	sort.Strings(drift.Changed)
+	return drift
+}
+
+// trackConfigDrift records since when the agent drifted from its recommended
+// config. The clock restarts when another config is recommended as agents
+// take a while to apply it. The caller must hold the agent lock.
+func (agent *Agent) trackConfigDrift(now time.Time) ConfigDrift {
+	drift := agent.configDrift()
+	if !drift.Drifted {
+		agent.configDriftedSince = nil
+	} else if drift.DriftedSince == nil {
+		agent.configDriftedSince = &now
+		drift.DriftedSince = &now
+	}
+	agent.driftConfigId = drift.RecommendedConfigId
+	return drift
+}
+
+func canonicalConfigHash(config map[string]interface{}) string {
+	// json sorts the keys of maps
+	serialized, err := json.Marshal(config)
+	if err != nil {
+		serialized = []byte(fmt.Sprintf("%v", config))
+	}
+	hash := sha256.Sum256(serialized)
+	return hex.EncodeToString(hash[:])
+}
+
+func diffConfigSections(
+	path []string, recommended, effective map[string]interface{}, drift *ConfigDrift,
+) {
+	sectionPath := func(key string) string {
+		return strings.Join(append(append([]string{}, path...), key), ".")
+	}
+
+	for key, r := range recommended {
+		e, ok := effective[key]
+		if !ok {
+			drift.Removed = append(drift.Removed, sectionPath(key))
+			continue
+		}
+		rMap, rIsMap := r.(map[string]interface{})
+		eMap, eIsMap := e.(map[string]interface{})
+		if rIsMap && eIsMap && len(path)+1 < configDriftDepth {
+			diffConfigSections(append(path, key), rMap, eMap, drift)
+			continue
+		}
+		if !reflect.DeepEqual(r, e) {
+			drift.Changed = append(drift.Changed, sectionPath(key))
+		}
+	}
+	for key := range effective {
+		if _, ok := recommended[key]; !ok {
+			drift.Added = append(drift.Added, sectionPath(key))
+		}
+	}
+}
+
+// ConfigDrifts returns the config drift of the connected agents, drifted
+// agents first
+func (agents *Agents) ConfigDrifts() []ConfigDrift {
+	drifts := []ConfigDrift{}
+	for _, agent := range agents.GetAllAgents() {
+		agent.mux.RLock()
+		drifts = append(drifts, agent.configDrift())
+		agent.mux.RUnlock()
+	}
+	sort.SliceStable(drifts, func(i, j int) bool {
+		if drifts[i].Drifted != drifts[j].Drifted {
+			return drifts[i].Drifted
+		}
+		return drifts[i].InstanceUID < drifts[j].InstanceUID
+	})
+	return drifts
+}
+
+// ConfigDrift returns the config drift of a connected agent, nil if the
+// agent isn't connected to this server
+func (agents *Agents) ConfigDrift(instanceUID string) *ConfigDrift {
+	agent := agents.FindAgent(instanceUID)
+	if agent == nil {
+		return nil
+	}
+	agent.mux.RLock()
+	defer agent.mux.RUnlock()
+	drift := agent.configDrift()
+	return &drift
+}
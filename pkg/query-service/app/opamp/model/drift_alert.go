@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const configDriftCheckInterval = time.Minute
+
+// ConfigDriftAlertSettings configure alerting on connected agents whose
+// effective config differs from the recommended one, e.g. because someone
+// edited the config on the host
+type ConfigDriftAlertSettings struct {
+	Enabled bool `json:"enabled"`
+	// DriftAfterMinutes is for how long an agent may run another config than
+	// the recommended one before it is alerted on, agents need some time to
+	// apply a new recommendation
+	DriftAfterMinutes int `json:"driftAfterMinutes"`
+	// Channels the alerts are sent to, the alert manager routes them if empty
+	Channels []string `json:"channels"`
+	// Severity of the alerts, the most severe level if empty
+	Severity string `json:"severity,omitempty"`
+}
+
+var defaultConfigDriftAlertSettings = ConfigDriftAlertSettings{
+	Enabled:           false,
+	DriftAfterMinutes: 30,
+	Channels:          []string{},
+}
+
+func (s *ConfigDriftAlertSettings) IsValid() error {
+	if s.DriftAfterMinutes < 1 || s.DriftAfterMinutes > 24*60 {
+		return fmt.Errorf("driftAfterMinutes must be between 1 and 1440")
+	}
+	if s.Channels == nil {
+		s.Channels = []string{}
+	}
+	return nil
+}
+
+func initConfigDriftAlertSettings() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_config_drift_alert_settings(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		settings_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("Error in creating agent config drift alert settings table: %s", err.Error())
+	}
+	return nil
+}
+
+// GetConfigDriftAlertSettings returns the config drift alert settings, the
+// defaults if they were never saved
+func GetConfigDriftAlertSettings(ctx context.Context) (ConfigDriftAlertSettings, error) {
+	var settingsJSON string
+	err := db.GetContext(ctx, &settingsJSON, `
+		SELECT settings_json FROM agent_config_drift_alert_settings WHERE id = 1
+	`)
+	if err == sql.ErrNoRows {
+		return defaultConfigDriftAlertSettings, nil
+	}
+	if err != nil {
+		return ConfigDriftAlertSettings{}, errors.Wrap(err, "failed to get config drift alert settings")
+	}
+
+	settings := defaultConfigDriftAlertSettings
+	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+		return ConfigDriftAlertSettings{}, errors.Wrap(err, "invalid config drift alert settings")
+	}
+	return settings, nil
+}
+
+func SaveConfigDriftAlertSettings(
+	ctx context.Context, userId string, settings *ConfigDriftAlertSettings,
+) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize config drift alert settings")
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO agent_config_drift_alert_settings (id, settings_json, updated_by, updated_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET
+			settings_json = excluded.settings_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, string(settingsJSON), userId, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to save config drift alert settings")
+	}
+	return nil
+}
+
+// ConfigDriftWatcher tracks since when the connected agents drifted from
+// their recommended config, and alerts on the ones drifted for longer than
+// configured. The alerts are resolved once the agents run the recommended
+// config again or disconnect, stale agents are alerted on separately.
+type ConfigDriftWatcher struct {
+	agents  *Agents
+	onAlert func(ctx context.Context, drift ConfigDrift, settings ConfigDriftAlertSettings, resolved bool)
+	alerted map[string]ConfigDrift
+	done    chan struct{}
+}
+
+func NewConfigDriftWatcher(
+	agents *Agents,
+	onAlert func(ctx context.Context, drift ConfigDrift, settings ConfigDriftAlertSettings, resolved bool),
+) *ConfigDriftWatcher {
+	return &ConfigDriftWatcher{
+		agents:  agents,
+		onAlert: onAlert,
+		alerted: map[string]ConfigDrift{},
+		done:    make(chan struct{}),
+	}
+}
+
+func (w *ConfigDriftWatcher) Start() {
+	go func() {
+		tick := time.NewTicker(configDriftCheckInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case now := <-tick.C:
+				if err := w.check(context.Background(), now); err != nil {
+					zap.L().Error("agent config drift check failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (w *ConfigDriftWatcher) Stop() {
+	close(w.done)
+}
+
+func (w *ConfigDriftWatcher) check(ctx context.Context, now time.Time) error {
+	settings, err := GetConfigDriftAlertSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	// drift is tracked whether alerting is enabled or not, so that the
+	// agents API reports it
+	drifted := map[string]ConfigDrift{}
+	driftAfter := time.Duration(settings.DriftAfterMinutes) * time.Minute
+	for _, agent := range w.agents.GetAllAgents() {
+		agent.mux.Lock()
+		drift := agent.trackConfigDrift(now)
+		agent.mux.Unlock()
+
+		if settings.Enabled && drift.Drifted && now.Sub(*drift.DriftedSince) >= driftAfter {
+			drifted[drift.InstanceUID] = drift
+		}
+	}
+
+	for id, drift := range w.alerted {
+		if _, ok := drifted[id]; !ok {
+			w.onAlert(ctx, drift, settings, true)
+			delete(w.alerted, id)
+		}
+	}
+	for id, drift := range drifted {
+		w.onAlert(ctx, drift, settings, false)
+		w.alerted[id] = drift
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opamp-go/protobufs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDrift(t *testing.T) {
+	require := require.New(t)
+
+	recommended := `
+receivers:
+  otlp:
+    protocols:
+      grpc: {}
+processors:
+  batch:
+    send_batch_size: 10000
+  memory_limiter:
+    limit_mib: 512
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces]
+`
+	agent := &Agent{ID: "agent-1"}
+	require.False(agent.configDrift().Drifted)
+
+	agent.remoteConfig = &protobufs.AgentRemoteConfig{
+		Config: &protobufs.AgentConfigMap{
+			ConfigMap: map[string]*protobufs.AgentConfigFile{
+				CollectorConfigFilename: {Body: []byte(recommended)},
+			},
+		},
+		ConfigHash: []byte("conf-1"),
+	}
+
+	// formatting differences are not drift
+	agent.EffectiveConfig = `
+processors:
+  memory_limiter: {limit_mib: 512}
+  batch: {send_batch_size: 10000}
+receivers:
+  otlp: {protocols: {grpc: {}}}
+service:
+  pipelines:
+    traces: {receivers: [otlp], processors: [batch], exporters: [clickhousetraces]}
+`
+	drift := agent.configDrift()
+	require.False(drift.Drifted)
+	require.Equal("conf-1", drift.RecommendedConfigId)
+	require.Equal(drift.RecommendedConfigHash, drift.EffectiveConfigHash)
+	require.Empty(drift.Changed)
+
+	agent.EffectiveConfig = `
+receivers:
+  otlp:
+    protocols:
+      grpc: {}
+      http: {}
+  hostmetrics: {}
+processors:
+  batch:
+    send_batch_size: 10000
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces]
+    metrics:
+      receivers: [hostmetrics]
+`
+	drift = agent.configDrift()
+	require.True(drift.Drifted)
+	require.Equal([]string{"receivers.hostmetrics", "service.pipelines.metrics"}, drift.Added)
+	require.Equal([]string{"processors.memory_limiter"}, drift.Removed)
+	require.Equal([]string{"receivers.otlp.protocols"}, drift.Changed)
+
+	agent.EffectiveConfig = "receivers: ["
+	drift = agent.configDrift()
+	require.True(drift.Drifted)
+	require.NotEmpty(drift.Error)
+}
+
+func TestConfigDriftWatcher(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	_, err = InitDB(testDBFile.Name())
+	require.Nil(err)
+
+	agent, _, err := AllAgents.FindOrCreateAgent("agent-1", nil)
+	require.Nil(err)
+	recommend := func(configId string, config string) {
+		agent.mux.Lock()
+		defer agent.mux.Unlock()
+		agent.remoteConfig = &protobufs.AgentRemoteConfig{
+			Config: &protobufs.AgentConfigMap{
+				ConfigMap: map[string]*protobufs.AgentConfigFile{
+					CollectorConfigFilename: {Body: []byte(config)},
+				},
+			},
+			ConfigHash: []byte(configId),
+		}
+	}
+	recommend("conf-1", "processors: {batch: {}}")
+	agent.EffectiveConfig = "processors: {batch: {}, memory_limiter: {}}"
+
+	type alert struct {
+		agent    string
+		resolved bool
+	}
+	alerts := []alert{}
+	watcher := NewConfigDriftWatcher(&AllAgents, func(
+		_ context.Context, drift ConfigDrift, _ ConfigDriftAlertSettings, resolved bool,
+	) {
+		alerts = append(alerts, alert{drift.InstanceUID, resolved})
+	})
+
+	// drift is tracked while alerting is disabled
+	start := time.Now()
+	require.Nil(watcher.check(context.Background(), start))
+	require.Empty(alerts)
+	view, err := AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.True(view.ConfigDrifted)
+	require.Equal(start.Unix(), view.ConfigDriftedSince.Unix())
+
+	require.Nil(SaveConfigDriftAlertSettings(context.Background(), "user", &ConfigDriftAlertSettings{
+		Enabled: true, DriftAfterMinutes: 30,
+	}))
+	require.Nil(watcher.check(context.Background(), start.Add(10*time.Minute)))
+	require.Empty(alerts)
+
+	// a new recommendation restarts the clock
+	recommend("conf-2", "processors: {batch: {send_batch_size: 1000}}")
+	require.Nil(watcher.check(context.Background(), start.Add(35*time.Minute)))
+	require.Empty(alerts)
+	drift := AllAgents.ConfigDrift("agent-1")
+	require.Equal(start.Add(35*time.Minute).Unix(), drift.DriftedSince.Unix())
+
+	require.Nil(watcher.check(context.Background(), start.Add(65*time.Minute)))
+	require.Equal([]alert{{"agent-1", false}}, alerts)
+
+	// running the recommended config resolves the alert
+	agent.EffectiveConfig = "processors:\n  batch:\n    send_batch_size: 1000\n"
+	require.Nil(watcher.check(context.Background(), start.Add(66*time.Minute)))
+	require.Equal([]alert{{"agent-1", false}, {"agent-1", true}}, alerts)
+	view, err = AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.False(view.ConfigDrifted)
+	require.Nil(view.ConfigDriftedSince)
+}
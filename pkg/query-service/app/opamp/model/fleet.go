@@ -0,0 +1,202 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const remoteConfigStatusFailed = "failed"
+
+func (s AgentStatus) String() string {
+	switch s {
+	case AgentStatusConnected:
+		return "connected"
+	case AgentStatusDisconnected:
+		return "disconnected"
+	default:
+		return "unknown"
+	}
+}
+
+// Health of an agent, degraded agents are connected but report being
+// unhealthy or failing to apply their remote config
+const (
+	AgentHealthHealthy      = "healthy"
+	AgentHealthDegraded     = "degraded"
+	AgentHealthDisconnected = "disconnected"
+)
+
+// AgentView describes an agent of the fleet as reported over OpAMP. The
+// effective config is only included when looking up a single agent.
+type AgentView struct {
+	InstanceUID string `json:"instanceUid"`
+	Hostname    string `json:"hostname"`
+	Version     string `json:"version"`
+	Group       string `json:"group,omitempty"`
+	Status      string `json:"status"`
+	Identity    string `json:"identity,omitempty"`
+
+	Health             string `json:"health"`
+	HealthError        string `json:"healthError,omitempty"`
+	RemoteConfigStatus string `json:"remoteConfigStatus,omitempty"`
+	RemoteConfigError  string `json:"remoteConfigError,omitempty"`
+
+	StartedAt time.Time `json:"startedAt"`
+	// LastSeenAt is when the agent last sent a message, its heartbeat
+	LastSeenAt      time.Time  `json:"lastSeenAt"`
+	TerminatedAt    *time.Time `json:"terminatedAt,omitempty"`
+	EffectiveConfig string     `json:"effectiveConfig,omitempty"`
+	// DisconnectedCleanly tells the agent was shut down rather than lost
+	DisconnectedCleanly bool `json:"disconnectedCleanly,omitempty"`
+
+	// ConfigDriftedSince is since when the effective config of a connected
+	// agent differs from the recommended one
+	ConfigDrifted      bool       `json:"configDrifted"`
+	ConfigDriftedSince *time.Time `json:"configDriftedSince,omitempty"`
+}
+
+type storedAgent struct {
+	ID              string       `db:"agent_id"`
+	StartedAt       time.Time    `db:"started_at"`
+	TerminatedAt    sql.NullTime `db:"terminated_at"`
+	LastSeenAt      sql.NullTime `db:"last_seen_at"`
+	EffectiveConfig string       `db:"effective_config"`
+	Hostname        string       `db:"hostname"`
+	Version         string       `db:"version"`
+	Identity        string       `db:"identity"`
+
+	DisconnectedCleanly bool `db:"disconnected_cleanly"`
+
+	RemoteConfigStatus string `db:"remote_config_status"`
+	RemoteConfigError  string `db:"remote_config_error"`
+}
+
+// view describes a connected agent. The caller must hold the agent lock.
+func (agent *Agent) view(withConfig bool) AgentView {
+	v := AgentView{
+		InstanceUID: agent.ID,
+		Hostname:    agent.Hostname,
+		Version:     agent.Version,
+		Group:       agent.info().Group,
+		Status:      agent.CurrentStatus.String(),
+		Identity:    agent.Identity,
+		StartedAt:   agent.StartedAt,
+		LastSeenAt:  agent.LastSeenAt,
+
+		DisconnectedCleanly: agent.DisconnectedCleanly,
+
+		Health:             AgentHealthHealthy,
+		RemoteConfigStatus: agent.RemoteConfigStatus,
+		RemoteConfigError:  agent.RemoteConfigError,
+	}
+	switch {
+	case agent.CurrentStatus != AgentStatusConnected:
+		v.Health = AgentHealthDisconnected
+	case agent.Status != nil && agent.Status.Health != nil && !agent.Status.Health.Healthy:
+		v.Health = AgentHealthDegraded
+		v.HealthError = agent.Status.Health.LastError
+	case agent.RemoteConfigStatus == remoteConfigStatusFailed:
+		v.Health = AgentHealthDegraded
+		v.HealthError = agent.RemoteConfigError
+	}
+	if agent.configDriftedSince != nil {
+		v.ConfigDrifted = true
+		driftedSince := *agent.configDriftedSince
+		v.ConfigDriftedSince = &driftedSince
+	}
+	if withConfig {
+		v.EffectiveConfig = agent.EffectiveConfig
+	}
+	return v
+}
+
+// agents only found in the db aren't connected to this server, whatever
+// status was last stored for them
+func (a storedAgent) view(withConfig bool) AgentView {
+	v := AgentView{
+		InstanceUID: a.ID,
+		Hostname:    a.Hostname,
+		Version:     a.Version,
+		Status:      AgentStatusDisconnected.String(),
+		Identity:    a.Identity,
+		StartedAt:   a.StartedAt,
+		LastSeenAt:  a.StartedAt,
+
+		DisconnectedCleanly: a.DisconnectedCleanly,
+
+		Health:             AgentHealthDisconnected,
+		RemoteConfigStatus: a.RemoteConfigStatus,
+		RemoteConfigError:  a.RemoteConfigError,
+	}
+	if a.LastSeenAt.Valid {
+		v.LastSeenAt = a.LastSeenAt.Time
+	}
+	if a.TerminatedAt.Valid {
+		terminatedAt := a.TerminatedAt.Time
+		v.TerminatedAt = &terminatedAt
+	}
+	if withConfig {
+		v.EffectiveConfig = a.EffectiveConfig
+	}
+	return v
+}
+
+const storedAgentColumns = `agent_id, started_at, terminated_at, last_seen_at,
+	effective_config, hostname, version, remote_config_status, remote_config_error, identity,
+	disconnected_cleanly`
+
+// ListAgents returns the connected agents along with the disconnected ones
+// last seen after seenSince, most recently seen first.
+func (agents *Agents) ListAgents(seenSince time.Time) ([]AgentView, error) {
+	views := []AgentView{}
+	connected := map[string]bool{}
+	for _, agent := range agents.GetAllAgents() {
+		agent.mux.RLock()
+		views = append(views, agent.view(false))
+		agent.mux.RUnlock()
+		connected[agent.ID] = true
+	}
+
+	stored := []storedAgent{}
+	err := db.Select(&stored, `SELECT `+storedAgentColumns+`
+		FROM agents WHERE last_seen_at >= ?`, seenSince.UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get agents")
+	}
+	for _, a := range stored {
+		if !connected[a.ID] {
+			views = append(views, a.view(false))
+		}
+	}
+
+	sort.SliceStable(views, func(i, j int) bool {
+		return views[i].LastSeenAt.After(views[j].LastSeenAt)
+	})
+	return views, nil
+}
+
+// GetAgentView returns the agent with the given instance uid including its
+// effective config, nil if the agent was never seen.
+func (agents *Agents) GetAgentView(instanceUID string) (*AgentView, error) {
+	if agent := agents.FindAgent(instanceUID); agent != nil {
+		agent.mux.RLock()
+		defer agent.mux.RUnlock()
+		v := agent.view(true)
+		return &v, nil
+	}
+
+	stored := storedAgent{}
+	err := db.Get(&stored, `SELECT `+storedAgentColumns+`
+		FROM agents WHERE agent_id = ?`, instanceUID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get agent")
+	}
+	v := stored.view(true)
+	return &v, nil
+}
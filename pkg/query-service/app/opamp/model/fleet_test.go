@@ -0,0 +1,195 @@
+package model
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/open-telemetry/opamp-go/protobufs"
+	"github.com/stretchr/testify/require"
+)
+
+func stringKeyValue(key, value string) *protobufs.KeyValue {
+	return &protobufs.KeyValue{
+		Key:   key,
+		Value: &protobufs.AnyValue{Value: &protobufs.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func TestAgentFleetViews(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	_, err = InitDB(testDBFile.Name())
+	require.Nil(err)
+
+	agent, created, err := AllAgents.FindOrCreateAgent("agent-1", nil)
+	require.Nil(err)
+	require.True(created)
+
+	agent.mux.Lock()
+	agent.updateAgentDescription(&protobufs.AgentToServer{
+		AgentDescription: &protobufs.AgentDescription{
+			IdentifyingAttributes: []*protobufs.KeyValue{
+				stringKeyValue(serviceVersionAttribute, "0.88.21"),
+			},
+			NonIdentifyingAttributes: []*protobufs.KeyValue{
+				stringKeyValue(hostNameAttribute, "collector-0"),
+				stringKeyValue(AgentGroupAttribute, "edge"),
+			},
+		},
+	})
+	agent.EffectiveConfig = "receivers: {}"
+	agent.mux.Unlock()
+
+	agents, err := AllAgents.ListAgents(time.Now().Add(-time.Hour))
+	require.Nil(err)
+	require.Equal(1, len(agents))
+	require.Equal("agent-1", agents[0].InstanceUID)
+	require.Equal("collector-0", agents[0].Hostname)
+	require.Equal("0.88.21", agents[0].Version)
+	require.Equal("edge", agents[0].Group)
+	require.Equal("connected", agents[0].Status)
+	require.Empty(agents[0].EffectiveConfig, "effective config is only included for a single agent")
+
+	// disconnected agents are read back from the db
+	AllAgents.RemoveConnection(nil)
+	require.Nil(AllAgents.FindAgent("agent-1"))
+
+	agents, err = AllAgents.ListAgents(time.Now().Add(-time.Hour))
+	require.Nil(err)
+	require.Equal(1, len(agents))
+	require.Equal("disconnected", agents[0].Status)
+	require.Equal("collector-0", agents[0].Hostname)
+	require.NotNil(agents[0].TerminatedAt)
+
+	agents, err = AllAgents.ListAgents(time.Now().Add(time.Hour))
+	require.Nil(err)
+	require.Empty(agents, "agents not seen recently aren't listed")
+
+	view, err := AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.NotNil(view)
+	require.Equal("receivers: {}", view.EffectiveConfig)
+
+	view, err = AllAgents.GetAgentView("unknown")
+	require.Nil(err)
+	require.Nil(view)
+}
+
+func TestStaleAgentWatcher(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	_, err = InitDB(testDBFile.Name())
+	require.Nil(err)
+
+	agent, _, err := AllAgents.FindOrCreateAgent("agent-1", nil)
+	require.Nil(err)
+	agent.mux.Lock()
+	agent.updateStatusField(&protobufs.AgentToServer{
+		Health: &protobufs.AgentHealth{Healthy: false, LastError: "exporter failing"},
+		RemoteConfigStatus: &protobufs.RemoteConfigStatus{
+			Status: protobufs.RemoteConfigStatuses_RemoteConfigStatuses_APPLIED,
+		},
+	})
+	agent.mux.Unlock()
+
+	view, err := AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.Equal(AgentHealthDegraded, view.Health)
+	require.Equal("exporter failing", view.HealthError)
+	require.Equal("applied", view.RemoteConfigStatus)
+
+	type alert struct {
+		agent    string
+		resolved bool
+	}
+	alerts := []alert{}
+	watcher := NewStaleAgentWatcher(&AllAgents, func(
+		_ context.Context, agent AgentView, _ StaleAgentAlertSettings, resolved bool,
+	) {
+		alerts = append(alerts, alert{agent.InstanceUID, resolved})
+	})
+
+	AllAgents.RemoveConnection(nil)
+	view, err = AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.Equal(*view.TerminatedAt, view.LastSeenAt, "agents are last seen when disconnecting")
+	now := view.LastSeenAt.Add(30 * time.Minute)
+
+	// disabled by default
+	require.Nil(watcher.check(context.Background(), now))
+	require.Empty(alerts)
+
+	require.Nil(SaveStaleAgentAlertSettings(context.Background(), "user", &StaleAgentAlertSettings{
+		Enabled: true, StaleAfterMinutes: 10,
+	}))
+	require.Nil(watcher.check(context.Background(), now))
+	require.Equal([]alert{{"agent-1", false}}, alerts)
+
+	view, err = AllAgents.GetAgentView("agent-1")
+	require.Nil(err)
+	require.Equal(AgentHealthDisconnected, view.Health)
+
+	// reporting again resolves the alert
+	_, _, err = AllAgents.FindOrCreateAgent("agent-1", nil)
+	require.Nil(err)
+	require.Nil(watcher.check(context.Background(), now))
+	require.Equal([]alert{{"agent-1", false}, {"agent-1", true}}, alerts)
+}
+
+func TestStaleAgentWatcherDecommissioned(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	_, err = InitDB(testDBFile.Name())
+	require.Nil(err)
+
+	now := time.Now()
+	for _, agent := range []*Agent{
+		// replaced by agent-2 on the same host
+		{ID: "agent-1", Hostname: "collector-0", StartedAt: now.Add(-2 * time.Hour)},
+		{ID: "agent-2", Hostname: "collector-0", StartedAt: now.Add(-25 * time.Minute)},
+		{ID: "agent-3", Hostname: "collector-1", StartedAt: now.Add(-2 * time.Hour), DisconnectedCleanly: true},
+		{ID: "agent-4", Hostname: "collector-2", StartedAt: now.Add(-2 * time.Hour)},
+	} {
+		agent.CurrentStatus = AgentStatusDisconnected
+		agent.LastSeenAt = now.Add(-20 * time.Minute)
+		agent.TerminatedAt = agent.LastSeenAt
+		require.Nil(agent.Upsert())
+	}
+	require.Nil(SaveStaleAgentAlertSettings(context.Background(), "user", &StaleAgentAlertSettings{
+		Enabled: true, StaleAfterMinutes: 10,
+	}))
+
+	alerted := []string{}
+	watcher := NewStaleAgentWatcher(&AllAgents, func(
+		_ context.Context, agent AgentView, _ StaleAgentAlertSettings, resolved bool,
+	) {
+		alerted = append(alerted, agent.InstanceUID)
+	})
+	require.Nil(watcher.check(context.Background(), now))
+	require.ElementsMatch([]string{"agent-2", "agent-4"}, alerted,
+		"agents replaced on their host or shut down cleanly aren't alerted on",
+	)
+
+	// clean disconnects are persisted along with the agent
+	agent, _, err := AllAgents.FindOrCreateAgent("agent-5", nil)
+	require.Nil(err)
+	agent.mux.Lock()
+	agent.DisconnectedCleanly = true
+	agent.mux.Unlock()
+	AllAgents.RemoveConnection(nil)
+	view, err := AllAgents.GetAgentView("agent-5")
+	require.Nil(err)
+	require.True(view.DisconnectedCleanly)
+}
@@ -0,0 +1,361 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opamp-go/protobufs"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// PackageOffer is a package offered to the agents of a group, or to all the
+// agents when the group is empty, e.g. a new version of the collector. The
+// package with an empty name is the collector itself, others are addons.
+// Offers for a group take precedence over the ones for all the agents, so
+// that upgrades can be rolled out to a canary group first.
+type PackageOffer struct {
+	Name        string `json:"name" db:"name"`
+	Group       string `json:"group" db:"agent_group"`
+	Version     string `json:"version" db:"version"`
+	DownloadURL string `json:"downloadUrl" db:"download_url"`
+	// ContentHash is the hex encoded hash of the file the agents verify
+	// their download against
+	ContentHash string `json:"contentHash" db:"content_hash"`
+	// Signature is the base64 encoded signature of the file, verified by
+	// the agents with their trusted keys
+	Signature string    `json:"signature,omitempty" db:"signature"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+func (o *PackageOffer) IsValid() error {
+	if strings.TrimSpace(o.Version) == "" {
+		return fmt.Errorf("version is required")
+	}
+	u, err := url.Parse(o.DownloadURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("downloadUrl must be an http or https url")
+	}
+	if _, err := hex.DecodeString(o.ContentHash); err != nil || o.ContentHash == "" {
+		return fmt.Errorf("contentHash must be a hex encoded hash")
+	}
+	if _, err := base64.StdEncoding.DecodeString(o.Signature); err != nil {
+		return fmt.Errorf("signature must be base64 encoded")
+	}
+	return nil
+}
+
+// AgentPackageStatus is the status of a package of an agent as last reported
+type AgentPackageStatus struct {
+	AgentID              string    `json:"agentId" db:"agent_id"`
+	Name                 string    `json:"name" db:"name"`
+	AgentHasVersion      string    `json:"agentHasVersion" db:"agent_has_version"`
+	ServerOfferedVersion string    `json:"serverOfferedVersion" db:"server_offered_version"`
+	Status               string    `json:"status" db:"status"`
+	ErrorMessage         string    `json:"errorMessage,omitempty" db:"error_message"`
+	UpdatedAt            time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+func packageStatusName(status protobufs.PackageStatusEnum) string {
+	switch status {
+	case protobufs.PackageStatusEnum_PackageStatusEnum_Installed:
+		return "installed"
+	case protobufs.PackageStatusEnum_PackageStatusEnum_InstallPending:
+		return "install_pending"
+	case protobufs.PackageStatusEnum_PackageStatusEnum_Installing:
+		return "installing"
+	case protobufs.PackageStatusEnum_PackageStatusEnum_InstallFailed:
+		return "install_failed"
+	default:
+		return "unknown"
+	}
+}
+
+// offers are read on every agent status report, they are cached and
+// reloaded when changed through this server
+var packageOffers = struct {
+	mux    sync.RWMutex
+	offers []PackageOffer
+	loaded bool
+}{}
+
+func initPackageTables() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_package_offers(
+		name TEXT NOT NULL,
+		agent_group TEXT NOT NULL,
+		version TEXT NOT NULL,
+		download_url TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		signature TEXT NOT NULL DEFAULT '',
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (name, agent_group)
+	);`)
+	if err != nil {
+		return fmt.Errorf("Error in creating agent package offers table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS agent_package_statuses(
+		agent_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		agent_has_version TEXT NOT NULL DEFAULT '',
+		server_offered_version TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error_message TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (agent_id, name)
+	);`)
+	if err != nil {
+		return fmt.Errorf("Error in creating agent package statuses table: %s", err.Error())
+	}
+
+	packageOffers.mux.Lock()
+	packageOffers.offers, packageOffers.loaded = nil, false
+	packageOffers.mux.Unlock()
+	return nil
+}
+
+func GetPackageOffers(ctx context.Context) ([]PackageOffer, error) {
+	packageOffers.mux.RLock()
+	if packageOffers.loaded {
+		defer packageOffers.mux.RUnlock()
+		return packageOffers.offers, nil
+	}
+	packageOffers.mux.RUnlock()
+
+	packageOffers.mux.Lock()
+	defer packageOffers.mux.Unlock()
+	offers := []PackageOffer{}
+	err := db.SelectContext(ctx, &offers, `SELECT
+		name, agent_group, version, download_url, content_hash, signature,
+		COALESCE(updated_by, '') as updated_by, updated_at
+		FROM agent_package_offers
+		ORDER BY name, agent_group`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get package offers")
+	}
+	packageOffers.offers, packageOffers.loaded = offers, true
+	return offers, nil
+}
+
+func invalidatePackageOffers() {
+	packageOffers.mux.Lock()
+	defer packageOffers.mux.Unlock()
+	packageOffers.loaded = false
+}
+
+// SavePackageOffer offers a package to the agents, use
+// Agents.OfferPackagesToAll to roll it out to the connected ones
+func SavePackageOffer(ctx context.Context, userId string, offer *PackageOffer) error {
+	offer.UpdatedBy = userId
+	offer.UpdatedAt = time.Now()
+	_, err := db.NamedExecContext(ctx, `INSERT INTO agent_package_offers (
+		name, agent_group, version, download_url, content_hash, signature, updated_by, updated_at
+	) VALUES (
+		:name, :agent_group, :version, :download_url, :content_hash, :signature, :updated_by, :updated_at
+	) ON CONFLICT(name, agent_group) DO UPDATE SET
+		version = excluded.version,
+		download_url = excluded.download_url,
+		content_hash = excluded.content_hash,
+		signature = excluded.signature,
+		updated_by = excluded.updated_by,
+		updated_at = excluded.updated_at`, offer)
+	invalidatePackageOffers()
+	if err != nil {
+		return errors.Wrap(err, "failed to save package offer")
+	}
+	return nil
+}
+
+// DeletePackageOffer stops offering a package, returning false if it wasn't
+// offered. Agents keep the packages they already installed.
+func DeletePackageOffer(ctx context.Context, name string, group string) (bool, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM agent_package_offers
+		WHERE name = $1 AND agent_group = $2`, name, group)
+	invalidatePackageOffers()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to delete package offer")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to delete package offer")
+	}
+	return affected > 0, nil
+}
+
+// ListPackageStatuses returns the last reported package statuses, of a
+// package and of an agent when given
+func ListPackageStatuses(ctx context.Context, name *string, agentID string) ([]AgentPackageStatus, error) {
+	query := `SELECT agent_id, name, agent_has_version, server_offered_version,
+		status, error_message, updated_at
+		FROM agent_package_statuses WHERE 1 = 1`
+	args := []interface{}{}
+	if name != nil {
+		query += ` AND name = ?`
+		args = append(args, *name)
+	}
+	if agentID != "" {
+		query += ` AND agent_id = ?`
+		args = append(args, agentID)
+	}
+	query += ` ORDER BY name, agent_id`
+
+	statuses := []AgentPackageStatus{}
+	if err := db.SelectContext(ctx, &statuses, query, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to get package statuses")
+	}
+	return statuses, nil
+}
+
+func savePackageStatuses(agentID string, statuses *protobufs.PackageStatuses) error {
+	now := time.Now().UTC()
+	for name, s := range statuses.Packages {
+		_, err := db.Exec(`INSERT INTO agent_package_statuses (
+			agent_id, name, agent_has_version, server_offered_version, status, error_message, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(agent_id, name) DO UPDATE SET
+			agent_has_version = excluded.agent_has_version,
+			server_offered_version = excluded.server_offered_version,
+			status = excluded.status,
+			error_message = excluded.error_message,
+			updated_at = excluded.updated_at`,
+			agentID, name, s.AgentHasVersion, s.ServerOfferedVersion,
+			packageStatusName(s.Status), s.ErrorMessage, now,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to save package statuses")
+		}
+	}
+	return nil
+}
+
+// packagesAvailable returns the packages offered to the agents of a group,
+// nil if none are. Nothing is sent rather than an empty list, which would
+// ask the agents to remove their addons.
+func packagesAvailable(offers []PackageOffer, group string) *protobufs.PackagesAvailable {
+	selected := map[string]PackageOffer{}
+	for _, o := range offers {
+		if o.Group != "" && o.Group != group {
+			continue
+		}
+		if _, ok := selected[o.Name]; ok && o.Group == "" {
+			continue
+		}
+		selected[o.Name] = o
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	available := &protobufs.PackagesAvailable{Packages: map[string]*protobufs.PackageAvailable{}}
+	allPackagesHash := sha256.New()
+	for _, name := range names {
+		o := selected[name]
+		contentHash, _ := hex.DecodeString(o.ContentHash)
+		signature, _ := base64.StdEncoding.DecodeString(o.Signature)
+
+		packageType := protobufs.PackageType_PackageType_Addon
+		if name == "" {
+			packageType = protobufs.PackageType_PackageType_TopLevel
+		}
+
+		hash := sha256.New()
+		for _, part := range []string{name, o.Version, o.DownloadURL, o.ContentHash, o.Signature} {
+			hash.Write([]byte(part))
+			hash.Write([]byte{0})
+		}
+		packageHash := hash.Sum(nil)
+
+		available.Packages[name] = &protobufs.PackageAvailable{
+			Type:    packageType,
+			Version: o.Version,
+			File: &protobufs.DownloadableFile{
+				DownloadUrl: o.DownloadURL,
+				ContentHash: contentHash,
+				Signature:   signature,
+			},
+			Hash: packageHash,
+		}
+		allPackagesHash.Write([]byte(name))
+		allPackagesHash.Write(packageHash)
+	}
+	available.AllPackagesHash = allPackagesHash.Sum(nil)
+	return available
+}
+
+// updatePackages adds the packages offered to the agent to the response
+// unless it already has them or they were already sent over its connection.
+// The caller must hold the agent lock.
+func (agent *Agent) updatePackages(response *protobufs.ServerToAgent) bool {
+	if agent.Status == nil || !agent.hasCapability(protobufs.AgentCapabilities_AgentCapabilities_AcceptsPackages) {
+		return false
+	}
+
+	offers, err := GetPackageOffers(context.Background())
+	if err != nil {
+		zap.L().Error("could not get package offers", zap.String("agentId", agent.ID), zap.Error(err))
+		return false
+	}
+	available := packagesAvailable(offers, agent.info().Group)
+	if available == nil {
+		return false
+	}
+
+	if statuses := agent.Status.PackageStatuses; statuses != nil &&
+		bytes.Equal(statuses.ServerProvidedAllPackagesHash, available.AllPackagesHash) {
+		return false
+	}
+	if bytes.Equal(agent.offeredPackagesHash, available.AllPackagesHash) {
+		return false
+	}
+
+	response.PackagesAvailable = available
+	agent.offeredPackagesHash = available.AllPackagesHash
+	return true
+}
+
+func (agent *Agent) updatePackageStatuses(newStatus *protobufs.AgentToServer) {
+	if newStatus.PackageStatuses == nil {
+		return
+	}
+	// the first status of an agent is kept as is, its package statuses are
+	// new even though they are the same
+	if agent.Status.PackageStatuses != newStatus.PackageStatuses &&
+		proto.Equal(agent.Status.PackageStatuses, newStatus.PackageStatuses) {
+		return
+	}
+	agent.Status.PackageStatuses = newStatus.PackageStatuses
+	if err := savePackageStatuses(agent.ID, newStatus.PackageStatuses); err != nil {
+		zap.L().Error("could not save package statuses", zap.String("agentId", agent.ID), zap.Error(err))
+	}
+}
+
+// OfferPackagesToAll sends the latest package offers to the connected agents
+// accepting packages which don't have them yet
+func (agents *Agents) OfferPackagesToAll() {
+	for _, agent := range agents.GetAllAgents() {
+		agent.mux.Lock()
+		response := &protobufs.ServerToAgent{InstanceUid: agent.ID}
+		if agent.updatePackages(response) {
+			agent.SendToAgent(response)
+		}
+		agent.mux.Unlock()
+	}
+}
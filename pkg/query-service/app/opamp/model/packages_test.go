@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/open-telemetry/opamp-go/protobufs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackagesAvailable(t *testing.T) {
+	require := require.New(t)
+
+	offers := []PackageOffer{
+		{Name: "", Version: "0.88.0", DownloadURL: "https://example.com/otelcol-0.88.0.tar.gz", ContentHash: "aa"},
+		{Name: "", Group: "canary", Version: "0.89.0", DownloadURL: "https://example.com/otelcol-0.89.0.tar.gz", ContentHash: "bb"},
+		{Name: "geoip", Version: "1.2.0", DownloadURL: "https://example.com/geoip-1.2.0.tar.gz", ContentHash: "cc"},
+		{Name: "geoip", Group: "other", Version: "1.3.0", DownloadURL: "https://example.com/geoip-1.3.0.tar.gz", ContentHash: "dd"},
+	}
+
+	stable := packagesAvailable(offers, "")
+	require.NotNil(stable)
+	require.Len(stable.Packages, 2)
+	require.Equal("0.88.0", stable.Packages[""].Version)
+	require.Equal(protobufs.PackageType_PackageType_TopLevel, stable.Packages[""].Type)
+	require.Equal([]byte{0xaa}, stable.Packages[""].File.ContentHash)
+	require.Equal("1.2.0", stable.Packages["geoip"].Version)
+	require.Equal(protobufs.PackageType_PackageType_Addon, stable.Packages["geoip"].Type)
+
+	canary := packagesAvailable(offers, "canary")
+	require.Equal("0.89.0", canary.Packages[""].Version)
+	require.Equal("1.2.0", canary.Packages["geoip"].Version)
+	require.NotEqual(stable.AllPackagesHash, canary.AllPackagesHash)
+	require.Equal(stable.Packages["geoip"].Hash, canary.Packages["geoip"].Hash)
+
+	// the hash of the offered packages is stable whatever the order of the offers
+	reversed := []PackageOffer{offers[3], offers[2], offers[1], offers[0]}
+	require.Equal(canary.AllPackagesHash, packagesAvailable(reversed, "canary").AllPackagesHash)
+
+	require.Nil(packagesAvailable(offers[1:2], ""))
+	require.Nil(packagesAvailable(nil, "canary"))
+}
+
+func TestPackageOfferIsValid(t *testing.T) {
+	valid := PackageOffer{
+		Version:     "0.88.0",
+		DownloadURL: "https://example.com/otelcol-0.88.0.tar.gz",
+		ContentHash: "0a1b",
+		Signature:   "c2lnbmF0dXJl",
+	}
+	require.NoError(t, valid.IsValid())
+
+	tests := []struct {
+		name   string
+		modify func(o *PackageOffer)
+	}{
+		{"no version", func(o *PackageOffer) { o.Version = "" }},
+		{"relative url", func(o *PackageOffer) { o.DownloadURL = "otelcol.tar.gz" }},
+		{"ftp url", func(o *PackageOffer) { o.DownloadURL = "ftp://example.com/otelcol.tar.gz" }},
+		{"no content hash", func(o *PackageOffer) { o.ContentHash = "" }},
+		{"content hash not hex", func(o *PackageOffer) { o.ContentHash = "xyz" }},
+		{"signature not base64", func(o *PackageOffer) { o.Signature = "not base64!" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := valid
+			tt.modify(&o)
+			require.Error(t, o.IsValid())
+		})
+	}
+}
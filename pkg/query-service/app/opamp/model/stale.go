@@ -0,0 +1,203 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	staleAgentCheckInterval = time.Minute
+
+	// agents not seen for longer are considered decommissioned and no
+	// longer alerted on
+	staleAgentLookback = 7 * 24 * time.Hour
+)
+
+// StaleAgentAlertSettings configure alerting on agents that stop reporting.
+// Connected agents are reporting as long as their connection is open.
+type StaleAgentAlertSettings struct {
+	Enabled bool `json:"enabled"`
+	// StaleAfterMinutes is for how long an agent may not report before it
+	// is alerted on
+	StaleAfterMinutes int `json:"staleAfterMinutes"`
+	// Channels the alerts are sent to, the alert manager routes them if empty
+	Channels []string `json:"channels"`
+	// Severity of the alerts, the most severe level if empty
+	Severity string `json:"severity,omitempty"`
+}
+
+var defaultStaleAgentAlertSettings = StaleAgentAlertSettings{
+	Enabled:           false,
+	StaleAfterMinutes: 10,
+	Channels:          []string{},
+}
+
+func (s *StaleAgentAlertSettings) IsValid() error {
+	if s.StaleAfterMinutes < 1 || s.StaleAfterMinutes > 24*60 {
+		return fmt.Errorf("staleAfterMinutes must be between 1 and 1440")
+	}
+	if s.Channels == nil {
+		s.Channels = []string{}
+	}
+	return nil
+}
+
+func initStaleAgentAlertSettings() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_stale_alert_settings(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		settings_json TEXT NOT NULL,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("Error in creating agent stale alert settings table: %s", err.Error())
+	}
+	return nil
+}
+
+// GetStaleAgentAlertSettings returns the stale agent alert settings, the
+// defaults if they were never saved
+func GetStaleAgentAlertSettings(ctx context.Context) (StaleAgentAlertSettings, error) {
+	var settingsJSON string
+	err := db.GetContext(ctx, &settingsJSON, `
+		SELECT settings_json FROM agent_stale_alert_settings WHERE id = 1
+	`)
+	if err == sql.ErrNoRows {
+		return defaultStaleAgentAlertSettings, nil
+	}
+	if err != nil {
+		return StaleAgentAlertSettings{}, errors.Wrap(err, "failed to get stale agent alert settings")
+	}
+
+	settings := defaultStaleAgentAlertSettings
+	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+		return StaleAgentAlertSettings{}, errors.Wrap(err, "invalid stale agent alert settings")
+	}
+	return settings, nil
+}
+
+func SaveStaleAgentAlertSettings(
+	ctx context.Context, userId string, settings *StaleAgentAlertSettings,
+) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize stale agent alert settings")
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO agent_stale_alert_settings (id, settings_json, updated_by, updated_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET
+			settings_json = excluded.settings_json,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, string(settingsJSON), userId, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to save stale agent alert settings")
+	}
+	return nil
+}
+
+// StaleAgentWatcher alerts on the agents that stopped reporting and resolves
+// the alerts once they report again. The alerts of stale agents are resent
+// on every check, like the ones of the rules.
+type StaleAgentWatcher struct {
+	agents  *Agents
+	onAlert func(ctx context.Context, agent AgentView, settings StaleAgentAlertSettings, resolved bool)
+	alerted map[string]AgentView
+	done    chan struct{}
+}
+
+func NewStaleAgentWatcher(
+	agents *Agents,
+	onAlert func(ctx context.Context, agent AgentView, settings StaleAgentAlertSettings, resolved bool),
+) *StaleAgentWatcher {
+	return &StaleAgentWatcher{
+		agents:  agents,
+		onAlert: onAlert,
+		alerted: map[string]AgentView{},
+		done:    make(chan struct{}),
+	}
+}
+
+func (w *StaleAgentWatcher) Start() {
+	go func() {
+		tick := time.NewTicker(staleAgentCheckInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case now := <-tick.C:
+				if err := w.check(context.Background(), now); err != nil {
+					zap.L().Error("stale agent check failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (w *StaleAgentWatcher) Stop() {
+	close(w.done)
+}
+
+// decommissioned tells if an agent stopped reporting on purpose: it shut
+// down cleanly, or its host started reporting again under another instance
+// uid, e.g. after a restart without a persisted uid
+func decommissioned(agent AgentView, agents []AgentView) bool {
+	if agent.DisconnectedCleanly {
+		return true
+	}
+	if agent.Hostname == "" {
+		return false
+	}
+	for _, other := range agents {
+		if other.InstanceUID != agent.InstanceUID && other.Hostname == agent.Hostname &&
+			other.StartedAt.After(agent.StartedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *StaleAgentWatcher) check(ctx context.Context, now time.Time) error {
+	settings, err := GetStaleAgentAlertSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	stale := map[string]AgentView{}
+	if settings.Enabled {
+		agents, err := w.agents.ListAgents(now.Add(-staleAgentLookback))
+		if err != nil {
+			return err
+		}
+		staleAfter := time.Duration(settings.StaleAfterMinutes) * time.Minute
+		for _, agent := range agents {
+			if agent.Status == AgentStatusConnected.String() || now.Sub(agent.LastSeenAt) < staleAfter {
+				continue
+			}
+			if decommissioned(agent, agents) {
+				continue
+			}
+			stale[agent.InstanceUID] = agent
+		}
+	}
+
+	for id, agent := range w.alerted {
+		if _, ok := stale[id]; !ok {
+			w.onAlert(ctx, agent, settings, true)
+			delete(w.alerted, id)
+		}
+	}
+	for id, agent := range stale {
+		w.onAlert(ctx, agent, settings, false)
+		w.alerted[id] = agent
+	}
+	return nil
+}
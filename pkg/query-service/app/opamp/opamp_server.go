@@ -21,16 +21,21 @@ type Server struct {
 
 	agentConfigProvider AgentConfigProvider
 
+	auth       AuthSettings
+	identities connectionIdentities
+
 	// cleanups to be run when stopping the server
 	cleanups []func()
 }
 
 const capabilities = protobufs.ServerCapabilities_ServerCapabilities_AcceptsEffectiveConfig |
 	protobufs.ServerCapabilities_ServerCapabilities_OffersRemoteConfig |
-	protobufs.ServerCapabilities_ServerCapabilities_AcceptsStatus
+	protobufs.ServerCapabilities_ServerCapabilities_AcceptsStatus |
+	protobufs.ServerCapabilities_ServerCapabilities_OffersPackages |
+	protobufs.ServerCapabilities_ServerCapabilities_AcceptsPackagesStatus
 
 func InitializeServer(
-	agents *model.Agents, agentConfigProvider AgentConfigProvider,
+	agents *model.Agents, agentConfigProvider AgentConfigProvider, auth AuthSettings,
 ) *Server {
 	if agents == nil {
 		agents = &model.AllAgents
@@ -39,20 +44,28 @@ func InitializeServer(
 	opAmpServer = &Server{
 		agents:              agents,
 		agentConfigProvider: agentConfigProvider,
+		auth:                auth,
+		identities:          connectionIdentities{byRemoteAddr: map[string]string{}},
 	}
 	opAmpServer.server = server.New(zap.S())
 	return opAmpServer
 }
 
 func (srv *Server) Start(listener string) error {
+	tlsConfig, err := srv.auth.tlsConfig()
+	if err != nil {
+		return err
+	}
 	settings := server.StartSettings{
 		Settings: server.Settings{
 			Callbacks: server.CallbacksStruct{
+				OnConnectingFunc:      srv.onConnecting,
 				OnMessageFunc:         srv.OnMessage,
 				OnConnectionCloseFunc: srv.onDisconnect,
 			},
 		},
 		ListenEndpoint: listener,
+		TLSConfig:      tlsConfig,
 	}
 
 	unsubscribe := srv.agentConfigProvider.SubscribeToConfigUpdates(func() {
@@ -78,6 +91,7 @@ func (srv *Server) Stop() {
 
 func (srv *Server) onDisconnect(conn types.Connection) {
 	srv.agents.RemoveConnection(conn)
+	srv.identities.remove(conn)
 }
 
 func (srv *Server) OnMessage(conn types.Connection, msg *protobufs.AgentToServer) *protobufs.ServerToAgent {
@@ -89,7 +103,25 @@ func (srv *Server) OnMessage(conn types.Connection, msg *protobufs.AgentToServer
 		// TODO: handle error
 	}
 
+	identity := srv.identities.get(conn)
+	if !created && agent.Identity != identity {
+		// another agent is using the instance id of a connected one
+		zap.L().Warn("rejected opamp message of agent authenticated as another identity",
+			zap.String("agentId", agentID), zap.String("identity", identity),
+		)
+		return &protobufs.ServerToAgent{
+			InstanceUid: agentID,
+			ErrorResponse: &protobufs.ServerErrorResponse{
+				Type:         protobufs.ServerErrorResponseType_ServerErrorResponseType_BadRequest,
+				ErrorMessage: "agent is connected with another identity",
+			},
+		}
+	}
+
 	if created {
+		if err := agent.SetIdentity(identity); err != nil {
+			zap.L().Error("failed to record agent identity", zap.String("agentId", agentID), zap.Error(err))
+		}
 		agent.CanLB = model.ExtractLbFlag(msg.AgentDescription)
 		zap.S().Debugf(
 			"New agent added:",
@@ -1,6 +1,10 @@
 package tailsampler
 
-import "time"
+import (
+	"time"
+
+	"golang.org/x/exp/slices"
+)
 
 type PolicyType string
 
@@ -79,4 +83,37 @@ type PolicyCfg struct {
 	PolicyFilterCfg `mapstructure:",squash" yaml:"policy_filter"`
 
 	SubPolicies []PolicyCfg `mapstructure:"sub_policies" yaml:"sub_policies"`
+
+	// AgentGroups limits a root policy to the agents of the agent groups
+	// defined on the server, all agents get it if empty. The collector
+	// doesn't know about agent groups, it is left out of the config
+	// sent to agents.
+	AgentGroups []string `mapstructure:"-" yaml:"agent_groups,omitempty"`
+}
+
+// ForAgentGroups returns the config of an agent member of the given agent
+// groups, with the root policies targeting other groups left out
+func (c *Config) ForAgentGroups(groups []string) *Config {
+	config := *c
+	config.PolicyCfgs = []PolicyCfg{}
+	for _, policy := range c.PolicyCfgs {
+		if !targetsAgentGroups(policy, groups) {
+			continue
+		}
+		policy.AgentGroups = nil
+		config.PolicyCfgs = append(config.PolicyCfgs, policy)
+	}
+	return &config
+}
+
+func targetsAgentGroups(policy PolicyCfg, groups []string) bool {
+	if len(policy.AgentGroups) == 0 {
+		return true
+	}
+	for _, group := range policy.AgentGroups {
+		if slices.Contains(groups, group) {
+			return true
+		}
+	}
+	return false
 }
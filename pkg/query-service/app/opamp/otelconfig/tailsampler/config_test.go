@@ -0,0 +1,27 @@
+package tailsampler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForAgentGroups(t *testing.T) {
+	require := require.New(t)
+
+	config := &Config{
+		DecisionWait: 10,
+		PolicyCfgs: []PolicyCfg{
+			{Name: "default"},
+			{Name: "edge", AgentGroups: []string{"edge", "gateway"}},
+			{Name: "prod", AgentGroups: []string{"prod"}},
+		},
+	}
+
+	edge := config.ForAgentGroups([]string{"gateway"})
+	require.Equal(config.DecisionWait, edge.DecisionWait)
+	require.Equal([]PolicyCfg{{Name: "default"}, {Name: "edge"}}, edge.PolicyCfgs)
+	require.Equal([]string{"edge", "gateway"}, config.PolicyCfgs[1].AgentGroups, "the config is left as is")
+
+	require.Equal([]PolicyCfg{{Name: "default"}}, config.ForAgentGroups(nil).PolicyCfgs)
+}
@@ -0,0 +1,330 @@
+package tailsampler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// the salt of the probabilistic sampler, the collector always uses it as
+// it doesn't pass the configured salt on
+const defaultHashSalt = "default-hash-seed"
+
+// result is the outcome of evaluating a filter or a policy, mirroring the
+// decisions of the signoztailsampler processor
+type result int
+
+const (
+	noResult result = iota
+	sampled
+	notSampled
+	// inverted filters produce decisions the processor doesn't act on
+	inverted
+	// probabilistic sampling without a trace id
+	undecided
+)
+
+// PolicyEvaluation tells whether a policy applies to a trace and why
+type PolicyEvaluation struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+
+	SubPolicies []PolicyEvaluation `json:"subPolicies,omitempty"`
+}
+
+// Decision is the outcome of evaluating sampling policies for a trace
+type Decision struct {
+	// Sampled is nil when it depends on the trace id, which wasn't given
+	Sampled *bool `json:"sampled"`
+
+	Policy             string  `json:"policy,omitempty"`
+	SubPolicy          string  `json:"subPolicy,omitempty"`
+	SamplingPercentage float64 `json:"samplingPercentage"`
+	Reason             string  `json:"reason"`
+
+	Evaluations []PolicyEvaluation `json:"evaluations"`
+}
+
+// Evaluate decides the way the signoztailsampler processor does whether a
+// trace with spans having the given attributes is kept. Root policies are
+// tried by priority and the first one reaching a decision decides. A policy
+// whose filter matches decides with its first matching sub policy if any,
+// else with its own sampling. Traces no policy decides on are kept. The
+// decision of the probabilistic sampling is made by hashing the trace id,
+// it is left undecided if traceID is empty.
+func (c *Config) Evaluate(traceID string, spans []map[string]interface{}) (*Decision, error) {
+	var traceIDBytes []byte
+	if traceID != "" {
+		var err error
+		if traceIDBytes, err = hex.DecodeString(traceID); err != nil || len(traceIDBytes) != 16 {
+			return nil, fmt.Errorf("trace id %s is not a 32 character hex string", traceID)
+		}
+	}
+
+	decision := &Decision{Evaluations: []PolicyEvaluation{}}
+	decided := false
+	for _, policy := range byPriority(c.PolicyCfgs) {
+		if decided {
+			decision.Evaluations = append(decision.Evaluations, PolicyEvaluation{
+				Name:   policy.Name,
+				Reason: "not evaluated, a policy with higher priority decided",
+			})
+			continue
+		}
+
+		evaluation, outcome, err := evaluatePolicy(policy, spans, traceIDBytes)
+		if err != nil {
+			return nil, err
+		}
+		decision.Evaluations = append(decision.Evaluations, evaluation)
+		if outcome.result == noResult {
+			continue
+		}
+
+		decided = true
+		decision.Policy = policy.Name
+		decision.SubPolicy = outcome.subPolicy
+		decision.SamplingPercentage = outcome.sampling.SamplingPercentage
+		decision.Reason = outcome.reason
+		if outcome.result != undecided {
+			kept := outcome.result == sampled
+			decision.Sampled = &kept
+		}
+	}
+
+	if !decided {
+		kept := true
+		decision.Sampled = &kept
+		decision.SamplingPercentage = 100
+		decision.Reason = "no policy decided on the trace, traces are kept by default"
+	}
+	return decision, nil
+}
+
+type policyOutcome struct {
+	result    result
+	subPolicy string
+	sampling  ProbabilisticCfg
+	reason    string
+}
+
+func byPriority(policies []PolicyCfg) []PolicyCfg {
+	sorted := append([]PolicyCfg{}, policies...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return sorted
+}
+
+// evaluatePolicy evaluates a root policy with its sub policies, which are
+// only evaluated when the filter of the root policy matches
+func evaluatePolicy(policy PolicyCfg, spans []map[string]interface{}, traceID []byte) (
+	PolicyEvaluation, policyOutcome, error,
+) {
+	evaluation := PolicyEvaluation{Name: policy.Name}
+	matched, reason, err := matchFilter(policy.PolicyFilterCfg, spans)
+	if err != nil {
+		return evaluation, policyOutcome{}, fmt.Errorf("invalid filter of policy %s: %w", policy.Name, err)
+	}
+	evaluation.Matched, evaluation.Reason = matched, reason
+	if !matched {
+		return evaluation, policyOutcome{result: noResult}, nil
+	}
+
+	var outcome *policyOutcome
+	for _, sub := range byPriority(policy.SubPolicies) {
+		if outcome != nil {
+			evaluation.SubPolicies = append(evaluation.SubPolicies, PolicyEvaluation{
+				Name:   sub.Name,
+				Reason: "not evaluated, a sub policy with higher priority decided",
+			})
+			continue
+		}
+
+		// the processor ignores the sub policies of sub policies
+		subMatched, subReason, err := matchFilter(sub.PolicyFilterCfg, spans)
+		if err != nil {
+			return evaluation, policyOutcome{}, fmt.Errorf(
+				"invalid filter of sub policy %s of policy %s: %w", sub.Name, policy.Name, err,
+			)
+		}
+		evaluation.SubPolicies = append(evaluation.SubPolicies, PolicyEvaluation{
+			Name: sub.Name, Matched: subMatched, Reason: subReason,
+		})
+		if subMatched {
+			result, reason := sample(sub.ProbabilisticCfg, traceID)
+			outcome = &policyOutcome{
+				result: result, subPolicy: sub.Name, sampling: sub.ProbabilisticCfg, reason: reason,
+			}
+		}
+	}
+	if outcome != nil {
+		return evaluation, *outcome, nil
+	}
+
+	result, reason := sample(policy.ProbabilisticCfg, traceID)
+	return evaluation, policyOutcome{result: result, sampling: policy.ProbabilisticCfg, reason: reason}, nil
+}
+
+// matchFilter tells if the filter of a policy matches a trace. With the AND
+// operator every filter must match, with any other operator one is enough.
+// Inverted string filters never match nor fail a policy, and policies
+// without filters never match.
+func matchFilter(filter PolicyFilterCfg, spans []map[string]interface{}) (bool, string, error) {
+	if len(filter.StringAttributeCfgs)+len(filter.NumericAttributeCfgs) == 0 {
+		return false, "the policy has no filter", nil
+	}
+
+	results := []result{}
+	reasons := []string{}
+	for _, cfg := range filter.StringAttributeCfgs {
+		result, err := matchStringAttribute(cfg, spans)
+		if err != nil {
+			return false, "", err
+		}
+		results = append(results, result)
+		reasons = append(reasons, conditionReason(cfg.Key, result))
+	}
+	for _, cfg := range filter.NumericAttributeCfgs {
+		result := matchNumericAttribute(cfg, spans)
+		results = append(results, result)
+		reasons = append(reasons, conditionReason(cfg.Key, result))
+	}
+
+	matched := false
+	for _, result := range results {
+		if result == notSampled && strings.ToLower(filter.FilterOp) == "and" {
+			return false, strings.Join(reasons, ", "), nil
+		}
+		if result == sampled {
+			matched = true
+		}
+	}
+	return matched, strings.Join(reasons, ", "), nil
+}
+
+func conditionReason(key string, result result) string {
+	switch result {
+	case sampled:
+		return fmt.Sprintf("%s matched", key)
+	case inverted:
+		return fmt.Sprintf("%s is ignored as its match is inverted", key)
+	}
+	return fmt.Sprintf("%s didn't match", key)
+}
+
+func matchStringAttribute(cfg StringAttributeCfg, spans []map[string]interface{}) (result, error) {
+	if cfg.InvertMatch {
+		return inverted, nil
+	}
+
+	var matches func(value string) bool
+	if cfg.EnabledRegexMatching {
+		regexes := []*regexp.Regexp{}
+		for _, value := range cfg.Values {
+			regex, err := regexp.Compile(value)
+			if err != nil {
+				return noResult, err
+			}
+			regexes = append(regexes, regex)
+		}
+		matches = func(value string) bool {
+			for _, regex := range regexes {
+				if regex.MatchString(value) {
+					return true
+				}
+			}
+			return false
+		}
+	} else {
+		values := map[string]struct{}{}
+		for _, value := range cfg.Values {
+			if value != "" {
+				values[value] = struct{}{}
+			}
+		}
+		matches = func(value string) bool {
+			_, ok := values[value]
+			return ok
+		}
+	}
+
+	for _, span := range spans {
+		// attributes which aren't strings read as empty strings, which the
+		// processor skips
+		value, ok := span[cfg.Key].(string)
+		if ok && value != "" && matches(value) {
+			return sampled, nil
+		}
+	}
+	return notSampled, nil
+}
+
+func matchNumericAttribute(cfg NumericAttributeCfg, spans []map[string]interface{}) result {
+	for _, span := range spans {
+		value, ok := span[cfg.Key]
+		if !ok {
+			continue
+		}
+		number := intValue(value)
+		if number >= cfg.MinValue && number <= cfg.MaxValue {
+			return sampled
+		}
+	}
+	return notSampled
+}
+
+// intValue reads an attribute the way the processor does, attributes which
+// aren't integers read as 0. JSON numbers are integers when they are whole.
+func intValue(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+	}
+	return 0
+}
+
+// sample makes the decision of the sampling of a policy. The probabilistic
+// sampler keeps the traces whose id hash falls below the sampling
+// percentage, the hash salt of policies is ignored like the processor does.
+func sample(cfg ProbabilisticCfg, traceID []byte) (result, string) {
+	switch cfg.SamplingPercentage {
+	case 0:
+		return notSampled, "the policy drops all traces"
+	case 100:
+		return sampled, "the policy keeps all traces"
+	}
+	if traceID == nil {
+		return undecided, fmt.Sprintf("the policy keeps %v%% of traces, depending on the trace id", cfg.SamplingPercentage)
+	}
+
+	if hashTraceID(defaultHashSalt, traceID) <= samplingThreshold(cfg.SamplingPercentage/100) {
+		return sampled, fmt.Sprintf("the trace id falls in the %v%% of traces the policy keeps", cfg.SamplingPercentage)
+	}
+	return notSampled, fmt.Sprintf("the trace id falls out of the %v%% of traces the policy keeps", cfg.SamplingPercentage)
+}
+
+func hashTraceID(salt string, traceID []byte) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(salt))
+	hasher.Write(traceID)
+	return hasher.Sum64()
+}
+
+func samplingThreshold(ratio float64) uint64 {
+	boundary := new(big.Float).SetInt(new(big.Int).SetUint64(math.MaxUint64))
+	threshold, _ := boundary.Mul(boundary, big.NewFloat(ratio)).Uint64()
+	return threshold
+}
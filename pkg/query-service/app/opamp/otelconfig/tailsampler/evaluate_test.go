@@ -0,0 +1,238 @@
+package tailsampler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	require := require.New(t)
+
+	config := &Config{
+		PolicyCfgs: []PolicyCfg{
+			{
+				Name: "default", Type: "policy_group", Root: true, Priority: 2,
+				ProbabilisticCfg: ProbabilisticCfg{SamplingPercentage: 10},
+				PolicyFilterCfg: PolicyFilterCfg{
+					StringAttributeCfgs: []StringAttributeCfg{
+						{Key: "service.name", Values: []string{".+"}, EnabledRegexMatching: true},
+					},
+				},
+			},
+			{
+				Name: "checkout", Type: "policy_group", Root: true, Priority: 1,
+				ProbabilisticCfg: ProbabilisticCfg{SamplingPercentage: 0},
+				PolicyFilterCfg: PolicyFilterCfg{
+					FilterOp: "AND",
+					StringAttributeCfgs: []StringAttributeCfg{
+						{Key: "service.name", Values: []string{"checkout"}},
+					},
+				},
+				SubPolicies: []PolicyCfg{
+					{
+						Name: "errors", Priority: 1,
+						ProbabilisticCfg: ProbabilisticCfg{SamplingPercentage: 100},
+						PolicyFilterCfg: PolicyFilterCfg{
+							FilterOp: "OR",
+							NumericAttributeCfgs: []NumericAttributeCfg{
+								{Key: "http.status_code", MinValue: 500, MaxValue: 599},
+							},
+							StringAttributeCfgs: []StringAttributeCfg{
+								{Key: "error.type", Values: []string{".+"}, EnabledRegexMatching: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	decision, err := config.Evaluate("", []map[string]interface{}{
+		{"service.name": "checkout", "http.status_code": 200},
+		{"service.name": "checkout", "http.status_code": 503},
+	})
+	require.Nil(err)
+	require.Equal("checkout", decision.Policy)
+	require.Equal("errors", decision.SubPolicy)
+	require.True(*decision.Sampled)
+	require.Equal("not evaluated, a policy with higher priority decided", decision.Evaluations[1].Reason)
+
+	decision, err = config.Evaluate("", []map[string]interface{}{{"service.name": "checkout"}})
+	require.Nil(err)
+	require.Empty(decision.SubPolicy)
+	require.False(*decision.Sampled, "the root policy sampling should apply without a matching sub policy")
+
+	decision, err = config.Evaluate("", []map[string]interface{}{{"service.name": "cart"}})
+	require.Nil(err)
+	require.Equal("default", decision.Policy)
+	require.Nil(decision.Sampled, "probabilistic decisions need the trace id")
+
+	// the same trace id always gets the same decision, and about the
+	// sampled percentage of trace ids are kept
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		traceID := fmt.Sprintf("%032x", i*7919)
+		decision, err = config.Evaluate(traceID, []map[string]interface{}{{"service.name": "cart"}})
+		require.Nil(err)
+		again, err := config.Evaluate(traceID, []map[string]interface{}{{"service.name": "cart"}})
+		require.Nil(err)
+		require.Equal(*decision.Sampled, *again.Sampled)
+		if *decision.Sampled {
+			kept++
+		}
+	}
+	require.InDelta(100, kept, 40)
+
+	_, err = config.Evaluate("not-a-trace-id", nil)
+	require.NotNil(err)
+}
+
+// TestEvaluateLikeCollector checks decisions against the behavior of the
+// signoztailsampler processor
+func TestEvaluateLikeCollector(t *testing.T) {
+	checkout := StringAttributeCfg{Key: "service.name", Values: []string{"checkout"}}
+	serverErrors := NumericAttributeCfg{Key: "http.status_code", MinValue: 500, MaxValue: 599}
+	// a trace id whose hash is below the threshold of 50% with the default
+	// salt but not with the "other" salt
+	traceID := findTraceID(t, func(id []byte) bool {
+		return hashTraceID(defaultHashSalt, id) <= samplingThreshold(0.5) &&
+			hashTraceID("other", id) > samplingThreshold(0.5)
+	})
+
+	tests := []struct {
+		name     string
+		policies []PolicyCfg
+		traceID  string
+		spans    []map[string]interface{}
+		policy   string
+		sampled  *bool
+	}{
+		{
+			name:     "traces no policy decides on are kept",
+			policies: []PolicyCfg{{Name: "drop-checkout", PolicyFilterCfg: PolicyFilterCfg{StringAttributeCfgs: []StringAttributeCfg{checkout}}}},
+			spans:    []map[string]interface{}{{"service.name": "cart"}},
+			sampled:  boolPtr(true),
+		},
+		{
+			name:    "no policies keep every trace",
+			spans:   []map[string]interface{}{{"service.name": "cart"}},
+			sampled: boolPtr(true),
+		},
+		{
+			name: "policies without filters never match",
+			policies: []PolicyCfg{
+				{Name: "drop-all", Priority: 1},
+				{Name: "drop-checkout", Priority: 2, PolicyFilterCfg: PolicyFilterCfg{StringAttributeCfgs: []StringAttributeCfg{checkout}}},
+			},
+			spans:   []map[string]interface{}{{"service.name": "checkout"}},
+			policy:  "drop-checkout",
+			sampled: boolPtr(false),
+		},
+		{
+			name: "an empty filter operator is OR",
+			policies: []PolicyCfg{{Name: "drop", PolicyFilterCfg: PolicyFilterCfg{
+				StringAttributeCfgs:  []StringAttributeCfg{checkout},
+				NumericAttributeCfgs: []NumericAttributeCfg{serverErrors},
+			}}},
+			spans:   []map[string]interface{}{{"service.name": "checkout", "http.status_code": 200}},
+			policy:  "drop",
+			sampled: boolPtr(false),
+		},
+		{
+			name: "the AND operator needs every filter to match",
+			policies: []PolicyCfg{{Name: "drop", PolicyFilterCfg: PolicyFilterCfg{
+				FilterOp:             "and",
+				StringAttributeCfgs:  []StringAttributeCfg{checkout},
+				NumericAttributeCfgs: []NumericAttributeCfg{serverErrors},
+			}}},
+			spans:   []map[string]interface{}{{"service.name": "checkout", "http.status_code": 200}},
+			sampled: boolPtr(true),
+		},
+		{
+			name: "the hash salt of policies is ignored",
+			policies: []PolicyCfg{{
+				Name:             "half",
+				ProbabilisticCfg: ProbabilisticCfg{SamplingPercentage: 50, HashSalt: "other"},
+				PolicyFilterCfg:  PolicyFilterCfg{StringAttributeCfgs: []StringAttributeCfg{checkout}},
+			}},
+			traceID: traceID,
+			spans:   []map[string]interface{}{{"service.name": "checkout"}},
+			policy:  "half",
+			sampled: boolPtr(true),
+		},
+		{
+			name: "inverted filters are ignored",
+			policies: []PolicyCfg{{Name: "drop", PolicyFilterCfg: PolicyFilterCfg{
+				StringAttributeCfgs: []StringAttributeCfg{
+					{Key: "service.name", Values: []string{"checkout"}, InvertMatch: true},
+				},
+			}}},
+			spans:   []map[string]interface{}{{"service.name": "cart"}},
+			sampled: boolPtr(true),
+		},
+		{
+			name: "string filters only match string attributes",
+			policies: []PolicyCfg{{Name: "drop", PolicyFilterCfg: PolicyFilterCfg{
+				StringAttributeCfgs: []StringAttributeCfg{{Key: "http.status_code", Values: []string{"500"}}},
+			}}},
+			spans:   []map[string]interface{}{{"http.status_code": 500}},
+			sampled: boolPtr(true),
+		},
+		{
+			name: "numeric filters read other attributes as 0",
+			policies: []PolicyCfg{{Name: "drop", PolicyFilterCfg: PolicyFilterCfg{
+				NumericAttributeCfgs: []NumericAttributeCfg{{Key: "retries", MinValue: 0, MaxValue: 0}},
+			}}},
+			spans:   []map[string]interface{}{{"retries": "3"}},
+			policy:  "drop",
+			sampled: boolPtr(false),
+		},
+		{
+			name: "sub policies decide before the root sampling",
+			policies: []PolicyCfg{{
+				Name:            "checkout",
+				PolicyFilterCfg: PolicyFilterCfg{StringAttributeCfgs: []StringAttributeCfg{checkout}},
+				SubPolicies: []PolicyCfg{
+					{Name: "no-filter", Priority: 1},
+					{
+						Name: "errors", Priority: 2,
+						ProbabilisticCfg: ProbabilisticCfg{SamplingPercentage: 100},
+						PolicyFilterCfg:  PolicyFilterCfg{NumericAttributeCfgs: []NumericAttributeCfg{serverErrors}},
+					},
+				},
+			}},
+			spans:   []map[string]interface{}{{"service.name": "checkout"}, {"http.status_code": float64(503)}},
+			policy:  "checkout",
+			sampled: boolPtr(true),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, err := (&Config{PolicyCfgs: tc.policies}).Evaluate(tc.traceID, tc.spans)
+			require.Nil(t, err)
+			require.Equal(t, tc.policy, decision.Policy)
+			require.Equal(t, tc.sampled, decision.Sampled)
+		})
+	}
+}
+
+func findTraceID(t *testing.T, accept func(id []byte) bool) string {
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("%032x", i*104729)
+		bytes, err := hex.DecodeString(id)
+		require.Nil(t, err)
+		if accept(bytes) {
+			return id
+		}
+	}
+	t.Fatal("no trace id found")
+	return ""
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -19,6 +19,7 @@ import (
 
 	"go.signoz.io/signoz/pkg/query-service/app/metrics"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/search"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -66,6 +67,59 @@ func parseGetTopOperationsRequest(r *http.Request) (*model.GetTopOperationsParam
 	return postData, nil
 }
 
+func parseGetDependencyCallsRequest(r *http.Request) (*model.GetDependencyCallsParams, error) {
+	var postData *model.GetDependencyCallsParams
+	err := json.NewDecoder(r.Body).Decode(&postData)
+	if err != nil {
+		return nil, err
+	}
+
+	postData.Start, err = parseTimeStr(postData.StartTime, "start")
+	if err != nil {
+		return nil, err
+	}
+	postData.End, err = parseTimeMinusBufferStr(postData.EndTime, "end")
+	if err != nil {
+		return nil, err
+	}
+
+	if postData.Limit <= 0 {
+		postData.Limit = 100
+	}
+
+	return postData, nil
+}
+
+func parseGetLatencyBreakdownRequest(r *http.Request) (*model.GetLatencyBreakdownParams, error) {
+	var postData *model.GetLatencyBreakdownParams
+	err := json.NewDecoder(r.Body).Decode(&postData)
+	if err != nil {
+		return nil, err
+	}
+
+	if postData.ServiceName == "" || postData.Operation == "" {
+		return nil, errors.New("service and operation are required")
+	}
+	if postData.Attribute == "" {
+		return nil, errors.New("attribute to break the latency down by is required")
+	}
+
+	postData.Start, err = parseTimeStr(postData.StartTime, "start")
+	if err != nil {
+		return nil, err
+	}
+	postData.End, err = parseTimeMinusBufferStr(postData.EndTime, "end")
+	if err != nil {
+		return nil, err
+	}
+
+	if postData.Limit <= 0 {
+		postData.Limit = 20
+	}
+
+	return postData, nil
+}
+
 func parseMetricsTime(s string) (time.Time, error) {
 	if t, err := strconv.ParseFloat(s, 64); err == nil {
 		s, ns := math.Modf(t)
@@ -259,6 +313,92 @@ func ParseSearchTracesParams(r *http.Request) (string, string, int, int, error)
 	return traceId, spanId, levelUpInt, levelDownInt, nil
 }
 
+const (
+	defaultTraceSpansDepth = 1
+	defaultTraceSpansLimit = 500
+	maxTraceSpansLimit     = 10000
+)
+
+func ParseSearchTraceSpansParams(r *http.Request) (*model.SearchTraceSpansParams, error) {
+	params := &model.SearchTraceSpansParams{
+		TraceID:      mux.Vars(r)["traceId"],
+		ParentSpanID: r.URL.Query().Get("parentSpanId"),
+		Depth:        defaultTraceSpansDepth,
+		Limit:        defaultTraceSpansLimit,
+	}
+
+	for key, target := range map[string]*int{
+		"depth":  &params.Depth,
+		"limit":  &params.Limit,
+		"offset": &params.Offset,
+	} {
+		value := r.URL.Query().Get(key)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer: %w", key, err)
+		}
+		*target = parsed
+	}
+
+	if params.Depth < 1 {
+		return nil, fmt.Errorf("depth must be at least 1")
+	}
+	if params.Limit < 1 || params.Limit > maxTraceSpansLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTraceSpansLimit)
+	}
+	if params.Offset < 0 {
+		return nil, fmt.Errorf("offset can not be negative")
+	}
+	return params, nil
+}
+
+// ParseSpanFieldSelection reads the optional span fields to include in trace
+// search results from the comma separated fields param, e.g.
+// fields=attributes,references&attributes=http.method,host.name
+// Returns nil, for all the fields, when neither param is set.
+func ParseSpanFieldSelection(r *http.Request) (*model.SpanFieldSelection, error) {
+	query := r.URL.Query()
+	if !query.Has("fields") && !query.Has("attributes") {
+		return nil, nil
+	}
+
+	selection := &model.SpanFieldSelection{Fields: map[string]bool{}}
+	if query.Has("fields") {
+		for _, field := range strings.Split(query.Get("fields"), ",") {
+			field = strings.TrimSpace(field)
+			switch field {
+			case "":
+				continue
+			case model.SpanFieldAttributes, model.SpanFieldEvents, model.SpanFieldReferences:
+				selection.Fields[field] = true
+			default:
+				return nil, fmt.Errorf(
+					"unknown span field %s, fields must be among %s, %s, %s", field,
+					model.SpanFieldAttributes, model.SpanFieldEvents, model.SpanFieldReferences,
+				)
+			}
+		}
+	} else {
+		selection.Fields = map[string]bool{
+			model.SpanFieldAttributes: true, model.SpanFieldEvents: true, model.SpanFieldReferences: true,
+		}
+	}
+
+	if query.Has("attributes") {
+		selection.Fields[model.SpanFieldAttributes] = true
+		selection.Attributes = map[string]bool{}
+		for _, key := range strings.Split(query.Get("attributes"), ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				selection.Attributes[key] = true
+			}
+		}
+	}
+	return selection, nil
+}
+
 func DoesExistInSlice(item string, list []string) bool {
 	for _, element := range list {
 		if item == element {
@@ -648,6 +788,179 @@ func parseTimeMinusBuffer(param string, r *http.Request) (*time.Time, error) {
 
 }
 
+const (
+	defaultAttributesRefreshLookbackHours = 24
+	maxAttributesRefreshLookbackHours     = 24 * 30
+)
+
+func parseRefreshAttributesParams(r *http.Request) (*model.RefreshAttributesParams, error) {
+	params := &model.RefreshAttributesParams{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+			return nil, fmt.Errorf("failed to decode request body: %w", err)
+		}
+	}
+
+	if params.LookbackHours == 0 {
+		params.LookbackHours = defaultAttributesRefreshLookbackHours
+	}
+	if params.LookbackHours < 0 || params.LookbackHours > maxAttributesRefreshLookbackHours {
+		return nil, fmt.Errorf("lookbackHours must be between 1 and %d", maxAttributesRefreshLookbackHours)
+	}
+	return params, nil
+}
+
+const (
+	defaultStaleResourcesUnusedForDays = 90
+	maxStaleResourcesUnusedForDays     = 365 * 5
+)
+
+func parseStaleResourcesParams(r *http.Request) (int, error) {
+	unusedForDaysStr := r.URL.Query().Get("unusedForDays")
+	if unusedForDaysStr == "" {
+		return defaultStaleResourcesUnusedForDays, nil
+	}
+
+	unusedForDays, err := strconv.Atoi(unusedForDaysStr)
+	if err != nil || unusedForDays < 1 || unusedForDays > maxStaleResourcesUnusedForDays {
+		return 0, fmt.Errorf("unusedForDays must be a number between 1 and %d", maxStaleResourcesUnusedForDays)
+	}
+	return unusedForDays, nil
+}
+
+const (
+	defaultQueryAuditReportLimit = 10
+	maxQueryAuditReportLimit     = 1000
+)
+
+func parseQueryAuditReportParams(r *http.Request) (*model.QueryAuditReportParams, error) {
+	params := &model.QueryAuditReportParams{
+		GroupBy: r.URL.Query().Get("groupBy"),
+		Limit:   defaultQueryAuditReportLimit,
+	}
+
+	end := time.Now().UnixMilli()
+	if endStr := r.URL.Query().Get("end"); endStr != "" {
+		var err error
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("end must be a unix timestamp in milliseconds")
+		}
+	}
+	start := end - 24*time.Hour.Milliseconds()
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		var err error
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("start must be a unix timestamp in milliseconds")
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("start must not be after end")
+	}
+	params.Start, params.End = start, end
+
+	switch params.GroupBy {
+	case "":
+		params.GroupBy = model.QueryAuditGroupByUser
+	case model.QueryAuditGroupByUser, model.QueryAuditGroupByDashboard, model.QueryAuditGroupByAlert:
+	default:
+		return nil, fmt.Errorf("groupBy must be one of user, dashboard or alert")
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxQueryAuditReportLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", maxQueryAuditReportLimit)
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+const (
+	defaultK8sPodTimelineLimit = 10000
+	maxK8sPodTimelineLimit     = 50000
+)
+
+func parseK8sPodTimelineParams(r *http.Request) (*model.K8sPodTimelineParams, error) {
+	params := &model.K8sPodTimelineParams{
+		Namespace: r.URL.Query().Get("namespace"),
+		Pod:       r.URL.Query().Get("pod"),
+		Limit:     defaultK8sPodTimelineLimit,
+	}
+
+	end := time.Now().UnixMilli()
+	if endStr := r.URL.Query().Get("end"); endStr != "" {
+		var err error
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("end must be a unix timestamp in milliseconds")
+		}
+	}
+	start := end - time.Hour.Milliseconds()
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		var err error
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("start must be a unix timestamp in milliseconds")
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("start must not be after end")
+	}
+	params.Start, params.End = time.UnixMilli(start), time.UnixMilli(end)
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxK8sPodTimelineLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", maxK8sPodTimelineLimit)
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+const (
+	defaultSessionTimelineLimit = 5000
+	maxSessionTimelineLimit     = 20000
+)
+
+func parseSessionTimelineParams(r *http.Request) (*model.SessionTimelineParams, error) {
+	params := &model.SessionTimelineParams{
+		SessionId: mux.Vars(r)["id"],
+		Limit:     defaultSessionTimelineLimit,
+	}
+	if params.SessionId == "" {
+		return nil, fmt.Errorf("session id is required")
+	}
+
+	end := time.Now().UnixMilli()
+	if endStr := r.URL.Query().Get("end"); endStr != "" {
+		var err error
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("end must be a unix timestamp in milliseconds")
+		}
+	}
+	// sessions of browser sdks expire after at most a few hours of use
+	start := end - 24*time.Hour.Milliseconds()
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		var err error
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("start must be a unix timestamp in milliseconds")
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("start must not be after end")
+	}
+	params.Start, params.End = time.UnixMilli(start), time.UnixMilli(end)
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSessionTimelineLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", maxSessionTimelineLimit)
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
 func parseTTLParams(r *http.Request) (*model.TTLParams, error) {
 
 	// make sure either of the query params are present
@@ -808,6 +1121,24 @@ func parseChangePasswordRequest(r *http.Request) (*model.ChangePasswordRequest,
 	return &req, nil
 }
 
+func parseRotateSigningKeyRequest(r *http.Request) (time.Duration, error) {
+	req := model.RotateSigningKeyRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return 0, fmt.Errorf("failed to decode request body: %w", err)
+		}
+	}
+
+	if req.GracePeriod == "" {
+		return auth.JwtRefresh, nil
+	}
+	gracePeriod, err := time.ParseDuration(req.GracePeriod)
+	if err != nil {
+		return 0, fmt.Errorf("invalid grace period %s: %w", req.GracePeriod, err)
+	}
+	return gracePeriod, nil
+}
+
 func parseFilterSet(r *http.Request) (*model.FilterSet, error) {
 	var filterSet model.FilterSet
 	err := json.NewDecoder(r.Body).Decode(&filterSet)
@@ -923,6 +1254,26 @@ func validateQueryRangeParamsV3(qp *v3.QueryRangeParamsV3) error {
 		return err
 	}
 
+	maxTimeout := int64(constants.ContextTimeoutMaxAllowed / time.Second)
+	if qp.Timeout < 0 || qp.Timeout > maxTimeout {
+		return fmt.Errorf("timeout must be between 0 and %d seconds", maxTimeout)
+	}
+
+	for name, variableType := range qp.VariableTypes {
+		if err := variableType.Validate(); err != nil {
+			return fmt.Errorf("invalid type of variable %s: %w", name, err)
+		}
+		value, ok := qp.Variables[name]
+		if !ok {
+			continue
+		}
+		coerced, err := variableType.Coerce(value)
+		if err != nil {
+			return fmt.Errorf("invalid value of variable %s: %w", name, err)
+		}
+		qp.Variables[name] = coerced
+	}
+
 	var expressions []string
 	for _, q := range qp.CompositeQuery.BuilderQueries {
 		expressions = append(expressions, q.Expression)
@@ -931,7 +1282,7 @@ func validateQueryRangeParamsV3(qp *v3.QueryRangeParamsV3) error {
 	if len(errs) > 0 {
 		return multierr.Combine(errs...)
 	}
-	return nil
+	return validateCalculatedColumns(qp.CompositeQuery)
 }
 
 // validateExpressions validates the math expressions using the list of
@@ -969,9 +1320,18 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot parse the request body: %v", err)}
 	}
 
+	if apiErr := PrepareQueryRangeParams(queryRangeParams); apiErr != nil {
+		return nil, apiErr
+	}
+	return queryRangeParams, nil
+}
+
+// PrepareQueryRangeParams validates query range params and replaces the
+// variables in their queries, the way the query range API does
+func PrepareQueryRangeParams(queryRangeParams *v3.QueryRangeParamsV3) *model.ApiError {
 	// validate the request body
 	if err := validateQueryRangeParamsV3(queryRangeParams); err != nil {
-		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 	}
 
 	// prepare the variables for the corresponding query type
@@ -994,7 +1354,7 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 			if query.QueryName != query.Expression {
 				expression, err := govaluate.NewEvaluableExpressionWithFunctions(query.Expression, evalFuncs())
 				if err != nil {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+					return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 				}
 
 				// get the group keys for the vars
@@ -1005,8 +1365,10 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 						for _, key := range varQuery.GroupBy {
 							groupKeys[v] = append(groupKeys[v], key.Key)
 						}
+						// labels set by label functions can be joined on too
+						groupKeys[v] = queryBuilder.LabelFunctionKeys(varQuery.Functions, groupKeys[v])
 					} else {
-						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("unknown variable %s", v)}
+						return &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("unknown variable %s", v)}
 					}
 				}
 
@@ -1017,11 +1379,11 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 
 				can, _, err := expression.CanJoin(params)
 				if err != nil {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+					return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 				}
 
 				if !can {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys")}
+					return &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys")}
 				}
 			}
 
@@ -1072,6 +1434,9 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 			}
 		}
 	}
+	// keep the unformatted values around, parameterized clickhouse queries
+	// bind them as is rather than splicing the formatted values into the query
+	rawVars := queryRangeParams.Variables
 	queryRangeParams.Variables = formattedVars
 
 	// prometheus instant query needs same timestamp
@@ -1086,10 +1451,18 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 			if chQuery.Disabled {
 				continue
 			}
+			if chQuery.Parameterized {
+				params, err := clickHouseQueryParameters(chQuery, rawVars, queryRangeParams)
+				if err != nil {
+					return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+				}
+				chQuery.Parameters = params
+				continue
+			}
 			tmpl := template.New("clickhouse-query")
 			tmpl, err := tmpl.Parse(chQuery.Query)
 			if err != nil {
-				return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+				return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 			}
 			var query bytes.Buffer
 
@@ -1098,7 +1471,7 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 
 			err = tmpl.Execute(&query, queryRangeParams.Variables)
 			if err != nil {
-				return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+				return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 			}
 			chQuery.Query = query.String()
 		}
@@ -1113,7 +1486,7 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 			tmpl := template.New("prometheus-query")
 			tmpl, err := tmpl.Parse(promQuery.Query)
 			if err != nil {
-				return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+				return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 			}
 			var query bytes.Buffer
 
@@ -1122,11 +1495,55 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 
 			err = tmpl.Execute(&query, queryRangeParams.Variables)
 			if err != nil {
-				return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+				return &model.ApiError{Typ: model.ErrorBadData, Err: err}
 			}
 			promQuery.Query = query.String()
 		}
 	}
 
-	return queryRangeParams, nil
+	return nil
+}
+
+// clickHouseQueryParameters resolves the values for the placeholders declared
+// in a parameterized clickhouse query from the request variables and the
+// reserved time range vars
+func clickHouseQueryParameters(chQuery *v3.ClickHouseQuery, vars map[string]interface{}, queryRangeParams *v3.QueryRangeParamsV3) (map[string]string, error) {
+	reserved := querytemplate.ReservedParametersV3(queryRangeParams)
+	params := make(map[string]string)
+	for _, name := range chQuery.ParameterNames() {
+		if value, ok := reserved[name]; ok {
+			params[name] = value
+			continue
+		}
+		value, ok := vars[name]
+		if !ok {
+			return nil, fmt.Errorf("no value provided for query parameter %s", name)
+		}
+		formatted, err := utils.ClickHouseParameterValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for query parameter %s: %w", name, err)
+		}
+		params[name] = formatted
+	}
+	return params, nil
+}
+
+func parseSearchParams(r *http.Request) (*search.Query, error) {
+	query := &search.Query{Text: r.URL.Query().Get("q")}
+	if types := r.URL.Query().Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			query.ResourceTypes = append(query.ResourceTypes, search.ResourceType(strings.TrimSpace(t)))
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit must be a positive number")
+		}
+		query.Limit = limit
+	}
+	if err := query.IsValid(); err != nil {
+		return nil, err
+	}
+	return query, nil
 }
@@ -3,12 +3,14 @@ package app
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/smartystreets/assertions/should"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/assert"
@@ -861,6 +863,197 @@ func TestParseQueryRangeParamsPromQLVars(t *testing.T) {
 	}
 }
 
+func TestParseQueryRangeParamsClickHouseParameters(t *testing.T) {
+	reqCases := []struct {
+		desc           string
+		compositeQuery v3.CompositeQuery
+		variables      map[string]interface{}
+		expectErr      bool
+		errMsg         string
+		expectedQuery  string
+		expectedParams map[string]string
+	}{
+		{
+			desc: "parameterized query binds dashboard variables",
+			compositeQuery: v3.CompositeQuery{
+				PanelType: v3.PanelTypeGraph,
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {
+						Query:         "SELECT count() FROM signoz_traces.distributed_signoz_index_v2 WHERE serviceName = {service_name:String} AND name IN {operation_name:Array(String)} AND durationNano > {min_duration:UInt64}",
+						Parameterized: true,
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"service_name":   "route' OR 1=1 --",
+				"operation_name": []interface{}{"GET /route", "it's"},
+				"min_duration":   float64(1000),
+			},
+			expectErr:     false,
+			expectedQuery: "SELECT count() FROM signoz_traces.distributed_signoz_index_v2 WHERE serviceName = {service_name:String} AND name IN {operation_name:Array(String)} AND durationNano > {min_duration:UInt64}",
+			expectedParams: map[string]string{
+				"service_name":   "route' OR 1=1 --",
+				"operation_name": `['GET /route','it\'s']`,
+				"min_duration":   "1000",
+			},
+		},
+		{
+			desc: "parameterized query with missing variable",
+			compositeQuery: v3.CompositeQuery{
+				PanelType: v3.PanelTypeGraph,
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {
+						Query:         "SELECT count() FROM signoz_traces.distributed_signoz_index_v2 WHERE serviceName = {service_name:String}",
+						Parameterized: true,
+					},
+				},
+			},
+			variables: map[string]interface{}{},
+			expectErr: true,
+			errMsg:    "no value provided for query parameter service_name",
+		},
+		{
+			desc: "parameterized query with template variables",
+			compositeQuery: v3.CompositeQuery{
+				PanelType: v3.PanelTypeGraph,
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {
+						Query:         "SELECT count() FROM signoz_traces.distributed_signoz_index_v2 WHERE serviceName = {{.service_name}}",
+						Parameterized: true,
+					},
+				},
+			},
+			variables: map[string]interface{}{"service_name": "route"},
+			expectErr: true,
+			errMsg:    "parameterized query can not contain template variables",
+		},
+	}
+
+	for _, tc := range reqCases {
+		t.Run(tc.desc, func(t *testing.T) {
+
+			queryRangeParams := &v3.QueryRangeParamsV3{
+				Start:          time.Now().Add(-time.Hour).UnixMilli(),
+				End:            time.Now().UnixMilli(),
+				Step:           time.Minute.Microseconds(),
+				CompositeQuery: &tc.compositeQuery,
+				Variables:      tc.variables,
+			}
+
+			body := &bytes.Buffer{}
+			err := json.NewEncoder(body).Encode(queryRangeParams)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v3/query_range", body)
+
+			parsedQueryRangeParams, apiErr := ParseQueryRangeParams(req)
+			if tc.expectErr {
+				require.Error(t, apiErr)
+				require.Contains(t, apiErr.Error(), tc.errMsg)
+			} else {
+				require.Nil(t, apiErr)
+				require.Equal(t, tc.expectedQuery, parsedQueryRangeParams.CompositeQuery.ClickHouseQueries["A"].Query)
+				require.Equal(t, tc.expectedParams, parsedQueryRangeParams.CompositeQuery.ClickHouseQueries["A"].Parameters)
+			}
+		})
+	}
+}
+
+func TestParseQueryRangeParamsTypedVars(t *testing.T) {
+	maxLimit := float64(100)
+	reqCases := []struct {
+		desc          string
+		variables     map[string]interface{}
+		variableTypes map[string]v3.VariableType
+		expectErr     bool
+		errMsg        string
+		expectedQuery string
+	}{
+		{
+			desc: "numbers and booleans are substituted unquoted",
+			variables: map[string]interface{}{
+				"min_duration": "1000.5",
+				"errors_only":  "true",
+				"limit":        "10",
+			},
+			variableTypes: map[string]v3.VariableType{
+				"min_duration": {DataType: v3.VariableDataTypeNumber},
+				"errors_only":  {DataType: v3.VariableDataTypeBoolean},
+				"limit":        {DataType: v3.VariableDataTypeNumber, Max: &maxLimit},
+			},
+			expectedQuery: "SELECT * FROM spans WHERE durationNano > 1000.500000 AND hasError = true LIMIT 10",
+		},
+		{
+			desc: "untyped variables are substituted as strings",
+			variables: map[string]interface{}{
+				"min_duration": "1000",
+				"errors_only":  "true",
+				"limit":        "10",
+			},
+			expectedQuery: "SELECT * FROM spans WHERE durationNano > '1000' AND hasError = 'true' LIMIT '10'",
+		},
+		{
+			desc:          "invalid number",
+			variables:     map[string]interface{}{"min_duration": "1000ms"},
+			variableTypes: map[string]v3.VariableType{"min_duration": {DataType: v3.VariableDataTypeNumber}},
+			expectErr:     true,
+			errMsg:        "invalid value of variable min_duration",
+		},
+		{
+			desc:          "number out of range",
+			variables:     map[string]interface{}{"limit": "1000"},
+			variableTypes: map[string]v3.VariableType{"limit": {DataType: v3.VariableDataTypeNumber, Max: &maxLimit}},
+			expectErr:     true,
+			errMsg:        "greater than the maximum",
+		},
+		{
+			desc:          "range of a boolean",
+			variables:     map[string]interface{}{"errors_only": "true"},
+			variableTypes: map[string]v3.VariableType{"errors_only": {DataType: v3.VariableDataTypeBoolean, Max: &maxLimit}},
+			expectErr:     true,
+			errMsg:        "invalid type of variable errors_only",
+		},
+	}
+
+	for _, tc := range reqCases {
+		t.Run(tc.desc, func(t *testing.T) {
+
+			queryRangeParams := &v3.QueryRangeParamsV3{
+				Start: time.Now().Add(-time.Hour).UnixMilli(),
+				End:   time.Now().UnixMilli(),
+				Step:  time.Minute.Microseconds(),
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType: v3.PanelTypeList,
+					QueryType: v3.QueryTypeClickHouseSQL,
+					ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+						"A": {
+							Query: "SELECT * FROM spans WHERE durationNano > {{.min_duration}} AND hasError = {{.errors_only}} LIMIT {{.limit}}",
+						},
+					},
+				},
+				Variables:     tc.variables,
+				VariableTypes: tc.variableTypes,
+			}
+
+			body := &bytes.Buffer{}
+			err := json.NewEncoder(body).Encode(queryRangeParams)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v3/query_range", body)
+
+			parsedQueryRangeParams, apiErr := ParseQueryRangeParams(req)
+			if tc.expectErr {
+				require.Error(t, apiErr)
+				require.Contains(t, apiErr.Error(), tc.errMsg)
+			} else {
+				require.Nil(t, apiErr)
+				require.Equal(t, tc.expectedQuery, parsedQueryRangeParams.CompositeQuery.ClickHouseQueries["A"].Query)
+			}
+		})
+	}
+}
+
 func TestQueryRangeFormula(t *testing.T) {
 	reqCases := []struct {
 		desc           string
@@ -1225,3 +1418,254 @@ func TestQueryRangeFormula(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGetDependencyCallsRequest(t *testing.T) {
+	recentEnd := time.Now().UnixNano()
+	reqCases := []struct {
+		desc      string
+		body      string
+		expectErr bool
+		limit     int
+		end       int64
+		tags      []model.TagQueryParam
+	}{
+		{
+			desc:  "default limit",
+			body:  `{"start": "1700000000000000000", "end": "1700003600000000000", "service": "frontend"}`,
+			limit: 100,
+			end:   1700003600000000000,
+		},
+		{
+			desc:  "limit",
+			body:  `{"start": "1700000000000000000", "end": "1700003600000000000", "limit": 5}`,
+			limit: 5,
+			end:   1700003600000000000,
+		},
+		{
+			desc:  "negative limit",
+			body:  `{"start": "1700000000000000000", "end": "1700003600000000000", "limit": -1}`,
+			limit: 100,
+			end:   1700003600000000000,
+		},
+		{
+			desc:  "recent end leaves out the spans still being ingested",
+			body:  fmt.Sprintf(`{"start": "1700000000000000000", "end": "%d"}`, recentEnd),
+			limit: 100,
+			end:   recentEnd - 30*time.Second.Nanoseconds(),
+		},
+		{
+			desc: "tags",
+			body: `{"start": "1700000000000000000", "end": "1700003600000000000", "tags": [
+				{"key": "http.route", "tagType": "SpanAttribute", "stringValues": ["/cart"], "operator": "Equals"}
+			]}`,
+			limit: 100,
+			end:   1700003600000000000,
+			tags: []model.TagQueryParam{{
+				Key: "http.route", TagType: model.SpanAttributeTagType, StringValues: []string{"/cart"}, Operator: model.EqualOperator,
+			}},
+		},
+		{
+			desc:      "no start",
+			body:      `{"end": "1700003600000000000"}`,
+			expectErr: true,
+		},
+		{
+			desc:      "no end",
+			body:      `{"start": "1700000000000000000"}`,
+			expectErr: true,
+		},
+		{
+			desc:      "start not in nanoseconds",
+			body:      `{"start": "2023-11-14T22:13:20Z", "end": "1700003600000000000"}`,
+			expectErr: true,
+		},
+		{
+			desc:      "invalid tags",
+			body:      `{"start": "1700000000000000000", "end": "1700003600000000000", "tags": {"key": "http.route"}}`,
+			expectErr: true,
+		},
+	}
+
+	for _, reqCase := range reqCases {
+		t.Run(reqCase.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/service/database_calls", strings.NewReader(reqCase.body))
+			params, err := parseGetDependencyCallsRequest(r)
+			if reqCase.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, reqCase.limit, params.Limit)
+			require.Equal(t, int64(1700000000000000000), params.Start.UnixNano())
+			require.Equal(t, reqCase.end, params.End.UnixNano())
+			require.Equal(t, reqCase.tags, params.Tags)
+		})
+	}
+}
+
+func TestParseGetLatencyBreakdownRequest(t *testing.T) {
+	reqCases := []struct {
+		desc      string
+		body      string
+		expectErr bool
+		limit     int
+	}{
+		{
+			desc:  "valid request with default limit",
+			body:  `{"start": "1700000000000000000", "end": "1700003600000000000", "service": "frontend", "operation": "GET /cart", "attribute": "db.system"}`,
+			limit: 20,
+		},
+		{
+			desc:  "valid request with limit",
+			body:  `{"start": "1700000000000000000", "end": "1700003600000000000", "service": "frontend", "operation": "GET /cart", "attribute": "peer.service", "limit": 5}`,
+			limit: 5,
+		},
+		{
+			desc:      "no operation",
+			body:      `{"start": "1700000000000000000", "end": "1700003600000000000", "service": "frontend", "attribute": "db.system"}`,
+			expectErr: true,
+		},
+		{
+			desc:      "no attribute",
+			body:      `{"start": "1700000000000000000", "end": "1700003600000000000", "service": "frontend", "operation": "GET /cart"}`,
+			expectErr: true,
+		},
+		{
+			desc:      "no start",
+			body:      `{"end": "1700003600000000000", "service": "frontend", "operation": "GET /cart", "attribute": "db.system"}`,
+			expectErr: true,
+		},
+	}
+
+	for _, reqCase := range reqCases {
+		t.Run(reqCase.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/service/latency_breakdown", strings.NewReader(reqCase.body))
+			params, err := parseGetLatencyBreakdownRequest(r)
+			if reqCase.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, reqCase.limit, params.Limit)
+			require.Equal(t, int64(1700000000000000000), params.Start.UnixNano())
+		})
+	}
+}
+
+func TestParseSessionTimelineParams(t *testing.T) {
+	require := require.New(t)
+
+	request := func(id string, query string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/sessions/"+id+"/timeline?"+query, nil)
+		return mux.SetURLVars(r, map[string]string{"id": id})
+	}
+
+	params, err := parseSessionTimelineParams(request("session1", "start=1000&end=5000&limit=10"))
+	require.Nil(err)
+	require.Equal("session1", params.SessionId)
+	require.Equal(time.UnixMilli(1000), params.Start)
+	require.Equal(time.UnixMilli(5000), params.End)
+	require.Equal(10, params.Limit)
+
+	params, err = parseSessionTimelineParams(request("session1", "end=86400000"))
+	require.Nil(err)
+	require.Equal(time.UnixMilli(0), params.Start, "the last day is looked at by default")
+	require.Equal(defaultSessionTimelineLimit, params.Limit)
+
+	for _, query := range []string{"start=5000&end=1000", "end=now", "limit=0", "limit=100000"} {
+		_, err := parseSessionTimelineParams(request("session1", query))
+		require.NotNil(err, query)
+	}
+	_, err = parseSessionTimelineParams(request("", ""))
+	require.NotNil(err, "the session id is required")
+}
+
+func TestParseSpanFieldSelection(t *testing.T) {
+	require := require.New(t)
+
+	parse := func(query string) (*model.SpanFieldSelection, error) {
+		return ParseSpanFieldSelection(httptest.NewRequest(http.MethodGet, "/api/v1/traces/trace1?"+query, nil))
+	}
+
+	selection, err := parse("spanId=span1")
+	require.Nil(err)
+	require.Nil(selection, "all the fields are returned by default")
+
+	selection, err = parse("fields=events,%20references")
+	require.Nil(err)
+	require.Equal(map[string]bool{model.SpanFieldEvents: true, model.SpanFieldReferences: true}, selection.Fields)
+	require.Nil(selection.Attributes)
+
+	selection, err = parse("attributes=http.method,host.name")
+	require.Nil(err)
+	require.Len(selection.Fields, 3)
+	require.Equal(map[string]bool{"http.method": true, "host.name": true}, selection.Attributes)
+
+	selection, err = parse("fields=&attributes=http.method")
+	require.Nil(err)
+	require.Equal(map[string]bool{model.SpanFieldAttributes: true}, selection.Fields)
+
+	_, err = parse("fields=attributes,logs")
+	require.NotNil(err)
+}
+
+func TestProjectSearchSpansResult(t *testing.T) {
+	require := require.New(t)
+
+	newResult := func() *model.SearchSpansResult {
+		return &model.SearchSpansResult{
+			Columns: []string{"__time", "SpanId", "TraceId", "ServiceName", "Name", "Kind", "DurationNano", "TagsKeys", "TagsValues", "References", "Events", "HasError"},
+			Events: [][]interface{}{{
+				uint64(1), "span1", "trace1", "frontend", "GET /", int8(2), uint64(10),
+				[]string{"http.method", "host.name", "user.id"}, []string{"GET", "host1", "42"},
+				[]string{"{TraceId=trace1, SpanId=span0, RefType=CHILD_OF}"}, []string{`{"name":"exception"}`},
+				false,
+			}},
+		}
+	}
+
+	result := newResult()
+	result.Project(nil)
+	require.Equal(newResult(), result)
+
+	selection, err := ParseSpanFieldSelection(httptest.NewRequest(http.MethodGet, "/?fields=references&attributes=host.name", nil))
+	require.Nil(err)
+	result = newResult()
+	result.Project(selection)
+	require.Len(result.Columns, 12, "the columns are kept")
+	span := result.Events[0]
+	require.Equal("span1", span[1])
+	require.Equal([]string{"host.name"}, span[7])
+	require.Equal([]string{"host1"}, span[8])
+	require.Equal([]string{"{TraceId=trace1, SpanId=span0, RefType=CHILD_OF}"}, span[9])
+	require.Equal([]string{}, span[10])
+
+	result = newResult()
+	result.Project(&model.SpanFieldSelection{Fields: map[string]bool{model.SpanFieldEvents: true}})
+	span = result.Events[0]
+	require.Equal([]string{}, span[7])
+	require.Equal([]string{}, span[8])
+	require.Equal([]string{}, span[9])
+	require.Equal([]string{`{"name":"exception"}`}, span[10])
+}
+
+func TestParseRefreshAttributesParams(t *testing.T) {
+	require := require.New(t)
+
+	parse := func(body string) (*model.RefreshAttributesParams, error) {
+		return parseRefreshAttributesParams(httptest.NewRequest(http.MethodPost, "/api/v1/admin/attributes/refresh", strings.NewReader(body)))
+	}
+
+	params, err := parse("")
+	require.Nil(err)
+	require.Equal(defaultAttributesRefreshLookbackHours, params.LookbackHours)
+
+	params, err = parse(`{"lookbackHours": 72}`)
+	require.Nil(err)
+	require.Equal(72, params.LookbackHours)
+
+	for _, body := range []string{`{"lookbackHours": -1}`, `{"lookbackHours": 10000}`, `{"lookbackHours": "1d"}`} {
+		_, err := parse(body)
+		require.NotNil(err, body)
+	}
+}
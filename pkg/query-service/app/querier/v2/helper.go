@@ -312,3 +312,54 @@ func (q *querier) runBuilderExpression(
 		}
 	}
 }
+
+// castFailureCounts holds the cast failures of the builder queries by query
+// name, the counts are made alongside the queries
+type castFailureCounts struct {
+	mtx    sync.Mutex
+	counts map[string]uint64
+}
+
+func newCastFailureCounts() *castFailureCounts {
+	return &castFailureCounts{counts: map[string]uint64{}}
+}
+
+func (q *querier) countCastFailures(
+	ctx context.Context, queryName string, builderQuery *v3.BuilderQuery, params *v3.QueryRangeParamsV3,
+	castFailures *castFailureCounts, wg *sync.WaitGroup,
+) {
+	defer wg.Done()
+	count, err := q.logsCastFailures(ctx, builderQuery, params)
+	if err != nil {
+		// the result is still usable without the failure count
+		zap.S().Error("error counting cast failures", zap.String("query", queryName), zap.Error(err))
+		return
+	}
+	castFailures.mtx.Lock()
+	defer castFailures.mtx.Unlock()
+	castFailures.counts[queryName] = count
+}
+
+// logsCastFailures counts the rows of a logs builder query whose aggregate
+// attribute value could not be cast to the requested type
+func (q *querier) logsCastFailures(ctx context.Context, builderQuery *v3.BuilderQuery, params *v3.QueryRangeParamsV3) (uint64, error) {
+	start := params.Start
+	end := params.End
+	if builderQuery.ShiftBy != 0 {
+		start = start - builderQuery.ShiftBy*1000
+		end = end - builderQuery.ShiftBy*1000
+	}
+
+	query, err := logsV3.PrepareLogsCastFailuresQuery(start, end, builderQuery)
+	if err != nil {
+		return 0, err
+	}
+	series, err := q.execClickHouseQuery(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	if len(series) == 0 || len(series[0].Points) == 0 {
+		return 0, nil
+	}
+	return uint64(series[0].Points[0].Value), nil
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
 	metricsV4 "go.signoz.io/signoz/pkg/query-service/app/metrics/v4"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
@@ -275,10 +276,15 @@ func (q *querier) runBuilderQueries(ctx context.Context, params *v3.QueryRangePa
 	ch := make(chan channelResult, len(params.CompositeQuery.BuilderQueries))
 	var wg sync.WaitGroup
 
+	castFailures := newCastFailureCounts()
 	for queryName, builderQuery := range params.CompositeQuery.BuilderQueries {
 		if queryName == builderQuery.Expression {
 			wg.Add(1)
 			go q.runBuilderQuery(ctx, builderQuery, params, keys, cacheKeys, ch, &wg)
+			if builderQuery.CastAggregateAttribute != "" {
+				wg.Add(1)
+				go q.countCastFailures(ctx, queryName, builderQuery, params, castFailures, &wg)
+			}
 		}
 	}
 
@@ -295,10 +301,17 @@ func (q *querier) runBuilderQueries(ctx context.Context, params *v3.QueryRangePa
 			errQueriesByName[result.Name] = result.Err.Error()
 			continue
 		}
-		results = append(results, &v3.Result{
+		res := &v3.Result{
 			QueryName: result.Name,
 			Series:    result.Series,
-		})
+		}
+		if builderQuery, ok := params.CompositeQuery.BuilderQueries[result.Name]; ok && builderQuery.GroupByRollup {
+			queryBuilder.ApplyRollupLevels(res.Series, builderQuery.GroupBy)
+		}
+		if count, ok := castFailures.counts[result.Name]; ok {
+			res.Meta = &v3.ResultMeta{CastFailures: count}
+		}
+		results = append(results, res)
 	}
 
 	var err error
@@ -403,7 +416,11 @@ func (q *querier) runClickHouseQueries(ctx context.Context, params *v3.QueryRang
 		wg.Add(1)
 		go func(queryName string, clickHouseQuery *v3.ClickHouseQuery) {
 			defer wg.Done()
-			series, err := q.execClickHouseQuery(ctx, clickHouseQuery.Query)
+			queryCtx := ctx
+			if len(clickHouseQuery.Parameters) > 0 {
+				queryCtx = clickhouse.Context(ctx, clickhouse.WithParameters(clickHouseQuery.Parameters))
+			}
+			series, err := q.execClickHouseQuery(queryCtx, clickHouseQuery.Query)
 			channelResults <- channelResult{Err: err, Name: queryName, Query: clickHouseQuery.Query, Series: series}
 		}(queryName, clickHouseQuery)
 	}
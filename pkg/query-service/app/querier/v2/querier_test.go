@@ -0,0 +1,81 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// concurrentQueriesReader answers the queries only once the expected number
+// of queries are running at the same time
+type concurrentQueriesReader struct {
+	interfaces.Reader
+	running sync.WaitGroup
+}
+
+func (r *concurrentQueriesReader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
+	r.running.Done()
+	allRunning := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(allRunning)
+	}()
+	select {
+	case <-allRunning:
+	case <-time.After(5 * time.Second):
+		return nil, fmt.Errorf("the queries were not run concurrently")
+	}
+
+	value := 10.0
+	if strings.Contains(query, "countIf(isNull(") {
+		value = 3
+	}
+	return []*v3.Series{{Points: []v3.Point{{Timestamp: 1675115596722, Value: value}}}}, nil
+}
+
+func TestQueryRangeCastFailures(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start:   1675115596722,
+		End:     1675115596722 + 120*60*1000,
+		Step:    5 * time.Minute.Milliseconds(),
+		NoCache: true,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:              "A",
+					StepInterval:           60,
+					DataSource:             v3.DataSourceLogs,
+					AggregateAttribute:     v3.AttributeKey{Key: "duration", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+					CastAggregateAttribute: v3.AttributeKeyDataTypeFloat64,
+					AggregateOperator:      v3.AggregateOperatorSum,
+					Expression:             "A",
+				},
+			},
+		},
+	}
+	reader := &concurrentQueriesReader{}
+	// the cast failures are counted alongside the query
+	reader.running.Add(2)
+	q := NewQuerier(QuerierOptions{
+		Reader:       reader,
+		FluxInterval: 5 * time.Minute,
+		KeyGenerator: queryBuilder.NewKeyGenerator(),
+	})
+
+	results, err, errByName := q.QueryRange(context.Background(), params, nil)
+	require.Nil(t, err, errByName)
+	require.Len(t, results, 1)
+	require.Equal(t, 10.0, results[0].Series[0].Points[0].Value)
+	require.NotNil(t, results[0].Meta)
+	require.Equal(t, uint64(3), results[0].Meta.CastFailures)
+}
@@ -2,9 +2,12 @@ package queryBuilder
 
 import (
 	"math"
+	"regexp"
 	"sort"
+	"strings"
 
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"golang.org/x/exp/slices"
 )
 
 // funcCutOffMin cuts off values below the threshold and replaces them with NaN
@@ -287,6 +290,22 @@ func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
 			return result
 		}
 		return funcTimeShift(result, shift)
+	case v3.FunctionNameLabelReplace:
+		args, err := fn.StringArgs()
+		if err != nil || len(args) != 4 {
+			return result
+		}
+		regex, err := regexp.Compile("^(?:" + args[3] + ")$")
+		if err != nil {
+			return result
+		}
+		return funcLabelReplace(result, args[0], args[1], args[2], regex)
+	case v3.FunctionNameLabelJoin:
+		args, err := fn.StringArgs()
+		if err != nil || len(args) < 3 {
+			return result
+		}
+		return funcLabelJoin(result, args[0], args[1], args[2:])
 	}
 	return result
 }
@@ -299,3 +318,71 @@ func funcTimeShift(result *v3.Result, shift float64) *v3.Result {
 	}
 	return result
 }
+
+// funcLabelReplace sets the destination label to the replacement, expanded
+// with the groups of the regex, of series where the regex matches the
+// source label. An empty replacement removes the destination label.
+func funcLabelReplace(result *v3.Result, destination, replacement, source string, regex *regexp.Regexp) *v3.Result {
+	for _, series := range result.Series {
+		value := series.Labels[source]
+		match := regex.FindStringSubmatchIndex(value)
+		if match == nil {
+			continue
+		}
+		replaced := regex.ExpandString(nil, replacement, value, match)
+		setSeriesLabel(series, destination, string(replaced))
+	}
+	return result
+}
+
+// funcLabelJoin sets the destination label to the values of the source
+// labels joined by the separator
+func funcLabelJoin(result *v3.Result, destination, separator string, sources []string) *v3.Result {
+	for _, series := range result.Series {
+		values := make([]string, len(sources))
+		for idx, source := range sources {
+			values[idx] = series.Labels[source]
+		}
+		setSeriesLabel(series, destination, strings.Join(values, separator))
+	}
+	return result
+}
+
+// setSeriesLabel sets a label in both the labels and the labels array of a
+// series, removing it if the value is empty
+func setSeriesLabel(series *v3.Series, name, value string) {
+	if series.Labels == nil {
+		series.Labels = map[string]string{}
+	}
+	labelsArray := make([]map[string]string, 0, len(series.LabelsArray)+1)
+	for _, labels := range series.LabelsArray {
+		if _, ok := labels[name]; !ok {
+			labelsArray = append(labelsArray, labels)
+		}
+	}
+
+	if value == "" {
+		delete(series.Labels, name)
+	} else {
+		series.Labels[name] = value
+		labelsArray = append(labelsArray, map[string]string{name: value})
+	}
+	series.LabelsArray = labelsArray
+}
+
+// LabelFunctionKeys returns the labels of the series of a query grouped by
+// keys once its label functions are applied, which formulas join them on
+func LabelFunctionKeys(functions []v3.Function, keys []string) []string {
+	labels := append([]string{}, keys...)
+	for _, fn := range functions {
+		if fn.Name != v3.FunctionNameLabelReplace && fn.Name != v3.FunctionNameLabelJoin {
+			continue
+		}
+		args, err := fn.StringArgs()
+		if err != nil || len(args) == 0 || slices.Contains(labels, args[0]) {
+			continue
+		}
+		labels = append(labels, args[0])
+	}
+	return labels
+}
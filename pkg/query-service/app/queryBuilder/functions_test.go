@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -602,3 +603,49 @@ func TestFuncMedian5(t *testing.T) {
 		}
 	}
 }
+
+func TestLabelFunctions(t *testing.T) {
+	series := func(labels map[string]string) *v3.Series {
+		labelsArray := []map[string]string{}
+		for k, v := range labels {
+			labelsArray = append(labelsArray, map[string]string{k: v})
+		}
+		return &v3.Series{Labels: labels, LabelsArray: labelsArray, Points: []v3.Point{{Timestamp: 1, Value: 1}}}
+	}
+	result := &v3.Result{
+		Series: []*v3.Series{
+			series(map[string]string{"pod_name": "checkout-6d4f", "namespace": "shop"}),
+			series(map[string]string{"pod_name": "", "namespace": "infra"}),
+			series(map[string]string{"pod": "stale", "pod_name": "", "namespace": "infra"}),
+		},
+	}
+
+	result = ApplyFunction(v3.Function{
+		Name: v3.FunctionNameLabelReplace,
+		Args: []interface{}{"pod", "$1", "pod_name", "(.+)"},
+	}, result)
+	require.Equal(t, "checkout-6d4f", result.Series[0].Labels["pod"])
+	require.NotContains(t, result.Series[1].Labels, "pod", "series not matching the regex should not change")
+	require.Equal(t, "stale", result.Series[2].Labels["pod"])
+
+	result = ApplyFunction(v3.Function{
+		Name: v3.FunctionNameLabelReplace,
+		Args: []interface{}{"pod", "", "namespace", "infra"},
+	}, result)
+	require.NotContains(t, result.Series[2].Labels, "pod", "an empty replacement should remove the label")
+	require.NotContains(t, result.Series[2].LabelsArray, map[string]string{"pod": "stale"})
+
+	result = ApplyFunction(v3.Function{
+		Name: v3.FunctionNameLabelJoin,
+		Args: []interface{}{"workload", "/", "namespace", "pod"},
+	}, result)
+	require.Equal(t, "shop/checkout-6d4f", result.Series[0].Labels["workload"])
+	require.Contains(t, result.Series[0].LabelsArray, map[string]string{"workload": "shop/checkout-6d4f"})
+	require.Equal(t, "infra/", result.Series[1].Labels["workload"])
+
+	require.Equal(t, []string{"pod_name", "pod", "workload"}, LabelFunctionKeys([]v3.Function{
+		{Name: v3.FunctionNameLabelReplace, Args: []interface{}{"pod", "$1", "pod_name", "(.+)"}},
+		{Name: v3.FunctionNameCumSum},
+		{Name: v3.FunctionNameLabelJoin, Args: []interface{}{"workload", "/", "namespace", "pod"}},
+	}, []string{"pod_name"}))
+}
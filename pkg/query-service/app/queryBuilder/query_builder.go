@@ -350,6 +350,10 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 				parts = append(parts, fmt.Sprintf("aggregateAttribute=%s", query.AggregateAttribute.CacheKey()))
 			}
 
+			if query.CastAggregateAttribute != "" {
+				parts = append(parts, fmt.Sprintf("cast=%s", query.CastAggregateAttribute))
+			}
+
 			if query.Filters != nil && len(query.Filters.Items) > 0 {
 				for idx, filter := range query.Filters.Items {
 					parts = append(parts, fmt.Sprintf("filter-%d=%s", idx, filter.CacheKey()))
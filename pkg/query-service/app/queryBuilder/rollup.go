@@ -0,0 +1,38 @@
+package queryBuilder
+
+import (
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// ApplyRollupLevels removes the group by keys that the series of a rollup
+// query are subtotals over from their labels, so that a subtotal can be told
+// apart from a series grouped by an empty value. The internal label holding
+// the level of a series is removed as well.
+func ApplyRollupLevels(series []*v3.Series, groupBy []v3.AttributeKey) {
+	for _, s := range series {
+		level, err := strconv.Atoi(s.Labels[constants.RollupLevelLabel])
+		if err != nil || level > len(groupBy) {
+			level = len(groupBy)
+		}
+
+		rolledUp := map[string]bool{constants.RollupLevelLabel: true}
+		delete(s.Labels, constants.RollupLevelLabel)
+		for _, key := range groupBy[level:] {
+			rolledUp[key.Key] = true
+			delete(s.Labels, key.Key)
+		}
+		labelsArray := []map[string]string{}
+		for _, labels := range s.LabelsArray {
+			for key := range labels {
+				if !rolledUp[key] {
+					labelsArray = append(labelsArray, labels)
+				}
+				break
+			}
+		}
+		s.LabelsArray = labelsArray
+	}
+}
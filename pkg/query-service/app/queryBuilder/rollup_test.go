@@ -0,0 +1,50 @@
+package queryBuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestApplyRollupLevels(t *testing.T) {
+	groupBy := []v3.AttributeKey{{Key: "k8s.cluster.name"}, {Key: "k8s.namespace.name"}, {Key: "k8s.pod.name"}}
+
+	row := func(level string, values ...string) *v3.Series {
+		labels := map[string]string{constants.RollupLevelLabel: level}
+		labelsArray := []map[string]string{}
+		for i, key := range groupBy {
+			labels[key.Key] = values[i]
+			labelsArray = append(labelsArray, map[string]string{key.Key: values[i]})
+		}
+		labelsArray = append(labelsArray, map[string]string{constants.RollupLevelLabel: level})
+		return &v3.Series{Labels: labels, LabelsArray: labelsArray}
+	}
+
+	series := []*v3.Series{
+		row("3", "prod", "api", ""),
+		row("2", "prod", "api", ""),
+		row("1", "prod", "", ""),
+		row("0", "", "", ""),
+	}
+	ApplyRollupLevels(series, groupBy)
+
+	// an empty value of a key the series is grouped by is kept
+	require.Equal(t, map[string]string{
+		"k8s.cluster.name":   "prod",
+		"k8s.namespace.name": "api",
+		"k8s.pod.name":       "",
+	}, series[0].Labels)
+	require.Len(t, series[0].LabelsArray, 3)
+	require.Equal(t, map[string]string{
+		"k8s.cluster.name":   "prod",
+		"k8s.namespace.name": "api",
+	}, series[1].Labels)
+	require.Equal(t, []map[string]string{
+		{"k8s.cluster.name": "prod"}, {"k8s.namespace.name": "api"},
+	}, series[1].LabelsArray)
+	require.Equal(t, map[string]string{"k8s.cluster.name": "prod"}, series[2].Labels)
+	require.Empty(t, series[3].Labels, "the level of the series is internal")
+	require.Empty(t, series[3].LabelsArray)
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// wantsQueryRangeCSV tells whether a query range request asks for its
+// results exported as CSV
+func wantsQueryRangeCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" ||
+		strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// queryRangeCSVRecords flattens query results into CSV records, a header
+// then a record per point of the series and per row of the lists. The
+// columns are the query name, the timestamp in milliseconds, the value of
+// the points and the labels of the series and data of the rows, by name.
+func queryRangeCSVRecords(results []*v3.Result) [][]string {
+	columnSet := map[string]struct{}{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for key := range series.Labels {
+				columnSet[key] = struct{}{}
+			}
+		}
+		for _, row := range result.List {
+			for key := range row.Data {
+				columnSet[key] = struct{}{}
+			}
+		}
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	records := [][]string{append([]string{"query", "timestamp", "value"}, columns...)}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for _, point := range series.Points {
+				record := []string{
+					result.QueryName,
+					strconv.FormatInt(point.Timestamp, 10),
+					strconv.FormatFloat(point.Value, 'f', -1, 64),
+				}
+				for _, column := range columns {
+					record = append(record, series.Labels[column])
+				}
+				records = append(records, record)
+			}
+		}
+		for _, row := range result.List {
+			record := []string{result.QueryName, strconv.FormatInt(row.Timestamp.UnixMilli(), 10), ""}
+			for _, column := range columns {
+				record = append(record, csvCell(row.Data[column]))
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// csvCell formats a value of a row, the maps of attributes as JSON
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return strconv.FormatInt(v.UnixMilli(), 10)
+	case map[string]string, map[string]interface{}, map[string]float64, map[string]bool, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// exportQueryRangeCSV runs a composite query and responds with its results
+// as a CSV file. The results go through the same exec as the query range
+// API, so the obfuscation profiles apply to the exports too.
+func (aH *APIHandler) exportQueryRangeCSV(
+	ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, exec queryRangeExecFunc,
+) {
+	if queryRangeParams.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(queryRangeParams.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	result, _, errQueriesByName, apiErr := exec(ctx, queryRangeParams)
+	if apiErr != nil {
+		RespondError(w, apiErr, errQueriesByName)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="query_range.csv"`)
+	w.WriteHeader(http.StatusOK)
+	if err := csv.NewWriter(w).WriteAll(queryRangeCSVRecords(result)); err != nil {
+		zap.L().Error("error writing the query range csv export", zap.Error(err))
+	}
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/obfuscation"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// hostsQuerier returns a series of the db-1 host per prom query, with a
+// point at the start of the range queried
+type hostsQuerier struct{}
+
+func (q *hostsQuerier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3, _ map[string]v3.AttributeKey) ([]*v3.Result, error, map[string]string) {
+	results := []*v3.Result{}
+	for name := range params.CompositeQuery.PromQueries {
+		results = append(results, &v3.Result{
+			QueryName: name,
+			Series: []*v3.Series{{
+				Labels: map[string]string{"host.name": "db-1"},
+				Points: []v3.Point{{Timestamp: params.Start, Value: 1}},
+			}},
+		})
+	}
+	return results, nil, nil
+}
+
+func (q *hostsQuerier) QueriesExecuted() []string {
+	return []string{}
+}
+
+// temporalityReader knows the temporality of no metric
+type temporalityReader struct {
+	interfaces.Reader
+}
+
+func (r *temporalityReader) FetchTemporality(ctx context.Context, metricNames []string) (map[string]map[v3.Temporality]bool, error) {
+	return map[string]map[v3.Temporality]bool{}, nil
+}
+
+// newObfuscatingHandler returns a handler whose viewers get the host names
+// in the query results masked, and the context of such a viewer
+func newObfuscatingHandler(t *testing.T) (*APIHandler, context.Context) {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(t, err)
+	controller, err := obfuscation.NewController(db)
+	require.Nil(t, err)
+
+	auth.AuthCacheObj.AdminGroupId = "admins"
+	auth.AuthCacheObj.ViewerGroupId = "viewers"
+	adminJwt, err := auth.GenerateJWTForUser(&model.User{Id: "admin1", Email: "admin1@signoz.io"})
+	require.Nil(t, err)
+	adminRequest := httptest.NewRequest(http.MethodPost, "/api/v1/obfuscation_profiles", nil)
+	adminRequest.Header.Add("Authorization", "Bearer "+adminJwt.AccessJwt)
+	adminCtx := context.WithValue(
+		auth.AttachJwtToContext(context.Background(), adminRequest),
+		constants.ContextUserKey, &model.UserPayload{User: model.User{Id: "admin1", GroupId: "admins"}},
+	)
+	_, apiErr := controller.CreateObfuscationProfile(adminCtx, &obfuscation.PostableObfuscationProfile{
+		Name: "viewers", Spec: obfuscation.ProfileSpec{
+			MaskAttributes: []string{"host.name"}, EnforcedRoles: []string{constants.ViewerGroup},
+		},
+	})
+	require.Nil(t, apiErr)
+
+	viewerCtx := context.WithValue(
+		context.Background(), constants.ContextUserKey,
+		&model.UserPayload{User: model.User{Id: "viewer1", GroupId: "viewers"}},
+	)
+	querier := &hostsQuerier{}
+	return &APIHandler{
+		reader: &temporalityReader{}, querier: querier, querierV2: querier, ObfuscationController: controller,
+	}, viewerCtx
+}
+
+func promQueryRangeBody(start, end int64, extra string) string {
+	return fmt.Sprintf(`{
+		"start": %d, "end": %d, "step": 60, %s
+		"compositeQuery": {"queryType": "promql", "panelType": "graph", "promQueries": {"A": {"query": "up"}}}
+	}`, start, end, extra)
+}
+
+func TestQueryRangeCSVExport(t *testing.T) {
+	aH, viewerCtx := newObfuscatingHandler(t)
+	end := time.Now().UnixMilli()
+	start := end - time.Hour.Milliseconds()
+
+	for _, version := range []string{"v3", "v4"} {
+		t.Run(version, func(t *testing.T) {
+			handle := aH.QueryRangeV3
+			if version == "v4" {
+				handle = aH.QueryRangeV4
+			}
+			request := httptest.NewRequest(
+				http.MethodPost, "/api/"+version+"/query_range?format=csv",
+				strings.NewReader(promQueryRangeBody(start, end, "")),
+			).WithContext(viewerCtx)
+			w := httptest.NewRecorder()
+			handle(w, request)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			require.Nil(t, err)
+			require.Equal(t, [][]string{
+				{"query", "timestamp", "value", "host.name"},
+				{"A", fmt.Sprint(start), "1", "****"},
+			}, records, "the exports are obfuscated like the query results")
+		})
+	}
+}
+
+func TestQueryRangeCSVRecords(t *testing.T) {
+	require := require.New(t)
+	ts := time.UnixMilli(1700000000000)
+	records := queryRangeCSVRecords([]*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service.name": "api"}, Points: []v3.Point{{Timestamp: 1000, Value: 1.5}, {Timestamp: 2000, Value: 2}}},
+				{Labels: map[string]string{"service.name": "web", "env": "prod"}, Points: []v3.Point{{Timestamp: 1000, Value: 3}}},
+			},
+		},
+		{
+			QueryName: "B",
+			List: []*v3.Row{{Timestamp: ts, Data: map[string]interface{}{
+				"body": "login, then logout", "attributes_string": map[string]string{"user.id": "alice"},
+			}}},
+		},
+	})
+	require.Equal([][]string{
+		{"query", "timestamp", "value", "attributes_string", "body", "env", "service.name"},
+		{"A", "1000", "1.5", "", "", "", "api"},
+		{"A", "2000", "2", "", "", "", "api"},
+		{"A", "1000", "3", "", "", "prod", "web"},
+		{"B", "1700000000000", "", `{"user.id":"alice"}`, "login, then logout", "", ""},
+	}, records)
+
+	require.Equal([][]string{{"query", "timestamp", "value"}}, queryRangeCSVRecords(nil))
+}
+
+func TestComparedQueryRangeObfuscation(t *testing.T) {
+	require := require.New(t)
+	aH, viewerCtx := newObfuscatingHandler(t)
+	end := time.Now().UnixMilli()
+	start := end - time.Hour.Milliseconds()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v3/query_range/compare", strings.NewReader(
+		promQueryRangeBody(start, end, fmt.Sprintf(
+			`"compareStart": %d, "compareEnd": %d,`, start-24*time.Hour.Milliseconds(), end-24*time.Hour.Milliseconds(),
+		)),
+	)).WithContext(viewerCtx)
+	w := httptest.NewRecorder()
+	aH.QueryRangeV3Compare(w, request)
+	require.Equal(http.StatusOK, w.Code, w.Body.String())
+	require.Contains(w.Body.String(), `"host.name":"****"`)
+	require.NotContains(w.Body.String(), "db-1", "the compared results are obfuscated")
+}
+
+func TestSLOBudgetForecastObfuscation(t *testing.T) {
+	require := require.New(t)
+	aH, viewerCtx := newObfuscatingHandler(t)
+	end := time.Now().UnixMilli()
+	start := end - time.Hour.Milliseconds()
+
+	body := map[string]interface{}{}
+	require.Nil(json.Unmarshal([]byte(promQueryRangeBody(start, end, "")), &body))
+	body["obfuscationProfile"] = "missing"
+	body["slo"] = map[string]interface{}{
+		"target": 99.9, "windowStart": start, "windowEnd": end + time.Hour.Milliseconds(),
+		"goodQuery": "A", "totalQuery": "B",
+	}
+	encoded, err := json.Marshal(body)
+	require.Nil(err)
+	request := httptest.NewRequest(
+		http.MethodPost, "/api/v3/slo/budget_forecast", strings.NewReader(string(encoded)),
+	).WithContext(viewerCtx)
+	w := httptest.NewRecorder()
+	aH.SLOBudgetForecast(w, request)
+
+	// the forecast goes through the obfuscation of the query results, which
+	// rejects the profiles that don't exist
+	require.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+	require.Contains(w.Body.String(), "obfuscation profile missing not found")
+}
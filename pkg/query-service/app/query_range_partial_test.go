@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// partialQuerier fails the queries named in errs
+type partialQuerier struct {
+	errs     map[string]error
+	deadline time.Time
+}
+
+func (q *partialQuerier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3, _ map[string]v3.AttributeKey) ([]*v3.Result, error, map[string]string) {
+	q.deadline, _ = ctx.Deadline()
+	results := []*v3.Result{}
+	errQueriesByName := map[string]string{}
+	var err error
+	for name := range params.CompositeQuery.PromQueries {
+		if queryErr, ok := q.errs[name]; ok {
+			errQueriesByName[name] = queryErr.Error()
+			err = fmt.Errorf("error in query %s: %w", name, queryErr)
+			continue
+		}
+		results = append(results, &v3.Result{QueryName: name})
+	}
+	if err != nil {
+		return results, err, errQueriesByName
+	}
+	return results, nil, nil
+}
+
+func (q *partialQuerier) QueriesExecuted() []string {
+	return []string{}
+}
+
+func TestQueryRangePartialResults(t *testing.T) {
+	newParams := func(allowPartial bool, timeout int64) *v3.QueryRangeParamsV3 {
+		return &v3.QueryRangeParamsV3{
+			Start: 1000, End: 2000, Step: 60,
+			AllowPartial: allowPartial,
+			Timeout:      timeout,
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypePromQL,
+				PanelType: v3.PanelTypeGraph,
+				PromQueries: map[string]*v3.PromQuery{
+					"A": {Query: "up"},
+					"B": {Query: "rate(calls[5m])"},
+					"C": {Query: "rate(errors[5m])"},
+				},
+			},
+		}
+	}
+	querier := &partialQuerier{errs: map[string]error{
+		"B": fmt.Errorf("unknown metric"),
+		"C": context.DeadlineExceeded,
+	}}
+
+	for _, version := range []string{"v3", "v4"} {
+		t.Run(version, func(t *testing.T) {
+			require := require.New(t)
+			aH := &APIHandler{querier: querier, querierV2: querier}
+			queryRange := aH.queryRangeV3
+			if version == "v4" {
+				queryRange = aH.queryRangeV4
+			}
+			request := httptest.NewRequest(http.MethodPost, "/api/"+version+"/query_range", nil)
+
+			w := httptest.NewRecorder()
+			queryRange(context.Background(), newParams(false, 0), w, request)
+			require.Equal(http.StatusBadRequest, w.Code, "a failed query fails the request by default")
+			require.True(querier.deadline.IsZero())
+
+			w = httptest.NewRecorder()
+			start := time.Now()
+			queryRange(context.Background(), newParams(true, 5), w, request)
+			require.Equal(http.StatusOK, w.Code, w.Body.String())
+			require.WithinDuration(start.Add(5*time.Second), querier.deadline, time.Second)
+
+			var resp struct {
+				Data v3.QueryRangeResponse `json:"data"`
+			}
+			require.Nil(json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Len(resp.Data.Result, 1)
+			require.Equal("A", resp.Data.Result[0].QueryName)
+			require.Equal([]v3.QueryError{
+				{QueryName: "B", Error: "unknown metric"},
+				{QueryName: "C", Error: context.DeadlineExceeded.Error(), TimedOut: true},
+			}, resp.Data.Errors)
+		})
+	}
+
+	// there is nothing to respond with when all the queries fail
+	aH := &APIHandler{querierV2: &partialQuerier{errs: map[string]error{
+		"A": fmt.Errorf("unknown metric"), "B": fmt.Errorf("unknown metric"), "C": fmt.Errorf("unknown metric"),
+	}}}
+	w := httptest.NewRecorder()
+	aH.queryRangeV4(context.Background(), newParams(true, 0), w, httptest.NewRequest(http.MethodPost, "/api/v4/query_range", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestValidateQueryRangeTimeout(t *testing.T) {
+	require := require.New(t)
+
+	params := &v3.QueryRangeParamsV3{
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:   v3.QueryTypePromQL,
+			PanelType:   v3.PanelTypeGraph,
+			PromQueries: map[string]*v3.PromQuery{"A": {Query: "up"}},
+		},
+	}
+	require.Nil(validateQueryRangeParamsV3(params))
+	params.Timeout = 30
+	require.Nil(validateQueryRangeParamsV3(params))
+	params.Timeout = -1
+	require.NotNil(validateQueryRangeParamsV3(params))
+	params.Timeout = 100000
+	require.NotNil(validateQueryRangeParamsV3(params))
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SigNoz/govaluate"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+type queryRangeExecFunc func(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) (
+	[]*v3.Result, []v3.QueryError, map[string]string, *model.ApiError,
+)
+
+// wantsQueryRangeStream tells if the client asked for the results of a
+// query range request to be streamed as each of its queries completes
+func wantsQueryRangeStream(r *http.Request) bool {
+	return r.URL.Query().Get("stream") == "true" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// queryRangeUnits splits the queries of a composite query into the units
+// which can run on their own, a formula with the queries it uses and the
+// formulas using them. Units of disabled builder queries only are left out.
+func queryRangeUnits(compositeQuery *v3.CompositeQuery) ([][]string, error) {
+	parent := map[string]string{}
+	var find func(name string) string
+	find = func(name string) string {
+		if parent[name] != name {
+			parent[name] = find(parent[name])
+		}
+		return parent[name]
+	}
+	union := func(a, b string) {
+		rootA, rootB := find(a), find(b)
+		if rootA != rootB {
+			parent[rootB] = rootA
+		}
+	}
+
+	for name := range compositeQuery.BuilderQueries {
+		parent[name] = name
+	}
+	for name := range compositeQuery.ClickHouseQueries {
+		parent[name] = name
+	}
+	for name := range compositeQuery.PromQueries {
+		parent[name] = name
+	}
+
+	for name, query := range compositeQuery.BuilderQueries {
+		if query.Expression == "" || query.Expression == name {
+			continue
+		}
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(query.Expression, evalFuncs())
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of formula %s: %w", name, err)
+		}
+		for _, variable := range expression.Vars() {
+			if _, ok := compositeQuery.BuilderQueries[variable]; ok {
+				union(name, variable)
+			}
+		}
+	}
+
+	byRoot := map[string][]string{}
+	for name := range parent {
+		root := find(name)
+		byRoot[root] = append(byRoot[root], name)
+	}
+
+	units := [][]string{}
+	for _, names := range byRoot {
+		enabled := false
+		for _, name := range names {
+			query, ok := compositeQuery.BuilderQueries[name]
+			if !ok || !query.Disabled {
+				enabled = true
+				break
+			}
+		}
+		if !enabled {
+			continue
+		}
+		sort.Strings(names)
+		units = append(units, names)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i][0] < units[j][0] })
+	return units, nil
+}
+
+// queryRangeParamsOf returns the params of a request running only some of
+// its queries
+func queryRangeParamsOf(queryRangeParams *v3.QueryRangeParamsV3, names []string) *v3.QueryRangeParamsV3 {
+	params := *queryRangeParams
+	compositeQuery := *queryRangeParams.CompositeQuery
+	compositeQuery.BuilderQueries = map[string]*v3.BuilderQuery{}
+	compositeQuery.ClickHouseQueries = map[string]*v3.ClickHouseQuery{}
+	compositeQuery.PromQueries = map[string]*v3.PromQuery{}
+	// tables with calculated columns need the results of all the queries
+	compositeQuery.CalculatedColumns = nil
+
+	for _, name := range names {
+		if query, ok := queryRangeParams.CompositeQuery.BuilderQueries[name]; ok {
+			compositeQuery.BuilderQueries[name] = query
+		}
+		if query, ok := queryRangeParams.CompositeQuery.ClickHouseQueries[name]; ok {
+			compositeQuery.ClickHouseQueries[name] = query
+		}
+		if query, ok := queryRangeParams.CompositeQuery.PromQueries[name]; ok {
+			compositeQuery.PromQueries[name] = query
+		}
+	}
+	params.CompositeQuery = &compositeQuery
+	return &params
+}
+
+func writeStreamEvent(w http.ResponseWriter, flusher http.Flusher, event string, data interface{}) {
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		zap.L().Error("could not serialize query range stream event", zap.Error(err))
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, serialized)
+	flusher.Flush()
+}
+
+// streamQueryRange runs the independent queries of a composite query
+// concurrently and sends the results of each as a server sent "result"
+// event as soon as it completes, so that panels render incrementally. The
+// queries which fail are reported in the event of their results instead of
+// failing the request. An "end" event follows the results of all queries.
+func (aH *APIHandler) streamQueryRange(
+	ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, exec queryRangeExecFunc,
+) {
+	units, err := queryRangeUnits(queryRangeParams.CompositeQuery)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		RespondError(w, &model.ApiError{
+			Typ: model.ErrorStreamingNotSupported,
+			Err: fmt.Errorf("the response can't be streamed"),
+		}, nil)
+		return
+	}
+
+	if queryRangeParams.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(queryRangeParams.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	completed := make(chan v3.QueryRangeStreamResult, len(units))
+	for _, names := range units {
+		go func(names []string) {
+			completed <- runQueryRangeUnit(ctx, queryRangeParams, names, exec)
+		}(names)
+	}
+
+	end := v3.QueryRangeStreamEnd{}
+	results := []*v3.Result{}
+	for range units {
+		unitResult := <-completed
+		writeStreamEvent(w, flusher, "result", unitResult)
+		results = append(results, unitResult.Result...)
+		end.Errors = append(end.Errors, unitResult.Errors...)
+	}
+	sort.Slice(end.Errors, func(i, j int) bool {
+		return end.Errors[i].QueryName < end.Errors[j].QueryName
+	})
+
+	if len(queryRangeParams.CompositeQuery.CalculatedColumns) > 0 {
+		table, err := buildTable(results, queryRangeParams.CompositeQuery)
+		if err != nil {
+			end.TableError = err.Error()
+		}
+		end.Table = table
+	}
+
+	select {
+	case <-ctx.Done():
+		end.ContextTimeout = true
+		end.ContextTimeoutMessage = "result might contain incomplete data due to context timeout, for custom timeout set the timeout header eg:- timeout:120"
+	default:
+	}
+	writeStreamEvent(w, flusher, "end", end)
+}
+
+func runQueryRangeUnit(
+	ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, names []string, exec queryRangeExecFunc,
+) v3.QueryRangeStreamResult {
+	unitResult := v3.QueryRangeStreamResult{QueryNames: names, Result: []*v3.Result{}}
+
+	result, queryErrors, errQueriesByName, apiErr := exec(ctx, queryRangeParamsOf(queryRangeParams, names))
+	if apiErr == nil {
+		if result != nil {
+			unitResult.Result = result
+		}
+		unitResult.Errors = queryErrors
+		return unitResult
+	}
+
+	if len(errQueriesByName) > 0 {
+		unitResult.Errors = partialQueryErrors(errQueriesByName)
+		return unitResult
+	}
+	for _, name := range names {
+		unitResult.Errors = append(unitResult.Errors, v3.QueryError{
+			QueryName: name,
+			Error:     apiErr.Error(),
+			TimedOut:  strings.Contains(apiErr.Error(), context.DeadlineExceeded.Error()),
+		})
+	}
+	return unitResult
+}
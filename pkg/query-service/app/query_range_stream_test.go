@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestQueryRangeUnits(t *testing.T) {
+	cq := &v3.CompositeQuery{
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			"A":  {QueryName: "A", Expression: "A"},
+			"B":  {QueryName: "B", Expression: "B", Disabled: true},
+			"C":  {QueryName: "C", Expression: "C"},
+			"D":  {QueryName: "D", Expression: "D", Disabled: true},
+			"E":  {QueryName: "E", Expression: "E", Disabled: true},
+			"F1": {QueryName: "F1", Expression: "A / B * 100"},
+			"F2": {QueryName: "F2", Expression: "B + E"},
+		},
+		PromQueries: map[string]*v3.PromQuery{
+			"P": {Query: "up"},
+		},
+	}
+
+	units, err := queryRangeUnits(cq)
+	require.Nil(t, err)
+	require.Equal(t, [][]string{{"A", "B", "E", "F1", "F2"}, {"C"}, {"P"}}, units)
+
+	params := queryRangeParamsOf(&v3.QueryRangeParamsV3{CompositeQuery: cq}, units[1])
+	require.Len(t, params.CompositeQuery.BuilderQueries, 1)
+	require.Empty(t, params.CompositeQuery.PromQueries)
+	require.Len(t, cq.BuilderQueries, 7, "the params of the request are left as is")
+}
+
+func TestStreamQueryRange(t *testing.T) {
+	cq := &v3.CompositeQuery{
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			"A": {QueryName: "A", Expression: "A"},
+			"B": {QueryName: "B", Expression: "B"},
+		},
+	}
+	exec := func(ctx context.Context, params *v3.QueryRangeParamsV3) (
+		[]*v3.Result, []v3.QueryError, map[string]string, *model.ApiError,
+	) {
+		if _, ok := params.CompositeQuery.BuilderQueries["B"]; ok {
+			return nil, nil, nil, model.BadRequest(fmt.Errorf("unknown attribute"))
+		}
+		return []*v3.Result{{QueryName: "A"}}, nil, nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	aH := &APIHandler{}
+	aH.streamQueryRange(context.Background(), &v3.QueryRangeParamsV3{CompositeQuery: cq}, w, exec)
+
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	require.Len(t, events, 3)
+	require.True(t, strings.HasPrefix(events[0], "event: result\n"))
+	require.True(t, strings.HasPrefix(events[1], "event: result\n"))
+	require.Contains(t, events[0]+events[1], `"queryNames":["A"],"result":[{"queryName":"A"`)
+	require.Contains(t, events[0]+events[1], `"queryNames":["B"],"result":[],"errors":[{"queryName":"B","error":"unknown attribute"`)
+	require.Equal(t, "event: end\ndata: {\"errors\":[{\"queryName\":\"B\",\"error\":\"unknown attribute\",\"timedOut\":false}]}", events[2])
+}
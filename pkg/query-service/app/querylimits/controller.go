@@ -0,0 +1,136 @@
+package querylimits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ranges relative to now are computed by clients a little before the query
+// reaches us, so their start is allowed to be this much older than the limit
+const lookbackGrace = time.Minute
+
+// Controller manages the limits on how far back the users of each role can
+// query data, bounding the cost of exploratory queries
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create query lookback limits repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+func orgIdFromContext(ctx context.Context) (string, *model.ApiError) {
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return "", model.UnauthorizedError(fmt.Errorf("failed to get user from context"))
+	}
+	return user.OrgId, nil
+}
+
+// RoleOf returns the role of a user, the JWT only carries their group id
+func RoleOf(user *model.UserPayload) string {
+	switch {
+	case auth.IsAdmin(user):
+		return constants.AdminGroup
+	case auth.IsEditor(user):
+		return constants.EditorGroup
+	case auth.IsViewer(user):
+		return constants.ViewerGroup
+	}
+	return user.Role
+}
+
+// GetLimits lists the lookback limits of the org of the user in ctx
+func (c *Controller) GetLimits(ctx context.Context) ([]LookbackLimit, *model.ApiError) {
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return c.repo.list(ctx, orgId)
+}
+
+func (c *Controller) SetLimit(
+	ctx context.Context, postable *PostableLookbackLimit,
+) (*LookbackLimit, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	return c.repo.upsert(ctx, orgId, userId, postable)
+}
+
+// DeleteLimit lifts the lookback limit of a role
+func (c *Controller) DeleteLimit(ctx context.Context, role string) *model.ApiError {
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	return c.repo.delete(ctx, orgId, role)
+}
+
+// GetLimitOfUser returns the lookback limit of the role of a user, nil if
+// their role is not limited
+func (c *Controller) GetLimitOfUser(
+	ctx context.Context, user *model.UserPayload,
+) (*LookbackLimit, *model.ApiError) {
+	return c.repo.get(ctx, user.OrgId, RoleOf(user))
+}
+
+// EnsureWithinLimit errors if a query starting at start looks further back
+// than the limit of the role of the user in ctx
+func (c *Controller) EnsureWithinLimit(ctx context.Context, start time.Time) *model.ApiError {
+	// queries made without a user, e.g. by rules, are not limited
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return nil
+	}
+	role := RoleOf(user)
+
+	limit, apiErr := c.GetLimitOfUser(ctx, user)
+	if apiErr != nil {
+		return apiErr
+	}
+	if limit == nil {
+		return nil
+	}
+
+	maxLookback := time.Duration(limit.MaxLookbackHours) * time.Hour
+	lookback := time.Since(start)
+	if lookback <= maxLookback+lookbackGrace {
+		return nil
+	}
+
+	return &model.ApiError{
+		Typ: model.ErrorForbidden,
+		Err: fmt.Errorf(
+			"users with the %s role can query at most the last %d hours of data, the query starts %d hours ago. Narrow the time range or ask an admin to raise the limit",
+			role, limit.MaxLookbackHours, int(lookback.Hours()),
+		),
+	}
+}
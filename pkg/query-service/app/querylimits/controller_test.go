@@ -0,0 +1,82 @@
+package querylimits
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func newTestController(t *testing.T) *Controller {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	if err != nil {
+		t.Fatalf("could not create temp file for test db: %v", err)
+	}
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+
+	testDB, err := sqlx.Open("sqlite3", testDBFilePath)
+	if err != nil {
+		t.Fatalf("could not open test db sqlite file: %v", err)
+	}
+
+	controller, err := NewController(testDB)
+	if err != nil {
+		t.Fatalf("could not create query limits controller: %v", err)
+	}
+	return controller
+}
+
+func TestLookbackLimitEnforcement(t *testing.T) {
+	require := require.New(t)
+	controller := newTestController(t)
+
+	auth.AuthCacheObj.AdminGroupId = "admins"
+	auth.AuthCacheObj.ViewerGroupId = "viewers"
+
+	viewerCtx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "viewer1", OrgId: "org1", GroupId: "viewers"},
+		},
+	)
+	adminCtx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "admin1", OrgId: "org1", GroupId: "admins"},
+		},
+	)
+
+	monthAgo := time.Now().Add(-30 * 24 * time.Hour)
+	require.Nil(controller.EnsureWithinLimit(viewerCtx, monthAgo), "no limit should mean no bound")
+
+	_, apiErr := controller.repo.upsert(adminCtx, "org1", "admin1", &PostableLookbackLimit{
+		Role: constants.ViewerGroup, MaxLookbackHours: 7 * 24,
+	})
+	require.Nil(apiErr)
+
+	apiErr = controller.EnsureWithinLimit(viewerCtx, monthAgo)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorForbidden, apiErr.Type())
+	require.Contains(apiErr.Error(), "at most the last 168 hours")
+
+	require.Nil(controller.EnsureWithinLimit(viewerCtx, time.Now().Add(-7*24*time.Hour)))
+	require.Nil(controller.EnsureWithinLimit(adminCtx, monthAgo))
+	require.Nil(controller.EnsureWithinLimit(context.Background(), monthAgo))
+
+	limits, apiErr := controller.GetLimits(adminCtx)
+	require.Nil(apiErr)
+	require.Len(limits, 1)
+
+	require.Nil(controller.DeleteLimit(adminCtx, constants.ViewerGroup))
+	require.Nil(controller.EnsureWithinLimit(viewerCtx, monthAgo))
+
+	require.NotNil((&PostableLookbackLimit{Role: "GUEST", MaxLookbackHours: 1}).IsValid())
+	require.NotNil((&PostableLookbackLimit{Role: constants.ViewerGroup}).IsValid())
+}
@@ -0,0 +1,38 @@
+package querylimits
+
+import (
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"golang.org/x/exp/slices"
+)
+
+var Roles = []string{
+	constants.AdminGroup, constants.EditorGroup, constants.ViewerGroup,
+}
+
+// LookbackLimit bounds how far back the users of a role in an org can query
+// data. Roles without a limit can query all the data retained.
+type LookbackLimit struct {
+	OrgId            string    `json:"orgId" db:"org_id"`
+	Role             string    `json:"role" db:"role"`
+	MaxLookbackHours int       `json:"maxLookbackHours" db:"max_lookback_hours"`
+	UpdatedBy        string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt        time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type PostableLookbackLimit struct {
+	Role             string `json:"role"`
+	MaxLookbackHours int    `json:"maxLookbackHours"`
+}
+
+func (p *PostableLookbackLimit) IsValid() error {
+	if !slices.Contains(Roles, p.Role) {
+		return fmt.Errorf("unknown role %s, must be one of %v", p.Role, Roles)
+	}
+	if p.MaxLookbackHours < 1 {
+		return fmt.Errorf("max lookback must be at least an hour")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package querylimits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS query_lookback_limits(
+			org_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			max_lookback_hours INTEGER NOT NULL,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(org_id, role)
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure query lookback limits schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for query lookback limits: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context, orgId string) ([]LookbackLimit, *model.ApiError) {
+	limits := []LookbackLimit{}
+
+	err := r.db.SelectContext(ctx, &limits, `
+		SELECT org_id, role, max_lookback_hours, updated_by, updated_at
+		FROM query_lookback_limits
+		WHERE org_id = $1
+		ORDER BY role
+	`, orgId)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookback limits: %w", err,
+		))
+	}
+	return limits, nil
+}
+
+func (r *Repo) get(ctx context.Context, orgId string, role string) (*LookbackLimit, *model.ApiError) {
+	limits := []LookbackLimit{}
+
+	err := r.db.SelectContext(ctx, &limits, `
+		SELECT org_id, role, max_lookback_hours, updated_by, updated_at
+		FROM query_lookback_limits
+		WHERE org_id = $1 AND role = $2
+	`, orgId, role)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query lookback limit of %s role: %w", role, err,
+		))
+	}
+
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	return &limits[0], nil
+}
+
+func (r *Repo) upsert(
+	ctx context.Context, orgId string, userId string, postable *PostableLookbackLimit,
+) (*LookbackLimit, *model.ApiError) {
+	limit := &LookbackLimit{
+		OrgId:            orgId,
+		Role:             postable.Role,
+		MaxLookbackHours: postable.MaxLookbackHours,
+		UpdatedBy:        userId,
+		UpdatedAt:        time.Now(),
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO query_lookback_limits (org_id, role, max_lookback_hours, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT(org_id, role) DO UPDATE SET
+			max_lookback_hours = excluded.max_lookback_hours,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, limit.OrgId, limit.Role, limit.MaxLookbackHours, limit.UpdatedBy, limit.UpdatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not save lookback limit of %s role: %w", limit.Role, err,
+		))
+	}
+
+	return limit, nil
+}
+
+func (r *Repo) delete(ctx context.Context, orgId string, role string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM query_lookback_limits WHERE org_id = $1 AND role = $2
+	`, orgId, role)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete lookback limit of %s role: %w", role, err,
+		))
+	}
+	return nil
+}
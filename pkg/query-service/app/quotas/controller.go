@@ -0,0 +1,146 @@
+package quotas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Controller manages the quotas capping how many dashboards, rules,
+// pipelines and saved views an org can create. These objects are not scoped
+// to orgs in the store, so the count of all of them is checked against the
+// quotas of the org of the user creating them.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create org quotas repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+func orgIdFromContext(ctx context.Context) (string, *model.ApiError) {
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return "", model.UnauthorizedError(fmt.Errorf("failed to get user from context"))
+	}
+	return user.OrgId, nil
+}
+
+// GetUsage reports the usage of every resource against the quotas of the
+// org of the user in ctx
+func (c *Controller) GetUsage(ctx context.Context) ([]QuotaUsage, *model.ApiError) {
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	quotas, apiErr := c.repo.list(ctx, orgId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	limits := map[Resource]int{}
+	for _, q := range quotas {
+		limits[q.Resource] = q.Limit
+	}
+
+	usage := []QuotaUsage{}
+	for _, resource := range Resources {
+		used, apiErr := c.repo.count(ctx, resource)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		u := QuotaUsage{Resource: resource, Used: used}
+		if limit, ok := limits[resource]; ok {
+			u.Limit = &limit
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+func (c *Controller) SetQuota(ctx context.Context, postable *PostableQuota) (*Quota, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	return c.repo.upsert(ctx, orgId, userId, postable)
+}
+
+// DeleteQuota lifts the quota of a resource
+func (c *Controller) DeleteQuota(ctx context.Context, resource Resource) *model.ApiError {
+	orgId, apiErr := orgIdFromContext(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	return c.repo.delete(ctx, orgId, resource)
+}
+
+// EnsureCanCreate errors if creating count more objects of a resource would
+// go over the quota of the org of the user in ctx
+func (c *Controller) EnsureCanCreate(ctx context.Context, resource Resource, count int) *model.ApiError {
+	used, apiErr := c.repo.count(ctx, resource)
+	if apiErr != nil {
+		return apiErr
+	}
+	return c.ensureWithinQuota(ctx, resource, used, used+count)
+}
+
+// EnsureWithinQuota errors if having total objects of a resource would go
+// over the quota of the org of the user in ctx. It is meant for resources
+// which get replaced as a whole, like pipelines.
+func (c *Controller) EnsureWithinQuota(ctx context.Context, resource Resource, total int) *model.ApiError {
+	used, apiErr := c.repo.count(ctx, resource)
+	if apiErr != nil {
+		return apiErr
+	}
+	return c.ensureWithinQuota(ctx, resource, used, total)
+}
+
+func (c *Controller) ensureWithinQuota(
+	ctx context.Context, resource Resource, used int, total int,
+) *model.ApiError {
+	// objects created without a user, e.g. by integrations, are not capped
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return nil
+	}
+
+	quota, apiErr := c.repo.get(ctx, user.OrgId, resource)
+	if apiErr != nil {
+		return apiErr
+	}
+	if quota == nil || !exceeded(quota.Limit, used, total) {
+		return nil
+	}
+
+	return &model.ApiError{
+		Typ: model.ErrorForbidden,
+		Err: fmt.Errorf(
+			"quota of %d %s reached for the org, %d in use. Remove unused %s or ask an admin to raise the quota",
+			quota.Limit, resource, used, resource,
+		),
+	}
+}
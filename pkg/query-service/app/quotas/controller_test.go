@@ -0,0 +1,85 @@
+package quotas
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func newTestController(t *testing.T) (*Controller, *sqlx.DB) {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	if err != nil {
+		t.Fatalf("could not create temp file for test db: %v", err)
+	}
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+
+	testDB, err := sqlx.Open("sqlite3", testDBFilePath)
+	if err != nil {
+		t.Fatalf("could not open test db sqlite file: %v", err)
+	}
+
+	controller, err := NewController(testDB)
+	if err != nil {
+		t.Fatalf("could not create quotas controller: %v", err)
+	}
+	return controller, testDB
+}
+
+func TestQuotaEnforcement(t *testing.T) {
+	require := require.New(t)
+	controller, db := newTestController(t)
+
+	_, err := db.Exec(`CREATE TABLE saved_views (uuid TEXT PRIMARY KEY)`)
+	require.Nil(err)
+	_, err = db.Exec(`INSERT INTO saved_views (uuid) VALUES ('a'), ('b')`)
+	require.Nil(err)
+
+	ctx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "user1", OrgId: "org1"},
+		},
+	)
+
+	require.Nil(controller.EnsureCanCreate(ctx, ResourceSavedViews, 1), "no quota should mean no cap")
+
+	_, apiErr := controller.repo.upsert(ctx, "org1", "user1", &PostableQuota{
+		Resource: ResourceSavedViews, Limit: 3,
+	})
+	require.Nil(apiErr)
+	require.Nil(controller.EnsureCanCreate(ctx, ResourceSavedViews, 1))
+
+	_, err = db.Exec(`INSERT INTO saved_views (uuid) VALUES ('c')`)
+	require.Nil(err)
+	apiErr = controller.EnsureCanCreate(ctx, ResourceSavedViews, 1)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorForbidden, apiErr.Type())
+
+	otherOrgCtx := context.WithValue(
+		context.Background(), constants.ContextUserKey, &model.UserPayload{
+			User: model.User{Id: "user2", OrgId: "org2"},
+		},
+	)
+	require.Nil(controller.EnsureCanCreate(otherOrgCtx, ResourceSavedViews, 1))
+
+	// lowering a quota below the usage still allows cleaning up
+	_, apiErr = controller.repo.upsert(ctx, "org1", "user1", &PostableQuota{
+		Resource: ResourceSavedViews, Limit: 1,
+	})
+	require.Nil(apiErr)
+	require.Nil(controller.EnsureWithinQuota(ctx, ResourceSavedViews, 2))
+	require.NotNil(controller.EnsureWithinQuota(ctx, ResourceSavedViews, 4))
+
+	require.Nil(controller.DeleteQuota(ctx, ResourceSavedViews))
+	require.Nil(controller.EnsureCanCreate(ctx, ResourceSavedViews, 10))
+
+	require.NotNil((&PostableQuota{Resource: "widgets", Limit: 1}).IsValid())
+	require.NotNil((&PostableQuota{Resource: ResourceRules, Limit: -1}).IsValid())
+}
@@ -0,0 +1,61 @@
+package quotas
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Resource is a kind of object whose count can be capped per org
+type Resource string
+
+const (
+	ResourceDashboards Resource = "dashboards"
+	ResourceRules      Resource = "rules"
+	ResourcePipelines  Resource = "pipelines"
+	ResourceSavedViews Resource = "saved_views"
+)
+
+var Resources = []Resource{
+	ResourceDashboards, ResourceRules, ResourcePipelines, ResourceSavedViews,
+}
+
+// Quota caps the number of objects of a resource an org can have
+type Quota struct {
+	OrgId     string    `json:"orgId" db:"org_id"`
+	Resource  Resource  `json:"resource" db:"resource"`
+	Limit     int       `json:"limit" db:"max_count"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type PostableQuota struct {
+	Resource Resource `json:"resource"`
+	Limit    int      `json:"limit"`
+}
+
+func (p *PostableQuota) IsValid() error {
+	if !slices.Contains(Resources, p.Resource) {
+		return fmt.Errorf("unknown resource %s, must be one of %v", p.Resource, Resources)
+	}
+	if p.Limit < 0 {
+		return fmt.Errorf("limit can't be negative")
+	}
+	return nil
+}
+
+// QuotaUsage is the number of objects of a resource in use against its
+// quota. Limit is nil for resources without a quota.
+type QuotaUsage struct {
+	Resource Resource `json:"resource"`
+	Limit    *int     `json:"limit"`
+	Used     int      `json:"used"`
+}
+
+// exceeded tells whether having total objects of a resource goes over its
+// limit. Going down to fewer objects is always allowed, so that orgs already
+// over a lowered quota can clean up.
+func exceeded(limit int, used int, total int) bool {
+	return total > limit && total > used
+}
@@ -0,0 +1,166 @@
+package quotas
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS org_quotas(
+			org_id TEXT NOT NULL,
+			resource TEXT NOT NULL,
+			max_count INTEGER NOT NULL,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(org_id, resource)
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure org quotas schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for org quotas: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context, orgId string) ([]Quota, *model.ApiError) {
+	quotas := []Quota{}
+
+	err := r.db.SelectContext(ctx, &quotas, `
+		SELECT org_id, resource, max_count, updated_by, updated_at
+		FROM org_quotas
+		WHERE org_id = $1
+		ORDER BY resource
+	`, orgId)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query org quotas: %w", err,
+		))
+	}
+	return quotas, nil
+}
+
+func (r *Repo) get(ctx context.Context, orgId string, resource Resource) (*Quota, *model.ApiError) {
+	quotas := []Quota{}
+
+	err := r.db.SelectContext(ctx, &quotas, `
+		SELECT org_id, resource, max_count, updated_by, updated_at
+		FROM org_quotas
+		WHERE org_id = $1 AND resource = $2
+	`, orgId, resource)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query %s quota: %w", resource, err,
+		))
+	}
+
+	if len(quotas) == 0 {
+		return nil, nil
+	}
+	return &quotas[0], nil
+}
+
+func (r *Repo) upsert(
+	ctx context.Context, orgId string, userId string, postable *PostableQuota,
+) (*Quota, *model.ApiError) {
+	quota := &Quota{
+		OrgId:     orgId,
+		Resource:  postable.Resource,
+		Limit:     postable.Limit,
+		UpdatedBy: userId,
+		UpdatedAt: time.Now(),
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO org_quotas (org_id, resource, max_count, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT(org_id, resource) DO UPDATE SET
+			max_count = excluded.max_count,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, quota.OrgId, quota.Resource, quota.Limit, quota.UpdatedBy, quota.UpdatedAt)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not save %s quota: %w", quota.Resource, err,
+		))
+	}
+
+	return quota, nil
+}
+
+func (r *Repo) delete(ctx context.Context, orgId string, resource Resource) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM org_quotas WHERE org_id = $1 AND resource = $2
+	`, orgId, resource)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete %s quota: %w", resource, err,
+		))
+	}
+	return nil
+}
+
+// count returns the number of objects of a resource in use. Pipelines are
+// counted in their latest config version.
+func (r *Repo) count(ctx context.Context, resource Resource) (int, *model.ApiError) {
+	var query string
+	args := []interface{}{}
+	switch resource {
+	case ResourceDashboards:
+		query = `SELECT count(*) FROM dashboards WHERE deleted_at IS NULL`
+	case ResourceRules:
+		query = `SELECT count(*) FROM rules WHERE deleted = 0`
+	case ResourceSavedViews:
+		query = `SELECT count(*) FROM saved_views`
+	case ResourcePipelines:
+		query = `
+			SELECT count(*)
+			FROM agent_config_elements e, agent_config_versions v
+			WHERE v.id = e.version_id
+			AND e.element_type = $1
+			AND v.version = (
+				SELECT max(version) FROM agent_config_versions WHERE element_type = $1
+			)
+		`
+		args = append(args, agentConf.ElementTypeLogPipelines)
+	default:
+		return 0, model.BadRequest(fmt.Errorf("unknown resource %s", resource))
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, model.InternalError(fmt.Errorf(
+			"could not count %s: %w", resource, err,
+		))
+	}
+	return count, nil
+}
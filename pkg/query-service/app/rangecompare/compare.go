@@ -0,0 +1,227 @@
+package rangecompare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// Range is a time range in milliseconds
+type Range struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// IsValid checks that the range starts before it ends
+func (r Range) IsValid() error {
+	if r.Start <= 0 || r.End <= r.Start {
+		return fmt.Errorf("the range must be timestamps in milliseconds with start before end")
+	}
+	return nil
+}
+
+// ValidateRanges checks that both ranges are valid and of the same length,
+// so that their points line up
+func ValidateRanges(baseline, comparison Range) error {
+	if err := baseline.IsValid(); err != nil {
+		return fmt.Errorf("invalid baseline: %w", err)
+	}
+	if err := comparison.IsValid(); err != nil {
+		return fmt.Errorf("invalid comparison: %w", err)
+	}
+	if baseline.End-baseline.Start != comparison.End-comparison.Start {
+		return fmt.Errorf("the baseline and the comparison ranges must be of the same length")
+	}
+	return nil
+}
+
+type QueryComparison struct {
+	QueryName string             `json:"queryName"`
+	Series    []SeriesComparison `json:"series"`
+}
+
+// SeriesComparison lines up the points of a group over the two ranges and
+// summarizes the change between them. Changes are nil when a side has no data
+// and percent changes are nil when the baseline is 0.
+type SeriesComparison struct {
+	Labels      map[string]string   `json:"labels"`
+	LabelsArray []map[string]string `json:"labelsArray"`
+
+	BaselineAvg   *float64 `json:"baselineAvg"`
+	ComparisonAvg *float64 `json:"comparisonAvg"`
+	Delta         *float64 `json:"delta"`
+	PercentChange *float64 `json:"percentChange"`
+
+	Points []PointComparison `json:"points"`
+}
+
+// PointComparison pairs the points at the same offset from the step aligned
+// start of their ranges
+type PointComparison struct {
+	Offset int64 `json:"offset"`
+
+	BaselineTimestamp   *int64   `json:"baselineTimestamp"`
+	Baseline            *float64 `json:"baseline"`
+	ComparisonTimestamp *int64   `json:"comparisonTimestamp"`
+	Comparison          *float64 `json:"comparison"`
+
+	Delta         *float64 `json:"delta"`
+	PercentChange *float64 `json:"percentChange"`
+}
+
+func seriesKey(s *v3.Series) string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(s.Labels[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func change(baseline, comparison *float64) (*float64, *float64) {
+	if baseline == nil || comparison == nil {
+		return nil, nil
+	}
+	delta := *comparison - *baseline
+	if *baseline == 0 {
+		return &delta, nil
+	}
+	percent := delta / abs(*baseline) * 100
+	return &delta, &percent
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func average(points []v3.Point) *float64 {
+	if len(points) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, p := range points {
+		sum += p.Value
+	}
+	avg := sum / float64(len(points))
+	return &avg
+}
+
+// stepOffset is the offset of a point from the start of its range, both
+// aligned to the step the way query results are, so that the points of
+// ranges which aren't step aligned still line up
+func stepOffset(ts int64, r Range, step int64) int64 {
+	if step <= 0 {
+		return ts - r.Start
+	}
+	alignedStart := r.Start - r.Start%step
+	offset := ts - alignedStart
+	return offset - offset%step
+}
+
+func compareSeries(
+	baseline, comparison *v3.Series, baselineRange, comparisonRange Range, step int64,
+) SeriesComparison {
+	result := SeriesComparison{Points: []PointComparison{}}
+
+	pointsByOffset := map[int64]*PointComparison{}
+	offsets := []int64{}
+	pointAt := func(offset int64) *PointComparison {
+		p, ok := pointsByOffset[offset]
+		if !ok {
+			p = &PointComparison{Offset: offset}
+			pointsByOffset[offset] = p
+			offsets = append(offsets, offset)
+		}
+		return p
+	}
+
+	if baseline != nil {
+		result.Labels, result.LabelsArray = baseline.Labels, baseline.LabelsArray
+		result.BaselineAvg = average(baseline.Points)
+		for _, point := range baseline.Points {
+			point := point
+			p := pointAt(stepOffset(point.Timestamp, baselineRange, step))
+			p.BaselineTimestamp, p.Baseline = &point.Timestamp, &point.Value
+		}
+	}
+	if comparison != nil {
+		if baseline == nil {
+			result.Labels, result.LabelsArray = comparison.Labels, comparison.LabelsArray
+		}
+		result.ComparisonAvg = average(comparison.Points)
+		for _, point := range comparison.Points {
+			point := point
+			p := pointAt(stepOffset(point.Timestamp, comparisonRange, step))
+			p.ComparisonTimestamp, p.Comparison = &point.Timestamp, &point.Value
+		}
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	for _, offset := range offsets {
+		p := pointsByOffset[offset]
+		p.Delta, p.PercentChange = change(p.Baseline, p.Comparison)
+		result.Points = append(result.Points, *p)
+	}
+	result.Delta, result.PercentChange = change(result.BaselineAvg, result.ComparisonAvg)
+	return result
+}
+
+// Compare aligns the series of the results of the same composite query over
+// two ranges by their labels, and their points by the offset from the step
+// aligned start of their range. steps has the step of each query in
+// milliseconds.
+func Compare(
+	baseline []*v3.Result, baselineRange Range,
+	comparison []*v3.Result, comparisonRange Range,
+	steps map[string]int64,
+) []QueryComparison {
+	comparisonByQuery := map[string]*v3.Result{}
+	for _, r := range comparison {
+		comparisonByQuery[r.QueryName] = r
+	}
+
+	result := []QueryComparison{}
+	for _, b := range baseline {
+		qc := QueryComparison{QueryName: b.QueryName, Series: []SeriesComparison{}}
+
+		comparisonSeries := map[string]*v3.Series{}
+		if c, ok := comparisonByQuery[b.QueryName]; ok {
+			for _, s := range c.Series {
+				comparisonSeries[seriesKey(s)] = s
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, s := range b.Series {
+			key := seriesKey(s)
+			seen[key] = true
+			qc.Series = append(qc.Series, compareSeries(
+				s, comparisonSeries[key], baselineRange, comparisonRange, steps[b.QueryName],
+			))
+		}
+		if c, ok := comparisonByQuery[b.QueryName]; ok {
+			for _, s := range c.Series {
+				if !seen[seriesKey(s)] {
+					qc.Series = append(qc.Series, compareSeries(
+						nil, s, baselineRange, comparisonRange, steps[b.QueryName],
+					))
+				}
+			}
+		}
+		result = append(result, qc)
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package rangecompare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestCompare(t *testing.T) {
+	require := require.New(t)
+
+	baselineRange := Range{Start: 1000, End: 4000}
+	comparisonRange := Range{Start: 101000, End: 104000}
+
+	series := func(service string, points ...v3.Point) *v3.Series {
+		return &v3.Series{
+			Labels:      map[string]string{"service": service},
+			LabelsArray: []map[string]string{{"service": service}},
+			Points:      points,
+		}
+	}
+
+	baseline := []*v3.Result{{
+		QueryName: "A",
+		Series: []*v3.Series{
+			series("api", v3.Point{Timestamp: 1000, Value: 10}, v3.Point{Timestamp: 2000, Value: 30}),
+			series("web", v3.Point{Timestamp: 1000, Value: 0}),
+		},
+	}}
+	comparison := []*v3.Result{{
+		QueryName: "A",
+		Series: []*v3.Series{
+			series("worker", v3.Point{Timestamp: 101000, Value: 5}),
+			series("web", v3.Point{Timestamp: 101000, Value: 4}),
+			series("api", v3.Point{Timestamp: 101000, Value: 15}, v3.Point{Timestamp: 103000, Value: 45}),
+		},
+	}}
+
+	result := Compare(baseline, baselineRange, comparison, comparisonRange, map[string]int64{"A": 1000})
+	require.Equal(1, len(result))
+	require.Equal("A", result[0].QueryName)
+	require.Equal(3, len(result[0].Series))
+
+	api := result[0].Series[0]
+	require.Equal("api", api.Labels["service"])
+	require.Equal(20.0, *api.BaselineAvg)
+	require.Equal(30.0, *api.ComparisonAvg)
+	require.Equal(10.0, *api.Delta)
+	require.Equal(50.0, *api.PercentChange)
+
+	require.Equal([]int64{0, 1000, 2000}, []int64{api.Points[0].Offset, api.Points[1].Offset, api.Points[2].Offset})
+	require.Equal(5.0, *api.Points[0].Delta)
+	require.Equal(50.0, *api.Points[0].PercentChange)
+	require.Nil(api.Points[1].Comparison)
+	require.Nil(api.Points[1].Delta, "changes need points in both ranges")
+	require.Nil(api.Points[2].Baseline)
+	require.Equal(int64(103000), *api.Points[2].ComparisonTimestamp)
+
+	web := result[0].Series[1]
+	require.Equal(4.0, *web.Delta)
+	require.Nil(web.PercentChange, "percent changes from 0 are undefined")
+
+	worker := result[0].Series[2]
+	require.Equal("worker", worker.Labels["service"])
+	require.Nil(worker.BaselineAvg)
+	require.Nil(worker.Delta)
+	require.Equal(5.0, *worker.ComparisonAvg)
+}
+
+func TestCompareUnalignedRanges(t *testing.T) {
+	require := require.New(t)
+
+	// the ranges start at different offsets into their step, the results
+	// have points at the step aligned timestamps
+	baselineRange := Range{Start: 1500, End: 3500}
+	comparisonRange := Range{Start: 101700, End: 103700}
+	series := func(points ...v3.Point) []*v3.Result {
+		return []*v3.Result{{QueryName: "A", Series: []*v3.Series{{Labels: map[string]string{}, Points: points}}}}
+	}
+
+	result := Compare(
+		series(v3.Point{Timestamp: 1000, Value: 1}, v3.Point{Timestamp: 2000, Value: 2}, v3.Point{Timestamp: 3000, Value: 3}),
+		baselineRange,
+		series(v3.Point{Timestamp: 101000, Value: 2}, v3.Point{Timestamp: 102000, Value: 4}, v3.Point{Timestamp: 103000, Value: 6}),
+		comparisonRange,
+		map[string]int64{"A": 1000},
+	)
+	points := result[0].Series[0].Points
+	require.Len(points, 3)
+	for i, p := range points {
+		require.Equal(int64(i*1000), p.Offset)
+		require.Equal(*p.Baseline*2, *p.Comparison)
+	}
+}
+
+func TestValidateRanges(t *testing.T) {
+	require := require.New(t)
+
+	require.Nil(ValidateRanges(Range{Start: 1000, End: 2000}, Range{Start: 5000, End: 6000}))
+	require.NotNil(ValidateRanges(Range{Start: 2000, End: 1000}, Range{Start: 5000, End: 6000}))
+	require.NotNil(ValidateRanges(Range{Start: 1000, End: 2000}, Range{Start: 6000, End: 6000}))
+	require.NotNil(ValidateRanges(Range{Start: 1000, End: 2000}, Range{Start: 5000, End: 7000}), "lengths differ")
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// remoteWriteReader records the remote write requests reaching the reader
+type remoteWriteReader struct {
+	interfaces.Reader
+	written []*prompb.WriteRequest
+}
+
+func (r *remoteWriteReader) WriteRemoteWriteRequest(ctx context.Context, req *prompb.WriteRequest) *model.ApiError {
+	r.written = append(r.written, req)
+	return nil
+}
+
+func TestPrometheusRemoteWriteSizeLimits(t *testing.T) {
+	maxBytes := constants.RemoteWriteMaxBytes
+	constants.RemoteWriteMaxBytes = 1024
+	t.Cleanup(func() { constants.RemoteWriteMaxBytes = maxBytes })
+
+	writeRequest, err := (&prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "queue_size"}},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+	}}}).Marshal()
+	require.Nil(t, err)
+	incompressible := make([]byte, 2048)
+	_, err = rand.Read(incompressible)
+	require.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		code    int
+		error   string
+		written int
+	}{
+		{name: "within the limits", payload: snappy.Encode(nil, writeRequest), code: http.StatusNoContent, written: 1},
+		{name: "compressed over the limit", payload: snappy.Encode(nil, incompressible), code: http.StatusBadRequest, error: "request body too large"},
+		{name: "decompressed over the limit", payload: snappy.Encode(nil, make([]byte, 8*1024)), code: http.StatusBadRequest, error: "exceeds the limit of 1024 bytes"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &remoteWriteReader{}
+			aH := &APIHandler{reader: reader}
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/prometheus/write", bytes.NewReader(test.payload))
+			w := httptest.NewRecorder()
+			aH.prometheusRemoteWrite(w, request)
+			require.Equal(t, test.code, w.Code, w.Body.String())
+			require.Contains(t, w.Body.String(), test.error)
+			require.Len(t, reader.written, test.written)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package savedqueries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// Controller manages the saved queries of the org. Running them is left to
+// the query range API, with the params resolved by QueryRangeParams.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create saved queries repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+func (c *Controller) ListSavedQueries(ctx context.Context) ([]SavedQuery, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+func (c *Controller) GetSavedQuery(ctx context.Context, id string) (*SavedQuery, *model.ApiError) {
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) CreateSavedQuery(
+	ctx context.Context, postable *PostableSavedQuery,
+) (*SavedQuery, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	return c.repo.insert(ctx, userId, postable)
+}
+
+func (c *Controller) UpdateSavedQuery(
+	ctx context.Context, id string, postable *PostableSavedQuery,
+) (*SavedQuery, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Description = postable.Description
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+	return &updated, nil
+}
+
+func (c *Controller) DeleteSavedQuery(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return c.repo.delete(ctx, id)
+}
+
+// QueryRangeParams returns the query range params to run a saved query with
+// the range and parameter values of the request
+func (c *Controller) QueryRangeParams(
+	ctx context.Context, id string, req *RunSavedQueryRequest,
+) (*v3.QueryRangeParamsV3, *model.ApiError) {
+	sq, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	params, err := sq.QueryRangeParams(req, time.Now())
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return params, nil
+}
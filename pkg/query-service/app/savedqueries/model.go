@@ -0,0 +1,184 @@
+package savedqueries
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	defaultRunRange = time.Hour
+	maxRunPoints    = 300
+	minRunStep      = 60
+)
+
+// parameter names are referenced as variables in the queries, e.g.
+// {{.service}} in a clickhouse query, so they have to be identifiers
+var parameterNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// SavedQuery is a named composite query with declared parameters. Unlike a
+// saved view it is run on its own with values for the parameters, by
+// scripts and the explorer alike.
+type SavedQuery struct {
+	Id          string         `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	Spec        SavedQuerySpec `json:"spec" db:"spec_json"`
+	CreatedBy   string         `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedBy   string         `json:"updatedBy" db:"updated_by"`
+	UpdatedAt   time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type SavedQuerySpec struct {
+	Parameters []QueryParameter `json:"parameters"`
+
+	// CompositeQuery references the parameters the same way as dashboard
+	// variables, e.g. {{.service}} as the value of a builder filter
+	CompositeQuery *v3.CompositeQuery `json:"compositeQuery"`
+}
+
+// QueryParameter is a variable of a saved query. Parameters without a
+// default value are required when running the query.
+type QueryParameter struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Type        v3.VariableType `json:"type"`
+	Default     interface{}     `json:"default,omitempty"`
+}
+
+// For serializing from db
+func (s *SavedQuerySpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s SavedQuerySpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize saved query spec to JSON")
+	}
+	return serialized, nil
+}
+
+type PostableSavedQuery struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Spec        SavedQuerySpec `json:"spec"`
+}
+
+func (p *PostableSavedQuery) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("saved query name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *SavedQuerySpec) IsValid() error {
+	if s.CompositeQuery == nil {
+		return fmt.Errorf("compositeQuery is required")
+	}
+	if err := s.CompositeQuery.Validate(); err != nil {
+		return fmt.Errorf("invalid compositeQuery: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, p := range s.Parameters {
+		if !parameterNameRegex.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+		seen[p.Name] = true
+
+		if err := p.Type.Validate(); err != nil {
+			return fmt.Errorf("invalid type of parameter %s: %w", p.Name, err)
+		}
+		if p.Default != nil {
+			if _, err := p.Type.Coerce(p.Default); err != nil {
+				return fmt.Errorf("invalid default of parameter %s: %w", p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RunSavedQueryRequest is the range and the parameter values to run a saved
+// query with. The range defaults to the last hour.
+type RunSavedQueryRequest struct {
+	Start      int64                  `json:"start"`
+	End        int64                  `json:"end"`
+	Step       int64                  `json:"step"`
+	Parameters map[string]interface{} `json:"parameters"`
+	NoCache    bool                   `json:"noCache"`
+}
+
+// QueryRangeParams returns the query range params to run the saved query
+// with, the parameters being passed as the variables of the query
+func (sq *SavedQuery) QueryRangeParams(req *RunSavedQueryRequest, now time.Time) (
+	*v3.QueryRangeParamsV3, error,
+) {
+	declared := map[string]bool{}
+	variables := map[string]interface{}{}
+	variableTypes := map[string]v3.VariableType{}
+	for _, p := range sq.Spec.Parameters {
+		declared[p.Name] = true
+		variableTypes[p.Name] = p.Type
+
+		value, ok := req.Parameters[p.Name]
+		if !ok || value == nil {
+			value = p.Default
+		}
+		if value == nil {
+			return nil, fmt.Errorf("parameter %s is required", p.Name)
+		}
+		variables[p.Name] = value
+	}
+	for name := range req.Parameters {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+
+	end := req.End
+	if end == 0 {
+		end = now.UnixMilli()
+	}
+	start := req.Start
+	if start == 0 {
+		start = end - defaultRunRange.Milliseconds()
+	}
+	if start >= end {
+		return nil, fmt.Errorf("start must be before end")
+	}
+	step := req.Step
+	if step == 0 {
+		step = (end - start) / 1000 / maxRunPoints
+		if step < minRunStep {
+			step = minRunStep
+		}
+	}
+
+	return &v3.QueryRangeParamsV3{
+		Start:          start,
+		End:            end,
+		Step:           step,
+		CompositeQuery: sq.Spec.CompositeQuery,
+		Variables:      variables,
+		VariableTypes:  variableTypes,
+		NoCache:        req.NoCache,
+	}, nil
+}
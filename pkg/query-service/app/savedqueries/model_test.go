@@ -0,0 +1,107 @@
+package savedqueries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func testCompositeQuery() *v3.CompositeQuery {
+	return &v3.CompositeQuery{
+		QueryType: v3.QueryTypeClickHouseSQL,
+		PanelType: v3.PanelTypeTable,
+		ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+			"A": {
+				Query: "SELECT count() FROM signoz_traces.distributed_signoz_index_v2 WHERE serviceName = {{.service}} LIMIT {{.limit}}",
+			},
+		},
+	}
+}
+
+func TestSavedQuerySpecIsValid(t *testing.T) {
+	maxLimit := 100.0
+	tests := []struct {
+		name       string
+		parameters []QueryParameter
+		wantErr    bool
+	}{
+		{
+			name: "valid parameters",
+			parameters: []QueryParameter{
+				{Name: "service"},
+				{Name: "limit", Type: v3.VariableType{DataType: v3.VariableDataTypeNumber, Max: &maxLimit}, Default: 10.0},
+			},
+		},
+		{
+			name:       "parameter name is not an identifier",
+			parameters: []QueryParameter{{Name: "service.name"}},
+			wantErr:    true,
+		},
+		{
+			name:       "duplicate parameter",
+			parameters: []QueryParameter{{Name: "service"}, {Name: "service"}},
+			wantErr:    true,
+		},
+		{
+			name: "default out of range",
+			parameters: []QueryParameter{
+				{Name: "limit", Type: v3.VariableType{DataType: v3.VariableDataTypeNumber, Max: &maxLimit}, Default: 1000.0},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := SavedQuerySpec{Parameters: tt.parameters, CompositeQuery: testCompositeQuery()}
+			err := spec.IsValid()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSavedQueryQueryRangeParams(t *testing.T) {
+	sq := &SavedQuery{
+		Spec: SavedQuerySpec{
+			Parameters: []QueryParameter{
+				{Name: "service"},
+				{Name: "limit", Type: v3.VariableType{DataType: v3.VariableDataTypeNumber}, Default: 10.0},
+			},
+			CompositeQuery: testCompositeQuery(),
+		},
+	}
+	now := time.UnixMilli(1700000000000)
+
+	params, err := sq.QueryRangeParams(&RunSavedQueryRequest{
+		Parameters: map[string]interface{}{"service": "frontend"},
+	}, now)
+	require.NoError(t, err)
+	require.Equal(t, now.UnixMilli(), params.End)
+	require.Equal(t, now.UnixMilli()-time.Hour.Milliseconds(), params.Start)
+	require.Equal(t, int64(60), params.Step)
+	require.Equal(t, map[string]interface{}{"service": "frontend", "limit": 10.0}, params.Variables)
+	require.Equal(t, v3.VariableDataTypeNumber, params.VariableTypes["limit"].DataType)
+
+	params, err = sq.QueryRangeParams(&RunSavedQueryRequest{
+		Start:      now.UnixMilli() - 7*24*time.Hour.Milliseconds(),
+		End:        now.UnixMilli(),
+		Parameters: map[string]interface{}{"service": "frontend", "limit": 5.0},
+	}, now)
+	require.NoError(t, err)
+	require.Equal(t, 5.0, params.Variables["limit"])
+	require.Equal(t, int64(7*24*60*60/maxRunPoints), params.Step)
+
+	_, err = sq.QueryRangeParams(&RunSavedQueryRequest{}, now)
+	require.ErrorContains(t, err, "parameter service is required")
+
+	_, err = sq.QueryRangeParams(&RunSavedQueryRequest{
+		Parameters: map[string]interface{}{"service": "frontend", "env": "prod"},
+	}, now)
+	require.ErrorContains(t, err, "unknown parameter env")
+}
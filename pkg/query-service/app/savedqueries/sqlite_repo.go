@@ -0,0 +1,186 @@
+package savedqueries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS saved_queries(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure saved queries schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for saved queries: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]SavedQuery, *model.ApiError) {
+	savedQueries := []SavedQuery{}
+
+	err := r.db.SelectContext(ctx, &savedQueries, `
+		SELECT id, name, description, spec_json, created_by, created_at, updated_by, updated_at
+		FROM saved_queries
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query saved queries: %w", err,
+		))
+	}
+	return savedQueries, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*SavedQuery, *model.ApiError) {
+	savedQueries := []SavedQuery{}
+
+	err := r.db.SelectContext(ctx, &savedQueries, `
+		SELECT id, name, description, spec_json, created_by, created_at, updated_by, updated_at
+		FROM saved_queries
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query saved query %s: %w", id, err,
+		))
+	}
+
+	if len(savedQueries) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("saved query %s not found", id))
+	}
+	return &savedQueries[0], nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM saved_queries WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query saved queries: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a saved query named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableSavedQuery,
+) (*SavedQuery, *model.ApiError) {
+	now := time.Now()
+	savedQuery := &SavedQuery{
+		Id:          uuid.NewString(),
+		Name:        postable.Name,
+		Description: postable.Description,
+		Spec:        postable.Spec,
+		CreatedBy:   userId,
+		CreatedAt:   now,
+		UpdatedBy:   userId,
+		UpdatedAt:   now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, savedQuery.Name, savedQuery.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO saved_queries (
+			id, name, description, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		savedQuery.Id, savedQuery.Name, savedQuery.Description, savedQuery.Spec,
+		savedQuery.CreatedBy, savedQuery.CreatedAt,
+		savedQuery.UpdatedBy, savedQuery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert saved query: %w", err,
+		))
+	}
+
+	return savedQuery, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, savedQuery *SavedQuery,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, savedQuery.Name, savedQuery.Id); apiErr != nil {
+		return apiErr
+	}
+
+	savedQuery.UpdatedBy = userId
+	savedQuery.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE saved_queries
+		SET name = $1, description = $2, spec_json = $3, updated_by = $4, updated_at = $5
+		WHERE id = $6
+	`,
+		savedQuery.Name, savedQuery.Description, savedQuery.Spec,
+		savedQuery.UpdatedBy, savedQuery.UpdatedAt, savedQuery.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update saved query %s: %w", savedQuery.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM saved_queries WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete saved query %s: %w", id, err,
+		))
+	}
+	return nil
+}
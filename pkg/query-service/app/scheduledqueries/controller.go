@@ -0,0 +1,263 @@
+package scheduledqueries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const runTimeout = 5 * time.Minute
+
+// PrepareFunc validates the params of a run and replaces the variables in
+// their queries, it is the one of the query range API so that scheduled
+// queries are run the way they are previewed
+type PrepareFunc func(params *v3.QueryRangeParamsV3) *model.ApiError
+
+// Controller manages scheduled queries, runs them on their schedule and
+// persists their results in clickhouse via the reader.
+type Controller struct {
+	repo    *Repo
+	reader  interfaces.Reader
+	querier interfaces.Querier
+	prepare PrepareFunc
+
+	cron       *cron.Cron
+	entries    map[string]cron.EntryID
+	entriesMtx sync.Mutex
+}
+
+func NewController(
+	db *sqlx.DB, reader interfaces.Reader, querier interfaces.Querier, prepare PrepareFunc,
+) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create scheduled queries repo: %w", err)
+	}
+
+	return &Controller{
+		repo:    repo,
+		reader:  reader,
+		querier: querier,
+		prepare: prepare,
+		cron:    cron.New(cron.WithLocation(time.UTC), cron.WithParser(cronParser)),
+		entries: map[string]cron.EntryID{},
+	}, nil
+}
+
+// Start schedules the enabled scheduled queries
+func (c *Controller) Start() {
+	ctx := context.Background()
+	if err := c.reader.CreateScheduledQueryResultsTable(ctx); err != nil {
+		zap.L().Error("could not create scheduled query results table", zap.Error(err))
+	}
+
+	scheduledQueries, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		zap.L().Error("could not list scheduled queries", zap.Error(apiErr.ToError()))
+	}
+	for i := range scheduledQueries {
+		c.schedule(&scheduledQueries[i])
+	}
+	c.cron.Start()
+}
+
+// Stop waits for the running scheduled queries to finish
+func (c *Controller) Stop() {
+	<-c.cron.Stop().Done()
+}
+
+func (c *Controller) schedule(sq *ScheduledQuery) {
+	c.entriesMtx.Lock()
+	defer c.entriesMtx.Unlock()
+
+	if entryId, ok := c.entries[sq.Id]; ok {
+		c.cron.Remove(entryId)
+		delete(c.entries, sq.Id)
+	}
+	if !sq.Spec.Enabled {
+		return
+	}
+
+	id := sq.Id
+	entryId, err := c.cron.AddFunc(sq.Spec.Schedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+		defer cancel()
+		if _, apiErr := c.RunScheduledQuery(ctx, id); apiErr != nil {
+			zap.L().Error("scheduled query run failed", zap.String("id", id), zap.Error(apiErr.ToError()))
+		}
+	})
+	if err != nil {
+		zap.L().Error("could not schedule query", zap.String("id", id), zap.Error(err))
+		return
+	}
+	c.entries[id] = entryId
+}
+
+func (c *Controller) unschedule(id string) {
+	c.entriesMtx.Lock()
+	defer c.entriesMtx.Unlock()
+
+	if entryId, ok := c.entries[id]; ok {
+		c.cron.Remove(entryId)
+		delete(c.entries, id)
+	}
+}
+
+func (c *Controller) ListScheduledQueries(ctx context.Context) ([]ScheduledQuery, *model.ApiError) {
+	return c.repo.list(ctx)
+}
+
+func (c *Controller) GetScheduledQuery(ctx context.Context, id string) (*ScheduledQuery, *model.ApiError) {
+	return c.repo.get(ctx, id)
+}
+
+func (c *Controller) CreateScheduledQuery(
+	ctx context.Context, postable *PostableScheduledQuery,
+) (*ScheduledQuery, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	scheduledQuery, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	c.schedule(scheduledQuery)
+	return scheduledQuery, nil
+}
+
+func (c *Controller) UpdateScheduledQuery(
+	ctx context.Context, id string, postable *PostableScheduledQuery,
+) (*ScheduledQuery, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+	c.schedule(&updated)
+	return &updated, nil
+}
+
+// DeleteScheduledQuery stops running a scheduled query. Its persisted
+// results are left to expire with their retention.
+func (c *Controller) DeleteScheduledQuery(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	c.unschedule(id)
+	return nil
+}
+
+// RunScheduledQuery runs a scheduled query over the range ending now and
+// persists its results, recording the outcome as its last run
+func (c *Controller) RunScheduledQuery(ctx context.Context, id string) (*ScheduledQuery, *model.ApiError) {
+	sq, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	runAt := time.Now()
+	apiErr = c.run(ctx, sq, runAt)
+	lastError := ""
+	if apiErr != nil {
+		lastError = apiErr.Error()
+	}
+	if err := c.repo.updateLastRun(ctx, id, runAt, lastError); err != nil {
+		zap.L().Error("could not record scheduled query run", zap.String("id", id), zap.Error(err.ToError()))
+	}
+	sq.LastRunAt, sq.LastError = &runAt, lastError
+	return sq, apiErr
+}
+
+func (c *Controller) run(ctx context.Context, sq *ScheduledQuery, runAt time.Time) *model.ApiError {
+	end := runAt.UnixMilli()
+	step := int64(sq.Spec.RangeMinutes) * 60 / 300
+	if step < 60 {
+		step = 60
+	}
+	params := &v3.QueryRangeParamsV3{
+		Start:          end - int64(sq.Spec.RangeMinutes)*time.Minute.Milliseconds(),
+		End:            end,
+		Step:           step,
+		CompositeQuery: sq.Spec.CompositeQuery,
+		Variables:      map[string]interface{}{},
+		NoCache:        true,
+	}
+	for name, value := range sq.Spec.Variables {
+		params.Variables[name] = value
+	}
+	if apiErr := c.prepare(params); apiErr != nil {
+		return model.BadRequest(fmt.Errorf("invalid scheduled query: %w", apiErr.ToError()))
+	}
+
+	results, err, _ := c.querier.QueryRange(ctx, params, map[string]v3.AttributeKey{})
+	if err != nil {
+		return model.BadRequest(fmt.Errorf("scheduled query failed: %w", err))
+	}
+
+	rows, err := resultsToRows(sq, results, end)
+	if err != nil {
+		return model.InternalError(fmt.Errorf("could not serialize scheduled query results: %w", err))
+	}
+	return c.reader.WriteScheduledQueryResults(ctx, rows)
+}
+
+// GetScheduledQueryResults returns the persisted results of a scheduled
+// query in the given range in milliseconds, in the query range format.
+// The results are only read through this API, they aren't a data source of
+// the query builder.
+func (c *Controller) GetScheduledQueryResults(
+	ctx context.Context, id string, start, end int64,
+) ([]*v3.Result, *model.ApiError) {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	rows, apiErr := c.reader.GetScheduledQueryResults(ctx, id, start, end)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	results, err := rowsToResults(rows)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not read scheduled query results: %w", err))
+	}
+	return results, nil
+}
@@ -0,0 +1,105 @@
+package scheduledqueries
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type fakeQuerier struct {
+	queried []*v3.QueryRangeParamsV3
+}
+
+func (q *fakeQuerier) QueryRange(
+	_ context.Context, params *v3.QueryRangeParamsV3, _ map[string]v3.AttributeKey,
+) ([]*v3.Result, error, map[string]string) {
+	q.queried = append(q.queried, params)
+	return []*v3.Result{{QueryName: "A", Series: []*v3.Series{{Points: []v3.Point{{Timestamp: params.End, Value: 1}}}}}}, nil, nil
+}
+
+func (q *fakeQuerier) QueriesExecuted() []string {
+	return nil
+}
+
+type fakeReader struct {
+	interfaces.Reader
+	written []model.ScheduledQueryResult
+}
+
+func (r *fakeReader) WriteScheduledQueryResults(_ context.Context, rows []model.ScheduledQueryResult) *model.ApiError {
+	r.written = append(r.written, rows...)
+	return nil
+}
+
+func TestRunPreparesQueries(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	testDBFilePath := testDBFile.Name()
+	t.Cleanup(func() { os.Remove(testDBFilePath) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFilePath)
+	require.Nil(err)
+
+	querier, reader := &fakeQuerier{}, &fakeReader{}
+	var prepared []*v3.QueryRangeParamsV3
+	prepare := func(params *v3.QueryRangeParamsV3) *model.ApiError {
+		prepared = append(prepared, params)
+		if params.Variables["env"] == "invalid" {
+			return model.BadRequest(fmt.Errorf("invalid variable"))
+		}
+		chQuery := params.CompositeQuery.ClickHouseQueries["A"]
+		chQuery.Query = fmt.Sprintf("SELECT count() FROM logs WHERE env = '%s'", params.Variables["env"])
+		return nil
+	}
+	controller, err := NewController(db, reader, querier, prepare)
+	require.Nil(err)
+
+	ctx := context.Background()
+	sq, apiErr := controller.repo.insert(ctx, "user", &PostableScheduledQuery{
+		Name: "daily logs",
+		Spec: ScheduledQuerySpec{
+			Schedule:     "0 0 * * *",
+			RangeMinutes: 60,
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				PanelType: v3.PanelTypeGraph,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT count() FROM logs WHERE env = {{.env}}"},
+				},
+			},
+			Variables: map[string]interface{}{"env": "prod"},
+		},
+	})
+	require.Nil(apiErr)
+
+	ran, apiErr := controller.RunScheduledQuery(ctx, sq.Id)
+	require.Nil(apiErr)
+	require.Empty(ran.LastError)
+	require.Len(prepared, 1)
+	require.Len(querier.queried, 1)
+	params := querier.queried[0]
+	require.Equal(time.Hour.Milliseconds(), params.End-params.Start)
+	require.Equal(
+		"SELECT count() FROM logs WHERE env = 'prod'", params.CompositeQuery.ClickHouseQueries["A"].Query,
+		"the queries are run once their variables are replaced",
+	)
+	require.Len(reader.written, 1)
+
+	sq.Spec.Variables["env"] = "invalid"
+	require.Nil(controller.repo.update(ctx, "user", sq))
+	ran, apiErr = controller.RunScheduledQuery(ctx, sq.Id)
+	require.NotNil(apiErr)
+	require.Contains(ran.LastError, "invalid variable")
+	require.Len(querier.queried, 1, "queries failing to prepare aren't run")
+}
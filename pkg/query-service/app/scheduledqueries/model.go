@@ -0,0 +1,114 @@
+package scheduledqueries
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	defaultRangeMinutes  = 24 * 60
+	defaultRetentionDays = 2 * 365
+)
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ScheduledQuery runs a composite query on a schedule and persists its
+// results, e.g. daily business KPIs kept for longer than the raw data
+type ScheduledQuery struct {
+	Id        string             `json:"id" db:"id"`
+	Name      string             `json:"name" db:"name"`
+	Spec      ScheduledQuerySpec `json:"spec" db:"spec_json"`
+	LastRunAt *time.Time         `json:"lastRunAt" db:"last_run_at"`
+	LastError string             `json:"lastError" db:"last_error"`
+	CreatedBy string             `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedBy string             `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time          `json:"updatedAt" db:"updated_at"`
+}
+
+type ScheduledQuerySpec struct {
+	Enabled bool `json:"enabled"`
+
+	// Schedule is a cron expression evaluated in UTC, e.g. "0 0 * * *"
+	Schedule string `json:"schedule"`
+	// RangeMinutes is the range ending at each run the query is run over
+	RangeMinutes int `json:"rangeMinutes,omitempty"`
+	// RetentionDays is how long the results are kept for
+	RetentionDays int `json:"retentionDays,omitempty"`
+
+	CompositeQuery *v3.CompositeQuery `json:"compositeQuery"`
+	// Variables are the values of the variables in the queries, the
+	// reserved time range variables are those of each run
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// For serializing from db
+func (s *ScheduledQuerySpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s ScheduledQuerySpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize scheduled query spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *ScheduledQuerySpec) setDefaults() {
+	if s.RangeMinutes == 0 {
+		s.RangeMinutes = defaultRangeMinutes
+	}
+	if s.RetentionDays == 0 {
+		s.RetentionDays = defaultRetentionDays
+	}
+}
+
+type PostableScheduledQuery struct {
+	Name string             `json:"name"`
+	Spec ScheduledQuerySpec `json:"spec"`
+}
+
+func (p *PostableScheduledQuery) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("scheduled query name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *ScheduledQuerySpec) IsValid() error {
+	if _, err := cronParser.Parse(s.Schedule); err != nil {
+		return fmt.Errorf("schedule is not a valid cron expression: %w", err)
+	}
+	if s.RangeMinutes < 0 {
+		return fmt.Errorf("rangeMinutes can not be negative")
+	}
+	if s.RetentionDays < 0 || s.RetentionDays > math.MaxUint16 {
+		return fmt.Errorf("retentionDays must be between 0 and %d", math.MaxUint16)
+	}
+	if s.CompositeQuery == nil {
+		return fmt.Errorf("compositeQuery is required")
+	}
+	if err := s.CompositeQuery.Validate(); err != nil {
+		return fmt.Errorf("invalid compositeQuery: %w", err)
+	}
+	if s.CompositeQuery.PanelType != v3.PanelTypeGraph && s.CompositeQuery.PanelType != v3.PanelTypeValue {
+		return fmt.Errorf("only %s and %s queries can be scheduled", v3.PanelTypeGraph, v3.PanelTypeValue)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package scheduledqueries
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// resultsToRows flattens the series of a run of a scheduled query into the
+// rows persisted for it
+func resultsToRows(sq *ScheduledQuery, results []*v3.Result, runAt int64) ([]model.ScheduledQueryResult, error) {
+	rows := []model.ScheduledQueryResult{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			labels := series.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			serializedLabels, err := json.Marshal(labels)
+			if err != nil {
+				return nil, err
+			}
+			for _, point := range series.Points {
+				if math.IsNaN(point.Value) || math.IsInf(point.Value, 0) {
+					continue
+				}
+				rows = append(rows, model.ScheduledQueryResult{
+					ScheduledQueryId: sq.Id,
+					QueryName:        result.QueryName,
+					Labels:           string(serializedLabels),
+					UnixMilli:        point.Timestamp,
+					RunAt:            runAt,
+					Value:            point.Value,
+					RetentionDays:    uint16(sq.Spec.RetentionDays),
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// rowsToResults groups persisted rows back into series per query, in the
+// format of query range results. The rows are expected to be ordered by
+// query name, labels and timestamp.
+func rowsToResults(rows []model.ScheduledQueryResult) ([]*v3.Result, error) {
+	results := []*v3.Result{}
+	var result *v3.Result
+	var series *v3.Series
+	lastLabels := ""
+	for _, row := range rows {
+		if result == nil || result.QueryName != row.QueryName {
+			result = &v3.Result{QueryName: row.QueryName, Series: []*v3.Series{}}
+			results = append(results, result)
+			series = nil
+		}
+		if series == nil || lastLabels != row.Labels {
+			labels := map[string]string{}
+			if err := json.Unmarshal([]byte(row.Labels), &labels); err != nil {
+				return nil, err
+			}
+			keys := make([]string, 0, len(labels))
+			for k := range labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			labelsArray := []map[string]string{}
+			for _, k := range keys {
+				labelsArray = append(labelsArray, map[string]string{k: labels[k]})
+			}
+			series = &v3.Series{Labels: labels, LabelsArray: labelsArray, Points: []v3.Point{}}
+			result.Series = append(result.Series, series)
+			lastLabels = row.Labels
+		}
+		series.Points = append(series.Points, v3.Point{Timestamp: row.UnixMilli, Value: row.Value})
+	}
+	return results, nil
+}
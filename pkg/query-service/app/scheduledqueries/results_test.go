@@ -0,0 +1,76 @@
+package scheduledqueries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestScheduledQueryResultsRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	sq := &ScheduledQuery{Id: "sq", Spec: ScheduledQuerySpec{RetentionDays: 365}}
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{
+					Labels: map[string]string{"service": "cart", "env": "prod"},
+					Points: []v3.Point{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 2}},
+				},
+				{
+					Labels: map[string]string{"service": "checkout", "env": "prod"},
+					Points: []v3.Point{{Timestamp: 1000, Value: 3}},
+				},
+			},
+		},
+		{
+			QueryName: "B",
+			Series:    []*v3.Series{{Points: []v3.Point{{Timestamp: 2000, Value: 4}}}},
+		},
+	}
+
+	rows, err := resultsToRows(sq, results, 3000)
+	require.Nil(err)
+	require.Len(rows, 4)
+	require.Equal(`{"env":"prod","service":"cart"}`, rows[0].Labels)
+	require.Equal(int64(3000), rows[0].RunAt)
+	require.Equal(uint16(365), rows[0].RetentionDays)
+	require.Equal("{}", rows[3].Labels)
+
+	read, err := rowsToResults(rows)
+	require.Nil(err)
+	require.Len(read, 2)
+	require.Len(read[0].Series, 2)
+	require.Equal(results[0].Series[0].Labels, read[0].Series[0].Labels)
+	require.Equal(
+		[]map[string]string{{"env": "prod"}, {"service": "cart"}}, read[0].Series[0].LabelsArray,
+	)
+	require.Equal(results[0].Series[0].Points, read[0].Series[0].Points)
+	require.Equal("B", read[1].QueryName)
+	require.Equal([]v3.Point{{Timestamp: 2000, Value: 4}}, read[1].Series[0].Points)
+}
+
+func TestScheduledQuerySpecValidation(t *testing.T) {
+	spec := ScheduledQuerySpec{
+		Schedule: "0 0 * * *",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypePromQL,
+			PanelType: v3.PanelTypeGraph,
+			PromQueries: map[string]*v3.PromQuery{
+				"A": {Query: "sum(rate(orders_total[5m]))"},
+			},
+		},
+	}
+	require.Nil(t, spec.IsValid())
+
+	spec.Schedule = "every day"
+	require.NotNil(t, spec.IsValid())
+
+	spec.Schedule = "@daily"
+	require.Nil(t, spec.IsValid())
+
+	spec.CompositeQuery.PanelType = v3.PanelTypeList
+	require.NotNil(t, spec.IsValid(), "only graph and value queries can be scheduled")
+}
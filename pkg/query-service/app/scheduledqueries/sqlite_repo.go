@@ -0,0 +1,200 @@
+package scheduledqueries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS scheduled_queries(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			last_run_at TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure scheduled queries schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for scheduled queries: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]ScheduledQuery, *model.ApiError) {
+	scheduledQueries := []ScheduledQuery{}
+
+	err := r.db.SelectContext(ctx, &scheduledQueries, `
+		SELECT id, name, spec_json, last_run_at, last_error, created_by, created_at, updated_by, updated_at
+		FROM scheduled_queries
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query scheduled queries: %w", err,
+		))
+	}
+	return scheduledQueries, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*ScheduledQuery, *model.ApiError) {
+	scheduledQueries := []ScheduledQuery{}
+
+	err := r.db.SelectContext(ctx, &scheduledQueries, `
+		SELECT id, name, spec_json, last_run_at, last_error, created_by, created_at, updated_by, updated_at
+		FROM scheduled_queries
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query scheduled query %s: %w", id, err,
+		))
+	}
+
+	if len(scheduledQueries) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("scheduled query %s not found", id))
+	}
+	return &scheduledQueries[0], nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM scheduled_queries WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query scheduled queries: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a scheduled query named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableScheduledQuery,
+) (*ScheduledQuery, *model.ApiError) {
+	now := time.Now()
+	scheduledQuery := &ScheduledQuery{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, scheduledQuery.Name, scheduledQuery.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO scheduled_queries (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		scheduledQuery.Id, scheduledQuery.Name, scheduledQuery.Spec,
+		scheduledQuery.CreatedBy, scheduledQuery.CreatedAt,
+		scheduledQuery.UpdatedBy, scheduledQuery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert scheduled query: %w", err,
+		))
+	}
+
+	return scheduledQuery, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, scheduledQuery *ScheduledQuery,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, scheduledQuery.Name, scheduledQuery.Id); apiErr != nil {
+		return apiErr
+	}
+
+	scheduledQuery.UpdatedBy = userId
+	scheduledQuery.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE scheduled_queries
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		scheduledQuery.Name, scheduledQuery.Spec,
+		scheduledQuery.UpdatedBy, scheduledQuery.UpdatedAt, scheduledQuery.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update scheduled query %s: %w", scheduledQuery.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) updateLastRun(
+	ctx context.Context, id string, runAt time.Time, lastError string,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE scheduled_queries SET last_run_at = $1, last_error = $2 WHERE id = $3
+	`, runAt, lastError, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update last run of scheduled query %s: %w", id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM scheduled_queries WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete scheduled query %s: %w", id, err,
+		))
+	}
+	return nil
+}
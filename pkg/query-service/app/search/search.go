@@ -0,0 +1,204 @@
+// Package search finds dashboards, alert rules, saved views, pipelines,
+// services and channels by name, description and labels, e.g. for a
+// command palette.
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+type ResourceType string
+
+const (
+	ResourceDashboards ResourceType = "dashboards"
+	ResourceRules      ResourceType = "rules"
+	ResourceSavedViews ResourceType = "saved_views"
+	ResourcePipelines  ResourceType = "pipelines"
+	ResourceServices   ResourceType = "services"
+	ResourceChannels   ResourceType = "channels"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// points a query term scores depending on where it matches, only the best
+// match of each term counts
+const (
+	scoreNameExact    = 100
+	scoreNamePrefix   = 60
+	scoreNameWord     = 40
+	scoreNameContains = 25
+	scoreLabel        = 15
+	scoreDescription  = 5
+)
+
+// Item is a searchable resource
+type Item struct {
+	Type        ResourceType `json:"type"`
+	Id          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	// Labels are the tags of the resource, and key=value labels of rules
+	Labels []string `json:"labels"`
+}
+
+type Result struct {
+	Item
+	Score int `json:"score"`
+}
+
+// Source is implemented by the searchable resources
+type Source interface {
+	// ListSearchable returns all the resources
+	ListSearchable(ctx context.Context) ([]Item, *model.ApiError)
+}
+
+type Query struct {
+	Text          string
+	ResourceTypes []ResourceType
+	Limit         int
+}
+
+func (q *Query) IsValid() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return fmt.Errorf("search text is required")
+	}
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	return nil
+}
+
+type Search struct {
+	sources map[ResourceType]Source
+}
+
+func NewSearch(sources map[ResourceType]Source) *Search {
+	return &Search{
+		sources: sources,
+	}
+}
+
+// Search returns the resources matching all the terms of the query, the
+// best matches first. Sources which can't be listed, e.g. services while
+// ClickHouse is unreachable, are left out rather than failing the search.
+func (s *Search) Search(ctx context.Context, query Query) ([]Result, *model.ApiError) {
+	if err := query.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	for _, resourceType := range query.ResourceTypes {
+		if _, ok := s.sources[resourceType]; !ok {
+			return nil, model.BadRequest(fmt.Errorf("unsupported resource type in search: %s", resourceType))
+		}
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	items := []Item{}
+	for resourceType, source := range s.sources {
+		if len(query.ResourceTypes) > 0 && !slices.Contains(query.ResourceTypes, resourceType) {
+			continue
+		}
+		searchable, apiErr := source.ListSearchable(ctx)
+		if apiErr != nil {
+			zap.L().Warn("could not list resources to search",
+				zap.String("type", string(resourceType)), zap.Error(apiErr.ToError()),
+			)
+			continue
+		}
+		for _, item := range searchable {
+			item.Type = resourceType
+			items = append(items, item)
+		}
+	}
+
+	results := rank(query.Text, items)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// rank scores the items matching all the terms of the query and sorts them
+// by score, the shorter names first on ties as they match more closely
+func rank(text string, items []Item) []Result {
+	query := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	terms := strings.Fields(query)
+
+	results := []Result{}
+	for _, item := range items {
+		name := strings.ToLower(item.Name)
+		total := 0
+		for _, term := range terms {
+			termScore := score(term, name, item)
+			if termScore == 0 {
+				total = 0
+				break
+			}
+			total += termScore
+		}
+		if total == 0 {
+			continue
+		}
+		if len(terms) > 1 && name == query {
+			total += scoreNameExact
+		}
+		results = append(results, Result{Item: item, Score: total})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if len(results[i].Name) != len(results[j].Name) {
+			return len(results[i].Name) < len(results[j].Name)
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Type < results[j].Type
+	})
+	return results
+}
+
+func score(term string, name string, item Item) int {
+	switch {
+	case name == term:
+		return scoreNameExact
+	case strings.HasPrefix(name, term):
+		return scoreNamePrefix
+	}
+	for _, word := range strings.FieldsFunc(name, isSeparator) {
+		if strings.HasPrefix(word, term) {
+			return scoreNameWord
+		}
+	}
+	if strings.Contains(name, term) {
+		return scoreNameContains
+	}
+	for _, label := range item.Labels {
+		if strings.Contains(strings.ToLower(label), term) {
+			return scoreLabel
+		}
+	}
+	if strings.Contains(strings.ToLower(item.Description), term) {
+		return scoreDescription
+	}
+	return 0
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type testSource struct {
+	items []Item
+	err   *model.ApiError
+}
+
+func (s *testSource) ListSearchable(ctx context.Context) ([]Item, *model.ApiError) {
+	return s.items, s.err
+}
+
+func TestSearch(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	search := NewSearch(map[ResourceType]Source{
+		ResourceDashboards: &testSource{items: []Item{
+			{Id: "d1", Name: "Checkout overview", Labels: []string{"payments"}},
+			{Id: "d2", Name: "Kafka lag", Description: "consumer lag of the checkout topics", Labels: []string{}},
+			{Id: "d3", Name: "Infra", Labels: []string{}},
+		}},
+		ResourceRules: &testSource{items: []Item{
+			{Id: "1", Name: "High error rate in payment checkout", Labels: []string{"severity=critical"}},
+		}},
+		ResourceServices: &testSource{items: []Item{
+			{Id: "checkout", Name: "checkout", Labels: []string{}},
+			{Id: "checkoutservice", Name: "checkoutservice", Labels: []string{}},
+		}},
+		ResourceChannels: &testSource{err: model.InternalError(fmt.Errorf("unreachable"))},
+	})
+
+	results, apiErr := search.Search(ctx, Query{Text: "checkout"})
+	require.Nil(apiErr)
+	ids := []string{}
+	for _, r := range results {
+		ids = append(ids, r.Id)
+	}
+	require.Equal([]string{"checkout", "checkoutservice", "d1", "1", "d2"}, ids)
+	require.Equal(ResourceServices, results[0].Type)
+	require.Equal(scoreNameExact, results[0].Score)
+
+	// all the terms must match
+	results, apiErr = search.Search(ctx, Query{Text: "checkout critical"})
+	require.Nil(apiErr)
+	require.Len(results, 1)
+	require.Equal(ResourceRules, results[0].Type)
+	require.Equal(scoreNameWord+scoreLabel, results[0].Score)
+
+	results, apiErr = search.Search(ctx, Query{Text: "Checkout", ResourceTypes: []ResourceType{ResourceDashboards}, Limit: 1})
+	require.Nil(apiErr)
+	require.Len(results, 1)
+	require.Equal("d1", results[0].Id)
+
+	_, apiErr = search.Search(ctx, Query{Text: " "})
+	require.NotNil(apiErr)
+	_, apiErr = search.Search(ctx, Query{Text: "checkout", ResourceTypes: []ResourceType{"traces"}})
+	require.NotNil(apiErr)
+}
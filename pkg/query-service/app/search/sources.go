@@ -0,0 +1,50 @@
+package search
+
+import (
+	"context"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ServicesSource searches the services which sent spans in the last day
+type ServicesSource struct {
+	Reader interfaces.Reader
+}
+
+func (s *ServicesSource) ListSearchable(ctx context.Context) ([]Item, *model.ApiError) {
+	services, err := s.Reader.GetServicesList(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []Item{}
+	for _, service := range *services {
+		items = append(items, Item{Id: service, Name: service, Labels: []string{}})
+	}
+	return items, nil
+}
+
+// ChannelsSource searches the notification channels, labelled with their
+// type, e.g. slack
+type ChannelsSource struct {
+	Reader interfaces.Reader
+}
+
+func (s *ChannelsSource) ListSearchable(ctx context.Context) ([]Item, *model.ApiError) {
+	channels, apiErr := s.Reader.GetChannels()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := []Item{}
+	for _, channel := range *channels {
+		items = append(items, Item{
+			Id:     strconv.Itoa(channel.Id),
+			Name:   channel.Name,
+			Labels: []string{channel.Type},
+		})
+	}
+	return items, nil
+}
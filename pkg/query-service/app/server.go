@@ -10,6 +10,9 @@ import (
 	"net/http"
 	_ "net/http/pprof" // http profiler
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/handlers"
@@ -21,19 +24,46 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/datadeletion"
+	"go.signoz.io/signoz/pkg/query-service/app/deliveryprofiles"
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/app/ingestionkeys"
+	"go.signoz.io/signoz/pkg/query-service/app/insertsettings"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/kafkareceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/keyusage"
+	"go.signoz.io/signoz/pkg/query-service/app/loadtests"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logreceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/lookuptables"
+	"go.signoz.io/signoz/pkg/query-service/app/metricexports"
+	"go.signoz.io/signoz/pkg/query-service/app/metricowners"
+	"go.signoz.io/signoz/pkg/query-service/app/obfuscation"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/app/querier"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimits"
+	"go.signoz.io/signoz/pkg/query-service/app/quotas"
+	"go.signoz.io/signoz/pkg/query-service/app/savedqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/scheduledqueries"
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/app/slackapp"
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/app/tracereceivers"
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 
 	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/filtersnippets"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/cache"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/dao"
 	"go.signoz.io/signoz/pkg/query-service/featureManager"
 	"go.signoz.io/signoz/pkg/query-service/healthcheck"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -41,6 +71,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
 )
 
@@ -81,6 +112,15 @@ type Server struct {
 
 	opampServer *opamp.Server
 
+	pipelineWatchdog *logparsingpipeline.Watchdog
+	staleAgents      *opAmpModel.StaleAgentWatcher
+	configDrift      *opAmpModel.ConfigDriftWatcher
+	rollouts         *agentConf.RolloutWatcher
+
+	scheduledQueries *scheduledqueries.Controller
+	metricExports    *metricexports.Controller
+	trash            *trash.Trash
+
 	unavailableChannel chan healthcheck.Status
 }
 
@@ -96,6 +136,10 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	if err := auth.InitSigningKeys(context.Background()); err != nil {
+		return nil, err
+	}
+
 	localDB, err := dashboards.InitDB(constants.RELATIONAL_DATASOURCE_PATH)
 	explorer.InitWithDSN(constants.RELATIONAL_DATASOURCE_PATH)
 
@@ -137,8 +181,36 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		}
 	}
 
+	filterSnippetsController, err := filtersnippets.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create filter snippets controller: %w", err,
+		)
+	}
+
+	quotasController, err := quotas.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create org quotas controller: %w", err,
+		)
+	}
+
+	queryLimitsController, err := querylimits.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create query limits controller: %w", err,
+		)
+	}
+
+	obfuscationController, err := obfuscation.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create obfuscation profiles controller: %w", err,
+		)
+	}
+
 	<-readerReady
-	rm, err := makeRulesManager(serverOptions.PromConfigPath, constants.GetAlertManagerApiPrefix(), serverOptions.RuleRepoURL, localDB, reader, serverOptions.DisableRules, fm)
+	rm, err := makeRulesManager(serverOptions.PromConfigPath, constants.GetAlertManagerApiPrefix(), serverOptions.RuleRepoURL, localDB, reader, serverOptions.DisableRules, fm, filterSnippetsController)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +241,160 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	lookupTablesController, err := lookuptables.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create lookup tables controller: %w", err,
+		)
+	}
+
+	metricOwnersController, err := metricowners.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create metric owners controller: %w", err,
+		)
+	}
+
+	logExportsController, err := logexports.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create log exports controller: %w", err,
+		)
+	}
+
+	kafkaReceiversController, err := kafkareceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create kafka receivers controller: %w", err,
+		)
+	}
+
+	traceReceiversController, err := tracereceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create trace receivers controller: %w", err,
+		)
+	}
+
+	logReceiversController, err := logreceivers.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create log receivers controller: %w", err,
+		)
+	}
+
+	deliveryProfilesController, err := deliveryprofiles.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create delivery profiles controller: %w", err,
+		)
+	}
+
+	insertSettingsController, err := insertsettings.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create clickhouse insert settings controller: %w", err,
+		)
+	}
+
+	keyUsageController, err := keyusage.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create attribute key usage controller: %w", err,
+		)
+	}
+
+	ingestionKeysController, err := ingestionkeys.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create ingestion keys controller: %w", err,
+		)
+	}
+
+	incidentsController, err := incidents.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create incidents controller: %w", err,
+		)
+	}
+	rm.AddAlertListener(incidentsController.OnAlerts)
+
+	slackAppController, err := slackapp.NewController(incidentsController)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create slack app controller: %w", err,
+		)
+	}
+	rm.AddAlertListener(slackAppController.OnAlerts)
+
+	scheduledQueriesController, err := scheduledqueries.NewController(
+		localDB,
+		reader,
+		querier.NewQuerier(querier.QuerierOptions{
+			Reader:        reader,
+			Cache:         c,
+			KeyGenerator:  queryBuilder.NewKeyGenerator(),
+			FluxInterval:  fluxInterval,
+			FeatureLookup: fm,
+		}),
+		PrepareQueryRangeParams,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create scheduled queries controller: %w", err,
+		)
+	}
+
+	savedQueriesController, err := savedqueries.NewController(localDB)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create saved queries controller: %w", err,
+		)
+	}
+
+	dataDeletionController, err := datadeletion.NewController(localDB, reader, keyUsageController)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create data deletion controller: %w", err,
+		)
+	}
+
+	loadTestsController, err := loadtests.NewController(localDB, reader)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create load tests controller: %w", err,
+		)
+	}
+
+	metricExportsController, err := metricexports.NewController(localDB, reader)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't create metric exports controller: %w", err,
+		)
+	}
+
+	// deleted resources are kept restorable until purged
+	trashController := trash.NewTrash(map[trash.ResourceType]trash.Store{
+		trash.ResourceDashboards: &dashboards.TrashStore{FeatureFlags: fm},
+		trash.ResourceRules:      rm,
+		trash.ResourcePipelines:  logParsingPipelineController,
+	})
+
+	taggingController := tagging.NewTagging(map[tagging.ResourceType]tagging.Store{
+		tagging.ResourceDashboards: &dashboards.TagStore{FeatureFlags: fm},
+		tagging.ResourceRules:      rm,
+		tagging.ResourceSavedViews: &explorer.TagStore{},
+	})
+
+	searchController := search.NewSearch(map[search.ResourceType]search.Source{
+		search.ResourceDashboards: &dashboards.SearchSource{},
+		search.ResourceRules:      rm,
+		search.ResourceSavedViews: &explorer.SearchSource{},
+		search.ResourcePipelines:  logParsingPipelineController,
+		search.ResourceServices:   &search.ServicesSource{Reader: reader},
+		search.ResourceChannels:   &search.ChannelsSource{Reader: reader},
+	})
+
 	telemetry.GetInstance().SetReader(reader)
 	apiHandler, err := NewAPIHandler(APIHandlerOpts{
 		Reader:                        reader,
@@ -183,6 +409,30 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		FeatureFlags:                  fm,
 		IntegrationsController:        integrationsController,
 		LogsParsingPipelineController: logParsingPipelineController,
+		LookupTablesController:        lookupTablesController,
+		MetricOwnersController:        metricOwnersController,
+		LogExportsController:          logExportsController,
+		KafkaReceiversController:      kafkaReceiversController,
+		TraceReceiversController:      traceReceiversController,
+		LogReceiversController:        logReceiversController,
+		DeliveryProfilesController:    deliveryProfilesController,
+		InsertSettingsController:      insertSettingsController,
+		KeyUsageController:            keyUsageController,
+		IngestionKeysController:       ingestionKeysController,
+		FilterSnippetsController:      filterSnippetsController,
+		QuotasController:              quotasController,
+		QueryLimitsController:         queryLimitsController,
+		ObfuscationController:         obfuscationController,
+		IncidentsController:           incidentsController,
+		SlackAppController:            slackAppController,
+		ScheduledQueriesController:    scheduledQueriesController,
+		SavedQueriesController:        savedQueriesController,
+		DataDeletionController:        dataDeletionController,
+		LoadTestsController:           loadTestsController,
+		MetricExportsController:       metricExportsController,
+		Trash:                         trashController,
+		Tagging:                       taggingController,
+		Search:                        searchController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 	})
@@ -194,6 +444,9 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		// logger: logger,
 		// tracer: tracer,
 		ruleManager:        rm,
+		scheduledQueries:   scheduledQueriesController,
+		metricExports:      metricExportsController,
+		trash:              trashController,
 		serverOptions:      serverOptions,
 		unavailableChannel: make(chan healthcheck.Status),
 	}
@@ -223,23 +476,252 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		DBEngine: "sqlite",
 		AgentFeatures: []agentConf.AgentFeature{
 			logParsingPipelineController,
+			lookupTablesController,
+			metricOwnersController,
+			logExportsController,
+			kafkaReceiversController,
+			traceReceiversController,
+			logReceiversController,
+			deliveryProfilesController,
+			insertSettingsController,
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	opampAuth, err := opamp.AuthSettingsFromEnv()
+	if err != nil {
+		return nil, err
+	}
 	s.opampServer = opamp.InitializeServer(
-		&opAmpModel.AllAgents, agentConfMgr,
+		&opAmpModel.AllAgents, agentConfMgr, opampAuth,
 	)
 
+	s.pipelineWatchdog = NewLogPipelineWatchdog(
+		logParsingPipelineController,
+		querier.NewQuerier(querier.QuerierOptions{
+			Reader:        reader,
+			Cache:         c,
+			KeyGenerator:  queryBuilder.NewKeyGenerator(),
+			FluxInterval:  fluxInterval,
+			FeatureLookup: fm,
+		}),
+		rm,
+	)
+	s.staleAgents = NewStaleAgentWatcher(rm)
+	s.configDrift = NewConfigDriftWatcher(rm)
+	s.rollouts = NewRolloutWatcher(rm)
+
 	return s, nil
 }
 
+// NewLogPipelineWatchdog creates the watchdog pausing log pipelines that lose
+// logs after being deployed, alerting through the rule manager when it does
+func NewLogPipelineWatchdog(
+	controller *logparsingpipeline.LogParsingPipelineController,
+	querier interfaces.Querier,
+	rm *rules.Manager,
+) *logparsingpipeline.Watchdog {
+	return logparsingpipeline.NewWatchdog(controller, querier, func(
+		ctx context.Context, event logparsingpipeline.WatchdogEvent,
+	) {
+		alertLabels := map[string]string{
+			labels.AlertNameLabel: "Log pipeline paused",
+			"pipeline":            event.PipelineName,
+		}
+		if levels := rm.GetSeverityLevels(); len(levels) > 0 {
+			alertLabels[rules.SeverityLabel] = levels[0].Name
+		}
+		rm.SendAlerts(ctx, &rules.Alert{
+			State:  rules.StateFiring,
+			Labels: labels.FromMap(alertLabels),
+			Annotations: labels.FromMap(map[string]string{
+				"summary": fmt.Sprintf("Log pipeline %s was paused", event.PipelineName),
+				"description": fmt.Sprintf(
+					"Pipeline %s dropped %.0f of %.0f logs (%.2f%%) after being deployed and was disabled in pipelines version %d.",
+					event.PipelineName, event.Dropped, event.Received, event.DropRatePercent, event.Version,
+				),
+			}),
+			Value:   event.DropRatePercent,
+			FiredAt: event.CreatedAt,
+		})
+	})
+}
+
+// NewStaleAgentWatcher creates the watcher alerting through the rule manager
+// on the agents that stopped reporting
+func NewStaleAgentWatcher(rm *rules.Manager) *opAmpModel.StaleAgentWatcher {
+	return opAmpModel.NewStaleAgentWatcher(&opAmpModel.AllAgents, func(
+		ctx context.Context,
+		agent opAmpModel.AgentView,
+		settings opAmpModel.StaleAgentAlertSettings,
+		resolved bool,
+	) {
+		alertLabels := map[string]string{
+			labels.AlertNameLabel: "Agent stopped reporting",
+			"agent":               agent.InstanceUID,
+			"hostname":            agent.Hostname,
+		}
+		if settings.Severity != "" {
+			alertLabels[rules.SeverityLabel] = settings.Severity
+		} else if levels := rm.GetSeverityLevels(); len(levels) > 0 {
+			alertLabels[rules.SeverityLabel] = levels[0].Name
+		}
+
+		now := time.Now()
+		alert := &rules.Alert{
+			State:  rules.StateFiring,
+			Labels: labels.FromMap(alertLabels),
+			Annotations: labels.FromMap(map[string]string{
+				"summary": fmt.Sprintf("Agent %s stopped reporting", agent.InstanceUID),
+				"description": fmt.Sprintf(
+					"Agent %s on host %s was last seen at %s.",
+					agent.InstanceUID, agent.Hostname, agent.LastSeenAt.UTC().Format(time.RFC3339),
+				),
+			}),
+			Receivers:  settings.Channels,
+			FiredAt:    agent.LastSeenAt,
+			ValidUntil: now.Add(4 * time.Minute),
+		}
+		if resolved {
+			alert.State = rules.StateInactive
+			alert.ResolvedAt = now
+		}
+		rm.SendAlerts(ctx, alert)
+	})
+}
+
+// NewConfigDriftWatcher creates the watcher alerting through the rule manager
+// on the agents running another config than the recommended one
+func NewConfigDriftWatcher(rm *rules.Manager) *opAmpModel.ConfigDriftWatcher {
+	return opAmpModel.NewConfigDriftWatcher(&opAmpModel.AllAgents, func(
+		ctx context.Context,
+		drift opAmpModel.ConfigDrift,
+		settings opAmpModel.ConfigDriftAlertSettings,
+		resolved bool,
+	) {
+		alertLabels := map[string]string{
+			labels.AlertNameLabel: "Agent config drifted",
+			"agent":               drift.InstanceUID,
+			"hostname":            drift.Hostname,
+		}
+		if settings.Severity != "" {
+			alertLabels[rules.SeverityLabel] = settings.Severity
+		} else if levels := rm.GetSeverityLevels(); len(levels) > 0 {
+			alertLabels[rules.SeverityLabel] = levels[0].Name
+		}
+
+		description := fmt.Sprintf(
+			"Agent %s on host %s runs another config than the recommended one since %s.",
+			drift.InstanceUID, drift.Hostname, drift.DriftedSince.UTC().Format(time.RFC3339),
+		)
+		for _, section := range []struct {
+			name  string
+			paths []string
+		}{
+			{"Added", drift.Added}, {"Removed", drift.Removed}, {"Changed", drift.Changed},
+		} {
+			if len(section.paths) > 0 {
+				description += fmt.Sprintf(" %s: %s.", section.name, strings.Join(section.paths, ", "))
+			}
+		}
+
+		now := time.Now()
+		alert := &rules.Alert{
+			State:  rules.StateFiring,
+			Labels: labels.FromMap(alertLabels),
+			Annotations: labels.FromMap(map[string]string{
+				"summary":     fmt.Sprintf("Config of agent %s drifted from the recommended one", drift.InstanceUID),
+				"description": description,
+			}),
+			Receivers:  settings.Channels,
+			FiredAt:    *drift.DriftedSince,
+			ValidUntil: now.Add(4 * time.Minute),
+		}
+		if resolved {
+			alert.State = rules.StateInactive
+			alert.ResolvedAt = now
+		}
+		rm.SendAlerts(ctx, alert)
+	})
+}
+
+// NewRolloutWatcher creates the watcher notifying about agent config
+// rollouts through the rule manager
+func NewRolloutWatcher(rm *rules.Manager) *agentConf.RolloutWatcher {
+	return agentConf.NewRolloutWatcher(func(
+		ctx context.Context,
+		rollout agentConf.RolloutNotification,
+		settings agentConf.RolloutNotificationSettings,
+	) {
+		severity := settings.Severity
+		if levels := rm.GetSeverityLevels(); severity == "" && len(levels) > 0 {
+			severity = levels[0].Name
+		}
+		rm.NotifyChannels(ctx, rolloutEvent(rollout, settings.Channels, severity, time.Now()))
+	})
+}
+
+// rolloutEvent is the event notified to the channels about a rollout.
+// Rollouts don't resolve, so they are posted as events rather than sent as
+// alerts, which the alert manager would notify as resolved.
+func rolloutEvent(
+	rollout agentConf.RolloutNotification, channels []string, severity string, at time.Time,
+) *rules.ChannelEvent {
+	eventLabels := map[string]string{
+		labels.AlertNameLabel: "Agent config rollout",
+		"elementType":         string(rollout.ElementType),
+		"version":             strconv.Itoa(rollout.Version),
+		"status":              string(rollout.Status),
+	}
+	if severity != "" {
+		eventLabels[rules.SeverityLabel] = severity
+	}
+
+	var summary string
+	switch rollout.Status {
+	case agentConf.Deployed:
+		summary = fmt.Sprintf("Version %d of %s was deployed to the agents", rollout.Version, rollout.ElementType)
+	case agentConf.DeployFailed:
+		summary = fmt.Sprintf("Version %d of %s failed to deploy", rollout.Version, rollout.ElementType)
+	default:
+		summary = fmt.Sprintf(
+			"Version %d of %s is being deployed since %s",
+			rollout.Version, rollout.ElementType, rollout.InProgressSince.UTC().Format(time.RFC3339),
+		)
+	}
+
+	description := summary + "."
+	if rollout.ChangeNote != "" {
+		description += fmt.Sprintf(" Change: %s.", rollout.ChangeNote)
+	}
+	if len(rollout.DeployedAgents) > 0 {
+		description += fmt.Sprintf(" Deployed to: %s.", strings.Join(rollout.DeployedAgents, ", "))
+	}
+	failed := make([]string, 0, len(rollout.FailedAgents))
+	for agent, message := range rollout.FailedAgents {
+		failed = append(failed, fmt.Sprintf("%s (%s)", agent, message))
+	}
+	sort.Strings(failed)
+	if len(failed) > 0 {
+		description += fmt.Sprintf(" Failed on: %s.", strings.Join(failed, ", "))
+	}
+
+	return &rules.ChannelEvent{
+		Labels:      eventLabels,
+		Summary:     summary,
+		Description: description,
+		At:          at,
+		Channels:    channels,
+	}
+}
+
 func (s *Server) createPrivateServer(api *APIHandler) (*http.Server, error) {
 
 	r := NewRouter()
 
+	r.Use(instrumentation.Middleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddlewarePrivate)
@@ -266,18 +748,42 @@ func (s *Server) createPublicServer(api *APIHandler) (*http.Server, error) {
 
 	r := NewRouter()
 
+	r.Use(instrumentation.Middleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddleware)
 
 	am := NewAuthMiddleware(auth.GetUserFromRequest)
+	r.Use(api.LookbackLimitMiddleware(am))
 
 	api.RegisterRoutes(r, am)
 	api.RegisterMetricsRoutes(r, am)
 	api.RegisterLogsRoutes(r, am)
 	api.RegisterIntegrationRoutes(r, am)
+	api.RegisterLookupTableRoutes(r, am)
+	api.RegisterMetricOwnerRoutes(r, am)
+	api.RegisterLogExportRoutes(r, am)
+	api.RegisterKafkaRoutes(r, am)
+	api.RegisterTraceReceiversRoutes(r, am)
+	api.RegisterLogReceiversRoutes(r, am)
+	api.RegisterIngestionKeyRoutes(r, am)
+	api.RegisterFilterSnippetRoutes(r, am)
+	api.RegisterQuotaRoutes(r, am)
+	api.RegisterQueryLimitRoutes(r, am)
+	api.RegisterObfuscationProfileRoutes(r, am)
+	api.RegisterScheduledQueryRoutes(r, am)
+	api.RegisterSavedQueryRoutes(r, am)
+	api.RegisterDataDeletionRoutes(r, am)
+	api.RegisterLoadTestRoutes(r, am)
+	api.RegisterMetricExportRoutes(r, am)
+	api.RegisterTrashRoutes(r, am)
+	api.RegisterTagRoutes(r, am)
+	api.RegisterSearchRoutes(r, am)
+	api.RegisterAgentConfigRoutes(r, am)
+	api.RegisterIncidentRoutes(r, am)
 	api.RegisterQueryRangeV3Routes(r, am)
 	api.RegisterQueryRangeV4Routes(r, am)
+	api.RegisterCapabilityRoutes(r, am)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
@@ -564,6 +1070,14 @@ func (s *Server) Start() error {
 
 	}()
 
+	s.pipelineWatchdog.Start()
+	s.staleAgents.Start()
+	s.configDrift.Start()
+	s.rollouts.Start()
+	s.scheduledQueries.Start()
+	s.metricExports.Start()
+	s.trash.Start()
+
 	go func() {
 		zap.S().Info("Starting OpAmp Websocket server", zap.String("addr", constants.OpAmpWsEndpoint))
 		err := s.opampServer.Start(constants.OpAmpWsEndpoint)
@@ -591,6 +1105,34 @@ func (s *Server) Stop() error {
 
 	s.opampServer.Stop()
 
+	if s.pipelineWatchdog != nil {
+		s.pipelineWatchdog.Stop()
+	}
+
+	if s.staleAgents != nil {
+		s.staleAgents.Stop()
+	}
+
+	if s.configDrift != nil {
+		s.configDrift.Stop()
+	}
+
+	if s.rollouts != nil {
+		s.rollouts.Stop()
+	}
+
+	if s.scheduledQueries != nil {
+		s.scheduledQueries.Stop()
+	}
+
+	if s.metricExports != nil {
+		s.metricExports.Stop()
+	}
+
+	if s.trash != nil {
+		s.trash.Stop()
+	}
+
 	if s.ruleManager != nil {
 		s.ruleManager.Stop()
 	}
@@ -605,7 +1147,8 @@ func makeRulesManager(
 	db *sqlx.DB,
 	ch interfaces.Reader,
 	disableRules bool,
-	fm interfaces.FeatureLookup) (*rules.Manager, error) {
+	fm interfaces.FeatureLookup,
+	filterSnippets *filtersnippets.Controller) (*rules.Manager, error) {
 
 	// create engine
 	pqle, err := pqle.FromReader(ch)
@@ -633,6 +1176,8 @@ func makeRulesManager(
 		Logger:       nil,
 		DisableRules: disableRules,
 		FeatureFlags: fm,
+
+		ExpandFilterSnippets: filterSnippets.ExpandFilterSnippets,
 	}
 
 	// create Manager
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
 func TestGetRouteContextTimeout(t *testing.T) {
@@ -39,3 +42,39 @@ func TestGetRouteContextTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestRolloutEvent(t *testing.T) {
+	since := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := since.Add(time.Hour)
+	rollout := agentConf.RolloutNotification{
+		ElementType:     agentConf.ElementTypeLogPipelines,
+		Version:         3,
+		Status:          agentConf.DeployFailed,
+		ChangeNote:      "parse the request ids",
+		InProgressSince: &since,
+		DeployedAgents:  []string{"agent-1"},
+		FailedAgents:    map[string]string{"agent-3": "bad regex", "agent-2": "timeout"},
+	}
+
+	event := rolloutEvent(rollout, []string{"ops-slack"}, "critical", at)
+	assert.Equal(t, &rules.ChannelEvent{
+		Labels: map[string]string{
+			labels.AlertNameLabel: "Agent config rollout",
+			"elementType":         string(agentConf.ElementTypeLogPipelines),
+			"version":             "3",
+			"status":              string(agentConf.DeployFailed),
+			rules.SeverityLabel:   "critical",
+		},
+		Summary: "Version 3 of log_pipelines failed to deploy",
+		Description: "Version 3 of log_pipelines failed to deploy. Change: parse the request ids. " +
+			"Deployed to: agent-1. Failed on: agent-2 (timeout), agent-3 (bad regex).",
+		At:       at,
+		Channels: []string{"ops-slack"},
+	}, event)
+
+	rollout.Status = agentConf.DeployInitiated
+	event = rolloutEvent(rollout, nil, "", at)
+	assert.Equal(t, "Version 3 of log_pipelines is being deployed since 2024-03-01T10:00:00Z", event.Summary)
+	assert.NotContains(t, event.Labels, rules.SeverityLabel)
+	assert.Empty(t, event.Channels, "the events without channels are posted to all of them")
+}
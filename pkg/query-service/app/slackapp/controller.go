@@ -0,0 +1,460 @@
+package slackapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	slackApiURL = "https://slack.com/api/"
+
+	// slack signs interaction requests with a timestamp, older requests are
+	// rejected to prevent replays
+	maxRequestAge = 5 * time.Minute
+
+	// slack limits the value of a button to 2000 characters
+	maxButtonValueLength = 2000
+
+	// MaxInteractionSize caps the size of interaction requests, which are
+	// read before their signature can be checked
+	MaxInteractionSize = 1 << 20
+
+	// messages of alerts firing for longer are no longer updated when they
+	// resolve, e.g. alerts of deleted rules never resolve
+	maxMessageAge = 7 * 24 * time.Hour
+)
+
+// Controller posts firing alerts to a slack channel with buttons to
+// acknowledge, silence and open them, and handles the clicks on these
+// buttons sent back by slack.
+type Controller struct {
+	botToken      string
+	signingSecret string
+	channel       string
+	apiURL        string
+	client        *http.Client
+
+	alertManager am.Manager
+	incidents    *incidents.Controller
+
+	messagesMtx sync.Mutex
+	// messages posted for firing alerts by fingerprint
+	messages map[string]postedMessage
+}
+
+func NewController(incidentsController *incidents.Controller) (*Controller, error) {
+	alertManager, err := am.New("")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create alertmanager client: %w", err)
+	}
+
+	return &Controller{
+		botToken:      constants.SlackAppBotToken,
+		signingSecret: constants.SlackAppSigningSecret,
+		channel:       constants.SlackAppChannel,
+		apiURL:        slackApiURL,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		alertManager:  alertManager,
+		incidents:     incidentsController,
+		messages:      map[string]postedMessage{},
+	}, nil
+}
+
+func (c *Controller) Enabled() bool {
+	return c.botToken != "" && c.channel != ""
+}
+
+type alertInfo struct {
+	labels       incidents.LabelSet
+	summary      string
+	description  string
+	generatorURL string
+	resolved     bool
+}
+
+// OnAlerts implements rules.AlertListener. A message is posted for every
+// alert that starts firing and updated once it resolves.
+func (c *Controller) OnAlerts(ctx context.Context, alerts ...*rules.Alert) {
+	if !c.Enabled() {
+		return
+	}
+
+	infos := []alertInfo{}
+	for _, a := range alerts {
+		alertLabels := incidents.LabelSet(a.Labels.Map())
+		if alertLabels[labels.AlertRuleIdLabel] == "" ||
+			strings.HasSuffix(alertLabels[labels.AlertNameLabel], rules.TestAlertPostFix) {
+			continue
+		}
+		infos = append(infos, alertInfo{
+			labels:       alertLabels,
+			summary:      a.Annotations.Get("summary"),
+			description:  a.Annotations.Get("description"),
+			generatorURL: a.GeneratorURL,
+			resolved:     !a.ResolvedAt.IsZero(),
+		})
+	}
+
+	// the listeners are called outside of the rule evaluation one batch at a
+	// time, so a resolved alert is never handled before it was posted
+	c.notify(ctx, infos)
+}
+
+func (c *Controller) notify(ctx context.Context, alerts []alertInfo) {
+	c.pruneMessages(time.Now())
+	for _, a := range alerts {
+		fingerprint := a.labels.Fingerprint()
+		c.messagesMtx.Lock()
+		posted, found := c.messages[fingerprint]
+		c.messagesMtx.Unlock()
+
+		text, blocks := alertBlocks(&a)
+		if a.resolved {
+			if !found {
+				continue
+			}
+			_, err := c.call(ctx, "chat.update", &message{
+				Channel: posted.channel, Ts: posted.ts, Text: text, Blocks: blocks,
+			})
+			if err != nil {
+				zap.L().Error("could not update slack message of resolved alert", zap.Error(err))
+			}
+			c.messagesMtx.Lock()
+			delete(c.messages, fingerprint)
+			c.messagesMtx.Unlock()
+			continue
+		}
+
+		if found {
+			// firing alerts are resent periodically, they are posted only once
+			continue
+		}
+		res, err := c.call(ctx, "chat.postMessage", &message{
+			Channel: c.channel, Text: text, Blocks: blocks,
+		})
+		if err != nil {
+			zap.L().Error("could not post alert to slack", zap.Error(err))
+			continue
+		}
+		c.messagesMtx.Lock()
+		c.messages[fingerprint] = postedMessage{channel: res.Channel, ts: res.Ts, postedAt: time.Now()}
+		c.messagesMtx.Unlock()
+	}
+}
+
+// pruneMessages forgets the messages posted too long ago to be updated
+func (c *Controller) pruneMessages(now time.Time) {
+	c.messagesMtx.Lock()
+	defer c.messagesMtx.Unlock()
+
+	for fingerprint, posted := range c.messages {
+		if now.Sub(posted.postedAt) > maxMessageAge {
+			delete(c.messages, fingerprint)
+		}
+	}
+}
+
+// buttonValue encodes the labels of an alert in the value of its buttons
+// so that the alert can be found when they are clicked. Alerts with too
+// many labels are identified by their rule only.
+func buttonValue(alertLabels incidents.LabelSet) string {
+	value, _ := json.Marshal(alertLabels)
+	if len(value) > maxButtonValueLength {
+		value, _ = json.Marshal(incidents.LabelSet{
+			labels.AlertNameLabel:   alertLabels[labels.AlertNameLabel],
+			labels.AlertRuleIdLabel: alertLabels[labels.AlertRuleIdLabel],
+		})
+	}
+	return string(value)
+}
+
+func alertButtons(value string, dashboardURL string, actions ...string) []interface{} {
+	elements := []interface{}{}
+	for _, action := range actions {
+		switch action {
+		case actionAcknowledge:
+			elements = append(elements, button{
+				Type:     "button",
+				Text:     textObject{Type: "plain_text", Text: "Acknowledge"},
+				ActionId: actionAcknowledge,
+				Value:    value,
+				Style:    "primary",
+			})
+		case actionSilence1h:
+			elements = append(elements, button{
+				Type:     "button",
+				Text:     textObject{Type: "plain_text", Text: "Silence 1h"},
+				ActionId: actionSilence1h,
+				Value:    value,
+			})
+		}
+	}
+	if dashboardURL != "" {
+		elements = append(elements, button{
+			Type:     "button",
+			Text:     textObject{Type: "plain_text", Text: "Open dashboard"},
+			ActionId: actionOpenDashboard,
+			URL:      dashboardURL,
+		})
+	}
+	return elements
+}
+
+func alertBlocks(a *alertInfo) (string, []interface{}) {
+	state := "FIRING"
+	if a.resolved {
+		state = "RESOLVED"
+	}
+	text := fmt.Sprintf("[%s] %s", state, a.labels[labels.AlertNameLabel])
+
+	body := "*" + text + "*"
+	for _, annotation := range []string{a.summary, a.description} {
+		if annotation != "" {
+			body += "\n" + annotation
+		}
+	}
+
+	blocks := []interface{}{
+		block{Type: "section", Text: &textObject{Type: "mrkdwn", Text: body}},
+	}
+	if !a.resolved {
+		blocks = append(blocks, block{
+			Type:    "actions",
+			BlockId: actionsBlockId,
+			Elements: alertButtons(
+				buttonValue(a.labels), a.generatorURL, actionAcknowledge, actionSilence1h,
+			),
+		})
+	}
+	return text, blocks
+}
+
+func (c *Controller) call(ctx context.Context, method string, msg *message) (*apiResponse, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.botToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("slack %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	res := &apiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("could not decode slack %s response: %w", method, err)
+	}
+	if !res.Ok {
+		return nil, fmt.Errorf("slack %s failed: %s", method, res.Error)
+	}
+	return res, nil
+}
+
+// respond replaces the message an interaction came from
+func (c *Controller) respond(ctx context.Context, responseURL string, msg *message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("slack responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// verifySignature checks that a request was sent by slack, as described in
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid slack request timestamp %q", timestamp)
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("slack request timestamp is too far from the current time")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid slack request signature")
+	}
+	return nil
+}
+
+// HandleInteraction handles a click on a button of an alert message. The
+// request body is the form encoded interaction payload sent by slack.
+func (c *Controller) HandleInteraction(
+	ctx context.Context, header http.Header, body []byte,
+) *model.ApiError {
+	if !c.Enabled() || c.signingSecret == "" {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("slack app is not configured")}
+	}
+	if err := verifySignature(c.signingSecret, header, body, time.Now()); err != nil {
+		return model.UnauthorizedError(err)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return model.BadRequest(fmt.Errorf("could not parse slack interaction: %w", err))
+	}
+	payload := interactionPayload{}
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return model.BadRequest(fmt.Errorf("could not parse slack interaction payload: %w", err))
+	}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return nil
+	}
+
+	action := payload.Actions[0]
+	if action.ActionId == actionOpenDashboard {
+		// slack opens the url of link buttons, the click only needs to be acknowledged
+		return nil
+	}
+
+	alertLabels := incidents.LabelSet{}
+	if err := json.Unmarshal([]byte(action.Value), &alertLabels); err != nil {
+		return model.BadRequest(fmt.Errorf("invalid alert in slack action: %w", err))
+	}
+	author := "slack:" + payload.User.Username
+
+	var status string
+	var remaining []string
+	switch action.ActionId {
+	case actionAcknowledge:
+		acknowledged, apiErr := c.incidents.AcknowledgeAlert(ctx, alertLabels.Fingerprint(), author)
+		if apiErr != nil {
+			return apiErr
+		}
+		if len(acknowledged) == 0 {
+			// alerts are acknowledged through their incidents, the message
+			// is left as is so that the alert can still be silenced
+			reply := &message{
+				Text:         "This alert is not linked to any open incident, there is nothing to acknowledge.",
+				ResponseType: "ephemeral",
+			}
+			if err := c.respond(ctx, payload.ResponseURL, reply); err != nil {
+				zap.L().Warn("could not reply to slack action", zap.Error(err))
+			}
+			return nil
+		}
+		status = fmt.Sprintf("Acknowledged by <@%s>, along with %d incidents", payload.User.Id, len(acknowledged))
+		remaining = []string{actionSilence1h}
+	case actionSilence1h:
+		silenceId, apiErr := c.silence(alertLabels, author, time.Hour)
+		if apiErr != nil {
+			return apiErr
+		}
+		zap.L().Info("alert silenced from slack",
+			zap.String("silenceId", silenceId), zap.String("author", author),
+		)
+		status = fmt.Sprintf("Silenced for 1h by <@%s>", payload.User.Id)
+	default:
+		return model.BadRequest(fmt.Errorf("unknown slack action %q", action.ActionId))
+	}
+
+	// the alert state is already updated, a message out of date isn't an error
+	msg := updatedMessage(&payload, action.Value, status, remaining)
+	if err := c.respond(ctx, payload.ResponseURL, msg); err != nil {
+		zap.L().Warn("could not update slack message after action", zap.Error(err))
+	}
+	return nil
+}
+
+func (c *Controller) silence(
+	alertLabels incidents.LabelSet, author string, duration time.Duration,
+) (string, *model.ApiError) {
+	matchers := []am.Matcher{}
+	for name, value := range alertLabels {
+		matchers = append(matchers, am.Matcher{Name: name, Value: value, IsEqual: true})
+	}
+	now := time.Now()
+	return c.alertManager.AddSilence(&am.Silence{
+		Matchers:  matchers,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: author,
+		Comment:   "Silenced from slack",
+	})
+}
+
+// updatedMessage rebuilds the message an interaction came from with only
+// the remaining actions and the status of the alert added
+func updatedMessage(payload *interactionPayload, value string, status string, actions []string) *message {
+	blocks := []interface{}{}
+	statuses := []interface{}{}
+	dashboardURL := ""
+	for _, b := range payload.Message.Blocks {
+		switch b["block_id"] {
+		case actionsBlockId:
+			dashboardURL = buttonURL(b, actionOpenDashboard)
+		case statusBlockId:
+			if elements, ok := b["elements"].([]interface{}); ok {
+				statuses = append(statuses, elements...)
+			}
+		default:
+			blocks = append(blocks, b)
+		}
+	}
+	statuses = append(statuses, textObject{Type: "mrkdwn", Text: status})
+
+	if elements := alertButtons(value, dashboardURL, actions...); len(elements) > 0 {
+		blocks = append(blocks, block{Type: "actions", BlockId: actionsBlockId, Elements: elements})
+	}
+	blocks = append(blocks, block{Type: "context", BlockId: statusBlockId, Elements: statuses})
+
+	return &message{Text: payload.Message.Text, Blocks: blocks, ReplaceOriginal: true}
+}
+
+func buttonURL(actionsBlock map[string]interface{}, actionId string) string {
+	elements, _ := actionsBlock["elements"].([]interface{})
+	for _, e := range elements {
+		element, ok := e.(map[string]interface{})
+		if !ok || element["action_id"] != actionId {
+			continue
+		}
+		u, _ := element["url"].(string)
+		return u
+	}
+	return ""
+}
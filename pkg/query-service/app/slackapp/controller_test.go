@@ -0,0 +1,177 @@
+package slackapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/incidents"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func signedHeader(secret string, ts time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	body := []byte("payload=%7B%7D")
+
+	require.NoError(t, verifySignature("secret", signedHeader("secret", now, body), body, now))
+	require.Error(t, verifySignature("other", signedHeader("secret", now, body), body, now))
+	require.Error(t, verifySignature("secret", signedHeader("secret", now, body), []byte("payload=x"), now))
+	require.Error(t, verifySignature(
+		"secret", signedHeader("secret", now.Add(-10*time.Minute), body), body, now,
+	))
+}
+
+func TestUpdatedMessage(t *testing.T) {
+	alert := &alertInfo{
+		labels:       incidents.LabelSet{"alertname": "High latency", "ruleId": "1"},
+		summary:      "p99 latency above 1s",
+		generatorURL: "http://signoz/alerts/1",
+	}
+	text, blocks := alertBlocks(alert)
+	require.Equal(t, "[FIRING] High latency", text)
+
+	// blocks come back from slack as generic json
+	serialized, err := json.Marshal(blocks)
+	require.NoError(t, err)
+	payload := &interactionPayload{}
+	payload.Message.Text = text
+	require.NoError(t, json.Unmarshal(serialized, &payload.Message.Blocks))
+
+	value := buttonValue(alert.labels)
+	acknowledged := updatedMessage(payload, value, "Acknowledged by <@U1>", []string{actionSilence1h})
+	require.True(t, acknowledged.ReplaceOriginal)
+	require.Len(t, acknowledged.Blocks, 3)
+
+	actions := acknowledged.Blocks[1].(block)
+	require.Len(t, actions.Elements, 2)
+	require.Equal(t, actionSilence1h, actions.Elements[0].(button).ActionId)
+	require.Equal(t, "http://signoz/alerts/1", actions.Elements[1].(button).URL)
+
+	// statuses accumulate as more actions are taken
+	serialized, err = json.Marshal(acknowledged.Blocks)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(serialized, &payload.Message.Blocks))
+	silenced := updatedMessage(payload, value, "Silenced for 1h by <@U2>", nil)
+	require.Len(t, silenced.Blocks, 3)
+	require.Len(t, silenced.Blocks[1].(block).Elements, 1)
+	require.Len(t, silenced.Blocks[2].(block).Elements, 2)
+}
+
+func TestPruneMessages(t *testing.T) {
+	now := time.Now()
+	c := &Controller{messages: map[string]postedMessage{
+		"recent": {channel: "C1", ts: "1", postedAt: now.Add(-time.Hour)},
+		"stale":  {channel: "C1", ts: "2", postedAt: now.Add(-maxMessageAge - time.Hour)},
+	}}
+	c.pruneMessages(now)
+	require.Len(t, c.messages, 1)
+	require.Contains(t, c.messages, "recent")
+}
+
+func TestAcknowledgeWithoutIncident(t *testing.T) {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.NoError(t, err)
+	incidentsController, err := incidents.NewController(db)
+	require.NoError(t, err)
+
+	replies := []message{}
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := message{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reply))
+		replies = append(replies, reply)
+	}))
+	defer slack.Close()
+
+	c := &Controller{
+		botToken: "token", signingSecret: "secret", channel: "C1",
+		client: slack.Client(), incidents: incidentsController,
+	}
+
+	payload := interactionPayload{Type: "block_actions", ResponseURL: slack.URL}
+	payload.User.Id = "U1"
+	payload.Actions = []interactionAction{{
+		ActionId: actionAcknowledge, Value: buttonValue(incidents.LabelSet{"alertname": "High latency", "ruleId": "1"}),
+	}}
+	serialized, err := json.Marshal(payload)
+	require.NoError(t, err)
+	body := []byte(url.Values{"payload": {string(serialized)}}.Encode())
+
+	apiErr := c.HandleInteraction(context.Background(), signedHeader("secret", time.Now(), body), body)
+	require.Nil(t, apiErr)
+	require.Len(t, replies, 1)
+	require.Equal(t, "ephemeral", replies[0].ResponseType)
+	require.False(t, replies[0].ReplaceOriginal, "the alert message should be left as is")
+	require.Contains(t, replies[0].Text, "not linked to any open incident")
+}
+
+func TestAlertMessages(t *testing.T) {
+	require := require.New(t)
+
+	calls := []string{}
+	updates := []message{}
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := message{}
+		require.NoError(json.NewDecoder(r.Body).Decode(&msg))
+		calls = append(calls, r.URL.Path)
+		if r.URL.Path == "/chat.update" {
+			updates = append(updates, msg)
+		}
+		json.NewEncoder(w).Encode(apiResponse{Ok: true, Channel: "C1", Ts: "1700000000.000100"})
+	}))
+	defer slack.Close()
+
+	c := &Controller{
+		botToken: "token", channel: "C1", apiURL: slack.URL + "/",
+		client: slack.Client(), messages: map[string]postedMessage{},
+	}
+	alert := func(resolvedAt time.Time) *rules.Alert {
+		return &rules.Alert{
+			Labels: labels.FromMap(map[string]string{
+				labels.AlertNameLabel: "High latency", labels.AlertRuleIdLabel: "1",
+			}),
+			Annotations: labels.FromMap(map[string]string{"summary": "p99 above 1s"}),
+			ResolvedAt:  resolvedAt,
+		}
+	}
+
+	// an alert resolving right after it fired updates the message posted
+	// for it
+	c.OnAlerts(context.Background(), alert(time.Time{}))
+	c.OnAlerts(context.Background(), alert(time.Time{}))
+	c.OnAlerts(context.Background(), alert(time.Now()))
+	require.Equal([]string{"/chat.postMessage", "/chat.update"}, calls, "firing alerts are posted once")
+	require.Equal("1700000000.000100", updates[0].Ts)
+	require.Empty(c.messages)
+
+	c.OnAlerts(context.Background(), alert(time.Now()))
+	require.Len(calls, 2, "resolved alerts which weren't posted are not")
+}
@@ -0,0 +1,82 @@
+package slackapp
+
+import "time"
+
+const (
+	actionAcknowledge   = "acknowledge"
+	actionSilence1h     = "silence_1h"
+	actionOpenDashboard = "open_dashboard"
+
+	actionsBlockId = "alert_actions"
+	statusBlockId  = "alert_status"
+)
+
+// textObject, button and block are the parts of the slack block kit used
+// for alert messages
+type textObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type button struct {
+	Type     string     `json:"type"`
+	Text     textObject `json:"text"`
+	ActionId string     `json:"action_id"`
+	Value    string     `json:"value,omitempty"`
+	URL      string     `json:"url,omitempty"`
+	Style    string     `json:"style,omitempty"`
+}
+
+type block struct {
+	Type     string        `json:"type"`
+	BlockId  string        `json:"block_id,omitempty"`
+	Text     *textObject   `json:"text,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+type message struct {
+	Channel         string        `json:"channel,omitempty"`
+	Ts              string        `json:"ts,omitempty"`
+	Text            string        `json:"text"`
+	Blocks          []interface{} `json:"blocks,omitempty"`
+	ReplaceOriginal bool          `json:"replace_original,omitempty"`
+	// ResponseType is "ephemeral" for replies only shown to the user who
+	// took an action
+	ResponseType string `json:"response_type,omitempty"`
+}
+
+type apiResponse struct {
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Ts      string `json:"ts,omitempty"`
+}
+
+type interactionAction struct {
+	ActionId string `json:"action_id"`
+	Value    string `json:"value"`
+}
+
+// interactionPayload is sent by slack when a button of a message is clicked
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Message     struct {
+		Ts     string                   `json:"ts"`
+		Text   string                   `json:"text"`
+		Blocks []map[string]interface{} `json:"blocks"`
+	} `json:"message"`
+	Actions []interactionAction `json:"actions"`
+}
+
+// postedMessage is a message posted for a firing alert, kept to update the
+// message once the alert resolves
+type postedMessage struct {
+	channel  string
+	ts       string
+	postedAt time.Time
+}
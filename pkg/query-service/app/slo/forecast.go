@@ -0,0 +1,273 @@
+package slo
+
+import (
+	"fmt"
+	"math"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// Step is the interval in seconds the good and total events of an SLO are
+// counted over for its burn chart
+const Step int64 = 3600
+
+const bucketsPerDay = 86400 / int(Step)
+
+// maxForecastHorizonRatio bounds how far past now the events are forecasted,
+// relative to how long they were observed in the window, as forecasts made
+// from a short history get meaningless far out
+const maxForecastHorizonRatio = 4
+
+type ForecastMode string
+
+const (
+	// ForecastModeLinear assumes the events keep arriving at their average
+	// rate over the lookback period
+	ForecastModeLinear ForecastMode = "linear"
+	// ForecastModeSeasonal assumes the events of every hour repeat the
+	// average of the same hour in the previous seasons, e.g. weeks
+	ForecastModeSeasonal ForecastMode = "seasonal"
+)
+
+type BudgetForecastRequest struct {
+	// Target is the percentage of good events the SLO aims for, e.g. 99.9
+	Target float64 `json:"target"`
+	// WindowStart and WindowEnd are the compliance period of the SLO in
+	// milliseconds, e.g. a quarter
+	WindowStart int64 `json:"windowStart"`
+	WindowEnd   int64 `json:"windowEnd"`
+	// GoodQuery and TotalQuery are the names of the queries of the composite
+	// query counting the good and all events
+	GoodQuery  string       `json:"goodQuery"`
+	TotalQuery string       `json:"totalQuery"`
+	Mode       ForecastMode `json:"mode"`
+	// LookbackDays is the number of days the linear forecast averages over
+	LookbackDays int `json:"lookbackDays"`
+	// SeasonDays is the length of the season of the seasonal forecast
+	SeasonDays int `json:"seasonDays"`
+}
+
+func (r *BudgetForecastRequest) IsValid() error {
+	if r.Target <= 0 || r.Target >= 100 {
+		return fmt.Errorf("target must be a percentage between 0 and 100")
+	}
+	if r.WindowStart <= 0 || r.WindowEnd <= r.WindowStart {
+		return fmt.Errorf("windowStart and windowEnd must be a valid range in milliseconds")
+	}
+	if r.GoodQuery == "" || r.TotalQuery == "" || r.GoodQuery == r.TotalQuery {
+		return fmt.Errorf("goodQuery and totalQuery must name two different queries")
+	}
+	if r.Mode == "" {
+		r.Mode = ForecastModeLinear
+	}
+	if r.Mode != ForecastModeLinear && r.Mode != ForecastModeSeasonal {
+		return fmt.Errorf("mode must be one of %s, %s", ForecastModeLinear, ForecastModeSeasonal)
+	}
+	if r.LookbackDays < 0 || r.SeasonDays < 0 {
+		return fmt.Errorf("lookbackDays and seasonDays can not be negative")
+	}
+	if r.LookbackDays == 0 {
+		r.LookbackDays = 7
+	}
+	if r.SeasonDays == 0 {
+		r.SeasonDays = 7
+	}
+	return nil
+}
+
+type BurnPoint struct {
+	Timestamp              int64   `json:"timestamp"`
+	Good                   float64 `json:"good"`
+	Total                  float64 `json:"total"`
+	BudgetRemainingPercent float64 `json:"budgetRemainingPercent"`
+	// Forecast is set for the points after now
+	Forecast bool `json:"forecast"`
+}
+
+type BudgetForecast struct {
+	Target float64      `json:"target"`
+	Mode   ForecastMode `json:"mode"`
+	// Budget is the number of bad events allowed up to ForecastEnd, from the
+	// observed and the forecasted total events
+	Budget                          float64 `json:"budget"`
+	BudgetRemainingPercent          float64 `json:"budgetRemainingPercent"`
+	ProjectedBudgetRemainingPercent float64 `json:"projectedBudgetRemainingPercent"`
+	// BurnRate is the error ratio of the last day relative to the one the
+	// target allows, the budget lasts exactly the window at 1
+	BurnRate float64 `json:"burnRate"`
+	// ExhaustedAt is when the budget ran or is projected to run out, nil
+	// when it lasts until ForecastEnd
+	ExhaustedAt *int64 `json:"exhaustedAt"`
+	// ForecastEnd is where the points end, the end of the window unless it
+	// is more than maxForecastHorizonRatio times the observed part of the
+	// window past now
+	ForecastEnd int64       `json:"forecastEnd"`
+	Points      []BurnPoint `json:"points"`
+}
+
+// bucketize sums the points of the series of a query into buckets of Step
+// starting at start
+func bucketize(result *v3.Result, start int64, buckets int) []float64 {
+	values := make([]float64, buckets)
+	if result == nil {
+		return values
+	}
+	for _, s := range result.Series {
+		for _, p := range s.Points {
+			i := int((p.Timestamp - start) / (Step * 1000))
+			if i < 0 || i >= buckets || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			values[i] += p.Value
+		}
+	}
+	return values
+}
+
+// forecastLinear fills the buckets after observed with the average of the
+// observed buckets of the lookback
+func forecastLinear(values []float64, observed, lookback int) {
+	from := observed - lookback
+	if from < 0 {
+		from = 0
+	}
+	avg := 0.0
+	if observed > from {
+		for _, v := range values[from:observed] {
+			avg += v
+		}
+		avg /= float64(observed - from)
+	}
+	for i := observed; i < len(values); i++ {
+		values[i] = avg
+	}
+}
+
+// forecastSeasonal fills the buckets after observed with the average of the
+// observed buckets a whole number of seasons before them, falling back to
+// the linear forecast for the buckets without any
+func forecastSeasonal(values []float64, observed, season, lookback int) {
+	linear := make([]float64, len(values))
+	copy(linear, values)
+	forecastLinear(linear, observed, lookback)
+
+	for i := observed; i < len(values); i++ {
+		sum, count := 0.0, 0
+		for j := i - season; j >= 0; j -= season {
+			if j < observed {
+				sum += values[j]
+				count++
+			}
+		}
+		if count == 0 {
+			values[i] = linear[i]
+			continue
+		}
+		values[i] = sum / float64(count)
+	}
+}
+
+func budgetRemainingPercent(budget, burned float64) float64 {
+	if budget <= 0 {
+		if burned > 0 {
+			return -100
+		}
+		return 100
+	}
+	return (budget - burned) / budget * 100
+}
+
+// Forecast computes the error budget burn chart of an SLO over its window
+// from the results of its good and total queries up to now, and projects
+// when the budget runs out. The forecast is cut short when the rest of the
+// window is too long compared to its observed part.
+func Forecast(req BudgetForecastRequest, results []*v3.Result, now int64) (*BudgetForecast, error) {
+	var good, total *v3.Result
+	for _, r := range results {
+		switch r.QueryName {
+		case req.GoodQuery:
+			good = r
+		case req.TotalQuery:
+			total = r
+		}
+	}
+	if total == nil {
+		return nil, fmt.Errorf("no result for the total query %s", req.TotalQuery)
+	}
+
+	stepMs := Step * 1000
+	start := req.WindowStart - req.WindowStart%stepMs
+	buckets := int((req.WindowEnd - start + stepMs - 1) / stepMs)
+	observed := int((now - start + stepMs - 1) / stepMs)
+	if observed < 0 {
+		observed = 0
+	}
+	if observed > buckets {
+		observed = buckets
+	}
+	if horizon := maxForecastHorizonRatio * observed; buckets-observed > horizon {
+		buckets = observed + horizon
+	}
+
+	goodValues := bucketize(good, start, buckets)
+	totalValues := bucketize(total, start, buckets)
+	badValues := make([]float64, buckets)
+	for i := 0; i < observed; i++ {
+		badValues[i] = math.Max(totalValues[i]-goodValues[i], 0)
+	}
+
+	lookback := req.LookbackDays * bucketsPerDay
+	for _, values := range [][]float64{totalValues, badValues} {
+		if req.Mode == ForecastModeSeasonal {
+			forecastSeasonal(values, observed, req.SeasonDays*bucketsPerDay, lookback)
+		} else {
+			forecastLinear(values, observed, lookback)
+		}
+	}
+
+	allowedErrorRatio := 1 - req.Target/100
+	forecast := &BudgetForecast{
+		Target:      req.Target,
+		Mode:        req.Mode,
+		ForecastEnd: start + int64(buckets)*stepMs,
+		Points:      make([]BurnPoint, 0, buckets),
+	}
+	for _, v := range totalValues {
+		forecast.Budget += v
+	}
+	forecast.Budget *= allowedErrorRatio
+
+	burned := 0.0
+	forecast.BudgetRemainingPercent = 100
+	for i := 0; i < buckets; i++ {
+		burned += badValues[i]
+		remaining := budgetRemainingPercent(forecast.Budget, burned)
+		forecast.Points = append(forecast.Points, BurnPoint{
+			Timestamp:              start + int64(i)*stepMs,
+			Good:                   totalValues[i] - badValues[i],
+			Total:                  totalValues[i],
+			BudgetRemainingPercent: remaining,
+			Forecast:               i >= observed,
+		})
+		if i < observed {
+			forecast.BudgetRemainingPercent = remaining
+		}
+		if forecast.ExhaustedAt == nil && burned > 0 && remaining <= 0 {
+			exhaustedAt := start + int64(i+1)*stepMs
+			forecast.ExhaustedAt = &exhaustedAt
+		}
+	}
+	forecast.ProjectedBudgetRemainingPercent = budgetRemainingPercent(forecast.Budget, burned)
+
+	lastDayTotal, lastDayBad := 0.0, 0.0
+	for i := observed - bucketsPerDay; i < observed; i++ {
+		if i >= 0 {
+			lastDayTotal += totalValues[i]
+			lastDayBad += badValues[i]
+		}
+	}
+	if lastDayTotal > 0 {
+		forecast.BurnRate = lastDayBad / lastDayTotal / allowedErrorRatio
+	}
+	return forecast, nil
+}
@@ -0,0 +1,81 @@
+package slo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestBudgetForecast(t *testing.T) {
+	require := require.New(t)
+
+	hour := Step * 1000
+	day := 24 * hour
+	start := 100 * day
+
+	series := func(value float64, hours int) *v3.Series {
+		s := &v3.Series{}
+		for i := 0; i < hours; i++ {
+			s.Points = append(s.Points, v3.Point{Timestamp: start + int64(i)*hour, Value: value})
+		}
+		return s
+	}
+	results := []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{series(500, 96), series(300, 96)}},
+		{QueryName: "B", Series: []*v3.Series{series(1000, 96)}},
+	}
+
+	req := BudgetForecastRequest{
+		Target:      90,
+		WindowStart: start,
+		WindowEnd:   start + 10*day,
+		GoodQuery:   "A",
+		TotalQuery:  "B",
+	}
+	require.Nil(req.IsValid())
+	require.Equal(ForecastModeLinear, req.Mode)
+
+	forecast, err := Forecast(req, results, start+4*day)
+	require.Nil(err)
+	require.Len(forecast.Points, 240)
+	require.False(forecast.Points[95].Forecast)
+	require.True(forecast.Points[96].Forecast)
+	require.Equal(800.0, forecast.Points[200].Good)
+
+	// 20% of the events are bad against the 10% allowed, so the budget of
+	// the whole window is burned in half of it
+	require.InDelta(24000, forecast.Budget, 0.01)
+	require.InDelta(2, forecast.BurnRate, 0.0001)
+	require.InDelta(20, forecast.BudgetRemainingPercent, 0.0001)
+	require.InDelta(-100, forecast.ProjectedBudgetRemainingPercent, 0.0001)
+	require.NotNil(forecast.ExhaustedAt)
+	require.Equal(start+5*day, *forecast.ExhaustedAt)
+	require.Equal(start+10*day, forecast.ForecastEnd)
+
+	// the forecast reaches at most maxForecastHorizonRatio times the
+	// observed part of the window past now
+	req.WindowEnd = start + 1000*day
+	forecast, err = Forecast(req, results, start+4*day)
+	require.Nil(err)
+	require.Len(forecast.Points, 96*(1+maxForecastHorizonRatio))
+	require.Equal(start+4*day*(1+maxForecastHorizonRatio), forecast.ForecastEnd)
+	require.InDelta(2400*4*(1+maxForecastHorizonRatio), forecast.Budget, 0.01, "the budget of the days forecasted")
+	forecast, err = Forecast(req, results, start+hour/2)
+	require.Nil(err)
+	require.Len(forecast.Points, 1+maxForecastHorizonRatio)
+	req.WindowEnd = start + 10*day
+
+	_, err = Forecast(req, results[:1], start+4*day)
+	require.NotNil(err, "the total query is required")
+}
+
+func TestForecastSeasonal(t *testing.T) {
+	values := []float64{1, 2, 3, 3, 4, 0, 0, 0, 0}
+	forecastSeasonal(values, 5, 3, 5)
+	require.Equal(t, []float64{1, 2, 3, 3, 4, 3, 2, 3, 3}, values)
+
+	values = []float64{4, 2, 0, 0}
+	forecastLinear(values, 2, 1)
+	require.Equal(t, []float64{4, 2, 2, 2}, values)
+}
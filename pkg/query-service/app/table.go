@@ -0,0 +1,199 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/SigNoz/govaluate"
+	"go.signoz.io/signoz/pkg/query-service/formatter"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// columnPlaceholder matches the column references in calculated column formats
+var columnPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// validateCalculatedColumns checks that the expressions of calculated
+// columns can be parsed
+func validateCalculatedColumns(cq *v3.CompositeQuery) error {
+	for _, col := range cq.CalculatedColumns {
+		if col.Expression == "" {
+			continue
+		}
+		if _, err := govaluate.NewEvaluableExpressionWithFunctions(col.Expression, evalFuncs()); err != nil {
+			return fmt.Errorf("invalid expression of calculated column %s: %w", col.Name, err)
+		}
+	}
+	return nil
+}
+
+// buildTable joins the series of table panel results into rows sharing
+// the same labels and computes the calculated columns of every row
+func buildTable(results []*v3.Result, cq *v3.CompositeQuery) (*v3.Table, error) {
+	table := &v3.Table{Columns: []*v3.TableColumn{}, Rows: []*v3.TableRow{}}
+
+	labelColumns := tableLabelColumns(results, cq)
+	for _, key := range labelColumns {
+		table.Columns = append(table.Columns, &v3.TableColumn{Name: key})
+	}
+
+	valueResults := []*v3.Result{}
+	for _, result := range results {
+		if len(result.Series) > 0 {
+			valueResults = append(valueResults, result)
+		}
+	}
+	sort.Slice(valueResults, func(i, j int) bool {
+		return valueResults[i].QueryName < valueResults[j].QueryName
+	})
+	for _, result := range valueResults {
+		table.Columns = append(table.Columns, &v3.TableColumn{
+			Name: result.QueryName, QueryName: result.QueryName, IsValueColumn: true,
+		})
+	}
+
+	expressions := make([]*govaluate.EvaluableExpression, len(cq.CalculatedColumns))
+	for i, col := range cq.CalculatedColumns {
+		table.Columns = append(table.Columns, &v3.TableColumn{Name: col.Name, IsCalculated: true})
+		if col.Expression == "" {
+			continue
+		}
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(col.Expression, evalFuncs())
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of calculated column %s: %w", col.Name, err)
+		}
+		expressions[i] = expression
+	}
+
+	for _, labelSet := range findUniqueLabelSets(valueResults) {
+		row := &v3.TableRow{Data: map[string]interface{}{}, Formatted: map[string]string{}}
+		for _, key := range labelColumns {
+			if value, ok := labelSet[key]; ok {
+				row.Data[key] = value
+			}
+		}
+		for _, result := range valueResults {
+			for _, series := range result.Series {
+				if isSubset(labelSet, series.Labels) && len(series.Points) > 0 {
+					row.Data[result.QueryName] = series.Points[len(series.Points)-1].Value
+					break
+				}
+			}
+		}
+		for i, col := range cq.CalculatedColumns {
+			calculateColumn(row, col, expressions[i])
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	sort.SliceStable(table.Rows, func(i, j int) bool {
+		for _, key := range labelColumns {
+			a, _ := table.Rows[i].Data[key].(string)
+			b, _ := table.Rows[j].Data[key].(string)
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	return table, nil
+}
+
+// tableLabelColumns lists the group by keys of builder queries in the order
+// they are specified, followed by any other labels of the results
+func tableLabelColumns(results []*v3.Result, cq *v3.CompositeQuery) []string {
+	columns := []string{}
+	seen := map[string]struct{}{}
+	add := func(key string) {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			columns = append(columns, key)
+		}
+	}
+
+	queryNames := []string{}
+	for name := range cq.BuilderQueries {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		for _, key := range cq.BuilderQueries[name].GroupBy {
+			add(key.Key)
+		}
+	}
+
+	others := []string{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for key := range series.Labels {
+				if _, ok := seen[key]; !ok {
+					others = append(others, key)
+				}
+			}
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		add(key)
+	}
+	return columns
+}
+
+// calculateColumn sets the value of a calculated column on a row. Rows
+// missing a column referenced by the expression get no value.
+func calculateColumn(row *v3.TableRow, col v3.CalculatedColumn, expression *govaluate.EvaluableExpression) {
+	var value interface{}
+	if expression != nil {
+		for _, v := range expression.Vars() {
+			if _, ok := row.Data[v]; !ok {
+				return
+			}
+		}
+		result, err := expression.Evaluate(row.Data)
+		if err != nil {
+			return
+		}
+		value = result
+		row.Data[col.Name] = value
+	}
+
+	rendered := renderColumnValue(value, col)
+	if col.Format != "" {
+		rendered = columnPlaceholder.ReplaceAllStringFunc(col.Format, func(placeholder string) string {
+			name := placeholder[1 : len(placeholder)-1]
+			if name == "value" {
+				return renderColumnValue(value, col)
+			}
+			if formatted, ok := row.Formatted[name]; ok {
+				return formatted
+			}
+			if v, ok := row.Data[name]; ok {
+				return renderColumnValue(v, v3.CalculatedColumn{})
+			}
+			return ""
+		})
+		if expression == nil {
+			row.Data[col.Name] = rendered
+		}
+	}
+	row.Formatted[col.Name] = rendered
+}
+
+func renderColumnValue(value interface{}, col v3.CalculatedColumn) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		if col.Unit != "" {
+			return formatter.FromUnit(col.Unit).Format(v, col.Unit)
+		}
+		if col.Precision != nil {
+			return strconv.FormatFloat(v, 'f', *col.Precision, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
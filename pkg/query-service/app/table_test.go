@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestBuildTable(t *testing.T) {
+	precision := 1
+	cq := &v3.CompositeQuery{
+		PanelType: v3.PanelTypeTable,
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			"A": {QueryName: "A", Expression: "A", GroupBy: []v3.AttributeKey{{Key: "service_name"}}},
+			"B": {QueryName: "B", Expression: "B", GroupBy: []v3.AttributeKey{{Key: "service_name"}}},
+		},
+		CalculatedColumns: []v3.CalculatedColumn{
+			{Name: "error_rate", Expression: "B / A * 100", Precision: &precision},
+			{Name: "latency", Expression: "A * 2", Unit: "ms"},
+			{Name: "summary", Format: "{service_name}: {error_rate}%"},
+		},
+	}
+	require.Nil(t, validateCalculatedColumns(cq))
+
+	results := []*v3.Result{
+		{QueryName: "B", Series: []*v3.Series{
+			{Labels: map[string]string{"service_name": "cart"}, Points: []v3.Point{{Value: 5}}},
+		}},
+		{QueryName: "A", Series: []*v3.Series{
+			{Labels: map[string]string{"service_name": "frontend"}, Points: []v3.Point{{Value: 1500}}},
+			{Labels: map[string]string{"service_name": "cart"}, Points: []v3.Point{{Value: 200}}},
+		}},
+	}
+
+	table, err := buildTable(results, cq)
+	require.Nil(t, err)
+
+	columns := []string{}
+	for _, c := range table.Columns {
+		columns = append(columns, c.Name)
+	}
+	require.Equal(t, []string{"service_name", "A", "B", "error_rate", "latency", "summary"}, columns)
+
+	require.Len(t, table.Rows, 2)
+	cart, frontend := table.Rows[0], table.Rows[1]
+	require.Equal(t, "cart", cart.Data["service_name"])
+	require.Equal(t, 2.5, cart.Data["error_rate"])
+	require.Equal(t, "2.5", cart.Formatted["error_rate"])
+	require.Equal(t, "400 ms", cart.Formatted["latency"])
+	require.Equal(t, "cart: 2.5%", cart.Data["summary"])
+
+	// rows missing a column referenced by the expression get no value
+	_, ok := frontend.Data["error_rate"]
+	require.False(t, ok)
+	require.Equal(t, "3 s", frontend.Formatted["latency"])
+	require.Equal(t, "frontend: %", frontend.Formatted["summary"])
+
+	cq.CalculatedColumns = append(cq.CalculatedColumns, v3.CalculatedColumn{Name: "bad", Expression: "A *"})
+	require.NotNil(t, validateCalculatedColumns(cq))
+}
+
+// tableQuerier returns the same series for every query range
+type tableQuerier struct {
+	results []*v3.Result
+}
+
+func (q *tableQuerier) QueryRange(context.Context, *v3.QueryRangeParamsV3, map[string]v3.AttributeKey) ([]*v3.Result, error, map[string]string) {
+	return q.results, nil, nil
+}
+
+func (q *tableQuerier) QueriesExecuted() []string {
+	return []string{}
+}
+
+func TestQueryRangeV4CalculatedColumns(t *testing.T) {
+	require := require.New(t)
+	querier := &tableQuerier{results: []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{
+			{Labels: map[string]string{"service_name": "cart"}, Points: []v3.Point{{Timestamp: 1000, Value: 200}}},
+			{Labels: map[string]string{"service_name": "frontend"}, Points: []v3.Point{{Timestamp: 1000, Value: 50}}},
+		}},
+		{QueryName: "B", Series: []*v3.Series{
+			{Labels: map[string]string{"service_name": "cart"}, Points: []v3.Point{{Timestamp: 1000, Value: 5}}},
+		}},
+	}}
+	aH := &APIHandler{querierV2: querier}
+	params := &v3.QueryRangeParamsV3{
+		Start: 1000, End: 2000, Step: 60,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypePromQL,
+			PanelType: v3.PanelTypeTable,
+			PromQueries: map[string]*v3.PromQuery{
+				"A": {Query: "sum by (service_name) (rate(calls[5m]))"},
+				"B": {Query: "sum by (service_name) (rate(errors[5m]))"},
+			},
+			CalculatedColumns: []v3.CalculatedColumn{
+				{Name: "error_rate", Expression: "B / A * 100", Unit: "percent"},
+			},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	aH.queryRangeV4(context.Background(), params, w, httptest.NewRequest(http.MethodPost, "/api/v4/query_range", nil))
+	require.Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data v3.QueryRangeResponse `json:"data"`
+	}
+	require.Nil(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(resp.Data.Result, 2)
+	require.NotNil(resp.Data.Table)
+
+	columns := []string{}
+	for _, c := range resp.Data.Table.Columns {
+		columns = append(columns, c.Name)
+	}
+	require.Equal([]string{"service_name", "A", "B", "error_rate"}, columns)
+	require.True(resp.Data.Table.Columns[3].IsCalculated)
+
+	require.Len(resp.Data.Table.Rows, 2)
+	cart, frontend := resp.Data.Table.Rows[0], resp.Data.Table.Rows[1]
+	require.Equal("cart", cart.Data["service_name"])
+	require.Equal(2.5, cart.Data["error_rate"])
+	require.Equal("frontend", frontend.Data["service_name"])
+	_, ok := frontend.Data["error_rate"]
+	require.False(ok)
+
+	// a table is only built when calculated columns are requested
+	params.CompositeQuery.CalculatedColumns = nil
+	w = httptest.NewRecorder()
+	aH.queryRangeV4(context.Background(), params, w, httptest.NewRequest(http.MethodPost, "/api/v4/query_range", nil))
+	require.Equal(http.StatusOK, w.Code, w.Body.String())
+	resp.Data = v3.QueryRangeResponse{}
+	require.Nil(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Nil(resp.Data.Table)
+}
@@ -0,0 +1,241 @@
+// Package tagging adds, removes and renames tags across dashboards, rules
+// and saved views in bulk.
+package tagging
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+type ResourceType string
+
+const (
+	ResourceDashboards ResourceType = "dashboards"
+	ResourceRules      ResourceType = "rules"
+	ResourceSavedViews ResourceType = "saved_views"
+)
+
+// Item is a taggable resource along with its tags
+type Item struct {
+	Type ResourceType `json:"type"`
+	Id   string       `json:"id"`
+	Name string       `json:"name"`
+	Tags []string     `json:"tags"`
+}
+
+// Store is implemented by the resources supporting tags
+type Store interface {
+	// ListTagged returns all the resources with their tags
+	ListTagged(ctx context.Context) ([]Item, *model.ApiError)
+
+	// SetTags replaces the tags of a resource
+	SetTags(ctx context.Context, id string, tags []string) *model.ApiError
+}
+
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionRemove Action = "remove"
+	ActionRename Action = "rename"
+)
+
+type ResourceRef struct {
+	Type ResourceType `json:"type"`
+	Id   string       `json:"id"`
+}
+
+// PostableTagChange changes a tag of the resources it selects. Tags are
+// added to the resources listed or having WithTag, and removed or renamed
+// in the resources having them, optionally only in the ones listed.
+type PostableTagChange struct {
+	Action Action `json:"action"`
+	Tag    string `json:"tag"`
+	NewTag string `json:"newTag,omitempty"`
+
+	WithTag       string         `json:"withTag,omitempty"`
+	Resources     []ResourceRef  `json:"resources,omitempty"`
+	ResourceTypes []ResourceType `json:"resourceTypes,omitempty"`
+
+	// DryRun reports the resources which would change without changing them
+	DryRun bool `json:"dryRun"`
+}
+
+func (p *PostableTagChange) IsValid() error {
+	if p.Tag == "" {
+		return fmt.Errorf("tag is required")
+	}
+	switch p.Action {
+	case ActionAdd:
+		if p.WithTag == "" && len(p.Resources) == 0 {
+			return fmt.Errorf("resources or withTag must be present to add a tag")
+		}
+	case ActionRemove:
+	case ActionRename:
+		if p.NewTag == "" || p.NewTag == p.Tag {
+			return fmt.Errorf("newTag different from the tag is required to rename it")
+		}
+	default:
+		return fmt.Errorf("unknown action %s, must be one of add, remove, rename", p.Action)
+	}
+	return nil
+}
+
+// TagChange is the change of the tags of a resource. Error is set if the
+// change couldn't be applied.
+type TagChange struct {
+	Item
+	NewTags []string `json:"newTags"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type TagChangeResult struct {
+	DryRun  bool        `json:"dryRun"`
+	Changes []TagChange `json:"changes"`
+}
+
+// TagUsage is the number of resources of each type having a tag
+type TagUsage struct {
+	Tag    string               `json:"tag"`
+	Counts map[ResourceType]int `json:"counts"`
+}
+
+type Tagging struct {
+	stores map[ResourceType]Store
+}
+
+func NewTagging(stores map[ResourceType]Store) *Tagging {
+	return &Tagging{
+		stores: stores,
+	}
+}
+
+func (t *Tagging) listItems(ctx context.Context, resourceTypes []ResourceType) ([]Item, *model.ApiError) {
+	items := []Item{}
+	for resourceType, store := range t.stores {
+		if len(resourceTypes) > 0 && !slices.Contains(resourceTypes, resourceType) {
+			continue
+		}
+		tagged, apiErr := store.ListTagged(ctx)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf("could not list tags of %s", resourceType))
+		}
+		for _, item := range tagged {
+			item.Type = resourceType
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Type != items[j].Type {
+			return items[i].Type < items[j].Type
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// ListTags returns the tags in use with the number of resources having them
+func (t *Tagging) ListTags(ctx context.Context) ([]TagUsage, *model.ApiError) {
+	items, apiErr := t.listItems(ctx, nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	counts := map[string]map[ResourceType]int{}
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			if counts[tag] == nil {
+				counts[tag] = map[ResourceType]int{}
+			}
+			counts[tag][item.Type]++
+		}
+	}
+
+	usage := []TagUsage{}
+	for tag, c := range counts {
+		usage = append(usage, TagUsage{Tag: tag, Counts: c})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Tag < usage[j].Tag
+	})
+	return usage, nil
+}
+
+// Apply changes the tags of the resources selected by the change, unless it
+// is a dry run. Resources failing to change don't stop the others from
+// changing, their errors are reported in the result.
+func (t *Tagging) Apply(ctx context.Context, change *PostableTagChange) (*TagChangeResult, *model.ApiError) {
+	if err := change.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	for _, resourceType := range change.ResourceTypes {
+		if _, ok := t.stores[resourceType]; !ok {
+			return nil, model.BadRequest(fmt.Errorf("unsupported resource type for tags: %s", resourceType))
+		}
+	}
+
+	items, apiErr := t.listItems(ctx, change.ResourceTypes)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	result := &TagChangeResult{DryRun: change.DryRun, Changes: []TagChange{}}
+	for _, item := range items {
+		if !change.selects(item) {
+			continue
+		}
+		newTags, changed := change.apply(item.Tags)
+		if !changed {
+			continue
+		}
+
+		tc := TagChange{Item: item, NewTags: newTags}
+		if !change.DryRun {
+			if apiErr := t.stores[item.Type].SetTags(ctx, item.Id, newTags); apiErr != nil {
+				tc.Error = apiErr.Error()
+			}
+		}
+		result.Changes = append(result.Changes, tc)
+	}
+	return result, nil
+}
+
+func (p *PostableTagChange) selects(item Item) bool {
+	if slices.Contains(p.Resources, ResourceRef{Type: item.Type, Id: item.Id}) {
+		return true
+	}
+	if p.Action == ActionAdd {
+		return p.WithTag != "" && slices.Contains(item.Tags, p.WithTag)
+	}
+	return len(p.Resources) == 0
+}
+
+// apply returns the tags changed by the action, and whether they changed.
+// A renamed tag keeps its position.
+func (p *PostableTagChange) apply(tags []string) ([]string, bool) {
+	has := slices.Contains(tags, p.Tag)
+	if has == (p.Action == ActionAdd) {
+		return tags, false
+	}
+	if p.Action == ActionAdd {
+		return append(append([]string{}, tags...), p.Tag), true
+	}
+
+	newTags := []string{}
+	for _, tag := range tags {
+		if tag == p.Tag && p.Action == ActionRename {
+			tag = p.NewTag
+		} else if tag == p.Tag {
+			continue
+		}
+		if !slices.Contains(newTags, tag) {
+			newTags = append(newTags, tag)
+		}
+	}
+	return newTags, true
+}
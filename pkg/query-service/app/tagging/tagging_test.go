@@ -0,0 +1,105 @@
+package tagging
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type testStore struct {
+	items  []Item
+	locked map[string]bool
+}
+
+func (s *testStore) ListTagged(ctx context.Context) ([]Item, *model.ApiError) {
+	return s.items, nil
+}
+
+func (s *testStore) SetTags(ctx context.Context, id string, tags []string) *model.ApiError {
+	if s.locked[id] {
+		return model.BadRequest(fmt.Errorf("%s is locked", id))
+	}
+	for i := range s.items {
+		if s.items[i].Id == id {
+			s.items[i].Tags = tags
+		}
+	}
+	return nil
+}
+
+func TestTagging(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	dashboards := &testStore{
+		items: []Item{
+			{Id: "d1", Name: "checkout", Tags: []string{"team-a", "payments"}},
+			{Id: "d2", Name: "search", Tags: []string{"team-a"}},
+			{Id: "d3", Name: "infra", Tags: []string{}},
+		},
+		locked: map[string]bool{"d2": true},
+	}
+	rules := &testStore{items: []Item{
+		{Id: "1", Name: "checkout errors", Tags: []string{"payments", "team-a", "team-b"}},
+	}}
+	tagging := NewTagging(map[ResourceType]Store{
+		ResourceDashboards: dashboards,
+		ResourceRules:      rules,
+	})
+
+	usage, apiErr := tagging.ListTags(ctx)
+	require.Nil(apiErr)
+	require.Equal([]TagUsage{
+		{Tag: "payments", Counts: map[ResourceType]int{ResourceDashboards: 1, ResourceRules: 1}},
+		{Tag: "team-a", Counts: map[ResourceType]int{ResourceDashboards: 2, ResourceRules: 1}},
+		{Tag: "team-b", Counts: map[ResourceType]int{ResourceRules: 1}},
+	}, usage)
+
+	// dry runs only report the changes
+	rename := &PostableTagChange{Action: ActionRename, Tag: "team-a", NewTag: "team-b", DryRun: true}
+	result, apiErr := tagging.Apply(ctx, rename)
+	require.Nil(apiErr)
+	require.Len(result.Changes, 3)
+	require.Equal([]string{"team-b", "payments"}, result.Changes[0].NewTags, "renamed tags should keep their place")
+	require.Equal([]string{"payments", "team-b"}, result.Changes[2].NewTags, "renamed tags should not be duplicated")
+	require.Equal([]string{"team-a", "payments"}, dashboards.items[0].Tags)
+
+	rename.DryRun = false
+	result, apiErr = tagging.Apply(ctx, rename)
+	require.Nil(apiErr)
+	require.Len(result.Changes, 3)
+	require.Equal("d2", result.Changes[1].Id)
+	require.Contains(result.Changes[1].Error, "locked", "failed changes should be reported")
+	require.Equal([]string{"team-b", "payments"}, dashboards.items[0].Tags)
+	require.Equal([]string{"team-a"}, dashboards.items[1].Tags)
+
+	// tags are added to the resources listed or having another tag
+	result, apiErr = tagging.Apply(ctx, &PostableTagChange{
+		Action:    ActionAdd,
+		Tag:       "critical",
+		WithTag:   "payments",
+		Resources: []ResourceRef{{Type: ResourceDashboards, Id: "d3"}},
+	})
+	require.Nil(apiErr)
+	require.Len(result.Changes, 3)
+	require.Equal([]string{"critical"}, dashboards.items[2].Tags)
+	require.Equal([]string{"payments", "team-b", "critical"}, rules.items[0].Tags)
+
+	result, apiErr = tagging.Apply(ctx, &PostableTagChange{
+		Action: ActionRemove, Tag: "critical", ResourceTypes: []ResourceType{ResourceRules},
+	})
+	require.Nil(apiErr)
+	require.Len(result.Changes, 1)
+	require.Equal([]string{"payments", "team-b"}, rules.items[0].Tags)
+	require.Equal([]string{"critical"}, dashboards.items[2].Tags)
+
+	_, apiErr = tagging.Apply(ctx, &PostableTagChange{Action: ActionAdd, Tag: "critical"})
+	require.NotNil(apiErr, "tags should only be added to selected resources")
+	_, apiErr = tagging.Apply(ctx, &PostableTagChange{
+		Action: ActionRemove, Tag: "critical", ResourceTypes: []ResourceType{"pipelines"},
+	})
+	require.NotNil(apiErr)
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTraceExistenceBatch is the max number of trace ids checked in one go
+	maxTraceExistenceBatch = 1000
+
+	// traceLinkWindow is how far from a log its trace is looked for, as the
+	// spans of a trace can start well before or after the logs linking to it
+	traceLinkWindow = time.Hour
+
+	// traceExistenceLookback is how far back traces are looked for when
+	// checking their existence without a time range, so that the check
+	// doesn't scan the whole traces table
+	traceExistenceLookback = 24 * time.Hour
+)
+
+func isLogsResult(params *v3.QueryRangeParamsV3, result *v3.Result) bool {
+	query, ok := params.CompositeQuery.BuilderQueries[result.QueryName]
+	return ok && query.DataSource == v3.DataSourceLogs
+}
+
+// logRowTraceIDs returns the trace ids referenced by logs list results
+// along with the time range of the rows referencing them
+func logRowTraceIDs(params *v3.QueryRangeParamsV3, results []*v3.Result) ([]string, time.Time, time.Time) {
+	seen := map[string]struct{}{}
+	traceIDs := []string{}
+	var start, end time.Time
+	for _, result := range results {
+		if !isLogsResult(params, result) {
+			continue
+		}
+		for _, row := range result.List {
+			traceID, _ := row.Data["trace_id"].(string)
+			if traceID == "" {
+				continue
+			}
+			if start.IsZero() || row.Timestamp.Before(start) {
+				start = row.Timestamp
+			}
+			if row.Timestamp.After(end) {
+				end = row.Timestamp
+			}
+			if _, ok := seen[traceID]; !ok {
+				seen[traceID] = struct{}{}
+				traceIDs = append(traceIDs, traceID)
+			}
+		}
+	}
+	return traceIDs, start, end
+}
+
+// markLogTraceLinks flags whether the traces referenced by the rows of logs
+// list results are still stored, so that links to expired or unsampled
+// traces are not rendered. Failures only leave the rows unflagged.
+func (aH *APIHandler) markLogTraceLinks(ctx context.Context, params *v3.QueryRangeParamsV3, results []*v3.Result) {
+	if params.CompositeQuery.PanelType != v3.PanelTypeList {
+		return
+	}
+	traceIDs, start, end := logRowTraceIDs(params, results)
+	if len(traceIDs) == 0 || len(traceIDs) > maxTraceExistenceBatch {
+		return
+	}
+
+	existing, apiErr := aH.reader.GetExistingTraceIDs(
+		ctx, traceIDs, start.Add(-traceLinkWindow).UnixNano(), end.Add(traceLinkWindow).UnixNano(),
+	)
+	if apiErr != nil {
+		zap.L().Warn("could not check the traces linked from logs", zap.Error(apiErr.ToError()))
+		return
+	}
+
+	for _, result := range results {
+		if !isLogsResult(params, result) {
+			continue
+		}
+		for _, row := range result.List {
+			traceID, _ := row.Data["trace_id"].(string)
+			if traceID == "" {
+				continue
+			}
+			_, exists := existing[traceID]
+			row.TraceExists = &exists
+		}
+	}
+}
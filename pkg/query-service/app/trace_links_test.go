@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// traceIDsReader stores the traces in traces and records the lookups
+type traceIDsReader struct {
+	interfaces.Reader
+	traces  map[string]struct{}
+	err     *model.ApiError
+	lookups [][]interface{}
+}
+
+func (r *traceIDsReader) GetExistingTraceIDs(
+	ctx context.Context, traceIDs []string, start, end int64,
+) (map[string]struct{}, *model.ApiError) {
+	r.lookups = append(r.lookups, []interface{}{traceIDs, start, end})
+	if r.err != nil {
+		return nil, r.err
+	}
+	existing := map[string]struct{}{}
+	for _, traceID := range traceIDs {
+		if _, ok := r.traces[traceID]; ok {
+			existing[traceID] = struct{}{}
+		}
+	}
+	return existing, nil
+}
+
+func traceLinksParams() *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+		PanelType: v3.PanelTypeList,
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			"A": {QueryName: "A", DataSource: v3.DataSourceLogs},
+			"B": {QueryName: "B", DataSource: v3.DataSourceTraces},
+		},
+	}}
+}
+
+func TestLogRowTraceIDs(t *testing.T) {
+	require := require.New(t)
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	results := []*v3.Result{
+		{QueryName: "A", List: []*v3.Row{
+			{Timestamp: t0.Add(time.Minute), Data: map[string]interface{}{"trace_id": "t1"}},
+			{Timestamp: t0, Data: map[string]interface{}{"trace_id": "t2"}},
+			{Timestamp: t0.Add(-time.Hour), Data: map[string]interface{}{"trace_id": ""}},
+			{Timestamp: t0.Add(2 * time.Minute), Data: map[string]interface{}{"trace_id": "t1"}},
+			{Timestamp: t0.Add(time.Hour), Data: map[string]interface{}{}},
+		}},
+		{QueryName: "B", List: []*v3.Row{
+			{Timestamp: t0.Add(-time.Hour), Data: map[string]interface{}{"trace_id": "t3"}},
+		}},
+		{QueryName: "F1", List: []*v3.Row{
+			{Timestamp: t0.Add(-time.Hour), Data: map[string]interface{}{"trace_id": "t4"}},
+		}},
+	}
+
+	traceIDs, start, end := logRowTraceIDs(traceLinksParams(), results)
+	require.Equal([]string{"t1", "t2"}, traceIDs, "only the trace ids of logs are returned, once")
+	require.Equal(t0, start, "rows without trace ids don't widen the time range")
+	require.Equal(t0.Add(2*time.Minute), end)
+
+	traceIDs, start, end = logRowTraceIDs(traceLinksParams(), []*v3.Result{{QueryName: "B"}})
+	require.Empty(traceIDs)
+	require.True(start.IsZero())
+	require.True(end.IsZero())
+}
+
+func TestMarkLogTraceLinks(t *testing.T) {
+	require := require.New(t)
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	newResults := func() []*v3.Result {
+		return []*v3.Result{
+			{QueryName: "A", List: []*v3.Row{
+				{Timestamp: t0, Data: map[string]interface{}{"trace_id": "stored"}},
+				{Timestamp: t0.Add(time.Minute), Data: map[string]interface{}{"trace_id": "expired"}},
+				{Timestamp: t0, Data: map[string]interface{}{}},
+			}},
+			{QueryName: "B", List: []*v3.Row{
+				{Timestamp: t0, Data: map[string]interface{}{"trace_id": "stored"}},
+			}},
+		}
+	}
+
+	reader := &traceIDsReader{traces: map[string]struct{}{"stored": {}}}
+	aH := &APIHandler{reader: reader}
+	results := newResults()
+	aH.markLogTraceLinks(ctx, traceLinksParams(), results)
+	rows := results[0].List
+	require.True(*rows[0].TraceExists)
+	require.False(*rows[1].TraceExists)
+	require.Nil(rows[2].TraceExists)
+	require.Nil(results[1].List[0].TraceExists, "only logs are flagged")
+
+	// the traces are looked for around the rows linking to them
+	require.Len(reader.lookups, 1)
+	require.Equal([]interface{}{
+		[]string{"stored", "expired"},
+		t0.Add(-traceLinkWindow).UnixNano(),
+		t0.Add(time.Minute + traceLinkWindow).UnixNano(),
+	}, reader.lookups[0])
+
+	// failures leave the rows unflagged
+	reader.err = model.InternalError(fmt.Errorf("failed"))
+	results = newResults()
+	aH.markLogTraceLinks(ctx, traceLinksParams(), results)
+	require.Nil(results[0].List[0].TraceExists)
+
+	// only list results are flagged
+	reader.lookups = nil
+	params := traceLinksParams()
+	params.CompositeQuery.PanelType = v3.PanelTypeTable
+	aH.markLogTraceLinks(ctx, params, newResults())
+	require.Empty(reader.lookups)
+}
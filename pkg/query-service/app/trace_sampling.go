@@ -0,0 +1,117 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	tsp "go.signoz.io/signoz/pkg/query-service/app/opamp/otelconfig/tailsampler"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+// TraceSamplingParams identify a trace by its id and the attributes of its
+// spans, either of which can be left out. The attributes of the stored spans
+// of the trace are used when only its id is given. The policies evaluated
+// are the ones deployed to agents in the given agent groups.
+type TraceSamplingParams struct {
+	TraceID     string                   `json:"traceId"`
+	Spans       []map[string]interface{} `json:"spans"`
+	AgentGroups []string                 `json:"agentGroups"`
+}
+
+type TraceSamplingExplanation struct {
+	*tsp.Decision
+
+	// the sampling rules version whose policies were evaluated
+	Version int `json:"version"`
+
+	// whether the trace has spans stored in the last day, only set when a
+	// trace id is given
+	Stored *bool `json:"stored,omitempty"`
+}
+
+// explainTraceSampling evaluates the deployed tail sampling policies for a
+// trace, telling which policy decides whether it is kept and why
+func (aH *APIHandler) explainTraceSampling(w http.ResponseWriter, r *http.Request) {
+	req := TraceSamplingParams{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.TraceID == "" && len(req.Spans) == 0 {
+		RespondError(w, model.BadRequestStr("trace id or span attributes are required"), nil)
+		return
+	}
+
+	config, version, apiErr := deployedSamplingConfig(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// the attributes of the spans of the trace are looked up when only its
+	// id is given
+	if len(req.Spans) == 0 {
+		req.Spans, apiErr = aH.reader.GetTraceSpanAttributes(r.Context(), req.TraceID)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+	}
+
+	decision, err := config.ForAgentGroups(req.AgentGroups).Evaluate(req.TraceID, req.Spans)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	explanation := TraceSamplingExplanation{Decision: decision, Version: version}
+
+	if req.TraceID != "" {
+		now := time.Now()
+		existing, apiErr := aH.reader.GetExistingTraceIDs(
+			r.Context(), []string{req.TraceID}, now.Add(-traceExistenceLookback).UnixNano(), now.UnixNano(),
+		)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		_, stored := existing[req.TraceID]
+		explanation.Stored = &stored
+	}
+	aH.Respond(w, explanation)
+}
+
+// deployedSamplingConfig returns the tail sampling config of the latest
+// sampling rules version along with the version
+func deployedSamplingConfig(r *http.Request) (*tsp.Config, int, *model.ApiError) {
+	latest, apiErr := agentConf.GetLatestVersion(r.Context(), agentConf.ElementTypeSamplingRules)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return nil, 0, model.NotFoundError(fmt.Errorf("no tail sampling policies are deployed"))
+		}
+		return nil, 0, apiErr
+	}
+	// the latest version is fetched without its config
+	configVersion, apiErr := agentConf.GetConfigVersion(
+		r.Context(), agentConf.ElementTypeSamplingRules, latest.Version,
+	)
+	if apiErr != nil {
+		return nil, 0, apiErr
+	}
+	if configVersion.LastConf == "" || configVersion.LastConf == "{}" {
+		return nil, 0, model.NotFoundError(fmt.Errorf(
+			"sampling rules version %d has not been deployed", latest.Version,
+		))
+	}
+
+	config := &tsp.Config{}
+	if err := yaml.Unmarshal([]byte(configVersion.LastConf), config); err != nil {
+		return nil, 0, model.InternalError(fmt.Errorf(
+			"could not parse the deployed tail sampling config: %w", err,
+		))
+	}
+	return config, latest.Version, nil
+}
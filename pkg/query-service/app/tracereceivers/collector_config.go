@@ -0,0 +1,118 @@
+package tracereceivers
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+const componentNamePrefix = "signoz_trace"
+
+// GenerateCollectorConfigWithTraceReceivers adds a jaeger or zipkin receiver
+// for each enabled trace receiver to the traces pipeline, so that the spans
+// they receive are converted to OTLP by the agents. Receivers are removed
+// when there are no enabled trace receivers.
+func GenerateCollectorConfigWithTraceReceivers(
+	config []byte, traceReceivers []TraceReceiver,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if c == nil {
+		return nil, model.BadRequest(fmt.Errorf("collector config is empty"))
+	}
+
+	service, ok := c["service"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("service not found in OTEL config"))
+	}
+	pipelines, ok := service["pipelines"].(map[string]interface{})
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("pipelines not found in OTEL config"))
+	}
+
+	receivers, ok := c["receivers"].(map[string]interface{})
+	if !ok || receivers == nil {
+		receivers = map[string]interface{}{}
+	}
+	for name := range receivers {
+		if isTraceReceiverComponent(name) {
+			delete(receivers, name)
+		}
+	}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		current, _ := pipeline["receivers"].([]interface{})
+		updated := []interface{}{}
+		for _, r := range current {
+			if name, ok := r.(string); !ok || !isTraceReceiverComponent(name) {
+				updated = append(updated, r)
+			}
+		}
+		pipeline["receivers"] = updated
+	}
+
+	tracesPipeline, hasTracesPipeline := pipelines["traces"].(map[string]interface{})
+	for _, tr := range traceReceivers {
+		if !tr.Spec.Enabled || !hasTracesPipeline {
+			// the agent doesn't process traces
+			continue
+		}
+		spec := tr.Spec
+		spec.setDefaults()
+
+		server := map[string]interface{}{
+			"endpoint": spec.endpoint(),
+		}
+		if spec.TLS != nil {
+			tls := map[string]interface{}{
+				"cert_file": spec.TLS.CertFile,
+				"key_file":  spec.TLS.KeyFile,
+			}
+			if spec.TLS.ClientCAFile != "" {
+				tls["client_ca_file"] = spec.TLS.ClientCAFile
+			}
+			server["tls"] = tls
+		}
+
+		name := componentNamePrefix + "_" + tr.Id
+		var receiverName string
+		if spec.Protocol == ProtocolZipkin {
+			receiverName = "zipkin/" + name
+			receivers[receiverName] = server
+		} else {
+			receiverName = "jaeger/" + name
+			receivers[receiverName] = map[string]interface{}{
+				"protocols": map[string]interface{}{
+					strings.TrimPrefix(spec.Protocol, "jaeger_"): server,
+				},
+			}
+		}
+		tracesPipeline["receivers"] = append(tracesPipeline["receivers"].([]interface{}), receiverName)
+	}
+
+	if len(receivers) > 0 {
+		c["receivers"] = receivers
+	} else {
+		delete(c, "receivers")
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not marshal collector config: %w", err,
+		))
+	}
+	return updatedConf, nil
+}
+
+func isTraceReceiverComponent(name string) bool {
+	_, componentName, _ := strings.Cut(name, "/")
+	return strings.HasPrefix(componentName, componentNamePrefix)
+}
@@ -0,0 +1,93 @@
+package tracereceivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+processors:
+  batch: {}
+exporters:
+  clickhousetraces: {}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousetraces]
+`
+
+type testConf struct {
+	Receivers map[string]map[string]interface{} `yaml:"receivers"`
+	Service   struct {
+		Pipelines map[string]struct {
+			Receivers []string `yaml:"receivers"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func TestGenerateCollectorConfigWithTraceReceivers(t *testing.T) {
+	require := require.New(t)
+
+	traceReceivers := []TraceReceiver{
+		{
+			Id: "jaeger", Name: "jaeger",
+			Spec: TraceReceiverSpec{
+				Enabled: true, Protocol: ProtocolJaegerGrpc, Port: 14250,
+				TLS: &TLSConfig{CertFile: "/certs/cert.pem", KeyFile: "/certs/key.pem"},
+			},
+		},
+		{
+			Id: "zipkin", Name: "zipkin",
+			Spec: TraceReceiverSpec{
+				Enabled: true, Protocol: ProtocolZipkin, ListenAddress: "localhost", Port: 9411,
+			},
+		},
+	}
+	for _, tr := range traceReceivers {
+		require.Nil(tr.Spec.IsValid())
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithTraceReceivers([]byte(testCollectorConf), traceReceivers)
+	require.Nil(apiErr)
+
+	var conf testConf
+	require.Nil(yaml.Unmarshal(updated, &conf))
+
+	require.Equal(
+		[]string{"otlp", "jaeger/signoz_trace_jaeger", "zipkin/signoz_trace_zipkin"},
+		conf.Service.Pipelines["traces"].Receivers,
+	)
+	require.Equal(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"endpoint": "0.0.0.0:14250",
+			"tls": map[string]interface{}{
+				"cert_file": "/certs/cert.pem",
+				"key_file":  "/certs/key.pem",
+			},
+		},
+	}, conf.Receivers["jaeger/signoz_trace_jaeger"]["protocols"])
+	require.Equal("localhost:9411", conf.Receivers["zipkin/signoz_trace_zipkin"]["endpoint"])
+
+	// trace receivers should get cleaned up when disabled
+	traceReceivers[0].Spec.Enabled = false
+	traceReceivers[1].Spec.Enabled = false
+	updated, apiErr = GenerateCollectorConfigWithTraceReceivers(updated, traceReceivers)
+	require.Nil(apiErr)
+
+	conf = testConf{}
+	require.Nil(yaml.Unmarshal(updated, &conf))
+	require.Equal(1, len(conf.Receivers))
+	require.Equal([]string{"otlp"}, conf.Service.Pipelines["traces"].Receivers)
+
+	compact := TraceReceiverSpec{
+		Protocol: ProtocolJaegerThriftCompact, Port: 6831,
+		TLS: &TLSConfig{CertFile: "/certs/cert.pem", KeyFile: "/certs/key.pem"},
+	}
+	require.NotNil(compact.IsValid(), "tls should not be allowed for UDP protocols")
+}
@@ -0,0 +1,242 @@
+package tracereceivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
+)
+
+const TraceReceiversFeatureType agentConf.AgentFeatureType = "trace_receivers"
+
+// ports of the OTLP receiver of the agents
+var reservedPorts = []int{4317, 4318}
+
+// Controller manages the jaeger and zipkin receivers agents accept spans
+// with and deploys the receiver config derived from them via agentConf.
+type Controller struct {
+	repo *Repo
+}
+
+func NewController(db *sqlx.DB) (*Controller, error) {
+	repo, err := NewRepo(db)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create trace receivers repo: %w", err)
+	}
+
+	return &Controller{
+		repo: repo,
+	}, nil
+}
+
+type TraceReceiversResponse struct {
+	*agentConf.ConfigVersion
+
+	TraceReceivers []TraceReceiver `json:"traceReceivers"`
+}
+
+func (c *Controller) ListTraceReceivers(ctx context.Context) (
+	*TraceReceiversResponse, *model.ApiError,
+) {
+	traceReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeTraceReceivers)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest trace receivers config version")
+	}
+
+	return &TraceReceiversResponse{
+		ConfigVersion:  latest,
+		TraceReceivers: traceReceivers,
+	}, nil
+}
+
+func (c *Controller) GetTraceReceiver(ctx context.Context, id string) (
+	*TraceReceiver, *model.ApiError,
+) {
+	return c.repo.get(ctx, id)
+}
+
+// CreateTraceReceiver stores a new trace receiver and starts deploying
+// an agent config that listens on its port
+func (c *Controller) CreateTraceReceiver(
+	ctx context.Context, postable *PostableTraceReceiver,
+) (*TraceReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+	if apiErr := c.ensurePortIsFree(ctx, "", postable.Spec); apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	traceReceiver, apiErr := c.repo.insert(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.delete(ctx, traceReceiver.Id)
+		return nil, apiErr
+	}
+
+	return traceReceiver, nil
+}
+
+// UpdateTraceReceiver replaces the name and spec of a trace receiver and
+// starts deploying the updated agent config
+func (c *Controller) UpdateTraceReceiver(
+	ctx context.Context, id string, postable *PostableTraceReceiver,
+) (*TraceReceiver, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	postable.Spec.setDefaults()
+
+	existing, apiErr := c.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := c.ensurePortIsFree(ctx, id, postable.Spec); apiErr != nil {
+		return nil, apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return nil, model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	updated := *existing
+	updated.Name = postable.Name
+	updated.Spec = postable.Spec
+	if apiErr := c.repo.update(ctx, userId, &updated); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := c.startNewVersion(ctx, userId); apiErr != nil {
+		c.repo.update(ctx, existing.UpdatedBy, existing)
+		return nil, apiErr
+	}
+
+	return &updated, nil
+}
+
+// DeleteTraceReceiver removes a trace receiver and starts deploying
+// an agent config without it
+func (c *Controller) DeleteTraceReceiver(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := c.repo.get(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(ctx)
+	if err != nil {
+		return model.UnauthorizedError(fmt.Errorf(
+			"failed to get userId from context: %w", err,
+		))
+	}
+
+	if apiErr := c.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	return c.startNewVersion(ctx, userId)
+}
+
+// ensurePortIsFree rejects enabling a receiver on a port the agents already
+// listen on, for OTLP or another enabled trace receiver
+func (c *Controller) ensurePortIsFree(
+	ctx context.Context, id string, spec TraceReceiverSpec,
+) *model.ApiError {
+	if !spec.Enabled {
+		return nil
+	}
+	if slices.Contains(reservedPorts, spec.Port) {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("port %d is reserved for the OTLP receiver", spec.Port),
+		}
+	}
+
+	traceReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	for _, tr := range traceReceivers {
+		if tr.Id != id && tr.Spec.Enabled && tr.Spec.Port == spec.Port {
+			return &model.ApiError{
+				Typ: model.ErrorConflict,
+				Err: fmt.Errorf("port %d is used by the trace receiver %s", spec.Port, tr.Name),
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) startNewVersion(ctx context.Context, userId string) *model.ApiError {
+	traceReceivers, apiErr := c.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	elements := make([]string, len(traceReceivers))
+	for i, d := range traceReceivers {
+		elements[i] = d.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeTraceReceivers, elements)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to start new trace receivers config version")
+	}
+	return nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) AgentFeatureType() agentConf.AgentFeatureType {
+	return TraceReceiversFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (c *Controller) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	traceReceivers, apiErr := c.repo.getByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithTraceReceivers(currentConfYaml, traceReceivers)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for trace receivers")
+	}
+
+	rawTraceReceivers, err := json.Marshal(traceReceivers)
+	if err != nil {
+		return nil, "", model.InternalError(fmt.Errorf(
+			"could not serialize trace receivers to JSON: %w", err,
+		))
+	}
+
+	return updatedConf, string(rawTraceReceivers), nil
+}
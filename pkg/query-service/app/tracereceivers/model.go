@@ -0,0 +1,127 @@
+package tracereceivers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+const defaultListenAddress = "0.0.0.0"
+
+// Protocols of the trace formats predating OTLP the agents can receive
+const (
+	ProtocolJaegerGrpc          = "jaeger_grpc"
+	ProtocolJaegerThriftHttp    = "jaeger_thrift_http"
+	ProtocolJaegerThriftCompact = "jaeger_thrift_compact"
+	ProtocolJaegerThriftBinary  = "jaeger_thrift_binary"
+	ProtocolZipkin              = "zipkin"
+)
+
+var supportedProtocols = []string{
+	ProtocolJaegerGrpc,
+	ProtocolJaegerThriftHttp,
+	ProtocolJaegerThriftCompact,
+	ProtocolJaegerThriftBinary,
+	ProtocolZipkin,
+}
+
+// the thrift compact and binary protocols are received over UDP
+var tlsProtocols = []string{ProtocolJaegerGrpc, ProtocolJaegerThriftHttp, ProtocolZipkin}
+
+// TraceReceiver makes agents receive spans in a jaeger or zipkin format
+// and convert them to OTLP
+type TraceReceiver struct {
+	Id        string            `json:"id" db:"id"`
+	Name      string            `json:"name" db:"name"`
+	Spec      TraceReceiverSpec `json:"spec" db:"spec_json"`
+	CreatedBy string            `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedBy string            `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time         `json:"updatedAt" db:"updated_at"`
+}
+
+type TraceReceiverSpec struct {
+	Enabled bool `json:"enabled"`
+
+	Protocol      string     `json:"protocol"`
+	ListenAddress string     `json:"listenAddress,omitempty"`
+	Port          int        `json:"port"`
+	TLS           *TLSConfig `json:"tls,omitempty"`
+}
+
+// TLSConfig points to the certificate files on the collectors' hosts
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// ClientCAFile makes the receiver require client certificates signed
+	// by the CA
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+}
+
+// For serializing from db
+func (s *TraceReceiverSpec) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+// For serializing to db
+func (s TraceReceiverSpec) Value() (driver.Value, error) {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize trace receiver spec to JSON")
+	}
+	return serialized, nil
+}
+
+func (s *TraceReceiverSpec) setDefaults() {
+	if s.ListenAddress == "" {
+		s.ListenAddress = defaultListenAddress
+	}
+}
+
+func (s *TraceReceiverSpec) endpoint() string {
+	return fmt.Sprintf("%s:%d", s.ListenAddress, s.Port)
+}
+
+type PostableTraceReceiver struct {
+	Name string            `json:"name"`
+	Spec TraceReceiverSpec `json:"spec"`
+}
+
+func (p *PostableTraceReceiver) IsValid() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("trace receiver name is required")
+	}
+	return p.Spec.IsValid()
+}
+
+func (s *TraceReceiverSpec) IsValid() error {
+	if !slices.Contains(supportedProtocols, s.Protocol) {
+		return fmt.Errorf("protocol must be one of %s", strings.Join(supportedProtocols, ", "))
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if strings.ContainsAny(s.ListenAddress, ": ") {
+		return fmt.Errorf("listenAddress must be a host without a port")
+	}
+	if s.TLS != nil {
+		if !slices.Contains(tlsProtocols, s.Protocol) {
+			return fmt.Errorf("tls is only supported for %s", strings.Join(tlsProtocols, ", "))
+		}
+		if strings.TrimSpace(s.TLS.CertFile) == "" || strings.TrimSpace(s.TLS.KeyFile) == "" {
+			return fmt.Errorf("tls requires both certFile and keyFile")
+		}
+	}
+	return nil
+}
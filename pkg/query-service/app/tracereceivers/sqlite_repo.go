@@ -0,0 +1,208 @@
+package tracereceivers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	createTablesStatements := `
+		CREATE TABLE IF NOT EXISTS trace_receivers(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			spec_json TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(createTablesStatements)
+	if err != nil {
+		return fmt.Errorf(
+			"could not ensure trace receivers schema in sqlite DB: %w", err,
+		)
+	}
+
+	return nil
+}
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	err := InitSqliteDBIfNeeded(db)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't ensure sqlite schema for trace receivers: %w", err,
+		)
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func (r *Repo) list(ctx context.Context) ([]TraceReceiver, *model.ApiError) {
+	traceReceivers := []TraceReceiver{}
+
+	err := r.db.SelectContext(ctx, &traceReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM trace_receivers
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query trace receivers: %w", err,
+		))
+	}
+	return traceReceivers, nil
+}
+
+func (r *Repo) get(ctx context.Context, id string) (*TraceReceiver, *model.ApiError) {
+	traceReceivers := []TraceReceiver{}
+
+	err := r.db.SelectContext(ctx, &traceReceivers, `
+		SELECT id, name, spec_json, created_by, created_at, updated_by, updated_at
+		FROM trace_receivers
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query trace receiver %s: %w", id, err,
+		))
+	}
+
+	if len(traceReceivers) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("trace receiver %s not found", id))
+	}
+	return &traceReceivers[0], nil
+}
+
+// getByVersion returns trace receivers associated with a given agent config version
+func (r *Repo) getByVersion(ctx context.Context, version int) ([]TraceReceiver, *model.ApiError) {
+	traceReceivers := []TraceReceiver{}
+
+	err := r.db.SelectContext(ctx, &traceReceivers, `
+		SELECT t.id, t.name, t.spec_json, t.created_by, t.created_at, t.updated_by, t.updated_at
+		FROM trace_receivers t,
+			agent_config_elements e,
+			agent_config_versions v
+		WHERE t.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY t.name
+	`, agentConf.ElementTypeTraceReceivers, version)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not query trace receivers for version %d: %w", version, err,
+		))
+	}
+	return traceReceivers, nil
+}
+
+func (r *Repo) ensureNameIsUnique(ctx context.Context, name string, id string) *model.ApiError {
+	var existing int
+	err := r.db.GetContext(ctx, &existing, `
+		SELECT count(*) FROM trace_receivers WHERE name = $1 AND id != $2
+	`, name, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not query trace receivers: %w", err,
+		))
+	}
+	if existing > 0 {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("a trace receiver named %s already exists", name),
+		}
+	}
+	return nil
+}
+
+func (r *Repo) insert(
+	ctx context.Context, userId string, postable *PostableTraceReceiver,
+) (*TraceReceiver, *model.ApiError) {
+	now := time.Now()
+	traceReceiver := &TraceReceiver{
+		Id:        uuid.NewString(),
+		Name:      postable.Name,
+		Spec:      postable.Spec,
+		CreatedBy: userId,
+		CreatedAt: now,
+		UpdatedBy: userId,
+		UpdatedAt: now,
+	}
+
+	if apiErr := r.ensureNameIsUnique(ctx, traceReceiver.Name, traceReceiver.Id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO trace_receivers (
+			id, name, spec_json, created_by, created_at, updated_by, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		traceReceiver.Id, traceReceiver.Name, traceReceiver.Spec,
+		traceReceiver.CreatedBy, traceReceiver.CreatedAt,
+		traceReceiver.UpdatedBy, traceReceiver.UpdatedAt,
+	)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf(
+			"could not insert trace receiver: %w", err,
+		))
+	}
+
+	return traceReceiver, nil
+}
+
+func (r *Repo) update(
+	ctx context.Context, userId string, traceReceiver *TraceReceiver,
+) *model.ApiError {
+	if apiErr := r.ensureNameIsUnique(ctx, traceReceiver.Name, traceReceiver.Id); apiErr != nil {
+		return apiErr
+	}
+
+	traceReceiver.UpdatedBy = userId
+	traceReceiver.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE trace_receivers
+		SET name = $1, spec_json = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`,
+		traceReceiver.Name, traceReceiver.Spec,
+		traceReceiver.UpdatedBy, traceReceiver.UpdatedAt, traceReceiver.Id,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not update trace receiver %s: %w", traceReceiver.Id, err,
+		))
+	}
+	return nil
+}
+
+func (r *Repo) delete(ctx context.Context, id string) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM trace_receivers WHERE id = $1
+	`, id)
+	if err != nil {
+		return model.InternalError(fmt.Errorf(
+			"could not delete trace receiver %s: %w", id, err,
+		))
+	}
+	return nil
+}
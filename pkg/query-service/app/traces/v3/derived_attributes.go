@@ -0,0 +1,61 @@
+package v3
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// derivedAttribute is computed from a span attribute at query time
+type derivedAttribute struct {
+	source     string
+	expression string
+}
+
+var derivedAttributes = map[string]derivedAttribute{
+	// normalizeQuery replaces literals with placeholders and collapses lists
+	// of literals like IN (1, 2, 3) into (?..), so that executions of the same
+	// statement with different arguments get the same fingerprint
+	constants.DBStatementFingerprint: {
+		source:     "db.statement",
+		expression: "normalizeQuery(stringTagMap['db.statement'])",
+	},
+}
+
+// DerivedAttributeExpression returns the expression computing a derived attribute
+func DerivedAttributeExpression(key string) (string, bool) {
+	attr, ok := derivedAttributes[key]
+	if !ok {
+		return "", false
+	}
+	return attr.expression, true
+}
+
+// DerivedAttributeKeys returns the derived attributes containing searchText
+func DerivedAttributeKeys(searchText string) []v3.AttributeKey {
+	keys := []v3.AttributeKey{}
+	for key := range derivedAttributes {
+		if strings.Contains(strings.ToLower(key), strings.ToLower(searchText)) {
+			keys = append(keys, v3.AttributeKey{
+				Key:      key,
+				DataType: v3.AttributeKeyDataTypeString,
+				Type:     v3.AttributeKeyTypeTag,
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// derivedAttributeExistsCondition checks that the attribute a derived attribute
+// is computed from is present
+func derivedAttributeExistsCondition(key string, op v3.FilterOperator) (string, bool) {
+	attr, ok := derivedAttributes[key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(tracesOperatorMappingV3[op], "string", "TagMap", attr.source), true
+}
@@ -59,6 +59,9 @@ var tracesOperatorMappingV3 = map[v3.FilterOperator]string{
 }
 
 func getColumnName(key v3.AttributeKey, keys map[string]v3.AttributeKey) string {
+	if expression, ok := DerivedAttributeExpression(key.Key); ok {
+		return expression
+	}
 	key = enrichKeyWithMetadata(key, keys)
 	if key.IsColumn {
 		return key.Key
@@ -67,6 +70,13 @@ func getColumnName(key v3.AttributeKey, keys map[string]v3.AttributeKey) string
 	return fmt.Sprintf("%s%s['%s']", filterDataType, filterType, key.Key)
 }
 
+// AttributeExpression returns the column or map lookup holding the value of
+// an attribute, e.g. stringTagMap['db.system']. Keys without type are looked
+// up in keys, span attributes are assumed if missing there.
+func AttributeExpression(key v3.AttributeKey, keys map[string]v3.AttributeKey) string {
+	return getColumnName(key, keys)
+}
+
 func getClickhouseTracesColumnDataTypeAndType(key v3.AttributeKey) (v3.AttributeKeyType, string) {
 	filterType := key.Type
 	filterDataType := "string"
@@ -178,7 +188,9 @@ func buildTracesFilterQuery(fs *v3.FilterSet, keys map[string]v3.AttributeKey) (
 				case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
 					conditions = append(conditions, fmt.Sprintf(operator, columnName, fmtVal))
 				case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
-					if key.IsColumn {
+					if condition, ok := derivedAttributeExistsCondition(key.Key, item.Operator); ok {
+						conditions = append(conditions, condition)
+					} else if key.IsColumn {
 						subQuery, err := existsSubQueryForFixedColumn(key, item.Operator)
 						if err != nil {
 							return "", err
@@ -217,6 +229,51 @@ func existsSubQueryForFixedColumn(key v3.AttributeKey, op v3.FilterOperator) (st
 	}
 }
 
+// buildTraceConditionsQuery restricts the spans queried to those of traces
+// satisfying the conditions. Traces needing spans are found with a single
+// scan of the spans matching any of the conditions grouped by trace, while
+// traces which mustn't have spans are excluded with an anti join.
+func buildTraceConditionsQuery(conditions []v3.TraceCondition, timeFilter string, keys map[string]v3.AttributeKey) (string, error) {
+	hasSpan := []string{}
+	noSpan := []string{}
+	for _, c := range conditions {
+		filter, err := buildTracesFilterQuery(c.Filters, keys)
+		if err != nil {
+			return "", err
+		}
+		filter = strings.TrimPrefix(filter, " AND ")
+		if filter == "" {
+			return "", fmt.Errorf("trace condition needs filters for the spans to look for")
+		}
+		filter = "(" + filter + ")"
+		if c.Operator == v3.TraceConditionNoSpan {
+			noSpan = append(noSpan, filter)
+		} else {
+			hasSpan = append(hasSpan, filter)
+		}
+	}
+
+	table := constants.SIGNOZ_TRACE_DBNAME + "." + constants.SIGNOZ_SPAN_INDEX_TABLENAME
+	query := ""
+	if len(hasSpan) > 0 {
+		having := make([]string, len(hasSpan))
+		for i, filter := range hasSpan {
+			having[i] = fmt.Sprintf("countIf%s > 0", filter)
+		}
+		query += fmt.Sprintf(
+			" AND traceID GLOBAL IN (SELECT traceID from %s where %s AND (%s) group by traceID having %s)",
+			table, timeFilter, strings.Join(hasSpan, " OR "), strings.Join(having, " AND "),
+		)
+	}
+	if len(noSpan) > 0 {
+		query += fmt.Sprintf(
+			" AND traceID GLOBAL NOT IN (SELECT DISTINCT traceID from %s where %s AND (%s))",
+			table, timeFilter, strings.Join(noSpan, " OR "),
+		)
+	}
+	return query, nil
+}
+
 func handleEmptyValuesInGroupBy(keys map[string]v3.AttributeKey, groupBy []v3.AttributeKey) (string, error) {
 	filterItems := []v3.FilterItem{}
 	if len(groupBy) != 0 {
@@ -247,9 +304,13 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, tableName str
 		return "", err
 	}
 	// timerange will be sent in epoch millisecond
-	spanIndexTableTimeFilter := fmt.Sprintf("(timestamp >= '%d' AND timestamp <= '%d')", start*getZerosForEpochNano(start), end*getZerosForEpochNano(end))
+	spanIndexTableTimeFilter := fmt.Sprintf("(timestamp >= %s AND timestamp <= %s)", utils.ClickHouseQuotedTimestampNano(mq, start), utils.ClickHouseQuotedTimestampNano(mq, end))
 
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy, keys)
+	rollup := panelType == v3.PanelTypeTable && mq.GroupByRollup && len(mq.GroupBy) > 0
+	if rollup {
+		selectLabels += utils.RollupLevelLabel(mq.GroupBy)
+	}
 
 	having := having(mq.Having)
 	if having != "" {
@@ -286,10 +347,19 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, tableName str
 	}
 	filterSubQuery += emptyValuesInGroupByFilter
 
+	traceConditionsQuery, err := buildTraceConditionsQuery(mq.TraceConditions, spanIndexTableTimeFilter, keys)
+	if err != nil {
+		return "", err
+	}
+	filterSubQuery += traceConditionsQuery
+
 	groupBy := groupByAttributeKeyTags(panelType, options.GraphLimitQtype, mq.GroupBy...)
 	if groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if rollup {
+		groupBy = " group by ROLLUP(" + groupByAttributeKeyTags(panelType, options.GraphLimitQtype, mq.GroupBy...) + ")"
+	}
 	enrichedOrderBy := enrichOrderBy(mq.OrderBy, keys)
 	orderBy := orderByAttributeKeyTags(panelType, enrichedOrderBy, mq.GroupBy, keys)
 	if orderBy != "" {
@@ -72,6 +72,14 @@ var buildFilterQueryData = []struct {
 		}},
 		ExpectedFilter: " AND has(stringTagMap, 'bytes')",
 	},
+	{
+		Name: "Test derived attribute",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "db.statement.fingerprint", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "SELECT * FROM users WHERE id = ?", Operator: "="},
+			{Key: v3.AttributeKey{Key: "db.statement.fingerprint", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "", Operator: "exists"},
+		}},
+		ExpectedFilter: " AND normalizeQuery(stringTagMap['db.statement']) = 'SELECT * FROM users WHERE id = ?' AND has(stringTagMap, 'db.statement')",
+	},
 	{
 		Name: "Test exists with fixed column",
 		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
@@ -1141,6 +1149,42 @@ var testBuildTracesQueryData = []struct {
 			"ORDER BY subQuery.durationNano desc;",
 		PanelType: v3.PanelTypeTrace,
 	},
+	{
+		Name:  "Test count of spans of traces with and without spans matching conditions",
+		Start: 1680066360726210000,
+		End:   1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:         "A",
+			StepInterval:      60,
+			AggregateOperator: v3.AggregateOperatorCount,
+			Expression:        "A",
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "checkout", Operator: "="},
+			}},
+			TraceConditions: []v3.TraceCondition{
+				{Operator: v3.TraceConditionHasSpan, Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "POST", Operator: "="},
+				}}},
+				{Operator: v3.TraceConditionHasSpan, Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "charge", Operator: "="},
+				}}},
+				{Operator: v3.TraceConditionNoSpan, Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "retry", Operator: "="},
+				}}},
+			},
+		},
+		TableName: "signoz_traces.distributed_signoz_index_v2",
+		ExpectedQuery: "SELECT toStartOfInterval(timestamp, INTERVAL 60 SECOND) AS ts, toFloat64(count()) as value" +
+			" from signoz_traces.distributed_signoz_index_v2 where (timestamp >= '1680066360726210000' AND timestamp <= '1680066458000000000')" +
+			" AND name = 'checkout' AND traceID GLOBAL IN (SELECT traceID from signoz_traces.distributed_signoz_index_v2" +
+			" where (timestamp >= '1680066360726210000' AND timestamp <= '1680066458000000000')" +
+			" AND ((stringTagMap['method'] = 'POST') OR (name = 'charge')) group by traceID" +
+			" having countIf(stringTagMap['method'] = 'POST') > 0 AND countIf(name = 'charge') > 0)" +
+			" AND traceID GLOBAL NOT IN (SELECT DISTINCT traceID from signoz_traces.distributed_signoz_index_v2" +
+			" where (timestamp >= '1680066360726210000' AND timestamp <= '1680066458000000000') AND ((name = 'retry')))" +
+			" group by ts order by value DESC",
+		PanelType: v3.PanelTypeGraph,
+	},
 }
 
 func TestBuildTracesQuery(t *testing.T) {
@@ -0,0 +1,143 @@
+// Package trash keeps deleted dashboards, rules and pipelines restorable for
+// a while, after which they are purged for good.
+package trash
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// Retention is how long deleted resources stay in the trash
+	Retention = 30 * 24 * time.Hour
+
+	purgeInterval = time.Hour
+)
+
+type ResourceType string
+
+const (
+	ResourceDashboards ResourceType = "dashboards"
+	ResourceRules      ResourceType = "rules"
+	ResourcePipelines  ResourceType = "pipelines"
+)
+
+// Item is a deleted resource which can still be restored
+type Item struct {
+	Type      ResourceType `json:"type"`
+	Id        string       `json:"id"`
+	Name      string       `json:"name"`
+	DeletedAt time.Time    `json:"deletedAt"`
+	DeletedBy string       `json:"deletedBy"`
+	PurgeAt   time.Time    `json:"purgeAt"`
+}
+
+// Store is implemented by the resources supporting soft deletion
+type Store interface {
+	// ListDeleted returns the deleted resources which weren't purged yet
+	ListDeleted(ctx context.Context) ([]Item, *model.ApiError)
+
+	// RestoreDeleted brings back a deleted resource as it was when deleted
+	RestoreDeleted(ctx context.Context, id string) *model.ApiError
+
+	// PurgeDeleted removes a deleted resource for good
+	PurgeDeleted(ctx context.Context, id string) *model.ApiError
+
+	// PurgeExpired removes the resources deleted before the given time
+	PurgeExpired(ctx context.Context, deletedBefore time.Time) *model.ApiError
+}
+
+type Trash struct {
+	stores map[ResourceType]Store
+	done   chan struct{}
+}
+
+func NewTrash(stores map[ResourceType]Store) *Trash {
+	return &Trash{
+		stores: stores,
+		done:   make(chan struct{}),
+	}
+}
+
+func (t *Trash) store(resourceType ResourceType) (Store, *model.ApiError) {
+	store, ok := t.stores[resourceType]
+	if !ok {
+		return nil, model.BadRequest(fmt.Errorf("unsupported resource type in trash: %s", resourceType))
+	}
+	return store, nil
+}
+
+// List returns the items in the trash, the most recently deleted first
+func (t *Trash) List(ctx context.Context) ([]Item, *model.ApiError) {
+	items := []Item{}
+	for resourceType, store := range t.stores {
+		deleted, apiErr := store.ListDeleted(ctx)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf("could not list deleted %s", resourceType))
+		}
+		for _, item := range deleted {
+			item.Type = resourceType
+			item.PurgeAt = item.DeletedAt.Add(Retention)
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (t *Trash) Restore(ctx context.Context, resourceType ResourceType, id string) *model.ApiError {
+	store, apiErr := t.store(resourceType)
+	if apiErr != nil {
+		return apiErr
+	}
+	return store.RestoreDeleted(ctx, id)
+}
+
+func (t *Trash) Purge(ctx context.Context, resourceType ResourceType, id string) *model.ApiError {
+	store, apiErr := t.store(resourceType)
+	if apiErr != nil {
+		return apiErr
+	}
+	return store.PurgeDeleted(ctx, id)
+}
+
+// Start purges the items which outlived the retention periodically
+func (t *Trash) Start() {
+	go func() {
+		t.purgeExpired(context.Background())
+
+		tick := time.NewTicker(purgeInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-tick.C:
+				t.purgeExpired(context.Background())
+			}
+		}
+	}()
+}
+
+func (t *Trash) Stop() {
+	close(t.done)
+}
+
+func (t *Trash) purgeExpired(ctx context.Context) {
+	deletedBefore := time.Now().Add(-Retention)
+	for resourceType, store := range t.stores {
+		if apiErr := store.PurgeExpired(ctx, deletedBefore); apiErr != nil {
+			zap.L().Error("could not purge expired items from trash",
+				zap.String("type", string(resourceType)), zap.Error(apiErr.ToError()),
+			)
+		}
+	}
+}
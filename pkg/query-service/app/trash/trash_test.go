@@ -0,0 +1,79 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type testStore struct {
+	items    []Item
+	restored []string
+	purged   []string
+}
+
+func (s *testStore) ListDeleted(ctx context.Context) ([]Item, *model.ApiError) {
+	return s.items, nil
+}
+
+func (s *testStore) RestoreDeleted(ctx context.Context, id string) *model.ApiError {
+	s.restored = append(s.restored, id)
+	return nil
+}
+
+func (s *testStore) PurgeDeleted(ctx context.Context, id string) *model.ApiError {
+	s.purged = append(s.purged, id)
+	return nil
+}
+
+func (s *testStore) PurgeExpired(ctx context.Context, deletedBefore time.Time) *model.ApiError {
+	for _, item := range s.items {
+		if item.DeletedAt.Before(deletedBefore) {
+			s.purged = append(s.purged, item.Id)
+		}
+	}
+	return nil
+}
+
+func TestTrash(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	dashboards := &testStore{items: []Item{
+		{Id: "d1", Name: "dashboard", DeletedAt: now.Add(-time.Hour)},
+		{Id: "d2", Name: "old dashboard", DeletedAt: now.Add(-Retention - time.Hour)},
+	}}
+	rules := &testStore{items: []Item{
+		{Id: "1", Name: "rule", DeletedAt: now.Add(-time.Minute)},
+	}}
+	trash := NewTrash(map[ResourceType]Store{
+		ResourceDashboards: dashboards,
+		ResourceRules:      rules,
+	})
+
+	items, apiErr := trash.List(ctx)
+	require.Nil(apiErr)
+	require.Len(items, 3)
+	require.Equal([]string{"1", "d1", "d2"}, []string{items[0].Id, items[1].Id, items[2].Id})
+	require.Equal(ResourceRules, items[0].Type)
+	require.Equal(ResourceDashboards, items[1].Type)
+	require.Equal(now.Add(-time.Hour).Add(Retention), items[1].PurgeAt)
+
+	require.Nil(trash.Restore(ctx, ResourceRules, "1"))
+	require.Equal([]string{"1"}, rules.restored)
+
+	require.Nil(trash.Purge(ctx, ResourceDashboards, "d1"))
+	require.Equal([]string{"d1"}, dashboards.purged)
+
+	apiErr = trash.Restore(ctx, ResourcePipelines, "p1")
+	require.NotNil(apiErr, "resources without a store can't be restored")
+	require.Equal(model.ErrorBadData, apiErr.Type())
+
+	trash.purgeExpired(ctx)
+	require.Equal([]string{"d1", "d2"}, dashboards.purged)
+	require.Empty(rules.purged)
+}
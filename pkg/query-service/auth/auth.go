@@ -499,6 +499,7 @@ func GenerateJWTForUser(user *model.User) (model.UserJwtObject, error) {
 	j := model.UserJwtObject{}
 	var err error
 	j.AccessJwtExpiry = time.Now().Add(JwtExpiry).Unix()
+	kid, secret := signingKeys.signingKey()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":    user.Id,
@@ -506,8 +507,11 @@ func GenerateJWTForUser(user *model.User) (model.UserJwtObject, error) {
 		"email": user.Email,
 		"exp":   j.AccessJwtExpiry,
 	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
-	j.AccessJwt, err = token.SignedString([]byte(JwtSecret))
+	j.AccessJwt, err = token.SignedString(secret)
 	if err != nil {
 		return j, errors.Errorf("failed to encode jwt: %v", err)
 	}
@@ -519,8 +523,11 @@ func GenerateJWTForUser(user *model.User) (model.UserJwtObject, error) {
 		"email": user.Email,
 		"exp":   j.RefreshJwtExpiry,
 	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
-	j.RefreshJwt, err = token.SignedString([]byte(JwtSecret))
+	j.RefreshJwt, err = token.SignedString(secret)
 	if err != nil {
 		return j, errors.Errorf("failed to encode jwt: %v", err)
 	}
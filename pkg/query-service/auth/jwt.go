@@ -24,7 +24,8 @@ func ParseJWT(jwtStr string) (jwt.MapClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.Errorf("unknown signing algo: %v", token.Header["alg"])
 		}
-		return []byte(JwtSecret), nil
+		kid, _ := token.Header["kid"].(string)
+		return signingKeys.verificationKey(kid, time.Now())
 	})
 
 	if err != nil {
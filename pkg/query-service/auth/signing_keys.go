@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// LegacySigningKeyId identifies the key configured with SIGNOZ_JWT_SECRET,
+// jwts signed with it don't have a kid header.
+const LegacySigningKeyId = "legacy"
+
+// keyring holds the jwt signing keys in memory, it is empty until
+// InitSigningKeys is called in which case JwtSecret is used for everything.
+type keyring struct {
+	mu       sync.RWMutex
+	activeId string
+	keys     map[string]model.JwtSigningKey
+}
+
+var signingKeys = &keyring{}
+
+func (k *keyring) load(keys []model.JwtSigningKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.activeId = ""
+	k.keys = map[string]model.JwtSigningKey{}
+	for _, key := range keys {
+		k.keys[key.Id] = key
+		if key.ExpiresAt == nil {
+			k.activeId = key.Id
+		}
+	}
+}
+
+func (k *keyring) secret(key model.JwtSigningKey) []byte {
+	if key.Id == LegacySigningKeyId {
+		return []byte(JwtSecret)
+	}
+	return []byte(key.Secret)
+}
+
+// signingKey returns the kid and secret to sign new jwts with
+func (k *keyring) signingKey() (string, []byte) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[k.activeId]
+	if !ok || key.Id == LegacySigningKeyId {
+		return "", []byte(JwtSecret)
+	}
+	return key.Id, k.secret(key)
+}
+
+// verificationKey returns the secret for the kid of a jwt if the key has
+// not expired
+func (k *keyring) verificationKey(kid string, now time.Time) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.keys) == 0 && kid == "" {
+		return []byte(JwtSecret), nil
+	}
+	if kid == "" {
+		kid = LegacySigningKeyId
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.Errorf("unknown signing key: %s", kid)
+	}
+	if key.ExpiresAt != nil && *key.ExpiresAt <= now.Unix() {
+		return nil, errors.Errorf("signing key %s has expired", kid)
+	}
+	return k.secret(key), nil
+}
+
+// InitSigningKeys loads the jwt signing keys, SIGNOZ_JWT_SECRET is
+// registered as the active key the first time so that existing sessions
+// keep working.
+func InitSigningKeys(ctx context.Context) error {
+	keys, apiErr := dao.DB().GetJwtSigningKeys(ctx)
+	if apiErr != nil {
+		return errors.Wrap(apiErr.Err, "failed to get jwt signing keys")
+	}
+
+	if len(keys) == 0 {
+		legacy := model.JwtSigningKey{
+			Id:        LegacySigningKeyId,
+			CreatedAt: time.Now().Unix(),
+		}
+		if apiErr := dao.DB().InsertJwtSigningKey(ctx, &legacy); apiErr != nil {
+			return errors.Wrap(apiErr.Err, "failed to register jwt secret as signing key")
+		}
+		keys = append(keys, legacy)
+	}
+
+	signingKeys.load(keys)
+	return nil
+}
+
+func reloadSigningKeys(ctx context.Context) *model.ApiError {
+	keys, apiErr := dao.DB().GetJwtSigningKeys(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	signingKeys.load(keys)
+	return nil
+}
+
+// ListSigningKeys returns the jwt signing keys without their secrets
+func ListSigningKeys(ctx context.Context) ([]model.JwtSigningKey, *model.ApiError) {
+	return dao.DB().GetJwtSigningKeys(ctx)
+}
+
+// RotateSigningKey generates a new key to sign jwts with. Jwts signed with
+// the previous keys remain valid for gracePeriod after which users have to
+// login again.
+func RotateSigningKey(ctx context.Context, gracePeriod time.Duration) (*model.JwtSigningKey, *model.ApiError) {
+	if gracePeriod < 0 {
+		return nil, model.BadRequest(fmt.Errorf("grace period can not be negative"))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to generate jwt signing key"))
+	}
+
+	now := time.Now()
+	key := &model.JwtSigningKey{
+		Id:        uuid.NewString(),
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: now.Unix(),
+	}
+	if apiErr := dao.DB().RotateJwtSigningKey(ctx, key, now.Add(gracePeriod).Unix()); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := reloadSigningKeys(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	zap.S().Infof("rotated jwt signing key, previous keys expire in %s", gracePeriod)
+	return key, nil
+}
+
+// RevokeSigningKey removes a retired signing key, invalidating the jwts
+// signed with it right away.
+func RevokeSigningKey(ctx context.Context, id string) *model.ApiError {
+	signingKeys.mu.RLock()
+	isActive := signingKeys.activeId == id
+	signingKeys.mu.RUnlock()
+
+	if isActive {
+		return &model.ApiError{
+			Typ: model.ErrorConflict,
+			Err: fmt.Errorf("signing key %s is active, rotate it before revoking", id),
+		}
+	}
+	if apiErr := dao.DB().DeleteJwtSigningKey(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return reloadSigningKeys(ctx)
+}
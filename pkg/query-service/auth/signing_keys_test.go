@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestKeyringVerificationKey(t *testing.T) {
+	JwtSecret = "legacy-secret"
+	now := time.Now()
+	expired := now.Add(-time.Minute).Unix()
+	retiring := now.Add(time.Hour).Unix()
+
+	k := &keyring{}
+
+	kid, secret := k.signingKey()
+	require.Equal(t, "", kid)
+	require.Equal(t, []byte("legacy-secret"), secret)
+	secret, err := k.verificationKey("", now)
+	require.NoError(t, err)
+	require.Equal(t, []byte("legacy-secret"), secret)
+
+	k.load([]model.JwtSigningKey{
+		{Id: LegacySigningKeyId, ExpiresAt: &retiring},
+		{Id: "old", Secret: "old-secret", ExpiresAt: &expired},
+		{Id: "new", Secret: "new-secret"},
+	})
+
+	kid, secret = k.signingKey()
+	require.Equal(t, "new", kid)
+	require.Equal(t, []byte("new-secret"), secret)
+
+	// jwts without a kid were signed with the legacy secret
+	secret, err = k.verificationKey("", now)
+	require.NoError(t, err)
+	require.Equal(t, []byte("legacy-secret"), secret)
+
+	_, err = k.verificationKey("", now.Add(2*time.Hour))
+	require.Error(t, err)
+
+	_, err = k.verificationKey("old", now)
+	require.Error(t, err)
+
+	_, err = k.verificationKey("unknown", now)
+	require.Error(t, err)
+
+	secret, err = k.verificationKey("new", now)
+	require.NoError(t, err)
+	require.Equal(t, []byte("new-secret"), secret)
+}
@@ -7,6 +7,7 @@ import (
 	inmemory "go.signoz.io/signoz/pkg/query-service/cache/inmemory"
 	redis "go.signoz.io/signoz/pkg/query-service/cache/redis"
 	"go.signoz.io/signoz/pkg/query-service/cache/status"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"gopkg.in/yaml.v2"
 )
@@ -60,10 +61,21 @@ func LoadFromYAMLCacheConfigFile(configFile string) (*Options, error) {
 func NewCache(options *Options) Cache {
 	switch options.Provider {
 	case "redis":
-		return redis.New(options.Redis)
+		return &instrumentedCache{Cache: redis.New(options.Redis)}
 	case "inmemory":
-		return inmemory.New(options.InMemory)
+		return &instrumentedCache{Cache: inmemory.New(options.InMemory)}
 	default:
 		return nil
 	}
 }
+
+// instrumentedCache records the result of lookups in the query service metrics
+type instrumentedCache struct {
+	Cache
+}
+
+func (c *instrumentedCache) Retrieve(cacheKey string, allowExpired bool) ([]byte, status.RetrieveStatus, error) {
+	data, retrieveStatus, err := c.Cache.Retrieve(cacheKey, allowExpired)
+	instrumentation.ObserveCacheLookup(retrieveStatus.String())
+	return data, retrieveStatus, err
+}
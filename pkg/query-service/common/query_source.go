@@ -0,0 +1,30 @@
+package common
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+)
+
+const (
+	QuerySourceDashboard = "dashboard"
+	QuerySourceAlert     = "alert"
+)
+
+// QuerySource identifies the dashboard or alert a query was run for
+type QuerySource struct {
+	Type string
+	Id   string
+}
+
+func ContextWithQuerySource(ctx context.Context, source QuerySource) context.Context {
+	return context.WithValue(ctx, constants.ContextQuerySourceKey, source)
+}
+
+func GetQuerySourceFromContext(ctx context.Context) *QuerySource {
+	source, ok := ctx.Value(constants.ContextQuerySourceKey).(QuerySource)
+	if !ok {
+		return nil
+	}
+	return &source
+}
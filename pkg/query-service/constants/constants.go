@@ -1,6 +1,7 @@
 package constants
 
 import (
+	"math"
 	"os"
 	"strconv"
 	"testing"
@@ -21,6 +22,8 @@ type ContextKey string
 
 const ContextUserKey ContextKey = "user"
 
+const ContextQuerySourceKey ContextKey = "querySource"
+
 var ConfigSignozIo = "https://config.signoz.io/api/v1"
 
 var DEFAULT_TELEMETRY_ANONYMOUS = false
@@ -148,6 +151,58 @@ func GetContextTimeoutMaxAllowed() time.Duration {
 
 var ContextTimeoutMaxAllowed = GetContextTimeoutMaxAllowed()
 
+// GetQueryAuditSampleRate returns the fraction of ClickHouse queries to
+// record in the query audit table, auditing is disabled by default
+func GetQueryAuditSampleRate() float64 {
+	sampleRateStr := GetOrDefaultEnv("QUERY_AUDIT_SAMPLE_RATE", "0")
+	sampleRate, err := strconv.ParseFloat(sampleRateStr, 64)
+	if err != nil || sampleRate < 0 {
+		return 0
+	}
+	return math.Min(sampleRate, 1)
+}
+
+var QueryAuditSampleRate = GetQueryAuditSampleRate()
+
+// GetLogPipelinesCPUBudgetCores returns the collector CPU, in cores, log
+// pipelines are expected to use at most before warnings are raised
+func GetLogPipelinesCPUBudgetCores() float64 {
+	budgetStr := GetOrDefaultEnv("LOG_PIPELINES_CPU_BUDGET_CORES", "0.5")
+	budget, err := strconv.ParseFloat(budgetStr, 64)
+	if err != nil || budget <= 0 {
+		return 0.5
+	}
+	return budget
+}
+
+var LogPipelinesCPUBudgetCores = GetLogPipelinesCPUBudgetCores()
+
+// GetRemoteWriteMaxBytes returns the maximum size of prometheus remote_write
+// payloads once decompressed, compressed payloads are capped to the same size
+func GetRemoteWriteMaxBytes() int {
+	maxStr := GetOrDefaultEnv("REMOTE_WRITE_MAX_BYTES", strconv.Itoa(32<<20))
+	maxBytes, err := strconv.Atoi(maxStr)
+	if err != nil || maxBytes <= 0 {
+		return 32 << 20
+	}
+	return maxBytes
+}
+
+var RemoteWriteMaxBytes = GetRemoteWriteMaxBytes()
+
+// Slack app posting alerts with actions to a channel, it is disabled
+// unless a bot token is set
+var SlackAppBotToken = GetOrDefaultEnv("SLACK_APP_BOT_TOKEN", "")
+var SlackAppSigningSecret = GetOrDefaultEnv("SLACK_APP_SIGNING_SECRET", "")
+var SlackAppChannel = GetOrDefaultEnv("SLACK_APP_CHANNEL", "")
+
+// TLS and authentication of the agents connecting to the opamp server, agents
+// aren't authenticated unless a client CA or tokens (identity=token,...) are set
+var OpAmpTLSCertFile = GetOrDefaultEnv("OPAMP_TLS_CERT_FILE", "")
+var OpAmpTLSKeyFile = GetOrDefaultEnv("OPAMP_TLS_KEY_FILE", "")
+var OpAmpTLSClientCAFile = GetOrDefaultEnv("OPAMP_TLS_CLIENT_CA_FILE", "")
+var OpAmpAuthTokens = GetOrDefaultEnv("OPAMP_AUTH_TOKENS", "")
+
 const (
 	TraceID                        = "traceID"
 	ServiceName                    = "serviceName"
@@ -164,6 +219,7 @@ const (
 	DBName                         = "dbName"
 	DBOperation                    = "dbOperation"
 	DBSystem                       = "dbSystem"
+	DBStatementFingerprint         = "db.statement.fingerprint"
 	MsgSystem                      = "msgSystem"
 	MsgOperation                   = "msgOperation"
 	Timestamp                      = "timestamp"
@@ -353,6 +409,11 @@ var StaticFieldsLogsV3 = map[string]v3.AttributeKey{
 
 const SigNozOrderByValue = "#SIGNOZ_VALUE"
 
+// RollupLevelLabel is the label holding the number of group by keys a series
+// of a query grouped by rollup is aggregated by, subtotal series have less
+// than the number of keys in the group by
+const RollupLevelLabel = "__rollup_level"
+
 const TIMESTAMP = "timestamp"
 
 const FirstQueryGraphLimit = "first_query_graph_limit"
@@ -37,6 +37,10 @@ type Queries interface {
 
 	GetIngestionKeys(ctx context.Context) ([]model.IngestionKey, *model.ApiError)
 
+	GetUserPreferences(ctx context.Context, userId string) (*model.UserPreferences, *model.ApiError)
+
+	GetJwtSigningKeys(ctx context.Context) ([]model.JwtSigningKey, *model.ApiError)
+
 	PrecheckLogin(ctx context.Context, email, sourceUrl string) (*model.PrecheckResponse, model.BaseApiError)
 }
 
@@ -66,4 +70,10 @@ type Mutations interface {
 	SetApdexSettings(ctx context.Context, set *model.ApdexSettings) *model.ApiError
 
 	InsertIngestionKey(ctx context.Context, ingestionKey *model.IngestionKey) *model.ApiError
+
+	UpdateUserPreferences(ctx context.Context, userId string, patch *model.UserPreferencesPatch) (*model.UserPreferences, *model.ApiError)
+
+	InsertJwtSigningKey(ctx context.Context, key *model.JwtSigningKey) *model.ApiError
+	RotateJwtSigningKey(ctx context.Context, key *model.JwtSigningKey, retiredKeysExpireAt int64) *model.ApiError
+	DeleteJwtSigningKey(ctx context.Context, id string) *model.ApiError
 }
@@ -86,6 +86,18 @@ func InitDB(dataSourceName string) (*ModelDaoSqlite, error) {
 			ingestion_url TEXT NOT NULL,
 			data_region TEXT NOT NULL
 		);
+		CREATE TABLE IF NOT EXISTS user_preferences (
+			user_id TEXT PRIMARY KEY,
+			preferences TEXT NOT NULL,
+			updated_at INTEGER NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id)
+		);
+		CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+			id TEXT PRIMARY KEY,
+			secret TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			expires_at INTEGER
+		);
 	`
 
 	_, err = db.Exec(table_schema)
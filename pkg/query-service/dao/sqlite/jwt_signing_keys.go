@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func (mds *ModelDaoSqlite) GetJwtSigningKeys(ctx context.Context) ([]model.JwtSigningKey, *model.ApiError) {
+	keys := []model.JwtSigningKey{}
+	err := mds.db.SelectContext(ctx, &keys, `SELECT * FROM jwt_signing_keys ORDER BY created_at`)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return keys, nil
+}
+
+func (mds *ModelDaoSqlite) InsertJwtSigningKey(ctx context.Context, key *model.JwtSigningKey) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `
+	INSERT INTO jwt_signing_keys (
+		id,
+		secret,
+		created_at,
+		expires_at
+	) VALUES (?, ?, ?, ?)`, key.Id, key.Secret, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+// RotateJwtSigningKey makes key the only active signing key. Previously
+// active keys expire at retiredKeysExpireAt and keys that have already
+// expired are removed.
+func (mds *ModelDaoSqlite) RotateJwtSigningKey(
+	ctx context.Context, key *model.JwtSigningKey, retiredKeysExpireAt int64,
+) *model.ApiError {
+	tx, err := mds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM jwt_signing_keys WHERE expires_at IS NOT NULL AND expires_at <= ?`,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE jwt_signing_keys SET expires_at = ? WHERE expires_at IS NULL`,
+		retiredKeysExpireAt,
+	)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO jwt_signing_keys (
+		id,
+		secret,
+		created_at,
+		expires_at
+	) VALUES (?, ?, ?, NULL)`, key.Id, key.Secret, key.CreatedAt)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) DeleteJwtSigningKey(ctx context.Context, id string) *model.ApiError {
+	result, err := mds.db.ExecContext(ctx, `DELETE FROM jwt_signing_keys WHERE id = ?`, id)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	if affected == 0 {
+		return &model.ApiError{
+			Typ: model.ErrorNotFound,
+			Err: fmt.Errorf("no jwt signing key found with id %s", id),
+		}
+	}
+	return nil
+}
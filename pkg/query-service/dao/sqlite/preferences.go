@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func (mds *ModelDaoSqlite) GetUserPreferences(ctx context.Context, userId string) (*model.UserPreferences, *model.ApiError) {
+	preferences := []model.UserPreferences{}
+	err := mds.db.SelectContext(ctx, &preferences, `SELECT preferences FROM user_preferences WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	if len(preferences) == 0 {
+		// user has not saved any preference yet
+		return &model.UserPreferences{}, nil
+	}
+	return &preferences[0], nil
+}
+
+// UpdateUserPreferences applies the patch to the stored preferences. The
+// read and the write are done in a transaction so that concurrent patches
+// of a user don't overwrite each other.
+func (mds *ModelDaoSqlite) UpdateUserPreferences(ctx context.Context, userId string, patch *model.UserPreferencesPatch) (*model.UserPreferences, *model.ApiError) {
+	if err := patch.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	tx, err := mds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	defer tx.Rollback()
+
+	// an update first takes the write lock of the database, reads taking the
+	// shared lock would let two patches read the same preferences
+	_, err = tx.ExecContext(ctx, `UPDATE user_preferences SET updated_at = updated_at WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	stored := []model.UserPreferences{}
+	err = tx.SelectContext(ctx, &stored, `SELECT preferences FROM user_preferences WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	preferences := &model.UserPreferences{}
+	if len(stored) > 0 {
+		preferences = &stored[0]
+	}
+
+	patch.Apply(preferences)
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT OR REPLACE INTO user_preferences (
+		user_id,
+		preferences,
+		updated_at
+	) VALUES (
+		?,
+		?,
+		?
+	)`, userId, preferences, time.Now().Unix())
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return preferences, nil
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func newTestModelDao(t *testing.T) *ModelDaoSqlite {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+
+	mds, err := InitDB(testDBFile.Name())
+	require.Nil(t, err)
+
+	// the preferences belong to users
+	ctx := context.Background()
+	org, apiErr := mds.CreateOrg(ctx, &model.Organization{Name: "org"})
+	require.Nil(t, apiErr)
+	group, apiErr := mds.GetGroupByName(ctx, constants.ViewerGroup)
+	require.Nil(t, apiErr)
+	for _, id := range []string{"user1", "user2"} {
+		_, apiErr := mds.CreateUser(ctx, &model.User{
+			Id: id, Name: id, Email: id + "@signoz.io", GroupId: group.Id, OrgId: org.Id,
+		}, false)
+		require.Nil(t, apiErr)
+	}
+	return mds
+}
+
+func TestUserPreferences(t *testing.T) {
+	require := require.New(t)
+	mds := newTestModelDao(t)
+	ctx := context.Background()
+
+	preferences, apiErr := mds.GetUserPreferences(ctx, "user1")
+	require.Nil(apiErr)
+	require.Equal(&model.UserPreferences{}, preferences, "users without preferences get the defaults")
+
+	theme := "dark"
+	pins := []string{"d1", "d2"}
+	_, apiErr = mds.UpdateUserPreferences(ctx, "user1", &model.UserPreferencesPatch{
+		Theme: &theme, PinnedDashboards: &pins,
+		ExplorerColumns: map[string][]string{"logs": {"body"}},
+	})
+	require.Nil(apiErr)
+
+	timeRange := "15m"
+	updated, apiErr := mds.UpdateUserPreferences(ctx, "user1", &model.UserPreferencesPatch{
+		DefaultTimeRange: &timeRange,
+	})
+	require.Nil(apiErr)
+	expected := &model.UserPreferences{
+		DefaultTimeRange: "15m",
+		Theme:            "dark",
+		PinnedDashboards: []string{"d1", "d2"},
+		ExplorerColumns:  map[string][]string{"logs": {"body"}},
+	}
+	require.Equal(expected, updated)
+
+	stored, apiErr := mds.GetUserPreferences(ctx, "user1")
+	require.Nil(apiErr)
+	require.Equal(expected, stored)
+
+	other, apiErr := mds.GetUserPreferences(ctx, "user2")
+	require.Nil(apiErr)
+	require.Equal(&model.UserPreferences{}, other, "preferences are per user")
+
+	duplicated := []string{"d1", "d1"}
+	_, apiErr = mds.UpdateUserPreferences(ctx, "user1", &model.UserPreferencesPatch{PinnedDashboards: &duplicated})
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Typ)
+	stored, apiErr = mds.GetUserPreferences(ctx, "user1")
+	require.Nil(apiErr)
+	require.Equal(expected, stored, "invalid patches are not applied")
+}
+
+func TestConcurrentUserPreferencesUpdates(t *testing.T) {
+	require := require.New(t)
+	mds := newTestModelDao(t)
+	ctx := context.Background()
+
+	// another update of the preferences is in progress
+	tx, err := mds.db.BeginTxx(ctx, nil)
+	require.Nil(err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO user_preferences (user_id, preferences, updated_at) VALUES (?, ?, ?)`,
+		"user1", model.UserPreferences{Theme: "dark"}, time.Now().Unix())
+	require.Nil(err)
+
+	updated := make(chan *model.ApiError)
+	go func() {
+		_, apiErr := mds.UpdateUserPreferences(ctx, "user1", &model.UserPreferencesPatch{
+			ExplorerColumns: map[string][]string{"logs": {"body"}},
+		})
+		updated <- apiErr
+	}()
+	// the patch waits for the other update instead of applying to the
+	// preferences read before it
+	time.Sleep(100 * time.Millisecond)
+	require.Nil(tx.Commit())
+	require.Nil(<-updated)
+
+	stored, apiErr := mds.GetUserPreferences(ctx, "user1")
+	require.Nil(apiErr)
+	require.Equal(&model.UserPreferences{
+		Theme: "dark", ExplorerColumns: map[string][]string{"logs": {"body"}},
+	}, stored)
+}
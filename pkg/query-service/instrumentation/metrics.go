@@ -0,0 +1,122 @@
+// Package instrumentation exposes metrics about query service itself in the
+// prometheus format, for operators to monitor it with their own scrapers.
+package instrumentation
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "signoz_query_service"
+
+// queries and rule evaluations can take up to the max context timeout
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Count of http requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of http requests by route, method and status code.",
+		Buckets:   durationBuckets,
+	}, []string{"route", "method", "code"})
+
+	clickhouseQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "clickhouse_query_duration_seconds",
+		Help:      "Duration of clickhouse queries by operation and whether they failed.",
+		Buckets:   durationBuckets,
+	}, []string{"operation", "status"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Count of query cache lookups by result, like hit or key miss.",
+	}, []string{"result"})
+
+	ruleEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_evaluations_total",
+		Help:      "Count of alert rule evaluations by rule type and whether they failed.",
+	}, []string{"rule_type", "status"})
+
+	ruleEvaluationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rule_evaluation_duration_seconds",
+		Help:      "Duration of alert rule evaluations by rule type.",
+		Buckets:   durationBuckets,
+	}, []string{"rule_type"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		clickhouseQueryDuration,
+		cacheLookups,
+		ruleEvaluations,
+		ruleEvaluationDuration,
+	)
+}
+
+// Handler serves the metrics of query service
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the rate, errors and duration of requests per route.
+// Routes are identified by their path template to keep cardinality bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if path, err := current.GetPathTemplate(); err == nil {
+				route = path
+			}
+		}
+		labels := prometheus.Labels{"route": route}
+
+		handler := promhttp.InstrumentHandlerDuration(
+			httpRequestDuration.MustCurryWith(labels),
+			promhttp.InstrumentHandlerCounter(httpRequests.MustCurryWith(labels), next),
+		)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// ObserveClickhouseQuery records a clickhouse query which started at start
+func ObserveClickhouseQuery(operation string, start time.Time, err error) {
+	clickhouseQueryDuration.WithLabelValues(operation, status(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCacheLookup records the result of a query cache lookup
+func ObserveCacheLookup(result string) {
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveRuleEvaluation records an alert rule evaluation
+func ObserveRuleEvaluation(ruleType string, duration time.Duration, err error) {
+	ruleEvaluations.WithLabelValues(ruleType, status(err)).Inc()
+	ruleEvaluationDuration.WithLabelValues(ruleType).Observe(duration.Seconds())
+}
@@ -0,0 +1,49 @@
+package instrumentation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/api/v1/dashboards/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	r.Handle("/metrics", Handler()).Methods(http.MethodGet)
+
+	for _, id := range []string{"a", "b"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/dashboards/"+id, nil))
+	}
+	ObserveClickhouseQuery("select", time.Now(), errors.New("timeout"))
+	ObserveCacheLookup("partial hit")
+	ObserveRuleEvaluation("THRESHOLD_RULE", time.Second, nil)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.Nil(err)
+
+	for _, expected := range []string{
+		`signoz_query_service_http_requests_total{code="404",method="get",route="/api/v1/dashboards/{uuid}"} 2`,
+		`signoz_query_service_http_request_duration_seconds_count{code="404",method="get",route="/api/v1/dashboards/{uuid}"} 2`,
+		`signoz_query_service_clickhouse_query_duration_seconds_count{operation="select",status="error"} 1`,
+		`signoz_query_service_cache_lookups_total{result="partial hit"} 1`,
+		`signoz_query_service_rule_evaluations_total{rule_type="THRESHOLD_RULE",status="success"} 1`,
+		`go_goroutines`,
+	} {
+		require.True(strings.Contains(string(body), expected), expected)
+	}
+}
@@ -22,6 +22,7 @@ type Manager interface {
 	EditRoute(receiver *Receiver) *model.ApiError
 	DeleteRoute(name string) *model.ApiError
 	TestReceiver(receiver *Receiver) *model.ApiError
+	AddSilence(silence *Silence) (string, *model.ApiError)
 }
 
 func New(url string) (Manager, error) {
@@ -62,6 +63,11 @@ func prepareTestApiURL() string {
 	return fmt.Sprintf("%s%s", basePath, "v1/testReceiver")
 }
 
+func prepareSilencesApiURL() string {
+	basePath := constants.GetAlertManagerApiPrefix()
+	return fmt.Sprintf("%s%s", basePath, "v2/silences")
+}
+
 func (m *manager) URL() *neturl.URL {
 	return m.parsedURL
 }
@@ -180,3 +186,28 @@ func (m *manager) TestReceiver(receiver *Receiver) *model.ApiError {
 
 	return nil
 }
+
+// AddSilence creates a silence in alertmanager and returns its id
+func (m *manager) AddSilence(silence *Silence) (string, *model.ApiError) {
+	silenceBytes, _ := json.Marshal(silence)
+
+	amURL := prepareSilencesApiURL()
+	response, err := http.Post(amURL, contentType, bytes.NewBuffer(silenceBytes))
+	if err != nil {
+		zap.L().Error("Error in getting response of API call to alertmanager", zap.String("url", amURL), zap.Error(err))
+		return "", &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		err := fmt.Errorf("error in getting 2xx response in API call to alertmanager(POST %s): %s", amURL, response.Status)
+		zap.L().Error("could not create silence", zap.Error(err))
+		return "", &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	created := SilenceResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&created); err != nil {
+		return "", &model.ApiError{Typ: model.ErrorInternal, Err: fmt.Errorf("could not decode silence response: %w", err)}
+	}
+	return created.SilenceID, nil
+}
@@ -29,6 +29,28 @@ type ReceiverResponse struct {
 	Data   Receiver `json:"data"`
 }
 
+// Matcher selects the alerts a silence applies to by a label
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence mutes the notifications of the alerts matching all of its
+// matchers between StartsAt and EndsAt
+type Silence struct {
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+type SilenceResponse struct {
+	SilenceID string `json:"silenceID"`
+}
+
 // Alert is a generic representation of an alert in the Prometheus eco-system.
 type Alert struct {
 	// Label value pairs for purpose of aggregation, matching, and disposition
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/util/stats"
@@ -26,6 +27,22 @@ type Reader interface {
 	GetTopLevelOperations(ctx context.Context, skipConfig *model.SkipConfig) (*map[string][]string, *model.ApiError)
 	GetServices(ctx context.Context, query *model.GetServicesParams, skipConfig *model.SkipConfig) (*[]model.ServiceItem, *model.ApiError)
 	GetTopOperations(ctx context.Context, query *model.GetTopOperationsParams) (*[]model.TopOperationsItem, *model.ApiError)
+	GetDatabaseCalls(ctx context.Context, query *model.GetDependencyCallsParams) (*[]model.DatabaseCallsItem, *model.ApiError)
+	GetExternalCalls(ctx context.Context, query *model.GetDependencyCallsParams) (*[]model.ExternalCallsItem, *model.ApiError)
+	GetLatencyBreakdown(ctx context.Context, query *model.GetLatencyBreakdownParams) (*[]model.LatencyBreakdownItem, *model.ApiError)
+	GetServiceSummary(ctx context.Context, serviceName string, start, end time.Time, skipConfig *model.SkipConfig) (*model.ServiceOverviewItem, *model.ApiError)
+	GetK8sPodEvents(ctx context.Context, params *model.K8sPodTimelineParams) ([]model.K8sEvent, *model.ApiError)
+	GetSessionTimeline(ctx context.Context, params *model.SessionTimelineParams) (*model.SessionTimeline, *model.ApiError)
+	IndexSessionIds(ctx context.Context) *model.ApiError
+	StartDataDeletion(ctx context.Context, params *model.DataDeletionParams) *model.ApiError
+	GetDataDeletionProgress(ctx context.Context, id string) ([]model.DataDeletionMutation, *model.ApiError)
+	WriteRemoteWriteRequest(ctx context.Context, req *prompb.WriteRequest) *model.ApiError
+	WriteDemoData(ctx context.Context, data *model.DemoData) *model.ApiError
+	GetSchemaStatus(ctx context.Context, refresh bool) (*model.SchemaStatus, *model.ApiError)
+	CheckSchemaCompatibility(ctx context.Context, features []string) *model.ApiError
+	CreateScheduledQueryResultsTable(ctx context.Context) error
+	WriteScheduledQueryResults(ctx context.Context, results []model.ScheduledQueryResult) *model.ApiError
+	GetScheduledQueryResults(ctx context.Context, scheduledQueryId string, start, end int64) ([]model.ScheduledQueryResult, *model.ApiError)
 	GetUsage(ctx context.Context, query *model.GetUsageParams) (*[]model.UsageItem, error)
 	GetServicesList(ctx context.Context) (*[]string, error)
 	GetDependencyGraph(ctx context.Context, query *model.GetServicesParams) (*[]model.ServiceMapDependencyResponseItem, error)
@@ -44,6 +61,8 @@ type Reader interface {
 	GetTagValues(ctx context.Context, query *model.TagFilterParams) (*model.TagValues, *model.ApiError)
 	GetFilteredSpans(ctx context.Context, query *model.GetFilteredSpansParams) (*model.GetFilterSpansResponse, *model.ApiError)
 	GetFilteredSpansAggregates(ctx context.Context, query *model.GetFilteredSpanAggregatesParams) (*model.GetFilteredSpansAggregatesResponse, *model.ApiError)
+	GetExistingTraceIDs(ctx context.Context, traceIDs []string, start, end int64) (map[string]struct{}, *model.ApiError)
+	GetTraceSpanAttributes(ctx context.Context, traceID string) ([]map[string]interface{}, *model.ApiError)
 
 	ListErrors(ctx context.Context, params *model.ListErrorsParams) (*[]model.Error, *model.ApiError)
 	CountErrors(ctx context.Context, params *model.CountErrorsParams) (uint64, *model.ApiError)
@@ -53,9 +72,14 @@ type Reader interface {
 
 	// Search Interfaces
 	SearchTraces(ctx context.Context, traceID string, spanId string, levelUp int, levelDown int, spanLimit int, smartTraceAlgorithm func(payload []model.SearchSpanResponseItem, targetSpanId string, levelUp int, levelDown int, spanLimit int) ([]model.SearchSpansResult, error)) (*[]model.SearchSpansResult, error)
+	SearchTraceSpans(ctx context.Context, params *model.SearchTraceSpansParams) (*model.SearchTraceSpansResult, *model.ApiError)
 
 	// Setter Interfaces
 	SetTTL(ctx context.Context, ttlParams *model.TTLParams) (*model.SetTTLResponseItem, *model.ApiError)
+	RefreshAttributeMetadata(params *model.RefreshAttributesParams) (*model.AttributeRefreshStatus, *model.ApiError)
+	GetAttributeMetadataRefreshStatus() *model.AttributeRefreshStatus
+
+	GetQueryAuditReport(ctx context.Context, params *model.QueryAuditReportParams) (*model.QueryAuditReport, *model.ApiError)
 
 	FetchTemporality(ctx context.Context, metricNames []string) (map[string]map[v3.Temporality]bool, error)
 	GetMetricAutocompleteMetricNames(ctx context.Context, matchText string, limit int) (*[]string, *model.ApiError)
@@ -79,6 +103,7 @@ type Reader interface {
 	GetTotalSamples(ctx context.Context) (uint64, error)
 	GetSpansInLastHeartBeatInterval(ctx context.Context, interval time.Duration) (uint64, error)
 	GetTimeSeriesInfo(ctx context.Context) (map[string]interface{}, error)
+	GetMetricSeriesCounts(ctx context.Context, start, end int64, labelKeys []string) ([]model.MetricSeriesCount, *model.ApiError)
 	GetSamplesInfoInLastHeartBeatInterval(ctx context.Context, interval time.Duration) (uint64, error)
 	GetLogsInfoInLastHeartBeatInterval(ctx context.Context, interval time.Duration) (uint64, error)
 	GetTagsInfoInLastHeartBeatInterval(ctx context.Context, interval time.Duration) (*model.TagsInfo, error)
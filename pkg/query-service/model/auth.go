@@ -60,6 +60,12 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"newPassword"`
 }
 
+type RotateSigningKeyRequest struct {
+	// GracePeriod is how long jwts signed with the previous keys stay valid,
+	// for example 24h. It defaults to the refresh token expiry.
+	GracePeriod string `json:"gracePeriod"`
+}
+
 type ResetPasswordEntry struct {
 	UserId string `json:"userId" db:"user_id"`
 	Token  string `json:"token" db:"token"`
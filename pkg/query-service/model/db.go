@@ -52,6 +52,15 @@ type IngestionKey struct {
 	DataRegion   string    `json:"dataRegion" db:"data_region"`
 }
 
+// JwtSigningKey is a secret used to sign and verify jwts, keys without an
+// expiry are active and the others are only accepted until they expire
+type JwtSigningKey struct {
+	Id        string `json:"id" db:"id"`
+	Secret    string `json:"-" db:"secret"`
+	CreatedAt int64  `json:"createdAt" db:"created_at"`
+	ExpiresAt *int64 `json:"expiresAt,omitempty" db:"expires_at"`
+}
+
 type UserFlag map[string]string
 
 func (uf UserFlag) Value() (driver.Value, error) {
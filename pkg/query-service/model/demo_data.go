@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// DemoData is generated sample telemetry to be written to clickhouse
+type DemoData struct {
+	Spans   []DemoSpan
+	Logs    []DemoLog
+	Metrics *prompb.WriteRequest
+}
+
+type DemoSpan struct {
+	Timestamp       time.Time
+	TraceId         string
+	SpanId          string
+	ParentSpanId    string
+	ServiceName     string
+	Name            string
+	Kind            int8
+	DurationNano    uint64
+	StatusCode      int16
+	HttpMethod      string
+	HttpRoute       string
+	HttpCode        string
+	HasError        bool
+	StringTagMap    map[string]string
+	NumberTagMap    map[string]float64
+	ResourceTagsMap map[string]string
+	Exception       *DemoException
+}
+
+type DemoException struct {
+	Type       string
+	Message    string
+	Stacktrace string
+}
+
+type DemoLog struct {
+	Timestamp      time.Time
+	Id             string
+	TraceId        string
+	SpanId         string
+	SeverityText   string
+	SeverityNumber uint8
+	Body           string
+	Resources      map[string]string
+	Attributes     map[string]string
+}
+
+// DemoDataSummary is the count of generated telemetry written by a request
+type DemoDataSummary struct {
+	Traces         int   `json:"traces"`
+	Spans          int   `json:"spans"`
+	Logs           int   `json:"logs"`
+	MetricSeries   int   `json:"metricSeries"`
+	MetricSamples  int   `json:"metricSamples"`
+	StartTimestamp int64 `json:"startTimestamp"`
+	EndTimestamp   int64 `json:"endTimestamp"`
+}
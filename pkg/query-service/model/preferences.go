@@ -0,0 +1,133 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+type PreferenceKey string
+
+const (
+	PreferenceKeyDefaultTimeRange PreferenceKey = "defaultTimeRange"
+	PreferenceKeyTheme            PreferenceKey = "theme"
+	PreferenceKeyPinnedDashboards PreferenceKey = "pinnedDashboards"
+	PreferenceKeyExplorerColumns  PreferenceKey = "explorerColumns"
+)
+
+var validThemes = []string{"light", "dark", "system"}
+
+// explorer source pages for which column selection can be persisted
+var validExplorerSourcePages = []string{"logs", "traces", "metrics"}
+
+const maxPinnedDashboards = 50
+
+// UserPreferences holds per user settings that follow the user across devices
+type UserPreferences struct {
+	DefaultTimeRange string              `json:"defaultTimeRange,omitempty"`
+	Theme            string              `json:"theme,omitempty"`
+	PinnedDashboards []string            `json:"pinnedDashboards,omitempty"`
+	ExplorerColumns  map[string][]string `json:"explorerColumns,omitempty"`
+}
+
+// For serializing to db
+func (up UserPreferences) Value() (driver.Value, error) {
+	return json.Marshal(up)
+}
+
+// For serializing from db
+func (up *UserPreferences) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("type assertion to string failed while scanning user preferences")
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, up)
+}
+
+// UserPreferencesPatch captures a partial update of user preferences. A nil
+// field leaves the stored value untouched while an empty value clears it.
+type UserPreferencesPatch struct {
+	DefaultTimeRange *string             `json:"defaultTimeRange"`
+	Theme            *string             `json:"theme"`
+	PinnedDashboards *[]string           `json:"pinnedDashboards"`
+	ExplorerColumns  map[string][]string `json:"explorerColumns"`
+}
+
+func (p *UserPreferencesPatch) Validate() error {
+	if p.DefaultTimeRange != nil && *p.DefaultTimeRange != "" {
+		d, err := time.ParseDuration(*p.DefaultTimeRange)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", PreferenceKeyDefaultTimeRange, *p.DefaultTimeRange, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s must be a positive duration", PreferenceKeyDefaultTimeRange)
+		}
+	}
+
+	if p.Theme != nil && *p.Theme != "" && !slices.Contains(validThemes, *p.Theme) {
+		return fmt.Errorf("invalid %s %q, use one of %v", PreferenceKeyTheme, *p.Theme, validThemes)
+	}
+
+	if p.PinnedDashboards != nil {
+		if len(*p.PinnedDashboards) > maxPinnedDashboards {
+			return fmt.Errorf("at most %d dashboards can be pinned", maxPinnedDashboards)
+		}
+		seen := map[string]struct{}{}
+		for _, id := range *p.PinnedDashboards {
+			if id == "" {
+				return fmt.Errorf("%s can not contain empty dashboard ids", PreferenceKeyPinnedDashboards)
+			}
+			if _, ok := seen[id]; ok {
+				return fmt.Errorf("dashboard %s is pinned more than once", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+
+	for sourcePage := range p.ExplorerColumns {
+		if !slices.Contains(validExplorerSourcePages, sourcePage) {
+			return fmt.Errorf(
+				"invalid source page %q in %s, use one of %v",
+				sourcePage, PreferenceKeyExplorerColumns, validExplorerSourcePages,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Apply merges the patch into existing preferences
+func (p *UserPreferencesPatch) Apply(up *UserPreferences) {
+	if p.DefaultTimeRange != nil {
+		up.DefaultTimeRange = *p.DefaultTimeRange
+	}
+	if p.Theme != nil {
+		up.Theme = *p.Theme
+	}
+	if p.PinnedDashboards != nil {
+		up.PinnedDashboards = *p.PinnedDashboards
+	}
+	if len(p.ExplorerColumns) > 0 && up.ExplorerColumns == nil {
+		up.ExplorerColumns = map[string][]string{}
+	}
+	for sourcePage, columns := range p.ExplorerColumns {
+		if len(columns) == 0 {
+			delete(up.ExplorerColumns, sourcePage)
+			continue
+		}
+		up.ExplorerColumns[sourcePage] = columns
+	}
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserPreferencesPatchValidate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ids := func(ids ...string) *[]string { return &ids }
+	tooMany := []string{}
+	for i := 0; i <= maxPinnedDashboards; i++ {
+		tooMany = append(tooMany, string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+
+	tests := []struct {
+		name  string
+		patch UserPreferencesPatch
+		valid bool
+	}{
+		{name: "empty", patch: UserPreferencesPatch{}, valid: true},
+		{name: "time range", patch: UserPreferencesPatch{DefaultTimeRange: str("15m")}, valid: true},
+		{name: "time range cleared", patch: UserPreferencesPatch{DefaultTimeRange: str("")}, valid: true},
+		{name: "invalid time range", patch: UserPreferencesPatch{DefaultTimeRange: str("fortnight")}},
+		{name: "negative time range", patch: UserPreferencesPatch{DefaultTimeRange: str("-1h")}},
+		{name: "theme", patch: UserPreferencesPatch{Theme: str("dark")}, valid: true},
+		{name: "theme cleared", patch: UserPreferencesPatch{Theme: str("")}, valid: true},
+		{name: "invalid theme", patch: UserPreferencesPatch{Theme: str("solarized")}},
+		{name: "pins", patch: UserPreferencesPatch{PinnedDashboards: ids("d1", "d2")}, valid: true},
+		{name: "pins cleared", patch: UserPreferencesPatch{PinnedDashboards: ids()}, valid: true},
+		{name: "duplicate pins", patch: UserPreferencesPatch{PinnedDashboards: ids("d1", "d2", "d1")}},
+		{name: "empty pin", patch: UserPreferencesPatch{PinnedDashboards: ids("d1", "")}},
+		{name: "too many pins", patch: UserPreferencesPatch{PinnedDashboards: &tooMany}},
+		{name: "columns", patch: UserPreferencesPatch{ExplorerColumns: map[string][]string{"logs": {"body"}}}, valid: true},
+		{name: "invalid columns page", patch: UserPreferencesPatch{ExplorerColumns: map[string][]string{"alerts": {"name"}}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.patch.Validate()
+			if test.valid {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestUserPreferencesPatchApply(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ids := func(ids ...string) *[]string { return &ids }
+	stored := func() UserPreferences {
+		return UserPreferences{
+			DefaultTimeRange: "1h",
+			Theme:            "dark",
+			PinnedDashboards: []string{"d1"},
+			ExplorerColumns:  map[string][]string{"logs": {"body"}, "traces": {"name"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		stored   UserPreferences
+		patch    UserPreferencesPatch
+		expected UserPreferences
+	}{
+		{
+			name:     "nil fields are left untouched",
+			stored:   stored(),
+			patch:    UserPreferencesPatch{},
+			expected: stored(),
+		},
+		{
+			name:   "empty fields are cleared",
+			stored: stored(),
+			patch: UserPreferencesPatch{
+				DefaultTimeRange: str(""), Theme: str(""), PinnedDashboards: ids(),
+				ExplorerColumns: map[string][]string{"logs": {}},
+			},
+			expected: UserPreferences{ExplorerColumns: map[string][]string{"traces": {"name"}}},
+		},
+		{
+			name:   "set fields are replaced",
+			stored: stored(),
+			patch: UserPreferencesPatch{
+				Theme: str("light"), PinnedDashboards: ids("d2", "d1"),
+				ExplorerColumns: map[string][]string{"metrics": {"value"}},
+			},
+			expected: UserPreferences{
+				DefaultTimeRange: "1h",
+				Theme:            "light",
+				PinnedDashboards: []string{"d2", "d1"},
+				ExplorerColumns:  map[string][]string{"logs": {"body"}, "traces": {"name"}, "metrics": {"value"}},
+			},
+		},
+		{
+			name:     "columns of new preferences",
+			stored:   UserPreferences{},
+			patch:    UserPreferencesPatch{ExplorerColumns: map[string][]string{"logs": {"body"}}},
+			expected: UserPreferences{ExplorerColumns: map[string][]string{"logs": {"body"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preferences := test.stored
+			test.patch.Apply(&preferences)
+			require.Equal(t, test.expected, preferences)
+		})
+	}
+}
@@ -164,6 +164,33 @@ type GetTopOperationsParams struct {
 	Limit       int             `json:"limit"`
 }
 
+// GetDependencyCallsParams are used for aggregating the client spans of
+// calls to databases and external services
+type GetDependencyCallsParams struct {
+	StartTime   string `json:"start"`
+	EndTime     string `json:"end"`
+	ServiceName string `json:"service"`
+	Start       *time.Time
+	End         *time.Time
+	Tags        []TagQueryParam `json:"tags"`
+	Limit       int             `json:"limit"`
+}
+
+// GetLatencyBreakdownParams are used for breaking down the duration of the
+// spans of an operation by the values of a span or resource attribute
+type GetLatencyBreakdownParams struct {
+	StartTime   string `json:"start"`
+	EndTime     string `json:"end"`
+	ServiceName string `json:"service"`
+	Operation   string `json:"operation"`
+	// Attribute is the key of the attribute, e.g. db.system or peer.service
+	Attribute string `json:"attribute"`
+	Start     *time.Time
+	End       *time.Time
+	Tags      []TagQueryParam `json:"tags"`
+	Limit     int             `json:"limit"`
+}
+
 type GetUsageParams struct {
 	StartTime   string
 	EndTime     string
@@ -361,6 +388,25 @@ func (tqn TagQueryNumber) GetTagMapColumn() string {
 	return NumberTagMapCol
 }
 
+// SearchTraceSpansParams selects a window of the span tree of a trace, the
+// descendants of ParentSpanID (or the root spans when empty) up to Depth
+// levels, paginated over the direct children with Limit and Offset
+type SearchTraceSpansParams struct {
+	TraceID      string
+	ParentSpanID string
+	Depth        int
+	Limit        int
+	Offset       int
+}
+
+// TraceExistenceParams are the trace ids to be checked for being stored,
+// optionally within a time range in ms which defaults to the last day
+type TraceExistenceParams struct {
+	TraceIDs []string `json:"traceIds"`
+	Start    int64    `json:"start"`
+	End      int64    `json:"end"`
+}
+
 type GetFilteredSpansParams struct {
 	TraceID            []string        `json:"traceID"`
 	ServiceName        []string        `json:"serviceName"`
@@ -487,6 +533,12 @@ type TTLParams struct {
 	DelDuration           int64  // Seconds after which data will be deleted.
 }
 
+// RefreshAttributesParams controls how much recent data is rescanned when
+// rebuilding the attribute key metadata
+type RefreshAttributesParams struct {
+	LookbackHours int `json:"lookbackHours"`
+}
+
 type GetTTLParams struct {
 	Type string
 }
@@ -560,3 +612,44 @@ type LogsAggregateParams struct {
 	Function       string `json:"function"`
 	StepSeconds    int    `json:"step"`
 }
+
+const (
+	QueryAuditGroupByUser      = "user"
+	QueryAuditGroupByDashboard = "dashboard"
+	QueryAuditGroupByAlert     = "alert"
+)
+
+type QueryAuditReportParams struct {
+	Start   int64  `json:"start"`
+	End     int64  `json:"end"`
+	GroupBy string `json:"groupBy"`
+	Limit   int    `json:"limit"`
+}
+
+type SessionTimelineParams struct {
+	SessionId string
+	Start     time.Time
+	End       time.Time
+	Limit     int
+}
+
+// DataDeletionParams selects the telemetry to delete by the value of a span
+// or log attribute, or resource attribute, in a time range
+type DataDeletionParams struct {
+	// Id tags the mutations of the deletion so that their progress can be
+	// tracked
+	Id             string
+	Signals        []string
+	AttributeKey   string
+	AttributeValue string
+	Start          time.Time
+	End            time.Time
+}
+
+type K8sPodTimelineParams struct {
+	Start     time.Time
+	End       time.Time
+	Namespace string
+	Pod       string
+	Limit     int
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -146,6 +147,98 @@ type TTLStatusItem struct {
 	ColdStorageTtl int       `json:"cold_storage_ttl" db:"cold_storage_ttl"`
 }
 
+type QueryAuditReport struct {
+	SampleRate float64                `json:"sampleRate"`
+	GroupBy    string                 `json:"groupBy"`
+	Items      []QueryAuditReportItem `json:"items"`
+}
+
+// QueryAuditReportItem holds the totals of the sampled queries along with
+// estimates of the actual totals extrapolated using the sample rate
+type QueryAuditReportItem struct {
+	Key                 string  `json:"key"`
+	SampledQueries      uint64  `json:"sampledQueries"`
+	SampledReadRows     uint64  `json:"sampledReadRows"`
+	SampledReadBytes    uint64  `json:"sampledReadBytes"`
+	SampledDurationMs   uint64  `json:"sampledDurationMs"`
+	EstimatedQueries    float64 `json:"estimatedQueries"`
+	EstimatedReadRows   float64 `json:"estimatedReadRows"`
+	EstimatedReadBytes  float64 `json:"estimatedReadBytes"`
+	EstimatedDurationMs float64 `json:"estimatedDurationMs"`
+}
+
+func NewQueryAuditReportItem(
+	key string, queries, readRows, readBytes, durationMs uint64, sampleRate float64,
+) QueryAuditReportItem {
+	item := QueryAuditReportItem{
+		Key:               key,
+		SampledQueries:    queries,
+		SampledReadRows:   readRows,
+		SampledReadBytes:  readBytes,
+		SampledDurationMs: durationMs,
+	}
+	if sampleRate > 0 {
+		item.EstimatedQueries = float64(queries) / sampleRate
+		item.EstimatedReadRows = float64(readRows) / sampleRate
+		item.EstimatedReadBytes = float64(readBytes) / sampleRate
+		item.EstimatedDurationMs = float64(durationMs) / sampleRate
+	}
+	return item
+}
+
+type AttributeRefreshStatus struct {
+	Status         string     `json:"status"`
+	LookbackHours  int        `json:"lookbackHours"`
+	StartedAt      time.Time  `json:"startedAt"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+	TotalSteps     int        `json:"totalSteps"`
+	CompletedSteps int        `json:"completedSteps"`
+	CurrentStep    string     `json:"currentStep,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+const (
+	SchemaFeatureTraces    = "traces"
+	SchemaFeatureLogs      = "logs"
+	SchemaFeatureMetrics   = "metrics"
+	SchemaFeatureMetricsV4 = "metrics_v4"
+)
+
+// SchemaMigrationVersion is the latest migration of a database applied by
+// the schema migrator
+type SchemaMigrationVersion struct {
+	Database string `json:"database"`
+	Found    bool   `json:"found"`
+	Version  int64  `json:"version,omitempty"`
+	Dirty    bool   `json:"dirty,omitempty"`
+}
+
+// SchemaRequirement is a table and its columns needed by queries of a
+// feature which are missing in clickhouse
+type SchemaRequirement struct {
+	Feature        string   `json:"feature"`
+	Database       string   `json:"database"`
+	Table          string   `json:"table"`
+	MissingTable   bool     `json:"missingTable,omitempty"`
+	MissingColumns []string `json:"missingColumns,omitempty"`
+}
+
+func (req *SchemaRequirement) String() string {
+	if req.MissingTable {
+		return fmt.Sprintf("table %s.%s is missing", req.Database, req.Table)
+	}
+	return fmt.Sprintf(
+		"table %s.%s is missing columns %s", req.Database, req.Table, strings.Join(req.MissingColumns, ", "),
+	)
+}
+
+type SchemaStatus struct {
+	CheckedAt  time.Time                `json:"checkedAt"`
+	Compatible bool                     `json:"compatible"`
+	Migrations []SchemaMigrationVersion `json:"migrations"`
+	Missing    []SchemaRequirement      `json:"missing"`
+}
+
 type ChannelItem struct {
 	Id        int       `json:"id" db:"id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
@@ -204,6 +297,83 @@ type SearchSpansResult struct {
 	Events  [][]interface{} `json:"events"`
 }
 
+// Optional fields of the spans in trace search results
+const (
+	SpanFieldAttributes = "attributes"
+	SpanFieldEvents     = "events"
+	SpanFieldReferences = "references"
+)
+
+// SpanFieldSelection limits the fields of the spans in trace search results
+// to those a view renders
+type SpanFieldSelection struct {
+	// Fields are the optional fields to include
+	Fields map[string]bool
+	// Attributes are the keys of the span and resource attributes to include
+	// when attributes are included, all of them when nil
+	Attributes map[string]bool
+}
+
+// Project empties the fields of the spans that are not selected. The columns
+// are kept so that the spans can still be read by the position of the fields.
+func (r *SearchSpansResult) Project(selection *SpanFieldSelection) {
+	if selection == nil {
+		return
+	}
+	column := func(name string) int {
+		for i, c := range r.Columns {
+			if c == name {
+				return i
+			}
+		}
+		return -1
+	}
+	keysIdx, valuesIdx := column("TagsKeys"), column("TagsValues")
+	eventsIdx, referencesIdx := column("Events"), column("References")
+
+	for _, span := range r.Events {
+		if eventsIdx >= 0 && eventsIdx < len(span) && !selection.Fields[SpanFieldEvents] {
+			span[eventsIdx] = []string{}
+		}
+		if referencesIdx >= 0 && referencesIdx < len(span) && !selection.Fields[SpanFieldReferences] {
+			span[referencesIdx] = []string{}
+		}
+		if keysIdx < 0 || valuesIdx < 0 || keysIdx >= len(span) || valuesIdx >= len(span) {
+			continue
+		}
+		if !selection.Fields[SpanFieldAttributes] {
+			span[keysIdx], span[valuesIdx] = []string{}, []string{}
+			continue
+		}
+		keys, keysOk := span[keysIdx].([]string)
+		values, valuesOk := span[valuesIdx].([]string)
+		if selection.Attributes == nil || !keysOk || !valuesOk || len(keys) != len(values) {
+			continue
+		}
+		selectedKeys, selectedValues := []string{}, []string{}
+		for i, key := range keys {
+			if selection.Attributes[key] {
+				selectedKeys = append(selectedKeys, key)
+				selectedValues = append(selectedValues, values[i])
+			}
+		}
+		span[keysIdx], span[valuesIdx] = selectedKeys, selectedValues
+	}
+}
+
+// SearchTraceSpansResult is a window of the span tree of a trace
+type SearchTraceSpansResult struct {
+	SearchSpansResult
+	// ChildCount holds the number of direct children of each returned span,
+	// children left out of the window can be fetched with a follow up request
+	ChildCount map[string]int `json:"childCount"`
+	TotalSpans int            `json:"totalSpans"`
+	// HasMore is set when there are more children of the parent span
+	// to be fetched starting at NextOffset
+	HasMore    bool `json:"hasMore"`
+	NextOffset int  `json:"nextOffset"`
+}
+
 type GetFilterSpansResponseItem struct {
 	Timestamp          time.Time `ch:"timestamp" json:"timestamp"`
 	SpanID             string    `ch:"spanID" json:"spanID"`
@@ -306,6 +476,134 @@ type TopOperationsItem struct {
 	Name         string  `json:"name" ch:"name"`
 }
 
+type DatabaseCallsItem struct {
+	DBSystem      string  `json:"dbSystem" ch:"dbSystem"`
+	Statement     string  `json:"statement" ch:"statement"`
+	Percentile50  float64 `json:"p50" ch:"p50"`
+	Percentile95  float64 `json:"p95" ch:"p95"`
+	Percentile99  float64 `json:"p99" ch:"p99"`
+	NumCalls      uint64  `json:"numCalls" ch:"numCalls"`
+	ErrorCount    uint64  `json:"errorCount" ch:"errorCount"`
+	TotalDuration float64 `json:"totalDuration" ch:"totalDuration"`
+	CallRate      float64 `json:"callRate"`
+	ErrorRate     float64 `json:"errorRate"`
+}
+
+// LatencyBreakdownItem is the duration of the spans of an operation having a
+// value of the attribute broken down by, spans without it have an empty value
+type LatencyBreakdownItem struct {
+	Value         string  `json:"value" ch:"value"`
+	Percentile50  float64 `json:"p50" ch:"p50"`
+	Percentile90  float64 `json:"p90" ch:"p90"`
+	Percentile95  float64 `json:"p95" ch:"p95"`
+	Percentile99  float64 `json:"p99" ch:"p99"`
+	NumCalls      uint64  `json:"numCalls" ch:"numCalls"`
+	ErrorCount    uint64  `json:"errorCount" ch:"errorCount"`
+	TotalDuration float64 `json:"totalDuration" ch:"totalDuration"`
+	CallRate      float64 `json:"callRate"`
+	ErrorRate     float64 `json:"errorRate"`
+}
+
+type ExternalCallsItem struct {
+	Host          string  `json:"host" ch:"host"`
+	Percentile50  float64 `json:"p50" ch:"p50"`
+	Percentile95  float64 `json:"p95" ch:"p95"`
+	Percentile99  float64 `json:"p99" ch:"p99"`
+	NumCalls      uint64  `json:"numCalls" ch:"numCalls"`
+	ErrorCount    uint64  `json:"errorCount" ch:"errorCount"`
+	TotalDuration float64 `json:"totalDuration" ch:"totalDuration"`
+	CallRate      float64 `json:"callRate"`
+	ErrorRate     float64 `json:"errorRate"`
+}
+
+// K8sEvent is a kubernetes event collected by the k8s events receiver
+type K8sEvent struct {
+	Timestamp  uint64 `json:"timestamp" ch:"timestamp"`
+	Namespace  string `json:"namespace" ch:"namespace"`
+	ObjectKind string `json:"objectKind" ch:"object_kind"`
+	ObjectName string `json:"objectName" ch:"object_name"`
+	FieldPath  string `json:"fieldPath" ch:"field_path"`
+	Reason     string `json:"reason" ch:"reason"`
+	Count      string `json:"count" ch:"count"`
+	Severity   string `json:"severity" ch:"severity"`
+	Message    string `json:"message" ch:"message"`
+}
+
+type SessionLog struct {
+	Timestamp    uint64 `ch:"timestamp"`
+	Id           string `ch:"id"`
+	TraceId      string `ch:"trace_id"`
+	SpanId       string `ch:"span_id"`
+	SeverityText string `ch:"severity_text"`
+	Body         string `ch:"body"`
+	ServiceName  string `ch:"service_name"`
+}
+
+type SessionSpan struct {
+	Timestamp    time.Time `ch:"timestamp"`
+	TraceId      string    `ch:"traceID"`
+	SpanId       string    `ch:"spanID"`
+	ParentSpanId string    `ch:"parentSpanID"`
+	ServiceName  string    `ch:"serviceName"`
+	Name         string    `ch:"name"`
+	DurationNano uint64    `ch:"durationNano"`
+	HasError     bool      `ch:"hasError"`
+}
+
+// SessionTimelineItem is a log or span of a session, fields not applicable
+// to the signal of the item are left empty
+type SessionTimelineItem struct {
+	Timestamp    int64  `json:"timestamp"`
+	Signal       string `json:"signal"`
+	ServiceName  string `json:"serviceName"`
+	TraceId      string `json:"traceId,omitempty"`
+	SpanId       string `json:"spanId,omitempty"`
+	ParentSpanId string `json:"parentSpanId,omitempty"`
+	Name         string `json:"name,omitempty"`
+	DurationNano uint64 `json:"durationNano,omitempty"`
+	HasError     bool   `json:"hasError,omitempty"`
+	LogId        string `json:"logId,omitempty"`
+	SeverityText string `json:"severityText,omitempty"`
+	Body         string `json:"body,omitempty"`
+}
+
+// DataDeletionMutation is the progress of the mutations of a data deletion
+// on a table, across all its replicas
+type DataDeletionMutation struct {
+	Database   string `json:"database" ch:"database"`
+	Table      string `json:"table" ch:"table"`
+	Mutations  uint64 `json:"mutations" ch:"mutations"`
+	Done       uint64 `json:"done" ch:"done"`
+	PartsToDo  int64  `json:"partsToDo" ch:"parts_to_do"`
+	FailReason string `json:"failReason,omitempty" ch:"fail_reason"`
+}
+
+// SessionTimeline is the telemetry of a frontend session ordered by time
+type SessionTimeline struct {
+	SessionId string                `json:"sessionId"`
+	Start     int64                 `json:"start,omitempty"`
+	End       int64                 `json:"end,omitempty"`
+	Services  []string              `json:"services"`
+	TraceIds  []string              `json:"traceIds"`
+	Logs      int                   `json:"logs"`
+	Spans     int                   `json:"spans"`
+	Truncated bool                  `json:"truncated"`
+	Items     []SessionTimelineItem `json:"items"`
+}
+
+// ScheduledQueryResult is a point of a series computed by a run of a
+// scheduled query, kept for the retention of the scheduled query rather
+// than that of the data it was computed from
+type ScheduledQueryResult struct {
+	ScheduledQueryId string  `json:"scheduledQueryId" ch:"scheduled_query_id"`
+	QueryName        string  `json:"queryName" ch:"query_name"`
+	Labels           string  `json:"labels" ch:"labels"`
+	UnixMilli        int64   `json:"unixMilli" ch:"unix_milli"`
+	RunAt            int64   `json:"runAt" ch:"run_at"`
+	Value            float64 `json:"value" ch:"value"`
+	RetentionDays    uint16  `json:"retentionDays" ch:"retention_days"`
+}
+
 type TagFilters struct {
 	StringTagKeys []string `json:"stringTagKeys" ch:"stringTagKeys"`
 	NumberTagKeys []string `json:"numberTagKeys" ch:"numberTagKeys"`
@@ -630,6 +928,14 @@ type DashboardsInfo struct {
 	TracesBasedPanels int `json:"tracesBasedPanels"`
 }
 
+// MetricSeriesCount is the number of series of a metric sharing the
+// values of a set of labels
+type MetricSeriesCount struct {
+	MetricName string            `json:"metricName"`
+	Labels     map[string]string `json:"labels"`
+	Series     uint64            `json:"series"`
+}
+
 type TagTelemetryData struct {
 	ServiceName string `json:"serviceName" ch:"serviceName"`
 	Env         string `json:"env" ch:"env"`
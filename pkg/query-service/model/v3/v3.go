@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -354,6 +355,113 @@ type QueryRangeParamsV3 struct {
 	CompositeQuery *CompositeQuery        `json:"compositeQuery"`
 	Variables      map[string]interface{} `json:"variables,omitempty"`
 	NoCache        bool                   `json:"noCache"`
+	// Timeout in seconds after which the queries still running are cancelled
+	Timeout int64 `json:"timeout,omitempty"`
+	// AllowPartial responds with the results of the successful queries and the
+	// errors of the failed ones instead of failing the whole request
+	AllowPartial bool `json:"allowPartial,omitempty"`
+	// VariableTypes declares the types of the variables that are not strings,
+	// their values are validated and substituted as numbers or booleans
+	VariableTypes map[string]VariableType `json:"variableTypes,omitempty"`
+	// ObfuscationProfile is the id of the obfuscation profile applied to the
+	// results when they are shared outside of SigNoz, in snapshots or exports.
+	// The profiles enforced for the role of the user apply anyway.
+	ObfuscationProfile string `json:"obfuscationProfile,omitempty"`
+}
+
+type VariableDataType string
+
+const (
+	VariableDataTypeString  VariableDataType = "string"
+	VariableDataTypeNumber  VariableDataType = "number"
+	VariableDataTypeBoolean VariableDataType = "boolean"
+)
+
+// VariableType is the type of a dashboard variable, numbers can be limited
+// to a range
+type VariableType struct {
+	DataType VariableDataType `json:"dataType"`
+	Min      *float64         `json:"min,omitempty"`
+	Max      *float64         `json:"max,omitempty"`
+}
+
+func (t VariableType) Validate() error {
+	switch t.DataType {
+	case "", VariableDataTypeString, VariableDataTypeBoolean:
+		if t.Min != nil || t.Max != nil {
+			return fmt.Errorf("min and max are only supported for number variables")
+		}
+	case VariableDataTypeNumber:
+		if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+			return fmt.Errorf("min can not be greater than max")
+		}
+	default:
+		return fmt.Errorf("invalid variable data type: %s", t.DataType)
+	}
+	return nil
+}
+
+// Coerce converts the value of a variable, or each of the values of a multi
+// select variable, to the type. Numbers are converted to int64 when they are
+// integers so that they can be used where clickhouse expects integers,
+// e.g. in LIMIT.
+func (t VariableType) Coerce(value interface{}) (interface{}, error) {
+	if values, ok := value.([]interface{}); ok {
+		coerced := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			c, err := t.coerceScalar(v)
+			if err != nil {
+				return nil, err
+			}
+			coerced = append(coerced, c)
+		}
+		return coerced, nil
+	}
+	return t.coerceScalar(value)
+}
+
+func (t VariableType) coerceScalar(value interface{}) (interface{}, error) {
+	switch t.DataType {
+	case VariableDataTypeNumber:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			number = parsed
+		default:
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		if t.Min != nil && number < *t.Min {
+			return nil, fmt.Errorf("%v is less than the minimum %v", number, *t.Min)
+		}
+		if t.Max != nil && number > *t.Max {
+			return nil, fmt.Errorf("%v is greater than the maximum %v", number, *t.Max)
+		}
+		if number == float64(int64(number)) {
+			return int64(number), nil
+		}
+		return number, nil
+	case VariableDataTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", v)
+			}
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("%v is not a boolean", value)
+		}
+	default:
+		return value, nil
+	}
 }
 
 type PromQuery struct {
@@ -379,6 +487,30 @@ type ClickHouseQuery struct {
 	Query    string `json:"query"`
 	Disabled bool   `json:"disabled"`
 	Legend   string `json:"legend,omitempty"`
+	// Parameterized queries reference variables with ClickHouse query parameter
+	// placeholders such as {service:String} or {hosts:Array(String)}, the values
+	// are bound on the server instead of being interpolated into the query text
+	Parameterized bool `json:"parameterized,omitempty"`
+	// Parameters holds the values bound to the placeholders of a parameterized
+	// query. It is populated from the request variables, never by the client
+	Parameters map[string]string `json:"-"`
+}
+
+// clickHouseParamRe matches the {<name>:<data type>} query parameter syntax
+var clickHouseParamRe = regexp.MustCompile(`\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*:\s*([^{}]+?)\s*\}`)
+
+// ParameterNames returns the names of the query parameters declared in the query
+func (c *ClickHouseQuery) ParameterNames() []string {
+	names := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range clickHouseParamRe.FindAllStringSubmatch(c.Query, -1) {
+		if _, ok := seen[match[1]]; ok {
+			continue
+		}
+		seen[match[1]] = struct{}{}
+		names = append(names, match[1])
+	}
+	return names
 }
 
 func (c *ClickHouseQuery) Validate() error {
@@ -390,6 +522,10 @@ func (c *ClickHouseQuery) Validate() error {
 		return fmt.Errorf("query is empty")
 	}
 
+	if c.Parameterized && strings.Contains(c.Query, "{{") {
+		return fmt.Errorf("parameterized query can not contain template variables, use {name:Type} placeholders instead")
+	}
+
 	return nil
 }
 
@@ -400,6 +536,26 @@ type CompositeQuery struct {
 	PanelType         PanelType                   `json:"panelType"`
 	QueryType         QueryType                   `json:"queryType"`
 	Unit              string                      `json:"unit,omitempty"`
+	// CalculatedColumns are derived from the other columns of every row of
+	// table panels
+	CalculatedColumns []CalculatedColumn `json:"calculatedColumns,omitempty"`
+}
+
+// CalculatedColumn is a column of a table panel computed from the group by
+// labels, query values and the calculated columns before it in a row.
+// Eg: {"name": "error_rate", "expression": "B / A * 100", "unit": "percent"}
+type CalculatedColumn struct {
+	Name string `json:"name"`
+	// Expression is evaluated with the other columns of the row as variables
+	Expression string `json:"expression,omitempty"`
+	// Format renders the column from a template referencing the value of
+	// the expression as {value} and other columns by their names.
+	// Eg: "{service_name} ({value})"
+	Format string `json:"format,omitempty"`
+	// Unit is used for rendering numeric values, Precision is only used
+	// when there is no unit
+	Unit      string `json:"unit,omitempty"`
+	Precision *int   `json:"precision,omitempty"`
 }
 
 func (c *CompositeQuery) Validate() error {
@@ -415,6 +571,9 @@ func (c *CompositeQuery) Validate() error {
 		if err := query.Validate(); err != nil {
 			return fmt.Errorf("builder query %s is invalid: %w", name, err)
 		}
+		if query.GroupByRollup && c.PanelType != PanelTypeTable {
+			return fmt.Errorf("builder query %s is invalid: group by rollup is only supported for table panels", name)
+		}
 	}
 
 	for name, query := range c.ClickHouseQueries {
@@ -429,6 +588,29 @@ func (c *CompositeQuery) Validate() error {
 		}
 	}
 
+	if len(c.CalculatedColumns) > 0 && c.PanelType != PanelTypeTable {
+		return fmt.Errorf("calculated columns are only supported for table panels")
+	}
+	seenColumns := map[string]struct{}{}
+	for _, col := range c.CalculatedColumns {
+		if col.Name == "" {
+			return fmt.Errorf("calculated column name is required")
+		}
+		if _, ok := c.BuilderQueries[col.Name]; ok {
+			return fmt.Errorf("calculated column %s has the same name as a query", col.Name)
+		}
+		if _, ok := seenColumns[col.Name]; ok {
+			return fmt.Errorf("calculated column %s is specified more than once", col.Name)
+		}
+		seenColumns[col.Name] = struct{}{}
+		if col.Expression == "" && col.Format == "" {
+			return fmt.Errorf("calculated column %s must have an expression or a format", col.Name)
+		}
+		if col.Precision != nil && (*col.Precision < 0 || *col.Precision > 10) {
+			return fmt.Errorf("precision of calculated column %s must be between 0 and 10", col.Name)
+		}
+	}
+
 	if err := c.PanelType.Validate(); err != nil {
 		return fmt.Errorf("panel type is invalid: %w", err)
 	}
@@ -583,6 +765,9 @@ const (
 	FunctionNameMedian5   FunctionName = "median5"
 	FunctionNameMedian7   FunctionName = "median7"
 	FunctionNameTimeShift FunctionName = "timeShift"
+
+	FunctionNameLabelReplace FunctionName = "labelReplace"
+	FunctionNameLabelJoin    FunctionName = "labelJoin"
 )
 
 func (f FunctionName) Validate() error {
@@ -601,7 +786,9 @@ func (f FunctionName) Validate() error {
 		FunctionNameMedian3,
 		FunctionNameMedian5,
 		FunctionNameMedian7,
-		FunctionNameTimeShift:
+		FunctionNameTimeShift,
+		FunctionNameLabelReplace,
+		FunctionNameLabelJoin:
 		return nil
 	default:
 		return fmt.Errorf("invalid function name: %s", f)
@@ -613,6 +800,20 @@ type Function struct {
 	Args []interface{} `json:"args,omitempty"`
 }
 
+// StringArgs returns the args of functions which take label names and
+// other strings as params
+func (f Function) StringArgs() ([]string, error) {
+	args := make([]string, len(f.Args))
+	for i, arg := range f.Args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("params of %s should be strings", f.Name)
+		}
+		args[i] = s
+	}
+	return args, nil
+}
+
 type BuilderQuery struct {
 	QueryName          string            `json:"queryName"`
 	StepInterval       int64             `json:"stepInterval"`
@@ -635,7 +836,57 @@ type BuilderQuery struct {
 	TimeAggregation    TimeAggregation   `json:"timeAggregation,omitempty"`
 	SpaceAggregation   SpaceAggregation  `json:"spaceAggregation,omitempty"`
 	Functions          []Function        `json:"functions,omitempty"`
-	ShiftBy            int64
+	// CastAggregateAttribute casts the values of a string aggregate attribute
+	// to the given numeric type, values that fail to cast are left out
+	CastAggregateAttribute AttributeKeyDataType `json:"castAggregateAttribute,omitempty"`
+	// GroupByRollup adds subtotal series for each prefix of the group by keys,
+	// e.g. per cluster and per cluster and namespace when grouping by cluster,
+	// namespace and pod
+	GroupByRollup bool `json:"groupByRollup,omitempty"`
+	// FilterSnippets are the names of org wide filter snippets whose filters
+	// are ANDed with Filters when the query runs
+	FilterSnippets []string `json:"filterSnippets,omitempty"`
+	// TraceConditions restrict traces queries to the spans of traces which
+	// have, or don't have, spans matching each of the conditions
+	TraceConditions []TraceCondition `json:"traceConditions,omitempty"`
+	ShiftBy         int64
+	// TimestampTemplates has the builders render the bounds of the time
+	// range as template variables, for the query to be run on any range
+	TimestampTemplates *TimestampTemplates `json:"-"`
+}
+
+// TimestampTemplates is the time range, in epoch milliseconds, a builder
+// query is built for when its timestamps are rendered as the reserved
+// template variables of ClickHouse queries, e.g. {{.start_timestamp_ms}}
+type TimestampTemplates struct {
+	Start int64
+	End   int64
+}
+
+type TraceConditionOperator string
+
+const (
+	TraceConditionHasSpan TraceConditionOperator = "has_span"
+	TraceConditionNoSpan  TraceConditionOperator = "no_span"
+)
+
+// TraceCondition is a condition on the other spans of the trace a span is
+// part of, e.g. traces with a span of the payment service that errored
+type TraceCondition struct {
+	Operator TraceConditionOperator `json:"op"`
+	Filters  *FilterSet             `json:"filters"`
+}
+
+func (c *TraceCondition) Validate() error {
+	switch c.Operator {
+	case TraceConditionHasSpan, TraceConditionNoSpan:
+	default:
+		return fmt.Errorf("invalid trace condition operator %s", c.Operator)
+	}
+	if c.Filters == nil || len(c.Filters.Items) == 0 {
+		return fmt.Errorf("trace condition needs filters for the spans to look for")
+	}
+	return c.Filters.Validate()
 }
 
 func (b *BuilderQuery) Validate() error {
@@ -678,6 +929,17 @@ func (b *BuilderQuery) Validate() error {
 		if b.AggregateAttribute == (AttributeKey{}) && b.AggregateOperator.RequireAttribute(b.DataSource) {
 			return fmt.Errorf("aggregate attribute is required")
 		}
+		if b.CastAggregateAttribute != "" {
+			if b.DataSource != DataSourceLogs {
+				return fmt.Errorf("casting the aggregate attribute is only supported for logs")
+			}
+			if b.CastAggregateAttribute != AttributeKeyDataTypeFloat64 && b.CastAggregateAttribute != AttributeKeyDataTypeInt64 {
+				return fmt.Errorf("aggregate attribute can only be cast to %s or %s", AttributeKeyDataTypeFloat64, AttributeKeyDataTypeInt64)
+			}
+			if b.AggregateAttribute.Key == "" || b.AggregateAttribute.DataType != AttributeKeyDataTypeString {
+				return fmt.Errorf("only string aggregate attributes can be cast")
+			}
+		}
 	}
 
 	if b.Filters != nil {
@@ -685,6 +947,14 @@ func (b *BuilderQuery) Validate() error {
 			return fmt.Errorf("filters are invalid: %w", err)
 		}
 	}
+	if len(b.TraceConditions) > 0 && b.DataSource != DataSourceTraces {
+		return fmt.Errorf("trace conditions are only supported for traces")
+	}
+	for _, c := range b.TraceConditions {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("trace condition is invalid: %w", err)
+		}
+	}
 	if b.GroupBy != nil {
 		for _, groupBy := range b.GroupBy {
 			if err := groupBy.Validate(); err != nil {
@@ -692,6 +962,10 @@ func (b *BuilderQuery) Validate() error {
 			}
 		}
 
+		if b.GroupByRollup && b.DataSource != DataSourceLogs && b.DataSource != DataSourceTraces {
+			return fmt.Errorf("group by rollup is only supported for logs and traces")
+		}
+
 		if b.DataSource == DataSourceMetrics && len(b.GroupBy) > 0 && b.SpaceAggregation == SpaceAggregationUnspecified {
 			if b.AggregateOperator == AggregateOperatorNoOp || b.AggregateOperator == AggregateOperatorRate {
 				return fmt.Errorf("group by requires aggregate operator other than noop or rate")
@@ -769,6 +1043,33 @@ func (b *BuilderQuery) Validate() error {
 					}
 					function.Args[0] = threshold
 				}
+			} else if function.Name == FunctionNameLabelReplace {
+				// labelReplace(destination, replacement, source, regex) like label_replace of promql
+				if len(function.Args) != 4 {
+					return fmt.Errorf("labelReplace takes destination label, replacement, source label and regex params")
+				}
+				args, err := function.StringArgs()
+				if err != nil {
+					return err
+				}
+				if args[0] == "" {
+					return fmt.Errorf("destination label of labelReplace cannot be empty")
+				}
+				if _, err := regexp.Compile("^(?:" + args[3] + ")$"); err != nil {
+					return fmt.Errorf("regex param of labelReplace is invalid: %w", err)
+				}
+			} else if function.Name == FunctionNameLabelJoin {
+				// labelJoin(destination, separator, source...) like label_join of promql
+				if len(function.Args) < 3 {
+					return fmt.Errorf("labelJoin takes destination label, separator and source labels params")
+				}
+				args, err := function.StringArgs()
+				if err != nil {
+					return err
+				}
+				if args[0] == "" {
+					return fmt.Errorf("destination label of labelJoin cannot be empty")
+				}
 			}
 		}
 	}
@@ -905,16 +1206,71 @@ func (h *Having) CacheKey() string {
 }
 
 type QueryRangeResponse struct {
-	ContextTimeout        bool      `json:"contextTimeout,omitempty"`
-	ContextTimeoutMessage string    `json:"contextTimeoutMessage,omitempty"`
-	ResultType            string    `json:"resultType"`
-	Result                []*Result `json:"result"`
+	ContextTimeout        bool         `json:"contextTimeout,omitempty"`
+	ContextTimeoutMessage string       `json:"contextTimeoutMessage,omitempty"`
+	ResultType            string       `json:"resultType"`
+	Result                []*Result    `json:"result"`
+	Errors                []QueryError `json:"errors,omitempty"`
+	// Table joins the results of table panels with calculated columns
+	Table *Table `json:"table,omitempty"`
+}
+
+// QueryRangeStreamResult is sent when a unit of the queries of a streamed
+// query range request completes, a formula along with the queries it uses
+type QueryRangeStreamResult struct {
+	QueryNames []string     `json:"queryNames"`
+	Result     []*Result    `json:"result"`
+	Errors     []QueryError `json:"errors,omitempty"`
+}
+
+// QueryRangeStreamEnd is sent once all the queries of a streamed query
+// range request completed
+type QueryRangeStreamEnd struct {
+	ContextTimeout        bool         `json:"contextTimeout,omitempty"`
+	ContextTimeoutMessage string       `json:"contextTimeoutMessage,omitempty"`
+	Errors                []QueryError `json:"errors,omitempty"`
+	Table                 *Table       `json:"table,omitempty"`
+	TableError            string       `json:"tableError,omitempty"`
+}
+
+type TableColumn struct {
+	Name          string `json:"name"`
+	QueryName     string `json:"queryName,omitempty"`
+	IsValueColumn bool   `json:"isValueColumn"`
+	IsCalculated  bool   `json:"isCalculated,omitempty"`
+}
+
+type TableRow struct {
+	Data map[string]interface{} `json:"data"`
+	// Formatted holds the rendered values of calculated columns
+	Formatted map[string]string `json:"formatted,omitempty"`
+}
+
+type Table struct {
+	Columns []*TableColumn `json:"columns"`
+	Rows    []*TableRow    `json:"rows"`
+}
+
+// QueryError is the error of a single query of a partial response
+type QueryError struct {
+	QueryName string `json:"queryName"`
+	Error     string `json:"error"`
+	TimedOut  bool   `json:"timedOut"`
 }
 
 type Result struct {
-	QueryName string    `json:"queryName"`
-	Series    []*Series `json:"series"`
-	List      []*Row    `json:"list"`
+	QueryName string      `json:"queryName"`
+	Series    []*Series   `json:"series"`
+	List      []*Row      `json:"list"`
+	Meta      *ResultMeta `json:"meta,omitempty"`
+}
+
+type ResultMeta struct {
+	// CastFailures is the number of rows in the time range whose aggregate
+	// attribute value could not be cast to the requested type
+	CastFailures uint64 `json:"castFailures"`
+	// Owners are the teams owning the metric series in the result
+	Owners []string `json:"owners,omitempty"`
 }
 
 type LogsLiveTailClient struct {
@@ -969,6 +1325,8 @@ func (s *Series) RemoveDuplicatePoints() {
 type Row struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
+	// TraceExists tells whether the trace a log row links to is still stored
+	TraceExists *bool `json:"traceExists,omitempty"`
 }
 
 type Point struct {
@@ -1013,7 +1371,9 @@ type SavedView struct {
 	Tags           []string        `json:"tags"`
 	CompositeQuery *CompositeQuery `json:"compositeQuery"`
 	// ExtraData is JSON encoded data used by frontend to store additional data
-	ExtraData string `json:"extraData"`
+	ExtraData      string     `json:"extraData"`
+	ViewCount      int64      `json:"viewCount"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
 }
 
 func (eq *SavedView) Validate() error {
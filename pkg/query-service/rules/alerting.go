@@ -33,6 +33,9 @@ type RuleType string
 const (
 	RuleTypeThreshold = "threshold_rule"
 	RuleTypeProm      = "promql_rule"
+
+	// RuleTypeLogPattern rules are threshold rules on log patterns
+	RuleTypeLogPattern = "log_pattern_rule"
 )
 
 type RuleHealth string
@@ -145,6 +148,9 @@ type RuleCondition struct {
 	MatchType      `json:"matchType,omitempty"`
 	TargetUnit     string `json:"targetUnit,omitempty"`
 	SelectedQuery  string `json:"selectedQueryName,omitempty"`
+
+	// LogPattern rules get their composite query generated from it
+	LogPattern *LogPatternCondition `json:"logPattern,omitempty" yaml:"logPattern,omitempty"`
 }
 
 func (rc *RuleCondition) IsValid() bool {
@@ -50,6 +50,9 @@ type PostableRule struct {
 
 	PreferredChannels []string `json:"preferredChannels,omitempty"`
 
+	// Tags organize rules, unlike labels they aren't added to the alerts
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
 	// legacy
@@ -112,7 +115,11 @@ func parseIntoRule(initRule PostableRule, content []byte, kind string) (*Postabl
 		rule.Frequency = Duration(1 * time.Minute)
 	}
 
-	if rule.RuleCondition != nil {
+	if rule.RuleCondition != nil && rule.RuleCondition.LogPattern != nil {
+		if err := prepareLogPatternCondition(rule); err != nil {
+			return nil, []error{err}
+		}
+	} else if rule.RuleCondition != nil {
 		if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 			rule.RuleType = RuleTypeThreshold
 		} else if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypePromQL {
@@ -161,7 +168,7 @@ func (r *PostableRule) Validate() (errs []error) {
 		}
 	}
 
-	if r.RuleType == RuleTypeThreshold {
+	if r.RuleType == RuleTypeThreshold || r.RuleType == RuleTypeLogPattern {
 		if r.RuleCondition.Target == nil {
 			errs = append(errs, errors.Errorf("rule condition missing the threshold"))
 		}
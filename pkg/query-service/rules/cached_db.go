@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// storedRulesCacheTTL bounds how long cached rules can be stale when they
+// are written by another query service sharing the db
+const storedRulesCacheTTL = 30 * time.Second
+
+// cachedRuleDB serves stored rules from memory so that polling the rules
+// doesn't query the db every time. The cache is dropped on every write.
+type cachedRuleDB struct {
+	RuleDB
+
+	mtx        sync.Mutex
+	rules      []StoredRule
+	expiresAt  time.Time
+	generation uint64
+}
+
+func newCachedRuleDB(db RuleDB) *cachedRuleDB {
+	return &cachedRuleDB{RuleDB: db}
+}
+
+func (c *cachedRuleDB) invalidate() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.rules = nil
+	c.generation++
+}
+
+// cached returns a copy of the cached rules, or nil along with the current
+// generation when they have to be fetched again
+func (c *cachedRuleDB) cached() ([]StoredRule, uint64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.rules == nil || time.Now().After(c.expiresAt) {
+		return nil, c.generation
+	}
+	return append([]StoredRule{}, c.rules...), c.generation
+}
+
+func (c *cachedRuleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
+	rules, generation := c.cached()
+	if rules != nil {
+		return rules, nil
+	}
+
+	rules, err := c.RuleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	// rules written while fetching them are not cached
+	if generation == c.generation {
+		c.rules = append([]StoredRule{}, rules...)
+		c.expiresAt = time.Now().Add(storedRulesCacheTTL)
+	}
+	return rules, nil
+}
+
+func (c *cachedRuleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, error) {
+	if rules, _ := c.cached(); rules != nil {
+		for _, r := range rules {
+			if strconv.Itoa(r.Id) == id {
+				return &r, nil
+			}
+		}
+	}
+	return c.RuleDB.GetStoredRule(ctx, id)
+}
+
+// invalidatingTx drops the cache once a write is committed
+type invalidatingTx struct {
+	Tx
+	c *cachedRuleDB
+}
+
+func (t *invalidatingTx) Commit() error {
+	defer t.c.invalidate()
+	return t.Tx.Commit()
+}
+
+func (c *cachedRuleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, error) {
+	defer c.invalidate()
+	id, tx, err := c.RuleDB.CreateRuleTx(ctx, rule)
+	if tx != nil {
+		tx = &invalidatingTx{Tx: tx, c: c}
+	}
+	return id, tx, err
+}
+
+func (c *cachedRuleDB) EditRuleTx(ctx context.Context, rule string, id string) (string, Tx, error) {
+	defer c.invalidate()
+	name, tx, err := c.RuleDB.EditRuleTx(ctx, rule, id)
+	if tx != nil {
+		tx = &invalidatingTx{Tx: tx, c: c}
+	}
+	return name, tx, err
+}
+
+func (c *cachedRuleDB) EditRules(
+	ctx context.Context, rules map[int]string, beforeCommit func(tx *sqlx.Tx) error,
+) error {
+	defer c.invalidate()
+	return c.RuleDB.EditRules(ctx, rules, beforeCommit)
+}
+
+func (c *cachedRuleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error) {
+	defer c.invalidate()
+	name, tx, err := c.RuleDB.DeleteRuleTx(ctx, id)
+	if tx != nil {
+		tx = &invalidatingTx{Tx: tx, c: c}
+	}
+	return name, tx, err
+}
+
+func (c *cachedRuleDB) RestoreRule(ctx context.Context, id string) error {
+	defer c.invalidate()
+	return c.RuleDB.RestoreRule(ctx, id)
+}
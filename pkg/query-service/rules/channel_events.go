@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// pagerDutyChangeEventsURL is where the events are sent for the pagerduty
+// channels, as change events which don't open incidents
+var pagerDutyChangeEventsURL = "https://events.pagerduty.com/v2/change/enqueue"
+
+const channelEventTimeout = 10 * time.Second
+
+// ChannelEvent is a notification about something that happened, like a
+// rollout, rather than about a condition which holds until it resolves.
+// Alerts sent to the alert manager are notified as resolved once they end
+// or their resolve timeout is over, so events are posted to the channels
+// directly instead.
+type ChannelEvent struct {
+	Labels      map[string]string
+	Summary     string
+	Description string
+	At          time.Time
+	// Channels are the names of the channels to post to, all the channels
+	// when empty
+	Channels []string
+}
+
+// eventReceiver is the part of the config of a channel that events are
+// posted to directly
+type eventReceiver struct {
+	Name         string `json:"name"`
+	SlackConfigs []struct {
+		APIURL  string `json:"api_url"`
+		Channel string `json:"channel"`
+	} `json:"slack_configs"`
+	MSTeamsConfigs []struct {
+		WebhookURL string `json:"webhook_url"`
+	} `json:"msteams_configs"`
+	WebhookConfigs []struct {
+		URL        string `json:"url"`
+		HTTPConfig struct {
+			BasicAuth *struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+			} `json:"basic_auth"`
+		} `json:"http_config"`
+	} `json:"webhook_configs"`
+	PagerdutyConfigs []struct {
+		RoutingKey string `json:"routing_key"`
+	} `json:"pagerduty_configs"`
+}
+
+// postsDirectly tells whether the events are posted to the channel rather
+// than sent through the alert manager
+func (r *eventReceiver) postsDirectly() bool {
+	return len(r.SlackConfigs)+len(r.MSTeamsConfigs)+len(r.WebhookConfigs)+len(r.PagerdutyConfigs) > 0
+}
+
+func (m *Manager) eventReceivers(ctx context.Context, channels []string) ([]eventReceiver, error) {
+	rows := []struct {
+		Name string `db:"name"`
+		Data string `db:"data"`
+	}{}
+	err := m.opts.DBConn.SelectContext(ctx, &rows, `SELECT name, data FROM notification_channels ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("could not get notification channels: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range channels {
+		wanted[name] = true
+	}
+	receivers := []eventReceiver{}
+	for _, row := range rows {
+		if len(wanted) > 0 && !wanted[row.Name] {
+			continue
+		}
+		receiver := eventReceiver{}
+		if err := json.Unmarshal([]byte(row.Data), &receiver); err != nil {
+			zap.L().Error("failed to parse notification channel", zap.String("channel", row.Name), zap.Error(err))
+			continue
+		}
+		receiver.Name = row.Name
+		receivers = append(receivers, receiver)
+	}
+	return receivers, nil
+}
+
+// NotifyChannels posts an event to its channels. The slack, msteams,
+// webhook and pagerduty channels get it directly. The other channels, e.g.
+// email, only get alerts from the alert manager: the event is sent to them
+// as an alert without an end, and they are notified of it as resolved after
+// the resolve timeout of the alert manager unless their send_resolved is off.
+func (m *Manager) NotifyChannels(ctx context.Context, event *ChannelEvent) {
+	receivers, err := m.eventReceivers(ctx, event.Channels)
+	if err != nil {
+		zap.L().Error("failed to notify channels of event", zap.String("summary", event.Summary), zap.Error(err))
+		return
+	}
+
+	client := &http.Client{Timeout: channelEventTimeout}
+	viaAlertManager := []string{}
+	for _, receiver := range receivers {
+		if !receiver.postsDirectly() {
+			viaAlertManager = append(viaAlertManager, receiver.Name)
+			continue
+		}
+		if err := postChannelEvent(ctx, client, &receiver, event); err != nil {
+			zap.L().Error("failed to post event to channel",
+				zap.String("channel", receiver.Name), zap.String("summary", event.Summary), zap.Error(err))
+		}
+	}
+	if len(viaAlertManager) > 0 && m.notifier != nil {
+		m.notifier.Send(event.alert(viaAlertManager))
+	}
+}
+
+// alert is the event as an alert of the alert manager for the given channels
+func (e *ChannelEvent) alert(channels []string) *am.Alert {
+	return &am.Alert{
+		Labels: labels.FromMap(e.Labels),
+		Annotations: labels.FromMap(map[string]string{
+			"summary":     e.Summary,
+			"description": e.Description,
+		}),
+		StartsAt:  e.At,
+		Receivers: channels,
+	}
+}
+
+func postChannelEvent(ctx context.Context, client *http.Client, receiver *eventReceiver, event *ChannelEvent) error {
+	text := fmt.Sprintf("*%s*\n%s", event.Summary, event.Description)
+	for _, config := range receiver.SlackConfigs {
+		body := map[string]interface{}{"text": text}
+		if config.Channel != "" {
+			body["channel"] = config.Channel
+		}
+		if err := postJSON(ctx, client, config.APIURL, body, nil); err != nil {
+			return err
+		}
+	}
+	for _, config := range receiver.MSTeamsConfigs {
+		body := map[string]interface{}{
+			"@type": "MessageCard", "@context": "http://schema.org/extensions",
+			"title": event.Summary, "text": event.Description,
+		}
+		if err := postJSON(ctx, client, config.WebhookURL, body, nil); err != nil {
+			return err
+		}
+	}
+	for _, config := range receiver.WebhookConfigs {
+		var auth func(*http.Request)
+		if basicAuth := config.HTTPConfig.BasicAuth; basicAuth != nil {
+			auth = func(r *http.Request) { r.SetBasicAuth(basicAuth.Username, basicAuth.Password) }
+		}
+		if err := postJSON(ctx, client, config.URL, webhookEventMessage(receiver.Name, event), auth); err != nil {
+			return err
+		}
+	}
+	for _, config := range receiver.PagerdutyConfigs {
+		body := map[string]interface{}{
+			"routing_key": config.RoutingKey,
+			"payload": map[string]interface{}{
+				"summary":        event.Summary,
+				"timestamp":      event.At.UTC().Format(time.RFC3339),
+				"source":         "signoz",
+				"custom_details": event.Labels,
+			},
+		}
+		if err := postJSON(ctx, client, pagerDutyChangeEventsURL, body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookEventMessage is the event in the format of the webhook messages of
+// the alert manager, as a firing alert which is never followed by its
+// resolution
+func webhookEventMessage(receiver string, event *ChannelEvent) map[string]interface{} {
+	annotations := map[string]string{"summary": event.Summary, "description": event.Description}
+	keys := make([]string, 0, len(event.Labels))
+	for key := range event.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	groupKey := make([]string, 0, len(keys))
+	for _, key := range keys {
+		groupKey = append(groupKey, fmt.Sprintf("%s=%q", key, event.Labels[key]))
+	}
+	return map[string]interface{}{
+		"version":           "4",
+		"groupKey":          "{}:{" + strings.Join(groupKey, ", ") + "}",
+		"status":            "firing",
+		"receiver":          receiver,
+		"groupLabels":       map[string]string{},
+		"commonLabels":      event.Labels,
+		"commonAnnotations": annotations,
+		"alerts": []map[string]interface{}{{
+			"status":      "firing",
+			"labels":      event.Labels,
+			"annotations": annotations,
+			"startsAt":    event.At,
+		}},
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}, auth func(*http.Request)) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != nil {
+		auth(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response status %s from %s", resp.Status, url)
+	}
+	return nil
+}
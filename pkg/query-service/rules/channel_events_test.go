@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// channelRequests records the requests posted to the channels, by path
+type channelRequests struct {
+	mtx      sync.Mutex
+	requests map[string][]map[string]interface{}
+	auth     map[string]string
+}
+
+func (c *channelRequests) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var payload interface{}
+	json.Unmarshal(body, &payload)
+	decoded, ok := payload.(map[string]interface{})
+	if !ok {
+		// the alert manager is posted lists of alerts
+		decoded = map[string]interface{}{"alerts": payload}
+	}
+	user, _, _ := r.BasicAuth()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.requests[r.URL.Path] = append(c.requests[r.URL.Path], decoded)
+	c.auth[r.URL.Path] = user
+}
+
+func (c *channelRequests) get(path string) []map[string]interface{} {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.requests[path]
+}
+
+// newChannelsManager returns a manager with the given channels, by name, in
+// its db and an alert manager behind its notifier
+func newChannelsManager(t *testing.T, channels map[string]string) (*Manager, *channelRequests, string) {
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(t, err)
+	_, err = db.Exec(`CREATE TABLE notification_channels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL,
+		name TEXT NOT NULL UNIQUE,
+		type TEXT NOT NULL,
+		deleted INTEGER DEFAULT 0,
+		data TEXT NOT NULL
+	);`)
+	require.Nil(t, err)
+
+	requests := &channelRequests{requests: map[string][]map[string]interface{}{}, auth: map[string]string{}}
+	server := httptest.NewServer(requests)
+	t.Cleanup(server.Close)
+
+	for name, data := range channels {
+		_, err := db.Exec(
+			`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES (?, ?, ?, ?, ?)`,
+			time.Now(), time.Now(), name, "any", strings.ReplaceAll(data, "{url}", server.URL),
+		)
+		require.Nil(t, err)
+	}
+
+	notifier, err := am.NewNotifier(&am.NotifierOptions{
+		QueueCapacity: 10, AlertManagerURLs: []string{server.URL}, Timeout: time.Second,
+	}, nil)
+	require.Nil(t, err)
+	go notifier.Run()
+	t.Cleanup(notifier.Stop)
+
+	return &Manager{opts: &ManagerOptions{DBConn: db}, notifier: notifier}, requests, server.URL
+}
+
+func TestNotifyChannels(t *testing.T) {
+	m, requests, url := newChannelsManager(t, map[string]string{
+		"slack":     `{"name": "slack", "slack_configs": [{"api_url": "{url}/slack", "channel": "#deploys"}]}`,
+		"teams":     `{"name": "teams", "msteams_configs": [{"webhook_url": "{url}/teams"}]}`,
+		"hook":      `{"name": "hook", "webhook_configs": [{"url": "{url}/hook", "http_config": {"basic_auth": {"username": "ops", "password": "secret"}}}]}`,
+		"pagerduty": `{"name": "pagerduty", "pagerduty_configs": [{"routing_key": "key1"}]}`,
+		"email":     `{"name": "email", "email_configs": [{"to": "ops@signoz.io", "smarthost": "{url}"}]}`,
+		"other":     `{"name": "other", "slack_configs": [{"api_url": "{url}/other"}]}`,
+		"broken":    `{"name": "broken", "slack_configs": {}}`,
+	})
+	pagerDutyURL := pagerDutyChangeEventsURL
+	pagerDutyChangeEventsURL = url + "/pagerduty"
+	t.Cleanup(func() { pagerDutyChangeEventsURL = pagerDutyURL })
+
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	m.NotifyChannels(context.Background(), &ChannelEvent{
+		Labels:      map[string]string{labels.AlertNameLabel: "Agent config rollout", "version": "3"},
+		Summary:     "Version 3 of log_pipelines was deployed to the agents",
+		Description: "Deployed to: agent-1.",
+		At:          at,
+		Channels:    []string{"slack", "teams", "hook", "pagerduty", "email", "broken"},
+	})
+
+	require := require.New(t)
+	require.Empty(requests.get("/other"), "only the channels of the event are posted to")
+
+	slack := requests.get("/slack")
+	require.Len(slack, 1)
+	require.Equal("#deploys", slack[0]["channel"])
+	require.Equal("*Version 3 of log_pipelines was deployed to the agents*\nDeployed to: agent-1.", slack[0]["text"])
+
+	teams := requests.get("/teams")
+	require.Len(teams, 1)
+	require.Equal("Version 3 of log_pipelines was deployed to the agents", teams[0]["title"])
+
+	hook := requests.get("/hook")
+	require.Len(hook, 1)
+	require.Equal("ops", requests.auth["/hook"])
+	require.Equal("firing", hook[0]["status"])
+	hookAlerts := hook[0]["alerts"].([]interface{})
+	require.Len(hookAlerts, 1)
+	require.NotContains(hookAlerts[0], "endsAt", "the events are never resolved")
+
+	pagerduty := requests.get("/pagerduty")
+	require.Len(pagerduty, 1)
+	require.Equal("key1", pagerduty[0]["routing_key"])
+	require.Equal("2024-03-01T10:00:00Z", pagerduty[0]["payload"].(map[string]interface{})["timestamp"])
+
+	// the channels which only get alerts get the event through the alert
+	// manager
+	require.Eventually(func() bool {
+		return len(requests.get("/v1/alerts")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	alerts := requests.get("/v1/alerts")[0]["alerts"].([]interface{})
+	require.Len(alerts, 1)
+	require.Equal([]interface{}{"email"}, alerts[0].(map[string]interface{})["receivers"])
+}
+
+func TestNotifyAllChannels(t *testing.T) {
+	m, requests, _ := newChannelsManager(t, map[string]string{
+		"slack": `{"name": "slack", "slack_configs": [{"api_url": "{url}/slack"}]}`,
+		"teams": `{"name": "teams", "msteams_configs": [{"webhook_url": "{url}/teams"}]}`,
+	})
+
+	m.NotifyChannels(context.Background(), &ChannelEvent{Summary: "deployed", At: time.Now()})
+	require.Len(t, requests.get("/slack"), 1)
+	require.Len(t, requests.get("/teams"), 1)
+	require.Empty(t, requests.get("/v1/alerts"), "no alert is sent when all the channels are posted to")
+}
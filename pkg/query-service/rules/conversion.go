@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+
+	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	metricsv3 "go.signoz.io/signoz/pkg/query-service/app/metrics/v3"
+	metricsV4 "go.signoz.io/signoz/pkg/query-service/app/metrics/v4"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// The builder queries are built for a fixed time range whose bounds the builders
+// render as the reserved template variables. The bounds are far enough apart
+// that the bound a builder shifted, e.g. to start a rate query one step early,
+// is told apart from the other.
+const (
+	conversionStartMs int64 = 1577836800000 // 2020-01-01T00:00:00Z
+	conversionEndMs   int64 = 1609459200000 // 2021-01-01T00:00:00Z
+)
+
+// ConvertRule converts the queries of a threshold rule to the given query type.
+// Builder queries can always be converted to ClickHouse SQL, the builder queries
+// are kept along so that the conversion can be undone as long as the SQL was
+// not edited.
+func ConvertRule(rule *PostableRule, to v3.QueryType, featureFlags interfaces.FeatureLookup) (*PostableRule, error) {
+	if rule.RuleCondition == nil || rule.RuleCondition.CompositeQuery == nil {
+		return nil, fmt.Errorf("rule has no queries to convert")
+	}
+
+	converted, err := cloneRule(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	from := converted.RuleCondition.QueryType()
+	switch {
+	case from == v3.QueryTypeBuilder && to == v3.QueryTypeClickHouseSQL:
+		chQueries, err := builderToClickHouseQueries(converted, featureFlags)
+		if err != nil {
+			return nil, err
+		}
+		converted.RuleCondition.CompositeQuery.QueryType = v3.QueryTypeClickHouseSQL
+		converted.RuleCondition.CompositeQuery.ClickHouseQueries = chQueries
+		converted.RuleType = RuleTypeThreshold
+		return converted, nil
+	case from == v3.QueryTypeClickHouseSQL && to == v3.QueryTypeBuilder:
+		if len(converted.RuleCondition.CompositeQuery.BuilderQueries) == 0 {
+			return nil, fmt.Errorf("the rule was not created with the query builder, ClickHouse SQL can't be converted to builder queries")
+		}
+		generated, err := builderToClickHouseQueries(converted, featureFlags)
+		if err != nil {
+			return nil, err
+		}
+		for name, query := range converted.RuleCondition.CompositeQuery.ClickHouseQueries {
+			if generated[name] == nil || generated[name].Query != query.Query {
+				return nil, fmt.Errorf("query %s has been edited, edited ClickHouse SQL can't be converted to builder queries", name)
+			}
+		}
+		converted.RuleCondition.CompositeQuery.QueryType = v3.QueryTypeBuilder
+		converted.RuleCondition.CompositeQuery.ClickHouseQueries = nil
+		converted.RuleType = RuleTypeThreshold
+		return converted, nil
+	case from == to:
+		return converted, nil
+	default:
+		return nil, fmt.Errorf("can not convert %s rule to %s", from, to)
+	}
+}
+
+func cloneRule(rule *PostableRule) (*PostableRule, error) {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy rule: %w", err)
+	}
+	var clone PostableRule
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy rule: %w", err)
+	}
+	return &clone, nil
+}
+
+func builderToClickHouseQueries(rule *PostableRule, featureFlags interfaces.FeatureLookup) (map[string]*v3.ClickHouseQuery, error) {
+	// building the queries updates the builder queries in place
+	rule, err := cloneRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	compositeQuery := *rule.RuleCondition.CompositeQuery
+	compositeQuery.QueryType = v3.QueryTypeBuilder
+	for _, q := range compositeQuery.BuilderQueries {
+		q.StepInterval = 60
+		q.TimestampTemplates = &v3.TimestampTemplates{Start: conversionStartMs, End: conversionEndMs}
+	}
+
+	params := &v3.QueryRangeParamsV3{
+		Start:          conversionStartMs,
+		End:            conversionEndMs,
+		Step:           60,
+		CompositeQuery: &compositeQuery,
+	}
+	if logsv3.EnrichmentRequired(params) {
+		logsv3.Enrich(params, map[string]v3.AttributeKey{})
+	}
+
+	builderOpts := queryBuilder.QueryBuilderOptions{
+		BuildMetricQuery: metricsv3.PrepareMetricQuery,
+		BuildTraceQuery:  tracesV3.PrepareTracesQuery,
+		BuildLogQuery:    logsv3.PrepareLogsQuery,
+	}
+	if rule.Version == "v4" {
+		builderOpts.BuildMetricQuery = metricsV4.PrepareMetricQuery
+	}
+
+	queries, err := queryBuilder.NewQueryBuilder(builderOpts, featureFlags).PrepareQueries(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build queries: %w", err)
+	}
+
+	chQueries := make(map[string]*v3.ClickHouseQuery, len(queries))
+	for name, query := range queries {
+		chQueries[name] = &v3.ClickHouseQuery{
+			Query:    query,
+			Disabled: compositeQuery.BuilderQueries[name] != nil && compositeQuery.BuilderQueries[name].Disabled,
+		}
+	}
+	return chQueries, nil
+}
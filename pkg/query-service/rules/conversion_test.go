@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestConvertRule(t *testing.T) {
+	fm := featureManager.StartManager()
+
+	target := 10.0
+	builderRule := &PostableRule{
+		Alert:      "Error logs",
+		AlertType:  "LOGS_BASED_ALERT",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				PanelType: v3.PanelTypeGraph,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:         "A",
+						StepInterval:      60,
+						DataSource:        v3.DataSourceLogs,
+						AggregateOperator: v3.AggregateOperatorCount,
+						Expression:        "A",
+						Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+							{Key: v3.AttributeKey{Key: "severity_text", Type: v3.AttributeKeyTypeUnspecified, DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Operator: v3.FilterOperatorEqual, Value: "ERROR"},
+						}},
+					},
+				},
+			},
+			Target:    &target,
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+		},
+	}
+
+	chRule, err := ConvertRule(builderRule, v3.QueryTypeClickHouseSQL, fm)
+	require.NoError(t, err)
+	assert.Equal(t, v3.QueryTypeBuilder, builderRule.RuleCondition.QueryType(), "the original rule should not change")
+	assert.Equal(t, v3.QueryTypeClickHouseSQL, chRule.RuleCondition.QueryType())
+
+	chQuery := chRule.RuleCondition.CompositeQuery.ClickHouseQueries["A"]
+	require.NotNil(t, chQuery)
+	assert.Contains(t, chQuery.Query, "timestamp >= {{.start_timestamp_nano}} AND timestamp <= {{.end_timestamp_nano}}")
+	assert.Contains(t, chQuery.Query, "severity_text = 'ERROR'")
+
+	// unedited sql can be turned back into the builder queries
+	back, err := ConvertRule(chRule, v3.QueryTypeBuilder, fm)
+	require.NoError(t, err)
+	assert.Equal(t, v3.QueryTypeBuilder, back.RuleCondition.QueryType())
+	assert.Empty(t, back.RuleCondition.CompositeQuery.ClickHouseQueries)
+
+	chRule.RuleCondition.CompositeQuery.ClickHouseQueries["A"].Query = strings.Replace(chQuery.Query, "'ERROR'", "'WARN'", 1)
+	_, err = ConvertRule(chRule, v3.QueryTypeBuilder, fm)
+	assert.Error(t, err)
+}
+
+func TestConvertRuleTimestamps(t *testing.T) {
+	fm := featureManager.StartManager()
+
+	rule := func(version string, query *v3.BuilderQuery) *PostableRule {
+		target := 10.0
+		query.QueryName, query.Expression, query.StepInterval = "A", "A", 60
+		return &PostableRule{
+			Alert:      "rule",
+			RuleType:   RuleTypeThreshold,
+			EvalWindow: Duration(5 * time.Minute),
+			Frequency:  Duration(1 * time.Minute),
+			Version:    version,
+			RuleCondition: &RuleCondition{
+				CompositeQuery: &v3.CompositeQuery{
+					QueryType:      v3.QueryTypeBuilder,
+					PanelType:      v3.PanelTypeGraph,
+					BuilderQueries: map[string]*v3.BuilderQuery{"A": query},
+				},
+				Target:    &target,
+				CompareOp: ValueIsAbove,
+				MatchType: AtleastOnce,
+			},
+		}
+	}
+	// a value which is close to the time range the queries are built for
+	sentinelLike := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "request_id", Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString},
+		Operator: v3.FilterOperatorEqual,
+		Value:    "1577836800000",
+	}
+
+	cases := []struct {
+		name     string
+		rule     *PostableRule
+		contains []string
+	}{
+		{
+			name: "rate of metrics",
+			rule: rule("v4", &v3.BuilderQuery{
+				DataSource:         v3.DataSourceMetrics,
+				AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total", DataType: v3.AttributeKeyDataTypeFloat64},
+				Temporality:        v3.Cumulative,
+				TimeAggregation:    v3.TimeAggregationRate,
+				SpaceAggregation:   v3.SpaceAggregationSum,
+				AggregateOperator:  v3.AggregateOperatorSumRate,
+			}),
+			contains: []string{"unix_milli >= ({{.start_timestamp_ms}} - 60000) AND unix_milli < {{.end_timestamp_ms}}"},
+		},
+		{
+			name: "traces",
+			rule: rule("", &v3.BuilderQuery{
+				DataSource:        v3.DataSourceTraces,
+				AggregateOperator: v3.AggregateOperatorCount,
+				Filters:           &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{sentinelLike}},
+			}),
+			contains: []string{
+				"(timestamp >= '{{.start_timestamp_nano}}' AND timestamp <= '{{.end_timestamp_nano}}')",
+				"= '1577836800000'",
+			},
+		},
+		{
+			name: "logs",
+			rule: rule("", &v3.BuilderQuery{
+				DataSource:        v3.DataSourceLogs,
+				AggregateOperator: v3.AggregateOperatorCount,
+				Filters:           &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{sentinelLike}},
+			}),
+			contains: []string{
+				"(timestamp >= {{.start_timestamp_nano}} AND timestamp <= {{.end_timestamp_nano}})",
+				"= '1577836800000'",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			converted, err := ConvertRule(c.rule, v3.QueryTypeClickHouseSQL, fm)
+			require.NoError(t, err)
+			query := converted.RuleCondition.CompositeQuery.ClickHouseQueries["A"].Query
+			for _, expected := range c.contains {
+				assert.Contains(t, query, expected)
+			}
+			assert.NotContains(t, query, "1609459200000", "the time range is rendered as template variables")
+		})
+	}
+}
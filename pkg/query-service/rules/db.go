@@ -22,6 +22,22 @@ type RuleDB interface {
 	// DeleteRuleTx deletes the given rule in the db and returns tx and group name (on success)
 	DeleteRuleTx(ctx context.Context, id string) (string, Tx, error)
 
+	// EditRules updates the given rules, keyed by id, in a single transaction
+	// along with what beforeCommit writes, nothing is written if either fails
+	EditRules(ctx context.Context, rules map[int]string, beforeCommit func(tx *sqlx.Tx) error) error
+
+	// GetDeletedRules fetches the rules in the trash, which are not purged yet
+	GetDeletedRules(ctx context.Context) ([]StoredRule, error)
+
+	// RestoreRule brings back a deleted rule
+	RestoreRule(ctx context.Context, id string) error
+
+	// PurgeRule removes a deleted rule from the db
+	PurgeRule(ctx context.Context, id string) error
+
+	// PurgeDeletedRules removes the rules deleted before the given time from the db
+	PurgeDeletedRules(ctx context.Context, deletedBefore time.Time) error
+
 	// GetStoredRules fetches the rule definitions from db
 	GetStoredRules(ctx context.Context) ([]StoredRule, error)
 
@@ -36,6 +52,9 @@ type StoredRule struct {
 	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
 	UpdatedBy *string    `json:"updated_by" db:"updated_by"`
 	Data      string     `json:"data" db:"data"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy *string    `json:"deleted_by,omitempty" db:"deleted_by"`
 }
 
 type Tx interface {
@@ -136,20 +155,59 @@ func (r *ruleDB) EditRuleTx(ctx context.Context, rule string, id string) (string
 	return groupName, nil, nil
 }
 
-// DeleteRuleTx deletes a given rule with id and returns
+// EditRules updates the given rules and writes what beforeCommit writes in
+// the same transaction
+func (r *ruleDB) EditRules(
+	ctx context.Context, rules map[int]string, beforeCommit func(tx *sqlx.Tx) error,
+) error {
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	updatedAt := time.Now()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, rule := range rules {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE rules SET updated_by=$1, updated_at=$2, data=$3 WHERE id=$4;`,
+			userEmail, updatedAt, rule, id,
+		)
+		if err != nil {
+			return fmt.Errorf("could not update rule %d: %w", id, err)
+		}
+	}
+	if beforeCommit != nil {
+		if err := beforeCommit(tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRuleTx moves a given rule with id to the trash and returns
 // taskname, sql tx and error (if any)
 func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error) {
 
 	idInt, _ := strconv.Atoi(id)
 	groupName := prepareTaskName(int64(idInt))
 
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+
 	// commented as this causes db locked error
 	// tx, err := r.Begin()
 	// if err != nil {
 	// 	return groupName, tx, err
 	// }
 
-	stmt, err := r.Prepare(`DELETE FROM rules WHERE id=$1;`)
+	stmt, err := r.Prepare(`UPDATE rules SET deleted=1, deleted_at=$1, deleted_by=$2 WHERE id=$3;`)
 
 	if err != nil {
 		return groupName, nil, err
@@ -157,7 +215,7 @@ func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error
 
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(idInt); err != nil {
+	if _, err := stmt.Exec(time.Now(), userEmail, idInt); err != nil {
 		zap.S().Errorf("Error in Executing prepared statement for DELETE to rules\n", err)
 		// tx.Rollback()
 		return groupName, nil, err
@@ -170,7 +228,7 @@ func (r *ruleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
 
 	rules := []StoredRule{}
 
-	query := "SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules"
+	query := "SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules WHERE deleted = 0"
 
 	err := r.Select(&rules, query)
 
@@ -190,7 +248,7 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 
 	rule := &StoredRule{}
 
-	query := fmt.Sprintf("SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules WHERE id=%d", intId)
+	query := fmt.Sprintf("SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules WHERE id=%d AND deleted = 0", intId)
 	err = r.Get(rule, query)
 
 	// zap.S().Info(query)
@@ -202,3 +260,43 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 
 	return rule, nil
 }
+
+func (r *ruleDB) GetDeletedRules(ctx context.Context) ([]StoredRule, error) {
+	rules := []StoredRule{}
+
+	query := `SELECT id, created_at, created_by, updated_at, updated_by, data, deleted_at, deleted_by
+		FROM rules WHERE deleted = 1`
+	if err := r.SelectContext(ctx, &rules, query); err != nil {
+		zap.L().Error("could not get deleted rules", zap.Error(err))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *ruleDB) RestoreRule(ctx context.Context, id string) error {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid id parameter")
+	}
+
+	_, err = r.ExecContext(ctx,
+		`UPDATE rules SET deleted=0, deleted_at=NULL, deleted_by=NULL WHERE id=$1 AND deleted = 1`, intId,
+	)
+	return err
+}
+
+func (r *ruleDB) PurgeRule(ctx context.Context, id string) error {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid id parameter")
+	}
+
+	_, err = r.ExecContext(ctx, `DELETE FROM rules WHERE id=$1 AND deleted = 1`, intId)
+	return err
+}
+
+func (r *ruleDB) PurgeDeletedRules(ctx context.Context, deletedBefore time.Time) error {
+	_, err := r.ExecContext(ctx, `DELETE FROM rules WHERE deleted = 1 AND deleted_at < $1`, deletedBefore)
+	return err
+}
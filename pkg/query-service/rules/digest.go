@@ -0,0 +1,383 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	// DigestLabel marks the summaries posted to channels in digest mode
+	DigestLabel = "digest"
+
+	minDigestInterval = 5 * time.Minute
+	maxDigestInterval = 24 * time.Hour
+	maxDigestTopN     = 50
+
+	// how often channels are checked for a digest to send
+	digestFlushInterval = 30 * time.Second
+)
+
+// ChannelDigest puts a notification channel in digest mode. The alerts sent
+// to the channel with a severity ranked after ImmediateSeverityRank are
+// batched into a summary sent every IntervalSeconds, listing the TopN groups
+// of alerts by the GroupBy labels that fired the most. Alerts without a
+// known severity are always sent right away.
+type ChannelDigest struct {
+	Channel               string   `json:"channel" db:"channel"`
+	IntervalSeconds       int      `json:"intervalSeconds" db:"interval_seconds"`
+	GroupBy               []string `json:"groupBy" db:"-"`
+	TopN                  int      `json:"topN" db:"top_n"`
+	ImmediateSeverityRank int      `json:"immediateSeverityRank" db:"immediate_severity_rank"`
+
+	GroupByJSON string `json:"-" db:"group_by"`
+}
+
+func (d *ChannelDigest) validate() error {
+	if strings.TrimSpace(d.Channel) == "" {
+		return fmt.Errorf("channel of the digest is required")
+	}
+	interval := time.Duration(d.IntervalSeconds) * time.Second
+	if interval < minDigestInterval || interval > maxDigestInterval {
+		return fmt.Errorf(
+			"digest interval must be between %s and %s", minDigestInterval, maxDigestInterval,
+		)
+	}
+	if len(d.GroupBy) == 0 {
+		d.GroupBy = []string{labels.AlertNameLabel}
+	}
+	for _, name := range d.GroupBy {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("labels to group digested alerts by can't be empty")
+		}
+	}
+	if d.TopN == 0 {
+		d.TopN = 5
+	}
+	if d.TopN < 0 || d.TopN > maxDigestTopN {
+		return fmt.Errorf("number of top alert groups must be between 1 and %d", maxDigestTopN)
+	}
+	if d.ImmediateSeverityRank == 0 {
+		d.ImmediateSeverityRank = 1
+	}
+	if d.ImmediateSeverityRank < 0 {
+		return fmt.Errorf("immediate severity rank can't be negative")
+	}
+	return nil
+}
+
+func (d *ChannelDigest) interval() time.Duration {
+	return time.Duration(d.IntervalSeconds) * time.Second
+}
+
+func initChannelDigests(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS alert_channel_digests (
+		channel TEXT PRIMARY KEY,
+		interval_seconds INTEGER NOT NULL,
+		group_by TEXT NOT NULL,
+		top_n INTEGER NOT NULL,
+		immediate_severity_rank INTEGER NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("could not create alert_channel_digests table: %w", err)
+	}
+	return nil
+}
+
+func getChannelDigests(db *sqlx.DB) ([]ChannelDigest, error) {
+	digests := []ChannelDigest{}
+	err := db.Select(&digests, `SELECT channel, interval_seconds, group_by, top_n, immediate_severity_rank
+		FROM alert_channel_digests ORDER BY channel`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range digests {
+		if err := json.Unmarshal([]byte(digests[i].GroupByJSON), &digests[i].GroupBy); err != nil {
+			return nil, fmt.Errorf("could not parse group by of digest for %s: %w", digests[i].Channel, err)
+		}
+	}
+	return digests, nil
+}
+
+// digestedAlert is an alert held back from a channel, the rules resend
+// firing alerts so they are counted once per fingerprint
+type digestedAlert struct {
+	labels   labels.BaseLabels
+	resolved bool
+}
+
+type channelDigestState struct {
+	config      ChannelDigest
+	windowStart time.Time
+	alerts      map[uint64]*digestedAlert
+}
+
+// alertDigester batches the alerts of the channels in digest mode. The
+// alerts of the current window are only kept in memory.
+type alertDigester struct {
+	mtx      sync.Mutex
+	channels map[string]*channelDigestState
+}
+
+func newAlertDigester(digests []ChannelDigest, now time.Time) *alertDigester {
+	d := &alertDigester{channels: map[string]*channelDigestState{}}
+	for _, digest := range digests {
+		d.set(digest, now)
+	}
+	return d
+}
+
+// set starts or reconfigures the digest of a channel, keeping the alerts
+// batched so far
+func (d *alertDigester) set(digest ChannelDigest, now time.Time) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if state, ok := d.channels[digest.Channel]; ok {
+		state.config = digest
+		return
+	}
+	d.channels[digest.Channel] = &channelDigestState{
+		config: digest, windowStart: now, alerts: map[uint64]*digestedAlert{},
+	}
+}
+
+// remove takes a channel out of digest mode, returning its pending summary
+func (d *alertDigester) remove(channel string, now time.Time) *ChannelEvent {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	state, ok := d.channels[channel]
+	if !ok {
+		return nil
+	}
+	delete(d.channels, channel)
+	return state.summary(now)
+}
+
+// route records the alert for the channels in digest mode that batch its
+// severity and returns the receivers it must be sent to right away
+func (d *alertDigester) route(alert *am.Alert, severityRank int, now time.Time) []string {
+	if severityRank <= 0 || len(alert.Receivers) == 0 {
+		return alert.Receivers
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	immediate := []string{}
+	for _, receiver := range alert.Receivers {
+		state, ok := d.channels[receiver]
+		if !ok || severityRank <= state.config.ImmediateSeverityRank {
+			immediate = append(immediate, receiver)
+			continue
+		}
+		fingerprint := alert.Labels.Hash()
+		digested, ok := state.alerts[fingerprint]
+		if !ok {
+			digested = &digestedAlert{labels: alert.Labels}
+			state.alerts[fingerprint] = digested
+		}
+		digested.resolved = alert.ResolvedAt(now)
+	}
+	return immediate
+}
+
+// due returns the summaries of the channels whose window is over and starts
+// their next window
+func (d *alertDigester) due(now time.Time) []*ChannelEvent {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	summaries := []*ChannelEvent{}
+	for _, state := range d.channels {
+		if now.Sub(state.windowStart) < state.config.interval() {
+			continue
+		}
+		if summary := state.summary(now); summary != nil {
+			summaries = append(summaries, summary)
+		}
+		state.windowStart = now
+		state.alerts = map[uint64]*digestedAlert{}
+	}
+	return summaries
+}
+
+type digestGroup struct {
+	key      string
+	firing   int
+	resolved int
+}
+
+// summary describes the alerts batched in the window, nil if there are none.
+// Summaries are events posted to the channel, sent as alerts they would be
+// notified as resolved by the alert manager.
+func (s *channelDigestState) summary(now time.Time) *ChannelEvent {
+	if len(s.alerts) == 0 {
+		return nil
+	}
+
+	groups := map[string]*digestGroup{}
+	firing := 0
+	for _, alert := range s.alerts {
+		values := []string{}
+		for _, name := range s.config.GroupBy {
+			value := alert.labels.Get(name)
+			if value == "" {
+				value = "-"
+			}
+			values = append(values, fmt.Sprintf("%s=%s", name, value))
+		}
+		key := strings.Join(values, ", ")
+		group, ok := groups[key]
+		if !ok {
+			group = &digestGroup{key: key}
+			groups[key] = group
+		}
+		if alert.resolved {
+			group.resolved++
+		} else {
+			group.firing++
+			firing++
+		}
+	}
+
+	ranked := []*digestGroup{}
+	for _, group := range groups {
+		ranked = append(ranked, group)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		ti, tj := ranked[i].firing+ranked[i].resolved, ranked[j].firing+ranked[j].resolved
+		if ti != tj {
+			return ti > tj
+		}
+		return ranked[i].key < ranked[j].key
+	})
+	if len(ranked) > s.config.TopN {
+		ranked = ranked[:s.config.TopN]
+	}
+
+	lines := []string{}
+	for _, group := range ranked {
+		lines = append(lines, fmt.Sprintf(
+			"%s: %d firing, %d resolved", group.key, group.firing, group.resolved,
+		))
+	}
+
+	window := now.Sub(s.windowStart).Round(time.Minute)
+	return &ChannelEvent{
+		Labels: map[string]string{
+			labels.AlertNameLabel: fmt.Sprintf("Alert digest for %s", s.config.Channel),
+			DigestLabel:           "true",
+			// summaries sent through the alert manager are alerts of their own
+			"digest_window": strconv.FormatInt(s.windowStart.Unix(), 10),
+		},
+		Summary: fmt.Sprintf(
+			"%d alerts in the last %s, %d still firing", len(s.alerts), window, firing,
+		),
+		Description: strings.Join(lines, "\n"),
+		At:          now,
+		Channels:    []string{s.config.Channel},
+	}
+}
+
+// digestReceivers holds back the alerts of channels in digest mode, the
+// alerts that no receiver is left for are dropped
+func (m *Manager) digestReceivers(alerts []*am.Alert) []*am.Alert {
+	if m.digester == nil {
+		return alerts
+	}
+	now := time.Now()
+	res := []*am.Alert{}
+	for _, alert := range alerts {
+		rank, _ := strconv.Atoi(alert.Labels.Get(SeverityRankLabel))
+		receivers := m.digester.route(alert, rank, now)
+		if len(alert.Receivers) > 0 && len(receivers) == 0 {
+			continue
+		}
+		alert.Receivers = receivers
+		res = append(res, alert)
+	}
+	return res
+}
+
+// sendDigests sends the summaries of the channels whose digest is due until
+// the manager is stopped
+func (m *Manager) sendDigests() {
+	ticker := time.NewTicker(digestFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.digestDone:
+			return
+		case now := <-ticker.C:
+			m.sendDueDigests(context.Background(), now)
+		}
+	}
+}
+
+// sendDueDigests posts the summaries of the channels whose digest is due
+func (m *Manager) sendDueDigests(ctx context.Context, now time.Time) {
+	for _, summary := range m.digester.due(now) {
+		m.NotifyChannels(ctx, summary)
+	}
+}
+
+// GetChannelDigests returns the channels in digest mode
+func (m *Manager) GetChannelDigests() ([]ChannelDigest, *model.ApiError) {
+	digests, err := getChannelDigests(m.opts.DBConn)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not get channel digests: %w", err))
+	}
+	return digests, nil
+}
+
+// SetChannelDigest puts a channel in digest mode or changes its digest
+func (m *Manager) SetChannelDigest(
+	ctx context.Context, digest *ChannelDigest,
+) (*ChannelDigest, *model.ApiError) {
+	if err := digest.validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	groupBy, err := json.Marshal(digest.GroupBy)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	digest.GroupByJSON = string(groupBy)
+
+	_, err = m.opts.DBConn.NamedExecContext(ctx, `INSERT OR REPLACE INTO alert_channel_digests
+		(channel, interval_seconds, group_by, top_n, immediate_severity_rank)
+		VALUES (:channel, :interval_seconds, :group_by, :top_n, :immediate_severity_rank)`, digest)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not save channel digest: %w", err))
+	}
+
+	m.digester.set(*digest, time.Now())
+	return digest, nil
+}
+
+// RemoveChannelDigest takes a channel out of digest mode, the alerts batched
+// so far are sent in a last summary
+func (m *Manager) RemoveChannelDigest(ctx context.Context, channel string) *model.ApiError {
+	res, err := m.opts.DBConn.ExecContext(
+		ctx, `DELETE FROM alert_channel_digests WHERE channel = $1`, channel,
+	)
+	if err != nil {
+		return model.InternalError(fmt.Errorf("could not remove channel digest: %w", err))
+	}
+	if deleted, _ := res.RowsAffected(); deleted == 0 {
+		return model.NotFoundError(fmt.Errorf("channel %s is not in digest mode", channel))
+	}
+
+	if summary := m.digester.remove(channel, time.Now()); summary != nil {
+		m.NotifyChannels(ctx, summary)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestChannelDigest(t *testing.T) {
+	require := require.New(t)
+
+	digest := ChannelDigest{Channel: "ops-email", IntervalSeconds: 3600, TopN: 1}
+	require.Nil(digest.validate())
+	require.Equal([]string{labels.AlertNameLabel}, digest.GroupBy)
+	require.Equal(1, digest.ImmediateSeverityRank)
+
+	for _, invalid := range []ChannelDigest{
+		{IntervalSeconds: 3600},
+		{Channel: "ops-email", IntervalSeconds: 60},
+		{Channel: "ops-email", IntervalSeconds: 3600, TopN: 100},
+		{Channel: "ops-email", IntervalSeconds: 3600, GroupBy: []string{""}},
+	} {
+		require.NotNil(invalid.validate(), invalid)
+	}
+
+	start := time.Now()
+	digester := newAlertDigester([]ChannelDigest{digest}, start)
+	m := &Manager{digester: digester}
+
+	alert := func(name, rank string, receivers ...string) *am.Alert {
+		return &am.Alert{
+			Labels: labels.FromMap(map[string]string{
+				labels.AlertNameLabel: name, "instance": rank + name, SeverityRankLabel: rank,
+			}),
+			StartsAt:  start,
+			Receivers: receivers,
+		}
+	}
+
+	sent := m.digestReceivers([]*am.Alert{
+		alert("HighLatency", "3", "ops-email", "slack"),
+		alert("HighLatency", "3", "ops-email"),
+		alert("DiskFull", "1", "ops-email"),
+		alert("ErrorRate", "2", "ops-email"),
+		alert("NoSeverity", "", "ops-email"),
+		alert("DefaultRoute", "3"),
+	})
+	names := []string{}
+	for _, a := range sent {
+		names = append(names, a.Name())
+	}
+	require.Equal([]string{"HighLatency", "DiskFull", "NoSeverity", "DefaultRoute"}, names)
+	require.Equal([]string{"slack"}, sent[0].Receivers, "digested channel is removed from the receivers")
+
+	// resent alerts are counted once
+	m.digestReceivers([]*am.Alert{alert("ErrorRate", "2", "ops-email")})
+
+	require.Empty(digester.due(start.Add(time.Minute)), "digest isn't due before the interval")
+
+	summaries := digester.due(start.Add(time.Hour))
+	require.Equal(1, len(summaries))
+	summary := summaries[0]
+	require.Equal([]string{"ops-email"}, summary.Channels)
+	require.Equal("true", summary.Labels[DigestLabel])
+	require.Equal("2 alerts in the last 1h0m0s, 2 still firing", summary.Summary)
+	require.Equal("alertname=ErrorRate: 1 firing, 0 resolved", summary.Description)
+
+	require.Empty(digester.due(start.Add(2*time.Hour)), "a new window starts empty")
+}
+
+func TestChannelDigestNotifications(t *testing.T) {
+	require := require.New(t)
+	m, requests, _ := newChannelsManager(t, map[string]string{
+		"ops-slack": `{"name": "ops-slack", "slack_configs": [{"api_url": "{url}/slack"}]}`,
+	})
+	require.Nil(initChannelDigests(m.opts.DBConn))
+	m.digester = newAlertDigester(nil, time.Now())
+	ctx := context.Background()
+	_, apiErr := m.SetChannelDigest(ctx, &ChannelDigest{Channel: "ops-slack", IntervalSeconds: 3600})
+	require.Nil(apiErr)
+	start := time.Now()
+
+	alert := func(name string) *am.Alert {
+		return &am.Alert{
+			Labels: labels.FromMap(map[string]string{
+				labels.AlertNameLabel: name, SeverityRankLabel: "2",
+			}),
+			StartsAt:  start,
+			Receivers: []string{"ops-slack"},
+		}
+	}
+	require.Empty(m.digestReceivers([]*am.Alert{alert("HighLatency"), alert("ErrorRate")}))
+
+	m.sendDueDigests(ctx, start.Add(time.Hour))
+	posted := requests.get("/slack")
+	require.Len(posted, 1)
+	require.Equal("*2 alerts in the last 1h0m0s, 2 still firing*\n"+
+		"alertname=ErrorRate: 1 firing, 0 resolved\nalertname=HighLatency: 1 firing, 0 resolved", posted[0]["text"])
+
+	// the summaries are posted once, and never followed by a resolution
+	m.sendDueDigests(ctx, start.Add(2*time.Hour))
+	require.Len(requests.get("/slack"), 1)
+	require.Never(func() bool { return len(requests.get("/v1/alerts")) > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+
+	// the alerts batched when the digest is removed are posted right away
+	require.Empty(m.digestReceivers([]*am.Alert{alert("DiskFull")}))
+	require.Nil(m.RemoveChannelDigest(ctx, "ops-slack"))
+	posted = requests.get("/slack")
+	require.Len(posted, 2)
+	require.Contains(posted[1]["text"], "alertname=DiskFull: 1 firing, 0 resolved")
+	require.Len(m.digestReceivers([]*am.Alert{alert("DiskFull")}), 1, "the channel is out of digest mode")
+}
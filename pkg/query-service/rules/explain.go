@@ -0,0 +1,258 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	qslabels "go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// ExplainedSeries is a series returned by the rule query and the value
+// compared with the threshold for it
+type ExplainedSeries struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+	// Values are the samples of the series in the evaluation window, only
+	// known for promql rules as builder queries reduce them in clickhouse
+	Values []float64 `json:"values,omitempty"`
+	Fired  bool      `json:"fired"`
+}
+
+// RuleExplanation is the outcome of evaluating a rule at a timestamp
+type RuleExplanation struct {
+	RuleId      string            `json:"ruleId"`
+	RuleName    string            `json:"ruleName"`
+	RuleType    RuleType          `json:"ruleType"`
+	EvaluatedAt time.Time         `json:"evaluatedAt"`
+	WindowStart time.Time         `json:"windowStart"`
+	WindowEnd   time.Time         `json:"windowEnd"`
+	CompareOp   string            `json:"op"`
+	MatchType   MatchType         `json:"matchType"`
+	Target      *float64          `json:"target,omitempty"`
+	TargetUnit  string            `json:"targetUnit,omitempty"`
+	Unit        string            `json:"unit,omitempty"`
+	Series      []ExplainedSeries `json:"series"`
+	Fired       int               `json:"fired"`
+	Message     string            `json:"message"`
+}
+
+func labelsMap(lbls qslabels.Labels) map[string]string {
+	m := make(map[string]string, len(lbls))
+	for _, l := range lbls {
+		m[l.Name] = l.Value
+	}
+	return m
+}
+
+// summarize sorts the series with the fired ones first and describes the
+// outcome of the evaluation
+func (e *RuleExplanation) summarize() {
+	sort.SliceStable(e.Series, func(i, j int) bool {
+		return e.Series[i].Fired && !e.Series[j].Fired
+	})
+	for _, s := range e.Series {
+		if s.Fired {
+			e.Fired++
+		}
+	}
+
+	target := "the threshold"
+	if e.Target != nil {
+		target = strconv.FormatFloat(*e.Target, 'f', -1, 64)
+		if e.TargetUnit != "" {
+			target += " " + e.TargetUnit
+		}
+	}
+	switch {
+	case len(e.Series) == 0:
+		e.Message = "the rule query returned no data in the evaluation window, so the rule didn't fire"
+	case e.Fired == 0:
+		e.Message = fmt.Sprintf(
+			"none of the %d series returned by the rule query were %s %s, so the rule didn't fire",
+			len(e.Series), ResolveCompareOp(CompareOp(e.CompareOp)), target,
+		)
+	default:
+		e.Message = fmt.Sprintf(
+			"%d of the %d series returned by the rule query were %s %s and fired",
+			e.Fired, len(e.Series), ResolveCompareOp(CompareOp(e.CompareOp)), target,
+		)
+	}
+}
+
+// Explain runs the rule query for the evaluation at ts and compares the
+// value of every series returned with the threshold. The rule must be
+// created with SendUnmatched for series not matching to be returned.
+func (r *ThresholdRule) Explain(ctx context.Context, ts time.Time, queriers *Queriers) (*RuleExplanation, error) {
+	ctx = common.ContextWithQuerySource(ctx, common.QuerySource{Type: common.QuerySourceAlert, Id: r.ID()})
+	res, err := r.buildAndRunQuery(ctx, ts, queriers.Ch)
+	if err != nil {
+		return nil, err
+	}
+
+	params := r.prepareQueryRange(ts)
+	explanation := &RuleExplanation{
+		RuleId:      r.ID(),
+		RuleName:    r.Name(),
+		RuleType:    r.Type(),
+		EvaluatedAt: ts,
+		WindowStart: time.UnixMilli(params.Start),
+		WindowEnd:   time.UnixMilli(params.End),
+		CompareOp:   string(r.compareOp()),
+		MatchType:   r.matchType(),
+		Target:      r.ruleCondition.Target,
+		TargetUnit:  r.ruleCondition.TargetUnit,
+		Unit:        r.Unit(),
+		Series:      []ExplainedSeries{},
+	}
+	for _, smpl := range res {
+		explanation.Series = append(explanation.Series, ExplainedSeries{
+			Labels: labelsMap(smpl.MetricOrig),
+			Value:  smpl.V,
+			Fired:  r.CheckCondition(smpl.V),
+		})
+	}
+	explanation.summarize()
+	return explanation, nil
+}
+
+// Explain runs the promql query of the rule for the evaluation at ts and
+// checks every series returned against the threshold
+func (r *PromRule) Explain(ctx context.Context, ts time.Time, queriers *Queriers) (*RuleExplanation, error) {
+	start := ts.Add(-r.evalWindow)
+	q, err := r.getPqlQuery()
+	if err != nil {
+		return nil, err
+	}
+	res, err := queriers.PqlEngine.RunAlertQuery(ctx, q, start, ts, 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &RuleExplanation{
+		RuleId:      r.ID(),
+		RuleName:    r.Name(),
+		RuleType:    RuleTypeProm,
+		EvaluatedAt: ts,
+		WindowStart: start,
+		WindowEnd:   ts,
+		CompareOp:   string(r.compareOp()),
+		MatchType:   r.matchType(),
+		TargetUnit:  r.ruleCondition.TargetUnit,
+		Unit:        r.Unit(),
+		Series:      []ExplainedSeries{},
+	}
+	if r.ruleCondition.Target != nil {
+		target := r.targetVal()
+		explanation.Target = &target
+	}
+
+	for _, series := range res {
+		if len(series.Floats) == 0 {
+			continue
+		}
+		values := make([]float64, 0, len(series.Floats))
+		for _, smpl := range series.Floats {
+			values = append(values, smpl.F)
+		}
+
+		alertSmpl, shouldAlert := r.shouldAlert(series)
+		value := alertSmpl.F
+		if !shouldAlert && (r.matchType() == AtleastOnce || r.matchType() == AllTheTimes) {
+			// the sample returned isn't meaningful when the rule didn't fire,
+			// report the one closest to crossing the threshold instead
+			value = closestToThreshold(values, r.compareOp(), r.targetVal())
+		}
+
+		lbls := make(map[string]string, len(series.Metric))
+		for _, l := range series.Metric {
+			lbls[l.Name] = l.Value
+		}
+		explanation.Series = append(explanation.Series, ExplainedSeries{
+			Labels: lbls,
+			Value:  value,
+			Values: values,
+			Fired:  shouldAlert,
+		})
+	}
+	explanation.summarize()
+	return explanation, nil
+}
+
+func closestToThreshold(values []float64, op CompareOp, target float64) float64 {
+	closest := math.NaN()
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(closest) {
+			closest = v
+			continue
+		}
+		switch op {
+		case ValueIsAbove:
+			closest = math.Max(closest, v)
+		case ValueIsBelow:
+			closest = math.Min(closest, v)
+		default:
+			if math.Abs(v-target) < math.Abs(closest-target) {
+				closest = v
+			}
+		}
+	}
+	return closest
+}
+
+// ExplainRule evaluates a saved rule at ts without changing the state of its
+// alerts, to find out why it did or didn't fire then
+func (m *Manager) ExplainRule(ctx context.Context, id string, ts time.Time) (*RuleExplanation, *model.ApiError) {
+	stored, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s not found: %w", id, err)}
+	}
+
+	parsedRule, errs := ParsePostableRule([]byte(stored.Data))
+	if len(errs) > 0 {
+		return nil, newApiErrorInternal(fmt.Errorf("failed to parse rule %s: %w", id, errs[0]))
+	}
+
+	var explanation *RuleExplanation
+	switch parsedRule.RuleType {
+	case RuleTypeThreshold, RuleTypeLogPattern:
+		rule, err := NewThresholdRule(
+			id,
+			parsedRule,
+			ThresholdRuleOpts{
+				SendUnmatched:        true,
+				ExpandFilterSnippets: m.opts.ExpandFilterSnippets,
+			},
+			m.featureFlags,
+		)
+		if err != nil {
+			return nil, newApiErrorBadData(err)
+		}
+		explanation, err = rule.Explain(ctx, ts, m.opts.Queriers)
+		if err != nil {
+			return nil, newApiErrorInternal(fmt.Errorf("rule evaluation failed: %w", err))
+		}
+	case RuleTypeProm:
+		rule, err := NewPromRule(id, parsedRule, log.With(m.logger, "alert", parsedRule.Alert), PromRuleOpts{})
+		if err != nil {
+			return nil, newApiErrorBadData(err)
+		}
+		explanation, err = rule.Explain(ctx, ts, m.opts.Queriers)
+		if err != nil {
+			return nil, newApiErrorInternal(fmt.Errorf("rule evaluation failed: %w", err))
+		}
+	default:
+		return nil, newApiErrorBadData(fmt.Errorf("failed to derive ruletype with given information"))
+	}
+
+	return explanation, nil
+}
@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestThresholdRuleExplain(t *testing.T) {
+	require := require.New(t)
+
+	target := 2.0
+	postableRule := PostableRule{
+		Alert:      "High error count",
+		AlertType:  "METRIC_BASED_ALERT",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT value, endpoint FROM table"},
+				},
+			},
+			CompareOp:  ValueIsAbove,
+			MatchType:  AtleastOnce,
+			Target:     &target,
+			TargetUnit: "none",
+		},
+	}
+	rule, err := NewThresholdRule("42", &postableRule, ThresholdRuleOpts{SendUnmatched: true}, featureManager.StartManager())
+	require.Nil(err)
+
+	mock, err := cmock.NewClickHouseNative(nil)
+	require.Nil(err)
+	cols := []cmock.ColumnType{{Name: "value", Type: "Int32"}, {Name: "endpoint", Type: "String"}}
+	mock.ExpectQuery("SELECT value, endpoint FROM table").WillReturnRows(cmock.NewRows(cols, [][]interface{}{
+		{int32(1), "/health"},
+		{int32(5), "/checkout"},
+	}))
+
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	explanation, err := rule.Explain(context.Background(), ts, &Queriers{Ch: mock})
+	require.Nil(err)
+	require.Equal("42", explanation.RuleId)
+	require.Equal(RuleType(RuleTypeThreshold), explanation.RuleType)
+	require.Equal(ts, explanation.EvaluatedAt)
+	require.True(explanation.WindowEnd.After(explanation.WindowStart))
+	require.Equal(1, explanation.Fired)
+	require.Len(explanation.Series, 2, "series not matching are explained too")
+	require.Equal("/checkout", explanation.Series[0].Labels["endpoint"], "fired series come first")
+	require.True(explanation.Series[0].Fired)
+	require.Equal(5.0, explanation.Series[0].Value)
+	require.False(explanation.Series[1].Fired)
+	require.Equal("1 of the 2 series returned by the rule query were > 2 none and fired", explanation.Message)
+}
+
+func TestRuleExplanationSummary(t *testing.T) {
+	require := require.New(t)
+
+	explanation := &RuleExplanation{CompareOp: string(ValueIsBelow), Series: []ExplainedSeries{}}
+	explanation.summarize()
+	require.Zero(explanation.Fired)
+	require.Equal("the rule query returned no data in the evaluation window, so the rule didn't fire", explanation.Message)
+
+	explanation = &RuleExplanation{
+		CompareOp: string(ValueIsBelow),
+		Series:    []ExplainedSeries{{Value: 3}, {Value: 4}},
+	}
+	explanation.summarize()
+	require.Equal("none of the 2 series returned by the rule query were < the threshold, so the rule didn't fire", explanation.Message)
+}
+
+func TestClosestToThreshold(t *testing.T) {
+	require := require.New(t)
+
+	values := []float64{math.NaN(), 3, 9, 5}
+	require.Equal(9.0, closestToThreshold(values, ValueIsAbove, 10))
+	require.Equal(3.0, closestToThreshold(values, ValueIsBelow, 1))
+	require.Equal(5.0, closestToThreshold(values, ValueIsEq, 6))
+	require.True(math.IsNaN(closestToThreshold([]float64{math.NaN()}, ValueIsAbove, 10)))
+}
@@ -0,0 +1,379 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	defaultPatternBaselineWindow = 24 * time.Hour
+
+	// patterns are cut short to keep the alert labels readable
+	maxPatternLength = 256
+
+	// the most frequent patterns of a slice of time are kept, to bound the
+	// memory taken by the baseline
+	maxPatternsPerSlice = 1000
+
+	// the baseline window is kept in about this many slices of time
+	patternBaselineBuckets = 48
+
+	logPatternQueryName = "A"
+)
+
+// the variable parts of log bodies which are masked to get their pattern,
+// applied in order so ids are not mistaken for numbers
+var logPatternMasks = []struct {
+	regex       string
+	placeholder string
+}{
+	{`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "<uuid>"},
+	{`\\b(0x)?[0-9a-fA-F]{16,}\\b`, "<hex>"},
+	{`\\b\\d{1,3}(\\.\\d{1,3}){3}\\b`, "<ip>"},
+	{`\\d+(\\.\\d+)?`, "<num>"},
+}
+
+// LogPatternCondition alerts on log patterns, the log bodies with their
+// variable parts like ids and numbers masked. A pattern alerts when it is
+// new, not seen in the baseline window before the evaluation window, or
+// when its volume changed from the baseline by more than ChangePercent.
+type LogPatternCondition struct {
+	Filters *v3.FilterSet `json:"filters,omitempty" yaml:"filters,omitempty"`
+
+	// BaselineWindow is how far back patterns are looked for, 24h by default
+	BaselineWindow Duration `json:"baselineWindow,omitempty" yaml:"baselineWindow,omitempty"`
+
+	// ChangePercent is the volume change alerted on, in either direction,
+	// compared to the average volume of the baseline. Only new patterns
+	// are alerted on when 0.
+	ChangePercent float64 `json:"changePercent,omitempty" yaml:"changePercent,omitempty"`
+
+	// MinCount is the least occurrences a pattern needs in the evaluation
+	// window, or on average in the baseline, to be alerted on
+	MinCount uint64 `json:"minCount,omitempty" yaml:"minCount,omitempty"`
+}
+
+func (c *LogPatternCondition) Validate(evalWindow time.Duration) error {
+	if c.ChangePercent < 0 {
+		return fmt.Errorf("change percent of log pattern condition can't be negative")
+	}
+	if c.BaselineWindow != 0 && time.Duration(c.BaselineWindow) <= evalWindow {
+		return fmt.Errorf("baseline window of log pattern condition must be longer than the eval window")
+	}
+	return nil
+}
+
+// countQuery returns the clickhouse query counting the occurrences of each
+// pattern between the start and end timestamps, in nanoseconds. Only the
+// most frequent patterns are returned.
+func (c *LogPatternCondition) countQuery(start, end string) (string, error) {
+	filter, err := logsv3.BuildLogsFilterQuery(c.Filters)
+	if err != nil {
+		return "", fmt.Errorf("invalid filters in log pattern condition: %w", err)
+	}
+	if filter != "" {
+		filter = " AND " + filter
+	}
+
+	pattern := "body"
+	for _, mask := range logPatternMasks {
+		pattern = fmt.Sprintf("replaceRegexpAll(%s, '%s', '%s')", pattern, mask.regex, mask.placeholder)
+	}
+
+	return fmt.Sprintf(`SELECT substring(%s, 1, %d) AS pattern, count() AS count
+FROM signoz_logs.distributed_logs
+WHERE timestamp >= %s AND timestamp < %s%s
+GROUP BY pattern
+ORDER BY count DESC
+LIMIT %d`,
+		pattern, maxPatternLength, start, end, filter, maxPatternsPerSlice,
+	), nil
+}
+
+// compositeQuery returns the clickhouse query counting the patterns of the
+// evaluation window. The rule doesn't run it as is, the logs are counted
+// incrementally and compared to the baseline kept by the rule.
+func (c *LogPatternCondition) compositeQuery() (*v3.CompositeQuery, error) {
+	query, err := c.countQuery("{{.start_timestamp_nano}}", "{{.end_timestamp_nano}}")
+	if err != nil {
+		return nil, err
+	}
+
+	return &v3.CompositeQuery{
+		QueryType: v3.QueryTypeClickHouseSQL,
+		PanelType: v3.PanelTypeValue,
+		ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+			logPatternQueryName: {Query: query},
+		},
+	}, nil
+}
+
+func (c *LogPatternCondition) baselineWindow() time.Duration {
+	if c.BaselineWindow == 0 {
+		return defaultPatternBaselineWindow
+	}
+	return time.Duration(c.BaselineWindow)
+}
+
+// patternAlert is a pattern alerting with its status, valued by its count
+// when new or by its percent change when changed
+type patternAlert struct {
+	pattern string
+	status  string
+	value   float64
+}
+
+// alertingPatterns compares the pattern counts of the evaluation window to
+// the counts of the baseline, which covers the given number of evaluation
+// windows
+func (c *LogPatternCondition) alertingPatterns(current, baseline map[string]uint64, windows float64) []patternAlert {
+	minCount := c.MinCount
+	if minCount == 0 {
+		minCount = 1
+	}
+
+	alerts := []patternAlert{}
+	for pattern, count := range current {
+		if baseline[pattern] == 0 && count >= minCount {
+			alerts = append(alerts, patternAlert{pattern: pattern, status: "new", value: float64(count)})
+		}
+	}
+
+	if c.ChangePercent > 0 && windows > 0 {
+		// patterns of the baseline which stopped are changed by -100%
+		for pattern, total := range baseline {
+			if total == 0 {
+				continue
+			}
+			count := float64(current[pattern])
+			expected := float64(total) / windows
+			change := (count - expected) * 100 / expected
+			if math.Max(count, expected) >= float64(minCount) && math.Abs(change) > c.ChangePercent {
+				alerts = append(alerts, patternAlert{pattern: pattern, status: "changed", value: change})
+			}
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].pattern < alerts[j].pattern
+	})
+	return alerts
+}
+
+// patternSlice is the counts of the patterns logged between start and end
+type patternSlice struct {
+	start  time.Time
+	end    time.Time
+	counts map[string]uint64
+}
+
+// logPatternBaseline keeps the pattern counts of the past evaluations of a
+// rule, so that every evaluation only counts the logs since the previous
+// one. The counts are kept in consecutive slices of time, the slices of the
+// past are merged so that there are at most patternBaselineBuckets of them
+// over the baseline window.
+type logPatternBaseline struct {
+	mtx    sync.Mutex
+	slices []patternSlice
+}
+
+// patternCounter counts the patterns logged between from and to
+type patternCounter func(from, to time.Time) (map[string]uint64, error)
+
+// evaluate counts the patterns of the evaluation window from start to end
+// and of the baseline window before it. When the baseline doesn't reach
+// back to the baseline window, like on the first evaluation, the baseline
+// window is counted at once. The baseline covers the returned number of
+// evaluation windows.
+func (b *logPatternBaseline) evaluate(start, end time.Time, baselineWindow time.Duration, count patternCounter) (
+	current, baseline map[string]uint64, windows float64, err error,
+) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	baselineStart := start.Add(-baselineWindow)
+	if len(b.slices) == 0 || b.slices[len(b.slices)-1].end.Before(baselineStart) ||
+		b.slices[len(b.slices)-1].end.After(end) {
+		counts, err := count(baselineStart, start)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		b.slices = []patternSlice{{start: baselineStart, end: start, counts: counts}}
+	}
+
+	if from := b.slices[len(b.slices)-1].end; from.Before(end) {
+		counts, err := count(from, end)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		b.slices = append(b.slices, patternSlice{start: from, end: end, counts: counts})
+	}
+
+	// the counts of slices straddling the start of the evaluation window are
+	// split in proportion
+	current, baseline = map[string]uint64{}, map[string]uint64{}
+	covered := time.Duration(0)
+	kept := b.slices[:0]
+	for _, slice := range b.slices {
+		switch {
+		case !slice.end.After(baselineStart):
+			continue
+		case !slice.start.Before(start):
+			addCounts(current, slice.counts)
+		case !slice.end.After(start):
+			addCounts(baseline, slice.counts)
+			covered += slice.end.Sub(slice.start)
+		default:
+			ratio := float64(slice.end.Sub(start)) / float64(slice.end.Sub(slice.start))
+			for pattern, count := range slice.counts {
+				inWindow := uint64(math.Round(float64(count) * ratio))
+				current[pattern] += inWindow
+				baseline[pattern] += count - inWindow
+			}
+			covered += start.Sub(slice.start)
+		}
+		kept = append(kept, slice)
+	}
+	b.slices = b.compact(kept, start, baselineWindow/patternBaselineBuckets)
+
+	return current, baseline, float64(covered) / float64(end.Sub(start)), nil
+}
+
+// compact merges the consecutive slices ending before start which fit in a
+// bucket, keeping the most frequent patterns of the merged slices
+func (b *logPatternBaseline) compact(slices []patternSlice, start time.Time, bucket time.Duration) []patternSlice {
+	compacted := []patternSlice{}
+	for _, slice := range slices {
+		last := len(compacted) - 1
+		if last >= 0 && !slice.end.After(start) && slice.end.Sub(compacted[last].start) <= bucket {
+			merged := map[string]uint64{}
+			addCounts(merged, compacted[last].counts)
+			addCounts(merged, slice.counts)
+			compacted[last] = patternSlice{start: compacted[last].start, end: slice.end, counts: topPatterns(merged)}
+			continue
+		}
+		compacted = append(compacted, slice)
+	}
+	return compacted
+}
+
+func addCounts(to, from map[string]uint64) {
+	for pattern, count := range from {
+		to[pattern] += count
+	}
+}
+
+// topPatterns keeps the maxPatternsPerSlice most frequent patterns
+func topPatterns(counts map[string]uint64) map[string]uint64 {
+	if len(counts) <= maxPatternsPerSlice {
+		return counts
+	}
+	patterns := make([]string, 0, len(counts))
+	for pattern := range counts {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return counts[patterns[i]] > counts[patterns[j]]
+	})
+	top := make(map[string]uint64, maxPatternsPerSlice)
+	for _, pattern := range patterns[:maxPatternsPerSlice] {
+		top[pattern] = counts[pattern]
+	}
+	return top
+}
+
+// countLogPatterns runs the count query of the log pattern condition
+// between from and to
+func (r *ThresholdRule) countLogPatterns(ctx context.Context, ch clickhouse.Conn, from, to time.Time) (map[string]uint64, error) {
+	query, err := r.ruleCondition.LogPattern.countQuery(
+		strconv.FormatInt(from.UnixNano(), 10), strconv.FormatInt(to.UnixNano(), 10),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ch.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count log patterns: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]uint64{}
+	for rows.Next() {
+		var pattern string
+		var count uint64
+		if err := rows.Scan(&pattern, &count); err != nil {
+			return nil, fmt.Errorf("failed to read log pattern counts: %w", err)
+		}
+		counts[pattern] = count
+	}
+	return counts, rows.Err()
+}
+
+// evalLogPatterns returns a sample for each alerting pattern of the
+// evaluation window at ts, labelled with the pattern and its status
+func (r *ThresholdRule) evalLogPatterns(ctx context.Context, ts time.Time, ch clickhouse.Conn) (Vector, error) {
+	lpc := r.ruleCondition.LogPattern
+	if lpc == nil {
+		return nil, fmt.Errorf("log pattern rule without a log pattern condition")
+	}
+
+	params := r.prepareQueryRange(ts)
+	current, baseline, windows, err := r.patterns.evaluate(
+		time.UnixMilli(params.Start), time.UnixMilli(params.End), lpc.baselineWindow(),
+		func(from, to time.Time) (map[string]uint64, error) {
+			return r.countLogPatterns(ctx, ch, from, to)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Vector
+	for _, alert := range lpc.alertingPatterns(current, baseline, windows) {
+		lbls := labels.NewBuilder(labels.Labels{}).
+			Set("pattern", alert.pattern).
+			Set("pattern_status", alert.status).
+			Labels()
+		result = append(result, Sample{
+			Point:      Point{V: alert.value, Vs: []float64{alert.value}},
+			Metric:     lbls,
+			MetricOrig: lbls,
+		})
+	}
+	return result, nil
+}
+
+// prepareLogPatternCondition turns the log pattern condition of a rule into
+// the threshold condition it is evaluated with, any alerting pattern fires
+func prepareLogPatternCondition(rule *PostableRule) error {
+	lpc := rule.RuleCondition.LogPattern
+	if err := lpc.Validate(time.Duration(rule.EvalWindow)); err != nil {
+		return err
+	}
+
+	compositeQuery, err := lpc.compositeQuery()
+	if err != nil {
+		return err
+	}
+
+	target := float64(0)
+	rule.RuleType = RuleTypeLogPattern
+	rule.RuleCondition.CompositeQuery = compositeQuery
+	rule.RuleCondition.SelectedQuery = logPatternQueryName
+	rule.RuleCondition.Target = &target
+	rule.RuleCondition.CompareOp = ValueIsNotEq
+	rule.RuleCondition.MatchType = AtleastOnce
+	return nil
+}
@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestLogPatternRule(t *testing.T) {
+	require := require.New(t)
+
+	parsed, errs := ParsePostableRule([]byte(`{
+		"alert": "new error patterns",
+		"evalWindow": "5m",
+		"frequency": "1m",
+		"condition": {
+			"logPattern": {
+				"filters": {
+					"op": "AND",
+					"items": [{
+						"key": {"key": "severity_text", "dataType": "string", "type": "", "isColumn": true},
+						"op": "=",
+						"value": "ERROR"
+					}]
+				},
+				"baselineWindow": "6h",
+				"changePercent": 200
+			}
+		}
+	}`))
+	require.Empty(errs)
+	require.Equal(RuleType(RuleTypeLogPattern), parsed.RuleType)
+	require.Equal(v3.QueryTypeClickHouseSQL, parsed.RuleCondition.QueryType())
+
+	rule, err := NewThresholdRule("1", parsed, ThresholdRuleOpts{}, featureManager.StartManager())
+	require.Nil(err)
+	require.Equal(RuleType(RuleTypeLogPattern), rule.Type())
+
+	ts := time.Now()
+	queries, err := rule.prepareClickhouseQueries(ts)
+	require.Nil(err)
+	query := queries[logPatternQueryName]
+
+	params := rule.prepareQueryRange(ts)
+	require.Contains(query, "severity_text = 'ERROR'")
+	require.Contains(query, `'\\b\\d{1,3}(\\.\\d{1,3}){3}\\b', '<ip>'`)
+	require.Contains(query, fmt.Sprintf("timestamp >= %d", params.Start*1e6))
+	require.Contains(query, fmt.Sprintf("timestamp < %d", params.End*1e6))
+	require.NotContains(query, "{{")
+
+	// the first evaluation counts the baseline window then the evaluation
+	// window, the next ones only count the logs since the previous one
+	counts := []cmock.ColumnType{{Name: "pattern", Type: "String"}, {Name: "count", Type: "UInt64"}}
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, sqlmock.QueryMatcherRegexp)
+	require.Nil(err)
+	baselineStart := time.UnixMilli(params.Start).Add(-6 * time.Hour).UnixNano()
+	mock.ExpectQuery(fmt.Sprintf("timestamp >= %d AND timestamp < %d", baselineStart, params.Start*1e6)).
+		WillReturnRows(cmock.NewRows(counts, [][]interface{}{
+			{"request <uuid> timed out", uint64(7200)},
+		}))
+	mock.ExpectQuery(fmt.Sprintf("timestamp >= %d AND timestamp < %d", params.Start*1e6, params.End*1e6)).
+		WillReturnRows(cmock.NewRows(counts, [][]interface{}{
+			{"connection to <ip> refused after <num> retries", uint64(3)},
+			{"request <uuid> timed out", uint64(400)},
+		}))
+	result, err := rule.buildAndRunQuery(context.Background(), ts, mock)
+	require.Nil(err)
+	require.Len(result, 2)
+	require.Equal("connection to <ip> refused after <num> retries", result[0].Metric.Get("pattern"))
+	require.Equal("new", result[0].Metric.Get("pattern_status"))
+	require.Equal(float64(3), result[0].V)
+	require.Equal("changed", result[1].Metric.Get("pattern_status"))
+	require.Equal(float64(300), result[1].V, "100 were expected in 5 minutes")
+
+	next := ts.Add(time.Minute)
+	nextParams := rule.prepareQueryRange(next)
+	mock.ExpectQuery(fmt.Sprintf("timestamp >= %d AND timestamp < %d", params.End*1e6, nextParams.End*1e6)).
+		WillReturnRows(cmock.NewRows(counts, [][]interface{}{}))
+	_, err = rule.buildAndRunQuery(context.Background(), next, mock)
+	require.Nil(err)
+	require.Nil(mock.ExpectationsWereMet())
+
+	// baseline has to extend beyond the evaluation window
+	_, errs = ParsePostableRule([]byte(`{
+		"alert": "patterns",
+		"evalWindow": "1h",
+		"condition": {"logPattern": {"baselineWindow": "30m"}}
+	}`))
+	require.NotEmpty(errs)
+}
+
+func TestLogPatternBaseline(t *testing.T) {
+	require := require.New(t)
+
+	lpc := &LogPatternCondition{ChangePercent: 50, MinCount: 2}
+	alerts := lpc.alertingPatterns(
+		map[string]uint64{"new": 2, "rare": 1, "steady": 10, "spike": 30},
+		map[string]uint64{"steady": 100, "spike": 100, "stopped": 100},
+		10,
+	)
+	require.Equal([]patternAlert{
+		{pattern: "new", status: "new", value: 2},
+		{pattern: "spike", status: "changed", value: 200},
+		{pattern: "stopped", status: "changed", value: -100},
+	}, alerts, "rare patterns and small changes don't alert")
+	require.Len((&LogPatternCondition{}).alertingPatterns(
+		map[string]uint64{"spike": 30}, map[string]uint64{"spike": 10}, 1,
+	), 0, "only new patterns alert without a change percent")
+
+	// a pattern counter logging "p" once a minute
+	scans := [][2]time.Time{}
+	counter := func(from, to time.Time) (map[string]uint64, error) {
+		scans = append(scans, [2]time.Time{from, to})
+		return map[string]uint64{"p": uint64(to.Sub(from) / time.Minute)}, nil
+	}
+
+	b := &logPatternBaseline{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	window, baselineWindow := 5*time.Minute, 4*time.Hour
+	current, baseline, windows, err := b.evaluate(start, start.Add(window), baselineWindow, counter)
+	require.Nil(err)
+	require.Equal(map[string]uint64{"p": 5}, current)
+	require.Equal(map[string]uint64{"p": 240}, baseline)
+	require.Equal(float64(48), windows)
+	require.Len(scans, 2)
+
+	for i := 1; i <= 600; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		current, baseline, windows, err = b.evaluate(at, at.Add(window), baselineWindow, counter)
+		require.Nil(err)
+		require.Equal(uint64(5), current["p"], "evaluation %d", i)
+		require.InDelta(float64(baseline["p"])/windows, 5, 0.001, "evaluation %d", i)
+	}
+	require.Len(scans, 602, "only the logs since the previous evaluation are counted")
+	require.LessOrEqual(len(b.slices), patternBaselineBuckets+int(window/time.Minute)+2)
+
+	// the baseline is counted again when evaluations stop for longer than it
+	later := start.Add(24 * time.Hour)
+	_, _, windows, err = b.evaluate(later, later.Add(window), baselineWindow, counter)
+	require.Nil(err)
+	require.Equal(float64(48), windows)
+	require.Equal([2]time.Time{later.Add(-baselineWindow), later}, scans[602])
+}
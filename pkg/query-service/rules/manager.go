@@ -61,6 +61,10 @@ type ManagerOptions struct {
 	ResendDelay  time.Duration
 	DisableRules bool
 	FeatureFlags interfaces.FeatureLookup
+
+	// ExpandFilterSnippets adds the filters of the snippets used by the
+	// builder queries of rules to their filters
+	ExpandFilterSnippets func(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.QueryRangeParamsV3, *model.ApiError)
 }
 
 // The Manager manages recording and alerting rules.
@@ -79,6 +83,35 @@ type Manager struct {
 	logger log.Logger
 
 	featureFlags interfaces.FeatureLookup
+
+	// listeners notified of the alerts sent to the alert manager, they are
+	// called from the listener queue so that they don't block evaluations
+	alertListeners    []AlertListener
+	alertListenersMtx sync.RWMutex
+	listenerQueue     chan listenerBatch
+	listenerDone      chan struct{}
+
+	// severity levels of the org, most severe first
+	severityLevels []SeverityLevel
+	severityMtx    sync.RWMutex
+
+	// batches the alerts of the channels in digest mode
+	digester   *alertDigester
+	digestDone chan struct{}
+}
+
+// AlertListener receives the alerts sent out by the rules, both
+// firing and resolved ones. Listeners are called one batch at a time
+// outside of the rule evaluation.
+type AlertListener func(ctx context.Context, alerts ...*Alert)
+
+// the batches of alerts waiting for the listeners, new batches are dropped
+// when the listeners fall this far behind
+const listenerQueueCapacity = 1000
+
+type listenerBatch struct {
+	ctx    context.Context
+	alerts []*Alert
 }
 
 func defaultOptions(o *ManagerOptions) *ManagerOptions {
@@ -108,7 +141,23 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		return nil, err
 	}
 
-	db := newRuleDB(o.DBConn)
+	db := newCachedRuleDB(newRuleDB(o.DBConn))
+
+	if err := initSeverityLevels(o.DBConn); err != nil {
+		return nil, err
+	}
+	severityLevels, err := getSeverityLevels(o.DBConn)
+	if err != nil {
+		return nil, fmt.Errorf("could not get severity levels: %w", err)
+	}
+
+	if err := initChannelDigests(o.DBConn); err != nil {
+		return nil, err
+	}
+	digests, err := getChannelDigests(o.DBConn)
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel digests: %w", err)
+	}
 
 	m := &Manager{
 		tasks:        map[string]Task{},
@@ -119,6 +168,14 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		block:        make(chan struct{}),
 		logger:       o.Logger,
 		featureFlags: o.FeatureFlags,
+
+		severityLevels: severityLevels,
+
+		digester:   newAlertDigester(digests, time.Now()),
+		digestDone: make(chan struct{}),
+
+		listenerQueue: make(chan listenerBatch, listenerQueueCapacity),
+		listenerDone:  make(chan struct{}),
 	}
 	return m, nil
 }
@@ -203,6 +260,8 @@ func (m *Manager) initiate() error {
 func (m *Manager) run() {
 	// initiate notifier
 	go m.notifier.Run()
+	go m.sendDigests()
+	go m.dispatchToListeners()
 
 	// initiate blocked tasks
 	close(m.block)
@@ -219,6 +278,21 @@ func (m *Manager) Stop() {
 		t.Stop()
 	}
 
+	if m.digestDone != nil {
+		select {
+		case <-m.digestDone:
+		default:
+			close(m.digestDone)
+		}
+	}
+	if m.listenerDone != nil {
+		select {
+		case <-m.listenerDone:
+		default:
+			close(m.listenerDone)
+		}
+	}
+
 	zap.S().Info("msg: ", "Rule manager stopped")
 }
 
@@ -248,6 +322,10 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 		return errs[0]
 	}
 
+	if err := m.validateSeverity(parsedRule); err != nil {
+		return err
+	}
+
 	taskName, _, err := m.ruleDB.EditRuleTx(ctx, ruleStr, id)
 	if err != nil {
 		return err
@@ -381,6 +459,10 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 		return nil, errs[0]
 	}
 
+	if err := m.validateSeverity(parsedRule); err != nil {
+		return nil, err
+	}
+
 	lastInsertId, tx, err := m.ruleDB.CreateRuleTx(ctx, ruleStr)
 	taskName := prepareTaskName(lastInsertId)
 	if err != nil {
@@ -509,12 +591,14 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 	}
 
 	ruleId := ruleIdFromTaskName(taskName)
-	if r.RuleType == RuleTypeThreshold {
+	if r.RuleType == RuleTypeThreshold || r.RuleType == RuleTypeLogPattern {
 		// create a threshold rule
 		tr, err := NewThresholdRule(
 			ruleId,
 			r,
-			ThresholdRuleOpts{},
+			ThresholdRuleOpts{
+				ExpandFilterSnippets: m.opts.ExpandFilterSnippets,
+			},
 			m.featureFlags,
 		)
 
@@ -553,7 +637,7 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 		m.rules[ruleId] = pr
 
 	} else {
-		return nil, fmt.Errorf(fmt.Sprintf("unsupported rule type. Supported types: %s, %s", RuleTypeProm, RuleTypeThreshold))
+		return nil, fmt.Errorf(fmt.Sprintf("unsupported rule type. Supported types: %s, %s, %s", RuleTypeProm, RuleTypeThreshold, RuleTypeLogPattern))
 	}
 
 	return task, nil
@@ -626,6 +710,55 @@ func (m *Manager) TriggeredAlerts() []*NamedAlert {
 	return namedAlerts
 }
 
+// AddAlertListener registers a listener for the alerts sent out by the rules
+func (m *Manager) AddAlertListener(listener AlertListener) {
+	m.alertListenersMtx.Lock()
+	defer m.alertListenersMtx.Unlock()
+	m.alertListeners = append(m.alertListeners, listener)
+}
+
+// notifyAlertListeners queues the alerts for the listeners without waiting,
+// the alerts are dropped when the queue is full
+func (m *Manager) notifyAlertListeners(ctx context.Context, alerts ...*Alert) {
+	m.alertListenersMtx.RLock()
+	listeners := len(m.alertListeners)
+	m.alertListenersMtx.RUnlock()
+	if listeners == 0 {
+		return
+	}
+
+	// the evaluation context is cancelled once the evaluation is done
+	select {
+	case m.listenerQueue <- listenerBatch{ctx: context.WithoutCancel(ctx), alerts: alerts}:
+	default:
+		zap.S().Warnf("alert listener queue is full, dropping %d alerts", len(alerts))
+	}
+}
+
+// dispatchToListeners calls the listeners with the queued alerts until the
+// manager is stopped
+func (m *Manager) dispatchToListeners() {
+	for {
+		select {
+		case <-m.listenerDone:
+			return
+		case batch := <-m.listenerQueue:
+			m.alertListenersMtx.RLock()
+			listeners := m.alertListeners
+			m.alertListenersMtx.RUnlock()
+			for _, listener := range listeners {
+				listener(batch.ctx, batch.alerts...)
+			}
+		}
+	}
+}
+
+// SendAlerts sends alerts that are not raised by a rule, like the ones about
+// actions taken automatically, to the alert manager and the alert listeners
+func (m *Manager) SendAlerts(ctx context.Context, alerts ...*Alert) {
+	m.prepareNotifyFunc()(ctx, "", alerts...)
+}
+
 // NotifyFunc sends notifications about a set of alerts generated by the given expression.
 type NotifyFunc func(ctx context.Context, expr string, alerts ...*Alert)
 
@@ -642,7 +775,7 @@ func (m *Manager) prepareNotifyFunc() NotifyFunc {
 
 			a := &am.Alert{
 				StartsAt:     alert.FiredAt,
-				Labels:       alert.Labels,
+				Labels:       m.withSeverityRank(alert.Labels),
 				Annotations:  alert.Annotations,
 				GeneratorURL: generatorURL,
 				Receivers:    alert.Receivers,
@@ -656,7 +789,10 @@ func (m *Manager) prepareNotifyFunc() NotifyFunc {
 		}
 
 		if len(alerts) > 0 {
-			m.notifier.Send(res...)
+			if res = m.digestReceivers(res); len(res) > 0 {
+				m.notifier.Send(res...)
+			}
+			m.notifyAlertListeners(ctx, alerts...)
 		}
 	}
 }
@@ -790,6 +926,8 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		return nil, err
 	}
 
+	storedSeverity := storedRule.Labels[SeverityLabel]
+
 	// patchedRule is combo of stored rule and patch received in the request
 	patchedRule, errs := parseIntoRule(storedRule, []byte(ruleStr), "json")
 	if len(errs) > 0 {
@@ -798,6 +936,14 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		return nil, errs[0]
 	}
 
+	// rules created before the org changed its severity levels can still be
+	// enabled or disabled
+	if patchedRule.Labels[SeverityLabel] != storedSeverity {
+		if err := m.validateSeverity(patchedRule); err != nil {
+			return nil, err
+		}
+	}
+
 	// deploy or un-deploy task according to patched (new) rule state
 	if err := m.syncRuleStateWithTask(taskName, patchedRule); err != nil {
 		zap.S().Errorf("failed to sync stored rule state with the task")
@@ -863,7 +1009,7 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 	var rule Rule
 	var err error
 
-	if parsedRule.RuleType == RuleTypeThreshold {
+	if parsedRule.RuleType == RuleTypeThreshold || parsedRule.RuleType == RuleTypeLogPattern {
 
 		// add special labels for test alerts
 		parsedRule.Annotations[labels.AlertSummaryLabel] = fmt.Sprintf("The rule threshold is set to %.4f, and the observed metric value is {{$value}}.", *parsedRule.RuleCondition.Target)
@@ -875,8 +1021,9 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 			alertname,
 			parsedRule,
 			ThresholdRuleOpts{
-				SendUnmatched: true,
-				SendAlways:    true,
+				SendUnmatched:        true,
+				SendAlways:           true,
+				ExpandFilterSnippets: m.opts.ExpandFilterSnippets,
 			},
 			m.featureFlags,
 		)
@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertListeners(t *testing.T) {
+	require := require.New(t)
+
+	m := &Manager{
+		listenerQueue: make(chan listenerBatch, 2),
+		listenerDone:  make(chan struct{}),
+	}
+	m.notifyAlertListeners(context.Background(), &Alert{}) // no listener, nothing is queued
+	require.Len(m.listenerQueue, 0)
+
+	unblock := make(chan struct{})
+	received := make(chan error, 10)
+	m.AddAlertListener(func(ctx context.Context, alerts ...*Alert) {
+		<-unblock
+		received <- ctx.Err()
+	})
+	go m.dispatchToListeners()
+	defer close(m.listenerDone)
+
+	// the listener blocks on the first batch, two more are queued and the
+	// rest are dropped without blocking the caller
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.notifyAlertListeners(ctx, &Alert{})
+		for len(m.listenerQueue) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for i := 0; i < 5; i++ {
+			m.notifyAlertListeners(ctx, &Alert{})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("notifying the listeners blocked")
+	}
+	cancel()
+
+	close(unblock)
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-received:
+			require.Nil(err, "the listeners get a context which isn't cancelled with the evaluation")
+		case <-time.After(time.Second):
+			t.Fatal("the listener wasn't called")
+		}
+	}
+	require.Never(func() bool { return len(received) > 0 }, 50*time.Millisecond, time.Millisecond)
+}
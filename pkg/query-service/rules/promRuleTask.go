@@ -10,6 +10,7 @@ import (
 	"github.com/go-kit/log"
 	opentracing "github.com/opentracing/opentracing-go"
 	plabels "github.com/prometheus/prometheus/model/labels"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	"go.uber.org/zap"
 )
 
@@ -291,9 +292,7 @@ func (g *PromRuleTask) CopyState(fromTask Task) error {
 			continue
 		}
 
-		for fp, a := range far.active {
-			ar.active[fp] = a
-		}
+		copyActiveAlerts(ar.active, far.active, ar.Labels().Get(SeverityLabel))
 	}
 
 	// Handle deleted and unmatched duplicate rules.
@@ -327,15 +326,17 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 			sp, ctx := opentracing.StartSpanFromContext(ctx, "rule")
 
 			sp.SetTag("name", rule.Name())
+			var err error
 			defer func(t time.Time) {
 				sp.Finish()
 
 				since := time.Since(t)
 				rule.SetEvaluationDuration(since)
 				rule.SetEvaluationTimestamp(t)
+				instrumentation.ObserveRuleEvaluation(string(rule.Type()), since, err)
 			}(time.Now())
 
-			_, err := rule.Eval(ctx, ts, g.opts.Queriers)
+			_, err = rule.Eval(ctx, ts, g.opts.Queriers)
 			if err != nil {
 				rule.SetHealth(HealthBad)
 				rule.SetLastError(err)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
+	"go.signoz.io/signoz/pkg/query-service/instrumentation"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
 )
@@ -240,8 +241,29 @@ func (g *RuleTask) EvalTimestamp(startTime int64) time.Time {
 	return time.Unix(0, base+offset).UTC()
 }
 
+// nameAndLabels identifies a rule across edits of its task. The severity is
+// left out so that rules whose severity is migrated keep their alerts.
 func nameAndLabels(rule Rule) string {
-	return rule.Name() + rule.Labels().String()
+	return rule.Name() + labels.NewBuilder(labels.FromMap(rule.Labels().Map())).
+		Del(SeverityLabel).
+		Labels().
+		String()
+}
+
+// copyActiveAlerts copies the alerts of a rule into the same rule of a new
+// task. The alerts are relabeled when the severity of the rule changed, which
+// changes their fingerprint.
+func copyActiveAlerts(to map[uint64]*Alert, from map[uint64]*Alert, severity string) {
+	for fp, a := range from {
+		if a.Labels == nil || !a.Labels.Has(SeverityLabel) || a.Labels.Get(SeverityLabel) == severity {
+			to[fp] = a
+			continue
+		}
+		relabeled := *a
+		lbs := labels.NewBuilder(labels.FromMap(a.Labels.Map())).Set(SeverityLabel, severity).Labels()
+		relabeled.Labels = lbs
+		to[lbs.Hash()] = &relabeled
+	}
 }
 
 // CopyState copies the alerting rule and staleness related state from the given group.
@@ -283,9 +305,7 @@ func (g *RuleTask) CopyState(fromTask Task) error {
 			continue
 		}
 
-		for fp, a := range far.active {
-			ar.active[fp] = a
-		}
+		copyActiveAlerts(ar.active, far.active, ar.Labels().Get(SeverityLabel))
 	}
 
 	return nil
@@ -310,15 +330,17 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 			sp, ctx := opentracing.StartSpanFromContext(ctx, "rule")
 
 			sp.SetTag("name", rule.Name())
+			var err error
 			defer func(t time.Time) {
 				sp.Finish()
 
 				since := time.Since(t)
 				rule.SetEvaluationDuration(since)
 				rule.SetEvaluationTimestamp(t)
+				instrumentation.ObserveRuleEvaluation(string(rule.Type()), since, err)
 			}(time.Now())
 
-			_, err := rule.Eval(ctx, ts, g.opts.Queriers)
+			_, err = rule.Eval(ctx, ts, g.opts.Queriers)
 			if err != nil {
 				rule.SetHealth(HealthBad)
 				rule.SetLastError(err)
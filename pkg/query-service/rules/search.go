@@ -0,0 +1,41 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/app/search"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ListSearchable returns the rules labelled with their tags and labels.
+// Implements search.Source
+func (m *Manager) ListSearchable(ctx context.Context) ([]search.Item, *model.ApiError) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []search.Item{}
+	for _, r := range storedRules {
+		parsed, errs := ParsePostableRule([]byte(r.Data))
+		if len(errs) > 0 {
+			continue
+		}
+		item := search.Item{
+			Id:          strconv.Itoa(r.Id),
+			Name:        parsed.Alert,
+			Description: parsed.Description,
+			Labels:      append([]string{}, parsed.Tags...),
+		}
+		if item.Description == "" {
+			item.Description = parsed.Annotations["description"]
+		}
+		for k, v := range parsed.Labels {
+			item.Labels = append(item.Labels, fmt.Sprintf("%s=%s", k, v))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,259 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// SeverityLabel is the rule label holding the severity of its alerts
+	SeverityLabel = "severity"
+	// SeverityRankLabel is added to the alerts sent to the alert manager so
+	// that routes can match on severities at or above a level, 1 being the
+	// most severe
+	SeverityRankLabel = "severity_rank"
+)
+
+// SeverityLevel is a severity that rules can use, levels are ordered from
+// the most to the least severe
+type SeverityLevel struct {
+	Name  string `json:"name" db:"name"`
+	Rank  int    `json:"rank" db:"rank"`
+	Color string `json:"color" db:"color"`
+}
+
+// the severities offered before the org configured their own
+var defaultSeverityLevels = []SeverityLevel{
+	{Name: "critical", Rank: 1, Color: "#F5222D"},
+	{Name: "error", Rank: 2, Color: "#FA8C16"},
+	{Name: "warning", Rank: 3, Color: "#FADB14"},
+	{Name: "info", Rank: 4, Color: "#1890FF"},
+}
+
+// PostableSeverityLevels replaces the severity levels of the org. The rank of
+// the levels is their position in Levels. Rules using a severity not in
+// Levels must be migrated to one of the new levels with Migrate, keyed by
+// the old severity.
+type PostableSeverityLevels struct {
+	Levels  []SeverityLevel   `json:"levels"`
+	Migrate map[string]string `json:"migrate,omitempty"`
+}
+
+var (
+	severityNameRegex  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	severityColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+func (p *PostableSeverityLevels) validate() error {
+	if len(p.Levels) == 0 {
+		return fmt.Errorf("at least one severity level is required")
+	}
+	names := map[string]bool{}
+	for i := range p.Levels {
+		level := &p.Levels[i]
+		if !severityNameRegex.MatchString(level.Name) {
+			return fmt.Errorf("invalid severity name %q", level.Name)
+		}
+		if names[level.Name] {
+			return fmt.Errorf("duplicate severity %q", level.Name)
+		}
+		names[level.Name] = true
+		if level.Color != "" && !severityColorRegex.MatchString(level.Color) {
+			return fmt.Errorf("color of severity %q must be a hex color like #F5222D", level.Name)
+		}
+		level.Rank = i + 1
+	}
+	for from, to := range p.Migrate {
+		if !names[to] {
+			return fmt.Errorf("severity %q is migrated to %q which is not a severity level", from, to)
+		}
+	}
+	return nil
+}
+
+func initSeverityLevels(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS alert_severity_levels (
+		name TEXT PRIMARY KEY,
+		rank INTEGER NOT NULL,
+		color TEXT NOT NULL DEFAULT ''
+	);`)
+	if err != nil {
+		return fmt.Errorf("could not create alert_severity_levels table: %w", err)
+	}
+	return nil
+}
+
+func getSeverityLevels(db *sqlx.DB) ([]SeverityLevel, error) {
+	levels := []SeverityLevel{}
+	err := db.Select(&levels, `SELECT name, rank, color FROM alert_severity_levels ORDER BY rank`)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return append([]SeverityLevel{}, defaultSeverityLevels...), nil
+	}
+	return levels, nil
+}
+
+func saveSeverityLevels(tx *sqlx.Tx, levels []SeverityLevel) error {
+	if _, err := tx.Exec(`DELETE FROM alert_severity_levels`); err != nil {
+		return err
+	}
+	for _, level := range levels {
+		_, err := tx.Exec(
+			`INSERT INTO alert_severity_levels (name, rank, color) VALUES ($1, $2, $3)`,
+			level.Name, level.Rank, level.Color,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func severityRank(levels []SeverityLevel, severity string) (int, bool) {
+	for _, level := range levels {
+		if level.Name == severity {
+			return level.Rank, true
+		}
+	}
+	return 0, false
+}
+
+// validateSeverity checks that the severity of the rule, if any, is one of
+// the levels of the org
+func (m *Manager) validateSeverity(rule *PostableRule) error {
+	severity := rule.Labels[SeverityLabel]
+	if severity == "" {
+		return nil
+	}
+
+	m.severityMtx.RLock()
+	defer m.severityMtx.RUnlock()
+	if _, ok := severityRank(m.severityLevels, severity); !ok {
+		names := []string{}
+		for _, level := range m.severityLevels {
+			names = append(names, level.Name)
+		}
+		return model.BadRequest(fmt.Errorf(
+			"unknown severity %q, must be one of %s", severity, strings.Join(names, ", "),
+		))
+	}
+	return nil
+}
+
+// withSeverityRank adds the rank of the severity of the alert to its labels
+func (m *Manager) withSeverityRank(ls labels.BaseLabels) labels.BaseLabels {
+	if ls == nil || !ls.Has(SeverityLabel) {
+		return ls
+	}
+
+	m.severityMtx.RLock()
+	rank, ok := severityRank(m.severityLevels, ls.Get(SeverityLabel))
+	m.severityMtx.RUnlock()
+	if !ok {
+		return ls
+	}
+	return labels.NewBuilder(labels.FromMap(ls.Map())).
+		Set(SeverityRankLabel, strconv.Itoa(rank)).
+		Labels()
+}
+
+// GetSeverityLevels returns the severity levels of the org, most severe first
+func (m *Manager) GetSeverityLevels() []SeverityLevel {
+	m.severityMtx.RLock()
+	defer m.severityMtx.RUnlock()
+	return append([]SeverityLevel{}, m.severityLevels...)
+}
+
+// SetSeverityLevels replaces the severity levels of the org and migrates the
+// rules using a severity that is no longer a level. Every rule is checked
+// before anything is written, then the levels and the migrated rules are
+// saved in a single transaction.
+func (m *Manager) SetSeverityLevels(
+	ctx context.Context, postable *PostableSeverityLevels,
+) ([]SeverityLevel, *model.ApiError) {
+	if err := postable.validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not get rules: %w", err))
+	}
+
+	migrated := map[int]*PostableRule{}
+	migratedJSON := map[int]string{}
+	unmapped := []string{}
+	for _, stored := range storedRules {
+		rule := PostableRule{}
+		if err := json.Unmarshal([]byte(stored.Data), &rule); err != nil {
+			zap.L().Warn("could not parse stored rule", zap.Int("id", stored.Id), zap.Error(err))
+			continue
+		}
+		severity := rule.Labels[SeverityLabel]
+		if severity == "" {
+			continue
+		}
+		if _, ok := severityRank(postable.Levels, severity); ok {
+			continue
+		}
+		to, ok := postable.Migrate[severity]
+		if !ok {
+			unmapped = append(unmapped, fmt.Sprintf("%s (rule %d)", severity, stored.Id))
+			continue
+		}
+
+		rule.Labels[SeverityLabel] = to
+		ruleJSON, err := json.Marshal(rule)
+		if err != nil {
+			return nil, model.InternalError(fmt.Errorf("could not migrate rule %d: %w", stored.Id, err))
+		}
+		parsedRule, errs := ParsePostableRule(ruleJSON)
+		if len(errs) > 0 {
+			return nil, model.BadRequest(fmt.Errorf("could not migrate rule %d: %w", stored.Id, errs[0]))
+		}
+		migrated[stored.Id] = parsedRule
+		migratedJSON[stored.Id] = string(ruleJSON)
+	}
+	if len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf(
+			"rules use severities that are not in the new levels, add them to migrate: %s",
+			strings.Join(unmapped, ", "),
+		)}
+	}
+
+	m.severityMtx.Lock()
+	err = m.ruleDB.EditRules(ctx, migratedJSON, func(tx *sqlx.Tx) error {
+		return saveSeverityLevels(tx, postable.Levels)
+	})
+	if err != nil {
+		m.severityMtx.Unlock()
+		return nil, model.InternalError(fmt.Errorf("could not save severity levels: %w", err))
+	}
+	m.severityLevels = append([]SeverityLevel{}, postable.Levels...)
+	m.severityMtx.Unlock()
+
+	// the tasks of the migrated rules keep the state of their alerts, which
+	// are relabeled with the new severity, see RuleTask.CopyState
+	if !m.opts.DisableRules {
+		for id, rule := range migrated {
+			if err := m.syncRuleStateWithTask(prepareTaskName(int64(id)), rule); err != nil {
+				zap.L().Error("could not reload migrated rule", zap.Int("id", id), zap.Error(err))
+			}
+		}
+	}
+
+	return m.GetSeverityLevels(), nil
+}
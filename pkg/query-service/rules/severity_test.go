@@ -0,0 +1,188 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestSeverityLevels(t *testing.T) {
+	require := require.New(t)
+
+	postable := PostableSeverityLevels{
+		Levels: []SeverityLevel{
+			{Name: "P1", Color: "#FF0000"},
+			{Name: "P2"},
+			{Name: "P3"},
+		},
+		Migrate: map[string]string{"critical": "P1"},
+	}
+	require.Nil(postable.validate())
+	require.Equal([]int{1, 2, 3}, []int{
+		postable.Levels[0].Rank, postable.Levels[1].Rank, postable.Levels[2].Rank,
+	})
+
+	for _, invalid := range []PostableSeverityLevels{
+		{},
+		{Levels: []SeverityLevel{{Name: "P1"}, {Name: "P1"}}},
+		{Levels: []SeverityLevel{{Name: "P 1"}}},
+		{Levels: []SeverityLevel{{Name: "P1", Color: "red"}}},
+		{Levels: []SeverityLevel{{Name: "P1"}}, Migrate: map[string]string{"critical": "P0"}},
+	} {
+		require.NotNil(invalid.validate(), invalid)
+	}
+
+	m := &Manager{severityLevels: postable.Levels}
+	require.Nil(m.validateSeverity(&PostableRule{Labels: map[string]string{"severity": "P2"}}))
+	require.Nil(m.validateSeverity(&PostableRule{}))
+	require.NotNil(m.validateSeverity(&PostableRule{Labels: map[string]string{"severity": "warning"}}))
+
+	ranked := m.withSeverityRank(labels.FromMap(map[string]string{"severity": "P3", "service": "api"}))
+	require.Equal("3", ranked.Get(SeverityRankLabel))
+	require.Equal("api", ranked.Get("service"))
+
+	unknown := m.withSeverityRank(labels.FromMap(map[string]string{"severity": "warning"}))
+	require.False(unknown.Has(SeverityRankLabel))
+}
+
+func TestSetSeverityLevels(t *testing.T) {
+	require := require.New(t)
+
+	testDBFile, err := os.CreateTemp("", "test-signoz-db-*")
+	require.Nil(err)
+	t.Cleanup(func() { os.Remove(testDBFile.Name()) })
+	testDBFile.Close()
+	db, err := sqlx.Open("sqlite3", testDBFile.Name())
+	require.Nil(err)
+	_, err = db.Exec(`CREATE TABLE rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at datetime,
+		created_by TEXT,
+		updated_at datetime NOT NULL,
+		updated_by TEXT,
+		deleted INTEGER DEFAULT 0,
+		data TEXT NOT NULL
+	)`)
+	require.Nil(err)
+	require.Nil(initSeverityLevels(db))
+
+	rule := func(severity string) string {
+		data, err := json.Marshal(PostableRule{
+			Alert:      "probe failures",
+			AlertType:  "METRIC_BASED_ALERT",
+			RuleType:   RuleTypeThreshold,
+			EvalWindow: Duration(5 * time.Minute),
+			Frequency:  Duration(1 * time.Minute),
+			Labels:     map[string]string{SeverityLabel: severity},
+			RuleCondition: &RuleCondition{
+				CompareOp: "2", // Below
+				MatchType: "1", // Once
+				Target:    &[]float64{1}[0],
+				CompositeQuery: &v3.CompositeQuery{
+					QueryType: v3.QueryTypeBuilder,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:          "A",
+							StepInterval:       60,
+							AggregateAttribute: v3.AttributeKey{Key: "probe_success"},
+							AggregateOperator:  v3.AggregateOperatorNoOp,
+							DataSource:         v3.DataSourceMetrics,
+							Expression:         "A",
+						},
+					},
+				},
+			},
+		})
+		require.Nil(err)
+		return string(data)
+	}
+	for _, data := range []string{
+		rule("critical"),
+		rule("warning"),
+		// a rule that can't be migrated as it is invalid
+		`{"alert": "broken", "labels": {"severity": "warning"}}`,
+	} {
+		_, err := db.Exec(`INSERT INTO rules (updated_at, data) VALUES ($1, $2)`, time.Now(), data)
+		require.Nil(err)
+	}
+
+	m := &Manager{
+		ruleDB:         newCachedRuleDB(newRuleDB(db)),
+		opts:           &ManagerOptions{DBConn: db, DisableRules: true},
+		severityLevels: defaultSeverityLevels,
+	}
+	ctx := context.Background()
+	severities := func() []string {
+		stored, err := m.ruleDB.GetStoredRules(ctx)
+		require.Nil(err)
+		severities := []string{}
+		for _, s := range stored {
+			r := PostableRule{}
+			require.Nil(json.Unmarshal([]byte(s.Data), &r))
+			severities = append(severities, r.Labels[SeverityLabel])
+		}
+		return severities
+	}
+	unchanged := func() {
+		require.Equal(defaultSeverityLevels, m.GetSeverityLevels())
+		levels, err := getSeverityLevels(db)
+		require.Nil(err)
+		require.Equal(defaultSeverityLevels, levels)
+		require.Equal([]string{"critical", "warning", "warning"}, severities())
+	}
+
+	postable := &PostableSeverityLevels{
+		Levels:  []SeverityLevel{{Name: "P1"}, {Name: "P2"}},
+		Migrate: map[string]string{"critical": "P1"},
+	}
+	_, apiErr := m.SetSeverityLevels(ctx, postable)
+	require.Equal(model.ErrorConflict, apiErr.Type(), "the warning severity isn't migrated")
+	unchanged()
+
+	postable.Migrate["warning"] = "P2"
+	_, apiErr = m.SetSeverityLevels(ctx, postable)
+	require.Equal(model.ErrorBadData, apiErr.Type(), "the broken rule can't be migrated")
+	unchanged()
+
+	_, err = db.Exec(`UPDATE rules SET deleted = 1 WHERE id = 3`)
+	require.Nil(err)
+	m.ruleDB.(*cachedRuleDB).invalidate()
+	levels, apiErr := m.SetSeverityLevels(ctx, postable)
+	require.Nil(apiErr)
+	require.Equal([]SeverityLevel{{Name: "P1", Rank: 1}, {Name: "P2", Rank: 2}}, levels)
+	stored, err := getSeverityLevels(db)
+	require.Nil(err)
+	require.Equal(levels, stored)
+	require.Equal([]string{"P1", "P2"}, severities())
+}
+
+func TestCopyActiveAlerts(t *testing.T) {
+	require := require.New(t)
+
+	critical := &Alert{State: StateFiring, Labels: labels.FromMap(map[string]string{
+		labels.AlertNameLabel: "probe failures", SeverityLabel: "critical",
+	})}
+	unlabeled := &Alert{State: StatePending, Labels: labels.FromMap(map[string]string{
+		labels.AlertNameLabel: "probe failures",
+	})}
+	from := map[uint64]*Alert{critical.Labels.Hash(): critical, unlabeled.Labels.Hash(): unlabeled}
+
+	to := map[uint64]*Alert{}
+	copyActiveAlerts(to, from, "P1")
+	require.Len(to, 2)
+	require.Equal(unlabeled, to[unlabeled.Labels.Hash()])
+
+	relabeled := labels.FromMap(map[string]string{labels.AlertNameLabel: "probe failures", SeverityLabel: "P1"})
+	require.NotNil(to[relabeled.Hash()], "the alert is keyed by its new fingerprint")
+	require.Equal(StateFiring, to[relabeled.Hash()].State)
+	require.Equal("critical", critical.Labels.Get(SeverityLabel), "the copied alerts are left as is")
+}
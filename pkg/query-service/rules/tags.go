@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/app/tagging"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ListTagged returns the rules with their tags. Implements tagging.Store
+func (m *Manager) ListTagged(ctx context.Context) ([]tagging.Item, *model.ApiError) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []tagging.Item{}
+	for _, r := range storedRules {
+		parsed, errs := ParsePostableRule([]byte(r.Data))
+		if len(errs) > 0 {
+			continue
+		}
+		item := tagging.Item{Id: strconv.Itoa(r.Id), Name: parsed.Alert, Tags: parsed.Tags}
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SetTags edits the tags of a rule keeping the rest of it as stored.
+// Implements tagging.Store
+func (m *Manager) SetTags(ctx context.Context, id string, tags []string) *model.ApiError {
+	stored, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		return model.NotFoundError(fmt.Errorf("no rule found with id %s: %w", id, err))
+	}
+
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(stored.Data), &data); err != nil {
+		return model.InternalError(fmt.Errorf("stored rule %s is not valid: %w", id, err))
+	}
+	data["tags"] = tags
+	ruleStr, err := json.Marshal(data)
+	if err != nil {
+		return model.InternalError(err)
+	}
+
+	if err := m.EditRule(ctx, string(ruleStr), id); err != nil {
+		return model.InternalError(fmt.Errorf("could not update tags of rule %s: %w", id, err))
+	}
+	return nil
+}
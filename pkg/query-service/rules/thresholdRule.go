@@ -19,11 +19,13 @@ import (
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/converter"
 
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	querytemplate "go.signoz.io/signoz/pkg/query-service/utils/queryTemplate"
@@ -72,6 +74,11 @@ type ThresholdRule struct {
 
 	opts ThresholdRuleOpts
 	typ  string
+
+	// log pattern rules are evaluated as threshold rules
+	ruleType RuleType
+	// patterns keeps the baseline of log pattern rules between evaluations
+	patterns *logPatternBaseline
 }
 
 type ThresholdRuleOpts struct {
@@ -83,6 +90,10 @@ type ThresholdRuleOpts struct {
 	// sendAlways will send alert irresepective of resendDelay
 	// or other params
 	SendAlways bool
+
+	// ExpandFilterSnippets adds the filters of the snippets used by the
+	// builder queries to their filters, snippets are left as is when nil
+	ExpandFilterSnippets func(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.QueryRangeParamsV3, *model.ApiError)
 }
 
 func NewThresholdRule(
@@ -113,6 +124,12 @@ func NewThresholdRule(
 		typ:               p.AlertType,
 		version:           p.Version,
 		temporalityMap:    make(map[string]map[v3.Temporality]bool),
+		ruleType:          RuleTypeThreshold,
+	}
+
+	if p.RuleType == RuleTypeLogPattern {
+		t.ruleType = RuleTypeLogPattern
+		t.patterns = &logPatternBaseline{}
 	}
 
 	if int64(t.evalWindow) == 0 {
@@ -181,7 +198,7 @@ func (r *ThresholdRule) compareOp() CompareOp {
 }
 
 func (r *ThresholdRule) Type() RuleType {
-	return RuleTypeThreshold
+	return r.ruleType
 }
 
 func (r *ThresholdRule) SetLastError(err error) {
@@ -725,6 +742,14 @@ func (r *ThresholdRule) runChQuery(ctx context.Context, db clickhouse.Conn, quer
 func (r *ThresholdRule) prepareBuilderQueries(ts time.Time, ch driver.Conn) (map[string]string, error) {
 	params := r.prepareQueryRange(ts)
 	if params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		if r.opts.ExpandFilterSnippets != nil {
+			expanded, apiErr := r.opts.ExpandFilterSnippets(context.Background(), params)
+			if apiErr != nil {
+				return nil, apiErr.ToError()
+			}
+			params = expanded
+		}
+
 		// check if any enrichment is required for logs if yes then enrich them
 		if logsv3.EnrichmentRequired(params) {
 			// Note: Sending empty fields key because enrichment is only needed for json
@@ -1049,6 +1074,10 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch c
 		return nil, fmt.Errorf("invalid rule condition")
 	}
 
+	if r.ruleType == RuleTypeLogPattern {
+		return r.evalLogPatterns(ctx, ts, ch)
+	}
+
 	// var to hold target query to be executed
 	var queries map[string]string
 	var err error
@@ -1113,6 +1142,7 @@ func normalizeLabelName(name string) string {
 func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (interface{}, error) {
 
 	valueFormatter := formatter.FromUnit(r.Unit())
+	ctx = common.ContextWithQuerySource(ctx, common.QuerySource{Type: common.QuerySourceAlert, Id: r.ID()})
 	res, err := r.buildAndRunQuery(ctx, ts, queriers.Ch)
 
 	if err != nil {
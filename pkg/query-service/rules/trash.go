@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/trash"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// ListDeleted returns the rules in the trash. Implements trash.Store
+func (m *Manager) ListDeleted(ctx context.Context) ([]trash.Item, *model.ApiError) {
+	deleted, err := m.ruleDB.GetDeletedRules(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	items := []trash.Item{}
+	for _, r := range deleted {
+		if r.DeletedAt == nil {
+			continue
+		}
+		item := trash.Item{Id: strconv.Itoa(r.Id), DeletedAt: *r.DeletedAt}
+		if parsed, errs := ParsePostableRule([]byte(r.Data)); len(errs) == 0 {
+			item.Name = parsed.Alert
+		}
+		if r.DeletedBy != nil {
+			item.DeletedBy = *r.DeletedBy
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (m *Manager) getDeletedRule(ctx context.Context, id string) (*StoredRule, *model.ApiError) {
+	deleted, err := m.ruleDB.GetDeletedRules(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	for _, r := range deleted {
+		if strconv.Itoa(r.Id) == id {
+			return &r, nil
+		}
+	}
+	return nil, model.NotFoundError(fmt.Errorf("no deleted rule found with id %s", id))
+}
+
+// RestoreDeleted restores a rule from the trash and resumes its evaluation.
+// Implements trash.Store
+func (m *Manager) RestoreDeleted(ctx context.Context, id string) *model.ApiError {
+	stored, apiErr := m.getDeletedRule(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	parsedRule, errs := ParsePostableRule([]byte(stored.Data))
+	if len(errs) > 0 {
+		return model.BadRequest(fmt.Errorf("deleted rule %s is not valid: %w", id, errs[0]))
+	}
+	if err := m.checkFeatureUsage(parsedRule); err != nil {
+		return model.BadRequest(err)
+	}
+
+	taskName := prepareTaskName(int64(stored.Id))
+	if !m.opts.DisableRules {
+		if err := m.addTask(parsedRule, taskName); err != nil {
+			return model.InternalError(err)
+		}
+	}
+
+	if err := m.ruleDB.RestoreRule(ctx, id); err != nil {
+		if !m.opts.DisableRules {
+			m.deleteTask(taskName)
+		}
+		return model.InternalError(fmt.Errorf("could not restore rule %s: %w", id, err))
+	}
+
+	if err := m.updateFeatureUsage(parsedRule, 1); err != nil {
+		zap.L().Error("error updating feature usage", zap.Error(err))
+	}
+	return nil
+}
+
+// PurgeDeleted removes a rule from the trash for good. Implements trash.Store
+func (m *Manager) PurgeDeleted(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := m.getDeletedRule(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if err := m.ruleDB.PurgeRule(ctx, id); err != nil {
+		return model.InternalError(fmt.Errorf("could not purge rule %s: %w", id, err))
+	}
+	return nil
+}
+
+// PurgeExpired implements trash.Store
+func (m *Manager) PurgeExpired(ctx context.Context, deletedBefore time.Time) *model.ApiError {
+	if err := m.ruleDB.PurgeDeletedRules(ctx, deletedBefore); err != nil {
+		return model.InternalError(err)
+	}
+	return nil
+}
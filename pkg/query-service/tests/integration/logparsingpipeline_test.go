@@ -490,7 +490,7 @@ func NewTestbedWithoutOpamp(t *testing.T) *LogPipelinesTestBed {
 func NewLogPipelinesTestBed(t *testing.T) *LogPipelinesTestBed {
 	testbed := NewTestbedWithoutOpamp(t)
 
-	opampServer := opamp.InitializeServer(nil, testbed.agentConfMgr)
+	opampServer := opamp.InitializeServer(nil, testbed.agentConfMgr, opamp.AuthSettings{})
 	err := opampServer.Start(opamp.GetAvailableLocalAddress())
 	require.Nil(t, err, "failed to start opamp server")
 
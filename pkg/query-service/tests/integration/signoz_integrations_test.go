@@ -17,6 +17,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/dao"
 	"go.signoz.io/signoz/pkg/query-service/featureManager"
 	"go.signoz.io/signoz/pkg/query-service/model"
+	"golang.org/x/exp/slices"
 )
 
 // Higher level tests for UI facing APIs
@@ -38,20 +39,28 @@ func TestSignozIntegrationLifeCycle(t *testing.T) {
 		"some integrations should come bundled with SigNoz",
 	)
 
+	// nginx logs are used for testing the connection status
+	testIntegrationIdx := slices.IndexFunc(
+		availableIntegrations, func(i integrations.IntegrationsListItem) bool {
+			return i.Id == "builtin::nginx"
+		},
+	)
+	require.GreaterOrEqual(testIntegrationIdx, 0)
+
 	// Should be able to install integration
-	require.False(availableIntegrations[0].IsInstalled)
+	require.False(availableIntegrations[testIntegrationIdx].IsInstalled)
 	testbed.RequestQSToInstallIntegration(
-		availableIntegrations[0].Id, map[string]interface{}{},
+		availableIntegrations[testIntegrationIdx].Id, map[string]interface{}{},
 	)
 
-	ii := testbed.GetIntegrationDetailsFromQS(availableIntegrations[0].Id)
-	require.Equal(ii.Id, availableIntegrations[0].Id)
+	ii := testbed.GetIntegrationDetailsFromQS(availableIntegrations[testIntegrationIdx].Id)
+	require.Equal(ii.Id, availableIntegrations[testIntegrationIdx].Id)
 	require.NotNil(ii.Installation)
 
 	installedResp = testbed.GetInstalledIntegrationsFromQS()
 	installedIntegrations := installedResp.Integrations
 	require.Equal(len(installedIntegrations), 1)
-	require.Equal(installedIntegrations[0].Id, availableIntegrations[0].Id)
+	require.Equal(installedIntegrations[0].Id, availableIntegrations[testIntegrationIdx].Id)
 
 	availableResp = testbed.GetAvailableIntegrationsFromQS()
 	availableIntegrations = availableResp.Integrations
@@ -73,13 +82,13 @@ func TestSignozIntegrationLifeCycle(t *testing.T) {
 	require.Equal(connectionStatus.Logs.LastReceivedTsMillis, int64(testLog.Timestamp/1000000))
 
 	// Should be able to uninstall integration
-	require.True(availableIntegrations[0].IsInstalled)
+	require.True(availableIntegrations[testIntegrationIdx].IsInstalled)
 	testbed.RequestQSToUninstallIntegration(
-		availableIntegrations[0].Id,
+		availableIntegrations[testIntegrationIdx].Id,
 	)
 
-	ii = testbed.GetIntegrationDetailsFromQS(availableIntegrations[0].Id)
-	require.Equal(ii.Id, availableIntegrations[0].Id)
+	ii = testbed.GetIntegrationDetailsFromQS(availableIntegrations[testIntegrationIdx].Id)
+	require.Equal(ii.Id, availableIntegrations[testIntegrationIdx].Id)
 	require.Nil(ii.Installation)
 
 	installedResp = testbed.GetInstalledIntegrationsFromQS()
@@ -89,7 +98,7 @@ func TestSignozIntegrationLifeCycle(t *testing.T) {
 	availableResp = testbed.GetAvailableIntegrationsFromQS()
 	availableIntegrations = availableResp.Integrations
 	require.Greater(len(availableIntegrations), 0)
-	require.False(availableIntegrations[0].IsInstalled)
+	require.False(availableIntegrations[testIntegrationIdx].IsInstalled)
 }
 
 type IntegrationsTestBed struct {
@@ -192,6 +192,43 @@ func ClickHouseFormattedValue(v interface{}) string {
 	}
 }
 
+// ClickHouseParameterValue formats the value to be bound to a clickhouse
+// query parameter. Unlike ClickHouseFormattedValue scalar strings are not quoted
+// since the server parses the value according to the declared parameter type
+func ClickHouseParameterValue(v interface{}) (string, error) {
+	v = getPointerValue(v)
+
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case []interface{}:
+		elems := make([]string, 0, len(x))
+		for _, elem := range x {
+			elem = getPointerValue(elem)
+			if str, ok := elem.(string); ok {
+				elems = append(elems, fmt.Sprintf("'%s'", quoteEscapedString(str)))
+				continue
+			}
+			formatted, err := ClickHouseParameterValue(elem)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, formatted)
+		}
+		return "[" + strings.Join(elems, ",") + "]", nil
+	case uint8, uint16, uint32, uint64, int, int8, int16, int32, int64:
+		return fmt.Sprintf("%d", x), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T for query parameter", v)
+	}
+}
+
 func getPointerValue(v interface{}) interface{} {
 	switch x := v.(type) {
 	case *uint8:
@@ -247,6 +284,19 @@ func GetClickhouseColumnName(typeName string, dataType, field string) string {
 	return colName
 }
 
+// RollupLevelLabel selects the number of group by keys each row of a rollup
+// is aggregated by, GROUPING is 1 for each key the row is a subtotal over
+func RollupLevelLabel(groupBy []v3.AttributeKey) string {
+	groupTags := []string{}
+	for _, tag := range groupBy {
+		groupTags = append(groupTags, fmt.Sprintf("`%s`", tag.Key))
+	}
+	return fmt.Sprintf(
+		" toString(%d - bitCount(GROUPING(%s))) as `%s`,",
+		len(groupBy), strings.Join(groupTags, ", "), constants.RollupLevelLabel,
+	)
+}
+
 // GetEpochNanoSecs takes epoch and returns it in ns
 func GetEpochNanoSecs(epoch int64) int64 {
 	temp := epoch
@@ -261,3 +311,53 @@ func GetEpochNanoSecs(epoch int64) int64 {
 	}
 	return temp * int64(math.Pow(10, float64(19-count)))
 }
+
+// ClickHouseFormattedTimestampMs formats a timestamp of the time range of a
+// builder query in epoch milliseconds
+func ClickHouseFormattedTimestampMs(mq *v3.BuilderQuery, ms int64) string {
+	if mq.TimestampTemplates == nil {
+		return strconv.FormatInt(ms, 10)
+	}
+	return shiftedTimestampTemplate(mq.TimestampTemplates, ms, "_ms", 1)
+}
+
+// ClickHouseFormattedTimestampNano formats a timestamp of the time range of
+// a builder query in epoch nanoseconds
+func ClickHouseFormattedTimestampNano(mq *v3.BuilderQuery, epoch int64) string {
+	if mq.TimestampTemplates == nil {
+		return strconv.FormatInt(GetEpochNanoSecs(epoch), 10)
+	}
+	return shiftedTimestampTemplate(mq.TimestampTemplates, epoch, "_nano", 1000000)
+}
+
+// ClickHouseQuotedTimestampNano formats a timestamp of the time range of a
+// builder query in epoch nanoseconds as a string, for DateTime64 columns.
+// Shifted template variables can't be quoted and are converted instead.
+func ClickHouseQuotedTimestampNano(mq *v3.BuilderQuery, epoch int64) string {
+	formatted := ClickHouseFormattedTimestampNano(mq, epoch)
+	if strings.HasPrefix(formatted, "(") {
+		return fmt.Sprintf("fromUnixTimestamp64Nano(toInt64%s)", formatted)
+	}
+	return "'" + formatted + "'"
+}
+
+// shiftedTimestampTemplate returns the template variable of the bound of the
+// range the timestamp, in epoch milliseconds, is nearest to, shifted by how
+// far the timestamp is from it, e.g. rate queries start one step early
+func shiftedTimestampTemplate(templates *v3.TimestampTemplates, ms int64, suffix string, perMs int64) string {
+	bound, value := "start", templates.Start
+	if math.Abs(float64(ms-templates.End)) < math.Abs(float64(ms-templates.Start)) {
+		bound, value = "end", templates.End
+	}
+
+	variable := fmt.Sprintf("{{.%s_timestamp%s}}", bound, suffix)
+	shift := (ms - value) * perMs
+	switch {
+	case shift < 0:
+		return fmt.Sprintf("(%s - %d)", variable, -shift)
+	case shift > 0:
+		return fmt.Sprintf("(%s + %d)", variable, shift)
+	default:
+		return variable
+	}
+}
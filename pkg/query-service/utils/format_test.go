@@ -465,3 +465,38 @@ func TestGetEpochNanoSecs(t *testing.T) {
 		})
 	}
 }
+
+func TestClickHouseFormattedTimestamps(t *testing.T) {
+	plain := &v3.BuilderQuery{}
+	templated := &v3.BuilderQuery{TimestampTemplates: &v3.TimestampTemplates{Start: 1577836800000, End: 1609459200000}}
+
+	tests := []struct {
+		name     string
+		format   func(mq *v3.BuilderQuery, epoch int64) string
+		mq       *v3.BuilderQuery
+		epoch    int64
+		expected string
+	}{
+		{name: "ms", format: ClickHouseFormattedTimestampMs, mq: plain, epoch: 1577836740000, expected: "1577836740000"},
+		{name: "nano", format: ClickHouseFormattedTimestampNano, mq: plain, epoch: 1577836740000, expected: "1577836740000000000"},
+		{name: "quoted nano", format: ClickHouseQuotedTimestampNano, mq: plain, epoch: 1577836740000, expected: "'1577836740000000000'"},
+		{name: "start ms", format: ClickHouseFormattedTimestampMs, mq: templated, epoch: 1577836800000, expected: "{{.start_timestamp_ms}}"},
+		{name: "end ms", format: ClickHouseFormattedTimestampMs, mq: templated, epoch: 1609459200000, expected: "{{.end_timestamp_ms}}"},
+		{name: "shifted start ms", format: ClickHouseFormattedTimestampMs, mq: templated, epoch: 1577836740000, expected: "({{.start_timestamp_ms}} - 60000)"},
+		{name: "shifted end ms", format: ClickHouseFormattedTimestampMs, mq: templated, epoch: 1609459260000, expected: "({{.end_timestamp_ms}} + 60000)"},
+		{name: "start nano", format: ClickHouseFormattedTimestampNano, mq: templated, epoch: 1577836800000, expected: "{{.start_timestamp_nano}}"},
+		{name: "shifted start nano", format: ClickHouseFormattedTimestampNano, mq: templated, epoch: 1577836740000, expected: "({{.start_timestamp_nano}} - 60000000000)"},
+		{name: "quoted start nano", format: ClickHouseQuotedTimestampNano, mq: templated, epoch: 1577836800000, expected: "'{{.start_timestamp_nano}}'"},
+		{
+			name: "quoted shifted start nano", format: ClickHouseQuotedTimestampNano, mq: templated, epoch: 1577836740000,
+			expected: "fromUnixTimestamp64Nano(toInt64({{.start_timestamp_nano}} - 60000000000))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format(tt.mq, tt.epoch); got != tt.expected {
+				t.Errorf("formatted timestamp = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
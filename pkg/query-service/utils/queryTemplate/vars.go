@@ -2,6 +2,7 @@ package querytemplate
 
 import (
 	"fmt"
+	"strconv"
 
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -40,3 +41,17 @@ func AssignReservedVarsV3(metricsQueryRangeParams *v3.QueryRangeParamsV3) {
 	metricsQueryRangeParams.Variables["end_datetime"] = fmt.Sprintf("toDateTime(%d)", metricsQueryRangeParams.End/1000)
 
 }
+
+// ReservedParametersV3 returns the reserved vars that can be bound to the
+// placeholders of a parameterized clickhouse query. assumes that
+// model.QueryRangeParamsV3.Start and End are Unix Milli timestamps
+func ReservedParametersV3(queryRangeParams *v3.QueryRangeParamsV3) map[string]string {
+	return map[string]string{
+		"start_timestamp":      strconv.FormatInt(queryRangeParams.Start/1000, 10),
+		"end_timestamp":        strconv.FormatInt(queryRangeParams.End/1000, 10),
+		"start_timestamp_ms":   strconv.FormatInt(queryRangeParams.Start, 10),
+		"end_timestamp_ms":     strconv.FormatInt(queryRangeParams.End, 10),
+		"start_timestamp_nano": strconv.FormatInt(queryRangeParams.Start*1e6, 10),
+		"end_timestamp_nano":   strconv.FormatInt(queryRangeParams.End*1e6, 10),
+	}
+}